@@ -8,6 +8,7 @@ def proto_targets():
         "//disk-mapper/recoverproto:write_generated_protos",
         "//keyservice/keyserviceproto:write_generated_protos",
         "//internal/versions/components:write_generated_protos",
+        "//internal/nodegroup:write_generated_protos",
         "//upgrade-agent/upgradeproto:write_generated_protos",
         "//verify/verifyproto:write_generated_protos",
     ]
@@ -21,6 +21,7 @@ import (
 	"github.com/edgelesssys/constellation/v2/bootstrapper/internal/kubernetes/k8sapi"
 	"github.com/edgelesssys/constellation/v2/bootstrapper/internal/kubernetes/kubewaiter"
 	"github.com/edgelesssys/constellation/v2/internal/attestation/choose"
+	qemusnp "github.com/edgelesssys/constellation/v2/internal/attestation/qemu/snp"
 	"github.com/edgelesssys/constellation/v2/internal/attestation/simulator"
 	"github.com/edgelesssys/constellation/v2/internal/attestation/tdx"
 	"github.com/edgelesssys/constellation/v2/internal/attestation/variant"
@@ -137,6 +138,10 @@ func main() {
 			openDevice = func() (io.ReadWriteCloser, error) {
 				return tdx.Open()
 			}
+		case variant.QEMUSEVSNP{}:
+			openDevice = func() (io.ReadWriteCloser, error) {
+				return qemusnp.Open()
+			}
 		default:
 			log.Error(fmt.Sprintf("Unsupported attestation variant: %s", attestVariant))
 		}
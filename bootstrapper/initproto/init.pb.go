@@ -8,6 +8,7 @@ package initproto
 
 import (
 	context "context"
+	nodegroup "github.com/edgelesssys/constellation/v2/internal/nodegroup"
 	components "github.com/edgelesssys/constellation/v2/internal/versions/components"
 	grpc "google.golang.org/grpc"
 	codes "google.golang.org/grpc/codes"
@@ -27,17 +28,19 @@ const (
 )
 
 type InitRequest struct {
-	state                protoimpl.MessageState  `protogen:"open.v1"`
-	KmsUri               string                  `protobuf:"bytes,1,opt,name=kms_uri,json=kmsUri,proto3" json:"kms_uri,omitempty"`
-	StorageUri           string                  `protobuf:"bytes,2,opt,name=storage_uri,json=storageUri,proto3" json:"storage_uri,omitempty"`
-	MeasurementSalt      []byte                  `protobuf:"bytes,3,opt,name=measurement_salt,json=measurementSalt,proto3" json:"measurement_salt,omitempty"`
-	KubernetesVersion    string                  `protobuf:"bytes,5,opt,name=kubernetes_version,json=kubernetesVersion,proto3" json:"kubernetes_version,omitempty"`
-	ConformanceMode      bool                    `protobuf:"varint,6,opt,name=conformance_mode,json=conformanceMode,proto3" json:"conformance_mode,omitempty"`
-	KubernetesComponents []*components.Component `protobuf:"bytes,7,rep,name=kubernetes_components,json=kubernetesComponents,proto3" json:"kubernetes_components,omitempty"`
-	InitSecret           []byte                  `protobuf:"bytes,8,opt,name=init_secret,json=initSecret,proto3" json:"init_secret,omitempty"`
-	ClusterName          string                  `protobuf:"bytes,9,opt,name=cluster_name,json=clusterName,proto3" json:"cluster_name,omitempty"`
-	ApiserverCertSans    []string                `protobuf:"bytes,10,rep,name=apiserver_cert_sans,json=apiserverCertSans,proto3" json:"apiserver_cert_sans,omitempty"`
-	ServiceCidr          string                  `protobuf:"bytes,11,opt,name=service_cidr,json=serviceCidr,proto3" json:"service_cidr,omitempty"`
+	state                protoimpl.MessageState         `protogen:"open.v1"`
+	KmsUri               string                         `protobuf:"bytes,1,opt,name=kms_uri,json=kmsUri,proto3" json:"kms_uri,omitempty"`
+	StorageUri           string                         `protobuf:"bytes,2,opt,name=storage_uri,json=storageUri,proto3" json:"storage_uri,omitempty"`
+	MeasurementSalt      []byte                         `protobuf:"bytes,3,opt,name=measurement_salt,json=measurementSalt,proto3" json:"measurement_salt,omitempty"`
+	KubernetesVersion    string                         `protobuf:"bytes,5,opt,name=kubernetes_version,json=kubernetesVersion,proto3" json:"kubernetes_version,omitempty"`
+	ConformanceMode      bool                           `protobuf:"varint,6,opt,name=conformance_mode,json=conformanceMode,proto3" json:"conformance_mode,omitempty"`
+	KubernetesComponents []*components.Component        `protobuf:"bytes,7,rep,name=kubernetes_components,json=kubernetesComponents,proto3" json:"kubernetes_components,omitempty"`
+	InitSecret           []byte                         `protobuf:"bytes,8,opt,name=init_secret,json=initSecret,proto3" json:"init_secret,omitempty"`
+	ClusterName          string                         `protobuf:"bytes,9,opt,name=cluster_name,json=clusterName,proto3" json:"cluster_name,omitempty"`
+	ApiserverCertSans    []string                       `protobuf:"bytes,10,rep,name=apiserver_cert_sans,json=apiserverCertSans,proto3" json:"apiserver_cert_sans,omitempty"`
+	ServiceCidr          string                         `protobuf:"bytes,11,opt,name=service_cidr,json=serviceCidr,proto3" json:"service_cidr,omitempty"`
+	NodeGroups           map[string]*nodegroup.Settings `protobuf:"bytes,12,rep,name=node_groups,json=nodeGroups,proto3" json:"node_groups,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	RestoreEtcdSnapshot  bool                           `protobuf:"varint,13,opt,name=restore_etcd_snapshot,json=restoreEtcdSnapshot,proto3" json:"restore_etcd_snapshot,omitempty"`
 	unknownFields        protoimpl.UnknownFields
 	sizeCache            protoimpl.SizeCache
 }
@@ -142,6 +145,20 @@ func (x *InitRequest) GetServiceCidr() string {
 	return ""
 }
 
+func (x *InitRequest) GetNodeGroups() map[string]*nodegroup.Settings {
+	if x != nil {
+		return x.NodeGroups
+	}
+	return nil
+}
+
+func (x *InitRequest) GetRestoreEtcdSnapshot() bool {
+	if x != nil {
+		return x.RestoreEtcdSnapshot
+	}
+	return false
+}
+
 type InitResponse struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
 	// Types that are valid to be assigned to Kind:
@@ -456,11 +473,99 @@ func (x *KubernetesComponent) GetExtract() bool {
 	return false
 }
 
+type UploadEtcdSnapshotRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	InitSecret    []byte                 `protobuf:"bytes,1,opt,name=init_secret,json=initSecret,proto3" json:"init_secret,omitempty"`
+	Chunk         []byte                 `protobuf:"bytes,2,opt,name=chunk,proto3" json:"chunk,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UploadEtcdSnapshotRequest) Reset() {
+	*x = UploadEtcdSnapshotRequest{}
+	mi := &file_bootstrapper_initproto_init_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UploadEtcdSnapshotRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UploadEtcdSnapshotRequest) ProtoMessage() {}
+
+func (x *UploadEtcdSnapshotRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bootstrapper_initproto_init_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UploadEtcdSnapshotRequest.ProtoReflect.Descriptor instead.
+func (*UploadEtcdSnapshotRequest) Descriptor() ([]byte, []int) {
+	return file_bootstrapper_initproto_init_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *UploadEtcdSnapshotRequest) GetInitSecret() []byte {
+	if x != nil {
+		return x.InitSecret
+	}
+	return nil
+}
+
+func (x *UploadEtcdSnapshotRequest) GetChunk() []byte {
+	if x != nil {
+		return x.Chunk
+	}
+	return nil
+}
+
+type UploadEtcdSnapshotResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UploadEtcdSnapshotResponse) Reset() {
+	*x = UploadEtcdSnapshotResponse{}
+	mi := &file_bootstrapper_initproto_init_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UploadEtcdSnapshotResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UploadEtcdSnapshotResponse) ProtoMessage() {}
+
+func (x *UploadEtcdSnapshotResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_bootstrapper_initproto_init_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UploadEtcdSnapshotResponse.ProtoReflect.Descriptor instead.
+func (*UploadEtcdSnapshotResponse) Descriptor() ([]byte, []int) {
+	return file_bootstrapper_initproto_init_proto_rawDescGZIP(), []int{7}
+}
+
 var File_bootstrapper_initproto_init_proto protoreflect.FileDescriptor
 
 const file_bootstrapper_initproto_init_proto_rawDesc = "" +
 	"\n" +
-	"!bootstrapper/initproto/init.proto\x12\x04init\x1a-internal/versions/components/components.proto\"\xd0\x03\n" +
+	"!bootstrapper/initproto/init.proto\x12\x04init\x1a\"internal/nodegroup/nodegroup.proto\x1a-internal/versions/components/components.proto\"\x9c\x05\n" +
 	"\vInitRequest\x12\x17\n" +
 	"\akms_uri\x18\x01 \x01(\tR\x06kmsUri\x12\x1f\n" +
 	"\vstorage_uri\x18\x02 \x01(\tR\n" +
@@ -474,7 +579,13 @@ const file_bootstrapper_initproto_init_proto_rawDesc = "" +
 	"\fcluster_name\x18\t \x01(\tR\vclusterName\x12.\n" +
 	"\x13apiserver_cert_sans\x18\n" +
 	" \x03(\tR\x11apiserverCertSans\x12!\n" +
-	"\fservice_cidr\x18\v \x01(\tR\vserviceCidrJ\x04\b\x04\x10\x05R\x19cloud_service_account_uri\"\xc1\x01\n" +
+	"\fservice_cidr\x18\v \x01(\tR\vserviceCidr\x12B\n" +
+	"\vnode_groups\x18\f \x03(\v2!.init.InitRequest.NodeGroupsEntryR\n" +
+	"nodeGroups\x122\n" +
+	"\x15restore_etcd_snapshot\x18\r \x01(\bR\x13restoreEtcdSnapshot\x1aR\n" +
+	"\x0fNodeGroupsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12)\n" +
+	"\x05value\x18\x02 \x01(\v2\x13.nodegroup.SettingsR\x05value:\x028\x01J\x04\b\x04\x10\x05R\x19cloud_service_account_uri\"\xc1\x01\n" +
 	"\fInitResponse\x12>\n" +
 	"\finit_success\x18\x01 \x01(\v2\x19.init.InitSuccessResponseH\x00R\vinitSuccess\x12>\n" +
 	"\finit_failure\x18\x02 \x01(\v2\x19.init.InitFailureResponseH\x00R\vinitFailure\x12)\n" +
@@ -495,9 +606,15 @@ const file_bootstrapper_initproto_init_proto_rawDesc = "" +
 	"\x03url\x18\x01 \x01(\tR\x03url\x12\x12\n" +
 	"\x04hash\x18\x02 \x01(\tR\x04hash\x12!\n" +
 	"\finstall_path\x18\x03 \x01(\tR\vinstallPath\x12\x18\n" +
-	"\aextract\x18\x04 \x01(\bR\aextract26\n" +
+	"\aextract\x18\x04 \x01(\bR\aextract\"R\n" +
+	"\x19UploadEtcdSnapshotRequest\x12\x1f\n" +
+	"\vinit_secret\x18\x01 \x01(\fR\n" +
+	"initSecret\x12\x14\n" +
+	"\x05chunk\x18\x02 \x01(\fR\x05chunk\"\x1c\n" +
+	"\x1aUploadEtcdSnapshotResponse2\x91\x01\n" +
 	"\x03API\x12/\n" +
-	"\x04Init\x12\x11.init.InitRequest\x1a\x12.init.InitResponse0\x01B@Z>github.com/edgelesssys/constellation/v2/bootstrapper/initprotob\x06proto3"
+	"\x04Init\x12\x11.init.InitRequest\x1a\x12.init.InitResponse0\x01\x12Y\n" +
+	"\x12UploadEtcdSnapshot\x12\x1f.init.UploadEtcdSnapshotRequest\x1a .init.UploadEtcdSnapshotResponse(\x01B@Z>github.com/edgelesssys/constellation/v2/bootstrapper/initprotob\x06proto3"
 
 var (
 	file_bootstrapper_initproto_init_proto_rawDescOnce sync.Once
@@ -511,28 +628,36 @@ func file_bootstrapper_initproto_init_proto_rawDescGZIP() []byte {
 	return file_bootstrapper_initproto_init_proto_rawDescData
 }
 
-var file_bootstrapper_initproto_init_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_bootstrapper_initproto_init_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
 var file_bootstrapper_initproto_init_proto_goTypes = []any{
-	(*InitRequest)(nil),          // 0: init.InitRequest
-	(*InitResponse)(nil),         // 1: init.InitResponse
-	(*InitSuccessResponse)(nil),  // 2: init.InitSuccessResponse
-	(*InitFailureResponse)(nil),  // 3: init.InitFailureResponse
-	(*LogResponseType)(nil),      // 4: init.LogResponseType
-	(*KubernetesComponent)(nil),  // 5: init.KubernetesComponent
-	(*components.Component)(nil), // 6: components.Component
+	(*InitRequest)(nil),                // 0: init.InitRequest
+	(*InitResponse)(nil),               // 1: init.InitResponse
+	(*InitSuccessResponse)(nil),        // 2: init.InitSuccessResponse
+	(*InitFailureResponse)(nil),        // 3: init.InitFailureResponse
+	(*LogResponseType)(nil),            // 4: init.LogResponseType
+	(*KubernetesComponent)(nil),        // 5: init.KubernetesComponent
+	(*UploadEtcdSnapshotRequest)(nil),  // 6: init.UploadEtcdSnapshotRequest
+	(*UploadEtcdSnapshotResponse)(nil), // 7: init.UploadEtcdSnapshotResponse
+	nil,                                // 8: init.InitRequest.NodeGroupsEntry
+	(*components.Component)(nil),       // 9: components.Component
+	(*nodegroup.Settings)(nil),         // 10: nodegroup.Settings
 }
 var file_bootstrapper_initproto_init_proto_depIdxs = []int32{
-	6, // 0: init.InitRequest.kubernetes_components:type_name -> components.Component
-	2, // 1: init.InitResponse.init_success:type_name -> init.InitSuccessResponse
-	3, // 2: init.InitResponse.init_failure:type_name -> init.InitFailureResponse
-	4, // 3: init.InitResponse.log:type_name -> init.LogResponseType
-	0, // 4: init.API.Init:input_type -> init.InitRequest
-	1, // 5: init.API.Init:output_type -> init.InitResponse
-	5, // [5:6] is the sub-list for method output_type
-	4, // [4:5] is the sub-list for method input_type
-	4, // [4:4] is the sub-list for extension type_name
-	4, // [4:4] is the sub-list for extension extendee
-	0, // [0:4] is the sub-list for field type_name
+	9,  // 0: init.InitRequest.kubernetes_components:type_name -> components.Component
+	8,  // 1: init.InitRequest.node_groups:type_name -> init.InitRequest.NodeGroupsEntry
+	2,  // 2: init.InitResponse.init_success:type_name -> init.InitSuccessResponse
+	3,  // 3: init.InitResponse.init_failure:type_name -> init.InitFailureResponse
+	4,  // 4: init.InitResponse.log:type_name -> init.LogResponseType
+	10, // 5: init.InitRequest.NodeGroupsEntry.value:type_name -> nodegroup.Settings
+	0,  // 6: init.API.Init:input_type -> init.InitRequest
+	6,  // 7: init.API.UploadEtcdSnapshot:input_type -> init.UploadEtcdSnapshotRequest
+	1,  // 8: init.API.Init:output_type -> init.InitResponse
+	7,  // 9: init.API.UploadEtcdSnapshot:output_type -> init.UploadEtcdSnapshotResponse
+	8,  // [8:10] is the sub-list for method output_type
+	6,  // [6:8] is the sub-list for method input_type
+	6,  // [6:6] is the sub-list for extension type_name
+	6,  // [6:6] is the sub-list for extension extendee
+	0,  // [0:6] is the sub-list for field type_name
 }
 
 func init() { file_bootstrapper_initproto_init_proto_init() }
@@ -551,7 +676,7 @@ func file_bootstrapper_initproto_init_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_bootstrapper_initproto_init_proto_rawDesc), len(file_bootstrapper_initproto_init_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   6,
+			NumMessages:   9,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -577,6 +702,7 @@ const _ = grpc.SupportPackageIsVersion6
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
 type APIClient interface {
 	Init(ctx context.Context, in *InitRequest, opts ...grpc.CallOption) (API_InitClient, error)
+	UploadEtcdSnapshot(ctx context.Context, opts ...grpc.CallOption) (API_UploadEtcdSnapshotClient, error)
 }
 
 type aPIClient struct {
@@ -619,9 +745,44 @@ func (x *aPIInitClient) Recv() (*InitResponse, error) {
 	return m, nil
 }
 
+func (c *aPIClient) UploadEtcdSnapshot(ctx context.Context, opts ...grpc.CallOption) (API_UploadEtcdSnapshotClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_API_serviceDesc.Streams[1], "/init.API/UploadEtcdSnapshot", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &aPIUploadEtcdSnapshotClient{stream}
+	return x, nil
+}
+
+type API_UploadEtcdSnapshotClient interface {
+	Send(*UploadEtcdSnapshotRequest) error
+	CloseAndRecv() (*UploadEtcdSnapshotResponse, error)
+	grpc.ClientStream
+}
+
+type aPIUploadEtcdSnapshotClient struct {
+	grpc.ClientStream
+}
+
+func (x *aPIUploadEtcdSnapshotClient) Send(m *UploadEtcdSnapshotRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *aPIUploadEtcdSnapshotClient) CloseAndRecv() (*UploadEtcdSnapshotResponse, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(UploadEtcdSnapshotResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // APIServer is the server API for API service.
 type APIServer interface {
 	Init(*InitRequest, API_InitServer) error
+	UploadEtcdSnapshot(API_UploadEtcdSnapshotServer) error
 }
 
 // UnimplementedAPIServer can be embedded to have forward compatible implementations.
@@ -631,6 +792,9 @@ type UnimplementedAPIServer struct {
 func (*UnimplementedAPIServer) Init(*InitRequest, API_InitServer) error {
 	return status.Errorf(codes.Unimplemented, "method Init not implemented")
 }
+func (*UnimplementedAPIServer) UploadEtcdSnapshot(API_UploadEtcdSnapshotServer) error {
+	return status.Errorf(codes.Unimplemented, "method UploadEtcdSnapshot not implemented")
+}
 
 func RegisterAPIServer(s *grpc.Server, srv APIServer) {
 	s.RegisterService(&_API_serviceDesc, srv)
@@ -657,6 +821,32 @@ func (x *aPIInitServer) Send(m *InitResponse) error {
 	return x.ServerStream.SendMsg(m)
 }
 
+func _API_UploadEtcdSnapshot_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(APIServer).UploadEtcdSnapshot(&aPIUploadEtcdSnapshotServer{stream})
+}
+
+type API_UploadEtcdSnapshotServer interface {
+	SendAndClose(*UploadEtcdSnapshotResponse) error
+	Recv() (*UploadEtcdSnapshotRequest, error)
+	grpc.ServerStream
+}
+
+type aPIUploadEtcdSnapshotServer struct {
+	grpc.ServerStream
+}
+
+func (x *aPIUploadEtcdSnapshotServer) SendAndClose(m *UploadEtcdSnapshotResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *aPIUploadEtcdSnapshotServer) Recv() (*UploadEtcdSnapshotRequest, error) {
+	m := new(UploadEtcdSnapshotRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 var _API_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "init.API",
 	HandlerType: (*APIServer)(nil),
@@ -667,6 +857,11 @@ var _API_serviceDesc = grpc.ServiceDesc{
 			Handler:       _API_Init_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "UploadEtcdSnapshot",
+			Handler:       _API_UploadEtcdSnapshot_Handler,
+			ClientStreams: true,
+		},
 	},
 	Metadata: "bootstrapper/initproto/init.proto",
 }
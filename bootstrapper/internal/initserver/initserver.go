@@ -44,6 +44,7 @@ import (
 	"github.com/edgelesssys/constellation/v2/internal/kms/kms"
 	kmssetup "github.com/edgelesssys/constellation/v2/internal/kms/setup"
 	"github.com/edgelesssys/constellation/v2/internal/logger"
+	"github.com/edgelesssys/constellation/v2/internal/nodegroup"
 	"github.com/edgelesssys/constellation/v2/internal/nodestate"
 	"github.com/edgelesssys/constellation/v2/internal/role"
 	"github.com/edgelesssys/constellation/v2/internal/versions/components"
@@ -55,6 +56,10 @@ import (
 	"google.golang.org/grpc/status"
 )
 
+// maxEtcdSnapshotSize is the maximum size of an uploaded etcd snapshot.
+// It matches the maximum storage quota recommended for etcd.
+const maxEtcdSnapshotSize = 8 << 30
+
 // Server is the initialization server, which is started on each node.
 // The server handles initialization calls from the CLI and initializes the
 // Kubernetes cluster.
@@ -67,6 +72,9 @@ type Server struct {
 	cleaner      cleaner
 	issuer       atls.Issuer
 	shutdownLock sync.RWMutex
+	// etcdSnapshotLock prevents concurrent uploads from writing the etcd snapshot,
+	// and uploads from replacing the snapshot while Init restores the cluster from it.
+	etcdSnapshotLock sync.Mutex
 
 	initSecretHash []byte
 	initFailure    error
@@ -158,13 +166,23 @@ func (s *Server) Init(req *initproto.InitRequest, stream initproto.API_InitServe
 		return errors.Join(err, s.sendLogsWithMessage(stream, status.Errorf(codes.Internal, "invalid init secret %s", err)))
 	}
 
+	var etcdSnapshotPath string
+	if req.RestoreEtcdSnapshot {
+		s.etcdSnapshotLock.Lock()
+		defer s.etcdSnapshotLock.Unlock()
+		if _, err := s.fileHandler.Stat(constants.EtcdSnapshotPath); err != nil {
+			return errors.Join(err, s.sendLogsWithMessage(stream, status.Errorf(codes.FailedPrecondition, "no etcd snapshot was uploaded: %s", err)))
+		}
+		etcdSnapshotPath = constants.EtcdSnapshotPath
+	}
+
 	cloudKms, err := kmssetup.KMS(stream.Context(), req.StorageUri, req.KmsUri)
 	if err != nil {
 		return errors.Join(err, s.sendLogsWithMessage(stream, status.Errorf(codes.Internal, "creating kms client: %s", err)))
 	}
 
 	// generate values for cluster attestation
-	clusterID, err := deriveMeasurementValues(stream.Context(), req.MeasurementSalt, cloudKms)
+	clusterID, err := deriveMeasurementValues(stream.Context(), req.MeasurementSalt, req.KmsUri, cloudKms)
 	if err != nil {
 		return errors.Join(err, s.sendLogsWithMessage(stream, status.Errorf(codes.Internal, "deriving measurement values: %s", err)))
 	}
@@ -277,6 +295,8 @@ func (s *Server) Init(req *initproto.InitRequest, stream initproto.API_InitServe
 		req.KubernetesComponents,
 		req.ApiserverCertSans,
 		req.ServiceCidr,
+		req.NodeGroups,
+		etcdSnapshotPath,
 	)
 	if err != nil {
 		return errors.Join(err, s.sendLogsWithMessage(stream, status.Errorf(codes.Internal, "initializing cluster: %s", err)))
@@ -294,6 +314,60 @@ func (s *Server) Init(req *initproto.InitRequest, stream initproto.API_InitServe
 	return stream.Send(&initproto.InitResponse{Kind: successMessage})
 }
 
+// UploadEtcdSnapshot receives an etcd snapshot in chunks and stores it on the state disk,
+// so that a subsequent Init call can restore the cluster from it.
+// A previously uploaded snapshot is replaced. If the upload fails, no snapshot is kept.
+func (s *Server) UploadEtcdSnapshot(stream initproto.API_UploadEtcdSnapshotServer) (retErr error) {
+	// Acquire lock to prevent shutdown while the upload is still running
+	s.shutdownLock.RLock()
+	defer s.shutdownLock.RUnlock()
+
+	log := s.log.With(slog.String("peer", grpclog.PeerAddrFromContext(stream.Context())))
+	log.Info("UploadEtcdSnapshot called")
+
+	req, err := stream.Recv()
+	if err != nil {
+		return status.Errorf(codes.Internal, "receiving etcd snapshot: %s", err)
+	}
+	if err := bcrypt.CompareHashAndPassword(s.initSecretHash, req.InitSecret); err != nil {
+		return status.Errorf(codes.PermissionDenied, "invalid init secret %s", err)
+	}
+
+	s.etcdSnapshotLock.Lock()
+	defer s.etcdSnapshotLock.Unlock()
+	defer func() {
+		if retErr != nil {
+			if err := s.fileHandler.Remove(constants.EtcdSnapshotPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+				log.With(slog.Any("error", err)).Error("Failed to remove partially uploaded etcd snapshot")
+			}
+		}
+	}()
+
+	if err := s.fileHandler.Write(constants.EtcdSnapshotPath, req.Chunk, file.OptMkdirAll, file.OptOverwrite); err != nil {
+		return status.Errorf(codes.Internal, "writing etcd snapshot: %s", err)
+	}
+	size := int64(len(req.Chunk))
+	for {
+		req, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return status.Errorf(codes.Internal, "receiving etcd snapshot: %s", err)
+		}
+		size += int64(len(req.Chunk))
+		if size > maxEtcdSnapshotSize {
+			return status.Errorf(codes.ResourceExhausted, "etcd snapshot exceeds the maximum size of %d bytes", int64(maxEtcdSnapshotSize))
+		}
+		if err := s.fileHandler.Write(constants.EtcdSnapshotPath, req.Chunk, file.OptAppend); err != nil {
+			return status.Errorf(codes.Internal, "writing etcd snapshot: %s", err)
+		}
+	}
+
+	log.With(slog.Int64("size", size)).Info("Etcd snapshot uploaded")
+	return stream.SendAndClose(&initproto.UploadEtcdSnapshotResponse{})
+}
+
 func (s *Server) sendLogsWithMessage(stream initproto.API_InitServer, message error) error {
 	// send back the error message
 	if err := stream.Send(&initproto.InitResponse{
@@ -372,8 +446,8 @@ func (s *Server) setupDisk(ctx context.Context, cloudKms kms.CloudKMS) error {
 	return s.disk.UpdatePassphrase(string(diskKey))
 }
 
-func deriveMeasurementValues(ctx context.Context, measurementSalt []byte, cloudKms kms.CloudKMS) (clusterID []byte, err error) {
-	secret, err := cloudKms.GetDEK(ctx, crypto.DEKPrefix+crypto.MeasurementSecretKeyID, crypto.DerivedKeyLengthDefault)
+func deriveMeasurementValues(ctx context.Context, measurementSalt []byte, kmsURI string, cloudKms kms.CloudKMS) (clusterID []byte, err error) {
+	secret, err := kmssetup.MeasurementSecret(ctx, kmsURI, cloudKms)
 	if err != nil {
 		return nil, err
 	}
@@ -396,6 +470,8 @@ type ClusterInitializer interface {
 		kubernetesComponents components.Components,
 		apiServerCertSANs []string,
 		serviceCIDR string,
+		nodeGroups nodegroup.Config,
+		etcdSnapshotPath string,
 	) ([]byte, error)
 }
 
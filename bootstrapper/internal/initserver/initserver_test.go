@@ -29,6 +29,7 @@ import (
 	kmssetup "github.com/edgelesssys/constellation/v2/internal/kms/setup"
 	"github.com/edgelesssys/constellation/v2/internal/kms/uri"
 	"github.com/edgelesssys/constellation/v2/internal/logger"
+	"github.com/edgelesssys/constellation/v2/internal/nodegroup"
 	"github.com/edgelesssys/constellation/v2/internal/versions/components"
 	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
@@ -128,6 +129,7 @@ func TestInit(t *testing.T) {
 		logCollector       stubJournaldCollector
 		initSecretHash     []byte
 		hostkeyDoesntExist bool
+		etcdSnapshot       []byte
 		wantErr            bool
 		wantShutdown       bool
 	}{
@@ -142,6 +144,29 @@ func TestInit(t *testing.T) {
 			logCollector:   stubJournaldCollector{logPipe: &stubReadCloser{reader: bytes.NewReader([]byte{})}},
 			wantShutdown:   true,
 		},
+		"restore uploaded etcd snapshot": {
+			nodeLock:       newFakeLock(),
+			initializer:    &stubClusterInitializer{},
+			disk:           &stubDisk{},
+			fileHandler:    file.NewHandler(afero.NewMemMapFs()),
+			initSecretHash: initSecretHash,
+			req:            &initproto.InitRequest{InitSecret: initSecret, KmsUri: masterSecret.EncodeToURI(), StorageUri: uri.NoStoreURI, RestoreEtcdSnapshot: true},
+			stream:         stubStream{},
+			logCollector:   stubJournaldCollector{logPipe: &stubReadCloser{reader: bytes.NewReader([]byte{})}},
+			etcdSnapshot:   []byte("snapshot"),
+			wantShutdown:   true,
+		},
+		"restore without uploaded etcd snapshot": {
+			nodeLock:       newFakeLock(),
+			initializer:    &stubClusterInitializer{},
+			disk:           &stubDisk{},
+			fileHandler:    file.NewHandler(afero.NewMemMapFs()),
+			initSecretHash: initSecretHash,
+			req:            &initproto.InitRequest{InitSecret: initSecret, KmsUri: masterSecret.EncodeToURI(), StorageUri: uri.NoStoreURI, RestoreEtcdSnapshot: true},
+			stream:         stubStream{},
+			logCollector:   stubJournaldCollector{logPipe: &stubReadCloser{reader: bytes.NewReader([]byte{})}},
+			wantErr:        true,
+		},
 		"node locked": {
 			nodeLock:       lockedLock,
 			initializer:    &stubClusterInitializer{},
@@ -249,6 +274,9 @@ func TestInit(t *testing.T) {
 					require.NoError(tc.fileHandler.Write(constants.SSHHostKeyPath, pem.EncodeToMemory(pemHostKey), file.OptMkdirAll))
 				}
 			}
+			if tc.etcdSnapshot != nil {
+				require.NoError(tc.fileHandler.Write(constants.EtcdSnapshotPath, tc.etcdSnapshot, file.OptMkdirAll))
+			}
 
 			serveStopper := newStubServeStopper()
 			server := &Server{
@@ -284,6 +312,91 @@ func TestInit(t *testing.T) {
 			}
 			assert.NoError(err)
 			assert.False(server.nodeLock.TryLockOnce(nil)) // lock should be locked
+			if tc.req.RestoreEtcdSnapshot {
+				assert.Equal(constants.EtcdSnapshotPath, tc.initializer.(*stubClusterInitializer).etcdSnapshotPath)
+			}
+		})
+	}
+}
+
+func TestUploadEtcdSnapshot(t *testing.T) {
+	initSecret := []byte("password")
+	initSecretHash, err := bcrypt.GenerateFromPassword(initSecret, bcrypt.DefaultCost)
+	require.NoError(t, err)
+	someErr := errors.New("failed")
+
+	testCases := map[string]struct {
+		reqs         []*initproto.UploadEtcdSnapshotRequest
+		recvErr      error
+		existing     []byte
+		wantSnapshot []byte
+		wantErr      bool
+	}{
+		"success": {
+			reqs: []*initproto.UploadEtcdSnapshotRequest{
+				{InitSecret: initSecret, Chunk: []byte("etcd ")},
+				{Chunk: []byte("snap")},
+				{Chunk: []byte("shot")},
+			},
+			wantSnapshot: []byte("etcd snapshot"),
+		},
+		"previous snapshot is replaced": {
+			reqs: []*initproto.UploadEtcdSnapshotRequest{
+				{InitSecret: initSecret, Chunk: []byte("new")},
+			},
+			existing:     []byte("old snapshot"),
+			wantSnapshot: []byte("new"),
+		},
+		"wrong init secret": {
+			reqs: []*initproto.UploadEtcdSnapshotRequest{
+				{InitSecret: []byte("wrongpassword"), Chunk: []byte("etcd snapshot")},
+			},
+			wantErr: true,
+		},
+		"no request": {
+			wantErr: true,
+		},
+		"upload aborted": {
+			reqs: []*initproto.UploadEtcdSnapshotRequest{
+				{InitSecret: initSecret, Chunk: []byte("etcd ")},
+			},
+			recvErr: someErr,
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			fileHandler := file.NewHandler(afero.NewMemMapFs())
+			if tc.existing != nil {
+				require.NoError(fileHandler.Write(constants.EtcdSnapshotPath, tc.existing, file.OptMkdirAll))
+			}
+			server := &Server{
+				fileHandler:    fileHandler,
+				log:            logger.NewTest(t),
+				initSecretHash: initSecretHash,
+			}
+			stream := &stubUploadStream{reqs: tc.reqs, recvErr: tc.recvErr}
+
+			err := server.UploadEtcdSnapshot(stream)
+			if tc.wantErr {
+				assert.Error(err)
+				_, err := fileHandler.Stat(constants.EtcdSnapshotPath)
+				if tc.existing != nil {
+					assert.NoError(err)
+				} else {
+					assert.ErrorIs(err, os.ErrNotExist)
+				}
+				return
+			}
+			require.NoError(err)
+			assert.True(stream.closed)
+			snapshot, err := fileHandler.Read(constants.EtcdSnapshotPath)
+			require.NoError(err)
+			assert.Equal(tc.wantSnapshot, snapshot)
 		})
 	}
 }
@@ -454,12 +567,14 @@ func (d *stubDisk) MarkDiskForReset() error {
 type stubClusterInitializer struct {
 	initClusterKubeconfig []byte
 	initClusterErr        error
+	etcdSnapshotPath      string
 }
 
 func (i *stubClusterInitializer) InitCluster(
-	context.Context, string, string,
-	bool, components.Components, []string, string,
+	_ context.Context, _ string, _ string,
+	_ bool, _ components.Components, _ []string, _ string, _ nodegroup.Config, etcdSnapshotPath string,
 ) ([]byte, error) {
+	i.etcdSnapshotPath = etcdSnapshotPath
 	return i.initClusterKubeconfig, i.initClusterErr
 }
 
@@ -529,6 +644,34 @@ func (s *stubStream) Context() context.Context {
 	return context.Background()
 }
 
+type stubUploadStream struct {
+	reqs    []*initproto.UploadEtcdSnapshotRequest
+	recvErr error
+	closed  bool
+	grpc.ServerStream
+}
+
+func (s *stubUploadStream) Recv() (*initproto.UploadEtcdSnapshotRequest, error) {
+	if len(s.reqs) == 0 {
+		if s.recvErr != nil {
+			return nil, s.recvErr
+		}
+		return nil, io.EOF
+	}
+	req := s.reqs[0]
+	s.reqs = s.reqs[1:]
+	return req, nil
+}
+
+func (s *stubUploadStream) SendAndClose(*initproto.UploadEtcdSnapshotResponse) error {
+	s.closed = true
+	return nil
+}
+
+func (s *stubUploadStream) Context() context.Context {
+	return context.Background()
+}
+
 type stubJournaldCollector struct {
 	logPipe    io.ReadCloser
 	collectErr error
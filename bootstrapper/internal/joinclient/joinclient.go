@@ -34,6 +34,7 @@ import (
 	"github.com/edgelesssys/constellation/v2/internal/cloud/metadata"
 	"github.com/edgelesssys/constellation/v2/internal/constants"
 	"github.com/edgelesssys/constellation/v2/internal/file"
+	"github.com/edgelesssys/constellation/v2/internal/nodegroup"
 	"github.com/edgelesssys/constellation/v2/internal/nodestate"
 	"github.com/edgelesssys/constellation/v2/internal/role"
 	"github.com/edgelesssys/constellation/v2/internal/versions/components"
@@ -59,6 +60,7 @@ type JoinClient struct {
 	diskUUID    string
 	nodeName    string
 	role        role.Role
+	nodeGroup   string
 	validIPs    []net.IP
 	disk        encryptedDisk
 	fileHandler file.Handler
@@ -262,6 +264,7 @@ func (c *JoinClient) requestJoinTicket(serviceEndpoint string) (ticket *joinprot
 		IsControlPlane:            c.role == role.ControlPlane,
 		HostPublicKey:             hostKeyPubSSH.Marshal(),
 		HostCertificatePrincipals: principalList,
+		NodeGroupName:             c.nodeGroup,
 	}
 	ticket, err = protoClient.IssueJoinTicket(ctx, req)
 	if err != nil {
@@ -337,7 +340,7 @@ func (c *JoinClient) startNodeAndJoin(ticket *joinproto.IssueJoinTicketResponse,
 	// We currently cannot recover from any failure in this function. Joining the k8s cluster
 	// sometimes fails transiently, and we don't want to brick the node because of that.
 	for i := range 3 {
-		err = c.joiner.JoinCluster(ctx, btd, c.role, ticket.KubernetesComponents)
+		err = c.joiner.JoinCluster(ctx, btd, c.role, ticket.KubernetesComponents, ticket.NodeGroupSettings)
 		if err == nil {
 			break
 		}
@@ -377,6 +380,7 @@ func (c *JoinClient) getNodeMetadata() error {
 
 	c.nodeName = inst.Name
 	c.role = inst.Role
+	c.nodeGroup = inst.NodeGroup
 	c.validIPs = ips
 
 	return nil
@@ -448,6 +452,7 @@ type ClusterJoiner interface {
 		args *kubeadm.BootstrapTokenDiscovery,
 		peerRole role.Role,
 		k8sComponents components.Components,
+		nodeGroupSettings *nodegroup.Settings,
 	) error
 }
 
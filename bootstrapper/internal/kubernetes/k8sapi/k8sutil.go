@@ -17,7 +17,10 @@ import (
 	"net"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/edgelesssys/constellation/v2/bootstrapper/internal/certificate"
@@ -26,6 +29,7 @@ import (
 	"github.com/edgelesssys/constellation/v2/internal/versions/components"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apiserver/pkg/authentication/user"
+	kubeadm "k8s.io/kubernetes/cmd/kubeadm/app/apis/kubeadm/v1beta3"
 	kubeconstants "k8s.io/kubernetes/cmd/kubeadm/app/constants"
 
 	"github.com/edgelesssys/constellation/v2/internal/crypto"
@@ -39,14 +43,22 @@ const (
 	kubeletStartTimeout = 10 * time.Minute
 
 	kubeletServicePath = "/usr/lib/systemd/system/kubelet.service"
+
+	// ctrPath is the path to the containerd CLI.
+	ctrPath = "/usr/bin/ctr"
+	// containerdNamespace is the containerd namespace used by the kubelet.
+	containerdNamespace = "k8s.io"
 )
 
 // Client provides the functions to talk to the k8s API.
 type Client interface {
 	Initialize(kubeconfig []byte) error
 	CreateConfigMap(ctx context.Context, configMap *corev1.ConfigMap) error
+	DeleteConfigMap(ctx context.Context, namespace, name string) error
 	AddNodeSelectorsToDeployment(ctx context.Context, selectors map[string]string, name string, namespace string) error
 	ListAllNamespaces(ctx context.Context) (*corev1.NamespaceList, error)
+	GetNodes(ctx context.Context) ([]corev1.Node, error)
+	DeleteNode(ctx context.Context, name string) error
 	AnnotateNode(ctx context.Context, nodeName, annotationKey, annotationValue string) error
 	PatchFirstNodePodCIDR(ctx context.Context, firstNodePodCIDR string) error
 }
@@ -83,9 +95,10 @@ func (k *KubernetesUtil) InstallComponents(ctx context.Context, kubernetesCompon
 }
 
 // InitCluster instruments kubeadm to initialize the K8s cluster.
+// If the path of an etcd snapshot is given, the etcd data directory is restored from it before the control plane is started.
 // On success an admin kubeconfig file is returned.
 func (k *KubernetesUtil) InitCluster(
-	ctx context.Context, initConfig []byte, nodeName, clusterName string, ips []net.IP, conformanceMode bool, log *slog.Logger,
+	ctx context.Context, initConfig []byte, nodeName, clusterName string, ips []net.IP, conformanceMode bool, etcdSnapshotPath string, log *slog.Logger,
 ) ([]byte, error) {
 	auditPolicy, err := resources.NewDefaultAuditPolicy().Marshal()
 	if err != nil {
@@ -134,6 +147,13 @@ func (k *KubernetesUtil) InitCluster(
 		return nil, fmt.Errorf("creating signed kubelete certificate: %w", err)
 	}
 
+	if etcdSnapshotPath != "" {
+		log.Info("Restoring etcd data directory from snapshot")
+		if err := restoreEtcdSnapshot(ctx, initConfigFile.Name(), etcdSnapshotPath, nodeName, ips); err != nil {
+			return nil, fmt.Errorf("restoring etcd snapshot: %w", err)
+		}
+	}
+
 	// Create static pods directory for all nodes (the Kubelets on the worker nodes also expect the path to exist)
 	// If the node rebooted after the static pod directory was created,
 	// the existing directory needs to be removed before we can
@@ -183,6 +203,73 @@ func (k *KubernetesUtil) InitCluster(
 	return out, nil
 }
 
+// restoreEtcdSnapshot restores the etcd snapshot at snapshotPath into the data directory of the local etcd member.
+// The snapshot is restored using etcdutl from the etcd image used by kubeadm, which is pulled during the preflight checks.
+// The restored member is the only member of the new etcd cluster. The snapshot is removed afterwards.
+func restoreEtcdSnapshot(ctx context.Context, initConfigPath, snapshotPath string, nodeName string, ips []net.IP) error {
+	if len(ips) == 0 {
+		return errors.New("no node IP to advertise to etcd peers")
+	}
+
+	cmd := exec.CommandContext(ctx, constants.KubeadmPath, "config", "images", "list", "--config", initConfigPath)
+	out, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return fmt.Errorf("kubeadm config images list failed (code %v) with: %s", exitErr.ExitCode(), exitErr.Stderr)
+		}
+		return fmt.Errorf("kubeadm config images list: %w", err)
+	}
+	etcdImage, err := etcdImageFromList(out)
+	if err != nil {
+		return err
+	}
+
+	snapshotDir := filepath.Dir(snapshotPath)
+	defer os.RemoveAll(snapshotDir)
+
+	// etcdutl refuses to restore into an existing data directory,
+	// which may be left over from a previous attempt to initialize the cluster.
+	if err := os.RemoveAll(kubeadm.DefaultEtcdDataDir); err != nil {
+		return fmt.Errorf("removing etcd data directory: %w", err)
+	}
+
+	peerURL := "https://" + net.JoinHostPort(ips[0].String(), strconv.Itoa(kubeconstants.EtcdListenPeerPort))
+	cmd = exec.CommandContext(ctx, ctrPath, "--namespace", containerdNamespace, "run", "--rm",
+		"--mount", fmt.Sprintf("type=bind,src=%[1]s,dst=%[1]s,options=rbind:rw", filepath.Dir(kubeadm.DefaultEtcdDataDir)),
+		"--mount", fmt.Sprintf("type=bind,src=%[1]s,dst=%[1]s,options=rbind:ro", snapshotDir),
+		etcdImage, "etcd-snapshot-restore",
+		"etcdutl", "snapshot", "restore", snapshotPath,
+		"--data-dir", kubeadm.DefaultEtcdDataDir,
+		"--name", nodeName,
+		"--initial-cluster", nodeName+"="+peerURL,
+		"--initial-advertise-peer-urls", peerURL,
+	)
+	out, err = cmd.CombinedOutput()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return fmt.Errorf("etcdutl snapshot restore failed (code %v) with: %s", exitErr.ExitCode(), out)
+		}
+		return fmt.Errorf("etcdutl snapshot restore: %w", err)
+	}
+	return nil
+}
+
+// etcdImageFromList returns the etcd image from the output of "kubeadm config images list".
+func etcdImageFromList(imageList []byte) (string, error) {
+	for _, image := range strings.Fields(string(imageList)) {
+		name, _, _ := strings.Cut(image, "@")
+		if tag := strings.LastIndex(name, ":"); tag > strings.LastIndex(name, "/") {
+			name = name[:tag]
+		}
+		if path.Base(name) == kubeconstants.Etcd {
+			return image, nil
+		}
+	}
+	return "", errors.New("no etcd image found in kubeadm image list")
+}
+
 // JoinCluster joins existing Kubernetes cluster using kubeadm join.
 func (k *KubernetesUtil) JoinCluster(ctx context.Context, joinConfig []byte, log *slog.Logger) error {
 	auditPolicy, err := resources.NewDefaultAuditPolicy().Marshal()
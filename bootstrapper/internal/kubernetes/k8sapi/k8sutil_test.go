@@ -0,0 +1,61 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package k8sapi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEtcdImageFromList(t *testing.T) {
+	testCases := map[string]struct {
+		imageList string
+		wantImage string
+		wantErr   bool
+	}{
+		"kubeadm default images": {
+			imageList: "registry.k8s.io/kube-apiserver:v1.31.1\n" +
+				"registry.k8s.io/kube-controller-manager:v1.31.1\n" +
+				"registry.k8s.io/kube-scheduler:v1.31.1\n" +
+				"registry.k8s.io/kube-proxy:v1.31.1\n" +
+				"registry.k8s.io/coredns/coredns:v1.11.3\n" +
+				"registry.k8s.io/pause:3.10\n" +
+				"registry.k8s.io/etcd:3.5.15-0\n",
+			wantImage: "registry.k8s.io/etcd:3.5.15-0",
+		},
+		"image with digest": {
+			imageList: "registry.k8s.io/pause:3.10\nregistry.k8s.io/etcd:3.5.15-0@sha256:a6dc63e6e8cfa0307d7851762fa6b629afb18f28d8aa3fab5a6e91b4af60026a\n",
+			wantImage: "registry.k8s.io/etcd:3.5.15-0@sha256:a6dc63e6e8cfa0307d7851762fa6b629afb18f28d8aa3fab5a6e91b4af60026a",
+		},
+		"registry with port": {
+			imageList: "registry.example.com:5000/etcd:3.5.15-0\n",
+			wantImage: "registry.example.com:5000/etcd:3.5.15-0",
+		},
+		"similar image names are ignored": {
+			imageList: "registry.k8s.io/etcd-backup:v1\nregistry.example.com/etcd/operator:v1\n",
+			wantErr:   true,
+		},
+		"empty list": {
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			image, err := etcdImageFromList([]byte(tc.imageList))
+			if tc.wantErr {
+				assert.Error(err)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(tc.wantImage, image)
+		})
+	}
+}
@@ -7,11 +7,17 @@ SPDX-License-Identifier: BUSL-1.1
 package k8sapi
 
 import (
+	"fmt"
 	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/edgelesssys/constellation/v2/bootstrapper/internal/certificate"
 	"github.com/edgelesssys/constellation/v2/internal/constants"
 	"github.com/edgelesssys/constellation/v2/internal/kubernetes"
+	"github.com/edgelesssys/constellation/v2/internal/nodegroup"
+	"github.com/edgelesssys/constellation/v2/internal/role"
 	"golang.org/x/mod/semver"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -240,6 +246,11 @@ func (k *KubeadmJoinYAML) SetControlPlane(advertiseAddress string) {
 	k.JoinConfiguration.SkipPhases = []string{"control-plane-prepare/download-certs"}
 }
 
+// SetNodeGroupSettings applies the Kubernetes node settings of the node's node group.
+func (k *KubeadmJoinYAML) SetNodeGroupSettings(settings *nodegroup.Settings, peerRole role.Role) {
+	setNodeGroupSettings(&k.JoinConfiguration.NodeRegistration, settings, peerRole == role.ControlPlane)
+}
+
 // Marshal into a k8s resource YAML.
 func (k *KubeadmJoinYAML) Marshal() ([]byte, error) {
 	return kubernetes.MarshalK8SResources(k)
@@ -305,7 +316,60 @@ func (k *KubeadmInitYAML) SetServiceSubnet(subnet string) {
 	}
 }
 
+// SetNodeGroupSettings applies the Kubernetes node settings of the node's node group.
+func (k *KubeadmInitYAML) SetNodeGroupSettings(settings *nodegroup.Settings) {
+	setNodeGroupSettings(&k.InitConfiguration.NodeRegistration, settings, true)
+}
+
 // Marshal into a k8s resource YAML.
 func (k *KubeadmInitYAML) Marshal() ([]byte, error) {
 	return kubernetes.MarshalK8SResources(k)
 }
+
+// setNodeGroupSettings sets the taints and kubelet flags of a node.
+// The KubeletConfiguration is shared by all nodes of the cluster, so per node group settings are passed as kubelet flags.
+func setNodeGroupSettings(registration *kubeadm.NodeRegistrationOptions, settings *nodegroup.Settings, controlPlane bool) {
+	if settings == nil {
+		return
+	}
+
+	// kubeadm only adds the default control-plane taint if no taints are set
+	if len(settings.Taints) > 0 {
+		if controlPlane {
+			registration.Taints = append(registration.Taints, kubeconstants.ControlPlaneTaint)
+		}
+		for _, taint := range settings.Taints {
+			registration.Taints = append(registration.Taints, corev1.Taint{
+				Key:    taint.Key,
+				Value:  taint.Value,
+				Effect: corev1.TaintEffect(taint.Effect),
+			})
+		}
+	}
+
+	if registration.KubeletExtraArgs == nil {
+		registration.KubeletExtraArgs = map[string]string{}
+	}
+	if len(settings.Labels) > 0 {
+		registration.KubeletExtraArgs["node-labels"] = joinKeyValues(settings.Labels)
+	}
+	if settings.KubeletMaxPods > 0 {
+		registration.KubeletExtraArgs["max-pods"] = strconv.Itoa(int(settings.KubeletMaxPods))
+	}
+	if len(settings.KubeletSystemReserved) > 0 {
+		registration.KubeletExtraArgs["system-reserved"] = joinKeyValues(settings.KubeletSystemReserved)
+	}
+	if len(settings.KubeletKubeReserved) > 0 {
+		registration.KubeletExtraArgs["kube-reserved"] = joinKeyValues(settings.KubeletKubeReserved)
+	}
+}
+
+// joinKeyValues formats a map as a sorted list of key=value pairs, as expected by kubelet flags.
+func joinKeyValues(m map[string]string) string {
+	pairs := make([]string, 0, len(m))
+	for key, value := range m {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", key, value))
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
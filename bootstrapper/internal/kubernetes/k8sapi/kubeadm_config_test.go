@@ -10,10 +10,15 @@ import (
 	"testing"
 
 	"github.com/edgelesssys/constellation/v2/internal/kubernetes"
+	"github.com/edgelesssys/constellation/v2/internal/nodegroup"
+	"github.com/edgelesssys/constellation/v2/internal/role"
 	"github.com/edgelesssys/constellation/v2/internal/versions"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/goleak"
+	corev1 "k8s.io/api/core/v1"
+	kubeadm "k8s.io/kubernetes/cmd/kubeadm/app/apis/kubeadm/v1beta3"
+	kubeconstants "k8s.io/kubernetes/cmd/kubeadm/app/constants"
 	kubeadmUtil "k8s.io/kubernetes/cmd/kubeadm/app/util"
 )
 
@@ -36,6 +41,7 @@ func TestInitConfiguration(t *testing.T) {
 				c.SetNodeIP("192.0.2.0")
 				c.SetNodeName("node")
 				c.SetProviderID("somecloudprovider://instance-id")
+				c.SetNodeGroupSettings(testNodeGroupSettings)
 				return c
 			}(),
 		},
@@ -107,6 +113,7 @@ func TestJoinConfiguration(t *testing.T) {
 				c.AppendDiscoveryTokenCaCertHash("discovery-token-ca-cert-hash")
 				c.SetProviderID("somecloudprovider://instance-id")
 				c.SetControlPlane("192.0.2.0")
+				c.SetNodeGroupSettings(testNodeGroupSettings, role.ControlPlane)
 				return c
 			}(),
 		},
@@ -125,3 +132,59 @@ func TestJoinConfiguration(t *testing.T) {
 		})
 	}
 }
+
+var testNodeGroupSettings = &nodegroup.Settings{
+	Role:                  "worker",
+	Labels:                map[string]string{"example.com/pool": "gpu", "example.com/tier": "batch"},
+	Taints:                []*nodegroup.Taint{{Key: "example.com/dedicated", Value: "gpu", Effect: "NoSchedule"}},
+	KubeletMaxPods:        50,
+	KubeletSystemReserved: map[string]string{"memory": "512Mi", "cpu": "500m"},
+	KubeletKubeReserved:   map[string]string{"pid": "1000"},
+}
+
+func TestSetNodeGroupSettings(t *testing.T) {
+	userTaint := corev1.Taint{Key: "example.com/dedicated", Value: "gpu", Effect: corev1.TaintEffectNoSchedule}
+
+	testCases := map[string]struct {
+		settings     *nodegroup.Settings
+		controlPlane bool
+		wantTaints   []corev1.Taint
+		wantArgs     map[string]string
+	}{
+		"no settings": {
+			wantArgs: map[string]string{"node-ip": "192.0.2.0"},
+		},
+		"empty settings": {
+			settings: &nodegroup.Settings{},
+			wantArgs: map[string]string{"node-ip": "192.0.2.0"},
+		},
+		"worker": {
+			settings:   testNodeGroupSettings,
+			wantTaints: []corev1.Taint{userTaint},
+			wantArgs: map[string]string{
+				"node-ip":         "192.0.2.0",
+				"node-labels":     "example.com/pool=gpu,example.com/tier=batch",
+				"max-pods":        "50",
+				"system-reserved": "cpu=500m,memory=512Mi",
+				"kube-reserved":   "pid=1000",
+			},
+		},
+		"control-plane keeps default taint": {
+			settings:     &nodegroup.Settings{Taints: testNodeGroupSettings.Taints},
+			controlPlane: true,
+			wantTaints:   []corev1.Taint{kubeconstants.ControlPlaneTaint, userTaint},
+			wantArgs:     map[string]string{"node-ip": "192.0.2.0"},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			registration := kubeadm.NodeRegistrationOptions{KubeletExtraArgs: map[string]string{"node-ip": "192.0.2.0"}}
+			setNodeGroupSettings(&registration, tc.settings, tc.controlPlane)
+			assert.Equal(tc.wantTaints, registration.Taints)
+			assert.Equal(tc.wantArgs, registration.KubeletExtraArgs)
+		})
+	}
+}
@@ -16,7 +16,7 @@ import (
 
 type clusterUtil interface {
 	InstallComponents(ctx context.Context, kubernetesComponents components.Components) error
-	InitCluster(ctx context.Context, initConfig []byte, nodeName, clusterName string, ips []net.IP, conformanceMode bool, log *slog.Logger) ([]byte, error)
+	InitCluster(ctx context.Context, initConfig []byte, nodeName, clusterName string, ips []net.IP, conformanceMode bool, etcdSnapshotPath string, log *slog.Logger) ([]byte, error)
 	JoinCluster(ctx context.Context, joinConfig []byte, log *slog.Logger) error
 	StartKubelet() error
 }
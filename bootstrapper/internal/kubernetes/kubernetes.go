@@ -22,9 +22,11 @@ import (
 	"github.com/edgelesssys/constellation/v2/internal/cloud/cloudprovider"
 	"github.com/edgelesssys/constellation/v2/internal/constants"
 	"github.com/edgelesssys/constellation/v2/internal/kubernetes"
+	"github.com/edgelesssys/constellation/v2/internal/nodegroup"
 	"github.com/edgelesssys/constellation/v2/internal/role"
 	"github.com/edgelesssys/constellation/v2/internal/versions/components"
 	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	kubeadm "k8s.io/kubernetes/cmd/kubeadm/app/apis/kubeadm/v1beta3"
 )
@@ -79,8 +81,10 @@ func New(cloudProvider string, clusterUtil clusterUtil, configProvider configura
 }
 
 // InitCluster initializes a new Kubernetes cluster and applies pod network provider.
+// If the path of an etcd snapshot is given, the cluster state is restored from the snapshot.
 func (k *KubeWrapper) InitCluster(
 	ctx context.Context, versionString, clusterName string, conformanceMode bool, kubernetesComponents components.Components, apiServerCertSANs []string, serviceCIDR string,
+	nodeGroups nodegroup.Config, etcdSnapshotPath string,
 ) ([]byte, error) {
 	k.log.With(slog.String("version", versionString)).Info("Installing Kubernetes components")
 	if err := k.clusterUtil.InstallComponents(ctx, kubernetesComponents); err != nil {
@@ -140,13 +144,14 @@ func (k *KubeWrapper) InitCluster(
 	initConfig.SetProviderID(instance.ProviderID)
 	initConfig.SetControlPlaneEndpoint(controlPlaneHost)
 	initConfig.SetServiceSubnet(serviceCIDR)
+	initConfig.SetNodeGroupSettings(nodeGroups.Lookup(instance.NodeGroup, role.ControlPlane))
 	initConfigYAML, err := initConfig.Marshal()
 	if err != nil {
 		return nil, fmt.Errorf("encoding kubeadm init configuration as YAML: %w", err)
 	}
 
 	k.log.Info("Initializing Kubernetes cluster")
-	kubeConfig, err := k.clusterUtil.InitCluster(ctx, initConfigYAML, nodeName, clusterName, validIPs, conformanceMode, k.log)
+	kubeConfig, err := k.clusterUtil.InitCluster(ctx, initConfigYAML, nodeName, clusterName, validIPs, conformanceMode, etcdSnapshotPath, k.log)
 	if err != nil {
 		return nil, fmt.Errorf("kubeadm init: %w", err)
 	}
@@ -165,6 +170,13 @@ func (k *KubeWrapper) InitCluster(
 		return nil, fmt.Errorf("waiting for Kubernetes API to be available: %w", err)
 	}
 
+	if etcdSnapshotPath != "" {
+		k.log.Info("Removing state of the previous cluster")
+		if err := k.removeStaleClusterState(ctx, nodeName); err != nil {
+			return nil, fmt.Errorf("removing state of the previous cluster: %w", err)
+		}
+	}
+
 	// Setup the K8s components ConfigMap.
 	k8sComponentsConfigMap, err := k.setupK8sComponentsConfigMap(ctx, kubernetesComponents, versionString)
 	if err != nil {
@@ -194,11 +206,18 @@ func (k *KubeWrapper) InitCluster(
 		return nil, fmt.Errorf("failed to setup internal ConfigMap: %w", err)
 	}
 
+	k.log.Info("Setting up node group ConfigMap")
+	if err := k.setupNodeGroupConfigMap(ctx, nodeGroups); err != nil {
+		return nil, fmt.Errorf("failed to setup node group ConfigMap: %w", err)
+	}
+
 	return kubeConfig, nil
 }
 
 // JoinCluster joins existing Kubernetes cluster.
-func (k *KubeWrapper) JoinCluster(ctx context.Context, args *kubeadm.BootstrapTokenDiscovery, peerRole role.Role, k8sComponents components.Components) error {
+func (k *KubeWrapper) JoinCluster(
+	ctx context.Context, args *kubeadm.BootstrapTokenDiscovery, peerRole role.Role, k8sComponents components.Components, nodeGroupSettings *nodegroup.Settings,
+) error {
 	k.log.With("k8sComponents", k8sComponents).Info("Installing provided kubernetes components")
 	if err := k.clusterUtil.InstallComponents(ctx, k8sComponents); err != nil {
 		return fmt.Errorf("installing kubernetes components: %w", err)
@@ -246,6 +265,7 @@ func (k *KubeWrapper) JoinCluster(ctx context.Context, args *kubeadm.BootstrapTo
 	if peerRole == role.ControlPlane {
 		joinConfig.SetControlPlane(nodeInternalIP)
 	}
+	joinConfig.SetNodeGroupSettings(nodeGroupSettings, peerRole)
 	joinConfigYAML, err := joinConfig.Marshal()
 	if err != nil {
 		return fmt.Errorf("encoding kubeadm join configuration as YAML: %w", err)
@@ -273,7 +293,9 @@ func (k *KubeWrapper) setupK8sComponentsConfigMap(ctx context.Context, component
 		return "", fmt.Errorf("constructing k8s-components ConfigMap: %w", err)
 	}
 
-	if err := k.client.CreateConfigMap(ctx, &componentsConfig); err != nil {
+	// The name of the ConfigMap is derived from its content.
+	// It may already exist if the cluster was restored from an etcd snapshot.
+	if err := k.client.CreateConfigMap(ctx, &componentsConfig); err != nil && !k8serrors.IsAlreadyExists(err) {
 		return "", fmt.Errorf("apply in KubeWrapper.setupK8sVersionConfigMap(..) for components config map failed with: %w", err)
 	}
 
@@ -303,6 +325,64 @@ func (k *KubeWrapper) setupInternalConfigMap(ctx context.Context) error {
 	return nil
 }
 
+// setupNodeGroupConfigMap stores the Kubernetes node settings of the cluster's node groups.
+// The JoinService reads the settings to hand them out to joining nodes.
+func (k *KubeWrapper) setupNodeGroupConfigMap(ctx context.Context, nodeGroups nodegroup.Config) error {
+	data, err := nodeGroups.Marshal()
+	if err != nil {
+		return err
+	}
+	config := corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "ConfigMap",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      constants.NodeGroupConfigMap,
+			Namespace: "kube-system",
+		},
+		Data: map[string]string{
+			constants.NodeGroupConfigKey: data,
+		},
+	}
+
+	if err := k.client.CreateConfigMap(ctx, &config); err != nil {
+		return fmt.Errorf("creating node group ConfigMap: %w", err)
+	}
+	return nil
+}
+
+// removeStaleClusterState removes objects restored from an etcd snapshot that belong to the previous cluster.
+// The nodes of the previous cluster no longer exist. The ConfigMaps set up during initialization are recreated for the new cluster.
+// The join-config holds the measurement salt of the previous cluster and is recreated by the CLI.
+func (k *KubeWrapper) removeStaleClusterState(ctx context.Context, nodeName string) error {
+	nodes, err := k.client.GetNodes(ctx)
+	if err != nil {
+		return fmt.Errorf("listing nodes: %w", err)
+	}
+	for _, node := range nodes {
+		if node.Name == nodeName {
+			continue
+		}
+		k.log.With(slog.String("nodeName", node.Name)).Info("Deleting node of the previous cluster")
+		if err := k.client.DeleteNode(ctx, node.Name); err != nil && !k8serrors.IsNotFound(err) {
+			return fmt.Errorf("deleting node %q: %w", node.Name, err)
+		}
+	}
+
+	staleConfigMaps := []struct{ namespace, name string }{
+		{"kube-system", constants.InternalConfigMap},
+		{"kube-system", constants.NodeGroupConfigMap},
+		{constants.ConstellationNamespace, constants.JoinConfigMap},
+	}
+	for _, configMap := range staleConfigMaps {
+		if err := k.client.DeleteConfigMap(ctx, configMap.namespace, configMap.name); err != nil && !k8serrors.IsNotFound(err) {
+			return fmt.Errorf("deleting ConfigMap %s/%s: %w", configMap.namespace, configMap.name, err)
+		}
+	}
+	return nil
+}
+
 // k8sCompliantHostname transforms a hostname to an RFC 1123 compliant, lowercase subdomain as required by Kubernetes node names.
 // The following regex is used by k8s for validation: /^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$/ .
 // Only a simple heuristic is used for now (to lowercase, replace underscores).
@@ -20,6 +20,7 @@ import (
 	"github.com/edgelesssys/constellation/v2/internal/constants"
 	"github.com/edgelesssys/constellation/v2/internal/kubernetes"
 	"github.com/edgelesssys/constellation/v2/internal/logger"
+	"github.com/edgelesssys/constellation/v2/internal/nodegroup"
 	"github.com/edgelesssys/constellation/v2/internal/role"
 	"github.com/edgelesssys/constellation/v2/internal/versions"
 	"github.com/edgelesssys/constellation/v2/internal/versions/components"
@@ -27,6 +28,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"go.uber.org/goleak"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	kubeadm "k8s.io/kubernetes/cmd/kubeadm/app/apis/kubeadm/v1beta3"
 )
 
@@ -48,8 +50,11 @@ func TestInitCluster(t *testing.T) {
 		providerMetadata  ProviderMetadata
 		wantConfig        k8sapi.KubeadmInitYAML
 		etcdIOPrioritizer stubEtcdIOPrioritizer
+		etcdSnapshotPath  string
 		wantErr           bool
 		k8sVersion        versions.ValidK8sVersion
+		wantDeletedNodes  []string
+		wantDeletedCMs    []string
 	}{
 		"kubeadm init works with metadata and loadbalancer": {
 			clusterUtil:       stubClusterUtil{kubeconfig: []byte("someKubeconfig")},
@@ -86,6 +91,68 @@ func TestInitCluster(t *testing.T) {
 			wantErr:    false,
 			k8sVersion: versions.Default,
 		},
+		"kubeadm init restores etcd snapshot and removes stale state": {
+			clusterUtil: stubClusterUtil{kubeconfig: []byte("someKubeconfig")},
+			kubectl: stubKubectl{
+				nodes: []corev1.Node{
+					{ObjectMeta: metav1.ObjectMeta{Name: "old-control-plane"}},
+					{ObjectMeta: metav1.ObjectMeta{Name: nodeName}},
+					{ObjectMeta: metav1.ObjectMeta{Name: "old-worker"}},
+				},
+			},
+			kubeAPIWaiter:     stubKubeAPIWaiter{},
+			etcdIOPrioritizer: stubEtcdIOPrioritizer{},
+			providerMetadata: &stubProviderMetadata{
+				selfResp: metadata.InstanceMetadata{
+					Name:       nodeName,
+					ProviderID: providerID,
+					VPCIP:      privateIP,
+				},
+				getLoadBalancerHostResp: loadbalancerIP,
+				getLoadBalancerPortResp: strconv.Itoa(constants.KubernetesPort),
+			},
+			etcdSnapshotPath: "/run/state/etcd-snapshot/snapshot.db",
+			wantConfig: k8sapi.KubeadmInitYAML{
+				InitConfiguration: kubeadm.InitConfiguration{
+					NodeRegistration: kubeadm.NodeRegistrationOptions{
+						KubeletExtraArgs: map[string]string{
+							"node-ip":     privateIP,
+							"provider-id": providerID,
+						},
+						Name: nodeName,
+					},
+				},
+				ClusterConfiguration: kubeadm.ClusterConfiguration{
+					ClusterName:          "kubernetes",
+					ControlPlaneEndpoint: loadbalancerIP,
+					APIServer: kubeadm.APIServer{
+						CertSANs: []string{privateIP},
+					},
+				},
+			},
+			k8sVersion:       versions.Default,
+			wantDeletedNodes: []string{"old-control-plane", "old-worker"},
+			wantDeletedCMs:   []string{constants.InternalConfigMap, constants.NodeGroupConfigMap, constants.JoinConfigMap},
+		},
+		"kubeadm init fails when removing stale nodes": {
+			clusterUtil: stubClusterUtil{kubeconfig: []byte("someKubeconfig")},
+			kubectl: stubKubectl{
+				nodes:         []corev1.Node{{ObjectMeta: metav1.ObjectMeta{Name: "old-worker"}}},
+				deleteNodeErr: assert.AnError,
+			},
+			kubeAPIWaiter:     stubKubeAPIWaiter{},
+			etcdIOPrioritizer: stubEtcdIOPrioritizer{},
+			providerMetadata: &stubProviderMetadata{
+				selfResp: metadata.InstanceMetadata{
+					Name:       nodeName,
+					ProviderID: providerID,
+					VPCIP:      privateIP,
+				},
+			},
+			etcdSnapshotPath: "/run/state/etcd-snapshot/snapshot.db",
+			k8sVersion:       versions.Default,
+			wantErr:          true,
+		},
 		"kubeadm init fails when annotating itself": {
 			clusterUtil:       stubClusterUtil{kubeconfig: []byte("someKubeconfig")},
 			kubeAPIWaiter:     stubKubeAPIWaiter{},
@@ -202,7 +269,7 @@ func TestInitCluster(t *testing.T) {
 
 			_, err := kube.InitCluster(
 				t.Context(), string(tc.k8sVersion), "kubernetes",
-				false, nil, nil, "",
+				false, nil, nil, "", nil, tc.etcdSnapshotPath,
 			)
 
 			if tc.wantErr {
@@ -211,6 +278,10 @@ func TestInitCluster(t *testing.T) {
 			}
 			require.NoError(err)
 
+			assert.Equal(tc.etcdSnapshotPath, tc.clusterUtil.etcdSnapshotPath)
+			assert.Equal(tc.wantDeletedNodes, tc.kubectl.deletedNodes)
+			assert.Equal(tc.wantDeletedCMs, tc.kubectl.deletedConfigMaps)
+
 			var kubeadmConfig k8sapi.KubeadmInitYAML
 			require.NoError(kubernetes.UnmarshalK8SResources(tc.clusterUtil.initConfigs[0], &kubeadmConfig))
 			require.Equal(tc.wantConfig.ClusterConfiguration, kubeadmConfig.ClusterConfiguration)
@@ -243,6 +314,7 @@ func TestJoinCluster(t *testing.T) {
 		wantConfig        kubeadm.JoinConfiguration
 		role              role.Role
 		k8sComponents     components.Components
+		nodeGroupSettings *nodegroup.Settings
 		etcdIOPrioritizer stubEtcdIOPrioritizer
 		wantErr           bool
 	}{
@@ -310,6 +382,39 @@ func TestJoinCluster(t *testing.T) {
 				},
 			},
 		},
+		"kubeadm join worker applies node group settings": {
+			clusterUtil:       stubClusterUtil{},
+			etcdIOPrioritizer: stubEtcdIOPrioritizer{},
+			providerMetadata: &stubProviderMetadata{
+				selfResp: metadata.InstanceMetadata{
+					ProviderID: "provider-id",
+					Name:       "metadata-name",
+					VPCIP:      "192.0.2.1",
+					NodeGroup:  "gpu",
+				},
+			},
+			role: role.Worker,
+			nodeGroupSettings: &nodegroup.Settings{
+				Role:           "worker",
+				Labels:         map[string]string{"example.com/pool": "gpu"},
+				Taints:         []*nodegroup.Taint{{Key: "example.com/dedicated", Value: "gpu", Effect: "NoSchedule"}},
+				KubeletMaxPods: 50,
+			},
+			wantConfig: kubeadm.JoinConfiguration{
+				Discovery: kubeadm.Discovery{
+					BootstrapToken: joinCommand,
+				},
+				NodeRegistration: kubeadm.NodeRegistrationOptions{
+					Name: "metadata-name",
+					KubeletExtraArgs: map[string]string{
+						"node-ip":     "192.0.2.1",
+						"node-labels": "example.com/pool=gpu",
+						"max-pods":    "50",
+					},
+					Taints: []corev1.Taint{{Key: "example.com/dedicated", Value: "gpu", Effect: corev1.TaintEffectNoSchedule}},
+				},
+			},
+		},
 		"kubeadm join control-plane node works with metadata": {
 			clusterUtil:       stubClusterUtil{},
 			etcdIOPrioritizer: stubEtcdIOPrioritizer{},
@@ -384,7 +489,7 @@ func TestJoinCluster(t *testing.T) {
 				log:               logger.NewTest(t),
 			}
 
-			err := kube.JoinCluster(t.Context(), joinCommand, tc.role, tc.k8sComponents)
+			err := kube.JoinCluster(t.Context(), joinCommand, tc.role, tc.k8sComponents, tc.nodeGroupSettings)
 			if tc.wantErr {
 				assert.Error(err)
 				return
@@ -457,16 +562,18 @@ type stubClusterUtil struct {
 
 	kubeconfig []byte
 
-	initConfigs [][]byte
-	joinConfigs [][]byte
+	initConfigs      [][]byte
+	etcdSnapshotPath string
+	joinConfigs      [][]byte
 }
 
 func (s *stubClusterUtil) InstallComponents(_ context.Context, _ components.Components) error {
 	return s.installComponentsErr
 }
 
-func (s *stubClusterUtil) InitCluster(_ context.Context, initConfig []byte, _, _ string, _ []net.IP, _ bool, _ *slog.Logger) ([]byte, error) {
+func (s *stubClusterUtil) InitCluster(_ context.Context, initConfig []byte, _, _ string, _ []net.IP, _ bool, etcdSnapshotPath string, _ *slog.Logger) ([]byte, error) {
 	s.initConfigs = append(s.initConfigs, initConfig)
+	s.etcdSnapshotPath = etcdSnapshotPath
 	return s.kubeconfig, s.initClusterErr
 }
 
@@ -526,8 +633,15 @@ type stubKubectl struct {
 	listAllNamespacesErr             error
 	annotateNodeErr                  error
 	enforceCoreDNSSpreadErr          error
+	getNodesErr                      error
+	deleteNodeErr                    error
+	deleteConfigMapErr               error
 
 	listAllNamespacesResp *corev1.NamespaceList
+	nodes                 []corev1.Node
+
+	deletedNodes      []string
+	deletedConfigMaps []string
 }
 
 func (s *stubKubectl) Initialize(_ []byte) error {
@@ -538,6 +652,20 @@ func (s *stubKubectl) CreateConfigMap(_ context.Context, _ *corev1.ConfigMap) er
 	return s.createConfigMapErr
 }
 
+func (s *stubKubectl) DeleteConfigMap(_ context.Context, _, name string) error {
+	s.deletedConfigMaps = append(s.deletedConfigMaps, name)
+	return s.deleteConfigMapErr
+}
+
+func (s *stubKubectl) GetNodes(_ context.Context) ([]corev1.Node, error) {
+	return s.nodes, s.getNodesErr
+}
+
+func (s *stubKubectl) DeleteNode(_ context.Context, name string) error {
+	s.deletedNodes = append(s.deletedNodes, name)
+	return s.deleteNodeErr
+}
+
 func (s *stubKubectl) AddNodeSelectorsToDeployment(_ context.Context, _ map[string]string, _, _ string) error {
 	return s.addTNodeSelectorsToDeploymentErr
 }
@@ -57,6 +57,9 @@ func NewRootCmd() *cobra.Command {
 	rootCmd.AddCommand(cmd.NewVerifyCmd())
 	rootCmd.AddCommand(cmd.NewUpgradeCmd())
 	rootCmd.AddCommand(cmd.NewRecoverCmd())
+	rootCmd.AddCommand(cmd.NewRestoreCmd())
+	rootCmd.AddCommand(cmd.NewRotateKeysCmd())
+	rootCmd.AddCommand(cmd.NewNodeCmd())
 	rootCmd.AddCommand(cmd.NewTerminateCmd())
 	rootCmd.AddCommand(cmd.NewIAMCmd())
 	rootCmd.AddCommand(cmd.NewVersionCmd())
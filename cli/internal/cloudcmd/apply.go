@@ -32,6 +32,13 @@ const (
 	WithoutRollbackOnError RollbackBehavior = false
 )
 
+// operatorNodeResourceTypes are the Terraform resource types of the nodes the node operator may replace
+// outside of Terraform, on providers without native scaling groups.
+var operatorNodeResourceTypes = map[cloudprovider.Provider][]string{
+	cloudprovider.OpenStack: {"openstack_compute_instance_v2", "openstack_networking_port_v2"},
+	cloudprovider.QEMU:      {"libvirt_domain", "libvirt_volume"},
+}
+
 // RollbackBehavior is a boolean flag that indicates whether a rollback should be performed.
 type RollbackBehavior bool
 
@@ -81,12 +88,38 @@ func (a *Applier) Plan(ctx context.Context, conf *config.Config) (bool, error) {
 		return false, fmt.Errorf("creating terraform variables: %w", err)
 	}
 
-	return plan(
+	hasDiff, err := plan(
 		ctx, a.terraformClient, a.fileHandler, a.out, a.logLevel, vars,
 		filepath.Join(constants.TerraformEmbeddedDir, strings.ToLower(conf.GetProvider().String())),
 		a.workingDir,
 		filepath.Join(a.backupDir, constants.TerraformUpgradeBackupDir),
 	)
+	if err != nil || !hasDiff {
+		return hasDiff, err
+	}
+
+	// Terraform creates the nodes on OpenStack and QEMU. Nodes replaced by the node operator, e.g. during an image upgrade,
+	// are gone from Terraform's point of view, and applying the plan would create them again on their old image.
+	resourceTypes, ok := operatorNodeResourceTypes[conf.GetProvider()]
+	if !ok {
+		return hasDiff, nil
+	}
+	recreated, err := a.terraformClient.RecreatedResources(ctx, resourceTypes)
+	if err != nil {
+		return false, fmt.Errorf("checking for nodes deleted outside of Terraform: %w", err)
+	}
+	if len(recreated) > 0 {
+		if err := a.RestoreWorkspace(); err != nil {
+			return false, fmt.Errorf("restoring Terraform workspace: %w", err)
+		}
+		return false, fmt.Errorf(
+			"applying the Terraform plan would recreate nodes deleted outside of Terraform, usually because the node operator replaced them: %s. "+
+				"On %s, infrastructure changes aren't supported after the node operator replaced nodes. "+
+				"Use --skip-phases=infrastructure to apply the other phases",
+			strings.Join(recreated, ", "), conf.GetProvider(),
+		)
+	}
+	return hasDiff, nil
 }
 
 // Apply applies the prepared configuration by creating or updating cloud resources.
@@ -10,6 +10,7 @@ import (
 	"bytes"
 	"context"
 	"io"
+	"os"
 	"path/filepath"
 	"runtime"
 	"testing"
@@ -225,12 +226,53 @@ func TestPlan(t *testing.T) {
 	}
 
 	testCases := map[string]struct {
-		upgradeID string
-		tf        *stubTerraformClient
-		fs        file.Handler
-		want      bool
-		wantErr   bool
+		upgradeID    string
+		provider     cloudprovider.Provider
+		tf           *stubTerraformClient
+		fs           file.Handler
+		want         bool
+		wantErr      bool
+		wantRestored bool
 	}{
+		"openstack nodes recreated": {
+			upgradeID: "1234",
+			provider:  cloudprovider.OpenStack,
+			tf: &stubTerraformClient{
+				planDiff:  true,
+				recreated: []string{"openstack_compute_instance_v2.instance_group_member[1]"},
+			},
+			fs:           setUpFilesystem([]string{}),
+			wantErr:      true,
+			wantRestored: true,
+		},
+		"openstack without recreated nodes": {
+			upgradeID: "1234",
+			provider:  cloudprovider.OpenStack,
+			tf: &stubTerraformClient{
+				planDiff: true,
+			},
+			fs:   setUpFilesystem([]string{}),
+			want: true,
+		},
+		"checking for recreated nodes fails": {
+			upgradeID: "1234",
+			provider:  cloudprovider.OpenStack,
+			tf: &stubTerraformClient{
+				planDiff:     true,
+				recreatedErr: assert.AnError,
+			},
+			fs:      setUpFilesystem([]string{}),
+			wantErr: true,
+		},
+		"recreated nodes are not checked on providers with scaling groups": {
+			upgradeID: "1234",
+			tf: &stubTerraformClient{
+				planDiff:     true,
+				recreatedErr: assert.AnError,
+			},
+			fs:   setUpFilesystem([]string{}),
+			want: true,
+		},
 		"success no diff": {
 			upgradeID: "1234",
 			tf:        &stubTerraformClient{},
@@ -300,12 +342,23 @@ func TestPlan(t *testing.T) {
 				out:             io.Discard,
 			}
 
+			provider := tc.provider
+			if provider == cloudprovider.Unknown {
+				provider = cloudprovider.Azure
+			}
 			cfg := config.Default()
-			cfg.RemoveProviderAndAttestationExcept(cloudprovider.Azure)
+			cfg.RemoveProviderAndAttestationExcept(provider)
+			if provider == cloudprovider.OpenStack {
+				cfg.Provider.OpenStack.Cloud = "openstack"
+			}
 
 			diff, err := u.Plan(t.Context(), cfg)
 			if tc.wantErr {
 				require.Error(err)
+				if tc.wantRestored {
+					_, err := tc.fs.Stat(filepath.Join(constants.UpgradeDir, tc.upgradeID, constants.TerraformUpgradeBackupDir))
+					require.ErrorIs(err, os.ErrNotExist)
+				}
 			} else {
 				require.NoError(err)
 				require.Equal(tc.want, diff)
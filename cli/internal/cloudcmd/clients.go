@@ -40,6 +40,7 @@ type tfResourceClient interface {
 	tfDestroyer
 	tfPlanner
 	ApplyCluster(ctx context.Context, provider cloudprovider.Provider, logLevel terraform.LogLevel) (state.Infrastructure, error)
+	RecreatedResources(ctx context.Context, resourceTypes []string) ([]string, error)
 }
 
 type tfIAMClient interface {
@@ -54,6 +55,11 @@ type tfIAMUpgradeClient interface {
 	ApplyIAM(ctx context.Context, csp cloudprovider.Provider, logLevel terraform.LogLevel) (terraform.IAMOutput, error)
 }
 
+// operatorNodeDeleter deletes the nodes the node operator created outside of Terraform.
+type operatorNodeDeleter interface {
+	DeleteOperatorNodes(ctx context.Context, uid string) error
+}
+
 type libvirtRunner interface {
 	Start(ctx context.Context, containerName, imageName string) error
 	Stop(ctx context.Context) error
@@ -48,6 +48,8 @@ type stubTerraformClient struct {
 	planDiff               bool
 	planErr                error
 	showPlanErr            error
+	recreated              []string
+	recreatedErr           error
 }
 
 func (c *stubTerraformClient) ApplyCluster(_ context.Context, _ cloudprovider.Provider, _ terraform.LogLevel) (state.Infrastructure, error) {
@@ -97,6 +99,10 @@ func (c *stubTerraformClient) Plan(_ context.Context, _ terraform.LogLevel) (boo
 	return c.planDiff, c.planErr
 }
 
+func (c *stubTerraformClient) RecreatedResources(_ context.Context, _ []string) ([]string, error) {
+	return c.recreated, c.recreatedErr
+}
+
 func (c *stubTerraformClient) ShowPlan(_ context.Context, _ terraform.LogLevel, _ io.Writer) error {
 	return c.showPlanErr
 }
@@ -0,0 +1,160 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package cloudcmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/edgelesssys/constellation/v2/internal/cloud/openstack"
+	"github.com/edgelesssys/constellation/v2/internal/cloud/openstack/clouds"
+	"github.com/edgelesssys/constellation/v2/internal/cloud/qemu"
+	"github.com/edgelesssys/constellation/v2/internal/config"
+	"github.com/edgelesssys/constellation/v2/internal/file"
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
+	"github.com/gophercloud/gophercloud/v2/openstack/networking/v2/ports"
+	"github.com/gophercloud/utils/v2/openstack/clientconfig"
+)
+
+// serverDeletionTimeout is the time to wait for the servers created by the node operator to be deleted.
+const serverDeletionTimeout = 10 * time.Minute
+
+// openStackNodeDeleter deletes the servers and ports the node operator created on OpenStack.
+type openStackNodeDeleter struct {
+	compute *gophercloud.ServiceClient
+	network *gophercloud.ServiceClient
+}
+
+// newOpenStackNodeDeleter creates an openStackNodeDeleter with the credentials of the configured clouds.yaml.
+func newOpenStackNodeDeleter(ctx context.Context, conf *config.Config, fileHandler file.Handler) (operatorNodeDeleter, error) {
+	cloudsYAML, err := clouds.ReadCloudsYAML(fileHandler, conf.Provider.OpenStack.CloudsYAMLPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading clouds.yaml: %w", err)
+	}
+	cloud, ok := cloudsYAML.Clouds[conf.Provider.OpenStack.Cloud]
+	if !ok {
+		return nil, fmt.Errorf("cloud %q not found in clouds.yaml", conf.Provider.OpenStack.Cloud)
+	}
+	clientOpts := &clientconfig.ClientOpts{
+		AuthType: clientconfig.AuthV3Password,
+		AuthInfo: &clientconfig.AuthInfo{
+			AuthURL:           cloud.AuthInfo.AuthURL,
+			Username:          cloud.AuthInfo.Username,
+			Password:          cloud.AuthInfo.Password,
+			ProjectID:         cloud.AuthInfo.ProjectID,
+			ProjectName:       cloud.AuthInfo.ProjectName,
+			UserDomainName:    cloud.AuthInfo.UserDomainName,
+			ProjectDomainName: cloud.AuthInfo.ProjectDomainName,
+		},
+		RegionName: cloud.RegionName,
+	}
+
+	compute, err := clientconfig.NewServiceClient(ctx, "compute", clientOpts)
+	if err != nil {
+		return nil, fmt.Errorf("creating compute client: %w", err)
+	}
+	// server tags require at least microversion 2.26
+	compute.Microversion = "2.42"
+	network, err := clientconfig.NewServiceClient(ctx, "network", clientOpts)
+	if err != nil {
+		return nil, fmt.Errorf("creating network client: %w", err)
+	}
+	return &openStackNodeDeleter{compute: compute, network: network}, nil
+}
+
+// DeleteOperatorNodes deletes the servers and ports created by the node operator for the cluster with the given UID.
+// Ports are deleted after their servers are gone, so Terraform can delete the cluster network afterwards.
+func (d *openStackNodeDeleter) DeleteOperatorNodes(ctx context.Context, uid string) error {
+	tags := strings.Join([]string{"constellation-uid-" + uid, openstack.OperatorNodeTag}, ",")
+
+	pages, err := servers.List(d.compute, servers.ListOpts{Tags: tags}).AllPages(ctx)
+	if err != nil {
+		return fmt.Errorf("listing servers: %w", err)
+	}
+	list, err := servers.ExtractServers(pages)
+	if err != nil {
+		return fmt.Errorf("extracting servers: %w", err)
+	}
+	for _, server := range list {
+		if err := servers.Delete(ctx, d.compute, server.ID).ExtractErr(); err != nil && !gophercloud.ResponseCodeIs(err, http.StatusNotFound) {
+			return fmt.Errorf("deleting server %q: %w", server.Name, err)
+		}
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, serverDeletionTimeout)
+	defer cancel()
+	for _, server := range list {
+		if err := gophercloud.WaitFor(waitCtx, func(ctx context.Context) (bool, error) {
+			_, err := servers.Get(ctx, d.compute, server.ID).Extract()
+			if gophercloud.ResponseCodeIs(err, http.StatusNotFound) {
+				return true, nil
+			}
+			return false, err
+		}); err != nil {
+			return fmt.Errorf("waiting for server %q to be deleted: %w", server.Name, err)
+		}
+	}
+
+	pages, err = ports.List(d.network, ports.ListOpts{Tags: tags}).AllPages(ctx)
+	if err != nil {
+		return fmt.Errorf("listing ports: %w", err)
+	}
+	portList, err := ports.ExtractPorts(pages)
+	if err != nil {
+		return fmt.Errorf("extracting ports: %w", err)
+	}
+	for _, port := range portList {
+		if err := ports.Delete(ctx, d.network, port.ID).ExtractErr(); err != nil && !gophercloud.ResponseCodeIs(err, http.StatusNotFound) {
+			return fmt.Errorf("deleting port %q: %w", port.Name, err)
+		}
+	}
+	return nil
+}
+
+// qemuNodeDeleter deletes the instances the node operator created on QEMU through the QEMU metadata API.
+type qemuNodeDeleter struct {
+	endpoint string
+	client   *http.Client
+}
+
+func newQEMUNodeDeleter() operatorNodeDeleter {
+	return &qemuNodeDeleter{endpoint: "http://" + qemu.HostMetadataEndpoint, client: &http.Client{}}
+}
+
+// DeleteOperatorNodes deletes the instances created by the node operator.
+// All instances of a QEMU cluster are created by the metadata API running on the local host, so the UID is not used.
+// If the metadata API is not running, there are no instances to delete.
+func (d *qemuNodeDeleter) DeleteOperatorNodes(ctx context.Context, _ string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, d.endpoint+"/instances", http.NoBody)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	resp, err := d.client.Do(req)
+	if opErr := (&net.OpError{}); errors.As(err, &opErr) && opErr.Op == "dial" {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("deleting instances: %w", err)
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusNoContent:
+		return nil
+	case http.StatusNotImplemented:
+		// the metadata API does not manage instances, so the node operator could not create any
+		return nil
+	default:
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("deleting instances: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+}
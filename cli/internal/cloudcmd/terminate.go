@@ -8,19 +8,26 @@ package cloudcmd
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/edgelesssys/constellation/v2/cli/internal/libvirt"
 	"github.com/edgelesssys/constellation/v2/cli/internal/terraform"
+	"github.com/edgelesssys/constellation/v2/internal/cloud/cloudprovider"
+	"github.com/edgelesssys/constellation/v2/internal/config"
+	"github.com/edgelesssys/constellation/v2/internal/file"
 )
 
 // Terminator deletes cloud provider resources.
 type Terminator struct {
 	newTerraformClient func(ctx context.Context, tfWorkspace string) (tfDestroyer, error)
 	newLibvirtRunner   func() libvirtRunner
+	// newNodeDeleter returns the deleter for the nodes the node operator created outside of Terraform,
+	// or nil if the node operator only uses resources managed by Terraform on the provider.
+	newNodeDeleter func(ctx context.Context, conf *config.Config) (operatorNodeDeleter, error)
 }
 
 // NewTerminator create a new cloud terminator.
-func NewTerminator() *Terminator {
+func NewTerminator(fileHandler file.Handler) *Terminator {
 	return &Terminator{
 		newTerraformClient: func(ctx context.Context, tfWorkspace string) (tfDestroyer, error) {
 			return terraform.New(ctx, tfWorkspace)
@@ -28,17 +35,41 @@ func NewTerminator() *Terminator {
 		newLibvirtRunner: func() libvirtRunner {
 			return libvirt.New()
 		},
+		newNodeDeleter: func(ctx context.Context, conf *config.Config) (operatorNodeDeleter, error) {
+			switch conf.GetProvider() {
+			case cloudprovider.OpenStack:
+				return newOpenStackNodeDeleter(ctx, conf, fileHandler)
+			case cloudprovider.QEMU:
+				return newQEMUNodeDeleter(), nil
+			default:
+				return nil, nil
+			}
+		},
 	}
 }
 
 // Terminate deletes the could provider resources.
-func (t *Terminator) Terminate(ctx context.Context, tfWorkspace string, logLevel terraform.LogLevel) (retErr error) {
+// On OpenStack and QEMU, the nodes the node operator created outside of Terraform are deleted first,
+// using the credentials of conf. They are left behind if conf is nil.
+func (t *Terminator) Terminate(ctx context.Context, tfWorkspace string, conf *config.Config, uid string, logLevel terraform.LogLevel) (retErr error) {
 	defer func() {
 		if retErr == nil {
 			retErr = t.newLibvirtRunner().Stop(ctx)
 		}
 	}()
 
+	if conf != nil {
+		deleter, err := t.newNodeDeleter(ctx, conf)
+		if err != nil {
+			return fmt.Errorf("creating client to delete nodes created by the node operator: %w", err)
+		}
+		if deleter != nil {
+			if err := deleter.DeleteOperatorNodes(ctx, uid); err != nil {
+				return fmt.Errorf("deleting nodes created by the node operator: %w", err)
+			}
+		}
+	}
+
 	cl, err := t.newTerraformClient(ctx, tfWorkspace)
 	if err != nil {
 		return err
@@ -9,9 +9,12 @@ package cloudcmd
 import (
 	"context"
 	"errors"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/edgelesssys/constellation/v2/cli/internal/terraform"
+	"github.com/edgelesssys/constellation/v2/internal/config"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -19,11 +22,41 @@ func TestTerminator(t *testing.T) {
 	someErr := errors.New("failed")
 
 	testCases := map[string]struct {
-		tfClient       tfDestroyer
-		newTfClientErr error
-		libvirt        *stubLibvirtRunner
-		wantErr        bool
+		tfClient          tfDestroyer
+		newTfClientErr    error
+		libvirt           *stubLibvirtRunner
+		conf              *config.Config
+		nodeDeleter       *stubOperatorNodeDeleter
+		newNodeDeleterErr error
+		wantNodesDeleted  bool
+		wantErr           bool
 	}{
+		"operator nodes are deleted": {
+			libvirt:          &stubLibvirtRunner{},
+			tfClient:         &stubTerraformClient{},
+			conf:             config.Default(),
+			nodeDeleter:      &stubOperatorNodeDeleter{},
+			wantNodesDeleted: true,
+		},
+		"no config": {
+			libvirt:     &stubLibvirtRunner{},
+			tfClient:    &stubTerraformClient{},
+			nodeDeleter: &stubOperatorNodeDeleter{},
+		},
+		"creating node deleter fails": {
+			libvirt:           &stubLibvirtRunner{},
+			tfClient:          &stubTerraformClient{},
+			conf:              config.Default(),
+			newNodeDeleterErr: someErr,
+			wantErr:           true,
+		},
+		"deleting operator nodes fails": {
+			libvirt:     &stubLibvirtRunner{},
+			tfClient:    &stubTerraformClient{},
+			conf:        config.Default(),
+			nodeDeleter: &stubOperatorNodeDeleter{deleteErr: someErr},
+			wantErr:     true,
+		},
 		"gcp": {
 			libvirt:  &stubLibvirtRunner{},
 			tfClient: &stubTerraformClient{},
@@ -61,9 +94,18 @@ func TestTerminator(t *testing.T) {
 				newLibvirtRunner: func() libvirtRunner {
 					return tc.libvirt
 				},
+				newNodeDeleter: func(_ context.Context, _ *config.Config) (operatorNodeDeleter, error) {
+					if tc.nodeDeleter == nil {
+						return nil, tc.newNodeDeleterErr
+					}
+					return tc.nodeDeleter, tc.newNodeDeleterErr
+				},
 			}
 
-			err := terminator.Terminate(t.Context(), "", terraform.LogLevelNone)
+			err := terminator.Terminate(t.Context(), "", tc.conf, "uid", terraform.LogLevelNone)
+			if tc.nodeDeleter != nil {
+				assert.Equal(tc.wantNodesDeleted, tc.nodeDeleter.deletedUID == "uid")
+			}
 
 			if tc.wantErr {
 				assert.Error(err)
@@ -77,3 +119,75 @@ func TestTerminator(t *testing.T) {
 		})
 	}
 }
+
+func TestQEMUNodeDeleter(t *testing.T) {
+	testCases := map[string]struct {
+		handler    http.HandlerFunc
+		noServer   bool
+		wantCalled bool
+		wantErr    bool
+	}{
+		"instances deleted": {
+			handler: func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusNoContent)
+			},
+			wantCalled: true,
+		},
+		"instance management not supported": {
+			handler: func(w http.ResponseWriter, _ *http.Request) {
+				http.Error(w, "not supported", http.StatusNotImplemented)
+			},
+			wantCalled: true,
+		},
+		"deleting instances fails": {
+			handler: func(w http.ResponseWriter, _ *http.Request) {
+				http.Error(w, "failed", http.StatusInternalServerError)
+			},
+			wantCalled: true,
+			wantErr:    true,
+		},
+		"metadata API not running": {
+			noServer: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			var called bool
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				called = true
+				assert.Equal(http.MethodDelete, r.Method)
+				assert.Equal("/instances", r.URL.Path)
+				tc.handler(w, r)
+			}))
+			defer server.Close()
+			if tc.noServer {
+				server.Close()
+			}
+
+			deleter := &qemuNodeDeleter{endpoint: server.URL, client: server.Client()}
+			err := deleter.DeleteOperatorNodes(t.Context(), "uid")
+			if tc.wantErr {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+			}
+			assert.Equal(tc.wantCalled, called)
+		})
+	}
+}
+
+type stubOperatorNodeDeleter struct {
+	deleteErr  error
+	deletedUID string
+}
+
+func (d *stubOperatorNodeDeleter) DeleteOperatorNodes(_ context.Context, uid string) error {
+	if d.deleteErr != nil {
+		return d.deleteErr
+	}
+	d.deletedUID = uid
+	return nil
+}
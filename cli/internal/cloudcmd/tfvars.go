@@ -215,8 +215,11 @@ func gcpTerraformVars(conf *config.Config, imageRef string) *terraform.GCPCluste
 	}
 
 	ccTech := "SEV"
-	if conf.GetAttestationConfig().GetVariant().Equal(variant.GCPSEVSNP{}) {
+	switch conf.GetAttestationConfig().GetVariant() {
+	case variant.GCPSEVSNP{}:
 		ccTech = "SEV_SNP"
+	case variant.GCPTDX{}:
+		ccTech = "TDX"
 	}
 
 	return &terraform.GCPClusterVariables{
@@ -24,6 +24,7 @@ import (
 	"github.com/edgelesssys/constellation/v2/internal/api/attestationconfigapi"
 	"github.com/edgelesssys/constellation/v2/internal/api/versionsapi"
 	"github.com/edgelesssys/constellation/v2/internal/atls"
+	"github.com/edgelesssys/constellation/v2/internal/attestation/measurements"
 	"github.com/edgelesssys/constellation/v2/internal/attestation/variant"
 	"github.com/edgelesssys/constellation/v2/internal/cloud/cloudprovider"
 	"github.com/edgelesssys/constellation/v2/internal/compatibility"
@@ -37,6 +38,7 @@ import (
 	"github.com/edgelesssys/constellation/v2/internal/grpc/dialer"
 	"github.com/edgelesssys/constellation/v2/internal/imagefetcher"
 	"github.com/edgelesssys/constellation/v2/internal/kms/uri"
+	"github.com/edgelesssys/constellation/v2/internal/nodegroup"
 	"github.com/edgelesssys/constellation/v2/internal/semver"
 	"github.com/edgelesssys/constellation/v2/internal/versions"
 	slogmulti "github.com/samber/slog-multi"
@@ -210,21 +212,38 @@ func (f *applyFlags) parse(flags *pflag.FlagSet) error {
 
 // runApply sets up the apply command and runs it.
 func runApply(cmd *cobra.Command, _ []string) error {
-	spinner, err := newSpinnerOrStderr(cmd)
+	apply, upgradeDir, err := newApplyCmd(cmd)
 	if err != nil {
 		return err
 	}
-	defer spinner.Stop()
+	defer apply.spinner.Stop()
+
+	ctx, cancel := context.WithTimeout(cmd.Context(), time.Hour)
+	defer cancel()
+	cmd.SetContext(ctx)
+
+	return apply.apply(cmd, attestationconfigapi.NewFetcher(), upgradeDir)
+}
+
+// newApplyCmd sets up the apply command from the command's flags.
+// It returns the command and the directory for files of the upgrade.
+func newApplyCmd(cmd *cobra.Command) (*applyCmd, string, error) {
+	spinner, err := newSpinnerOrStderr(cmd)
+	if err != nil {
+		return nil, "", err
+	}
 
 	flags := applyFlags{}
 	if err := flags.parse(cmd.Flags()); err != nil {
-		return err
+		spinner.Stop()
+		return nil, "", err
 	}
 
 	fileHandler := file.NewHandler(afero.NewOsFs())
 	debugLogger, err := newDebugFileLogger(cmd, fileHandler)
 	if err != nil {
-		return err
+		spinner.Stop()
+		return nil, "", err
 	}
 
 	newDialer := func(validator atls.Validator) *dialer.Dialer {
@@ -247,7 +266,7 @@ func runApply(cmd *cobra.Command, _ []string) error {
 
 	applier := constellation.NewApplier(debugLogger, spinner, constellation.ApplyContextCLI, newDialer)
 
-	apply := &applyCmd{
+	return &applyCmd{
 		fileHandler:     fileHandler,
 		flags:           flags,
 		log:             debugLogger,
@@ -257,13 +276,7 @@ func runApply(cmd *cobra.Command, _ []string) error {
 		newInfraApplier: newInfraApplier,
 		imageFetcher:    imagefetcher.New(),
 		applier:         applier,
-	}
-
-	ctx, cancel := context.WithTimeout(cmd.Context(), time.Hour)
-	defer cancel()
-	cmd.SetContext(ctx)
-
-	return apply.apply(cmd, attestationconfigapi.NewFetcher(), upgradeDir)
+	}, upgradeDir, nil
 }
 
 type applyCmd struct {
@@ -280,6 +293,9 @@ type applyCmd struct {
 	applier      applier
 
 	newInfraApplier func(context.Context) (cloudApplier, func(), error)
+
+	// restore is set if the cluster is restored from an etcd snapshot instead of being initialized from scratch.
+	restore *clusterRestore
 }
 
 /*
@@ -403,6 +419,12 @@ func (a *applyCmd) apply(
 		}
 	}
 
+	// Apply node group settings, which are handed out to nodes joining the cluster
+	a.log.Debug("Applying node group settings to cluster")
+	if err := a.applier.ApplyNodeGroupConfig(cmd.Context(), conf.NodeGroupSettings()); err != nil {
+		return fmt.Errorf("applying node group settings: %w", err)
+	}
+
 	// Extend API Server Cert SANs
 	if !a.flags.skipPhases.contains(skipCertSANsPhase) {
 		if err := a.applier.ExtendClusterConfigCertSANs(
@@ -476,6 +498,20 @@ func (a *applyCmd) validateInputs(cmd *cobra.Command, configFetcher attestationc
 	preInitValidateErr := stateFile.Validate(state.PreInit, conf.GetAttestationConfig().GetVariant())
 	postInitValidateErr := stateFile.Validate(state.PostInit, conf.GetAttestationConfig().GetVariant())
 
+	// A cluster is restored on new infrastructure, since the control-plane nodes of the previous cluster are lost.
+	// Keys are derived from the master secret of the previous cluster, which is not supported for external KMSs.
+	if a.restore != nil {
+		if preCreateValidateErr != nil {
+			return nil, nil, fmt.Errorf(
+				"restoring a cluster requires new infrastructure, run 'constellation terminate' to remove the previous cluster first: %w",
+				preCreateValidateErr,
+			)
+		}
+		if conf.KMS != nil && conf.KMS.KMIP != nil {
+			return nil, nil, errors.New("restoring a cluster that uses an external KMS is not supported")
+		}
+	}
+
 	// If the state file is in a pre-create state, we need to create the cluster,
 	// in which case the workspace has to be clean
 	if preCreateValidateErr == nil {
@@ -607,6 +643,10 @@ func (a *applyCmd) applyJoinConfig(cmd *cobra.Command, newConfig config.Attestat
 		return fmt.Errorf("getting cluster attestation config: %w", err)
 	}
 
+	if err := a.applyMeasurementTransition(cmd, clusterAttestationConfig, newConfig); err != nil {
+		return err
+	}
+
 	// If the current config is equal, or there is an error when comparing the configs, we skip the upgrade.
 	equal, err := newConfig.EqualTo(clusterAttestationConfig)
 	if err != nil {
@@ -642,6 +682,50 @@ func (a *applyCmd) applyJoinConfig(cmd *cobra.Command, newConfig config.Attestat
 	return nil
 }
 
+// applyMeasurementTransition keeps nodes running the previous image able to attest during a node image upgrade.
+// If the configured measurements differ from the cluster's measurements, the cluster's current measurement sets
+// are added to the accepted measurements of the new config. They are kept until all nodes run the new image.
+func (a *applyCmd) applyMeasurementTransition(cmd *cobra.Command, clusterConfig, newConfig config.AttestationCfg) error {
+	clusterMeasurements := clusterConfig.GetMeasurements()
+	if !clusterMeasurements.EqualTo(newConfig.GetMeasurements()) {
+		if a.flags.skipPhases.contains(skipImagePhase) {
+			return nil
+		}
+		a.log.Debug("Measurements changed, keeping the cluster's current measurements accepted until all nodes are upgraded")
+		newConfig.SetAcceptedMeasurements(
+			measurements.AppendUnique(newConfig.GetAcceptedMeasurements(), config.MeasurementSets(clusterConfig)...),
+		)
+		cmd.Println("The cluster's current measurements stay accepted until all nodes run the new image.")
+		cmd.Println("Run 'constellation apply' again after the upgrade completes to remove them. 'constellation status' shows the progress.")
+		return nil
+	}
+
+	var transitionSets []measurements.M
+	for _, accepted := range clusterConfig.GetAcceptedMeasurements() {
+		if !slices.ContainsFunc(newConfig.GetAcceptedMeasurements(), accepted.EqualTo) {
+			transitionSets = append(transitionSets, accepted)
+		}
+	}
+	if len(transitionSets) == 0 {
+		return nil
+	}
+	inProgress, err := a.applier.NodeImageUpgradeInProgress(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("checking node image upgrade status: %w", err)
+	}
+	if inProgress {
+		a.log.Debug("Node image upgrade in progress, keeping the cluster's accepted measurements")
+		newConfig.SetAcceptedMeasurements(
+			measurements.AppendUnique(newConfig.GetAcceptedMeasurements(), transitionSets...),
+		)
+		cmd.Printf("The node image upgrade is still in progress, %d previous measurement set(s) stay accepted.\n", len(transitionSets))
+		cmd.Println("Run 'constellation apply' again after the upgrade completes to remove them.")
+		return nil
+	}
+	cmd.Printf("All nodes run the new image, removing %d previous measurement set(s) from the cluster's attestation config.\n", len(transitionSets))
+	return nil
+}
+
 func (a *applyCmd) runNodeImageUpgrade(cmd *cobra.Command, conf *config.Config) error {
 	provider := conf.GetProvider()
 	attestationVariant := conf.GetAttestationConfig().GetVariant()
@@ -665,6 +749,9 @@ func (a *applyCmd) runNodeImageUpgrade(cmd *cobra.Command, conf *config.Config)
 	switch {
 	case errors.Is(err, kubecmd.ErrInProgress):
 		cmd.PrintErrln("Skipping image upgrade: Another upgrade is already in progress.")
+	case errors.Is(err, kubecmd.ErrRolledBack):
+		cmd.PrintErrf("Skipping image upgrade: %s.\n", err)
+		cmd.PrintErrln("The node operator rolled back a previous upgrade to this image. Use --force to retry the upgrade.")
 	case errors.As(err, &upgradeErr):
 		cmd.PrintErrln(err)
 	case err != nil:
@@ -716,6 +803,10 @@ func (a *applyCmd) checkInitFilesClean() error {
 	} else if !errors.Is(err, fs.ErrNotExist) {
 		return fmt.Errorf("checking for %q: %w", a.flags.pathPrefixer.PrefixPrintablePath(constants.AdminConfFilename), err)
 	}
+	// A restored cluster keeps using the master secret of the previous cluster.
+	if a.restore != nil {
+		return nil
+	}
 	a.log.Debug("Checking master secrets file")
 	if _, err := a.fileHandler.Stat(constants.MasterSecretFilename); err == nil {
 		return fmt.Errorf(
@@ -856,6 +947,8 @@ type applier interface {
 	ExtendClusterConfigCertSANs(ctx context.Context, clusterEndpoint, customEndpoint string, additionalAPIServerCertSANs []string) error
 	GetClusterAttestationConfig(ctx context.Context, variant variant.Variant) (config.AttestationCfg, error)
 	ApplyJoinConfig(ctx context.Context, newAttestConfig config.AttestationCfg, measurementSalt []byte) error
+	ApplyNodeGroupConfig(ctx context.Context, nodeGroups nodegroup.Config) error
+	NodeImageUpgradeInProgress(ctx context.Context) (bool, error)
 	UpgradeNodeImage(ctx context.Context, imageVersion semver.Semver, imageReference string, force bool) error
 	UpgradeKubernetesVersion(ctx context.Context, kubernetesVersion versions.ValidK8sVersion, force bool) error
 	BackupCRDs(ctx context.Context, fileHandler file.Handler, upgradeDir string) ([]apiextensionsv1.CustomResourceDefinition, error)
@@ -18,6 +18,7 @@ import (
 	"time"
 
 	"github.com/edgelesssys/constellation/v2/internal/atls"
+	"github.com/edgelesssys/constellation/v2/internal/attestation/measurements"
 	"github.com/edgelesssys/constellation/v2/internal/cloud/cloudprovider"
 	"github.com/edgelesssys/constellation/v2/internal/cloud/gcpshared"
 	"github.com/edgelesssys/constellation/v2/internal/config"
@@ -467,6 +468,77 @@ func TestValidateInputs(t *testing.T) {
 	}
 }
 
+func TestApplyMeasurementTransition(t *testing.T) {
+	oldMeasurements := measurements.M{0: measurements.WithAllBytes(0x01, measurements.Enforce, measurements.PCRMeasurementLength)}
+	newMeasurements := measurements.M{0: measurements.WithAllBytes(0x02, measurements.Enforce, measurements.PCRMeasurementLength)}
+	olderMeasurements := measurements.M{0: measurements.WithAllBytes(0x03, measurements.Enforce, measurements.PCRMeasurementLength)}
+
+	testCases := map[string]struct {
+		clusterConfig     *config.QEMUVTPM
+		newConfig         *config.QEMUVTPM
+		skipPhases        skipPhases
+		upgradeInProgress bool
+		wantAccepted      []measurements.M
+		wantOutput        string
+	}{
+		"measurements changed": {
+			clusterConfig: &config.QEMUVTPM{Measurements: oldMeasurements},
+			newConfig:     &config.QEMUVTPM{Measurements: newMeasurements},
+			wantAccepted:  []measurements.M{oldMeasurements},
+			wantOutput:    "current measurements stay accepted",
+		},
+		"measurements changed while previous sets are accepted": {
+			clusterConfig: &config.QEMUVTPM{Measurements: oldMeasurements, AcceptedMeasurements: []measurements.M{olderMeasurements}},
+			newConfig:     &config.QEMUVTPM{Measurements: newMeasurements},
+			wantAccepted:  []measurements.M{oldMeasurements, olderMeasurements},
+		},
+		"measurements changed, image phase skipped": {
+			clusterConfig: &config.QEMUVTPM{Measurements: oldMeasurements},
+			newConfig:     &config.QEMUVTPM{Measurements: newMeasurements},
+			skipPhases:    newPhases(skipImagePhase),
+		},
+		"upgrade in progress": {
+			clusterConfig:     &config.QEMUVTPM{Measurements: newMeasurements, AcceptedMeasurements: []measurements.M{oldMeasurements}},
+			newConfig:         &config.QEMUVTPM{Measurements: newMeasurements},
+			upgradeInProgress: true,
+			wantAccepted:      []measurements.M{oldMeasurements},
+			wantOutput:        "1 previous measurement set(s) stay accepted",
+		},
+		"upgrade finished": {
+			clusterConfig: &config.QEMUVTPM{Measurements: newMeasurements, AcceptedMeasurements: []measurements.M{oldMeasurements}},
+			newConfig:     &config.QEMUVTPM{Measurements: newMeasurements},
+			wantOutput:    "removing 1 previous measurement set(s)",
+		},
+		"configured accepted measurements are kept": {
+			clusterConfig: &config.QEMUVTPM{Measurements: newMeasurements, AcceptedMeasurements: []measurements.M{oldMeasurements}},
+			newConfig:     &config.QEMUVTPM{Measurements: newMeasurements, AcceptedMeasurements: []measurements.M{olderMeasurements}},
+			wantAccepted:  []measurements.M{olderMeasurements},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			require := require.New(t)
+
+			a := applyCmd{
+				applier: &stubConstellApplier{
+					stubKubernetesUpgrader: &stubKubernetesUpgrader{upgradeInProgress: tc.upgradeInProgress},
+				},
+				flags: applyFlags{skipPhases: tc.skipPhases},
+				log:   logger.NewTest(t),
+			}
+			cmd := NewApplyCmd()
+			cmd.SetContext(t.Context())
+			var out bytes.Buffer
+			cmd.SetOut(&out)
+
+			require.NoError(a.applyMeasurementTransition(cmd, tc.clusterConfig, tc.newConfig))
+			assert.Equal(t, tc.wantAccepted, tc.newConfig.AcceptedMeasurements)
+			assert.Contains(t, out.String(), tc.wantOutput)
+		})
+	}
+}
+
 func TestSkipPhasesCompletion(t *testing.T) {
 	testCases := map[string]struct {
 		toComplete      string
@@ -531,6 +603,7 @@ type stubConstellApplier struct {
 	generateMeasurementSaltErr error
 	initErr                    error
 	initOutput                 constellation.InitOutput
+	initPayload                constellation.InitPayload
 	*stubKubernetesUpgrader
 	helmApplier
 }
@@ -549,7 +622,8 @@ func (s *stubConstellApplier) GenerateMeasurementSalt() ([]byte, error) {
 	return s.measurementSalt, s.generateMeasurementSaltErr
 }
 
-func (s *stubConstellApplier) Init(context.Context, atls.Validator, *state.State, io.Writer, constellation.InitPayload) (constellation.InitOutput, error) {
+func (s *stubConstellApplier) Init(_ context.Context, _ atls.Validator, _ *state.State, _ io.Writer, payload constellation.InitPayload) (constellation.InitOutput, error) {
+	s.initPayload = payload
 	return s.initOutput, s.initErr
 }
 
@@ -32,17 +32,18 @@ func (a *applyCmd) runHelmApply(cmd *cobra.Command, conf *config.Config, stateFi
 	}
 
 	options := helm.Options{
-		CSP:                 conf.GetProvider(),
-		AttestationVariant:  conf.GetAttestationConfig().GetVariant(),
-		K8sVersion:          conf.KubernetesVersion,
-		MicroserviceVersion: conf.MicroserviceVersion,
-		DeployCSIDriver:     conf.DeployCSIDriver(),
-		Force:               a.flags.force,
-		Conformance:         a.flags.conformance,
-		HelmWaitMode:        a.flags.helmWaitMode,
-		ApplyTimeout:        a.flags.helmTimeout,
-		AllowDestructive:    helm.DenyDestructive,
-		ServiceCIDR:         conf.ServiceCIDR,
+		CSP:                     conf.GetProvider(),
+		AttestationVariant:      conf.GetAttestationConfig().GetVariant(),
+		K8sVersion:              conf.KubernetesVersion,
+		MicroserviceVersion:     conf.MicroserviceVersion,
+		DeployCSIDriver:         conf.DeployCSIDriver(),
+		Force:                   a.flags.force,
+		Conformance:             a.flags.conformance,
+		HelmWaitMode:            a.flags.helmWaitMode,
+		ApplyTimeout:            a.flags.helmTimeout,
+		AllowDestructive:        helm.DenyDestructive,
+		ServiceCIDR:             conf.ServiceCIDR,
+		KeyServiceAuthorization: conf.KeyServiceAuthorization,
 	}
 	if conf.Provider.OpenStack != nil {
 		var deployYawolLoadBalancer bool
@@ -57,6 +58,14 @@ func (a *applyCmd) runHelmApply(cmd *cobra.Command, conf *config.Config, stateFi
 		}
 	}
 
+	externalKMS, err := a.externalKMSValues(conf)
+	if err != nil {
+		return err
+	}
+	if externalKMS.KMSURI != "" {
+		options.ExternalKMSValues = &externalKMS
+	}
+
 	a.log.Debug("Getting service account URI")
 	serviceAccURI, err := cloudcmd.GetMarshaledServiceAccountURI(conf, a.fileHandler)
 	if err != nil {
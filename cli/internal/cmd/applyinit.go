@@ -18,6 +18,7 @@ import (
 	"github.com/edgelesssys/constellation/v2/internal/config"
 	"github.com/edgelesssys/constellation/v2/internal/constants"
 	"github.com/edgelesssys/constellation/v2/internal/constellation"
+	"github.com/edgelesssys/constellation/v2/internal/constellation/helm"
 	"github.com/edgelesssys/constellation/v2/internal/constellation/state"
 	"github.com/edgelesssys/constellation/v2/internal/file"
 	"github.com/edgelesssys/constellation/v2/internal/kms/uri"
@@ -36,9 +37,16 @@ func (a *applyCmd) runInit(cmd *cobra.Command, conf *config.Config, stateFile *s
 	}
 
 	a.log.Debug("Running init RPC")
-	masterSecret, err := a.generateAndPersistMasterSecret(cmd.OutOrStdout())
-	if err != nil {
-		return nil, fmt.Errorf("generating master secret: %w", err)
+	var masterSecret uri.MasterSecret
+	var openEtcdSnapshot func() (io.ReadCloser, error)
+	if a.restore != nil {
+		a.log.Debug("Restoring cluster from etcd snapshot")
+		masterSecret, openEtcdSnapshot = a.restore.masterSecret, a.restore.openEtcdSnapshot
+	} else {
+		masterSecret, err = a.generateAndPersistMasterSecret(cmd.OutOrStdout())
+		if err != nil {
+			return nil, fmt.Errorf("generating master secret: %w", err)
+		}
 	}
 
 	measurementSalt, err := a.applier.GenerateMeasurementSalt()
@@ -46,15 +54,24 @@ func (a *applyCmd) runInit(cmd *cobra.Command, conf *config.Config, stateFile *s
 		return nil, fmt.Errorf("generating measurement salt: %w", err)
 	}
 
+	externalKMS, err := a.externalKMSValues(conf)
+	if err != nil {
+		return nil, err
+	}
+
 	clusterLogs := &bytes.Buffer{}
 	resp, err := a.applier.Init(
 		cmd.Context(), validator, stateFile, clusterLogs,
 		constellation.InitPayload{
-			MasterSecret:    masterSecret,
-			MeasurementSalt: measurementSalt,
-			K8sVersion:      conf.KubernetesVersion,
-			ConformanceMode: a.flags.conformance,
-			ServiceCIDR:     conf.ServiceCIDR,
+			MasterSecret:     masterSecret,
+			MeasurementSalt:  measurementSalt,
+			K8sVersion:       conf.KubernetesVersion,
+			ConformanceMode:  a.flags.conformance,
+			ServiceCIDR:      conf.ServiceCIDR,
+			KMSURI:           externalKMS.KMSURI,
+			StorageURI:       externalKMS.StorageURI,
+			NodeGroups:       conf.NodeGroupSettings(),
+			OpenEtcdSnapshot: openEtcdSnapshot,
 		})
 	if len(clusterLogs.Bytes()) > 0 {
 		if err := a.fileHandler.Write(constants.ErrorLog, clusterLogs.Bytes(), file.OptAppend); err != nil {
@@ -98,6 +115,39 @@ func (a *applyCmd) generateAndPersistMasterSecret(outWriter io.Writer) (uri.Mast
 	return secret, nil
 }
 
+// externalKMSValues returns the URIs of the external KMS configured in conf.
+// If no external KMS is configured, empty values are returned.
+func (a *applyCmd) externalKMSValues(conf *config.Config) (helm.ExternalKMSValues, error) {
+	if conf.KMS == nil || conf.KMS.KMIP == nil {
+		return helm.ExternalKMSValues{}, nil
+	}
+	kmipConf := conf.KMS.KMIP
+
+	caCert, err := a.fileHandler.Read(kmipConf.CACertPath)
+	if err != nil {
+		return helm.ExternalKMSValues{}, fmt.Errorf("reading KMIP CA certificate: %w", err)
+	}
+	clientCert, err := a.fileHandler.Read(kmipConf.ClientCertPath)
+	if err != nil {
+		return helm.ExternalKMSValues{}, fmt.Errorf("reading KMIP client certificate: %w", err)
+	}
+	clientKey, err := a.fileHandler.Read(kmipConf.ClientKeyPath)
+	if err != nil {
+		return helm.ExternalKMSValues{}, fmt.Errorf("reading KMIP client key: %w", err)
+	}
+
+	return helm.ExternalKMSValues{
+		KMSURI: uri.KMIPConfig{
+			Endpoint:   kmipConf.Endpoint,
+			KeyName:    kmipConf.KeyName,
+			CACert:     caCert,
+			ClientCert: clientCert,
+			ClientKey:  clientKey,
+		}.EncodeToURI(),
+		StorageURI: kmipConf.StorageURI,
+	}, nil
+}
+
 // writeInitOutput writes the output of a cluster initialization to the
 // state- / kubeconfig-file and saves it to disk.
 func (a *applyCmd) writeInitOutput(
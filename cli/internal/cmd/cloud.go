@@ -39,5 +39,5 @@ type iamDestroyer interface {
 }
 
 type cloudTerminator interface {
-	Terminate(ctx context.Context, workspace string, logLevel terraform.LogLevel) error
+	Terminate(ctx context.Context, workspace string, conf *config.Config, uid string, logLevel terraform.LogLevel) error
 }
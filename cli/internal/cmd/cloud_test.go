@@ -61,10 +61,15 @@ func (c *stubCloudCreator) WorkingDirIsEmpty() (bool, error) {
 type stubCloudTerminator struct {
 	called       bool
 	terminateErr error
+
+	conf *config.Config
+	uid  string
 }
 
-func (c *stubCloudTerminator) Terminate(_ context.Context, _ string, _ terraform.LogLevel) error {
+func (c *stubCloudTerminator) Terminate(_ context.Context, _ string, conf *config.Config, uid string, _ terraform.LogLevel) error {
 	c.called = true
+	c.conf = conf
+	c.uid = uid
 	return c.terminateErr
 }
 
@@ -42,6 +42,7 @@ func newConfigFetchMeasurementsCmd() *cobra.Command {
 	cmd.Flags().StringP("signature-url", "s", "", "alternative URL to fetch measurements' signature from")
 	cmd.Flags().Bool("insecure", false, "skip the measurement signature verification")
 	must(cmd.Flags().MarkHidden("insecure"))
+	cmd.Flags().Bool("append", false, "keep the previously configured measurements as accepted measurements, e.g., to allow nodes running the previous image during an upgrade")
 
 	return cmd
 }
@@ -51,6 +52,7 @@ type fetchMeasurementsFlags struct {
 	measurementsURL *url.URL
 	signatureURL    *url.URL
 	insecure        bool
+	append          bool
 }
 
 func (f *fetchMeasurementsFlags) parse(flags *pflag.FlagSet) error {
@@ -71,6 +73,10 @@ func (f *fetchMeasurementsFlags) parse(flags *pflag.FlagSet) error {
 	if err != nil {
 		return fmt.Errorf("getting 'insecure' flag: %w", err)
 	}
+	f.append, err = flags.GetBool("append")
+	if err != nil {
+		return fmt.Errorf("getting 'append' flag: %w", err)
+	}
 	return nil
 }
 
@@ -155,7 +161,11 @@ func (cfm *configFetchMeasurementsCmd) configFetchMeasurements(
 	cfm.log.Debug(fmt.Sprintf("Measurements: %s", fetchedMeasurements.String()))
 
 	cfm.log.Debug("Updating measurements in configuration")
-	conf.UpdateMeasurements(fetchedMeasurements)
+	if cfm.flags.append {
+		conf.AppendMeasurements(fetchedMeasurements)
+	} else {
+		conf.UpdateMeasurements(fetchedMeasurements)
+	}
 	if err := fileHandler.WriteYAML(constants.ConfigFilename, conf, file.OptOverwrite); err != nil {
 		return err
 	}
@@ -36,6 +36,7 @@ func TestParseFetchMeasurementsFlags(t *testing.T) {
 		urlFlag          string
 		signatureURLFlag string
 		forceFlag        bool
+		appendFlag       bool
 		wantFlags        fetchMeasurementsFlags
 		wantErr          bool
 	}{
@@ -53,6 +54,12 @@ func TestParseFetchMeasurementsFlags(t *testing.T) {
 				signatureURL:    urlMustParse("https://some.other.url/with/path.sig"),
 			},
 		},
+		"append": {
+			appendFlag: true,
+			wantFlags: fetchMeasurementsFlags{
+				append: true,
+			},
+		},
 		"broken url": {
 			urlFlag: "%notaurl%",
 			wantErr: true,
@@ -76,6 +83,9 @@ func TestParseFetchMeasurementsFlags(t *testing.T) {
 			if tc.signatureURLFlag != "" {
 				require.NoError(cmd.Flags().Set("signature-url", tc.signatureURLFlag))
 			}
+			if tc.appendFlag {
+				require.NoError(cmd.Flags().Set("append", "true"))
+			}
 			var flags fetchMeasurementsFlags
 			err := flags.parse(cmd.Flags())
 			if tc.wantErr {
@@ -151,12 +161,22 @@ func newTestClient(fn roundTripFunc) *http.Client {
 }
 
 func TestConfigFetchMeasurements(t *testing.T) {
+	fetchedMeasurements := measurements.M{
+		4: measurements.WithAllBytes(0x55, measurements.Enforce, measurements.PCRMeasurementLength),
+	}
+
 	testCases := map[string]struct {
 		insecureFlag bool
+		appendFlag   bool
 		err          error
 		wantErr      bool
+		wantAccepted int
 	}{
 		"no error succeeds": {},
+		"append keeps previous measurements": {
+			appendFlag:   true,
+			wantAccepted: 1,
+		},
 		"failing rekor verify should not result in error": {
 			err: &measurements.RekorError{},
 		},
@@ -179,9 +199,10 @@ func TestConfigFetchMeasurements(t *testing.T) {
 
 			err := fileHandler.WriteYAML(constants.ConfigFilename, gcpConfig, file.OptMkdirAll)
 			require.NoError(err)
-			fetcher := stubVerifyFetcher{err: tc.err}
+			fetcher := stubVerifyFetcher{measurements: fetchedMeasurements, err: tc.err}
 			cfm := &configFetchMeasurementsCmd{canFetchMeasurements: true, log: logger.NewTest(t), verifyFetcher: fetcher}
 			cfm.flags.insecure = tc.insecureFlag
+			cfm.flags.append = tc.appendFlag
 			cfm.flags.force = true
 
 			err = cfm.configFetchMeasurements(cmd, fileHandler, stubAttestationFetcher{})
@@ -190,16 +211,21 @@ func TestConfigFetchMeasurements(t *testing.T) {
 				return
 			}
 			assert.NoError(err)
+
+			var writtenConfig config.Config
+			require.NoError(fileHandler.ReadYAML(constants.ConfigFilename, &writtenConfig))
+			assert.Len(writtenConfig.GetAttestationConfig().GetAcceptedMeasurements(), tc.wantAccepted)
 		})
 	}
 }
 
 type stubVerifyFetcher struct {
-	err error
+	measurements measurements.M
+	err          error
 }
 
 func (f stubVerifyFetcher) FetchAndVerifyMeasurements(_ context.Context, _ string, _ cloudprovider.Provider, _ variant.Variant, _ bool) (measurements.M, error) {
-	return nil, f.err
+	return f.measurements, f.err
 }
 
 type stubAttestationFetcher struct{}
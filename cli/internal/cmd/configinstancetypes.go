@@ -38,6 +38,8 @@ Azure Trusted Launch instance types:
 %v
 GCP instance types:
 %v
+GCP Intel TDX instance types:
+%v
 STACKIT instance types:
 %v
 `,
@@ -47,6 +49,7 @@ STACKIT instance types:
 		formatInstanceTypes(instancetypes.AzureSNPInstanceTypes),
 		formatInstanceTypes(instancetypes.AzureTrustedLaunchInstanceTypes),
 		formatInstanceTypes(instancetypes.GCPInstanceTypes),
+		formatInstanceTypes(instancetypes.GCPTDXInstanceTypes),
 		formatInstanceTypes(instancetypes.STACKITInstanceTypes),
 	)
 }
@@ -269,6 +269,7 @@ func TestCreate(t *testing.T) {
 func TestCheckDirClean(t *testing.T) {
 	testCases := map[string]struct {
 		existingFiles []string
+		restore       bool
 		wantErr       bool
 	}{
 		"no file exists": {},
@@ -284,6 +285,15 @@ func TestCheckDirClean(t *testing.T) {
 			existingFiles: []string{constants.AdminConfFilename, constants.MasterSecretFilename},
 			wantErr:       true,
 		},
+		"master secret exists on restore": {
+			existingFiles: []string{constants.MasterSecretFilename},
+			restore:       true,
+		},
+		"adminconf exists on restore": {
+			existingFiles: []string{constants.AdminConfFilename, constants.MasterSecretFilename},
+			restore:       true,
+			wantErr:       true,
+		},
 	}
 
 	for name, tc := range testCases {
@@ -296,6 +306,9 @@ func TestCheckDirClean(t *testing.T) {
 				require.NoError(fh.Write(f, []byte{1, 2, 3}, file.OptNone))
 			}
 			a := &applyCmd{log: logger.NewTest(t), fileHandler: fh}
+			if tc.restore {
+				a.restore = &clusterRestore{}
+			}
 			err := a.checkInitFilesClean()
 
 			if tc.wantErr {
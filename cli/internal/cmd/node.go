@@ -0,0 +1,26 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// NewNodeCmd creates a new node parent command. Node needs another
+// verb, and does nothing on its own.
+func NewNodeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "node",
+		Short: "Manage the nodes of a Constellation cluster",
+		Long:  "Manage the nodes of a Constellation cluster.",
+		Args:  cobra.ExactArgs(0),
+	}
+
+	cmd.AddCommand(newNodeRevokeCmd())
+
+	return cmd
+}
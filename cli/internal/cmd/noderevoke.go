@@ -0,0 +1,174 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/edgelesssys/constellation/v2/internal/constants"
+	"github.com/edgelesssys/constellation/v2/internal/constellation/kubecmd"
+	"github.com/edgelesssys/constellation/v2/internal/denylist"
+	"github.com/edgelesssys/constellation/v2/internal/file"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+func newNodeRevokeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "revoke <node-name>",
+		Short: "Revoke a node and remove it from the cluster",
+		Long: "Revoke a node and remove it from the cluster.\n\n" +
+			"The node and the UUID of its state disk are added to the deny-list of the JoinService, " +
+			"which then refuses to issue keys to the node, so it can neither join nor rejoin the cluster. " +
+			"If the node is part of the cluster, it's drained, removed from the cluster, and its instance is deleted.\n\n" +
+			"Nodes that already left the cluster can be revoked by passing the UUID of their state disk or the ID of their attestation report.\n\n" +
+			"Revoking node names and state disk UUIDs is best-effort: nodes report them themselves and they aren't attested, " +
+			"so a compromised node that is still running can evade the revocation by presenting a different name or disk UUID. " +
+			"Only IDs of SEV-SNP attestation reports are attested and reliably identify a node.",
+		Args: cobra.ExactArgs(1),
+		RunE: runNodeRevoke,
+	}
+	cmd.Flags().BoolP("yes", "y", false, "revoke the node without further confirmation")
+	cmd.Flags().StringSlice("disk-uuid", nil, "additional state disk UUIDs to revoke")
+	cmd.Flags().StringSlice("report-id", nil, "hex encoded IDs of SEV-SNP attestation reports to revoke")
+	return cmd
+}
+
+type nodeRevokeFlags struct {
+	rootFlags
+	yes       bool
+	diskUUIDs []string
+	reportIDs []string
+}
+
+func (f *nodeRevokeFlags) parse(flags *pflag.FlagSet) error {
+	if err := f.rootFlags.parse(flags); err != nil {
+		return err
+	}
+
+	yes, err := flags.GetBool("yes")
+	if err != nil {
+		return fmt.Errorf("getting 'yes' flag: %w", err)
+	}
+	f.yes = yes
+
+	diskUUIDs, err := flags.GetStringSlice("disk-uuid")
+	if err != nil {
+		return fmt.Errorf("getting 'disk-uuid' flag: %w", err)
+	}
+	f.diskUUIDs = diskUUIDs
+
+	reportIDs, err := flags.GetStringSlice("report-id")
+	if err != nil {
+		return fmt.Errorf("getting 'report-id' flag: %w", err)
+	}
+	f.reportIDs = reportIDs
+	return nil
+}
+
+// runNodeRevoke runs the node revoke command.
+func runNodeRevoke(cmd *cobra.Command, args []string) error {
+	log, err := newCLILogger(cmd)
+	if err != nil {
+		return fmt.Errorf("creating logger: %w", err)
+	}
+	spinner, err := newSpinnerOrStderr(cmd)
+	if err != nil {
+		return fmt.Errorf("creating spinner: %w", err)
+	}
+	defer spinner.Stop()
+
+	fileHandler := file.NewHandler(afero.NewOsFs())
+	kubeConfig, err := fileHandler.Read(constants.AdminConfFilename)
+	if err != nil {
+		return fmt.Errorf("reading kubeconfig: %w", err)
+	}
+	kubeClient, err := kubecmd.New(kubeConfig, log)
+	if err != nil {
+		return fmt.Errorf("setting up kubernetes client: %w", err)
+	}
+
+	r := &nodeRevokeCmd{
+		log:     log,
+		spinner: spinner,
+	}
+	if err := r.flags.parse(cmd.Flags()); err != nil {
+		return err
+	}
+	r.log.Debug("Using flags", "yes", r.flags.yes, "diskUUIDs", r.flags.diskUUIDs, "reportIDs", r.flags.reportIDs)
+	return r.revoke(cmd, args[0], kubeClient)
+}
+
+type nodeRevokeCmd struct {
+	log     debugLog
+	spinner spinnerInterf
+	flags   nodeRevokeFlags
+}
+
+// revoke adds the node to the deny-list of the JoinService and removes it from the cluster.
+func (r *nodeRevokeCmd) revoke(cmd *cobra.Command, nodeName string, kubeClient nodeRevoker) error {
+	if !r.flags.yes {
+		cmd.Printf("You are about to revoke node %q.\n", nodeName)
+		cmd.Println("The node will be removed from the cluster and can't join the cluster again.")
+		ok, err := askToConfirm(cmd, "Do you want to continue?")
+		if err != nil {
+			return err
+		}
+		if !ok {
+			cmd.Println("The revocation was aborted.")
+			return nil
+		}
+	}
+
+	r.spinner.Start("Revoking node", false)
+	revoked, err := kubeClient.RevokeNode(cmd.Context(), nodeName, denylist.DenyList{
+		DiskUUIDs: r.flags.diskUUIDs,
+		ReportIDs: r.flags.reportIDs,
+	})
+	r.spinner.Stop()
+	if errors.Is(err, kubecmd.ErrLastControlPlane) {
+		return fmt.Errorf("revoking node %s: %w, add another control-plane node first", nodeName, err)
+	}
+	if !revoked.DenyList.IsEmpty() {
+		cmd.Println("Added to the deny-list of the JoinService:")
+		printDenyListEntries(cmd, "Node", revoked.DenyList.NodeNames)
+		printDenyListEntries(cmd, "State disk", revoked.DenyList.DiskUUIDs)
+		printDenyListEntries(cmd, "Attestation report", revoked.DenyList.ReportIDs)
+	}
+	if err != nil {
+		return fmt.Errorf("revoking node %s: %w", nodeName, err)
+	}
+	if len(revoked.DenyList.ReportIDs) == 0 {
+		cmd.Println("Warning: node names and state disk UUIDs aren't attested, a compromised node can evade their revocation. " +
+			"On SEV-SNP, also revoke the ID of the node's attestation report with --report-id.")
+	}
+
+	if !revoked.Evicted {
+		cmd.Printf("Node %q isn't part of the cluster, it was only added to the deny-list.\n", nodeName)
+		return nil
+	}
+	if len(revoked.DenyList.DiskUUIDs) == 0 {
+		cmd.Println("Warning: the UUID of the node's state disk is unknown. The node can rejoin the cluster after a reboot until it's removed.")
+	}
+	cmd.Printf("Node %q was revoked and is removed from the cluster by the constellation-operator.\n", nodeName)
+	cmd.Println("The node's instance is deleted and its scaling group shrinks by one node.")
+	return nil
+}
+
+func printDenyListEntries(cmd *cobra.Command, kind string, entries []string) {
+	if len(entries) > 0 {
+		cmd.Printf("  %s: %s\n", kind, strings.Join(entries, ", "))
+	}
+}
+
+type nodeRevoker interface {
+	RevokeNode(ctx context.Context, nodeName string, extra denylist.DenyList) (kubecmd.RevokedNode, error)
+}
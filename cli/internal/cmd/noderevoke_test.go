@@ -0,0 +1,151 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/edgelesssys/constellation/v2/internal/constellation/kubecmd"
+	"github.com/edgelesssys/constellation/v2/internal/denylist"
+	"github.com/edgelesssys/constellation/v2/internal/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNodeRevoke(t *testing.T) {
+	testCases := map[string]struct {
+		kubeClient  *stubNodeRevoker
+		yes         bool
+		stdin       string
+		diskUUIDs   []string
+		reportIDs   []string
+		wantRevoke  bool
+		wantErr     bool
+		wantOutput  string
+		wantExtraDL denylist.DenyList
+	}{
+		"revoke node": {
+			kubeClient: &stubNodeRevoker{revoked: kubecmd.RevokedNode{
+				DenyList: denylist.DenyList{NodeNames: []string{"worker-0"}, DiskUUIDs: []string{"uuid"}},
+				Evicted:  true,
+			}},
+			yes:        true,
+			wantRevoke: true,
+			wantOutput: "is removed from the cluster",
+		},
+		"interactive": {
+			kubeClient: &stubNodeRevoker{revoked: kubecmd.RevokedNode{
+				DenyList: denylist.DenyList{NodeNames: []string{"worker-0"}, DiskUUIDs: []string{"uuid"}},
+				Evicted:  true,
+			}},
+			stdin:      "y\n",
+			wantRevoke: true,
+		},
+		"interactive abort": {
+			kubeClient: &stubNodeRevoker{},
+			stdin:      "n\n",
+		},
+		"additional entries": {
+			kubeClient: &stubNodeRevoker{revoked: kubecmd.RevokedNode{
+				DenyList: denylist.DenyList{NodeNames: []string{"worker-0"}, DiskUUIDs: []string{"uuid"}, ReportIDs: []string{"abcdef"}},
+			}},
+			yes:         true,
+			diskUUIDs:   []string{"uuid"},
+			reportIDs:   []string{"abcdef"},
+			wantRevoke:  true,
+			wantOutput:  "isn't part of the cluster",
+			wantExtraDL: denylist.DenyList{DiskUUIDs: []string{"uuid"}, ReportIDs: []string{"abcdef"}},
+		},
+		"unknown disk UUID": {
+			kubeClient: &stubNodeRevoker{revoked: kubecmd.RevokedNode{
+				DenyList: denylist.DenyList{NodeNames: []string{"worker-0"}},
+				Evicted:  true,
+			}},
+			yes:        true,
+			wantRevoke: true,
+			wantOutput: "the UUID of the node's state disk is unknown",
+		},
+		"no attestation report ID": {
+			kubeClient: &stubNodeRevoker{revoked: kubecmd.RevokedNode{
+				DenyList: denylist.DenyList{DiskUUIDs: []string{"uuid"}, NodeNames: []string{"worker-0"}},
+				Evicted:  true,
+			}},
+			yes:        true,
+			wantRevoke: true,
+			wantOutput: "aren't attested",
+		},
+		"last control-plane node": {
+			kubeClient: &stubNodeRevoker{err: kubecmd.ErrLastControlPlane},
+			yes:        true,
+			wantRevoke: true,
+			wantErr:    true,
+		},
+		"eviction fails": {
+			kubeClient: &stubNodeRevoker{
+				revoked: kubecmd.RevokedNode{DenyList: denylist.DenyList{NodeNames: []string{"worker-0"}}},
+				err:     errors.New("failed"),
+			},
+			yes:        true,
+			wantRevoke: true,
+			wantErr:    true,
+			wantOutput: "Added to the deny-list",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			cmd := newNodeRevokeCmd()
+			out := &bytes.Buffer{}
+			cmd.SetOut(out)
+			cmd.SetErr(&bytes.Buffer{})
+			cmd.SetIn(bytes.NewBufferString(tc.stdin))
+			cmd.SetContext(t.Context())
+
+			r := &nodeRevokeCmd{
+				log:     logger.NewTest(t),
+				spinner: &nopSpinner{},
+				flags: nodeRevokeFlags{
+					yes:       tc.yes,
+					diskUUIDs: tc.diskUUIDs,
+					reportIDs: tc.reportIDs,
+				},
+			}
+
+			err := r.revoke(cmd, "worker-0", tc.kubeClient)
+			if tc.wantErr {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+			}
+			assert.Equal(tc.wantRevoke, tc.kubeClient.called)
+			if tc.wantRevoke {
+				assert.Equal("worker-0", tc.kubeClient.nodeName)
+				assert.Equal(tc.wantExtraDL, tc.kubeClient.extra)
+			}
+			assert.Contains(out.String(), tc.wantOutput)
+		})
+	}
+}
+
+type stubNodeRevoker struct {
+	revoked  kubecmd.RevokedNode
+	err      error
+	called   bool
+	nodeName string
+	extra    denylist.DenyList
+}
+
+func (s *stubNodeRevoker) RevokeNode(_ context.Context, nodeName string, extra denylist.DenyList) (kubecmd.RevokedNode, error) {
+	s.called = true
+	s.nodeName = nodeName
+	s.extra = extra
+	return s.revoked, s.err
+}
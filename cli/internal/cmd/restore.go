@@ -0,0 +1,209 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/edgelesssys/constellation/v2/internal/api/attestationconfigapi"
+	"github.com/edgelesssys/constellation/v2/internal/constants"
+	"github.com/edgelesssys/constellation/v2/internal/etcdbackup"
+	"github.com/edgelesssys/constellation/v2/internal/kms/uri"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// NewRestoreCmd returns a new cobra.Command for the restore command.
+func NewRestoreCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "restore",
+		Short: "Restore a Constellation cluster from an etcd snapshot",
+		Long: "Restore a Constellation cluster from an encrypted etcd snapshot.\n\n" +
+			"New infrastructure is created and the first control-plane node is bootstrapped from the snapshot, " +
+			"using the master secret of the previous cluster. Worker nodes join the restored cluster automatically.\n" +
+			"This is only required if all control-plane nodes of a cluster are lost. " +
+			"Otherwise, use 'constellation recover'.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Define flags for apply backend that are not set by restore
+			cmd.Flags().StringSlice("skip-phases", nil, "")
+			cmd.Flags().Duration("helm-timeout", 10*time.Minute, "")
+			return runRestore(cmd, args)
+		},
+	}
+	cmd.Flags().String("snapshot", "", "path to an encrypted etcd snapshot")
+	cmd.Flags().String("storage-uri", "", "storage URI of the etcd backups, the latest snapshot is restored")
+	cmd.Flags().String("prefix", "", "prefix of the etcd snapshots in the storage")
+	cmd.Flags().Bool("conformance", false, "enable conformance mode")
+	cmd.Flags().Bool("skip-helm-wait", false, "install helm charts without waiting for deployments to be ready")
+	cmd.Flags().Bool("merge-kubeconfig", false, "merge Constellation kubeconfig file with default kubeconfig file in $HOME/.kube/config")
+	cmd.Flags().BoolP("yes", "y", false, "restore the cluster without further confirmation")
+	cmd.MarkFlagsMutuallyExclusive("snapshot", "storage-uri")
+	cmd.MarkFlagsOneRequired("snapshot", "storage-uri")
+	return cmd
+}
+
+// restoreFlags defines the flags selecting the snapshot to restore.
+type restoreFlags struct {
+	snapshotPath string
+	storageURI   string
+	prefix       string
+}
+
+// parse the restore command flags.
+func (f *restoreFlags) parse(flags *pflag.FlagSet) error {
+	var err error
+	f.snapshotPath, err = flags.GetString("snapshot")
+	if err != nil {
+		return fmt.Errorf("getting 'snapshot' flag: %w", err)
+	}
+	f.storageURI, err = flags.GetString("storage-uri")
+	if err != nil {
+		return fmt.Errorf("getting 'storage-uri' flag: %w", err)
+	}
+	f.prefix, err = flags.GetString("prefix")
+	if err != nil {
+		return fmt.Errorf("getting 'prefix' flag: %w", err)
+	}
+	return nil
+}
+
+// clusterRestore holds the values required to restore a cluster from an etcd snapshot.
+type clusterRestore struct {
+	// masterSecret is the master secret of the previous cluster.
+	masterSecret uri.MasterSecret
+	// openEtcdSnapshot opens a stream of the decrypted etcd snapshot.
+	openEtcdSnapshot func() (io.ReadCloser, error)
+}
+
+type restoreCmd struct {
+	apply      *applyCmd
+	flags      restoreFlags
+	newStorage func(ctx context.Context, storageURI string) (etcdbackup.Storage, error)
+}
+
+// runRestore sets up the restore command and runs it.
+func runRestore(cmd *cobra.Command, _ []string) error {
+	flags := restoreFlags{}
+	if err := flags.parse(cmd.Flags()); err != nil {
+		return err
+	}
+
+	apply, upgradeDir, err := newApplyCmd(cmd)
+	if err != nil {
+		return err
+	}
+	defer apply.spinner.Stop()
+
+	ctx, cancel := context.WithTimeout(cmd.Context(), time.Hour)
+	defer cancel()
+	cmd.SetContext(ctx)
+
+	r := &restoreCmd{
+		apply:      apply,
+		flags:      flags,
+		newStorage: etcdbackup.NewStorage,
+	}
+	return r.restore(cmd, attestationconfigapi.NewFetcher(), upgradeDir)
+}
+
+// restore creates a new cluster from the etcd snapshot.
+// The snapshot is streamed and decrypted while it is uploaded to the bootstrapper, so it is never held in memory.
+// Its first segment is decrypted before any infrastructure is created, so a wrong master secret or snapshot fails early.
+func (r *restoreCmd) restore(cmd *cobra.Command, configFetcher attestationconfigapi.Fetcher, upgradeDir string) error {
+	var masterSecret uri.MasterSecret
+	if err := r.apply.fileHandler.ReadJSON(constants.MasterSecretFilename, &masterSecret); err != nil {
+		return fmt.Errorf(
+			"reading master secret %q, the master secret of the previous cluster is required to restore it: %w",
+			r.apply.flags.pathPrefixer.PrefixPrintablePath(constants.MasterSecretFilename), err,
+		)
+	}
+
+	openEncrypted, err := r.selectSnapshot(cmd)
+	if err != nil {
+		return err
+	}
+
+	key, err := etcdbackup.DeriveKey(masterSecret)
+	if err != nil {
+		return fmt.Errorf("deriving snapshot encryption key: %w", err)
+	}
+	openSnapshot := func() (io.ReadCloser, error) {
+		encrypted, err := openEncrypted()
+		if err != nil {
+			return nil, err
+		}
+		decrypter, err := etcdbackup.NewDecrypter(key, encrypted)
+		if err != nil {
+			encrypted.Close()
+			return nil, fmt.Errorf("decrypting etcd snapshot, make sure the snapshot belongs to the cluster of the master secret: %w", err)
+		}
+		return &snapshotReader{Reader: decrypter, Closer: encrypted}, nil
+	}
+
+	// Decrypting the first segment authenticates it with the key derived from the master secret.
+	snapshot, err := openSnapshot()
+	if err != nil {
+		return err
+	}
+	_, err = io.ReadFull(snapshot, make([]byte, 1))
+	snapshot.Close()
+	if err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("decrypting etcd snapshot, make sure the snapshot belongs to the cluster of the master secret: %w", err)
+	}
+
+	r.apply.restore = &clusterRestore{
+		masterSecret:     masterSecret,
+		openEtcdSnapshot: openSnapshot,
+	}
+	return r.apply.apply(cmd, configFetcher, upgradeDir)
+}
+
+// selectSnapshot returns a function opening the encrypted etcd snapshot selected by the flags.
+func (r *restoreCmd) selectSnapshot(cmd *cobra.Command) (func() (io.ReadCloser, error), error) {
+	if r.flags.snapshotPath != "" {
+		r.apply.log.Debug(fmt.Sprintf("Reading etcd snapshot from %q", r.flags.snapshotPath))
+		return func() (io.ReadCloser, error) {
+			snapshot, err := r.apply.fileHandler.Open(r.flags.snapshotPath)
+			if err != nil {
+				return nil, fmt.Errorf("reading etcd snapshot: %w", err)
+			}
+			return snapshot, nil
+		}, nil
+	}
+
+	store, err := r.newStorage(cmd.Context(), r.flags.storageURI)
+	if err != nil {
+		return nil, fmt.Errorf("creating snapshot storage: %w", err)
+	}
+	names, err := etcdbackup.ListSnapshots(cmd.Context(), store, r.flags.prefix)
+	if err != nil {
+		return nil, fmt.Errorf("listing etcd snapshots: %w", err)
+	}
+	if len(names) == 0 {
+		return nil, errors.New("no etcd snapshots found in storage")
+	}
+	latest := names[len(names)-1]
+	cmd.Printf("Restoring etcd snapshot %q\n", latest)
+	return func() (io.ReadCloser, error) {
+		snapshot, err := store.Get(cmd.Context(), latest)
+		if err != nil {
+			return nil, fmt.Errorf("getting etcd snapshot %q: %w", latest, err)
+		}
+		return snapshot, nil
+	}, nil
+}
+
+// snapshotReader reads a decrypted etcd snapshot and closes the underlying encrypted snapshot.
+type snapshotReader struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,225 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/edgelesssys/constellation/v2/internal/cloud/cloudprovider"
+	"github.com/edgelesssys/constellation/v2/internal/config"
+	"github.com/edgelesssys/constellation/v2/internal/constants"
+	"github.com/edgelesssys/constellation/v2/internal/constellation"
+	"github.com/edgelesssys/constellation/v2/internal/constellation/state"
+	"github.com/edgelesssys/constellation/v2/internal/etcdbackup"
+	"github.com/edgelesssys/constellation/v2/internal/etcdbackup/storage/localfs"
+	"github.com/edgelesssys/constellation/v2/internal/file"
+	"github.com/edgelesssys/constellation/v2/internal/kms/uri"
+	"github.com/edgelesssys/constellation/v2/internal/logger"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/tools/clientcmd"
+	k8sclientapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+func TestRestore(t *testing.T) {
+	masterSecret := uri.MasterSecret{
+		Key:  bytes.Repeat([]byte{0x01}, 32),
+		Salt: bytes.Repeat([]byte{0x02}, 32),
+	}
+	otherMasterSecret := uri.MasterSecret{
+		Key:  bytes.Repeat([]byte{0x03}, 32),
+		Salt: bytes.Repeat([]byte{0x04}, 32),
+	}
+	encrypt := func(ms uri.MasterSecret, snapshot []byte) []byte {
+		key, err := etcdbackup.DeriveKey(ms)
+		require.NoError(t, err)
+		encrypter, err := etcdbackup.NewEncrypter(key, bytes.NewReader(snapshot))
+		require.NoError(t, err)
+		encrypted, err := io.ReadAll(encrypter)
+		require.NoError(t, err)
+		return encrypted
+	}
+	snapshotPath := "/backups/snapshot.db.enc"
+	oldSnapshot := etcdbackup.SnapshotName("cluster", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	latestSnapshot := etcdbackup.SnapshotName("cluster", time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC))
+
+	kubeconfig, err := clientcmd.Write(k8sclientapi.Config{
+		Clusters: map[string]*k8sclientapi.Cluster{
+			"cluster": {Server: "https://192.0.2.1:6443"},
+		},
+	})
+	require.NoError(t, err)
+
+	testCases := map[string]struct {
+		flags          restoreFlags
+		masterSecret   *uri.MasterSecret
+		snapshotFile   []byte
+		storedSnapshot map[string][]byte
+		existingFiles  []string
+		configMutator  func(*config.Config)
+		wantSnapshot   []byte
+		wantErr        bool
+	}{
+		"restore snapshot from file": {
+			flags:        restoreFlags{snapshotPath: snapshotPath},
+			masterSecret: &masterSecret,
+			snapshotFile: encrypt(masterSecret, []byte("snapshot")),
+			wantSnapshot: []byte("snapshot"),
+		},
+		"restore latest snapshot from storage": {
+			flags:        restoreFlags{storageURI: "storage://local", prefix: "cluster"},
+			masterSecret: &masterSecret,
+			storedSnapshot: map[string][]byte{
+				oldSnapshot:    encrypt(masterSecret, []byte("old snapshot")),
+				latestSnapshot: encrypt(masterSecret, []byte("latest snapshot")),
+			},
+			wantSnapshot: []byte("latest snapshot"),
+		},
+		"no snapshots in storage": {
+			flags:        restoreFlags{storageURI: "storage://local", prefix: "other-cluster"},
+			masterSecret: &masterSecret,
+			storedSnapshot: map[string][]byte{
+				latestSnapshot: encrypt(masterSecret, []byte("latest snapshot")),
+			},
+			wantErr: true,
+		},
+		"snapshot file does not exist": {
+			flags:        restoreFlags{snapshotPath: snapshotPath},
+			masterSecret: &masterSecret,
+			wantErr:      true,
+		},
+		"master secret does not exist": {
+			flags:        restoreFlags{snapshotPath: snapshotPath},
+			snapshotFile: encrypt(masterSecret, []byte("snapshot")),
+			wantErr:      true,
+		},
+		"snapshot of another cluster": {
+			flags:        restoreFlags{snapshotPath: snapshotPath},
+			masterSecret: &masterSecret,
+			snapshotFile: encrypt(otherMasterSecret, []byte("snapshot")),
+			wantErr:      true,
+		},
+		"workspace of existing cluster": {
+			flags:         restoreFlags{snapshotPath: snapshotPath},
+			masterSecret:  &masterSecret,
+			snapshotFile:  encrypt(masterSecret, []byte("snapshot")),
+			existingFiles: []string{constants.AdminConfFilename},
+			wantErr:       true,
+		},
+		"external KMS": {
+			flags:        restoreFlags{snapshotPath: snapshotPath},
+			masterSecret: &masterSecret,
+			snapshotFile: encrypt(masterSecret, []byte("snapshot")),
+			configMutator: func(c *config.Config) {
+				c.KMS = &config.KMSConfig{KMIP: &config.KMIPConfig{
+					Endpoint:       "192.0.2.2:5696",
+					KeyName:        "key",
+					CACertPath:     "ca.pem",
+					ClientCertPath: "client.pem",
+					ClientKeyPath:  "client-key.pem",
+					StorageURI:     "storage://no-store",
+				}}
+			},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			cmd := NewRestoreCmd()
+			cmd.SetOut(&bytes.Buffer{})
+			cmd.SetErr(&bytes.Buffer{})
+			cmd.SetContext(t.Context())
+
+			fs := afero.NewMemMapFs()
+			fileHandler := file.NewHandler(fs)
+			conf := defaultConfigWithExpectedMeasurements(t, config.Default(), cloudprovider.GCP)
+			if tc.configMutator != nil {
+				tc.configMutator(conf)
+			}
+			require.NoError(fileHandler.WriteYAML(constants.ConfigFilename, conf))
+			if tc.masterSecret != nil {
+				require.NoError(fileHandler.WriteJSON(constants.MasterSecretFilename, tc.masterSecret))
+			}
+			if tc.snapshotFile != nil {
+				require.NoError(fileHandler.Write(snapshotPath, tc.snapshotFile, file.OptMkdirAll))
+			}
+			for _, f := range tc.existingFiles {
+				require.NoError(fileHandler.Write(f, []byte{1, 2, 3}))
+			}
+			store := localfs.New(afero.NewMemMapFs(), "/")
+			for name, snapshot := range tc.storedSnapshot {
+				require.NoError(store.Put(t.Context(), name, bytes.NewReader(snapshot)))
+			}
+
+			creator := &stubCloudCreator{
+				state:            state.Infrastructure{ClusterEndpoint: "192.0.2.1"},
+				planDiff:         true,
+				workspaceIsEmpty: true,
+			}
+			applier := &stubConstellApplier{
+				measurementSalt: bytes.Repeat([]byte{0x05}, 32),
+				initOutput: constellation.InitOutput{
+					Kubeconfig: kubeconfig,
+					ClusterID:  "clusterID",
+				},
+			}
+			r := &restoreCmd{
+				apply: &applyCmd{
+					fileHandler: fileHandler,
+					flags: applyFlags{
+						rootFlags:  rootFlags{force: true},
+						yes:        true,
+						skipPhases: newPhases(skipAttestationConfigPhase, skipCertSANsPhase, skipHelmPhase),
+					},
+					log:     logger.NewTest(t),
+					spinner: &nopSpinner{},
+					merger:  &stubMerger{},
+					newInfraApplier: func(_ context.Context) (cloudApplier, func(), error) {
+						return creator, func() {}, nil
+					},
+					applier: applier,
+				},
+				flags: tc.flags,
+				newStorage: func(_ context.Context, _ string) (etcdbackup.Storage, error) {
+					return store, nil
+				},
+			}
+
+			err := r.restore(cmd, stubAttestationFetcher{}, "restore")
+			if tc.wantErr {
+				assert.Error(err)
+				return
+			}
+			require.NoError(err)
+
+			assert.True(creator.applyCalled)
+			require.NotNil(applier.initPayload.OpenEtcdSnapshot)
+			snapshot, err := applier.initPayload.OpenEtcdSnapshot()
+			require.NoError(err)
+			gotSnapshot, err := io.ReadAll(snapshot)
+			require.NoError(err)
+			require.NoError(snapshot.Close())
+			assert.Equal(tc.wantSnapshot, gotSnapshot)
+			assert.Equal(*tc.masterSecret, applier.initPayload.MasterSecret)
+
+			var gotMasterSecret uri.MasterSecret
+			require.NoError(fileHandler.ReadJSON(constants.MasterSecretFilename, &gotMasterSecret))
+			assert.Equal(*tc.masterSecret, gotMasterSecret)
+			_, err = fileHandler.Stat(constants.AdminConfFilename)
+			assert.NoError(err)
+		})
+	}
+}
@@ -0,0 +1,289 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package cmd
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"time"
+
+	"github.com/edgelesssys/constellation/v2/internal/constants"
+	"github.com/edgelesssys/constellation/v2/internal/constellation/kubecmd"
+	"github.com/edgelesssys/constellation/v2/internal/crypto"
+	"github.com/edgelesssys/constellation/v2/internal/file"
+	"github.com/edgelesssys/constellation/v2/internal/kms/uri"
+	updatev1alpha1 "github.com/edgelesssys/constellation/v2/operators/constellation-node-operator/api/v1alpha1"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// NewRotateKeysCmd returns a new cobra.Command for the rotate-keys command.
+func NewRotateKeysCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rotate-keys",
+		Short: "Rotate the master secret or KEK of a Constellation cluster",
+		Long: "Rotate the master secret of a Constellation cluster and re-key the state disks of all nodes.\n\n" +
+			"If the cluster uses an external KMS, rotate the key encryption key in the KMS first. " +
+			"The state disk keys of all nodes are then re-wrapped with the current version of the key.\n\n" +
+			"An interrupted rotation is resumed by running the command again.\n\n" +
+			"Keys derived from the master secret for persistent volumes, the s3proxy, etcd snapshots and other workloads aren't re-wrapped. " +
+			"The rotation is refused while such resources are found, unless --force is set. " +
+			"Data encrypted with these keys, such as etcd snapshots created before the rotation, can only be decrypted using the previous master secret.",
+		Args: cobra.NoArgs,
+		RunE: runRotateKeys,
+	}
+	cmd.Flags().BoolP("yes", "y", false, "rotate the keys without further confirmation")
+	cmd.Flags().Duration("timeout", time.Hour, "maximum time to wait for the state disks of all nodes to be re-keyed")
+	return cmd
+}
+
+type rotateKeysFlags struct {
+	rootFlags
+	yes     bool
+	timeout time.Duration
+}
+
+func (f *rotateKeysFlags) parse(flags *pflag.FlagSet) error {
+	if err := f.rootFlags.parse(flags); err != nil {
+		return err
+	}
+
+	yes, err := flags.GetBool("yes")
+	if err != nil {
+		return fmt.Errorf("getting 'yes' flag: %w", err)
+	}
+	f.yes = yes
+
+	timeout, err := flags.GetDuration("timeout")
+	if err != nil {
+		return fmt.Errorf("getting 'timeout' flag: %w", err)
+	}
+	f.timeout = timeout
+	return nil
+}
+
+// runRotateKeys runs the rotate-keys command.
+func runRotateKeys(cmd *cobra.Command, _ []string) error {
+	log, err := newCLILogger(cmd)
+	if err != nil {
+		return fmt.Errorf("creating logger: %w", err)
+	}
+	spinner, err := newSpinnerOrStderr(cmd)
+	if err != nil {
+		return fmt.Errorf("creating spinner: %w", err)
+	}
+	defer spinner.Stop()
+
+	fileHandler := file.NewHandler(afero.NewOsFs())
+	kubeConfig, err := fileHandler.Read(constants.AdminConfFilename)
+	if err != nil {
+		return fmt.Errorf("reading kubeconfig: %w", err)
+	}
+	kubeClient, err := kubecmd.New(kubeConfig, log)
+	if err != nil {
+		return fmt.Errorf("setting up kubernetes client: %w", err)
+	}
+
+	r := &rotateKeysCmd{
+		log:             log,
+		fileHandler:     fileHandler,
+		spinner:         spinner,
+		newMasterSecret: generateMasterSecret,
+		interval:        10 * time.Second,
+	}
+	if err := r.flags.parse(cmd.Flags()); err != nil {
+		return err
+	}
+	r.log.Debug("Using flags", "yes", r.flags.yes, "timeout", r.flags.timeout, "force", r.flags.force)
+	return r.rotateKeys(cmd, kubeClient)
+}
+
+type rotateKeysCmd struct {
+	log             debugLog
+	fileHandler     file.Handler
+	spinner         spinnerInterf
+	newMasterSecret func() (uri.MasterSecret, error)
+	interval        time.Duration
+	flags           rotateKeysFlags
+}
+
+// rotateKeys rotates the master secret of the cluster, or re-wraps the keys of an external KMS,
+// and waits until the state disks of all nodes were re-keyed.
+func (r *rotateKeysCmd) rotateKeys(cmd *cobra.Command, kubeClient keyRotator) error {
+	ctx, cancel := context.WithTimeout(cmd.Context(), r.flags.timeout)
+	defer cancel()
+
+	keyRotation, err := kubeClient.GetKeyRotation(ctx)
+	if err != nil {
+		return err
+	}
+	if keyRotation != nil && keyRotation.Status.Phase != updatev1alpha1.KeyRotationPhaseCompleted {
+		cmd.Printf("Resuming key rotation %s.\n", keyRotation.Spec.RotationID)
+		return r.waitForRotation(ctx, cmd, kubeClient)
+	}
+
+	externalKMS, err := kubeClient.UsesExternalKMS(ctx)
+	if err != nil {
+		return err
+	}
+	if externalKMS {
+		if !r.flags.yes {
+			cmd.Println("The cluster uses an external KMS.")
+			cmd.Println("Make sure you rotated the key encryption key in your KMS before continuing.")
+			ok, err := askToConfirm(cmd, "Do you want to re-wrap the state disk keys of all nodes?")
+			if err != nil {
+				return err
+			}
+			if !ok {
+				cmd.Println("The key rotation was aborted.")
+				return nil
+			}
+		}
+	} else {
+		previous, next, ok, err := r.prepareMasterSecrets(ctx, cmd, kubeClient)
+		if err != nil || !ok {
+			return err
+		}
+		r.spinner.Start("Replacing master secret", false)
+		err = kubeClient.RotateMasterSecret(ctx, previous, next)
+		r.spinner.Stop()
+		if err != nil {
+			return fmt.Errorf("replacing master secret: %w", err)
+		}
+	}
+
+	rotationID, err := crypto.GenerateRandomBytes(8)
+	if err != nil {
+		return fmt.Errorf("generating rotation ID: %w", err)
+	}
+	if err := kubeClient.StartKeyRotation(ctx, hex.EncodeToString(rotationID)); err != nil {
+		return fmt.Errorf("starting key rotation: %w", err)
+	}
+	return r.waitForRotation(ctx, cmd, kubeClient)
+}
+
+// prepareMasterSecrets returns the previous and the new master secret of the cluster.
+// A new master secret is generated and persisted next to the previous one, unless an interrupted rotation is resumed.
+func (r *rotateKeysCmd) prepareMasterSecrets(ctx context.Context, cmd *cobra.Command, kubeClient keyRotator) (previous, next uri.MasterSecret, ok bool, err error) {
+	var current uri.MasterSecret
+	if err := r.fileHandler.ReadJSON(constants.MasterSecretFilename, &current); err != nil {
+		return previous, next, false, fmt.Errorf("reading master secret from %q: %w", r.flags.pathPrefixer.PrefixPrintablePath(constants.MasterSecretFilename), err)
+	}
+
+	// the previous master secret is only persisted while a rotation is in progress
+	err = r.fileHandler.ReadJSON(constants.PreviousMasterSecretFilename, &previous)
+	switch {
+	case err == nil:
+		cmd.Printf("Resuming interrupted rotation of the master secret using %q.\n", r.flags.pathPrefixer.PrefixPrintablePath(constants.PreviousMasterSecretFilename))
+		return previous, current, true, nil
+	case !errors.Is(err, fs.ErrNotExist):
+		return previous, next, false, fmt.Errorf("reading previous master secret: %w", err)
+	}
+
+	consumers, err := kubeClient.ListMasterSecretConsumers(ctx)
+	if err != nil {
+		return previous, next, false, fmt.Errorf("listing resources using keys derived from the master secret: %w", err)
+	}
+	if len(consumers) > 0 && !r.flags.force {
+		return previous, next, false, fmt.Errorf(
+			"found %d resources using keys derived from the master secret, their data would become inaccessible: %v; use --force to rotate anyway",
+			len(consumers), consumers,
+		)
+	}
+	cmd.PrintErrln("Warning: Workload keys derived from the master secret by applications in the cluster change with the master secret.")
+	cmd.PrintErrln("Data encrypted with these keys, including etcd snapshots created before the rotation, can only be decrypted using the previous master secret.")
+	cmd.PrintErrln("Back up the previous master secret if you need to access such data.")
+
+	if !r.flags.yes {
+		cmd.Println("You are about to rotate the master secret of your Constellation cluster.")
+		cmd.Println("The state disks of all nodes are re-keyed with keys derived from a new master secret.")
+		cmd.Println("Nodes that reboot before their state disk is re-keyed can only be recovered using the previous master secret.")
+		ok, err := askToConfirm(cmd, "Do you want to continue?")
+		if err != nil {
+			return previous, next, false, err
+		}
+		if !ok {
+			cmd.Println("The key rotation was aborted.")
+			return previous, next, false, nil
+		}
+	}
+
+	next, err = r.newMasterSecret()
+	if err != nil {
+		return previous, next, false, fmt.Errorf("generating master secret: %w", err)
+	}
+	// the cluster ID is derived from the measurement secret, so it is pinned to its value before the rotation
+	next.MeasurementSecret, err = measurementSecret(current)
+	if err != nil {
+		return previous, next, false, fmt.Errorf("deriving measurement secret: %w", err)
+	}
+	if err := r.fileHandler.WriteJSON(constants.PreviousMasterSecretFilename, current, file.OptNone); err != nil {
+		return previous, next, false, fmt.Errorf("writing previous master secret: %w", err)
+	}
+	if err := r.fileHandler.WriteJSON(constants.MasterSecretFilename, next, file.OptOverwrite); err != nil {
+		return previous, next, false, fmt.Errorf("writing master secret: %w", err)
+	}
+	cmd.Printf("Your new master secret was written to %q. The previous master secret is kept in %q until the rotation is completed.\n",
+		r.flags.pathPrefixer.PrefixPrintablePath(constants.MasterSecretFilename), r.flags.pathPrefixer.PrefixPrintablePath(constants.PreviousMasterSecretFilename))
+	return current, next, true, nil
+}
+
+// waitForRotation waits until the state disks of all nodes were re-keyed and removes the previous master secret.
+func (r *rotateKeysCmd) waitForRotation(ctx context.Context, cmd *cobra.Command, kubeClient keyRotator) error {
+	r.spinner.Start("Re-keying state disks of all nodes", false)
+	err := kubeClient.WaitForKeyRotation(ctx, r.interval)
+	r.spinner.Stop()
+	if err != nil {
+		return fmt.Errorf("waiting for key rotation (run the command again to resume): %w", err)
+	}
+
+	if err := kubeClient.RemovePreviousMasterSecret(ctx); err != nil {
+		return fmt.Errorf("removing previous master secret from cluster: %w", err)
+	}
+	if err := r.fileHandler.Remove(constants.PreviousMasterSecretFilename); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("removing %q: %w", r.flags.pathPrefixer.PrefixPrintablePath(constants.PreviousMasterSecretFilename), err)
+	}
+
+	cmd.Println("The keys of your Constellation cluster were rotated successfully.")
+	return nil
+}
+
+// generateMasterSecret generates a new random master secret.
+func generateMasterSecret() (uri.MasterSecret, error) {
+	key, err := crypto.GenerateRandomBytes(crypto.MasterSecretLengthDefault)
+	if err != nil {
+		return uri.MasterSecret{}, err
+	}
+	salt, err := crypto.GenerateRandomBytes(crypto.RNGLengthDefault)
+	if err != nil {
+		return uri.MasterSecret{}, err
+	}
+	return uri.MasterSecret{Key: key, Salt: salt}, nil
+}
+
+// measurementSecret returns the measurement secret pinned in the given master secret,
+// or derives it from the master secret if none was pinned yet.
+func measurementSecret(masterSecret uri.MasterSecret) ([]byte, error) {
+	if len(masterSecret.MeasurementSecret) > 0 {
+		return masterSecret.MeasurementSecret, nil
+	}
+	return crypto.DeriveKey(masterSecret.Key, masterSecret.Salt, []byte(crypto.DEKPrefix+crypto.MeasurementSecretKeyID), crypto.DerivedKeyLengthDefault)
+}
+
+type keyRotator interface {
+	GetKeyRotation(ctx context.Context) (*updatev1alpha1.KeyRotation, error)
+	UsesExternalKMS(ctx context.Context) (bool, error)
+	ListMasterSecretConsumers(ctx context.Context) ([]string, error)
+	RotateMasterSecret(ctx context.Context, previous, next uri.MasterSecret) error
+	StartKeyRotation(ctx context.Context, rotationID string) error
+	WaitForKeyRotation(ctx context.Context, interval time.Duration) error
+	RemovePreviousMasterSecret(ctx context.Context) error
+}
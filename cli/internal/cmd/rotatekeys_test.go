@@ -0,0 +1,263 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/edgelesssys/constellation/v2/internal/constants"
+	"github.com/edgelesssys/constellation/v2/internal/crypto"
+	"github.com/edgelesssys/constellation/v2/internal/file"
+	"github.com/edgelesssys/constellation/v2/internal/kms/uri"
+	"github.com/edgelesssys/constellation/v2/internal/logger"
+	updatev1alpha1 "github.com/edgelesssys/constellation/v2/operators/constellation-node-operator/api/v1alpha1"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotateKeys(t *testing.T) {
+	current := uri.MasterSecret{Key: []byte("current-key"), Salt: []byte("current-salt"), MeasurementSecret: []byte("measurement-secret")}
+	// the measurement secret is carried over to the new master secret
+	next := uri.MasterSecret{Key: []byte("next-key"), Salt: []byte("next-salt"), MeasurementSecret: current.MeasurementSecret}
+	someErr := errors.New("failed")
+
+	withMasterSecret := func(require *require.Assertions) file.Handler {
+		fh := file.NewHandler(afero.NewMemMapFs())
+		require.NoError(fh.WriteJSON(constants.MasterSecretFilename, current))
+		return fh
+	}
+	withPreviousMasterSecret := func(require *require.Assertions) file.Handler {
+		fh := file.NewHandler(afero.NewMemMapFs())
+		require.NoError(fh.WriteJSON(constants.MasterSecretFilename, next))
+		require.NoError(fh.WriteJSON(constants.PreviousMasterSecretFilename, current))
+		return fh
+	}
+
+	testCases := map[string]struct {
+		setupFs            func(*require.Assertions) file.Handler
+		kubeClient         *stubKeyRotator
+		yes                bool
+		force              bool
+		stdin              string
+		wantRotateSecret   bool
+		wantStart          bool
+		wantWait           bool
+		wantNewMasterSaved bool
+		wantErr            bool
+	}{
+		"rotate master secret": {
+			setupFs:            withMasterSecret,
+			kubeClient:         &stubKeyRotator{},
+			yes:                true,
+			wantRotateSecret:   true,
+			wantStart:          true,
+			wantWait:           true,
+			wantNewMasterSaved: true,
+		},
+		"interactive": {
+			setupFs:            withMasterSecret,
+			kubeClient:         &stubKeyRotator{},
+			stdin:              "y\n",
+			wantRotateSecret:   true,
+			wantStart:          true,
+			wantWait:           true,
+			wantNewMasterSaved: true,
+		},
+		"interactive abort": {
+			setupFs:    withMasterSecret,
+			kubeClient: &stubKeyRotator{},
+			stdin:      "n\n",
+		},
+		"master secret consumers": {
+			setupFs:    withMasterSecret,
+			kubeClient: &stubKeyRotator{consumers: []string{"PersistentVolume pv"}},
+			yes:        true,
+			wantErr:    true,
+		},
+		"master secret consumers with force": {
+			setupFs:            withMasterSecret,
+			kubeClient:         &stubKeyRotator{consumers: []string{"PersistentVolume pv"}},
+			yes:                true,
+			force:              true,
+			wantRotateSecret:   true,
+			wantStart:          true,
+			wantWait:           true,
+			wantNewMasterSaved: true,
+		},
+		"resume interrupted master secret rotation": {
+			setupFs:            withPreviousMasterSecret,
+			kubeClient:         &stubKeyRotator{consumers: []string{"PersistentVolume pv"}},
+			wantRotateSecret:   true,
+			wantStart:          true,
+			wantWait:           true,
+			wantNewMasterSaved: true,
+		},
+		"resume rotation in progress": {
+			setupFs: withPreviousMasterSecret,
+			kubeClient: &stubKeyRotator{keyRotation: &updatev1alpha1.KeyRotation{
+				Status: updatev1alpha1.KeyRotationStatus{Phase: updatev1alpha1.KeyRotationPhaseInProgress},
+			}},
+			wantWait:           true,
+			wantNewMasterSaved: true,
+		},
+		"external KMS": {
+			setupFs:    withMasterSecret,
+			kubeClient: &stubKeyRotator{externalKMS: true},
+			yes:        true,
+			wantStart:  true,
+			wantWait:   true,
+		},
+		"replacing master secret fails": {
+			setupFs:            withMasterSecret,
+			kubeClient:         &stubKeyRotator{rotateErr: someErr},
+			yes:                true,
+			wantRotateSecret:   true,
+			wantNewMasterSaved: true,
+			wantErr:            true,
+		},
+		"waiting fails": {
+			setupFs:            withMasterSecret,
+			kubeClient:         &stubKeyRotator{waitErr: someErr},
+			yes:                true,
+			wantRotateSecret:   true,
+			wantStart:          true,
+			wantWait:           true,
+			wantNewMasterSaved: true,
+			wantErr:            true,
+		},
+		"missing master secret": {
+			setupFs: func(*require.Assertions) file.Handler {
+				return file.NewHandler(afero.NewMemMapFs())
+			},
+			kubeClient: &stubKeyRotator{},
+			yes:        true,
+			wantErr:    true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			cmd := NewRotateKeysCmd()
+			cmd.SetOut(&bytes.Buffer{})
+			cmd.SetErr(&bytes.Buffer{})
+			cmd.SetIn(bytes.NewBufferString(tc.stdin))
+			cmd.SetContext(t.Context())
+
+			fileHandler := tc.setupFs(require)
+			r := &rotateKeysCmd{
+				log:             logger.NewTest(t),
+				fileHandler:     fileHandler,
+				spinner:         &nopSpinner{},
+				newMasterSecret: func() (uri.MasterSecret, error) { return uri.MasterSecret{Key: next.Key, Salt: next.Salt}, nil },
+				interval:        time.Millisecond,
+				flags: rotateKeysFlags{
+					rootFlags: rootFlags{force: tc.force},
+					yes:       tc.yes,
+					timeout:   time.Minute,
+				},
+			}
+
+			err := r.rotateKeys(cmd, tc.kubeClient)
+			if tc.wantErr {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+			}
+
+			if tc.wantRotateSecret {
+				assert.Equal(current, tc.kubeClient.previous)
+				assert.Equal(next, tc.kubeClient.next)
+			} else {
+				assert.Nil(tc.kubeClient.previous.Key)
+			}
+			assert.Equal(tc.wantStart, tc.kubeClient.startCalled)
+			assert.Equal(tc.wantWait, tc.kubeClient.waitCalled)
+
+			var masterSecret uri.MasterSecret
+			if tc.wantNewMasterSaved {
+				require.NoError(fileHandler.ReadJSON(constants.MasterSecretFilename, &masterSecret))
+				assert.Equal(next, masterSecret)
+			}
+			_, statErr := fileHandler.Stat(constants.PreviousMasterSecretFilename)
+			if tc.wantWait && !tc.wantErr {
+				// the previous master secret is removed after a successful rotation
+				assert.True(tc.kubeClient.removeCalled)
+				assert.Error(statErr)
+			}
+		})
+	}
+}
+
+func TestMeasurementSecret(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	masterSecret := uri.MasterSecret{Key: []byte("key"), Salt: []byte("salt")}
+	derived, err := measurementSecret(masterSecret)
+	require.NoError(err)
+	want, err := crypto.DeriveKey(masterSecret.Key, masterSecret.Salt, []byte(crypto.DEKPrefix+crypto.MeasurementSecretKeyID), crypto.DerivedKeyLengthDefault)
+	require.NoError(err)
+	assert.Equal(want, derived)
+
+	masterSecret.MeasurementSecret = []byte("pinned")
+	pinned, err := measurementSecret(masterSecret)
+	require.NoError(err)
+	assert.Equal([]byte("pinned"), pinned)
+}
+
+type stubKeyRotator struct {
+	keyRotation  *updatev1alpha1.KeyRotation
+	externalKMS  bool
+	consumers    []string
+	rotateErr    error
+	waitErr      error
+	previous     uri.MasterSecret
+	next         uri.MasterSecret
+	startCalled  bool
+	waitCalled   bool
+	removeCalled bool
+}
+
+func (s *stubKeyRotator) GetKeyRotation(context.Context) (*updatev1alpha1.KeyRotation, error) {
+	return s.keyRotation, nil
+}
+
+func (s *stubKeyRotator) UsesExternalKMS(context.Context) (bool, error) {
+	return s.externalKMS, nil
+}
+
+func (s *stubKeyRotator) ListMasterSecretConsumers(context.Context) ([]string, error) {
+	return s.consumers, nil
+}
+
+func (s *stubKeyRotator) RotateMasterSecret(_ context.Context, previous, next uri.MasterSecret) error {
+	s.previous, s.next = previous, next
+	return s.rotateErr
+}
+
+func (s *stubKeyRotator) StartKeyRotation(context.Context, string) error {
+	s.startCalled = true
+	return nil
+}
+
+func (s *stubKeyRotator) WaitForKeyRotation(context.Context, time.Duration) error {
+	s.waitCalled = true
+	return s.waitErr
+}
+
+func (s *stubKeyRotator) RemovePreviousMasterSecret(context.Context) error {
+	s.removeCalled = true
+	return nil
+}
@@ -10,6 +10,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/edgelesssys/constellation/v2/internal/api/attestationconfigapi"
@@ -116,14 +117,22 @@ func (s *statusCmd) status(
 		return fmt.Errorf("getting cluster status: %w", err)
 	}
 
-	cmd.Print(statusOutput(nodeVersion, serviceVersions, status, string(prettyYAML)))
+	// measurement sets accepted by the cluster but not configured locally were added by apply during a node image upgrade.
+	var transitionSets int
+	for _, accepted := range attestationConfig.GetAcceptedMeasurements() {
+		if !slices.ContainsFunc(conf.GetAttestationConfig().GetAcceptedMeasurements(), accepted.EqualTo) {
+			transitionSets++
+		}
+	}
+
+	cmd.Print(statusOutput(nodeVersion, serviceVersions, status, string(prettyYAML), transitionSets))
 	return nil
 }
 
 // statusOutput creates the status cmd output string by formatting the received information.
 func statusOutput(
 	nodeVersion kubecmd.NodeVersion, serviceVersions fmt.Stringer,
-	status map[string]kubecmd.NodeStatus, rawAttestationConfig string,
+	status map[string]kubecmd.NodeStatus, rawAttestationConfig string, transitionSets int,
 ) string {
 	builder := strings.Builder{}
 
@@ -131,6 +140,7 @@ func statusOutput(
 	builder.WriteString(serviceVersions.String())
 	builder.WriteString(fmt.Sprintf("Cluster status: %s\n", nodeVersion.ClusterStatus()))
 	builder.WriteString(nodeStatusString(status, nodeVersion))
+	builder.WriteString(measurementTransitionString(status, nodeVersion, transitionSets))
 	builder.WriteString(fmt.Sprintf("Attestation config:\n%s", indentEntireStringWithTab(rawAttestationConfig)))
 	return builder.String()
 }
@@ -165,6 +175,20 @@ func nodeStatusString(status map[string]kubecmd.NodeStatus, targetVersions kubec
 	return builder.String()
 }
 
+// measurementTransitionString creates the part of the output string describing the previous measurement sets
+// the cluster accepts during a node image upgrade.
+func measurementTransitionString(status map[string]kubecmd.NodeStatus, targetVersions kubecmd.NodeVersion, transitionSets int) string {
+	if transitionSets == 0 {
+		return ""
+	}
+	for _, node := range status {
+		if node.ImageVersion() != targetVersions.ImageReference() {
+			return fmt.Sprintf("Previous measurements: %d set(s) accepted until all nodes run the target image\n", transitionSets)
+		}
+	}
+	return fmt.Sprintf("Previous measurements: %d set(s) still accepted, run 'constellation apply' to remove them now that all nodes run the target image\n", transitionSets)
+}
+
 // targetVersionsString creates the target versions part of the output string.
 func targetVersionsString(target kubecmd.NodeVersion) string {
 	builder := strings.Builder{}
@@ -31,6 +31,13 @@ const successOutput = targetVersions + versionsOutput + nodesUpToDateOutput + at
 
 const inProgressOutput = targetVersions + versionsOutput + nodesInProgressOutput + attestationConfigOutput
 
+const transitionOutput = targetVersions + versionsOutput + nodesInProgressOutput + `Previous measurements: 1 set(s) accepted until all nodes run the target image
+` + attestationConfigOutput + `	acceptedMeasurements:
+	    - 15:
+	        expected: "0101010101010101010101010101010101010101010101010101010101010101"
+	        warnOnly: false
+`
+
 const targetVersions = `Target versions:
 	Image: v1.1.0
 	Kubernetes: v1.2.3
@@ -162,6 +169,61 @@ func TestStatus(t *testing.T) {
 			},
 			expectedOutput: inProgressOutput,
 		},
+		"previous measurements accepted during upgrade": {
+			kubeClient: stubKubeClient{
+				status: map[string]kubecmd.NodeStatus{
+					"outdated": kubecmd.NewNodeStatus(corev1.Node{
+						ObjectMeta: metav1.ObjectMeta{
+							Name: "outdated",
+							Annotations: map[string]string{
+								"constellation.edgeless.systems/node-image": "v1.0.0",
+							},
+						},
+						Status: corev1.NodeStatus{
+							NodeInfo: corev1.NodeSystemInfo{
+								KubeletVersion: "v1.2.2",
+							},
+						},
+					}),
+					"uptodate": kubecmd.NewNodeStatus(corev1.Node{
+						ObjectMeta: metav1.ObjectMeta{
+							Name: "uptodate",
+							Annotations: map[string]string{
+								"constellation.edgeless.systems/node-image": "v1.1.0",
+							},
+						},
+						Status: corev1.NodeStatus{
+							NodeInfo: corev1.NodeSystemInfo{
+								KubeletVersion: "v1.2.3",
+							},
+						},
+					}),
+				},
+				version: mustParseNodeVersion(updatev1alpha1.NodeVersion{
+					Spec: updatev1alpha1.NodeVersionSpec{
+						ImageVersion:             "v1.1.0",
+						ImageReference:           "v1.1.0",
+						KubernetesClusterVersion: "v1.2.3",
+					},
+					Status: updatev1alpha1.NodeVersionStatus{
+						Conditions: []metav1.Condition{
+							{
+								Message: "Some node versions are out of date",
+							},
+						},
+					},
+				}),
+				attestation: &config.QEMUVTPM{
+					Measurements: measurements.M{
+						15: measurements.WithAllBytes(0, measurements.Enforce, measurements.PCRMeasurementLength),
+					},
+					AcceptedMeasurements: []measurements.M{
+						{15: measurements.WithAllBytes(1, measurements.Enforce, measurements.PCRMeasurementLength)},
+					},
+				},
+			},
+			expectedOutput: transitionOutput,
+		},
 		"error getting node status": {
 			kubeClient: stubKubeClient{
 				statusErr: assert.AnError,
@@ -16,7 +16,9 @@ import (
 	"github.com/spf13/pflag"
 
 	"github.com/edgelesssys/constellation/v2/cli/internal/cloudcmd"
+	"github.com/edgelesssys/constellation/v2/internal/config"
 	"github.com/edgelesssys/constellation/v2/internal/constants"
+	"github.com/edgelesssys/constellation/v2/internal/constellation/state"
 	"github.com/edgelesssys/constellation/v2/internal/file"
 )
 
@@ -59,14 +61,15 @@ func runTerminate(cmd *cobra.Command, _ []string) error {
 		return fmt.Errorf("creating spinner: %w", err)
 	}
 	defer spinner.Stop()
-	terminator := cloudcmd.NewTerminator()
+	fileHandler := file.NewHandler(afero.NewOsFs())
+	terminator := cloudcmd.NewTerminator(fileHandler)
 
 	logger, err := newCLILogger(cmd)
 	if err != nil {
 		return fmt.Errorf("creating logger: %w", err)
 	}
 
-	t := &terminateCmd{log: logger, fileHandler: file.NewHandler(afero.NewOsFs())}
+	t := &terminateCmd{log: logger, fileHandler: fileHandler}
 	if err := t.flags.parse(cmd.Flags()); err != nil {
 		return err
 	}
@@ -95,8 +98,12 @@ func (t *terminateCmd) terminate(cmd *cobra.Command, terminator cloudTerminator,
 		}
 	}
 
+	// The config and state file are only needed to delete the nodes the node operator created outside of Terraform.
+	// A cluster can still be terminated without them.
+	conf, uid := t.readOperatorNodeInfo(cmd)
+
 	spinner.Start("Terminating", false)
-	err := terminator.Terminate(cmd.Context(), constants.TerraformWorkingDir, t.flags.tfLogLevel)
+	err := terminator.Terminate(cmd.Context(), constants.TerraformWorkingDir, conf, uid, t.flags.tfLogLevel)
 	spinner.Stop()
 	if err != nil {
 		return fmt.Errorf("terminating Constellation cluster: %w", err)
@@ -115,3 +122,24 @@ func (t *terminateCmd) terminate(cmd *cobra.Command, terminator cloudTerminator,
 
 	return removeErr
 }
+
+// readOperatorNodeInfo returns the config and the cluster UID needed to delete the nodes the node operator created.
+// The config isn't validated, since only the provider and its credentials are needed.
+// If either can't be read, a nil config is returned and the nodes are not deleted.
+func (t *terminateCmd) readOperatorNodeInfo(cmd *cobra.Command) (*config.Config, string) {
+	var conf config.Config
+	if err := t.fileHandler.ReadYAML(constants.ConfigFilename, &conf); err != nil {
+		t.log.Debug(fmt.Sprintf("Reading config file failed: %q", err))
+		cmd.PrintErrf("Warning: unable to read %s, nodes created by the node operator on OpenStack or QEMU won't be deleted\n",
+			t.flags.pathPrefixer.PrefixPrintablePath(constants.ConfigFilename))
+		return nil, ""
+	}
+	stateFile, err := state.ReadFromFile(t.fileHandler, constants.StateFilename)
+	if err != nil {
+		t.log.Debug(fmt.Sprintf("Reading state file failed: %q", err))
+		cmd.PrintErrf("Warning: unable to read %s, nodes created by the node operator on OpenStack or QEMU won't be deleted\n",
+			t.flags.pathPrefixer.PrefixPrintablePath(constants.StateFilename))
+		return nil, ""
+	}
+	return &conf, stateFile.Infrastructure.UID
+}
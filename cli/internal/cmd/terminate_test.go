@@ -11,6 +11,8 @@ import (
 	"errors"
 	"testing"
 
+	"github.com/edgelesssys/constellation/v2/internal/cloud/cloudprovider"
+	"github.com/edgelesssys/constellation/v2/internal/config"
 	"github.com/edgelesssys/constellation/v2/internal/constants"
 	"github.com/edgelesssys/constellation/v2/internal/constellation/state"
 	"github.com/edgelesssys/constellation/v2/internal/file"
@@ -56,15 +58,33 @@ func TestTerminate(t *testing.T) {
 	}
 	someErr := errors.New("failed")
 
+	withConfig := func(require *require.Assertions, stateFile *state.State) afero.Fs {
+		fs := setupFs(require, stateFile)
+		conf := defaultConfigWithExpectedMeasurements(t, config.Default(), cloudprovider.OpenStack)
+		fileHandler := file.NewHandler(fs)
+		require.NoError(fileHandler.WriteYAML(constants.ConfigFilename, conf))
+		return fs
+	}
+	stateWithUID := state.New()
+	stateWithUID.Infrastructure.UID = "uid"
+
 	testCases := map[string]struct {
-		stateFile  *state.State
-		yesFlag    bool
-		stdin      string
-		setupFs    func(*require.Assertions, *state.State) afero.Fs
-		terminator spyCloudTerminator
-		wantErr    bool
-		wantAbort  bool
+		stateFile    *state.State
+		yesFlag      bool
+		stdin        string
+		setupFs      func(*require.Assertions, *state.State) afero.Fs
+		terminator   spyCloudTerminator
+		wantOperator bool
+		wantErr      bool
+		wantAbort    bool
 	}{
+		"config and cluster UID are passed to delete operator nodes": {
+			stateFile:    stateWithUID,
+			setupFs:      withConfig,
+			terminator:   &stubCloudTerminator{},
+			yesFlag:      true,
+			wantOperator: true,
+		},
 		"success": {
 			stateFile:  state.New(),
 			setupFs:    setupFs,
@@ -155,6 +175,15 @@ func TestTerminate(t *testing.T) {
 					assert.False(tc.terminator.Called())
 				} else {
 					assert.True(tc.terminator.Called())
+					stub, ok := tc.terminator.(*stubCloudTerminator)
+					require.True(ok)
+					if tc.wantOperator {
+						require.NotNil(stub.conf)
+						assert.Equal(cloudprovider.OpenStack, stub.conf.GetProvider())
+						assert.Equal("uid", stub.uid)
+					} else {
+						assert.Nil(stub.conf)
+					}
 					_, err = fileHandler.Stat(constants.AdminConfFilename)
 					assert.Error(err)
 					_, err = fileHandler.Stat(constants.StateFilename)
@@ -22,6 +22,7 @@ import (
 	"github.com/edgelesssys/constellation/v2/internal/file"
 	"github.com/edgelesssys/constellation/v2/internal/kms/uri"
 	"github.com/edgelesssys/constellation/v2/internal/logger"
+	"github.com/edgelesssys/constellation/v2/internal/nodegroup"
 	"github.com/edgelesssys/constellation/v2/internal/semver"
 	"github.com/edgelesssys/constellation/v2/internal/versions"
 	"github.com/spf13/afero"
@@ -95,6 +96,16 @@ func TestUpgradeApply(t *testing.T) {
 			flags:             applyFlags{yes: true, skipPhases: skipPhases{skipInitPhase: struct{}{}}},
 			fh:                fsWithStateFileAndTfState,
 		},
+		"nodeVersion rolled back error": {
+			kubeUpgrader: &stubKubernetesUpgrader{
+				currentConfig:  config.DefaultForAzureSEVSNP(),
+				nodeVersionErr: kubecmd.ErrRolledBack,
+			},
+			helmUpgrader:      &stubHelmApplier{},
+			terraformUpgrader: &stubTerraformUpgrader{},
+			flags:             applyFlags{yes: true, skipPhases: skipPhases{skipInitPhase: struct{}{}}},
+			fh:                fsWithStateFileAndTfState,
+		},
 		"helm other error": {
 			kubeUpgrader: &stubKubernetesUpgrader{
 				currentConfig: config.DefaultForAzureSEVSNP(),
@@ -290,6 +301,8 @@ type stubKubernetesUpgrader struct {
 	backupCRDsCalled               bool
 	backupCRsErr                   error
 	backupCRsCalled                bool
+	upgradeInProgress              bool
+	appliedConfig                  config.AttestationCfg
 }
 
 func (u *stubKubernetesUpgrader) BackupCRDs(_ context.Context, _ file.Handler, _ string) ([]apiextensionsv1.CustomResourceDefinition, error) {
@@ -312,10 +325,19 @@ func (u *stubKubernetesUpgrader) UpgradeKubernetesVersion(_ context.Context, _ v
 	return u.kubernetesVersionErr
 }
 
-func (u *stubKubernetesUpgrader) ApplyJoinConfig(_ context.Context, _ config.AttestationCfg, _ []byte) error {
+func (u *stubKubernetesUpgrader) ApplyJoinConfig(_ context.Context, newConfig config.AttestationCfg, _ []byte) error {
+	u.appliedConfig = newConfig
+	return nil
+}
+
+func (u *stubKubernetesUpgrader) ApplyNodeGroupConfig(_ context.Context, _ nodegroup.Config) error {
 	return nil
 }
 
+func (u *stubKubernetesUpgrader) NodeImageUpgradeInProgress(_ context.Context) (bool, error) {
+	return u.upgradeInProgress, nil
+}
+
 func (u *stubKubernetesUpgrader) GetClusterAttestationConfig(_ context.Context, _ variant.Variant) (config.AttestationCfg, error) {
 	return u.currentConfig, u.getClusterAttestationConfigErr
 }
@@ -20,11 +20,14 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/edgelesssys/constellation/v2/internal/api/attestationconfigapi"
 	"github.com/edgelesssys/constellation/v2/internal/atls"
 	azuretdx "github.com/edgelesssys/constellation/v2/internal/attestation/azure/tdx"
 	"github.com/edgelesssys/constellation/v2/internal/attestation/choose"
+	"github.com/edgelesssys/constellation/v2/internal/attestation/evidence"
+	gcptdx "github.com/edgelesssys/constellation/v2/internal/attestation/gcp/tdx"
 	"github.com/edgelesssys/constellation/v2/internal/attestation/measurements"
 	"github.com/edgelesssys/constellation/v2/internal/attestation/snp"
 	"github.com/edgelesssys/constellation/v2/internal/attestation/variant"
@@ -60,17 +63,21 @@ func NewVerifyCmd() *cobra.Command {
 		RunE: runVerify,
 	}
 	cmd.Flags().String("cluster-id", "", "expected cluster identifier")
-	cmd.Flags().StringP("output", "o", "", "print the attestation document in the output format {json|raw}")
+	cmd.Flags().StringP("output", "o", "", "print the attestation document in the output format {json|raw|claims}")
 	cmd.Flags().StringP("node-endpoint", "e", "", "endpoint of the node to verify, passed as HOST[:PORT]")
+	cmd.Flags().String("from-archive", "", "verify the attestation evidence archived by the JoinService in the given file offline, instead of a running node")
+	cmd.MarkFlagsMutuallyExclusive("from-archive", "node-endpoint")
+	cmd.MarkFlagsMutuallyExclusive("from-archive", "cluster-id")
 	return cmd
 }
 
 type verifyFlags struct {
 	rootFlags
-	endpoint  string
-	ownerID   string
-	clusterID string
-	output    string
+	endpoint    string
+	ownerID     string
+	clusterID   string
+	output      string
+	fromArchive string
 }
 
 func (f *verifyFlags) parse(flags *pflag.FlagSet) error {
@@ -91,6 +98,10 @@ func (f *verifyFlags) parse(flags *pflag.FlagSet) error {
 	if err != nil {
 		return fmt.Errorf("getting 'cluster-id' flag: %w", err)
 	}
+	f.fromArchive, err = flags.GetString("from-archive")
+	if err != nil {
+		return fmt.Errorf("getting 'from-archive' flag: %w", err)
+	}
 	return nil
 }
 
@@ -141,6 +152,10 @@ func (c *verifyCmd) verify(cmd *cobra.Command, verifyClient verifyClient, config
 		stateFile = state.New() // A state file is only required if the user has not provided IP or ID flags
 	}
 
+	if c.flags.fromArchive != "" {
+		return c.verifyArchive(cmd, conf, stateFile)
+	}
+
 	ownerID, clusterID, err := c.validateIDFlags(cmd, stateFile)
 	if err != nil {
 		return err
@@ -197,6 +212,12 @@ func (c *verifyCmd) verify(cmd *cobra.Command, verifyClient verifyClient, config
 	case "raw":
 		attDocOutput = fmt.Sprintf("Attestation Document:\n%s\n", rawAttestationDoc)
 
+	case "claims":
+		attDocOutput, err = formatClaims(rawAttestationDoc, attConfig)
+		if err != nil {
+			return fmt.Errorf("printing attestation claims: %w", err)
+		}
+
 	case "":
 		attDocOutput, err = formatDefault(cmd.Context(), rawAttestationDoc, attConfig, c.log)
 		if err != nil {
@@ -213,6 +234,110 @@ func (c *verifyCmd) verify(cmd *cobra.Command, verifyClient verifyClient, config
 	return nil
 }
 
+// verifyArchive re-validates the attestation evidence archived by the JoinService against the attestation config.
+// Since nodes are attested before they are initialized, the measurements aren't updated with the cluster ID.
+func (c *verifyCmd) verifyArchive(cmd *cobra.Command, conf *config.Config, stateFile *state.State) error {
+	if c.flags.output != "" && c.flags.output != "claims" {
+		return fmt.Errorf("output format %q is not supported with --from-archive", c.flags.output)
+	}
+
+	c.log.Debug(fmt.Sprintf("Reading evidence archive from %q", c.flags.fromArchive))
+	data, err := c.fileHandler.Read(c.flags.fromArchive)
+	if err != nil {
+		return fmt.Errorf("reading evidence archive: %w", err)
+	}
+	records, err := evidence.ReadRecords(data)
+	if err != nil {
+		return fmt.Errorf("reading evidence archive: %w", err)
+	}
+	if len(records) == 0 {
+		return fmt.Errorf("no evidence found in %q", c.flags.fromArchive)
+	}
+
+	if stateFile.Infrastructure.Azure != nil {
+		conf.UpdateMAAURL(stateFile.Infrastructure.Azure.AttestationURL)
+	}
+	attConfig := conf.GetAttestationConfig()
+	configHash, err := evidence.ConfigHash(attConfig)
+	if err != nil {
+		return fmt.Errorf("hashing attestation config: %w", err)
+	}
+
+	c.log.Debug(fmt.Sprintf("Creating aTLS Validator for %q", attConfig.GetVariant()))
+	validator, err := choose.Validator(attConfig, warnLogger{cmd: cmd, log: c.log})
+	if err != nil {
+		return fmt.Errorf("creating aTLS validator: %w", err)
+	}
+
+	var failed int
+	for _, record := range records {
+		result := verifyArchivedEvidence(cmd.Context(), record, validator, attConfig, configHash, time.Now())
+		if result.Claims.Verdict == verify.VerdictFail {
+			failed++
+		}
+
+		if c.flags.output == "claims" {
+			jsonBytes, err := json.Marshal(result)
+			if err != nil {
+				return fmt.Errorf("marshaling verification result: %w", err)
+			}
+			cmd.Println(string(jsonBytes))
+			continue
+		}
+		cmd.Println(result.String())
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d archived attestation statements failed verification", failed, len(records))
+	}
+	cmd.PrintErrln("Verification OK")
+	return nil
+}
+
+// archivedEvidenceResult is the result of re-validating archived attestation evidence.
+type archivedEvidenceResult struct {
+	NodeName string    `json:"node_name"`
+	JoinedAt time.Time `json:"joined_at"`
+	// ConfigMatches is true if the evidence was accepted with the same attestation config it was verified with.
+	ConfigMatches bool                `json:"config_matches"`
+	Claims        verify.ClaimsReport `json:"claims"`
+}
+
+// String returns a single line summary of the result.
+func (r archivedEvidenceResult) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\t%s\t%s", r.NodeName, r.JoinedAt.UTC().Format(time.RFC3339), r.Claims.Verdict)
+	for _, check := range r.Claims.Checks {
+		if check.Verdict == verify.VerdictFail {
+			fmt.Fprintf(&b, "\t%s: %s", check.Name, check.Detail)
+		}
+	}
+	if !r.ConfigMatches {
+		b.WriteString("\t(accepted with a different attestation config)")
+	}
+	return b.String()
+}
+
+// verifyArchivedEvidence validates an archived attestation statement and creates a claims report for it.
+func verifyArchivedEvidence(ctx context.Context, record evidence.Record, validator atls.Validator,
+	attestationCfg config.AttestationCfg, configHash string, now time.Time,
+) archivedEvidenceResult {
+	validationErr := record.Validate(ctx, validator)
+	report, err := verify.NewClaimsReport(attestationCfg, record.AttestationDocument, validationErr, now)
+	if err != nil {
+		if validationErr == nil {
+			validationErr = err
+		}
+		report = verify.NewFailedClaimsReport(attestationCfg, validationErr, now)
+	}
+	return archivedEvidenceResult{
+		NodeName:      record.NodeName,
+		JoinedAt:      record.Timestamp,
+		ConfigMatches: record.ConfigHash == configHash,
+		Claims:        report,
+	}
+}
+
 func (c *verifyCmd) validateIDFlags(cmd *cobra.Command, stateFile *state.State) (ownerID, clusterID string, err error) {
 	ownerID, clusterID = c.flags.ownerID, c.flags.clusterID
 	if c.flags.clusterID == "" {
@@ -255,15 +380,26 @@ func formatJSON(ctx context.Context, docString []byte, attestationCfg config.Att
 	}
 
 	switch attestationCfg.GetVariant() {
-	case variant.AWSSEVSNP{}, variant.AzureSEVSNP{}, variant.GCPSEVSNP{}:
+	case variant.AWSSEVSNP{}, variant.AzureSEVSNP{}, variant.GCPSEVSNP{}, variant.QEMUSEVSNP{}:
 		return snpFormatJSON(ctx, doc.InstanceInfo, attestationCfg, log)
-	case variant.AzureTDX{}:
+	case variant.AzureTDX{}, variant.GCPTDX{}:
 		return tdxFormatJSON(doc.InstanceInfo, attestationCfg)
 	default:
 		return "", fmt.Errorf("json output is not supported for variant %s", attestationCfg.GetVariant())
 	}
 }
 
+// formatClaims returns the normalized claims of a verified attestation doc.
+// Unlike formatJSON, the output uses the same schema for all attestation variants.
+func formatClaims(docString []byte, attestationCfg config.AttestationCfg) (string, error) {
+	report, err := verify.NewClaimsReport(attestationCfg, docString, nil, time.Now())
+	if err != nil {
+		return "", fmt.Errorf("creating claims report: %w", err)
+	}
+	jsonBytes, err := json.Marshal(report)
+	return string(jsonBytes), err
+}
+
 func snpFormatJSON(ctx context.Context, instanceInfoRaw []byte, attestationCfg config.AttestationCfg, log debugLog,
 ) (string, error) {
 	var instanceInfo snp.InstanceInfo
@@ -282,12 +418,19 @@ func snpFormatJSON(ctx context.Context, instanceInfoRaw []byte, attestationCfg c
 func tdxFormatJSON(instanceInfoRaw []byte, attestationCfg config.AttestationCfg) (string, error) {
 	var rawQuote []byte
 
-	if attestationCfg.GetVariant().Equal(variant.AzureTDX{}) {
+	switch attestationCfg.GetVariant() {
+	case variant.AzureTDX{}:
 		var instanceInfo azuretdx.InstanceInfo
 		if err := json.Unmarshal(instanceInfoRaw, &instanceInfo); err != nil {
 			return "", fmt.Errorf("unmarshalling instance info: %w", err)
 		}
 		rawQuote = instanceInfo.AttestationReport
+	case variant.GCPTDX{}:
+		var instanceInfo gcptdx.InstanceInfo
+		if err := json.Unmarshal(instanceInfoRaw, &instanceInfo); err != nil {
+			return "", fmt.Errorf("unmarshalling instance info: %w", err)
+		}
+		rawQuote = instanceInfo.AttestationReport
 	}
 
 	tdxQuote, err := abi.QuoteToProto(rawQuote)
@@ -314,14 +457,18 @@ func formatDefault(ctx context.Context, docString []byte, attestationCfg config.
 		return "", fmt.Errorf("unmarshalling attestation document: %w", err)
 	}
 
-	if err := parseQuotes(b, doc.Attestation.Quotes, attestationCfg.GetMeasurements()); err != nil {
-		return "", fmt.Errorf("parse quote: %w", err)
+	// QEMU SEV-SNP attestation has no TPM quotes, the launch measurement is part of the SNP report
+	if !attestationCfg.GetVariant().Equal(variant.QEMUSEVSNP{}) {
+		if err := parseQuotes(b, doc.Attestation.Quotes, attestationCfg.GetMeasurements()); err != nil {
+			return "", fmt.Errorf("parse quote: %w", err)
+		}
 	}
 
 	// If we have a non SNP variant, print only the PCRs
 	if !(attestationCfg.GetVariant().Equal(variant.AzureSEVSNP{}) ||
 		attestationCfg.GetVariant().Equal(variant.AWSSEVSNP{}) ||
-		attestationCfg.GetVariant().Equal(variant.GCPSEVSNP{})) {
+		attestationCfg.GetVariant().Equal(variant.GCPSEVSNP{}) ||
+		attestationCfg.GetVariant().Equal(variant.QEMUSEVSNP{})) {
 		return b.String(), nil
 	}
 
@@ -446,7 +593,7 @@ func updateInitMeasurements(config config.AttestationCfg, ownerID, clusterID str
 	switch config.GetVariant() {
 	case variant.AWSNitroTPM{}, variant.AWSSEVSNP{},
 		variant.AzureTrustedLaunch{}, variant.AzureSEVSNP{}, variant.AzureTDX{}, // AzureTDX also uses a vTPM for measurements
-		variant.GCPSEVES{}, variant.GCPSEVSNP{},
+		variant.GCPSEVES{}, variant.GCPSEVSNP{}, variant.GCPTDX{}, // GCPTDX also uses a vTPM for measurements
 		variant.QEMUVTPM{}:
 		if err := updateMeasurementTPM(m, uint32(measurements.PCRIndexOwnerID), ownerID); err != nil {
 			return err
@@ -457,6 +604,10 @@ func updateInitMeasurements(config config.AttestationCfg, ownerID, clusterID str
 		// Since adding support for measuring ownerID to TDX would require additional code changes,
 		// the current implementation does not support it, but can be changed if we decide to add support in the future
 		return updateMeasurementTDX(m, uint32(measurements.TDXIndexClusterID), clusterID)
+	case variant.QEMUSEVSNP{}:
+		// SEV-SNP has no measurement registers that can be extended at runtime,
+		// so the owner and cluster ID can't be part of the attestation.
+		return nil
 	default:
 		return errors.New("selecting attestation variant: unknown attestation variant")
 	}
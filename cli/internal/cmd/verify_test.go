@@ -11,6 +11,7 @@ import (
 	"context"
 	"crypto/sha256"
 	"crypto/sha512"
+	"encoding/asn1"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
@@ -18,8 +19,10 @@ import (
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/edgelesssys/constellation/v2/internal/atls"
+	"github.com/edgelesssys/constellation/v2/internal/attestation/evidence"
 	"github.com/edgelesssys/constellation/v2/internal/attestation/measurements"
 	"github.com/edgelesssys/constellation/v2/internal/attestation/variant"
 	"github.com/edgelesssys/constellation/v2/internal/cloud/cloudprovider"
@@ -30,6 +33,7 @@ import (
 	"github.com/edgelesssys/constellation/v2/internal/grpc/dialer"
 	"github.com/edgelesssys/constellation/v2/internal/grpc/testdialer"
 	"github.com/edgelesssys/constellation/v2/internal/logger"
+	"github.com/edgelesssys/constellation/v2/internal/verify"
 	"github.com/edgelesssys/constellation/v2/verify/verifyproto"
 	tpmProto "github.com/google/go-tpm-tools/proto/tpm"
 	"github.com/spf13/afero"
@@ -245,6 +249,150 @@ func TestFormatDefault(t *testing.T) {
 	}
 }
 
+func TestFormatClaims(t *testing.T) {
+	pcrs := map[uint32][]byte{4: make([]byte, 32)}
+	vtpmDoc := struct {
+		Attestation struct {
+			Quotes []*tpmProto.Quote
+		}
+	}{}
+	vtpmDoc.Attestation.Quotes = []*tpmProto.Quote{{Pcrs: &tpmProto.PCRs{Hash: tpmProto.HashAlgo_SHA256, Pcrs: pcrs}}}
+	validDoc, err := json.Marshal(vtpmDoc)
+	require.NoError(t, err)
+
+	testCases := map[string]struct {
+		doc         []byte
+		attCfg      config.AttestationCfg
+		wantVerdict string
+		wantErr     bool
+	}{
+		"vtpm doc": {
+			doc: validDoc,
+			attCfg: &config.QEMUVTPM{Measurements: measurements.M{
+				4: measurements.WithAllBytes(0x00, measurements.Enforce, measurements.PCRMeasurementLength),
+			}},
+			wantVerdict: "pass",
+		},
+		"invalid doc": {
+			doc:     []byte("invalid"),
+			attCfg:  &config.AzureSEVSNP{},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			out, err := formatClaims(tc.doc, tc.attCfg)
+			if tc.wantErr {
+				assert.Error(err)
+				return
+			}
+			require.NoError(err)
+
+			var report struct {
+				SchemaVersion string `json:"schema_version"`
+				Verdict       string `json:"verdict"`
+			}
+			require.NoError(json.Unmarshal([]byte(out), &report))
+			assert.NotEmpty(report.SchemaVersion)
+			assert.Equal(tc.wantVerdict, report.Verdict)
+		})
+	}
+}
+
+func TestVerifyArchivedEvidence(t *testing.T) {
+	pcrs := map[uint32][]byte{4: make([]byte, 32)}
+	vtpmDoc := struct {
+		Attestation struct {
+			Quotes []*tpmProto.Quote
+		}
+	}{}
+	vtpmDoc.Attestation.Quotes = []*tpmProto.Quote{{Pcrs: &tpmProto.PCRs{Hash: tpmProto.HashAlgo_SHA256, Pcrs: pcrs}}}
+	validDoc, err := json.Marshal(vtpmDoc)
+	require.NoError(t, err)
+	attCfg := &config.QEMUVTPM{Measurements: measurements.M{
+		4: measurements.WithAllBytes(0x00, measurements.Enforce, measurements.PCRMeasurementLength),
+	}}
+	joinedAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	newRecord := func(doc []byte, configHash string) evidence.Record {
+		return evidence.Record{
+			SchemaVersion:       evidence.SchemaVersion,
+			Variant:             variant.QEMUVTPM{}.String(),
+			NodeName:            "worker-0",
+			Timestamp:           joinedAt,
+			UserData:            []byte("user data"),
+			AttestationDocument: doc,
+			ConfigHash:          configHash,
+		}
+	}
+
+	testCases := map[string]struct {
+		record            evidence.Record
+		validator         *stubArchiveValidator
+		wantVerdict       verify.Verdict
+		wantConfigMatches bool
+		wantSummary       string
+	}{
+		"valid evidence": {
+			record:            newRecord(validDoc, "hash"),
+			validator:         &stubArchiveValidator{userData: []byte("user data")},
+			wantVerdict:       verify.VerdictPass,
+			wantConfigMatches: true,
+			wantSummary:       "worker-0\t2024-01-02T03:04:05Z\tpass",
+		},
+		"accepted with different config": {
+			record:      newRecord(validDoc, "other hash"),
+			validator:   &stubArchiveValidator{userData: []byte("user data")},
+			wantVerdict: verify.VerdictPass,
+			wantSummary: "worker-0\t2024-01-02T03:04:05Z\tpass\t(accepted with a different attestation config)",
+		},
+		"validation fails": {
+			record:            newRecord(validDoc, "hash"),
+			validator:         &stubArchiveValidator{err: errors.New("invalid")},
+			wantVerdict:       verify.VerdictFail,
+			wantConfigMatches: true,
+			wantSummary:       "worker-0\t2024-01-02T03:04:05Z\tfail\tattestation: invalid",
+		},
+		"unparsable document": {
+			record:            newRecord([]byte("invalid"), "hash"),
+			validator:         &stubArchiveValidator{userData: []byte("user data")},
+			wantVerdict:       verify.VerdictFail,
+			wantConfigMatches: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			result := verifyArchivedEvidence(t.Context(), tc.record, tc.validator, attCfg, "hash", time.Now())
+			assert.Equal("worker-0", result.NodeName)
+			assert.Equal(joinedAt, result.JoinedAt)
+			assert.Equal(tc.wantConfigMatches, result.ConfigMatches)
+			assert.Equal(tc.wantVerdict, result.Claims.Verdict)
+			if tc.wantSummary != "" {
+				assert.Equal(tc.wantSummary, result.String())
+			}
+		})
+	}
+}
+
+type stubArchiveValidator struct {
+	userData []byte
+	err      error
+}
+
+func (v *stubArchiveValidator) Validate(context.Context, []byte, []byte) ([]byte, error) {
+	return v.userData, v.err
+}
+
+func (v *stubArchiveValidator) OID() asn1.ObjectIdentifier {
+	return variant.QEMUVTPM{}.OID()
+}
+
 func TestVerifyClient(t *testing.T) {
 	testCases := map[string]struct {
 		attestationDoc atls.FakeAttestationDoc
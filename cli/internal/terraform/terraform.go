@@ -23,6 +23,7 @@ import (
 	"fmt"
 	"io"
 	"path/filepath"
+	"slices"
 
 	"github.com/edgelesssys/constellation/v2/internal/cloud/cloudprovider"
 	"github.com/edgelesssys/constellation/v2/internal/constants"
@@ -442,6 +443,29 @@ func (c *Client) ShowPlan(ctx context.Context, logLevel LogLevel, output io.Writ
 	return nil
 }
 
+// RecreatedResources returns the addresses of the resources of the given types that were deleted outside of Terraform
+// and that the plan file in the Terraform working directory would create again.
+func (c *Client) RecreatedResources(ctx context.Context, resourceTypes []string) ([]string, error) {
+	plan, err := c.tf.ShowPlanFile(ctx, terraformUpgradePlanFile)
+	if err != nil {
+		return nil, fmt.Errorf("terraform show plan: %w", err)
+	}
+
+	deleted := make(map[string]struct{})
+	for _, drift := range plan.ResourceDrift {
+		if drift.Change != nil && drift.Change.Actions.Delete() && slices.Contains(resourceTypes, drift.Type) {
+			deleted[drift.Address] = struct{}{}
+		}
+	}
+	var recreated []string
+	for _, change := range plan.ResourceChanges {
+		if _, ok := deleted[change.Address]; ok && change.Change != nil && change.Change.Actions.Create() {
+			recreated = append(recreated, change.Address)
+		}
+	}
+	return recreated, nil
+}
+
 // Destroy destroys Terraform-created cloud resources.
 func (c *Client) Destroy(ctx context.Context, logLevel LogLevel) error {
 	if err := c.setLogLevel(logLevel); err != nil {
@@ -622,6 +646,7 @@ type tfInterface interface {
 	Show(context.Context, ...tfexec.ShowOption) (*tfjson.State, error)
 	Plan(ctx context.Context, opts ...tfexec.PlanOption) (bool, error)
 	ShowPlanFileRaw(ctx context.Context, planPath string, opts ...tfexec.ShowOption) (string, error)
+	ShowPlanFile(ctx context.Context, planPath string, opts ...tfexec.ShowOption) (*tfjson.Plan, error)
 	SetLog(level string) error
 	SetLogPath(path string) error
 	TFMigrator
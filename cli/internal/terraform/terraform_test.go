@@ -1330,6 +1330,81 @@ func TestShowIAM(t *testing.T) {
 	}
 }
 
+func TestRecreatedResources(t *testing.T) {
+	change := func(address, resourceType string, actions ...tfjson.Action) *tfjson.ResourceChange {
+		return &tfjson.ResourceChange{Address: address, Type: resourceType, Change: &tfjson.Change{Actions: actions}}
+	}
+	server := "module.instance_group[\"worker_default\"].openstack_compute_instance_v2.instance_group_member[1]"
+	port := "module.instance_group[\"worker_default\"].openstack_networking_port_v2.port[1]"
+	resourceTypes := []string{"openstack_compute_instance_v2", "openstack_networking_port_v2"}
+
+	testCases := map[string]struct {
+		tf      *stubTerraform
+		want    []string
+		wantErr bool
+	}{
+		"deleted resources are recreated": {
+			tf: &stubTerraform{showPlan: &tfjson.Plan{
+				ResourceDrift: []*tfjson.ResourceChange{
+					change(server, "openstack_compute_instance_v2", tfjson.ActionDelete),
+					change(port, "openstack_networking_port_v2", tfjson.ActionDelete),
+				},
+				ResourceChanges: []*tfjson.ResourceChange{
+					change(server, "openstack_compute_instance_v2", tfjson.ActionCreate),
+					change(port, "openstack_networking_port_v2", tfjson.ActionCreate),
+				},
+			}},
+			want: []string{server, port},
+		},
+		"updated resources are ignored": {
+			tf: &stubTerraform{showPlan: &tfjson.Plan{
+				ResourceDrift: []*tfjson.ResourceChange{
+					change(server, "openstack_compute_instance_v2", tfjson.ActionUpdate),
+				},
+				ResourceChanges: []*tfjson.ResourceChange{
+					change(server, "openstack_compute_instance_v2", tfjson.ActionUpdate),
+				},
+			}},
+		},
+		"other resource types are ignored": {
+			tf: &stubTerraform{showPlan: &tfjson.Plan{
+				ResourceDrift: []*tfjson.ResourceChange{
+					change("openstack_networking_secgroup_v2.vpc_secgroup", "openstack_networking_secgroup_v2", tfjson.ActionDelete),
+				},
+				ResourceChanges: []*tfjson.ResourceChange{
+					change("openstack_networking_secgroup_v2.vpc_secgroup", "openstack_networking_secgroup_v2", tfjson.ActionCreate),
+				},
+			}},
+		},
+		"new resources are ignored": {
+			tf: &stubTerraform{showPlan: &tfjson.Plan{
+				ResourceChanges: []*tfjson.ResourceChange{
+					change(server, "openstack_compute_instance_v2", tfjson.ActionCreate),
+				},
+			}},
+		},
+		"show plan fails": {
+			tf:      &stubTerraform{showPlanFileErr: assert.AnError},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			c := &Client{tf: tc.tf}
+			recreated, err := c.RecreatedResources(t.Context(), resourceTypes)
+			if tc.wantErr {
+				assert.Error(err)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(tc.want, recreated)
+		})
+	}
+}
+
 type stubTerraform struct {
 	applyErr        error
 	destroyErr      error
@@ -1341,6 +1416,7 @@ type stubTerraform struct {
 	showPlanFileErr error
 	stateMvErr      error
 	showState       *tfjson.State
+	showPlan        *tfjson.Plan
 }
 
 func (s *stubTerraform) Apply(context.Context, ...tfexec.ApplyOption) error {
@@ -1367,6 +1443,10 @@ func (s *stubTerraform) ShowPlanFileRaw(context.Context, string, ...tfexec.ShowO
 	return "", s.showPlanFileErr
 }
 
+func (s *stubTerraform) ShowPlanFile(context.Context, string, ...tfexec.ShowOption) (*tfjson.Plan, error) {
+	return s.showPlan, s.showPlanFileErr
+}
+
 func (s *stubTerraform) SetLog(_ string) error {
 	return s.setLogErr
 }
@@ -133,7 +133,7 @@ type GCPClusterVariables struct {
 	CustomEndpoint string `hcl:"custom_endpoint" cty:"custom_endpoint"`
 	// InternalLoadBalancer is true if an internal load balancer should be created.
 	InternalLoadBalancer bool `hcl:"internal_load_balancer" cty:"internal_load_balancer"`
-	// CCTechnology is the confidential computing technology to use on the VMs. (`SEV` or `SEV_SNP`)
+	// CCTechnology is the confidential computing technology to use on the VMs. (`SEV`, `SEV_SNP` or `TDX`)
 	CCTechnology string `hcl:"cc_technology" cty:"cc_technology"`
 	// IAMServiceAccountControlPlane is the IAM service account mail address to attach to VMs.
 	IAMServiceAccountVM string `hcl:"iam_service_account_vm" cty:"iam_service_account_vm"`
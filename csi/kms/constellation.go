@@ -8,17 +8,24 @@ package kms
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io/fs"
+	"os"
+	"strings"
 
+	"github.com/edgelesssys/constellation/v2/internal/constants"
 	"github.com/edgelesssys/constellation/v2/keyservice/keyserviceproto"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
 )
 
 // ConstellationKMS is a key service to fetch volume keys.
 type ConstellationKMS struct {
-	endpoint string
-	kms      kmsClient
+	endpoint  string
+	kms       kmsClient
+	readToken func() ([]byte, error)
 }
 
 // NewConstellationKMS initializes a ConstellationKMS.
@@ -26,11 +33,23 @@ func NewConstellationKMS(endpoint string) *ConstellationKMS {
 	return &ConstellationKMS{
 		endpoint: endpoint, // default: "kms.kube-system:port"
 		kms:      &constellationKMSClient{},
+		readToken: func() ([]byte, error) {
+			return os.ReadFile(constants.KeyServiceTokenPath)
+		},
 	}
 }
 
 // GetDEK request a data encryption key derived from the Constellation's master secret.
+// If a service account token for the key service is mounted, it is sent to authenticate the request.
 func (k *ConstellationKMS) GetDEK(ctx context.Context, dekID string, dekSize int) ([]byte, error) {
+	token, err := k.readToken()
+	switch {
+	case err == nil:
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+strings.TrimSpace(string(token)))
+	case !errors.Is(err, fs.ErrNotExist):
+		return nil, fmt.Errorf("reading service account token: %w", err)
+	}
+
 	conn, err := grpc.NewClient(k.endpoint, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	if err != nil {
 		return nil, err
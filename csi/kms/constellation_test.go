@@ -9,12 +9,14 @@ package kms
 import (
 	"context"
 	"errors"
+	"io/fs"
 	"testing"
 
 	"github.com/edgelesssys/constellation/v2/keyservice/keyserviceproto"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/goleak"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/test/bufconn"
 )
 
@@ -25,24 +27,42 @@ func TestMain(m *testing.M) {
 type stubKMSClient struct {
 	getDataKeyErr error
 	dataKey       []byte
+	authorization []string
 }
 
-func (c *stubKMSClient) GetDataKey(context.Context, *keyserviceproto.GetDataKeyRequest, *grpc.ClientConn) (*keyserviceproto.GetDataKeyResponse, error) {
+func (c *stubKMSClient) GetDataKey(ctx context.Context, _ *keyserviceproto.GetDataKeyRequest, _ *grpc.ClientConn) (*keyserviceproto.GetDataKeyResponse, error) {
+	md, _ := metadata.FromOutgoingContext(ctx)
+	c.authorization = md.Get("authorization")
 	return &keyserviceproto.GetDataKeyResponse{DataKey: c.dataKey}, c.getDataKeyErr
 }
 
 func TestConstellationKMS(t *testing.T) {
 	testCases := map[string]struct {
-		kms     *stubKMSClient
-		wantErr bool
+		kms               *stubKMSClient
+		token             []byte
+		readTokenErr      error
+		wantAuthorization []string
+		wantErr           bool
 	}{
 		"GetDataKey success": {
-			kms:     &stubKMSClient{dataKey: []byte{0x1, 0x2, 0x3}},
-			wantErr: false,
+			kms:          &stubKMSClient{dataKey: []byte{0x1, 0x2, 0x3}},
+			readTokenErr: fs.ErrNotExist,
+			wantErr:      false,
+		},
+		"GetDataKey with token": {
+			kms:               &stubKMSClient{dataKey: []byte{0x1, 0x2, 0x3}},
+			token:             []byte("token"),
+			wantAuthorization: []string{"Bearer token"},
 		},
 		"GetDataKey error": {
-			kms:     &stubKMSClient{getDataKeyErr: errors.New("error")},
-			wantErr: true,
+			kms:          &stubKMSClient{getDataKeyErr: errors.New("error")},
+			readTokenErr: fs.ErrNotExist,
+			wantErr:      true,
+		},
+		"reading token fails": {
+			kms:          &stubKMSClient{dataKey: []byte{0x1, 0x2, 0x3}},
+			readTokenErr: errors.New("error"),
+			wantErr:      true,
 		},
 	}
 
@@ -56,6 +76,9 @@ func TestConstellationKMS(t *testing.T) {
 			kms := &ConstellationKMS{
 				endpoint: listener.Addr().String(),
 				kms:      tc.kms,
+				readToken: func() ([]byte, error) {
+					return tc.token, tc.readTokenErr
+				},
 			}
 			res, err := kms.GetDEK(t.Context(), "data-key", 64)
 
@@ -65,6 +88,7 @@ func TestConstellationKMS(t *testing.T) {
 			} else {
 				assert.NoError(err)
 				assert.NotNil(res)
+				assert.Equal(tc.wantAuthorization, tc.kms.authorization)
 			}
 		})
 	}
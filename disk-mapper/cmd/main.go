@@ -23,6 +23,7 @@ import (
 	"github.com/edgelesssys/constellation/v2/disk-mapper/internal/rejoinclient"
 	"github.com/edgelesssys/constellation/v2/disk-mapper/internal/setup"
 	"github.com/edgelesssys/constellation/v2/internal/attestation/choose"
+	qemusnp "github.com/edgelesssys/constellation/v2/internal/attestation/qemu/snp"
 	"github.com/edgelesssys/constellation/v2/internal/attestation/tdx"
 	"github.com/edgelesssys/constellation/v2/internal/attestation/variant"
 	"github.com/edgelesssys/constellation/v2/internal/attestation/vtpm"
@@ -154,12 +155,17 @@ func run() error {
 	}
 	defer free()
 
-	// Use TDX if available
+	// Use TDX or SEV-SNP if available
 	openDevice := vtpm.OpenVTPM
-	if attestVariant.OID().Equal(variant.QEMUTDX{}.OID()) {
+	switch {
+	case attestVariant.OID().Equal(variant.QEMUTDX{}.OID()):
 		openDevice = func() (io.ReadWriteCloser, error) {
 			return tdx.Open()
 		}
+	case attestVariant.OID().Equal(variant.QEMUSEVSNP{}.OID()):
+		openDevice = func() (io.ReadWriteCloser, error) {
+			return qemusnp.Open()
+		}
 	}
 	setupManger := setup.New(
 		log.WithGroup("setupManager"),
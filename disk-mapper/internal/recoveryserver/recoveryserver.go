@@ -27,6 +27,7 @@ import (
 	"github.com/edgelesssys/constellation/v2/internal/grpc/atlscredentials"
 	"github.com/edgelesssys/constellation/v2/internal/grpc/grpclog"
 	"github.com/edgelesssys/constellation/v2/internal/kms/kms"
+	kmssetup "github.com/edgelesssys/constellation/v2/internal/kms/setup"
 	"github.com/edgelesssys/constellation/v2/internal/logger"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
@@ -114,7 +115,7 @@ func (s *RecoveryServer) Recover(ctx context.Context, req *recoverproto.RecoverM
 		return nil, status.Errorf(codes.Internal, "creating kms client: %s", err)
 	}
 
-	measurementSecret, err := cloudKms.GetDEK(ctx, crypto.DEKPrefix+crypto.MeasurementSecretKeyID, crypto.DerivedKeyLengthDefault)
+	measurementSecret, err := kmssetup.MeasurementSecret(ctx, req.KmsUri, cloudKms)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "requesting measurementSecret: %s", err)
 	}
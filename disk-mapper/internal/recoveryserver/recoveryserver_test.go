@@ -76,16 +76,25 @@ func TestServe(t *testing.T) {
 
 func TestRecover(t *testing.T) {
 	testCases := map[string]struct {
-		kmsURI     string
-		storageURI string
-		factory    kmsFactory
-		wantErr    bool
+		kmsURI                string
+		storageURI            string
+		factory               kmsFactory
+		wantMeasurementSecret []byte
+		wantErr               bool
 	}{
 		"success": {
 			// base64 encoded: key=masterkey&salt=somesalt
-			kmsURI:     "kms://cluster-kms?key=bWFzdGVya2V5&salt=c29tZXNhbHQ=",
-			storageURI: "storage://no-store",
-			factory:    newStubKMS(nil, nil),
+			kmsURI:                "kms://cluster-kms?key=bWFzdGVya2V5&salt=c29tZXNhbHQ=",
+			storageURI:            "storage://no-store",
+			factory:               newStubKMS(nil, nil),
+			wantMeasurementSecret: []byte("someDEK"),
+		},
+		"pinned measurement secret": {
+			// base64 encoded: key=masterkey&salt=somesalt&measurementSecret=pinned
+			kmsURI:                "kms://cluster-kms?key=bWFzdGVya2V5&salt=c29tZXNhbHQ=&measurementSecret=cGlubmVk",
+			storageURI:            "storage://no-store",
+			factory:               newStubKMS(nil, nil),
+			wantMeasurementSecret: []byte("pinned"),
 		},
 		"kms init fails": {
 			factory: newStubKMS(errors.New("setup failed"), nil),
@@ -140,7 +149,7 @@ func TestRecover(t *testing.T) {
 			wg.Wait()
 			require.NoError(serveErr)
 			assert.NoError(err)
-			assert.NotNil(measurementSecret)
+			assert.Equal(tc.wantMeasurementSecret, measurementSecret)
 			assert.NotNil(diskKey)
 		})
 	}
@@ -195,6 +195,11 @@ const sidebars = {
           label: 'Recover your cluster',
           id: 'workflows/recovery',
         },
+        {
+          type: 'doc',
+          label: 'Back up etcd',
+          id: 'workflows/backup',
+        },
         {
           type: 'doc',
           label: 'Verify your cluster',
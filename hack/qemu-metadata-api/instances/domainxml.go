@@ -0,0 +1,297 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package instances
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// domainXML contains the parts of a libvirt domain definition the manager needs to read.
+type domainXML struct {
+	Name    string `xml:"name"`
+	Devices struct {
+		Disks []struct {
+			Device string `xml:"device,attr"`
+			Source struct {
+				File string `xml:"file,attr"`
+			} `xml:"source"`
+		} `xml:"disk"`
+		Interfaces []struct {
+			Type string `xml:"type,attr"`
+			MAC  struct {
+				Address string `xml:"address,attr"`
+			} `xml:"mac"`
+			Source struct {
+				Network string `xml:"network,attr"`
+			} `xml:"source"`
+		} `xml:"interface"`
+	} `xml:"devices"`
+}
+
+// diskFiles returns the file paths of the domain's disks in the order of definition.
+func (d domainXML) diskFiles() []string {
+	var files []string
+	for _, disk := range d.Devices.Disks {
+		if disk.Device != "" && disk.Device != "disk" {
+			continue
+		}
+		if disk.Source.File == "" {
+			continue
+		}
+		files = append(files, disk.Source.File)
+	}
+	return files
+}
+
+// macInNetwork returns the MAC address of the domain's interface in the given libvirt network.
+func (d domainXML) macInNetwork(network string) (string, bool) {
+	for _, iface := range d.Devices.Interfaces {
+		if iface.Type == "network" && iface.Source.Network == network {
+			return strings.ToLower(iface.MAC.Address), true
+		}
+	}
+	return "", false
+}
+
+func parseDomainXML(raw string) (domainXML, error) {
+	var dom domainXML
+	if err := xml.Unmarshal([]byte(raw), &dom); err != nil {
+		return domainXML{}, fmt.Errorf("parsing domain XML: %w", err)
+	}
+	return dom, nil
+}
+
+// cloneDomainXML derives the definition of a new domain from the definition of an existing one.
+// The new domain uses the given disk files instead of the disks of the template,
+// and libvirt generates a new UUID and MAC address when the domain is defined.
+func cloneDomainXML(template, name string, diskFiles []string) (string, error) {
+	var root xmlNode
+	if err := xml.Unmarshal([]byte(template), &root); err != nil {
+		return "", fmt.Errorf("parsing domain XML: %w", err)
+	}
+	if root.XMLName.Local != "domain" {
+		return "", fmt.Errorf("expected domain XML, got %q", root.XMLName.Local)
+	}
+	root.trimSpace()
+
+	nameNode := root.child("name")
+	if nameNode == nil {
+		return "", errors.New("domain XML has no name")
+	}
+	nameNode.Content = name
+	// Domain metadata contains XML of foreign namespaces and is set by the manager through libvirt.
+	root.removeChildren("uuid", "metadata")
+
+	if osNode := root.child("os"); osNode != nil {
+		if nvram := osNode.child("nvram"); nvram != nil && nvram.Content != "" {
+			nvram.Content = path.Join(path.Dir(nvram.Content), name+"_VARS.fd")
+		}
+	}
+
+	devices := root.child("devices")
+	if devices == nil {
+		return "", errors.New("domain XML has no devices")
+	}
+	var diskIdx int
+	for _, dev := range devices.Children {
+		switch dev.XMLName.Local {
+		case "disk":
+			if device := dev.attr("device"); device != "" && device != "disk" {
+				continue
+			}
+			source := dev.child("source")
+			if source == nil || source.attr("file") == "" {
+				continue
+			}
+			if diskIdx >= len(diskFiles) {
+				return "", fmt.Errorf("domain has more than %d disks", len(diskFiles))
+			}
+			source.setAttr("file", diskFiles[diskIdx])
+			// The backing chain of the new disk is read by libvirt.
+			dev.removeChildren("backingStore")
+			diskIdx++
+		case "interface":
+			dev.removeChildren("mac", "target", "alias")
+		}
+	}
+	if diskIdx != len(diskFiles) {
+		return "", fmt.Errorf("domain has %d disks, expected %d", diskIdx, len(diskFiles))
+	}
+
+	out, err := xml.MarshalIndent(&root, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("marshaling domain XML: %w", err)
+	}
+	return string(out), nil
+}
+
+// xmlNode is a generic XML element, used to modify libvirt XML without knowing its full schema.
+type xmlNode struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr `xml:",any,attr"`
+	Content  string     `xml:",chardata"`
+	Children []*xmlNode `xml:",any"`
+}
+
+// MarshalXML marshals the node, leaving namespace declarations to the encoder.
+func (n *xmlNode) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	start.Name = n.XMLName
+	start.Attr = nil
+	for _, attr := range n.Attrs {
+		if attr.Name.Space == "xmlns" || attr.Name.Local == "xmlns" {
+			continue
+		}
+		start.Attr = append(start.Attr, attr)
+	}
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	if n.Content != "" {
+		if err := e.EncodeToken(xml.CharData(n.Content)); err != nil {
+			return err
+		}
+	}
+	for _, child := range n.Children {
+		if err := e.Encode(child); err != nil {
+			return err
+		}
+	}
+	return e.EncodeToken(start.End())
+}
+
+// trimSpace removes the indentation of the XML document.
+func (n *xmlNode) trimSpace() {
+	if len(n.Children) > 0 {
+		n.Content = strings.TrimSpace(n.Content)
+	}
+	for _, child := range n.Children {
+		child.trimSpace()
+	}
+}
+
+func (n *xmlNode) child(name string) *xmlNode {
+	for _, child := range n.Children {
+		if child.XMLName.Local == name {
+			return child
+		}
+	}
+	return nil
+}
+
+func (n *xmlNode) removeChildren(names ...string) {
+	children := n.Children[:0]
+	for _, child := range n.Children {
+		remove := false
+		for _, name := range names {
+			if child.XMLName.Local == name {
+				remove = true
+				break
+			}
+		}
+		if !remove {
+			children = append(children, child)
+		}
+	}
+	n.Children = children
+}
+
+func (n *xmlNode) attr(name string) string {
+	for _, attr := range n.Attrs {
+		if attr.Name.Space == "" && attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+func (n *xmlNode) setAttr(name, value string) {
+	for i, attr := range n.Attrs {
+		if attr.Name.Space == "" && attr.Name.Local == name {
+			n.Attrs[i].Value = value
+			return
+		}
+	}
+	n.Attrs = append(n.Attrs, xml.Attr{Name: xml.Name{Local: name}, Value: value})
+}
+
+// volumeXML is a libvirt storage volume definition.
+type volumeXML struct {
+	XMLName  xml.Name `xml:"volume"`
+	Name     string   `xml:"name"`
+	Capacity struct {
+		Unit  string `xml:"unit,attr,omitempty"`
+		Value uint64 `xml:",chardata"`
+	} `xml:"capacity"`
+	Target struct {
+		Path   string       `xml:"path,omitempty"`
+		Format volumeFormat `xml:"format"`
+	} `xml:"target"`
+	BackingStore *backingStoreXML `xml:"backingStore,omitempty"`
+}
+
+type backingStoreXML struct {
+	Path   string       `xml:"path"`
+	Format volumeFormat `xml:"format"`
+}
+
+type volumeFormat struct {
+	Type string `xml:"type,attr"`
+}
+
+func parseVolumeXML(raw string) (volumeXML, error) {
+	var vol volumeXML
+	if err := xml.Unmarshal([]byte(raw), &vol); err != nil {
+		return volumeXML{}, fmt.Errorf("parsing volume XML: %w", err)
+	}
+	return vol, nil
+}
+
+// networkXML contains the parts of a libvirt network definition the manager needs to read.
+type networkXML struct {
+	IPs []struct {
+		DHCP struct {
+			Hosts []dhcpHostXML `xml:"host"`
+		} `xml:"dhcp"`
+	} `xml:"ip"`
+}
+
+// hosts returns the static DHCP hosts of the network.
+func (n networkXML) hosts() []dhcpHostXML {
+	var hosts []dhcpHostXML
+	for _, ip := range n.IPs {
+		hosts = append(hosts, ip.DHCP.Hosts...)
+	}
+	return hosts
+}
+
+// dhcpHostXML is a static DHCP host entry of a libvirt network.
+type dhcpHostXML struct {
+	XMLName xml.Name `xml:"host"`
+	MAC     string   `xml:"mac,attr"`
+	Name    string   `xml:"name,attr"`
+	IP      string   `xml:"ip,attr"`
+}
+
+func parseNetworkXML(raw string) (networkXML, error) {
+	var network networkXML
+	if err := xml.Unmarshal([]byte(raw), &network); err != nil {
+		return networkXML{}, fmt.Errorf("parsing network XML: %w", err)
+	}
+	return network, nil
+}
+
+func marshalXML(v any) (string, error) {
+	out, err := xml.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
@@ -0,0 +1,630 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+/*
+Package instances manages the libvirt domains of a Constellation cluster on QEMU.
+
+Instances are grouped into scaling groups by the names Terraform gives their domains:
+"<name>-<role>-<group uid>-<index>". New instances are cloned from an existing instance of their group.
+
+Terraform does not know about the instances created here. To not collide with instances Terraform creates later,
+their domains are named "<name>-<role>-<group uid>-op-<index>", their hostnames "<role>-op-<index>",
+and their IPs are allocated from the end of the subnet, while Terraform allocates from its start.
+*/
+package instances
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/edgelesssys/constellation/v2/internal/cloud/qemu"
+	"github.com/edgelesssys/constellation/v2/internal/role"
+)
+
+var (
+	domainNameRegexp = regexp.MustCompile(`^(.+-(control-plane|worker)-[0-9a-f]+)-(op-)?([0-9]+)$`)
+	// operatorHostnameRegexp matches the hostnames of instances created by the manager.
+	operatorHostnameRegexp = regexp.MustCompile(`^(control-plane|worker)-op-[0-9]+$`)
+)
+
+// Manager manages the QEMU instances of a Constellation cluster.
+type Manager struct {
+	virt    virtAPI
+	network string
+
+	// mux serializes instance creation and deletion, which allocate names and IPs.
+	mux sync.Mutex
+}
+
+func newManager(virt virtAPI, network string) *Manager {
+	return &Manager{
+		virt:    virt,
+		network: network,
+	}
+}
+
+// ListScalingGroups returns the scaling groups of the cluster.
+func (m *Manager) ListScalingGroups() ([]qemu.ScalingGroup, error) {
+	domains, err := m.listDomains()
+	if err != nil {
+		return nil, err
+	}
+
+	var groups []qemu.ScalingGroup
+	for _, dom := range domains {
+		if len(groups) > 0 && groups[len(groups)-1].ID == dom.groupID {
+			continue
+		}
+		image, err := m.groupImage(domains, dom.groupID)
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, qemu.ScalingGroup{
+			ID:    dom.groupID,
+			Role:  dom.role,
+			Image: image,
+		})
+	}
+	return groups, nil
+}
+
+// SetScalingGroupImage sets the image new instances of a scaling group boot from.
+// The image is the path of a libvirt volume.
+func (m *Manager) SetScalingGroupImage(groupID, image string) error {
+	if _, err := m.virt.volumeXML(image); err != nil {
+		return fmt.Errorf("getting image volume %q: %w", image, err)
+	}
+
+	domains, err := m.listDomains()
+	if err != nil {
+		return err
+	}
+	members := groupMembers(domains, groupID)
+	if len(members) == 0 {
+		return fmt.Errorf("scaling group %q: %w", groupID, qemu.ErrInstanceNotFound)
+	}
+	for _, dom := range members {
+		if err := m.virt.setDomainImage(dom.name, image); err != nil {
+			return fmt.Errorf("setting image of domain %q: %w", dom.name, err)
+		}
+	}
+	return nil
+}
+
+// CreateInstance creates a new instance in a scaling group.
+// The instance is a clone of the group's first instance, booting from the group's image.
+func (m *Manager) CreateInstance(groupID string) (inst qemu.Instance, retErr error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	domains, err := m.listDomains()
+	if err != nil {
+		return qemu.Instance{}, err
+	}
+	members := groupMembers(domains, groupID)
+	if len(members) == 0 {
+		return qemu.Instance{}, fmt.Errorf("scaling group %q: %w", groupID, qemu.ErrInstanceNotFound)
+	}
+	template := members[0]
+	if len(template.disks) != 2 {
+		return qemu.Instance{}, fmt.Errorf("expected domain %q to have a boot and a state disk, got %d disks", template.name, len(template.disks))
+	}
+	image, err := m.groupImage(domains, groupID)
+	if err != nil {
+		return qemu.Instance{}, err
+	}
+
+	network, err := m.networkXML()
+	if err != nil {
+		return qemu.Instance{}, err
+	}
+	host, err := nextDHCPHost(network.hosts(), template)
+	if err != nil {
+		return qemu.Instance{}, err
+	}
+	name := fmt.Sprintf("%s-op-%d", groupID, nextOperatorIndex(members))
+
+	// Undo all changes if the instance can not be created completely.
+	var cleanups []func() error
+	defer func() {
+		if retErr == nil {
+			return
+		}
+		for i := len(cleanups) - 1; i >= 0; i-- {
+			if err := cleanups[i](); err != nil {
+				retErr = errors.Join(retErr, err)
+			}
+		}
+	}()
+
+	bootDisk, err := m.createBootVolume(name+"-boot", template.disks[0], image)
+	if err != nil {
+		return qemu.Instance{}, err
+	}
+	cleanups = append(cleanups, func() error { return m.virt.deleteVolume(bootDisk) })
+	stateDisk, err := m.createStateVolume(name+"-state", template.disks[1])
+	if err != nil {
+		return qemu.Instance{}, err
+	}
+	cleanups = append(cleanups, func() error { return m.virt.deleteVolume(stateDisk) })
+
+	domXML, err := cloneDomainXML(template.xml, name, []string{bootDisk, stateDisk})
+	if err != nil {
+		return qemu.Instance{}, err
+	}
+	if err := m.virt.defineDomain(domXML); err != nil {
+		return qemu.Instance{}, fmt.Errorf("defining domain %q: %w", name, err)
+	}
+	cleanups = append(cleanups, func() error { return m.virt.undefineDomain(name) })
+	if err := m.virt.setDomainImage(name, image); err != nil {
+		return qemu.Instance{}, fmt.Errorf("setting image of domain %q: %w", name, err)
+	}
+
+	// libvirt generates the MAC address of the new domain when defining it.
+	definedXML, err := m.virt.domainXML(name)
+	if err != nil {
+		return qemu.Instance{}, fmt.Errorf("getting XML of domain %q: %w", name, err)
+	}
+	defined, err := parseDomainXML(definedXML)
+	if err != nil {
+		return qemu.Instance{}, err
+	}
+	mac, ok := defined.macInNetwork(m.network)
+	if !ok || mac == "" {
+		return qemu.Instance{}, fmt.Errorf("domain %q has no interface in network %q", name, m.network)
+	}
+	host.MAC = mac
+	hostXML, err := marshalXML(host)
+	if err != nil {
+		return qemu.Instance{}, fmt.Errorf("marshaling DHCP host: %w", err)
+	}
+	if err := m.virt.addDHCPHost(hostXML); err != nil {
+		return qemu.Instance{}, fmt.Errorf("adding DHCP host %q: %w", host.Name, err)
+	}
+	cleanups = append(cleanups, func() error { return m.virt.removeDHCPHost(hostXML) })
+
+	if err := m.virt.startDomain(name); err != nil {
+		return qemu.Instance{}, fmt.Errorf("starting domain %q: %w", name, err)
+	}
+
+	return qemu.Instance{
+		Name:           host.Name,
+		ProviderID:     qemu.ProviderID(host.Name),
+		ScalingGroupID: groupID,
+		Image:          image,
+		State:          qemu.InstanceStateRunning,
+	}, nil
+}
+
+// GetInstance returns the instance with the given hostname.
+func (m *Manager) GetInstance(hostname string) (qemu.Instance, error) {
+	dom, _, err := m.findInstance(hostname)
+	if err != nil {
+		return qemu.Instance{}, err
+	}
+	if dom == nil {
+		return qemu.Instance{}, fmt.Errorf("instance %q: %w", hostname, qemu.ErrInstanceNotFound)
+	}
+	image, err := m.diskImage(dom.disks)
+	if err != nil {
+		return qemu.Instance{}, err
+	}
+	state, err := m.virt.domainState(dom.name)
+	if err != nil {
+		return qemu.Instance{}, fmt.Errorf("getting state of domain %q: %w", dom.name, err)
+	}
+	return qemu.Instance{
+		Name:           hostname,
+		ProviderID:     qemu.ProviderID(hostname),
+		ScalingGroupID: dom.groupID,
+		Image:          image,
+		State:          state,
+	}, nil
+}
+
+// DeleteInstance deletes the instance with the given hostname, including its disks.
+func (m *Manager) DeleteInstance(hostname string) error {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	dom, host, err := m.findInstance(hostname)
+	if err != nil {
+		return err
+	}
+	// The domain is already gone if a previous deletion failed to remove the DHCP host.
+	if dom != nil {
+		if err := m.deleteDomain(*dom); err != nil {
+			return err
+		}
+	}
+	return m.removeDHCPHost(host)
+}
+
+// DeleteOperatorInstances deletes all instances created by CreateInstance, including their disks.
+// Terraform does not know about these instances, so they have to be deleted before Terraform destroys the cluster.
+func (m *Manager) DeleteOperatorInstances() error {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	domains, err := m.listDomains()
+	if err != nil {
+		return err
+	}
+	network, err := m.networkXML()
+	if err != nil {
+		return err
+	}
+	for _, dom := range domains {
+		if !dom.operatorNode {
+			continue
+		}
+		if err := m.deleteDomain(dom); err != nil {
+			return err
+		}
+	}
+	for _, host := range network.hosts() {
+		if !operatorHostnameRegexp.MatchString(host.Name) {
+			continue
+		}
+		if err := m.removeDHCPHost(host); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteDomain deletes a domain and its disks.
+func (m *Manager) deleteDomain(dom domain) error {
+	if err := m.virt.undefineDomain(dom.name); err != nil {
+		return fmt.Errorf("deleting domain %q: %w", dom.name, err)
+	}
+	for _, disk := range dom.disks {
+		if err := m.virt.deleteVolume(disk); err != nil {
+			return fmt.Errorf("deleting disk %q of domain %q: %w", disk, dom.name, err)
+		}
+	}
+	return nil
+}
+
+func (m *Manager) removeDHCPHost(host dhcpHostXML) error {
+	hostXML, err := marshalXML(host)
+	if err != nil {
+		return fmt.Errorf("marshaling DHCP host: %w", err)
+	}
+	if err := m.virt.removeDHCPHost(hostXML); err != nil {
+		return fmt.Errorf("removing DHCP host %q: %w", host.Name, err)
+	}
+	return nil
+}
+
+// findInstance returns the domain and DHCP host entry of the instance with the given hostname.
+// The returned domain is nil if only the DHCP host entry exists.
+func (m *Manager) findInstance(hostname string) (*domain, dhcpHostXML, error) {
+	network, err := m.networkXML()
+	if err != nil {
+		return nil, dhcpHostXML{}, err
+	}
+	var host dhcpHostXML
+	for _, h := range network.hosts() {
+		if h.Name == hostname {
+			host = h
+			break
+		}
+	}
+	if host.Name == "" {
+		return nil, dhcpHostXML{}, fmt.Errorf("instance %q: %w", hostname, qemu.ErrInstanceNotFound)
+	}
+
+	domains, err := m.listDomains()
+	if err != nil {
+		return nil, dhcpHostXML{}, err
+	}
+	for _, dom := range domains {
+		if dom.mac == strings.ToLower(host.MAC) {
+			return &dom, host, nil
+		}
+	}
+	return nil, host, nil
+}
+
+// groupImage returns the image of a scaling group.
+// If no image was set explicitly, the image of the group's first instance is used.
+func (m *Manager) groupImage(domains []domain, groupID string) (string, error) {
+	members := groupMembers(domains, groupID)
+	for _, dom := range members {
+		image, err := m.virt.domainImage(dom.name)
+		if err != nil {
+			return "", fmt.Errorf("getting image of domain %q: %w", dom.name, err)
+		}
+		if image != "" {
+			return image, nil
+		}
+	}
+	if len(members) == 0 {
+		return "", fmt.Errorf("scaling group %q: %w", groupID, qemu.ErrInstanceNotFound)
+	}
+	return m.diskImage(members[0].disks)
+}
+
+// diskImage returns the image an instance booted from, which is the backing store of its boot disk.
+func (m *Manager) diskImage(disks []string) (string, error) {
+	if len(disks) == 0 {
+		return "", errors.New("instance has no disks")
+	}
+	raw, err := m.virt.volumeXML(disks[0])
+	if err != nil {
+		return "", fmt.Errorf("getting boot volume %q: %w", disks[0], err)
+	}
+	vol, err := parseVolumeXML(raw)
+	if err != nil {
+		return "", err
+	}
+	if vol.BackingStore == nil || vol.BackingStore.Path == "" {
+		return "", fmt.Errorf("boot volume %q has no backing store", disks[0])
+	}
+	return vol.BackingStore.Path, nil
+}
+
+// createBootVolume creates a copy-on-write boot volume on top of the image, next to the template's boot volume.
+func (m *Manager) createBootVolume(name, templateDisk, image string) (string, error) {
+	templateRaw, err := m.virt.volumeXML(templateDisk)
+	if err != nil {
+		return "", fmt.Errorf("getting volume %q: %w", templateDisk, err)
+	}
+	template, err := parseVolumeXML(templateRaw)
+	if err != nil {
+		return "", err
+	}
+	imageRaw, err := m.virt.volumeXML(image)
+	if err != nil {
+		return "", fmt.Errorf("getting image volume %q: %w", image, err)
+	}
+	imageVol, err := parseVolumeXML(imageRaw)
+	if err != nil {
+		return "", err
+	}
+
+	vol := volumeXML{Name: name}
+	vol.Capacity = template.Capacity
+	vol.Target.Format = template.Target.Format
+	vol.BackingStore = &backingStoreXML{
+		Path:   image,
+		Format: imageVol.Target.Format,
+	}
+	return m.createVolume(templateDisk, vol)
+}
+
+// createStateVolume creates an empty state volume of the template's size, next to the template's state volume.
+func (m *Manager) createStateVolume(name, templateDisk string) (string, error) {
+	templateRaw, err := m.virt.volumeXML(templateDisk)
+	if err != nil {
+		return "", fmt.Errorf("getting volume %q: %w", templateDisk, err)
+	}
+	template, err := parseVolumeXML(templateRaw)
+	if err != nil {
+		return "", err
+	}
+
+	vol := volumeXML{Name: name}
+	vol.Capacity = template.Capacity
+	vol.Target.Format = template.Target.Format
+	return m.createVolume(templateDisk, vol)
+}
+
+func (m *Manager) createVolume(sibling string, vol volumeXML) (string, error) {
+	raw, err := marshalXML(vol)
+	if err != nil {
+		return "", fmt.Errorf("marshaling volume %q: %w", vol.Name, err)
+	}
+	path, err := m.virt.createVolume(sibling, raw)
+	if err != nil {
+		return "", fmt.Errorf("creating volume %q: %w", vol.Name, err)
+	}
+	return path, nil
+}
+
+func (m *Manager) networkXML() (networkXML, error) {
+	raw, err := m.virt.networkXML()
+	if err != nil {
+		return networkXML{}, fmt.Errorf("getting network %q: %w", m.network, err)
+	}
+	return parseNetworkXML(raw)
+}
+
+// listDomains returns the domains of the cluster, sorted by scaling group and index.
+// Within a scaling group, domains created by Terraform come before domains created by the manager.
+func (m *Manager) listDomains() ([]domain, error) {
+	names, err := m.virt.listDomains()
+	if err != nil {
+		return nil, fmt.Errorf("listing domains: %w", err)
+	}
+
+	var domains []domain
+	for _, name := range names {
+		matches := domainNameRegexp.FindStringSubmatch(name)
+		if matches == nil {
+			continue
+		}
+		index, err := strconv.Atoi(matches[4])
+		if err != nil {
+			continue
+		}
+		raw, err := m.virt.domainXML(name)
+		if errors.Is(err, qemu.ErrInstanceNotFound) {
+			// domain was deleted in the meantime
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("getting XML of domain %q: %w", name, err)
+		}
+		dom, err := parseDomainXML(raw)
+		if err != nil {
+			return nil, err
+		}
+		mac, ok := dom.macInNetwork(m.network)
+		if !ok {
+			// domain belongs to another cluster
+			continue
+		}
+		domains = append(domains, domain{
+			name:         name,
+			groupID:      matches[1],
+			role:         role.FromString(matches[2]),
+			index:        index,
+			operatorNode: matches[3] != "",
+			xml:          raw,
+			disks:        dom.diskFiles(),
+			mac:          mac,
+		})
+	}
+
+	sort.Slice(domains, func(i, j int) bool {
+		if domains[i].groupID != domains[j].groupID {
+			return domains[i].groupID < domains[j].groupID
+		}
+		if domains[i].operatorNode != domains[j].operatorNode {
+			return !domains[i].operatorNode
+		}
+		return domains[i].index < domains[j].index
+	})
+	return domains, nil
+}
+
+// domain is a libvirt domain belonging to the cluster.
+type domain struct {
+	name    string
+	groupID string
+	role    role.Role
+	index   int
+	// operatorNode is true if the domain was created by the manager instead of Terraform.
+	operatorNode bool
+	xml          string
+	disks        []string
+	mac          string
+}
+
+func groupMembers(domains []domain, groupID string) []domain {
+	var members []domain
+	for _, dom := range domains {
+		if dom.groupID == groupID {
+			members = append(members, dom)
+		}
+	}
+	return members
+}
+
+// nextOperatorIndex returns the index of the next domain created by the manager in a scaling group.
+func nextOperatorIndex(members []domain) int {
+	next := 0
+	for _, dom := range members {
+		if dom.operatorNode && dom.index >= next {
+			next = dom.index + 1
+		}
+	}
+	return next
+}
+
+// nextDHCPHost returns the DHCP host entry for a new instance of the template's scaling group.
+// The hostname is "<role>-op-<index>" with an index not used by any other instance of the role,
+// and the IP is the highest free address of the template's subnet.
+// Terraform assigns addresses from the start of the subnet, so the two never overlap unless the subnet is full.
+func nextDHCPHost(hosts []dhcpHostXML, template domain) (dhcpHostXML, error) {
+	var templateHost *dhcpHostXML
+	for i := range hosts {
+		if strings.EqualFold(hosts[i].MAC, template.mac) {
+			templateHost = &hosts[i]
+			break
+		}
+	}
+	if templateHost == nil {
+		return dhcpHostXML{}, fmt.Errorf("no DHCP host found for domain %q", template.name)
+	}
+	templateIP, err := netip.ParseAddr(templateHost.IP)
+	if err != nil {
+		return dhcpHostXML{}, fmt.Errorf("parsing IP of domain %q: %w", template.name, err)
+	}
+	// Terraform assigns a /24 subnet to the instances of each role.
+	subnet, err := templateIP.Prefix(24)
+	if err != nil {
+		return dhcpHostXML{}, fmt.Errorf("getting subnet of domain %q: %w", template.name, err)
+	}
+
+	hostnamePrefix := template.role.TFString() + "-op-"
+	nextIndex := 0
+	usedIPs := map[netip.Addr]struct{}{}
+	for _, host := range hosts {
+		if index, ok := strings.CutPrefix(host.Name, hostnamePrefix); ok {
+			if i, err := strconv.Atoi(index); err == nil && i >= nextIndex {
+				nextIndex = i + 1
+			}
+		}
+		if ip, err := netip.ParseAddr(host.IP); err == nil {
+			usedIPs[ip] = struct{}{}
+		}
+	}
+
+	// The last address of the subnet is the broadcast address, the first one the network address.
+	nextIP := lastAddr(subnet).Prev()
+	for ; nextIP != subnet.Addr(); nextIP = nextIP.Prev() {
+		if _, ok := usedIPs[nextIP]; !ok {
+			break
+		}
+	}
+	if nextIP == subnet.Addr() {
+		return dhcpHostXML{}, fmt.Errorf("no free IP left in subnet %s", subnet)
+	}
+
+	return dhcpHostXML{
+		Name: fmt.Sprintf("%s%d", hostnamePrefix, nextIndex),
+		IP:   nextIP.String(),
+	}, nil
+}
+
+// lastAddr returns the last address of an IPv4 prefix.
+func lastAddr(prefix netip.Prefix) netip.Addr {
+	addr := prefix.Masked().Addr().As4()
+	for i := prefix.Bits(); i < 32; i++ {
+		addr[i/8] |= 1 << (7 - i%8)
+	}
+	return netip.AddrFrom4(addr)
+}
+
+// virtAPI is the libvirt functionality used by the manager.
+type virtAPI interface {
+	// listDomains returns the names of all domains.
+	listDomains() ([]string, error)
+	// domainXML returns the inactive XML definition of a domain.
+	domainXML(name string) (string, error)
+	// domainState returns the state of a domain.
+	domainState(name string) (qemu.InstanceState, error)
+	// domainImage returns the image stored in a domain's metadata, or an empty string.
+	domainImage(name string) (string, error)
+	// setDomainImage stores an image in a domain's metadata.
+	setDomainImage(name, image string) error
+	// defineDomain defines a new domain.
+	defineDomain(xml string) error
+	// startDomain starts a defined domain.
+	startDomain(name string) error
+	// undefineDomain stops a domain and removes its definition.
+	undefineDomain(name string) error
+	// volumeXML returns the XML definition of the volume with the given path.
+	volumeXML(path string) (string, error)
+	// createVolume creates a volume in the storage pool of the sibling volume and returns its path.
+	createVolume(sibling, xml string) (string, error)
+	// deleteVolume deletes the volume with the given path.
+	deleteVolume(path string) error
+	// networkXML returns the XML definition of the cluster network.
+	networkXML() (string, error)
+	// addDHCPHost adds a static DHCP host entry to the cluster network.
+	addDHCPHost(xml string) error
+	// removeDHCPHost removes a static DHCP host entry from the cluster network.
+	removeDHCPHost(xml string) error
+}
@@ -0,0 +1,706 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package instances
+
+import (
+	"encoding/xml"
+	"fmt"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/edgelesssys/constellation/v2/internal/cloud/qemu"
+	"github.com/edgelesssys/constellation/v2/internal/role"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testNetwork  = "constell-network"
+	testImage    = "/var/lib/libvirt/images/constell-image"
+	testNewImage = "/var/lib/libvirt/images/constell-image-v2"
+	workerGroup  = "constell-worker-1a2b3c4d"
+	cpGroup      = "constell-control-plane-5e6f7a8b"
+)
+
+func TestListScalingGroups(t *testing.T) {
+	testCases := map[string]struct {
+		virt       *stubVirt
+		wantGroups []qemu.ScalingGroup
+		wantErr    bool
+	}{
+		"groups of cluster": {
+			virt: newTestVirt(),
+			wantGroups: []qemu.ScalingGroup{
+				{ID: cpGroup, Role: role.ControlPlane, Image: testImage},
+				{ID: workerGroup, Role: role.Worker, Image: testImage},
+			},
+		},
+		"group image set": {
+			virt: func() *stubVirt {
+				v := newTestVirt()
+				v.images[workerGroup+"-1"] = testNewImage
+				return v
+			}(),
+			wantGroups: []qemu.ScalingGroup{
+				{ID: cpGroup, Role: role.ControlPlane, Image: testImage},
+				{ID: workerGroup, Role: role.Worker, Image: testNewImage},
+			},
+		},
+		"operator instances belong to their group": {
+			virt: func() *stubVirt {
+				v := newTestVirt()
+				v.addInstance(workerGroup+"-op-0", "worker-op-0", "52:54:00:00:02:02", "10.42.2.254")
+				return v
+			}(),
+			wantGroups: []qemu.ScalingGroup{
+				{ID: cpGroup, Role: role.ControlPlane, Image: testImage},
+				{ID: workerGroup, Role: role.Worker, Image: testImage},
+			},
+		},
+		"domains of other networks and unknown domains are ignored": {
+			virt: func() *stubVirt {
+				v := newTestVirt()
+				v.addDomain("other-worker-1a2b3c4d-0", "other-network", "52:54:00:00:00:99")
+				v.domains["some-vm"] = v.domains[workerGroup+"-0"]
+				return v
+			}(),
+			wantGroups: []qemu.ScalingGroup{
+				{ID: cpGroup, Role: role.ControlPlane, Image: testImage},
+				{ID: workerGroup, Role: role.Worker, Image: testImage},
+			},
+		},
+		"listing domains fails": {
+			virt: func() *stubVirt {
+				v := newTestVirt()
+				v.listErr = assert.AnError
+				return v
+			}(),
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			m := newManager(tc.virt, testNetwork)
+			groups, err := m.ListScalingGroups()
+			if tc.wantErr {
+				assert.Error(err)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(tc.wantGroups, groups)
+		})
+	}
+}
+
+func TestSetScalingGroupImage(t *testing.T) {
+	testCases := map[string]struct {
+		groupID string
+		image   string
+		wantErr bool
+	}{
+		"set image": {
+			groupID: workerGroup,
+			image:   testNewImage,
+		},
+		"unknown group": {
+			groupID: "constell-worker-ffffffff",
+			image:   testNewImage,
+			wantErr: true,
+		},
+		"unknown image": {
+			groupID: workerGroup,
+			image:   "/var/lib/libvirt/images/unknown",
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			virt := newTestVirt()
+			m := newManager(virt, testNetwork)
+			err := m.SetScalingGroupImage(tc.groupID, tc.image)
+			if tc.wantErr {
+				assert.Error(err)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(tc.image, virt.images[workerGroup+"-0"])
+			assert.Equal(tc.image, virt.images[workerGroup+"-1"])
+			assert.Empty(virt.images[cpGroup+"-0"])
+		})
+	}
+}
+
+func TestCreateInstance(t *testing.T) {
+	testCases := map[string]struct {
+		virt         *stubVirt
+		groupID      string
+		wantInstance qemu.Instance
+		wantDomain   string
+		wantHost     dhcpHostXML
+		wantImage    string
+		wantErr      bool
+	}{
+		"create worker": {
+			virt:    newTestVirt(),
+			groupID: workerGroup,
+			wantInstance: qemu.Instance{
+				Name:           "worker-op-0",
+				ProviderID:     "qemu:///hostname/worker-op-0",
+				ScalingGroupID: workerGroup,
+				Image:          testImage,
+				State:          qemu.InstanceStateRunning,
+			},
+			wantDomain: workerGroup + "-op-0",
+			wantHost:   dhcpHostXML{Name: "worker-op-0", IP: "10.42.2.254"},
+			wantImage:  testImage,
+		},
+		"index and IP continue after operator instances": {
+			virt: func() *stubVirt {
+				v := newTestVirt()
+				v.addInstance(workerGroup+"-op-2", "worker-op-2", "52:54:00:00:02:02", "10.42.2.254")
+				return v
+			}(),
+			groupID: workerGroup,
+			wantInstance: qemu.Instance{
+				Name:           "worker-op-3",
+				ProviderID:     "qemu:///hostname/worker-op-3",
+				ScalingGroupID: workerGroup,
+				Image:          testImage,
+				State:          qemu.InstanceStateRunning,
+			},
+			wantDomain: workerGroup + "-op-3",
+			wantHost:   dhcpHostXML{Name: "worker-op-3", IP: "10.42.2.253"},
+			wantImage:  testImage,
+		},
+		"create control plane with new image": {
+			virt: func() *stubVirt {
+				v := newTestVirt()
+				v.images[cpGroup+"-0"] = testNewImage
+				return v
+			}(),
+			groupID: cpGroup,
+			wantInstance: qemu.Instance{
+				Name:           "control-plane-op-0",
+				ProviderID:     "qemu:///hostname/control-plane-op-0",
+				ScalingGroupID: cpGroup,
+				Image:          testNewImage,
+				State:          qemu.InstanceStateRunning,
+			},
+			wantDomain: cpGroup + "-op-0",
+			wantHost:   dhcpHostXML{Name: "control-plane-op-0", IP: "10.42.1.254"},
+			wantImage:  testNewImage,
+		},
+		"unknown group": {
+			virt:    newTestVirt(),
+			groupID: "constell-worker-ffffffff",
+			wantErr: true,
+		},
+		"starting domain fails": {
+			virt: func() *stubVirt {
+				v := newTestVirt()
+				v.startErr = assert.AnError
+				return v
+			}(),
+			groupID: workerGroup,
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			wantDomains := len(tc.virt.domains)
+			wantVolumes := len(tc.virt.volumes)
+			wantHosts := len(tc.virt.hosts)
+
+			m := newManager(tc.virt, testNetwork)
+			inst, err := m.CreateInstance(tc.groupID)
+			if tc.wantErr {
+				assert.Error(err)
+				// all changes are rolled back
+				assert.Len(tc.virt.domains, wantDomains)
+				assert.Len(tc.virt.volumes, wantVolumes)
+				assert.Len(tc.virt.hosts, wantHosts)
+				return
+			}
+			require.NoError(err)
+			assert.Equal(tc.wantInstance, inst)
+
+			require.Contains(tc.virt.domains, tc.wantDomain)
+			assert.Contains(tc.virt.running, tc.wantDomain)
+			assert.Equal(tc.wantImage, tc.virt.images[tc.wantDomain])
+			dom, err := parseDomainXML(tc.virt.domains[tc.wantDomain])
+			require.NoError(err)
+			assert.Equal(tc.wantDomain, dom.Name)
+			disks := dom.diskFiles()
+			require.Len(disks, 2)
+			boot, err := parseVolumeXML(tc.virt.volumes[disks[0]])
+			require.NoError(err)
+			require.NotNil(boot.BackingStore)
+			assert.Equal(tc.wantImage, boot.BackingStore.Path)
+			assert.Contains(tc.virt.volumes, disks[1])
+
+			mac, ok := dom.macInNetwork(testNetwork)
+			require.True(ok)
+			tc.wantHost.MAC = mac
+			assert.Contains(tc.virt.hosts, tc.wantHost)
+		})
+	}
+}
+
+func TestGetInstance(t *testing.T) {
+	testCases := map[string]struct {
+		hostname     string
+		wantInstance qemu.Instance
+		wantNotFound bool
+	}{
+		"get instance": {
+			hostname: "worker-1",
+			wantInstance: qemu.Instance{
+				Name:           "worker-1",
+				ProviderID:     "qemu:///hostname/worker-1",
+				ScalingGroupID: workerGroup,
+				Image:          testImage,
+				State:          qemu.InstanceStateRunning,
+			},
+		},
+		"unknown instance": {
+			hostname:     "worker-5",
+			wantNotFound: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			m := newManager(newTestVirt(), testNetwork)
+			inst, err := m.GetInstance(tc.hostname)
+			if tc.wantNotFound {
+				assert.ErrorIs(err, qemu.ErrInstanceNotFound)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(tc.wantInstance, inst)
+		})
+	}
+}
+
+func TestDeleteInstance(t *testing.T) {
+	testCases := map[string]struct {
+		virt               *stubVirt
+		hostname           string
+		wantVolumesDeleted bool
+		wantNotFound       bool
+	}{
+		"delete instance": {
+			virt:               newTestVirt(),
+			hostname:           "worker-0",
+			wantVolumesDeleted: true,
+		},
+		"domain already deleted": {
+			virt: func() *stubVirt {
+				v := newTestVirt()
+				delete(v.domains, workerGroup+"-0")
+				return v
+			}(),
+			hostname: "worker-0",
+		},
+		"unknown instance": {
+			virt:         newTestVirt(),
+			hostname:     "worker-5",
+			wantNotFound: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			m := newManager(tc.virt, testNetwork)
+			err := m.DeleteInstance(tc.hostname)
+			if tc.wantNotFound {
+				assert.ErrorIs(err, qemu.ErrInstanceNotFound)
+				return
+			}
+			assert.NoError(err)
+			assert.NotContains(tc.virt.domains, workerGroup+"-0")
+			if tc.wantVolumesDeleted {
+				assert.NotContains(tc.virt.volumes, "/pool/"+workerGroup+"-0-boot")
+				assert.NotContains(tc.virt.volumes, "/pool/"+workerGroup+"-0-state")
+			}
+			for _, host := range tc.virt.hosts {
+				assert.NotEqual(tc.hostname, host.Name)
+			}
+			assert.Contains(tc.virt.domains, workerGroup+"-1")
+		})
+	}
+}
+
+func TestDeleteOperatorInstances(t *testing.T) {
+	testCases := map[string]struct {
+		virt    *stubVirt
+		wantErr bool
+	}{
+		"delete operator instances": {
+			virt: func() *stubVirt {
+				v := newTestVirt()
+				v.addInstance(workerGroup+"-op-0", "worker-op-0", "52:54:00:00:02:02", "10.42.2.254")
+				v.addInstance(cpGroup+"-op-3", "control-plane-op-3", "52:54:00:00:01:01", "10.42.1.254")
+				// DHCP host left behind by a failed deletion
+				v.hosts = append(v.hosts, dhcpHostXML{MAC: "52:54:00:00:02:03", Name: "worker-op-1", IP: "10.42.2.253"})
+				return v
+			}(),
+		},
+		"no operator instances": {
+			virt: newTestVirt(),
+		},
+		"listing domains fails": {
+			virt: func() *stubVirt {
+				v := newTestVirt()
+				v.listErr = assert.AnError
+				return v
+			}(),
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			m := newManager(tc.virt, testNetwork)
+			err := m.DeleteOperatorInstances()
+			if tc.wantErr {
+				assert.Error(err)
+				return
+			}
+			assert.NoError(err)
+			assert.Len(tc.virt.domains, 3)
+			assert.Contains(tc.virt.domains, cpGroup+"-0")
+			assert.Contains(tc.virt.domains, workerGroup+"-0")
+			assert.Contains(tc.virt.domains, workerGroup+"-1")
+			for path := range tc.virt.volumes {
+				assert.NotContains(path, "-op-")
+			}
+			var hostnames []string
+			for _, host := range tc.virt.hosts {
+				hostnames = append(hostnames, host.Name)
+			}
+			assert.ElementsMatch([]string{"control-plane-0", "worker-0", "worker-1"}, hostnames)
+		})
+	}
+}
+
+func TestCloneDomainXML(t *testing.T) {
+	template := testDomainXML("constell-worker-1a2b3c4d-0", testNetwork, "52:54:00:00:00:01",
+		"/pool/constell-worker-1a2b3c4d-0-boot", "/pool/constell-worker-1a2b3c4d-0-state")
+
+	out, err := cloneDomainXML(template, "constell-worker-1a2b3c4d-1", []string{"/pool/new-boot", "/pool/new-state"})
+	require.NoError(t, err)
+
+	assert := assert.New(t)
+	dom, err := parseDomainXML(out)
+	require.NoError(t, err)
+	assert.Equal("constell-worker-1a2b3c4d-1", dom.Name)
+	assert.Equal([]string{"/pool/new-boot", "/pool/new-state"}, dom.diskFiles())
+	mac, ok := dom.macInNetwork(testNetwork)
+	assert.True(ok)
+	assert.Empty(mac)
+	assert.NotContains(out, "<uuid>")
+	assert.NotContains(out, "metadata")
+	assert.Contains(out, "/var/lib/libvirt/qemu/nvram/constell-worker-1a2b3c4d-1_VARS.fd")
+	assert.Contains(out, `<tpm model="tpm-tis">`)
+	assert.Contains(out, `<commandline xmlns="http://libvirt.org/schemas/domain/qemu/1.0">`)
+
+	_, err = cloneDomainXML(template, "constell-worker-1a2b3c4d-1", []string{"/pool/new-boot"})
+	assert.Error(err)
+}
+
+func TestNextDHCPHost(t *testing.T) {
+	template := domain{name: workerGroup + "-0", role: role.Worker, mac: "52:54:00:00:02:00"}
+
+	testCases := map[string]struct {
+		hosts    []dhcpHostXML
+		wantHost dhcpHostXML
+		wantErr  bool
+	}{
+		"next host": {
+			hosts: []dhcpHostXML{
+				{MAC: "52:54:00:00:01:00", Name: "control-plane-0", IP: "10.42.1.100"},
+				{MAC: "52:54:00:00:02:00", Name: "worker-0", IP: "10.42.2.100"},
+				{MAC: "52:54:00:00:02:01", Name: "worker-1", IP: "10.42.2.101"},
+			},
+			wantHost: dhcpHostXML{Name: "worker-op-0", IP: "10.42.2.254"},
+		},
+		"skips used IPs": {
+			hosts: []dhcpHostXML{
+				{MAC: "52:54:00:00:01:00", Name: "control-plane-op-7", IP: "10.42.1.254"},
+				{MAC: "52:54:00:00:02:00", Name: "worker-0", IP: "10.42.2.100"},
+				{MAC: "52:54:00:00:02:01", Name: "worker-op-3", IP: "10.42.2.254"},
+				{MAC: "52:54:00:00:02:02", Name: "worker-op-1", IP: "10.42.2.252"},
+			},
+			wantHost: dhcpHostXML{Name: "worker-op-4", IP: "10.42.2.253"},
+		},
+		"template without host": {
+			hosts: []dhcpHostXML{
+				{MAC: "52:54:00:00:01:00", Name: "control-plane-0", IP: "10.42.1.100"},
+			},
+			wantErr: true,
+		},
+		"subnet exhausted": {
+			hosts: func() []dhcpHostXML {
+				hosts := []dhcpHostXML{{MAC: "52:54:00:00:02:00", Name: "worker-0", IP: "10.42.2.1"}}
+				for i := 2; i < 255; i++ {
+					hosts = append(hosts, dhcpHostXML{Name: fmt.Sprintf("worker-op-%d", i), IP: fmt.Sprintf("10.42.2.%d", i)})
+				}
+				return hosts
+			}(),
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			host, err := nextDHCPHost(tc.hosts, template)
+			if tc.wantErr {
+				assert.Error(err)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(tc.wantHost, host)
+		})
+	}
+}
+
+// newTestVirt returns a stubVirt with a control-plane and two worker instances.
+func newTestVirt() *stubVirt {
+	v := &stubVirt{
+		domains: map[string]string{},
+		running: map[string]bool{},
+		images:  map[string]string{},
+		volumes: map[string]string{
+			testImage:    `<volume><name>constell-image</name><capacity unit="bytes">1000</capacity><target><format type="raw"></format></target></volume>`,
+			testNewImage: `<volume><name>constell-image-v2</name><capacity unit="bytes">1000</capacity><target><format type="raw"></format></target></volume>`,
+		},
+	}
+	v.addInstance(cpGroup+"-0", "control-plane-0", "52:54:00:00:01:00", "10.42.1.100")
+	v.addInstance(workerGroup+"-0", "worker-0", "52:54:00:00:02:00", "10.42.2.100")
+	v.addInstance(workerGroup+"-1", "worker-1", "52:54:00:00:02:01", "10.42.2.101")
+	return v
+}
+
+type stubVirt struct {
+	domains  map[string]string
+	running  map[string]bool
+	images   map[string]string
+	volumes  map[string]string
+	hosts    []dhcpHostXML
+	macs     int
+	listErr  error
+	startErr error
+}
+
+func (v *stubVirt) addInstance(name, hostname, mac, ip string) {
+	v.addDomain(name, testNetwork, mac)
+	v.volumes["/pool/"+name+"-boot"] = fmt.Sprintf(`<volume><name>%s-boot</name><capacity unit="bytes">2000</capacity>`+
+		`<target><path>/pool/%s-boot</path><format type="qcow2"></format></target>`+
+		`<backingStore><path>%s</path><format type="raw"></format></backingStore></volume>`, name, name, testImage)
+	v.volumes["/pool/"+name+"-state"] = fmt.Sprintf(`<volume><name>%s-state</name><capacity unit="bytes">3000</capacity>`+
+		`<target><path>/pool/%s-state</path><format type="qcow2"></format></target></volume>`, name, name)
+	v.hosts = append(v.hosts, dhcpHostXML{MAC: mac, Name: hostname, IP: ip})
+}
+
+func (v *stubVirt) addDomain(name, network, mac string) {
+	v.domains[name] = testDomainXML(name, network, mac, "/pool/"+name+"-boot", "/pool/"+name+"-state")
+	v.running[name] = true
+}
+
+func (v *stubVirt) listDomains() ([]string, error) {
+	var names []string
+	for name := range v.domains {
+		names = append(names, name)
+	}
+	return names, v.listErr
+}
+
+func (v *stubVirt) domainXML(name string) (string, error) {
+	raw, ok := v.domains[name]
+	if !ok {
+		return "", qemu.ErrInstanceNotFound
+	}
+	return raw, nil
+}
+
+func (v *stubVirt) domainState(name string) (qemu.InstanceState, error) {
+	if v.running[name] {
+		return qemu.InstanceStateRunning, nil
+	}
+	return qemu.InstanceStateStopped, nil
+}
+
+func (v *stubVirt) domainImage(name string) (string, error) {
+	return v.images[name], nil
+}
+
+func (v *stubVirt) setDomainImage(name, image string) error {
+	if _, ok := v.domains[name]; !ok {
+		return qemu.ErrInstanceNotFound
+	}
+	v.images[name] = image
+	return nil
+}
+
+func (v *stubVirt) defineDomain(raw string) error {
+	dom, err := parseDomainXML(raw)
+	if err != nil {
+		return err
+	}
+	// libvirt generates a MAC address for interfaces without one
+	v.macs++
+	v.domains[dom.Name] = strings.Replace(raw, `<source network="`+testNetwork+`">`,
+		fmt.Sprintf(`<mac address="52:54:00:00:ff:%02x"></mac><source network="%s">`, v.macs, testNetwork), 1)
+	return nil
+}
+
+func (v *stubVirt) startDomain(name string) error {
+	if v.startErr != nil {
+		return v.startErr
+	}
+	v.running[name] = true
+	return nil
+}
+
+func (v *stubVirt) undefineDomain(name string) error {
+	delete(v.domains, name)
+	delete(v.running, name)
+	delete(v.images, name)
+	return nil
+}
+
+func (v *stubVirt) volumeXML(path string) (string, error) {
+	raw, ok := v.volumes[path]
+	if !ok {
+		return "", fmt.Errorf("volume %q not found", path)
+	}
+	return raw, nil
+}
+
+func (v *stubVirt) createVolume(sibling, raw string) (string, error) {
+	vol, err := parseVolumeXML(raw)
+	if err != nil {
+		return "", err
+	}
+	volPath := path.Join(path.Dir(sibling), vol.Name)
+	v.volumes[volPath] = raw
+	return volPath, nil
+}
+
+func (v *stubVirt) deleteVolume(path string) error {
+	delete(v.volumes, path)
+	return nil
+}
+
+func (v *stubVirt) networkXML() (string, error) {
+	var hosts strings.Builder
+	for _, host := range v.hosts {
+		raw, err := marshalXML(host)
+		if err != nil {
+			return "", err
+		}
+		hosts.WriteString(raw)
+	}
+	return fmt.Sprintf(`<network><name>%s</name><ip address="10.42.0.1" netmask="255.255.252.0"><dhcp>%s</dhcp></ip></network>`,
+		testNetwork, hosts.String()), nil
+}
+
+func (v *stubVirt) addDHCPHost(raw string) error {
+	host, err := parseDHCPHost(raw)
+	if err != nil {
+		return err
+	}
+	v.hosts = append(v.hosts, host)
+	return nil
+}
+
+func (v *stubVirt) removeDHCPHost(raw string) error {
+	host, err := parseDHCPHost(raw)
+	if err != nil {
+		return err
+	}
+	for i, h := range v.hosts {
+		if h == host {
+			v.hosts = append(v.hosts[:i], v.hosts[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("host %q not found", host.Name)
+}
+
+func parseDHCPHost(raw string) (dhcpHostXML, error) {
+	network, err := parseNetworkXML(`<network><ip><dhcp>` + raw + `</dhcp></ip></network>`)
+	if err != nil {
+		return dhcpHostXML{}, err
+	}
+	hosts := network.hosts()
+	if len(hosts) != 1 {
+		return dhcpHostXML{}, fmt.Errorf("expected one host, got %d", len(hosts))
+	}
+	hosts[0].XMLName = xml.Name{}
+	return hosts[0], nil
+}
+
+func testDomainXML(name, network, mac, bootDisk, stateDisk string) string {
+	return fmt.Sprintf(`<domain type="kvm" xmlns:qemu="http://libvirt.org/schemas/domain/qemu/1.0">
+  <name>%[1]s</name>
+  <uuid>8f9c3f5e-7c8e-4bde-9c3a-0c7d1c8f0a11</uuid>
+  <metadata>
+    <constellation:instance xmlns:constellation="https://constellation.edgeless.systems/qemu"><constellation:image>%[6]s</constellation:image></constellation:instance>
+  </metadata>
+  <memory unit="KiB">2097152</memory>
+  <vcpu placement="static">2</vcpu>
+  <os>
+    <type arch="x86_64" machine="q35">hvm</type>
+    <loader readonly="yes" secure="no" type="pflash">/usr/share/OVMF/OVMF_CODE.fd</loader>
+    <nvram template="/usr/share/OVMF/OVMF_VARS.fd">/var/lib/libvirt/qemu/nvram/worker-0_VARS.fd</nvram>
+  </os>
+  <devices>
+    <disk type="file" device="disk">
+      <driver name="qemu" type="qcow2"/>
+      <source file="%[4]s"/>
+      <target dev="vda" bus="virtio"/>
+    </disk>
+    <disk type="file" device="disk">
+      <driver name="qemu" type="qcow2"/>
+      <source file="%[5]s"/>
+      <target dev="vdb" bus="virtio"/>
+    </disk>
+    <interface type="network">
+      <mac address="%[3]s"/>
+      <source network="%[2]s"/>
+      <model type="virtio"/>
+    </interface>
+    <tpm model="tpm-tis">
+      <backend type="emulator" version="2.0"/>
+    </tpm>
+  </devices>
+  <qemu:commandline>
+    <qemu:arg value="-no-reboot"/>
+  </qemu:commandline>
+</domain>`, name, network, mac, bootDisk, stateDisk, testImage)
+}
@@ -0,0 +1,235 @@
+//go:build cgo
+
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package instances
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+
+	"github.com/edgelesssys/constellation/v2/internal/cloud/qemu"
+	"libvirt.org/go/libvirt"
+)
+
+const (
+	// metadataURI is the namespace of the domain metadata set by the manager.
+	metadataURI = "https://constellation.edgeless.systems/qemu"
+	// metadataPrefix is the XML prefix of the domain metadata set by the manager.
+	metadataPrefix = "constellation"
+)
+
+// New creates a new Manager for the domains in the given libvirt network.
+func New(conn *libvirt.Connect, networkName string) *Manager {
+	return newManager(&libvirtAPI{conn: conn, networkName: networkName}, networkName)
+}
+
+// libvirtAPI implements virtAPI using a libvirt connection.
+type libvirtAPI struct {
+	conn        *libvirt.Connect
+	networkName string
+}
+
+func (l *libvirtAPI) listDomains() ([]string, error) {
+	doms, err := l.conn.ListAllDomains(0)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, dom := range doms {
+		name, err := dom.GetName()
+		_ = dom.Free()
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+func (l *libvirtAPI) domainXML(name string) (string, error) {
+	dom, err := l.lookupDomain(name)
+	if err != nil {
+		return "", err
+	}
+	defer dom.Free()
+	return dom.GetXMLDesc(libvirt.DOMAIN_XML_INACTIVE)
+}
+
+func (l *libvirtAPI) domainState(name string) (qemu.InstanceState, error) {
+	dom, err := l.lookupDomain(name)
+	if err != nil {
+		return qemu.InstanceStateUnknown, err
+	}
+	defer dom.Free()
+	state, _, err := dom.GetState()
+	if err != nil {
+		return qemu.InstanceStateUnknown, err
+	}
+	switch state {
+	case libvirt.DOMAIN_RUNNING, libvirt.DOMAIN_BLOCKED:
+		return qemu.InstanceStateRunning, nil
+	case libvirt.DOMAIN_SHUTOFF, libvirt.DOMAIN_PAUSED, libvirt.DOMAIN_PMSUSPENDED:
+		return qemu.InstanceStateStopped, nil
+	case libvirt.DOMAIN_SHUTDOWN:
+		return qemu.InstanceStateShuttingDown, nil
+	case libvirt.DOMAIN_CRASHED:
+		return qemu.InstanceStateCrashed, nil
+	default:
+		return qemu.InstanceStateUnknown, nil
+	}
+}
+
+func (l *libvirtAPI) domainImage(name string) (string, error) {
+	dom, err := l.lookupDomain(name)
+	if err != nil {
+		return "", err
+	}
+	defer dom.Free()
+	raw, err := dom.GetMetadata(libvirt.DOMAIN_METADATA_ELEMENT, metadataURI, libvirt.DOMAIN_AFFECT_CONFIG)
+	if errors.Is(err, libvirt.ERR_NO_DOMAIN_METADATA) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	var metadata domainMetadata
+	if err := xml.Unmarshal([]byte(raw), &metadata); err != nil {
+		return "", fmt.Errorf("parsing domain metadata: %w", err)
+	}
+	return metadata.Image, nil
+}
+
+func (l *libvirtAPI) setDomainImage(name, image string) error {
+	dom, err := l.lookupDomain(name)
+	if err != nil {
+		return err
+	}
+	defer dom.Free()
+	raw, err := marshalXML(domainMetadata{Image: image})
+	if err != nil {
+		return fmt.Errorf("marshaling domain metadata: %w", err)
+	}
+	return dom.SetMetadata(libvirt.DOMAIN_METADATA_ELEMENT, raw, metadataPrefix, metadataURI, libvirt.DOMAIN_AFFECT_CONFIG)
+}
+
+func (l *libvirtAPI) defineDomain(xml string) error {
+	dom, err := l.conn.DomainDefineXML(xml)
+	if err != nil {
+		return err
+	}
+	return dom.Free()
+}
+
+func (l *libvirtAPI) startDomain(name string) error {
+	dom, err := l.lookupDomain(name)
+	if err != nil {
+		return err
+	}
+	defer dom.Free()
+	return dom.Create()
+}
+
+func (l *libvirtAPI) undefineDomain(name string) error {
+	dom, err := l.lookupDomain(name)
+	if errors.Is(err, qemu.ErrInstanceNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer dom.Free()
+	active, err := dom.IsActive()
+	if err != nil {
+		return err
+	}
+	if active {
+		if err := dom.Destroy(); err != nil {
+			return fmt.Errorf("stopping domain: %w", err)
+		}
+	}
+	return dom.UndefineFlags(libvirt.DOMAIN_UNDEFINE_NVRAM)
+}
+
+func (l *libvirtAPI) volumeXML(path string) (string, error) {
+	vol, err := l.conn.LookupStorageVolByPath(path)
+	if err != nil {
+		return "", err
+	}
+	defer vol.Free()
+	return vol.GetXMLDesc(0)
+}
+
+func (l *libvirtAPI) createVolume(sibling, xml string) (string, error) {
+	siblingVol, err := l.conn.LookupStorageVolByPath(sibling)
+	if err != nil {
+		return "", err
+	}
+	defer siblingVol.Free()
+	pool, err := siblingVol.LookupPoolByVolume()
+	if err != nil {
+		return "", fmt.Errorf("getting storage pool of volume %q: %w", sibling, err)
+	}
+	defer pool.Free()
+	vol, err := pool.StorageVolCreateXML(xml, 0)
+	if err != nil {
+		return "", err
+	}
+	defer vol.Free()
+	return vol.GetPath()
+}
+
+func (l *libvirtAPI) deleteVolume(path string) error {
+	vol, err := l.conn.LookupStorageVolByPath(path)
+	if errors.Is(err, libvirt.ERR_NO_STORAGE_VOL) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer vol.Free()
+	return vol.Delete(0)
+}
+
+func (l *libvirtAPI) networkXML() (string, error) {
+	network, err := l.conn.LookupNetworkByName(l.networkName)
+	if err != nil {
+		return "", err
+	}
+	defer network.Free()
+	return network.GetXMLDesc(0)
+}
+
+func (l *libvirtAPI) addDHCPHost(xml string) error {
+	return l.updateDHCPHost(libvirt.NETWORK_UPDATE_COMMAND_ADD_LAST, xml)
+}
+
+func (l *libvirtAPI) removeDHCPHost(xml string) error {
+	return l.updateDHCPHost(libvirt.NETWORK_UPDATE_COMMAND_DELETE, xml)
+}
+
+func (l *libvirtAPI) updateDHCPHost(cmd libvirt.NetworkUpdateCommand, xml string) error {
+	network, err := l.conn.LookupNetworkByName(l.networkName)
+	if err != nil {
+		return err
+	}
+	defer network.Free()
+	return network.Update(cmd, libvirt.NETWORK_SECTION_IP_DHCP_HOST, -1, xml,
+		libvirt.NETWORK_UPDATE_AFFECT_LIVE|libvirt.NETWORK_UPDATE_AFFECT_CONFIG)
+}
+
+func (l *libvirtAPI) lookupDomain(name string) (*libvirt.Domain, error) {
+	dom, err := l.conn.LookupDomainByName(name)
+	if errors.Is(err, libvirt.ERR_NO_DOMAIN) {
+		return nil, fmt.Errorf("domain %q: %w", name, qemu.ErrInstanceNotFound)
+	}
+	return dom, err
+}
+
+// domainMetadata is the metadata the manager stores in a domain's definition.
+type domainMetadata struct {
+	XMLName xml.Name `xml:"instance"`
+	Image   string   `xml:"image"`
+}
@@ -15,6 +15,7 @@ import (
 
 	"github.com/edgelesssys/constellation/v2/hack/qemu-metadata-api/dhcp/dnsmasq"
 	"github.com/edgelesssys/constellation/v2/hack/qemu-metadata-api/dhcp/virtwrapper"
+	"github.com/edgelesssys/constellation/v2/hack/qemu-metadata-api/instances"
 	"github.com/edgelesssys/constellation/v2/hack/qemu-metadata-api/server"
 	"github.com/edgelesssys/constellation/v2/internal/logger"
 	"libvirt.org/go/libvirt"
@@ -31,6 +32,7 @@ func main() {
 	log := logger.NewJSONLogger(slog.LevelInfo)
 
 	var leaseGetter server.LeaseGetter
+	var instanceManager server.InstanceManager
 	if *leasesFileName == "" {
 		conn, err := libvirt.NewConnect(*libvirtURI)
 		if err != nil {
@@ -39,12 +41,13 @@ func main() {
 		}
 		defer conn.Close()
 		leaseGetter = virtwrapper.New(conn, *targetNetwork)
+		instanceManager = instances.New(conn, *targetNetwork)
 	} else {
-		log.Info("Using dnsmasq leases file")
+		log.Info("Using dnsmasq leases file, managing instances is not supported")
 		leaseGetter = dnsmasq.New(*leasesFileName)
 	}
 
-	serv := server.New(log, *targetNetwork, *initSecretHash, leaseGetter)
+	serv := server.New(log, *targetNetwork, *initSecretHash, leaseGetter, instanceManager)
 	if err := serv.ListenAndServe(*bindPort); err != nil {
 		log.With(slog.Any("error", err)).Error("Failed to serve")
 		os.Exit(1)
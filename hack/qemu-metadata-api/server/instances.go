@@ -0,0 +1,203 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+
+	"github.com/edgelesssys/constellation/v2/internal/cloud/qemu"
+	"github.com/edgelesssys/constellation/v2/internal/role"
+)
+
+// controlPlaneOnly only allows requests from control-plane instances, where the node operator runs.
+// It also rejects all requests if the server does not manage instances.
+func (s *Server) controlPlaneOnly(next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log := s.log.With(slog.String("peer", r.RemoteAddr))
+		if s.instances == nil {
+			log.Error("Managing instances is not supported")
+			http.Error(w, "Managing instances is not supported", http.StatusNotImplemented)
+			return
+		}
+
+		remoteIP, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			log.With(slog.Any("error", err)).Error("Failed to parse remote address")
+			http.Error(w, fmt.Sprintf("Failed to parse remote address: %s\n", err), http.StatusInternalServerError)
+			return
+		}
+		peers, err := s.listAll()
+		if err != nil {
+			log.With(slog.Any("error", err)).Error("Failed to list peer metadata")
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		for _, peer := range peers {
+			if peer.VPCIP == remoteIP && peer.Role == role.ControlPlane {
+				next(w, r)
+				return
+			}
+		}
+
+		log.Error("Request is not from a control-plane instance")
+		http.Error(w, "Only control-plane instances may manage instances", http.StatusForbidden)
+	})
+}
+
+// hostOnly only allows requests from the host the server runs on, where the CLI runs.
+// It also rejects all requests if the server does not manage instances.
+func (s *Server) hostOnly(next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log := s.log.With(slog.String("peer", r.RemoteAddr))
+		if s.instances == nil {
+			log.Error("Managing instances is not supported")
+			http.Error(w, "Managing instances is not supported", http.StatusNotImplemented)
+			return
+		}
+
+		remoteAddr, err := netip.ParseAddrPort(r.RemoteAddr)
+		if err != nil {
+			log.With(slog.Any("error", err)).Error("Failed to parse remote address")
+			http.Error(w, fmt.Sprintf("Failed to parse remote address: %s\n", err), http.StatusInternalServerError)
+			return
+		}
+		if !remoteAddr.Addr().IsLoopback() {
+			log.Error("Request is not from the host")
+			http.Error(w, "Only the host may delete all instances", http.StatusForbidden)
+			return
+		}
+		next(w, r)
+	})
+}
+
+// listScalingGroups returns the scaling groups of the cluster.
+func (s *Server) listScalingGroups(w http.ResponseWriter, r *http.Request) {
+	log := s.log.With(slog.String("peer", r.RemoteAddr))
+	log.Info("Serving GET request for /scalinggroups")
+
+	groups, err := s.instances.ListScalingGroups()
+	if err != nil {
+		log.With(slog.Any("error", err)).Error("Failed to list scaling groups")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.writeJSON(w, log, groups)
+}
+
+// setScalingGroupImage sets the image of a scaling group.
+func (s *Server) setScalingGroupImage(w http.ResponseWriter, r *http.Request) {
+	groupID := r.PathValue("id")
+	log := s.log.With(slog.String("peer", r.RemoteAddr), slog.String("scalingGroup", groupID))
+	log.Info("Serving PUT request for /scalinggroups/{id}/image")
+
+	var req qemu.SetImageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.With(slog.Any("error", err)).Error("Failed to decode request")
+		http.Error(w, fmt.Sprintf("Failed to decode request: %s\n", err), http.StatusBadRequest)
+		return
+	}
+	if err := s.instances.SetScalingGroupImage(groupID, req.Image); err != nil {
+		log.With(slog.Any("error", err)).Error("Failed to set scaling group image")
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+	log.Info("Request successful")
+}
+
+// createInstance creates a new instance in a scaling group.
+func (s *Server) createInstance(w http.ResponseWriter, r *http.Request) {
+	groupID := r.PathValue("id")
+	log := s.log.With(slog.String("peer", r.RemoteAddr), slog.String("scalingGroup", groupID))
+	log.Info("Serving POST request for /scalinggroups/{id}/instances")
+
+	instance, err := s.instances.CreateInstance(groupID)
+	if err != nil {
+		log.With(slog.Any("error", err)).Error("Failed to create instance")
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+	log.With(slog.String("instance", instance.Name)).Info("Created instance")
+	s.writeJSON(w, log, instance)
+}
+
+// getInstance returns an instance.
+func (s *Server) getInstance(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	log := s.log.With(slog.String("peer", r.RemoteAddr), slog.String("instance", name))
+	log.Info("Serving GET request for /instances/{name}")
+
+	instance, err := s.instances.GetInstance(name)
+	if err != nil {
+		log.With(slog.Any("error", err)).Error("Failed to get instance")
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+	s.writeJSON(w, log, instance)
+}
+
+// deleteInstance deletes an instance.
+func (s *Server) deleteInstance(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	log := s.log.With(slog.String("peer", r.RemoteAddr), slog.String("instance", name))
+	log.Info("Serving DELETE request for /instances/{name}")
+
+	if err := s.instances.DeleteInstance(name); err != nil {
+		log.With(slog.Any("error", err)).Error("Failed to delete instance")
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+	log.Info("Request successful")
+}
+
+// deleteOperatorInstances deletes all instances created through the API.
+// The CLI calls it before destroying the cluster, since Terraform does not know about these instances.
+func (s *Server) deleteOperatorInstances(w http.ResponseWriter, r *http.Request) {
+	log := s.log.With(slog.String("peer", r.RemoteAddr))
+	log.Info("Serving DELETE request for /instances")
+
+	if err := s.instances.DeleteOperatorInstances(); err != nil {
+		log.With(slog.Any("error", err)).Error("Failed to delete instances")
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+	log.Info("Request successful")
+}
+
+func (s *Server) writeJSON(w http.ResponseWriter, log *slog.Logger, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	log.Info("Request successful")
+}
+
+func errorStatus(err error) int {
+	if errors.Is(err, qemu.ErrInstanceNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+// InstanceManager manages the instances of the cluster.
+type InstanceManager interface {
+	ListScalingGroups() ([]qemu.ScalingGroup, error)
+	SetScalingGroupImage(groupID, image string) error
+	CreateInstance(groupID string) (qemu.Instance, error)
+	GetInstance(hostname string) (qemu.Instance, error)
+	DeleteInstance(hostname string) error
+	DeleteOperatorInstances() error
+}
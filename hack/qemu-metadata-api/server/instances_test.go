@@ -0,0 +1,268 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/edgelesssys/constellation/v2/hack/qemu-metadata-api/dhcp"
+	"github.com/edgelesssys/constellation/v2/internal/cloud/qemu"
+	"github.com/edgelesssys/constellation/v2/internal/logger"
+	"github.com/edgelesssys/constellation/v2/internal/role"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstanceEndpoints(t *testing.T) {
+	leases := &stubLeaseGetter{
+		leases: []dhcp.NetworkDHCPLease{
+			{
+				IPaddr:   "192.0.100.1",
+				Hostname: "control-plane-0",
+			},
+			{
+				IPaddr:   "192.0.200.1",
+				Hostname: "worker-0",
+			},
+		},
+	}
+	instance := qemu.Instance{
+		Name:           "worker-1",
+		ProviderID:     "qemu:///hostname/worker-1",
+		ScalingGroupID: "constell-worker-1a2b3c4d",
+		Image:          "/var/lib/libvirt/images/image",
+		State:          qemu.InstanceStateRunning,
+	}
+
+	testCases := map[string]struct {
+		instances  *stubInstanceManager
+		noManager  bool
+		method     string
+		path       string
+		body       string
+		remoteAddr string
+		wantStatus int
+		wantBody   any
+		check      func(*assert.Assertions, *stubInstanceManager)
+	}{
+		"list scaling groups": {
+			instances: &stubInstanceManager{
+				groups: []qemu.ScalingGroup{{ID: "constell-worker-1a2b3c4d", Role: role.Worker, Image: "/image"}},
+			},
+			method:     http.MethodGet,
+			path:       "/scalinggroups",
+			remoteAddr: "192.0.100.1:1234",
+			wantStatus: http.StatusOK,
+			wantBody:   []qemu.ScalingGroup{{ID: "constell-worker-1a2b3c4d", Role: role.Worker, Image: "/image"}},
+		},
+		"set scaling group image": {
+			instances:  &stubInstanceManager{},
+			method:     http.MethodPut,
+			path:       "/scalinggroups/constell-worker-1a2b3c4d/image",
+			body:       `{"image":"/new-image"}`,
+			remoteAddr: "192.0.100.1:1234",
+			wantStatus: http.StatusNoContent,
+			check: func(assert *assert.Assertions, m *stubInstanceManager) {
+				assert.Equal("constell-worker-1a2b3c4d", m.groupID)
+				assert.Equal("/new-image", m.image)
+			},
+		},
+		"set scaling group image with invalid body": {
+			instances:  &stubInstanceManager{},
+			method:     http.MethodPut,
+			path:       "/scalinggroups/constell-worker-1a2b3c4d/image",
+			body:       `image`,
+			remoteAddr: "192.0.100.1:1234",
+			wantStatus: http.StatusBadRequest,
+		},
+		"create instance": {
+			instances:  &stubInstanceManager{instance: instance},
+			method:     http.MethodPost,
+			path:       "/scalinggroups/constell-worker-1a2b3c4d/instances",
+			remoteAddr: "192.0.100.1:1234",
+			wantStatus: http.StatusOK,
+			wantBody:   instance,
+			check: func(assert *assert.Assertions, m *stubInstanceManager) {
+				assert.Equal("constell-worker-1a2b3c4d", m.groupID)
+			},
+		},
+		"create instance in unknown group": {
+			instances:  &stubInstanceManager{err: qemu.ErrInstanceNotFound},
+			method:     http.MethodPost,
+			path:       "/scalinggroups/constell-worker-1a2b3c4d/instances",
+			remoteAddr: "192.0.100.1:1234",
+			wantStatus: http.StatusNotFound,
+		},
+		"get instance": {
+			instances:  &stubInstanceManager{instance: instance},
+			method:     http.MethodGet,
+			path:       "/instances/worker-1",
+			remoteAddr: "192.0.100.1:1234",
+			wantStatus: http.StatusOK,
+			wantBody:   instance,
+			check: func(assert *assert.Assertions, m *stubInstanceManager) {
+				assert.Equal("worker-1", m.hostname)
+			},
+		},
+		"get instance error": {
+			instances:  &stubInstanceManager{err: assert.AnError},
+			method:     http.MethodGet,
+			path:       "/instances/worker-1",
+			remoteAddr: "192.0.100.1:1234",
+			wantStatus: http.StatusInternalServerError,
+		},
+		"delete instance": {
+			instances:  &stubInstanceManager{},
+			method:     http.MethodDelete,
+			path:       "/instances/worker-1",
+			remoteAddr: "192.0.100.1:1234",
+			wantStatus: http.StatusNoContent,
+			check: func(assert *assert.Assertions, m *stubInstanceManager) {
+				assert.Equal("worker-1", m.deleted)
+			},
+		},
+		"delete unknown instance": {
+			instances:  &stubInstanceManager{err: qemu.ErrInstanceNotFound},
+			method:     http.MethodDelete,
+			path:       "/instances/worker-1",
+			remoteAddr: "192.0.100.1:1234",
+			wantStatus: http.StatusNotFound,
+		},
+		"delete operator instances": {
+			instances:  &stubInstanceManager{},
+			method:     http.MethodDelete,
+			path:       "/instances",
+			remoteAddr: "127.0.0.1:1234",
+			wantStatus: http.StatusNoContent,
+			check: func(assert *assert.Assertions, m *stubInstanceManager) {
+				assert.True(m.deletedOperatorInstances)
+			},
+		},
+		"delete operator instances fails": {
+			instances:  &stubInstanceManager{err: assert.AnError},
+			method:     http.MethodDelete,
+			path:       "/instances",
+			remoteAddr: "[::1]:1234",
+			wantStatus: http.StatusInternalServerError,
+		},
+		"delete operator instances from control plane": {
+			instances:  &stubInstanceManager{},
+			method:     http.MethodDelete,
+			path:       "/instances",
+			remoteAddr: "192.0.100.1:1234",
+			wantStatus: http.StatusForbidden,
+			check: func(assert *assert.Assertions, m *stubInstanceManager) {
+				assert.False(m.deletedOperatorInstances)
+			},
+		},
+		"request from worker": {
+			instances:  &stubInstanceManager{},
+			method:     http.MethodDelete,
+			path:       "/instances/worker-1",
+			remoteAddr: "192.0.200.1:1234",
+			wantStatus: http.StatusForbidden,
+			check: func(assert *assert.Assertions, m *stubInstanceManager) {
+				assert.Empty(m.deleted)
+			},
+		},
+		"request from unknown peer": {
+			instances:  &stubInstanceManager{},
+			method:     http.MethodGet,
+			path:       "/scalinggroups",
+			remoteAddr: "192.0.0.5:1234",
+			wantStatus: http.StatusForbidden,
+		},
+		"managing instances not supported": {
+			noManager:  true,
+			method:     http.MethodGet,
+			path:       "/scalinggroups",
+			remoteAddr: "192.0.100.1:1234",
+			wantStatus: http.StatusNotImplemented,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			var instances InstanceManager
+			if !tc.noManager {
+				instances = tc.instances
+			}
+			server := New(logger.NewTest(t), "test", "initSecretHash", leases, instances)
+
+			req, err := http.NewRequestWithContext(t.Context(), tc.method, "http://192.0.0.1"+tc.path, strings.NewReader(tc.body))
+			require.NoError(err)
+			req.RemoteAddr = tc.remoteAddr
+
+			w := httptest.NewRecorder()
+			server.handler().ServeHTTP(w, req)
+
+			assert.Equal(tc.wantStatus, w.Code)
+			if tc.wantBody != nil {
+				wantBody, err := json.Marshal(tc.wantBody)
+				require.NoError(err)
+				assert.JSONEq(string(wantBody), w.Body.String())
+			}
+			if tc.check != nil {
+				tc.check(assert, tc.instances)
+			}
+		})
+	}
+}
+
+type stubInstanceManager struct {
+	groups   []qemu.ScalingGroup
+	instance qemu.Instance
+	err      error
+
+	groupID  string
+	image    string
+	hostname string
+	deleted  string
+
+	deletedOperatorInstances bool
+}
+
+func (m *stubInstanceManager) ListScalingGroups() ([]qemu.ScalingGroup, error) {
+	return m.groups, m.err
+}
+
+func (m *stubInstanceManager) SetScalingGroupImage(groupID, image string) error {
+	m.groupID = groupID
+	m.image = image
+	return m.err
+}
+
+func (m *stubInstanceManager) CreateInstance(groupID string) (qemu.Instance, error) {
+	m.groupID = groupID
+	return m.instance, m.err
+}
+
+func (m *stubInstanceManager) GetInstance(hostname string) (qemu.Instance, error) {
+	m.hostname = hostname
+	return m.instance, m.err
+}
+
+func (m *stubInstanceManager) DeleteInstance(hostname string) error {
+	if m.err == nil {
+		m.deleted = hostname
+	}
+	return m.err
+}
+
+func (m *stubInstanceManager) DeleteOperatorInstances() error {
+	if m.err == nil {
+		m.deletedOperatorInstances = true
+	}
+	return m.err
+}
@@ -16,6 +16,7 @@ import (
 
 	"github.com/edgelesssys/constellation/v2/hack/qemu-metadata-api/dhcp"
 	"github.com/edgelesssys/constellation/v2/internal/cloud/metadata"
+	"github.com/edgelesssys/constellation/v2/internal/cloud/qemu"
 	"github.com/edgelesssys/constellation/v2/internal/role"
 )
 
@@ -23,15 +24,18 @@ import (
 type Server struct {
 	log               *slog.Logger
 	dhcpLeaseGetter   LeaseGetter
+	instances         InstanceManager
 	network           string
 	initSecretHashVal []byte
 }
 
 // New creates a new Server.
-func New(log *slog.Logger, network, initSecretHash string, getter LeaseGetter) *Server {
+// If instances is nil, the server does not support managing instances.
+func New(log *slog.Logger, network, initSecretHash string, getter LeaseGetter, instances InstanceManager) *Server {
 	return &Server{
 		log:               log,
 		dhcpLeaseGetter:   getter,
+		instances:         instances,
 		network:           network,
 		initSecretHashVal: []byte(initSecretHash),
 	}
@@ -39,14 +43,8 @@ func New(log *slog.Logger, network, initSecretHash string, getter LeaseGetter) *
 
 // ListenAndServe on a given port.
 func (s *Server) ListenAndServe(port string) error {
-	mux := http.NewServeMux()
-	mux.Handle("/self", http.HandlerFunc(s.listSelf))
-	mux.Handle("/peers", http.HandlerFunc(s.listPeers))
-	mux.Handle("/endpoint", http.HandlerFunc(s.getEndpoint))
-	mux.Handle("/initsecrethash", http.HandlerFunc(s.initSecretHash))
-
 	server := http.Server{
-		Handler: mux,
+		Handler: s.handler(),
 	}
 
 	lis, err := net.Listen("tcp", net.JoinHostPort("", port))
@@ -58,6 +56,21 @@ func (s *Server) ListenAndServe(port string) error {
 	return server.Serve(lis)
 }
 
+func (s *Server) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/self", http.HandlerFunc(s.listSelf))
+	mux.Handle("/peers", http.HandlerFunc(s.listPeers))
+	mux.Handle("/endpoint", http.HandlerFunc(s.getEndpoint))
+	mux.Handle("/initsecrethash", http.HandlerFunc(s.initSecretHash))
+	mux.Handle("GET /scalinggroups", s.controlPlaneOnly(s.listScalingGroups))
+	mux.Handle("PUT /scalinggroups/{id}/image", s.controlPlaneOnly(s.setScalingGroupImage))
+	mux.Handle("POST /scalinggroups/{id}/instances", s.controlPlaneOnly(s.createInstance))
+	mux.Handle("GET /instances/{name}", s.controlPlaneOnly(s.getInstance))
+	mux.Handle("DELETE /instances/{name}", s.controlPlaneOnly(s.deleteInstance))
+	mux.Handle("DELETE /instances", s.hostOnly(s.deleteOperatorInstances))
+	return mux
+}
+
 // listSelf returns peer information about the instance issuing the request.
 func (s *Server) listSelf(w http.ResponseWriter, r *http.Request) {
 	log := s.log.With(slog.String("peer", r.RemoteAddr))
@@ -180,7 +193,7 @@ func (s *Server) listAll() ([]metadata.InstanceMetadata, error) {
 			Name:       lease.Hostname,
 			Role:       instanceRole,
 			VPCIP:      lease.IPaddr,
-			ProviderID: "qemu:///hostname/" + lease.Hostname,
+			ProviderID: qemu.ProviderID(lease.Hostname),
 		})
 	}
 
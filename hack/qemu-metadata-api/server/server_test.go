@@ -55,7 +55,7 @@ func TestListAll(t *testing.T) {
 		t.Run(name, func(t *testing.T) {
 			assert := assert.New(t)
 
-			server := New(logger.NewTest(t), "test", "initSecretHash", tc.stubLeaseGetter)
+			server := New(logger.NewTest(t), "test", "initSecretHash", tc.stubLeaseGetter, nil)
 
 			res, err := server.listAll()
 
@@ -124,7 +124,7 @@ func TestListSelf(t *testing.T) {
 			assert := assert.New(t)
 			require := require.New(t)
 
-			server := New(logger.NewTest(t), "test", "initSecretHash", tc.stubLeaseGetter)
+			server := New(logger.NewTest(t), "test", "initSecretHash", tc.stubLeaseGetter, nil)
 
 			req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, "http://192.0.0.1/self", nil)
 			require.NoError(err)
@@ -184,7 +184,7 @@ func TestListPeers(t *testing.T) {
 			assert := assert.New(t)
 			require := require.New(t)
 
-			server := New(logger.NewTest(t), "test", "initSecretHash", tc.stubNetworkGetter)
+			server := New(logger.NewTest(t), "test", "initSecretHash", tc.stubNetworkGetter, nil)
 
 			req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, "http://192.0.0.1/peers", nil)
 			require.NoError(err)
@@ -240,7 +240,7 @@ func TestInitSecretHash(t *testing.T) {
 			assert := assert.New(t)
 			require := require.New(t)
 
-			server := New(logger.NewTest(t), "test", tc.wantHash, defaultConnect)
+			server := New(logger.NewTest(t), "test", tc.wantHash, defaultConnect, nil)
 
 			req, err := http.NewRequestWithContext(t.Context(), tc.method, "http://192.0.0.1/initsecrethash", nil)
 			require.NoError(err)
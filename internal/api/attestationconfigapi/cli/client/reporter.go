@@ -316,7 +316,7 @@ func actionForVariant(
 	switch attestationVariant {
 	case variant.AWSSEVSNP{}, variant.AzureSEVSNP{}, variant.GCPSEVSNP{}:
 		snpAction()
-	case variant.AzureTDX{}:
+	case variant.AzureTDX{}, variant.GCPTDX{}:
 		tdxAction()
 	default:
 		panic(fmt.Sprintf("unsupported attestation variant: %s", attestationVariant))
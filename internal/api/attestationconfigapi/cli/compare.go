@@ -44,7 +44,7 @@ func runCompare(cmd *cobra.Command, args []string) error {
 }
 
 func compare(cmd *cobra.Command, attestationVariant variant.Variant, files []string, fs file.Handler) (retErr error) {
-	if !slices.Contains([]variant.Variant{variant.AWSSEVSNP{}, variant.AzureSEVSNP{}, variant.GCPSEVSNP{}, variant.AzureTDX{}}, attestationVariant) {
+	if !slices.Contains([]variant.Variant{variant.AWSSEVSNP{}, variant.AzureSEVSNP{}, variant.GCPSEVSNP{}, variant.AzureTDX{}, variant.GCPTDX{}}, attestationVariant) {
 		return fmt.Errorf("variant %s not supported", attestationVariant)
 	}
 
@@ -59,7 +59,7 @@ func compare(cmd *cobra.Command, attestationVariant variant.Variant, files []str
 
 func compareVersions(attestationVariant variant.Variant, files []string, fs file.Handler) (string, error) {
 	readReport := readSNPReport
-	if attestationVariant.Equal(variant.AzureTDX{}) {
+	if attestationVariant.Equal(variant.AzureTDX{}) || attestationVariant.Equal(variant.GCPTDX{}) {
 		readReport = readTDXReport
 	}
 
@@ -126,7 +126,7 @@ func uploadReport(
 		}
 		log.Info(fmt.Sprintf("Input SNP report: %+v", newVersion))
 
-	case variant.AzureTDX{}:
+	case variant.AzureTDX{}, variant.GCPTDX{}:
 		latestVersion = latestVersionInAPI.TDXVersion
 
 		log.Info(fmt.Sprintf("Reading TDX report from file: %s", cfg.path))
@@ -22,7 +22,7 @@ func arg0isAttestationVariant() cobra.PositionalArgs {
 			return errors.New("argument 0 isn't a valid attestation variant")
 		}
 		switch attestationVariant {
-		case variant.AWSSEVSNP{}, variant.AzureSEVSNP{}, variant.AzureTDX{}, variant.GCPSEVSNP{}:
+		case variant.AWSSEVSNP{}, variant.AzureSEVSNP{}, variant.AzureTDX{}, variant.GCPSEVSNP{}, variant.GCPTDX{}:
 			return nil
 		default:
 			return errors.New("argument 0 isn't a supported attestation variant")
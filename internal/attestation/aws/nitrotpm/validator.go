@@ -34,7 +34,7 @@ type Validator struct {
 func NewValidator(cfg *config.AWSNitroTPM, log attestation.Logger) *Validator {
 	v := &Validator{}
 	v.Validator = vtpm.NewValidator(
-		cfg.Measurements,
+		config.MeasurementSets(cfg),
 		getTrustedKey,
 		v.tpmEnabled,
 		log,
@@ -19,7 +19,6 @@ import (
 	"github.com/edgelesssys/constellation/v2/internal/attestation/variant"
 	"github.com/edgelesssys/constellation/v2/internal/attestation/vtpm"
 	"github.com/edgelesssys/constellation/v2/internal/config"
-	"github.com/google/go-sev-guest/abi"
 	"github.com/google/go-sev-guest/kds"
 	"github.com/google/go-sev-guest/proto/sevsnp"
 	"github.com/google/go-sev-guest/validate"
@@ -47,12 +46,12 @@ type Validator struct {
 func NewValidator(cfg *config.AWSSEVSNP, log attestation.Logger) *Validator {
 	v := &Validator{
 		cfg:             cfg,
-		reportValidator: &awsValidator{httpsGetter: trust.DefaultHTTPSGetter(), verifier: &reportVerifierImpl{}, validator: &reportValidatorImpl{}},
+		reportValidator: &awsValidator{httpsGetter: trust.DefaultHTTPSGetter(), verifier: &reportVerifierImpl{}, validator: &reportValidatorImpl{}, revocations: snp.NewRevocationChecker(trust.DefaultHTTPSGetter())},
 		log:             log,
 	}
 
 	v.Validator = vtpm.NewValidator(
-		cfg.Measurements,
+		config.MeasurementSets(cfg),
 		v.getTrustedKey,
 		func(vtpm.AttestationDocument, *attest.MachineState) error { return nil },
 		log,
@@ -107,6 +106,7 @@ type snpReportValidator interface {
 type awsValidator struct {
 	verifier    reportVerifier
 	validator   reportValidator
+	revocations revocationChecker
 	httpsGetter trust.HTTPSGetter
 }
 
@@ -116,6 +116,9 @@ type reportVerifier interface {
 type reportValidator interface {
 	SnpAttestation(att *sevsnp.Attestation, opts *validate.Options) error
 }
+type revocationChecker interface {
+	Check(att *sevsnp.Attestation, pinned config.CRL, log attestation.Logger) error
+}
 
 type reportValidatorImpl struct{}
 
@@ -154,15 +157,19 @@ func (a *awsValidator) validate(attestation vtpm.AttestationDocument, ask *x509.
 		return newValidationError(fmt.Errorf("verifying SNP attestation: %w", err))
 	}
 
+	if config.CheckRevocations {
+		if err := a.revocations.Check(att, config.AMDCRL, log); err != nil {
+			return newValidationError(fmt.Errorf("checking revocations: %w", err))
+		}
+	}
+
 	validateOpts := &validate.Options{
 		// Check that the attestation key's digest is included in the report.
 		ReportData: akDigest[:],
-		GuestPolicy: abi.SnpPolicy{
-			Debug: false, // Debug means the VM can be decrypted by the host for debugging purposes and thus is not allowed.
-			SMT:   true,  // Allow Simultaneous Multi-Threading (SMT). Normally, we would want to disable SMT
-			// but AWS machines are currently facing issues if it's disabled.
-		},
-		VMPL: new(int), // Checks that Virtual Machine Privilege Level (VMPL) is 0.
+		// Check that the guest policy and platform info match the attestation config.
+		GuestPolicy:  snp.GuestPolicy(config.GuestPolicy),
+		PlatformInfo: snp.PlatformInfo(config.PlatformInfo),
+		VMPL:         new(int), // Checks that Virtual Machine Privilege Level (VMPL) is 0.
 		// This checks that the reported LaunchTCB version is equal or greater than the minimum specified in the config.
 		// We don't specify Options.MinimumTCB as it only restricts the allowed TCB for Current_ and Reported_TCB.
 		// Because we allow Options.ProvisionalFirmware, there is not security gained in also checking Current_ and Reported_TCB.
@@ -178,6 +185,11 @@ func (a *awsValidator) validate(attestation vtpm.AttestationDocument, ask *x509.
 		PermitProvisionalFirmware: true,
 	}
 
+	// Check the guest policy and platform info first to return errors that name the violated config field.
+	if err := snp.CheckPolicy(att.Report, config.GuestPolicy, config.PlatformInfo); err != nil {
+		return newValidationError(fmt.Errorf("report violates attestation config: %w", err))
+	}
+
 	// Checks if the attestation report matches the given constraints.
 	// Some constraints are implicitly checked by validate.SnpAttestation:
 	// - the report is not expired
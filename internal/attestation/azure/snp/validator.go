@@ -22,7 +22,6 @@ import (
 	"github.com/edgelesssys/constellation/v2/internal/attestation/variant"
 	"github.com/edgelesssys/constellation/v2/internal/attestation/vtpm"
 	"github.com/edgelesssys/constellation/v2/internal/config"
-	"github.com/google/go-sev-guest/abi"
 	"github.com/google/go-sev-guest/kds"
 	spb "github.com/google/go-sev-guest/proto/sevsnp"
 	"github.com/google/go-sev-guest/validate"
@@ -42,6 +41,7 @@ type Validator struct {
 
 	attestationVerifier  attestationVerifier
 	attestationValidator attestationValidator
+	revocations          revocationChecker
 
 	config *config.AzureSEVSNP
 
@@ -56,6 +56,10 @@ type attestationValidator interface {
 	SNPAttestation(attestation *spb.Attestation, options *validate.Options) error
 }
 
+type revocationChecker interface {
+	Check(att *spb.Attestation, pinned config.CRL, log attestation.Logger) error
+}
+
 type attestationVerifierImpl struct{}
 
 // SNPAttestation verifies the report signature, the VCEK certificate, as well as the certificate chain of the attestation report.
@@ -83,9 +87,10 @@ func NewValidator(cfg *config.AzureSEVSNP, log attestation.Logger) *Validator {
 		getter:               trust.DefaultHTTPSGetter(),
 		attestationVerifier:  attestationVerifierImpl{},
 		attestationValidator: attestationValidatorImpl{},
+		revocations:          snp.NewRevocationChecker(trust.DefaultHTTPSGetter()),
 	}
 	v.Validator = vtpm.NewValidator(
-		cfg.Measurements,
+		config.MeasurementSets(cfg),
 		v.getTrustedKey,
 		// stub, since SEV-SNP attestation is already verified in trustedKeyFromSNP().
 		func(vtpm.AttestationDocument, *attest.MachineState) error {
@@ -125,16 +130,25 @@ func (v *Validator) getTrustedKey(ctx context.Context, attDoc vtpm.AttestationDo
 		return nil, fmt.Errorf("verifying SNP attestation: %w", err)
 	}
 
+	if v.config.CheckRevocations {
+		if err := v.revocations.Check(att, v.config.AMDCRL, v.log); err != nil {
+			return nil, fmt.Errorf("checking revocations: %w", err)
+		}
+	}
+
+	// Check the guest policy and platform info first to return errors that name the violated config field.
+	if err := snp.CheckPolicy(att.Report, v.config.GuestPolicy, v.config.PlatformInfo); err != nil {
+		return nil, fmt.Errorf("report violates attestation config: %w", err)
+	}
+
 	// Checks if the attestation report matches the given constraints.
 	// Some constraints are implicitly checked by validate.SnpAttestation:
 	// - the report is not expired
 	if err := v.attestationValidator.SNPAttestation(att, &validate.Options{
-		GuestPolicy: abi.SnpPolicy{
-			Debug: false, // Debug means the VM can be decrypted by the host for debugging purposes and thus is not allowed.
-			SMT:   true,  // Allow Simultaneous Multi-Threading (SMT). Normally, we would want to disable SMT
-			// but Azure does not allow to disable it.
-		},
-		VMPL: new(int), // Checks that Virtual Machine Privilege Level (VMPL) is 0.
+		// Check that the guest policy and platform info match the attestation config.
+		GuestPolicy:  snp.GuestPolicy(v.config.GuestPolicy),
+		PlatformInfo: snp.PlatformInfo(v.config.PlatformInfo),
+		VMPL:         new(int), // Checks that Virtual Machine Privilege Level (VMPL) is 0.
 		// This checks that the reported TCB version is equal or greater than the minimum specified in the config.
 		MinimumTCB: kds.TCBParts{
 			BlSpl:    v.config.BootloaderVersion.Value, // Bootloader
@@ -46,7 +46,7 @@ func NewValidator(cfg *config.AzureTDX, log attestation.Logger) *Validator {
 	}
 
 	v.Validator = vtpm.NewValidator(
-		cfg.Measurements,
+		config.MeasurementSets(cfg),
 		v.getTrustedTPMKey,
 		func(vtpm.AttestationDocument, *attest.MachineState) error {
 			return nil
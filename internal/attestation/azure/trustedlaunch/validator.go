@@ -41,7 +41,7 @@ func NewValidator(cfg *config.AzureTrustedLaunch, log attestation.Logger) *Valid
 	rootPool.AddCert(ameRoot)
 	v := &Validator{roots: rootPool}
 	v.Validator = vtpm.NewValidator(
-		cfg.Measurements,
+		config.MeasurementSets(cfg),
 		v.verifyAttestationKey,
 		validateVM,
 		log,
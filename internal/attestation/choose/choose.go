@@ -18,7 +18,10 @@ import (
 	"github.com/edgelesssys/constellation/v2/internal/attestation/azure/trustedlaunch"
 	"github.com/edgelesssys/constellation/v2/internal/attestation/gcp/es"
 	gcpsnp "github.com/edgelesssys/constellation/v2/internal/attestation/gcp/snp"
+	gcptdx "github.com/edgelesssys/constellation/v2/internal/attestation/gcp/tdx"
+	"github.com/edgelesssys/constellation/v2/internal/attestation/policy"
 	"github.com/edgelesssys/constellation/v2/internal/attestation/qemu"
+	qemusnp "github.com/edgelesssys/constellation/v2/internal/attestation/qemu/snp"
 	"github.com/edgelesssys/constellation/v2/internal/attestation/tdx"
 	"github.com/edgelesssys/constellation/v2/internal/attestation/variant"
 	"github.com/edgelesssys/constellation/v2/internal/config"
@@ -41,8 +44,12 @@ func Issuer(attestationVariant variant.Variant, log attestation.Logger) (atls.Is
 		return es.NewIssuer(log), nil
 	case variant.GCPSEVSNP{}:
 		return gcpsnp.NewIssuer(log), nil
+	case variant.GCPTDX{}:
+		return gcptdx.NewIssuer(log), nil
 	case variant.QEMUVTPM{}:
 		return qemu.NewIssuer(log), nil
+	case variant.QEMUSEVSNP{}:
+		return qemusnp.NewIssuer(log), nil
 	case variant.QEMUTDX{}:
 		return tdx.NewIssuer(log), nil
 	case variant.Dummy{}:
@@ -53,7 +60,24 @@ func Issuer(attestationVariant variant.Variant, log attestation.Logger) (atls.Is
 }
 
 // Validator returns the validator for the given variant.
+// If the config defines an attestation policy, the validator additionally enforces the policy.
 func Validator(cfg config.AttestationCfg, log attestation.Logger) (atls.Validator, error) {
+	validator, err := variantValidator(cfg, log)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(cfg.GetPolicy()) == 0 {
+		return validator, nil
+	}
+	attestationPolicy, err := config.CompilePolicy(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("compiling attestation policy: %w", err)
+	}
+	return policy.NewValidator(validator, cfg.GetVariant(), attestationPolicy), nil
+}
+
+func variantValidator(cfg config.AttestationCfg, log attestation.Logger) (atls.Validator, error) {
 	switch cfg := cfg.(type) {
 	case *config.AWSSEVSNP:
 		return awssnp.NewValidator(cfg, log), nil
@@ -69,8 +93,12 @@ func Validator(cfg config.AttestationCfg, log attestation.Logger) (atls.Validato
 		return es.NewValidator(cfg, log)
 	case *config.GCPSEVSNP:
 		return gcpsnp.NewValidator(cfg, log)
+	case *config.GCPTDX:
+		return gcptdx.NewValidator(cfg, log)
 	case *config.QEMUVTPM:
 		return qemu.NewValidator(cfg, log), nil
+	case *config.QEMUSEVSNP:
+		return qemusnp.NewValidator(cfg, log), nil
 	case *config.QEMUTDX:
 		return tdx.NewValidator(cfg, log), nil
 	case *config.DummyCfg:
@@ -43,9 +43,15 @@ func TestIssuer(t *testing.T) {
 		"gcp-sev-snp": {
 			variant: variant.GCPSEVSNP{},
 		},
+		"gcp-tdx": {
+			variant: variant.GCPTDX{},
+		},
 		"qemu-vtpm": {
 			variant: variant.QEMUVTPM{},
 		},
+		"qemu-sev-snp": {
+			variant: variant.QEMUSEVSNP{},
+		},
 		"dummy": {
 			variant: variant.Dummy{},
 		},
@@ -95,12 +101,29 @@ func TestValidator(t *testing.T) {
 		"gcp-sev-snp": {
 			cfg: &config.GCPSEVSNP{},
 		},
+		"gcp-tdx": {
+			cfg: &config.GCPTDX{},
+		},
 		"qemu-vtpm": {
 			cfg: &config.QEMUVTPM{},
 		},
+		"qemu-sev-snp": {
+			cfg: &config.QEMUSEVSNP{},
+		},
 		"dummy": {
 			cfg: &config.DummyCfg{},
 		},
+		"with attestation policy": {
+			cfg: &config.QEMUVTPM{
+				Policy: []config.PolicyRule{{Name: "variant", Expression: `claims.variant == "qemu-vtpm"`}},
+			},
+		},
+		"invalid attestation policy": {
+			cfg: &config.QEMUVTPM{
+				Policy: []config.PolicyRule{{Name: "invalid", Expression: "claims.variant =="}},
+			},
+			wantErr: true,
+		},
 		"unknown": {
 			cfg:     unknownConfig{},
 			wantErr: true,
@@ -144,10 +167,20 @@ func (unknownConfig) GetVariant() variant.Variant {
 	return unknownVariant{}
 }
 
+func (unknownConfig) GetPolicy() []config.PolicyRule {
+	return nil
+}
+
 func (unknownConfig) GetMeasurements() measurements.M {
 	return nil
 }
 
 func (unknownConfig) SetMeasurements(measurements.M) {}
 
+func (unknownConfig) GetAcceptedMeasurements() []measurements.M {
+	return nil
+}
+
+func (unknownConfig) SetAcceptedMeasurements([]measurements.M) {}
+
 func (unknownConfig) EqualTo(config.AttestationCfg) (bool, error) { return false, nil }
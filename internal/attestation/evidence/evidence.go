@@ -0,0 +1,167 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+/*
+Package evidence defines the archived attestation evidence of joining nodes.
+
+The JoinService archives the attestation statement of every node it accepts, together with the nonce it was issued for,
+so the statement can later be re-validated offline, e.g., by `constellation verify --from-archive`.
+Records are stored as JSON in ConfigMaps labeled with constants.JoinEvidenceLabel.
+*/
+package evidence
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/edgelesssys/constellation/v2/internal/atls"
+	"github.com/edgelesssys/constellation/v2/internal/attestation/variant"
+	"github.com/edgelesssys/constellation/v2/internal/config"
+)
+
+// SchemaVersion identifies the schema of a Record.
+const SchemaVersion = "constellation.edgeless.systems/evidence/v1"
+
+// Record is the attestation evidence a node was accepted with.
+type Record struct {
+	// SchemaVersion is the schema of the record.
+	SchemaVersion string `json:"schema_version"`
+	// Variant is the attestation variant of the node.
+	Variant string `json:"variant"`
+	// NodeName is the name of the node.
+	NodeName string `json:"node_name"`
+	// Timestamp is the time the attestation statement was validated.
+	Timestamp time.Time `json:"timestamp"`
+	// Nonce is the nonce the attestation statement was requested with.
+	Nonce []byte `json:"nonce"`
+	// UserData is the data bound to the attestation statement, i.e., the hash of the node's aTLS public key.
+	UserData []byte `json:"user_data"`
+	// AttestationDocument is the raw attestation statement of the node.
+	AttestationDocument []byte `json:"attestation_document"`
+	// ConfigHash is the hash of the attestation config the statement was validated with, see ConfigHash.
+	ConfigHash string `json:"config_hash"`
+}
+
+// Key returns a key that uniquely identifies the record.
+// The key can be used as ConfigMap key.
+func (r Record) Key() string {
+	return r.NodeName + "." + strconv.FormatInt(r.Timestamp.UnixNano(), 10)
+}
+
+// Validate validates the archived attestation statement using the given validator.
+func (r Record) Validate(ctx context.Context, validator atls.Validator) error {
+	recordVariant, err := variant.FromString(r.Variant)
+	if err != nil {
+		return fmt.Errorf("parsing attestation variant: %w", err)
+	}
+	if !recordVariant.OID().Equal(validator.OID()) {
+		return fmt.Errorf("record has attestation variant %s, but validator expects a different variant", r.Variant)
+	}
+	userData, err := validator.Validate(ctx, r.AttestationDocument, r.Nonce)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(userData, r.UserData) {
+		return errors.New("user data of attestation statement does not match record")
+	}
+	return nil
+}
+
+// ConfigHash returns the hash of an attestation config.
+// It is used to identify the attestation config a statement was validated with.
+func ConfigHash(attestationCfg config.AttestationCfg) (string, error) {
+	data, err := json.Marshal(attestationCfg)
+	if err != nil {
+		return "", fmt.Errorf("marshaling attestation config: %w", err)
+	}
+	hash := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(hash[:]), nil
+}
+
+// ReadRecords parses records from data.
+// data may contain records as JSON objects, one after another (e.g., JSON lines),
+// as well as ConfigMaps or lists of ConfigMaps storing records, as printed by `kubectl get configmaps -o json`.
+// The records are returned sorted by timestamp.
+func ReadRecords(data []byte) ([]Record, error) {
+	var records []Record
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	for {
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("decoding evidence: %w", err)
+		}
+
+		var object struct {
+			Kind  string            `json:"kind"`
+			Data  map[string]string `json:"data"`
+			Items []struct {
+				Data map[string]string `json:"data"`
+			} `json:"items"`
+		}
+		if err := json.Unmarshal(raw, &object); err != nil {
+			return nil, fmt.Errorf("decoding evidence: %w", err)
+		}
+
+		var values []string
+		switch object.Kind {
+		case "ConfigMap":
+			values = mapValues(object.Data)
+		case "List", "ConfigMapList":
+			for _, item := range object.Items {
+				values = append(values, mapValues(item.Data)...)
+			}
+		default:
+			values = []string{string(raw)}
+		}
+
+		for _, value := range values {
+			record, err := unmarshalRecord([]byte(value))
+			if err != nil {
+				return nil, err
+			}
+			records = append(records, record)
+		}
+	}
+
+	slices.SortStableFunc(records, func(a, b Record) int {
+		if c := a.Timestamp.Compare(b.Timestamp); c != 0 {
+			return c
+		}
+		return strings.Compare(a.NodeName, b.NodeName)
+	})
+	return records, nil
+}
+
+func unmarshalRecord(data []byte) (Record, error) {
+	var record Record
+	if err := json.Unmarshal(data, &record); err != nil {
+		return Record{}, fmt.Errorf("unmarshaling evidence record: %w", err)
+	}
+	if record.SchemaVersion != SchemaVersion {
+		return Record{}, fmt.Errorf("unsupported evidence schema version %q", record.SchemaVersion)
+	}
+	return record, nil
+}
+
+func mapValues(m map[string]string) []string {
+	values := make([]string, 0, len(m))
+	for _, v := range m {
+		values = append(values, v)
+	}
+	return values
+}
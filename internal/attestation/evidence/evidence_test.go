@@ -0,0 +1,172 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package evidence
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/edgelesssys/constellation/v2/internal/atls"
+	"github.com/edgelesssys/constellation/v2/internal/attestation/measurements"
+	"github.com/edgelesssys/constellation/v2/internal/attestation/variant"
+	"github.com/edgelesssys/constellation/v2/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	nonce := []byte("nonce")
+	userData := []byte("user data")
+	attDoc, err := json.Marshal(atls.FakeAttestationDoc{UserData: userData, Nonce: nonce})
+	require.NoError(t, err)
+	validRecord := Record{
+		SchemaVersion:       SchemaVersion,
+		Variant:             variant.Dummy{}.String(),
+		Nonce:               nonce,
+		UserData:            userData,
+		AttestationDocument: attDoc,
+	}
+
+	testCases := map[string]struct {
+		modify  func(*Record)
+		wantErr bool
+	}{
+		"valid": {
+			modify: func(*Record) {},
+		},
+		"different nonce": {
+			modify:  func(r *Record) { r.Nonce = []byte("other") },
+			wantErr: true,
+		},
+		"different user data": {
+			modify:  func(r *Record) { r.UserData = []byte("other") },
+			wantErr: true,
+		},
+		"different variant": {
+			modify:  func(r *Record) { r.Variant = variant.QEMUVTPM{}.String() },
+			wantErr: true,
+		},
+		"unknown variant": {
+			modify:  func(r *Record) { r.Variant = "unknown" },
+			wantErr: true,
+		},
+		"invalid attestation document": {
+			modify:  func(r *Record) { r.AttestationDocument = []byte("invalid") },
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			record := validRecord
+			tc.modify(&record)
+			err := record.Validate(t.Context(), atls.NewFakeValidator(variant.Dummy{}))
+			if tc.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestConfigHash(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	newCfg := func(pcr9 byte) config.AttestationCfg {
+		return &config.QEMUVTPM{Measurements: measurements.M{9: measurements.WithAllBytes(pcr9, measurements.Enforce, measurements.PCRMeasurementLength)}}
+	}
+
+	hash, err := ConfigHash(newCfg(0x01))
+	require.NoError(err)
+	assert.Regexp("^sha256:[0-9a-f]{64}$", hash)
+
+	sameHash, err := ConfigHash(newCfg(0x01))
+	require.NoError(err)
+	assert.Equal(hash, sameHash)
+
+	otherHash, err := ConfigHash(newCfg(0x02))
+	require.NoError(err)
+	assert.NotEqual(hash, otherHash)
+}
+
+func TestReadRecords(t *testing.T) {
+	first := Record{SchemaVersion: SchemaVersion, NodeName: "node-b", Timestamp: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	second := Record{SchemaVersion: SchemaVersion, NodeName: "node-a", Timestamp: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)}
+	third := Record{SchemaVersion: SchemaVersion, NodeName: "node-b", Timestamp: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)}
+	marshal := func(r Record) string {
+		data, err := json.Marshal(r)
+		require.NoError(t, err)
+		return string(data)
+	}
+	quote := func(s string) string {
+		data, err := json.Marshal(s)
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	testCases := map[string]struct {
+		data        string
+		wantRecords []Record
+		wantErr     bool
+	}{
+		"empty": {},
+		"JSON lines": {
+			data:        marshal(third) + "\n" + marshal(first) + "\n" + marshal(second) + "\n",
+			wantRecords: []Record{first, second, third},
+		},
+		"ConfigMap": {
+			data: fmt.Sprintf(`{"kind":"ConfigMap","data":{"a":%s,"b":%s}}`,
+				quote(marshal(second)), quote(marshal(first))),
+			wantRecords: []Record{first, second},
+		},
+		"list of ConfigMaps": {
+			data: fmt.Sprintf(`{"kind":"List","items":[{"data":{"a":%s}},{"data":{"b":%s,"c":%s}}]}`,
+				quote(marshal(third)), quote(marshal(second)), quote(marshal(first))),
+			wantRecords: []Record{first, second, third},
+		},
+		"unsupported schema version": {
+			data:    `{"schema_version":"other"}`,
+			wantErr: true,
+		},
+		"invalid record in ConfigMap": {
+			data:    `{"kind":"ConfigMap","data":{"a":"invalid"}}`,
+			wantErr: true,
+		},
+		"invalid JSON": {
+			data:    marshal(first) + "\n{",
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			records, err := ReadRecords([]byte(tc.data))
+			if tc.wantErr {
+				assert.Error(err)
+				return
+			}
+			require.NoError(err)
+			require.Len(records, len(tc.wantRecords))
+			for i := range records {
+				assert.Equal(tc.wantRecords[i].NodeName, records[i].NodeName)
+				assert.True(tc.wantRecords[i].Timestamp.Equal(records[i].Timestamp))
+			}
+		})
+	}
+}
+
+func TestKey(t *testing.T) {
+	record := Record{NodeName: "worker-0", Timestamp: time.Unix(1, 5)}
+	assert.Equal(t, "worker-0.1000000005", record.Key())
+}
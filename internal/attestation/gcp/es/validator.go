@@ -34,7 +34,7 @@ func NewValidator(cfg *config.GCPSEVES, log attestation.Logger) (*Validator, err
 
 	return &Validator{
 		Validator: vtpm.NewValidator(
-			cfg.Measurements,
+			config.MeasurementSets(cfg),
 			getTrustedKey,
 			validateCVM,
 			log,
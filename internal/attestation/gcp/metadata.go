@@ -10,6 +10,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 
 	"cloud.google.com/go/compute/metadata"
@@ -44,6 +45,30 @@ func GCEInstanceInfo(client gcpMetadataClient) func(context.Context, io.ReadWrit
 	}
 }
 
+// InstanceInfo returns the instance info for a GCE instance from the GCE Metadata API.
+func InstanceInfo(ctx context.Context, client gcpMetadataClient) (*attest.GCEInstanceInfo, error) {
+	instanceName, err := client.InstanceName(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting instance name: %w", err)
+	}
+
+	projectID, err := client.ProjectID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting project ID: %w", err)
+	}
+
+	zone, err := client.Zone(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting zone: %w", err)
+	}
+
+	return &attest.GCEInstanceInfo{
+		InstanceName: instanceName,
+		ProjectId:    projectID,
+		Zone:         zone,
+	}, nil
+}
+
 type gcpMetadataClient interface {
 	ProjectID(context.Context) (string, error)
 	InstanceName(context.Context) (string, error)
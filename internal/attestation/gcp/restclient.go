@@ -73,6 +73,23 @@ func TrustedKeyGetter(
 				ProjectId:    instanceInfo.GCP.ProjectId,
 				Zone:         instanceInfo.GCP.Zone,
 			}
+		case variant.GCPTDX{}:
+			// The GCE instance info is stored in the GCP field of the TDX instance info.
+			// The TDX instance info type can't be used here, since its package imports this package.
+			var instanceInfo struct {
+				GCP *attest.GCEInstanceInfo
+			}
+			if err := json.Unmarshal(attDoc.InstanceInfo, &instanceInfo); err != nil {
+				return nil, err
+			}
+			if instanceInfo.GCP == nil {
+				return nil, fmt.Errorf("no GCE instance info in attestation document")
+			}
+			gceInstanceInfo = attest.GCEInstanceInfo{
+				InstanceName: instanceInfo.GCP.InstanceName,
+				ProjectId:    instanceInfo.GCP.ProjectId,
+				Zone:         instanceInfo.GCP.Zone,
+			}
 		default:
 			return nil, fmt.Errorf("unsupported attestation variant: %v", attestationVariant)
 		}
@@ -8,9 +8,7 @@ package snp
 
 import (
 	"context"
-	"crypto/x509"
 	"encoding/json"
-	"encoding/pem"
 	"fmt"
 	"io"
 
@@ -20,10 +18,8 @@ import (
 	"github.com/edgelesssys/constellation/v2/internal/attestation/variant"
 	"github.com/edgelesssys/constellation/v2/internal/attestation/vtpm"
 
-	"github.com/google/go-sev-guest/abi"
 	"github.com/google/go-tpm-tools/client"
 	tpmclient "github.com/google/go-tpm-tools/client"
-	"github.com/google/go-tpm-tools/proto/attest"
 )
 
 // Issuer issues SEV-SNP attestations.
@@ -69,12 +65,12 @@ func getInstanceInfo(ctx context.Context, _ io.ReadWriteCloser, extraData []byte
 		return nil, fmt.Errorf("getting extended report: %w", err)
 	}
 
-	vcek, certChain, err := parseSNPCertTable(certs)
+	vcek, certChain, err := snp.ParseCertTable(certs)
 	if err != nil {
 		return nil, fmt.Errorf("parsing vcek: %w", err)
 	}
 
-	gceInstanceInfo, err := gceInstanceInfo(ctx)
+	gceInstanceInfo, err := gcp.InstanceInfo(ctx, gcp.MetadataClient{})
 	if err != nil {
 		return nil, fmt.Errorf("getting GCE instance info: %w", err)
 	}
@@ -91,71 +87,3 @@ func getInstanceInfo(ctx context.Context, _ io.ReadWriteCloser, extraData []byte
 
 	return raw, nil
 }
-
-// gceInstanceInfo returns the instance info for a GCE instance from the metadata API.
-func gceInstanceInfo(ctx context.Context) (*attest.GCEInstanceInfo, error) {
-	c := gcp.MetadataClient{}
-
-	instanceName, err := c.InstanceName(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("getting instance name: %w", err)
-	}
-
-	projectID, err := c.ProjectID(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("getting project ID: %w", err)
-	}
-
-	zone, err := c.Zone(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("getting zone: %w", err)
-	}
-
-	return &attest.GCEInstanceInfo{
-		InstanceName: instanceName,
-		ProjectId:    projectID,
-		Zone:         zone,
-	}, nil
-}
-
-// parseSNPCertTable takes a marshalled SNP certificate table and returns the PEM-encoded VCEK certificate and,
-// if present, the ASK of the SNP certificate chain.
-// AMD documentation on certificate tables can be found in section 4.1.8.1, revision 2.03 "SEV-ES Guest-Hypervisor Communication Block Standardization".
-// https://www.amd.com/content/dam/amd/en/documents/epyc-technical-docs/specifications/56421.pdf
-func parseSNPCertTable(certs []byte) (vcekPEM []byte, certChain []byte, err error) {
-	certTable := abi.CertTable{}
-	if err := certTable.Unmarshal(certs); err != nil {
-		return nil, nil, fmt.Errorf("unmarshalling SNP certificate table: %w", err)
-	}
-
-	vcekRaw, err := certTable.GetByGUIDString(abi.VcekGUID)
-	if err != nil {
-		return nil, nil, fmt.Errorf("getting VCEK certificate: %w", err)
-	}
-
-	// An optional check for certificate well-formedness. vcekRaw == cert.Raw.
-	vcek, err := x509.ParseCertificate(vcekRaw)
-	if err != nil {
-		return nil, nil, fmt.Errorf("parsing certificate: %w", err)
-	}
-
-	vcekPEM = pem.EncodeToMemory(&pem.Block{
-		Type:  "CERTIFICATE",
-		Bytes: vcek.Raw,
-	})
-
-	var askPEM []byte
-	if askRaw, err := certTable.GetByGUIDString(abi.AskGUID); err == nil {
-		ask, err := x509.ParseCertificate(askRaw)
-		if err != nil {
-			return nil, nil, fmt.Errorf("parsing ASK certificate: %w", err)
-		}
-
-		askPEM = pem.EncodeToMemory(&pem.Block{
-			Type:  "CERTIFICATE",
-			Bytes: ask.Raw,
-		})
-	}
-
-	return vcekPEM, askPEM, nil
-}
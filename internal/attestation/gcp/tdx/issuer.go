@@ -0,0 +1,87 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package tdx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/edgelesssys/constellation/v2/internal/attestation"
+	"github.com/edgelesssys/constellation/v2/internal/attestation/gcp"
+	"github.com/edgelesssys/constellation/v2/internal/attestation/variant"
+	"github.com/edgelesssys/constellation/v2/internal/attestation/vtpm"
+	"github.com/google/go-tdx-guest/client"
+	tpmclient "github.com/google/go-tpm-tools/client"
+	"github.com/google/go-tpm-tools/proto/attest"
+)
+
+// Issuer issues TDX attestations.
+type Issuer struct {
+	variant.GCPTDX
+	*vtpm.Issuer
+
+	quoteGetter  func(reportData [64]byte) ([]byte, error)
+	instanceInfo func(ctx context.Context) (*attest.GCEInstanceInfo, error)
+}
+
+// NewIssuer creates a TDX based issuer for GCP.
+func NewIssuer(log attestation.Logger) *Issuer {
+	i := &Issuer{
+		quoteGetter: getQuote,
+		instanceInfo: func(ctx context.Context) (*attest.GCEInstanceInfo, error) {
+			return gcp.InstanceInfo(ctx, gcp.MetadataClient{})
+		},
+	}
+
+	i.Issuer = vtpm.NewIssuer(
+		vtpm.OpenVTPM,
+		tpmclient.GceAttestationKeyRSA,
+		i.getInstanceInfo,
+		log,
+	)
+	return i
+}
+
+// getInstanceInfo generates a TDX quote with the extra data as its report data.
+// The returned bytes will be written into the attestation document.
+func (i *Issuer) getInstanceInfo(ctx context.Context, _ io.ReadWriteCloser, extraData []byte) ([]byte, error) {
+	if len(extraData) > 64 {
+		return nil, fmt.Errorf("extra data too long: %d, should be 64 bytes at most", len(extraData))
+	}
+	var reportData [64]byte
+	copy(reportData[:], extraData)
+
+	quote, err := i.quoteGetter(reportData)
+	if err != nil {
+		return nil, fmt.Errorf("getting TDX quote: %w", err)
+	}
+
+	gceInstanceInfo, err := i.instanceInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting GCE instance info: %w", err)
+	}
+
+	raw, err := json.Marshal(InstanceInfo{
+		AttestationReport: quote,
+		GCP:               gceInstanceInfo,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("marshalling instance info: %w", err)
+	}
+	return raw, nil
+}
+
+// getQuote retrieves a TDX quote using the configfs-tsm interface, falling back to the TDX guest device.
+func getQuote(reportData [64]byte) ([]byte, error) {
+	quoteProvider, err := client.GetQuoteProvider()
+	if err != nil {
+		return nil, fmt.Errorf("getting quote provider: %w", err)
+	}
+	return client.GetRawQuote(quoteProvider, reportData)
+}
@@ -0,0 +1,83 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package tdx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/edgelesssys/constellation/v2/internal/attestation/gcp/tdx/testdata"
+	"github.com/google/go-tpm-tools/proto/attest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestGetInstanceInfo(t *testing.T) {
+	gceInstanceInfo := &attest.GCEInstanceInfo{InstanceName: "instance", ProjectId: "project", Zone: "zone"}
+
+	testCases := map[string]struct {
+		extraData       []byte
+		quoteErr        error
+		instanceInfoErr error
+		wantErr         bool
+	}{
+		"success": {
+			extraData: []byte("extra data"),
+		},
+		"extra data too long": {
+			extraData: make([]byte, 65),
+			wantErr:   true,
+		},
+		"error getting quote": {
+			extraData: []byte("extra data"),
+			quoteErr:  errors.New("failed"),
+			wantErr:   true,
+		},
+		"error getting instance info": {
+			extraData:       []byte("extra data"),
+			instanceInfoErr: errors.New("failed"),
+			wantErr:         true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			var gotReportData [64]byte
+			issuer := &Issuer{
+				quoteGetter: func(reportData [64]byte) ([]byte, error) {
+					gotReportData = reportData
+					return testdata.Quote, tc.quoteErr
+				},
+				instanceInfo: func(context.Context) (*attest.GCEInstanceInfo, error) {
+					return gceInstanceInfo, tc.instanceInfoErr
+				},
+			}
+
+			raw, err := issuer.getInstanceInfo(t.Context(), nil, tc.extraData)
+			if tc.wantErr {
+				assert.Error(err)
+				return
+			}
+			require.NoError(err)
+
+			var wantReportData [64]byte
+			copy(wantReportData[:], tc.extraData)
+			assert.Equal(wantReportData, gotReportData)
+
+			var instanceInfo InstanceInfo
+			require.NoError(json.Unmarshal(raw, &instanceInfo))
+			assert.Equal(testdata.Quote, instanceInfo.AttestationReport)
+			assert.True(proto.Equal(gceInstanceInfo, instanceInfo.GCP))
+		})
+	}
+}
@@ -0,0 +1,51 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+/*
+# GCP TDX attestation
+
+Google offers [confidential VMs] utilizing Intel TDX to provide memory encryption.
+
+Each TDX VM comes with a [virtual Trusted Platform Module (vTPM)].
+This vTPM can be used to generate encryption keys unique to the VM or to attest the platform's boot chain.
+We can use the vTPM to verify the VM is running on Intel TDX enabled hardware and booted the expected OS image, allowing us to bootstrap a constellation cluster.
+
+# Issuer
+
+Retrieves a TDX quote for the VM it's running in, using the TDX guest device or the Linux configfs-tsm interface.
+The quote's report data is set to the extra data of the attestation, binding the TDX quote to the TPM attestation statement.
+Additionally project ID, zone, and instance name are fetched from the metadata server and attached to the attestation statement.
+
+# Validator
+
+First, it verifies the TDX quote by checking the signatures and collateral against the Intel root key, and validates its claims against the attestation config.
+Then, it verifies the TPM attestation by using a public key provided by Google's API corresponding to the project ID, zone, instance name tuple attached to the attestation document.
+
+# Problems
+
+  - We have to trust Google
+
+    Since the vTPM is provided by Google, and they could do whatever they want with it, we have no save proof of the VMs actually being confidential.
+
+  - The provided vTPM has no endorsement certificate for its attestation key
+
+    Without a certificate signing the authenticity of any endorsement keys we have no way of establishing a chain of trust.
+    Instead, we have to rely on Google's API to provide us with the public key of the vTPM's endorsement key.
+
+[confidential VMs]: https://cloud.google.com/confidential-computing/confidential-vm/docs/confidential-vm-overview
+[virtual Trusted Platform Module (vTPM)]: https://cloud.google.com/security/shielded-cloud/shielded-vm#vtpm
+*/
+package tdx
+
+import "github.com/google/go-tpm-tools/proto/attest"
+
+// InstanceInfo wraps the TDX quote with additional GCP specific runtime data.
+type InstanceInfo struct {
+	// AttestationReport is the raw TDX quote.
+	AttestationReport []byte
+	// GCP contains the GCE instance info used to retrieve the vTPM's signing key.
+	GCP *attest.GCEInstanceInfo
+}
@@ -0,0 +1,15 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+// Package testdata contains testing data for an attestation process.
+package testdata
+
+import _ "embed"
+
+// Quote is a recorded TDX quote (version 4) from an Intel Sapphire Rapids machine.
+//
+//go:embed quote.dat
+var Quote []byte
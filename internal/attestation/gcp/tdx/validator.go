@@ -0,0 +1,155 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package tdx
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/edgelesssys/constellation/v2/internal/attestation"
+	"github.com/edgelesssys/constellation/v2/internal/attestation/gcp"
+	"github.com/edgelesssys/constellation/v2/internal/attestation/variant"
+	"github.com/edgelesssys/constellation/v2/internal/attestation/vtpm"
+	"github.com/edgelesssys/constellation/v2/internal/config"
+	"github.com/google/go-tdx-guest/abi"
+	"github.com/google/go-tdx-guest/proto/tdx"
+	"github.com/google/go-tdx-guest/validate"
+	"github.com/google/go-tdx-guest/verify"
+	"github.com/google/go-tdx-guest/verify/trust"
+	"github.com/google/go-tpm-tools/proto/attest"
+)
+
+// Validator for GCP TDX / TPM attestation.
+type Validator struct {
+	variant.GCPTDX
+	*vtpm.Validator
+	cfg *config.GCPTDX
+
+	// verifier verifies the TDX quote. verifier is required for testing.
+	verifier quoteVerifier
+	// getter retrieves the TDX collateral from the Intel PCS.
+	getter trust.HTTPSGetter
+	// now returns the time at which certificates and collateral are checked for validity.
+	now func() time.Time
+
+	// gceKeyGetter gets the public key of the EK from the GCE metadata API.
+	gceKeyGetter func(ctx context.Context, attDoc vtpm.AttestationDocument, _ []byte) (crypto.PublicKey, error)
+}
+
+// NewValidator creates a new Validator.
+func NewValidator(cfg *config.GCPTDX, log attestation.Logger) (*Validator, error) {
+	getGCEKey, err := gcp.TrustedKeyGetter(variant.GCPTDX{}, gcp.NewRESTClient)
+	if err != nil {
+		return nil, fmt.Errorf("creating trusted key getter: %w", err)
+	}
+
+	v := &Validator{
+		cfg:          cfg,
+		verifier:     quoteVerifierImpl{},
+		getter:       trust.DefaultHTTPSGetter(),
+		now:          time.Now,
+		gceKeyGetter: getGCEKey,
+	}
+
+	v.Validator = vtpm.NewValidator(
+		config.MeasurementSets(cfg),
+		v.getTrustedKey,
+		func(vtpm.AttestationDocument, *attest.MachineState) error { return nil },
+		log,
+	)
+	return v, nil
+}
+
+// getTrustedKey validates the TDX quote and returns the TPM endorsement key provided through the GCE metadata API.
+func (v *Validator) getTrustedKey(ctx context.Context, attDoc vtpm.AttestationDocument, extraData []byte) (crypto.PublicKey, error) {
+	if len(extraData) > 64 {
+		return nil, fmt.Errorf("extra data too long: %d, should be 64 bytes at most", len(extraData))
+	}
+	var reportData [64]byte
+	copy(reportData[:], extraData)
+
+	var instanceInfo InstanceInfo
+	if err := json.Unmarshal(attDoc.InstanceInfo, &instanceInfo); err != nil {
+		return nil, fmt.Errorf("unmarshalling instance info: %w", err)
+	}
+
+	quotePb, err := abi.QuoteToProto(instanceInfo.AttestationReport)
+	if err != nil {
+		return nil, fmt.Errorf("parsing TDX quote: %w", err)
+	}
+	quote, ok := quotePb.(*tdx.QuoteV4)
+	if !ok {
+		return nil, fmt.Errorf("unexpected quote type: %T", quotePb)
+	}
+
+	if err := v.validateQuote(quote, reportData); err != nil {
+		return nil, fmt.Errorf("validating TDX quote: %w", err)
+	}
+
+	ekPub, err := v.gceKeyGetter(ctx, attDoc, nil)
+	if err != nil {
+		return nil, fmt.Errorf("getting TPM endorsement key: %w", err)
+	}
+
+	return ekPub, nil
+}
+
+// validateQuote verifies the quote's signature and certificate chain, and validates its claims against the attestation config.
+// The quote's report data must match the extra data of the attestation, binding the quote to the TPM attestation.
+func (v *Validator) validateQuote(quote *tdx.QuoteV4, reportData [64]byte) error {
+	roots := x509.NewCertPool()
+	roots.AddCert((*x509.Certificate)(&v.cfg.IntelRootKey))
+
+	now := v.now()
+	if err := v.verifier.TdxQuote(quote, &verify.Options{
+		CheckRevocations: true,
+		GetCollateral:    true,
+		TrustedRoots:     roots,
+		Getter:           v.getter,
+		Now: &verify.TimeSet{
+			PckCertChain: now,
+			TcbInfo:      now,
+			QeIdentity:   now,
+			PckCrl:       now,
+			RootCaCrl:    now,
+		},
+	}); err != nil {
+		return fmt.Errorf("verifying quote: %w", err)
+	}
+
+	if err := validate.TdxQuote(quote, &validate.Options{
+		HeaderOptions: validate.HeaderOptions{
+			MinimumQeSvn:  v.cfg.QESVN.Value,
+			MinimumPceSvn: v.cfg.PCESVN.Value,
+			QeVendorID:    v.cfg.QEVendorID.Value,
+		},
+		TdQuoteBodyOptions: validate.TdQuoteBodyOptions{
+			MinimumTeeTcbSvn: v.cfg.TEETCBSVN.Value,
+			MrSeam:           v.cfg.MRSeam,
+			Xfam:             v.cfg.XFAM.Value,
+			ReportData:       reportData[:],
+		},
+	}); err != nil {
+		return fmt.Errorf("validating quote claims: %w", err)
+	}
+
+	return nil
+}
+
+type quoteVerifier interface {
+	TdxQuote(quote *tdx.QuoteV4, opts *verify.Options) error
+}
+
+type quoteVerifierImpl struct{}
+
+func (quoteVerifierImpl) TdxQuote(quote *tdx.QuoteV4, opts *verify.Options) error {
+	return verify.TdxQuote(quote, opts)
+}
@@ -0,0 +1,181 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package tdx
+
+import (
+	"context"
+	"crypto"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/edgelesssys/constellation/v2/internal/attestation/gcp/tdx/testdata"
+	"github.com/edgelesssys/constellation/v2/internal/attestation/vtpm"
+	"github.com/edgelesssys/constellation/v2/internal/config"
+	"github.com/edgelesssys/constellation/v2/internal/encoding"
+	"github.com/google/go-tdx-guest/proto/tdx"
+	tdxtesting "github.com/google/go-tdx-guest/testing"
+	"github.com/google/go-tdx-guest/verify"
+	"github.com/google/go-tpm-tools/proto/attest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetTrustedKey(t *testing.T) {
+	// report data, MR_SEAM and time of the recorded quote
+	reportData := mustDecodeHex(t, "6c62dec1b8191749a31dab490be532a35944dea47caef1f980863993d9899545eb7406a38d1eed313b987a467dacead6f0c87a6d766c66f6f29f8acb281f1113")
+	mrSeam := mustDecodeHex(t, "2fd279c16164a93dd5bf373d834328d46008c2b693af9ebb865b08b2ced320c9a89b4869a9fab60fbe9d0c5a5363c656")
+	quoteTime := time.Date(2023, time.July, 1, 1, 0, 0, 0, time.UTC)
+
+	gceInstanceInfo := &attest.GCEInstanceInfo{InstanceName: "instance", ProjectId: "project", Zone: "zone"}
+	ekPub := &struct{ crypto.PublicKey }{}
+
+	testCases := map[string]struct {
+		quote        []byte
+		extraData    []byte
+		cfg          func(*config.GCPTDX)
+		now          time.Time
+		gceKeyGetter func(context.Context, vtpm.AttestationDocument, []byte) (crypto.PublicKey, error)
+		wantErr      bool
+	}{
+		"success": {
+			quote:     testdata.Quote,
+			extraData: reportData,
+			now:       quoteTime,
+		},
+		"success with MR_SEAM": {
+			quote:     testdata.Quote,
+			extraData: reportData,
+			cfg:       func(c *config.GCPTDX) { c.MRSeam = mrSeam },
+			now:       quoteTime,
+		},
+		"extra data not bound to quote": {
+			quote:     testdata.Quote,
+			extraData: []byte("other extra data"),
+			now:       quoteTime,
+			wantErr:   true,
+		},
+		"extra data too long": {
+			quote:     testdata.Quote,
+			extraData: make([]byte, 65),
+			now:       quoteTime,
+			wantErr:   true,
+		},
+		"MR_SEAM mismatch": {
+			quote:     testdata.Quote,
+			extraData: reportData,
+			cfg:       func(c *config.GCPTDX) { c.MRSeam = make([]byte, 48) },
+			now:       quoteTime,
+			wantErr:   true,
+		},
+		"QE SVN too low": {
+			quote:     testdata.Quote,
+			extraData: reportData,
+			cfg:       func(c *config.GCPTDX) { c.QESVN = config.AttestationVersion[uint16]{Value: 1} },
+			now:       quoteTime,
+			wantErr:   true,
+		},
+		"TEE TCB SVN too low": {
+			quote:     testdata.Quote,
+			extraData: reportData,
+			cfg: func(c *config.GCPTDX) {
+				c.TEETCBSVN = config.AttestationVersion[encoding.HexBytes]{Value: mustDecodeHex(t, "ff000000000000000000000000000000")}
+			},
+			now:     quoteTime,
+			wantErr: true,
+		},
+		"untrusted root key": {
+			quote:     testdata.Quote,
+			extraData: reportData,
+			cfg:       func(c *config.GCPTDX) { c.IntelRootKey = config.DefaultForAWSSEVSNP().AMDRootKey },
+			now:       quoteTime,
+			wantErr:   true,
+		},
+		"certificate chain expired": {
+			quote:     testdata.Quote,
+			extraData: reportData,
+			now:       time.Date(2053, time.July, 1, 1, 0, 0, 0, time.UTC),
+			wantErr:   true,
+		},
+		"invalid quote": {
+			quote:     []byte("invalid"),
+			extraData: reportData,
+			now:       quoteTime,
+			wantErr:   true,
+		},
+		"error getting endorsement key": {
+			quote:     testdata.Quote,
+			extraData: reportData,
+			now:       quoteTime,
+			gceKeyGetter: func(context.Context, vtpm.AttestationDocument, []byte) (crypto.PublicKey, error) {
+				return nil, errors.New("failed")
+			},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			cfg := &config.GCPTDX{
+				IntelRootKey: config.DefaultForGCPTDX().IntelRootKey,
+			}
+			if tc.cfg != nil {
+				tc.cfg(cfg)
+			}
+			gceKeyGetter := tc.gceKeyGetter
+			if gceKeyGetter == nil {
+				gceKeyGetter = func(context.Context, vtpm.AttestationDocument, []byte) (crypto.PublicKey, error) {
+					return ekPub, nil
+				}
+			}
+
+			v := &Validator{
+				cfg:          cfg,
+				verifier:     offlineQuoteVerifier{},
+				getter:       tdxtesting.TestGetter,
+				now:          func() time.Time { return tc.now },
+				gceKeyGetter: gceKeyGetter,
+			}
+
+			instanceInfo, err := json.Marshal(InstanceInfo{
+				AttestationReport: tc.quote,
+				GCP:               gceInstanceInfo,
+			})
+			require.NoError(err)
+
+			key, err := v.getTrustedKey(t.Context(), vtpm.AttestationDocument{InstanceInfo: instanceInfo}, tc.extraData)
+			if tc.wantErr {
+				assert.Error(err)
+				return
+			}
+			require.NoError(err)
+			assert.Equal(ekPub, key)
+		})
+	}
+}
+
+// offlineQuoteVerifier verifies the quote's signature and PCK certificate chain without checking the collateral.
+// The TCB info of the collateral available for testing doesn't match the TCB level of the recorded quote.
+type offlineQuoteVerifier struct{}
+
+func (offlineQuoteVerifier) TdxQuote(quote *tdx.QuoteV4, opts *verify.Options) error {
+	opts.GetCollateral = false
+	opts.CheckRevocations = false
+	return verify.TdxQuote(quote, opts)
+}
+
+func mustDecodeHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	require.NoError(t, err)
+	return b
+}
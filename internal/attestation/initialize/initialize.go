@@ -6,19 +6,29 @@ SPDX-License-Identifier: BUSL-1.1
 
 // Package initialize implements functions to mark a node as initialized in the context of cluster attestation.
 // This is done by measuring the cluster ID using the available CC technology.
+//
+// SEV-SNP guests without a vTPM have no measurement registers that can be extended at runtime.
+// For those, the node state is tracked in a marker file on a tmpfs, which, like a PCR, is reset on reboot.
 package initialize
 
 import (
 	"bytes"
 	"errors"
+	"fmt"
 	"io"
+	"os"
+	"path/filepath"
 
 	"github.com/edgelesssys/constellation/v2/internal/attestation/measurements"
+	qemusnp "github.com/edgelesssys/constellation/v2/internal/attestation/qemu/snp"
 	"github.com/edgelesssys/constellation/v2/internal/attestation/tdx"
 	tdxapi "github.com/edgelesssys/go-tdx-qpl/tdx"
 	"github.com/google/go-tpm/legacy/tpm2"
 )
 
+// snpBootstrappedMarker is the marker file used to record the bootstrapped state of SEV-SNP guests without a vTPM.
+const snpBootstrappedMarker = "/run/constellation/bootstrapped"
+
 // MarkNodeAsBootstrapped marks a node as initialized by extending PCRs.
 // clusterID is used to uniquely identify this running instance of Constellation.
 func MarkNodeAsBootstrapped(openDevice func() (io.ReadWriteCloser, error), clusterID []byte) error {
@@ -35,6 +45,9 @@ func MarkNodeAsBootstrapped(openDevice func() (io.ReadWriteCloser, error), clust
 	if handle, ok := tdx.IsTDXDevice(device); ok {
 		return tdxMarkNodeAsBootstrapped(handle, clusterID)
 	}
+	if qemusnp.IsSNPDevice(device) {
+		return snpMarkNodeAsBootstrapped(snpBootstrappedMarker, clusterID)
+	}
 	return tpmMarkNodeAsBootstrapped(device, clusterID)
 }
 
@@ -53,6 +66,9 @@ func IsNodeBootstrapped(openDevice func() (io.ReadWriteCloser, error)) (bool, er
 	if handle, ok := tdx.IsTDXDevice(device); ok {
 		return tdxIsNodeBootstrapped(handle)
 	}
+	if qemusnp.IsSNPDevice(device) {
+		return snpIsNodeBootstrapped(snpBootstrappedMarker)
+	}
 	return tpmIsNodeBootstrapped(device)
 }
 
@@ -64,6 +80,17 @@ func tdxIsNodeBootstrapped(handle tdx.Device) (bool, error) {
 	return measurementInitialized(tdMeasure[measurements.TDXIndexClusterID][:]), nil
 }
 
+func snpIsNodeBootstrapped(markerPath string) (bool, error) {
+	clusterID, err := os.ReadFile(markerPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("reading bootstrapped marker: %w", err)
+	}
+	return measurementInitialized(clusterID), nil
+}
+
 func tpmIsNodeBootstrapped(tpm io.ReadWriteCloser) (bool, error) {
 	idxClusterID := int(measurements.PCRIndexClusterID)
 	pcrs, err := tpm2.ReadPCRs(tpm, tpm2.PCRSelection{
@@ -120,6 +147,16 @@ func tdxMarkNodeAsBootstrapped(handle tdx.Device, clusterID []byte) error {
 	return tdxapi.ExtendRTMR(handle, clusterID, measurements.RTMRIndexClusterID)
 }
 
+func snpMarkNodeAsBootstrapped(markerPath string, clusterID []byte) error {
+	if err := os.MkdirAll(filepath.Dir(markerPath), 0o700); err != nil {
+		return fmt.Errorf("creating marker directory: %w", err)
+	}
+	if err := os.WriteFile(markerPath, clusterID, 0o600); err != nil {
+		return fmt.Errorf("writing bootstrapped marker: %w", err)
+	}
+	return nil
+}
+
 func tpmMarkNodeAsBootstrapped(tpm io.ReadWriteCloser, clusterID []byte) error {
 	return tpm2.PCREvent(tpm, measurements.PCRIndexClusterID, clusterID)
 }
@@ -10,6 +10,7 @@ import (
 	"errors"
 	"io"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/edgelesssys/constellation/v2/internal/attestation/measurements"
@@ -100,3 +101,20 @@ func TestIsNodeInitialized(t *testing.T) {
 		})
 	}
 }
+
+func TestSNPMarker(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	markerPath := filepath.Join(t.TempDir(), "constellation", "bootstrapped")
+
+	initialized, err := snpIsNodeBootstrapped(markerPath)
+	require.NoError(err)
+	assert.False(initialized)
+
+	require.NoError(snpMarkNodeAsBootstrapped(markerPath, []byte{0x0, 0x1, 0x2, 0x3}))
+
+	initialized, err = snpIsNodeBootstrapped(markerPath)
+	require.NoError(err)
+	assert.True(initialized)
+}
@@ -269,6 +269,8 @@ func attestationVariantFromGoIdentifier(identifier string) (variant.Variant, err
 		return variant.GCPSEVES{}, nil
 	case "GCPSEVSNP":
 		return variant.GCPSEVSNP{}, nil
+	case "GCPTDX":
+		return variant.GCPTDX{}, nil
 	case "AzureSEVSNP":
 		return variant.AzureSEVSNP{}, nil
 	case "AzureTDX":
@@ -277,6 +279,8 @@ func attestationVariantFromGoIdentifier(identifier string) (variant.Variant, err
 		return variant.AzureTrustedLaunch{}, nil
 	case "QEMUVTPM":
 		return variant.QEMUVTPM{}, nil
+	case "QEMUSEVSNP":
+		return variant.QEMUSEVSNP{}, nil
 	case "QEMUTDX":
 		return variant.QEMUTDX{}, nil
 	}
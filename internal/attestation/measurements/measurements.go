@@ -24,6 +24,7 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -54,10 +55,16 @@ const (
 	// RTMRIndexClusterID is the RTMR we extend to mark the node as initialized.
 	RTMRIndexClusterID = 2
 
+	// SNPIndexLaunchMeasurement is the measurement index of the SEV-SNP launch digest.
+	// SEV-SNP has no runtime measurement registers, so the launch digest is the only measurement.
+	SNPIndexLaunchMeasurement = 0
+
 	// PCRMeasurementLength holds the length for valid PCR measurements (SHA256).
 	PCRMeasurementLength = 32
 	// TDXMeasurementLength holds the length for valid TDX measurements (SHA384).
 	TDXMeasurementLength = 48
+	// SNPMeasurementLength holds the length for valid SEV-SNP launch measurements (SHA384).
+	SNPMeasurementLength = 48
 )
 
 // M are Platform Configuration Register (PCR) values that make up the Measurements.
@@ -258,6 +265,45 @@ func (m M) Compare(other map[uint32][]byte) (warnings []string, errs []error) {
 	return warnings, errs
 }
 
+// CompareAny compares the given list of measurements to each of the accepted measurement sets.
+// The measurements are accepted if they match at least one set.
+// In that case, the warnings of the first matching set are returned.
+// Otherwise, the errors of all sets are returned.
+// An empty set would accept any measurements, so it is rejected as an error.
+func CompareAny(accepted []M, other map[uint32][]byte) (warnings []string, errs []error) {
+	if len(accepted) == 0 {
+		return nil, []error{errors.New("no accepted measurements configured")}
+	}
+	for i, m := range accepted {
+		if len(m) == 0 {
+			return nil, []error{fmt.Errorf("measurement set %d is empty", i)}
+		}
+	}
+	if len(accepted) == 1 {
+		return accepted[0].Compare(other)
+	}
+
+	for i, m := range accepted {
+		setWarnings, setErrs := m.Compare(other)
+		if len(setErrs) == 0 {
+			return setWarnings, nil
+		}
+		errs = append(errs, fmt.Errorf("measurement set %d: %w", i, errors.Join(setErrs...)))
+	}
+	return nil, errs
+}
+
+// AppendUnique appends the given measurement sets to sets,
+// skipping sets that are equal to a set that is already contained.
+func AppendUnique(sets []M, other ...M) []M {
+	for _, m := range other {
+		if !slices.ContainsFunc(sets, m.EqualTo) {
+			sets = append(sets, m)
+		}
+	}
+	return sets
+}
+
 // GetEnforced returns a list of all enforced Measurements,
 // i.e. all Measurements that are not marked as WarnOnly.
 func (m *M) GetEnforced() []uint32 {
@@ -519,9 +565,15 @@ func DefaultsFor(provider cloudprovider.Provider, attestationVariant variant.Var
 	case provider == cloudprovider.GCP && attestationVariant == variant.GCPSEVSNP{}:
 		return gcp_GCPSEVSNP.Copy()
 
+	case provider == cloudprovider.GCP && attestationVariant == variant.GCPTDX{}:
+		return gcp_GCPTDX.Copy()
+
 	case provider == cloudprovider.OpenStack && attestationVariant == variant.QEMUVTPM{}:
 		return openstack_QEMUVTPM.Copy()
 
+	case provider == cloudprovider.QEMU && attestationVariant == variant.QEMUSEVSNP{}:
+		return qemu_QEMUSEVSNP.Copy()
+
 	case provider == cloudprovider.QEMU && attestationVariant == variant.QEMUTDX{}:
 		return qemu_QEMUTDX.Copy()
 
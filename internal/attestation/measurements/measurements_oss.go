@@ -73,6 +73,15 @@ var (
 		13:                        WithAllBytes(0x00, Enforce, PCRMeasurementLength),
 		uint32(PCRIndexClusterID): WithAllBytes(0x00, Enforce, PCRMeasurementLength),
 	}
+	gcp_GCPTDX = M{
+		4:                         PlaceHolderMeasurement(PCRMeasurementLength),
+		8:                         WithAllBytes(0x00, Enforce, PCRMeasurementLength),
+		9:                         PlaceHolderMeasurement(PCRMeasurementLength),
+		11:                        WithAllBytes(0x00, Enforce, PCRMeasurementLength),
+		12:                        PlaceHolderMeasurement(PCRMeasurementLength),
+		13:                        WithAllBytes(0x00, Enforce, PCRMeasurementLength),
+		uint32(PCRIndexClusterID): WithAllBytes(0x00, Enforce, PCRMeasurementLength),
+	}
 	openstack_QEMUVTPM = M{
 		4:                         PlaceHolderMeasurement(PCRMeasurementLength),
 		8:                         WithAllBytes(0x00, Enforce, PCRMeasurementLength),
@@ -82,6 +91,9 @@ var (
 		13:                        WithAllBytes(0x00, Enforce, PCRMeasurementLength),
 		uint32(PCRIndexClusterID): WithAllBytes(0x00, Enforce, PCRMeasurementLength),
 	}
+	qemu_QEMUSEVSNP = M{
+		SNPIndexLaunchMeasurement: PlaceHolderMeasurement(SNPMeasurementLength),
+	}
 	qemu_QEMUTDX = M{
 		0:                         PlaceHolderMeasurement(TDXMeasurementLength),
 		1:                         PlaceHolderMeasurement(TDXMeasurementLength),
@@ -1042,3 +1042,122 @@ func TestMeasurementsCompare(t *testing.T) {
 		})
 	}
 }
+
+func TestMeasurementsCompareAny(t *testing.T) {
+	oldImage := M{
+		0: WithAllBytes(0x00, Enforce, PCRMeasurementLength),
+		1: WithAllBytes(0x11, Enforce, PCRMeasurementLength),
+	}
+	newImage := M{
+		0: WithAllBytes(0x00, Enforce, PCRMeasurementLength),
+		1: WithAllBytes(0x22, Enforce, PCRMeasurementLength),
+		2: WithAllBytes(0x33, WarnOnly, PCRMeasurementLength),
+	}
+
+	testCases := map[string]struct {
+		accepted     []M
+		actual       map[uint32][]byte
+		wantErrs     int
+		wantWarnings int
+	}{
+		"no accepted measurements": {
+			actual: map[uint32][]byte{
+				0: bytes.Repeat([]byte{0x00}, PCRMeasurementLength),
+			},
+			wantErrs: 1,
+		},
+		"empty set is rejected": {
+			accepted: []M{oldImage, {}},
+			actual: map[uint32][]byte{
+				0: bytes.Repeat([]byte{0x00}, PCRMeasurementLength),
+				1: bytes.Repeat([]byte{0x11}, PCRMeasurementLength),
+			},
+			wantErrs: 1,
+		},
+		"single empty set is rejected": {
+			accepted: []M{{}},
+			actual: map[uint32][]byte{
+				0: bytes.Repeat([]byte{0x00}, PCRMeasurementLength),
+			},
+			wantErrs: 1,
+		},
+		"single set behaves like compare": {
+			accepted: []M{oldImage},
+			actual: map[uint32][]byte{
+				0: bytes.Repeat([]byte{0xFF}, PCRMeasurementLength),
+				1: bytes.Repeat([]byte{0xFF}, PCRMeasurementLength),
+			},
+			wantErrs: 2,
+		},
+		"first set matches": {
+			accepted: []M{oldImage, newImage},
+			actual: map[uint32][]byte{
+				0: bytes.Repeat([]byte{0x00}, PCRMeasurementLength),
+				1: bytes.Repeat([]byte{0x11}, PCRMeasurementLength),
+			},
+		},
+		"second set matches with warnings": {
+			accepted: []M{oldImage, newImage},
+			actual: map[uint32][]byte{
+				0: bytes.Repeat([]byte{0x00}, PCRMeasurementLength),
+				1: bytes.Repeat([]byte{0x22}, PCRMeasurementLength),
+			},
+			wantWarnings: 1,
+		},
+		"no set matches": {
+			accepted: []M{oldImage, newImage},
+			actual: map[uint32][]byte{
+				0: bytes.Repeat([]byte{0x00}, PCRMeasurementLength),
+				1: bytes.Repeat([]byte{0xFF}, PCRMeasurementLength),
+			},
+			wantErrs: 2,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			gotWarnings, gotErrs := CompareAny(tc.accepted, tc.actual)
+			assert.Equal(tc.wantErrs, len(gotErrs))
+			assert.Equal(tc.wantWarnings, len(gotWarnings))
+		})
+	}
+}
+
+func TestAppendUnique(t *testing.T) {
+	m1 := M{0: WithAllBytes(0x11, Enforce, PCRMeasurementLength)}
+	m2 := M{0: WithAllBytes(0x22, Enforce, PCRMeasurementLength)}
+	m3 := M{0: WithAllBytes(0x22, WarnOnly, PCRMeasurementLength)}
+
+	testCases := map[string]struct {
+		sets  []M
+		other []M
+		want  []M
+	}{
+		"append to empty": {
+			other: []M{m1, m2},
+			want:  []M{m1, m2},
+		},
+		"skip contained set": {
+			sets:  []M{m1},
+			other: []M{m1, m2},
+			want:  []M{m1, m2},
+		},
+		"skip duplicates in other": {
+			other: []M{m2, m2},
+			want:  []M{m2},
+		},
+		"validation option is compared": {
+			sets:  []M{m2},
+			other: []M{m3},
+			want:  []M{m2, m3},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.want, AppendUnique(tc.sets, tc.other...))
+		})
+	}
+}
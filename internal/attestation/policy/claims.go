@@ -0,0 +1,289 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package policy
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/edgelesssys/constellation/v2/internal/attestation/variant"
+	"github.com/google/go-sev-guest/abi"
+	"github.com/google/go-sev-guest/kds"
+	tdxabi "github.com/google/go-tdx-guest/abi"
+	"github.com/google/go-tdx-guest/proto/tdx"
+	tpmProto "github.com/google/go-tpm-tools/proto/tpm"
+)
+
+// Claims are the normalized claims of an attestation statement.
+// They are independent of the attestation variant the statement was issued for.
+type Claims struct {
+	// Variant is the attestation variant of the statement.
+	Variant string
+	// Measurements are the measurements of the statement,
+	// indexed the same way as the measurements of the attestation config.
+	Measurements map[uint32][]byte
+	// SNP holds the claims of an AMD SEV-SNP attestation report, if the statement contains one.
+	SNP *SNPClaims
+	// TDX holds the claims of an Intel TDX quote, if the statement contains one.
+	TDX *TDXClaims
+}
+
+// SNPClaims are the claims of an AMD SEV-SNP attestation report.
+type SNPClaims struct {
+	// LaunchTCB is the TCB version of the platform at the time the guest was launched.
+	LaunchTCB TCB
+	// ReportedTCB is the TCB version the platform reports to the guest.
+	ReportedTCB TCB
+	// GuestPolicy is the guest policy the guest was launched with.
+	GuestPolicy uint64
+	// PlatformInfo is the information about the platform the guest runs on.
+	PlatformInfo uint64
+	// LaunchMeasurement is the measurement of the initial guest memory.
+	LaunchMeasurement []byte
+	// HostData is the data provided by the host at launch.
+	HostData []byte
+	// IDKeyDigest is the digest of the key that signed the ID block.
+	IDKeyDigest []byte
+	// AuthorKeyDigest is the digest of the key that signed the ID key.
+	AuthorKeyDigest []byte
+}
+
+// TCB are the security patch levels of the SEV-SNP firmware components.
+type TCB struct {
+	Bootloader uint8
+	TEE        uint8
+	SNP        uint8
+	Microcode  uint8
+}
+
+// TDXClaims are the claims of an Intel TDX quote.
+type TDXClaims struct {
+	// QESVN is the security version number of the quoting enclave.
+	QESVN uint16
+	// PCESVN is the security version number of the provisioning certification enclave.
+	PCESVN uint16
+	// TEETCBSVN is the security version number of the TDX TCB.
+	TEETCBSVN []byte
+	// MRSeam is the measurement of the TDX module.
+	MRSeam []byte
+	// MRSignerSeam is the measurement of the signer of the TDX module.
+	MRSignerSeam []byte
+	// XFAM are the extended features available to the guest.
+	XFAM []byte
+}
+
+// ExtractClaims extracts the normalized claims from a raw attestation document of the given variant.
+// The claims are taken as-is from the document. The document must have been validated before.
+func ExtractClaims(attestationVariant variant.Variant, attDocRaw []byte) (Claims, error) {
+	claims := Claims{Variant: attestationVariant.String()}
+
+	switch attestationVariant {
+	case variant.QEMUTDX{}:
+		var attDoc struct {
+			RawQuote []byte
+		}
+		if err := json.Unmarshal(attDocRaw, &attDoc); err != nil {
+			return Claims{}, fmt.Errorf("unmarshaling attestation document: %w", err)
+		}
+		quote, err := parseTDXQuote(attDoc.RawQuote)
+		if err != nil {
+			return Claims{}, err
+		}
+		// MRTD is at index 0, followed by the RTMRs.
+		claims.Measurements = map[uint32][]byte{0: quote.TdQuoteBody.MrTd}
+		for idx, rtmr := range quote.TdQuoteBody.Rtmrs {
+			claims.Measurements[uint32(idx+1)] = rtmr
+		}
+		claims.TDX = tdxClaims(quote)
+		return claims, nil
+
+	case variant.QEMUSEVSNP{}:
+		var attDoc struct {
+			InstanceInfo instanceInfo
+		}
+		if err := json.Unmarshal(attDocRaw, &attDoc); err != nil {
+			return Claims{}, fmt.Errorf("unmarshaling attestation document: %w", err)
+		}
+		snpClaims, err := parseSNPReport(attDoc.InstanceInfo.AttestationReport)
+		if err != nil {
+			return Claims{}, err
+		}
+		claims.Measurements = map[uint32][]byte{0: snpClaims.LaunchMeasurement}
+		claims.SNP = snpClaims
+		return claims, nil
+
+	case variant.Dummy{}:
+		return claims, nil
+	}
+
+	// All other variants use a vTPM for runtime measurements.
+	var attDoc struct {
+		Attestation struct {
+			Quotes []*tpmProto.Quote
+		}
+		InstanceInfo []byte
+	}
+	if err := json.Unmarshal(attDocRaw, &attDoc); err != nil {
+		return Claims{}, fmt.Errorf("unmarshaling TPM attestation document: %w", err)
+	}
+	quote, err := sha256Quote(attDoc.Attestation.Quotes)
+	if err != nil {
+		return Claims{}, err
+	}
+	claims.Measurements = quote.Pcrs.Pcrs
+
+	switch attestationVariant {
+	case variant.AWSSEVSNP{}, variant.AzureSEVSNP{}, variant.GCPSEVSNP{}:
+		var instanceInfo instanceInfo
+		if err := json.Unmarshal(attDoc.InstanceInfo, &instanceInfo); err != nil {
+			return Claims{}, fmt.Errorf("unmarshaling instance info: %w", err)
+		}
+		claims.SNP, err = parseSNPReport(instanceInfo.AttestationReport)
+		if err != nil {
+			return Claims{}, err
+		}
+	case variant.AzureTDX{}, variant.GCPTDX{}:
+		var instanceInfo instanceInfo
+		if err := json.Unmarshal(attDoc.InstanceInfo, &instanceInfo); err != nil {
+			return Claims{}, fmt.Errorf("unmarshaling instance info: %w", err)
+		}
+		quote, err := parseTDXQuote(instanceInfo.AttestationReport)
+		if err != nil {
+			return Claims{}, err
+		}
+		claims.TDX = tdxClaims(quote)
+	}
+
+	return claims, nil
+}
+
+// sha256Quote returns the quote over the SHA256 PCR bank.
+// The vtpm package isn't used here, since its tests depend on the config package, which depends on this package.
+func sha256Quote(quotes []*tpmProto.Quote) (*tpmProto.Quote, error) {
+	for _, quote := range quotes {
+		if quote != nil && quote.Pcrs != nil && quote.Pcrs.Hash == tpmProto.HashAlgo_SHA256 {
+			return quote, nil
+		}
+	}
+	return nil, errors.New("attestation did not include SHA256 hashed PCRs")
+}
+
+// instanceInfo holds the hardware attestation report of the instance info of all variants.
+// The remaining fields are variant specific and not needed for the claims.
+type instanceInfo struct {
+	AttestationReport []byte
+}
+
+// celValue converts the claims to the value that is exposed to policy expressions.
+// Byte values are hex encoded, so they can be compared to string literals.
+func (c Claims) celValue() map[string]any {
+	measurements := make(map[int64]string, len(c.Measurements))
+	for idx, m := range c.Measurements {
+		measurements[int64(idx)] = hex.EncodeToString(m)
+	}
+
+	value := map[string]any{
+		"variant":      c.Variant,
+		"measurements": measurements,
+	}
+	if c.SNP != nil {
+		value["snp"] = map[string]any{
+			"launch_tcb":         c.SNP.LaunchTCB.celValue(),
+			"reported_tcb":       c.SNP.ReportedTCB.celValue(),
+			"guest_policy":       int64(c.SNP.GuestPolicy),
+			"platform_info":      int64(c.SNP.PlatformInfo),
+			"launch_measurement": hex.EncodeToString(c.SNP.LaunchMeasurement),
+			"host_data":          hex.EncodeToString(c.SNP.HostData),
+			"id_key_digest":      hex.EncodeToString(c.SNP.IDKeyDigest),
+			"author_key_digest":  hex.EncodeToString(c.SNP.AuthorKeyDigest),
+		}
+	}
+	if c.TDX != nil {
+		value["tdx"] = map[string]any{
+			"qe_svn":         int64(c.TDX.QESVN),
+			"pce_svn":        int64(c.TDX.PCESVN),
+			"tee_tcb_svn":    hex.EncodeToString(c.TDX.TEETCBSVN),
+			"mr_seam":        hex.EncodeToString(c.TDX.MRSeam),
+			"mr_signer_seam": hex.EncodeToString(c.TDX.MRSignerSeam),
+			"xfam":           hex.EncodeToString(c.TDX.XFAM),
+		}
+	}
+	return value
+}
+
+func (t TCB) celValue() map[string]int64 {
+	return map[string]int64{
+		"bootloader": int64(t.Bootloader),
+		"tee":        int64(t.TEE),
+		"snp":        int64(t.SNP),
+		"microcode":  int64(t.Microcode),
+	}
+}
+
+func parseSNPReport(reportRaw []byte) (*SNPClaims, error) {
+	report, err := abi.ReportToProto(reportRaw)
+	if err != nil {
+		return nil, fmt.Errorf("parsing SNP report: %w", err)
+	}
+	return &SNPClaims{
+		LaunchTCB:         newTCB(report.LaunchTcb),
+		ReportedTCB:       newTCB(report.ReportedTcb),
+		GuestPolicy:       report.Policy,
+		PlatformInfo:      report.PlatformInfo,
+		LaunchMeasurement: report.Measurement,
+		HostData:          report.HostData,
+		IDKeyDigest:       report.IdKeyDigest,
+		AuthorKeyDigest:   report.AuthorKeyDigest,
+	}, nil
+}
+
+func newTCB(tcb uint64) TCB {
+	parts := kds.DecomposeTCBVersion(kds.TCBVersion(tcb))
+	return TCB{
+		Bootloader: parts.BlSpl,
+		TEE:        parts.TeeSpl,
+		SNP:        parts.SnpSpl,
+		Microcode:  parts.UcodeSpl,
+	}
+}
+
+func parseTDXQuote(rawQuote []byte) (*tdx.QuoteV4, error) {
+	quotePb, err := tdxabi.QuoteToProto(rawQuote)
+	if err != nil {
+		return nil, fmt.Errorf("parsing TDX quote: %w", err)
+	}
+	quote, ok := quotePb.(*tdx.QuoteV4)
+	if !ok {
+		return nil, fmt.Errorf("unexpected quote type: %T", quotePb)
+	}
+	if quote.Header == nil || quote.TdQuoteBody == nil {
+		return nil, errors.New("TDX quote is missing header or body")
+	}
+	return quote, nil
+}
+
+func tdxClaims(quote *tdx.QuoteV4) *TDXClaims {
+	return &TDXClaims{
+		QESVN:        svn(quote.Header.QeSvn),
+		PCESVN:       svn(quote.Header.PceSvn),
+		TEETCBSVN:    quote.TdQuoteBody.TeeTcbSvn,
+		MRSeam:       quote.TdQuoteBody.MrSeam,
+		MRSignerSeam: quote.TdQuoteBody.MrSignerSeam,
+		XFAM:         quote.TdQuoteBody.Xfam,
+	}
+}
+
+// svn decodes a little-endian security version number.
+func svn(b []byte) uint16 {
+	if len(b) < 2 {
+		return 0
+	}
+	return binary.LittleEndian.Uint16(b)
+}
@@ -0,0 +1,146 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package policy
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	gcptdxtestdata "github.com/edgelesssys/constellation/v2/internal/attestation/gcp/tdx/testdata"
+	snptestdata "github.com/edgelesssys/constellation/v2/internal/attestation/snp/testdata"
+	"github.com/edgelesssys/constellation/v2/internal/attestation/variant"
+	"github.com/edgelesssys/constellation/v2/internal/attestation/vtpm"
+	"github.com/google/go-tpm-tools/proto/attest"
+	tpmProto "github.com/google/go-tpm-tools/proto/tpm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractClaims(t *testing.T) {
+	launchMeasurement, err := hex.DecodeString("5677f1de87289e7ad2c7e99c805d0468b1a9ccd83f0d245afa5242d405da4d5725852f8c6550564870e5f3206dfb1841")
+	require.NoError(t, err)
+	pcrs := map[uint32][]byte{
+		4:  bytes.Repeat([]byte{0x04}, 32),
+		15: bytes.Repeat([]byte{0x15}, 32),
+	}
+	snpInstanceInfo := instanceInfo{AttestationReport: snptestdata.AttestationReport}
+
+	testCases := map[string]struct {
+		variant          variant.Variant
+		attDoc           any
+		wantMeasurements map[uint32][]byte
+		wantSNP          bool
+		wantTDX          bool
+		wantErr          bool
+	}{
+		"qemu-sev-snp": {
+			variant: variant.QEMUSEVSNP{},
+			attDoc: map[string]any{
+				"InstanceInfo": snpInstanceInfo,
+			},
+			wantMeasurements: map[uint32][]byte{0: launchMeasurement},
+			wantSNP:          true,
+		},
+		"qemu-tdx": {
+			variant: variant.QEMUTDX{},
+			attDoc: map[string]any{
+				"RawQuote": gcptdxtestdata.Quote,
+			},
+			wantTDX: true,
+		},
+		"azure-sev-snp": {
+			variant:          variant.AzureSEVSNP{},
+			attDoc:           newVTPMDoc(t, pcrs, snpInstanceInfo),
+			wantMeasurements: pcrs,
+			wantSNP:          true,
+		},
+		"gcp-tdx": {
+			variant: variant.GCPTDX{},
+			attDoc: newVTPMDoc(t, pcrs, map[string]any{
+				"AttestationReport": gcptdxtestdata.Quote,
+			}),
+			wantMeasurements: pcrs,
+			wantTDX:          true,
+		},
+		"qemu-vtpm": {
+			variant:          variant.QEMUVTPM{},
+			attDoc:           newVTPMDoc(t, pcrs, nil),
+			wantMeasurements: pcrs,
+		},
+		"vTPM document without quotes": {
+			variant: variant.QEMUVTPM{},
+			attDoc:  vtpm.AttestationDocument{Attestation: &attest.Attestation{}},
+			wantErr: true,
+		},
+		"invalid SNP report": {
+			variant: variant.QEMUSEVSNP{},
+			attDoc: map[string]any{
+				"InstanceInfo": instanceInfo{AttestationReport: []byte("invalid")},
+			},
+			wantErr: true,
+		},
+		"invalid TDX quote": {
+			variant: variant.QEMUTDX{},
+			attDoc: map[string]any{
+				"RawQuote": []byte("invalid"),
+			},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			attDocRaw, err := json.Marshal(tc.attDoc)
+			require.NoError(err)
+
+			claims, err := ExtractClaims(tc.variant, attDocRaw)
+			if tc.wantErr {
+				assert.Error(err)
+				return
+			}
+			require.NoError(err)
+
+			assert.Equal(tc.variant.String(), claims.Variant)
+			if tc.wantMeasurements != nil {
+				assert.Equal(tc.wantMeasurements, claims.Measurements)
+			} else {
+				// MRTD and the RTMRs.
+				assert.Len(claims.Measurements, 5)
+			}
+			assert.Equal(tc.wantSNP, claims.SNP != nil)
+			assert.Equal(tc.wantTDX, claims.TDX != nil)
+			if tc.wantSNP {
+				assert.Equal(launchMeasurement, claims.SNP.LaunchMeasurement)
+				assert.Equal(make([]byte, 32), claims.SNP.HostData)
+			}
+		})
+	}
+}
+
+func newVTPMDoc(t *testing.T, pcrs map[uint32][]byte, instanceInfo any) vtpm.AttestationDocument {
+	t.Helper()
+	var instanceInfoRaw []byte
+	if instanceInfo != nil {
+		var err error
+		instanceInfoRaw, err = json.Marshal(instanceInfo)
+		require.NoError(t, err)
+	}
+	return vtpm.AttestationDocument{
+		Attestation: &attest.Attestation{
+			Quotes: []*tpmProto.Quote{
+				{Pcrs: &tpmProto.PCRs{Hash: tpmProto.HashAlgo_SHA1, Pcrs: map[uint32][]byte{0: make([]byte, 20)}}},
+				{Pcrs: &tpmProto.PCRs{Hash: tpmProto.HashAlgo_SHA256, Pcrs: pcrs}},
+			},
+		},
+		InstanceInfo: instanceInfoRaw,
+	}
+}
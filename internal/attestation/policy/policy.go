@@ -0,0 +1,203 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+/*
+# Attestation policies
+
+Package policy implements expression-based attestation policies.
+
+A policy is a list of rules written in the Common Expression Language (CEL).
+After an attestation statement passed the validation of its variant,
+every rule is evaluated over the normalized claims of the statement and must evaluate to true.
+
+The following variables are available to rules:
+
+  - claims.variant: the attestation variant, e.g. "azure-sev-snp"
+
+  - claims.measurements: a map from measurement index to the hex encoded measurement value
+
+  - claims.snp: the claims of the AMD SEV-SNP report, if present.
+    Has the fields launch_tcb and reported_tcb (each with bootloader, tee, snp and microcode),
+    guest_policy, platform_info, launch_measurement, host_data, id_key_digest and author_key_digest.
+
+  - claims.tdx: the claims of the Intel TDX quote, if present.
+    Has the fields qe_svn, pce_svn, tee_tcb_svn, mr_seam, mr_signer_seam and xfam.
+
+  - now: the time of the evaluation
+
+Byte values are hex encoded lowercase strings. Example rules:
+
+	claims.measurements[15] in ["0000000000000000000000000000000000000000000000000000000000000000", "..."]
+	claims.snp.launch_tcb.microcode >= 115 || now < timestamp("2025-01-01T00:00:00Z")
+*/
+package policy
+
+import (
+	"context"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/edgelesssys/constellation/v2/internal/attestation/variant"
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+)
+
+// Rule is a named policy expression.
+type Rule struct {
+	// Name identifies the rule in error messages.
+	Name string
+	// Expression is the CEL expression of the rule. It must evaluate to a bool.
+	Expression string
+}
+
+// Policy is a compiled list of rules.
+type Policy struct {
+	rules []compiledRule
+}
+
+type compiledRule struct {
+	name    string
+	program cel.Program
+}
+
+// Compile compiles the given rules into a policy.
+// A policy without rules accepts all claims.
+func Compile(rules []Rule) (*Policy, error) {
+	env, err := newEnv()
+	if err != nil {
+		return nil, fmt.Errorf("creating CEL environment: %w", err)
+	}
+
+	policy := &Policy{}
+	for _, rule := range rules {
+		ast, issues := env.Compile(rule.Expression)
+		if issues.Err() != nil {
+			return nil, fmt.Errorf("compiling rule %q: %w", rule.Name, issues.Err())
+		}
+		if !ast.OutputType().IsExactType(types.BoolType) && !ast.OutputType().IsExactType(types.DynType) {
+			return nil, fmt.Errorf("rule %q must evaluate to a bool, got %s", rule.Name, ast.OutputType())
+		}
+		program, err := env.Program(ast)
+		if err != nil {
+			return nil, fmt.Errorf("creating program for rule %q: %w", rule.Name, err)
+		}
+		policy.rules = append(policy.rules, compiledRule{name: rule.Name, program: program})
+	}
+	return policy, nil
+}
+
+// Empty returns true if the policy has no rules.
+func (p *Policy) Empty() bool {
+	return p == nil || len(p.rules) == 0
+}
+
+// Evaluate evaluates all rules of the policy over the given claims.
+// An error listing all rules that weren't satisfied is returned.
+func (p *Policy) Evaluate(claims Claims, now time.Time) error {
+	var errs []error
+	for _, result := range p.Results(claims, now) {
+		if result.Err != nil {
+			errs = append(errs, result.Err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// RuleResult is the result of evaluating a single rule.
+type RuleResult struct {
+	// Name is the name of the rule.
+	Name string
+	// Err is nil if the rule is satisfied.
+	Err error
+}
+
+// Results evaluates all rules of the policy over the given claims and returns the result of each rule.
+func (p *Policy) Results(claims Claims, now time.Time) []RuleResult {
+	if p.Empty() {
+		return nil
+	}
+
+	activation := map[string]any{
+		"claims": claims.celValue(),
+		"now":    now,
+	}
+
+	results := make([]RuleResult, 0, len(p.rules))
+	for _, rule := range p.rules {
+		results = append(results, RuleResult{Name: rule.name, Err: rule.evaluate(activation)})
+	}
+	return results
+}
+
+func (r compiledRule) evaluate(activation map[string]any) error {
+	out, _, err := r.program.Eval(activation)
+	if err != nil {
+		return fmt.Errorf("rule %q: %w", r.name, err)
+	}
+	satisfied, ok := out.Value().(bool)
+	if !ok {
+		return fmt.Errorf("rule %q: expected bool result, got %s", r.name, out.Type())
+	}
+	if !satisfied {
+		return fmt.Errorf("rule %q is not satisfied", r.name)
+	}
+	return nil
+}
+
+func newEnv() (*cel.Env, error) {
+	return cel.NewEnv(
+		cel.Variable("claims", cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable("now", cel.TimestampType),
+	)
+}
+
+// Validator wraps a validator and enforces a policy on the claims of the attestation statements it validated.
+type Validator struct {
+	validator validator
+	variant   variant.Variant
+	policy    *Policy
+	now       func() time.Time
+}
+
+// NewValidator returns a validator that enforces the policy on statements validated by the given validator.
+func NewValidator(validator validator, attestationVariant variant.Variant, policy *Policy) *Validator {
+	return &Validator{
+		validator: validator,
+		variant:   attestationVariant,
+		policy:    policy,
+		now:       time.Now,
+	}
+}
+
+// OID returns the OID of the wrapped validator.
+func (v *Validator) OID() asn1.ObjectIdentifier {
+	return v.validator.OID()
+}
+
+// Validate validates the attestation statement using the wrapped validator
+// and checks that the statement's claims satisfy the policy.
+func (v *Validator) Validate(ctx context.Context, attDoc []byte, nonce []byte) ([]byte, error) {
+	userData, err := v.validator.Validate(ctx, attDoc, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, err := ExtractClaims(v.variant, attDoc)
+	if err != nil {
+		return nil, fmt.Errorf("extracting claims: %w", err)
+	}
+	if err := v.policy.Evaluate(claims, v.now()); err != nil {
+		return nil, fmt.Errorf("attestation policy not satisfied: %w", err)
+	}
+	return userData, nil
+}
+
+type validator interface {
+	variant.Getter
+	Validate(ctx context.Context, attDoc []byte, nonce []byte) ([]byte, error)
+}
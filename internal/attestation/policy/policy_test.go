@@ -0,0 +1,245 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package policy
+
+import (
+	"bytes"
+	"context"
+	"encoding/asn1"
+	"testing"
+	"time"
+
+	"github.com/edgelesssys/constellation/v2/internal/attestation/variant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompile(t *testing.T) {
+	testCases := map[string]struct {
+		rules   []Rule
+		wantErr bool
+	}{
+		"no rules": {},
+		"valid rules": {
+			rules: []Rule{
+				{Name: "variant", Expression: `claims.variant == "azure-sev-snp"`},
+				{Name: "time", Expression: `now < timestamp("2030-01-01T00:00:00Z")`},
+			},
+		},
+		"syntax error": {
+			rules:   []Rule{{Name: "broken", Expression: `claims.variant ==`}},
+			wantErr: true,
+		},
+		"undeclared variable": {
+			rules:   []Rule{{Name: "unknown", Expression: `measurements[15] == ""`}},
+			wantErr: true,
+		},
+		"non-bool result": {
+			rules:   []Rule{{Name: "int", Expression: `1 + 1`}},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			_, err := Compile(tc.rules)
+			if tc.wantErr {
+				assert.Error(err)
+				return
+			}
+			assert.NoError(err)
+		})
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	pcr15 := bytes.Repeat([]byte{0x15}, 32)
+	pcr15Hex := "1515151515151515151515151515151515151515151515151515151515151515"
+	snpClaims := Claims{
+		Variant:      variant.AzureSEVSNP{}.String(),
+		Measurements: map[uint32][]byte{15: pcr15},
+		SNP: &SNPClaims{
+			LaunchTCB:   TCB{Bootloader: 3, TEE: 0, SNP: 8, Microcode: 115},
+			GuestPolicy: 0x30000,
+		},
+	}
+	deadline := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	testCases := map[string]struct {
+		rules     []Rule
+		claims    Claims
+		now       time.Time
+		wantErr   bool
+		wantInErr []string
+	}{
+		"empty policy": {
+			claims: snpClaims,
+		},
+		"measurement in set": {
+			rules:  []Rule{{Name: "pcr15", Expression: `claims.measurements[15] in ["00", "` + pcr15Hex + `"]`}},
+			claims: snpClaims,
+		},
+		"measurement not in set": {
+			rules:     []Rule{{Name: "pcr15", Expression: `claims.measurements[15] in ["00", "01"]`}},
+			claims:    snpClaims,
+			wantErr:   true,
+			wantInErr: []string{`rule "pcr15" is not satisfied`},
+		},
+		"TCB high enough": {
+			rules:  []Rule{{Name: "microcode", Expression: `claims.snp.launch_tcb.microcode >= 115 || now < timestamp("2025-01-01T00:00:00Z")`}},
+			claims: snpClaims,
+			now:    deadline.Add(time.Hour),
+		},
+		"TCB too low before deadline": {
+			rules:  []Rule{{Name: "microcode", Expression: `claims.snp.launch_tcb.microcode >= 200 || now < timestamp("2025-01-01T00:00:00Z")`}},
+			claims: snpClaims,
+			now:    deadline.Add(-time.Hour),
+		},
+		"TCB too low after deadline": {
+			rules:   []Rule{{Name: "microcode", Expression: `claims.snp.launch_tcb.microcode >= 200 || now < timestamp("2025-01-01T00:00:00Z")`}},
+			claims:  snpClaims,
+			now:     deadline.Add(time.Hour),
+			wantErr: true,
+		},
+		"guest policy": {
+			rules:  []Rule{{Name: "policy", Expression: `claims.snp.guest_policy == 0x30000`}},
+			claims: snpClaims,
+		},
+		"optional claims checked with has": {
+			rules:  []Rule{{Name: "tdx", Expression: `!has(claims.tdx) || claims.tdx.qe_svn >= 8`}},
+			claims: snpClaims,
+		},
+		"missing claim": {
+			rules:     []Rule{{Name: "tdx", Expression: `claims.tdx.qe_svn >= 8`}},
+			claims:    snpClaims,
+			wantErr:   true,
+			wantInErr: []string{`rule "tdx"`},
+		},
+		"missing measurement": {
+			rules:   []Rule{{Name: "pcr4", Expression: `claims.measurements[4] == "00"`}},
+			claims:  snpClaims,
+			wantErr: true,
+		},
+		"dynamic non-bool result": {
+			rules:   []Rule{{Name: "variant", Expression: `claims.variant`}},
+			claims:  snpClaims,
+			wantErr: true,
+		},
+		"all violated rules are reported": {
+			rules: []Rule{
+				{Name: "first", Expression: `claims.variant == "gcp-sev-snp"`},
+				{Name: "second", Expression: `true`},
+				{Name: "third", Expression: `claims.snp.launch_tcb.tee > 0`},
+			},
+			claims:    snpClaims,
+			wantErr:   true,
+			wantInErr: []string{`rule "first"`, `rule "third"`},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			policy, err := Compile(tc.rules)
+			require.NoError(err)
+
+			err = policy.Evaluate(tc.claims, tc.now)
+			if tc.wantErr {
+				assert.Error(err)
+				for _, msg := range tc.wantInErr {
+					assert.ErrorContains(err, msg)
+				}
+				return
+			}
+			assert.NoError(err)
+		})
+	}
+}
+
+func TestResults(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	policy, err := Compile([]Rule{
+		{Name: "variant", Expression: `claims.variant == "azure-sev-snp"`},
+		{Name: "microcode", Expression: `claims.snp.launch_tcb.microcode >= 200`},
+	})
+	require.NoError(err)
+
+	results := policy.Results(Claims{
+		Variant: variant.AzureSEVSNP{}.String(),
+		SNP:     &SNPClaims{LaunchTCB: TCB{Microcode: 115}},
+	}, time.Now())
+	require.Len(results, 2)
+	assert.Equal("variant", results[0].Name)
+	assert.NoError(results[0].Err)
+	assert.Equal("microcode", results[1].Name)
+	assert.ErrorContains(results[1].Err, `rule "microcode" is not satisfied`)
+
+	var empty *Policy
+	assert.Empty(empty.Results(Claims{}, time.Now()))
+}
+
+func TestValidator(t *testing.T) {
+	testCases := map[string]struct {
+		validator *stubValidator
+		rules     []Rule
+		wantErr   bool
+	}{
+		"policy satisfied": {
+			validator: &stubValidator{userData: []byte("user data")},
+			rules:     []Rule{{Name: "variant", Expression: `claims.variant == "dummy"`}},
+		},
+		"policy not satisfied": {
+			validator: &stubValidator{userData: []byte("user data")},
+			rules:     []Rule{{Name: "variant", Expression: `claims.variant == "azure-sev-snp"`}},
+			wantErr:   true,
+		},
+		"validation fails": {
+			validator: &stubValidator{err: assert.AnError},
+			wantErr:   true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			policy, err := Compile(tc.rules)
+			require.NoError(err)
+
+			v := NewValidator(tc.validator, variant.Dummy{}, policy)
+			assert.True(v.OID().Equal(variant.Dummy{}.OID()))
+
+			userData, err := v.Validate(context.Background(), []byte("{}"), nil)
+			if tc.wantErr {
+				assert.Error(err)
+				return
+			}
+			require.NoError(err)
+			assert.Equal(tc.validator.userData, userData)
+		})
+	}
+}
+
+type stubValidator struct {
+	userData []byte
+	err      error
+}
+
+func (s *stubValidator) OID() asn1.ObjectIdentifier {
+	return variant.Dummy{}.OID()
+}
+
+func (s *stubValidator) Validate(context.Context, []byte, []byte) ([]byte, error) {
+	return s.userData, s.err
+}
@@ -0,0 +1,72 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package snp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/edgelesssys/constellation/v2/internal/attestation"
+	"github.com/edgelesssys/constellation/v2/internal/attestation/snp"
+	"github.com/edgelesssys/constellation/v2/internal/attestation/variant"
+)
+
+// Issuer issues SEV-SNP attestation documents for QEMU guests.
+type Issuer struct {
+	variant.QEMUSEVSNP
+	getReport func(reportData [64]byte) (report, certs []byte, err error)
+	log       attestation.Logger
+}
+
+// NewIssuer initializes a new QEMU SEV-SNP Issuer.
+func NewIssuer(log attestation.Logger) *Issuer {
+	if log == nil {
+		log = attestation.NOPLogger{}
+	}
+	return &Issuer{
+		getReport: snp.GetExtendedReport,
+		log:       log,
+	}
+}
+
+// Issue issues a SEV-SNP attestation document.
+func (i *Issuer) Issue(_ context.Context, userData []byte, nonce []byte) (attDoc []byte, err error) {
+	i.log.Info("Issuing attestation statement")
+	defer func() {
+		if err != nil {
+			i.log.Warn(fmt.Sprintf("Failed to issue attestation document: %s", err))
+		}
+	}()
+
+	var reportData [64]byte
+	copy(reportData[:], attestation.MakeExtraData(userData, nonce))
+
+	report, certs, err := i.getReport(reportData)
+	if err != nil {
+		return nil, fmt.Errorf("getting extended report: %w", err)
+	}
+
+	info := snp.InstanceInfo{AttestationReport: report}
+	// Bare-metal hosts are not required to provide certificates.
+	// In that case, the validator fetches the VCEK from AMD KDS.
+	if len(certs) > 0 {
+		info.ReportSigner, info.CertChain, err = snp.ParseCertTable(certs)
+		if err != nil {
+			return nil, fmt.Errorf("parsing certificate table: %w", err)
+		}
+	}
+
+	rawAttDoc, err := json.Marshal(attestationDocument{
+		InstanceInfo: info,
+		UserData:     userData,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("marshaling attestation document: %w", err)
+	}
+	return rawAttDoc, nil
+}
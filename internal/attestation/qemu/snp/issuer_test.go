@@ -0,0 +1,73 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package snp
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/edgelesssys/constellation/v2/internal/attestation"
+	"github.com/edgelesssys/constellation/v2/internal/attestation/snp/testdata"
+	"github.com/edgelesssys/constellation/v2/internal/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIssue(t *testing.T) {
+	userData := []byte("user data")
+	nonce := []byte("nonce")
+
+	testCases := map[string]struct {
+		getReport func(reportData [64]byte) ([]byte, []byte, error)
+		wantErr   bool
+	}{
+		"success without certificates": {
+			getReport: func(reportData [64]byte) ([]byte, []byte, error) {
+				if !attestation.CompareExtraData(reportData[:], attestation.MakeExtraData(userData, nonce)) {
+					return nil, nil, assert.AnError
+				}
+				return testdata.AttestationReport, nil, nil
+			},
+		},
+		"getting report fails": {
+			getReport: func([64]byte) ([]byte, []byte, error) {
+				return nil, nil, assert.AnError
+			},
+			wantErr: true,
+		},
+		"invalid certificate table": {
+			getReport: func([64]byte) ([]byte, []byte, error) {
+				return testdata.AttestationReport, []byte("invalid"), nil
+			},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			issuer := NewIssuer(logger.NewTest(t))
+			issuer.getReport = tc.getReport
+
+			attDocRaw, err := issuer.Issue(context.Background(), userData, nonce)
+			if tc.wantErr {
+				assert.Error(err)
+				return
+			}
+			require.NoError(err)
+
+			var attDoc attestationDocument
+			require.NoError(json.Unmarshal(attDocRaw, &attDoc))
+			assert.Equal(userData, attDoc.UserData)
+			assert.Equal(testdata.AttestationReport, attDoc.InstanceInfo.AttestationReport)
+			assert.Empty(attDoc.InstanceInfo.ReportSigner)
+		})
+	}
+}
@@ -0,0 +1,85 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+/*
+# QEMU SEV-SNP attestation
+
+Attestation for QEMU guests running on bare-metal AMD SEV-SNP hosts.
+
+Unlike the cloud SEV-SNP variants, no vTPM is involved.
+The attestation statement is the SEV-SNP attestation report itself, read from the SEV-SNP guest device.
+
+# Issuer
+
+The issuer requests an extended attestation report from the AMD PSP.
+The report data is set to the hash of the user data and the nonce.
+If the host provides the VCEK and ASK in the certificate table of the extended report,
+they are included in the attestation document.
+
+# Validator
+
+The validator verifies the report's signature and the VCEK certificate chain up to the configured AMD root key.
+It then compares the report against the attestation config:
+
+  - the report data must match the user data and nonce
+
+  - the launch measurement must match the expected measurement at index 0
+
+  - the host data must match, if configured
+
+  - the ID block must be signed by one of the accepted ID keys, if configured
+
+  - the guest policy, platform info and TCB versions must be acceptable
+*/
+package snp
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/edgelesssys/constellation/v2/internal/attestation/measurements"
+	"github.com/edgelesssys/constellation/v2/internal/attestation/snp"
+	"github.com/google/go-sev-guest/abi"
+)
+
+// GuestDevice is the path to the SEV-SNP guest device.
+const GuestDevice = "/dev/sev-guest"
+
+type attestationDocument struct {
+	// InstanceInfo holds the SEV-SNP attestation report and the certificates provided by the host.
+	InstanceInfo snp.InstanceInfo
+	// UserData is the user data that was passed to the PSP and was included in the report.
+	UserData []byte
+}
+
+// Open opens the SEV-SNP guest device.
+func Open() (*os.File, error) {
+	return os.Open(GuestDevice)
+}
+
+// IsSNPDevice checks if the given device is the SEV-SNP guest device.
+func IsSNPDevice(device io.ReadWriteCloser) bool {
+	f, ok := device.(*os.File)
+	return ok && f.Name() == GuestDevice
+}
+
+// GetSelectedMeasurements returns the launch measurement of the guest.
+// SEV-SNP has no runtime measurement registers, so the launch digest is the only measurement.
+func GetSelectedMeasurements() (measurements.M, error) {
+	reportRaw, _, err := snp.GetExtendedReport([64]byte{})
+	if err != nil {
+		return nil, fmt.Errorf("getting attestation report: %w", err)
+	}
+	report, err := abi.ReportToProto(reportRaw)
+	if err != nil {
+		return nil, fmt.Errorf("parsing attestation report: %w", err)
+	}
+
+	return measurements.M{
+		measurements.SNPIndexLaunchMeasurement: {Expected: report.Measurement},
+	}, nil
+}
@@ -0,0 +1,189 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package snp
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/edgelesssys/constellation/v2/internal/attestation"
+	"github.com/edgelesssys/constellation/v2/internal/attestation/measurements"
+	"github.com/edgelesssys/constellation/v2/internal/attestation/snp"
+	"github.com/edgelesssys/constellation/v2/internal/attestation/variant"
+	"github.com/edgelesssys/constellation/v2/internal/config"
+	"github.com/google/go-sev-guest/kds"
+	"github.com/google/go-sev-guest/proto/sevsnp"
+	"github.com/google/go-sev-guest/validate"
+	"github.com/google/go-sev-guest/verify"
+	"github.com/google/go-sev-guest/verify/trust"
+)
+
+// Validator validates SEV-SNP attestation documents of QEMU guests.
+type Validator struct {
+	variant.QEMUSEVSNP
+	cfg *config.QEMUSEVSNP
+
+	httpsGetter trust.HTTPSGetter
+	verifier    reportVerifier
+	validator   reportValidator
+	revocations revocationChecker
+
+	log attestation.Logger
+}
+
+// NewValidator initializes a new QEMU SEV-SNP Validator.
+func NewValidator(cfg *config.QEMUSEVSNP, log attestation.Logger) *Validator {
+	if log == nil {
+		log = attestation.NOPLogger{}
+	}
+	return &Validator{
+		cfg:         cfg,
+		httpsGetter: trust.DefaultHTTPSGetter(),
+		verifier:    &reportVerifierImpl{},
+		validator:   &reportValidatorImpl{},
+		revocations: snp.NewRevocationChecker(trust.DefaultHTTPSGetter()),
+		log:         log,
+	}
+}
+
+// Validate validates the given attestation document using SEV-SNP attestation.
+func (v *Validator) Validate(_ context.Context, attDocRaw []byte, nonce []byte) (userData []byte, err error) {
+	v.log.Info("Validating attestation document")
+	defer func() {
+		if err != nil {
+			v.log.Warn(fmt.Sprintf("Failed to validate attestation document: %s", err))
+		}
+	}()
+
+	var attDoc attestationDocument
+	if err := json.Unmarshal(attDocRaw, &attDoc); err != nil {
+		return nil, fmt.Errorf("unmarshaling attestation document: %w", err)
+	}
+
+	certChain := snp.NewCertificateChain((*x509.Certificate)(&v.cfg.AMDSigningKey), (*x509.Certificate)(&v.cfg.AMDRootKey))
+	att, err := attDoc.InstanceInfo.AttestationWithCerts(v.httpsGetter, certChain, v.log)
+	if err != nil {
+		return nil, fmt.Errorf("getting attestation with certs: %w", err)
+	}
+
+	verifyOpts, err := getVerifyOpts(att)
+	if err != nil {
+		return nil, fmt.Errorf("getting verify options: %w", err)
+	}
+	if err := v.verifier.SnpAttestation(att, verifyOpts); err != nil {
+		return nil, fmt.Errorf("verifying SNP attestation: %w", err)
+	}
+
+	if v.cfg.CheckRevocations {
+		if err := v.revocations.Check(att, v.cfg.AMDCRL, v.log); err != nil {
+			return nil, fmt.Errorf("checking revocations: %w", err)
+		}
+	}
+
+	// Check the guest policy and platform info first to return errors that name the violated config field.
+	if err := snp.CheckPolicy(att.Report, v.cfg.GuestPolicy, v.cfg.PlatformInfo); err != nil {
+		return nil, fmt.Errorf("report violates attestation config: %w", err)
+	}
+
+	var reportData [64]byte
+	copy(reportData[:], attestation.MakeExtraData(attDoc.UserData, nonce))
+
+	validateOpts := &validate.Options{
+		// Check that the user data and nonce are included in the report.
+		ReportData:   reportData[:],
+		GuestPolicy:  snp.GuestPolicy(v.cfg.GuestPolicy),
+		PlatformInfo: snp.PlatformInfo(v.cfg.PlatformInfo),
+		VMPL:         new(int), // Checks that Virtual Machine Privilege Level (VMPL) is 0.
+		// See the GCP SEV-SNP validator for why only the launch TCB is checked.
+		MinimumLaunchTCB: kds.TCBParts{
+			BlSpl:    v.cfg.BootloaderVersion,
+			TeeSpl:   v.cfg.TEEVersion,
+			SnpSpl:   v.cfg.SNPVersion,
+			UcodeSpl: v.cfg.MicrocodeVersion,
+		},
+		PermitProvisionalFirmware: true,
+	}
+	if len(v.cfg.HostData) > 0 {
+		validateOpts.HostData = v.cfg.HostData
+	}
+	if len(v.cfg.IDKeyDigests) > 0 {
+		validateOpts.RequireIDBlock = true
+		validateOpts.TrustedIDKeyHashes = v.cfg.IDKeyDigests
+	}
+
+	if err := v.validator.SnpAttestation(att, validateOpts); err != nil {
+		return nil, fmt.Errorf("validating SNP attestation: %w", err)
+	}
+
+	warnings, errs := measurements.CompareAny(config.MeasurementSets(v.cfg), map[uint32][]byte{
+		measurements.SNPIndexLaunchMeasurement: att.Report.Measurement,
+	})
+	for _, warning := range warnings {
+		v.log.Warn(warning)
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("measurement validation failed:\n%w", errors.Join(errs...))
+	}
+
+	return attDoc.UserData, nil
+}
+
+type reportVerifier interface {
+	SnpAttestation(att *sevsnp.Attestation, opts *verify.Options) error
+}
+
+type reportValidator interface {
+	SnpAttestation(att *sevsnp.Attestation, opts *validate.Options) error
+}
+
+type revocationChecker interface {
+	Check(att *sevsnp.Attestation, pinned config.CRL, log attestation.Logger) error
+}
+
+type reportVerifierImpl struct{}
+
+func (reportVerifierImpl) SnpAttestation(att *sevsnp.Attestation, opts *verify.Options) error {
+	return verify.SnpAttestation(att, opts)
+}
+
+type reportValidatorImpl struct{}
+
+func (reportValidatorImpl) SnpAttestation(att *sevsnp.Attestation, opts *validate.Options) error {
+	return validate.SnpAttestation(att, opts)
+}
+
+func getVerifyOpts(att *sevsnp.Attestation) (*verify.Options, error) {
+	ask, err := x509.ParseCertificate(att.CertificateChain.AskCert)
+	if err != nil {
+		return nil, fmt.Errorf("parsing ASK certificate: %w", err)
+	}
+	ark, err := x509.ParseCertificate(att.CertificateChain.ArkCert)
+	if err != nil {
+		return nil, fmt.Errorf("parsing ARK certificate: %w", err)
+	}
+	// With explicit trusted roots, go-sev-guest only checks that the ASK signed the VCEK.
+	// Make sure the ASK is signed by the trusted ARK.
+	if err := ask.CheckSignatureFrom(ark); err != nil {
+		return nil, fmt.Errorf("verifying ASK certificate: %w", err)
+	}
+
+	productName := kds.ProductLine(snp.Product())
+	return &verify.Options{
+		DisableCertFetching: true,
+		TrustedRoots: map[string][]*trust.AMDRootCerts{
+			productName: {
+				{
+					Product:      productName,
+					ProductCerts: &trust.ProductCerts{Ask: ask, Ark: ark},
+				},
+			},
+		},
+	}, nil
+}
@@ -0,0 +1,201 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package snp
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"testing"
+
+	"github.com/edgelesssys/constellation/v2/internal/attestation/idkeydigest"
+	"github.com/edgelesssys/constellation/v2/internal/attestation/measurements"
+	"github.com/edgelesssys/constellation/v2/internal/attestation/snp"
+	"github.com/edgelesssys/constellation/v2/internal/attestation/snp/testdata"
+	"github.com/edgelesssys/constellation/v2/internal/config"
+	"github.com/edgelesssys/constellation/v2/internal/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	// The recorded report binds the SHA-256 digest of testdata.RuntimeData.
+	userData := testdata.RuntimeData
+	launchMeasurement := mustDecodeHex(t, "5677f1de87289e7ad2c7e99c805d0468b1a9ccd83f0d245afa5242d405da4d5725852f8c6550564870e5f3206dfb1841")
+	idKeyDigest := mustDecodeHex(t, "57e229e0ffe5fa92d0faddff6cae0e61c926fc9ef9afd20a8b8cfcf7129db9338cbe5bf3f6987733a2bf65d06dc38fc1")
+
+	defaultAttDoc := attestationDocument{
+		InstanceInfo: snp.InstanceInfo{
+			AttestationReport: testdata.AttestationReport,
+			ReportSigner:      testdata.AzureThimVCEK,
+			CertChain:         testdata.CertChain,
+		},
+		UserData: userData,
+	}
+	defaultCfg := func() *config.QEMUSEVSNP {
+		cfg := config.DefaultForQEMUSEVSNP()
+		cfg.Measurements = measurements.M{
+			measurements.SNPIndexLaunchMeasurement: {Expected: launchMeasurement},
+		}
+		return cfg
+	}
+
+	testCases := map[string]struct {
+		attDoc     any
+		nonce      []byte
+		cfg        func() *config.QEMUSEVSNP
+		wantErr    bool
+		wantErrMsg string
+	}{
+		"success": {
+			attDoc: defaultAttDoc,
+			cfg:    defaultCfg,
+		},
+		"success with host data and ID key digest": {
+			attDoc: defaultAttDoc,
+			cfg: func() *config.QEMUSEVSNP {
+				cfg := defaultCfg()
+				cfg.HostData = make([]byte, 32)
+				cfg.IDKeyDigests = idkeydigest.List{bytes.Repeat([]byte{0x01}, 48), idKeyDigest}
+				return cfg
+			},
+		},
+		"launch measurement mismatch is only warned about": {
+			attDoc: defaultAttDoc,
+			cfg: func() *config.QEMUSEVSNP {
+				cfg := defaultCfg()
+				cfg.Measurements = measurements.M{
+					measurements.SNPIndexLaunchMeasurement: measurements.WithAllBytes(0x11, measurements.WarnOnly, measurements.SNPMeasurementLength),
+				}
+				return cfg
+			},
+		},
+		"launch measurement mismatch": {
+			attDoc: defaultAttDoc,
+			cfg: func() *config.QEMUSEVSNP {
+				cfg := defaultCfg()
+				cfg.Measurements = measurements.M{
+					measurements.SNPIndexLaunchMeasurement: measurements.WithAllBytes(0x11, measurements.Enforce, measurements.SNPMeasurementLength),
+				}
+				return cfg
+			},
+			wantErr:    true,
+			wantErrMsg: "measurement validation failed",
+		},
+		"nonce not bound to report": {
+			attDoc:     defaultAttDoc,
+			nonce:      []byte("nonce"),
+			cfg:        defaultCfg,
+			wantErr:    true,
+			wantErrMsg: "REPORT_DATA",
+		},
+		"host data mismatch": {
+			attDoc: defaultAttDoc,
+			cfg: func() *config.QEMUSEVSNP {
+				cfg := defaultCfg()
+				cfg.HostData = bytes.Repeat([]byte{0x01}, 32)
+				return cfg
+			},
+			wantErr:    true,
+			wantErrMsg: "HOST_DATA",
+		},
+		"untrusted ID key": {
+			attDoc: defaultAttDoc,
+			cfg: func() *config.QEMUSEVSNP {
+				cfg := defaultCfg()
+				cfg.IDKeyDigests = idkeydigest.List{bytes.Repeat([]byte{0x01}, 48)}
+				return cfg
+			},
+			wantErr: true,
+		},
+		"launch TCB too low": {
+			attDoc: defaultAttDoc,
+			cfg: func() *config.QEMUSEVSNP {
+				cfg := defaultCfg()
+				cfg.MicrocodeVersion = 255
+				return cfg
+			},
+			wantErr: true,
+		},
+		"platform info violated": {
+			attDoc: defaultAttDoc,
+			cfg: func() *config.QEMUSEVSNP {
+				cfg := defaultCfg()
+				cfg.PlatformInfo = &config.SNPPlatformInfo{AllowSMT: false}
+				return cfg
+			},
+			wantErr:    true,
+			wantErrMsg: "report violates attestation config",
+		},
+		"untrusted root key": {
+			attDoc: defaultAttDoc,
+			cfg: func() *config.QEMUSEVSNP {
+				cfg := defaultCfg()
+				// Use the ASK as root key, which doesn't match the ARK that signed the chain.
+				cfg.AMDRootKey = mustParseFirstCert(t, testdata.CertChain)
+				return cfg
+			},
+			wantErr: true,
+		},
+		"invalid attestation document": {
+			attDoc:     "not an attestation document",
+			cfg:        defaultCfg,
+			wantErr:    true,
+			wantErrMsg: "unmarshaling attestation document",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			attDocRaw, err := json.Marshal(tc.attDoc)
+			require.NoError(err)
+
+			v := NewValidator(tc.cfg(), logger.NewTest(t))
+			v.httpsGetter = &stubHTTPSGetter{}
+
+			gotUserData, err := v.Validate(context.Background(), attDocRaw, tc.nonce)
+			if tc.wantErr {
+				assert.Error(err)
+				if tc.wantErrMsg != "" {
+					assert.ErrorContains(err, tc.wantErrMsg)
+				}
+				return
+			}
+			require.NoError(err)
+			assert.Equal(userData, gotUserData)
+		})
+	}
+}
+
+// stubHTTPSGetter fails all requests, so the tests never reach out to AMD KDS.
+type stubHTTPSGetter struct{}
+
+func (stubHTTPSGetter) Get(string) ([]byte, error) {
+	return nil, assert.AnError
+}
+
+func mustDecodeHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	require.NoError(t, err)
+	return b
+}
+
+func mustParseFirstCert(t *testing.T, pemData []byte) config.Certificate {
+	t.Helper()
+	block, _ := pem.Decode(pemData)
+	require.NotNil(t, block)
+	cert, err := x509.ParseCertificate(block.Bytes)
+	require.NoError(t, err)
+	return config.Certificate(*cert)
+}
@@ -28,7 +28,7 @@ type Validator struct {
 func NewValidator(cfg *config.QEMUVTPM, log attestation.Logger) *Validator {
 	return &Validator{
 		Validator: vtpm.NewValidator(
-			cfg.Measurements,
+			config.MeasurementSets(cfg),
 			unconditionalTrust,
 			func(vtpm.AttestationDocument, *attest.MachineState) error { return nil },
 			log,
@@ -0,0 +1,146 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package snp
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/edgelesssys/constellation/v2/internal/attestation"
+	"github.com/edgelesssys/constellation/v2/internal/config"
+	"github.com/google/go-sev-guest/abi"
+	"github.com/google/go-sev-guest/kds"
+	spb "github.com/google/go-sev-guest/proto/sevsnp"
+	"github.com/google/go-sev-guest/verify/trust"
+)
+
+// CheckRevocation checks that neither the ASK nor the VCEK / VLEK of the attestation were revoked.
+// The CRL must be signed by the ARK of the attestation's certificate chain.
+func CheckRevocation(att *spb.Attestation, crl *x509.RevocationList) error {
+	if crl == nil {
+		return errors.New("no CRL available")
+	}
+	chain := att.GetCertificateChain()
+	ark, err := x509.ParseCertificate(chain.GetArkCert())
+	if err != nil {
+		return fmt.Errorf("parsing ARK certificate: %w", err)
+	}
+	if err := crl.CheckSignatureFrom(ark); err != nil {
+		return fmt.Errorf("CRL is not signed by ARK: %w", err)
+	}
+
+	certs := []struct {
+		name string
+		raw  []byte
+	}{
+		{"ASK", chain.GetAskCert()},
+		{"VCEK", chain.GetVcekCert()},
+		{"VLEK", chain.GetVlekCert()},
+	}
+	for _, c := range certs {
+		name, raw := c.name, c.raw
+		if len(raw) == 0 {
+			continue
+		}
+		cert, err := x509.ParseCertificate(raw)
+		if err != nil {
+			return fmt.Errorf("parsing %s certificate: %w", name, err)
+		}
+		for _, revoked := range crl.RevokedCertificateEntries {
+			if revoked.SerialNumber.Cmp(cert.SerialNumber) == 0 {
+				return fmt.Errorf("%s certificate with serial number %s was revoked at %s", name, cert.SerialNumber, revoked.RevocationTime)
+			}
+		}
+	}
+	return nil
+}
+
+// CRLURL returns the URL of the CRL that covers the certificate chain of the attestation.
+func CRLURL(att *spb.Attestation) (string, error) {
+	if ask, err := x509.ParseCertificate(att.GetCertificateChain().GetAskCert()); err == nil && len(ask.CRLDistributionPoints) > 0 {
+		return ask.CRLDistributionPoints[0], nil
+	}
+	info, err := abi.ParseSignerInfo(att.GetReport().GetSignerInfo())
+	if err != nil {
+		return "", fmt.Errorf("parsing signer info: %w", err)
+	}
+	return kds.CrlLinkByKey(kds.ProductLine(Product()), info.SigningKey), nil
+}
+
+// RevocationChecker checks attestations against the AMD CRL.
+// CRLs fetched from the AMD KDS are cached until their next update.
+type RevocationChecker struct {
+	getter trust.HTTPSGetter
+	now    func() time.Time
+
+	mux  sync.Mutex
+	crls map[string]*x509.RevocationList
+}
+
+// NewRevocationChecker returns a new RevocationChecker that fetches CRLs using getter.
+func NewRevocationChecker(getter trust.HTTPSGetter) *RevocationChecker {
+	return &RevocationChecker{
+		getter: getter,
+		now:    time.Now,
+		crls:   make(map[string]*x509.RevocationList),
+	}
+}
+
+// Check checks that the certificates of the attestation weren't revoked.
+// If a CRL is pinned, it's used instead of fetching the CRL from the AMD KDS, even after its next update.
+// A pinned CRL past its next update only causes a warning, so that clusters without network access keep working.
+func (r *RevocationChecker) Check(att *spb.Attestation, pinned config.CRL, log attestation.Logger) error {
+	crl := (*x509.RevocationList)(&pinned)
+	if pinned.IsEmpty() {
+		url, err := CRLURL(att)
+		if err != nil {
+			return fmt.Errorf("getting CRL URL: %w", err)
+		}
+		crl, err = r.crl(url)
+		if err != nil {
+			return fmt.Errorf("getting CRL: %w", err)
+		}
+	} else if err := CheckCRLValidity(crl, r.now()); err != nil {
+		log.Warn(fmt.Sprintf("Pinned CRL is outdated, revocations since its next update are not detected: %v", err))
+	}
+	return CheckRevocation(att, crl)
+}
+
+// CheckCRLValidity checks that the CRL isn't due for its next update at the given time.
+func CheckCRLValidity(crl *x509.RevocationList, now time.Time) error {
+	if crl.NextUpdate.IsZero() || !now.Before(crl.NextUpdate) {
+		return fmt.Errorf("CRL expired at %s", crl.NextUpdate)
+	}
+	return nil
+}
+
+// crl returns the CRL at url, fetching it if it's not cached or the cached CRL is outdated.
+func (r *RevocationChecker) crl(url string) (*x509.RevocationList, error) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	if crl, ok := r.crls[url]; ok && r.now().Before(crl.NextUpdate) {
+		return crl, nil
+	}
+	raw, err := r.getter.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("fetching CRL from %s: %w", url, err)
+	}
+	crl, err := x509.ParseRevocationList(raw)
+	if err != nil {
+		return nil, fmt.Errorf("parsing CRL: %w", err)
+	}
+	// an expired CRL doesn't list revocations since its next update, so it can't be trusted.
+	if err := CheckCRLValidity(crl, r.now()); err != nil {
+		return nil, fmt.Errorf("CRL fetched from %s: %w", url, err)
+	}
+	r.crls[url] = crl
+	return crl, nil
+}
@@ -0,0 +1,237 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package snp
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/edgelesssys/constellation/v2/internal/config"
+	spb "github.com/google/go-sev-guest/proto/sevsnp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckRevocation(t *testing.T) {
+	pki := newTestPKI(t)
+	otherPKI := newTestPKI(t)
+
+	testCases := map[string]struct {
+		crl     *x509.RevocationList
+		wantErr bool
+	}{
+		"nothing revoked": {
+			crl: pki.crl(t, time.Hour),
+		},
+		"other certificates revoked": {
+			crl: pki.crl(t, time.Hour, big.NewInt(42)),
+		},
+		"VCEK revoked": {
+			crl:     pki.crl(t, time.Hour, pki.vcek.SerialNumber),
+			wantErr: true,
+		},
+		"ASK revoked": {
+			crl:     pki.crl(t, time.Hour, pki.ask.SerialNumber),
+			wantErr: true,
+		},
+		"CRL not signed by ARK": {
+			crl:     otherPKI.crl(t, time.Hour),
+			wantErr: true,
+		},
+		"no CRL": {
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			err := CheckRevocation(pki.attestation(), tc.crl)
+			if tc.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestRevocationChecker(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	pki := newTestPKI(t)
+	crl := pki.crl(t, 3*time.Hour, pki.vcek.SerialNumber)
+	getter := &stubCRLGetter{crl: pki.crl(t, time.Hour).Raw}
+	log := &stubAttestationLogger{}
+	now := time.Now()
+	checker := NewRevocationChecker(getter)
+	checker.now = func() time.Time { return now }
+
+	// the CRL is fetched from the URL in the ASK certificate and cached
+	require.NoError(checker.Check(pki.attestation(), config.CRL{}, log))
+	require.NoError(checker.Check(pki.attestation(), config.CRL{}, log))
+	assert.Equal([]string{"https://kds.example.com/crl"}, getter.urls)
+
+	// a pinned CRL is used instead of fetching the CRL
+	assert.Error(checker.Check(pki.attestation(), config.CRL(*crl), log))
+	assert.Len(getter.urls, 1)
+	assert.Empty(log.warnings)
+
+	// the CRL is fetched again after its next update
+	getter.crl = crl.Raw
+	now = now.Add(2 * time.Hour)
+	assert.Error(checker.Check(pki.attestation(), config.CRL{}, log))
+	assert.Len(getter.urls, 2)
+
+	// an expired CRL is rejected
+	getter.crl = pki.crl(t, time.Hour).Raw
+	now = now.Add(2 * time.Hour)
+	assert.Error(checker.Check(pki.attestation(), config.CRL{}, log))
+	assert.Len(getter.urls, 3)
+
+	// an expired pinned CRL is still used, but causes a warning
+	assert.NoError(checker.Check(pki.attestation(), config.CRL(*pki.crl(t, time.Hour)), log))
+	assert.Len(log.warnings, 1)
+
+	// errors fetching the CRL are returned
+	getter.err = errors.New("failed")
+	assert.Error(checker.Check(pki.attestation(), config.CRL{}, log))
+}
+
+func TestCheckCRLValidity(t *testing.T) {
+	pki := newTestPKI(t)
+	now := time.Now().Add(time.Minute)
+
+	testCases := map[string]struct {
+		crl     *x509.RevocationList
+		wantErr bool
+	}{
+		"valid": {
+			crl: pki.crl(t, time.Hour),
+		},
+		"expired": {
+			crl:     pki.crl(t, time.Nanosecond),
+			wantErr: true,
+		},
+		"no next update": {
+			crl:     &x509.RevocationList{},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			err := CheckCRLValidity(tc.crl, now)
+			if tc.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+type testPKI struct {
+	arkKey *ecdsa.PrivateKey
+	ark    *x509.Certificate
+	ask    *x509.Certificate
+	vcek   *x509.Certificate
+}
+
+func newTestPKI(t *testing.T) *testPKI {
+	t.Helper()
+	require := require.New(t)
+
+	newCert := func(serial int64, template *x509.Certificate, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(err)
+		template.SerialNumber = big.NewInt(serial)
+		template.NotBefore = time.Now().Add(-time.Hour)
+		template.NotAfter = time.Now().Add(time.Hour)
+		if parent == nil {
+			parent, parentKey = template, key
+		}
+		raw, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+		require.NoError(err)
+		cert, err := x509.ParseCertificate(raw)
+		require.NoError(err)
+		return cert, key
+	}
+
+	ark, arkKey := newCert(1, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "ARK"},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}, nil, nil)
+	ask, askKey := newCert(2, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "ASK"},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		CRLDistributionPoints: []string{"https://kds.example.com/crl"},
+	}, ark, arkKey)
+	vcek, _ := newCert(3, &x509.Certificate{Subject: pkix.Name{CommonName: "VCEK"}}, ask, askKey)
+
+	return &testPKI{arkKey: arkKey, ark: ark, ask: ask, vcek: vcek}
+}
+
+func (p *testPKI) crl(t *testing.T, validity time.Duration, revoked ...*big.Int) *x509.RevocationList {
+	t.Helper()
+	var entries []x509.RevocationListEntry
+	for _, serial := range revoked {
+		entries = append(entries, x509.RevocationListEntry{SerialNumber: serial, RevocationTime: time.Now()})
+	}
+	raw, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:                    big.NewInt(1),
+		ThisUpdate:                time.Now(),
+		NextUpdate:                time.Now().Add(validity),
+		RevokedCertificateEntries: entries,
+	}, p.ark, p.arkKey)
+	require.NoError(t, err)
+	crl, err := x509.ParseRevocationList(raw)
+	require.NoError(t, err)
+	return crl
+}
+
+func (p *testPKI) attestation() *spb.Attestation {
+	return &spb.Attestation{
+		CertificateChain: &spb.CertificateChain{
+			ArkCert:  p.ark.Raw,
+			AskCert:  p.ask.Raw,
+			VcekCert: p.vcek.Raw,
+		},
+	}
+}
+
+type stubCRLGetter struct {
+	crl  []byte
+	err  error
+	urls []string
+}
+
+func (s *stubCRLGetter) Get(url string) ([]byte, error) {
+	s.urls = append(s.urls, url)
+	return s.crl, s.err
+}
+
+type stubAttestationLogger struct {
+	warnings []string
+}
+
+func (s *stubAttestationLogger) Info(string, ...any) {}
+
+func (s *stubAttestationLogger) Warn(msg string, _ ...any) {
+	s.warnings = append(s.warnings, msg)
+}
@@ -0,0 +1,96 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package snp
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/edgelesssys/constellation/v2/internal/config"
+	"github.com/google/go-sev-guest/abi"
+	spb "github.com/google/go-sev-guest/proto/sevsnp"
+)
+
+// GuestPolicy returns the maximum acceptable guest policy for the given config.
+// If cfg is nil, SMT is allowed, but debugging and migration agents are not.
+func GuestPolicy(cfg *config.SNPGuestPolicy) abi.SnpPolicy {
+	if cfg == nil {
+		return abi.SnpPolicy{
+			Debug: false, // Debug means the VM can be decrypted by the host for debugging purposes and thus is not allowed.
+			SMT:   true,  // Allow Simultaneous Multi-Threading (SMT), since not all CSPs support disabling it.
+		}
+	}
+	return abi.SnpPolicy{
+		ABIMajor:     cfg.MinimumABIMajor,
+		ABIMinor:     cfg.MinimumABIMinor,
+		Debug:        cfg.AllowDebug,
+		MigrateMA:    cfg.AllowMigrationAgent,
+		SMT:          cfg.AllowSMT,
+		SingleSocket: cfg.RequireSingleSocket,
+	}
+}
+
+// PlatformInfo returns the maximum acceptable platform info for the given config.
+// If cfg is nil, the platform info isn't checked.
+func PlatformInfo(cfg *config.SNPPlatformInfo) *abi.SnpPlatformInfo {
+	if cfg == nil {
+		return nil
+	}
+	return &abi.SnpPlatformInfo{
+		SMTEnabled:  cfg.AllowSMT,
+		TSMEEnabled: cfg.AllowTSME,
+		ECCEnabled:  cfg.RequireECC,
+	}
+}
+
+// CheckPolicy checks the guest policy and platform info of the report against the attestation config.
+// validate.SnpAttestation performs the same checks, but the errors returned here name the violated config field.
+func CheckPolicy(report *spb.Report, guestPolicy *config.SNPGuestPolicy, platformInfo *config.SNPPlatformInfo) error {
+	var errs []error
+
+	policy, err := abi.ParseSnpPolicy(report.GetPolicy())
+	if err != nil {
+		return fmt.Errorf("parsing guest policy: %w", err)
+	}
+	required := GuestPolicy(guestPolicy)
+	if policy.Debug && !required.Debug {
+		errs = append(errs, errors.New("guest policy allows debugging, but guestPolicy.allowDebug is false"))
+	}
+	if policy.MigrateMA && !required.MigrateMA {
+		errs = append(errs, errors.New("guest policy allows a migration agent, but guestPolicy.allowMigrationAgent is false"))
+	}
+	if policy.SMT && !required.SMT {
+		errs = append(errs, errors.New("guest policy allows SMT, but guestPolicy.allowSMT is false"))
+	}
+	if !policy.SingleSocket && required.SingleSocket {
+		errs = append(errs, errors.New("guest policy isn't restricted to a single socket, but guestPolicy.requireSingleSocket is true"))
+	}
+	if policy.ABIMajor < required.ABIMajor || (policy.ABIMajor == required.ABIMajor && policy.ABIMinor < required.ABIMinor) {
+		errs = append(errs, fmt.Errorf(
+			"guest policy requires ABI version %d.%d, but guestPolicy.minimumABIMajor and guestPolicy.minimumABIMinor require at least %d.%d",
+			policy.ABIMajor, policy.ABIMinor, required.ABIMajor, required.ABIMinor,
+		))
+	}
+
+	if platformInfo != nil {
+		info, err := abi.ParseSnpPlatformInfo(report.GetPlatformInfo())
+		if err != nil {
+			return fmt.Errorf("parsing platform info: %w", err)
+		}
+		if info.SMTEnabled && !platformInfo.AllowSMT {
+			errs = append(errs, errors.New("platform has SMT enabled, but platformInfo.allowSMT is false"))
+		}
+		if info.TSMEEnabled && !platformInfo.AllowTSME {
+			errs = append(errs, errors.New("platform has TSME enabled, but platformInfo.allowTSME is false"))
+		}
+		if !info.ECCEnabled && platformInfo.RequireECC {
+			errs = append(errs, errors.New("platform doesn't use ECC memory, but platformInfo.requireECC is true"))
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,124 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package snp
+
+import (
+	"testing"
+
+	"github.com/edgelesssys/constellation/v2/internal/config"
+	spb "github.com/google/go-sev-guest/proto/sevsnp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckPolicy(t *testing.T) {
+	const (
+		policyReserved   = 1 << 17
+		policySMT        = 1 << 16
+		policyMigrateMA  = 1 << 18
+		policyDebug      = 1 << 19
+		policySingleSock = 1 << 20
+		platformSMT      = 1 << 0
+		platformTSME     = 1 << 1
+		platformECC      = 1 << 2
+	)
+	abiVersion := func(major, minor uint64) uint64 { return major<<8 | minor }
+
+	testCases := map[string]struct {
+		policy       uint64
+		platformInfo uint64
+		guestPolicy  *config.SNPGuestPolicy
+		platformCfg  *config.SNPPlatformInfo
+		wantErr      string
+	}{
+		"default policy": {
+			policy: policyReserved | policySMT,
+		},
+		"default policy rejects debug": {
+			policy:  policyReserved | policyDebug,
+			wantErr: "guestPolicy.allowDebug",
+		},
+		"default policy rejects migration agent": {
+			policy:  policyReserved | policyMigrateMA,
+			wantErr: "guestPolicy.allowMigrationAgent",
+		},
+		"debug allowed": {
+			policy:      policyReserved | policyDebug,
+			guestPolicy: &config.SNPGuestPolicy{AllowDebug: true},
+		},
+		"SMT not allowed": {
+			policy:      policyReserved | policySMT,
+			guestPolicy: &config.SNPGuestPolicy{},
+			wantErr:     "guestPolicy.allowSMT",
+		},
+		"single socket required": {
+			policy:      policyReserved,
+			guestPolicy: &config.SNPGuestPolicy{RequireSingleSocket: true},
+			wantErr:     "guestPolicy.requireSingleSocket",
+		},
+		"single socket present": {
+			policy:      policyReserved | policySingleSock,
+			guestPolicy: &config.SNPGuestPolicy{RequireSingleSocket: true},
+		},
+		"ABI version sufficient": {
+			policy:      policyReserved | abiVersion(1, 55),
+			guestPolicy: &config.SNPGuestPolicy{MinimumABIMajor: 1, MinimumABIMinor: 51},
+		},
+		"ABI minor version too low": {
+			policy:      policyReserved | abiVersion(1, 50),
+			guestPolicy: &config.SNPGuestPolicy{MinimumABIMajor: 1, MinimumABIMinor: 51},
+			wantErr:     "guestPolicy.minimumABIMajor",
+		},
+		"ABI major version too low": {
+			policy:      policyReserved | abiVersion(0, 99),
+			guestPolicy: &config.SNPGuestPolicy{MinimumABIMajor: 1},
+			wantErr:     "guestPolicy.minimumABIMajor",
+		},
+		"platform info not checked by default": {
+			policy:       policyReserved,
+			platformInfo: platformSMT | platformTSME,
+		},
+		"platform info allowed": {
+			policy:       policyReserved,
+			platformInfo: platformSMT | platformTSME | platformECC,
+			platformCfg:  &config.SNPPlatformInfo{AllowSMT: true, AllowTSME: true, RequireECC: true},
+		},
+		"platform SMT not allowed": {
+			policy:       policyReserved,
+			platformInfo: platformSMT,
+			platformCfg:  &config.SNPPlatformInfo{},
+			wantErr:      "platformInfo.allowSMT",
+		},
+		"platform TSME not allowed": {
+			policy:       policyReserved,
+			platformInfo: platformTSME,
+			platformCfg:  &config.SNPPlatformInfo{},
+			wantErr:      "platformInfo.allowTSME",
+		},
+		"platform ECC required": {
+			policy:      policyReserved,
+			platformCfg: &config.SNPPlatformInfo{RequireECC: true},
+			wantErr:     "platformInfo.requireECC",
+		},
+		"invalid policy": {
+			policy:  policySMT,
+			wantErr: "parsing guest policy",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			report := &spb.Report{Policy: tc.policy, PlatformInfo: tc.platformInfo}
+
+			err := CheckPolicy(report, tc.guestPolicy, tc.platformCfg)
+			if tc.wantErr != "" {
+				assert.ErrorContains(t, err, tc.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -53,6 +53,48 @@ func GetExtendedReport(reportData [64]byte) (report, certChain []byte, err error
 	return report, certChain, nil
 }
 
+// ParseCertTable takes a marshalled SNP certificate table and returns the PEM-encoded VCEK certificate and,
+// if present, the ASK of the SNP certificate chain.
+// AMD documentation on certificate tables can be found in section 4.1.8.1, revision 2.03 "SEV-ES Guest-Hypervisor Communication Block Standardization".
+// https://www.amd.com/content/dam/amd/en/documents/epyc-technical-docs/specifications/56421.pdf
+func ParseCertTable(certs []byte) (vcekPEM []byte, certChain []byte, err error) {
+	certTable := abi.CertTable{}
+	if err := certTable.Unmarshal(certs); err != nil {
+		return nil, nil, fmt.Errorf("unmarshalling SNP certificate table: %w", err)
+	}
+
+	vcekRaw, err := certTable.GetByGUIDString(abi.VcekGUID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("getting VCEK certificate: %w", err)
+	}
+
+	// An optional check for certificate well-formedness. vcekRaw == cert.Raw.
+	vcek, err := x509.ParseCertificate(vcekRaw)
+	if err != nil {
+		return nil, nil, fmt.Errorf("parsing certificate: %w", err)
+	}
+
+	vcekPEM = pem.EncodeToMemory(&pem.Block{
+		Type:  "CERTIFICATE",
+		Bytes: vcek.Raw,
+	})
+
+	var askPEM []byte
+	if askRaw, err := certTable.GetByGUIDString(abi.AskGUID); err == nil {
+		ask, err := x509.ParseCertificate(askRaw)
+		if err != nil {
+			return nil, nil, fmt.Errorf("parsing ASK certificate: %w", err)
+		}
+
+		askPEM = pem.EncodeToMemory(&pem.Block{
+			Type:  "CERTIFICATE",
+			Bytes: ask.Raw,
+		})
+	}
+
+	return vcekPEM, askPEM, nil
+}
+
 // InstanceInfo contains the necessary information to establish trust in a SNP CVM.
 type InstanceInfo struct {
 	// ReportSigner is the PEM-encoded certificate used to validate the attestation report's signature.
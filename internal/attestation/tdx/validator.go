@@ -29,7 +29,7 @@ type Validator struct {
 	variant.QEMUTDX
 
 	tdx      tdxVerifier
-	expected measurements.M
+	expected []measurements.M
 
 	log attestation.Logger
 }
@@ -42,7 +42,7 @@ func NewValidator(cfg *config.QEMUTDX, log attestation.Logger) *Validator {
 
 	return &Validator{
 		tdx:      verification.New(),
-		expected: cfg.Measurements,
+		expected: config.MeasurementSets(cfg),
 		log:      log,
 	}
 }
@@ -81,7 +81,7 @@ func (v *Validator) Validate(ctx context.Context, attDocRaw []byte, nonce []byte
 	}
 
 	// Verify the quote against the expected measurements.
-	warnings, errs := v.expected.Compare(tdMeasure)
+	warnings, errs := measurements.CompareAny(v.expected, tdMeasure)
 	for _, warning := range warnings {
 		v.log.Warn(warning)
 	}
@@ -45,18 +45,20 @@ const (
 	awsSEVSNP          = "aws-sev-snp"
 	gcpSEVES           = "gcp-sev-es"
 	gcpSEVSNP          = "gcp-sev-snp"
+	gcpTDX             = "gcp-tdx"
 	azureTDX           = "azure-tdx"
 	azureSEVSNP        = "azure-sev-snp"
 	azureTrustedLaunch = "azure-trustedlaunch"
 	qemuVTPM           = "qemu-vtpm"
+	qemuSEVSNP         = "qemu-sev-snp"
 	qemuTDX            = "qemu-tdx"
 )
 
 var providerAttestationMapping = map[cloudprovider.Provider][]Variant{
 	cloudprovider.AWS:       {AWSSEVSNP{}, AWSNitroTPM{}},
 	cloudprovider.Azure:     {AzureSEVSNP{}, AzureTDX{}, AzureTrustedLaunch{}},
-	cloudprovider.GCP:       {GCPSEVSNP{}, GCPSEVES{}},
-	cloudprovider.QEMU:      {QEMUVTPM{}},
+	cloudprovider.GCP:       {GCPSEVSNP{}, GCPSEVES{}, GCPTDX{}},
+	cloudprovider.QEMU:      {QEMUVTPM{}, QEMUSEVSNP{}},
 	cloudprovider.OpenStack: {QEMUVTPM{}},
 }
 
@@ -113,6 +115,8 @@ func FromString(oid string) (Variant, error) {
 		return GCPSEVES{}, nil
 	case gcpSEVSNP:
 		return GCPSEVSNP{}, nil
+	case gcpTDX:
+		return GCPTDX{}, nil
 	case azureSEVSNP:
 		return AzureSEVSNP{}, nil
 	case azureTrustedLaunch:
@@ -121,6 +125,8 @@ func FromString(oid string) (Variant, error) {
 		return AzureTDX{}, nil
 	case qemuVTPM:
 		return QEMUVTPM{}, nil
+	case qemuSEVSNP:
+		return QEMUSEVSNP{}, nil
 	case qemuTDX:
 		return QEMUTDX{}, nil
 	}
@@ -230,6 +236,24 @@ func (GCPSEVSNP) Equal(other Getter) bool {
 	return other.OID().Equal(GCPSEVSNP{}.OID())
 }
 
+// GCPTDX holds the GCP TDX OID.
+type GCPTDX struct{}
+
+// OID returns the struct's object identifier.
+func (GCPTDX) OID() asn1.ObjectIdentifier {
+	return asn1.ObjectIdentifier{1, 3, 9900, 3, 3}
+}
+
+// String returns the string representation of the OID.
+func (GCPTDX) String() string {
+	return gcpTDX
+}
+
+// Equal returns true if the other variant is also GCPTDX.
+func (GCPTDX) Equal(other Getter) bool {
+	return other.OID().Equal(GCPTDX{}.OID())
+}
+
 // AzureTDX holds the OID for Azure TDX CVMs.
 type AzureTDX struct{}
 
@@ -302,6 +326,24 @@ func (QEMUVTPM) Equal(other Getter) bool {
 	return other.OID().Equal(QEMUVTPM{}.OID())
 }
 
+// QEMUSEVSNP holds the QEMU SEV-SNP OID.
+type QEMUSEVSNP struct{}
+
+// OID returns the struct's object identifier.
+func (QEMUSEVSNP) OID() asn1.ObjectIdentifier {
+	return asn1.ObjectIdentifier{1, 3, 9900, 5, 2}
+}
+
+// String returns the string representation of the OID.
+func (QEMUSEVSNP) String() string {
+	return qemuSEVSNP
+}
+
+// Equal returns true if the other variant is also QEMUSEVSNP.
+func (QEMUSEVSNP) Equal(other Getter) bool {
+	return other.OID().Equal(QEMUSEVSNP{}.OID())
+}
+
 // QEMUTDX holds the QEMU TDX OID.
 // Placeholder for dev-cloud integration.
 type QEMUTDX struct{}
@@ -158,7 +158,7 @@ func (i *Issuer) Issue(ctx context.Context, userData []byte, nonce []byte) (res
 
 // Validator handles validation of TPM based attestation.
 type Validator struct {
-	expected      measurements.M
+	expected      []measurements.M
 	getTrustedKey GetTPMTrustedAttestationPublicKey
 	validateCVM   ValidateCVM
 
@@ -166,7 +166,7 @@ type Validator struct {
 }
 
 // NewValidator returns a new Validator.
-func NewValidator(expected measurements.M, getTrustedKey GetTPMTrustedAttestationPublicKey,
+func NewValidator(expected []measurements.M, getTrustedKey GetTPMTrustedAttestationPublicKey,
 	validateCVM ValidateCVM, log attestation.Logger,
 ) *Validator {
 	if log == nil {
@@ -238,7 +238,7 @@ func (v *Validator) Validate(ctx context.Context, attDocRaw []byte, nonce []byte
 	if err != nil {
 		return nil, err
 	}
-	warnings, errs := v.expected.Compare(attDoc.Attestation.Quotes[quoteIdx].Pcrs.Pcrs)
+	warnings, errs := measurements.CompareAny(v.expected, attDoc.Attestation.Quotes[quoteIdx].Pcrs.Pcrs)
 	for _, warning := range warnings {
 		v.log.Warn(warning)
 	}
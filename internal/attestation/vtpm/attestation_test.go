@@ -85,7 +85,7 @@ func TestValidate(t *testing.T) {
 	defer tpmCloser.Close()
 
 	issuer := NewIssuer(tpmOpen, tpmclient.AttestationKeyRSA, fakeGetInstanceInfo, logger.NewTest(t))
-	validator := NewValidator(testExpectedPCRs, fakeGetTrustedKey, fakeValidateCVM, logger.NewTest(t))
+	validator := NewValidator([]measurements.M{testExpectedPCRs}, fakeGetTrustedKey, fakeValidateCVM, logger.NewTest(t))
 
 	nonce := []byte{1, 2, 3, 4}
 	challenge := []byte("Constellation")
@@ -144,7 +144,7 @@ func TestValidate(t *testing.T) {
 		},
 	}
 	warningValidator := NewValidator(
-		expectedPCRs,
+		[]measurements.M{expectedPCRs},
 		fakeGetTrustedKey,
 		fakeValidateCVM,
 		warnLog,
@@ -161,18 +161,18 @@ func TestValidate(t *testing.T) {
 		wantErr   bool
 	}{
 		"valid": {
-			validator: NewValidator(testExpectedPCRs, fakeGetTrustedKey, fakeValidateCVM, warnLog),
+			validator: NewValidator([]measurements.M{testExpectedPCRs}, fakeGetTrustedKey, fakeValidateCVM, warnLog),
 			attDoc:    mustMarshalAttestation(attDoc, require),
 			nonce:     nonce,
 		},
 		"invalid nonce": {
-			validator: NewValidator(testExpectedPCRs, fakeGetTrustedKey, fakeValidateCVM, warnLog),
+			validator: NewValidator([]measurements.M{testExpectedPCRs}, fakeGetTrustedKey, fakeValidateCVM, warnLog),
 			attDoc:    mustMarshalAttestation(attDoc, require),
 			nonce:     []byte{4, 3, 2, 1},
 			wantErr:   true,
 		},
 		"invalid signature": {
-			validator: NewValidator(testExpectedPCRs, fakeGetTrustedKey, fakeValidateCVM, warnLog),
+			validator: NewValidator([]measurements.M{testExpectedPCRs}, fakeGetTrustedKey, fakeValidateCVM, warnLog),
 			attDoc: mustMarshalAttestation(AttestationDocument{
 				Attestation:  attDoc.Attestation,
 				InstanceInfo: attDoc.InstanceInfo,
@@ -183,7 +183,7 @@ func TestValidate(t *testing.T) {
 		},
 		"untrusted attestation public key": {
 			validator: NewValidator(
-				testExpectedPCRs,
+				[]measurements.M{testExpectedPCRs},
 				func(context.Context, AttestationDocument, []byte) (crypto.PublicKey, error) {
 					return nil, errors.New("untrusted")
 				},
@@ -194,7 +194,7 @@ func TestValidate(t *testing.T) {
 		},
 		"not a CVM": {
 			validator: NewValidator(
-				testExpectedPCRs,
+				[]measurements.M{testExpectedPCRs},
 				fakeGetTrustedKey,
 				func(AttestationDocument, *attest.MachineState) error {
 					return errors.New("untrusted")
@@ -206,7 +206,7 @@ func TestValidate(t *testing.T) {
 		},
 		"untrusted PCRs": {
 			validator: NewValidator(
-				measurements.M{
+				[]measurements.M{{
 					0: measurements.Measurement{
 						Expected:      []byte{0xFF},
 						ValidationOpt: measurements.Enforce,
@@ -215,7 +215,7 @@ func TestValidate(t *testing.T) {
 						Expected:      []byte{0xFF},
 						ValidationOpt: measurements.Enforce,
 					},
-				},
+				}},
 				fakeGetTrustedKey,
 				fakeValidateCVM,
 				warnLog),
@@ -223,9 +223,26 @@ func TestValidate(t *testing.T) {
 			nonce:   nonce,
 			wantErr: true,
 		},
+		"second accepted measurement set matches": {
+			validator: NewValidator(
+				[]measurements.M{
+					{
+						0: measurements.Measurement{
+							Expected:      []byte{0xFF},
+							ValidationOpt: measurements.Enforce,
+						},
+					},
+					testExpectedPCRs,
+				},
+				fakeGetTrustedKey,
+				fakeValidateCVM,
+				warnLog),
+			attDoc: mustMarshalAttestation(attDoc, require),
+			nonce:  nonce,
+		},
 		"untrusted WarnOnly PCRs": {
 			validator: NewValidator(
-				measurements.M{
+				[]measurements.M{{
 					0: measurements.Measurement{
 						Expected:      []byte{0xFF},
 						ValidationOpt: measurements.WarnOnly,
@@ -234,7 +251,7 @@ func TestValidate(t *testing.T) {
 						Expected:      []byte{0xFF},
 						ValidationOpt: measurements.WarnOnly,
 					},
-				},
+				}},
 				fakeGetTrustedKey,
 				fakeValidateCVM,
 				logger.NewTest(t)),
@@ -243,7 +260,7 @@ func TestValidate(t *testing.T) {
 			wantErr: false,
 		},
 		"no sha256 quote": {
-			validator: NewValidator(testExpectedPCRs, fakeGetTrustedKey, fakeValidateCVM, warnLog),
+			validator: NewValidator([]measurements.M{testExpectedPCRs}, fakeGetTrustedKey, fakeValidateCVM, warnLog),
 			attDoc: mustMarshalAttestation(AttestationDocument{
 				Attestation: &attest.Attestation{
 					AkPub: attDoc.Attestation.AkPub,
@@ -260,7 +277,7 @@ func TestValidate(t *testing.T) {
 			wantErr: true,
 		},
 		"invalid attestation document": {
-			validator: NewValidator(testExpectedPCRs, fakeGetTrustedKey, fakeValidateCVM, warnLog),
+			validator: NewValidator([]measurements.M{testExpectedPCRs}, fakeGetTrustedKey, fakeValidateCVM, warnLog),
 			attDoc:    []byte("invalid attestation"),
 			nonce:     nonce,
 			wantErr:   true,
@@ -98,15 +98,22 @@ func (c *Cloud) Self(ctx context.Context) (metadata.InstanceMetadata, error) {
 		return metadata.InstanceMetadata{}, fmt.Errorf("retrieving instance identity: %w", err)
 	}
 
-	instanceRole, err := c.readInstanceTag(ctx, cloud.TagRole)
+	tags, err := c.readInstanceTags(ctx)
+	if err != nil {
+		return metadata.InstanceMetadata{}, fmt.Errorf("retrieving instance tags: %w", err)
+	}
+	instanceRole, err := findTag(tags, cloud.TagRole)
 	if err != nil {
 		return metadata.InstanceMetadata{}, fmt.Errorf("retrieving role tag: %w", err)
 	}
+	// the node group tag is missing on instances created by older versions of Constellation
+	nodeGroup, _ := findTag(tags, cloud.TagNodeGroup)
 
 	return metadata.InstanceMetadata{
 		Name:       identity.InstanceID,
 		ProviderID: fmt.Sprintf("aws:///%s/%s", identity.AvailabilityZone, identity.InstanceID),
 		Role:       role.FromString(instanceRole),
+		NodeGroup:  nodeGroup,
 		VPCIP:      identity.PrivateIP,
 	}, nil
 }
@@ -254,6 +261,7 @@ func (c *Cloud) convertToMetadataInstance(ec2Instances []ec2Types.Instance) ([]m
 			return nil, fmt.Errorf("retrieving tag for instance %s: %w", *ec2Instance.InstanceId, err)
 		}
 		newInstance.Role = role.FromString(instanceRole)
+		newInstance.NodeGroup, _ = findTag(ec2Instance.Tags, cloud.TagNodeGroup)
 
 		// Set ProviderID
 		if ec2Instance.Placement != nil {
@@ -271,27 +279,35 @@ func (c *Cloud) convertToMetadataInstance(ec2Instances []ec2Types.Instance) ([]m
 }
 
 func (c *Cloud) readInstanceTag(ctx context.Context, tag string) (string, error) {
+	tags, err := c.readInstanceTags(ctx)
+	if err != nil {
+		return "", err
+	}
+	return findTag(tags, tag)
+}
+
+func (c *Cloud) readInstanceTags(ctx context.Context) ([]ec2Types.Tag, error) {
 	identity, err := c.imds.GetInstanceIdentityDocument(ctx, &imds.GetInstanceIdentityDocumentInput{})
 	if err != nil {
-		return "", fmt.Errorf("retrieving instance identity: %w", err)
+		return nil, fmt.Errorf("retrieving instance identity: %w", err)
 	}
 
 	if identity == nil {
-		return "", errors.New("instance identity is nil")
+		return nil, errors.New("instance identity is nil")
 	}
 
 	out, err := c.ec2.DescribeInstances(ctx, &ec2.DescribeInstancesInput{
 		InstanceIds: []string{identity.InstanceID},
 	})
 	if err != nil {
-		return "", fmt.Errorf("descibing instances: %w", err)
+		return nil, fmt.Errorf("descibing instances: %w", err)
 	}
 
 	if len(out.Reservations) != 1 || len(out.Reservations[0].Instances) != 1 {
-		return "", fmt.Errorf("expected 1 instance, got %d", len(out.Reservations[0].Instances))
+		return nil, fmt.Errorf("expected 1 instance, got %d", len(out.Reservations[0].Instances))
 	}
 
-	return findTag(out.Reservations[0].Instances[0].Tags, tag)
+	return out.Reservations[0].Instances[0].Tags, nil
 }
 
 func findTag(tags []ec2Types.Tag, wantKey string) (string, error) {
@@ -91,6 +91,10 @@ func TestSelf(t *testing.T) {
 											Key:   aws.String(cloud.TagRole),
 											Value: aws.String("worker"),
 										},
+										{
+											Key:   aws.String(cloud.TagNodeGroup),
+											Value: aws.String("worker_default"),
+										},
 										{
 											Key:   aws.String(cloud.TagInitSecretHash),
 											Value: aws.String("initSecretHash"),
@@ -106,6 +110,7 @@ func TestSelf(t *testing.T) {
 				Name:       "test-instance-id",
 				ProviderID: "aws:///test-zone/test-instance-id",
 				Role:       role.Worker,
+				NodeGroup:  "worker_default",
 				VPCIP:      "192.0.2.1",
 			},
 		},
@@ -449,6 +449,10 @@ func convertToInstanceMetadata(vm armcompute.VirtualMachineScaleSetVM, networkIn
 	if vm.Tags != nil || vm.Tags[cloud.TagRole] != nil {
 		instanceRole = *vm.Tags[cloud.TagRole]
 	}
+	var nodeGroup string
+	if tag, ok := vm.Tags[cloud.TagNodeGroup]; ok && tag != nil {
+		nodeGroup = *tag
+	}
 
 	var privateIP string
 	for _, networkInterface := range networkInterfaces {
@@ -469,6 +473,7 @@ func convertToInstanceMetadata(vm armcompute.VirtualMachineScaleSetVM, networkIn
 		Name:       *vm.Properties.OSProfile.ComputerName,
 		ProviderID: "azure://" + *vm.ID,
 		Role:       role.FromString(instanceRole),
+		NodeGroup:  nodeGroup,
 		VPCIP:      privateIP,
 	}, nil
 }
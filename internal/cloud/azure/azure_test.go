@@ -278,8 +278,9 @@ func TestList(t *testing.T) {
 			},
 		},
 		Tags: map[string]*string{
-			cloud.TagUID:  to.Ptr("uid"),
-			cloud.TagRole: to.Ptr("worker"),
+			cloud.TagUID:       to.Ptr("uid"),
+			cloud.TagRole:      to.Ptr("worker"),
+			cloud.TagNodeGroup: to.Ptr("worker_default"),
 		},
 	}
 
@@ -287,6 +288,7 @@ func TestList(t *testing.T) {
 		Name:       "scale-set-0",
 		ProviderID: "azure:///subscriptions/subscription-id/resourceGroups/resource-group/providers/Microsoft.Compute/virtualMachineScaleSets/scale-set/virtualMachines/0",
 		Role:       role.Worker,
+		NodeGroup:  "worker_default",
 		VPCIP:      "192.0.2.0",
 	}
 
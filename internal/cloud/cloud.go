@@ -29,6 +29,8 @@ package cloud
 const (
 	// TagRole is the tag/label key used to identify the role of a node.
 	TagRole = "constellation-role"
+	// TagNodeGroup is the tag/label key used to identify the node group of a node.
+	TagNodeGroup = "constellation-node-group"
 	// TagUID is the tag/label key used to identify the UID of a cluster.
 	TagUID = "constellation-uid"
 	// TagInitSecretHash is the tag/label key used to identify the hash of the init secret.
@@ -541,6 +541,7 @@ func convertToInstanceMetadata(in *computepb.Instance, project string, zone stri
 		Name:          *in.Name,
 		ProviderID:    gcpshared.JoinProviderID(project, zone, *in.Name),
 		Role:          role.FromString(in.Labels[cloud.TagRole]),
+		NodeGroup:     in.Labels[cloud.TagNodeGroup],
 		VPCIP:         vpcIP,
 		AliasIPRanges: ips,
 	}, nil
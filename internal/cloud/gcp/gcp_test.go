@@ -647,8 +647,9 @@ func TestList(t *testing.T) {
 							Name: proto.String("anotherInstance"),
 							Zone: proto.String("someZone-west3-b"),
 							Labels: map[string]string{
-								cloud.TagUID:  "1234",
-								cloud.TagRole: role.Worker.String(),
+								cloud.TagUID:       "1234",
+								cloud.TagRole:      role.Worker.String(),
+								cloud.TagNodeGroup: "worker_default",
 							},
 							NetworkInterfaces: []*computepb.NetworkInterface{
 								{
@@ -690,6 +691,7 @@ func TestList(t *testing.T) {
 				{
 					Name:             "anotherInstance",
 					Role:             role.Worker,
+					NodeGroup:        "worker_default",
 					ProviderID:       "gce://someProject/someZone-west3-b/anotherInstance",
 					VPCIP:            "192.0.2.1",
 					AliasIPRanges:    []string{"198.51.100.0/24"},
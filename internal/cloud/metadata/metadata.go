@@ -17,6 +17,9 @@ type InstanceMetadata struct {
 	Name       string
 	ProviderID string
 	Role       role.Role
+	// NodeGroup is the name of the node group the instance belongs to.
+	// May be empty on certain CSPs.
+	NodeGroup string
 	// VPCIP is the primary IP address of the instance in the VPC.
 	VPCIP string
 
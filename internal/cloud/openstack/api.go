@@ -23,6 +23,7 @@ type imdsAPI interface {
 	uid(ctx context.Context) (string, error)
 	initSecretHash(ctx context.Context) (string, error)
 	role(ctx context.Context) (role.Role, error)
+	nodeGroup(ctx context.Context) (string, error)
 	vpcIP(ctx context.Context) (string, error)
 	loadBalancerEndpoint(ctx context.Context) (string, error)
 }
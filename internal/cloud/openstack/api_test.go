@@ -29,6 +29,8 @@ type stubIMDSClient struct {
 	initSecretHashErr          error
 	roleResult                 role.Role
 	roleErr                    error
+	nodeGroupResult            string
+	nodeGroupErr               error
 	vpcIPResult                string
 	vpcIPErr                   error
 	loadBalancerEndpointResult string
@@ -59,6 +61,10 @@ func (c *stubIMDSClient) role(_ context.Context) (role.Role, error) {
 	return c.roleResult, c.roleErr
 }
 
+func (c *stubIMDSClient) nodeGroup(_ context.Context) (string, error) {
+	return c.nodeGroupResult, c.nodeGroupErr
+}
+
 func (c *stubIMDSClient) vpcIP(_ context.Context) (string, error) {
 	return c.vpcIPResult, c.vpcIPErr
 }
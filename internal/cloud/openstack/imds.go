@@ -130,6 +130,18 @@ func (c *imdsClient) role(ctx context.Context) (role.Role, error) {
 	return role.FromString(c.cache.Tags.Role), nil
 }
 
+// nodeGroup returns the node group of the instance the function is called from.
+// The node group is empty for instances created by older versions of Constellation.
+func (c *imdsClient) nodeGroup(ctx context.Context) (string, error) {
+	if c.timeForUpdate(c.cacheTime) || len(c.cache.Tags.Role) == 0 {
+		if err := c.update(ctx); err != nil {
+			return "", err
+		}
+	}
+
+	return c.cache.Tags.NodeGroup, nil
+}
+
 func (c *imdsClient) loadBalancerEndpoint(ctx context.Context) (string, error) {
 	if c.timeForUpdate(c.cacheTime) || c.userDataCache.LoadBalancerEndpoint == "" {
 		if err := c.update(ctx); err != nil {
@@ -303,6 +315,7 @@ type metadataResponse struct {
 type metadataTags struct {
 	InitSecretHash string `json:"constellation-init-secret-hash,omitempty"`
 	Role           string `json:"constellation-role,omitempty"`
+	NodeGroup      string `json:"constellation-node-group,omitempty"`
 	UID            string `json:"constellation-uid,omitempty"`
 }
 
@@ -28,6 +28,10 @@ const (
 	microversion  = "2.42"
 )
 
+// OperatorNodeTag is the tag of the servers and ports created by the node operator.
+// Terraform does not manage these resources, so they have to be deleted before the cluster is destroyed.
+const OperatorNodeTag = "constellation-operator-node"
+
 // MetadataClient is the metadata client for OpenStack.
 type MetadataClient struct {
 	api  serversAPI
@@ -38,36 +42,9 @@ type MetadataClient struct {
 func New(ctx context.Context) (*MetadataClient, error) {
 	imds := &imdsClient{client: &http.Client{}}
 
-	authURL, err := imds.authURL(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("getting auth URL: %w", err)
-	}
-	username, err := imds.username(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("getting token name: %w", err)
-	}
-	password, err := imds.password(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("getting token password: %w", err)
-	}
-	userDomainName, err := imds.userDomainName(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("getting user domain name: %w", err)
-	}
-	regionName, err := imds.regionName(ctx)
+	clientOpts, err := clientOptsFromIMDS(ctx, imds)
 	if err != nil {
-		return nil, fmt.Errorf("getting region name: %w", err)
-	}
-
-	clientOpts := &clientconfig.ClientOpts{
-		AuthType: clientconfig.AuthV3Password,
-		AuthInfo: &clientconfig.AuthInfo{
-			AuthURL:        authURL,
-			UserDomainName: userDomainName,
-			Username:       username,
-			Password:       password,
-		},
-		RegionName: regionName,
+		return nil, err
 	}
 
 	serversClient, err := clientconfig.NewServiceClient(ctx, "compute", clientOpts)
@@ -91,6 +68,18 @@ func New(ctx context.Context) (*MetadataClient, error) {
 	}, nil
 }
 
+// NewClientOpts returns the options to create clients for the OpenStack API.
+// The credentials are read from the user data of the instance the function is called from.
+func NewClientOpts(ctx context.Context) (*clientconfig.ClientOpts, error) {
+	return clientOptsFromIMDS(ctx, &imdsClient{client: &http.Client{}})
+}
+
+// UserData returns the raw user data of the instance the function is called from.
+// All instances of a cluster share the same user data.
+func UserData(ctx context.Context) ([]byte, error) {
+	return httpGet(ctx, &http.Client{}, imdsUserDataURL)
+}
+
 // Self returns the metadata of the current instance.
 func (c *MetadataClient) Self(ctx context.Context) (metadata.InstanceMetadata, error) {
 	name, err := c.imds.name(ctx)
@@ -105,6 +94,10 @@ func (c *MetadataClient) Self(ctx context.Context) (metadata.InstanceMetadata, e
 	if err != nil {
 		return metadata.InstanceMetadata{}, fmt.Errorf("getting role: %w", err)
 	}
+	nodeGroup, err := c.imds.nodeGroup(ctx)
+	if err != nil {
+		return metadata.InstanceMetadata{}, fmt.Errorf("getting node group: %w", err)
+	}
 	vpcIP, err := c.imds.vpcIP(ctx)
 	if err != nil {
 		return metadata.InstanceMetadata{}, fmt.Errorf("getting vpc ip: %w", err)
@@ -114,6 +107,7 @@ func (c *MetadataClient) Self(ctx context.Context) (metadata.InstanceMetadata, e
 		Name:       name,
 		ProviderID: providerID,
 		Role:       role,
+		NodeGroup:  nodeGroup,
 		VPCIP:      vpcIP,
 	}, nil
 }
@@ -150,10 +144,13 @@ func (c *MetadataClient) List(ctx context.Context) ([]metadata.InstanceMetadata,
 		}
 
 		var serverRole role.Role
+		var nodeGroup string
 		for _, t := range *s.Tags {
-			if strings.HasPrefix(t, "constellation-role-") {
+			switch {
+			case strings.HasPrefix(t, "constellation-role-"):
 				serverRole = role.FromString(strings.TrimPrefix(t, "constellation-role-"))
-				break
+			case strings.HasPrefix(t, "constellation-node-group-"):
+				nodeGroup = strings.TrimPrefix(t, "constellation-node-group-")
 			}
 		}
 		if serverRole == role.Unknown {
@@ -203,6 +200,7 @@ func (c *MetadataClient) List(ctx context.Context) ([]metadata.InstanceMetadata,
 			Name:       s.Name,
 			ProviderID: s.ID,
 			Role:       serverRole,
+			NodeGroup:  nodeGroup,
 			VPCIP:      vpcIP,
 		}
 		result = append(result, im)
@@ -290,6 +288,40 @@ func (c *MetadataClient) getSubnetCIDR(ctx context.Context, uidTag string) (neti
 	return cidr, nil
 }
 
+func clientOptsFromIMDS(ctx context.Context, imds *imdsClient) (*clientconfig.ClientOpts, error) {
+	authURL, err := imds.authURL(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting auth URL: %w", err)
+	}
+	username, err := imds.username(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting token name: %w", err)
+	}
+	password, err := imds.password(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting token password: %w", err)
+	}
+	userDomainName, err := imds.userDomainName(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting user domain name: %w", err)
+	}
+	regionName, err := imds.regionName(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting region name: %w", err)
+	}
+
+	return &clientconfig.ClientOpts{
+		AuthType: clientconfig.AuthV3Password,
+		AuthInfo: &clientconfig.AuthInfo{
+			AuthURL:        authURL,
+			UserDomainName: userDomainName,
+			Username:       username,
+			Password:       password,
+		},
+		RegionName: regionName,
+	}, nil
+}
+
 func (c *MetadataClient) getServers(ctx context.Context, uidTag string) ([]servers.Server, error) {
 	listServersOpts := servers.ListOpts{Tags: uidTag}
 	serversPage, err := c.api.ListServers(listServersOpts).AllPages(ctx)
@@ -34,12 +34,14 @@ func TestSelf(t *testing.T) {
 				nameResult:       "name",
 				providerIDResult: "providerID",
 				roleResult:       role.ControlPlane,
+				nodeGroupResult:  "control_plane_default",
 				vpcIPResult:      "192.0.2.1",
 			},
 			want: metadata.InstanceMetadata{
 				Name:       "name",
 				ProviderID: "providerID",
 				Role:       role.ControlPlane,
+				NodeGroup:  "control_plane_default",
 				VPCIP:      "192.0.2.1",
 			},
 		},
@@ -70,6 +72,16 @@ func TestSelf(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		"fail to get node group": {
+			imds: &stubIMDSClient{
+				nameResult:       "name",
+				providerIDResult: "providerID",
+				roleResult:       role.ControlPlane,
+				nodeGroupErr:     someErr,
+				vpcIPResult:      "192.0.2.1",
+			},
+			wantErr: true,
+		},
 		"fail to get VPC IP": {
 			imds: &stubIMDSClient{
 				nameResult:       "name",
@@ -165,7 +177,7 @@ func TestList(t *testing.T) {
 					{
 						Name:      "name2",
 						ID:        "id2",
-						Tags:      &[]string{"constellation-role-worker", "constellation-uid-7777"},
+						Tags:      &[]string{"constellation-role-worker", "constellation-node-group-worker_default", "constellation-uid-7777"},
 						Addresses: newTestAddrs("192.0.2.6", "192.0.2.99"),
 					},
 					{
@@ -189,6 +201,7 @@ func TestList(t *testing.T) {
 					Name:       "name2",
 					ProviderID: "id2",
 					Role:       role.Worker,
+					NodeGroup:  "worker_default",
 					VPCIP:      "192.0.2.6",
 				},
 			},
@@ -0,0 +1,80 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package qemu
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/edgelesssys/constellation/v2/internal/role"
+)
+
+// ErrInstanceNotFound is returned if an instance or scaling group does not exist.
+var ErrInstanceNotFound = errors.New("instance not found")
+
+// ScalingGroup is a group of QEMU instances sharing the same role and configuration.
+// Scaling groups are managed by the QEMU metadata API.
+type ScalingGroup struct {
+	// ID is the common name prefix of the libvirt domains of the group.
+	ID string `json:"id"`
+	// Role is the role of the group's instances.
+	Role role.Role `json:"role"`
+	// Image is the path of the libvirt volume new instances of the group boot from.
+	Image string `json:"image"`
+}
+
+// Instance is a QEMU instance managed by the QEMU metadata API.
+type Instance struct {
+	// Name is the hostname of the instance, which is also used as the Kubernetes node name.
+	Name string `json:"name"`
+	// ProviderID is the Kubernetes provider ID of the instance.
+	ProviderID string `json:"providerID"`
+	// ScalingGroupID is the ID of the scaling group the instance belongs to.
+	ScalingGroupID string `json:"scalingGroupID"`
+	// Image is the path of the libvirt volume the instance booted from.
+	Image string `json:"image"`
+	// State is the state of the instance's libvirt domain.
+	State InstanceState `json:"state"`
+}
+
+// SetImageRequest is the request body to change the image of a scaling group.
+type SetImageRequest struct {
+	Image string `json:"image"`
+}
+
+// InstanceState is the state of a QEMU instance.
+type InstanceState string
+
+const (
+	// InstanceStateRunning is the state of a running instance.
+	InstanceStateRunning InstanceState = "running"
+	// InstanceStateStopped is the state of an instance that is shut off or paused.
+	InstanceStateStopped InstanceState = "stopped"
+	// InstanceStateShuttingDown is the state of an instance that is being shut down.
+	InstanceStateShuttingDown InstanceState = "shutting-down"
+	// InstanceStateCrashed is the state of a crashed instance.
+	InstanceStateCrashed InstanceState = "crashed"
+	// InstanceStateUnknown is the state of an instance whose state can not be determined.
+	InstanceStateUnknown InstanceState = "unknown"
+)
+
+// ProviderID returns the Kubernetes provider ID of the instance with the given hostname.
+func ProviderID(hostname string) string {
+	return providerIDPrefix + hostname
+}
+
+// HostnameFromProviderID returns the hostname of the instance with the given provider ID.
+func HostnameFromProviderID(providerID string) (string, error) {
+	hostname, ok := strings.CutPrefix(providerID, providerIDPrefix)
+	if !ok || hostname == "" || strings.Contains(hostname, "/") {
+		return "", fmt.Errorf("invalid QEMU provider ID %q", providerID)
+	}
+	return hostname, nil
+}
+
+const providerIDPrefix = "qemu:///hostname/"
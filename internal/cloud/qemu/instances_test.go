@@ -0,0 +1,55 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package qemu
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHostnameFromProviderID(t *testing.T) {
+	testCases := map[string]struct {
+		providerID   string
+		wantHostname string
+		wantErr      bool
+	}{
+		"valid provider ID": {
+			providerID:   "qemu:///hostname/worker-0",
+			wantHostname: "worker-0",
+		},
+		"other provider": {
+			providerID: "aws:///us-east-2a/i-06888991e7138ed4e",
+			wantErr:    true,
+		},
+		"missing hostname": {
+			providerID: "qemu:///hostname/",
+			wantErr:    true,
+		},
+		"nested path": {
+			providerID: "qemu:///hostname/worker/0",
+			wantErr:    true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			hostname, err := HostnameFromProviderID(tc.providerID)
+			if tc.wantErr {
+				assert.Error(err)
+				return
+			}
+			require.NoError(err)
+			assert.Equal(tc.wantHostname, hostname)
+			assert.Equal(tc.providerID, ProviderID(hostname))
+		})
+	}
+}
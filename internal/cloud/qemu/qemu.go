@@ -22,7 +22,12 @@ import (
 	"github.com/edgelesssys/constellation/v2/internal/constants"
 )
 
-const qemuMetadataEndpoint = "10.42.0.1:8080"
+const (
+	// MetadataEndpoint is the address of the QEMU metadata API, as seen from the instances.
+	MetadataEndpoint = "10.42.0.1:8080"
+	// HostMetadataEndpoint is the address of the QEMU metadata API, as seen from the host running the cluster.
+	HostMetadataEndpoint = "127.0.0.1:8080"
+)
 
 // Cloud provides an interface to fake a CSP API for QEMU instances.
 type Cloud struct{}
@@ -95,7 +100,7 @@ func (c *Cloud) UID(_ context.Context) (string, error) {
 func (c *Cloud) retrieveMetadata(ctx context.Context, uri string) ([]byte, error) {
 	url := &url.URL{
 		Scheme: "http",
-		Host:   qemuMetadataEndpoint,
+		Host:   MetadataEndpoint,
 		Path:   uri,
 	}
 
@@ -11,9 +11,12 @@ import (
 	"crypto/x509"
 	"encoding/json"
 	"encoding/pem"
+	"errors"
 	"fmt"
+	"slices"
 
 	"github.com/edgelesssys/constellation/v2/internal/attestation/measurements"
+	"github.com/edgelesssys/constellation/v2/internal/attestation/policy"
 	"github.com/edgelesssys/constellation/v2/internal/attestation/variant"
 )
 
@@ -30,8 +33,14 @@ type AttestationCfg interface {
 	GetMeasurements() measurements.M
 	// SetMeasurements updates a config's measurements using the given measurements.
 	SetMeasurements(m measurements.M)
+	// GetAcceptedMeasurements returns the additional measurement sets that are accepted next to the measurements.
+	GetAcceptedMeasurements() []measurements.M
+	// SetAcceptedMeasurements updates a config's additional accepted measurement sets.
+	SetAcceptedMeasurements(m []measurements.M)
 	// GetVariant returns the variant of the attestation config.
 	GetVariant() variant.Variant
+	// GetPolicy returns the additional policy rules attestation statements must satisfy.
+	GetPolicy() []PolicyRule
 	// EqualTo returns true if the config is equal to the given config.
 	// If the variant differs, an error must be returned.
 	EqualTo(AttestationCfg) (bool, error)
@@ -54,8 +63,12 @@ func UnmarshalAttestationConfig(data []byte, attestVariant variant.Variant) (Att
 		return unmarshalTypedConfig[*GCPSEVES](data)
 	case variant.GCPSEVSNP{}:
 		return unmarshalTypedConfig[*GCPSEVSNP](data)
+	case variant.GCPTDX{}:
+		return unmarshalTypedConfig[*GCPTDX](data)
 	case variant.QEMUVTPM{}:
 		return unmarshalTypedConfig[*QEMUVTPM](data)
+	case variant.QEMUSEVSNP{}:
+		return unmarshalTypedConfig[*QEMUSEVSNP](data)
 	case variant.QEMUTDX{}:
 		return unmarshalTypedConfig[*QEMUTDX](data)
 	case variant.Dummy{}:
@@ -65,6 +78,27 @@ func UnmarshalAttestationConfig(data []byte, attestVariant variant.Variant) (Att
 	}
 }
 
+// MeasurementSets returns all measurement sets accepted by the given config.
+// The config's measurements are always the first set.
+func MeasurementSets(cfg AttestationCfg) []measurements.M {
+	return append([]measurements.M{cfg.GetMeasurements()}, cfg.GetAcceptedMeasurements()...)
+}
+
+// CompilePolicy compiles the attestation policy rules of the given config.
+func CompilePolicy(cfg AttestationCfg) (*policy.Policy, error) {
+	rules := make([]policy.Rule, 0, len(cfg.GetPolicy()))
+	for _, rule := range cfg.GetPolicy() {
+		rules = append(rules, policy.Rule{Name: rule.Name, Expression: rule.Expression})
+	}
+	return policy.Compile(rules)
+}
+
+func measurementSetsEqual(a, b []measurements.M) bool {
+	return slices.EqualFunc(a, b, func(x, y measurements.M) bool {
+		return x.EqualTo(y)
+	})
+}
+
 func unmarshalTypedConfig[T AttestationCfg](data []byte) (AttestationCfg, error) {
 	var cfg T
 	if err := json.Unmarshal(data, &cfg); err != nil {
@@ -131,6 +165,72 @@ func (c *Certificate) unmarshal(unmarshalFunc func(any) error) error {
 	return nil
 }
 
+// CRL is a wrapper around x509.RevocationList allowing custom marshaling.
+type CRL x509.RevocationList
+
+// Equal returns true if the embedded Raw values are equal.
+func (c CRL) Equal(other CRL) bool {
+	return bytes.Equal(c.Raw, other.Raw)
+}
+
+// IsEmpty returns true if no CRL is set.
+func (c CRL) IsEmpty() bool {
+	return len(c.Raw) == 0
+}
+
+// MarshalJSON marshals the CRL to PEM.
+func (c CRL) MarshalJSON() ([]byte, error) {
+	if len(c.Raw) == 0 {
+		return json.Marshal(new(string))
+	}
+	pem := pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: c.Raw})
+	return json.Marshal(string(pem))
+}
+
+// MarshalYAML marshals the CRL to PEM.
+func (c CRL) MarshalYAML() (any, error) {
+	if len(c.Raw) == 0 {
+		return "", nil
+	}
+	pem := pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: c.Raw})
+	return string(pem), nil
+}
+
+// UnmarshalJSON unmarshals the CRL from PEM.
+func (c *CRL) UnmarshalJSON(data []byte) error {
+	if len(data) == 0 {
+		return nil
+	}
+	return c.unmarshal(func(val any) error {
+		return json.Unmarshal(data, val)
+	})
+}
+
+// UnmarshalYAML unmarshals the CRL from PEM.
+func (c *CRL) UnmarshalYAML(unmarshal func(any) error) error {
+	return c.unmarshal(unmarshal)
+}
+
+func (c *CRL) unmarshal(unmarshalFunc func(any) error) error {
+	var pemData string
+	if err := unmarshalFunc(&pemData); err != nil {
+		return err
+	}
+	if pemData == "" {
+		return nil
+	}
+	block, _ := pem.Decode([]byte(pemData))
+	if block == nil {
+		return errors.New("no PEM block found in CRL")
+	}
+	crl, err := x509.ParseRevocationList(block.Bytes)
+	if err != nil {
+		return err
+	}
+	*c = CRL(*crl)
+	return nil
+}
+
 func mustParsePEM(data string) Certificate {
 	jsonData := fmt.Sprintf("\"%s\"", data)
 	var cert Certificate
@@ -140,6 +240,12 @@ func mustParsePEM(data string) Certificate {
 	return cert
 }
 
+// defaultSNPGuestPolicy returns the guest policy used for all SEV-SNP variants.
+// SMT is allowed, since not all CSPs support disabling it.
+func defaultSNPGuestPolicy() *SNPGuestPolicy {
+	return &SNPGuestPolicy{AllowSMT: true}
+}
+
 // DummyCfg is a placeholder for unknown attestation configs.
 type DummyCfg struct {
 	// description: |
@@ -157,11 +263,24 @@ func (DummyCfg) GetVariant() variant.Variant {
 	return variant.Dummy{}
 }
 
+// GetPolicy returns no policy rules.
+func (DummyCfg) GetPolicy() []PolicyRule {
+	return nil
+}
+
 // SetMeasurements sets the configs measurements.
 func (c *DummyCfg) SetMeasurements(m measurements.M) {
 	c.Measurements = m
 }
 
+// GetAcceptedMeasurements returns no additional measurement sets.
+func (DummyCfg) GetAcceptedMeasurements() []measurements.M {
+	return nil
+}
+
+// SetAcceptedMeasurements is a no-op for the dummy config.
+func (*DummyCfg) SetAcceptedMeasurements([]measurements.M) {}
+
 // EqualTo returns true if measurements of the configs are equal.
 func (c DummyCfg) EqualTo(other AttestationCfg) (bool, error) {
 	return c.Measurements.EqualTo(other.GetMeasurements()), nil
@@ -7,10 +7,12 @@ SPDX-License-Identifier: BUSL-1.1
 package config
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"testing"
 
+	"github.com/edgelesssys/constellation/v2/internal/attestation/idkeydigest"
 	"github.com/edgelesssys/constellation/v2/internal/attestation/measurements"
 	"github.com/edgelesssys/constellation/v2/internal/attestation/variant"
 	"github.com/edgelesssys/constellation/v2/internal/cloud/cloudprovider"
@@ -47,6 +49,17 @@ func TestUnmarshalAttestationConfig(t *testing.T) {
 		"QEMUVTPM": {
 			cfg: &QEMUVTPM{Measurements: measurements.DefaultsFor(cloudprovider.QEMU, variant.QEMUVTPM{})},
 		},
+		"QEMUSEVSNP": {
+			cfg: DefaultForQEMUSEVSNP(),
+		},
+		"QEMUSEVSNP with host data and ID key digests": {
+			cfg: func() AttestationCfg {
+				cfg := DefaultForQEMUSEVSNP()
+				cfg.HostData = bytes.Repeat([]byte{0x01}, 32)
+				cfg.IDKeyDigests = idkeydigest.List{bytes.Repeat([]byte{0x02}, 48)}
+				return cfg
+			}(),
+		},
 		"QEMUTDX": {
 			cfg: &QEMUTDX{Measurements: measurements.DefaultsFor(cloudprovider.QEMU, variant.QEMUTDX{})},
 		},
@@ -9,6 +9,7 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"slices"
 
 	"github.com/edgelesssys/constellation/v2/internal/api/attestationconfigapi"
 	"github.com/edgelesssys/constellation/v2/internal/attestation/measurements"
@@ -26,6 +27,7 @@ func DefaultForAWSSEVSNP() *AWSSEVSNP {
 		TEEVersion:        NewLatestPlaceholderVersion[uint8](),
 		SNPVersion:        NewLatestPlaceholderVersion[uint8](),
 		MicrocodeVersion:  NewLatestPlaceholderVersion[uint8](),
+		GuestPolicy:       defaultSNPGuestPolicy(),
 		AMDRootKey:        mustParsePEM(arkPEM),
 	}
 }
@@ -35,6 +37,11 @@ func (AWSSEVSNP) GetVariant() variant.Variant {
 	return variant.AWSSEVSNP{}
 }
 
+// GetPolicy returns the attestation policy rules of the config.
+func (c AWSSEVSNP) GetPolicy() []PolicyRule {
+	return c.Policy
+}
+
 // GetMeasurements returns the measurements used for attestation.
 func (c AWSSEVSNP) GetMeasurements() measurements.M {
 	return c.Measurements
@@ -45,6 +52,16 @@ func (c *AWSSEVSNP) SetMeasurements(m measurements.M) {
 	c.Measurements = m
 }
 
+// GetAcceptedMeasurements returns the additional measurement sets that are accepted for attestation.
+func (c AWSSEVSNP) GetAcceptedMeasurements() []measurements.M {
+	return c.AcceptedMeasurements
+}
+
+// SetAcceptedMeasurements updates the additional measurement sets that are accepted for attestation.
+func (c *AWSSEVSNP) SetAcceptedMeasurements(m []measurements.M) {
+	c.AcceptedMeasurements = m
+}
+
 // EqualTo returns true if the config is equal to the given config.
 func (c AWSSEVSNP) EqualTo(other AttestationCfg) (bool, error) {
 	otherCfg, ok := other.(*AWSSEVSNP)
@@ -52,15 +69,19 @@ func (c AWSSEVSNP) EqualTo(other AttestationCfg) (bool, error) {
 		return false, fmt.Errorf("cannot compare %T with %T", c, other)
 	}
 
-	measurementsEqual := c.Measurements.EqualTo(otherCfg.Measurements)
+	measurementsEqual := c.Measurements.EqualTo(otherCfg.Measurements) && measurementSetsEqual(c.AcceptedMeasurements, otherCfg.AcceptedMeasurements)
 	bootloaderEqual := c.BootloaderVersion == otherCfg.BootloaderVersion
 	teeEqual := c.TEEVersion == otherCfg.TEEVersion
 	snpEqual := c.SNPVersion == otherCfg.SNPVersion
 	microcodeEqual := c.MicrocodeVersion == otherCfg.MicrocodeVersion
 	rootKeyEqual := bytes.Equal(c.AMDRootKey.Raw, otherCfg.AMDRootKey.Raw)
 	signingKeyEqual := bytes.Equal(c.AMDSigningKey.Raw, otherCfg.AMDSigningKey.Raw)
+	guestPolicyEqual := c.GuestPolicy.EqualTo(otherCfg.GuestPolicy)
+	platformInfoEqual := c.PlatformInfo.EqualTo(otherCfg.PlatformInfo)
+	revocationsEqual := c.CheckRevocations == otherCfg.CheckRevocations && c.AMDCRL.Equal(otherCfg.AMDCRL)
+	policyEqual := slices.Equal(c.Policy, otherCfg.Policy)
 
-	return measurementsEqual && bootloaderEqual && teeEqual && snpEqual && microcodeEqual && rootKeyEqual && signingKeyEqual, nil
+	return measurementsEqual && bootloaderEqual && teeEqual && snpEqual && microcodeEqual && rootKeyEqual && signingKeyEqual && guestPolicyEqual && platformInfoEqual && revocationsEqual && policyEqual, nil
 }
 
 func (c *AWSSEVSNP) getToMarshallLatestWithResolvedVersions() AttestationCfg {
@@ -108,6 +129,11 @@ func (AWSNitroTPM) GetVariant() variant.Variant {
 	return variant.AWSNitroTPM{}
 }
 
+// GetPolicy returns the attestation policy rules of the config.
+func (c AWSNitroTPM) GetPolicy() []PolicyRule {
+	return c.Policy
+}
+
 // GetMeasurements returns the measurements used for attestation.
 func (c AWSNitroTPM) GetMeasurements() measurements.M {
 	return c.Measurements
@@ -118,11 +144,23 @@ func (c *AWSNitroTPM) SetMeasurements(m measurements.M) {
 	c.Measurements = m
 }
 
+// GetAcceptedMeasurements returns the additional measurement sets that are accepted for attestation.
+func (c AWSNitroTPM) GetAcceptedMeasurements() []measurements.M {
+	return c.AcceptedMeasurements
+}
+
+// SetAcceptedMeasurements updates the additional measurement sets that are accepted for attestation.
+func (c *AWSNitroTPM) SetAcceptedMeasurements(m []measurements.M) {
+	c.AcceptedMeasurements = m
+}
+
 // EqualTo returns true if the config is equal to the given config.
 func (c AWSNitroTPM) EqualTo(other AttestationCfg) (bool, error) {
 	otherCfg, ok := other.(*AWSNitroTPM)
 	if !ok {
 		return false, fmt.Errorf("cannot compare %T with %T", c, other)
 	}
-	return c.Measurements.EqualTo(otherCfg.Measurements), nil
+	return c.Measurements.EqualTo(otherCfg.Measurements) &&
+		measurementSetsEqual(c.AcceptedMeasurements, otherCfg.AcceptedMeasurements) &&
+		slices.Equal(c.Policy, otherCfg.Policy), nil
 }
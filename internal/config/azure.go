@@ -9,6 +9,7 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"slices"
 
 	"github.com/edgelesssys/constellation/v2/internal/api/attestationconfigapi"
 	"github.com/edgelesssys/constellation/v2/internal/attestation/idkeydigest"
@@ -32,6 +33,7 @@ func DefaultForAzureSEVSNP() *AzureSEVSNP {
 		TEEVersion:        NewLatestPlaceholderVersion[uint8](),
 		SNPVersion:        NewLatestPlaceholderVersion[uint8](),
 		MicrocodeVersion:  NewLatestPlaceholderVersion[uint8](),
+		GuestPolicy:       defaultSNPGuestPolicy(),
 		FirmwareSignerConfig: SNPFirmwareSignerConfig{
 			AcceptedKeyDigests: idkeydigest.DefaultList(),
 			EnforcementPolicy:  idkeydigest.MAAFallback,
@@ -45,6 +47,11 @@ func (AzureSEVSNP) GetVariant() variant.Variant {
 	return variant.AzureSEVSNP{}
 }
 
+// GetPolicy returns the attestation policy rules of the config.
+func (c AzureSEVSNP) GetPolicy() []PolicyRule {
+	return c.Policy
+}
+
 // GetMeasurements returns the measurements used for attestation.
 func (c AzureSEVSNP) GetMeasurements() measurements.M {
 	return c.Measurements
@@ -55,6 +62,16 @@ func (c *AzureSEVSNP) SetMeasurements(m measurements.M) {
 	c.Measurements = m
 }
 
+// GetAcceptedMeasurements returns the additional measurement sets that are accepted for attestation.
+func (c AzureSEVSNP) GetAcceptedMeasurements() []measurements.M {
+	return c.AcceptedMeasurements
+}
+
+// SetAcceptedMeasurements updates the additional measurement sets that are accepted for attestation.
+func (c *AzureSEVSNP) SetAcceptedMeasurements(m []measurements.M) {
+	c.AcceptedMeasurements = m
+}
+
 // EqualTo returns true if the config is equal to the given config.
 func (c AzureSEVSNP) EqualTo(old AttestationCfg) (bool, error) {
 	otherCfg, ok := old.(*AzureSEVSNP)
@@ -63,14 +80,18 @@ func (c AzureSEVSNP) EqualTo(old AttestationCfg) (bool, error) {
 	}
 
 	firmwareSignerCfgEqual := c.FirmwareSignerConfig.EqualTo(otherCfg.FirmwareSignerConfig)
-	measurementsEqual := c.Measurements.EqualTo(otherCfg.Measurements)
+	measurementsEqual := c.Measurements.EqualTo(otherCfg.Measurements) && measurementSetsEqual(c.AcceptedMeasurements, otherCfg.AcceptedMeasurements)
 	bootloaderEqual := c.BootloaderVersion == otherCfg.BootloaderVersion
 	teeEqual := c.TEEVersion == otherCfg.TEEVersion
 	snpEqual := c.SNPVersion == otherCfg.SNPVersion
 	microcodeEqual := c.MicrocodeVersion == otherCfg.MicrocodeVersion
 	rootKeyEqual := bytes.Equal(c.AMDRootKey.Raw, otherCfg.AMDRootKey.Raw)
+	guestPolicyEqual := c.GuestPolicy.EqualTo(otherCfg.GuestPolicy)
+	platformInfoEqual := c.PlatformInfo.EqualTo(otherCfg.PlatformInfo)
+	revocationsEqual := c.CheckRevocations == otherCfg.CheckRevocations && c.AMDCRL.Equal(otherCfg.AMDCRL)
+	policyEqual := slices.Equal(c.Policy, otherCfg.Policy)
 
-	return firmwareSignerCfgEqual && measurementsEqual && bootloaderEqual && teeEqual && snpEqual && microcodeEqual && rootKeyEqual, nil
+	return firmwareSignerCfgEqual && measurementsEqual && bootloaderEqual && teeEqual && snpEqual && microcodeEqual && rootKeyEqual && guestPolicyEqual && platformInfoEqual && revocationsEqual && policyEqual, nil
 }
 
 // FetchAndSetLatestVersionNumbers fetches the latest version numbers from the configapi and sets them.
@@ -118,6 +139,11 @@ func (AzureTrustedLaunch) GetVariant() variant.Variant {
 	return variant.AzureTrustedLaunch{}
 }
 
+// GetPolicy returns the attestation policy rules of the config.
+func (c AzureTrustedLaunch) GetPolicy() []PolicyRule {
+	return c.Policy
+}
+
 // GetMeasurements returns the measurements used for attestation.
 func (c AzureTrustedLaunch) GetMeasurements() measurements.M {
 	return c.Measurements
@@ -128,13 +154,25 @@ func (c *AzureTrustedLaunch) SetMeasurements(m measurements.M) {
 	c.Measurements = m
 }
 
+// GetAcceptedMeasurements returns the additional measurement sets that are accepted for attestation.
+func (c AzureTrustedLaunch) GetAcceptedMeasurements() []measurements.M {
+	return c.AcceptedMeasurements
+}
+
+// SetAcceptedMeasurements updates the additional measurement sets that are accepted for attestation.
+func (c *AzureTrustedLaunch) SetAcceptedMeasurements(m []measurements.M) {
+	c.AcceptedMeasurements = m
+}
+
 // EqualTo returns true if the config is equal to the given config.
 func (c AzureTrustedLaunch) EqualTo(other AttestationCfg) (bool, error) {
 	otherCfg, ok := other.(*AzureTrustedLaunch)
 	if !ok {
 		return false, fmt.Errorf("cannot compare %T with %T", c, other)
 	}
-	return c.Measurements.EqualTo(otherCfg.Measurements), nil
+	return c.Measurements.EqualTo(otherCfg.Measurements) &&
+		measurementSetsEqual(c.AcceptedMeasurements, otherCfg.AcceptedMeasurements) &&
+		slices.Equal(c.Policy, otherCfg.Policy), nil
 }
 
 // DefaultForAzureTDX returns the default configuration for Azure TDX attestation.
@@ -160,6 +198,11 @@ func (AzureTDX) GetVariant() variant.Variant {
 	return variant.AzureTDX{}
 }
 
+// GetPolicy returns the attestation policy rules of the config.
+func (c AzureTDX) GetPolicy() []PolicyRule {
+	return c.Policy
+}
+
 // GetMeasurements returns the measurements used for attestation.
 func (c AzureTDX) GetMeasurements() measurements.M {
 	return c.Measurements
@@ -170,13 +213,25 @@ func (c *AzureTDX) SetMeasurements(m measurements.M) {
 	c.Measurements = m
 }
 
+// GetAcceptedMeasurements returns the additional measurement sets that are accepted for attestation.
+func (c AzureTDX) GetAcceptedMeasurements() []measurements.M {
+	return c.AcceptedMeasurements
+}
+
+// SetAcceptedMeasurements updates the additional measurement sets that are accepted for attestation.
+func (c *AzureTDX) SetAcceptedMeasurements(m []measurements.M) {
+	c.AcceptedMeasurements = m
+}
+
 // EqualTo returns true if the config is equal to the given config.
 func (c AzureTDX) EqualTo(other AttestationCfg) (bool, error) {
 	otherCfg, ok := other.(*AzureTDX)
 	if !ok {
 		return false, fmt.Errorf("cannot compare %T with %T", c, other)
 	}
-	return c.Measurements.EqualTo(otherCfg.Measurements), nil
+	return c.Measurements.EqualTo(otherCfg.Measurements) &&
+		measurementSetsEqual(c.AcceptedMeasurements, otherCfg.AcceptedMeasurements) &&
+		slices.Equal(c.Policy, otherCfg.Policy), nil
 }
 
 // FetchAndSetLatestVersionNumbers fetches the latest version numbers from the configapi and sets them.
@@ -25,6 +25,7 @@ import (
 	"io/fs"
 	"os"
 	"reflect"
+	"slices"
 	"strings"
 
 	"github.com/go-playground/locales/en"
@@ -43,6 +44,7 @@ import (
 	"github.com/edgelesssys/constellation/v2/internal/constants"
 	"github.com/edgelesssys/constellation/v2/internal/encoding"
 	"github.com/edgelesssys/constellation/v2/internal/file"
+	"github.com/edgelesssys/constellation/v2/internal/nodegroup"
 	"github.com/edgelesssys/constellation/v2/internal/semver"
 	"github.com/edgelesssys/constellation/v2/internal/versions"
 )
@@ -100,6 +102,12 @@ type Config struct {
 	// description: |
 	//   Configuration for attestation validation. This configuration provides sensible defaults for the Constellation version it was created for.\nSee the docs for an overview on attestation: https://docs.edgeless.systems/constellation/architecture/attestation
 	Attestation AttestationConfig `yaml:"attestation"`
+	// description: |
+	//   Optional external key management service used to protect the keys of the cluster. If unset, all keys are derived from the cluster's master secret.
+	KMS *KMSConfig `yaml:"kms,omitempty" validate:"omitempty"`
+	// description: |
+	//   Authorization mode of key derivations by the KeyService. "enforce" denies derivations that aren't allowed by a KeyAccessPolicy, "audit" only logs them. Defaults to "enforce". Use "audit" only temporarily to migrate callers that don't authenticate yet.
+	KeyServiceAuthorization string `yaml:"keyServiceAuthorization,omitempty" validate:"omitempty,oneof=audit enforce"`
 }
 
 // ProviderConfig are cloud-provider specific configuration values used by the CLI.
@@ -287,6 +295,12 @@ type AttestationConfig struct {
 	//   GCP SEV-SNP attestation.
 	GCPSEVSNP *GCPSEVSNP `yaml:"gcpSEVSNP,omitempty" validate:"omitempty"`
 	// description: |
+	//   GCP TDX attestation.
+	GCPTDX *GCPTDX `yaml:"gcpTDX,omitempty" validate:"omitempty"`
+	// description: |
+	//   QEMU SEV-SNP attestation for bare-metal SEV-SNP hosts without a vTPM.
+	QEMUSEVSNP *QEMUSEVSNP `yaml:"qemuSEVSNP,omitempty" validate:"omitempty"`
+	// description: |
 	//   QEMU tdx attestation.
 	QEMUTDX *QEMUTDX `yaml:"qemuTDX,omitempty" validate:"omitempty"`
 	// description: |
@@ -315,6 +329,85 @@ type NodeGroup struct {
 	// description: |
 	//   Number of nodes to be initially created.
 	InitialCount int `yaml:"initialCount" validate:"min=0"`
+	// description: |
+	//   Kubernetes labels added to the nodes of this group. Keys in the kubernetes.io and k8s.io namespaces are only allowed under kubelet.kubernetes.io and node.kubernetes.io.
+	Labels map[string]string `yaml:"labels,omitempty" validate:"node_labels"`
+	// description: |
+	//   Kubernetes taints added to the nodes of this group.
+	Taints []NodeTaint `yaml:"taints,omitempty" validate:"dive"`
+	// description: |
+	//   Kubelet settings of the nodes in this group.
+	Kubelet KubeletConfig `yaml:"kubelet,omitempty"`
+}
+
+// NodeTaint is a Kubernetes taint added to the nodes of a node group.
+type NodeTaint struct {
+	// description: |
+	//   Key of the taint.
+	Key string `yaml:"key" validate:"required"`
+	// description: |
+	//   Value of the taint.
+	Value string `yaml:"value,omitempty"`
+	// description: |
+	//   Effect of the taint. Valid values are "NoSchedule", "PreferNoSchedule" and "NoExecute".
+	Effect string `yaml:"effect" validate:"required,oneof=NoSchedule PreferNoSchedule NoExecute"`
+}
+
+// KubeletConfig configures the kubelet of the nodes in a node group.
+type KubeletConfig struct {
+	// description: |
+	//   Maximum number of pods that can run on a node. Zero keeps the Kubernetes default.
+	MaxPods int32 `yaml:"maxPods,omitempty" validate:"min=0"`
+	// description: |
+	//   Resources reserved for system daemons, e.g. cpu: 500m. Valid resources are cpu, memory, ephemeral-storage and pid.
+	SystemReserved map[string]string `yaml:"systemReserved,omitempty" validate:"reserved_resources"`
+	// description: |
+	//   Resources reserved for Kubernetes system daemons, e.g. memory: 1Gi. Valid resources are cpu, memory, ephemeral-storage and pid.
+	KubeReserved map[string]string `yaml:"kubeReserved,omitempty" validate:"reserved_resources"`
+}
+
+// Settings returns the Kubernetes node settings of the node group.
+func (n NodeGroup) Settings() *nodegroup.Settings {
+	settings := &nodegroup.Settings{
+		Role:                  n.Role,
+		Labels:                n.Labels,
+		KubeletMaxPods:        n.Kubelet.MaxPods,
+		KubeletSystemReserved: n.Kubelet.SystemReserved,
+		KubeletKubeReserved:   n.Kubelet.KubeReserved,
+	}
+	for _, taint := range n.Taints {
+		settings.Taints = append(settings.Taints, &nodegroup.Taint{Key: taint.Key, Value: taint.Value, Effect: taint.Effect})
+	}
+	return settings
+}
+
+// KMSConfig configures an external key management service.
+type KMSConfig struct {
+	// description: |
+	//   Key management server or HSM speaking the Key Management Interoperability Protocol (KMIP).
+	KMIP *KMIPConfig `yaml:"kmip" validate:"required"`
+}
+
+// KMIPConfig configures a KMIP server as external KMS.
+type KMIPConfig struct {
+	// description: |
+	//   Address of the KMIP server in the form host:port.
+	Endpoint string `yaml:"endpoint" validate:"required,hostname_port"`
+	// description: |
+	//   Name of the AES key on the KMIP server used to wrap the cluster's data encryption keys.
+	KeyName string `yaml:"keyName" validate:"required"`
+	// description: |
+	//   Path to the PEM encoded CA certificate used to verify the KMIP server.
+	CACertPath string `yaml:"caCertPath" validate:"required"`
+	// description: |
+	//   Path to the PEM encoded client certificate used to authenticate to the KMIP server.
+	ClientCertPath string `yaml:"clientCertPath" validate:"required"`
+	// description: |
+	//   Path to the PEM encoded private key of the client certificate.
+	ClientKeyPath string `yaml:"clientKeyPath" validate:"required"`
+	// description: |
+	//   URI of the storage backend for wrapped data encryption keys, e.g. "storage://vault?...". See the KMS documentation for supported backends.
+	StorageURI string `yaml:"storageURI" validate:"required,startswith=storage://"`
 }
 
 // Default returns a struct with the default config.
@@ -402,7 +495,9 @@ func Default() *Config {
 			AzureTrustedLaunch: &AzureTrustedLaunch{Measurements: measurements.DefaultsFor(cloudprovider.Azure, variant.AzureTrustedLaunch{})},
 			GCPSEVES:           &GCPSEVES{Measurements: measurements.DefaultsFor(cloudprovider.GCP, variant.GCPSEVES{})},
 			GCPSEVSNP:          DefaultForGCPSEVSNP(),
+			GCPTDX:             DefaultForGCPTDX(),
 			QEMUVTPM:           &QEMUVTPM{Measurements: measurements.DefaultsFor(cloudprovider.QEMU, variant.QEMUVTPM{})},
+			QEMUSEVSNP:         DefaultForQEMUSEVSNP(),
 		},
 	}
 }
@@ -493,6 +588,11 @@ func New(fileHandler file.Handler, name string, fetcher attestationconfigapi.Fet
 			return c, err
 		}
 	}
+	if gcp := c.Attestation.GCPTDX; gcp != nil {
+		if err := gcp.FetchAndSetLatestVersionNumbers(context.Background(), fetcher); err != nil {
+			return c, err
+		}
+	}
 
 	// Read secrets from env-vars.
 	clientSecretValue := os.Getenv(constants.EnvVarAzureClientSecretValue)
@@ -543,9 +643,66 @@ func (c *Config) UpdateMeasurements(newMeasurements measurements.M) {
 	if c.Attestation.GCPSEVSNP != nil {
 		c.Attestation.GCPSEVSNP.Measurements.CopyFrom(newMeasurements)
 	}
+	if c.Attestation.GCPTDX != nil {
+		c.Attestation.GCPTDX.Measurements.CopyFrom(newMeasurements)
+	}
 	if c.Attestation.QEMUVTPM != nil {
 		c.Attestation.QEMUVTPM.Measurements.CopyFrom(newMeasurements)
 	}
+	if c.Attestation.QEMUSEVSNP != nil {
+		c.Attestation.QEMUSEVSNP.Measurements.CopyFrom(newMeasurements)
+	}
+}
+
+// AppendMeasurements overwrites measurements in config with the provided ones,
+// but keeps the previous measurements as an accepted measurement set.
+// This allows nodes running either the previous or the new image to attest, e.g., during an image upgrade.
+func (c *Config) AppendMeasurements(newMeasurements measurements.M) {
+	if c.Attestation.AWSSEVSNP != nil {
+		appendMeasurements(c.Attestation.AWSSEVSNP, newMeasurements)
+	}
+	if c.Attestation.AWSNitroTPM != nil {
+		appendMeasurements(c.Attestation.AWSNitroTPM, newMeasurements)
+	}
+	if c.Attestation.AzureSEVSNP != nil {
+		appendMeasurements(c.Attestation.AzureSEVSNP, newMeasurements)
+	}
+	if c.Attestation.AzureTDX != nil {
+		appendMeasurements(c.Attestation.AzureTDX, newMeasurements)
+	}
+	if c.Attestation.AzureTrustedLaunch != nil {
+		appendMeasurements(c.Attestation.AzureTrustedLaunch, newMeasurements)
+	}
+	if c.Attestation.GCPSEVES != nil {
+		appendMeasurements(c.Attestation.GCPSEVES, newMeasurements)
+	}
+	if c.Attestation.GCPSEVSNP != nil {
+		appendMeasurements(c.Attestation.GCPSEVSNP, newMeasurements)
+	}
+	if c.Attestation.GCPTDX != nil {
+		appendMeasurements(c.Attestation.GCPTDX, newMeasurements)
+	}
+	if c.Attestation.QEMUVTPM != nil {
+		appendMeasurements(c.Attestation.QEMUVTPM, newMeasurements)
+	}
+	if c.Attestation.QEMUSEVSNP != nil {
+		appendMeasurements(c.Attestation.QEMUSEVSNP, newMeasurements)
+	}
+	if c.Attestation.QEMUTDX != nil {
+		appendMeasurements(c.Attestation.QEMUTDX, newMeasurements)
+	}
+}
+
+func appendMeasurements(cfg AttestationCfg, newMeasurements measurements.M) {
+	current := cfg.GetMeasurements()
+	previous := current.Copy()
+	current.CopyFrom(newMeasurements)
+
+	accepted := slices.DeleteFunc(cfg.GetAcceptedMeasurements(), current.EqualTo)
+	if len(previous) > 0 && !previous.EqualTo(current) {
+		accepted = measurements.AppendUnique(accepted, previous)
+	}
+	cfg.SetAcceptedMeasurements(accepted)
 }
 
 // RemoveProviderAndAttestationExcept calls RemoveProviderExcept and sets the default attestations for the provider (only used for convenience in tests).
@@ -597,8 +754,12 @@ func (c *Config) SetAttestation(attestation variant.Variant) {
 		c.Attestation = AttestationConfig{GCPSEVES: currentAttestationConfigs.GCPSEVES}
 	case variant.GCPSEVSNP:
 		c.Attestation = AttestationConfig{GCPSEVSNP: currentAttestationConfigs.GCPSEVSNP}
+	case variant.GCPTDX:
+		c.Attestation = AttestationConfig{GCPTDX: currentAttestationConfigs.GCPTDX}
 	case variant.QEMUVTPM:
 		c.Attestation = AttestationConfig{QEMUVTPM: currentAttestationConfigs.QEMUVTPM}
+	case variant.QEMUSEVSNP:
+		c.Attestation = AttestationConfig{QEMUSEVSNP: currentAttestationConfigs.QEMUSEVSNP}
 	}
 }
 
@@ -667,9 +828,15 @@ func (c *Config) GetAttestationConfig() AttestationCfg {
 	if c.Attestation.GCPSEVSNP != nil {
 		return c.Attestation.GCPSEVSNP
 	}
+	if c.Attestation.GCPTDX != nil {
+		return c.Attestation.GCPTDX.getToMarshallLatestWithResolvedVersions()
+	}
 	if c.Attestation.QEMUVTPM != nil {
 		return c.Attestation.QEMUVTPM
 	}
+	if c.Attestation.QEMUSEVSNP != nil {
+		return c.Attestation.QEMUSEVSNP
+	}
 	return &DummyCfg{}
 }
 
@@ -703,6 +870,15 @@ func (c *Config) GetZone() string {
 	return ""
 }
 
+// NodeGroupSettings returns the Kubernetes node settings of all node groups.
+func (c *Config) NodeGroupSettings() nodegroup.Config {
+	settings := nodegroup.Config{}
+	for name, group := range c.NodeGroups {
+		settings[name] = group.Settings()
+	}
+	return settings
+}
+
 // UpdateMAAURL updates the MAA URL in the config.
 func (c *Config) UpdateMAAURL(maaURL string) {
 	if c.Attestation.AzureSEVSNP != nil {
@@ -839,6 +1015,19 @@ func (c *Config) Validate(force bool) error {
 	// Register NodeGroup validation
 	validate.RegisterStructValidation(validateNodeGroups, Config{})
 
+	if err := validate.RegisterValidation("node_labels", validateNodeLabelsField); err != nil {
+		return err
+	}
+	if err := validate.RegisterTranslation("node_labels", trans, registerNodeLabelsError, translateNodeLabelsError); err != nil {
+		return err
+	}
+	if err := validate.RegisterValidation("reserved_resources", validateReservedResourcesField); err != nil {
+		return err
+	}
+	if err := validate.RegisterTranslation("reserved_resources", trans, registerReservedResourcesError, translateReservedResourcesError); err != nil {
+		return err
+	}
+
 	// Register Attestation validation error types
 	if err := validate.RegisterTranslation("no_attestation", trans, registerNoAttestationError, translateNoAttestationError); err != nil {
 		return err
@@ -866,6 +1055,13 @@ func (c *Config) Validate(force bool) error {
 		return err
 	}
 
+	if err := validate.RegisterValidation("attestation_policy", validateAttestationPolicy); err != nil {
+		return err
+	}
+	if err := validate.RegisterTranslation("attestation_policy", trans, registerAttestationPolicyError, translateAttestationPolicyError); err != nil {
+		return err
+	}
+
 	validate.RegisterStructValidation(validateMeasurement, measurements.Measurement{})
 	validate.RegisterStructValidation(validateAttestation, AttestationConfig{})
 
@@ -948,7 +1144,12 @@ func (c *Config) SetCSPNodeGroupDefaults(csp cloudprovider.Provider) {
 		}
 		stateDiskType = "Premium_LRS"
 	case cloudprovider.GCP:
-		instanceType = "n2d-standard-4"
+		// Check attestation variant, and use different default instance type if we have TDX
+		if c.GetAttestationConfig().GetVariant().Equal(variant.GCPTDX{}) {
+			instanceType = "c3-standard-4"
+		} else {
+			instanceType = "n2d-standard-4"
+		}
 		stateDiskType = "pd-ssd"
 		zone = c.Provider.GCP.Zone
 	case cloudprovider.QEMU, cloudprovider.OpenStack:
@@ -987,11 +1188,78 @@ func (c SNPFirmwareSignerConfig) EqualTo(other SNPFirmwareSignerConfig) bool {
 	return c.AcceptedKeyDigests.EqualTo(other.AcceptedKeyDigests) && c.EnforcementPolicy == other.EnforcementPolicy && c.MAAURL == other.MAAURL
 }
 
+// SNPGuestPolicy is the configuration for validating the guest policy of an SEV-SNP attestation report.
+type SNPGuestPolicy struct {
+	// description: |
+	//   Accept guests that can be debugged by the host. Debugging allows the host to decrypt the guest's memory and must not be allowed for production clusters.
+	AllowDebug bool `json:"allowDebug" yaml:"allowDebug"`
+	// description: |
+	//   Accept guests that can be associated with a migration agent.
+	AllowMigrationAgent bool `json:"allowMigrationAgent" yaml:"allowMigrationAgent"`
+	// description: |
+	//   Accept guests that allow simultaneous multithreading (SMT).
+	AllowSMT bool `json:"allowSMT" yaml:"allowSMT"`
+	// description: |
+	//   Require guests to be restricted to a single socket.
+	RequireSingleSocket bool `json:"requireSingleSocket" yaml:"requireSingleSocket"`
+	// description: |
+	//   Lowest acceptable major version of the SEV-SNP firmware ABI required by the guest.
+	MinimumABIMajor uint8 `json:"minimumABIMajor" yaml:"minimumABIMajor"`
+	// description: |
+	//   Lowest acceptable minor version of the SEV-SNP firmware ABI required by the guest. Only compared if the major versions are equal.
+	MinimumABIMinor uint8 `json:"minimumABIMinor" yaml:"minimumABIMinor"`
+}
+
+// EqualTo returns true if the policy is equal to the given policy.
+func (p *SNPGuestPolicy) EqualTo(other *SNPGuestPolicy) bool {
+	if p == nil || other == nil {
+		return p == other
+	}
+	return *p == *other
+}
+
+// SNPPlatformInfo is the configuration for validating the platform info of an SEV-SNP attestation report.
+type SNPPlatformInfo struct {
+	// description: |
+	//   Accept platforms with simultaneous multithreading (SMT) enabled.
+	AllowSMT bool `json:"allowSMT" yaml:"allowSMT"`
+	// description: |
+	//   Accept platforms with transparent secure memory encryption (TSME) enabled.
+	AllowTSME bool `json:"allowTSME" yaml:"allowTSME"`
+	// description: |
+	//   Require platforms to use error correcting codes (ECC) for memory.
+	RequireECC bool `json:"requireECC" yaml:"requireECC"`
+}
+
+// EqualTo returns true if the platform info is equal to the given platform info.
+func (p *SNPPlatformInfo) EqualTo(other *SNPPlatformInfo) bool {
+	if p == nil || other == nil {
+		return p == other
+	}
+	return *p == *other
+}
+
+// PolicyRule is an additional rule for attestation statements.
+type PolicyRule struct {
+	// description: |
+	//   Name of the rule. Used in error messages.
+	Name string `json:"name" yaml:"name" validate:"required"`
+	// description: |
+	//   CEL expression the normalized attestation claims must satisfy. It must evaluate to true for the attestation to succeed.
+	Expression string `json:"expression" yaml:"expression" validate:"required,attestation_policy"`
+}
+
 // GCPSEVES is the configuration for GCP SEV-ES attestation.
 type GCPSEVES struct {
 	// description: |
 	//   Expected TPM measurements.
 	Measurements measurements.M `json:"measurements" yaml:"measurements" validate:"required,no_placeholders"`
+	// description: |
+	//   Additional sets of expected measurements. An attestation statement is accepted if it matches the measurements above or any of these sets, e.g., the measurements of the previous image during an upgrade.
+	AcceptedMeasurements []measurements.M `json:"acceptedMeasurements,omitempty" yaml:"acceptedMeasurements,omitempty" validate:"dive,min=1,no_placeholders"`
+	// description: |
+	//   Additional rules the attestation statement must satisfy. Each rule is a CEL expression over the normalized attestation claims.
+	Policy []PolicyRule `json:"policy,omitempty" yaml:"policy,omitempty" validate:"dive"`
 }
 
 // GCPSEVSNP is the configuration for GCP SEV-SNP attestation.
@@ -1000,6 +1268,9 @@ type GCPSEVSNP struct {
 	//   Expected TPM measurements.
 	Measurements measurements.M `json:"measurements" yaml:"measurements" validate:"required,no_placeholders"`
 	// description: |
+	//   Additional sets of expected measurements. An attestation statement is accepted if it matches the measurements above or any of these sets, e.g., the measurements of the previous image during an upgrade.
+	AcceptedMeasurements []measurements.M `json:"acceptedMeasurements,omitempty" yaml:"acceptedMeasurements,omitempty" validate:"dive,min=1,no_placeholders"`
+	// description: |
 	//   Lowest acceptable bootloader version.
 	BootloaderVersion AttestationVersion[uint8] `json:"bootloaderVersion" yaml:"bootloaderVersion"`
 	// description: |
@@ -1012,11 +1283,26 @@ type GCPSEVSNP struct {
 	//   Lowest acceptable microcode version.
 	MicrocodeVersion AttestationVersion[uint8] `json:"microcodeVersion" yaml:"microcodeVersion"`
 	// description: |
+	//   Guest policy the SEV-SNP attestation report must adhere to. If not set, guests that can be debugged or have a migration agent are rejected.
+	GuestPolicy *SNPGuestPolicy `json:"guestPolicy,omitempty" yaml:"guestPolicy,omitempty"`
+	// description: |
+	//   Platform features the SEV-SNP attestation report must adhere to. If not set, the platform info isn't checked.
+	PlatformInfo *SNPPlatformInfo `json:"platformInfo,omitempty" yaml:"platformInfo,omitempty"`
+	// description: |
+	//   Check that the VCEK / VLEK and the AMD Signing Key weren't revoked by AMD. The certificate revocation list (CRL) is fetched from the AMD Key Distribution Service, unless 'amdCRL' is set.
+	CheckRevocations bool `json:"checkRevocations" yaml:"checkRevocations"`
+	// description: |
+	//   Pinned AMD certificate revocation list (CRL). If set, revocations are checked against this CRL instead of fetching it from the AMD Key Distribution Service, which allows checking revocations without network access. Keep it up to date: a pinned CRL past its next update is still used, but only causes a warning.
+	AMDCRL CRL `json:"amdCRL,omitempty" yaml:"amdCRL,omitempty"`
+	// description: |
 	//   AMD Root Key certificate used to verify the SEV-SNP certificate chain.
 	AMDRootKey Certificate `json:"amdRootKey" yaml:"amdRootKey"`
 	// description: |
 	//   AMD Signing Key certificate used to verify the SEV-SNP VCEK / VLEK certificate.
 	AMDSigningKey Certificate `json:"amdSigningKey,omitempty" yaml:"amdSigningKey,omitempty"`
+	// description: |
+	//   Additional rules the attestation statement must satisfy. Each rule is a CEL expression over the normalized attestation claims.
+	Policy []PolicyRule `json:"policy,omitempty" yaml:"policy,omitempty" validate:"dive"`
 }
 
 // QEMUVTPM is the configuration for QEMU vTPM attestation.
@@ -1024,6 +1310,12 @@ type QEMUVTPM struct {
 	// description: |
 	//   Expected TPM measurements.
 	Measurements measurements.M `json:"measurements" yaml:"measurements" validate:"required,no_placeholders"`
+	// description: |
+	//   Additional sets of expected measurements. An attestation statement is accepted if it matches the measurements above or any of these sets, e.g., the measurements of the previous image during an upgrade.
+	AcceptedMeasurements []measurements.M `json:"acceptedMeasurements,omitempty" yaml:"acceptedMeasurements,omitempty" validate:"dive,min=1,no_placeholders"`
+	// description: |
+	//   Additional rules the attestation statement must satisfy. Each rule is a CEL expression over the normalized attestation claims.
+	Policy []PolicyRule `json:"policy,omitempty" yaml:"policy,omitempty" validate:"dive"`
 }
 
 // GetVariant returns qemu-vtpm as the variant.
@@ -1031,6 +1323,11 @@ func (QEMUVTPM) GetVariant() variant.Variant {
 	return variant.QEMUVTPM{}
 }
 
+// GetPolicy returns the attestation policy rules of the config.
+func (c QEMUVTPM) GetPolicy() []PolicyRule {
+	return c.Policy
+}
+
 // GetMeasurements returns the measurements used for attestation.
 func (c QEMUVTPM) GetMeasurements() measurements.M {
 	return c.Measurements
@@ -1041,13 +1338,75 @@ func (c *QEMUVTPM) SetMeasurements(m measurements.M) {
 	c.Measurements = m
 }
 
+// GetAcceptedMeasurements returns the additional measurement sets that are accepted for attestation.
+func (c QEMUVTPM) GetAcceptedMeasurements() []measurements.M {
+	return c.AcceptedMeasurements
+}
+
+// SetAcceptedMeasurements updates the additional measurement sets that are accepted for attestation.
+func (c *QEMUVTPM) SetAcceptedMeasurements(m []measurements.M) {
+	c.AcceptedMeasurements = m
+}
+
 // EqualTo returns true if the config is equal to the given config.
 func (c QEMUVTPM) EqualTo(other AttestationCfg) (bool, error) {
 	otherCfg, ok := other.(*QEMUVTPM)
 	if !ok {
 		return false, fmt.Errorf("cannot compare %T with %T", c, other)
 	}
-	return c.Measurements.EqualTo(otherCfg.Measurements), nil
+	return c.Measurements.EqualTo(otherCfg.Measurements) &&
+		measurementSetsEqual(c.AcceptedMeasurements, otherCfg.AcceptedMeasurements) &&
+		slices.Equal(c.Policy, otherCfg.Policy), nil
+}
+
+// QEMUSEVSNP is the configuration for SEV-SNP attestation of QEMU guests on bare-metal hosts.
+// The SEV-SNP attestation report is used directly, without a vTPM.
+type QEMUSEVSNP struct {
+	// description: |
+	//   Expected SEV-SNP launch measurement. Index 0 holds the launch digest.
+	Measurements measurements.M `json:"measurements" yaml:"measurements" validate:"required,no_placeholders"`
+	// description: |
+	//   Additional sets of expected measurements. An attestation statement is accepted if it matches the measurements above or any of these sets, e.g., the measurements of the previous image during an upgrade.
+	AcceptedMeasurements []measurements.M `json:"acceptedMeasurements,omitempty" yaml:"acceptedMeasurements,omitempty" validate:"dive,min=1,no_placeholders"`
+	// description: |
+	//   Lowest acceptable bootloader version.
+	BootloaderVersion uint8 `json:"bootloaderVersion" yaml:"bootloaderVersion"`
+	// description: |
+	//   Lowest acceptable TEE version.
+	TEEVersion uint8 `json:"teeVersion" yaml:"teeVersion"`
+	// description: |
+	//   Lowest acceptable SEV-SNP version.
+	SNPVersion uint8 `json:"snpVersion" yaml:"snpVersion"`
+	// description: |
+	//   Lowest acceptable microcode version.
+	MicrocodeVersion uint8 `json:"microcodeVersion" yaml:"microcodeVersion"`
+	// description: |
+	//   Expected host data of the SEV-SNP attestation report (32 bytes, hex encoded). The host data is set by the host when launching the guest. If not set, the host data isn't checked.
+	HostData encoding.HexBytes `json:"hostData,omitempty" yaml:"hostData,omitempty" validate:"omitempty,len=32"`
+	// description: |
+	//   Accepted digests of the key that signed the ID block the guest was launched with. If set, guests without an ID block signed by one of these keys are rejected.
+	IDKeyDigests idkeydigest.List `json:"idKeyDigests,omitempty" yaml:"idKeyDigests,omitempty"`
+	// description: |
+	//   Guest policy the SEV-SNP attestation report must adhere to. If not set, guests that can be debugged or have a migration agent are rejected.
+	GuestPolicy *SNPGuestPolicy `json:"guestPolicy,omitempty" yaml:"guestPolicy,omitempty"`
+	// description: |
+	//   Platform features the SEV-SNP attestation report must adhere to. If not set, the platform info isn't checked.
+	PlatformInfo *SNPPlatformInfo `json:"platformInfo,omitempty" yaml:"platformInfo,omitempty"`
+	// description: |
+	//   Check that the VCEK / VLEK and the AMD Signing Key weren't revoked by AMD. The certificate revocation list (CRL) is fetched from the AMD Key Distribution Service, unless 'amdCRL' is set.
+	CheckRevocations bool `json:"checkRevocations" yaml:"checkRevocations"`
+	// description: |
+	//   Pinned AMD certificate revocation list (CRL). If set, revocations are checked against this CRL instead of fetching it from the AMD Key Distribution Service, which allows checking revocations without network access. Keep it up to date: a pinned CRL past its next update is still used, but only causes a warning.
+	AMDCRL CRL `json:"amdCRL,omitempty" yaml:"amdCRL,omitempty"`
+	// description: |
+	//   AMD Root Key certificate used to verify the SEV-SNP certificate chain.
+	AMDRootKey Certificate `json:"amdRootKey" yaml:"amdRootKey"`
+	// description: |
+	//   AMD Signing Key certificate used to verify the SEV-SNP VCEK / VLEK certificate.
+	AMDSigningKey Certificate `json:"amdSigningKey,omitempty" yaml:"amdSigningKey,omitempty"`
+	// description: |
+	//   Additional rules the attestation statement must satisfy. Each rule is a CEL expression over the normalized attestation claims.
+	Policy []PolicyRule `json:"policy,omitempty" yaml:"policy,omitempty" validate:"dive"`
 }
 
 // QEMUTDX is the configuration for QEMU TDX attestation.
@@ -1055,6 +1414,12 @@ type QEMUTDX struct {
 	// description: |
 	//   Expected TDX measurements.
 	Measurements measurements.M `json:"measurements" yaml:"measurements" validate:"required,no_placeholders"`
+	// description: |
+	//   Additional sets of expected measurements. An attestation statement is accepted if it matches the measurements above or any of these sets, e.g., the measurements of the previous image during an upgrade.
+	AcceptedMeasurements []measurements.M `json:"acceptedMeasurements,omitempty" yaml:"acceptedMeasurements,omitempty" validate:"dive,min=1,no_placeholders"`
+	// description: |
+	//   Additional rules the attestation statement must satisfy. Each rule is a CEL expression over the normalized attestation claims.
+	Policy []PolicyRule `json:"policy,omitempty" yaml:"policy,omitempty" validate:"dive"`
 }
 
 // GetVariant returns qemu-tdx as the variant.
@@ -1062,6 +1427,11 @@ func (QEMUTDX) GetVariant() variant.Variant {
 	return variant.QEMUTDX{}
 }
 
+// GetPolicy returns the attestation policy rules of the config.
+func (c QEMUTDX) GetPolicy() []PolicyRule {
+	return c.Policy
+}
+
 // GetMeasurements returns the measurements used for attestation.
 func (c QEMUTDX) GetMeasurements() measurements.M {
 	return c.Measurements
@@ -1072,13 +1442,25 @@ func (c *QEMUTDX) SetMeasurements(m measurements.M) {
 	c.Measurements = m
 }
 
+// GetAcceptedMeasurements returns the additional measurement sets that are accepted for attestation.
+func (c QEMUTDX) GetAcceptedMeasurements() []measurements.M {
+	return c.AcceptedMeasurements
+}
+
+// SetAcceptedMeasurements updates the additional measurement sets that are accepted for attestation.
+func (c *QEMUTDX) SetAcceptedMeasurements(m []measurements.M) {
+	c.AcceptedMeasurements = m
+}
+
 // EqualTo returns true if the config is equal to the given config.
 func (c QEMUTDX) EqualTo(other AttestationCfg) (bool, error) {
 	otherCfg, ok := other.(*QEMUTDX)
 	if !ok {
 		return false, fmt.Errorf("cannot compare %T with %T", c, other)
 	}
-	return c.Measurements.EqualTo(otherCfg.Measurements), nil
+	return c.Measurements.EqualTo(otherCfg.Measurements) &&
+		measurementSetsEqual(c.AcceptedMeasurements, otherCfg.AcceptedMeasurements) &&
+		slices.Equal(c.Policy, otherCfg.Policy), nil
 }
 
 // AWSSEVSNP is the configuration for AWS SEV-SNP attestation.
@@ -1087,6 +1469,9 @@ type AWSSEVSNP struct {
 	//   Expected TPM measurements.
 	Measurements measurements.M `json:"measurements" yaml:"measurements" validate:"required,no_placeholders"`
 	// description: |
+	//   Additional sets of expected measurements. An attestation statement is accepted if it matches the measurements above or any of these sets, e.g., the measurements of the previous image during an upgrade.
+	AcceptedMeasurements []measurements.M `json:"acceptedMeasurements,omitempty" yaml:"acceptedMeasurements,omitempty" validate:"dive,min=1,no_placeholders"`
+	// description: |
 	//   Lowest acceptable bootloader version.
 	BootloaderVersion AttestationVersion[uint8] `json:"bootloaderVersion" yaml:"bootloaderVersion"`
 	// description: |
@@ -1099,11 +1484,26 @@ type AWSSEVSNP struct {
 	//   Lowest acceptable microcode version.
 	MicrocodeVersion AttestationVersion[uint8] `json:"microcodeVersion" yaml:"microcodeVersion"`
 	// description: |
+	//   Guest policy the SEV-SNP attestation report must adhere to. If not set, guests that can be debugged or have a migration agent are rejected.
+	GuestPolicy *SNPGuestPolicy `json:"guestPolicy,omitempty" yaml:"guestPolicy,omitempty"`
+	// description: |
+	//   Platform features the SEV-SNP attestation report must adhere to. If not set, the platform info isn't checked.
+	PlatformInfo *SNPPlatformInfo `json:"platformInfo,omitempty" yaml:"platformInfo,omitempty"`
+	// description: |
+	//   Check that the VCEK / VLEK and the AMD Signing Key weren't revoked by AMD. The certificate revocation list (CRL) is fetched from the AMD Key Distribution Service, unless 'amdCRL' is set.
+	CheckRevocations bool `json:"checkRevocations" yaml:"checkRevocations"`
+	// description: |
+	//   Pinned AMD certificate revocation list (CRL). If set, revocations are checked against this CRL instead of fetching it from the AMD Key Distribution Service, which allows checking revocations without network access. Keep it up to date: a pinned CRL past its next update is still used, but only causes a warning.
+	AMDCRL CRL `json:"amdCRL,omitempty" yaml:"amdCRL,omitempty"`
+	// description: |
 	//   AMD Root Key certificate used to verify the SEV-SNP certificate chain.
 	AMDRootKey Certificate `json:"amdRootKey" yaml:"amdRootKey"`
 	// description: |
 	//   AMD Signing Key certificate used to verify the SEV-SNP VCEK / VLEK certificate.
 	AMDSigningKey Certificate `json:"amdSigningKey,omitempty" yaml:"amdSigningKey,omitempty"`
+	// description: |
+	//   Additional rules the attestation statement must satisfy. Each rule is a CEL expression over the normalized attestation claims.
+	Policy []PolicyRule `json:"policy,omitempty" yaml:"policy,omitempty" validate:"dive"`
 }
 
 // AWSNitroTPM is the configuration for AWS Nitro TPM attestation.
@@ -1111,6 +1511,12 @@ type AWSNitroTPM struct {
 	// description: |
 	//   Expected TPM measurements.
 	Measurements measurements.M `json:"measurements" yaml:"measurements" validate:"required,no_placeholders"`
+	// description: |
+	//   Additional sets of expected measurements. An attestation statement is accepted if it matches the measurements above or any of these sets, e.g., the measurements of the previous image during an upgrade.
+	AcceptedMeasurements []measurements.M `json:"acceptedMeasurements,omitempty" yaml:"acceptedMeasurements,omitempty" validate:"dive,min=1,no_placeholders"`
+	// description: |
+	//   Additional rules the attestation statement must satisfy. Each rule is a CEL expression over the normalized attestation claims.
+	Policy []PolicyRule `json:"policy,omitempty" yaml:"policy,omitempty" validate:"dive"`
 }
 
 // AzureSEVSNP is the configuration for Azure SEV-SNP attestation.
@@ -1119,6 +1525,9 @@ type AzureSEVSNP struct {
 	//   Expected TPM measurements.
 	Measurements measurements.M `json:"measurements" yaml:"measurements" validate:"required,no_placeholders"`
 	// description: |
+	//   Additional sets of expected measurements. An attestation statement is accepted if it matches the measurements above or any of these sets, e.g., the measurements of the previous image during an upgrade.
+	AcceptedMeasurements []measurements.M `json:"acceptedMeasurements,omitempty" yaml:"acceptedMeasurements,omitempty" validate:"dive,min=1,no_placeholders"`
+	// description: |
 	//   Lowest acceptable bootloader version.
 	BootloaderVersion AttestationVersion[uint8] `json:"bootloaderVersion" yaml:"bootloaderVersion"`
 	// description: |
@@ -1134,11 +1543,60 @@ type AzureSEVSNP struct {
 	//   Configuration for validating the firmware signature.
 	FirmwareSignerConfig SNPFirmwareSignerConfig `json:"firmwareSignerConfig" yaml:"firmwareSignerConfig"`
 	// description: |
+	//   Guest policy the SEV-SNP attestation report must adhere to. If not set, guests that can be debugged or have a migration agent are rejected.
+	GuestPolicy *SNPGuestPolicy `json:"guestPolicy,omitempty" yaml:"guestPolicy,omitempty"`
+	// description: |
+	//   Platform features the SEV-SNP attestation report must adhere to. If not set, the platform info isn't checked.
+	PlatformInfo *SNPPlatformInfo `json:"platformInfo,omitempty" yaml:"platformInfo,omitempty"`
+	// description: |
+	//   Check that the VCEK / VLEK and the AMD Signing Key weren't revoked by AMD. The certificate revocation list (CRL) is fetched from the AMD Key Distribution Service, unless 'amdCRL' is set.
+	CheckRevocations bool `json:"checkRevocations" yaml:"checkRevocations"`
+	// description: |
+	//   Pinned AMD certificate revocation list (CRL). If set, revocations are checked against this CRL instead of fetching it from the AMD Key Distribution Service, which allows checking revocations without network access. Keep it up to date: a pinned CRL past its next update is still used, but only causes a warning.
+	AMDCRL CRL `json:"amdCRL,omitempty" yaml:"amdCRL,omitempty"`
+	// description: |
 	//   AMD Root Key certificate used to verify the SEV-SNP certificate chain.
 	AMDRootKey Certificate `json:"amdRootKey" yaml:"amdRootKey"`
 	// description: |
 	//   AMD Signing Key certificate used to verify the SEV-SNP VCEK / VLEK certificate.
 	AMDSigningKey Certificate `json:"amdSigningKey,omitempty" yaml:"amdSigningKey,omitempty"`
+	// description: |
+	//   Additional rules the attestation statement must satisfy. Each rule is a CEL expression over the normalized attestation claims.
+	Policy []PolicyRule `json:"policy,omitempty" yaml:"policy,omitempty" validate:"dive"`
+}
+
+// GCPTDX is the configuration for GCP TDX attestation.
+type GCPTDX struct {
+	// description: |
+	//   Expected TPM measurements.
+	Measurements measurements.M `json:"measurements" yaml:"measurements" validate:"required,no_placeholders"`
+	// description: |
+	//   Additional sets of expected measurements. An attestation statement is accepted if it matches the measurements above or any of these sets, e.g., the measurements of the previous image during an upgrade.
+	AcceptedMeasurements []measurements.M `json:"acceptedMeasurements,omitempty" yaml:"acceptedMeasurements,omitempty" validate:"dive,min=1,no_placeholders"`
+	// description: |
+	//   Minimum required QE security version number (SVN).
+	QESVN AttestationVersion[uint16] `json:"qeSVN" yaml:"qeSVN"`
+	// description: |
+	//   Minimum required PCE security version number (SVN).
+	PCESVN AttestationVersion[uint16] `json:"pceSVN" yaml:"pceSVN"`
+	// description: |
+	//   Component-wise minimum required 16 byte hex-encoded TEE_TCB security version number (SVN).
+	TEETCBSVN AttestationVersion[encoding.HexBytes] `json:"teeTCBSVN" yaml:"teeTCBSVN"`
+	// description: |
+	//   Expected 16 byte hex-encoded QE_VENDOR_ID field.
+	QEVendorID AttestationVersion[encoding.HexBytes] `json:"qeVendorID" yaml:"qeVendorID"`
+	// description: |
+	//   Expected 48 byte hex-encoded MR_SEAM value.
+	MRSeam encoding.HexBytes `json:"mrSeam,omitempty" yaml:"mrSeam,omitempty"`
+	// description: |
+	//   Expected 8 byte hex-encoded eXtended Features Available Mask (XFAM) field. Defaults to the latest available XFAM on GCP VMs. Unset to disable validation.
+	XFAM AttestationVersion[encoding.HexBytes] `json:"xfam" yaml:"xfam"`
+	// description: |
+	//   Intel Root Key certificate used to verify the TDX certificate chain.
+	IntelRootKey Certificate `json:"intelRootKey" yaml:"intelRootKey"`
+	// description: |
+	//   Additional rules the attestation statement must satisfy. Each rule is a CEL expression over the normalized attestation claims.
+	Policy []PolicyRule `json:"policy,omitempty" yaml:"policy,omitempty" validate:"dive"`
 }
 
 // AzureTrustedLaunch is the configuration for Azure Trusted Launch attestation.
@@ -1146,6 +1604,12 @@ type AzureTrustedLaunch struct {
 	// description: |
 	//   Expected TPM measurements.
 	Measurements measurements.M `json:"measurements" yaml:"measurements" validate:"required,no_placeholders"`
+	// description: |
+	//   Additional sets of expected measurements. An attestation statement is accepted if it matches the measurements above or any of these sets, e.g., the measurements of the previous image during an upgrade.
+	AcceptedMeasurements []measurements.M `json:"acceptedMeasurements,omitempty" yaml:"acceptedMeasurements,omitempty" validate:"dive,min=1,no_placeholders"`
+	// description: |
+	//   Additional rules the attestation statement must satisfy. Each rule is a CEL expression over the normalized attestation claims.
+	Policy []PolicyRule `json:"policy,omitempty" yaml:"policy,omitempty" validate:"dive"`
 }
 
 // AzureTDX is the configuration for Azure TDX attestation.
@@ -1154,6 +1618,9 @@ type AzureTDX struct {
 	//   Expected TPM measurements.
 	Measurements measurements.M `json:"measurements" yaml:"measurements" validate:"required,no_placeholders"`
 	// description: |
+	//   Additional sets of expected measurements. An attestation statement is accepted if it matches the measurements above or any of these sets, e.g., the measurements of the previous image during an upgrade.
+	AcceptedMeasurements []measurements.M `json:"acceptedMeasurements,omitempty" yaml:"acceptedMeasurements,omitempty" validate:"dive,min=1,no_placeholders"`
+	// description: |
 	//   Minimum required QE security version number (SVN).
 	QESVN AttestationVersion[uint16] `json:"qeSVN" yaml:"qeSVN"`
 	// description: |
@@ -1174,6 +1641,9 @@ type AzureTDX struct {
 	// description: |
 	//   Intel Root Key certificate used to verify the TDX certificate chain.
 	IntelRootKey Certificate `json:"intelRootKey" yaml:"intelRootKey"`
+	// description: |
+	//   Additional rules the attestation statement must satisfy. Each rule is a CEL expression over the normalized attestation claims.
+	Policy []PolicyRule `json:"policy,omitempty" yaml:"policy,omitempty" validate:"dive"`
 }
 
 func toPtr[T any](v T) *T {
@@ -20,15 +20,24 @@ var (
 	QEMUConfigDoc                      encoder.Doc
 	AttestationConfigDoc               encoder.Doc
 	NodeGroupDoc                       encoder.Doc
+	NodeTaintDoc                       encoder.Doc
+	KubeletConfigDoc                   encoder.Doc
+	KMSConfigDoc                       encoder.Doc
+	KMIPConfigDoc                      encoder.Doc
 	UnsupportedAppRegistrationErrorDoc encoder.Doc
 	SNPFirmwareSignerConfigDoc         encoder.Doc
+	SNPGuestPolicyDoc                  encoder.Doc
+	SNPPlatformInfoDoc                 encoder.Doc
+	PolicyRuleDoc                      encoder.Doc
 	GCPSEVESDoc                        encoder.Doc
 	GCPSEVSNPDoc                       encoder.Doc
 	QEMUVTPMDoc                        encoder.Doc
+	QEMUSEVSNPDoc                      encoder.Doc
 	QEMUTDXDoc                         encoder.Doc
 	AWSSEVSNPDoc                       encoder.Doc
 	AWSNitroTPMDoc                     encoder.Doc
 	AzureSEVSNPDoc                     encoder.Doc
+	GCPTDXDoc                          encoder.Doc
 	AzureTrustedLaunchDoc              encoder.Doc
 	AzureTDXDoc                        encoder.Doc
 )
@@ -37,7 +46,7 @@ func init() {
 	ConfigDoc.Type = "Config"
 	ConfigDoc.Comments[encoder.LineComment] = "Config defines configuration used by CLI."
 	ConfigDoc.Description = "Config defines configuration used by CLI."
-	ConfigDoc.Fields = make([]encoder.Doc, 13)
+	ConfigDoc.Fields = make([]encoder.Doc, 15)
 	ConfigDoc.Fields[0].Name = "version"
 	ConfigDoc.Fields[0].Type = "string"
 	ConfigDoc.Fields[0].Note = ""
@@ -103,6 +112,16 @@ func init() {
 	ConfigDoc.Fields[12].Note = ""
 	ConfigDoc.Fields[12].Description = "Configuration for attestation validation. This configuration provides sensible defaults for the Constellation version it was created for.\nSee the docs for an overview on attestation: https://docs.edgeless.systems/constellation/architecture/attestation"
 	ConfigDoc.Fields[12].Comments[encoder.LineComment] = "Configuration for attestation validation. This configuration provides sensible defaults for the Constellation version it was created for.\nSee the docs for an overview on attestation: https://docs.edgeless.systems/constellation/architecture/attestation"
+	ConfigDoc.Fields[13].Name = "kms"
+	ConfigDoc.Fields[13].Type = "KMSConfig"
+	ConfigDoc.Fields[13].Note = ""
+	ConfigDoc.Fields[13].Description = "Optional external key management service used to protect the keys of the cluster. If unset, all keys are derived from the cluster's master secret."
+	ConfigDoc.Fields[13].Comments[encoder.LineComment] = "Optional external key management service used to protect the keys of the cluster. If unset, all keys are derived from the cluster's master secret."
+	ConfigDoc.Fields[14].Name = "keyServiceAuthorization"
+	ConfigDoc.Fields[14].Type = "string"
+	ConfigDoc.Fields[14].Note = ""
+	ConfigDoc.Fields[14].Description = "Authorization mode of key derivations by the KeyService. \"enforce\" denies derivations that aren't allowed by a KeyAccessPolicy, \"audit\" only logs them. Defaults to \"enforce\". Use \"audit\" only temporarily to migrate callers that don't authenticate yet."
+	ConfigDoc.Fields[14].Comments[encoder.LineComment] = "Authorization mode of key derivations by the KeyService. \"enforce\" denies derivations that aren't allowed by a KeyAccessPolicy, \"audit\" only logs them. Defaults to \"enforce\". Use \"audit\" only temporarily to migrate callers that don't authenticate yet."
 
 	ProviderConfigDoc.Type = "ProviderConfig"
 	ProviderConfigDoc.Comments[encoder.LineComment] = "ProviderConfig are cloud-provider specific configuration values used by the CLI."
@@ -399,7 +418,7 @@ func init() {
 			FieldName: "attestation",
 		},
 	}
-	AttestationConfigDoc.Fields = make([]encoder.Doc, 9)
+	AttestationConfigDoc.Fields = make([]encoder.Doc, 11)
 	AttestationConfigDoc.Fields[0].Name = "awsSEVSNP"
 	AttestationConfigDoc.Fields[0].Type = "AWSSEVSNP"
 	AttestationConfigDoc.Fields[0].Note = ""
@@ -435,16 +454,26 @@ func init() {
 	AttestationConfigDoc.Fields[6].Note = ""
 	AttestationConfigDoc.Fields[6].Description = "GCP SEV-SNP attestation."
 	AttestationConfigDoc.Fields[6].Comments[encoder.LineComment] = "GCP SEV-SNP attestation."
-	AttestationConfigDoc.Fields[7].Name = "qemuTDX"
-	AttestationConfigDoc.Fields[7].Type = "QEMUTDX"
+	AttestationConfigDoc.Fields[7].Name = "gcpTDX"
+	AttestationConfigDoc.Fields[7].Type = "GCPTDX"
 	AttestationConfigDoc.Fields[7].Note = ""
-	AttestationConfigDoc.Fields[7].Description = "QEMU tdx attestation."
-	AttestationConfigDoc.Fields[7].Comments[encoder.LineComment] = "QEMU tdx attestation."
-	AttestationConfigDoc.Fields[8].Name = "qemuVTPM"
-	AttestationConfigDoc.Fields[8].Type = "QEMUVTPM"
+	AttestationConfigDoc.Fields[7].Description = "GCP TDX attestation."
+	AttestationConfigDoc.Fields[7].Comments[encoder.LineComment] = "GCP TDX attestation."
+	AttestationConfigDoc.Fields[8].Name = "qemuSEVSNP"
+	AttestationConfigDoc.Fields[8].Type = "QEMUSEVSNP"
 	AttestationConfigDoc.Fields[8].Note = ""
-	AttestationConfigDoc.Fields[8].Description = "QEMU vTPM attestation."
-	AttestationConfigDoc.Fields[8].Comments[encoder.LineComment] = "QEMU vTPM attestation."
+	AttestationConfigDoc.Fields[8].Description = "QEMU SEV-SNP attestation for bare-metal SEV-SNP hosts without a vTPM."
+	AttestationConfigDoc.Fields[8].Comments[encoder.LineComment] = "QEMU SEV-SNP attestation for bare-metal SEV-SNP hosts without a vTPM."
+	AttestationConfigDoc.Fields[9].Name = "qemuTDX"
+	AttestationConfigDoc.Fields[9].Type = "QEMUTDX"
+	AttestationConfigDoc.Fields[9].Note = ""
+	AttestationConfigDoc.Fields[9].Description = "QEMU tdx attestation."
+	AttestationConfigDoc.Fields[9].Comments[encoder.LineComment] = "QEMU tdx attestation."
+	AttestationConfigDoc.Fields[10].Name = "qemuVTPM"
+	AttestationConfigDoc.Fields[10].Type = "QEMUVTPM"
+	AttestationConfigDoc.Fields[10].Note = ""
+	AttestationConfigDoc.Fields[10].Description = "QEMU vTPM attestation."
+	AttestationConfigDoc.Fields[10].Comments[encoder.LineComment] = "QEMU vTPM attestation."
 
 	NodeGroupDoc.Type = "NodeGroup"
 	NodeGroupDoc.Comments[encoder.LineComment] = "NodeGroup defines a group of nodes with the same role and configuration."
@@ -455,7 +484,7 @@ func init() {
 			FieldName: "nodeGroups",
 		},
 	}
-	NodeGroupDoc.Fields = make([]encoder.Doc, 6)
+	NodeGroupDoc.Fields = make([]encoder.Doc, 9)
 	NodeGroupDoc.Fields[0].Name = "role"
 	NodeGroupDoc.Fields[0].Type = "string"
 	NodeGroupDoc.Fields[0].Note = ""
@@ -486,6 +515,130 @@ func init() {
 	NodeGroupDoc.Fields[5].Note = ""
 	NodeGroupDoc.Fields[5].Description = "Number of nodes to be initially created."
 	NodeGroupDoc.Fields[5].Comments[encoder.LineComment] = "Number of nodes to be initially created."
+	NodeGroupDoc.Fields[6].Name = "labels"
+	NodeGroupDoc.Fields[6].Type = "map[string]string"
+	NodeGroupDoc.Fields[6].Note = ""
+	NodeGroupDoc.Fields[6].Description = "Kubernetes labels added to the nodes of this group. Keys in the kubernetes.io and k8s.io namespaces are only allowed under kubelet.kubernetes.io and node.kubernetes.io."
+	NodeGroupDoc.Fields[6].Comments[encoder.LineComment] = "Kubernetes labels added to the nodes of this group. Keys in the kubernetes.io and k8s.io namespaces are only allowed under kubelet.kubernetes.io and node.kubernetes.io."
+	NodeGroupDoc.Fields[7].Name = "taints"
+	NodeGroupDoc.Fields[7].Type = "[]NodeTaint"
+	NodeGroupDoc.Fields[7].Note = ""
+	NodeGroupDoc.Fields[7].Description = "Kubernetes taints added to the nodes of this group."
+	NodeGroupDoc.Fields[7].Comments[encoder.LineComment] = "Kubernetes taints added to the nodes of this group."
+	NodeGroupDoc.Fields[8].Name = "kubelet"
+	NodeGroupDoc.Fields[8].Type = "KubeletConfig"
+	NodeGroupDoc.Fields[8].Note = ""
+	NodeGroupDoc.Fields[8].Description = "Kubelet settings of the nodes in this group."
+	NodeGroupDoc.Fields[8].Comments[encoder.LineComment] = "Kubelet settings of the nodes in this group."
+
+	NodeTaintDoc.Type = "NodeTaint"
+	NodeTaintDoc.Comments[encoder.LineComment] = "NodeTaint is a Kubernetes taint added to the nodes of a node group."
+	NodeTaintDoc.Description = "NodeTaint is a Kubernetes taint added to the nodes of a node group."
+	NodeTaintDoc.AppearsIn = []encoder.Appearance{
+		{
+			TypeName:  "NodeGroup",
+			FieldName: "taints",
+		},
+	}
+	NodeTaintDoc.Fields = make([]encoder.Doc, 3)
+	NodeTaintDoc.Fields[0].Name = "key"
+	NodeTaintDoc.Fields[0].Type = "string"
+	NodeTaintDoc.Fields[0].Note = ""
+	NodeTaintDoc.Fields[0].Description = "Key of the taint."
+	NodeTaintDoc.Fields[0].Comments[encoder.LineComment] = "Key of the taint."
+	NodeTaintDoc.Fields[1].Name = "value"
+	NodeTaintDoc.Fields[1].Type = "string"
+	NodeTaintDoc.Fields[1].Note = ""
+	NodeTaintDoc.Fields[1].Description = "Value of the taint."
+	NodeTaintDoc.Fields[1].Comments[encoder.LineComment] = "Value of the taint."
+	NodeTaintDoc.Fields[2].Name = "effect"
+	NodeTaintDoc.Fields[2].Type = "string"
+	NodeTaintDoc.Fields[2].Note = ""
+	NodeTaintDoc.Fields[2].Description = "Effect of the taint. Valid values are \"NoSchedule\", \"PreferNoSchedule\" and \"NoExecute\"."
+	NodeTaintDoc.Fields[2].Comments[encoder.LineComment] = "Effect of the taint. Valid values are \"NoSchedule\", \"PreferNoSchedule\" and \"NoExecute\"."
+
+	KubeletConfigDoc.Type = "KubeletConfig"
+	KubeletConfigDoc.Comments[encoder.LineComment] = "KubeletConfig configures the kubelet of the nodes in a node group."
+	KubeletConfigDoc.Description = "KubeletConfig configures the kubelet of the nodes in a node group."
+	KubeletConfigDoc.AppearsIn = []encoder.Appearance{
+		{
+			TypeName:  "NodeGroup",
+			FieldName: "kubelet",
+		},
+	}
+	KubeletConfigDoc.Fields = make([]encoder.Doc, 3)
+	KubeletConfigDoc.Fields[0].Name = "maxPods"
+	KubeletConfigDoc.Fields[0].Type = "int32"
+	KubeletConfigDoc.Fields[0].Note = ""
+	KubeletConfigDoc.Fields[0].Description = "Maximum number of pods that can run on a node. Zero keeps the Kubernetes default."
+	KubeletConfigDoc.Fields[0].Comments[encoder.LineComment] = "Maximum number of pods that can run on a node. Zero keeps the Kubernetes default."
+	KubeletConfigDoc.Fields[1].Name = "systemReserved"
+	KubeletConfigDoc.Fields[1].Type = "map[string]string"
+	KubeletConfigDoc.Fields[1].Note = ""
+	KubeletConfigDoc.Fields[1].Description = "Resources reserved for system daemons, e.g. cpu: 500m. Valid resources are cpu, memory, ephemeral-storage and pid."
+	KubeletConfigDoc.Fields[1].Comments[encoder.LineComment] = "Resources reserved for system daemons, e.g. cpu: 500m. Valid resources are cpu, memory, ephemeral-storage and pid."
+	KubeletConfigDoc.Fields[2].Name = "kubeReserved"
+	KubeletConfigDoc.Fields[2].Type = "map[string]string"
+	KubeletConfigDoc.Fields[2].Note = ""
+	KubeletConfigDoc.Fields[2].Description = "Resources reserved for Kubernetes system daemons, e.g. memory: 1Gi. Valid resources are cpu, memory, ephemeral-storage and pid."
+	KubeletConfigDoc.Fields[2].Comments[encoder.LineComment] = "Resources reserved for Kubernetes system daemons, e.g. memory: 1Gi. Valid resources are cpu, memory, ephemeral-storage and pid."
+
+	KMSConfigDoc.Type = "KMSConfig"
+	KMSConfigDoc.Comments[encoder.LineComment] = "KMSConfig configures an external key management service."
+	KMSConfigDoc.Description = "KMSConfig configures an external key management service."
+	KMSConfigDoc.AppearsIn = []encoder.Appearance{
+		{
+			TypeName:  "Config",
+			FieldName: "kms",
+		},
+	}
+	KMSConfigDoc.Fields = make([]encoder.Doc, 1)
+	KMSConfigDoc.Fields[0].Name = "kmip"
+	KMSConfigDoc.Fields[0].Type = "KMIPConfig"
+	KMSConfigDoc.Fields[0].Note = ""
+	KMSConfigDoc.Fields[0].Description = "Key management server or HSM speaking the Key Management Interoperability Protocol (KMIP)."
+	KMSConfigDoc.Fields[0].Comments[encoder.LineComment] = "Key management server or HSM speaking the Key Management Interoperability Protocol (KMIP)."
+
+	KMIPConfigDoc.Type = "KMIPConfig"
+	KMIPConfigDoc.Comments[encoder.LineComment] = "KMIPConfig configures a KMIP server as external KMS."
+	KMIPConfigDoc.Description = "KMIPConfig configures a KMIP server as external KMS."
+	KMIPConfigDoc.AppearsIn = []encoder.Appearance{
+		{
+			TypeName:  "KMSConfig",
+			FieldName: "kmip",
+		},
+	}
+	KMIPConfigDoc.Fields = make([]encoder.Doc, 6)
+	KMIPConfigDoc.Fields[0].Name = "endpoint"
+	KMIPConfigDoc.Fields[0].Type = "string"
+	KMIPConfigDoc.Fields[0].Note = ""
+	KMIPConfigDoc.Fields[0].Description = "Address of the KMIP server in the form host:port."
+	KMIPConfigDoc.Fields[0].Comments[encoder.LineComment] = "Address of the KMIP server in the form host:port."
+	KMIPConfigDoc.Fields[1].Name = "keyName"
+	KMIPConfigDoc.Fields[1].Type = "string"
+	KMIPConfigDoc.Fields[1].Note = ""
+	KMIPConfigDoc.Fields[1].Description = "Name of the AES key on the KMIP server used to wrap the cluster's data encryption keys."
+	KMIPConfigDoc.Fields[1].Comments[encoder.LineComment] = "Name of the AES key on the KMIP server used to wrap the cluster's data encryption keys."
+	KMIPConfigDoc.Fields[2].Name = "caCertPath"
+	KMIPConfigDoc.Fields[2].Type = "string"
+	KMIPConfigDoc.Fields[2].Note = ""
+	KMIPConfigDoc.Fields[2].Description = "Path to the PEM encoded CA certificate used to verify the KMIP server."
+	KMIPConfigDoc.Fields[2].Comments[encoder.LineComment] = "Path to the PEM encoded CA certificate used to verify the KMIP server."
+	KMIPConfigDoc.Fields[3].Name = "clientCertPath"
+	KMIPConfigDoc.Fields[3].Type = "string"
+	KMIPConfigDoc.Fields[3].Note = ""
+	KMIPConfigDoc.Fields[3].Description = "Path to the PEM encoded client certificate used to authenticate to the KMIP server."
+	KMIPConfigDoc.Fields[3].Comments[encoder.LineComment] = "Path to the PEM encoded client certificate used to authenticate to the KMIP server."
+	KMIPConfigDoc.Fields[4].Name = "clientKeyPath"
+	KMIPConfigDoc.Fields[4].Type = "string"
+	KMIPConfigDoc.Fields[4].Note = ""
+	KMIPConfigDoc.Fields[4].Description = "Path to the PEM encoded private key of the client certificate."
+	KMIPConfigDoc.Fields[4].Comments[encoder.LineComment] = "Path to the PEM encoded private key of the client certificate."
+	KMIPConfigDoc.Fields[5].Name = "storageURI"
+	KMIPConfigDoc.Fields[5].Type = "string"
+	KMIPConfigDoc.Fields[5].Note = ""
+	KMIPConfigDoc.Fields[5].Description = "URI of the storage backend for wrapped data encryption keys, e.g. \"storage://vault?...\". See the KMS documentation for supported backends."
+	KMIPConfigDoc.Fields[5].Comments[encoder.LineComment] = "URI of the storage backend for wrapped data encryption keys, e.g. \"storage://vault?...\". See the KMS documentation for supported backends."
 
 	UnsupportedAppRegistrationErrorDoc.Type = "UnsupportedAppRegistrationError"
 	UnsupportedAppRegistrationErrorDoc.Comments[encoder.LineComment] = "UnsupportedAppRegistrationError is returned when the config contains configuration related to now unsupported app registrations."
@@ -518,6 +671,158 @@ func init() {
 	SNPFirmwareSignerConfigDoc.Fields[2].Description = "URL of the Microsoft Azure Attestation (MAA) instance to use for fallback validation. Only used if 'enforcementPolicy' is set to 'maaFallback'."
 	SNPFirmwareSignerConfigDoc.Fields[2].Comments[encoder.LineComment] = "URL of the Microsoft Azure Attestation (MAA) instance to use for fallback validation. Only used if 'enforcementPolicy' is set to 'maaFallback'."
 
+	SNPGuestPolicyDoc.Type = "SNPGuestPolicy"
+	SNPGuestPolicyDoc.Comments[encoder.LineComment] = "SNPGuestPolicy is the configuration for validating the guest policy of an SEV-SNP attestation report."
+	SNPGuestPolicyDoc.Description = "SNPGuestPolicy is the configuration for validating the guest policy of an SEV-SNP attestation report."
+	SNPGuestPolicyDoc.AppearsIn = []encoder.Appearance{
+		{
+			TypeName:  "GCPSEVSNP",
+			FieldName: "guestPolicy",
+		},
+		{
+			TypeName:  "QEMUSEVSNP",
+			FieldName: "guestPolicy",
+		},
+		{
+			TypeName:  "AWSSEVSNP",
+			FieldName: "guestPolicy",
+		},
+		{
+			TypeName:  "AzureSEVSNP",
+			FieldName: "guestPolicy",
+		},
+	}
+	SNPGuestPolicyDoc.Fields = make([]encoder.Doc, 6)
+	SNPGuestPolicyDoc.Fields[0].Name = "allowDebug"
+	SNPGuestPolicyDoc.Fields[0].Type = "bool"
+	SNPGuestPolicyDoc.Fields[0].Note = ""
+	SNPGuestPolicyDoc.Fields[0].Description = "Accept guests that can be debugged by the host. Debugging allows the host to decrypt the guest's memory and must not be allowed for production clusters."
+	SNPGuestPolicyDoc.Fields[0].Comments[encoder.LineComment] = "Accept guests that can be debugged by the host. Debugging allows the host to decrypt the guest's memory and must not be allowed for production clusters."
+	SNPGuestPolicyDoc.Fields[1].Name = "allowMigrationAgent"
+	SNPGuestPolicyDoc.Fields[1].Type = "bool"
+	SNPGuestPolicyDoc.Fields[1].Note = ""
+	SNPGuestPolicyDoc.Fields[1].Description = "Accept guests that can be associated with a migration agent."
+	SNPGuestPolicyDoc.Fields[1].Comments[encoder.LineComment] = "Accept guests that can be associated with a migration agent."
+	SNPGuestPolicyDoc.Fields[2].Name = "allowSMT"
+	SNPGuestPolicyDoc.Fields[2].Type = "bool"
+	SNPGuestPolicyDoc.Fields[2].Note = ""
+	SNPGuestPolicyDoc.Fields[2].Description = "Accept guests that allow simultaneous multithreading (SMT)."
+	SNPGuestPolicyDoc.Fields[2].Comments[encoder.LineComment] = "Accept guests that allow simultaneous multithreading (SMT)."
+	SNPGuestPolicyDoc.Fields[3].Name = "requireSingleSocket"
+	SNPGuestPolicyDoc.Fields[3].Type = "bool"
+	SNPGuestPolicyDoc.Fields[3].Note = ""
+	SNPGuestPolicyDoc.Fields[3].Description = "Require guests to be restricted to a single socket."
+	SNPGuestPolicyDoc.Fields[3].Comments[encoder.LineComment] = "Require guests to be restricted to a single socket."
+	SNPGuestPolicyDoc.Fields[4].Name = "minimumABIMajor"
+	SNPGuestPolicyDoc.Fields[4].Type = "uint8"
+	SNPGuestPolicyDoc.Fields[4].Note = ""
+	SNPGuestPolicyDoc.Fields[4].Description = "Lowest acceptable major version of the SEV-SNP firmware ABI required by the guest."
+	SNPGuestPolicyDoc.Fields[4].Comments[encoder.LineComment] = "Lowest acceptable major version of the SEV-SNP firmware ABI required by the guest."
+	SNPGuestPolicyDoc.Fields[5].Name = "minimumABIMinor"
+	SNPGuestPolicyDoc.Fields[5].Type = "uint8"
+	SNPGuestPolicyDoc.Fields[5].Note = ""
+	SNPGuestPolicyDoc.Fields[5].Description = "Lowest acceptable minor version of the SEV-SNP firmware ABI required by the guest. Only compared if the major versions are equal."
+	SNPGuestPolicyDoc.Fields[5].Comments[encoder.LineComment] = "Lowest acceptable minor version of the SEV-SNP firmware ABI required by the guest. Only compared if the major versions are equal."
+
+	SNPPlatformInfoDoc.Type = "SNPPlatformInfo"
+	SNPPlatformInfoDoc.Comments[encoder.LineComment] = "SNPPlatformInfo is the configuration for validating the platform info of an SEV-SNP attestation report."
+	SNPPlatformInfoDoc.Description = "SNPPlatformInfo is the configuration for validating the platform info of an SEV-SNP attestation report."
+	SNPPlatformInfoDoc.AppearsIn = []encoder.Appearance{
+		{
+			TypeName:  "GCPSEVSNP",
+			FieldName: "platformInfo",
+		},
+		{
+			TypeName:  "QEMUSEVSNP",
+			FieldName: "platformInfo",
+		},
+		{
+			TypeName:  "AWSSEVSNP",
+			FieldName: "platformInfo",
+		},
+		{
+			TypeName:  "AzureSEVSNP",
+			FieldName: "platformInfo",
+		},
+	}
+	SNPPlatformInfoDoc.Fields = make([]encoder.Doc, 3)
+	SNPPlatformInfoDoc.Fields[0].Name = "allowSMT"
+	SNPPlatformInfoDoc.Fields[0].Type = "bool"
+	SNPPlatformInfoDoc.Fields[0].Note = ""
+	SNPPlatformInfoDoc.Fields[0].Description = "Accept platforms with simultaneous multithreading (SMT) enabled."
+	SNPPlatformInfoDoc.Fields[0].Comments[encoder.LineComment] = "Accept platforms with simultaneous multithreading (SMT) enabled."
+	SNPPlatformInfoDoc.Fields[1].Name = "allowTSME"
+	SNPPlatformInfoDoc.Fields[1].Type = "bool"
+	SNPPlatformInfoDoc.Fields[1].Note = ""
+	SNPPlatformInfoDoc.Fields[1].Description = "Accept platforms with transparent secure memory encryption (TSME) enabled."
+	SNPPlatformInfoDoc.Fields[1].Comments[encoder.LineComment] = "Accept platforms with transparent secure memory encryption (TSME) enabled."
+	SNPPlatformInfoDoc.Fields[2].Name = "requireECC"
+	SNPPlatformInfoDoc.Fields[2].Type = "bool"
+	SNPPlatformInfoDoc.Fields[2].Note = ""
+	SNPPlatformInfoDoc.Fields[2].Description = "Require platforms to use error correcting codes (ECC) for memory."
+	SNPPlatformInfoDoc.Fields[2].Comments[encoder.LineComment] = "Require platforms to use error correcting codes (ECC) for memory."
+
+	PolicyRuleDoc.Type = "PolicyRule"
+	PolicyRuleDoc.Comments[encoder.LineComment] = "PolicyRule is an additional rule for attestation statements."
+	PolicyRuleDoc.Description = "PolicyRule is an additional rule for attestation statements."
+	PolicyRuleDoc.AppearsIn = []encoder.Appearance{
+		{
+			TypeName:  "GCPSEVES",
+			FieldName: "policy",
+		},
+		{
+			TypeName:  "GCPSEVSNP",
+			FieldName: "policy",
+		},
+		{
+			TypeName:  "QEMUVTPM",
+			FieldName: "policy",
+		},
+		{
+			TypeName:  "QEMUSEVSNP",
+			FieldName: "policy",
+		},
+		{
+			TypeName:  "QEMUTDX",
+			FieldName: "policy",
+		},
+		{
+			TypeName:  "AWSSEVSNP",
+			FieldName: "policy",
+		},
+		{
+			TypeName:  "AWSNitroTPM",
+			FieldName: "policy",
+		},
+		{
+			TypeName:  "AzureSEVSNP",
+			FieldName: "policy",
+		},
+		{
+			TypeName:  "GCPTDX",
+			FieldName: "policy",
+		},
+		{
+			TypeName:  "AzureTrustedLaunch",
+			FieldName: "policy",
+		},
+		{
+			TypeName:  "AzureTDX",
+			FieldName: "policy",
+		},
+	}
+	PolicyRuleDoc.Fields = make([]encoder.Doc, 2)
+	PolicyRuleDoc.Fields[0].Name = "name"
+	PolicyRuleDoc.Fields[0].Type = "string"
+	PolicyRuleDoc.Fields[0].Note = ""
+	PolicyRuleDoc.Fields[0].Description = "Name of the rule. Used in error messages."
+	PolicyRuleDoc.Fields[0].Comments[encoder.LineComment] = "Name of the rule. Used in error messages."
+	PolicyRuleDoc.Fields[1].Name = "expression"
+	PolicyRuleDoc.Fields[1].Type = "string"
+	PolicyRuleDoc.Fields[1].Note = ""
+	PolicyRuleDoc.Fields[1].Description = "CEL expression the normalized attestation claims must satisfy. It must evaluate to true for the attestation to succeed."
+	PolicyRuleDoc.Fields[1].Comments[encoder.LineComment] = "CEL expression the normalized attestation claims must satisfy. It must evaluate to true for the attestation to succeed."
+
 	GCPSEVESDoc.Type = "GCPSEVES"
 	GCPSEVESDoc.Comments[encoder.LineComment] = "GCPSEVES is the configuration for GCP SEV-ES attestation."
 	GCPSEVESDoc.Description = "GCPSEVES is the configuration for GCP SEV-ES attestation."
@@ -527,12 +832,22 @@ func init() {
 			FieldName: "gcpSEVES",
 		},
 	}
-	GCPSEVESDoc.Fields = make([]encoder.Doc, 1)
+	GCPSEVESDoc.Fields = make([]encoder.Doc, 3)
 	GCPSEVESDoc.Fields[0].Name = "measurements"
 	GCPSEVESDoc.Fields[0].Type = "M"
 	GCPSEVESDoc.Fields[0].Note = ""
 	GCPSEVESDoc.Fields[0].Description = "Expected TPM measurements."
 	GCPSEVESDoc.Fields[0].Comments[encoder.LineComment] = "Expected TPM measurements."
+	GCPSEVESDoc.Fields[1].Name = "acceptedMeasurements"
+	GCPSEVESDoc.Fields[1].Type = "[]M"
+	GCPSEVESDoc.Fields[1].Note = ""
+	GCPSEVESDoc.Fields[1].Description = "Additional sets of expected measurements. An attestation statement is accepted if it matches the measurements above or any of these sets, e.g., the measurements of the previous image during an upgrade."
+	GCPSEVESDoc.Fields[1].Comments[encoder.LineComment] = "Additional sets of expected measurements. An attestation statement is accepted if it matches the measurements above or any of these sets, e.g., the measurements of the previous image during an upgrade."
+	GCPSEVESDoc.Fields[2].Name = "policy"
+	GCPSEVESDoc.Fields[2].Type = "[]PolicyRule"
+	GCPSEVESDoc.Fields[2].Note = ""
+	GCPSEVESDoc.Fields[2].Description = "Additional rules the attestation statement must satisfy. Each rule is a CEL expression over the normalized attestation claims."
+	GCPSEVESDoc.Fields[2].Comments[encoder.LineComment] = "Additional rules the attestation statement must satisfy. Each rule is a CEL expression over the normalized attestation claims."
 
 	GCPSEVSNPDoc.Type = "GCPSEVSNP"
 	GCPSEVSNPDoc.Comments[encoder.LineComment] = "GCPSEVSNP is the configuration for GCP SEV-SNP attestation."
@@ -543,42 +858,72 @@ func init() {
 			FieldName: "gcpSEVSNP",
 		},
 	}
-	GCPSEVSNPDoc.Fields = make([]encoder.Doc, 7)
+	GCPSEVSNPDoc.Fields = make([]encoder.Doc, 13)
 	GCPSEVSNPDoc.Fields[0].Name = "measurements"
 	GCPSEVSNPDoc.Fields[0].Type = "M"
 	GCPSEVSNPDoc.Fields[0].Note = ""
 	GCPSEVSNPDoc.Fields[0].Description = "Expected TPM measurements."
 	GCPSEVSNPDoc.Fields[0].Comments[encoder.LineComment] = "Expected TPM measurements."
-	GCPSEVSNPDoc.Fields[1].Name = "bootloaderVersion"
-	GCPSEVSNPDoc.Fields[1].Type = ""
+	GCPSEVSNPDoc.Fields[1].Name = "acceptedMeasurements"
+	GCPSEVSNPDoc.Fields[1].Type = "[]M"
 	GCPSEVSNPDoc.Fields[1].Note = ""
-	GCPSEVSNPDoc.Fields[1].Description = "Lowest acceptable bootloader version."
-	GCPSEVSNPDoc.Fields[1].Comments[encoder.LineComment] = "Lowest acceptable bootloader version."
-	GCPSEVSNPDoc.Fields[2].Name = "teeVersion"
+	GCPSEVSNPDoc.Fields[1].Description = "Additional sets of expected measurements. An attestation statement is accepted if it matches the measurements above or any of these sets, e.g., the measurements of the previous image during an upgrade."
+	GCPSEVSNPDoc.Fields[1].Comments[encoder.LineComment] = "Additional sets of expected measurements. An attestation statement is accepted if it matches the measurements above or any of these sets, e.g., the measurements of the previous image during an upgrade."
+	GCPSEVSNPDoc.Fields[2].Name = "bootloaderVersion"
 	GCPSEVSNPDoc.Fields[2].Type = ""
 	GCPSEVSNPDoc.Fields[2].Note = ""
-	GCPSEVSNPDoc.Fields[2].Description = "Lowest acceptable TEE version."
-	GCPSEVSNPDoc.Fields[2].Comments[encoder.LineComment] = "Lowest acceptable TEE version."
-	GCPSEVSNPDoc.Fields[3].Name = "snpVersion"
+	GCPSEVSNPDoc.Fields[2].Description = "Lowest acceptable bootloader version."
+	GCPSEVSNPDoc.Fields[2].Comments[encoder.LineComment] = "Lowest acceptable bootloader version."
+	GCPSEVSNPDoc.Fields[3].Name = "teeVersion"
 	GCPSEVSNPDoc.Fields[3].Type = ""
 	GCPSEVSNPDoc.Fields[3].Note = ""
-	GCPSEVSNPDoc.Fields[3].Description = "Lowest acceptable SEV-SNP version."
-	GCPSEVSNPDoc.Fields[3].Comments[encoder.LineComment] = "Lowest acceptable SEV-SNP version."
-	GCPSEVSNPDoc.Fields[4].Name = "microcodeVersion"
+	GCPSEVSNPDoc.Fields[3].Description = "Lowest acceptable TEE version."
+	GCPSEVSNPDoc.Fields[3].Comments[encoder.LineComment] = "Lowest acceptable TEE version."
+	GCPSEVSNPDoc.Fields[4].Name = "snpVersion"
 	GCPSEVSNPDoc.Fields[4].Type = ""
 	GCPSEVSNPDoc.Fields[4].Note = ""
-	GCPSEVSNPDoc.Fields[4].Description = "Lowest acceptable microcode version."
-	GCPSEVSNPDoc.Fields[4].Comments[encoder.LineComment] = "Lowest acceptable microcode version."
-	GCPSEVSNPDoc.Fields[5].Name = "amdRootKey"
-	GCPSEVSNPDoc.Fields[5].Type = "Certificate"
+	GCPSEVSNPDoc.Fields[4].Description = "Lowest acceptable SEV-SNP version."
+	GCPSEVSNPDoc.Fields[4].Comments[encoder.LineComment] = "Lowest acceptable SEV-SNP version."
+	GCPSEVSNPDoc.Fields[5].Name = "microcodeVersion"
+	GCPSEVSNPDoc.Fields[5].Type = ""
 	GCPSEVSNPDoc.Fields[5].Note = ""
-	GCPSEVSNPDoc.Fields[5].Description = "AMD Root Key certificate used to verify the SEV-SNP certificate chain."
-	GCPSEVSNPDoc.Fields[5].Comments[encoder.LineComment] = "AMD Root Key certificate used to verify the SEV-SNP certificate chain."
-	GCPSEVSNPDoc.Fields[6].Name = "amdSigningKey"
-	GCPSEVSNPDoc.Fields[6].Type = "Certificate"
+	GCPSEVSNPDoc.Fields[5].Description = "Lowest acceptable microcode version."
+	GCPSEVSNPDoc.Fields[5].Comments[encoder.LineComment] = "Lowest acceptable microcode version."
+	GCPSEVSNPDoc.Fields[6].Name = "guestPolicy"
+	GCPSEVSNPDoc.Fields[6].Type = "SNPGuestPolicy"
 	GCPSEVSNPDoc.Fields[6].Note = ""
-	GCPSEVSNPDoc.Fields[6].Description = "AMD Signing Key certificate used to verify the SEV-SNP VCEK / VLEK certificate."
-	GCPSEVSNPDoc.Fields[6].Comments[encoder.LineComment] = "AMD Signing Key certificate used to verify the SEV-SNP VCEK / VLEK certificate."
+	GCPSEVSNPDoc.Fields[6].Description = "Guest policy the SEV-SNP attestation report must adhere to. If not set, guests that can be debugged or have a migration agent are rejected."
+	GCPSEVSNPDoc.Fields[6].Comments[encoder.LineComment] = "Guest policy the SEV-SNP attestation report must adhere to. If not set, guests that can be debugged or have a migration agent are rejected."
+	GCPSEVSNPDoc.Fields[7].Name = "platformInfo"
+	GCPSEVSNPDoc.Fields[7].Type = "SNPPlatformInfo"
+	GCPSEVSNPDoc.Fields[7].Note = ""
+	GCPSEVSNPDoc.Fields[7].Description = "Platform features the SEV-SNP attestation report must adhere to. If not set, the platform info isn't checked."
+	GCPSEVSNPDoc.Fields[7].Comments[encoder.LineComment] = "Platform features the SEV-SNP attestation report must adhere to. If not set, the platform info isn't checked."
+	GCPSEVSNPDoc.Fields[8].Name = "checkRevocations"
+	GCPSEVSNPDoc.Fields[8].Type = "bool"
+	GCPSEVSNPDoc.Fields[8].Note = ""
+	GCPSEVSNPDoc.Fields[8].Description = "Check that the VCEK / VLEK and the AMD Signing Key weren't revoked by AMD. The certificate revocation list (CRL) is fetched from the AMD Key Distribution Service, unless 'amdCRL' is set."
+	GCPSEVSNPDoc.Fields[8].Comments[encoder.LineComment] = "Check that the VCEK / VLEK and the AMD Signing Key weren't revoked by AMD. The certificate revocation list (CRL) is fetched from the AMD Key Distribution Service, unless 'amdCRL' is set."
+	GCPSEVSNPDoc.Fields[9].Name = "amdCRL"
+	GCPSEVSNPDoc.Fields[9].Type = "CRL"
+	GCPSEVSNPDoc.Fields[9].Note = ""
+	GCPSEVSNPDoc.Fields[9].Description = "Pinned AMD certificate revocation list (CRL). If set, revocations are checked against this CRL instead of fetching it from the AMD Key Distribution Service, which allows checking revocations without network access. Keep it up to date: a pinned CRL past its next update is still used, but only causes a warning."
+	GCPSEVSNPDoc.Fields[9].Comments[encoder.LineComment] = "Pinned AMD certificate revocation list (CRL). If set, revocations are checked against this CRL instead of fetching it from the AMD Key Distribution Service, which allows checking revocations without network access. Keep it up to date: a pinned CRL past its next update is still used, but only causes a warning."
+	GCPSEVSNPDoc.Fields[10].Name = "amdRootKey"
+	GCPSEVSNPDoc.Fields[10].Type = "Certificate"
+	GCPSEVSNPDoc.Fields[10].Note = ""
+	GCPSEVSNPDoc.Fields[10].Description = "AMD Root Key certificate used to verify the SEV-SNP certificate chain."
+	GCPSEVSNPDoc.Fields[10].Comments[encoder.LineComment] = "AMD Root Key certificate used to verify the SEV-SNP certificate chain."
+	GCPSEVSNPDoc.Fields[11].Name = "amdSigningKey"
+	GCPSEVSNPDoc.Fields[11].Type = "Certificate"
+	GCPSEVSNPDoc.Fields[11].Note = ""
+	GCPSEVSNPDoc.Fields[11].Description = "AMD Signing Key certificate used to verify the SEV-SNP VCEK / VLEK certificate."
+	GCPSEVSNPDoc.Fields[11].Comments[encoder.LineComment] = "AMD Signing Key certificate used to verify the SEV-SNP VCEK / VLEK certificate."
+	GCPSEVSNPDoc.Fields[12].Name = "policy"
+	GCPSEVSNPDoc.Fields[12].Type = "[]PolicyRule"
+	GCPSEVSNPDoc.Fields[12].Note = ""
+	GCPSEVSNPDoc.Fields[12].Description = "Additional rules the attestation statement must satisfy. Each rule is a CEL expression over the normalized attestation claims."
+	GCPSEVSNPDoc.Fields[12].Comments[encoder.LineComment] = "Additional rules the attestation statement must satisfy. Each rule is a CEL expression over the normalized attestation claims."
 
 	QEMUVTPMDoc.Type = "QEMUVTPM"
 	QEMUVTPMDoc.Comments[encoder.LineComment] = "QEMUVTPM is the configuration for QEMU vTPM attestation."
@@ -589,12 +934,108 @@ func init() {
 			FieldName: "qemuVTPM",
 		},
 	}
-	QEMUVTPMDoc.Fields = make([]encoder.Doc, 1)
+	QEMUVTPMDoc.Fields = make([]encoder.Doc, 3)
 	QEMUVTPMDoc.Fields[0].Name = "measurements"
 	QEMUVTPMDoc.Fields[0].Type = "M"
 	QEMUVTPMDoc.Fields[0].Note = ""
 	QEMUVTPMDoc.Fields[0].Description = "Expected TPM measurements."
 	QEMUVTPMDoc.Fields[0].Comments[encoder.LineComment] = "Expected TPM measurements."
+	QEMUVTPMDoc.Fields[1].Name = "acceptedMeasurements"
+	QEMUVTPMDoc.Fields[1].Type = "[]M"
+	QEMUVTPMDoc.Fields[1].Note = ""
+	QEMUVTPMDoc.Fields[1].Description = "Additional sets of expected measurements. An attestation statement is accepted if it matches the measurements above or any of these sets, e.g., the measurements of the previous image during an upgrade."
+	QEMUVTPMDoc.Fields[1].Comments[encoder.LineComment] = "Additional sets of expected measurements. An attestation statement is accepted if it matches the measurements above or any of these sets, e.g., the measurements of the previous image during an upgrade."
+	QEMUVTPMDoc.Fields[2].Name = "policy"
+	QEMUVTPMDoc.Fields[2].Type = "[]PolicyRule"
+	QEMUVTPMDoc.Fields[2].Note = ""
+	QEMUVTPMDoc.Fields[2].Description = "Additional rules the attestation statement must satisfy. Each rule is a CEL expression over the normalized attestation claims."
+	QEMUVTPMDoc.Fields[2].Comments[encoder.LineComment] = "Additional rules the attestation statement must satisfy. Each rule is a CEL expression over the normalized attestation claims."
+
+	QEMUSEVSNPDoc.Type = "QEMUSEVSNP"
+	QEMUSEVSNPDoc.Comments[encoder.LineComment] = "QEMUSEVSNP is the configuration for SEV-SNP attestation of QEMU guests on bare-metal hosts."
+	QEMUSEVSNPDoc.Description = "QEMUSEVSNP is the configuration for SEV-SNP attestation of QEMU guests on bare-metal hosts.\nThe SEV-SNP attestation report is used directly, without a vTPM.\n"
+	QEMUSEVSNPDoc.AppearsIn = []encoder.Appearance{
+		{
+			TypeName:  "AttestationConfig",
+			FieldName: "qemuSEVSNP",
+		},
+	}
+	QEMUSEVSNPDoc.Fields = make([]encoder.Doc, 15)
+	QEMUSEVSNPDoc.Fields[0].Name = "measurements"
+	QEMUSEVSNPDoc.Fields[0].Type = "M"
+	QEMUSEVSNPDoc.Fields[0].Note = ""
+	QEMUSEVSNPDoc.Fields[0].Description = "Expected SEV-SNP launch measurement. Index 0 holds the launch digest."
+	QEMUSEVSNPDoc.Fields[0].Comments[encoder.LineComment] = "Expected SEV-SNP launch measurement. Index 0 holds the launch digest."
+	QEMUSEVSNPDoc.Fields[1].Name = "acceptedMeasurements"
+	QEMUSEVSNPDoc.Fields[1].Type = "[]M"
+	QEMUSEVSNPDoc.Fields[1].Note = ""
+	QEMUSEVSNPDoc.Fields[1].Description = "Additional sets of expected measurements. An attestation statement is accepted if it matches the measurements above or any of these sets, e.g., the measurements of the previous image during an upgrade."
+	QEMUSEVSNPDoc.Fields[1].Comments[encoder.LineComment] = "Additional sets of expected measurements. An attestation statement is accepted if it matches the measurements above or any of these sets, e.g., the measurements of the previous image during an upgrade."
+	QEMUSEVSNPDoc.Fields[2].Name = "bootloaderVersion"
+	QEMUSEVSNPDoc.Fields[2].Type = "uint8"
+	QEMUSEVSNPDoc.Fields[2].Note = ""
+	QEMUSEVSNPDoc.Fields[2].Description = "Lowest acceptable bootloader version."
+	QEMUSEVSNPDoc.Fields[2].Comments[encoder.LineComment] = "Lowest acceptable bootloader version."
+	QEMUSEVSNPDoc.Fields[3].Name = "teeVersion"
+	QEMUSEVSNPDoc.Fields[3].Type = "uint8"
+	QEMUSEVSNPDoc.Fields[3].Note = ""
+	QEMUSEVSNPDoc.Fields[3].Description = "Lowest acceptable TEE version."
+	QEMUSEVSNPDoc.Fields[3].Comments[encoder.LineComment] = "Lowest acceptable TEE version."
+	QEMUSEVSNPDoc.Fields[4].Name = "snpVersion"
+	QEMUSEVSNPDoc.Fields[4].Type = "uint8"
+	QEMUSEVSNPDoc.Fields[4].Note = ""
+	QEMUSEVSNPDoc.Fields[4].Description = "Lowest acceptable SEV-SNP version."
+	QEMUSEVSNPDoc.Fields[4].Comments[encoder.LineComment] = "Lowest acceptable SEV-SNP version."
+	QEMUSEVSNPDoc.Fields[5].Name = "microcodeVersion"
+	QEMUSEVSNPDoc.Fields[5].Type = "uint8"
+	QEMUSEVSNPDoc.Fields[5].Note = ""
+	QEMUSEVSNPDoc.Fields[5].Description = "Lowest acceptable microcode version."
+	QEMUSEVSNPDoc.Fields[5].Comments[encoder.LineComment] = "Lowest acceptable microcode version."
+	QEMUSEVSNPDoc.Fields[6].Name = "hostData"
+	QEMUSEVSNPDoc.Fields[6].Type = "HexBytes"
+	QEMUSEVSNPDoc.Fields[6].Note = ""
+	QEMUSEVSNPDoc.Fields[6].Description = "Expected host data of the SEV-SNP attestation report (32 bytes, hex encoded). The host data is set by the host when launching the guest. If not set, the host data isn't checked."
+	QEMUSEVSNPDoc.Fields[6].Comments[encoder.LineComment] = "Expected host data of the SEV-SNP attestation report (32 bytes, hex encoded). The host data is set by the host when launching the guest. If not set, the host data isn't checked."
+	QEMUSEVSNPDoc.Fields[7].Name = "idKeyDigests"
+	QEMUSEVSNPDoc.Fields[7].Type = "List"
+	QEMUSEVSNPDoc.Fields[7].Note = ""
+	QEMUSEVSNPDoc.Fields[7].Description = "Accepted digests of the key that signed the ID block the guest was launched with. If set, guests without an ID block signed by one of these keys are rejected."
+	QEMUSEVSNPDoc.Fields[7].Comments[encoder.LineComment] = "Accepted digests of the key that signed the ID block the guest was launched with. If set, guests without an ID block signed by one of these keys are rejected."
+	QEMUSEVSNPDoc.Fields[8].Name = "guestPolicy"
+	QEMUSEVSNPDoc.Fields[8].Type = "SNPGuestPolicy"
+	QEMUSEVSNPDoc.Fields[8].Note = ""
+	QEMUSEVSNPDoc.Fields[8].Description = "Guest policy the SEV-SNP attestation report must adhere to. If not set, guests that can be debugged or have a migration agent are rejected."
+	QEMUSEVSNPDoc.Fields[8].Comments[encoder.LineComment] = "Guest policy the SEV-SNP attestation report must adhere to. If not set, guests that can be debugged or have a migration agent are rejected."
+	QEMUSEVSNPDoc.Fields[9].Name = "platformInfo"
+	QEMUSEVSNPDoc.Fields[9].Type = "SNPPlatformInfo"
+	QEMUSEVSNPDoc.Fields[9].Note = ""
+	QEMUSEVSNPDoc.Fields[9].Description = "Platform features the SEV-SNP attestation report must adhere to. If not set, the platform info isn't checked."
+	QEMUSEVSNPDoc.Fields[9].Comments[encoder.LineComment] = "Platform features the SEV-SNP attestation report must adhere to. If not set, the platform info isn't checked."
+	QEMUSEVSNPDoc.Fields[10].Name = "checkRevocations"
+	QEMUSEVSNPDoc.Fields[10].Type = "bool"
+	QEMUSEVSNPDoc.Fields[10].Note = ""
+	QEMUSEVSNPDoc.Fields[10].Description = "Check that the VCEK / VLEK and the AMD Signing Key weren't revoked by AMD. The certificate revocation list (CRL) is fetched from the AMD Key Distribution Service, unless 'amdCRL' is set."
+	QEMUSEVSNPDoc.Fields[10].Comments[encoder.LineComment] = "Check that the VCEK / VLEK and the AMD Signing Key weren't revoked by AMD. The certificate revocation list (CRL) is fetched from the AMD Key Distribution Service, unless 'amdCRL' is set."
+	QEMUSEVSNPDoc.Fields[11].Name = "amdCRL"
+	QEMUSEVSNPDoc.Fields[11].Type = "CRL"
+	QEMUSEVSNPDoc.Fields[11].Note = ""
+	QEMUSEVSNPDoc.Fields[11].Description = "Pinned AMD certificate revocation list (CRL). If set, revocations are checked against this CRL instead of fetching it from the AMD Key Distribution Service, which allows checking revocations without network access. Keep it up to date: a pinned CRL past its next update is still used, but only causes a warning."
+	QEMUSEVSNPDoc.Fields[11].Comments[encoder.LineComment] = "Pinned AMD certificate revocation list (CRL). If set, revocations are checked against this CRL instead of fetching it from the AMD Key Distribution Service, which allows checking revocations without network access. Keep it up to date: a pinned CRL past its next update is still used, but only causes a warning."
+	QEMUSEVSNPDoc.Fields[12].Name = "amdRootKey"
+	QEMUSEVSNPDoc.Fields[12].Type = "Certificate"
+	QEMUSEVSNPDoc.Fields[12].Note = ""
+	QEMUSEVSNPDoc.Fields[12].Description = "AMD Root Key certificate used to verify the SEV-SNP certificate chain."
+	QEMUSEVSNPDoc.Fields[12].Comments[encoder.LineComment] = "AMD Root Key certificate used to verify the SEV-SNP certificate chain."
+	QEMUSEVSNPDoc.Fields[13].Name = "amdSigningKey"
+	QEMUSEVSNPDoc.Fields[13].Type = "Certificate"
+	QEMUSEVSNPDoc.Fields[13].Note = ""
+	QEMUSEVSNPDoc.Fields[13].Description = "AMD Signing Key certificate used to verify the SEV-SNP VCEK / VLEK certificate."
+	QEMUSEVSNPDoc.Fields[13].Comments[encoder.LineComment] = "AMD Signing Key certificate used to verify the SEV-SNP VCEK / VLEK certificate."
+	QEMUSEVSNPDoc.Fields[14].Name = "policy"
+	QEMUSEVSNPDoc.Fields[14].Type = "[]PolicyRule"
+	QEMUSEVSNPDoc.Fields[14].Note = ""
+	QEMUSEVSNPDoc.Fields[14].Description = "Additional rules the attestation statement must satisfy. Each rule is a CEL expression over the normalized attestation claims."
+	QEMUSEVSNPDoc.Fields[14].Comments[encoder.LineComment] = "Additional rules the attestation statement must satisfy. Each rule is a CEL expression over the normalized attestation claims."
 
 	QEMUTDXDoc.Type = "QEMUTDX"
 	QEMUTDXDoc.Comments[encoder.LineComment] = "QEMUTDX is the configuration for QEMU TDX attestation."
@@ -605,12 +1046,22 @@ func init() {
 			FieldName: "qemuTDX",
 		},
 	}
-	QEMUTDXDoc.Fields = make([]encoder.Doc, 1)
+	QEMUTDXDoc.Fields = make([]encoder.Doc, 3)
 	QEMUTDXDoc.Fields[0].Name = "measurements"
 	QEMUTDXDoc.Fields[0].Type = "M"
 	QEMUTDXDoc.Fields[0].Note = ""
 	QEMUTDXDoc.Fields[0].Description = "Expected TDX measurements."
 	QEMUTDXDoc.Fields[0].Comments[encoder.LineComment] = "Expected TDX measurements."
+	QEMUTDXDoc.Fields[1].Name = "acceptedMeasurements"
+	QEMUTDXDoc.Fields[1].Type = "[]M"
+	QEMUTDXDoc.Fields[1].Note = ""
+	QEMUTDXDoc.Fields[1].Description = "Additional sets of expected measurements. An attestation statement is accepted if it matches the measurements above or any of these sets, e.g., the measurements of the previous image during an upgrade."
+	QEMUTDXDoc.Fields[1].Comments[encoder.LineComment] = "Additional sets of expected measurements. An attestation statement is accepted if it matches the measurements above or any of these sets, e.g., the measurements of the previous image during an upgrade."
+	QEMUTDXDoc.Fields[2].Name = "policy"
+	QEMUTDXDoc.Fields[2].Type = "[]PolicyRule"
+	QEMUTDXDoc.Fields[2].Note = ""
+	QEMUTDXDoc.Fields[2].Description = "Additional rules the attestation statement must satisfy. Each rule is a CEL expression over the normalized attestation claims."
+	QEMUTDXDoc.Fields[2].Comments[encoder.LineComment] = "Additional rules the attestation statement must satisfy. Each rule is a CEL expression over the normalized attestation claims."
 
 	AWSSEVSNPDoc.Type = "AWSSEVSNP"
 	AWSSEVSNPDoc.Comments[encoder.LineComment] = "AWSSEVSNP is the configuration for AWS SEV-SNP attestation."
@@ -621,42 +1072,72 @@ func init() {
 			FieldName: "awsSEVSNP",
 		},
 	}
-	AWSSEVSNPDoc.Fields = make([]encoder.Doc, 7)
+	AWSSEVSNPDoc.Fields = make([]encoder.Doc, 13)
 	AWSSEVSNPDoc.Fields[0].Name = "measurements"
 	AWSSEVSNPDoc.Fields[0].Type = "M"
 	AWSSEVSNPDoc.Fields[0].Note = ""
 	AWSSEVSNPDoc.Fields[0].Description = "Expected TPM measurements."
 	AWSSEVSNPDoc.Fields[0].Comments[encoder.LineComment] = "Expected TPM measurements."
-	AWSSEVSNPDoc.Fields[1].Name = "bootloaderVersion"
-	AWSSEVSNPDoc.Fields[1].Type = ""
+	AWSSEVSNPDoc.Fields[1].Name = "acceptedMeasurements"
+	AWSSEVSNPDoc.Fields[1].Type = "[]M"
 	AWSSEVSNPDoc.Fields[1].Note = ""
-	AWSSEVSNPDoc.Fields[1].Description = "Lowest acceptable bootloader version."
-	AWSSEVSNPDoc.Fields[1].Comments[encoder.LineComment] = "Lowest acceptable bootloader version."
-	AWSSEVSNPDoc.Fields[2].Name = "teeVersion"
+	AWSSEVSNPDoc.Fields[1].Description = "Additional sets of expected measurements. An attestation statement is accepted if it matches the measurements above or any of these sets, e.g., the measurements of the previous image during an upgrade."
+	AWSSEVSNPDoc.Fields[1].Comments[encoder.LineComment] = "Additional sets of expected measurements. An attestation statement is accepted if it matches the measurements above or any of these sets, e.g., the measurements of the previous image during an upgrade."
+	AWSSEVSNPDoc.Fields[2].Name = "bootloaderVersion"
 	AWSSEVSNPDoc.Fields[2].Type = ""
 	AWSSEVSNPDoc.Fields[2].Note = ""
-	AWSSEVSNPDoc.Fields[2].Description = "Lowest acceptable TEE version."
-	AWSSEVSNPDoc.Fields[2].Comments[encoder.LineComment] = "Lowest acceptable TEE version."
-	AWSSEVSNPDoc.Fields[3].Name = "snpVersion"
+	AWSSEVSNPDoc.Fields[2].Description = "Lowest acceptable bootloader version."
+	AWSSEVSNPDoc.Fields[2].Comments[encoder.LineComment] = "Lowest acceptable bootloader version."
+	AWSSEVSNPDoc.Fields[3].Name = "teeVersion"
 	AWSSEVSNPDoc.Fields[3].Type = ""
 	AWSSEVSNPDoc.Fields[3].Note = ""
-	AWSSEVSNPDoc.Fields[3].Description = "Lowest acceptable SEV-SNP version."
-	AWSSEVSNPDoc.Fields[3].Comments[encoder.LineComment] = "Lowest acceptable SEV-SNP version."
-	AWSSEVSNPDoc.Fields[4].Name = "microcodeVersion"
+	AWSSEVSNPDoc.Fields[3].Description = "Lowest acceptable TEE version."
+	AWSSEVSNPDoc.Fields[3].Comments[encoder.LineComment] = "Lowest acceptable TEE version."
+	AWSSEVSNPDoc.Fields[4].Name = "snpVersion"
 	AWSSEVSNPDoc.Fields[4].Type = ""
 	AWSSEVSNPDoc.Fields[4].Note = ""
-	AWSSEVSNPDoc.Fields[4].Description = "Lowest acceptable microcode version."
-	AWSSEVSNPDoc.Fields[4].Comments[encoder.LineComment] = "Lowest acceptable microcode version."
-	AWSSEVSNPDoc.Fields[5].Name = "amdRootKey"
-	AWSSEVSNPDoc.Fields[5].Type = "Certificate"
+	AWSSEVSNPDoc.Fields[4].Description = "Lowest acceptable SEV-SNP version."
+	AWSSEVSNPDoc.Fields[4].Comments[encoder.LineComment] = "Lowest acceptable SEV-SNP version."
+	AWSSEVSNPDoc.Fields[5].Name = "microcodeVersion"
+	AWSSEVSNPDoc.Fields[5].Type = ""
 	AWSSEVSNPDoc.Fields[5].Note = ""
-	AWSSEVSNPDoc.Fields[5].Description = "AMD Root Key certificate used to verify the SEV-SNP certificate chain."
-	AWSSEVSNPDoc.Fields[5].Comments[encoder.LineComment] = "AMD Root Key certificate used to verify the SEV-SNP certificate chain."
-	AWSSEVSNPDoc.Fields[6].Name = "amdSigningKey"
-	AWSSEVSNPDoc.Fields[6].Type = "Certificate"
+	AWSSEVSNPDoc.Fields[5].Description = "Lowest acceptable microcode version."
+	AWSSEVSNPDoc.Fields[5].Comments[encoder.LineComment] = "Lowest acceptable microcode version."
+	AWSSEVSNPDoc.Fields[6].Name = "guestPolicy"
+	AWSSEVSNPDoc.Fields[6].Type = "SNPGuestPolicy"
 	AWSSEVSNPDoc.Fields[6].Note = ""
-	AWSSEVSNPDoc.Fields[6].Description = "AMD Signing Key certificate used to verify the SEV-SNP VCEK / VLEK certificate."
-	AWSSEVSNPDoc.Fields[6].Comments[encoder.LineComment] = "AMD Signing Key certificate used to verify the SEV-SNP VCEK / VLEK certificate."
+	AWSSEVSNPDoc.Fields[6].Description = "Guest policy the SEV-SNP attestation report must adhere to. If not set, guests that can be debugged or have a migration agent are rejected."
+	AWSSEVSNPDoc.Fields[6].Comments[encoder.LineComment] = "Guest policy the SEV-SNP attestation report must adhere to. If not set, guests that can be debugged or have a migration agent are rejected."
+	AWSSEVSNPDoc.Fields[7].Name = "platformInfo"
+	AWSSEVSNPDoc.Fields[7].Type = "SNPPlatformInfo"
+	AWSSEVSNPDoc.Fields[7].Note = ""
+	AWSSEVSNPDoc.Fields[7].Description = "Platform features the SEV-SNP attestation report must adhere to. If not set, the platform info isn't checked."
+	AWSSEVSNPDoc.Fields[7].Comments[encoder.LineComment] = "Platform features the SEV-SNP attestation report must adhere to. If not set, the platform info isn't checked."
+	AWSSEVSNPDoc.Fields[8].Name = "checkRevocations"
+	AWSSEVSNPDoc.Fields[8].Type = "bool"
+	AWSSEVSNPDoc.Fields[8].Note = ""
+	AWSSEVSNPDoc.Fields[8].Description = "Check that the VCEK / VLEK and the AMD Signing Key weren't revoked by AMD. The certificate revocation list (CRL) is fetched from the AMD Key Distribution Service, unless 'amdCRL' is set."
+	AWSSEVSNPDoc.Fields[8].Comments[encoder.LineComment] = "Check that the VCEK / VLEK and the AMD Signing Key weren't revoked by AMD. The certificate revocation list (CRL) is fetched from the AMD Key Distribution Service, unless 'amdCRL' is set."
+	AWSSEVSNPDoc.Fields[9].Name = "amdCRL"
+	AWSSEVSNPDoc.Fields[9].Type = "CRL"
+	AWSSEVSNPDoc.Fields[9].Note = ""
+	AWSSEVSNPDoc.Fields[9].Description = "Pinned AMD certificate revocation list (CRL). If set, revocations are checked against this CRL instead of fetching it from the AMD Key Distribution Service, which allows checking revocations without network access. Keep it up to date: a pinned CRL past its next update is still used, but only causes a warning."
+	AWSSEVSNPDoc.Fields[9].Comments[encoder.LineComment] = "Pinned AMD certificate revocation list (CRL). If set, revocations are checked against this CRL instead of fetching it from the AMD Key Distribution Service, which allows checking revocations without network access. Keep it up to date: a pinned CRL past its next update is still used, but only causes a warning."
+	AWSSEVSNPDoc.Fields[10].Name = "amdRootKey"
+	AWSSEVSNPDoc.Fields[10].Type = "Certificate"
+	AWSSEVSNPDoc.Fields[10].Note = ""
+	AWSSEVSNPDoc.Fields[10].Description = "AMD Root Key certificate used to verify the SEV-SNP certificate chain."
+	AWSSEVSNPDoc.Fields[10].Comments[encoder.LineComment] = "AMD Root Key certificate used to verify the SEV-SNP certificate chain."
+	AWSSEVSNPDoc.Fields[11].Name = "amdSigningKey"
+	AWSSEVSNPDoc.Fields[11].Type = "Certificate"
+	AWSSEVSNPDoc.Fields[11].Note = ""
+	AWSSEVSNPDoc.Fields[11].Description = "AMD Signing Key certificate used to verify the SEV-SNP VCEK / VLEK certificate."
+	AWSSEVSNPDoc.Fields[11].Comments[encoder.LineComment] = "AMD Signing Key certificate used to verify the SEV-SNP VCEK / VLEK certificate."
+	AWSSEVSNPDoc.Fields[12].Name = "policy"
+	AWSSEVSNPDoc.Fields[12].Type = "[]PolicyRule"
+	AWSSEVSNPDoc.Fields[12].Note = ""
+	AWSSEVSNPDoc.Fields[12].Description = "Additional rules the attestation statement must satisfy. Each rule is a CEL expression over the normalized attestation claims."
+	AWSSEVSNPDoc.Fields[12].Comments[encoder.LineComment] = "Additional rules the attestation statement must satisfy. Each rule is a CEL expression over the normalized attestation claims."
 
 	AWSNitroTPMDoc.Type = "AWSNitroTPM"
 	AWSNitroTPMDoc.Comments[encoder.LineComment] = "AWSNitroTPM is the configuration for AWS Nitro TPM attestation."
@@ -667,12 +1148,22 @@ func init() {
 			FieldName: "awsNitroTPM",
 		},
 	}
-	AWSNitroTPMDoc.Fields = make([]encoder.Doc, 1)
+	AWSNitroTPMDoc.Fields = make([]encoder.Doc, 3)
 	AWSNitroTPMDoc.Fields[0].Name = "measurements"
 	AWSNitroTPMDoc.Fields[0].Type = "M"
 	AWSNitroTPMDoc.Fields[0].Note = ""
 	AWSNitroTPMDoc.Fields[0].Description = "Expected TPM measurements."
 	AWSNitroTPMDoc.Fields[0].Comments[encoder.LineComment] = "Expected TPM measurements."
+	AWSNitroTPMDoc.Fields[1].Name = "acceptedMeasurements"
+	AWSNitroTPMDoc.Fields[1].Type = "[]M"
+	AWSNitroTPMDoc.Fields[1].Note = ""
+	AWSNitroTPMDoc.Fields[1].Description = "Additional sets of expected measurements. An attestation statement is accepted if it matches the measurements above or any of these sets, e.g., the measurements of the previous image during an upgrade."
+	AWSNitroTPMDoc.Fields[1].Comments[encoder.LineComment] = "Additional sets of expected measurements. An attestation statement is accepted if it matches the measurements above or any of these sets, e.g., the measurements of the previous image during an upgrade."
+	AWSNitroTPMDoc.Fields[2].Name = "policy"
+	AWSNitroTPMDoc.Fields[2].Type = "[]PolicyRule"
+	AWSNitroTPMDoc.Fields[2].Note = ""
+	AWSNitroTPMDoc.Fields[2].Description = "Additional rules the attestation statement must satisfy. Each rule is a CEL expression over the normalized attestation claims."
+	AWSNitroTPMDoc.Fields[2].Comments[encoder.LineComment] = "Additional rules the attestation statement must satisfy. Each rule is a CEL expression over the normalized attestation claims."
 
 	AzureSEVSNPDoc.Type = "AzureSEVSNP"
 	AzureSEVSNPDoc.Comments[encoder.LineComment] = "AzureSEVSNP is the configuration for Azure SEV-SNP attestation."
@@ -683,47 +1174,138 @@ func init() {
 			FieldName: "azureSEVSNP",
 		},
 	}
-	AzureSEVSNPDoc.Fields = make([]encoder.Doc, 8)
+	AzureSEVSNPDoc.Fields = make([]encoder.Doc, 14)
 	AzureSEVSNPDoc.Fields[0].Name = "measurements"
 	AzureSEVSNPDoc.Fields[0].Type = "M"
 	AzureSEVSNPDoc.Fields[0].Note = ""
 	AzureSEVSNPDoc.Fields[0].Description = "Expected TPM measurements."
 	AzureSEVSNPDoc.Fields[0].Comments[encoder.LineComment] = "Expected TPM measurements."
-	AzureSEVSNPDoc.Fields[1].Name = "bootloaderVersion"
-	AzureSEVSNPDoc.Fields[1].Type = ""
+	AzureSEVSNPDoc.Fields[1].Name = "acceptedMeasurements"
+	AzureSEVSNPDoc.Fields[1].Type = "[]M"
 	AzureSEVSNPDoc.Fields[1].Note = ""
-	AzureSEVSNPDoc.Fields[1].Description = "Lowest acceptable bootloader version."
-	AzureSEVSNPDoc.Fields[1].Comments[encoder.LineComment] = "Lowest acceptable bootloader version."
-	AzureSEVSNPDoc.Fields[2].Name = "teeVersion"
+	AzureSEVSNPDoc.Fields[1].Description = "Additional sets of expected measurements. An attestation statement is accepted if it matches the measurements above or any of these sets, e.g., the measurements of the previous image during an upgrade."
+	AzureSEVSNPDoc.Fields[1].Comments[encoder.LineComment] = "Additional sets of expected measurements. An attestation statement is accepted if it matches the measurements above or any of these sets, e.g., the measurements of the previous image during an upgrade."
+	AzureSEVSNPDoc.Fields[2].Name = "bootloaderVersion"
 	AzureSEVSNPDoc.Fields[2].Type = ""
 	AzureSEVSNPDoc.Fields[2].Note = ""
-	AzureSEVSNPDoc.Fields[2].Description = "Lowest acceptable TEE version."
-	AzureSEVSNPDoc.Fields[2].Comments[encoder.LineComment] = "Lowest acceptable TEE version."
-	AzureSEVSNPDoc.Fields[3].Name = "snpVersion"
+	AzureSEVSNPDoc.Fields[2].Description = "Lowest acceptable bootloader version."
+	AzureSEVSNPDoc.Fields[2].Comments[encoder.LineComment] = "Lowest acceptable bootloader version."
+	AzureSEVSNPDoc.Fields[3].Name = "teeVersion"
 	AzureSEVSNPDoc.Fields[3].Type = ""
 	AzureSEVSNPDoc.Fields[3].Note = ""
-	AzureSEVSNPDoc.Fields[3].Description = "Lowest acceptable SEV-SNP version."
-	AzureSEVSNPDoc.Fields[3].Comments[encoder.LineComment] = "Lowest acceptable SEV-SNP version."
-	AzureSEVSNPDoc.Fields[4].Name = "microcodeVersion"
+	AzureSEVSNPDoc.Fields[3].Description = "Lowest acceptable TEE version."
+	AzureSEVSNPDoc.Fields[3].Comments[encoder.LineComment] = "Lowest acceptable TEE version."
+	AzureSEVSNPDoc.Fields[4].Name = "snpVersion"
 	AzureSEVSNPDoc.Fields[4].Type = ""
 	AzureSEVSNPDoc.Fields[4].Note = ""
-	AzureSEVSNPDoc.Fields[4].Description = "Lowest acceptable microcode version."
-	AzureSEVSNPDoc.Fields[4].Comments[encoder.LineComment] = "Lowest acceptable microcode version."
-	AzureSEVSNPDoc.Fields[5].Name = "firmwareSignerConfig"
-	AzureSEVSNPDoc.Fields[5].Type = "SNPFirmwareSignerConfig"
+	AzureSEVSNPDoc.Fields[4].Description = "Lowest acceptable SEV-SNP version."
+	AzureSEVSNPDoc.Fields[4].Comments[encoder.LineComment] = "Lowest acceptable SEV-SNP version."
+	AzureSEVSNPDoc.Fields[5].Name = "microcodeVersion"
+	AzureSEVSNPDoc.Fields[5].Type = ""
 	AzureSEVSNPDoc.Fields[5].Note = ""
-	AzureSEVSNPDoc.Fields[5].Description = "Configuration for validating the firmware signature."
-	AzureSEVSNPDoc.Fields[5].Comments[encoder.LineComment] = "Configuration for validating the firmware signature."
-	AzureSEVSNPDoc.Fields[6].Name = "amdRootKey"
-	AzureSEVSNPDoc.Fields[6].Type = "Certificate"
+	AzureSEVSNPDoc.Fields[5].Description = "Lowest acceptable microcode version."
+	AzureSEVSNPDoc.Fields[5].Comments[encoder.LineComment] = "Lowest acceptable microcode version."
+	AzureSEVSNPDoc.Fields[6].Name = "firmwareSignerConfig"
+	AzureSEVSNPDoc.Fields[6].Type = "SNPFirmwareSignerConfig"
 	AzureSEVSNPDoc.Fields[6].Note = ""
-	AzureSEVSNPDoc.Fields[6].Description = "AMD Root Key certificate used to verify the SEV-SNP certificate chain."
-	AzureSEVSNPDoc.Fields[6].Comments[encoder.LineComment] = "AMD Root Key certificate used to verify the SEV-SNP certificate chain."
-	AzureSEVSNPDoc.Fields[7].Name = "amdSigningKey"
-	AzureSEVSNPDoc.Fields[7].Type = "Certificate"
+	AzureSEVSNPDoc.Fields[6].Description = "Configuration for validating the firmware signature."
+	AzureSEVSNPDoc.Fields[6].Comments[encoder.LineComment] = "Configuration for validating the firmware signature."
+	AzureSEVSNPDoc.Fields[7].Name = "guestPolicy"
+	AzureSEVSNPDoc.Fields[7].Type = "SNPGuestPolicy"
 	AzureSEVSNPDoc.Fields[7].Note = ""
-	AzureSEVSNPDoc.Fields[7].Description = "AMD Signing Key certificate used to verify the SEV-SNP VCEK / VLEK certificate."
-	AzureSEVSNPDoc.Fields[7].Comments[encoder.LineComment] = "AMD Signing Key certificate used to verify the SEV-SNP VCEK / VLEK certificate."
+	AzureSEVSNPDoc.Fields[7].Description = "Guest policy the SEV-SNP attestation report must adhere to. If not set, guests that can be debugged or have a migration agent are rejected."
+	AzureSEVSNPDoc.Fields[7].Comments[encoder.LineComment] = "Guest policy the SEV-SNP attestation report must adhere to. If not set, guests that can be debugged or have a migration agent are rejected."
+	AzureSEVSNPDoc.Fields[8].Name = "platformInfo"
+	AzureSEVSNPDoc.Fields[8].Type = "SNPPlatformInfo"
+	AzureSEVSNPDoc.Fields[8].Note = ""
+	AzureSEVSNPDoc.Fields[8].Description = "Platform features the SEV-SNP attestation report must adhere to. If not set, the platform info isn't checked."
+	AzureSEVSNPDoc.Fields[8].Comments[encoder.LineComment] = "Platform features the SEV-SNP attestation report must adhere to. If not set, the platform info isn't checked."
+	AzureSEVSNPDoc.Fields[9].Name = "checkRevocations"
+	AzureSEVSNPDoc.Fields[9].Type = "bool"
+	AzureSEVSNPDoc.Fields[9].Note = ""
+	AzureSEVSNPDoc.Fields[9].Description = "Check that the VCEK / VLEK and the AMD Signing Key weren't revoked by AMD. The certificate revocation list (CRL) is fetched from the AMD Key Distribution Service, unless 'amdCRL' is set."
+	AzureSEVSNPDoc.Fields[9].Comments[encoder.LineComment] = "Check that the VCEK / VLEK and the AMD Signing Key weren't revoked by AMD. The certificate revocation list (CRL) is fetched from the AMD Key Distribution Service, unless 'amdCRL' is set."
+	AzureSEVSNPDoc.Fields[10].Name = "amdCRL"
+	AzureSEVSNPDoc.Fields[10].Type = "CRL"
+	AzureSEVSNPDoc.Fields[10].Note = ""
+	AzureSEVSNPDoc.Fields[10].Description = "Pinned AMD certificate revocation list (CRL). If set, revocations are checked against this CRL instead of fetching it from the AMD Key Distribution Service, which allows checking revocations without network access. Keep it up to date: a pinned CRL past its next update is still used, but only causes a warning."
+	AzureSEVSNPDoc.Fields[10].Comments[encoder.LineComment] = "Pinned AMD certificate revocation list (CRL). If set, revocations are checked against this CRL instead of fetching it from the AMD Key Distribution Service, which allows checking revocations without network access. Keep it up to date: a pinned CRL past its next update is still used, but only causes a warning."
+	AzureSEVSNPDoc.Fields[11].Name = "amdRootKey"
+	AzureSEVSNPDoc.Fields[11].Type = "Certificate"
+	AzureSEVSNPDoc.Fields[11].Note = ""
+	AzureSEVSNPDoc.Fields[11].Description = "AMD Root Key certificate used to verify the SEV-SNP certificate chain."
+	AzureSEVSNPDoc.Fields[11].Comments[encoder.LineComment] = "AMD Root Key certificate used to verify the SEV-SNP certificate chain."
+	AzureSEVSNPDoc.Fields[12].Name = "amdSigningKey"
+	AzureSEVSNPDoc.Fields[12].Type = "Certificate"
+	AzureSEVSNPDoc.Fields[12].Note = ""
+	AzureSEVSNPDoc.Fields[12].Description = "AMD Signing Key certificate used to verify the SEV-SNP VCEK / VLEK certificate."
+	AzureSEVSNPDoc.Fields[12].Comments[encoder.LineComment] = "AMD Signing Key certificate used to verify the SEV-SNP VCEK / VLEK certificate."
+	AzureSEVSNPDoc.Fields[13].Name = "policy"
+	AzureSEVSNPDoc.Fields[13].Type = "[]PolicyRule"
+	AzureSEVSNPDoc.Fields[13].Note = ""
+	AzureSEVSNPDoc.Fields[13].Description = "Additional rules the attestation statement must satisfy. Each rule is a CEL expression over the normalized attestation claims."
+	AzureSEVSNPDoc.Fields[13].Comments[encoder.LineComment] = "Additional rules the attestation statement must satisfy. Each rule is a CEL expression over the normalized attestation claims."
+
+	GCPTDXDoc.Type = "GCPTDX"
+	GCPTDXDoc.Comments[encoder.LineComment] = "GCPTDX is the configuration for GCP TDX attestation."
+	GCPTDXDoc.Description = "GCPTDX is the configuration for GCP TDX attestation."
+	GCPTDXDoc.AppearsIn = []encoder.Appearance{
+		{
+			TypeName:  "AttestationConfig",
+			FieldName: "gcpTDX",
+		},
+	}
+	GCPTDXDoc.Fields = make([]encoder.Doc, 10)
+	GCPTDXDoc.Fields[0].Name = "measurements"
+	GCPTDXDoc.Fields[0].Type = "M"
+	GCPTDXDoc.Fields[0].Note = ""
+	GCPTDXDoc.Fields[0].Description = "Expected TPM measurements."
+	GCPTDXDoc.Fields[0].Comments[encoder.LineComment] = "Expected TPM measurements."
+	GCPTDXDoc.Fields[1].Name = "acceptedMeasurements"
+	GCPTDXDoc.Fields[1].Type = "[]M"
+	GCPTDXDoc.Fields[1].Note = ""
+	GCPTDXDoc.Fields[1].Description = "Additional sets of expected measurements. An attestation statement is accepted if it matches the measurements above or any of these sets, e.g., the measurements of the previous image during an upgrade."
+	GCPTDXDoc.Fields[1].Comments[encoder.LineComment] = "Additional sets of expected measurements. An attestation statement is accepted if it matches the measurements above or any of these sets, e.g., the measurements of the previous image during an upgrade."
+	GCPTDXDoc.Fields[2].Name = "qeSVN"
+	GCPTDXDoc.Fields[2].Type = ""
+	GCPTDXDoc.Fields[2].Note = ""
+	GCPTDXDoc.Fields[2].Description = "Minimum required QE security version number (SVN)."
+	GCPTDXDoc.Fields[2].Comments[encoder.LineComment] = "Minimum required QE security version number (SVN)."
+	GCPTDXDoc.Fields[3].Name = "pceSVN"
+	GCPTDXDoc.Fields[3].Type = ""
+	GCPTDXDoc.Fields[3].Note = ""
+	GCPTDXDoc.Fields[3].Description = "Minimum required PCE security version number (SVN)."
+	GCPTDXDoc.Fields[3].Comments[encoder.LineComment] = "Minimum required PCE security version number (SVN)."
+	GCPTDXDoc.Fields[4].Name = "teeTCBSVN"
+	GCPTDXDoc.Fields[4].Type = ""
+	GCPTDXDoc.Fields[4].Note = ""
+	GCPTDXDoc.Fields[4].Description = "Component-wise minimum required 16 byte hex-encoded TEE_TCB security version number (SVN)."
+	GCPTDXDoc.Fields[4].Comments[encoder.LineComment] = "Component-wise minimum required 16 byte hex-encoded TEE_TCB security version number (SVN)."
+	GCPTDXDoc.Fields[5].Name = "qeVendorID"
+	GCPTDXDoc.Fields[5].Type = ""
+	GCPTDXDoc.Fields[5].Note = ""
+	GCPTDXDoc.Fields[5].Description = "Expected 16 byte hex-encoded QE_VENDOR_ID field."
+	GCPTDXDoc.Fields[5].Comments[encoder.LineComment] = "Expected 16 byte hex-encoded QE_VENDOR_ID field."
+	GCPTDXDoc.Fields[6].Name = "mrSeam"
+	GCPTDXDoc.Fields[6].Type = "HexBytes"
+	GCPTDXDoc.Fields[6].Note = ""
+	GCPTDXDoc.Fields[6].Description = "Expected 48 byte hex-encoded MR_SEAM value."
+	GCPTDXDoc.Fields[6].Comments[encoder.LineComment] = "Expected 48 byte hex-encoded MR_SEAM value."
+	GCPTDXDoc.Fields[7].Name = "xfam"
+	GCPTDXDoc.Fields[7].Type = ""
+	GCPTDXDoc.Fields[7].Note = ""
+	GCPTDXDoc.Fields[7].Description = "Expected 8 byte hex-encoded eXtended Features Available Mask (XFAM) field. Defaults to the latest available XFAM on GCP VMs. Unset to disable validation."
+	GCPTDXDoc.Fields[7].Comments[encoder.LineComment] = "Expected 8 byte hex-encoded eXtended Features Available Mask (XFAM) field. Defaults to the latest available XFAM on GCP VMs. Unset to disable validation."
+	GCPTDXDoc.Fields[8].Name = "intelRootKey"
+	GCPTDXDoc.Fields[8].Type = "Certificate"
+	GCPTDXDoc.Fields[8].Note = ""
+	GCPTDXDoc.Fields[8].Description = "Intel Root Key certificate used to verify the TDX certificate chain."
+	GCPTDXDoc.Fields[8].Comments[encoder.LineComment] = "Intel Root Key certificate used to verify the TDX certificate chain."
+	GCPTDXDoc.Fields[9].Name = "policy"
+	GCPTDXDoc.Fields[9].Type = "[]PolicyRule"
+	GCPTDXDoc.Fields[9].Note = ""
+	GCPTDXDoc.Fields[9].Description = "Additional rules the attestation statement must satisfy. Each rule is a CEL expression over the normalized attestation claims."
+	GCPTDXDoc.Fields[9].Comments[encoder.LineComment] = "Additional rules the attestation statement must satisfy. Each rule is a CEL expression over the normalized attestation claims."
 
 	AzureTrustedLaunchDoc.Type = "AzureTrustedLaunch"
 	AzureTrustedLaunchDoc.Comments[encoder.LineComment] = "AzureTrustedLaunch is the configuration for Azure Trusted Launch attestation."
@@ -734,12 +1316,22 @@ func init() {
 			FieldName: "azureTrustedLaunch",
 		},
 	}
-	AzureTrustedLaunchDoc.Fields = make([]encoder.Doc, 1)
+	AzureTrustedLaunchDoc.Fields = make([]encoder.Doc, 3)
 	AzureTrustedLaunchDoc.Fields[0].Name = "measurements"
 	AzureTrustedLaunchDoc.Fields[0].Type = "M"
 	AzureTrustedLaunchDoc.Fields[0].Note = ""
 	AzureTrustedLaunchDoc.Fields[0].Description = "Expected TPM measurements."
 	AzureTrustedLaunchDoc.Fields[0].Comments[encoder.LineComment] = "Expected TPM measurements."
+	AzureTrustedLaunchDoc.Fields[1].Name = "acceptedMeasurements"
+	AzureTrustedLaunchDoc.Fields[1].Type = "[]M"
+	AzureTrustedLaunchDoc.Fields[1].Note = ""
+	AzureTrustedLaunchDoc.Fields[1].Description = "Additional sets of expected measurements. An attestation statement is accepted if it matches the measurements above or any of these sets, e.g., the measurements of the previous image during an upgrade."
+	AzureTrustedLaunchDoc.Fields[1].Comments[encoder.LineComment] = "Additional sets of expected measurements. An attestation statement is accepted if it matches the measurements above or any of these sets, e.g., the measurements of the previous image during an upgrade."
+	AzureTrustedLaunchDoc.Fields[2].Name = "policy"
+	AzureTrustedLaunchDoc.Fields[2].Type = "[]PolicyRule"
+	AzureTrustedLaunchDoc.Fields[2].Note = ""
+	AzureTrustedLaunchDoc.Fields[2].Description = "Additional rules the attestation statement must satisfy. Each rule is a CEL expression over the normalized attestation claims."
+	AzureTrustedLaunchDoc.Fields[2].Comments[encoder.LineComment] = "Additional rules the attestation statement must satisfy. Each rule is a CEL expression over the normalized attestation claims."
 
 	AzureTDXDoc.Type = "AzureTDX"
 	AzureTDXDoc.Comments[encoder.LineComment] = "AzureTDX is the configuration for Azure TDX attestation."
@@ -750,47 +1342,57 @@ func init() {
 			FieldName: "azureTDX",
 		},
 	}
-	AzureTDXDoc.Fields = make([]encoder.Doc, 8)
+	AzureTDXDoc.Fields = make([]encoder.Doc, 10)
 	AzureTDXDoc.Fields[0].Name = "measurements"
 	AzureTDXDoc.Fields[0].Type = "M"
 	AzureTDXDoc.Fields[0].Note = ""
 	AzureTDXDoc.Fields[0].Description = "Expected TPM measurements."
 	AzureTDXDoc.Fields[0].Comments[encoder.LineComment] = "Expected TPM measurements."
-	AzureTDXDoc.Fields[1].Name = "qeSVN"
-	AzureTDXDoc.Fields[1].Type = ""
+	AzureTDXDoc.Fields[1].Name = "acceptedMeasurements"
+	AzureTDXDoc.Fields[1].Type = "[]M"
 	AzureTDXDoc.Fields[1].Note = ""
-	AzureTDXDoc.Fields[1].Description = "Minimum required QE security version number (SVN)."
-	AzureTDXDoc.Fields[1].Comments[encoder.LineComment] = "Minimum required QE security version number (SVN)."
-	AzureTDXDoc.Fields[2].Name = "pceSVN"
+	AzureTDXDoc.Fields[1].Description = "Additional sets of expected measurements. An attestation statement is accepted if it matches the measurements above or any of these sets, e.g., the measurements of the previous image during an upgrade."
+	AzureTDXDoc.Fields[1].Comments[encoder.LineComment] = "Additional sets of expected measurements. An attestation statement is accepted if it matches the measurements above or any of these sets, e.g., the measurements of the previous image during an upgrade."
+	AzureTDXDoc.Fields[2].Name = "qeSVN"
 	AzureTDXDoc.Fields[2].Type = ""
 	AzureTDXDoc.Fields[2].Note = ""
-	AzureTDXDoc.Fields[2].Description = "Minimum required PCE security version number (SVN)."
-	AzureTDXDoc.Fields[2].Comments[encoder.LineComment] = "Minimum required PCE security version number (SVN)."
-	AzureTDXDoc.Fields[3].Name = "teeTCBSVN"
+	AzureTDXDoc.Fields[2].Description = "Minimum required QE security version number (SVN)."
+	AzureTDXDoc.Fields[2].Comments[encoder.LineComment] = "Minimum required QE security version number (SVN)."
+	AzureTDXDoc.Fields[3].Name = "pceSVN"
 	AzureTDXDoc.Fields[3].Type = ""
 	AzureTDXDoc.Fields[3].Note = ""
-	AzureTDXDoc.Fields[3].Description = "Component-wise minimum required 16 byte hex-encoded TEE_TCB security version number (SVN)."
-	AzureTDXDoc.Fields[3].Comments[encoder.LineComment] = "Component-wise minimum required 16 byte hex-encoded TEE_TCB security version number (SVN)."
-	AzureTDXDoc.Fields[4].Name = "qeVendorID"
+	AzureTDXDoc.Fields[3].Description = "Minimum required PCE security version number (SVN)."
+	AzureTDXDoc.Fields[3].Comments[encoder.LineComment] = "Minimum required PCE security version number (SVN)."
+	AzureTDXDoc.Fields[4].Name = "teeTCBSVN"
 	AzureTDXDoc.Fields[4].Type = ""
 	AzureTDXDoc.Fields[4].Note = ""
-	AzureTDXDoc.Fields[4].Description = "Expected 16 byte hex-encoded QE_VENDOR_ID field."
-	AzureTDXDoc.Fields[4].Comments[encoder.LineComment] = "Expected 16 byte hex-encoded QE_VENDOR_ID field."
-	AzureTDXDoc.Fields[5].Name = "mrSeam"
-	AzureTDXDoc.Fields[5].Type = "HexBytes"
+	AzureTDXDoc.Fields[4].Description = "Component-wise minimum required 16 byte hex-encoded TEE_TCB security version number (SVN)."
+	AzureTDXDoc.Fields[4].Comments[encoder.LineComment] = "Component-wise minimum required 16 byte hex-encoded TEE_TCB security version number (SVN)."
+	AzureTDXDoc.Fields[5].Name = "qeVendorID"
+	AzureTDXDoc.Fields[5].Type = ""
 	AzureTDXDoc.Fields[5].Note = ""
-	AzureTDXDoc.Fields[5].Description = "Expected 48 byte hex-encoded MR_SEAM value."
-	AzureTDXDoc.Fields[5].Comments[encoder.LineComment] = "Expected 48 byte hex-encoded MR_SEAM value."
-	AzureTDXDoc.Fields[6].Name = "xfam"
-	AzureTDXDoc.Fields[6].Type = ""
+	AzureTDXDoc.Fields[5].Description = "Expected 16 byte hex-encoded QE_VENDOR_ID field."
+	AzureTDXDoc.Fields[5].Comments[encoder.LineComment] = "Expected 16 byte hex-encoded QE_VENDOR_ID field."
+	AzureTDXDoc.Fields[6].Name = "mrSeam"
+	AzureTDXDoc.Fields[6].Type = "HexBytes"
 	AzureTDXDoc.Fields[6].Note = ""
-	AzureTDXDoc.Fields[6].Description = "Expected 8 byte hex-encoded eXtended Features Available Mask (XFAM) field. Defaults to the latest available XFAM on Azure VMs. Unset to disable validation."
-	AzureTDXDoc.Fields[6].Comments[encoder.LineComment] = "Expected 8 byte hex-encoded eXtended Features Available Mask (XFAM) field. Defaults to the latest available XFAM on Azure VMs. Unset to disable validation."
-	AzureTDXDoc.Fields[7].Name = "intelRootKey"
-	AzureTDXDoc.Fields[7].Type = "Certificate"
+	AzureTDXDoc.Fields[6].Description = "Expected 48 byte hex-encoded MR_SEAM value."
+	AzureTDXDoc.Fields[6].Comments[encoder.LineComment] = "Expected 48 byte hex-encoded MR_SEAM value."
+	AzureTDXDoc.Fields[7].Name = "xfam"
+	AzureTDXDoc.Fields[7].Type = ""
 	AzureTDXDoc.Fields[7].Note = ""
-	AzureTDXDoc.Fields[7].Description = "Intel Root Key certificate used to verify the TDX certificate chain."
-	AzureTDXDoc.Fields[7].Comments[encoder.LineComment] = "Intel Root Key certificate used to verify the TDX certificate chain."
+	AzureTDXDoc.Fields[7].Description = "Expected 8 byte hex-encoded eXtended Features Available Mask (XFAM) field. Defaults to the latest available XFAM on Azure VMs. Unset to disable validation."
+	AzureTDXDoc.Fields[7].Comments[encoder.LineComment] = "Expected 8 byte hex-encoded eXtended Features Available Mask (XFAM) field. Defaults to the latest available XFAM on Azure VMs. Unset to disable validation."
+	AzureTDXDoc.Fields[8].Name = "intelRootKey"
+	AzureTDXDoc.Fields[8].Type = "Certificate"
+	AzureTDXDoc.Fields[8].Note = ""
+	AzureTDXDoc.Fields[8].Description = "Intel Root Key certificate used to verify the TDX certificate chain."
+	AzureTDXDoc.Fields[8].Comments[encoder.LineComment] = "Intel Root Key certificate used to verify the TDX certificate chain."
+	AzureTDXDoc.Fields[9].Name = "policy"
+	AzureTDXDoc.Fields[9].Type = "[]PolicyRule"
+	AzureTDXDoc.Fields[9].Note = ""
+	AzureTDXDoc.Fields[9].Description = "Additional rules the attestation statement must satisfy. Each rule is a CEL expression over the normalized attestation claims."
+	AzureTDXDoc.Fields[9].Comments[encoder.LineComment] = "Additional rules the attestation statement must satisfy. Each rule is a CEL expression over the normalized attestation claims."
 }
 
 func (_ Config) Doc() *encoder.Doc {
@@ -829,6 +1431,22 @@ func (_ NodeGroup) Doc() *encoder.Doc {
 	return &NodeGroupDoc
 }
 
+func (_ NodeTaint) Doc() *encoder.Doc {
+	return &NodeTaintDoc
+}
+
+func (_ KubeletConfig) Doc() *encoder.Doc {
+	return &KubeletConfigDoc
+}
+
+func (_ KMSConfig) Doc() *encoder.Doc {
+	return &KMSConfigDoc
+}
+
+func (_ KMIPConfig) Doc() *encoder.Doc {
+	return &KMIPConfigDoc
+}
+
 func (_ UnsupportedAppRegistrationError) Doc() *encoder.Doc {
 	return &UnsupportedAppRegistrationErrorDoc
 }
@@ -837,6 +1455,18 @@ func (_ SNPFirmwareSignerConfig) Doc() *encoder.Doc {
 	return &SNPFirmwareSignerConfigDoc
 }
 
+func (_ SNPGuestPolicy) Doc() *encoder.Doc {
+	return &SNPGuestPolicyDoc
+}
+
+func (_ SNPPlatformInfo) Doc() *encoder.Doc {
+	return &SNPPlatformInfoDoc
+}
+
+func (_ PolicyRule) Doc() *encoder.Doc {
+	return &PolicyRuleDoc
+}
+
 func (_ GCPSEVES) Doc() *encoder.Doc {
 	return &GCPSEVESDoc
 }
@@ -849,6 +1479,10 @@ func (_ QEMUVTPM) Doc() *encoder.Doc {
 	return &QEMUVTPMDoc
 }
 
+func (_ QEMUSEVSNP) Doc() *encoder.Doc {
+	return &QEMUSEVSNPDoc
+}
+
 func (_ QEMUTDX) Doc() *encoder.Doc {
 	return &QEMUTDXDoc
 }
@@ -865,6 +1499,10 @@ func (_ AzureSEVSNP) Doc() *encoder.Doc {
 	return &AzureSEVSNPDoc
 }
 
+func (_ GCPTDX) Doc() *encoder.Doc {
+	return &GCPTDXDoc
+}
+
 func (_ AzureTrustedLaunch) Doc() *encoder.Doc {
 	return &AzureTrustedLaunchDoc
 }
@@ -888,15 +1526,24 @@ func GetConfigurationDoc() *encoder.FileDoc {
 			&QEMUConfigDoc,
 			&AttestationConfigDoc,
 			&NodeGroupDoc,
+			&NodeTaintDoc,
+			&KubeletConfigDoc,
+			&KMSConfigDoc,
+			&KMIPConfigDoc,
 			&UnsupportedAppRegistrationErrorDoc,
 			&SNPFirmwareSignerConfigDoc,
+			&SNPGuestPolicyDoc,
+			&SNPPlatformInfoDoc,
+			&PolicyRuleDoc,
 			&GCPSEVESDoc,
 			&GCPSEVSNPDoc,
 			&QEMUVTPMDoc,
+			&QEMUSEVSNPDoc,
 			&QEMUTDXDoc,
 			&AWSSEVSNPDoc,
 			&AWSNitroTPMDoc,
 			&AzureSEVSNPDoc,
+			&GCPTDXDoc,
 			&AzureTrustedLaunchDoc,
 			&AzureTDXDoc,
 		},
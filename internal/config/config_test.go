@@ -328,7 +328,7 @@ func TestFromFile(t *testing.T) {
 }
 
 func TestValidate(t *testing.T) {
-	const defaultErrCount = 33 // expect this number of error messages by default because user-specific values are not set and multiple providers are defined by default
+	const defaultErrCount = 35 // expect this number of error messages by default because user-specific values are not set and multiple providers are defined by default
 	const azErrCount = 7
 	const awsErrCount = 8
 	const gcpErrCount = 8
@@ -491,6 +491,134 @@ func TestValidate(t *testing.T) {
 				return cnf
 			}(),
 		},
+		"KMS config without backend": {
+			cnf: func() *Config {
+				cnf := Default()
+				cnf.Image = ""
+				cnf.KMS = &KMSConfig{}
+				return cnf
+			}(),
+			wantErr:      true,
+			wantErrCount: defaultErrCount + 1,
+		},
+		"invalid KMIP config": {
+			cnf: func() *Config {
+				cnf := Default()
+				cnf.Image = ""
+				cnf.KMS = &KMSConfig{
+					KMIP: &KMIPConfig{
+						Endpoint:   "kmip.example.com",
+						StorageURI: "kms://vault",
+					},
+				}
+				return cnf
+			}(),
+			wantErr:      true,
+			wantErrCount: defaultErrCount + 6,
+		},
+		"valid KMIP config": {
+			cnf: func() *Config {
+				cnf := Default()
+				cnf.Image = ""
+				cnf.KMS = &KMSConfig{
+					KMIP: &KMIPConfig{
+						Endpoint:       "kmip.example.com:5696",
+						KeyName:        "constellation-kek",
+						CACertPath:     "ca.pem",
+						ClientCertPath: "client.pem",
+						ClientKeyPath:  "client-key.pem",
+						StorageURI:     "storage://vault?address=https%3A%2F%2Fvault.example.com",
+					},
+				}
+				return cnf
+			}(),
+			wantErr:      true,
+			wantErrCount: defaultErrCount,
+		},
+		"valid node group settings": {
+			cnf: func() *Config {
+				cnf := Default()
+				cnf.Image = ""
+				group := cnf.NodeGroups[constants.WorkerDefault]
+				group.Labels = map[string]string{"example.com/pool": "gpu", "node.kubernetes.io/exclude-from-external-load-balancers": "true"}
+				group.Taints = []NodeTaint{{Key: "example.com/dedicated", Value: "gpu", Effect: "NoSchedule"}}
+				group.Kubelet = KubeletConfig{
+					MaxPods:        50,
+					SystemReserved: map[string]string{"cpu": "500m", "memory": "512Mi"},
+					KubeReserved:   map[string]string{"pid": "1000"},
+				}
+				cnf.NodeGroups[constants.WorkerDefault] = group
+				return cnf
+			}(),
+			wantErr:      true,
+			wantErrCount: defaultErrCount,
+		},
+		"invalid node group settings": {
+			cnf: func() *Config {
+				cnf := Default()
+				cnf.Image = ""
+				group := cnf.NodeGroups[constants.WorkerDefault]
+				group.Labels = map[string]string{"node-role.kubernetes.io/gpu": ""}
+				group.Taints = []NodeTaint{{Effect: "NoSchedule"}, {Key: "example.com/dedicated", Effect: "Evict"}}
+				group.Kubelet = KubeletConfig{
+					MaxPods:        -1,
+					SystemReserved: map[string]string{"gpu": "1"},
+					KubeReserved:   map[string]string{"memory": "lots"},
+				}
+				cnf.NodeGroups[constants.WorkerDefault] = group
+				return cnf
+			}(),
+			wantErr:      true,
+			wantErrCount: defaultErrCount + 6,
+		},
+		"valid attestation policy": {
+			cnf: func() *Config {
+				cnf := Default()
+				cnf.Image = ""
+				cnf.Attestation.QEMUVTPM.Policy = []PolicyRule{
+					{Name: "pcr15", Expression: `claims.measurements[15] in ["00", "01"]`},
+				}
+				return cnf
+			}(),
+			wantErr:      true,
+			wantErrCount: defaultErrCount,
+		},
+		"accepted measurements with placeholders": {
+			cnf: func() *Config {
+				cnf := Default()
+				cnf.Image = ""
+				cnf.Attestation.QEMUVTPM.AcceptedMeasurements = []measurements.M{
+					{4: measurements.PlaceHolderMeasurement(measurements.PCRMeasurementLength)},
+				}
+				return cnf
+			}(),
+			wantErr:      true,
+			wantErrCount: defaultErrCount + 1,
+		},
+		"empty accepted measurements": {
+			cnf: func() *Config {
+				cnf := Default()
+				cnf.Image = ""
+				cnf.Attestation.QEMUVTPM.AcceptedMeasurements = []measurements.M{{}}
+				return cnf
+			}(),
+			wantErr:      true,
+			wantErrCount: defaultErrCount + 1,
+		},
+		"invalid attestation policy": {
+			cnf: func() *Config {
+				cnf := Default()
+				cnf.Image = ""
+				cnf.Attestation.QEMUVTPM.Policy = []PolicyRule{
+					{Name: "broken", Expression: `claims.measurements[15] ==`},
+					{Name: "no bool", Expression: `1 + 1`},
+					{Expression: `true`},
+				}
+				return cnf
+			}(),
+			wantErr:      true,
+			wantErrCount: defaultErrCount + 3,
+		},
 		"miniup default config is not valid because image and measurements are missing in OSS": {
 			cnf: func() *Config {
 				config, _ := MiniDefault()
@@ -642,6 +770,57 @@ func TestConfig_UpdateMeasurements(t *testing.T) {
 	}
 }
 
+func TestConfig_AppendMeasurements(t *testing.T) {
+	oldMeasurements := measurements.M{
+		1: measurements.WithAllBytes(0x00, measurements.Enforce, measurements.PCRMeasurementLength),
+		2: measurements.WithAllBytes(0x01, measurements.Enforce, measurements.PCRMeasurementLength),
+	}
+	newMeasurements := measurements.M{
+		1: measurements.WithAllBytes(0x00, measurements.Enforce, measurements.PCRMeasurementLength),
+		2: measurements.WithAllBytes(0x02, measurements.Enforce, measurements.PCRMeasurementLength),
+	}
+
+	testCases := map[string]struct {
+		accepted     []measurements.M
+		measurements measurements.M
+		wantAccepted []measurements.M
+	}{
+		"previous measurements are kept": {
+			measurements: newMeasurements,
+			wantAccepted: []measurements.M{oldMeasurements},
+		},
+		"equal measurements are not duplicated": {
+			accepted:     []measurements.M{oldMeasurements},
+			measurements: newMeasurements,
+			wantAccepted: []measurements.M{oldMeasurements},
+		},
+		"unchanged measurements": {
+			measurements: oldMeasurements,
+		},
+		"new measurements are removed from accepted sets": {
+			accepted:     []measurements.M{newMeasurements},
+			measurements: newMeasurements,
+			wantAccepted: []measurements.M{oldMeasurements},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			conf := Default()
+			conf.RemoveProviderAndAttestationExcept(cloudprovider.Azure)
+			conf.Attestation.AzureSEVSNP.Measurements = oldMeasurements.Copy()
+			conf.Attestation.AzureSEVSNP.AcceptedMeasurements = tc.accepted
+
+			conf.AppendMeasurements(tc.measurements)
+
+			assert.Equal(tc.measurements, conf.Attestation.AzureSEVSNP.Measurements)
+			assert.Equal(tc.wantAccepted, conf.Attestation.AzureSEVSNP.AcceptedMeasurements)
+		})
+	}
+}
+
 func TestConfig_IsReleaseImage(t *testing.T) {
 	testCases := map[string]struct {
 		conf *Config
@@ -9,14 +9,19 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"slices"
 
 	"github.com/edgelesssys/constellation/v2/internal/api/attestationconfigapi"
 	"github.com/edgelesssys/constellation/v2/internal/attestation/measurements"
 	"github.com/edgelesssys/constellation/v2/internal/attestation/variant"
 	"github.com/edgelesssys/constellation/v2/internal/cloud/cloudprovider"
+	"github.com/edgelesssys/constellation/v2/internal/encoding"
 )
 
-var _ svnResolveMarshaller = &GCPSEVSNP{}
+var (
+	_ svnResolveMarshaller = &GCPSEVSNP{}
+	_ svnResolveMarshaller = &GCPTDX{}
+)
 
 // DefaultForGCPSEVSNP provides a valid default configuration for GCP SEV-SNP attestation.
 func DefaultForGCPSEVSNP() *GCPSEVSNP {
@@ -26,6 +31,7 @@ func DefaultForGCPSEVSNP() *GCPSEVSNP {
 		TEEVersion:        NewLatestPlaceholderVersion[uint8](),
 		SNPVersion:        NewLatestPlaceholderVersion[uint8](),
 		MicrocodeVersion:  NewLatestPlaceholderVersion[uint8](),
+		GuestPolicy:       defaultSNPGuestPolicy(),
 		AMDRootKey:        mustParsePEM(arkPEM),
 	}
 }
@@ -35,6 +41,11 @@ func (GCPSEVSNP) GetVariant() variant.Variant {
 	return variant.GCPSEVSNP{}
 }
 
+// GetPolicy returns the attestation policy rules of the config.
+func (c GCPSEVSNP) GetPolicy() []PolicyRule {
+	return c.Policy
+}
+
 // GetMeasurements returns the measurements used for attestation.
 func (c GCPSEVSNP) GetMeasurements() measurements.M {
 	return c.Measurements
@@ -45,6 +56,16 @@ func (c *GCPSEVSNP) SetMeasurements(m measurements.M) {
 	c.Measurements = m
 }
 
+// GetAcceptedMeasurements returns the additional measurement sets that are accepted for attestation.
+func (c GCPSEVSNP) GetAcceptedMeasurements() []measurements.M {
+	return c.AcceptedMeasurements
+}
+
+// SetAcceptedMeasurements updates the additional measurement sets that are accepted for attestation.
+func (c *GCPSEVSNP) SetAcceptedMeasurements(m []measurements.M) {
+	c.AcceptedMeasurements = m
+}
+
 // EqualTo returns true if the config is equal to the given config.
 func (c GCPSEVSNP) EqualTo(other AttestationCfg) (bool, error) {
 	otherCfg, ok := other.(*GCPSEVSNP)
@@ -52,15 +73,19 @@ func (c GCPSEVSNP) EqualTo(other AttestationCfg) (bool, error) {
 		return false, fmt.Errorf("cannot compare %T with %T", c, other)
 	}
 
-	measurementsEqual := c.Measurements.EqualTo(otherCfg.Measurements)
+	measurementsEqual := c.Measurements.EqualTo(otherCfg.Measurements) && measurementSetsEqual(c.AcceptedMeasurements, otherCfg.AcceptedMeasurements)
 	bootloaderEqual := c.BootloaderVersion == otherCfg.BootloaderVersion
 	teeEqual := c.TEEVersion == otherCfg.TEEVersion
 	snpEqual := c.SNPVersion == otherCfg.SNPVersion
 	microcodeEqual := c.MicrocodeVersion == otherCfg.MicrocodeVersion
 	rootKeyEqual := bytes.Equal(c.AMDRootKey.Raw, otherCfg.AMDRootKey.Raw)
 	signingKeyEqual := bytes.Equal(c.AMDSigningKey.Raw, otherCfg.AMDSigningKey.Raw)
+	guestPolicyEqual := c.GuestPolicy.EqualTo(otherCfg.GuestPolicy)
+	platformInfoEqual := c.PlatformInfo.EqualTo(otherCfg.PlatformInfo)
+	revocationsEqual := c.CheckRevocations == otherCfg.CheckRevocations && c.AMDCRL.Equal(otherCfg.AMDCRL)
+	policyEqual := slices.Equal(c.Policy, otherCfg.Policy)
 
-	return measurementsEqual && bootloaderEqual && teeEqual && snpEqual && microcodeEqual && rootKeyEqual && signingKeyEqual, nil
+	return measurementsEqual && bootloaderEqual && teeEqual && snpEqual && microcodeEqual && rootKeyEqual && signingKeyEqual && guestPolicyEqual && platformInfoEqual && revocationsEqual && policyEqual, nil
 }
 
 func (c *GCPSEVSNP) getToMarshallLatestWithResolvedVersions() AttestationCfg {
@@ -103,11 +128,127 @@ func (c *GCPSEVSNP) mergeWithLatestVersion(latest attestationconfigapi.SEVSNPVer
 	}
 }
 
+// DefaultForGCPTDX provides a valid default configuration for GCP TDX attestation.
+func DefaultForGCPTDX() *GCPTDX {
+	return &GCPTDX{
+		Measurements: measurements.DefaultsFor(cloudprovider.GCP, variant.GCPTDX{}),
+		QESVN:        NewLatestPlaceholderVersion[uint16](),
+		PCESVN:       NewLatestPlaceholderVersion[uint16](),
+		TEETCBSVN:    NewLatestPlaceholderVersion[encoding.HexBytes](),
+		QEVendorID:   NewLatestPlaceholderVersion[encoding.HexBytes](),
+		// Don't set a default for MRSEAM as it effectively prevents upgrading the SEAM module
+		// Quote verification still makes sure the module comes from Intel (through MRSIGNERSEAM), and is not of a lower version than expected
+		// MRSeam:  nil,
+		XFAM: NewLatestPlaceholderVersion[encoding.HexBytes](),
+
+		IntelRootKey: mustParsePEM(tdxRootPEM),
+	}
+}
+
+// GetVariant returns gcp-tdx as the variant.
+func (GCPTDX) GetVariant() variant.Variant {
+	return variant.GCPTDX{}
+}
+
+// GetPolicy returns the attestation policy rules of the config.
+func (c GCPTDX) GetPolicy() []PolicyRule {
+	return c.Policy
+}
+
+// GetMeasurements returns the measurements used for attestation.
+func (c GCPTDX) GetMeasurements() measurements.M {
+	return c.Measurements
+}
+
+// SetMeasurements updates a config's measurements using the given measurements.
+func (c *GCPTDX) SetMeasurements(m measurements.M) {
+	c.Measurements = m
+}
+
+// GetAcceptedMeasurements returns the additional measurement sets that are accepted for attestation.
+func (c GCPTDX) GetAcceptedMeasurements() []measurements.M {
+	return c.AcceptedMeasurements
+}
+
+// SetAcceptedMeasurements updates the additional measurement sets that are accepted for attestation.
+func (c *GCPTDX) SetAcceptedMeasurements(m []measurements.M) {
+	c.AcceptedMeasurements = m
+}
+
+// EqualTo returns true if the config is equal to the given config.
+func (c GCPTDX) EqualTo(other AttestationCfg) (bool, error) {
+	otherCfg, ok := other.(*GCPTDX)
+	if !ok {
+		return false, fmt.Errorf("cannot compare %T with %T", c, other)
+	}
+
+	measurementsEqual := c.Measurements.EqualTo(otherCfg.Measurements) && measurementSetsEqual(c.AcceptedMeasurements, otherCfg.AcceptedMeasurements)
+	qeSVNEqual := c.QESVN == otherCfg.QESVN
+	pceSVNEqual := c.PCESVN == otherCfg.PCESVN
+	teeTCBSVNEqual := c.TEETCBSVN.WantLatest == otherCfg.TEETCBSVN.WantLatest && bytes.Equal(c.TEETCBSVN.Value, otherCfg.TEETCBSVN.Value)
+	qeVendorIDEqual := c.QEVendorID.WantLatest == otherCfg.QEVendorID.WantLatest && bytes.Equal(c.QEVendorID.Value, otherCfg.QEVendorID.Value)
+	mrSeamEqual := bytes.Equal(c.MRSeam, otherCfg.MRSeam)
+	xfamEqual := c.XFAM.WantLatest == otherCfg.XFAM.WantLatest && bytes.Equal(c.XFAM.Value, otherCfg.XFAM.Value)
+	rootKeyEqual := bytes.Equal(c.IntelRootKey.Raw, otherCfg.IntelRootKey.Raw)
+	policyEqual := slices.Equal(c.Policy, otherCfg.Policy)
+
+	return measurementsEqual && qeSVNEqual && pceSVNEqual && teeTCBSVNEqual && qeVendorIDEqual && mrSeamEqual && xfamEqual && rootKeyEqual && policyEqual, nil
+}
+
+// FetchAndSetLatestVersionNumbers fetches the latest version numbers from the configapi and sets them.
+func (c *GCPTDX) FetchAndSetLatestVersionNumbers(ctx context.Context, fetcher attestationconfigapi.Fetcher) error {
+	// Only talk to the API if at least one version number is set to latest.
+	if !(c.PCESVN.WantLatest || c.QESVN.WantLatest || c.TEETCBSVN.WantLatest || c.QEVendorID.WantLatest || c.XFAM.WantLatest) {
+		return nil
+	}
+
+	versions, err := fetcher.FetchLatestVersion(ctx, variant.GCPTDX{})
+	if err != nil {
+		return fmt.Errorf("fetching latest TCB versions from configapi: %w", err)
+	}
+	// set values and keep WantLatest flag
+	c.mergeWithLatestVersion(versions.TDXVersion)
+	return nil
+}
+
+func (c *GCPTDX) mergeWithLatestVersion(latest attestationconfigapi.TDXVersion) {
+	if c.PCESVN.WantLatest {
+		c.PCESVN.Value = latest.PCESVN
+	}
+	if c.QESVN.WantLatest {
+		c.QESVN.Value = latest.QESVN
+	}
+	if c.TEETCBSVN.WantLatest {
+		c.TEETCBSVN.Value = latest.TEETCBSVN[:]
+	}
+	if c.QEVendorID.WantLatest {
+		c.QEVendorID.Value = latest.QEVendorID[:]
+	}
+	if c.XFAM.WantLatest {
+		c.XFAM.Value = latest.XFAM[:]
+	}
+}
+
+func (c *GCPTDX) getToMarshallLatestWithResolvedVersions() AttestationCfg {
+	cp := *c
+	cp.PCESVN.WantLatest = false
+	cp.QESVN.WantLatest = false
+	cp.TEETCBSVN.WantLatest = false
+	cp.QEVendorID.WantLatest = false
+	cp.XFAM.WantLatest = false
+	return &cp
+}
+
 // GetVariant returns gcp-sev-es as the variant.
 func (GCPSEVES) GetVariant() variant.Variant {
 	return variant.GCPSEVES{}
 }
 
+// GetPolicy returns the attestation policy rules of the config.
+func (c GCPSEVES) GetPolicy() []PolicyRule {
+	return c.Policy
+}
+
 // GetMeasurements returns the measurements used for attestation.
 func (c GCPSEVES) GetMeasurements() measurements.M {
 	return c.Measurements
@@ -118,11 +259,23 @@ func (c *GCPSEVES) SetMeasurements(m measurements.M) {
 	c.Measurements = m
 }
 
+// GetAcceptedMeasurements returns the additional measurement sets that are accepted for attestation.
+func (c GCPSEVES) GetAcceptedMeasurements() []measurements.M {
+	return c.AcceptedMeasurements
+}
+
+// SetAcceptedMeasurements updates the additional measurement sets that are accepted for attestation.
+func (c *GCPSEVES) SetAcceptedMeasurements(m []measurements.M) {
+	c.AcceptedMeasurements = m
+}
+
 // EqualTo returns true if the config is equal to the given config.
 func (c GCPSEVES) EqualTo(other AttestationCfg) (bool, error) {
 	otherCfg, ok := other.(*GCPSEVES)
 	if !ok {
 		return false, fmt.Errorf("cannot compare %T with %T", c, other)
 	}
-	return c.Measurements.EqualTo(otherCfg.Measurements), nil
+	return c.Measurements.EqualTo(otherCfg.Measurements) &&
+		measurementSetsEqual(c.AcceptedMeasurements, otherCfg.AcceptedMeasurements) &&
+		slices.Equal(c.Policy, otherCfg.Policy), nil
 }
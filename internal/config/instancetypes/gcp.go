@@ -55,3 +55,13 @@ var GCPInstanceTypes = []string{
 	"c2d-highmem-56",
 	"c2d-highmem-112",
 }
+
+// GCPTDXInstanceTypes are valid GCP TDX instance types.
+var GCPTDXInstanceTypes = []string{
+	"c3-standard-4",
+	"c3-standard-8",
+	"c3-standard-22",
+	"c3-standard-44",
+	"c3-standard-88",
+	"c3-standard-176",
+}
@@ -0,0 +1,81 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+
+	"github.com/edgelesssys/constellation/v2/internal/attestation/measurements"
+	"github.com/edgelesssys/constellation/v2/internal/attestation/variant"
+	"github.com/edgelesssys/constellation/v2/internal/cloud/cloudprovider"
+)
+
+// DefaultForQEMUSEVSNP provides a valid default configuration for QEMU SEV-SNP attestation.
+func DefaultForQEMUSEVSNP() *QEMUSEVSNP {
+	return &QEMUSEVSNP{
+		Measurements: measurements.DefaultsFor(cloudprovider.QEMU, variant.QEMUSEVSNP{}),
+		GuestPolicy:  defaultSNPGuestPolicy(),
+		AMDRootKey:   mustParsePEM(arkPEM),
+	}
+}
+
+// GetVariant returns qemu-sev-snp as the variant.
+func (QEMUSEVSNP) GetVariant() variant.Variant {
+	return variant.QEMUSEVSNP{}
+}
+
+// GetPolicy returns the attestation policy rules of the config.
+func (c QEMUSEVSNP) GetPolicy() []PolicyRule {
+	return c.Policy
+}
+
+// GetMeasurements returns the measurements used for attestation.
+func (c QEMUSEVSNP) GetMeasurements() measurements.M {
+	return c.Measurements
+}
+
+// SetMeasurements updates a config's measurements using the given measurements.
+func (c *QEMUSEVSNP) SetMeasurements(m measurements.M) {
+	c.Measurements = m
+}
+
+// GetAcceptedMeasurements returns the additional measurement sets that are accepted for attestation.
+func (c QEMUSEVSNP) GetAcceptedMeasurements() []measurements.M {
+	return c.AcceptedMeasurements
+}
+
+// SetAcceptedMeasurements updates the additional measurement sets that are accepted for attestation.
+func (c *QEMUSEVSNP) SetAcceptedMeasurements(m []measurements.M) {
+	c.AcceptedMeasurements = m
+}
+
+// EqualTo returns true if the config is equal to the given config.
+func (c QEMUSEVSNP) EqualTo(other AttestationCfg) (bool, error) {
+	otherCfg, ok := other.(*QEMUSEVSNP)
+	if !ok {
+		return false, fmt.Errorf("cannot compare %T with %T", c, other)
+	}
+
+	measurementsEqual := c.Measurements.EqualTo(otherCfg.Measurements) && measurementSetsEqual(c.AcceptedMeasurements, otherCfg.AcceptedMeasurements)
+	bootloaderEqual := c.BootloaderVersion == otherCfg.BootloaderVersion
+	teeEqual := c.TEEVersion == otherCfg.TEEVersion
+	snpEqual := c.SNPVersion == otherCfg.SNPVersion
+	microcodeEqual := c.MicrocodeVersion == otherCfg.MicrocodeVersion
+	hostDataEqual := bytes.Equal(c.HostData, otherCfg.HostData)
+	idKeyDigestsEqual := c.IDKeyDigests.EqualTo(otherCfg.IDKeyDigests)
+	rootKeyEqual := bytes.Equal(c.AMDRootKey.Raw, otherCfg.AMDRootKey.Raw)
+	signingKeyEqual := bytes.Equal(c.AMDSigningKey.Raw, otherCfg.AMDSigningKey.Raw)
+	guestPolicyEqual := c.GuestPolicy.EqualTo(otherCfg.GuestPolicy)
+	platformInfoEqual := c.PlatformInfo.EqualTo(otherCfg.PlatformInfo)
+	revocationsEqual := c.CheckRevocations == otherCfg.CheckRevocations && c.AMDCRL.Equal(otherCfg.AMDCRL)
+	policyEqual := slices.Equal(c.Policy, otherCfg.Policy)
+
+	return measurementsEqual && bootloaderEqual && teeEqual && snpEqual && microcodeEqual && hostDataEqual &&
+		idKeyDigestsEqual && rootKeyEqual && signingKeyEqual && guestPolicyEqual && platformInfoEqual && revocationsEqual && policyEqual, nil
+}
@@ -19,9 +19,12 @@ import (
 	ut "github.com/go-playground/universal-translator"
 	"github.com/go-playground/validator/v10"
 	"golang.org/x/mod/semver"
+	"k8s.io/apimachinery/pkg/api/resource"
+	k8svalidation "k8s.io/apimachinery/pkg/util/validation"
 
 	"github.com/edgelesssys/constellation/v2/internal/api/versionsapi"
 	"github.com/edgelesssys/constellation/v2/internal/attestation/measurements"
+	"github.com/edgelesssys/constellation/v2/internal/attestation/policy"
 	"github.com/edgelesssys/constellation/v2/internal/attestation/variant"
 	"github.com/edgelesssys/constellation/v2/internal/cloud/cloudprovider"
 	"github.com/edgelesssys/constellation/v2/internal/compatibility"
@@ -205,9 +208,15 @@ func validateAttestation(sl validator.StructLevel) {
 	if attestation.GCPSEVSNP != nil {
 		attestationCount++
 	}
+	if attestation.GCPTDX != nil {
+		attestationCount++
+	}
 	if attestation.QEMUVTPM != nil {
 		attestationCount++
 	}
+	if attestation.QEMUSEVSNP != nil {
+		attestationCount++
+	}
 
 	if attestationCount < 1 {
 		sl.ReportError(attestation, "Attestation", "Attestation", "no_attestation", "")
@@ -250,7 +259,7 @@ func translateNoAttestationError(ut ut.Translator, fe validator.FieldError) stri
 }
 
 func registerNoAttestationError(ut ut.Translator) error {
-	return ut.Add("no_attestation", "{0}: No attestation has been defined (requires either awsSEVSNP, awsNitroTPM, azureSEVSNP, azureTDX, azureTrustedLaunch, gcpSEVES, gcpSEVSNP, or qemuVTPM)", true)
+	return ut.Add("no_attestation", "{0}: No attestation has been defined (requires either awsSEVSNP, awsNitroTPM, azureSEVSNP, azureTDX, azureTrustedLaunch, gcpSEVES, gcpSEVSNP, gcpTDX, qemuVTPM, or qemuSEVSNP)", true)
 }
 
 func translateNoDefaultControlPlaneGroupError(ut ut.Translator, fe validator.FieldError) string {
@@ -370,9 +379,15 @@ func (c *Config) translateMoreThanOneAttestationError(ut ut.Translator, fe valid
 	if c.Attestation.GCPSEVSNP != nil {
 		definedAttestations = append(definedAttestations, "GCPSEVSNP")
 	}
+	if c.Attestation.GCPTDX != nil {
+		definedAttestations = append(definedAttestations, "GCPTDX")
+	}
 	if c.Attestation.QEMUVTPM != nil {
 		definedAttestations = append(definedAttestations, "QEMUVTPM")
 	}
+	if c.Attestation.QEMUSEVSNP != nil {
+		definedAttestations = append(definedAttestations, "QEMUSEVSNP")
+	}
 
 	t, _ := ut.T("more_than_one_attestation", fe.Field(), strings.Join(definedAttestations, ", "))
 
@@ -417,7 +432,7 @@ func (c *Config) translateInstanceTypeError(ut ut.Translator, fe validator.Field
 	case cloudprovider.Azure:
 		return c.translateAzureInstanceTypeError(ut, fe)
 	case cloudprovider.GCP:
-		return translateGCPInstanceTypeError(ut, fe)
+		return c.translateGCPInstanceTypeError(ut, fe)
 	}
 	t, _ := ut.T("instance_type", fe.Field())
 
@@ -444,11 +459,16 @@ func (c *Config) translateAWSInstanceTypeError(ut ut.Translator, fe validator.Fi
 }
 
 func registerTranslateGCPInstanceTypeError(ut ut.Translator) error {
-	return ut.Add("instance_type", fmt.Sprintf("{0} must be one of %v", instancetypes.GCPInstanceTypes), true)
+	return ut.Add("instance_type", "{0} must be one of {1}", true)
 }
 
-func translateGCPInstanceTypeError(ut ut.Translator, fe validator.FieldError) string {
-	t, _ := ut.T("instance_type", fe.Field())
+func (c *Config) translateGCPInstanceTypeError(ut ut.Translator, fe validator.FieldError) string {
+	instances := instancetypes.GCPInstanceTypes
+	if c.GetAttestationConfig().GetVariant().Equal(variant.GCPTDX{}) {
+		instances = instancetypes.GCPTDXInstanceTypes
+	}
+
+	t, _ := ut.T("instance_type", fe.Field(), fmt.Sprintf("%v", instances))
 
 	return t
 }
@@ -548,7 +568,13 @@ func validInstanceTypeForProvider(insType string, attestation variant.Variant, p
 				return true
 			}
 		}
-	case variant.QEMUVTPM{}, variant.QEMUTDX{}:
+	case variant.GCPTDX{}:
+		for _, instanceType := range instancetypes.GCPTDXInstanceTypes {
+			if insType == instanceType {
+				return true
+			}
+		}
+	case variant.QEMUVTPM{}, variant.QEMUTDX{}, variant.QEMUSEVSNP{}:
 		// only allow confidential instances on stackit cloud using QEMU vTPM
 		if provider.OpenStack != nil {
 			if cloud := provider.OpenStack.Cloud; strings.ToLower(cloud) == "stackit" {
@@ -639,6 +665,9 @@ func translateContainsPlaceholderError(ut ut.Translator, fe validator.FieldError
 		if len(placeholders) == 1 {
 			msg = fmt.Sprintf("measurement %v contains", placeholders)
 		}
+	default:
+		// entries of acceptedMeasurements, e.g. "acceptedMeasurements[0]"
+		msg = fmt.Sprintf("%s %v contain", fe.Field(), getPlaceholderEntries(fe.Value().(measurements.M)))
 	}
 
 	t, _ := ut.T("no_placeholders", msg)
@@ -660,6 +689,111 @@ func getPlaceholderEntries(m measurements.M) []uint32 {
 	return placeholders
 }
 
+// validateAttestationPolicy checks that a policy rule expression compiles.
+func validateAttestationPolicy(fl validator.FieldLevel) bool {
+	_, err := compilePolicyExpression(fl.Field().String())
+	return err == nil
+}
+
+func compilePolicyExpression(expression string) (*policy.Policy, error) {
+	return policy.Compile([]policy.Rule{{Expression: expression}})
+}
+
+func registerAttestationPolicyError(ut ut.Translator) error {
+	return ut.Add("attestation_policy", "{0} is not a valid policy expression: {1}", true)
+}
+
+func translateAttestationPolicyError(ut ut.Translator, fe validator.FieldError) string {
+	_, err := compilePolicyExpression(fe.Value().(string))
+	t, _ := ut.T("attestation_policy", fe.Field(), fmt.Sprint(err))
+	return t
+}
+
+func validateNodeLabelsField(fl validator.FieldLevel) bool {
+	labels, ok := fl.Field().Interface().(map[string]string)
+	return ok && validateNodeLabels(labels) == nil
+}
+
+// validateNodeLabels checks that the labels are valid Kubernetes labels the kubelet is allowed to set on its node.
+func validateNodeLabels(labels map[string]string) error {
+	for _, key := range sortedKeys(labels) {
+		if errs := k8svalidation.IsQualifiedName(key); len(errs) > 0 {
+			return fmt.Errorf("invalid label key %q: %s", key, strings.Join(errs, "; "))
+		}
+		if errs := k8svalidation.IsValidLabelValue(labels[key]); len(errs) > 0 {
+			return fmt.Errorf("invalid value of label %q: %s", key, strings.Join(errs, "; "))
+		}
+		if prefix, _, ok := strings.Cut(key, "/"); ok && isRestrictedLabelPrefix(prefix) {
+			return fmt.Errorf("label %q is in a namespace reserved for Kubernetes", key)
+		}
+	}
+	return nil
+}
+
+// isRestrictedLabelPrefix reports whether the kubelet is forbidden to set labels with the given prefix.
+func isRestrictedLabelPrefix(prefix string) bool {
+	for _, allowed := range []string{"kubelet.kubernetes.io", "node.kubernetes.io"} {
+		if prefix == allowed || strings.HasSuffix(prefix, "."+allowed) {
+			return false
+		}
+	}
+	for _, restricted := range []string{"kubernetes.io", "k8s.io"} {
+		if prefix == restricted || strings.HasSuffix(prefix, "."+restricted) {
+			return true
+		}
+	}
+	return false
+}
+
+func registerNodeLabelsError(ut ut.Translator) error {
+	return ut.Add("node_labels", "{0}: {1}", true)
+}
+
+func translateNodeLabelsError(ut ut.Translator, fe validator.FieldError) string {
+	labels, _ := fe.Value().(map[string]string)
+	t, _ := ut.T("node_labels", fe.Field(), fmt.Sprint(validateNodeLabels(labels)))
+	return t
+}
+
+func validateReservedResourcesField(fl validator.FieldLevel) bool {
+	resources, ok := fl.Field().Interface().(map[string]string)
+	return ok && validateReservedResources(resources) == nil
+}
+
+// validateReservedResources checks that the resources can be reserved by the kubelet.
+func validateReservedResources(resources map[string]string) error {
+	for _, name := range sortedKeys(resources) {
+		switch name {
+		case "cpu", "memory", "ephemeral-storage", "pid":
+		default:
+			return fmt.Errorf("unsupported resource %q, must be one of cpu, memory, ephemeral-storage, pid", name)
+		}
+		if _, err := resource.ParseQuantity(resources[name]); err != nil {
+			return fmt.Errorf("invalid quantity of resource %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func registerReservedResourcesError(ut ut.Translator) error {
+	return ut.Add("reserved_resources", "{0}: {1}", true)
+}
+
+func translateReservedResourcesError(ut ut.Translator, fe validator.FieldError) string {
+	resources, _ := fe.Value().(map[string]string)
+	t, _ := ut.T("reserved_resources", fe.Field(), fmt.Sprint(validateReservedResources(resources)))
+	return t
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 // validateK8sVersion does not check the patch version.
 func (c *Config) validateK8sVersion(fl validator.FieldLevel) bool {
 	_, err := versions.NewValidK8sVersion(compatibility.EnsurePrefixV(fl.Field().String()), false)
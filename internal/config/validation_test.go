@@ -84,3 +84,76 @@ func TestValidateMicroserviceVersion(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateNodeLabels(t *testing.T) {
+	testCases := map[string]struct {
+		labels    map[string]string
+		wantError bool
+	}{
+		"empty": {},
+		"valid labels": {
+			labels: map[string]string{"pool": "gpu", "example.com/tier": "frontend"},
+		},
+		"allowed kubernetes namespace": {
+			labels: map[string]string{"node.kubernetes.io/instance-group": "a", "team.kubelet.kubernetes.io/owner": "b"},
+		},
+		"restricted kubernetes namespace": {
+			labels:    map[string]string{"node-role.kubernetes.io/gpu": ""},
+			wantError: true,
+		},
+		"restricted k8s namespace": {
+			labels:    map[string]string{"example.k8s.io/pool": "gpu"},
+			wantError: true,
+		},
+		"invalid key": {
+			labels:    map[string]string{"invalid key": "value"},
+			wantError: true,
+		},
+		"invalid value": {
+			labels:    map[string]string{"pool": "invalid value"},
+			wantError: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			err := validateNodeLabels(tc.labels)
+			if tc.wantError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestValidateReservedResources(t *testing.T) {
+	testCases := map[string]struct {
+		resources map[string]string
+		wantError bool
+	}{
+		"empty": {},
+		"valid resources": {
+			resources: map[string]string{"cpu": "500m", "memory": "1Gi", "ephemeral-storage": "10Gi", "pid": "1000"},
+		},
+		"unsupported resource": {
+			resources: map[string]string{"nvidia.com/gpu": "1"},
+			wantError: true,
+		},
+		"invalid quantity": {
+			resources: map[string]string{"memory": "lots"},
+			wantError: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			err := validateReservedResources(tc.resources)
+			if tc.wantError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -32,6 +32,21 @@ const (
 	ConstellationMasterSecretKey = "mastersecret"
 	// ConstellationSaltKey is the name of the key for the salt in the master secret kubernetes secret.
 	ConstellationSaltKey = "salt"
+	// ConstellationKMSURIKey is the name of the key for the URI of an external KMS in the master secret kubernetes secret.
+	ConstellationKMSURIKey = "kmsuri"
+	// ConstellationStorageURIKey is the name of the key for the URI of an external KMS's storage backend in the master secret kubernetes secret.
+	ConstellationStorageURIKey = "storageuri"
+	// ConstellationPreviousMasterSecretKey is the name of the key for the master secret replaced by an ongoing key rotation in the master secret kubernetes secret.
+	ConstellationPreviousMasterSecretKey = "previousmastersecret"
+	// ConstellationPreviousSaltKey is the name of the key for the salt replaced by an ongoing key rotation in the master secret kubernetes secret.
+	ConstellationPreviousSaltKey = "previoussalt"
+	// ConstellationMeasurementSecretKey is the name of the key for the measurement secret in the master secret kubernetes secret.
+	// The measurement secret is pinned on the first key rotation, so the cluster's clusterID does not change.
+	ConstellationMeasurementSecretKey = "measurementsecret"
+	// KeyServiceTokenAudience is the audience of the service account tokens callers of the key service authenticate with.
+	KeyServiceTokenAudience = "constellation-key-service"
+	// KeyServiceTokenPath is the path of the projected service account token callers of the key service authenticate with.
+	KeyServiceTokenPath = "/var/run/secrets/tokens/key-service-token"
 	// ConstellationVerifyServiceUserData is the user data that the verification service includes in the attestation.
 	ConstellationVerifyServiceUserData = "VerifyService"
 	// AttestationVariant is the name of the environment variable that contains the attestation variant.
@@ -69,6 +84,10 @@ const (
 	VerifyServiceNodePortGRPC = 30081
 	// KeyServicePort is the port the KMS server listens on.
 	KeyServicePort = 9000
+	// KeyServiceATLSPort is the port the KMS server accepts aTLS connections of attested callers on.
+	KeyServiceATLSPort = 9001
+	// KeyServiceWorkloadHTTPSPort is the port the KMS server serves the workload key API over HTTPS on.
+	KeyServiceWorkloadHTTPSPort = 9002
 	// BootstrapperPort port of bootstrapper.
 	BootstrapperPort = 9000
 	// KubernetesPort port for Kubernetes API.
@@ -92,6 +111,8 @@ const (
 	AdminConfFilename = "constellation-admin.conf"
 	// MasterSecretFilename filename of Constellation mastersecret.
 	MasterSecretFilename = "constellation-mastersecret.json"
+	// PreviousMasterSecretFilename filename of the Constellation mastersecret replaced by an ongoing key rotation.
+	PreviousMasterSecretFilename = "constellation-mastersecret.previous.json"
 	// TerraformWorkingDir is the directory name for the TerraformClient workspace.
 	TerraformWorkingDir = "constellation-terraform"
 	// TerraformIAMWorkingDir is the directory name for the Terraform IAM Client workspace.
@@ -102,6 +123,10 @@ const (
 	ErrorLog = "constellation-cluster.log"
 	// ControlPlaneAdminConfFilename filepath to control plane kubernetes admin config.
 	ControlPlaneAdminConfFilename = "/etc/kubernetes/admin.conf"
+	// KubernetesCACertFilename filepath to the certificate of the Kubernetes root CA on control-plane nodes.
+	KubernetesCACertFilename = "/etc/kubernetes/pki/ca.crt"
+	// KubernetesCAKeyFilename filepath to the private key of the Kubernetes root CA on control-plane nodes.
+	KubernetesCAKeyFilename = "/etc/kubernetes/pki/ca.key"
 	// KubectlPath path to kubectl binary.
 	KubectlPath = "/run/state/bin/kubectl"
 	// UpgradeAgentSocketPath is the path to the UDS that is used for the gRPC connection to the upgrade agent.
@@ -116,6 +141,8 @@ const (
 	KubeadmPath = "/run/state/bin/kubeadm"
 	// KubeletPath install path for kubelet.
 	KubeletPath = "/run/state/bin/kubelet"
+	// EtcdSnapshotPath is the path an uploaded etcd snapshot is stored at on the state disk, until the cluster is restored from it.
+	EtcdSnapshotPath = "/run/state/etcd-snapshot/snapshot.db"
 	// KubeadmPatchDir directory for kubeadm patches .
 	KubeadmPatchDir = "/opt/kubernetes/patches"
 
@@ -142,10 +169,18 @@ const (
 	CertCacheAskKey = "ask"
 	// CertCacheArkKey is the name of the key holding the ARK certificate in the SEV-SNP certificate cache.
 	CertCacheArkKey = "ark"
+	// CertCacheCRLKey is the name of the key holding the AMD certificate revocation list in the SEV-SNP certificate cache.
+	CertCacheCRLKey = "crl"
 	// NodeVersionResourceName resource name used for NodeVersion in constellation-operator and CLI.
 	NodeVersionResourceName = "constellation-version"
+	// KeyRotationResourceName resource name used for KeyRotation in constellation-operator and CLI.
+	KeyRotationResourceName = "constellation-key-rotation"
 	// NodeKubernetesComponentsAnnotationKey is the name of the annotation holding the reference to the ConfigMap listing all K8s components.
 	NodeKubernetesComponentsAnnotationKey = "constellation.edgeless.systems/kubernetes-components"
+	// NodeDiskUUIDAnnotationKey is the name of the annotation holding the UUID of the state disk of a node.
+	NodeDiskUUIDAnnotationKey = "constellation.edgeless.systems/disk-uuid"
+	// NodeObsoleteAnnotationKey is the name of the annotation marking a node for removal by the constellation-operator.
+	NodeObsoleteAnnotationKey = "constellation.edgeless.systems/obsolete"
 	// JoiningNodesConfigMapName is the name of the configMap holding the joining nodes with the components hashes the node-operator should annotate the nodes with.
 	JoiningNodesConfigMapName = "joining-nodes"
 
@@ -228,6 +263,18 @@ const (
 	ConstellationNamespace = "kube-system"
 	// JoinConfigMap k8s config map with node join config.
 	JoinConfigMap = "join-config"
+	// JoinDenyListConfigMap k8s config map with the nodes that must not receive join or rejoin tickets.
+	JoinDenyListConfigMap = "join-deny-list"
+	// JoinDenyListKey is the key of the deny-list in the JoinDenyListConfigMap.
+	JoinDenyListKey = "deny-list.json"
+	// JoinEvidenceConfigMapPrefix is the name prefix of the k8s config maps archiving the attestation evidence of joined nodes.
+	JoinEvidenceConfigMapPrefix = "join-evidence"
+	// JoinEvidenceLabel is the label of the k8s config maps archiving the attestation evidence of joined nodes.
+	JoinEvidenceLabel = "constellation.edgeless.systems/join-evidence"
+	// NodeGroupConfigMap k8s config map with the Kubernetes node settings of the cluster's node groups.
+	NodeGroupConfigMap = "node-group-config"
+	// NodeGroupConfigKey is the key of the node group settings in the NodeGroupConfigMap.
+	NodeGroupConfigKey = "node-groups.json"
 	// InternalConfigMap k8s config map with internal Constellation config.
 	InternalConfigMap = "internal-config"
 	// KubeadmConfigMap k8s config map with kubeadm config
@@ -25,6 +25,7 @@ import (
 	"github.com/edgelesssys/constellation/v2/internal/grpc/grpclog"
 	grpcRetry "github.com/edgelesssys/constellation/v2/internal/grpc/retry"
 	"github.com/edgelesssys/constellation/v2/internal/kms/uri"
+	"github.com/edgelesssys/constellation/v2/internal/nodegroup"
 	"github.com/edgelesssys/constellation/v2/internal/retry"
 	"github.com/edgelesssys/constellation/v2/internal/versions"
 	"google.golang.org/grpc"
@@ -38,8 +39,22 @@ type InitPayload struct {
 	K8sVersion      versions.ValidK8sVersion
 	ConformanceMode bool
 	ServiceCIDR     string
+	// KMSURI and StorageURI configure an external KMS.
+	// If KMSURI is empty, all keys are derived from MasterSecret.
+	KMSURI     string
+	StorageURI string
+	// NodeGroups maps node group names to the Kubernetes node settings of the node group.
+	NodeGroups nodegroup.Config
+	// OpenEtcdSnapshot opens the decrypted etcd snapshot the cluster is restored from.
+	// It is called once per init attempt, and the snapshot is uploaded to the bootstrapper in chunks.
+	// If nil, a new cluster is bootstrapped.
+	OpenEtcdSnapshot func() (io.ReadCloser, error)
 }
 
+// etcdSnapshotChunkSize is the size of the chunks an etcd snapshot is uploaded in.
+// It has to stay below the maximum gRPC message size of the bootstrapper.
+const etcdSnapshotChunkSize = 1 << 20
+
 // GrpcDialer dials a gRPC server.
 type GrpcDialer interface {
 	Dial(target string) (*grpc.ClientConn, error)
@@ -56,10 +71,15 @@ func (a *Applier) Init(
 	InitOutput,
 	error,
 ) {
+	kmsURI, storageURI := payload.MasterSecret.EncodeToURI(), uri.NoStoreURI
+	if payload.KMSURI != "" {
+		kmsURI, storageURI = payload.KMSURI, payload.StorageURI
+	}
+
 	// Prepare the Request
 	req := &initproto.InitRequest{
-		KmsUri:               payload.MasterSecret.EncodeToURI(),
-		StorageUri:           uri.NoStoreURI,
+		KmsUri:               kmsURI,
+		StorageUri:           storageURI,
 		MeasurementSalt:      payload.MeasurementSalt,
 		KubernetesVersion:    versions.VersionConfigs[payload.K8sVersion].ClusterVersion,
 		KubernetesComponents: versions.VersionConfigs[payload.K8sVersion].KubernetesComponents,
@@ -68,6 +88,8 @@ func (a *Applier) Init(
 		ClusterName:          state.Infrastructure.Name,
 		ApiserverCertSans:    state.Infrastructure.APIServerCertSANs,
 		ServiceCidr:          payload.ServiceCIDR,
+		NodeGroups:           payload.NodeGroups,
+		RestoreEtcdSnapshot:  payload.OpenEtcdSnapshot != nil,
 	}
 
 	doer := &initDoer{
@@ -77,6 +99,7 @@ func (a *Applier) Init(
 			strconv.Itoa(constants.BootstrapperPort),
 		),
 		req:              req,
+		openEtcdSnapshot: payload.OpenEtcdSnapshot,
 		log:              a.log,
 		clusterLogWriter: clusterLogWriter,
 		spinner:          a.spinner,
@@ -150,6 +173,9 @@ type initDoer struct {
 	// clusterLogWriter is the writer to which the cluster logs are written.
 	clusterLogWriter io.Writer
 
+	// openEtcdSnapshot opens the etcd snapshot uploaded before the init call, if set.
+	openEtcdSnapshot func() (io.ReadCloser, error)
+
 	// Read-Only-fields:
 
 	// resp is the response returned upon successful initialization.
@@ -189,6 +215,11 @@ func (d *initDoer) Do(ctx context.Context) error {
 
 	protoClient := initproto.NewAPIClient(conn)
 	d.log.Debug("Created protoClient")
+	if d.openEtcdSnapshot != nil {
+		if err := d.uploadEtcdSnapshot(ctx, protoClient); err != nil {
+			return err
+		}
+	}
 	resp, err := protoClient.Init(ctx, d.req)
 	if err != nil {
 		return &NonRetriableInitError{
@@ -247,6 +278,54 @@ func (d *initDoer) Do(ctx context.Context) error {
 	return nil
 }
 
+// uploadEtcdSnapshot streams the etcd snapshot to the bootstrapper in chunks.
+// The first chunk carries the init secret to authenticate the upload.
+func (d *initDoer) uploadEtcdSnapshot(ctx context.Context, client initproto.APIClient) error {
+	snapshot, err := d.openEtcdSnapshot()
+	if err != nil {
+		return fmt.Errorf("opening etcd snapshot: %w", err)
+	}
+	defer snapshot.Close()
+
+	// Canceling the context aborts the upload, so the bootstrapper discards a partial snapshot.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := client.UploadEtcdSnapshot(ctx)
+	if err != nil {
+		return fmt.Errorf("uploading etcd snapshot: %w", err)
+	}
+
+	d.log.Debug("Uploading etcd snapshot")
+	buf := make([]byte, etcdSnapshotChunkSize)
+	req := &initproto.UploadEtcdSnapshotRequest{InitSecret: d.req.InitSecret}
+	for first, done := true, false; !done; first = false {
+		n, err := io.ReadFull(snapshot, buf)
+		switch {
+		case errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF):
+			done = true
+		case err != nil:
+			return fmt.Errorf("reading etcd snapshot: %w", err)
+		}
+		// The first message is sent even for an empty snapshot, since it carries the init secret.
+		if n == 0 && !first {
+			continue
+		}
+		req.Chunk = buf[:n]
+		if err := stream.Send(req); errors.Is(err, io.EOF) {
+			// The server aborted the upload, the error is returned by CloseAndRecv.
+			break
+		} else if err != nil {
+			return fmt.Errorf("uploading etcd snapshot: %w", err)
+		}
+		req = &initproto.UploadEtcdSnapshotRequest{}
+	}
+	if _, err := stream.CloseAndRecv(); err != nil {
+		return fmt.Errorf("uploading etcd snapshot: %w", err)
+	}
+	d.log.Debug("Uploaded etcd snapshot")
+	return nil
+}
+
 // getLogs retrieves the cluster logs from the bootstrapper and saves them in the initDoer.
 func (d *initDoer) getLogs(resp initproto.API_InitClient) error {
 	d.log.Debug("Attempting to collect cluster logs")
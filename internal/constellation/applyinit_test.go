@@ -62,10 +62,22 @@ func TestInit(t *testing.T) {
 		}
 	}
 
+	successResponse := &initproto.InitResponse{
+		Kind: &initproto.InitResponse_InitSuccess{
+			InitSuccess: &initproto.InitSuccessResponse{
+				Kubeconfig: respKubeconfigBytes,
+				OwnerId:    []byte{},
+				ClusterId:  []byte{},
+			},
+		},
+	}
+	etcdSnapshot := bytes.Repeat([]byte{0x42}, 2*etcdSnapshotChunkSize+123)
+
 	testCases := map[string]struct {
-		server             initproto.APIServer
+		server             *stubInitServer
 		state              *state.State
 		initServerEndpoint string
+		etcdSnapshot       []byte
 		wantClusterLogs    []byte
 		wantErr            bool
 	}{
@@ -173,6 +185,28 @@ func TestInit(t *testing.T) {
 			initServerEndpoint: clusterEndpoint,
 			wantErr:            true,
 		},
+		"restore etcd snapshot": {
+			server:             newInitServer(nil, successResponse),
+			state:              newState(clusterEndpoint),
+			initServerEndpoint: clusterEndpoint,
+			etcdSnapshot:       etcdSnapshot,
+		},
+		"restore empty etcd snapshot": {
+			server:             newInitServer(nil, successResponse),
+			state:              newState(clusterEndpoint),
+			initServerEndpoint: clusterEndpoint,
+			etcdSnapshot:       []byte{},
+		},
+		"etcd snapshot upload fails": {
+			server: &stubInitServer{
+				res:       []*initproto.InitResponse{successResponse},
+				uploadErr: assert.AnError,
+			},
+			state:              newState(clusterEndpoint),
+			initServerEndpoint: clusterEndpoint,
+			etcdSnapshot:       etcdSnapshot,
+			wantErr:            true,
+		},
 		"collect logs": {
 			server: newInitServer(nil,
 				&initproto.InitResponse{
@@ -216,18 +250,26 @@ func TestInit(t *testing.T) {
 			clusterLogs := &bytes.Buffer{}
 			ctx, cancel := context.WithTimeout(t.Context(), time.Second*4)
 			defer cancel()
-			_, err := a.Init(ctx, nil, tc.state, clusterLogs, InitPayload{
+			payload := InitPayload{
 				MasterSecret:    uri.MasterSecret{},
 				MeasurementSalt: []byte{},
 				K8sVersion:      "v1.26.5",
 				ConformanceMode: false,
-			})
+			}
+			if tc.etcdSnapshot != nil {
+				payload.OpenEtcdSnapshot = func() (io.ReadCloser, error) {
+					return io.NopCloser(bytes.NewReader(tc.etcdSnapshot)), nil
+				}
+			}
+			_, err := a.Init(ctx, nil, tc.state, clusterLogs, payload)
 			if tc.wantErr {
 				assert.Error(err)
 				assert.Equal(tc.wantClusterLogs, clusterLogs.Bytes())
-			} else {
-				assert.NoError(err)
+				return
 			}
+			assert.NoError(err)
+			assert.Equal(tc.etcdSnapshot != nil, tc.server.restoreEtcdSnapshot)
+			assert.Equal(tc.etcdSnapshot, tc.server.etcdSnapshot)
 		})
 	}
 }
@@ -365,15 +407,37 @@ func setupTestInitServer(dialer *testdialer.BufconnDialer, server initproto.APIS
 }
 
 type stubInitServer struct {
-	res     []*initproto.InitResponse
-	initErr error
+	res       []*initproto.InitResponse
+	initErr   error
+	uploadErr error
+
+	etcdSnapshot        []byte
+	restoreEtcdSnapshot bool
 
 	initproto.UnimplementedAPIServer
 }
 
-func (s *stubInitServer) Init(_ *initproto.InitRequest, stream initproto.API_InitServer) error {
+func (s *stubInitServer) Init(req *initproto.InitRequest, stream initproto.API_InitServer) error {
+	s.restoreEtcdSnapshot = req.RestoreEtcdSnapshot
 	for _, r := range s.res {
 		_ = stream.Send(r)
 	}
 	return s.initErr
 }
+
+func (s *stubInitServer) UploadEtcdSnapshot(stream initproto.API_UploadEtcdSnapshotServer) error {
+	if s.uploadErr != nil {
+		return s.uploadErr
+	}
+	s.etcdSnapshot = []byte{}
+	for {
+		req, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return stream.SendAndClose(&initproto.UploadEtcdSnapshotResponse{})
+		}
+		if err != nil {
+			return err
+		}
+		s.etcdSnapshot = append(s.etcdSnapshot, req.Chunk...)
+	}
+}
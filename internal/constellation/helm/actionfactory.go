@@ -147,10 +147,12 @@ func (a actionFactory) newInstall(release release, timeout time.Duration) *insta
 
 func (a actionFactory) newUpgrade(release release, timeout time.Duration) *upgradeAction {
 	action := &upgradeAction{helmAction: newHelmUpgradeAction(a.cfg, timeout), release: release, log: a.log}
-	if release.releaseName == constellationOperatorsInfo.releaseName {
+	// Helm doesn't update CRDs on upgrades, so the CRDs of the operators and the key service are applied manually.
+	if release.releaseName == constellationOperatorsInfo.releaseName ||
+		release.releaseName == constellationServicesInfo.releaseName {
 		action.preUpgrade = func(ctx context.Context) error {
 			if err := a.updateCRDs(ctx, release.chart); err != nil {
-				return fmt.Errorf("updating operator CRDs: %w", err)
+				return fmt.Errorf("updating CRDs of %s: %w", release.releaseName, err)
 			}
 			return nil
 		}
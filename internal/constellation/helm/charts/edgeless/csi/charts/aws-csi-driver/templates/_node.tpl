@@ -118,6 +118,9 @@ spec:
               mountPath: /tmp
             - name: cryptsetup
               mountPath: /run/cryptsetup
+            - name: key-service-token
+              mountPath: /var/run/secrets/tokens
+              readOnly: true
           {{- with .Values.node.volumeMounts }}
           {{- toYaml . | nindent 12 }}
           {{- end }}
@@ -239,6 +242,14 @@ spec:
           hostPath:
             path: /run/cryptsetup
             type: Directory
+        # Service account token the plugin authenticates to the Constellation KeyService with.
+        - name: key-service-token
+          projected:
+            sources:
+              - serviceAccountToken:
+                  audience: constellation-key-service
+                  expirationSeconds: 3600
+                  path: key-service-token
         - name: tmp-dir
           emptyDir: {}
         - name: probe-dir
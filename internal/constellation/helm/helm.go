@@ -91,7 +91,10 @@ type Options struct {
 	HelmWaitMode        WaitMode
 	ApplyTimeout        time.Duration
 	OpenStackValues     *OpenStackValues
+	ExternalKMSValues   *ExternalKMSValues
 	ServiceCIDR         string
+	// KeyServiceAuthorization is the authorization mode of the KeyService. The chart default is used if empty.
+	KeyServiceAuthorization string
 }
 
 // PrepareApply loads the charts and returns the executor to apply them.
@@ -116,7 +119,7 @@ func (h Client) loadReleases(
 	helmLoader := newLoader(flags.CSP, flags.AttestationVariant, flags.K8sVersion, stateFile, h.cliVersion)
 	h.log.Debug("Created new Helm loader")
 	// TODO(burgerdev): pass down the entire flags struct
-	return helmLoader.loadReleases(flags.Conformance, flags.DeployCSIDriver, flags.HelmWaitMode, secret, serviceAccURI, flags.OpenStackValues, flags.ServiceCIDR)
+	return helmLoader.loadReleases(flags.Conformance, flags.DeployCSIDriver, flags.HelmWaitMode, secret, serviceAccURI, flags.OpenStackValues, flags.ExternalKMSValues, flags.ServiceCIDR, flags.KeyServiceAuthorization)
 }
 
 // Applier runs the Helm actions.
@@ -124,9 +124,16 @@ type OpenStackValues struct {
 	YawolImageID            string
 }
 
+// ExternalKMSValues are the URIs of an external KMS used by the key service instead of the master secret.
+type ExternalKMSValues struct {
+	KMSURI     string
+	StorageURI string
+}
+
 // loadReleases loads the embedded helm charts and returns them as a HelmReleases object.
 func (i *chartLoader) loadReleases(conformanceMode, deployCSIDriver bool, helmWaitMode WaitMode, masterSecret uri.MasterSecret,
-	serviceAccURI string, openStackValues *OpenStackValues, serviceCIDR string,
+	serviceAccURI string, openStackValues *OpenStackValues, externalKMSValues *ExternalKMSValues, serviceCIDR string,
+	keyServiceAuthorization string,
 ) (releaseApplyOrder, error) {
 	ciliumRelease, err := i.loadRelease(ciliumInfo, helmWaitMode)
 	if err != nil {
@@ -162,10 +169,16 @@ func (i *chartLoader) loadReleases(conformanceMode, deployCSIDriver bool, helmWa
 	}
 
 	svcVals, err := extraConstellationServicesValues(i.csp, i.attestationVariant, masterSecret,
-		serviceAccURI, i.stateFile.Infrastructure, openStackValues)
+		serviceAccURI, i.stateFile.Infrastructure, openStackValues, externalKMSValues)
 	if err != nil {
 		return nil, fmt.Errorf("extending constellation-services values: %w", err)
 	}
+	if keyServiceAuthorization != "" {
+		// The authorization mode is set on every apply, so enforcing authorization survives upgrades.
+		svcVals = mergeMaps(svcVals, map[string]any{
+			"key-service": map[string]any{"authorization": keyServiceAuthorization},
+		})
+	}
 	conServicesRelease.values = mergeMaps(conServicesRelease.values, svcVals)
 
 	releases := releaseApplyOrder{ciliumRelease, coreDNSRelease, conServicesRelease, certManagerRelease, operatorRelease}
@@ -304,7 +317,8 @@ func (i *chartLoader) loadOperatorsValues() map[string]any {
 					"image": i.constellationOperatorImage,
 				},
 			},
-			"csp": i.csp.String(),
+			"csp":                i.csp.String(),
+			"attestationVariant": i.attestationVariant.String(),
 		},
 		"node-maintenance-operator": map[string]any{
 			"controllerManager": map[string]any{
@@ -322,17 +336,23 @@ func (i *chartLoader) loadOperatorsValues() map[string]any {
 func (i *chartLoader) loadConstellationServicesValues() map[string]any {
 	return map[string]any{
 		"global": map[string]any{
-			"keyServicePort":      constants.KeyServicePort,
-			"keyServiceNamespace": "", // empty namespace means we use the release namespace
-			"serviceBasePath":     constants.ServiceBasePath,
-			"joinConfigCMName":    constants.JoinConfigMap,
-			"internalCMName":      constants.InternalConfigMap,
+			"keyServicePort":              constants.KeyServicePort,
+			"keyServiceATLSPort":          constants.KeyServiceATLSPort,
+			"keyServiceWorkloadHTTPSPort": constants.KeyServiceWorkloadHTTPSPort,
+			"keyServiceTokenAudience":     constants.KeyServiceTokenAudience,
+			"keyServiceNamespace":         "", // empty namespace means we use the release namespace
+			"serviceBasePath":             constants.ServiceBasePath,
+			"joinConfigCMName":            constants.JoinConfigMap,
+			"internalCMName":              constants.InternalConfigMap,
 		},
 		"key-service": map[string]any{
-			"image":               i.keyServiceImage,
-			"saltKeyName":         constants.ConstellationSaltKey,
-			"masterSecretKeyName": constants.ConstellationMasterSecretKey,
-			"masterSecretName":    constants.ConstellationMasterSecretStoreName,
+			"image":                    i.keyServiceImage,
+			"saltKeyName":              constants.ConstellationSaltKey,
+			"masterSecretKeyName":      constants.ConstellationMasterSecretKey,
+			"masterSecretName":         constants.ConstellationMasterSecretStoreName,
+			"kmsURIKeyName":            constants.ConstellationKMSURIKey,
+			"storageURIKeyName":        constants.ConstellationStorageURIKey,
+			"measurementSecretKeyName": constants.ConstellationMeasurementSecretKey,
 		},
 		"join-service": map[string]any{
 			"csp":   i.csp.String(),
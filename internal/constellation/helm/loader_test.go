@@ -21,6 +21,8 @@ import (
 	"github.com/stretchr/testify/require"
 	"helm.sh/helm/v3/pkg/chartutil"
 	"helm.sh/helm/v3/pkg/engine"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
 
 	"github.com/edgelesssys/constellation/v2/internal/attestation/measurements"
 	"github.com/edgelesssys/constellation/v2/internal/attestation/variant"
@@ -29,6 +31,7 @@ import (
 	"github.com/edgelesssys/constellation/v2/internal/cloud/gcpshared"
 	"github.com/edgelesssys/constellation/v2/internal/cloud/openstack"
 	"github.com/edgelesssys/constellation/v2/internal/config"
+	"github.com/edgelesssys/constellation/v2/internal/constants"
 	"github.com/edgelesssys/constellation/v2/internal/constellation/state"
 	"github.com/edgelesssys/constellation/v2/internal/kms/uri"
 	"github.com/edgelesssys/constellation/v2/internal/semver"
@@ -94,7 +97,7 @@ func TestLoadReleases(t *testing.T) {
 	helmReleases, err := chartLoader.loadReleases(
 		true, false, WaitModeAtomic,
 		uri.MasterSecret{Key: []byte("secret"), Salt: []byte("masterSalt")},
-		fakeServiceAccURI(cloudprovider.GCP), nil, "172.16.128.0/17",
+		fakeServiceAccURI(cloudprovider.GCP), nil, nil, "172.16.128.0/17", "",
 	)
 	require.NoError(err)
 	for _, release := range helmReleases {
@@ -212,7 +215,7 @@ func TestConstellationServices(t *testing.T) {
 					UID:   "uid",
 					Azure: &state.Azure{},
 					GCP:   &state.GCP{},
-				}, openstackValues)
+				}, openstackValues, nil)
 			require.NoError(err)
 			values = mergeMaps(values, extraVals)
 
@@ -312,16 +315,20 @@ func TestExtraCoreDNSValues(t *testing.T) {
 // TestOperators checks if the rendered constellation-services chart produces the expected yaml files.
 func TestOperators(t *testing.T) {
 	testCases := map[string]struct {
-		csp cloudprovider.Provider
+		csp                cloudprovider.Provider
+		attestationVariant variant.Variant
 	}{
 		"GCP": {
-			csp: cloudprovider.GCP,
+			csp:                cloudprovider.GCP,
+			attestationVariant: variant.GCPSEVES{},
 		},
 		"Azure": {
-			csp: cloudprovider.Azure,
+			csp:                cloudprovider.Azure,
+			attestationVariant: variant.AzureSEVSNP{},
 		},
 		"QEMU": {
-			csp: cloudprovider.QEMU,
+			csp:                cloudprovider.QEMU,
+			attestationVariant: variant.QEMUVTPM{},
 		},
 	}
 
@@ -332,6 +339,7 @@ func TestOperators(t *testing.T) {
 
 			chartLoader := chartLoader{
 				csp:                          tc.csp,
+				attestationVariant:           tc.attestationVariant,
 				joinServiceImage:             "joinServiceImage",
 				keyServiceImage:              "keyServiceImage",
 				ccmImage:                     "ccmImage",
@@ -381,6 +389,232 @@ func TestOperators(t *testing.T) {
 	}
 }
 
+// TestKeyServiceAuthorizationUpgrade checks that upgrading a cluster in enforce mode keeps the KeyService in enforce mode,
+// and that every caller of the KeyService deployed by Constellation authenticates with a service account token that a KeyAccessPolicy allows.
+// Callers are the JoinService and the node plugins of the CSI drivers, which map encrypted volumes with cryptsetup.
+func TestKeyServiceAuthorizationUpgrade(t *testing.T) {
+	testCases := map[string]struct {
+		csp                cloudprovider.Provider
+		attestationVariant variant.Variant
+		openStackValues    *OpenStackValues
+		wantCSICaller      bool
+	}{
+		"AWS": {
+			csp:                cloudprovider.AWS,
+			attestationVariant: variant.AWSNitroTPM{},
+			wantCSICaller:      true,
+		},
+		"Azure": {
+			csp:                cloudprovider.Azure,
+			attestationVariant: variant.AzureSEVSNP{},
+			wantCSICaller:      true,
+		},
+		"GCP": {
+			csp:                cloudprovider.GCP,
+			attestationVariant: variant.GCPSEVES{},
+			wantCSICaller:      true,
+		},
+		"OpenStack": {
+			csp:                cloudprovider.OpenStack,
+			attestationVariant: variant.QEMUVTPM{},
+			openStackValues:    &OpenStackValues{},
+			wantCSICaller:      true,
+		},
+		"QEMU": {
+			csp:                cloudprovider.QEMU,
+			attestationVariant: variant.QEMUVTPM{},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			chartLoader := newLoader(
+				tc.csp, tc.attestationVariant, versions.Default,
+				state.New().
+					SetInfrastructure(state.Infrastructure{
+						UID:   "uid",
+						Name:  "testCluster-uid",
+						Azure: &state.Azure{},
+						GCP:   &state.GCP{ProjectID: "test-project-id", IPCidrPod: "test-pod-cidr"},
+					}).
+					SetClusterValues(state.ClusterValues{MeasurementSalt: []byte{0x41}}),
+				semver.NewFromInt(2, 10, 0, ""),
+			)
+			releases, err := chartLoader.loadReleases(
+				false, true, WaitModeAtomic,
+				uri.MasterSecret{
+					Key:  []byte("aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"),
+					Salt: []byte("aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"),
+				},
+				fakeServiceAccURI(tc.csp), tc.openStackValues, nil, "172.16.128.0/17", "enforce",
+			)
+			require.NoError(err)
+
+			var manifests []string
+			for _, release := range releases {
+				if release.releaseName != constellationServicesInfo.releaseName && release.releaseName != csiInfo.releaseName {
+					continue
+				}
+				if release.releaseName == constellationServicesInfo.releaseName {
+					require.NoError(addInClusterValues(release.values, tc.csp))
+				}
+				require.NoError(chartutil.ProcessDependencies(release.chart, release.values))
+				valuesToRender, err := chartutil.ToRenderValues(release.chart, release.values, chartutil.ReleaseOptions{
+					Name:      release.releaseName,
+					Namespace: "kube-system",
+					Revision:  2,
+					IsUpgrade: true,
+				}, chartutil.DefaultCapabilities)
+				require.NoError(err)
+				result, err := engine.Render(release.chart, valuesToRender)
+				require.NoError(err)
+				for file, rendered := range result {
+					if strings.HasSuffix(file, ".yaml") {
+						manifests = append(manifests, strings.Split(rendered, "\n---\n")...)
+					}
+				}
+			}
+
+			var policies []keyAccessPolicy
+			var workloads []podWorkload
+			for _, manifest := range manifests {
+				var meta struct {
+					Kind string `json:"kind"`
+				}
+				require.NoError(yaml.Unmarshal([]byte(manifest), &meta))
+				switch meta.Kind {
+				case "KeyAccessPolicy":
+					var policy keyAccessPolicy
+					require.NoError(yaml.Unmarshal([]byte(manifest), &policy))
+					policies = append(policies, policy)
+				case "DaemonSet", "Deployment":
+					var workload podWorkload
+					require.NoError(yaml.Unmarshal([]byte(manifest), &workload))
+					workloads = append(workloads, workload)
+				}
+			}
+
+			var callers []string
+			for _, workload := range workloads {
+				podSpec := workload.Spec.Template.Spec
+				if workload.Metadata.Name == "key-service" {
+					assert.Contains(podSpec.Containers[0].Args, "--authorization=enforce")
+					continue
+				}
+				if !callsKeyService(podSpec) {
+					continue
+				}
+				callers = append(callers, workload.Metadata.Name)
+
+				assert.True(mountsKeyServiceToken(podSpec), "%s doesn't mount a service account token for the KeyService", workload.Metadata.Name)
+				serviceAccount := podSpec.ServiceAccountName
+				if serviceAccount == "" {
+					serviceAccount = podSpec.DeprecatedServiceAccount
+				}
+				assert.True(allowedByPolicy(policies, "kube-system", serviceAccount), "no KeyAccessPolicy allows %s", workload.Metadata.Name)
+			}
+			assert.Contains(callers, "join-service")
+			if tc.wantCSICaller {
+				assert.Len(callers, 2)
+			}
+		})
+	}
+}
+
+// keyAccessPolicy is the subset of a KeyAccessPolicy used to check authorization of callers.
+type keyAccessPolicy struct {
+	Spec struct {
+		Subjects []struct {
+			Kind      string `json:"kind"`
+			Namespace string `json:"namespace"`
+			Name      string `json:"name"`
+		} `json:"subjects"`
+		KeyIDPrefixes []string `json:"keyIDPrefixes"`
+	} `json:"spec"`
+}
+
+// podWorkload is a DaemonSet or Deployment.
+type podWorkload struct {
+	Metadata struct {
+		Name string `json:"name"`
+	} `json:"metadata"`
+	Spec struct {
+		Template corev1.PodTemplateSpec `json:"template"`
+	} `json:"spec"`
+}
+
+// callsKeyService returns true if a container of the Pod derives keys from the KeyService.
+func callsKeyService(podSpec corev1.PodSpec) bool {
+	for _, container := range podSpec.Containers {
+		for _, arg := range container.Args {
+			if strings.HasPrefix(arg, "--key-service-endpoint=") {
+				return true
+			}
+		}
+		for _, mount := range container.VolumeMounts {
+			if mount.MountPath == "/run/cryptsetup" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// mountsKeyServiceToken returns true if every container of the Pod that calls the KeyService mounts a projected
+// service account token for the KeyService at the path clients read it from.
+func mountsKeyServiceToken(podSpec corev1.PodSpec) bool {
+	var tokenVolume string
+	for _, volume := range podSpec.Volumes {
+		if volume.Projected == nil {
+			continue
+		}
+		for _, source := range volume.Projected.Sources {
+			if source.ServiceAccountToken != nil &&
+				source.ServiceAccountToken.Audience == constants.KeyServiceTokenAudience &&
+				source.ServiceAccountToken.Path == path.Base(constants.KeyServiceTokenPath) {
+				tokenVolume = volume.Name
+			}
+		}
+	}
+	if tokenVolume == "" {
+		return false
+	}
+
+	for _, container := range podSpec.Containers {
+		if !callsKeyService(corev1.PodSpec{Containers: []corev1.Container{container}}) {
+			continue
+		}
+		var mounted bool
+		for _, mount := range container.VolumeMounts {
+			if mount.Name == tokenVolume && mount.MountPath == path.Dir(constants.KeyServiceTokenPath) {
+				mounted = true
+			}
+		}
+		if !mounted {
+			return false
+		}
+	}
+	return true
+}
+
+// allowedByPolicy returns true if a policy allows the service account to derive keys.
+func allowedByPolicy(policies []keyAccessPolicy, namespace, serviceAccount string) bool {
+	for _, policy := range policies {
+		if len(policy.Spec.KeyIDPrefixes) == 0 {
+			continue
+		}
+		for _, subject := range policy.Spec.Subjects {
+			if subject.Kind == "ServiceAccount" && subject.Namespace == namespace && subject.Name == serviceAccount {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // compareMaps ensures that both maps specify the same templates.
 func compareMaps(expectedData map[string]string, result map[string]string, assert *assert.Assertions, require *require.Assertions, t *testing.T) {
 	// This whole block is only to produce useful error messages.
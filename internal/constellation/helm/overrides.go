@@ -111,7 +111,7 @@ func extraCiliumValues(provider cloudprovider.Provider, conformanceMode bool, ou
 // Values set inside this function are only applied during init, not during upgrade.
 func extraConstellationServicesValues(
 	csp cloudprovider.Provider, attestationVariant variant.Variant, masterSecret uri.MasterSecret, serviceAccURI string,
-	output state.Infrastructure, openStackCfg *OpenStackValues,
+	output state.Infrastructure, openStackCfg *OpenStackValues, externalKMSCfg *ExternalKMSValues,
 ) (map[string]any, error) {
 	extraVals := map[string]any{}
 	extraVals["join-service"] = map[string]any{
@@ -121,10 +121,19 @@ func extraConstellationServicesValues(
 		"attestationVariant": attestationVariant.String(),
 	}
 
-	extraVals["key-service"] = map[string]any{
-		"masterSecret": base64.StdEncoding.EncodeToString(masterSecret.Key),
-		"salt":         base64.StdEncoding.EncodeToString(masterSecret.Salt),
+	keyServiceVals := map[string]any{
+		"masterSecret":       base64.StdEncoding.EncodeToString(masterSecret.Key),
+		"salt":               base64.StdEncoding.EncodeToString(masterSecret.Salt),
+		"attestationVariant": attestationVariant.String(),
+	}
+	if len(masterSecret.MeasurementSecret) > 0 {
+		keyServiceVals["measurementSecret"] = base64.StdEncoding.EncodeToString(masterSecret.MeasurementSecret)
+	}
+	if externalKMSCfg != nil {
+		keyServiceVals["kmsURI"] = base64.StdEncoding.EncodeToString([]byte(externalKMSCfg.KMSURI))
+		keyServiceVals["storageURI"] = base64.StdEncoding.EncodeToString([]byte(externalKMSCfg.StorageURI))
 	}
+	extraVals["key-service"] = keyServiceVals
 	switch csp {
 	case cloudprovider.OpenStack:
 		creds, err := openstack.AccountKeyFromURI(serviceAccURI)
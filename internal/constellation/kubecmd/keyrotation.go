@@ -0,0 +1,283 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package kubecmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/edgelesssys/constellation/v2/internal/constants"
+	"github.com/edgelesssys/constellation/v2/internal/crypto"
+	"github.com/edgelesssys/constellation/v2/internal/kms/uri"
+	updatev1alpha1 "github.com/edgelesssys/constellation/v2/operators/constellation-node-operator/api/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	// keyServiceDaemonSet is the name of the key service DaemonSet.
+	keyServiceDaemonSet = "key-service"
+	// confidentialCSIDriverSuffix is the suffix of the names of Constellation's CSI drivers.
+	confidentialCSIDriverSuffix = ".csi.confidential.cloud"
+	// s3proxyImage is the name of the s3proxy container image.
+	s3proxyImage = "/constellation/s3proxy"
+)
+
+var keyRotationGVR = schema.GroupVersionResource{
+	Group:    "update.edgeless.systems",
+	Version:  "v1alpha1",
+	Resource: "keyrotations",
+}
+
+var etcdBackupGVR = schema.GroupVersionResource{
+	Group:    "update.edgeless.systems",
+	Version:  "v1alpha1",
+	Resource: "etcdbackups",
+}
+
+type keyRotationKubectl interface {
+	CreateCR(ctx context.Context, gvr schema.GroupVersionResource, obj *unstructured.Unstructured) (*unstructured.Unstructured, error)
+	DeleteCR(ctx context.Context, gvr schema.GroupVersionResource, name string) error
+	GetSecret(ctx context.Context, namespace, name string) (*corev1.Secret, error)
+	ReplaceSecret(ctx context.Context, secret *corev1.Secret) error
+	ListPersistentVolumes(ctx context.Context) ([]corev1.PersistentVolume, error)
+	ListPods(ctx context.Context) ([]corev1.Pod, error)
+	ListCRs(ctx context.Context, gvr schema.GroupVersionResource) ([]unstructured.Unstructured, error)
+	GetDaemonSet(ctx context.Context, namespace, name string) (*appsv1.DaemonSet, error)
+	RestartDaemonSet(ctx context.Context, namespace, name string) error
+}
+
+// ErrMasterSecretMismatch signals that the master secret of the cluster doesn't match the expected master secret.
+var ErrMasterSecretMismatch = errors.New("master secret of the cluster doesn't match the local master secret")
+
+// GetKeyRotation returns the KeyRotation of the cluster.
+// If no key rotation was started yet, nil is returned.
+func (k *KubeCmd) GetKeyRotation(ctx context.Context) (*updatev1alpha1.KeyRotation, error) {
+	var raw *unstructured.Unstructured
+	err := k.retryAction(ctx, func(ctx context.Context) error {
+		var err error
+		raw, err = k.kubectl.GetCR(ctx, keyRotationGVR, constants.KeyRotationResourceName)
+		if k8serrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("getting KeyRotation: %w", err)
+	}
+	if raw == nil {
+		return nil, nil
+	}
+
+	var keyRotation updatev1alpha1.KeyRotation
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(raw.UnstructuredContent(), &keyRotation); err != nil {
+		return nil, fmt.Errorf("converting unstructured to KeyRotation: %w", err)
+	}
+	return &keyRotation, nil
+}
+
+// UsesExternalKMS checks if the cluster uses an external KMS instead of the master secret.
+func (k *KubeCmd) UsesExternalKMS(ctx context.Context) (bool, error) {
+	secret, err := k.kubectl.GetSecret(ctx, constants.ConstellationNamespace, constants.ConstellationMasterSecretStoreName)
+	if err != nil {
+		return false, fmt.Errorf("getting master secret: %w", err)
+	}
+	_, ok := secret.Data[constants.ConstellationKMSURIKey]
+	return ok, nil
+}
+
+// ListMasterSecretConsumers returns the resources whose data is encrypted with keys derived from the master secret.
+// The data becomes inaccessible if the master secret is rotated, since these keys aren't re-wrapped:
+// PersistentVolumes provisioned by one of Constellation's CSI drivers, Pods of the s3proxy,
+// and EtcdBackups, whose snapshots are encrypted with a workload key.
+// Workload keys derived by other applications can't be detected.
+func (k *KubeCmd) ListMasterSecretConsumers(ctx context.Context) ([]string, error) {
+	var consumers []string
+
+	volumes, err := k.kubectl.ListPersistentVolumes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, volume := range volumes {
+		if volume.Spec.CSI != nil && strings.HasSuffix(volume.Spec.CSI.Driver, confidentialCSIDriverSuffix) {
+			consumers = append(consumers, "PersistentVolume "+volume.Name)
+		}
+	}
+
+	pods, err := k.kubectl.ListPods(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, pod := range pods {
+		for _, container := range pod.Spec.Containers {
+			if strings.Contains(container.Image, s3proxyImage) {
+				consumers = append(consumers, "s3proxy Pod "+pod.Namespace+"/"+pod.Name)
+				break
+			}
+		}
+	}
+
+	backups, err := k.kubectl.ListCRs(ctx, etcdBackupGVR)
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return nil, fmt.Errorf("listing EtcdBackups: %w", err)
+	}
+	for _, backup := range backups {
+		consumers = append(consumers, "EtcdBackup "+backup.GetName())
+	}
+	return consumers, nil
+}
+
+// RotateMasterSecret replaces the master secret of the cluster and restarts the key service to use it.
+// The previous master secret is kept until [KubeCmd.RemovePreviousMasterSecret] is called,
+// so state disks can be re-keyed from the previous to the new master secret.
+// The measurement secret is pinned to the value derived from the first master secret, so the cluster ID stays stable.
+func (k *KubeCmd) RotateMasterSecret(ctx context.Context, previous, next uri.MasterSecret) error {
+	secret, err := k.kubectl.GetSecret(ctx, constants.ConstellationNamespace, constants.ConstellationMasterSecretStoreName)
+	if err != nil {
+		return fmt.Errorf("getting master secret: %w", err)
+	}
+
+	switch currentKey := secret.Data[constants.ConstellationMasterSecretKey]; {
+	case bytes.Equal(currentKey, next.Key):
+		k.log.Debug("Master secret was already replaced")
+	case bytes.Equal(currentKey, previous.Key):
+		if _, ok := secret.Data[constants.ConstellationMeasurementSecretKey]; !ok {
+			measurementSecret := previous.MeasurementSecret
+			if len(measurementSecret) == 0 {
+				measurementSecret, err = crypto.DeriveKey(previous.Key, previous.Salt, []byte(crypto.DEKPrefix+crypto.MeasurementSecretKeyID), crypto.DerivedKeyLengthDefault)
+				if err != nil {
+					return fmt.Errorf("deriving measurement secret: %w", err)
+				}
+			}
+			secret.Data[constants.ConstellationMeasurementSecretKey] = measurementSecret
+		}
+		secret.Data[constants.ConstellationPreviousMasterSecretKey] = previous.Key
+		secret.Data[constants.ConstellationPreviousSaltKey] = previous.Salt
+		secret.Data[constants.ConstellationMasterSecretKey] = next.Key
+		secret.Data[constants.ConstellationSaltKey] = next.Salt
+
+		k.log.Debug("Replacing master secret")
+		if err := k.kubectl.ReplaceSecret(ctx, secret); err != nil {
+			return fmt.Errorf("replacing master secret: %w", err)
+		}
+	default:
+		return ErrMasterSecretMismatch
+	}
+
+	k.log.Debug("Restarting key service")
+	if err := k.retryAction(ctx, func(ctx context.Context) error {
+		return k.kubectl.RestartDaemonSet(ctx, constants.ConstellationNamespace, keyServiceDaemonSet)
+	}); err != nil {
+		return fmt.Errorf("restarting key service: %w", err)
+	}
+	return k.waitForKeyService(ctx)
+}
+
+// RemovePreviousMasterSecret removes the previous master secret from the cluster after a completed key rotation.
+func (k *KubeCmd) RemovePreviousMasterSecret(ctx context.Context) error {
+	secret, err := k.kubectl.GetSecret(ctx, constants.ConstellationNamespace, constants.ConstellationMasterSecretStoreName)
+	if err != nil {
+		return fmt.Errorf("getting master secret: %w", err)
+	}
+	if _, ok := secret.Data[constants.ConstellationPreviousMasterSecretKey]; !ok {
+		return nil
+	}
+	delete(secret.Data, constants.ConstellationPreviousMasterSecretKey)
+	delete(secret.Data, constants.ConstellationPreviousSaltKey)
+	if err := k.kubectl.ReplaceSecret(ctx, secret); err != nil {
+		return fmt.Errorf("replacing master secret: %w", err)
+	}
+	return nil
+}
+
+// StartKeyRotation starts re-keying the state disks of all nodes.
+// A completed KeyRotation is replaced.
+func (k *KubeCmd) StartKeyRotation(ctx context.Context, rotationID string) error {
+	keyRotation, err := k.GetKeyRotation(ctx)
+	if err != nil {
+		return err
+	}
+	if keyRotation != nil {
+		if keyRotation.Status.Phase != updatev1alpha1.KeyRotationPhaseCompleted {
+			return ErrInProgress
+		}
+		k.log.Debug("Deleting completed KeyRotation", "rotationID", keyRotation.Spec.RotationID)
+		if err := k.kubectl.DeleteCR(ctx, keyRotationGVR, constants.KeyRotationResourceName); err != nil && !k8serrors.IsNotFound(err) {
+			return fmt.Errorf("deleting completed KeyRotation: %w", err)
+		}
+	}
+
+	raw, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&updatev1alpha1.KeyRotation{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: updatev1alpha1.GroupVersion.String(),
+			Kind:       "KeyRotation",
+		},
+		ObjectMeta: metav1.ObjectMeta{Name: constants.KeyRotationResourceName},
+		Spec:       updatev1alpha1.KeyRotationSpec{RotationID: rotationID},
+	})
+	if err != nil {
+		return fmt.Errorf("converting KeyRotation to unstructured: %w", err)
+	}
+	if err := k.retryAction(ctx, func(ctx context.Context) error {
+		_, err := k.kubectl.CreateCR(ctx, keyRotationGVR, &unstructured.Unstructured{Object: raw})
+		return err
+	}); err != nil {
+		return fmt.Errorf("creating KeyRotation: %w", err)
+	}
+	return nil
+}
+
+// WaitForKeyRotation blocks until the state disks of all nodes were re-keyed.
+func (k *KubeCmd) WaitForKeyRotation(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		keyRotation, err := k.GetKeyRotation(ctx)
+		if err != nil {
+			return err
+		}
+		if keyRotation == nil {
+			return errors.New("KeyRotation was deleted")
+		}
+		if keyRotation.Status.Phase == updatev1alpha1.KeyRotationPhaseCompleted {
+			return nil
+		}
+		k.log.Debug("Waiting for key rotation", "rotatedNodes", keyRotation.Status.RotatedNodes, "pendingNodes", keyRotation.Status.PendingNodes)
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("waiting for key rotation: %w", ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// waitForKeyService blocks until all key service pods were restarted.
+func (k *KubeCmd) waitForKeyService(ctx context.Context) error {
+	return k.retryAction(ctx, func(ctx context.Context) error {
+		daemonSet, err := k.kubectl.GetDaemonSet(ctx, constants.ConstellationNamespace, keyServiceDaemonSet)
+		if err != nil {
+			return err
+		}
+		status := daemonSet.Status
+		if status.ObservedGeneration < daemonSet.Generation ||
+			status.UpdatedNumberScheduled != status.DesiredNumberScheduled ||
+			status.NumberAvailable != status.DesiredNumberScheduled {
+			return fmt.Errorf("key service restarted on %d of %d nodes", status.UpdatedNumberScheduled, status.DesiredNumberScheduled)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,346 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package kubecmd
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/edgelesssys/constellation/v2/internal/constants"
+	"github.com/edgelesssys/constellation/v2/internal/kms/uri"
+	"github.com/edgelesssys/constellation/v2/internal/logger"
+	updatev1alpha1 "github.com/edgelesssys/constellation/v2/operators/constellation-node-operator/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestRotateMasterSecret(t *testing.T) {
+	previous := uri.MasterSecret{Key: []byte("previous-key"), Salt: []byte("previous-salt")}
+	next := uri.MasterSecret{Key: []byte("next-key"), Salt: []byte("next-salt")}
+	someErr := errors.New("failed")
+
+	testCases := map[string]struct {
+		secretData            map[string][]byte
+		replaceErr            error
+		restartErr            error
+		wantReplace           bool
+		wantMeasurementSecret []byte
+		wantErr               bool
+	}{
+		"master secret is replaced": {
+			secretData: map[string][]byte{
+				constants.ConstellationMasterSecretKey: previous.Key,
+				constants.ConstellationSaltKey:         previous.Salt,
+			},
+			wantReplace: true,
+		},
+		"pinned measurement secret is kept": {
+			secretData: map[string][]byte{
+				constants.ConstellationMasterSecretKey:      previous.Key,
+				constants.ConstellationSaltKey:              previous.Salt,
+				constants.ConstellationMeasurementSecretKey: []byte("pinned"),
+			},
+			wantReplace:           true,
+			wantMeasurementSecret: []byte("pinned"),
+		},
+		"master secret was already replaced": {
+			secretData: map[string][]byte{
+				constants.ConstellationMasterSecretKey: next.Key,
+				constants.ConstellationSaltKey:         next.Salt,
+			},
+		},
+		"master secret doesn't match": {
+			secretData: map[string][]byte{
+				constants.ConstellationMasterSecretKey: []byte("other-key"),
+				constants.ConstellationSaltKey:         []byte("other-salt"),
+			},
+			wantErr: true,
+		},
+		"replacing secret fails": {
+			secretData: map[string][]byte{
+				constants.ConstellationMasterSecretKey: previous.Key,
+				constants.ConstellationSaltKey:         previous.Salt,
+			},
+			replaceErr:  someErr,
+			wantReplace: true,
+			wantErr:     true,
+		},
+		"restarting key service fails": {
+			secretData: map[string][]byte{
+				constants.ConstellationMasterSecretKey: next.Key,
+				constants.ConstellationSaltKey:         next.Salt,
+			},
+			restartErr: someErr,
+			wantErr:    true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			kubectl := &stubKeyRotationKubectl{
+				secret:     &corev1.Secret{Data: tc.secretData},
+				replaceErr: tc.replaceErr,
+				restartErr: tc.restartErr,
+				daemonSet:  &appsv1.DaemonSet{},
+			}
+			cmd := &KubeCmd{kubectl: kubectl, log: logger.NewTest(t), maxAttempts: 1, retryInterval: time.Millisecond}
+
+			err := cmd.RotateMasterSecret(t.Context(), previous, next)
+			if tc.wantErr {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+			}
+
+			if !tc.wantReplace {
+				assert.Nil(kubectl.replacedSecret)
+				return
+			}
+			require.NotNil(kubectl.replacedSecret)
+			data := kubectl.replacedSecret.Data
+			assert.Equal(next.Key, data[constants.ConstellationMasterSecretKey])
+			assert.Equal(next.Salt, data[constants.ConstellationSaltKey])
+			assert.Equal(previous.Key, data[constants.ConstellationPreviousMasterSecretKey])
+			assert.Equal(previous.Salt, data[constants.ConstellationPreviousSaltKey])
+			if tc.wantMeasurementSecret != nil {
+				assert.Equal(tc.wantMeasurementSecret, data[constants.ConstellationMeasurementSecretKey])
+			} else {
+				assert.Len(data[constants.ConstellationMeasurementSecretKey], 32)
+			}
+		})
+	}
+}
+
+func TestRemovePreviousMasterSecret(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	kubectl := &stubKeyRotationKubectl{secret: &corev1.Secret{Data: map[string][]byte{
+		constants.ConstellationMasterSecretKey:         []byte("key"),
+		constants.ConstellationSaltKey:                 []byte("salt"),
+		constants.ConstellationPreviousMasterSecretKey: []byte("previous-key"),
+		constants.ConstellationPreviousSaltKey:         []byte("previous-salt"),
+	}}}
+	cmd := &KubeCmd{kubectl: kubectl, log: logger.NewTest(t), maxAttempts: 1, retryInterval: time.Millisecond}
+
+	require.NoError(cmd.RemovePreviousMasterSecret(t.Context()))
+	require.NotNil(kubectl.replacedSecret)
+	assert.Equal(map[string][]byte{
+		constants.ConstellationMasterSecretKey: []byte("key"),
+		constants.ConstellationSaltKey:         []byte("salt"),
+	}, kubectl.replacedSecret.Data)
+
+	// the secret is not replaced again if there is no previous master secret
+	kubectl.replacedSecret = nil
+	require.NoError(cmd.RemovePreviousMasterSecret(t.Context()))
+	assert.Nil(kubectl.replacedSecret)
+}
+
+func TestStartKeyRotation(t *testing.T) {
+	keyRotation := func(phase updatev1alpha1.KeyRotationPhase) *unstructured.Unstructured {
+		raw, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&updatev1alpha1.KeyRotation{
+			ObjectMeta: metav1.ObjectMeta{Name: constants.KeyRotationResourceName},
+			Status:     updatev1alpha1.KeyRotationStatus{Phase: phase},
+		})
+		require.NoError(t, err)
+		return &unstructured.Unstructured{Object: raw}
+	}
+
+	testCases := map[string]struct {
+		existing   *unstructured.Unstructured
+		wantDelete bool
+		wantErr    bool
+	}{
+		"no previous rotation": {},
+		"completed rotation is replaced": {
+			existing:   keyRotation(updatev1alpha1.KeyRotationPhaseCompleted),
+			wantDelete: true,
+		},
+		"rotation in progress": {
+			existing: keyRotation(updatev1alpha1.KeyRotationPhaseInProgress),
+			wantErr:  true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			kubectl := &stubKeyRotationKubectl{keyRotation: tc.existing}
+			cmd := &KubeCmd{kubectl: kubectl, log: logger.NewTest(t), maxAttempts: 1, retryInterval: time.Millisecond}
+
+			err := cmd.StartKeyRotation(t.Context(), "rotation-id")
+			if tc.wantErr {
+				assert.ErrorIs(err, ErrInProgress)
+				assert.Nil(kubectl.createdCR)
+				return
+			}
+			require.NoError(err)
+			assert.Equal(tc.wantDelete, kubectl.deletedCR)
+			require.NotNil(kubectl.createdCR)
+			rotationID, _, err := unstructured.NestedString(kubectl.createdCR.Object, "spec", "rotationID")
+			require.NoError(err)
+			assert.Equal("rotation-id", rotationID)
+			assert.Equal(constants.KeyRotationResourceName, kubectl.createdCR.GetName())
+		})
+	}
+}
+
+func TestListMasterSecretConsumers(t *testing.T) {
+	volume := func(name, driver string) corev1.PersistentVolume {
+		pv := corev1.PersistentVolume{ObjectMeta: metav1.ObjectMeta{Name: name}}
+		if driver != "" {
+			pv.Spec.CSI = &corev1.CSIPersistentVolumeSource{Driver: driver}
+		}
+		return pv
+	}
+	pod := func(namespace, name, image string) corev1.Pod {
+		return corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Image: image}}},
+		}
+	}
+	etcdBackup := func(name string) unstructured.Unstructured {
+		backup := unstructured.Unstructured{}
+		backup.SetName(name)
+		return backup
+	}
+
+	testCases := map[string]struct {
+		kubectl       *stubKeyRotationKubectl
+		wantConsumers []string
+		wantErr       bool
+	}{
+		"no consumers": {
+			kubectl: &stubKeyRotationKubectl{
+				volumes: []corev1.PersistentVolume{volume("other", "ebs.csi.aws.com")},
+				pods:    []corev1.Pod{pod("default", "nginx", "nginx:latest")},
+			},
+		},
+		"confidential volumes, s3proxy and etcd backups": {
+			kubectl: &stubKeyRotationKubectl{
+				volumes: []corev1.PersistentVolume{
+					volume("gcp", "gcp.csi.confidential.cloud"),
+					volume("other", "ebs.csi.aws.com"),
+					volume("local", ""),
+					volume("azure", "azuredisk.csi.confidential.cloud"),
+				},
+				pods: []corev1.Pod{
+					pod("default", "s3proxy-0", "ghcr.io/edgelesssys/constellation/s3proxy:v2.23.0"),
+					pod("default", "nginx", "nginx:latest"),
+				},
+				etcdBackups: []unstructured.Unstructured{etcdBackup("daily")},
+			},
+			wantConsumers: []string{"PersistentVolume gcp", "PersistentVolume azure", "s3proxy Pod default/s3proxy-0", "EtcdBackup daily"},
+		},
+		"EtcdBackup CRD not installed": {
+			kubectl: &stubKeyRotationKubectl{
+				volumes:       []corev1.PersistentVolume{volume("gcp", "gcp.csi.confidential.cloud")},
+				etcdBackupErr: k8serrors.NewNotFound(schema.GroupResource{}, "etcdbackups"),
+			},
+			wantConsumers: []string{"PersistentVolume gcp"},
+		},
+		"listing EtcdBackups fails": {
+			kubectl: &stubKeyRotationKubectl{etcdBackupErr: errors.New("failed")},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			cmd := &KubeCmd{kubectl: tc.kubectl, log: logger.NewTest(t)}
+
+			consumers, err := cmd.ListMasterSecretConsumers(t.Context())
+			if tc.wantErr {
+				assert.Error(err)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(tc.wantConsumers, consumers)
+		})
+	}
+}
+
+type stubKeyRotationKubectl struct {
+	kubectlInterface
+	secret         *corev1.Secret
+	replacedSecret *corev1.Secret
+	replaceErr     error
+	daemonSet      *appsv1.DaemonSet
+	restartErr     error
+	volumes        []corev1.PersistentVolume
+	pods           []corev1.Pod
+	etcdBackups    []unstructured.Unstructured
+	etcdBackupErr  error
+	keyRotation    *unstructured.Unstructured
+	createdCR      *unstructured.Unstructured
+	deletedCR      bool
+}
+
+func (s *stubKeyRotationKubectl) GetSecret(context.Context, string, string) (*corev1.Secret, error) {
+	return s.secret.DeepCopy(), nil
+}
+
+func (s *stubKeyRotationKubectl) ReplaceSecret(_ context.Context, secret *corev1.Secret) error {
+	s.replacedSecret = secret
+	if s.replaceErr != nil {
+		return s.replaceErr
+	}
+	s.secret = secret
+	return nil
+}
+
+func (s *stubKeyRotationKubectl) GetDaemonSet(context.Context, string, string) (*appsv1.DaemonSet, error) {
+	return s.daemonSet, nil
+}
+
+func (s *stubKeyRotationKubectl) RestartDaemonSet(context.Context, string, string) error {
+	return s.restartErr
+}
+
+func (s *stubKeyRotationKubectl) ListPersistentVolumes(context.Context) ([]corev1.PersistentVolume, error) {
+	return s.volumes, nil
+}
+
+func (s *stubKeyRotationKubectl) ListPods(context.Context) ([]corev1.Pod, error) {
+	return s.pods, nil
+}
+
+func (s *stubKeyRotationKubectl) ListCRs(context.Context, schema.GroupVersionResource) ([]unstructured.Unstructured, error) {
+	return s.etcdBackups, s.etcdBackupErr
+}
+
+func (s *stubKeyRotationKubectl) GetCR(context.Context, schema.GroupVersionResource, string) (*unstructured.Unstructured, error) {
+	if s.keyRotation == nil {
+		return nil, k8serrors.NewNotFound(schema.GroupResource{}, constants.KeyRotationResourceName)
+	}
+	return s.keyRotation, nil
+}
+
+func (s *stubKeyRotationKubectl) CreateCR(_ context.Context, _ schema.GroupVersionResource, obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	s.createdCR = obj
+	return obj, nil
+}
+
+func (s *stubKeyRotationKubectl) DeleteCR(context.Context, schema.GroupVersionResource, string) error {
+	s.deletedCR = true
+	return nil
+}
@@ -31,6 +31,7 @@ import (
 	"github.com/edgelesssys/constellation/v2/internal/constants"
 	internalk8s "github.com/edgelesssys/constellation/v2/internal/kubernetes"
 	"github.com/edgelesssys/constellation/v2/internal/kubernetes/kubectl"
+	"github.com/edgelesssys/constellation/v2/internal/nodegroup"
 	conretry "github.com/edgelesssys/constellation/v2/internal/retry"
 	"github.com/edgelesssys/constellation/v2/internal/semver"
 	"github.com/edgelesssys/constellation/v2/internal/versions"
@@ -52,6 +53,9 @@ import (
 // ErrInProgress signals that an upgrade is in progress inside the cluster.
 var ErrInProgress = errors.New("upgrade in progress")
 
+// ErrRolledBack signals that the node operator rolled back a previous upgrade to the same image.
+var ErrRolledBack = errors.New("image was rolled back")
+
 // InvalidUpgradeError present an invalid upgrade. It wraps the source and destination version for improved debuggability.
 type applyError struct {
 	expected string
@@ -95,7 +99,7 @@ func (k *KubeCmd) UpgradeNodeImage(ctx context.Context, imageVersion semver.Semv
 
 	k.log.Debug("Checking if image upgrade is valid")
 	var upgradeErr *compatibility.InvalidUpgradeError
-	err = k.isValidImageUpgrade(nodeVersion, imageVersion.String(), force)
+	err = k.isValidImageUpgrade(nodeVersion, imageVersion.String(), imageReference, force)
 	switch {
 	case errors.As(err, &upgradeErr):
 		return fmt.Errorf("skipping image upgrade: %w", err)
@@ -187,6 +191,28 @@ func (k *KubeCmd) ClusterStatus(ctx context.Context) (map[string]NodeStatus, err
 	return clusterStatus, nil
 }
 
+// NodeImageUpgradeInProgress returns true if not all nodes of the cluster run the cluster's target image yet.
+func (k *KubeCmd) NodeImageUpgradeInProgress(ctx context.Context) (bool, error) {
+	nodeVersion, err := k.getConstellationVersion(ctx)
+	if err != nil {
+		return false, fmt.Errorf("retrieving Constellation version: %w", err)
+	}
+	if isOutdated(nodeVersion) {
+		return true, nil
+	}
+
+	status, err := k.ClusterStatus(ctx)
+	if err != nil {
+		return false, err
+	}
+	for _, node := range status {
+		if node.ImageVersion() != nodeVersion.Spec.ImageReference {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 // GetClusterAttestationConfig fetches the join-config configmap from the cluster,
 // and returns the attestation config.
 func (k *KubeCmd) GetClusterAttestationConfig(ctx context.Context, variant variant.Variant) (config.AttestationCfg, error) {
@@ -245,6 +271,41 @@ func (k *KubeCmd) ApplyJoinConfig(ctx context.Context, newAttestConfig config.At
 	return nil
 }
 
+// ApplyNodeGroupConfig creates or updates the ConfigMap holding the Kubernetes node settings of the cluster's node groups.
+// The JoinService hands out these settings to joining nodes, existing nodes are not modified.
+func (k *KubeCmd) ApplyNodeGroupConfig(ctx context.Context, nodeGroups nodegroup.Config) error {
+	data, err := nodeGroups.Marshal()
+	if err != nil {
+		return err
+	}
+
+	if err := k.retryAction(ctx, func(ctx context.Context) error {
+		existingConfig, err := k.kubectl.GetConfigMap(ctx, constants.ConstellationNamespace, constants.NodeGroupConfigMap)
+		if k8serrors.IsNotFound(err) {
+			k.log.Debug("ConfigMap does not exist, creating it now", "name", constants.NodeGroupConfigMap, "namespace", constants.ConstellationNamespace)
+			return k.kubectl.CreateConfigMap(ctx, nodeGroupConfigMap(data))
+		}
+		if err != nil {
+			return err
+		}
+
+		if existingConfig.Data[constants.NodeGroupConfigKey] == data {
+			k.log.Debug("Node group settings are up to date")
+			return nil
+		}
+		nodeGroupConfig := existingConfig.DeepCopy()
+		if nodeGroupConfig.Data == nil {
+			nodeGroupConfig.Data = map[string]string{}
+		}
+		nodeGroupConfig.Data[constants.NodeGroupConfigKey] = data
+		_, err = k.kubectl.UpdateConfigMap(ctx, nodeGroupConfig)
+		return err
+	}); err != nil {
+		return fmt.Errorf("applying %s ConfigMap: %w", constants.NodeGroupConfigMap, err)
+	}
+	return nil
+}
+
 // ExtendClusterConfigCertSANs extends the ClusterConfig stored under "kube-system/kubeadm-config" with the given SANs.
 // Empty strings are ignored, existing SANs are preserved.
 func (k *KubeCmd) ExtendClusterConfigCertSANs(ctx context.Context, alternativeNames []string) error {
@@ -368,16 +429,20 @@ func (k *KubeCmd) applyNodeVersion(ctx context.Context, nodeVersion updatev1alph
 }
 
 // isValidImageUpdate checks if the new image version is a valid upgrade, and there is no upgrade already running.
-func (k *KubeCmd) isValidImageUpgrade(nodeVersion updatev1alpha1.NodeVersion, newImageVersion string, force bool) error {
+func (k *KubeCmd) isValidImageUpgrade(nodeVersion updatev1alpha1.NodeVersion, newImageVersion, newImageReference string, force bool) error {
 	if !force {
 		// check if an image update is already in progress
 		if nodeVersion.Status.ActiveClusterVersionUpgrade {
 			return ErrInProgress
 		}
-		for _, condition := range nodeVersion.Status.Conditions {
-			if condition.Type == updatev1alpha1.ConditionOutdated && condition.Status == metav1.ConditionTrue {
-				return ErrInProgress
-			}
+		if isOutdated(nodeVersion) {
+			return ErrInProgress
+		}
+
+		// check if the canary rollout of the new image failed before
+		if canary := nodeVersion.Status.Canary; canary != nil && canary.Phase == updatev1alpha1.CanaryPhaseFailed &&
+			strings.EqualFold(canary.ImageReference, newImageReference) {
+			return fmt.Errorf("%w: %s", ErrRolledBack, canary.Message)
 		}
 
 		// check if the image upgrade is valid for the current version
@@ -388,6 +453,16 @@ func (k *KubeCmd) isValidImageUpgrade(nodeVersion updatev1alpha1.NodeVersion, ne
 	return nil
 }
 
+// isOutdated returns true if the node operator reports that nodes are not yet running the target versions.
+func isOutdated(nodeVersion updatev1alpha1.NodeVersion) bool {
+	for _, condition := range nodeVersion.Status.Conditions {
+		if condition.Type == updatev1alpha1.ConditionOutdated && condition.Status == metav1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
 func (k *KubeCmd) prepareUpdateK8s(nodeVersion *updatev1alpha1.NodeVersion, newClusterVersion string, components components.Components, force bool) (*corev1.ConfigMap, error) {
 	configMap, err := internalk8s.ConstructK8sComponentsCM(components, newClusterVersion)
 	if err != nil {
@@ -516,6 +591,18 @@ func joinConfigMap(attestationCfgJSON, measurementSalt []byte) *corev1.ConfigMap
 	}
 }
 
+func nodeGroupConfigMap(data string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      constants.NodeGroupConfigMap,
+			Namespace: constants.ConstellationNamespace,
+		},
+		Data: map[string]string{
+			constants.NodeGroupConfigKey: data,
+		},
+	}
+}
+
 type kubeDoer struct {
 	action func(ctx context.Context) error
 }
@@ -534,6 +621,8 @@ type kubectlInterface interface {
 	GetCR(ctx context.Context, gvr schema.GroupVersionResource, name string) (*unstructured.Unstructured, error)
 	UpdateCR(ctx context.Context, gvr schema.GroupVersionResource, obj *unstructured.Unstructured) (*unstructured.Unstructured, error)
 	crdLister
+	keyRotationKubectl
+	nodeRevocationKubectl
 }
 
 type debugLog interface {
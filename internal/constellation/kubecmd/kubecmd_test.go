@@ -19,6 +19,7 @@ import (
 	"github.com/edgelesssys/constellation/v2/internal/config"
 	"github.com/edgelesssys/constellation/v2/internal/constants"
 	"github.com/edgelesssys/constellation/v2/internal/logger"
+	"github.com/edgelesssys/constellation/v2/internal/nodegroup"
 	"github.com/edgelesssys/constellation/v2/internal/semver"
 	"github.com/edgelesssys/constellation/v2/internal/versions"
 	"github.com/edgelesssys/constellation/v2/internal/versions/components"
@@ -38,6 +39,7 @@ import (
 func TestUpgradeNodeImage(t *testing.T) {
 	testCases := map[string]struct {
 		conditions          []metav1.Condition
+		canary              *updatev1alpha1.CanaryStatus
 		currentImageVersion semver.Semver
 		newImageVersion     semver.Semver
 		badImageVersion     string
@@ -84,6 +86,37 @@ func TestUpgradeNodeImage(t *testing.T) {
 			force:               true,
 			wantUpdate:          true,
 		},
+		"image was rolled back": {
+			canary: &updatev1alpha1.CanaryStatus{
+				ImageReference: "/path/to/image:v1.2.3",
+				Phase:          updatev1alpha1.CanaryPhaseFailed,
+			},
+			currentImageVersion: semver.NewFromInt(1, 2, 2, ""),
+			newImageVersion:     semver.NewFromInt(1, 2, 3, ""),
+			wantErr:             true,
+			assertCorrectError: func(t *testing.T, err error) bool {
+				return assert.ErrorIs(t, err, ErrRolledBack)
+			},
+		},
+		"success after other image was rolled back": {
+			canary: &updatev1alpha1.CanaryStatus{
+				ImageReference: "/path/to/image:v1.2.4",
+				Phase:          updatev1alpha1.CanaryPhaseFailed,
+			},
+			currentImageVersion: semver.NewFromInt(1, 2, 2, ""),
+			newImageVersion:     semver.NewFromInt(1, 2, 3, ""),
+			wantUpdate:          true,
+		},
+		"success with force and image was rolled back": {
+			canary: &updatev1alpha1.CanaryStatus{
+				ImageReference: "/path/to/image:v1.2.3",
+				Phase:          updatev1alpha1.CanaryPhaseFailed,
+			},
+			currentImageVersion: semver.NewFromInt(1, 2, 2, ""),
+			newImageVersion:     semver.NewFromInt(1, 2, 3, ""),
+			force:               true,
+			wantUpdate:          true,
+		},
 		"get error": {
 			currentImageVersion: semver.NewFromInt(1, 2, 2, ""),
 			newImageVersion:     semver.NewFromInt(1, 2, 3, ""),
@@ -145,6 +178,7 @@ func TestUpgradeNodeImage(t *testing.T) {
 				},
 				Status: updatev1alpha1.NodeVersionStatus{
 					Conditions: tc.conditions,
+					Canary:     tc.canary,
 				},
 			}
 			unstrNodeVersion, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&nodeVersion)
@@ -198,6 +232,92 @@ func TestUpgradeNodeImage(t *testing.T) {
 	}
 }
 
+func TestNodeImageUpgradeInProgress(t *testing.T) {
+	nodeWithImage := func(name, image string) corev1.Node {
+		return corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Annotations: map[string]string{"constellation.edgeless.systems/node-image": image},
+			},
+		}
+	}
+
+	testCases := map[string]struct {
+		conditions     []metav1.Condition
+		nodes          []corev1.Node
+		getCRErr       error
+		nodesErr       error
+		wantInProgress bool
+		wantErr        bool
+	}{
+		"all nodes upgraded": {
+			nodes: []corev1.Node{nodeWithImage("node-1", "/path/to/image:v1.2.3"), nodeWithImage("node-2", "/path/to/image:v1.2.3")},
+		},
+		"node with old image": {
+			nodes:          []corev1.Node{nodeWithImage("node-1", "/path/to/image:v1.2.3"), nodeWithImage("node-2", "/path/to/image:v1.2.2")},
+			wantInProgress: true,
+		},
+		"outdated condition": {
+			conditions: []metav1.Condition{{
+				Type:   updatev1alpha1.ConditionOutdated,
+				Status: metav1.ConditionTrue,
+			}},
+			nodes:          []corev1.Node{nodeWithImage("node-1", "/path/to/image:v1.2.3")},
+			wantInProgress: true,
+		},
+		"get nodeversion error": {
+			getCRErr: assert.AnError,
+			wantErr:  true,
+		},
+		"get nodes error": {
+			nodesErr: assert.AnError,
+			wantErr:  true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			nodeVersion := updatev1alpha1.NodeVersion{
+				Spec: updatev1alpha1.NodeVersionSpec{
+					ImageReference: "/path/to/image:v1.2.3",
+					ImageVersion:   "v1.2.3",
+				},
+				Status: updatev1alpha1.NodeVersionStatus{
+					Conditions: tc.conditions,
+				},
+			}
+			unstrNodeVersion, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&nodeVersion)
+			require.NoError(err)
+
+			kubectl := &stubKubectl{
+				unstructuredInterface: &stubUnstructuredClient{
+					object:   &unstructured.Unstructured{Object: unstrNodeVersion},
+					getCRErr: tc.getCRErr,
+				},
+				nodes:    tc.nodes,
+				nodesErr: tc.nodesErr,
+			}
+			cmd := KubeCmd{
+				kubectl:       kubectl,
+				retryInterval: time.Millisecond,
+				maxAttempts:   1,
+				log:           logger.NewTest(t),
+			}
+
+			inProgress, err := cmd.NodeImageUpgradeInProgress(t.Context())
+			if tc.wantErr {
+				assert.Error(err)
+				return
+			}
+			require.NoError(err)
+			assert.Equal(tc.wantInProgress, inProgress)
+		})
+	}
+}
+
 func TestUpgradeKubernetesVersion(t *testing.T) {
 	testCases := map[string]struct {
 		conditions               []metav1.Condition
@@ -360,7 +480,7 @@ func TestIsValidImageUpgrade(t *testing.T) {
 				},
 			}
 
-			err := upgrader.isValidImageUpgrade(nodeVersion, tc.newImageVersion, false)
+			err := upgrader.isValidImageUpgrade(nodeVersion, tc.newImageVersion, "", false)
 
 			if tc.wantErr {
 				assert.Error(err)
@@ -679,6 +799,100 @@ func TestRetryAction(t *testing.T) {
 	}
 }
 
+func TestApplyNodeGroupConfig(t *testing.T) {
+	nodeGroups := nodegroup.Config{
+		"worker_default": {
+			Role:   "Worker",
+			Labels: map[string]string{"example.com/tier": "backend"},
+		},
+	}
+	wantData, err := nodeGroups.Marshal()
+	require.NoError(t, err)
+
+	existingConfigMap := func(data string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      constants.NodeGroupConfigMap,
+				Namespace: constants.ConstellationNamespace,
+			},
+			Data: map[string]string{constants.NodeGroupConfigKey: data},
+		}
+	}
+
+	testCases := map[string]struct {
+		kubectl    *stubKubectl
+		wantCreate bool
+		wantUpdate bool
+		wantErr    bool
+	}{
+		"ConfigMap does not exist yet": {
+			kubectl: &stubKubectl{
+				getCMErr: k8serrors.NewNotFound(schema.GroupResource{}, constants.NodeGroupConfigMap),
+			},
+			wantCreate: true,
+		},
+		"ConfigMap is updated": {
+			kubectl: &stubKubectl{
+				configMaps: map[string]*corev1.ConfigMap{
+					constants.NodeGroupConfigMap: existingConfigMap("{}"),
+				},
+			},
+			wantUpdate: true,
+		},
+		"ConfigMap is up to date": {
+			kubectl: &stubKubectl{
+				configMaps: map[string]*corev1.ConfigMap{
+					constants.NodeGroupConfigMap: existingConfigMap(wantData),
+				},
+			},
+		},
+		"get ConfigMap error": {
+			kubectl: &stubKubectl{
+				getCMErr: assert.AnError,
+			},
+			wantErr: true,
+		},
+		"update ConfigMap error": {
+			kubectl: &stubKubectl{
+				configMaps: map[string]*corev1.ConfigMap{
+					constants.NodeGroupConfigMap: existingConfigMap("{}"),
+				},
+				updateCMErr: assert.AnError,
+			},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			cmd := &KubeCmd{
+				kubectl:       tc.kubectl,
+				log:           logger.NewTest(t),
+				retryInterval: time.Millisecond,
+				maxAttempts:   5,
+			}
+
+			err := cmd.ApplyNodeGroupConfig(t.Context(), nodeGroups)
+			if tc.wantErr {
+				assert.Error(err)
+				return
+			}
+			assert.NoError(err)
+
+			if tc.wantCreate {
+				assert.Equal(wantData, tc.kubectl.configMaps[constants.NodeGroupConfigMap].Data[constants.NodeGroupConfigKey])
+			}
+			if tc.wantUpdate {
+				assert.Equal(wantData, tc.kubectl.updatedConfigMaps[constants.NodeGroupConfigMap].Data[constants.NodeGroupConfigKey])
+			} else {
+				assert.Empty(tc.kubectl.updatedConfigMaps)
+			}
+		})
+	}
+}
+
 func TestExtendClusterConfigCertSANs(t *testing.T) {
 	ctx := t.Context()
 
@@ -767,6 +981,8 @@ type unstructuredInterface interface {
 
 type stubKubectl struct {
 	unstructuredInterface
+	keyRotationKubectl
+	nodeRevocationKubectl
 	configMaps        map[string]*corev1.ConfigMap
 	updatedConfigMaps map[string]*corev1.ConfigMap
 	k8sVersion        string
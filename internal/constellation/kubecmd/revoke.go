@@ -0,0 +1,136 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package kubecmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/edgelesssys/constellation/v2/internal/constants"
+	"github.com/edgelesssys/constellation/v2/internal/denylist"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// controlPlaneRoleLabel is the label of control-plane nodes.
+const controlPlaneRoleLabel = "node-role.kubernetes.io/control-plane"
+
+// ErrLastControlPlane signals that a node can't be revoked because it's the only control-plane node of the cluster.
+var ErrLastControlPlane = errors.New("the node is the only control-plane node of the cluster")
+
+type nodeRevocationKubectl interface {
+	AnnotateNode(ctx context.Context, nodeName, annotationKey, annotationValue string) error
+}
+
+// RevokedNode is the result of revoking a node.
+type RevokedNode struct {
+	// DenyList holds the entries that were added to the deny-list of the JoinService.
+	DenyList denylist.DenyList
+	// Evicted is true if the node was part of the cluster and was marked for removal.
+	Evicted bool
+}
+
+// RevokeNode adds a node and the UUID of its state disk to the deny-list of the JoinService,
+// so the node can't join or rejoin the cluster, and marks the node for removal by the constellation-operator.
+// Additional entries can be passed in extra, e.g. attestation report IDs, or the disk UUIDs of nodes that already left the cluster.
+//
+// Revoking node names and disk UUIDs is best-effort: both are reported by the node itself and aren't attested,
+// so a compromised node that is still running can evade the revocation by presenting other values.
+// Only attestation report IDs, which exist on SEV-SNP, reliably identify a node.
+func (k *KubeCmd) RevokeNode(ctx context.Context, nodeName string, extra denylist.DenyList) (RevokedNode, error) {
+	var nodes []corev1.Node
+	if err := k.retryAction(ctx, func(ctx context.Context) error {
+		var err error
+		nodes, err = k.kubectl.GetNodes(ctx)
+		return err
+	}); err != nil {
+		return RevokedNode{}, fmt.Errorf("getting nodes: %w", err)
+	}
+
+	var node *corev1.Node
+	var controlPlanes int
+	for i := range nodes {
+		if _, ok := nodes[i].Labels[controlPlaneRoleLabel]; ok {
+			controlPlanes++
+		}
+		if nodes[i].Name == nodeName {
+			node = &nodes[i]
+		}
+	}
+
+	entries := extra.Merge(denylist.DenyList{NodeNames: []string{nodeName}})
+	if node != nil {
+		if _, ok := node.Labels[controlPlaneRoleLabel]; ok && controlPlanes < 2 {
+			return RevokedNode{}, ErrLastControlPlane
+		}
+		if diskUUID := node.Annotations[constants.NodeDiskUUIDAnnotationKey]; diskUUID != "" {
+			entries = entries.Merge(denylist.DenyList{DiskUUIDs: []string{diskUUID}})
+		} else {
+			k.log.Debug("Node has no disk UUID annotation, only its name is revoked", "node", nodeName)
+		}
+	}
+
+	if err := k.addToDenyList(ctx, entries); err != nil {
+		return RevokedNode{}, err
+	}
+	if node == nil {
+		return RevokedNode{DenyList: entries}, nil
+	}
+
+	if err := k.retryAction(ctx, func(ctx context.Context) error {
+		return k.kubectl.AnnotateNode(ctx, nodeName, constants.NodeObsoleteAnnotationKey, "true")
+	}); err != nil {
+		return RevokedNode{DenyList: entries}, fmt.Errorf("marking node %s for removal: %w", nodeName, err)
+	}
+	return RevokedNode{DenyList: entries, Evicted: true}, nil
+}
+
+// addToDenyList adds the given entries to the deny-list of the JoinService.
+// The deny-list ConfigMap is created if it doesn't exist yet.
+func (k *KubeCmd) addToDenyList(ctx context.Context, entries denylist.DenyList) error {
+	err := k.retryAction(ctx, func(ctx context.Context) error {
+		cm, err := k.kubectl.GetConfigMap(ctx, constants.ConstellationNamespace, constants.JoinDenyListConfigMap)
+		if k8serrors.IsNotFound(err) {
+			data, err := entries.Marshal()
+			if err != nil {
+				return err
+			}
+			k.log.Debug("ConfigMap does not exist, creating it now", "name", constants.JoinDenyListConfigMap, "namespace", constants.ConstellationNamespace)
+			return k.kubectl.CreateConfigMap(ctx, &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      constants.JoinDenyListConfigMap,
+					Namespace: constants.ConstellationNamespace,
+				},
+				Data: map[string]string{constants.JoinDenyListKey: data},
+			})
+		}
+		if err != nil {
+			return err
+		}
+
+		current, err := denylist.Unmarshal(cm.Data[constants.JoinDenyListKey])
+		if err != nil {
+			return err
+		}
+		data, err := current.Merge(entries).Marshal()
+		if err != nil {
+			return err
+		}
+		if cm.Data == nil {
+			cm.Data = map[string]string{}
+		}
+		cm.Data[constants.JoinDenyListKey] = data
+		_, err = k.kubectl.UpdateConfigMap(ctx, cm)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("updating %s ConfigMap: %w", constants.JoinDenyListConfigMap, err)
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package kubecmd
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/edgelesssys/constellation/v2/internal/constants"
+	"github.com/edgelesssys/constellation/v2/internal/denylist"
+	"github.com/edgelesssys/constellation/v2/internal/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestRevokeNode(t *testing.T) {
+	node := func(name, diskUUID string, controlPlane bool) corev1.Node {
+		n := corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{}, Annotations: map[string]string{}}}
+		if diskUUID != "" {
+			n.Annotations[constants.NodeDiskUUIDAnnotationKey] = diskUUID
+		}
+		if controlPlane {
+			n.Labels[controlPlaneRoleLabel] = ""
+		}
+		return n
+	}
+	denyListCM := func(d denylist.DenyList) *corev1.ConfigMap {
+		data, err := d.Marshal()
+		require.NoError(t, err)
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: constants.JoinDenyListConfigMap, Namespace: constants.ConstellationNamespace},
+			Data:       map[string]string{constants.JoinDenyListKey: data},
+		}
+	}
+
+	testCases := map[string]struct {
+		nodes        []corev1.Node
+		denyList     *corev1.ConfigMap
+		nodeName     string
+		extra        denylist.DenyList
+		annotateErr  error
+		wantDenyList denylist.DenyList
+		wantAdded    denylist.DenyList
+		wantEvicted  bool
+		wantErr      bool
+	}{
+		"worker is revoked": {
+			nodes:        []corev1.Node{node("control-plane-0", "uuid-0", true), node("worker-0", "uuid-1", false)},
+			nodeName:     "worker-0",
+			wantDenyList: denylist.DenyList{DiskUUIDs: []string{"uuid-1"}, NodeNames: []string{"worker-0"}},
+			wantAdded:    denylist.DenyList{DiskUUIDs: []string{"uuid-1"}, NodeNames: []string{"worker-0"}},
+			wantEvicted:  true,
+		},
+		"existing entries are kept": {
+			nodes:        []corev1.Node{node("control-plane-0", "uuid-0", true), node("worker-0", "uuid-1", false)},
+			denyList:     denyListCM(denylist.DenyList{NodeNames: []string{"worker-9"}}),
+			nodeName:     "worker-0",
+			extra:        denylist.DenyList{ReportIDs: []string{"abcdef"}},
+			wantDenyList: denylist.DenyList{DiskUUIDs: []string{"uuid-1"}, NodeNames: []string{"worker-0", "worker-9"}, ReportIDs: []string{"abcdef"}},
+			wantAdded:    denylist.DenyList{DiskUUIDs: []string{"uuid-1"}, NodeNames: []string{"worker-0"}, ReportIDs: []string{"abcdef"}},
+			wantEvicted:  true,
+		},
+		"node already left the cluster": {
+			nodes:        []corev1.Node{node("control-plane-0", "uuid-0", true)},
+			nodeName:     "worker-0",
+			extra:        denylist.DenyList{DiskUUIDs: []string{"uuid-1"}},
+			wantDenyList: denylist.DenyList{DiskUUIDs: []string{"uuid-1"}, NodeNames: []string{"worker-0"}},
+			wantAdded:    denylist.DenyList{DiskUUIDs: []string{"uuid-1"}, NodeNames: []string{"worker-0"}},
+		},
+		"node without disk UUID": {
+			nodes:        []corev1.Node{node("control-plane-0", "uuid-0", true), node("worker-0", "", false)},
+			nodeName:     "worker-0",
+			wantDenyList: denylist.DenyList{NodeNames: []string{"worker-0"}},
+			wantAdded:    denylist.DenyList{NodeNames: []string{"worker-0"}},
+			wantEvicted:  true,
+		},
+		"control-plane node is revoked": {
+			nodes:        []corev1.Node{node("control-plane-0", "uuid-0", true), node("control-plane-1", "uuid-1", true)},
+			nodeName:     "control-plane-1",
+			wantDenyList: denylist.DenyList{DiskUUIDs: []string{"uuid-1"}, NodeNames: []string{"control-plane-1"}},
+			wantAdded:    denylist.DenyList{DiskUUIDs: []string{"uuid-1"}, NodeNames: []string{"control-plane-1"}},
+			wantEvicted:  true,
+		},
+		"last control-plane node": {
+			nodes:    []corev1.Node{node("control-plane-0", "uuid-0", true), node("worker-0", "uuid-1", false)},
+			nodeName: "control-plane-0",
+			wantErr:  true,
+		},
+		"marking node fails": {
+			nodes:        []corev1.Node{node("control-plane-0", "uuid-0", true), node("worker-0", "uuid-1", false)},
+			nodeName:     "worker-0",
+			annotateErr:  errors.New("failed"),
+			wantDenyList: denylist.DenyList{DiskUUIDs: []string{"uuid-1"}, NodeNames: []string{"worker-0"}},
+			wantErr:      true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			kubectl := &stubRevocationKubectl{nodes: tc.nodes, denyList: tc.denyList, annotateErr: tc.annotateErr}
+			cmd := &KubeCmd{kubectl: kubectl, log: logger.NewTest(t), maxAttempts: 1, retryInterval: time.Millisecond}
+
+			revoked, err := cmd.RevokeNode(t.Context(), tc.nodeName, tc.extra)
+			if tc.wantErr {
+				assert.Error(err)
+			} else {
+				require.NoError(err)
+				assert.Equal(tc.wantAdded, revoked.DenyList)
+				assert.Equal(tc.wantEvicted, revoked.Evicted)
+			}
+
+			if tc.wantDenyList.IsEmpty() {
+				assert.Nil(kubectl.denyList)
+			} else {
+				require.NotNil(kubectl.denyList)
+				got, err := denylist.Unmarshal(kubectl.denyList.Data[constants.JoinDenyListKey])
+				require.NoError(err)
+				assert.Equal(tc.wantDenyList, got)
+			}
+			if tc.wantEvicted {
+				assert.Equal(map[string]string{tc.nodeName: "true"}, kubectl.obsolete)
+			} else {
+				assert.Empty(kubectl.obsolete)
+			}
+		})
+	}
+}
+
+type stubRevocationKubectl struct {
+	kubectlInterface
+	nodes       []corev1.Node
+	denyList    *corev1.ConfigMap
+	annotateErr error
+	obsolete    map[string]string
+}
+
+func (s *stubRevocationKubectl) GetNodes(context.Context) ([]corev1.Node, error) {
+	return s.nodes, nil
+}
+
+func (s *stubRevocationKubectl) GetConfigMap(_ context.Context, _, name string) (*corev1.ConfigMap, error) {
+	if s.denyList == nil {
+		return nil, k8serrors.NewNotFound(schema.GroupResource{Resource: "configmaps"}, name)
+	}
+	return s.denyList.DeepCopy(), nil
+}
+
+func (s *stubRevocationKubectl) CreateConfigMap(_ context.Context, cm *corev1.ConfigMap) error {
+	s.denyList = cm
+	return nil
+}
+
+func (s *stubRevocationKubectl) UpdateConfigMap(_ context.Context, cm *corev1.ConfigMap) (*corev1.ConfigMap, error) {
+	s.denyList = cm
+	return cm, nil
+}
+
+func (s *stubRevocationKubectl) AnnotateNode(_ context.Context, nodeName, key, value string) error {
+	if s.annotateErr != nil {
+		return s.annotateErr
+	}
+	if key == constants.NodeObsoleteAnnotationKey {
+		if s.obsolete == nil {
+			s.obsolete = map[string]string{}
+		}
+		s.obsolete[nodeName] = value
+	}
+	return nil
+}
@@ -11,6 +11,8 @@ import (
 
 	updatev1alpha1 "github.com/edgelesssys/constellation/v2/operators/constellation-node-operator/api/v1alpha1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // NodeVersion bundles version information of a Constellation cluster.
@@ -23,14 +25,26 @@ type NodeVersion struct {
 
 // NewNodeVersion returns the target versions for the cluster.
 func NewNodeVersion(nodeVersion updatev1alpha1.NodeVersion) (NodeVersion, error) {
-	if len(nodeVersion.Status.Conditions) != 1 {
-		return NodeVersion{}, fmt.Errorf("expected exactly one condition, got %d", len(nodeVersion.Status.Conditions))
+	conditions := nodeVersion.Status.Conditions
+	outdated := meta.FindStatusCondition(conditions, updatev1alpha1.ConditionOutdated)
+	if outdated == nil {
+		// older node operators set a single condition without type
+		if len(conditions) != 1 {
+			return NodeVersion{}, fmt.Errorf("expected exactly one condition, got %d", len(conditions))
+		}
+		outdated = &conditions[0]
 	}
+	clusterStatus := outdated.Message
+	blocked := meta.FindStatusCondition(conditions, updatev1alpha1.ConditionRolloutBlocked)
+	if outdated.Status == metav1.ConditionTrue && blocked != nil && blocked.Status == metav1.ConditionTrue {
+		clusterStatus = fmt.Sprintf("%s (%s)", clusterStatus, blocked.Message)
+	}
+
 	return NodeVersion{
 		imageVersion:      nodeVersion.Spec.ImageVersion,
 		imageReference:    nodeVersion.Spec.ImageReference,
 		kubernetesVersion: nodeVersion.Spec.KubernetesClusterVersion,
-		clusterStatus:     nodeVersion.Status.Conditions[0].Message,
+		clusterStatus:     clusterStatus,
 	}, nil
 }
 
@@ -0,0 +1,92 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package kubecmd
+
+import (
+	"testing"
+
+	updatev1alpha1 "github.com/edgelesssys/constellation/v2/operators/constellation-node-operator/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNewNodeVersion(t *testing.T) {
+	outdated := metav1.Condition{
+		Type:    updatev1alpha1.ConditionOutdated,
+		Status:  metav1.ConditionTrue,
+		Message: "Some node versions are out of date",
+	}
+	upToDate := metav1.Condition{
+		Type:    updatev1alpha1.ConditionOutdated,
+		Status:  metav1.ConditionFalse,
+		Message: "Node version of every node is up to date",
+	}
+	paused := metav1.Condition{
+		Type:    updatev1alpha1.ConditionRolloutBlocked,
+		Status:  metav1.ConditionTrue,
+		Message: "Node replacements are paused",
+	}
+	allowed := metav1.Condition{
+		Type:    updatev1alpha1.ConditionRolloutBlocked,
+		Status:  metav1.ConditionFalse,
+		Message: "Node replacements may be started",
+	}
+
+	testCases := map[string]struct {
+		conditions        []metav1.Condition
+		wantClusterStatus string
+		wantErr           bool
+	}{
+		"single untyped condition": {
+			conditions:        []metav1.Condition{{Message: "Node version of every node is up to date"}},
+			wantClusterStatus: "Node version of every node is up to date",
+		},
+		"outdated and rollout allowed": {
+			conditions:        []metav1.Condition{outdated, allowed},
+			wantClusterStatus: "Some node versions are out of date",
+		},
+		"outdated and rollout blocked": {
+			conditions:        []metav1.Condition{outdated, paused},
+			wantClusterStatus: "Some node versions are out of date (Node replacements are paused)",
+		},
+		"up to date and rollout blocked": {
+			conditions:        []metav1.Condition{paused, upToDate},
+			wantClusterStatus: "Node version of every node is up to date",
+		},
+		"no conditions": {
+			wantErr: true,
+		},
+		"no outdated condition": {
+			conditions: []metav1.Condition{{Message: "a"}, {Message: "b"}},
+			wantErr:    true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			nodeVersion, err := NewNodeVersion(updatev1alpha1.NodeVersion{
+				Spec: updatev1alpha1.NodeVersionSpec{
+					ImageVersion:             "v1.1.0",
+					ImageReference:           "ref",
+					KubernetesClusterVersion: "v1.2.3",
+				},
+				Status: updatev1alpha1.NodeVersionStatus{Conditions: tc.conditions},
+			})
+			if tc.wantErr {
+				assert.Error(err)
+				return
+			}
+			require.NoError(err)
+			assert.Equal(tc.wantClusterStatus, nodeVersion.ClusterStatus())
+			assert.Equal("v1.1.0", nodeVersion.ImageVersion())
+		})
+	}
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/edgelesssys/constellation/v2/internal/attestation/variant"
 	"github.com/edgelesssys/constellation/v2/internal/config"
 	"github.com/edgelesssys/constellation/v2/internal/file"
+	"github.com/edgelesssys/constellation/v2/internal/nodegroup"
 	"github.com/edgelesssys/constellation/v2/internal/semver"
 	"github.com/edgelesssys/constellation/v2/internal/versions"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
@@ -52,6 +53,24 @@ func (a *Applier) ApplyJoinConfig(ctx context.Context, newAttestConfig config.At
 	return a.kubecmdClient.ApplyJoinConfig(ctx, newAttestConfig, measurementSalt)
 }
 
+// ApplyNodeGroupConfig creates or updates the ConfigMap holding the Kubernetes node settings of the cluster's node groups.
+func (a *Applier) ApplyNodeGroupConfig(ctx context.Context, nodeGroups nodegroup.Config) error {
+	if a.kubecmdClient == nil {
+		return errKubecmdNotInitialised
+	}
+
+	return a.kubecmdClient.ApplyNodeGroupConfig(ctx, nodeGroups)
+}
+
+// NodeImageUpgradeInProgress returns true if not all nodes of the cluster run the cluster's target image yet.
+func (a *Applier) NodeImageUpgradeInProgress(ctx context.Context) (bool, error) {
+	if a.kubecmdClient == nil {
+		return false, errKubecmdNotInitialised
+	}
+
+	return a.kubecmdClient.NodeImageUpgradeInProgress(ctx)
+}
+
 // UpgradeNodeImage upgrades the node image of the cluster to the given version.
 func (a *Applier) UpgradeNodeImage(ctx context.Context, imageVersion semver.Semver, imageReference string, force bool) error {
 	if a.kubecmdClient == nil {
@@ -94,6 +113,8 @@ type kubecmdClient interface {
 	ExtendClusterConfigCertSANs(ctx context.Context, alternativeNames []string) error
 	GetClusterAttestationConfig(ctx context.Context, variant variant.Variant) (config.AttestationCfg, error)
 	ApplyJoinConfig(ctx context.Context, newAttestConfig config.AttestationCfg, measurementSalt []byte) error
+	ApplyNodeGroupConfig(ctx context.Context, nodeGroups nodegroup.Config) error
+	NodeImageUpgradeInProgress(ctx context.Context) (bool, error)
 	BackupCRs(ctx context.Context, fileHandler file.Handler, crds []apiextensionsv1.CustomResourceDefinition, upgradeDir string) error
 	BackupCRDs(ctx context.Context, fileHandler file.Handler, upgradeDir string) ([]apiextensionsv1.CustomResourceDefinition, error)
 }
@@ -38,6 +38,9 @@ const (
 	DEKPrefix = "key-"
 	// MeasurementSecretKeyID is name used for the measurementSecret DEK.
 	MeasurementSecretKeyID = "measurementSecret"
+	// WorkloadKeyIDPrefix is the prefix of the IDs of keys derived for workloads.
+	// The prefix is followed by the namespace of the workload and the name of the key.
+	WorkloadKeyIDPrefix = "workload/"
 )
 
 // DeriveKey derives a key from a secret.
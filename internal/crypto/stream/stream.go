@@ -0,0 +1,305 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+/*
+Package stream implements segmented authenticated encryption of data streams
+using the STREAM construction (https://eprint.iacr.org/2015/189.pdf).
+
+The plaintext is split into segments of [SegmentSize] bytes, the final segment may be shorter.
+A plaintext whose size is a multiple of [SegmentSize] ends with a full final segment,
+an empty plaintext consists of a single empty segment.
+Each segment is sealed individually with an AEAD that uses 12 byte nonces and [TagSize] byte tags, e.g. AES-GCM.
+The nonce of a segment consists of a random per-stream prefix of [NoncePrefixSize] bytes,
+the big endian segment index, and a flag marking the final segment.
+This prevents reordering, dropping, and truncation of segments.
+
+Storing the nonce prefix and choosing the additional data authenticated with every segment
+is up to the user of this package.
+*/
+package stream
+
+import (
+	"bufio"
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+const (
+	// SegmentSize is the size of a plaintext segment.
+	SegmentSize = 64 * 1024
+	// NoncePrefixSize is the size of the random nonce prefix of a stream.
+	NoncePrefixSize = 7
+	// TagSize is the size of the authentication tag appended to every segment.
+	TagSize = 16
+	// CiphertextSegmentSize is the size of a full ciphertext segment.
+	CiphertextSegmentSize = SegmentSize + TagSize
+)
+
+// NewEncryptingReader returns a reader that yields the ciphertext of the plaintext read from src.
+func NewEncryptingReader(src io.Reader, aead cipher.AEAD, noncePrefix, aad []byte) io.Reader {
+	return &encryptingReader{
+		src:         bufio.NewReaderSize(src, SegmentSize),
+		aead:        aead,
+		noncePrefix: noncePrefix,
+		aad:         aad,
+		buf:         make([]byte, SegmentSize),
+		sealed:      make([]byte, 0, CiphertextSegmentSize),
+	}
+}
+
+// NewDecryptingReader returns a reader that yields the plaintext of the ciphertext read from src.
+// The end of the stream is detected by the final segment, so src has to end with it.
+// Segments are only released after they were authenticated.
+func NewDecryptingReader(src io.Reader, aead cipher.AEAD, noncePrefix, aad []byte) io.Reader {
+	return &decryptingReader{
+		src:         bufio.NewReaderSize(src, CiphertextSegmentSize),
+		aead:        aead,
+		noncePrefix: noncePrefix,
+		aad:         aad,
+		buf:         make([]byte, CiphertextSegmentSize),
+	}
+}
+
+// NewRangeReader returns a reader that yields the plaintext bytes [start, end] of a stream with the given plaintext size.
+// src has to start at the segment holding start, as returned by [CiphertextRange].
+// Segments are only released after they were authenticated.
+func NewRangeReader(src io.Reader, aead cipher.AEAD, noncePrefix, aad []byte, start, end, plaintextSize int64) io.Reader {
+	return &rangeReader{
+		src:           src,
+		aead:          aead,
+		noncePrefix:   noncePrefix,
+		aad:           aad,
+		segment:       start / SegmentSize,
+		lastSegment:   max(end, 0) / SegmentSize,
+		finalSegment:  finalSegment(plaintextSize),
+		plaintextSize: plaintextSize,
+		skip:          start % SegmentSize,
+		remaining:     end - start + 1,
+		buf:           make([]byte, CiphertextSegmentSize),
+	}
+}
+
+// SegmentNonce returns the nonce of a segment: nonce prefix || segment index || final flag.
+func SegmentNonce(noncePrefix []byte, segment int64, final bool) []byte {
+	nonce := make([]byte, NoncePrefixSize+5)
+	copy(nonce, noncePrefix)
+	binary.BigEndian.PutUint32(nonce[NoncePrefixSize:], uint32(segment))
+	if final {
+		nonce[NoncePrefixSize+4] = 1
+	}
+	return nonce
+}
+
+// CiphertextSize returns the size of the ciphertext of a plaintext with the given size.
+func CiphertextSize(plaintextSize int64) int64 {
+	return plaintextSize + (finalSegment(plaintextSize)+1)*TagSize
+}
+
+// PlaintextSize returns the size of the plaintext of a ciphertext with the given size.
+func PlaintextSize(ciphertextSize int64) (int64, error) {
+	segments := ciphertextSize / CiphertextSegmentSize
+	rest := ciphertextSize % CiphertextSegmentSize
+	if rest == 0 && segments > 0 {
+		return segments * SegmentSize, nil
+	}
+	if rest < TagSize {
+		return 0, fmt.Errorf("invalid ciphertext size %d", ciphertextSize)
+	}
+	return segments*SegmentSize + rest - TagSize, nil
+}
+
+// CiphertextRange returns the ciphertext byte range [ctStart, ctEnd] holding all segments
+// that cover the plaintext bytes [start, end] of a stream with the given plaintext size.
+func CiphertextRange(start, end, plaintextSize int64) (ctStart, ctEnd int64) {
+	ctStart = start / SegmentSize * CiphertextSegmentSize
+	ctEnd = min((max(end, 0)/SegmentSize+1)*CiphertextSegmentSize, CiphertextSize(plaintextSize)) - 1
+	return ctStart, ctEnd
+}
+
+// encryptingReader encrypts a plaintext stream in segments.
+type encryptingReader struct {
+	src         *bufio.Reader
+	aead        cipher.AEAD
+	noncePrefix []byte
+	aad         []byte
+	buf         []byte
+	sealed      []byte
+	out         []byte
+	segment     int64
+	done        bool
+}
+
+// Read implements io.Reader.
+func (r *encryptingReader) Read(p []byte) (int, error) {
+	for len(r.out) == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+		if err := r.sealSegment(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, r.out)
+	r.out = r.out[n:]
+	return n, nil
+}
+
+// sealSegment reads and encrypts the next plaintext segment.
+func (r *encryptingReader) sealSegment() error {
+	if r.segment > math.MaxUint32 {
+		return errors.New("stream exceeds the maximum number of segments")
+	}
+	n, err := io.ReadFull(r.src, r.buf)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return err
+	}
+	final := n < SegmentSize
+	if !final {
+		// A full segment is only the final one if no data follows.
+		if _, err := r.src.Peek(1); errors.Is(err, io.EOF) {
+			final = true
+		} else if err != nil {
+			return err
+		}
+	}
+
+	r.out = r.aead.Seal(r.sealed[:0], SegmentNonce(r.noncePrefix, r.segment, final), r.buf[:n], r.aad)
+	r.segment++
+	r.done = final
+	return nil
+}
+
+// decryptingReader decrypts a ciphertext stream of unknown size.
+type decryptingReader struct {
+	src         *bufio.Reader
+	aead        cipher.AEAD
+	noncePrefix []byte
+	aad         []byte
+	buf         []byte
+	out         []byte
+	segment     int64
+	done        bool
+}
+
+// Read implements io.Reader.
+func (r *decryptingReader) Read(p []byte) (int, error) {
+	for len(r.out) == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+		if err := r.openSegment(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, r.out)
+	r.out = r.out[n:]
+	return n, nil
+}
+
+// openSegment reads and decrypts the next ciphertext segment.
+// A segment is the final one if no data follows it.
+func (r *decryptingReader) openSegment() error {
+	if r.segment > math.MaxUint32 {
+		return errors.New("stream exceeds the maximum number of segments")
+	}
+	n, err := io.ReadFull(r.src, r.buf)
+	if errors.Is(err, io.EOF) {
+		// Every stream ends with a final segment, so the stream was truncated.
+		err = io.ErrUnexpectedEOF
+	}
+	final := errors.Is(err, io.ErrUnexpectedEOF) && n > 0
+	if err != nil && !final {
+		return fmt.Errorf("reading segment %d: %w", r.segment, err)
+	}
+	if !final {
+		if _, err := r.src.Peek(1); errors.Is(err, io.EOF) {
+			final = true
+		} else if err != nil {
+			return fmt.Errorf("reading segment %d: %w", r.segment+1, err)
+		}
+	}
+
+	plaintext, err := r.aead.Open(r.buf[:0], SegmentNonce(r.noncePrefix, r.segment, final), r.buf[:n], r.aad)
+	if err != nil {
+		return fmt.Errorf("decrypting segment %d: %w", r.segment, err)
+	}
+	r.out = plaintext
+	r.segment++
+	r.done = final
+	return nil
+}
+
+// rangeReader decrypts a range of segments of a ciphertext stream of known size.
+type rangeReader struct {
+	src           io.Reader
+	aead          cipher.AEAD
+	noncePrefix   []byte
+	aad           []byte
+	segment       int64
+	lastSegment   int64
+	finalSegment  int64
+	plaintextSize int64
+	skip          int64
+	remaining     int64
+	buf           []byte
+	out           []byte
+}
+
+// Read implements io.Reader.
+func (r *rangeReader) Read(p []byte) (int, error) {
+	for len(r.out) == 0 {
+		if r.segment > r.lastSegment {
+			return 0, io.EOF
+		}
+		if err := r.openSegment(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, r.out)
+	r.out = r.out[n:]
+	return n, nil
+}
+
+// openSegment reads and decrypts the next ciphertext segment.
+func (r *rangeReader) openSegment() error {
+	size := int64(CiphertextSegmentSize)
+	if r.segment == r.finalSegment {
+		size = r.plaintextSize - r.segment*SegmentSize + TagSize
+	}
+	if _, err := io.ReadFull(r.src, r.buf[:size]); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return fmt.Errorf("reading segment %d: %w", r.segment, err)
+	}
+
+	plaintext, err := r.aead.Open(r.buf[:0], SegmentNonce(r.noncePrefix, r.segment, r.segment == r.finalSegment), r.buf[:size], r.aad)
+	if err != nil {
+		return fmt.Errorf("decrypting segment %d: %w", r.segment, err)
+	}
+	plaintext = plaintext[r.skip:]
+	r.skip = 0
+	if int64(len(plaintext)) > r.remaining {
+		plaintext = plaintext[:r.remaining]
+	}
+	r.remaining -= int64(len(plaintext))
+	r.out = plaintext
+	r.segment++
+	return nil
+}
+
+// finalSegment returns the index of the final segment of a plaintext with the given size.
+// Empty plaintexts consist of a single empty segment.
+func finalSegment(plaintextSize int64) int64 {
+	if plaintextSize == 0 {
+		return 0
+	}
+	return (plaintextSize - 1) / SegmentSize
+}
@@ -0,0 +1,204 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package stream
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"io"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	sizes := map[string]int{
+		"empty":                 0,
+		"single byte":           1,
+		"less than one segment": SegmentSize - 1,
+		"one segment":           SegmentSize,
+		"one segment and a bit": SegmentSize + 1,
+		"multiple segments":     3*SegmentSize + 1234,
+	}
+
+	for name, size := range sizes {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			aead := newTestAEAD(t)
+			noncePrefix := make([]byte, NoncePrefixSize)
+			_, err := rand.Read(noncePrefix)
+			require.NoError(err)
+			plaintext := make([]byte, size)
+			_, err = rand.Read(plaintext)
+			require.NoError(err)
+
+			// OneByteReader makes sure segments are assembled from short reads.
+			ciphertext, err := io.ReadAll(NewEncryptingReader(iotest.OneByteReader(bytes.NewReader(plaintext)), aead, noncePrefix, []byte("aad")))
+			require.NoError(err)
+			assert.Len(ciphertext, int(CiphertextSize(int64(size))))
+
+			decrypted, err := io.ReadAll(NewDecryptingReader(iotest.HalfReader(bytes.NewReader(ciphertext)), aead, noncePrefix, []byte("aad")))
+			require.NoError(err)
+			assert.Equal(plaintext, decrypted)
+
+			decrypted, err = io.ReadAll(NewRangeReader(bytes.NewReader(ciphertext), aead, noncePrefix, []byte("aad"), 0, int64(size)-1, int64(size)))
+			require.NoError(err)
+			assert.Equal(plaintext, decrypted)
+		})
+	}
+}
+
+func TestRangeReader(t *testing.T) {
+	const size = 3*SegmentSize + 100
+	aead := newTestAEAD(t)
+	noncePrefix := make([]byte, NoncePrefixSize)
+	plaintext := make([]byte, size)
+	_, err := rand.Read(plaintext)
+	require.NoError(t, err)
+	ciphertext, err := io.ReadAll(NewEncryptingReader(bytes.NewReader(plaintext), aead, noncePrefix, nil))
+	require.NoError(t, err)
+
+	testCases := map[string]struct {
+		start, end int64
+	}{
+		"single byte":          {start: 10, end: 10},
+		"segment boundary":     {start: SegmentSize - 1, end: SegmentSize},
+		"full second segment":  {start: SegmentSize, end: 2*SegmentSize - 1},
+		"spanning segments":    {start: 100, end: 2*SegmentSize + 5},
+		"final segment only":   {start: 3 * SegmentSize, end: size - 1},
+		"middle to final byte": {start: SegmentSize + 7, end: size - 1},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			require := require.New(t)
+
+			ctStart, ctEnd := CiphertextRange(tc.start, tc.end, size)
+			decrypted, err := io.ReadAll(NewRangeReader(bytes.NewReader(ciphertext[ctStart:ctEnd+1]), aead, noncePrefix, nil, tc.start, tc.end, size))
+			require.NoError(err)
+			assert.Equal(t, plaintext[tc.start:tc.end+1], decrypted)
+		})
+	}
+}
+
+func TestDecryptingReaderTampering(t *testing.T) {
+	aead := newTestAEAD(t)
+	noncePrefix := make([]byte, NoncePrefixSize)
+	ciphertext, err := io.ReadAll(NewEncryptingReader(bytes.NewReader(make([]byte, 2*SegmentSize+10)), aead, noncePrefix, []byte("aad")))
+	require.NoError(t, err)
+
+	testCases := map[string]struct {
+		ciphertext  []byte
+		noncePrefix []byte
+		aad         []byte
+	}{
+		"modified segment": {
+			ciphertext: func() []byte {
+				c := bytes.Clone(ciphertext)
+				c[CiphertextSegmentSize+1] ^= 1
+				return c
+			}(),
+		},
+		"wrong nonce prefix": {
+			ciphertext:  ciphertext,
+			noncePrefix: bytes.Repeat([]byte{1}, NoncePrefixSize),
+		},
+		"wrong additional data": {
+			ciphertext: ciphertext,
+			aad:        []byte("other"),
+		},
+		"final segment dropped": {
+			ciphertext: ciphertext[:2*CiphertextSegmentSize],
+		},
+		"final segment truncated": {
+			ciphertext: ciphertext[:len(ciphertext)-1],
+		},
+		"swapped segments": {
+			ciphertext: func() []byte {
+				c := bytes.Clone(ciphertext)
+				copy(c, ciphertext[CiphertextSegmentSize:2*CiphertextSegmentSize])
+				copy(c[CiphertextSegmentSize:], ciphertext[:CiphertextSegmentSize])
+				return c
+			}(),
+		},
+		"data appended": {
+			ciphertext: append(bytes.Clone(ciphertext), 0),
+		},
+		"empty": {
+			ciphertext: nil,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			prefix := noncePrefix
+			if tc.noncePrefix != nil {
+				prefix = tc.noncePrefix
+			}
+			aad := []byte("aad")
+			if tc.aad != nil {
+				aad = tc.aad
+			}
+			_, err := io.ReadAll(NewDecryptingReader(bytes.NewReader(tc.ciphertext), aead, prefix, aad))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestEncryptingReaderReadError(t *testing.T) {
+	someErr := errors.New("failed")
+	encrypter := NewEncryptingReader(io.MultiReader(bytes.NewReader(make([]byte, SegmentSize+1)), iotest.ErrReader(someErr)), newTestAEAD(t), make([]byte, NoncePrefixSize), nil)
+	_, err := io.ReadAll(encrypter)
+	assert.ErrorIs(t, err, someErr)
+}
+
+func TestPlaintextSize(t *testing.T) {
+	testCases := map[string]struct {
+		ciphertextSize int64
+		want           int64
+		wantErr        bool
+	}{
+		"empty plaintext":      {ciphertextSize: TagSize, want: 0},
+		"single full segment":  {ciphertextSize: CiphertextSegmentSize, want: SegmentSize},
+		"partial segment":      {ciphertextSize: CiphertextSegmentSize + TagSize + 5, want: SegmentSize + 5},
+		"no ciphertext":        {ciphertextSize: 0, wantErr: true},
+		"shorter than tag":     {ciphertextSize: TagSize - 1, wantErr: true},
+		"truncated second tag": {ciphertextSize: CiphertextSegmentSize + 3, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			got, err := PlaintextSize(tc.ciphertextSize)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+			assert.Equal(t, tc.ciphertextSize, CiphertextSize(got))
+		})
+	}
+}
+
+func newTestAEAD(t *testing.T) cipher.AEAD {
+	t.Helper()
+	key := make([]byte, 32)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+	block, err := aes.NewCipher(key)
+	require.NoError(t, err)
+	aead, err := cipher.NewGCM(block)
+	require.NoError(t, err)
+	return aead
+}
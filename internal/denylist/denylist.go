@@ -0,0 +1,110 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+/*
+Package denylist defines the deny-list of the JoinService.
+
+The JoinService doesn't issue join or rejoin tickets to nodes on the deny-list.
+The deny-list is stored as JSON in the join-deny-list ConfigMap and is updated by `constellation node revoke`.
+
+Only attestation report IDs are attested. Disk UUIDs and node names are reported by the nodes themselves,
+so matching them is best-effort: a compromised node can evade it by presenting other values.
+*/
+package denylist
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// DenyList lists nodes that must not receive join or rejoin tickets.
+type DenyList struct {
+	// DiskUUIDs are the UUIDs of state disks that must not receive a key.
+	// They are reported by the node and aren't attested.
+	DiskUUIDs []string `json:"diskUUIDs,omitempty"`
+	// NodeNames are the names of nodes that must not join the cluster.
+	// They are reported by the node and aren't attested.
+	NodeNames []string `json:"nodeNames,omitempty"`
+	// ReportIDs are the hex encoded IDs of SEV-SNP attestation reports, which identify a confidential VM for its lifetime.
+	// They are part of the attested report, so they can't be forged by the node.
+	ReportIDs []string `json:"reportIDs,omitempty"`
+}
+
+// Unmarshal parses a deny-list from its JSON representation.
+// An empty string results in an empty deny-list.
+func Unmarshal(data string) (DenyList, error) {
+	var d DenyList
+	if strings.TrimSpace(data) == "" {
+		return d, nil
+	}
+	if err := json.Unmarshal([]byte(data), &d); err != nil {
+		return DenyList{}, fmt.Errorf("unmarshaling deny-list: %w", err)
+	}
+	return d, nil
+}
+
+// Marshal returns the JSON representation of the deny-list.
+func (d DenyList) Marshal() (string, error) {
+	data, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("marshaling deny-list: %w", err)
+	}
+	return string(data), nil
+}
+
+// Merge returns a deny-list containing the entries of both deny-lists.
+// Entries are deduplicated and sorted.
+func (d DenyList) Merge(other DenyList) DenyList {
+	return DenyList{
+		DiskUUIDs: merge(d.DiskUUIDs, other.DiskUUIDs),
+		NodeNames: merge(d.NodeNames, other.NodeNames),
+		ReportIDs: merge(d.ReportIDs, other.ReportIDs),
+	}
+}
+
+// Denies checks if a node with the given disk UUID, node name and attestation report ID is on the deny-list.
+// Empty values are ignored. The returned reason describes the matching entry.
+func (d DenyList) Denies(diskUUID, nodeName, reportID string) (reason string, denied bool) {
+	switch {
+	case contains(d.DiskUUIDs, diskUUID):
+		return fmt.Sprintf("disk UUID %q is revoked", diskUUID), true
+	case contains(d.NodeNames, nodeName):
+		return fmt.Sprintf("node %q is revoked", nodeName), true
+	case contains(d.ReportIDs, reportID):
+		return fmt.Sprintf("attestation report ID %q is revoked", reportID), true
+	}
+	return "", false
+}
+
+// IsEmpty returns true if the deny-list has no entries.
+func (d DenyList) IsEmpty() bool {
+	return len(d.DiskUUIDs) == 0 && len(d.NodeNames) == 0 && len(d.ReportIDs) == 0
+}
+
+// contains checks if list contains value, ignoring case.
+// Disk UUIDs and report IDs are hex encoded, and node names are case-insensitive DNS names.
+func contains(list []string, value string) bool {
+	if value == "" {
+		return false
+	}
+	return slices.ContainsFunc(list, func(entry string) bool {
+		return strings.EqualFold(entry, value)
+	})
+}
+
+func merge(a, b []string) []string {
+	var merged []string
+	for _, entry := range slices.Concat(a, b) {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry != "" {
+			merged = append(merged, entry)
+		}
+	}
+	slices.Sort(merged)
+	return slices.Compact(merged)
+}
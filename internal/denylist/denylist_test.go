@@ -0,0 +1,89 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package denylist
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDenies(t *testing.T) {
+	d := DenyList{
+		DiskUUIDs: []string{"8a0d4d4e-8c3b-4b5a-9c1e-0d2f4b6a8c0e"},
+		NodeNames: []string{"worker-1"},
+		ReportIDs: []string{"abcdef"},
+	}
+
+	testCases := map[string]struct {
+		diskUUID   string
+		nodeName   string
+		reportID   string
+		wantDenied bool
+	}{
+		"allowed": {
+			diskUUID: "00000000-0000-0000-0000-000000000000",
+			nodeName: "worker-2",
+			reportID: "012345",
+		},
+		"no values": {},
+		"disk UUID": {
+			diskUUID:   "8A0D4D4E-8C3B-4B5A-9C1E-0D2F4B6A8C0E",
+			nodeName:   "worker-2",
+			wantDenied: true,
+		},
+		"node name": {
+			nodeName:   "worker-1",
+			wantDenied: true,
+		},
+		"report ID": {
+			reportID:   "ABCDEF",
+			wantDenied: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			reason, denied := d.Denies(tc.diskUUID, tc.nodeName, tc.reportID)
+			assert.Equal(t, tc.wantDenied, denied)
+			assert.Equal(t, tc.wantDenied, reason != "")
+		})
+	}
+}
+
+func TestMerge(t *testing.T) {
+	d := DenyList{NodeNames: []string{"worker-2", "worker-1"}}
+	merged := d.Merge(DenyList{
+		DiskUUIDs: []string{"UUID", ""},
+		NodeNames: []string{"Worker-1", "worker-3"},
+	})
+	assert.Equal(t, DenyList{
+		DiskUUIDs: []string{"uuid"},
+		NodeNames: []string{"worker-1", "worker-2", "worker-3"},
+	}, merged)
+	assert.False(t, merged.IsEmpty())
+	assert.True(t, DenyList{}.IsEmpty())
+}
+
+func TestMarshalUnmarshal(t *testing.T) {
+	require := require.New(t)
+
+	d := DenyList{DiskUUIDs: []string{"uuid"}, ReportIDs: []string{"abcdef"}}
+	data, err := d.Marshal()
+	require.NoError(err)
+	got, err := Unmarshal(data)
+	require.NoError(err)
+	assert.Equal(t, d, got)
+
+	got, err = Unmarshal("")
+	require.NoError(err)
+	assert.True(t, got.IsEmpty())
+
+	_, err = Unmarshal("{")
+	assert.Error(t, err)
+}
@@ -0,0 +1,251 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+/*
+Package etcdbackup provides the building blocks shared by etcd snapshot backups and restores.
+
+Snapshots are encrypted with AES-256-GCM using a key derived by the keyservice for the
+workload key name [KeyName]. Since snapshots can be as large as the etcd quota, they are
+encrypted in segments using the stream package, so that neither encryption nor decryption
+has to hold the whole snapshot in memory. An encrypted snapshot has the following layout:
+
+	magic | nonce prefix | segment 0 | ... | segment n
+
+The magic header identifies the format and is authenticated as additional data of every segment.
+*/
+package etcdbackup
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/edgelesssys/constellation/v2/internal/crypto"
+	"github.com/edgelesssys/constellation/v2/internal/crypto/stream"
+	"github.com/edgelesssys/constellation/v2/internal/etcdbackup/storage/awss3"
+	"github.com/edgelesssys/constellation/v2/internal/etcdbackup/storage/azureblob"
+	"github.com/edgelesssys/constellation/v2/internal/etcdbackup/storage/gcs"
+	"github.com/edgelesssys/constellation/v2/internal/etcdbackup/storage/localfs"
+	"github.com/edgelesssys/constellation/v2/internal/kms/uri"
+	"github.com/spf13/afero"
+)
+
+const (
+	// KeyName is the name of the workload key used to encrypt etcd snapshots.
+	// The key is derived for the kube-system namespace.
+	KeyName = "etcd-backup"
+	// KeyNamespace is the namespace the snapshot encryption key is derived for.
+	KeyNamespace = "kube-system"
+	// KeyLength is the length of the snapshot encryption key in bytes.
+	KeyLength = 32
+
+	snapshotNamePrefix = "etcd-snapshot-"
+	snapshotNameSuffix = ".db.enc"
+	snapshotTimeFormat = "20060102T150405Z"
+)
+
+// magic identifies encrypted etcd snapshots.
+var magic = []byte("CSTETCD1")
+
+// ErrInvalidSnapshot is returned when data is not an encrypted etcd snapshot.
+var ErrInvalidSnapshot = errors.New("invalid encrypted etcd snapshot")
+
+// Storage stores encrypted etcd snapshots.
+type Storage interface {
+	// Put saves a snapshot by name, reading it from data until EOF.
+	Put(ctx context.Context, name string, data io.Reader) error
+	// Get returns a reader for a snapshot by name.
+	// The snapshot is streamed from the storage, the caller has to close the reader.
+	Get(ctx context.Context, name string) (io.ReadCloser, error)
+	// List returns the names of all snapshots with the given prefix.
+	List(ctx context.Context, prefix string) ([]string, error)
+	// Delete removes a snapshot by name.
+	Delete(ctx context.Context, name string) error
+}
+
+// NewStorage creates a snapshot storage from the given storage URI.
+// Supported are the AWS S3, Azure Blob Storage and Google Cloud Storage URIs of the KMS,
+// as well as "storage://local?path=<dir>" for a directory on the local filesystem.
+func NewStorage(ctx context.Context, storageURI string) (Storage, error) {
+	u, err := url.Parse(storageURI)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "storage" {
+		return nil, fmt.Errorf("invalid storage URI: invalid scheme: %s", u.Scheme)
+	}
+
+	switch u.Host {
+	case "aws":
+		cfg, err := uri.DecodeAWSS3ConfigFromURI(storageURI)
+		if err != nil {
+			return nil, err
+		}
+		return awss3.New(ctx, cfg)
+
+	case "azure":
+		cfg, err := uri.DecodeAzureBlobConfigFromURI(storageURI)
+		if err != nil {
+			return nil, err
+		}
+		return azureblob.New(cfg)
+
+	case "gcp":
+		cfg, err := uri.DecodeGoogleCloudStorageConfigFromURI(storageURI)
+		if err != nil {
+			return nil, err
+		}
+		return gcs.New(cfg), nil
+
+	case "local":
+		dir := u.Query().Get("path")
+		if dir == "" {
+			return nil, errors.New("missing query parameter \"path\"")
+		}
+		return localfs.New(afero.NewOsFs(), dir), nil
+
+	default:
+		return nil, fmt.Errorf("unknown storage type: %s", u.Host)
+	}
+}
+
+// DeriveKey derives the snapshot encryption key from the master secret of the cluster.
+// The key is equal to the workload key the keyservice derives for [KeyName] in [KeyNamespace].
+func DeriveKey(masterSecret uri.MasterSecret) ([]byte, error) {
+	keyID := crypto.DEKPrefix + crypto.WorkloadKeyIDPrefix + KeyNamespace + "/" + KeyName
+	return crypto.DeriveKey(masterSecret.Key, masterSecret.Salt, []byte(keyID), KeyLength)
+}
+
+// NewEncrypter returns a reader that reads the snapshot and returns it encrypted with the given key.
+func NewEncrypter(key []byte, snapshot io.Reader) (io.Reader, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	header := make([]byte, len(magic)+stream.NoncePrefixSize)
+	copy(header, magic)
+	if _, err := rand.Read(header[len(magic):]); err != nil {
+		return nil, fmt.Errorf("generating nonce: %w", err)
+	}
+	return io.MultiReader(bytes.NewReader(header), stream.NewEncryptingReader(snapshot, aead, header[len(magic):], magic)), nil
+}
+
+// NewDecrypter returns a reader that reads the encrypted snapshot and returns it decrypted with the given key.
+// Reading fails if the snapshot was modified or truncated.
+func NewDecrypter(key []byte, encrypted io.Reader) (io.Reader, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	header := make([]byte, len(magic)+stream.NoncePrefixSize)
+	if _, err := io.ReadFull(encrypted, header); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, ErrInvalidSnapshot
+		}
+		return nil, fmt.Errorf("reading snapshot header: %w", err)
+	}
+	if !bytes.Equal(header[:len(magic)], magic) {
+		return nil, ErrInvalidSnapshot
+	}
+	return stream.NewDecryptingReader(encrypted, aead, header[len(magic):], magic), nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != KeyLength {
+		return nil, fmt.Errorf("invalid key length %d, expected %d", len(key), KeyLength)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// SnapshotName returns the name of a snapshot taken at the given time.
+// The prefix is used as a directory, so that multiple clusters can share a bucket.
+func SnapshotName(prefix string, t time.Time) string {
+	return path.Join(prefix, snapshotNamePrefix+t.UTC().Format(snapshotTimeFormat)+snapshotNameSuffix)
+}
+
+// SnapshotTime returns the time a snapshot was taken, parsed from its name.
+// It returns false if the name was not created by [SnapshotName].
+func SnapshotTime(name string) (time.Time, bool) {
+	base := path.Base(name)
+	if !strings.HasPrefix(base, snapshotNamePrefix) || !strings.HasSuffix(base, snapshotNameSuffix) {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(snapshotTimeFormat, strings.TrimSuffix(strings.TrimPrefix(base, snapshotNamePrefix), snapshotNameSuffix))
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// ListSnapshots returns the names of all snapshots in the storage with the given prefix, oldest first.
+// Objects that are not snapshots, or are stored below a nested prefix, are ignored.
+func ListSnapshots(ctx context.Context, store Storage, prefix string) ([]string, error) {
+	listPrefix := prefix
+	if listPrefix != "" && !strings.HasSuffix(listPrefix, "/") {
+		listPrefix += "/"
+	}
+	names, err := store.List(ctx, listPrefix)
+	if err != nil {
+		return nil, err
+	}
+	var snapshots []string
+	for _, name := range names {
+		if _, ok := SnapshotTime(name); ok && path.Dir(name) == path.Clean(prefix) {
+			snapshots = append(snapshots, name)
+		}
+	}
+	sortSnapshots(snapshots)
+	return snapshots, nil
+}
+
+// ExpiredSnapshots returns the snapshots that exceed the retention limits.
+// At most maxCount snapshots are kept, and snapshots older than maxAge are expired.
+// A limit of zero disables it. The most recent snapshot is never expired.
+func ExpiredSnapshots(names []string, now time.Time, maxCount int, maxAge time.Duration) []string {
+	snapshots := make([]string, 0, len(names))
+	for _, name := range names {
+		if _, ok := SnapshotTime(name); ok {
+			snapshots = append(snapshots, name)
+		}
+	}
+	sortSnapshots(snapshots)
+
+	var expired []string
+	for i, name := range snapshots {
+		newer := len(snapshots) - 1 - i
+		if newer == 0 {
+			break
+		}
+		taken, _ := SnapshotTime(name)
+		if (maxCount > 0 && newer >= maxCount) || (maxAge > 0 && now.Sub(taken) > maxAge) {
+			expired = append(expired, name)
+		}
+	}
+	return expired
+}
+
+// sortSnapshots sorts snapshot names by the time they were taken, oldest first.
+func sortSnapshots(names []string) {
+	sort.SliceStable(names, func(i, j int) bool {
+		ti, _ := SnapshotTime(names[i])
+		tj, _ := SnapshotTime(names[j])
+		return ti.Before(tj)
+	})
+}
@@ -0,0 +1,363 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package etcdbackup
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/edgelesssys/constellation/v2/internal/crypto"
+	"github.com/edgelesssys/constellation/v2/internal/crypto/stream"
+	"github.com/edgelesssys/constellation/v2/internal/etcdbackup/storage/localfs"
+	"github.com/edgelesssys/constellation/v2/internal/kms/kms/cluster"
+	"github.com/edgelesssys/constellation/v2/internal/kms/uri"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeriveKey(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	masterSecret := uri.MasterSecret{
+		Key:  bytes.Repeat([]byte{0x1}, 32),
+		Salt: bytes.Repeat([]byte{0x2}, 32),
+	}
+
+	key, err := DeriveKey(masterSecret)
+	require.NoError(err)
+	assert.Len(key, KeyLength)
+
+	// The key must match the workload key the keyservice derives for the node operator.
+	clusterKMS, err := cluster.New(masterSecret.Key, masterSecret.Salt)
+	require.NoError(err)
+	workloadKey, err := clusterKMS.GetDEK(t.Context(), crypto.DEKPrefix+crypto.WorkloadKeyIDPrefix+"kube-system/etcd-backup", KeyLength)
+	require.NoError(err)
+	assert.Equal(workloadKey, key)
+
+	masterSecret.Salt = bytes.Repeat([]byte{0x3}, 32)
+	otherKey, err := DeriveKey(masterSecret)
+	require.NoError(err)
+	assert.NotEqual(key, otherKey)
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	key := bytes.Repeat([]byte{0x1}, KeyLength)
+
+	testCases := map[string]int{
+		"empty":                0,
+		"single segment":       100,
+		"full segment":         stream.SegmentSize,
+		"multiple segments":    3*stream.SegmentSize + 5,
+		"multiple of segments": 2 * stream.SegmentSize,
+	}
+
+	for name, size := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			snapshot := make([]byte, size)
+			_, err := rand.Read(snapshot)
+			require.NoError(err)
+
+			encrypter, err := NewEncrypter(key, bytes.NewReader(snapshot))
+			require.NoError(err)
+			// Read in small pieces to make sure segments are not required to be read at once.
+			encrypted, err := io.ReadAll(iotest.OneByteReader(encrypter))
+			require.NoError(err)
+			if size > 0 {
+				assert.False(bytes.Contains(encrypted, snapshot))
+			}
+
+			decrypter, err := NewDecrypter(key, iotest.HalfReader(bytes.NewReader(encrypted)))
+			require.NoError(err)
+			decrypted, err := io.ReadAll(decrypter)
+			require.NoError(err)
+			assert.Equal(snapshot, decrypted)
+
+			encryptedAgain, err := encrypt(key, snapshot)
+			require.NoError(err)
+			assert.NotEqual(encrypted, encryptedAgain)
+		})
+	}
+}
+
+func TestEncryptReadFails(t *testing.T) {
+	key := bytes.Repeat([]byte{0x1}, KeyLength)
+	someErr := errors.New("failed")
+
+	encrypter, err := NewEncrypter(key, io.MultiReader(bytes.NewReader(make([]byte, stream.SegmentSize+1)), iotest.ErrReader(someErr)))
+	require.NoError(t, err)
+	_, err = io.ReadAll(encrypter)
+	assert.ErrorIs(t, err, someErr)
+}
+
+func TestDecrypt(t *testing.T) {
+	key := bytes.Repeat([]byte{0x1}, KeyLength)
+	snapshot := bytes.Repeat([]byte("etcd snapshot"), stream.SegmentSize/4)
+	encrypted, err := encrypt(key, snapshot)
+	require.NoError(t, err)
+	header := len(magic) + stream.NoncePrefixSize
+	fullSegment := stream.CiphertextSegmentSize
+	require.Len(t, encrypted, header+3*fullSegment+(len(snapshot)-3*stream.SegmentSize)+stream.TagSize)
+
+	testCases := map[string]struct {
+		key         []byte
+		encrypted   func() []byte
+		wantInvalid bool
+		wantErr     bool
+	}{
+		"success": {
+			key:       key,
+			encrypted: func() []byte { return bytes.Clone(encrypted) },
+		},
+		"wrong key": {
+			key:       bytes.Repeat([]byte{0x2}, KeyLength),
+			encrypted: func() []byte { return bytes.Clone(encrypted) },
+			wantErr:   true,
+		},
+		"invalid key length": {
+			key:       []byte{0x1},
+			encrypted: func() []byte { return bytes.Clone(encrypted) },
+			wantErr:   true,
+		},
+		"modified ciphertext": {
+			key: key,
+			encrypted: func() []byte {
+				modified := bytes.Clone(encrypted)
+				modified[len(modified)-1] ^= 0xff
+				return modified
+			},
+			wantErr: true,
+		},
+		"wrong magic": {
+			key: key,
+			encrypted: func() []byte {
+				modified := bytes.Clone(encrypted)
+				modified[0] ^= 0xff
+				return modified
+			},
+			wantInvalid: true,
+			wantErr:     true,
+		},
+		"last segment dropped": {
+			key:       key,
+			encrypted: func() []byte { return bytes.Clone(encrypted[:header+3*fullSegment]) },
+			wantErr:   true,
+		},
+		"last segment truncated": {
+			key:       key,
+			encrypted: func() []byte { return bytes.Clone(encrypted[:header+3*fullSegment+20]) },
+			wantErr:   true,
+		},
+		"segments reordered": {
+			key: key,
+			encrypted: func() []byte {
+				modified := bytes.Clone(encrypted)
+				copy(modified[header:], encrypted[header+fullSegment:header+2*fullSegment])
+				copy(modified[header+fullSegment:], encrypted[header:header+fullSegment])
+				return modified
+			},
+			wantErr: true,
+		},
+		"data appended": {
+			key:       key,
+			encrypted: func() []byte { return append(bytes.Clone(encrypted), 0x1) },
+			wantErr:   true,
+		},
+		"too short": {
+			key:         key,
+			encrypted:   func() []byte { return bytes.Clone(encrypted[:len(magic)+4]) },
+			wantInvalid: true,
+			wantErr:     true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			decrypted, err := decrypt(tc.key, tc.encrypted())
+			if tc.wantErr {
+				assert.Error(err)
+				assert.Equal(tc.wantInvalid, errors.Is(err, ErrInvalidSnapshot))
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(snapshot, decrypted)
+		})
+	}
+}
+
+func encrypt(key, snapshot []byte) ([]byte, error) {
+	encrypter, err := NewEncrypter(key, bytes.NewReader(snapshot))
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(encrypter)
+}
+
+func decrypt(key, encrypted []byte) ([]byte, error) {
+	decrypter, err := NewDecrypter(key, bytes.NewReader(encrypted))
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(decrypter)
+}
+
+func TestSnapshotName(t *testing.T) {
+	assert := assert.New(t)
+
+	taken := time.Date(2024, 3, 5, 13, 4, 5, 0, time.FixedZone("CET", 3600))
+	assert.Equal("etcd-snapshot-20240305T120405Z.db.enc", SnapshotName("", taken))
+	assert.Equal("cluster/etcd-snapshot-20240305T120405Z.db.enc", SnapshotName("cluster", taken))
+	assert.Equal("cluster/etcd-snapshot-20240305T120405Z.db.enc", SnapshotName("cluster/", taken))
+
+	parsed, ok := SnapshotTime(SnapshotName("cluster", taken))
+	assert.True(ok)
+	assert.True(parsed.Equal(taken))
+
+	for _, name := range []string{"snapshot.db", "etcd-snapshot-.db.enc", "etcd-snapshot-20240305T120405Z.db"} {
+		_, ok := SnapshotTime(name)
+		assert.False(ok, name)
+	}
+}
+
+func TestListSnapshots(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	now := time.Date(2024, 3, 5, 12, 0, 0, 0, time.UTC)
+	store := localfs.New(afero.NewMemMapFs(), "/backups")
+	for _, name := range []string{
+		SnapshotName("cluster", now),
+		SnapshotName("cluster", now.Add(-time.Hour)),
+		SnapshotName("cluster/nested", now),
+		SnapshotName("cluster-2", now),
+		SnapshotName("", now),
+		"cluster/unrelated",
+	} {
+		require.NoError(store.Put(t.Context(), name, strings.NewReader("")))
+	}
+
+	snapshots, err := ListSnapshots(t.Context(), store, "cluster")
+	require.NoError(err)
+	assert.Equal([]string{SnapshotName("cluster", now.Add(-time.Hour)), SnapshotName("cluster", now)}, snapshots)
+
+	snapshots, err = ListSnapshots(t.Context(), store, "")
+	require.NoError(err)
+	assert.Equal([]string{SnapshotName("", now)}, snapshots)
+}
+
+func TestExpiredSnapshots(t *testing.T) {
+	now := time.Date(2024, 3, 5, 12, 0, 0, 0, time.UTC)
+	names := []string{
+		SnapshotName("c", now.Add(-1*time.Hour)),
+		SnapshotName("c", now.Add(-3*time.Hour)),
+		SnapshotName("c", now.Add(-2*time.Hour)),
+		"c/unrelated",
+	}
+
+	testCases := map[string]struct {
+		names       []string
+		maxCount    int
+		maxAge      time.Duration
+		wantExpired []string
+	}{
+		"no limits": {
+			names: names,
+		},
+		"max count": {
+			names:       names,
+			maxCount:    2,
+			wantExpired: []string{SnapshotName("c", now.Add(-3*time.Hour))},
+		},
+		"max age": {
+			names:       names,
+			maxAge:      90 * time.Minute,
+			wantExpired: []string{SnapshotName("c", now.Add(-3*time.Hour)), SnapshotName("c", now.Add(-2*time.Hour))},
+		},
+		"both limits": {
+			names:       names,
+			maxCount:    1,
+			maxAge:      150 * time.Minute,
+			wantExpired: []string{SnapshotName("c", now.Add(-3*time.Hour)), SnapshotName("c", now.Add(-2*time.Hour))},
+		},
+		"latest snapshot is kept": {
+			names:       names,
+			maxAge:      time.Minute,
+			wantExpired: []string{SnapshotName("c", now.Add(-3*time.Hour)), SnapshotName("c", now.Add(-2*time.Hour))},
+		},
+		"no snapshots": {
+			maxCount: 1,
+			maxAge:   time.Minute,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			assert.Equal(tc.wantExpired, ExpiredSnapshots(tc.names, now, tc.maxCount, tc.maxAge))
+		})
+	}
+}
+
+func TestNewStorage(t *testing.T) {
+	testCases := map[string]struct {
+		uri     string
+		wantErr bool
+	}{
+		"local": {
+			uri: "storage://local?path=/tmp/backups",
+		},
+		"local without path": {
+			uri:     "storage://local",
+			wantErr: true,
+		},
+		"gcp": {
+			uri: "storage://gcp?projectID=project&bucket=bucket&credentialsPath=/var/secrets/google/key.json",
+		},
+		"gcp missing bucket": {
+			uri:     "storage://gcp?projectID=project&credentialsPath=/var/secrets/google/key.json",
+			wantErr: true,
+		},
+		"aws missing bucket": {
+			uri:     "storage://aws?region=eu-central-1&accessKeyID=id&accessKey=key",
+			wantErr: true,
+		},
+		"wrong scheme": {
+			uri:     "kms://aws?region=eu-central-1",
+			wantErr: true,
+		},
+		"unknown storage": {
+			uri:     "storage://vault?address=localhost",
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			store, err := NewStorage(t.Context(), tc.uri)
+			if tc.wantErr {
+				assert.Error(err)
+				return
+			}
+			assert.NoError(err)
+			assert.NotNil(store)
+		})
+	}
+}
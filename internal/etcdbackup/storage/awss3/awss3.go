@@ -0,0 +1,121 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+// Package awss3 implements a storage backend for etcd snapshots using AWS S3.
+package awss3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	s3manager "github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/edgelesssys/constellation/v2/internal/etcdbackup/storage"
+	"github.com/edgelesssys/constellation/v2/internal/kms/uri"
+)
+
+type awsS3ClientAPI interface {
+	GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+	ListObjectsV2(ctx context.Context, params *s3.ListObjectsV2Input, optFns ...func(*s3.Options)) (*s3.ListObjectsV2Output, error)
+	DeleteObject(ctx context.Context, params *s3.DeleteObjectInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectOutput, error)
+}
+
+// uploadClient has the same interface as the S3 uploader.
+type uploadClient interface {
+	Upload(ctx context.Context, input *s3.PutObjectInput, opts ...func(*s3manager.Uploader)) (*s3manager.UploadOutput, error)
+}
+
+// Storage stores etcd snapshots in an AWS S3 bucket.
+type Storage struct {
+	bucketID     string
+	client       awsS3ClientAPI
+	uploadClient uploadClient
+}
+
+// New creates a Storage client for AWS S3 using the provided config.
+// The bucket must already exist.
+func New(ctx context.Context, cfg uri.AWSS3Config) (*Storage, error) {
+	clientCfg, err := awsconfig.LoadDefaultConfig(
+		ctx,
+		awsconfig.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(cfg.AccessKeyID, cfg.AccessKey, "")),
+		awsconfig.WithRegion(cfg.Region),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("loading AWS S3 client config: %w", err)
+	}
+	client := s3.NewFromConfig(clientCfg)
+	return &Storage{client: client, uploadClient: s3manager.NewUploader(client), bucketID: cfg.Bucket}, nil
+}
+
+// Get returns a reader for a snapshot from AWS S3 by name.
+func (s *Storage) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	output, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: &s.bucketID,
+		Key:    &name,
+	})
+	if err != nil {
+		var nsk *types.NoSuchKey
+		if errors.As(err, &nsk) {
+			return nil, storage.ErrNotFound
+		}
+		return nil, fmt.Errorf("downloading snapshot from storage: %w", err)
+	}
+	return output.Body, nil
+}
+
+// Put saves a snapshot to AWS S3 by name.
+// Large snapshots are uploaded in parts, so the snapshot is never held in memory as a whole.
+// Parts of a failed upload are removed.
+func (s *Storage) Put(ctx context.Context, name string, data io.Reader) error {
+	if _, err := s.uploadClient.Upload(ctx, &s3.PutObjectInput{
+		Bucket: &s.bucketID,
+		Key:    &name,
+		Body:   data,
+	}); err != nil {
+		return fmt.Errorf("uploading snapshot to storage: %w", err)
+	}
+	return nil
+}
+
+// List returns the names of all snapshots in AWS S3 with the given prefix.
+func (s *Storage) List(ctx context.Context, prefix string) ([]string, error) {
+	var names []string
+	input := &s3.ListObjectsV2Input{
+		Bucket: &s.bucketID,
+		Prefix: &prefix,
+	}
+	for {
+		output, err := s.client.ListObjectsV2(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("listing snapshots in storage: %w", err)
+		}
+		for _, object := range output.Contents {
+			if object.Key != nil {
+				names = append(names, *object.Key)
+			}
+		}
+		if output.IsTruncated == nil || !*output.IsTruncated {
+			return names, nil
+		}
+		input.ContinuationToken = output.NextContinuationToken
+	}
+}
+
+// Delete removes a snapshot from AWS S3 by name.
+func (s *Storage) Delete(ctx context.Context, name string) error {
+	if _, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: &s.bucketID,
+		Key:    &name,
+	}); err != nil {
+		return fmt.Errorf("deleting snapshot from storage: %w", err)
+	}
+	return nil
+}
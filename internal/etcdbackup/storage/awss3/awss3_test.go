@@ -0,0 +1,204 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package awss3
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	s3manager "github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/edgelesssys/constellation/v2/internal/etcdbackup/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGet(t *testing.T) {
+	testCases := map[string]struct {
+		client       *stubAWSS3Client
+		wantNotFound bool
+		wantErr      bool
+	}{
+		"success": {
+			client: &stubAWSS3Client{objects: map[string][]byte{"snapshot": []byte("data")}},
+		},
+		"not found": {
+			client:       &stubAWSS3Client{getObjectErr: &types.NoSuchKey{}},
+			wantNotFound: true,
+			wantErr:      true,
+		},
+		"get fails": {
+			client:  &stubAWSS3Client{getObjectErr: errors.New("failed")},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			store := &Storage{client: tc.client, bucketID: "bucket"}
+			reader, err := store.Get(t.Context(), "snapshot")
+			if tc.wantErr {
+				assert.Error(err)
+				assert.Equal(tc.wantNotFound, errors.Is(err, storage.ErrNotFound))
+				return
+			}
+			assert.NoError(err)
+			defer reader.Close()
+			data, err := io.ReadAll(reader)
+			assert.NoError(err)
+			assert.Equal([]byte("data"), data)
+		})
+	}
+}
+
+func TestPut(t *testing.T) {
+	testCases := map[string]struct {
+		client  *stubAWSS3Client
+		wantErr bool
+	}{
+		"success": {
+			client: &stubAWSS3Client{objects: map[string][]byte{}},
+		},
+		"upload fails": {
+			client:  &stubAWSS3Client{uploadErr: errors.New("failed")},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			store := &Storage{client: tc.client, uploadClient: tc.client, bucketID: "bucket"}
+			err := store.Put(t.Context(), "snapshot", bytes.NewReader([]byte("data")))
+			if tc.wantErr {
+				assert.Error(err)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal([]byte("data"), tc.client.objects["snapshot"])
+		})
+	}
+}
+
+func TestList(t *testing.T) {
+	testCases := map[string]struct {
+		client    *stubAWSS3Client
+		wantNames []string
+		wantErr   bool
+	}{
+		"single page": {
+			client:    &stubAWSS3Client{pages: [][]string{{"a", "b"}}},
+			wantNames: []string{"a", "b"},
+		},
+		"multiple pages": {
+			client:    &stubAWSS3Client{pages: [][]string{{"a"}, {"b"}, {"c"}}},
+			wantNames: []string{"a", "b", "c"},
+		},
+		"empty": {
+			client: &stubAWSS3Client{pages: [][]string{{}}},
+		},
+		"list fails": {
+			client:  &stubAWSS3Client{listErr: errors.New("failed")},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			store := &Storage{client: tc.client, bucketID: "bucket"}
+			names, err := store.List(t.Context(), "prefix/")
+			if tc.wantErr {
+				assert.Error(err)
+				return
+			}
+			require.NoError(err)
+			assert.Equal(tc.wantNames, names)
+			assert.Equal("prefix/", tc.client.listPrefix)
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+	assert := assert.New(t)
+
+	client := &stubAWSS3Client{objects: map[string][]byte{"snapshot": []byte("data")}}
+	store := &Storage{client: client, bucketID: "bucket"}
+	assert.NoError(store.Delete(t.Context(), "snapshot"))
+	assert.NotContains(client.objects, "snapshot")
+
+	client.deleteObjectErr = errors.New("failed")
+	assert.Error(store.Delete(t.Context(), "snapshot"))
+}
+
+type stubAWSS3Client struct {
+	objects         map[string][]byte
+	pages           [][]string
+	listPrefix      string
+	getObjectErr    error
+	uploadErr       error
+	listErr         error
+	deleteObjectErr error
+}
+
+func (s *stubAWSS3Client) GetObject(_ context.Context, params *s3.GetObjectInput, _ ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	if s.getObjectErr != nil {
+		return nil, s.getObjectErr
+	}
+	return &s3.GetObjectOutput{Body: io.NopCloser(bytes.NewReader(s.objects[*params.Key]))}, nil
+}
+
+func (s *stubAWSS3Client) Upload(_ context.Context, params *s3.PutObjectInput, _ ...func(*s3manager.Uploader)) (*s3manager.UploadOutput, error) {
+	if s.uploadErr != nil {
+		return nil, s.uploadErr
+	}
+	data, err := io.ReadAll(params.Body)
+	if err != nil {
+		return nil, err
+	}
+	s.objects[*params.Key] = data
+	return &s3manager.UploadOutput{}, nil
+}
+
+func (s *stubAWSS3Client) ListObjectsV2(_ context.Context, params *s3.ListObjectsV2Input, _ ...func(*s3.Options)) (*s3.ListObjectsV2Output, error) {
+	if s.listErr != nil {
+		return nil, s.listErr
+	}
+	s.listPrefix = *params.Prefix
+	page := 0
+	if params.ContinuationToken != nil {
+		page = int((*params.ContinuationToken)[0] - '0')
+	}
+	output := &s3.ListObjectsV2Output{}
+	for _, name := range s.pages[page] {
+		output.Contents = append(output.Contents, types.Object{Key: &name})
+	}
+	if page+1 < len(s.pages) {
+		truncated := true
+		token := string(rune('0' + page + 1))
+		output.IsTruncated = &truncated
+		output.NextContinuationToken = &token
+	}
+	return output, nil
+}
+
+func (s *stubAWSS3Client) DeleteObject(_ context.Context, params *s3.DeleteObjectInput, _ ...func(*s3.Options)) (*s3.DeleteObjectOutput, error) {
+	if s.deleteObjectErr != nil {
+		return nil, s.deleteObjectErr
+	}
+	delete(s.objects, *params.Key)
+	return &s3.DeleteObjectOutput{}, nil
+}
@@ -0,0 +1,111 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+// Package azureblob implements a storage backend for etcd snapshots using Azure Blob Storage.
+package azureblob
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
+	"github.com/edgelesssys/constellation/v2/internal/etcdbackup/storage"
+	"github.com/edgelesssys/constellation/v2/internal/kms/uri"
+)
+
+type azureBlobAPI interface {
+	DownloadStream(context.Context, string, string, *blob.DownloadStreamOptions) (azblob.DownloadStreamResponse, error)
+	UploadStream(context.Context, string, string, io.Reader, *azblob.UploadStreamOptions) (azblob.UploadStreamResponse, error)
+	NewListBlobsFlatPager(string, *azblob.ListBlobsFlatOptions) *runtime.Pager[azblob.ListBlobsFlatResponse]
+	DeleteBlob(context.Context, string, string, *azblob.DeleteBlobOptions) (azblob.DeleteBlobResponse, error)
+}
+
+// Storage stores etcd snapshots in an Azure Blob Storage container.
+type Storage struct {
+	client    azureBlobAPI
+	container string
+}
+
+// New initializes a storage client using Azure's Blob Storage using the provided config.
+// The storage container must already exist.
+func New(cfg uri.AzureBlobConfig) (*Storage, error) {
+	var creds azcore.TokenCredential
+
+	creds, err := azidentity.NewClientSecretCredential(cfg.TenantID, cfg.ClientID, cfg.ClientSecret, nil)
+	if err != nil {
+		// Fallback: try to load default credentials
+		creds, err = azidentity.NewDefaultAzureCredential(nil)
+		if err != nil {
+			return nil, fmt.Errorf("invalid client-secret credentials. Trying to load default credentials: %w", err)
+		}
+	}
+
+	client, err := azblob.NewClient(fmt.Sprintf("https://%s.blob.core.windows.net/", cfg.StorageAccount), creds, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating storage client: %w", err)
+	}
+
+	return &Storage{
+		client:    client,
+		container: cfg.Container,
+	}, nil
+}
+
+// Get returns a reader for a snapshot from Azure Blob Storage by name.
+func (s *Storage) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	res, err := s.client.DownloadStream(ctx, s.container, name, nil)
+	if err != nil {
+		if bloberror.HasCode(err, bloberror.BlobNotFound) {
+			return nil, storage.ErrNotFound
+		}
+		return nil, fmt.Errorf("downloading snapshot from storage: %w", err)
+	}
+	return res.Body, nil
+}
+
+// Put saves a snapshot to Azure Blob Storage by name.
+// The snapshot is uploaded in blocks, so it is never held in memory as a whole.
+func (s *Storage) Put(ctx context.Context, name string, data io.Reader) error {
+	if _, err := s.client.UploadStream(ctx, s.container, name, data, nil); err != nil {
+		return fmt.Errorf("uploading snapshot to storage: %w", err)
+	}
+	return nil
+}
+
+// List returns the names of all snapshots in Azure Blob Storage with the given prefix.
+func (s *Storage) List(ctx context.Context, prefix string) ([]string, error) {
+	var names []string
+	pager := s.client.NewListBlobsFlatPager(s.container, &azblob.ListBlobsFlatOptions{Prefix: &prefix})
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("listing snapshots in storage: %w", err)
+		}
+		if page.Segment == nil {
+			continue
+		}
+		for _, item := range page.Segment.BlobItems {
+			if item.Name != nil {
+				names = append(names, *item.Name)
+			}
+		}
+	}
+	return names, nil
+}
+
+// Delete removes a snapshot from Azure Blob Storage by name.
+func (s *Storage) Delete(ctx context.Context, name string) error {
+	if _, err := s.client.DeleteBlob(ctx, s.container, name, nil); err != nil {
+		return fmt.Errorf("deleting snapshot from storage: %w", err)
+	}
+	return nil
+}
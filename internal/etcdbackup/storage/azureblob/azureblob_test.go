@@ -0,0 +1,199 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package azureblob
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/container"
+	"github.com/edgelesssys/constellation/v2/internal/etcdbackup/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGet(t *testing.T) {
+	testCases := map[string]struct {
+		client       stubAzureBlobAPI
+		wantNotFound bool
+		wantErr      bool
+	}{
+		"success": {
+			client: stubAzureBlobAPI{downloadData: []byte{0x1, 0x2, 0x3}},
+		},
+		"DownloadStream fails": {
+			client:  stubAzureBlobAPI{downloadErr: errors.New("failed")},
+			wantErr: true,
+		},
+		"BlobNotFound error": {
+			client:       stubAzureBlobAPI{downloadErr: &azcore.ResponseError{ErrorCode: string(bloberror.BlobNotFound)}},
+			wantNotFound: true,
+			wantErr:      true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			client := &Storage{client: &tc.client, container: "test"}
+			reader, err := client.Get(t.Context(), "snapshot")
+			if tc.wantErr {
+				assert.Error(err)
+				assert.Equal(tc.wantNotFound, errors.Is(err, storage.ErrNotFound))
+				return
+			}
+			assert.NoError(err)
+			defer reader.Close()
+			out, err := io.ReadAll(reader)
+			assert.NoError(err)
+			assert.Equal(tc.client.downloadData, out)
+		})
+	}
+}
+
+func TestPut(t *testing.T) {
+	testCases := map[string]struct {
+		client  stubAzureBlobAPI
+		wantErr bool
+	}{
+		"success": {
+			client: stubAzureBlobAPI{},
+		},
+		"Upload fails": {
+			client:  stubAzureBlobAPI{uploadErr: errors.New("failed")},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			testData := []byte{0x1, 0x2, 0x3}
+			client := &Storage{client: &tc.client, container: "test"}
+			err := client.Put(t.Context(), "snapshot", bytes.NewReader(testData))
+			if tc.wantErr {
+				assert.Error(err)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(testData, tc.client.uploadData)
+		})
+	}
+}
+
+func TestList(t *testing.T) {
+	testCases := map[string]struct {
+		client    stubAzureBlobAPI
+		wantNames []string
+		wantErr   bool
+	}{
+		"single page": {
+			client:    stubAzureBlobAPI{pages: [][]string{{"a", "b"}}},
+			wantNames: []string{"a", "b"},
+		},
+		"multiple pages": {
+			client:    stubAzureBlobAPI{pages: [][]string{{"a"}, {"b", "c"}}},
+			wantNames: []string{"a", "b", "c"},
+		},
+		"list fails": {
+			client:  stubAzureBlobAPI{pages: [][]string{{"a"}}, listErr: errors.New("failed")},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			client := &Storage{client: &tc.client, container: "test"}
+			names, err := client.List(t.Context(), "prefix/")
+			if tc.wantErr {
+				assert.Error(err)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(tc.wantNames, names)
+			assert.Equal("prefix/", tc.client.listPrefix)
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+	assert := assert.New(t)
+
+	stub := &stubAzureBlobAPI{}
+	client := &Storage{client: stub, container: "test"}
+	assert.NoError(client.Delete(t.Context(), "snapshot"))
+	assert.Equal([]string{"snapshot"}, stub.deleted)
+
+	stub.deleteErr = errors.New("failed")
+	assert.Error(client.Delete(t.Context(), "snapshot"))
+}
+
+type stubAzureBlobAPI struct {
+	downloadErr  error
+	downloadData []byte
+	uploadErr    error
+	uploadData   []byte
+	pages        [][]string
+	listPrefix   string
+	listErr      error
+	deleted      []string
+	deleteErr    error
+}
+
+func (s *stubAzureBlobAPI) DownloadStream(context.Context, string, string, *blob.DownloadStreamOptions) (blob.DownloadStreamResponse, error) {
+	res := blob.DownloadStreamResponse{}
+	res.Body = io.NopCloser(bytes.NewReader(s.downloadData))
+	return res, s.downloadErr
+}
+
+func (s *stubAzureBlobAPI) UploadStream(_ context.Context, _, _ string, data io.Reader, _ *azblob.UploadStreamOptions) (azblob.UploadStreamResponse, error) {
+	uploadData, err := io.ReadAll(data)
+	if err != nil {
+		return azblob.UploadStreamResponse{}, err
+	}
+	s.uploadData = uploadData
+	return azblob.UploadStreamResponse{}, s.uploadErr
+}
+
+func (s *stubAzureBlobAPI) NewListBlobsFlatPager(_ string, opts *azblob.ListBlobsFlatOptions) *runtime.Pager[azblob.ListBlobsFlatResponse] {
+	s.listPrefix = *opts.Prefix
+	page := 0
+	return runtime.NewPager(runtime.PagingHandler[azblob.ListBlobsFlatResponse]{
+		More: func(azblob.ListBlobsFlatResponse) bool {
+			return page < len(s.pages)
+		},
+		Fetcher: func(context.Context, *azblob.ListBlobsFlatResponse) (azblob.ListBlobsFlatResponse, error) {
+			if s.listErr != nil {
+				return azblob.ListBlobsFlatResponse{}, s.listErr
+			}
+			segment := &container.BlobFlatListSegment{}
+			for _, name := range s.pages[page] {
+				segment.BlobItems = append(segment.BlobItems, &container.BlobItem{Name: &name})
+			}
+			page++
+			res := azblob.ListBlobsFlatResponse{}
+			res.Segment = segment
+			return res, nil
+		},
+	})
+}
+
+func (s *stubAzureBlobAPI) DeleteBlob(_ context.Context, _, name string, _ *azblob.DeleteBlobOptions) (azblob.DeleteBlobResponse, error) {
+	s.deleted = append(s.deleted, name)
+	return azblob.DeleteBlobResponse{}, s.deleteErr
+}
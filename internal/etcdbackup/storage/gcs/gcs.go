@@ -0,0 +1,169 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+// Package gcs implements a storage backend for etcd snapshots using Google Cloud Storage (GCS).
+package gcs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	gcstorage "cloud.google.com/go/storage"
+	"github.com/edgelesssys/constellation/v2/internal/etcdbackup/storage"
+	"github.com/edgelesssys/constellation/v2/internal/kms/uri"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+)
+
+type gcpStorageAPI interface {
+	Close() error
+	NewWriter(ctx context.Context, bucketName, objectName string) io.WriteCloser
+	NewReader(ctx context.Context, bucketName, objectName string) (io.ReadCloser, error)
+	ListObjects(ctx context.Context, bucketName, prefix string) ([]string, error)
+	DeleteObject(ctx context.Context, bucketName, objectName string) error
+}
+
+type wrappedGCPClient struct {
+	*gcstorage.Client
+}
+
+func (c *wrappedGCPClient) NewWriter(ctx context.Context, bucketName, objectName string) io.WriteCloser {
+	return c.Client.Bucket(bucketName).Object(objectName).NewWriter(ctx)
+}
+
+func (c *wrappedGCPClient) NewReader(ctx context.Context, bucketName, objectName string) (io.ReadCloser, error) {
+	return c.Client.Bucket(bucketName).Object(objectName).NewReader(ctx)
+}
+
+func (c *wrappedGCPClient) ListObjects(ctx context.Context, bucketName, prefix string) ([]string, error) {
+	var names []string
+	it := c.Client.Bucket(bucketName).Objects(ctx, &gcstorage.Query{Prefix: prefix})
+	for {
+		attrs, err := it.Next()
+		if errors.Is(err, iterator.Done) {
+			return names, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, attrs.Name)
+	}
+}
+
+func (c *wrappedGCPClient) DeleteObject(ctx context.Context, bucketName, objectName string) error {
+	return c.Client.Bucket(bucketName).Object(objectName).Delete(ctx)
+}
+
+// Storage stores etcd snapshots in a Google Cloud Storage bucket.
+type Storage struct {
+	newClient  func(ctx context.Context) (gcpStorageAPI, error)
+	bucketName string
+}
+
+// New creates a Storage client for Google Cloud Storage using the provided config.
+// The bucket must already exist.
+func New(cfg uri.GoogleCloudStorageConfig) *Storage {
+	return &Storage{
+		newClient:  newGCPStorageClientFactory(cfg.CredentialsPath),
+		bucketName: cfg.Bucket,
+	}
+}
+
+// Get returns a reader for a snapshot from Google Cloud Storage by name.
+// The client is closed once the returned reader is closed.
+func (s *Storage) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	client, err := s.newClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := client.NewReader(ctx, s.bucketName, name)
+	if err != nil {
+		_ = client.Close()
+		if errors.Is(err, gcstorage.ErrObjectNotExist) {
+			return nil, storage.ErrNotFound
+		}
+		return nil, fmt.Errorf("downloading snapshot from storage: %w", err)
+	}
+	return &objectReader{ReadCloser: reader, client: client}, nil
+}
+
+// Put saves a snapshot to Google Cloud Storage by name.
+// The snapshot is uploaded in chunks, so it is never held in memory as a whole.
+func (s *Storage) Put(ctx context.Context, name string, data io.Reader) error {
+	client, err := s.newClient(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	// Canceling the context of the writer aborts the upload,
+	// so that a failed upload doesn't leave a truncated snapshot behind.
+	writerCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	writer := client.NewWriter(writerCtx, s.bucketName, name)
+	if _, err := io.Copy(writer, data); err != nil {
+		cancel()
+		_ = writer.Close()
+		return fmt.Errorf("uploading snapshot to storage: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("uploading snapshot to storage: %w", err)
+	}
+	return nil
+}
+
+// List returns the names of all snapshots in Google Cloud Storage with the given prefix.
+func (s *Storage) List(ctx context.Context, prefix string) ([]string, error) {
+	client, err := s.newClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	names, err := client.ListObjects(ctx, s.bucketName, prefix)
+	if err != nil {
+		return nil, fmt.Errorf("listing snapshots in storage: %w", err)
+	}
+	return names, nil
+}
+
+// Delete removes a snapshot from Google Cloud Storage by name.
+func (s *Storage) Delete(ctx context.Context, name string) error {
+	client, err := s.newClient(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if err := client.DeleteObject(ctx, s.bucketName, name); err != nil {
+		return fmt.Errorf("deleting snapshot from storage: %w", err)
+	}
+	return nil
+}
+
+func newGCPStorageClientFactory(credPath string) func(context.Context) (gcpStorageAPI, error) {
+	return func(ctx context.Context) (gcpStorageAPI, error) {
+		client, err := gcstorage.NewClient(ctx, option.WithCredentialsFile(credPath))
+		if err != nil {
+			return nil, err
+		}
+		return &wrappedGCPClient{client}, nil
+	}
+}
+
+// objectReader reads an object and closes the client it was created with once it is closed.
+type objectReader struct {
+	io.ReadCloser
+	client gcpStorageAPI
+}
+
+// Close closes the object reader and the client.
+func (r *objectReader) Close() error {
+	return errors.Join(r.ReadCloser.Close(), r.client.Close())
+}
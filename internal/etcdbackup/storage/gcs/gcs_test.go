@@ -0,0 +1,224 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package gcs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	gcstorage "cloud.google.com/go/storage"
+	"github.com/edgelesssys/constellation/v2/internal/etcdbackup/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+type stubGCPStorageAPI struct {
+	newClientErr    error
+	newReaderErr    error
+	newReaderOutput []byte
+	writer          *stubWriteCloser
+	listOutput      []string
+	listErr         error
+	deleteErr       error
+	deleted         []string
+}
+
+func (s *stubGCPStorageAPI) stubClientFactory(_ context.Context) (gcpStorageAPI, error) {
+	return s, s.newClientErr
+}
+
+func (s *stubGCPStorageAPI) Close() error {
+	return nil
+}
+
+func (s *stubGCPStorageAPI) NewWriter(ctx context.Context, _, _ string) io.WriteCloser {
+	s.writer.ctx = ctx
+	return s.writer
+}
+
+func (s *stubGCPStorageAPI) NewReader(_ context.Context, _, _ string) (io.ReadCloser, error) {
+	return io.NopCloser(bytes.NewReader(s.newReaderOutput)), s.newReaderErr
+}
+
+func (s *stubGCPStorageAPI) ListObjects(_ context.Context, _, _ string) ([]string, error) {
+	return s.listOutput, s.listErr
+}
+
+func (s *stubGCPStorageAPI) DeleteObject(_ context.Context, _, objectName string) error {
+	s.deleted = append(s.deleted, objectName)
+	return s.deleteErr
+}
+
+type stubWriteCloser struct {
+	ctx      context.Context
+	result   []byte
+	aborted  bool
+	writeErr error
+	closeErr error
+}
+
+func (s *stubWriteCloser) Write(p []byte) (int, error) {
+	s.result = append(s.result, p...)
+	return len(p), s.writeErr
+}
+
+func (s *stubWriteCloser) Close() error {
+	s.aborted = s.ctx.Err() != nil
+	return s.closeErr
+}
+
+func TestGet(t *testing.T) {
+	someErr := errors.New("error")
+
+	testCases := map[string]struct {
+		client       *stubGCPStorageAPI
+		wantNotFound bool
+		wantErr      bool
+	}{
+		"success": {
+			client: &stubGCPStorageAPI{newReaderOutput: []byte("test-data")},
+		},
+		"creating client fails": {
+			client:  &stubGCPStorageAPI{newClientErr: someErr},
+			wantErr: true,
+		},
+		"object does not exist": {
+			client:       &stubGCPStorageAPI{newReaderErr: gcstorage.ErrObjectNotExist},
+			wantNotFound: true,
+			wantErr:      true,
+		},
+		"NewReader fails": {
+			client:  &stubGCPStorageAPI{newReaderErr: someErr},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			client := &Storage{newClient: tc.client.stubClientFactory, bucketName: "test"}
+			reader, err := client.Get(t.Context(), "snapshot")
+			if tc.wantErr {
+				assert.Error(err)
+				assert.Equal(tc.wantNotFound, errors.Is(err, storage.ErrNotFound))
+				return
+			}
+			assert.NoError(err)
+			out, err := io.ReadAll(reader)
+			assert.NoError(err)
+			assert.NoError(reader.Close())
+			assert.Equal(tc.client.newReaderOutput, out)
+		})
+	}
+}
+
+func TestPut(t *testing.T) {
+	someErr := errors.New("error")
+
+	testCases := map[string]struct {
+		client      *stubGCPStorageAPI
+		data        io.Reader
+		wantErr     bool
+		wantAborted bool
+	}{
+		"success": {
+			client: &stubGCPStorageAPI{writer: &stubWriteCloser{}},
+		},
+		"reading snapshot fails": {
+			client:      &stubGCPStorageAPI{writer: &stubWriteCloser{}},
+			data:        io.MultiReader(strings.NewReader("test"), iotest.ErrReader(someErr)),
+			wantErr:     true,
+			wantAborted: true,
+		},
+		"creating client fails": {
+			client:  &stubGCPStorageAPI{newClientErr: someErr},
+			wantErr: true,
+		},
+		"write fails": {
+			client:      &stubGCPStorageAPI{writer: &stubWriteCloser{writeErr: someErr}},
+			wantErr:     true,
+			wantAborted: true,
+		},
+		"close fails": {
+			client:  &stubGCPStorageAPI{writer: &stubWriteCloser{closeErr: someErr}},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			data := tc.data
+			if data == nil {
+				data = strings.NewReader("test-data")
+			}
+			client := &Storage{newClient: tc.client.stubClientFactory, bucketName: "test"}
+			err := client.Put(t.Context(), "snapshot", data)
+			if tc.wantErr {
+				assert.Error(err)
+				if tc.client.writer != nil {
+					assert.Equal(tc.wantAborted, tc.client.writer.aborted)
+				}
+				return
+			}
+			assert.NoError(err)
+			assert.Equal([]byte("test-data"), tc.client.writer.result)
+		})
+	}
+}
+
+func TestList(t *testing.T) {
+	testCases := map[string]struct {
+		client  *stubGCPStorageAPI
+		wantErr bool
+	}{
+		"success": {
+			client: &stubGCPStorageAPI{listOutput: []string{"a", "b"}},
+		},
+		"creating client fails": {
+			client:  &stubGCPStorageAPI{newClientErr: errors.New("error")},
+			wantErr: true,
+		},
+		"list fails": {
+			client:  &stubGCPStorageAPI{listErr: errors.New("error")},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			client := &Storage{newClient: tc.client.stubClientFactory, bucketName: "test"}
+			names, err := client.List(t.Context(), "prefix/")
+			if tc.wantErr {
+				assert.Error(err)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(tc.client.listOutput, names)
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+	assert := assert.New(t)
+
+	stub := &stubGCPStorageAPI{}
+	client := &Storage{newClient: stub.stubClientFactory, bucketName: "test"}
+	assert.NoError(client.Delete(t.Context(), "snapshot"))
+	assert.Equal([]string{"snapshot"}, stub.deleted)
+
+	stub.deleteErr = errors.New("error")
+	assert.Error(client.Delete(t.Context(), "snapshot"))
+}
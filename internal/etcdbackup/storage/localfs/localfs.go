@@ -0,0 +1,140 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+// Package localfs implements a storage backend for etcd snapshots using a directory on the local filesystem.
+// This package should be used for testing only.
+package localfs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/edgelesssys/constellation/v2/internal/etcdbackup/storage"
+	"github.com/spf13/afero"
+)
+
+// Storage stores etcd snapshots as files in a directory.
+// Snapshot names may contain slashes, which are mapped to subdirectories.
+type Storage struct {
+	fs  afero.Fs
+	dir string
+}
+
+// New creates a Storage that stores snapshots in the given directory.
+func New(fs afero.Fs, dir string) *Storage {
+	return &Storage{fs: fs, dir: dir}
+}
+
+// Get returns a reader for a snapshot from the local filesystem by name.
+func (s *Storage) Get(_ context.Context, name string) (io.ReadCloser, error) {
+	file, err := s.path(name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := s.fs.Open(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, storage.ErrNotFound
+	} else if err != nil {
+		return nil, fmt.Errorf("reading snapshot: %w", err)
+	}
+	return data, nil
+}
+
+// Put saves a snapshot to the local filesystem by name.
+func (s *Storage) Put(_ context.Context, name string, data io.Reader) error {
+	file, err := s.path(name)
+	if err != nil {
+		return err
+	}
+	if err := s.fs.MkdirAll(filepath.Dir(file), 0o700); err != nil {
+		return fmt.Errorf("creating snapshot directory: %w", err)
+	}
+	// Write to a temporary file first, so that a failed write doesn't leave a truncated snapshot behind.
+	tmpFile := file + ".tmp"
+	if err := s.writeFile(tmpFile, data); err != nil {
+		_ = s.fs.Remove(tmpFile)
+		return fmt.Errorf("writing snapshot: %w", err)
+	}
+	if err := s.fs.Rename(tmpFile, file); err != nil {
+		_ = s.fs.Remove(tmpFile)
+		return fmt.Errorf("writing snapshot: %w", err)
+	}
+	return nil
+}
+
+// List returns the names of all snapshots on the local filesystem with the given prefix.
+func (s *Storage) List(_ context.Context, prefix string) ([]string, error) {
+	if exists, err := afero.DirExists(s.fs, s.dir); err != nil {
+		return nil, fmt.Errorf("checking snapshot directory: %w", err)
+	} else if !exists {
+		return nil, nil
+	}
+
+	var names []string
+	err := afero.Walk(s.fs, s.dir, func(file string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(s.dir, file)
+		if err != nil {
+			return err
+		}
+		if name := filepath.ToSlash(rel); strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing snapshots: %w", err)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Delete removes a snapshot from the local filesystem by name.
+func (s *Storage) Delete(_ context.Context, name string) error {
+	file, err := s.path(name)
+	if err != nil {
+		return err
+	}
+	if err := s.fs.Remove(file); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("deleting snapshot: %w", err)
+	}
+	return nil
+}
+
+// writeFile writes data to a new file that only the owner can access.
+func (s *Storage) writeFile(file string, data io.Reader) error {
+	f, err := s.fs.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, data); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// path returns the file path of a snapshot, making sure it stays inside the storage directory.
+func (s *Storage) path(name string) (string, error) {
+	cleaned := path.Clean("/" + name)
+	if name == "" || cleaned == "/" || cleaned[1:] != name {
+		return "", fmt.Errorf("invalid snapshot name %q", name)
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(name)), nil
+}
@@ -0,0 +1,91 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package localfs
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/edgelesssys/constellation/v2/internal/etcdbackup/storage"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorage(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	store := New(afero.NewMemMapFs(), "/backups")
+
+	names, err := store.List(t.Context(), "")
+	require.NoError(err)
+	assert.Empty(names)
+
+	_, err = store.Get(t.Context(), "cluster/snapshot-1")
+	assert.ErrorIs(err, storage.ErrNotFound)
+
+	require.NoError(store.Put(t.Context(), "cluster/snapshot-1", strings.NewReader("one")))
+	require.NoError(store.Put(t.Context(), "cluster/snapshot-2", strings.NewReader("two")))
+	require.NoError(store.Put(t.Context(), "other/snapshot-1", strings.NewReader("other")))
+
+	reader, err := store.Get(t.Context(), "cluster/snapshot-2")
+	require.NoError(err)
+	data, err := io.ReadAll(reader)
+	require.NoError(err)
+	require.NoError(reader.Close())
+	assert.Equal([]byte("two"), data)
+
+	names, err = store.List(t.Context(), "cluster/")
+	require.NoError(err)
+	assert.Equal([]string{"cluster/snapshot-1", "cluster/snapshot-2"}, names)
+
+	require.NoError(store.Delete(t.Context(), "cluster/snapshot-1"))
+	require.NoError(store.Delete(t.Context(), "cluster/snapshot-1"))
+	names, err = store.List(t.Context(), "")
+	require.NoError(err)
+	assert.Equal([]string{"cluster/snapshot-2", "other/snapshot-1"}, names)
+}
+
+func TestPutFailureLeavesNoSnapshot(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	store := New(afero.NewMemMapFs(), "/backups")
+	data := io.MultiReader(strings.NewReader("partial"), iotest.ErrReader(errors.New("failed")))
+	assert.Error(store.Put(t.Context(), "cluster/snapshot-1", data))
+
+	names, err := store.List(t.Context(), "")
+	require.NoError(err)
+	assert.Empty(names)
+}
+
+func TestInvalidName(t *testing.T) {
+	testCases := map[string]string{
+		"empty":             "",
+		"parent directory":  "../snapshot",
+		"nested parent":     "cluster/../../snapshot",
+		"absolute":          "/snapshot",
+		"trailing slash":    "cluster/",
+		"current directory": ".",
+	}
+
+	for name, snapshotName := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			store := New(afero.NewMemMapFs(), "/backups")
+			assert.Error(store.Put(t.Context(), snapshotName, strings.NewReader("data")))
+			_, err := store.Get(t.Context(), snapshotName)
+			assert.Error(err)
+			assert.Error(store.Delete(t.Context(), snapshotName))
+		})
+	}
+}
@@ -0,0 +1,19 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+/*
+Package storage implements storage backends for etcd snapshots.
+
+If a snapshot that doesn't exist is requested, the backend MUST return [ErrNotFound].
+*/
+package storage
+
+import (
+	"errors"
+)
+
+// ErrNotFound indicates that a snapshot is not found in storage.
+var ErrNotFound = errors.New("snapshot not found")
@@ -84,6 +84,12 @@ func (h *Handler) Read(name string) ([]byte, error) {
 	return io.ReadAll(file)
 }
 
+// Open opens the file with the given name for reading.
+// The caller has to close the returned reader.
+func (h *Handler) Open(name string) (io.ReadCloser, error) {
+	return h.fs.OpenFile(name, os.O_RDONLY, 0o600)
+}
+
 // Write writes the data bytes into the file with the given name.
 func (h *Handler) Write(name string, data []byte, options ...Option) error {
 	if hasOption(options, OptMkdirAll) {
@@ -53,5 +53,10 @@ func (c *KMSClient) GetDEK(ctx context.Context, keyID string, dekSize int) ([]by
 	return c.kms.GetDEK(ctx, keyID, dekSize)
 }
 
+// RewrapDEK re-encrypts a stored Data Encryption Key using the current version of the KEK in AWS KMS.
+func (c *KMSClient) RewrapDEK(ctx context.Context, keyID string) error {
+	return c.kms.RewrapDEK(ctx, keyID)
+}
+
 // Close is a no-op for AWS.
 func (c *KMSClient) Close() {}
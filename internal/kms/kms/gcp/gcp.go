@@ -64,6 +64,11 @@ func (c *KMSClient) GetDEK(ctx context.Context, keyID string, dekSize int) ([]by
 	return c.kms.GetDEK(ctx, keyID, dekSize)
 }
 
+// RewrapDEK re-encrypts a stored Data Encryption Key using the current version of the KEK in Google Cloud KMS.
+func (c *KMSClient) RewrapDEK(ctx context.Context, keyID string) error {
+	return c.kms.RewrapDEK(ctx, keyID)
+}
+
 // Close closes the KMS client.
 func (c *KMSClient) Close() {
 	_ = c.client.Close()
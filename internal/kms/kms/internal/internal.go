@@ -66,6 +66,26 @@ func (c *KMSClient) GetDEK(ctx context.Context, keyID string, dekSize int) ([]by
 	return dek, nil
 }
 
+// RewrapDEK decrypts the stored Data Encryption Key for keyID and encrypts it again using the current KEK.
+func (c *KMSClient) RewrapDEK(ctx context.Context, keyID string) error {
+	encryptedDEK, err := c.Storage.Get(ctx, keyID)
+	if err != nil {
+		return fmt.Errorf("loading encrypted DEK from storage: %w", err)
+	}
+
+	wrappedKey := &wrapping.BlobInfo{}
+	if err := json.Unmarshal(encryptedDEK, wrappedKey); err != nil {
+		return fmt.Errorf("unmarshaling wrapped DEK: %w", err)
+	}
+
+	dek, err := c.Wrapper.Decrypt(ctx, wrappedKey)
+	if err != nil {
+		return fmt.Errorf("decrypting DEK: %w", err)
+	}
+
+	return c.putDEK(ctx, keyID, dek)
+}
+
 // putDEK encrypts a Data Encryption Key and saves it to storage.
 func (c *KMSClient) putDEK(ctx context.Context, keyID string, plainDEK []byte) error {
 	wrappedKey, err := c.Wrapper.Encrypt(ctx, plainDEK)
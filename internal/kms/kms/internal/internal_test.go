@@ -52,6 +52,7 @@ func (s *stubWrapper) Client() *cloudkms.KeyManagementClient {
 
 type stubStorage struct {
 	key    []byte
+	putKey []byte
 	getErr error
 	putErr error
 }
@@ -60,7 +61,8 @@ func (s *stubStorage) Get(context.Context, string) ([]byte, error) {
 	return s.key, s.getErr
 }
 
-func (s *stubStorage) Put(context.Context, string, []byte) error {
+func (s *stubStorage) Put(_ context.Context, _ string, key []byte) error {
+	s.putKey = key
 	return s.putErr
 }
 
@@ -145,3 +147,69 @@ func TestGetDEK(t *testing.T) {
 		})
 	}
 }
+
+func TestRewrapDEK(t *testing.T) {
+	someErr := errors.New("failed")
+	savedTestKey, err := json.Marshal(&wrapping.BlobInfo{
+		Ciphertext: []byte("encrypted-dek"),
+		KeyInfo:    &wrapping.KeyInfo{KeyId: "kek-v1"},
+	})
+	require.NoError(t, err)
+	rewrappedTestKey := &wrapping.BlobInfo{
+		Ciphertext: []byte("rewrapped-dek"),
+		KeyInfo:    &wrapping.KeyInfo{KeyId: "kek-v2"},
+	}
+
+	testCases := map[string]struct {
+		wrapper *stubWrapper
+		storage *stubStorage
+		wantErr bool
+	}{
+		"success": {
+			wrapper: &stubWrapper{decryptResponse: []byte("dek"), encryptResponse: rewrappedTestKey},
+			storage: &stubStorage{key: savedTestKey},
+		},
+		"DEK does not exist": {
+			wrapper: &stubWrapper{},
+			storage: &stubStorage{getErr: storage.ErrDEKUnset},
+			wantErr: true,
+		},
+		"decrypt fails": {
+			wrapper: &stubWrapper{decryptErr: someErr},
+			storage: &stubStorage{key: savedTestKey},
+			wantErr: true,
+		},
+		"encrypt fails": {
+			wrapper: &stubWrapper{decryptResponse: []byte("dek"), encryptErr: someErr},
+			storage: &stubStorage{key: savedTestKey},
+			wantErr: true,
+		},
+		"put fails": {
+			wrapper: &stubWrapper{decryptResponse: []byte("dek"), encryptResponse: rewrappedTestKey},
+			storage: &stubStorage{key: savedTestKey, putErr: someErr},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			client := &KMSClient{
+				Wrapper: tc.wrapper,
+				Storage: tc.storage,
+			}
+
+			err := client.RewrapDEK(t.Context(), "volume-01")
+			if tc.wantErr {
+				assert.Error(err)
+				return
+			}
+			require.NoError(err)
+			var stored wrapping.BlobInfo
+			require.NoError(json.Unmarshal(tc.storage.putKey, &stored))
+			assert.Equal("kek-v2", stored.KeyInfo.KeyId)
+		})
+	}
+}
@@ -0,0 +1,193 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package kmip
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+)
+
+// protocol version spoken by the client.
+const (
+	protocolVersionMajor = 1
+	protocolVersionMinor = 4
+)
+
+// client sends KMIP requests to a server over mutual TLS.
+// A new connection is opened for every request.
+type client struct {
+	endpoint  string
+	tlsConfig *tls.Config
+	dialer    *net.Dialer
+}
+
+// locate returns the unique identifier of the symmetric key with the given name.
+func (c *client) locate(ctx context.Context, name string) (string, error) {
+	payload, err := c.send(ctx, operationLocate,
+		structure(tagAttribute,
+			textString(tagAttributeName, "Object Type"),
+			enumeration(tagAttributeValue, objectTypeSymmetricKey),
+		),
+		structure(tagAttribute,
+			textString(tagAttributeName, "Name"),
+			structure(tagAttributeValue,
+				textString(tagNameValue, name),
+				enumeration(tagNameType, nameTypeUninterpretedTextString),
+			),
+		),
+	)
+	if err != nil {
+		return "", err
+	}
+
+	var uids []string
+	for _, i := range payload.value.([]item) {
+		if i.tag != tagUniqueIdentifier {
+			continue
+		}
+		if uid, ok := i.value.(string); ok {
+			uids = append(uids, uid)
+		}
+	}
+	switch len(uids) {
+	case 0:
+		return "", errors.New("no key with this name found")
+	case 1:
+		return uids[0], nil
+	default:
+		return "", fmt.Errorf("name is ambiguous: found %d keys", len(uids))
+	}
+}
+
+// encrypt encrypts plaintext with the key identified by uid using AES-GCM with a server generated IV.
+func (c *client) encrypt(ctx context.Context, uid string, plaintext []byte) (ciphertext, iv, authTag []byte, err error) {
+	payload, err := c.send(ctx, operationEncrypt,
+		textString(tagUniqueIdentifier, uid),
+		structure(tagCryptographicParameters,
+			enumeration(tagBlockCipherMode, blockCipherModeGCM),
+			enumeration(tagCryptographicAlgorithm, cryptographicAlgorithmAES),
+			boolean(tagRandomIV, true),
+			integer(tagTagLength, gcmTagLength),
+		),
+		byteString(tagData, plaintext),
+	)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	if ciphertext, err = payload.bytesChild(tagData); err != nil {
+		return nil, nil, nil, err
+	}
+	if iv, err = payload.bytesChild(tagIVCounterNonce); err != nil {
+		return nil, nil, nil, err
+	}
+	if authTag, err = payload.bytesChild(tagAuthenticatedEncryptionTag); err != nil {
+		return nil, nil, nil, err
+	}
+	if len(authTag) != gcmTagLength {
+		return nil, nil, nil, fmt.Errorf("unexpected authentication tag length %d", len(authTag))
+	}
+	return ciphertext, iv, authTag, nil
+}
+
+// decrypt decrypts a ciphertext created by encrypt with the key identified by uid.
+func (c *client) decrypt(ctx context.Context, uid string, ciphertext, iv, authTag []byte) ([]byte, error) {
+	payload, err := c.send(ctx, operationDecrypt,
+		textString(tagUniqueIdentifier, uid),
+		structure(tagCryptographicParameters,
+			enumeration(tagBlockCipherMode, blockCipherModeGCM),
+			enumeration(tagCryptographicAlgorithm, cryptographicAlgorithmAES),
+			integer(tagTagLength, gcmTagLength),
+		),
+		byteString(tagData, ciphertext),
+		byteString(tagIVCounterNonce, iv),
+		byteString(tagAuthenticatedEncryptionTag, authTag),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return payload.bytesChild(tagData)
+}
+
+// send sends a request with a single batch item to the server and returns the response payload.
+func (c *client) send(ctx context.Context, operation uint32, payload ...item) (item, error) {
+	req, err := structure(tagRequestMessage,
+		structure(tagRequestHeader,
+			structure(tagProtocolVersion,
+				integer(tagProtocolVersionMajor, protocolVersionMajor),
+				integer(tagProtocolVersionMinor, protocolVersionMinor),
+			),
+			integer(tagBatchCount, 1),
+		),
+		structure(tagBatchItem,
+			enumeration(tagOperation, operation),
+			structure(tagRequestPayload, payload...),
+		),
+	).marshal()
+	if err != nil {
+		return item{}, fmt.Errorf("encoding request: %w", err)
+	}
+
+	tlsDialer := &tls.Dialer{NetDialer: c.dialer, Config: c.tlsConfig}
+	conn, err := tlsDialer.DialContext(ctx, "tcp", c.endpoint)
+	if err != nil {
+		return item{}, fmt.Errorf("connecting to KMIP server: %w", err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return item{}, fmt.Errorf("setting connection deadline: %w", err)
+		}
+	}
+
+	if _, err := conn.Write(req); err != nil {
+		return item{}, fmt.Errorf("sending request: %w", err)
+	}
+	resp, err := readMessage(conn)
+	if err != nil {
+		return item{}, fmt.Errorf("reading response: %w", err)
+	}
+
+	return parseResponse(resp, operation)
+}
+
+// parseResponse checks the result of the single batch item in a response message and returns its payload.
+func parseResponse(resp item, operation uint32) (item, error) {
+	if resp.tag != tagResponseMessage {
+		return item{}, fmt.Errorf("unexpected message tag 0x%06x", uint32(resp.tag))
+	}
+	batchItem, ok := resp.child(tagBatchItem)
+	if !ok {
+		return item{}, errors.New("response contains no batch item")
+	}
+
+	status, err := batchItem.enumChild(tagResultStatus)
+	if err != nil {
+		return item{}, err
+	}
+	if status != resultStatusSuccess {
+		reason, _ := batchItem.enumChild(tagResultReason)
+		message, _ := batchItem.textChild(tagResultMessage)
+		return item{}, fmt.Errorf("operation failed: status %d, reason 0x%x: %s", status, reason, message)
+	}
+
+	if op, err := batchItem.enumChild(tagOperation); err == nil && op != operation {
+		return item{}, fmt.Errorf("response for unexpected operation 0x%x", op)
+	}
+
+	payload, ok := batchItem.child(tagResponsePayload)
+	if !ok {
+		return item{}, errors.New("response contains no payload")
+	}
+	if _, ok := payload.value.([]item); !ok {
+		return item{}, errors.New("response payload is not a structure")
+	}
+	return payload, nil
+}
@@ -0,0 +1,187 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+/*
+Package kmip implements a KMS backend for key management servers and HSMs speaking the
+Key Management Interoperability Protocol (KMIP).
+
+The client speaks KMIP 1.4, which KMIP 2.x servers support for backwards compatibility.
+It authenticates using mutual TLS and wraps DEKs with a named AES key on the server
+using the Encrypt and Decrypt operations in GCM mode.
+
+The client certificate requires permissions for the following operations on the KEK:
+  - Locate
+  - Encrypt
+  - Decrypt
+*/
+package kmip
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	kmsInterface "github.com/edgelesssys/constellation/v2/internal/kms/kms"
+	"github.com/edgelesssys/constellation/v2/internal/kms/kms/internal"
+	"github.com/edgelesssys/constellation/v2/internal/kms/uri"
+	wrapping "github.com/hashicorp/go-kms-wrapping/v2"
+)
+
+// gcmTagLength is the length of the authentication tag requested from the server.
+const gcmTagLength = 16
+
+// KMSClient implements the CloudKMS interface for KMIP.
+type KMSClient struct {
+	kms *internal.KMSClient
+}
+
+// New initializes a KMS client for a KMIP server.
+func New(_ context.Context, store kmsInterface.Storage, cfg uri.KMIPConfig) (*KMSClient, error) {
+	if store == nil {
+		return nil, errors.New("no storage backend provided for KMS")
+	}
+
+	tlsConfig, err := newTLSConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("setting up TLS config: %w", err)
+	}
+
+	return &KMSClient{
+		kms: &internal.KMSClient{
+			Storage: store,
+			Wrapper: &kmipWrapper{
+				client: &client{
+					endpoint:  cfg.Endpoint,
+					tlsConfig: tlsConfig,
+					dialer:    &net.Dialer{Timeout: 30 * time.Second},
+				},
+				keyName: cfg.KeyName,
+			},
+		},
+	}, nil
+}
+
+// GetDEK fetches an encrypted Data Encryption Key from storage and decrypts it using a KEK stored on the KMIP server.
+func (c *KMSClient) GetDEK(ctx context.Context, keyID string, dekSize int) ([]byte, error) {
+	return c.kms.GetDEK(ctx, keyID, dekSize)
+}
+
+// RewrapDEK re-encrypts a stored Data Encryption Key using the current version of the KEK in the KMIP server.
+func (c *KMSClient) RewrapDEK(ctx context.Context, keyID string) error {
+	return c.kms.RewrapDEK(ctx, keyID)
+}
+
+// Close is a no-op for KMIP, since connections are only kept open for a single request.
+func (c *KMSClient) Close() {}
+
+type kmipAPI interface {
+	locate(ctx context.Context, name string) (string, error)
+	encrypt(ctx context.Context, uid string, plaintext []byte) (ciphertext, iv, authTag []byte, err error)
+	decrypt(ctx context.Context, uid string, ciphertext, iv, authTag []byte) ([]byte, error)
+}
+
+// kmipWrapper wraps and unwraps DEKs using a named symmetric key on a KMIP server.
+type kmipWrapper struct {
+	client  kmipAPI
+	keyName string
+
+	mux sync.Mutex
+	uid string
+}
+
+// Encrypt encrypts plaintext using the KEK.
+// The unique identifier of the KEK is saved with the ciphertext,
+// so DEKs stay decryptable if the name is moved to a new key.
+func (w *kmipWrapper) Encrypt(ctx context.Context, plaintext []byte, _ ...wrapping.Option) (*wrapping.BlobInfo, error) {
+	uid, err := w.keyUID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ciphertext, iv, authTag, err := w.client.encrypt(ctx, uid, plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("encrypting with KMIP key %q: %w", w.keyName, err)
+	}
+
+	return &wrapping.BlobInfo{
+		Ciphertext: append(ciphertext, authTag...),
+		Iv:         iv,
+		KeyInfo: &wrapping.KeyInfo{
+			KeyId: uid,
+		},
+	}, nil
+}
+
+// Decrypt decrypts a ciphertext created by Encrypt.
+func (w *kmipWrapper) Decrypt(ctx context.Context, in *wrapping.BlobInfo, _ ...wrapping.Option) ([]byte, error) {
+	if in == nil {
+		return nil, errors.New("no ciphertext to decrypt")
+	}
+	if len(in.Ciphertext) < gcmTagLength {
+		return nil, errors.New("ciphertext too short")
+	}
+
+	uid := in.GetKeyInfo().GetKeyId()
+	if uid == "" {
+		var err error
+		uid, err = w.keyUID(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	split := len(in.Ciphertext) - gcmTagLength
+	plaintext, err := w.client.decrypt(ctx, uid, in.Ciphertext[:split], in.Iv, in.Ciphertext[split:])
+	if err != nil {
+		return nil, fmt.Errorf("decrypting with KMIP key %q: %w", uid, err)
+	}
+	return plaintext, nil
+}
+
+// keyUID returns the unique identifier of the KEK, locating it by name on first use.
+func (w *kmipWrapper) keyUID(ctx context.Context) (string, error) {
+	w.mux.Lock()
+	defer w.mux.Unlock()
+
+	if w.uid != "" {
+		return w.uid, nil
+	}
+	uid, err := w.client.locate(ctx, w.keyName)
+	if err != nil {
+		return "", fmt.Errorf("locating KMIP key %q: %w", w.keyName, err)
+	}
+	w.uid = uid
+	return uid, nil
+}
+
+func newTLSConfig(cfg uri.KMIPConfig) (*tls.Config, error) {
+	clientCert, err := tls.X509KeyPair(cfg.ClientCert, cfg.ClientKey)
+	if err != nil {
+		return nil, fmt.Errorf("loading client certificate: %w", err)
+	}
+
+	rootCAs := x509.NewCertPool()
+	if !rootCAs.AppendCertsFromPEM(cfg.CACert) {
+		return nil, errors.New("no valid CA certificate found")
+	}
+
+	host, _, err := net.SplitHostPort(cfg.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("parsing endpoint: %w", err)
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{clientCert},
+		RootCAs:      rootCAs,
+		ServerName:   host,
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
@@ -0,0 +1,474 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package kmip
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/edgelesssys/constellation/v2/internal/kms/kms"
+	"github.com/edgelesssys/constellation/v2/internal/kms/storage/memfs"
+	"github.com/edgelesssys/constellation/v2/internal/kms/uri"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+)
+
+func TestMain(m *testing.M) {
+	goleak.VerifyTestMain(m, goleak.IgnoreAnyFunction("github.com/bazelbuild/rules_go/go/tools/bzltestutil.RegisterTimeoutHandler.func1"))
+}
+
+func TestGetDEK(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	pki := newTestPKI(t)
+	server := newStubKMIPServer(t, pki, map[string][]byte{"kek": make32(1), "other-kek": make32(2)})
+	defer server.close()
+
+	store := memfs.New()
+	cfg := uri.KMIPConfig{
+		Endpoint:   server.addr(),
+		KeyName:    "kek",
+		CACert:     pki.caPEM,
+		ClientCert: pki.clientCertPEM,
+		ClientKey:  pki.clientKeyPEM,
+	}
+	client, err := New(t.Context(), store, cfg)
+	require.NoError(err)
+	defer client.Close()
+
+	dek, err := client.GetDEK(t.Context(), "volume-01", 32)
+	require.NoError(err)
+	assert.Len(dek, 32)
+
+	dekAgain, err := client.GetDEK(t.Context(), "volume-01", 32)
+	require.NoError(err)
+	assert.Equal(dek, dekAgain)
+
+	// the unique identifier of the KEK is stored with the DEK,
+	// so renaming keys on the server does not break existing DEKs
+	cfg.KeyName = "other-kek"
+	otherClient, err := New(t.Context(), store, cfg)
+	require.NoError(err)
+	dekAgain, err = otherClient.GetDEK(t.Context(), "volume-01", 32)
+	require.NoError(err)
+	assert.Equal(dek, dekAgain)
+
+	// unknown key names are rejected
+	cfg.KeyName = "unknown"
+	unknownClient, err := New(t.Context(), store, cfg)
+	require.NoError(err)
+	_, err = unknownClient.GetDEK(t.Context(), "volume-02", 32)
+	assert.Error(err)
+}
+
+func TestNew(t *testing.T) {
+	pki := newTestPKI(t)
+
+	testCases := map[string]struct {
+		cfg     uri.KMIPConfig
+		noStore bool
+		wantErr bool
+	}{
+		"success": {
+			cfg: uri.KMIPConfig{
+				Endpoint:   "localhost:5696",
+				KeyName:    "kek",
+				CACert:     pki.caPEM,
+				ClientCert: pki.clientCertPEM,
+				ClientKey:  pki.clientKeyPEM,
+			},
+		},
+		"no storage": {
+			cfg: uri.KMIPConfig{
+				Endpoint:   "localhost:5696",
+				KeyName:    "kek",
+				CACert:     pki.caPEM,
+				ClientCert: pki.clientCertPEM,
+				ClientKey:  pki.clientKeyPEM,
+			},
+			noStore: true,
+			wantErr: true,
+		},
+		"invalid client key": {
+			cfg: uri.KMIPConfig{
+				Endpoint:   "localhost:5696",
+				KeyName:    "kek",
+				CACert:     pki.caPEM,
+				ClientCert: pki.clientCertPEM,
+				ClientKey:  []byte("invalid"),
+			},
+			wantErr: true,
+		},
+		"invalid CA certificate": {
+			cfg: uri.KMIPConfig{
+				Endpoint:   "localhost:5696",
+				KeyName:    "kek",
+				CACert:     []byte("invalid"),
+				ClientCert: pki.clientCertPEM,
+				ClientKey:  pki.clientKeyPEM,
+			},
+			wantErr: true,
+		},
+		"endpoint without port": {
+			cfg: uri.KMIPConfig{
+				Endpoint:   "localhost",
+				KeyName:    "kek",
+				CACert:     pki.caPEM,
+				ClientCert: pki.clientCertPEM,
+				ClientKey:  pki.clientKeyPEM,
+			},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			var store kms.Storage = memfs.New()
+			if tc.noStore {
+				store = nil
+			}
+
+			_, err := New(t.Context(), store, tc.cfg)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestParseResponse(t *testing.T) {
+	testCases := map[string]struct {
+		resp    item
+		wantErr bool
+	}{
+		"success": {
+			resp: structure(tagResponseMessage,
+				structure(tagBatchItem,
+					enumeration(tagOperation, operationLocate),
+					enumeration(tagResultStatus, resultStatusSuccess),
+					structure(tagResponsePayload),
+				),
+			),
+		},
+		"operation failed": {
+			resp: structure(tagResponseMessage,
+				structure(tagBatchItem,
+					enumeration(tagOperation, operationLocate),
+					enumeration(tagResultStatus, 1),
+					enumeration(tagResultReason, 0x01),
+					textString(tagResultMessage, "item not found"),
+				),
+			),
+			wantErr: true,
+		},
+		"unexpected operation": {
+			resp: structure(tagResponseMessage,
+				structure(tagBatchItem,
+					enumeration(tagOperation, operationEncrypt),
+					enumeration(tagResultStatus, resultStatusSuccess),
+					structure(tagResponsePayload),
+				),
+			),
+			wantErr: true,
+		},
+		"no batch item": {
+			resp:    structure(tagResponseMessage),
+			wantErr: true,
+		},
+		"no payload": {
+			resp: structure(tagResponseMessage,
+				structure(tagBatchItem,
+					enumeration(tagResultStatus, resultStatusSuccess),
+				),
+			),
+			wantErr: true,
+		},
+		"not a response": {
+			resp:    structure(tagRequestMessage),
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_, err := parseResponse(tc.resp, operationLocate)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+// stubKMIPServer is a stand-in for a KMIP server supporting Locate, Encrypt and Decrypt of AES keys.
+type stubKMIPServer struct {
+	listener net.Listener
+	keys     map[string][]byte // unique identifier -> key
+	names    map[string]string // name -> unique identifier
+	wg       sync.WaitGroup
+}
+
+func newStubKMIPServer(t *testing.T, pki testPKI, keys map[string][]byte) *stubKMIPServer {
+	t.Helper()
+	serverCert, err := tls.X509KeyPair(pki.serverCertPEM, pki.serverKeyPEM)
+	require.NoError(t, err)
+	clientCAs := x509.NewCertPool()
+	require.True(t, clientCAs.AppendCertsFromPEM(pki.caPEM))
+
+	listener, err := tls.Listen("tcp", "localhost:0", &tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+		ClientCAs:    clientCAs,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		MinVersion:   tls.VersionTLS12,
+	})
+	require.NoError(t, err)
+
+	s := &stubKMIPServer{
+		listener: listener,
+		keys:     map[string][]byte{},
+		names:    map[string]string{},
+	}
+	uid := 0
+	for name, key := range keys {
+		uid++
+		id := strconv.Itoa(uid)
+		s.keys[id] = key
+		s.names[name] = id
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			s.handle(conn)
+		}
+	}()
+	return s
+}
+
+func (s *stubKMIPServer) addr() string {
+	return s.listener.Addr().String()
+}
+
+func (s *stubKMIPServer) close() {
+	s.listener.Close()
+	s.wg.Wait()
+}
+
+func (s *stubKMIPServer) handle(conn net.Conn) {
+	defer conn.Close()
+
+	req, err := readMessage(conn)
+	if err != nil {
+		return
+	}
+	batchItem, _ := req.child(tagBatchItem)
+	operation, _ := batchItem.enumChild(tagOperation)
+	payload, _ := batchItem.child(tagRequestPayload)
+
+	respPayload, err := s.process(operation, payload)
+	batch := []item{enumeration(tagOperation, operation)}
+	if err != nil {
+		batch = append(batch,
+			enumeration(tagResultStatus, 1),
+			enumeration(tagResultReason, 0x01),
+			textString(tagResultMessage, err.Error()),
+		)
+	} else {
+		batch = append(batch,
+			enumeration(tagResultStatus, resultStatusSuccess),
+			structure(tagResponsePayload, respPayload...),
+		)
+	}
+
+	resp, err := structure(tagResponseMessage,
+		structure(tagResponseHeader,
+			structure(tagProtocolVersion,
+				integer(tagProtocolVersionMajor, protocolVersionMajor),
+				integer(tagProtocolVersionMinor, protocolVersionMinor),
+			),
+			integer(tagBatchCount, 1),
+		),
+		structure(tagBatchItem, batch...),
+	).marshal()
+	if err != nil {
+		return
+	}
+	_, _ = conn.Write(resp)
+}
+
+func (s *stubKMIPServer) process(operation uint32, payload item) ([]item, error) {
+	switch operation {
+	case operationLocate:
+		var name string
+		for _, attr := range payload.value.([]item) {
+			if attrName, _ := attr.textChild(tagAttributeName); attrName == "Name" {
+				value, _ := attr.child(tagAttributeValue)
+				name, _ = value.textChild(tagNameValue)
+			}
+		}
+		uid, ok := s.names[name]
+		if !ok {
+			return nil, nil
+		}
+		return []item{textString(tagUniqueIdentifier, uid)}, nil
+
+	case operationEncrypt:
+		gcm, err := s.cipher(payload)
+		if err != nil {
+			return nil, err
+		}
+		plaintext, err := payload.bytesChild(tagData)
+		if err != nil {
+			return nil, err
+		}
+		iv := make([]byte, gcm.NonceSize())
+		if _, err := rand.Read(iv); err != nil {
+			return nil, err
+		}
+		sealed := gcm.Seal(nil, iv, plaintext, nil)
+		split := len(sealed) - gcm.Overhead()
+		return []item{
+			byteString(tagData, sealed[:split]),
+			byteString(tagIVCounterNonce, iv),
+			byteString(tagAuthenticatedEncryptionTag, sealed[split:]),
+		}, nil
+
+	case operationDecrypt:
+		gcm, err := s.cipher(payload)
+		if err != nil {
+			return nil, err
+		}
+		ciphertext, err := payload.bytesChild(tagData)
+		if err != nil {
+			return nil, err
+		}
+		iv, err := payload.bytesChild(tagIVCounterNonce)
+		if err != nil {
+			return nil, err
+		}
+		authTag, err := payload.bytesChild(tagAuthenticatedEncryptionTag)
+		if err != nil {
+			return nil, err
+		}
+		plaintext, err := gcm.Open(nil, iv, append(ciphertext, authTag...), nil)
+		if err != nil {
+			return nil, err
+		}
+		return []item{byteString(tagData, plaintext)}, nil
+
+	default:
+		return nil, errors.New("operation not supported")
+	}
+}
+
+func (s *stubKMIPServer) cipher(payload item) (cipher.AEAD, error) {
+	uid, err := payload.textChild(tagUniqueIdentifier)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := s.keys[uid]
+	if !ok {
+		return nil, errors.New("item not found")
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+type testPKI struct {
+	caPEM         []byte
+	serverCertPEM []byte
+	serverKeyPEM  []byte
+	clientCertPEM []byte
+	clientKeyPEM  []byte
+}
+
+func newTestPKI(t *testing.T) testPKI {
+	t.Helper()
+	require := require.New(t)
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(err)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	require.NoError(err)
+	caCert, err := x509.ParseCertificate(caDER)
+	require.NoError(err)
+
+	issue := func(serial int64, extKeyUsage x509.ExtKeyUsage) ([]byte, []byte) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(err)
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			Subject:      pkix.Name{CommonName: "localhost"},
+			DNSNames:     []string{"localhost"},
+			IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{extKeyUsage},
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+		require.NoError(err)
+		keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+		require.NoError(err)
+		return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+			pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+	}
+
+	serverCert, serverKey := issue(2, x509.ExtKeyUsageServerAuth)
+	clientCert, clientKey := issue(3, x509.ExtKeyUsageClientAuth)
+	return testPKI{
+		caPEM:         pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER}),
+		serverCertPEM: serverCert,
+		serverKeyPEM:  serverKey,
+		clientCertPEM: clientCert,
+		clientKeyPEM:  clientKey,
+	}
+}
+
+func make32(b byte) []byte {
+	key := make([]byte, 32)
+	for i := range key {
+		key[i] = b
+	}
+	return key
+}
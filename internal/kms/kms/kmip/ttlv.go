@@ -0,0 +1,308 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package kmip
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// maxMessageSize limits the size of KMIP messages read from the server.
+const maxMessageSize = 1 << 20
+
+// ttlvHeaderSize is the size of the tag, type and length fields of a TTLV item.
+const ttlvHeaderSize = 8
+
+// tag identifies a TTLV item, see KMIP 1.4 specification section 9.1.3.1.
+type tag uint32
+
+// Tags used by the client.
+const (
+	tagAttribute                  tag = 0x420008
+	tagAttributeName              tag = 0x42000A
+	tagAttributeValue             tag = 0x42000B
+	tagBatchCount                 tag = 0x42000D
+	tagBatchItem                  tag = 0x42000F
+	tagBlockCipherMode            tag = 0x420011
+	tagCryptographicAlgorithm     tag = 0x420028
+	tagCryptographicParameters    tag = 0x42002B
+	tagIVCounterNonce             tag = 0x42003D
+	tagName                       tag = 0x420053
+	tagNameType                   tag = 0x420054
+	tagNameValue                  tag = 0x420055
+	tagObjectType                 tag = 0x420057
+	tagOperation                  tag = 0x42005C
+	tagProtocolVersion            tag = 0x420069
+	tagProtocolVersionMajor       tag = 0x42006A
+	tagProtocolVersionMinor       tag = 0x42006B
+	tagRequestHeader              tag = 0x420077
+	tagRequestMessage             tag = 0x420078
+	tagRequestPayload             tag = 0x420079
+	tagResponseHeader             tag = 0x42007A
+	tagResponseMessage            tag = 0x42007B
+	tagResponsePayload            tag = 0x42007C
+	tagResultMessage              tag = 0x42007D
+	tagResultReason               tag = 0x42007E
+	tagResultStatus               tag = 0x42007F
+	tagUniqueIdentifier           tag = 0x420094
+	tagData                       tag = 0x4200C2
+	tagRandomIV                   tag = 0x4200C5
+	tagTagLength                  tag = 0x4200CE
+	tagAuthenticatedEncryptionTag tag = 0x4200FF
+)
+
+// Enumeration values used by the client.
+const (
+	operationLocate  uint32 = 0x08
+	operationEncrypt uint32 = 0x1F
+	operationDecrypt uint32 = 0x20
+
+	objectTypeSymmetricKey uint32 = 0x02
+
+	nameTypeUninterpretedTextString uint32 = 0x01
+
+	cryptographicAlgorithmAES uint32 = 0x03
+
+	blockCipherModeGCM uint32 = 0x09
+
+	resultStatusSuccess uint32 = 0x00
+)
+
+// itemType is the encoding type of a TTLV item.
+type itemType byte
+
+const (
+	typeStructure   itemType = 0x01
+	typeInteger     itemType = 0x02
+	typeLongInteger itemType = 0x03
+	typeEnumeration itemType = 0x05
+	typeBoolean     itemType = 0x06
+	typeTextString  itemType = 0x07
+	typeByteString  itemType = 0x08
+	typeDateTime    itemType = 0x09
+)
+
+// item is a single TTLV encoded value.
+// Depending on typ, value holds []item, int32, int64, uint32, bool, string or []byte.
+type item struct {
+	tag   tag
+	typ   itemType
+	value any
+}
+
+func structure(t tag, children ...item) item {
+	return item{tag: t, typ: typeStructure, value: children}
+}
+
+func integer(t tag, v int32) item {
+	return item{tag: t, typ: typeInteger, value: v}
+}
+
+func enumeration(t tag, v uint32) item {
+	return item{tag: t, typ: typeEnumeration, value: v}
+}
+
+func boolean(t tag, v bool) item {
+	return item{tag: t, typ: typeBoolean, value: v}
+}
+
+func textString(t tag, v string) item {
+	return item{tag: t, typ: typeTextString, value: v}
+}
+
+func byteString(t tag, v []byte) item {
+	return item{tag: t, typ: typeByteString, value: v}
+}
+
+// child returns the first direct child of a structure with the given tag.
+func (i item) child(t tag) (item, bool) {
+	children, ok := i.value.([]item)
+	if !ok {
+		return item{}, false
+	}
+	for _, c := range children {
+		if c.tag == t {
+			return c, true
+		}
+	}
+	return item{}, false
+}
+
+// bytesChild returns the value of the byte string child with the given tag.
+func (i item) bytesChild(t tag) ([]byte, error) {
+	c, ok := i.child(t)
+	if !ok {
+		return nil, fmt.Errorf("missing item with tag 0x%06x", uint32(t))
+	}
+	v, ok := c.value.([]byte)
+	if !ok {
+		return nil, fmt.Errorf("item with tag 0x%06x is not a byte string", uint32(t))
+	}
+	return v, nil
+}
+
+// textChild returns the value of the text string child with the given tag.
+func (i item) textChild(t tag) (string, error) {
+	c, ok := i.child(t)
+	if !ok {
+		return "", fmt.Errorf("missing item with tag 0x%06x", uint32(t))
+	}
+	v, ok := c.value.(string)
+	if !ok {
+		return "", fmt.Errorf("item with tag 0x%06x is not a text string", uint32(t))
+	}
+	return v, nil
+}
+
+// enumChild returns the value of the enumeration child with the given tag.
+func (i item) enumChild(t tag) (uint32, error) {
+	c, ok := i.child(t)
+	if !ok {
+		return 0, fmt.Errorf("missing item with tag 0x%06x", uint32(t))
+	}
+	v, ok := c.value.(uint32)
+	if !ok {
+		return 0, fmt.Errorf("item with tag 0x%06x is not an enumeration", uint32(t))
+	}
+	return v, nil
+}
+
+// marshal encodes the item in TTLV format.
+func (i item) marshal() ([]byte, error) {
+	var value []byte
+	switch v := i.value.(type) {
+	case []item:
+		for _, c := range v {
+			encoded, err := c.marshal()
+			if err != nil {
+				return nil, err
+			}
+			value = append(value, encoded...)
+		}
+	case int32:
+		value = binary.BigEndian.AppendUint32(nil, uint32(v))
+	case uint32:
+		value = binary.BigEndian.AppendUint32(nil, v)
+	case int64:
+		value = binary.BigEndian.AppendUint64(nil, uint64(v))
+	case bool:
+		var b uint64
+		if v {
+			b = 1
+		}
+		value = binary.BigEndian.AppendUint64(nil, b)
+	case string:
+		value = []byte(v)
+	case []byte:
+		value = v
+	default:
+		return nil, fmt.Errorf("unsupported value type %T for tag 0x%06x", i.value, uint32(i.tag))
+	}
+
+	out := make([]byte, ttlvHeaderSize, ttlvHeaderSize+len(value)+7)
+	out[0] = byte(i.tag >> 16)
+	out[1] = byte(i.tag >> 8)
+	out[2] = byte(i.tag)
+	out[3] = byte(i.typ)
+	binary.BigEndian.PutUint32(out[4:8], uint32(len(value)))
+	out = append(out, value...)
+
+	// values are padded to a multiple of eight bytes
+	if pad := len(value) % 8; pad != 0 {
+		out = append(out, make([]byte, 8-pad)...)
+	}
+	return out, nil
+}
+
+// unmarshal decodes a single TTLV item from data and returns the number of bytes consumed.
+func unmarshal(data []byte) (item, int, error) {
+	if len(data) < ttlvHeaderSize {
+		return item{}, 0, errors.New("TTLV item too short")
+	}
+	t := tag(uint32(data[0])<<16 | uint32(data[1])<<8 | uint32(data[2]))
+	typ := itemType(data[3])
+	length := int(binary.BigEndian.Uint32(data[4:8]))
+
+	padded := length
+	if pad := length % 8; pad != 0 {
+		padded += 8 - pad
+	}
+	if len(data) < ttlvHeaderSize+padded {
+		return item{}, 0, fmt.Errorf("TTLV item with tag 0x%06x exceeds message length", uint32(t))
+	}
+	raw := data[ttlvHeaderSize : ttlvHeaderSize+length]
+
+	var value any
+	switch typ {
+	case typeStructure:
+		var children []item
+		for len(raw) > 0 {
+			c, n, err := unmarshal(raw)
+			if err != nil {
+				return item{}, 0, err
+			}
+			children = append(children, c)
+			raw = raw[n:]
+		}
+		value = children
+	case typeInteger:
+		if length != 4 {
+			return item{}, 0, fmt.Errorf("invalid integer length %d", length)
+		}
+		value = int32(binary.BigEndian.Uint32(raw))
+	case typeEnumeration:
+		if length != 4 {
+			return item{}, 0, fmt.Errorf("invalid enumeration length %d", length)
+		}
+		value = binary.BigEndian.Uint32(raw)
+	case typeLongInteger, typeDateTime:
+		if length != 8 {
+			return item{}, 0, fmt.Errorf("invalid long integer length %d", length)
+		}
+		value = int64(binary.BigEndian.Uint64(raw))
+	case typeBoolean:
+		if length != 8 {
+			return item{}, 0, fmt.Errorf("invalid boolean length %d", length)
+		}
+		value = binary.BigEndian.Uint64(raw) != 0
+	case typeTextString:
+		value = string(raw)
+	case typeByteString:
+		value = append([]byte(nil), raw...)
+	default:
+		// unknown types are kept as raw bytes, since the client does not need to interpret them
+		value = append([]byte(nil), raw...)
+	}
+
+	return item{tag: t, typ: typ, value: value}, ttlvHeaderSize + padded, nil
+}
+
+// readMessage reads a single TTLV encoded message from r.
+func readMessage(r io.Reader) (item, error) {
+	header := make([]byte, ttlvHeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return item{}, fmt.Errorf("reading message header: %w", err)
+	}
+	if itemType(header[3]) != typeStructure {
+		return item{}, errors.New("message is not a structure")
+	}
+	length := binary.BigEndian.Uint32(header[4:8])
+	if length > maxMessageSize {
+		return item{}, fmt.Errorf("message size %d exceeds limit", length)
+	}
+
+	msg := make([]byte, ttlvHeaderSize+int(length))
+	copy(msg, header)
+	if _, err := io.ReadFull(r, msg[ttlvHeaderSize:]); err != nil {
+		return item{}, fmt.Errorf("reading message body: %w", err)
+	}
+
+	i, _, err := unmarshal(msg)
+	return i, err
+}
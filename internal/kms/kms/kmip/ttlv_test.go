@@ -0,0 +1,110 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package kmip
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarshal(t *testing.T) {
+	// test vectors from the KMIP 1.4 specification, section 9.1.2
+	testCases := map[string]struct {
+		item item
+		want string
+	}{
+		"integer": {
+			item: integer(tag(0x420020), 8),
+			want: "42002002000000040000000800000000",
+		},
+		"enumeration": {
+			item: enumeration(tag(0x420020), 255),
+			want: "4200200500000004000000ff00000000",
+		},
+		"boolean": {
+			item: boolean(tag(0x420020), true),
+			want: "42002006000000080000000000000001",
+		},
+		"text string": {
+			item: textString(tag(0x420020), "Hello World"),
+			want: "420020070000000b48656c6c6f20576f726c640000000000",
+		},
+		"byte string": {
+			item: byteString(tag(0x420020), []byte{0x01, 0x02, 0x03}),
+			want: "42002008000000030102030000000000",
+		},
+		"structure": {
+			item: structure(tag(0x420020),
+				enumeration(tag(0x420004), 254),
+				integer(tag(0x420005), 255),
+			),
+			want: "42002001000000204200040500000004000000fe000000004200050200000004000000ff00000000",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			encoded, err := tc.item.marshal()
+			require.NoError(err)
+			assert.Equal(tc.want, hex.EncodeToString(encoded))
+
+			decoded, n, err := unmarshal(encoded)
+			require.NoError(err)
+			assert.Equal(len(encoded), n)
+			assert.Equal(tc.item, decoded)
+		})
+	}
+}
+
+func TestUnmarshalInvalid(t *testing.T) {
+	testCases := map[string]string{
+		"too short":               "42002002000000",
+		"length exceeds message":  "4200200700000010414243",
+		"invalid integer length":  "42002002000000080000000000000008",
+		"invalid child structure": "420020010000000842002002000000",
+	}
+
+	for name, data := range testCases {
+		t.Run(name, func(t *testing.T) {
+			raw, err := hex.DecodeString(data)
+			require.NoError(t, err)
+			_, _, err = unmarshal(raw)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestReadMessage(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	msg := structure(tagResponseMessage,
+		structure(tagBatchItem,
+			enumeration(tagResultStatus, resultStatusSuccess),
+			structure(tagResponsePayload, textString(tagUniqueIdentifier, "1")),
+		),
+	)
+	encoded, err := msg.marshal()
+	require.NoError(err)
+
+	// trailing data must not be consumed
+	r := bytes.NewReader(append(encoded, 0xff))
+	decoded, err := readMessage(r)
+	require.NoError(err)
+	assert.Equal(msg, decoded)
+	assert.Equal(1, r.Len())
+
+	_, err = readMessage(bytes.NewReader(encoded[:len(encoded)-1]))
+	assert.Error(err)
+}
@@ -20,6 +20,13 @@ type CloudKMS interface {
 	Close()
 }
 
+// Rewrapper is implemented by KMS backends which persist DEKs wrapped with a KEK.
+type Rewrapper interface {
+	// RewrapDEK decrypts the stored DEK for dekID and encrypts it again using the current version of the KEK.
+	// This allows retiring old KEK versions after rotating the KEK in the KMS.
+	RewrapDEK(ctx context.Context, dekID string) error
+}
+
 // Storage provides an abstract interface for the storage backend used for DEKs.
 type Storage interface {
 	// Get returns a DEK from the storage by key ID. If the DEK does not exist, returns storage.ErrDEKUnset.
@@ -0,0 +1,123 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+/*
+Package vault implements a KMS backend for the Transit secrets engine of HashiCorp Vault and OpenBao.
+
+The token or AppRole used to authenticate with Vault requires the following capabilities:
+  - update on <mount>/encrypt/<keyName>
+  - update on <mount>/decrypt/<keyName>
+*/
+package vault
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	kmsInterface "github.com/edgelesssys/constellation/v2/internal/kms/kms"
+	"github.com/edgelesssys/constellation/v2/internal/kms/kms/internal"
+	"github.com/edgelesssys/constellation/v2/internal/kms/uri"
+	"github.com/edgelesssys/constellation/v2/internal/kms/vaultclient"
+	wrapping "github.com/hashicorp/go-kms-wrapping/v2"
+)
+
+// KMSClient implements the CloudKMS interface for Vault Transit.
+type KMSClient struct {
+	kms *internal.KMSClient
+}
+
+// New initializes a KMS client for Vault Transit.
+func New(ctx context.Context, store kmsInterface.Storage, cfg uri.VaultConfig) (*KMSClient, error) {
+	if store == nil {
+		return nil, errors.New("no storage backend provided for KMS")
+	}
+
+	client, err := vaultclient.New(ctx, cfg.Address, cfg.Auth)
+	if err != nil {
+		return nil, fmt.Errorf("creating Vault client: %w", err)
+	}
+
+	return &KMSClient{
+		kms: &internal.KMSClient{
+			Storage: store,
+			Wrapper: &transitWrapper{
+				client:  client,
+				mount:   cfg.Mount,
+				keyName: cfg.KeyName,
+			},
+		},
+	}, nil
+}
+
+// GetDEK fetches an encrypted Data Encryption Key from storage and decrypts it using a KEK stored in Vault Transit.
+func (c *KMSClient) GetDEK(ctx context.Context, keyID string, dekSize int) ([]byte, error) {
+	return c.kms.GetDEK(ctx, keyID, dekSize)
+}
+
+// RewrapDEK re-encrypts a stored Data Encryption Key using the current version of the KEK in Vault.
+func (c *KMSClient) RewrapDEK(ctx context.Context, keyID string) error {
+	return c.kms.RewrapDEK(ctx, keyID)
+}
+
+// Close is a no-op for Vault.
+func (c *KMSClient) Close() {}
+
+type vaultAPI interface {
+	Write(ctx context.Context, path string, body, v any) error
+}
+
+// transitWrapper wraps and unwraps DEKs using the encrypt and decrypt endpoints of Vault Transit.
+type transitWrapper struct {
+	client  vaultAPI
+	mount   string
+	keyName string
+}
+
+// Encrypt encrypts plaintext using the Transit key.
+func (w *transitWrapper) Encrypt(ctx context.Context, plaintext []byte, _ ...wrapping.Option) (*wrapping.BlobInfo, error) {
+	var resp struct {
+		Ciphertext string `json:"ciphertext"`
+	}
+	if err := w.client.Write(ctx, w.mount+"/encrypt/"+w.keyName, map[string]string{
+		"plaintext": base64.StdEncoding.EncodeToString(plaintext),
+	}, &resp); err != nil {
+		return nil, fmt.Errorf("encrypting with Vault Transit: %w", err)
+	}
+	if resp.Ciphertext == "" {
+		return nil, errors.New("Vault Transit returned empty ciphertext")
+	}
+
+	return &wrapping.BlobInfo{
+		Ciphertext: []byte(resp.Ciphertext),
+		KeyInfo: &wrapping.KeyInfo{
+			KeyId: w.keyName,
+		},
+	}, nil
+}
+
+// Decrypt decrypts a ciphertext created by Encrypt using the Transit key.
+func (w *transitWrapper) Decrypt(ctx context.Context, in *wrapping.BlobInfo, _ ...wrapping.Option) ([]byte, error) {
+	if in == nil {
+		return nil, errors.New("no ciphertext to decrypt")
+	}
+
+	var resp struct {
+		Plaintext string `json:"plaintext"`
+	}
+	if err := w.client.Write(ctx, w.mount+"/decrypt/"+w.keyName, map[string]string{
+		"ciphertext": string(in.Ciphertext),
+	}, &resp); err != nil {
+		return nil, fmt.Errorf("decrypting with Vault Transit: %w", err)
+	}
+
+	plaintext, err := base64.StdEncoding.DecodeString(resp.Plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("decoding plaintext: %w", err)
+	}
+	return plaintext, nil
+}
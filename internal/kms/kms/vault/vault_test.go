@@ -0,0 +1,119 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package vault
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/edgelesssys/constellation/v2/internal/kms/storage/memfs"
+	"github.com/edgelesssys/constellation/v2/internal/kms/uri"
+	wrapping "github.com/hashicorp/go-kms-wrapping/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+)
+
+func TestMain(m *testing.M) {
+	goleak.VerifyTestMain(m, goleak.IgnoreAnyFunction("github.com/bazelbuild/rules_go/go/tools/bzltestutil.RegisterTimeoutHandler.func1"))
+}
+
+// stubTransit is a stand-in for the Transit secrets engine of a Vault dev server.
+// Ciphertexts are the base64 encoded plaintext with the key name as prefix.
+type stubTransit struct {
+	token   string
+	keyName string
+}
+
+func (s *stubTransit) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("X-Vault-Token") != s.token {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `{"errors":["permission denied"]}`)
+		return
+	}
+
+	var req map[string]string
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	prefix := "vault:v1:" + s.keyName + ":"
+	switch r.URL.Path {
+	case "/v1/transit/encrypt/" + s.keyName:
+		fmt.Fprintf(w, `{"data":{"ciphertext":%q}}`, prefix+req["plaintext"])
+	case "/v1/transit/decrypt/" + s.keyName:
+		plaintext, ok := strings.CutPrefix(req["ciphertext"], prefix)
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"errors":["cipher: message authentication failed"]}`)
+			return
+		}
+		fmt.Fprintf(w, `{"data":{"plaintext":%q}}`, plaintext)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"errors":[]}`)
+	}
+}
+
+func TestGetDEK(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	server := httptest.NewServer(&stubTransit{token: "token", keyName: "kek"})
+	defer server.Close()
+
+	store := memfs.New()
+	cfg := uri.VaultConfig{
+		Address: server.URL,
+		Mount:   "transit",
+		KeyName: "kek",
+		Auth:    uri.VaultAuth{Token: "token"},
+	}
+	client, err := New(t.Context(), store, cfg)
+	require.NoError(err)
+	defer client.Close()
+
+	dek, err := client.GetDEK(t.Context(), "volume-01", 32)
+	require.NoError(err)
+	assert.Len(dek, 32)
+
+	wrapped, err := store.Get(t.Context(), "volume-01")
+	require.NoError(err)
+	var blob wrapping.BlobInfo
+	require.NoError(json.Unmarshal(wrapped, &blob))
+	assert.Equal("vault:v1:kek:"+base64.StdEncoding.EncodeToString(dek), string(blob.Ciphertext))
+
+	dekAgain, err := client.GetDEK(t.Context(), "volume-01", 32)
+	require.NoError(err)
+	assert.Equal(dek, dekAgain)
+
+	// a DEK wrapped by a different key can not be unwrapped
+	cfg.KeyName = "other-kek"
+	otherClient, err := New(t.Context(), store, cfg)
+	require.NoError(err)
+	_, err = otherClient.GetDEK(t.Context(), "volume-01", 32)
+	assert.Error(err)
+
+	// invalid credentials are rejected
+	cfg.KeyName = "kek"
+	cfg.Auth.Token = "invalid"
+	unauthorizedClient, err := New(t.Context(), store, cfg)
+	require.NoError(err)
+	_, err = unauthorizedClient.GetDEK(t.Context(), "volume-01", 32)
+	assert.Error(err)
+}
+
+func TestNewNoStore(t *testing.T) {
+	_, err := New(t.Context(), nil, uri.VaultConfig{Auth: uri.VaultAuth{Token: "token"}})
+	assert.Error(t, err)
+}
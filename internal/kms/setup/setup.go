@@ -20,14 +20,18 @@ import (
 	"fmt"
 	"net/url"
 
+	"github.com/edgelesssys/constellation/v2/internal/crypto"
 	"github.com/edgelesssys/constellation/v2/internal/kms/kms"
 	"github.com/edgelesssys/constellation/v2/internal/kms/kms/aws"
 	"github.com/edgelesssys/constellation/v2/internal/kms/kms/azure"
 	"github.com/edgelesssys/constellation/v2/internal/kms/kms/cluster"
 	"github.com/edgelesssys/constellation/v2/internal/kms/kms/gcp"
+	"github.com/edgelesssys/constellation/v2/internal/kms/kms/kmip"
+	"github.com/edgelesssys/constellation/v2/internal/kms/kms/vault"
 	"github.com/edgelesssys/constellation/v2/internal/kms/storage/awss3"
 	"github.com/edgelesssys/constellation/v2/internal/kms/storage/azureblob"
 	"github.com/edgelesssys/constellation/v2/internal/kms/storage/gcs"
+	"github.com/edgelesssys/constellation/v2/internal/kms/storage/vaultkv"
 	"github.com/edgelesssys/constellation/v2/internal/kms/uri"
 )
 
@@ -48,6 +52,17 @@ func KMS(ctx context.Context, storageURI, kmsURI string) (kms.CloudKMS, error) {
 	return getKMS(ctx, kmsURI, store)
 }
 
+// MeasurementSecret returns the measurement secret of the cluster.
+// A measurement secret pinned in a cluster KMS URI takes precedence,
+// since the measurement secret must not change when the master secret is rotated.
+// Otherwise, the measurement secret is derived using the given KMS.
+func MeasurementSecret(ctx context.Context, kmsURI string, cloudKms kms.CloudKMS) ([]byte, error) {
+	if masterSecret, err := uri.DecodeMasterSecretFromURI(kmsURI); err == nil && len(masterSecret.MeasurementSecret) > 0 {
+		return masterSecret.MeasurementSecret, nil
+	}
+	return cloudKms.GetDEK(ctx, crypto.DEKPrefix+crypto.MeasurementSecretKeyID, crypto.DerivedKeyLengthDefault)
+}
+
 // getStore creates a key store depending on the given parameters.
 func getStore(ctx context.Context, storageURI string) (kms.Storage, error) {
 	url, err := url.Parse(storageURI)
@@ -80,6 +95,13 @@ func getStore(ctx context.Context, storageURI string) (kms.Storage, error) {
 		}
 		return gcs.New(ctx, cfg)
 
+	case "vault":
+		cfg, err := uri.DecodeVaultKVConfigFromURI(storageURI)
+		if err != nil {
+			return nil, err
+		}
+		return vaultkv.New(ctx, cfg)
+
 	case "no-store":
 		return nil, nil
 
@@ -120,6 +142,20 @@ func getKMS(ctx context.Context, kmsURI string, store kms.Storage) (kms.CloudKMS
 		}
 		return gcp.New(ctx, store, cfg)
 
+	case "kmip":
+		cfg, err := uri.DecodeKMIPConfigFromURI(kmsURI)
+		if err != nil {
+			return nil, fmt.Errorf("invalid KMIP URI: %w", err)
+		}
+		return kmip.New(ctx, store, cfg)
+
+	case "vault":
+		cfg, err := uri.DecodeVaultConfigFromURI(kmsURI)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Vault URI: %w", err)
+		}
+		return vault.New(ctx, store, cfg)
+
 	case "cluster-kms":
 		cfg, err := uri.DecodeMasterSecretFromURI(kmsURI)
 		if err != nil {
@@ -9,8 +9,10 @@ package setup
 import (
 	"testing"
 
+	"github.com/edgelesssys/constellation/v2/internal/crypto"
 	"github.com/edgelesssys/constellation/v2/internal/kms/uri"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/goleak"
 )
 
@@ -33,4 +35,48 @@ func TestSetUpKMS(t *testing.T) {
 	kms, err = KMS(t.Context(), "storage://no-store", masterSecret.EncodeToURI())
 	assert.NoError(err)
 	assert.NotNil(kms)
+
+	vaultCfg := uri.VaultConfig{
+		Address: "http://127.0.0.1:8200",
+		Mount:   "transit",
+		KeyName: "key",
+		Auth:    uri.VaultAuth{Token: "token"},
+	}
+	kms, err = KMS(t.Context(), "storage://no-store", vaultCfg.EncodeToURI())
+	assert.Error(err)
+	assert.Nil(kms)
+}
+
+func TestMeasurementSecret(t *testing.T) {
+	masterSecret := uri.MasterSecret{Key: []byte("key"), Salt: []byte("salt")}
+	derived, err := crypto.DeriveKey(masterSecret.Key, masterSecret.Salt, []byte(crypto.DEKPrefix+crypto.MeasurementSecretKeyID), crypto.DerivedKeyLengthDefault)
+	require.NoError(t, err)
+
+	testCases := map[string]struct {
+		masterSecret uri.MasterSecret
+		want         []byte
+	}{
+		"derived from master secret": {
+			masterSecret: masterSecret,
+			want:         derived,
+		},
+		"pinned measurement secret": {
+			masterSecret: uri.MasterSecret{Key: []byte("other key"), Salt: []byte("other salt"), MeasurementSecret: []byte("pinned")},
+			want:         []byte("pinned"),
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			kms, err := KMS(t.Context(), uri.NoStoreURI, tc.masterSecret.EncodeToURI())
+			require.NoError(err)
+
+			secret, err := MeasurementSecret(t.Context(), tc.masterSecret.EncodeToURI(), kms)
+			require.NoError(err)
+			assert.Equal(tc.want, secret)
+		})
+	}
 }
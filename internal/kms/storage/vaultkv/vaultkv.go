@@ -0,0 +1,92 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+/*
+Package vaultkv implements a storage backend for the KMS using the KV version 2 secrets engine of HashiCorp Vault and OpenBao.
+
+The token or AppRole used to authenticate with Vault requires the following capabilities:
+  - create, read and update on <mount>/data/<path>/*
+*/
+package vaultkv
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"path"
+
+	"github.com/edgelesssys/constellation/v2/internal/kms/storage"
+	"github.com/edgelesssys/constellation/v2/internal/kms/uri"
+	"github.com/edgelesssys/constellation/v2/internal/kms/vaultclient"
+)
+
+type vaultAPI interface {
+	Read(ctx context.Context, path string, v any) error
+	Write(ctx context.Context, path string, body, v any) error
+}
+
+// Storage is an implementation of the Storage interface, storing keys in Vault KV version 2.
+type Storage struct {
+	client vaultAPI
+	mount  string
+	path   string
+}
+
+// New creates a Storage client for Vault KV version 2 using the provided config.
+func New(ctx context.Context, cfg uri.VaultKVConfig) (*Storage, error) {
+	client, err := vaultclient.New(ctx, cfg.Address, cfg.Auth)
+	if err != nil {
+		return nil, fmt.Errorf("creating Vault client: %w", err)
+	}
+	return &Storage{
+		client: client,
+		mount:  cfg.Mount,
+		path:   cfg.Path,
+	}, nil
+}
+
+// Get returns a DEK from Vault KV by key ID.
+func (s *Storage) Get(ctx context.Context, keyID string) ([]byte, error) {
+	var secret struct {
+		Data struct {
+			DEK string `json:"dek"`
+		} `json:"data"`
+	}
+	if err := s.client.Read(ctx, s.secretPath(keyID), &secret); err != nil {
+		if errors.Is(err, vaultclient.ErrNotFound) {
+			return nil, storage.ErrDEKUnset
+		}
+		return nil, fmt.Errorf("reading DEK from Vault KV: %w", err)
+	}
+	// A deleted secret version is returned without data
+	if secret.Data.DEK == "" {
+		return nil, storage.ErrDEKUnset
+	}
+
+	dek, err := base64.StdEncoding.DecodeString(secret.Data.DEK)
+	if err != nil {
+		return nil, fmt.Errorf("decoding DEK: %w", err)
+	}
+	return dek, nil
+}
+
+// Put saves a DEK to Vault KV by key ID.
+func (s *Storage) Put(ctx context.Context, keyID string, data []byte) error {
+	body := map[string]any{
+		"data": map[string]string{
+			"dek": base64.StdEncoding.EncodeToString(data),
+		},
+	}
+	if err := s.client.Write(ctx, s.secretPath(keyID), body, nil); err != nil {
+		return fmt.Errorf("writing DEK to Vault KV: %w", err)
+	}
+	return nil
+}
+
+func (s *Storage) secretPath(keyID string) string {
+	return path.Join(s.mount, "data", s.path, keyID)
+}
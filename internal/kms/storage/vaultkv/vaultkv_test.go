@@ -0,0 +1,145 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package vaultkv
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/edgelesssys/constellation/v2/internal/kms/storage"
+	"github.com/edgelesssys/constellation/v2/internal/kms/vaultclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+)
+
+func TestMain(m *testing.M) {
+	goleak.VerifyTestMain(m, goleak.IgnoreAnyFunction("github.com/bazelbuild/rules_go/go/tools/bzltestutil.RegisterTimeoutHandler.func1"))
+}
+
+type stubVaultAPI struct {
+	readPath   string
+	readOutput string
+	readErr    error
+	writePath  string
+	writeBody  any
+	writeErr   error
+}
+
+func (s *stubVaultAPI) Read(_ context.Context, path string, v any) error {
+	s.readPath = path
+	if s.readErr != nil {
+		return s.readErr
+	}
+	return json.Unmarshal([]byte(s.readOutput), v)
+}
+
+func (s *stubVaultAPI) Write(_ context.Context, path string, body, _ any) error {
+	s.writePath = path
+	s.writeBody = body
+	return s.writeErr
+}
+
+func TestVaultKVGet(t *testing.T) {
+	testCases := map[string]struct {
+		client     *stubVaultAPI
+		wantDEK    []byte
+		unsetError bool
+		wantErr    bool
+	}{
+		"success": {
+			client:  &stubVaultAPI{readOutput: `{"data":{"dek":"dGVzdC1kYXRh"},"metadata":{"version":1}}`},
+			wantDEK: []byte("test-data"),
+		},
+		"secret not found": {
+			client:     &stubVaultAPI{readErr: vaultclient.ErrNotFound},
+			unsetError: true,
+			wantErr:    true,
+		},
+		"secret deleted": {
+			client:     &stubVaultAPI{readOutput: `{"data":null,"metadata":{"version":1,"deletion_time":"2024-01-01T00:00:00Z"}}`},
+			unsetError: true,
+			wantErr:    true,
+		},
+		"read fails": {
+			client:  &stubVaultAPI{readErr: errors.New("failed")},
+			wantErr: true,
+		},
+		"invalid encoding": {
+			client:  &stubVaultAPI{readOutput: `{"data":{"dek":"not base64!"}}`},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			client := &Storage{
+				client: tc.client,
+				mount:  "secret",
+				path:   "constellation",
+			}
+
+			dek, err := client.Get(t.Context(), "volume-01")
+			assert.Equal("secret/data/constellation/volume-01", tc.client.readPath)
+			if tc.wantErr {
+				assert.Error(err)
+				if tc.unsetError {
+					assert.ErrorIs(err, storage.ErrDEKUnset)
+				} else {
+					assert.NotErrorIs(err, storage.ErrDEKUnset)
+				}
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(tc.wantDEK, dek)
+		})
+	}
+}
+
+func TestVaultKVPut(t *testing.T) {
+	testCases := map[string]struct {
+		client  *stubVaultAPI
+		wantErr bool
+	}{
+		"success": {
+			client: &stubVaultAPI{},
+		},
+		"write fails": {
+			client:  &stubVaultAPI{writeErr: errors.New("failed")},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			client := &Storage{
+				client: tc.client,
+				mount:  "secret",
+				path:   "constellation",
+			}
+
+			err := client.Put(t.Context(), "volume-01", []byte("test-data"))
+			if tc.wantErr {
+				assert.Error(err)
+				return
+			}
+			require.NoError(err)
+			assert.Equal("secret/data/constellation/volume-01", tc.client.writePath)
+
+			body, err := json.Marshal(tc.client.writeBody)
+			require.NoError(err)
+			assert.JSONEq(`{"data":{"dek":"dGVzdC1kYXRh"}}`, string(body))
+		})
+	}
+}
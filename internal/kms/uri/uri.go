@@ -51,6 +51,9 @@ const (
 	awsS3URI      = "storage://aws?bucket=%s&region=%s&accessKeyID=%s&accessKey=%s"
 	azureBlobURI  = "storage://azure?account=%s&container=%s&tenantID=%s&clientID=%s&clientSecret=%s"
 	gcpStorageURI = "storage://gcp?projectID=%s&bucket=%s&credentialsPath=%s"
+	kmipKMSURI    = "kms://kmip?endpoint=%s&keyName=%s&caCert=%s&clientCert=%s&clientKey=%s"
+	vaultKMSURI   = "kms://vault?address=%s&mount=%s&keyName=%s&%s"
+	vaultKVURI    = "storage://vault?address=%s&mount=%s&path=%s&%s"
 	// NoStoreURI is a URI that indicates that no storage is used.
 	// Should only be used with cluster KMS.
 	NoStoreURI = "storage://no-store"
@@ -62,15 +65,22 @@ type MasterSecret struct {
 	Key []byte `json:"key"`
 	// Salt is the salt used in HKDF to derive keys.
 	Salt []byte `json:"salt"`
+	// MeasurementSecret is the measurement secret pinned by a master secret rotation.
+	// If empty, the measurement secret is derived from Key and Salt.
+	MeasurementSecret []byte `json:"measurementSecret,omitempty"`
 }
 
 // EncodeToURI returns a URI encoding the master secret.
 func (m MasterSecret) EncodeToURI() string {
-	return fmt.Sprintf(
+	kmsURI := fmt.Sprintf(
 		clusterKMSURI,
 		base64.URLEncoding.EncodeToString(m.Key),
 		base64.URLEncoding.EncodeToString(m.Salt),
 	)
+	if len(m.MeasurementSecret) > 0 {
+		kmsURI += "&measurementSecret=" + base64.URLEncoding.EncodeToString(m.MeasurementSecret)
+	}
+	return kmsURI
 }
 
 // DecodeMasterSecretFromURI decodes a master secret from a URI.
@@ -96,9 +106,17 @@ func DecodeMasterSecretFromURI(uri string) (MasterSecret, error) {
 	if err != nil {
 		return MasterSecret{}, err
 	}
+	var measurementSecret []byte
+	if q.Has("measurementSecret") {
+		measurementSecret, err = getBase64QueryParameter(q, "measurementSecret")
+		if err != nil {
+			return MasterSecret{}, err
+		}
+	}
 	return MasterSecret{
-		Key:  key,
-		Salt: salt,
+		Key:               key,
+		Salt:              salt,
+		MeasurementSecret: measurementSecret,
 	}, nil
 }
 
@@ -505,6 +523,253 @@ func (g GoogleCloudStorageConfig) EncodeToURI() string {
 	)
 }
 
+// KMIPConfig is the configuration to use a KMIP compliant key management server or HSM as KMS.
+type KMIPConfig struct {
+	// Endpoint is the host and port of the KMIP server, e.g. kmip.example.com:5696.
+	Endpoint string
+	// KeyName is the name of the symmetric key on the KMIP server used as KEK.
+	KeyName string
+	// CACert is the PEM encoded CA certificate used to verify the KMIP server.
+	CACert []byte
+	// ClientCert is the PEM encoded client certificate used for mutual TLS authentication.
+	ClientCert []byte
+	// ClientKey is the PEM encoded private key of the client certificate.
+	ClientKey []byte
+}
+
+// DecodeKMIPConfigFromURI decodes a KMIP configuration from a URI.
+func DecodeKMIPConfigFromURI(uri string) (KMIPConfig, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return KMIPConfig{}, err
+	}
+
+	if u.Scheme != "kms" {
+		return KMIPConfig{}, fmt.Errorf("invalid scheme: %q", u.Scheme)
+	}
+	if u.Host != "kmip" {
+		return KMIPConfig{}, fmt.Errorf("invalid host: %q", u.Host)
+	}
+
+	q := u.Query()
+	endpoint, err := getQueryParameter(q, "endpoint")
+	if err != nil {
+		return KMIPConfig{}, err
+	}
+	keyName, err := getQueryParameter(q, "keyName")
+	if err != nil {
+		return KMIPConfig{}, err
+	}
+	caCert, err := getBase64QueryParameter(q, "caCert")
+	if err != nil {
+		return KMIPConfig{}, err
+	}
+	clientCert, err := getBase64QueryParameter(q, "clientCert")
+	if err != nil {
+		return KMIPConfig{}, err
+	}
+	clientKey, err := getBase64QueryParameter(q, "clientKey")
+	if err != nil {
+		return KMIPConfig{}, err
+	}
+
+	return KMIPConfig{
+		Endpoint:   endpoint,
+		KeyName:    keyName,
+		CACert:     caCert,
+		ClientCert: clientCert,
+		ClientKey:  clientKey,
+	}, nil
+}
+
+// EncodeToURI returns a URI encoding the KMIP configuration.
+func (k KMIPConfig) EncodeToURI() string {
+	return fmt.Sprintf(
+		kmipKMSURI,
+		url.QueryEscape(k.Endpoint),
+		url.QueryEscape(k.KeyName),
+		base64.URLEncoding.EncodeToString(k.CACert),
+		base64.URLEncoding.EncodeToString(k.ClientCert),
+		base64.URLEncoding.EncodeToString(k.ClientKey),
+	)
+}
+
+// VaultAuth is the configuration to authenticate with a HashiCorp Vault or OpenBao server.
+// Either Token, or RoleID and SecretID for AppRole authentication must be set.
+type VaultAuth struct {
+	// Token is a Vault token used for authentication.
+	Token string
+	// RoleID is the role ID used for AppRole authentication.
+	RoleID string
+	// SecretID is the secret ID used for AppRole authentication.
+	SecretID string
+}
+
+// encode returns the query parameters encoding the Vault authentication configuration.
+func (a VaultAuth) encode() string {
+	q := url.Values{}
+	if a.Token != "" {
+		q.Set("token", a.Token)
+	}
+	if a.RoleID != "" {
+		q.Set("roleID", a.RoleID)
+	}
+	if a.SecretID != "" {
+		q.Set("secretID", a.SecretID)
+	}
+	return q.Encode()
+}
+
+// decodeVaultAuth decodes the Vault authentication configuration from the query parameters.
+func decodeVaultAuth(q url.Values) (VaultAuth, error) {
+	if q.Get("token") != "" {
+		token, err := getQueryParameter(q, "token")
+		if err != nil {
+			return VaultAuth{}, err
+		}
+		return VaultAuth{Token: token}, nil
+	}
+
+	roleID, err := getQueryParameter(q, "roleID")
+	if err != nil {
+		return VaultAuth{}, fmt.Errorf("neither token nor AppRole credentials set: %w", err)
+	}
+	secretID, err := getQueryParameter(q, "secretID")
+	if err != nil {
+		return VaultAuth{}, err
+	}
+	return VaultAuth{
+		RoleID:   roleID,
+		SecretID: secretID,
+	}, nil
+}
+
+// VaultConfig is the configuration to use the Transit secrets engine of HashiCorp Vault or OpenBao as KMS.
+type VaultConfig struct {
+	// Address is the URL of the Vault server, e.g. https://vault.example.com:8200.
+	Address string
+	// Mount is the mount path of the Transit secrets engine.
+	Mount string
+	// KeyName is the name of the Transit key used as KEK.
+	KeyName string
+	// Auth is the configuration used to authenticate with the Vault server.
+	Auth VaultAuth
+}
+
+// DecodeVaultConfigFromURI decodes a Vault Transit configuration from a URI.
+func DecodeVaultConfigFromURI(uri string) (VaultConfig, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return VaultConfig{}, err
+	}
+
+	if u.Scheme != "kms" {
+		return VaultConfig{}, fmt.Errorf("invalid scheme: %q", u.Scheme)
+	}
+	if u.Host != "vault" {
+		return VaultConfig{}, fmt.Errorf("invalid host: %q", u.Host)
+	}
+
+	q := u.Query()
+	address, err := getQueryParameter(q, "address")
+	if err != nil {
+		return VaultConfig{}, err
+	}
+	mount, err := getQueryParameter(q, "mount")
+	if err != nil {
+		return VaultConfig{}, err
+	}
+	keyName, err := getQueryParameter(q, "keyName")
+	if err != nil {
+		return VaultConfig{}, err
+	}
+	auth, err := decodeVaultAuth(q)
+	if err != nil {
+		return VaultConfig{}, err
+	}
+
+	return VaultConfig{
+		Address: address,
+		Mount:   mount,
+		KeyName: keyName,
+		Auth:    auth,
+	}, nil
+}
+
+// EncodeToURI returns a URI encoding the Vault Transit configuration.
+func (v VaultConfig) EncodeToURI() string {
+	return fmt.Sprintf(
+		vaultKMSURI,
+		url.QueryEscape(v.Address),
+		url.QueryEscape(v.Mount),
+		url.QueryEscape(v.KeyName),
+		v.Auth.encode(),
+	)
+}
+
+// VaultKVConfig is the configuration to use the KV version 2 secrets engine of HashiCorp Vault or OpenBao as storage.
+type VaultKVConfig struct {
+	// Address is the URL of the Vault server, e.g. https://vault.example.com:8200.
+	Address string
+	// Mount is the mount path of the KV version 2 secrets engine.
+	Mount string
+	// Path is the path prefix under which DEKs are stored.
+	Path string
+	// Auth is the configuration used to authenticate with the Vault server.
+	Auth VaultAuth
+}
+
+// DecodeVaultKVConfigFromURI decodes a Vault KV configuration from a URI.
+func DecodeVaultKVConfigFromURI(uri string) (VaultKVConfig, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return VaultKVConfig{}, err
+	}
+
+	if u.Scheme != "storage" {
+		return VaultKVConfig{}, fmt.Errorf("invalid scheme: %q", u.Scheme)
+	}
+	if u.Host != "vault" {
+		return VaultKVConfig{}, fmt.Errorf("invalid host: %q", u.Host)
+	}
+
+	q := u.Query()
+	address, err := getQueryParameter(q, "address")
+	if err != nil {
+		return VaultKVConfig{}, err
+	}
+	mount, err := getQueryParameter(q, "mount")
+	if err != nil {
+		return VaultKVConfig{}, err
+	}
+	path, err := getQueryParameter(q, "path")
+	if err != nil {
+		return VaultKVConfig{}, err
+	}
+	auth, err := decodeVaultAuth(q)
+	if err != nil {
+		return VaultKVConfig{}, err
+	}
+
+	return VaultKVConfig{
+		Address: address,
+		Mount:   mount,
+		Path:    path,
+		Auth:    auth,
+	}, nil
+}
+
+// EncodeToURI returns a URI encoding the Vault KV configuration.
+func (v VaultKVConfig) EncodeToURI() string {
+	return fmt.Sprintf(
+		vaultKVURI,
+		url.QueryEscape(v.Address),
+		url.QueryEscape(v.Mount),
+		url.QueryEscape(v.Path),
+		v.Auth.encode(),
+	)
+}
+
 // getBase64QueryParameter returns the url-base64-decoded value for the given key from the query parameters.
 func getBase64QueryParameter(q url.Values, key string) ([]byte, error) {
 	value, err := getQueryParameter(q, key)
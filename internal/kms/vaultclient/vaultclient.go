@@ -0,0 +1,207 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+/*
+Package vaultclient implements a minimal client for the HTTP API of HashiCorp Vault and OpenBao.
+
+The client supports token and AppRole authentication.
+Tokens obtained through AppRole login are renewed by logging in again
+once the server rejects a request with 403 Forbidden.
+*/
+package vaultclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/edgelesssys/constellation/v2/internal/kms/uri"
+)
+
+// ErrNotFound is returned if the requested path does not exist on the Vault server.
+var ErrNotFound = errors.New("path not found")
+
+// Client is a client for the HTTP API of a Vault server.
+type Client struct {
+	address string
+	auth    uri.VaultAuth
+	client  *http.Client
+
+	mux   sync.Mutex
+	token string
+}
+
+// New creates a new Vault client and authenticates with the server.
+func New(ctx context.Context, address string, auth uri.VaultAuth) (*Client, error) {
+	c := &Client{
+		address: strings.TrimSuffix(address, "/"),
+		auth:    auth,
+		client:  &http.Client{Timeout: 30 * time.Second},
+		token:   auth.Token,
+	}
+
+	if c.token == "" {
+		if err := c.login(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// Read sends a GET request for the given path and unmarshals the "data" field of the response into v.
+func (c *Client) Read(ctx context.Context, path string, v any) error {
+	return c.do(ctx, http.MethodGet, path, nil, v)
+}
+
+// Write sends a POST request with the given body for the given path
+// and unmarshals the "data" field of the response into v, if v is not nil.
+func (c *Client) Write(ctx context.Context, path string, body, v any) error {
+	return c.do(ctx, http.MethodPost, path, body, v)
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body, v any) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		payload, err = json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("marshaling request body: %w", err)
+		}
+	}
+
+	resp, err := c.request(ctx, method, path, payload, c.getToken())
+	if err != nil {
+		return err
+	}
+
+	// AppRole tokens expire, try to log in again once and repeat the request
+	if resp.StatusCode == http.StatusForbidden && c.auth.Token == "" {
+		resp.Body.Close()
+		if err := c.login(ctx); err != nil {
+			return err
+		}
+		resp, err = c.request(ctx, method, path, payload, c.getToken())
+		if err != nil {
+			return err
+		}
+	}
+	defer resp.Body.Close()
+
+	return decodeResponse(resp, v)
+}
+
+// login authenticates with the Vault server using AppRole credentials.
+func (c *Client) login(ctx context.Context) error {
+	payload, err := json.Marshal(map[string]string{
+		"role_id":   c.auth.RoleID,
+		"secret_id": c.auth.SecretID,
+	})
+	if err != nil {
+		return fmt.Errorf("marshaling login request: %w", err)
+	}
+
+	resp, err := c.request(ctx, http.MethodPost, "auth/approle/login", payload, "")
+	if err != nil {
+		return fmt.Errorf("logging in with AppRole: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var loginResp struct {
+		Auth struct {
+			ClientToken string `json:"client_token"`
+		} `json:"auth"`
+	}
+	if err := checkStatus(resp); err != nil {
+		return fmt.Errorf("logging in with AppRole: %w", err)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&loginResp); err != nil {
+		return fmt.Errorf("decoding AppRole login response: %w", err)
+	}
+	if loginResp.Auth.ClientToken == "" {
+		return errors.New("AppRole login response contains no client token")
+	}
+
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.token = loginResp.Auth.ClientToken
+	return nil
+}
+
+func (c *Client) getToken() string {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	return c.token
+}
+
+func (c *Client) request(ctx context.Context, method, path string, payload []byte, token string) (*http.Response, error) {
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.address+"/v1/"+strings.TrimPrefix(path, "/"), body)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("sending request to Vault: %w", err)
+	}
+	return resp, nil
+}
+
+// decodeResponse checks the status of a response and unmarshals its "data" field into v.
+func decodeResponse(resp *http.Response, v any) error {
+	if err := checkStatus(resp); err != nil {
+		return err
+	}
+	if v == nil {
+		return nil
+	}
+
+	var dataResp struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&dataResp); err != nil {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+	if err := json.Unmarshal(dataResp.Data, v); err != nil {
+		return fmt.Errorf("decoding response data: %w", err)
+	}
+	return nil
+}
+
+// checkStatus returns an error containing the errors reported by Vault if the response indicates a failure.
+func checkStatus(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+
+	var errResp struct {
+		Errors []string `json:"errors"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&errResp); err != nil || len(errResp.Errors) == 0 {
+		return fmt.Errorf("unexpected response status: %s", resp.Status)
+	}
+	return fmt.Errorf("unexpected response status: %s: %s", resp.Status, strings.Join(errResp.Errors, ", "))
+}
@@ -0,0 +1,140 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package vaultclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/edgelesssys/constellation/v2/internal/kms/uri"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+)
+
+func TestMain(m *testing.M) {
+	goleak.VerifyTestMain(m, goleak.IgnoreAnyFunction("github.com/bazelbuild/rules_go/go/tools/bzltestutil.RegisterTimeoutHandler.func1"))
+}
+
+// stubVault is a stand-in for a Vault dev server that serves a single secret.
+type stubVault struct {
+	validToken string
+	logins     int
+}
+
+func (s *stubVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/v1/auth/approle/login":
+		var req map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req["role_id"] != "role" || req["secret_id"] != "secret" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"errors":["invalid role or secret ID"]}`)
+			return
+		}
+		s.logins++
+		s.validToken = fmt.Sprintf("token-%d", s.logins)
+		fmt.Fprintf(w, `{"auth":{"client_token":%q}}`, s.validToken)
+	case "/v1/secret/data/key":
+		if r.Header.Get("X-Vault-Token") != s.validToken {
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `{"errors":["permission denied"]}`)
+			return
+		}
+		fmt.Fprint(w, `{"data":{"value":"secret-value"}}`)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"errors":[]}`)
+	}
+}
+
+func TestRead(t *testing.T) {
+	testCases := map[string]struct {
+		auth        uri.VaultAuth
+		validToken  string
+		expireToken bool
+		path        string
+		wantLogins  int
+		wantNewErr  bool
+		wantErr     bool
+		wantErrIs   error
+	}{
+		"token": {
+			auth:       uri.VaultAuth{Token: "static"},
+			validToken: "static",
+			path:       "secret/data/key",
+		},
+		"invalid token": {
+			auth:       uri.VaultAuth{Token: "invalid"},
+			validToken: "static",
+			path:       "secret/data/key",
+			wantErr:    true,
+		},
+		"approle": {
+			auth:       uri.VaultAuth{RoleID: "role", SecretID: "secret"},
+			path:       "secret/data/key",
+			wantLogins: 1,
+		},
+		"approle expired token is renewed": {
+			auth:        uri.VaultAuth{RoleID: "role", SecretID: "secret"},
+			expireToken: true,
+			path:        "secret/data/key",
+			wantLogins:  2,
+		},
+		"approle login fails": {
+			auth:       uri.VaultAuth{RoleID: "role", SecretID: "wrong"},
+			wantNewErr: true,
+		},
+		"not found": {
+			auth:       uri.VaultAuth{Token: "static"},
+			validToken: "static",
+			path:       "secret/data/missing",
+			wantErrIs:  ErrNotFound,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			vault := &stubVault{validToken: tc.validToken}
+			server := httptest.NewServer(vault)
+			defer server.Close()
+
+			client, err := New(t.Context(), server.URL, tc.auth)
+			if tc.wantNewErr {
+				assert.Error(err)
+				return
+			}
+			require.NoError(err)
+			defer client.client.CloseIdleConnections()
+
+			if tc.expireToken {
+				vault.validToken = "rotated"
+			}
+
+			var data struct {
+				Value string `json:"value"`
+			}
+			err = client.Read(t.Context(), tc.path, &data)
+			if tc.wantErrIs != nil {
+				assert.ErrorIs(err, tc.wantErrIs)
+				return
+			}
+			if tc.wantErr {
+				assert.Error(err)
+				return
+			}
+			require.NoError(err)
+			assert.Equal("secret-value", data.Value)
+			assert.Equal(tc.wantLogins, vault.logins)
+		})
+	}
+}
@@ -14,7 +14,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	v1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
@@ -114,6 +116,16 @@ func (k *Kubectl) UpdateCR(ctx context.Context, gvr schema.GroupVersionResource,
 	return k.dynamicClient.Resource(gvr).Update(ctx, obj, metav1.UpdateOptions{})
 }
 
+// CreateCR creates the given Custom Resource.
+func (k *Kubectl) CreateCR(ctx context.Context, gvr schema.GroupVersionResource, obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	return k.dynamicClient.Resource(gvr).Create(ctx, obj, metav1.CreateOptions{})
+}
+
+// DeleteCR deletes a Custom Resource given it's name and group version resource.
+func (k *Kubectl) DeleteCR(ctx context.Context, gvr schema.GroupVersionResource, name string) error {
+	return k.dynamicClient.Resource(gvr).Delete(ctx, name, metav1.DeleteOptions{})
+}
+
 // CreateConfigMap creates the provided configmap.
 func (k *Kubectl) CreateConfigMap(ctx context.Context, configMap *corev1.ConfigMap) error {
 	_, err := k.CoreV1().ConfigMaps(configMap.ObjectMeta.Namespace).Create(ctx, configMap, metav1.CreateOptions{})
@@ -123,6 +135,11 @@ func (k *Kubectl) CreateConfigMap(ctx context.Context, configMap *corev1.ConfigM
 	return nil
 }
 
+// DeleteConfigMap deletes a ConfigMap given it's name and namespace.
+func (k *Kubectl) DeleteConfigMap(ctx context.Context, namespace, name string) error {
+	return k.CoreV1().ConfigMaps(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+}
+
 // GetConfigMap returns a ConfigMap given it's name and namespace.
 func (k *Kubectl) GetConfigMap(ctx context.Context, namespace, name string) (*corev1.ConfigMap, error) {
 	return k.CoreV1().ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
@@ -133,6 +150,63 @@ func (k *Kubectl) UpdateConfigMap(ctx context.Context, configMap *corev1.ConfigM
 	return k.CoreV1().ConfigMaps(configMap.ObjectMeta.Namespace).Update(ctx, configMap, metav1.UpdateOptions{})
 }
 
+// GetSecret returns a Secret given it's name and namespace.
+func (k *Kubectl) GetSecret(ctx context.Context, namespace, name string) (*corev1.Secret, error) {
+	return k.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+}
+
+// ReplaceSecret deletes the given Secret and creates it again with the new content.
+// This allows changing the data of immutable Secrets.
+// The Secret is only deleted if it was not modified since it was retrieved.
+func (k *Kubectl) ReplaceSecret(ctx context.Context, secret *corev1.Secret) error {
+	secrets := k.CoreV1().Secrets(secret.Namespace)
+	if err := secrets.Delete(ctx, secret.Name, metav1.DeleteOptions{
+		Preconditions: &metav1.Preconditions{UID: &secret.UID, ResourceVersion: &secret.ResourceVersion},
+	}); err != nil {
+		return fmt.Errorf("deleting Secret: %w", err)
+	}
+
+	newSecret := secret.DeepCopy()
+	newSecret.UID = ""
+	newSecret.ResourceVersion = ""
+	newSecret.CreationTimestamp = metav1.Time{}
+	newSecret.ManagedFields = nil
+	return retry.OnError(retry.DefaultBackoff, func(error) bool { return true }, func() error {
+		_, err := secrets.Create(ctx, newSecret, metav1.CreateOptions{})
+		return err
+	})
+}
+
+// ListPersistentVolumes returns all PersistentVolumes in the cluster.
+func (k *Kubectl) ListPersistentVolumes(ctx context.Context) ([]corev1.PersistentVolume, error) {
+	volumes, err := k.CoreV1().PersistentVolumes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("listing persistent volumes: %w", err)
+	}
+	return volumes.Items, nil
+}
+
+// ListPods returns the Pods of all namespaces.
+func (k *Kubectl) ListPods(ctx context.Context) ([]corev1.Pod, error) {
+	pods, err := k.CoreV1().Pods(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("listing pods: %w", err)
+	}
+	return pods.Items, nil
+}
+
+// GetDaemonSet returns a DaemonSet given it's name and namespace.
+func (k *Kubectl) GetDaemonSet(ctx context.Context, namespace, name string) (*appsv1.DaemonSet, error) {
+	return k.AppsV1().DaemonSets(namespace).Get(ctx, name, metav1.GetOptions{})
+}
+
+// RestartDaemonSet triggers a rolling restart of the DaemonSet, like "kubectl rollout restart".
+func (k *Kubectl) RestartDaemonSet(ctx context.Context, namespace, name string) error {
+	patch := fmt.Sprintf(`{"spec":{"template":{"metadata":{"annotations":{"kubectl.kubernetes.io/restartedAt":"%s"}}}}}`, time.Now().Format(time.RFC3339))
+	_, err := k.AppsV1().DaemonSets(namespace).Patch(ctx, name, types.StrategicMergePatchType, []byte(patch), metav1.PatchOptions{})
+	return err
+}
+
 // AnnotateNode adds the provided annotations to the node, identified by name.
 func (k *Kubectl) AnnotateNode(ctx context.Context, nodeName, annotationKey, annotationValue string) error {
 	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
@@ -172,6 +246,11 @@ func (k *Kubectl) GetNodes(ctx context.Context) ([]corev1.Node, error) {
 	return nodes.Items, nil
 }
 
+// DeleteNode deletes the node with the given name.
+func (k *Kubectl) DeleteNode(ctx context.Context, name string) error {
+	return k.CoreV1().Nodes().Delete(ctx, name, metav1.DeleteOptions{})
+}
+
 // PatchFirstNodePodCIDR patches the firstNodePodCIDR of the first control-plane node for Cilium.
 func (k *Kubectl) PatchFirstNodePodCIDR(ctx context.Context, firstNodePodCIDR string) error {
 	selector := labels.Set{"node-role.kubernetes.io/control-plane": ""}.AsSelector()
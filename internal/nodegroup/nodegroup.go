@@ -0,0 +1,66 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+/*
+Package nodegroup holds the Kubernetes node settings of Constellation node groups.
+
+The settings of all node groups are stored in the cluster, so that the JoinService
+can hand out the settings of a node's group in the node's join ticket.
+*/
+package nodegroup
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/edgelesssys/constellation/v2/internal/role"
+)
+
+// Config maps node group names to the settings of the node group.
+type Config map[string]*Settings
+
+// Unmarshal parses node group settings stored in the cluster.
+func Unmarshal(data string) (Config, error) {
+	config := Config{}
+	if data == "" {
+		return config, nil
+	}
+	if err := json.Unmarshal([]byte(data), &config); err != nil {
+		return nil, fmt.Errorf("unmarshaling node group settings: %w", err)
+	}
+	return config, nil
+}
+
+// Marshal encodes the node group settings to be stored in the cluster.
+func (c Config) Marshal() (string, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return "", fmt.Errorf("marshaling node group settings: %w", err)
+	}
+	return string(data), nil
+}
+
+// Lookup returns the settings of the node group with the given name.
+// If the node group is unknown, e.g. because the CSP does not expose the node group of an instance,
+// the settings of the only node group with the given role are returned.
+// Lookup returns nil if no settings apply.
+func (c Config) Lookup(name string, nodeRole role.Role) *Settings {
+	if settings, ok := c[name]; ok && settings != nil {
+		return settings
+	}
+
+	var match *Settings
+	for _, settings := range c {
+		if settings == nil || role.FromString(settings.Role) != nodeRole {
+			continue
+		}
+		if match != nil {
+			return nil // ambiguous
+		}
+		match = settings
+	}
+	return match
+}
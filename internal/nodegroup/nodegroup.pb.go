@@ -0,0 +1,248 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        v5.29.1
+// source: internal/nodegroup/nodegroup.proto
+
+package nodegroup
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Settings struct {
+	state                 protoimpl.MessageState `protogen:"open.v1"`
+	Role                  string                 `protobuf:"bytes,1,opt,name=role,proto3" json:"role,omitempty"`
+	Labels                map[string]string      `protobuf:"bytes,2,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Taints                []*Taint               `protobuf:"bytes,3,rep,name=taints,proto3" json:"taints,omitempty"`
+	KubeletMaxPods        int32                  `protobuf:"varint,4,opt,name=kubelet_max_pods,json=kubeletMaxPods,proto3" json:"kubelet_max_pods,omitempty"`
+	KubeletSystemReserved map[string]string      `protobuf:"bytes,5,rep,name=kubelet_system_reserved,json=kubeletSystemReserved,proto3" json:"kubelet_system_reserved,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	KubeletKubeReserved   map[string]string      `protobuf:"bytes,6,rep,name=kubelet_kube_reserved,json=kubeletKubeReserved,proto3" json:"kubelet_kube_reserved,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields         protoimpl.UnknownFields
+	sizeCache             protoimpl.SizeCache
+}
+
+func (x *Settings) Reset() {
+	*x = Settings{}
+	mi := &file_internal_nodegroup_nodegroup_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Settings) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Settings) ProtoMessage() {}
+
+func (x *Settings) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_nodegroup_nodegroup_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Settings.ProtoReflect.Descriptor instead.
+func (*Settings) Descriptor() ([]byte, []int) {
+	return file_internal_nodegroup_nodegroup_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Settings) GetRole() string {
+	if x != nil {
+		return x.Role
+	}
+	return ""
+}
+
+func (x *Settings) GetLabels() map[string]string {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+func (x *Settings) GetTaints() []*Taint {
+	if x != nil {
+		return x.Taints
+	}
+	return nil
+}
+
+func (x *Settings) GetKubeletMaxPods() int32 {
+	if x != nil {
+		return x.KubeletMaxPods
+	}
+	return 0
+}
+
+func (x *Settings) GetKubeletSystemReserved() map[string]string {
+	if x != nil {
+		return x.KubeletSystemReserved
+	}
+	return nil
+}
+
+func (x *Settings) GetKubeletKubeReserved() map[string]string {
+	if x != nil {
+		return x.KubeletKubeReserved
+	}
+	return nil
+}
+
+type Taint struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Value         string                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	Effect        string                 `protobuf:"bytes,3,opt,name=effect,proto3" json:"effect,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Taint) Reset() {
+	*x = Taint{}
+	mi := &file_internal_nodegroup_nodegroup_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Taint) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Taint) ProtoMessage() {}
+
+func (x *Taint) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_nodegroup_nodegroup_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Taint.ProtoReflect.Descriptor instead.
+func (*Taint) Descriptor() ([]byte, []int) {
+	return file_internal_nodegroup_nodegroup_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Taint) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *Taint) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *Taint) GetEffect() string {
+	if x != nil {
+		return x.Effect
+	}
+	return ""
+}
+
+var File_internal_nodegroup_nodegroup_proto protoreflect.FileDescriptor
+
+const file_internal_nodegroup_nodegroup_proto_rawDesc = "" +
+	"\n" +
+	"\"internal/nodegroup/nodegroup.proto\x12\tnodegroup\"\xc2\x04\n" +
+	"\bSettings\x12\x12\n" +
+	"\x04role\x18\x01 \x01(\tR\x04role\x127\n" +
+	"\x06labels\x18\x02 \x03(\v2\x1f.nodegroup.Settings.LabelsEntryR\x06labels\x12(\n" +
+	"\x06taints\x18\x03 \x03(\v2\x10.nodegroup.TaintR\x06taints\x12(\n" +
+	"\x10kubelet_max_pods\x18\x04 \x01(\x05R\x0ekubeletMaxPods\x12f\n" +
+	"\x17kubelet_system_reserved\x18\x05 \x03(\v2..nodegroup.Settings.KubeletSystemReservedEntryR\x15kubeletSystemReserved\x12`\n" +
+	"\x15kubelet_kube_reserved\x18\x06 \x03(\v2,.nodegroup.Settings.KubeletKubeReservedEntryR\x13kubeletKubeReserved\x1a9\n" +
+	"\vLabelsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\x1aH\n" +
+	"\x1aKubeletSystemReservedEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\x1aF\n" +
+	"\x18KubeletKubeReservedEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"G\n" +
+	"\x05Taint\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value\x12\x16\n" +
+	"\x06effect\x18\x03 \x01(\tR\x06effectB<Z:github.com/edgelesssys/constellation/v2/internal/nodegroupb\x06proto3"
+
+var (
+	file_internal_nodegroup_nodegroup_proto_rawDescOnce sync.Once
+	file_internal_nodegroup_nodegroup_proto_rawDescData []byte
+)
+
+func file_internal_nodegroup_nodegroup_proto_rawDescGZIP() []byte {
+	file_internal_nodegroup_nodegroup_proto_rawDescOnce.Do(func() {
+		file_internal_nodegroup_nodegroup_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_internal_nodegroup_nodegroup_proto_rawDesc), len(file_internal_nodegroup_nodegroup_proto_rawDesc)))
+	})
+	return file_internal_nodegroup_nodegroup_proto_rawDescData
+}
+
+var file_internal_nodegroup_nodegroup_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_internal_nodegroup_nodegroup_proto_goTypes = []any{
+	(*Settings)(nil), // 0: nodegroup.Settings
+	(*Taint)(nil),    // 1: nodegroup.Taint
+	nil,              // 2: nodegroup.Settings.LabelsEntry
+	nil,              // 3: nodegroup.Settings.KubeletSystemReservedEntry
+	nil,              // 4: nodegroup.Settings.KubeletKubeReservedEntry
+}
+var file_internal_nodegroup_nodegroup_proto_depIdxs = []int32{
+	2, // 0: nodegroup.Settings.labels:type_name -> nodegroup.Settings.LabelsEntry
+	1, // 1: nodegroup.Settings.taints:type_name -> nodegroup.Taint
+	3, // 2: nodegroup.Settings.kubelet_system_reserved:type_name -> nodegroup.Settings.KubeletSystemReservedEntry
+	4, // 3: nodegroup.Settings.kubelet_kube_reserved:type_name -> nodegroup.Settings.KubeletKubeReservedEntry
+	4, // [4:4] is the sub-list for method output_type
+	4, // [4:4] is the sub-list for method input_type
+	4, // [4:4] is the sub-list for extension type_name
+	4, // [4:4] is the sub-list for extension extendee
+	0, // [0:4] is the sub-list for field type_name
+}
+
+func init() { file_internal_nodegroup_nodegroup_proto_init() }
+func file_internal_nodegroup_nodegroup_proto_init() {
+	if File_internal_nodegroup_nodegroup_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_internal_nodegroup_nodegroup_proto_rawDesc), len(file_internal_nodegroup_nodegroup_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   5,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_internal_nodegroup_nodegroup_proto_goTypes,
+		DependencyIndexes: file_internal_nodegroup_nodegroup_proto_depIdxs,
+		MessageInfos:      file_internal_nodegroup_nodegroup_proto_msgTypes,
+	}.Build()
+	File_internal_nodegroup_nodegroup_proto = out.File
+	file_internal_nodegroup_nodegroup_proto_goTypes = nil
+	file_internal_nodegroup_nodegroup_proto_depIdxs = nil
+}
@@ -0,0 +1,113 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package nodegroup
+
+import (
+	"testing"
+
+	"github.com/edgelesssys/constellation/v2/internal/role"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarshalUnmarshal(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	config := Config{
+		"worker_default": {
+			Role:                  "worker",
+			Labels:                map[string]string{"example.com/pool": "default"},
+			Taints:                []*Taint{{Key: "example.com/dedicated", Value: "gpu", Effect: "NoSchedule"}},
+			KubeletMaxPods:        50,
+			KubeletSystemReserved: map[string]string{"cpu": "500m"},
+			KubeletKubeReserved:   map[string]string{"memory": "1Gi"},
+		},
+	}
+
+	data, err := config.Marshal()
+	require.NoError(err)
+	unmarshaled, err := Unmarshal(data)
+	require.NoError(err)
+	require.Contains(unmarshaled, "worker_default")
+	got := unmarshaled["worker_default"]
+	assert.Equal("worker", got.Role)
+	assert.Equal(map[string]string{"example.com/pool": "default"}, got.Labels)
+	require.Len(got.Taints, 1)
+	assert.Equal("example.com/dedicated", got.Taints[0].Key)
+	assert.Equal("gpu", got.Taints[0].Value)
+	assert.Equal("NoSchedule", got.Taints[0].Effect)
+	assert.EqualValues(50, got.KubeletMaxPods)
+	assert.Equal(map[string]string{"cpu": "500m"}, got.KubeletSystemReserved)
+	assert.Equal(map[string]string{"memory": "1Gi"}, got.KubeletKubeReserved)
+
+	empty, err := Unmarshal("")
+	require.NoError(err)
+	assert.Empty(empty)
+
+	_, err = Unmarshal("{")
+	assert.Error(err)
+}
+
+func TestLookup(t *testing.T) {
+	controlPlane := &Settings{Role: "control-plane"}
+	worker := &Settings{Role: "worker"}
+	gpuWorker := &Settings{Role: "worker"}
+
+	testCases := map[string]struct {
+		config   Config
+		name     string
+		role     role.Role
+		wantNil  bool
+		expected *Settings
+	}{
+		"by name": {
+			config:   Config{"control_plane_default": controlPlane, "worker_default": worker, "gpu": gpuWorker},
+			name:     "gpu",
+			role:     role.Worker,
+			expected: gpuWorker,
+		},
+		"only group of role": {
+			config:   Config{"control_plane_default": controlPlane, "worker_default": worker},
+			role:     role.Worker,
+			expected: worker,
+		},
+		"unknown name falls back to role": {
+			config:   Config{"control_plane_default": controlPlane, "worker_default": worker},
+			name:     "deleted",
+			role:     role.ControlPlane,
+			expected: controlPlane,
+		},
+		"ambiguous role": {
+			config:  Config{"control_plane_default": controlPlane, "worker_default": worker, "gpu": gpuWorker},
+			role:    role.Worker,
+			wantNil: true,
+		},
+		"no group of role": {
+			config:  Config{"control_plane_default": controlPlane},
+			role:    role.Worker,
+			wantNil: true,
+		},
+		"empty config": {
+			config:  Config{},
+			name:    "worker_default",
+			role:    role.Worker,
+			wantNil: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			settings := tc.config.Lookup(tc.name, tc.role)
+			if tc.wantNil {
+				assert.Nil(t, settings)
+				return
+			}
+			assert.Same(t, tc.expected, settings)
+		})
+	}
+}
@@ -0,0 +1,270 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package verify
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/edgelesssys/constellation/v2/internal/attestation/measurements"
+	"github.com/edgelesssys/constellation/v2/internal/attestation/policy"
+	"github.com/edgelesssys/constellation/v2/internal/config"
+)
+
+// ClaimsSchemaVersion identifies the schema of a ClaimsReport.
+// It changes with every incompatible change of the schema.
+const ClaimsSchemaVersion = "constellation.edgeless.systems/claims/v1"
+
+// Verdict is the outcome of a check.
+type Verdict string
+
+const (
+	// VerdictPass means the check succeeded.
+	VerdictPass Verdict = "pass"
+	// VerdictWarn means the check found deviations that aren't enforced.
+	VerdictWarn Verdict = "warn"
+	// VerdictFail means the check failed.
+	VerdictFail Verdict = "fail"
+	// VerdictUnchecked means there is no reference value for a claim.
+	VerdictUnchecked Verdict = "unchecked"
+)
+
+// ClaimsReport is the normalized, variant independent result of verifying a Constellation node.
+//
+// Similar to an Entity Attestation Token, the report lists the claims of the node's attestation statement.
+// Similar to a Concise Reference Integrity Manifest, each claim is listed together with the reference values it was compared to.
+type ClaimsReport struct {
+	SchemaVersion  string                `json:"schema_version"`
+	Variant        string                `json:"variant"`
+	VerifiedAt     time.Time             `json:"verified_at"`
+	Verdict        Verdict               `json:"verdict"`
+	Measurements   []MeasurementClaim    `json:"measurements"`
+	TCB            *TCBClaims            `json:"tcb,omitempty"`
+	FirmwareSigner *FirmwareSignerClaims `json:"firmware_signer,omitempty"`
+	Policy         []CheckResult         `json:"policy,omitempty"`
+	Checks         []CheckResult         `json:"checks"`
+}
+
+// MeasurementClaim is a single measurement of the node and the reference value it was compared to.
+type MeasurementClaim struct {
+	Index     uint32  `json:"index"`
+	Actual    string  `json:"actual"`
+	Reference string  `json:"reference,omitempty"`
+	Enforced  bool    `json:"enforced"`
+	Verdict   Verdict `json:"verdict"`
+}
+
+// TCBClaims are the security versions of the trusted computing base of the node's hardware.
+// Only the field matching the node's TEE type is set.
+type TCBClaims struct {
+	SNP *SNPTCBClaims `json:"snp,omitempty"`
+	TDX *TDXTCBClaims `json:"tdx,omitempty"`
+}
+
+// SNPTCBClaims are the TCB claims of an AMD SEV-SNP attestation report.
+type SNPTCBClaims struct {
+	Launch       SNPTCB `json:"launch"`
+	Reported     SNPTCB `json:"reported"`
+	GuestPolicy  uint64 `json:"guest_policy"`
+	PlatformInfo uint64 `json:"platform_info"`
+}
+
+// SNPTCB are the security patch levels of the SEV-SNP firmware components.
+type SNPTCB struct {
+	Bootloader uint8 `json:"bootloader"`
+	TEE        uint8 `json:"tee"`
+	SNP        uint8 `json:"snp"`
+	Microcode  uint8 `json:"microcode"`
+}
+
+// TDXTCBClaims are the TCB claims of an Intel TDX quote.
+type TDXTCBClaims struct {
+	QESVN     uint16 `json:"qe_svn"`
+	PCESVN    uint16 `json:"pce_svn"`
+	TEETCBSVN string `json:"tee_tcb_svn"`
+	XFAM      string `json:"xfam"`
+}
+
+// FirmwareSignerClaims identify who signed the firmware the node was launched with.
+type FirmwareSignerClaims struct {
+	IDKeyDigest     string `json:"id_key_digest,omitempty"`
+	AuthorKeyDigest string `json:"author_key_digest,omitempty"`
+	MRSeam          string `json:"mr_seam,omitempty"`
+	MRSignerSeam    string `json:"mr_signer_seam,omitempty"`
+}
+
+// CheckResult is the verdict of a single check.
+type CheckResult struct {
+	Name    string  `json:"name"`
+	Verdict Verdict `json:"verdict"`
+	Detail  string  `json:"detail,omitempty"`
+}
+
+// NewClaimsReport creates a claims report for the given attestation document.
+// validationErr is the result of validating the document with the validator of the attestation config.
+func NewClaimsReport(attestationCfg config.AttestationCfg, attDoc []byte, validationErr error, now time.Time) (ClaimsReport, error) {
+	claims, err := policy.ExtractClaims(attestationCfg.GetVariant(), attDoc)
+	if err != nil {
+		return ClaimsReport{}, fmt.Errorf("extracting claims: %w", err)
+	}
+
+	report := ClaimsReport{
+		SchemaVersion: ClaimsSchemaVersion,
+		Variant:       claims.Variant,
+		VerifiedAt:    now.UTC(),
+	}
+
+	attestationCheck := CheckResult{Name: "attestation", Verdict: VerdictPass}
+	if validationErr != nil {
+		attestationCheck.Verdict = VerdictFail
+		attestationCheck.Detail = validationErr.Error()
+	}
+	report.Checks = append(report.Checks, attestationCheck)
+
+	var measurementsCheck CheckResult
+	report.Measurements, measurementsCheck = measurementClaims(config.MeasurementSets(attestationCfg), claims.Measurements)
+	report.Checks = append(report.Checks, measurementsCheck)
+
+	if claims.SNP != nil {
+		report.TCB = &TCBClaims{SNP: &SNPTCBClaims{
+			Launch:       newSNPTCB(claims.SNP.LaunchTCB),
+			Reported:     newSNPTCB(claims.SNP.ReportedTCB),
+			GuestPolicy:  claims.SNP.GuestPolicy,
+			PlatformInfo: claims.SNP.PlatformInfo,
+		}}
+		report.FirmwareSigner = &FirmwareSignerClaims{
+			IDKeyDigest:     hex.EncodeToString(claims.SNP.IDKeyDigest),
+			AuthorKeyDigest: hex.EncodeToString(claims.SNP.AuthorKeyDigest),
+		}
+	}
+	if claims.TDX != nil {
+		report.TCB = &TCBClaims{TDX: &TDXTCBClaims{
+			QESVN:     claims.TDX.QESVN,
+			PCESVN:    claims.TDX.PCESVN,
+			TEETCBSVN: hex.EncodeToString(claims.TDX.TEETCBSVN),
+			XFAM:      hex.EncodeToString(claims.TDX.XFAM),
+		}}
+		report.FirmwareSigner = &FirmwareSignerClaims{
+			MRSeam:       hex.EncodeToString(claims.TDX.MRSeam),
+			MRSignerSeam: hex.EncodeToString(claims.TDX.MRSignerSeam),
+		}
+	}
+
+	if len(attestationCfg.GetPolicy()) > 0 {
+		attestationPolicy, err := config.CompilePolicy(attestationCfg)
+		if err != nil {
+			return ClaimsReport{}, fmt.Errorf("compiling attestation policy: %w", err)
+		}
+		policyCheck := CheckResult{Name: "policy", Verdict: VerdictPass}
+		for _, result := range attestationPolicy.Results(claims, now) {
+			ruleResult := CheckResult{Name: result.Name, Verdict: VerdictPass}
+			if result.Err != nil {
+				ruleResult.Verdict = VerdictFail
+				ruleResult.Detail = result.Err.Error()
+				policyCheck.Verdict = VerdictFail
+			}
+			report.Policy = append(report.Policy, ruleResult)
+		}
+		report.Checks = append(report.Checks, policyCheck)
+	}
+
+	report.Verdict = VerdictPass
+	for _, check := range report.Checks {
+		if check.Verdict == VerdictFail {
+			report.Verdict = VerdictFail
+			break
+		}
+		if check.Verdict == VerdictWarn {
+			report.Verdict = VerdictWarn
+		}
+	}
+	return report, nil
+}
+
+// NewFailedClaimsReport creates a failing claims report for an attestation document
+// that no claims could be extracted from, e.g., because it couldn't be parsed.
+func NewFailedClaimsReport(attestationCfg config.AttestationCfg, err error, now time.Time) ClaimsReport {
+	return ClaimsReport{
+		SchemaVersion: ClaimsSchemaVersion,
+		Variant:       attestationCfg.GetVariant().String(),
+		VerifiedAt:    now.UTC(),
+		Verdict:       VerdictFail,
+		Checks:        []CheckResult{{Name: "attestation", Verdict: VerdictFail, Detail: err.Error()}},
+	}
+}
+
+// measurementClaims compares the actual measurements to the measurement set they match,
+// or to the first set if they match none.
+func measurementClaims(accepted []measurements.M, actual map[uint32][]byte) ([]MeasurementClaim, CheckResult) {
+	check := CheckResult{Name: "measurements", Verdict: VerdictFail, Detail: "no accepted measurement set matches"}
+	reference := measurements.M{}
+	for i, m := range accepted {
+		if _, errs := m.Compare(actual); len(errs) == 0 {
+			reference = m
+			check.Verdict = VerdictPass
+			check.Detail = fmt.Sprintf("matches accepted measurement set %d", i)
+			break
+		}
+	}
+	if check.Verdict == VerdictFail && len(accepted) > 0 {
+		reference = accepted[0]
+	}
+
+	indices := make([]uint32, 0, len(actual)+len(reference))
+	for idx := range actual {
+		indices = append(indices, idx)
+	}
+	for idx := range reference {
+		if _, ok := actual[idx]; !ok {
+			indices = append(indices, idx)
+		}
+	}
+	sort.Slice(indices, func(i, j int) bool { return indices[i] < indices[j] })
+
+	var warnOnly []string
+	claims := make([]MeasurementClaim, 0, len(indices))
+	for _, idx := range indices {
+		claim := MeasurementClaim{
+			Index:   idx,
+			Actual:  hex.EncodeToString(actual[idx]),
+			Verdict: VerdictUnchecked,
+		}
+		if expected, ok := reference[idx]; ok {
+			claim.Reference = hex.EncodeToString(expected.Expected)
+			claim.Enforced = expected.ValidationOpt == measurements.Enforce
+			switch {
+			case bytes.Equal(expected.Expected, actual[idx]):
+				claim.Verdict = VerdictPass
+			case claim.Enforced:
+				claim.Verdict = VerdictFail
+			default:
+				claim.Verdict = VerdictWarn
+				warnOnly = append(warnOnly, fmt.Sprint(idx))
+			}
+		}
+		claims = append(claims, claim)
+	}
+
+	if check.Verdict == VerdictPass && len(warnOnly) > 0 {
+		check.Verdict = VerdictWarn
+		check.Detail += fmt.Sprintf(", measurements %s differ but aren't enforced", strings.Join(warnOnly, ", "))
+	}
+	return claims, check
+}
+
+func newSNPTCB(tcb policy.TCB) SNPTCB {
+	return SNPTCB{
+		Bootloader: tcb.Bootloader,
+		TEE:        tcb.TEE,
+		SNP:        tcb.SNP,
+		Microcode:  tcb.Microcode,
+	}
+}
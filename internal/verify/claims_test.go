@@ -0,0 +1,166 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package verify
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/edgelesssys/constellation/v2/internal/attestation/measurements"
+	"github.com/edgelesssys/constellation/v2/internal/config"
+	tpmProto "github.com/google/go-tpm-tools/proto/tpm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewClaimsReport(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	pcrs := map[uint32][]byte{
+		4: bytes.Repeat([]byte{0x11}, measurements.PCRMeasurementLength),
+		8: bytes.Repeat([]byte{0x22}, measurements.PCRMeasurementLength),
+		9: bytes.Repeat([]byte{0x33}, measurements.PCRMeasurementLength),
+	}
+	matching := measurements.M{
+		4: measurements.WithAllBytes(0x11, measurements.Enforce, measurements.PCRMeasurementLength),
+		8: measurements.WithAllBytes(0x22, measurements.Enforce, measurements.PCRMeasurementLength),
+	}
+	mismatching := measurements.M{
+		4: measurements.WithAllBytes(0xAA, measurements.Enforce, measurements.PCRMeasurementLength),
+		8: measurements.WithAllBytes(0x22, measurements.Enforce, measurements.PCRMeasurementLength),
+	}
+	warnOnly := measurements.M{
+		4: measurements.WithAllBytes(0x11, measurements.Enforce, measurements.PCRMeasurementLength),
+		8: measurements.WithAllBytes(0xAA, measurements.WarnOnly, measurements.PCRMeasurementLength),
+	}
+
+	testCases := map[string]struct {
+		cfg               *config.QEMUVTPM
+		validationErr     error
+		wantVerdict       Verdict
+		wantMeasurements  []Verdict
+		wantChecks        map[string]Verdict
+		wantPolicyVerdict map[string]Verdict
+	}{
+		"matching measurements": {
+			cfg:              &config.QEMUVTPM{Measurements: matching},
+			wantVerdict:      VerdictPass,
+			wantMeasurements: []Verdict{VerdictPass, VerdictPass, VerdictUnchecked},
+			wantChecks:       map[string]Verdict{"attestation": VerdictPass, "measurements": VerdictPass},
+		},
+		"mismatching measurements": {
+			cfg:              &config.QEMUVTPM{Measurements: mismatching},
+			validationErr:    errors.New("measurement mismatch"),
+			wantVerdict:      VerdictFail,
+			wantMeasurements: []Verdict{VerdictFail, VerdictPass, VerdictUnchecked},
+			wantChecks:       map[string]Verdict{"attestation": VerdictFail, "measurements": VerdictFail},
+		},
+		"accepted measurement set matches": {
+			cfg: &config.QEMUVTPM{
+				Measurements:         mismatching,
+				AcceptedMeasurements: []measurements.M{matching},
+			},
+			wantVerdict:      VerdictPass,
+			wantMeasurements: []Verdict{VerdictPass, VerdictPass, VerdictUnchecked},
+			wantChecks:       map[string]Verdict{"attestation": VerdictPass, "measurements": VerdictPass},
+		},
+		"warn only measurement differs": {
+			cfg:              &config.QEMUVTPM{Measurements: warnOnly},
+			wantVerdict:      VerdictWarn,
+			wantMeasurements: []Verdict{VerdictPass, VerdictWarn, VerdictUnchecked},
+			wantChecks:       map[string]Verdict{"attestation": VerdictPass, "measurements": VerdictWarn},
+		},
+		"policy": {
+			cfg: &config.QEMUVTPM{
+				Measurements: matching,
+				Policy: []config.PolicyRule{
+					{Name: "pcr 9 set", Expression: `claims.measurements[9] == "` + strings.Repeat("33", 32) + `"`},
+					{Name: "is snp", Expression: `has(claims.snp)`},
+				},
+			},
+			validationErr:     errors.New("policy rule \"is snp\" failed"),
+			wantVerdict:       VerdictFail,
+			wantMeasurements:  []Verdict{VerdictPass, VerdictPass, VerdictUnchecked},
+			wantChecks:        map[string]Verdict{"attestation": VerdictFail, "measurements": VerdictPass, "policy": VerdictFail},
+			wantPolicyVerdict: map[string]Verdict{"pcr 9 set": VerdictPass, "is snp": VerdictFail},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			report, err := NewClaimsReport(tc.cfg, newVTPMDoc(t, pcrs), tc.validationErr, now)
+			require.NoError(err)
+
+			assert.Equal(ClaimsSchemaVersion, report.SchemaVersion)
+			assert.Equal("qemu-vtpm", report.Variant)
+			assert.Equal(now, report.VerifiedAt)
+			assert.Equal(tc.wantVerdict, report.Verdict)
+
+			var gotMeasurements []Verdict
+			for _, m := range report.Measurements {
+				gotMeasurements = append(gotMeasurements, m.Verdict)
+			}
+			assert.Equal(tc.wantMeasurements, gotMeasurements)
+
+			gotChecks := map[string]Verdict{}
+			for _, check := range report.Checks {
+				gotChecks[check.Name] = check.Verdict
+			}
+			assert.Equal(tc.wantChecks, gotChecks)
+
+			var gotPolicy map[string]Verdict
+			for _, rule := range report.Policy {
+				if gotPolicy == nil {
+					gotPolicy = map[string]Verdict{}
+				}
+				gotPolicy[rule.Name] = rule.Verdict
+			}
+			assert.Equal(tc.wantPolicyVerdict, gotPolicy)
+
+			assert.Nil(report.TCB)
+			assert.Nil(report.FirmwareSigner)
+		})
+	}
+}
+
+func TestNewClaimsReportInvalidDocument(t *testing.T) {
+	_, err := NewClaimsReport(&config.QEMUVTPM{}, []byte("invalid"), nil, time.Now())
+	assert.Error(t, err)
+}
+
+func newVTPMDoc(t *testing.T, pcrs map[uint32][]byte) []byte {
+	t.Helper()
+	doc := struct {
+		Attestation struct {
+			Quotes []*tpmProto.Quote
+		}
+	}{}
+	doc.Attestation.Quotes = []*tpmProto.Quote{
+		{Pcrs: &tpmProto.PCRs{Hash: tpmProto.HashAlgo_SHA256, Pcrs: pcrs}},
+	}
+	raw, err := json.Marshal(doc)
+	require.NoError(t, err)
+	return raw
+}
+
+func TestNewFailedClaimsReport(t *testing.T) {
+	assert := assert.New(t)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	report := NewFailedClaimsReport(&config.QEMUVTPM{}, errors.New("invalid document"), now)
+	assert.Equal(ClaimsSchemaVersion, report.SchemaVersion)
+	assert.Equal("qemu-vtpm", report.Variant)
+	assert.Equal(now, report.VerifiedAt)
+	assert.Equal(VerdictFail, report.Verdict)
+	assert.Equal([]CheckResult{{Name: "attestation", Verdict: VerdictFail, Detail: "invalid document"}}, report.Checks)
+}
@@ -44,10 +44,21 @@ import (
 // vpcIPTimeout is the maximum amount of time to wait for retrieval of the VPC ip.
 const vpcIPTimeout = 30 * time.Second
 
+// crlRefreshInterval is the interval in which the validator is updated to pick up a refreshed AMD CRL.
+const crlRefreshInterval = time.Hour
+
+// evidencePruneInterval is the interval in which archived attestation evidence past its retention time is deleted.
+const evidencePruneInterval = time.Hour
+
+// evidencePruneTimeout is the timeout for deleting archived attestation evidence.
+const evidencePruneTimeout = 5 * time.Minute
+
 func main() {
 	provider := flag.String("cloud-provider", "", "cloud service provider this binary is running on")
 	keyServiceEndpoint := flag.String("key-service-endpoint", "", "endpoint of Constellations key management service")
 	attestationVariant := flag.String("attestation-variant", "", "attestation variant to use for aTLS connections")
+	allowUnarchivedJoins := flag.Bool("allow-unarchived-joins", false, "admit nodes even if their attestation evidence can't be archived")
+	evidenceRetention := flag.Duration("evidence-retention", 0, "time archived attestation evidence is kept before it's deleted, 0 keeps it forever")
 	verbosity := flag.Int("v", 0, logger.CmdLineVerbosityDescription)
 	flag.Parse()
 
@@ -79,7 +90,7 @@ func main() {
 		os.Exit(1)
 	}
 
-	validator, err := watcher.NewValidator(log.WithGroup("validator"), attVariant, handler, cachedCerts)
+	validator, err := watcher.NewValidator(log.WithGroup("validator"), attVariant, handler, cachedCerts, certCacheClient)
 	if err != nil {
 		flag.Usage()
 		log.With(slog.Any("error", err)).Error("Failed to create validator")
@@ -115,6 +126,8 @@ func main() {
 		kubeadm,
 		keyServiceClient,
 		kubeClient,
+		validator,
+		*allowUnarchivedJoins,
 		log.WithGroup("server"),
 		file.NewHandler(afero.NewOsFs()),
 	)
@@ -138,6 +151,30 @@ func main() {
 		}
 	}()
 
+	switch attVariant {
+	case variant.AWSSEVSNP{}, variant.AzureSEVSNP{}, variant.GCPSEVSNP{}, variant.QEMUSEVSNP{}:
+		go func() {
+			// Regularly update the validator, so revocations are checked against the latest AMD CRL.
+			for range time.Tick(crlRefreshInterval) {
+				if err := validator.Update(); err != nil {
+					log.With(slog.Any("error", err)).Error("Failed to refresh validator")
+				}
+			}
+		}()
+	}
+
+	if *evidenceRetention > 0 {
+		go func() {
+			for range time.Tick(evidencePruneInterval) {
+				ctx, cancel := context.WithTimeout(context.Background(), evidencePruneTimeout)
+				if err := kubeClient.PruneEvidence(ctx, time.Now().Add(-*evidenceRetention)); err != nil {
+					log.With(slog.Any("error", err)).Error("Failed to prune archived attestation evidence")
+				}
+				cancel()
+			}
+		}()
+	}
+
 	if err := server.Run(creds, strconv.Itoa(constants.JoinServicePort)); err != nil {
 		log.With(slog.Any("error", err)).Error("Failed to run server")
 		os.Exit(1)
@@ -12,6 +12,7 @@ import (
 	"fmt"
 
 	"github.com/google/go-sev-guest/abi"
+	"github.com/google/go-sev-guest/kds"
 	"github.com/google/go-sev-guest/verify/trust"
 )
 
@@ -36,3 +37,16 @@ func (c *KDSClient) CertChain(signingType abi.ReportSigner) (ask, ark *x509.Cert
 
 	return askark.Ask, askark.Ark, nil
 }
+
+// CRL queries the AMD KDS for the certificate revocation list for given signing type (VCEK / VLEK).
+func (c *KDSClient) CRL(signingType abi.ReportSigner) (*x509.RevocationList, error) {
+	raw, err := c.getter.Get(kds.CrlLinkByKey("Milan", signingType))
+	if err != nil {
+		return nil, fmt.Errorf("retrieving CRL: %w", err)
+	}
+	crl, err := x509.ParseRevocationList(raw)
+	if err != nil {
+		return nil, fmt.Errorf("parsing CRL: %w", err)
+	}
+	return crl, nil
+}
@@ -10,8 +10,11 @@ package certcache
 import (
 	"context"
 	"crypto/x509"
+	"encoding/pem"
+	"errors"
 	"fmt"
 	"log/slog"
+	"time"
 
 	"github.com/edgelesssys/constellation/v2/internal/attestation/variant"
 	"github.com/edgelesssys/constellation/v2/internal/constants"
@@ -48,7 +51,7 @@ func NewClient(log *slog.Logger, kubeClient kubeClient, attVariant variant.Varia
 func (c *Client) CreateCertChainCache(ctx context.Context) (*CachedCerts, error) {
 	var reportSigner abi.ReportSigner
 	switch c.attVariant {
-	case variant.AzureSEVSNP{}:
+	case variant.AzureSEVSNP{}, variant.QEMUSEVSNP{}:
 		reportSigner = abi.VcekReportSigner
 	case variant.AWSSEVSNP{}:
 		reportSigner = abi.VlekReportSigner
@@ -68,6 +71,70 @@ func (c *Client) CreateCertChainCache(ctx context.Context) (*CachedCerts, error)
 	}, nil
 }
 
+// CRL returns the AMD certificate revocation list (CRL) for the attestation variant.
+// The CRL is read from the certificate cache. If it isn't cached or its next update is due,
+// it's retrieved from the KDS and the cache is updated, so that not every JoinService instance has to query the KDS.
+func (c *Client) CRL(ctx context.Context) (*x509.RevocationList, error) {
+	var reportSigner abi.ReportSigner
+	switch c.attVariant {
+	case variant.AzureSEVSNP{}, variant.GCPSEVSNP{}, variant.QEMUSEVSNP{}:
+		reportSigner = abi.VcekReportSigner
+	case variant.AWSSEVSNP{}:
+		reportSigner = abi.VlekReportSigner
+	default:
+		return nil, fmt.Errorf("no CRL available for attestation variant %q", c.attVariant)
+	}
+
+	cacheExists := true
+	crlRaw, err := c.kubeClient.GetConfigMapData(ctx, constants.SevSnpCertCacheConfigMapName, constants.CertCacheCRLKey)
+	if k8serrors.IsNotFound(err) {
+		c.log.Debug("Certificate chain cache does not exist")
+		cacheExists = false
+	} else if err != nil {
+		return nil, fmt.Errorf("getting CRL from configmap: %w", err)
+	}
+	if crlRaw != "" {
+		crl, err := pemToCRL([]byte(crlRaw))
+		if err != nil {
+			return nil, fmt.Errorf("parsing cached CRL: %w", err)
+		}
+		if time.Now().Before(crl.NextUpdate) {
+			c.log.Debug("CRL cache hit")
+			return crl, nil
+		}
+		c.log.Debug("Cached CRL is outdated")
+	}
+
+	c.log.Debug("Retrieving CRL from KDS")
+	crl, err := c.kdsClient.CRL(reportSigner)
+	if err != nil {
+		return nil, fmt.Errorf("retrieving CRL from KDS: %w", err)
+	}
+	crlPem := pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: crl.Raw})
+
+	if cacheExists {
+		err = c.kubeClient.UpdateConfigMap(ctx, constants.SevSnpCertCacheConfigMapName, constants.CertCacheCRLKey, string(crlPem))
+	} else {
+		err = c.kubeClient.CreateConfigMap(ctx, constants.SevSnpCertCacheConfigMapName, map[string]string{
+			constants.CertCacheCRLKey: string(crlPem),
+		})
+	}
+	if err != nil {
+		// Another JoinService instance may have updated the cache concurrently.
+		// The retrieved CRL is still valid, so only log the error.
+		c.log.Warn(fmt.Sprintf("Failed to update CRL cache: %v", err))
+	}
+	return crl, nil
+}
+
+func pemToCRL(pemData []byte) (*x509.RevocationList, error) {
+	block, _ := pem.Decode(pemData)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+	return x509.ParseRevocationList(block.Bytes)
+}
+
 // CachedCerts contains the cached certificates.
 type CachedCerts struct {
 	ask *x509.Certificate
@@ -204,4 +271,5 @@ type kubeClient interface {
 
 type kdsClient interface {
 	CertChain(signingType abi.ReportSigner) (ask, ark *x509.Certificate, err error)
+	CRL(signingType abi.ReportSigner) (*x509.RevocationList, error)
 }
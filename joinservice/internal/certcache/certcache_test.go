@@ -8,8 +8,15 @@ package certcache
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
 	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
 	"testing"
+	"time"
 
 	"github.com/edgelesssys/constellation/v2/internal/attestation/variant"
 	"github.com/edgelesssys/constellation/v2/internal/constants"
@@ -141,6 +148,9 @@ type stubKdsClient struct {
 	askResponse  []byte
 	arkResponse  []byte
 	certChainErr error
+	crlResponse  *x509.RevocationList
+	crlErr       error
+	crlCalls     int
 }
 
 func (c *stubKdsClient) CertChain(abi.ReportSigner) (ask, ark *x509.Certificate, err error) {
@@ -153,6 +163,11 @@ func (c *stubKdsClient) CertChain(abi.ReportSigner) (ask, ark *x509.Certificate,
 	return ask, ark, c.certChainErr
 }
 
+func (c *stubKdsClient) CRL(abi.ReportSigner) (*x509.RevocationList, error) {
+	c.crlCalls++
+	return c.crlResponse, c.crlErr
+}
+
 func mustParsePEM(pemBytes []byte) *x509.Certificate {
 	cert, err := crypto.PemToX509Cert(pemBytes)
 	if err != nil {
@@ -220,11 +235,137 @@ func TestGetCertChainCache(t *testing.T) {
 	}
 }
 
+func TestCRL(t *testing.T) {
+	notFoundErr := k8serrors.NewNotFound(schema.GroupResource{}, "test")
+	freshCRL := newTestCRL(t, time.Now().Add(time.Hour))
+	outdatedCRL := newTestCRL(t, time.Now().Add(-time.Hour))
+
+	testCases := map[string]struct {
+		variant      variant.Variant
+		kubeClient   *stubKubeClient
+		kdsClient    *stubKdsClient
+		wantCRL      *x509.RevocationList
+		wantKDSCalls int
+		wantErr      bool
+	}{
+		"available in configmap": {
+			variant:    variant.AzureSEVSNP{},
+			kubeClient: &stubKubeClient{crlResponse: crlToPEM(freshCRL)},
+			kdsClient:  &stubKdsClient{},
+			wantCRL:    freshCRL,
+		},
+		"outdated in configmap": {
+			variant:      variant.AWSSEVSNP{},
+			kubeClient:   &stubKubeClient{crlResponse: crlToPEM(outdatedCRL)},
+			kdsClient:    &stubKdsClient{crlResponse: freshCRL},
+			wantCRL:      freshCRL,
+			wantKDSCalls: 1,
+		},
+		"not in configmap": {
+			variant:      variant.GCPSEVSNP{},
+			kubeClient:   &stubKubeClient{},
+			kdsClient:    &stubKdsClient{crlResponse: freshCRL},
+			wantCRL:      freshCRL,
+			wantKDSCalls: 1,
+		},
+		"configmap does not exist": {
+			variant:      variant.AzureSEVSNP{},
+			kubeClient:   &stubKubeClient{getConfigMapDataErr: notFoundErr},
+			kdsClient:    &stubKdsClient{crlResponse: freshCRL},
+			wantCRL:      freshCRL,
+			wantKDSCalls: 1,
+		},
+		"updating configmap fails": {
+			variant:      variant.AzureSEVSNP{},
+			kubeClient:   &stubKubeClient{updateConfigMapErr: assert.AnError},
+			kdsClient:    &stubKdsClient{crlResponse: freshCRL},
+			wantCRL:      freshCRL,
+			wantKDSCalls: 1,
+		},
+		"invalid CRL in configmap": {
+			variant:    variant.AzureSEVSNP{},
+			kubeClient: &stubKubeClient{crlResponse: "invalid"},
+			kdsClient:  &stubKdsClient{},
+			wantErr:    true,
+		},
+		"error getting configmap": {
+			variant:    variant.AzureSEVSNP{},
+			kubeClient: &stubKubeClient{getConfigMapDataErr: assert.AnError},
+			kdsClient:  &stubKdsClient{},
+			wantErr:    true,
+		},
+		"error getting CRL from KDS": {
+			variant:      variant.AzureSEVSNP{},
+			kubeClient:   &stubKubeClient{},
+			kdsClient:    &stubKdsClient{crlErr: assert.AnError},
+			wantKDSCalls: 1,
+			wantErr:      true,
+		},
+		"unsupported variant": {
+			variant:    variant.QEMUVTPM{},
+			kubeClient: &stubKubeClient{},
+			kdsClient:  &stubKdsClient{},
+			wantErr:    true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			c := &Client{
+				attVariant: tc.variant,
+				log:        logger.NewTest(t),
+				kubeClient: tc.kubeClient,
+				kdsClient:  tc.kdsClient,
+			}
+
+			crl, err := c.CRL(t.Context())
+			assert.Equal(tc.wantKDSCalls, tc.kdsClient.crlCalls)
+			if tc.wantErr {
+				assert.Error(err)
+				return
+			}
+			require.NoError(err)
+			assert.Equal(tc.wantCRL.Raw, crl.Raw)
+		})
+	}
+}
+
+func newTestCRL(t *testing.T, nextUpdate time.Time) *x509.RevocationList {
+	t.Helper()
+	require := require.New(t)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(err)
+	issuer := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "ARK"},
+		SubjectKeyId: []byte{1},
+		KeyUsage:     x509.KeyUsageCRLSign,
+	}
+	raw, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:     big.NewInt(1),
+		ThisUpdate: nextUpdate.Add(-2 * time.Hour),
+		NextUpdate: nextUpdate,
+	}, issuer, key)
+	require.NoError(err)
+	crl, err := x509.ParseRevocationList(raw)
+	require.NoError(err)
+	return crl
+}
+
+func crlToPEM(crl *x509.RevocationList) string {
+	return string(pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: crl.Raw}))
+}
+
 type stubKubeClient struct {
 	askResponse         string
 	arkResponse         string
 	createConfigMapErr  error
 	updateConfigMapErr  error
+	crlResponse         string
 	getConfigMapDataErr error
 }
 
@@ -239,6 +380,9 @@ func (s *stubKubeClient) GetConfigMapData(_ context.Context, _ string, key strin
 	if key == constants.CertCacheArkKey {
 		return s.arkResponse, s.getConfigMapDataErr
 	}
+	if key == constants.CertCacheCRLKey {
+		return s.crlResponse, s.getConfigMapDataErr
+	}
 	return "", s.getConfigMapDataErr
 }
 
@@ -9,19 +9,26 @@ package kms
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"log/slog"
+	"os"
+	"strings"
 
+	"github.com/edgelesssys/constellation/v2/internal/constants"
 	"github.com/edgelesssys/constellation/v2/keyservice/keyserviceproto"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
 )
 
 // Client interacts with Constellation's keyservice.
 type Client struct {
-	log      *slog.Logger
-	endpoint string
-	grpc     grpcClient
+	log       *slog.Logger
+	endpoint  string
+	grpc      grpcClient
+	readToken func() ([]byte, error)
 }
 
 // New creates a new KMS.
@@ -30,6 +37,9 @@ func New(log *slog.Logger, endpoint string) Client {
 		log:      log,
 		endpoint: endpoint,
 		grpc:     client{},
+		readToken: func() ([]byte, error) {
+			return os.ReadFile(constants.KeyServiceTokenPath)
+		},
 	}
 }
 
@@ -38,6 +48,11 @@ func (c Client) GetDataKey(ctx context.Context, keyID string, length int) ([]byt
 	log := c.log.With(slog.String("keyID", keyID), slog.String("endpoint", c.endpoint))
 	// the KMS does not use aTLS since traffic is only routed through the Constellation cluster
 	// cluster internal connections are considered trustworthy
+	// callers authenticate with their service account token, which the KMS uses to authorize the request
+	ctx, err := c.withToken(ctx)
+	if err != nil {
+		return nil, err
+	}
 	log.Info(fmt.Sprintf("Connecting to KMS at %s", c.endpoint))
 	conn, err := grpc.NewClient(c.endpoint, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	if err != nil {
@@ -62,6 +77,18 @@ func (c Client) GetDataKey(ctx context.Context, keyID string, length int) ([]byt
 	return res.DataKey, nil
 }
 
+// withToken attaches the projected service account token to the request, if one is mounted.
+func (c Client) withToken(ctx context.Context) (context.Context, error) {
+	token, err := c.readToken()
+	if errors.Is(err, fs.ErrNotExist) {
+		return ctx, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading service account token: %w", err)
+	}
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+strings.TrimSpace(string(token))), nil
+}
+
 type grpcClient interface {
 	GetDataKey(context.Context, *keyserviceproto.GetDataKeyRequest, *grpc.ClientConn) (*keyserviceproto.GetDataKeyResponse, error)
 }
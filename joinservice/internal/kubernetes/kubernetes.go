@@ -15,20 +15,29 @@ import (
 	"strings"
 	"time"
 
+	"github.com/edgelesssys/constellation/v2/internal/attestation/evidence"
 	"github.com/edgelesssys/constellation/v2/internal/constants"
+	"github.com/edgelesssys/constellation/v2/internal/denylist"
+	"github.com/edgelesssys/constellation/v2/internal/nodegroup"
 	"github.com/edgelesssys/constellation/v2/internal/versions/components"
 	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/retry"
 )
 
+// maxEvidenceShardSize is the maximum size of the data stored in a single evidence ConfigMap.
+// ConfigMaps are limited to 1 MiB, so a new shard is started well before that.
+const maxEvidenceShardSize = 768 * 1024
+
 // Client is a kubernetes client.
 type Client struct {
-	client    *kubernetes.Clientset
+	client    kubernetes.Interface
 	dynClient dynamic.Interface
 }
 
@@ -94,8 +103,145 @@ func (c *Client) GetK8sComponentsRefFromNodeVersionCRD(ctx context.Context, node
 	return k8sComponentsRef, nil
 }
 
+// GetDenyList returns the deny-list of the JoinService.
+// If the deny-list ConfigMap doesn't exist, an empty deny-list is returned.
+func (c *Client) GetDenyList(ctx context.Context) (denylist.DenyList, error) {
+	cm, err := c.client.CoreV1().ConfigMaps(constants.ConstellationNamespace).Get(ctx, constants.JoinDenyListConfigMap, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		return denylist.DenyList{}, nil
+	}
+	if err != nil {
+		return denylist.DenyList{}, fmt.Errorf("failed to get deny-list configmap: %w", err)
+	}
+	return denylist.Unmarshal(cm.Data[constants.JoinDenyListKey])
+}
+
+// GetNodeGroupConfig returns the Kubernetes node settings of the cluster's node groups.
+// An empty config is returned if no node group settings are stored in the cluster.
+func (c *Client) GetNodeGroupConfig(ctx context.Context) (nodegroup.Config, error) {
+	cm, err := c.client.CoreV1().ConfigMaps(constants.ConstellationNamespace).Get(ctx, constants.NodeGroupConfigMap, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		return nodegroup.Config{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get node group configmap: %w", err)
+	}
+	return nodegroup.Unmarshal(cm.Data[constants.NodeGroupConfigKey])
+}
+
+// AppendEvidence archives the attestation evidence of a joined node.
+// Records are appended to ConfigMaps sharded by day. Existing records are never modified.
+// Full shards are made immutable and the record is appended to the next shard of the day.
+func (c *Client) AppendEvidence(ctx context.Context, record evidence.Record) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("marshaling evidence: %w", err)
+	}
+	key := record.Key()
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		for shard := 0; ; shard++ {
+			name := fmt.Sprintf("%s-%s-%d", constants.JoinEvidenceConfigMapPrefix, record.Timestamp.UTC().Format("20060102"), shard)
+			cm, err := c.client.CoreV1().ConfigMaps(constants.ConstellationNamespace).Get(ctx, name, metav1.GetOptions{})
+			if k8serrors.IsNotFound(err) {
+				return c.createEvidenceShard(ctx, name, key, string(data))
+			}
+			if err != nil {
+				return fmt.Errorf("getting evidence configmap %s: %w", name, err)
+			}
+			if _, ok := cm.Data[key]; ok {
+				return fmt.Errorf("evidence %s already exists in configmap %s", key, name)
+			}
+			if cm.Immutable != nil && *cm.Immutable {
+				continue
+			}
+
+			if evidenceShardSize(cm)+len(key)+len(data) > maxEvidenceShardSize {
+				immutable := true
+				cm.Immutable = &immutable
+				if _, err := c.client.CoreV1().ConfigMaps(constants.ConstellationNamespace).Update(ctx, cm, metav1.UpdateOptions{}); err != nil {
+					return fmt.Errorf("sealing evidence configmap %s: %w", name, err)
+				}
+				continue
+			}
+
+			if cm.Data == nil {
+				cm.Data = map[string]string{}
+			}
+			cm.Data[key] = string(data)
+			if _, err := c.client.CoreV1().ConfigMaps(constants.ConstellationNamespace).Update(ctx, cm, metav1.UpdateOptions{}); err != nil {
+				return fmt.Errorf("updating evidence configmap %s: %w", name, err)
+			}
+			return nil
+		}
+	})
+}
+
+func (c *Client) createEvidenceShard(ctx context.Context, name, key, data string) error {
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: constants.ConstellationNamespace,
+			Labels:    map[string]string{constants.JoinEvidenceLabel: "true"},
+		},
+		Data: map[string]string{key: data},
+	}
+	_, err := c.client.CoreV1().ConfigMaps(constants.ConstellationNamespace).Create(ctx, cm, metav1.CreateOptions{})
+	if k8serrors.IsAlreadyExists(err) {
+		// Another replica created the shard concurrently. Report a conflict, so the caller retries.
+		return k8serrors.NewConflict(corev1.Resource("configmaps"), name, err)
+	}
+	if err != nil {
+		return fmt.Errorf("creating evidence configmap %s: %w", name, err)
+	}
+	return nil
+}
+
+// PruneEvidence deletes the evidence ConfigMaps of days that ended before the given time.
+func (c *Client) PruneEvidence(ctx context.Context, before time.Time) error {
+	cms, err := c.client.CoreV1().ConfigMaps(constants.ConstellationNamespace).List(ctx, metav1.ListOptions{
+		LabelSelector: constants.JoinEvidenceLabel + "=true",
+	})
+	if err != nil {
+		return fmt.Errorf("listing evidence configmaps: %w", err)
+	}
+	for _, cm := range cms.Items {
+		day, err := evidenceShardDay(cm.Name)
+		if err != nil {
+			continue
+		}
+		if day.AddDate(0, 0, 1).After(before) {
+			continue
+		}
+		err = c.client.CoreV1().ConfigMaps(constants.ConstellationNamespace).Delete(ctx, cm.Name, metav1.DeleteOptions{})
+		// another replica may have pruned the shard concurrently.
+		if err != nil && !k8serrors.IsNotFound(err) {
+			return fmt.Errorf("deleting evidence configmap %s: %w", cm.Name, err)
+		}
+	}
+	return nil
+}
+
+// evidenceShardDay returns the day an evidence ConfigMap holds records of.
+func evidenceShardDay(name string) (time.Time, error) {
+	day, _, ok := strings.Cut(strings.TrimPrefix(name, constants.JoinEvidenceConfigMapPrefix+"-"), "-")
+	if !ok {
+		return time.Time{}, fmt.Errorf("invalid evidence configmap name %s", name)
+	}
+	return time.Parse("20060102", day)
+}
+
+func evidenceShardSize(cm *corev1.ConfigMap) int {
+	var size int
+	for k, v := range cm.Data {
+		size += len(k) + len(v)
+	}
+	return size
+}
+
 // AddNodeToJoiningNodes adds the provided node as a joining node CRD.
-func (c *Client) AddNodeToJoiningNodes(ctx context.Context, nodeName string, componentsReference string, isControlPlane bool) error {
+// The UUID of the node's state disk is recorded, so the node can be revoked later on.
+func (c *Client) AddNodeToJoiningNodes(ctx context.Context, nodeName, componentsReference, diskUUID string, isControlPlane bool) error {
 	joiningNode := &unstructured.Unstructured{}
 
 	compliantNodeName, err := k8sCompliantHostname(nodeName)
@@ -121,6 +267,7 @@ func (c *Client) AddNodeToJoiningNodes(ctx context.Context, nodeName string, com
 		"spec": map[string]any{
 			"name":                compliantNodeName,
 			"componentsreference": componentsReference,
+			"diskuuid":            diskUUID,
 			"iscontrolplane":      isControlPlane,
 			"deadline":            deadline,
 		},
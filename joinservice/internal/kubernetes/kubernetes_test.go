@@ -7,10 +7,20 @@ SPDX-License-Identifier: BUSL-1.1
 package kubernetes
 
 import (
+	"fmt"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/edgelesssys/constellation/v2/internal/attestation/evidence"
+	"github.com/edgelesssys/constellation/v2/internal/constants"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/goleak"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
 )
 
 func TestMain(m *testing.M) {
@@ -61,3 +71,187 @@ func TestK8sCompliantHostname(t *testing.T) {
 		})
 	}
 }
+
+func TestAppendEvidence(t *testing.T) {
+	timestamp := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	record := evidence.Record{SchemaVersion: evidence.SchemaVersion, NodeName: "worker-0", Timestamp: timestamp}
+	shardName := func(shard int) string {
+		return fmt.Sprintf("%s-20240102-%d", constants.JoinEvidenceConfigMapPrefix, shard)
+	}
+	immutable := true
+
+	testCases := map[string]struct {
+		existing  []*corev1.ConfigMap
+		wantShard string
+		wantErr   bool
+	}{
+		"first record of the day": {
+			wantShard: shardName(0),
+		},
+		"record is appended": {
+			existing: []*corev1.ConfigMap{
+				{ObjectMeta: metav1.ObjectMeta{Name: shardName(0), Namespace: constants.ConstellationNamespace}, Data: map[string]string{"other": "{}"}},
+			},
+			wantShard: shardName(0),
+		},
+		"full shard is skipped": {
+			existing: []*corev1.ConfigMap{
+				{ObjectMeta: metav1.ObjectMeta{Name: shardName(0), Namespace: constants.ConstellationNamespace}, Data: map[string]string{"other": strings.Repeat("a", maxEvidenceShardSize)}},
+			},
+			wantShard: shardName(1),
+		},
+		"immutable shard is skipped": {
+			existing: []*corev1.ConfigMap{
+				{ObjectMeta: metav1.ObjectMeta{Name: shardName(0), Namespace: constants.ConstellationNamespace}, Immutable: &immutable},
+				{ObjectMeta: metav1.ObjectMeta{Name: shardName(1), Namespace: constants.ConstellationNamespace}, Data: map[string]string{"other": "{}"}},
+			},
+			wantShard: shardName(1),
+		},
+		"existing record is not overwritten": {
+			existing: []*corev1.ConfigMap{
+				{ObjectMeta: metav1.ObjectMeta{Name: shardName(0), Namespace: constants.ConstellationNamespace}, Data: map[string]string{record.Key(): "{}"}},
+			},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			clientset := fake.NewSimpleClientset()
+			for _, cm := range tc.existing {
+				_, err := clientset.CoreV1().ConfigMaps(constants.ConstellationNamespace).Create(t.Context(), cm, metav1.CreateOptions{})
+				require.NoError(err)
+			}
+			client := &Client{client: clientset}
+
+			err := client.AppendEvidence(t.Context(), record)
+			if tc.wantErr {
+				assert.Error(err)
+				return
+			}
+			require.NoError(err)
+
+			cm, err := clientset.CoreV1().ConfigMaps(constants.ConstellationNamespace).Get(t.Context(), tc.wantShard, metav1.GetOptions{})
+			require.NoError(err)
+			records, err := evidence.ReadRecords([]byte(cm.Data[record.Key()]))
+			require.NoError(err)
+			require.Len(records, 1)
+			assert.Equal(record.NodeName, records[0].NodeName)
+
+			if tc.wantShard != shardName(0) {
+				previous, err := clientset.CoreV1().ConfigMaps(constants.ConstellationNamespace).Get(t.Context(), shardName(0), metav1.GetOptions{})
+				require.NoError(err)
+				require.NotNil(previous.Immutable)
+				assert.True(*previous.Immutable)
+			}
+		})
+	}
+}
+
+func TestPruneEvidence(t *testing.T) {
+	shard := func(name string, labeled bool) *corev1.ConfigMap {
+		cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: constants.ConstellationNamespace}}
+		if labeled {
+			cm.Labels = map[string]string{constants.JoinEvidenceLabel: "true"}
+		}
+		return cm
+	}
+	before := time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC)
+
+	testCases := map[string]struct {
+		existing *corev1.ConfigMap
+		wantKept bool
+	}{
+		"older shard is deleted": {
+			existing: shard(constants.JoinEvidenceConfigMapPrefix+"-20231231-0", true),
+		},
+		"shard of the previous day is deleted": {
+			existing: shard(constants.JoinEvidenceConfigMapPrefix+"-20240101-3", true),
+		},
+		"shard of the current day is kept": {
+			existing: shard(constants.JoinEvidenceConfigMapPrefix+"-20240102-0", true),
+			wantKept: true,
+		},
+		"unlabeled configmap is kept": {
+			existing: shard(constants.JoinEvidenceConfigMapPrefix+"-20231231-0", false),
+			wantKept: true,
+		},
+		"invalid name is kept": {
+			existing: shard(constants.JoinEvidenceConfigMapPrefix+"-invalid", true),
+			wantKept: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			clientset := fake.NewSimpleClientset(tc.existing)
+			client := &Client{client: clientset}
+
+			require.NoError(client.PruneEvidence(t.Context(), before))
+
+			_, err := clientset.CoreV1().ConfigMaps(constants.ConstellationNamespace).Get(t.Context(), tc.existing.Name, metav1.GetOptions{})
+			if tc.wantKept {
+				assert.NoError(err)
+			} else {
+				assert.True(k8serrors.IsNotFound(err))
+			}
+		})
+	}
+}
+
+func TestGetNodeGroupConfig(t *testing.T) {
+	testCases := map[string]struct {
+		existing   *corev1.ConfigMap
+		wantGroups []string
+		wantErr    bool
+	}{
+		"no node group settings": {},
+		"node group settings": {
+			existing: &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: constants.NodeGroupConfigMap, Namespace: constants.ConstellationNamespace},
+				Data: map[string]string{
+					constants.NodeGroupConfigKey: `{"worker_default":{"role":"worker","labels":{"example.com/pool":"default"}}}`,
+				},
+			},
+			wantGroups: []string{"worker_default"},
+		},
+		"invalid node group settings": {
+			existing: &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: constants.NodeGroupConfigMap, Namespace: constants.ConstellationNamespace},
+				Data:       map[string]string{constants.NodeGroupConfigKey: "{"},
+			},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			clientset := fake.NewSimpleClientset()
+			if tc.existing != nil {
+				_, err := clientset.CoreV1().ConfigMaps(constants.ConstellationNamespace).Create(t.Context(), tc.existing, metav1.CreateOptions{})
+				require.NoError(err)
+			}
+			client := &Client{client: clientset}
+
+			config, err := client.GetNodeGroupConfig(t.Context())
+			if tc.wantErr {
+				assert.Error(err)
+				return
+			}
+			require.NoError(err)
+			assert.Len(config, len(tc.wantGroups))
+			for _, group := range tc.wantGroups {
+				assert.Contains(config, group)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package server
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"github.com/edgelesssys/constellation/v2/internal/attestation/snp"
+	"github.com/edgelesssys/constellation/v2/internal/attestation/vtpm"
+	"github.com/google/go-sev-guest/abi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// checkDenyList returns a gRPC status error if the calling node is on the deny-list.
+// The node is identified by the UUID of its state disk, its name, if known, and the ID of its attestation report.
+// Only the report ID is attested, the disk UUID and node name are taken from the request.
+func (s *Server) checkDenyList(ctx context.Context, log *slog.Logger, diskUUID, nodeName string) error {
+	denyList, err := s.kubeClient.GetDenyList(ctx)
+	if err != nil {
+		log.With(slog.Any("error", err)).Error("Failed to get deny-list")
+		return status.Errorf(codes.Unavailable, "getting deny-list: %s", err)
+	}
+	if denyList.IsEmpty() {
+		return nil
+	}
+
+	reportID, err := attestationReportID(ctx)
+	if err != nil {
+		log.With(slog.Any("error", err)).Error("Failed to get attestation report ID")
+		return status.Errorf(codes.Internal, "getting attestation report ID: %s", err)
+	}
+
+	if reason, denied := denyList.Denies(diskUUID, nodeName, reportID); denied {
+		log.With(slog.String("diskUUID", diskUUID), slog.String("nodeName", nodeName), slog.String("reportID", reportID)).
+			Warn(fmt.Sprintf("Denying revoked node: %s", reason))
+		return status.Errorf(codes.PermissionDenied, "node was revoked: %s", reason)
+	}
+	return nil
+}
+
+// attestationReportID returns the hex encoded ID of the SEV-SNP attestation report the caller attested itself with.
+// The attestation document was verified during the aTLS handshake and is embedded in the caller's certificate.
+// An empty string is returned if the caller didn't attest itself with an SEV-SNP report.
+func attestationReportID(ctx context.Context) (string, error) {
+	cert, ok := peerCertificate(ctx)
+	if !ok {
+		return "", nil
+	}
+
+	for _, ext := range cert.Extensions {
+		var attDoc vtpm.AttestationDocument
+		if err := json.Unmarshal(ext.Value, &attDoc); err != nil || len(attDoc.InstanceInfo) == 0 {
+			continue
+		}
+		var instanceInfo snp.InstanceInfo
+		if err := json.Unmarshal(attDoc.InstanceInfo, &instanceInfo); err != nil || len(instanceInfo.AttestationReport) == 0 {
+			continue
+		}
+		report, err := abi.ReportToProto(instanceInfo.AttestationReport)
+		if err != nil {
+			return "", fmt.Errorf("parsing attestation report: %w", err)
+		}
+		return hex.EncodeToString(report.ReportId), nil
+	}
+	return "", nil
+}
@@ -0,0 +1,88 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"encoding/json"
+	"net"
+	"testing"
+
+	"github.com/edgelesssys/constellation/v2/internal/attestation/snp"
+	"github.com/edgelesssys/constellation/v2/internal/attestation/snp/testdata"
+	"github.com/edgelesssys/constellation/v2/internal/attestation/vtpm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+func TestAttestationReportID(t *testing.T) {
+	report, err := hex.DecodeString(testdata.AttestationReportVLEK)
+	require.NoError(t, err)
+	// REPORT_ID is located at offset 0x140 of the attestation report.
+	wantReportID := hex.EncodeToString(report[0x140:0x160])
+
+	attDoc := func(instanceInfo any) []byte {
+		info, err := json.Marshal(instanceInfo)
+		require.NoError(t, err)
+		doc, err := json.Marshal(vtpm.AttestationDocument{InstanceInfo: info})
+		require.NoError(t, err)
+		return doc
+	}
+	withCert := func(extensions ...[]byte) context.Context {
+		cert := &x509.Certificate{}
+		for i, value := range extensions {
+			cert.Extensions = append(cert.Extensions, pkix.Extension{Id: asn1.ObjectIdentifier{1, 3, 9900, i}, Value: value})
+		}
+		return peer.NewContext(t.Context(), &peer.Peer{Addr: &net.TCPAddr{}, AuthInfo: credentials.TLSInfo{
+			State: tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}},
+		}})
+	}
+
+	testCases := map[string]struct {
+		ctx          context.Context
+		wantReportID string
+		wantErr      bool
+	}{
+		"SEV-SNP attestation": {
+			ctx:          withCert(attDoc(snp.InstanceInfo{AttestationReport: report})),
+			wantReportID: wantReportID,
+		},
+		"other extensions are skipped": {
+			ctx:          withCert([]byte("not JSON"), attDoc(snp.InstanceInfo{AttestationReport: report})),
+			wantReportID: wantReportID,
+		},
+		"attestation without SEV-SNP report": {
+			ctx: withCert(attDoc(map[string]string{"other": "info"})),
+		},
+		"invalid report": {
+			ctx:     withCert(attDoc(snp.InstanceInfo{AttestationReport: []byte{0x1, 0x2}})),
+			wantErr: true,
+		},
+		"no peer certificate": {
+			ctx: t.Context(),
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			reportID, err := attestationReportID(tc.ctx)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantReportID, reportID)
+		})
+	}
+}
@@ -0,0 +1,55 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package server
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+
+	"github.com/edgelesssys/constellation/v2/internal/attestation/evidence"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+// archiveEvidence archives the attestation evidence the calling node was accepted with.
+func (s *Server) archiveEvidence(ctx context.Context, nodeName string) error {
+	cert, ok := peerCertificate(ctx)
+	if !ok {
+		return errors.New("no peer certificate found")
+	}
+	record, err := s.evidence.PeerEvidence(cert)
+	if err != nil {
+		return fmt.Errorf("getting evidence: %w", err)
+	}
+	record.NodeName = nodeName
+	if err := s.kubeClient.AppendEvidence(ctx, record); err != nil {
+		return fmt.Errorf("storing evidence: %w", err)
+	}
+	return nil
+}
+
+// peerCertificate returns the aTLS certificate of the caller.
+// The attestation document embedded in the certificate was verified during the aTLS handshake.
+func peerCertificate(ctx context.Context) (*x509.Certificate, bool) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil, false
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.PeerCertificates) == 0 {
+		return nil, false
+	}
+	return tlsInfo.State.PeerCertificates[0], true
+}
+
+// evidenceRecorder records the evidence of attestation statements validated during aTLS handshakes.
+type evidenceRecorder interface {
+	// PeerEvidence returns the evidence of the attestation statement embedded in the given aTLS certificate.
+	PeerEvidence(cert *x509.Certificate) (evidence.Record, error)
+}
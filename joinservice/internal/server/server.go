@@ -16,11 +16,15 @@ import (
 	"time"
 
 	"github.com/edgelesssys/constellation/v2/internal/attestation"
+	"github.com/edgelesssys/constellation/v2/internal/attestation/evidence"
 	"github.com/edgelesssys/constellation/v2/internal/constants"
 	"github.com/edgelesssys/constellation/v2/internal/crypto"
+	"github.com/edgelesssys/constellation/v2/internal/denylist"
 	"github.com/edgelesssys/constellation/v2/internal/file"
 	"github.com/edgelesssys/constellation/v2/internal/grpc/grpclog"
 	"github.com/edgelesssys/constellation/v2/internal/logger"
+	"github.com/edgelesssys/constellation/v2/internal/nodegroup"
+	"github.com/edgelesssys/constellation/v2/internal/role"
 	"github.com/edgelesssys/constellation/v2/internal/versions/components"
 	"github.com/edgelesssys/constellation/v2/joinservice/joinproto"
 	"golang.org/x/crypto/ssh"
@@ -42,24 +46,30 @@ type Server struct {
 	dataKeyGetter   dataKeyGetter
 	ca              certificateAuthority
 	kubeClient      kubeClient
-	fileHandler     file.Handler
+	evidence        evidenceRecorder
+	// allowUnarchivedJoins admits nodes even if their attestation evidence can't be archived.
+	// By default, joins fail instead, so every node in the cluster has archived evidence.
+	allowUnarchivedJoins bool
+	fileHandler          file.Handler
 	joinproto.UnimplementedAPIServer
 }
 
 // New initializes a new Server.
 func New(
 	measurementSalt []byte, ca certificateAuthority,
-	joinTokenGetter joinTokenGetter, dataKeyGetter dataKeyGetter, kubeClient kubeClient, log *slog.Logger,
-	fileHandler file.Handler,
+	joinTokenGetter joinTokenGetter, dataKeyGetter dataKeyGetter, kubeClient kubeClient, recorder evidenceRecorder,
+	allowUnarchivedJoins bool, log *slog.Logger, fileHandler file.Handler,
 ) (*Server, error) {
 	return &Server{
-		measurementSalt: measurementSalt,
-		log:             log,
-		joinTokenGetter: joinTokenGetter,
-		dataKeyGetter:   dataKeyGetter,
-		ca:              ca,
-		kubeClient:      kubeClient,
-		fileHandler:     fileHandler,
+		measurementSalt:      measurementSalt,
+		log:                  log,
+		joinTokenGetter:      joinTokenGetter,
+		dataKeyGetter:        dataKeyGetter,
+		ca:                   ca,
+		kubeClient:           kubeClient,
+		evidence:             recorder,
+		allowUnarchivedJoins: allowUnarchivedJoins,
+		fileHandler:          fileHandler,
 	}, nil
 }
 
@@ -93,6 +103,17 @@ func (s *Server) IssueJoinTicket(ctx context.Context, req *joinproto.IssueJoinTi
 	log := s.log.With(slog.String("peerAddress", grpclog.PeerAddrFromContext(ctx)))
 	log.Info("IssueJoinTicket called")
 
+	nodeName, err := s.ca.GetNodeNameFromCSR(req.CertificateRequest)
+	if err != nil {
+		log.With(slog.Any("error", err)).Error("Failed getting node name from CSR")
+		return nil, status.Errorf(codes.Internal, "getting node name from CSR: %s", err)
+	}
+
+	log.Info("Checking deny-list")
+	if err := s.checkDenyList(ctx, log, req.DiskUuid, nodeName); err != nil {
+		return nil, err
+	}
+
 	log.Info("Requesting measurement secret")
 	measurementSecret, err := s.dataKeyGetter.GetDataKey(ctx, attestation.MeasurementSecretContext, crypto.DerivedKeyLengthDefault)
 	if err != nil {
@@ -156,6 +177,18 @@ func (s *Server) IssueJoinTicket(ctx context.Context, req *joinproto.IssueJoinTi
 		return nil, status.Errorf(codes.Internal, "getting components: %s", err)
 	}
 
+	log.Info("Querying node group settings")
+	nodeGroupConfig, err := s.kubeClient.GetNodeGroupConfig(ctx)
+	if err != nil {
+		log.With(slog.Any("error", err)).Error("Failed getting node group settings")
+		return nil, status.Errorf(codes.Internal, "getting node group settings: %s", err)
+	}
+	nodeRole := role.Worker
+	if req.IsControlPlane {
+		nodeRole = role.ControlPlane
+	}
+	nodeGroupSettings := nodeGroupConfig.Lookup(req.NodeGroupName, nodeRole)
+
 	log.Info("Creating signed kubelet certificate")
 	kubeletCert, err := s.ca.GetCertificate(req.CertificateRequest)
 	if err != nil {
@@ -180,13 +213,16 @@ func (s *Server) IssueJoinTicket(ctx context.Context, req *joinproto.IssueJoinTi
 		}
 	}
 
-	nodeName, err := s.ca.GetNodeNameFromCSR(req.CertificateRequest)
-	if err != nil {
-		log.With(slog.Any("error", err)).Error("Failed getting node name from CSR")
-		return nil, status.Errorf(codes.Internal, "getting node name from CSR: %s", err)
+	log.Info("Archiving attestation evidence")
+	if err := s.archiveEvidence(ctx, nodeName); err != nil {
+		if !s.allowUnarchivedJoins {
+			log.With(slog.Any("error", err)).Error("Failed to archive attestation evidence")
+			return nil, status.Errorf(codes.Internal, "archiving attestation evidence: %s", err)
+		}
+		log.With(slog.Any("error", err)).Warn("Failed to archive attestation evidence, admitting node without archived evidence")
 	}
 
-	if err := s.kubeClient.AddNodeToJoiningNodes(ctx, nodeName, componentsConfigMapName, req.IsControlPlane); err != nil {
+	if err := s.kubeClient.AddNodeToJoiningNodes(ctx, nodeName, componentsConfigMapName, req.DiskUuid, req.IsControlPlane); err != nil {
 		log.With(slog.Any("error", err)).Error("Failed adding node to joining nodes")
 		return nil, status.Errorf(codes.Internal, "adding node to joining nodes: %s", err)
 	}
@@ -204,6 +240,7 @@ func (s *Server) IssueJoinTicket(ctx context.Context, req *joinproto.IssueJoinTi
 		KubernetesComponents:     components,
 		AuthorizedCaPublicKey:    ssh.MarshalAuthorizedKey(ca.PublicKey()),
 		HostCertificate:          ssh.MarshalAuthorizedKey(hostCertificate),
+		NodeGroupSettings:        nodeGroupSettings,
 	}, nil
 }
 
@@ -212,6 +249,11 @@ func (s *Server) IssueRejoinTicket(ctx context.Context, req *joinproto.IssueRejo
 	log := s.log.With(slog.String("peerAddress", grpclog.PeerAddrFromContext(ctx)))
 	log.Info("IssueRejoinTicket called")
 
+	log.Info("Checking deny-list")
+	if err := s.checkDenyList(ctx, log, req.DiskUuid, ""); err != nil {
+		return nil, err
+	}
+
 	log.Info("Requesting measurement secret")
 	measurementSecret, err := s.dataKeyGetter.GetDataKey(ctx, attestation.MeasurementSecretContext, crypto.DerivedKeyLengthDefault)
 	if err != nil {
@@ -265,7 +307,10 @@ type certificateAuthority interface {
 type kubeClient interface {
 	GetK8sComponentsRefFromNodeVersionCRD(ctx context.Context, nodeName string) (string, error)
 	GetComponents(ctx context.Context, configMapName string) (components.Components, error)
-	AddNodeToJoiningNodes(ctx context.Context, nodeName string, componentsHash string, isControlPlane bool) error
+	AddNodeToJoiningNodes(ctx context.Context, nodeName, componentsHash, diskUUID string, isControlPlane bool) error
+	GetDenyList(ctx context.Context) (denylist.DenyList, error)
+	AppendEvidence(ctx context.Context, record evidence.Record) error
+	GetNodeGroupConfig(ctx context.Context) (nodegroup.Config, error)
 }
 
 func (s *Server) extendPrincipals(principals []string) []string {
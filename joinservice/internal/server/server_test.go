@@ -9,14 +9,20 @@ package server
 import (
 	"context"
 	"crypto/ed25519"
+	"crypto/tls"
+	"crypto/x509"
 	"errors"
+	"net"
 	"testing"
 	"time"
 
 	"github.com/edgelesssys/constellation/v2/internal/attestation"
+	"github.com/edgelesssys/constellation/v2/internal/attestation/evidence"
 	"github.com/edgelesssys/constellation/v2/internal/constants"
+	"github.com/edgelesssys/constellation/v2/internal/denylist"
 	"github.com/edgelesssys/constellation/v2/internal/file"
 	"github.com/edgelesssys/constellation/v2/internal/logger"
+	"github.com/edgelesssys/constellation/v2/internal/nodegroup"
 	"github.com/edgelesssys/constellation/v2/internal/versions/components"
 	"github.com/edgelesssys/constellation/v2/joinservice/joinproto"
 	"github.com/spf13/afero"
@@ -24,6 +30,8 @@ import (
 	"github.com/stretchr/testify/require"
 	"go.uber.org/goleak"
 	"golang.org/x/crypto/ssh"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
 	kubeadmv1 "k8s.io/kubernetes/cmd/kubeadm/app/apis/kubeadm/v1beta3"
 )
 
@@ -59,15 +67,32 @@ func TestIssueJoinTicket(t *testing.T) {
 		},
 	}
 
+	gpuSettings := &nodegroup.Settings{
+		Role:           "worker",
+		Labels:         map[string]string{"example.com/pool": "gpu"},
+		Taints:         []*nodegroup.Taint{{Key: "example.com/dedicated", Value: "gpu", Effect: "NoSchedule"}},
+		KubeletMaxPods: 50,
+	}
+	nodeGroups := nodegroup.Config{
+		"control_plane_default": {Role: "control-plane"},
+		"worker_default":        {Role: "worker"},
+		"gpu":                   gpuSettings,
+	}
+
 	testCases := map[string]struct {
 		isControlPlane                  bool
+		nodeGroupName                   string
 		kubeadm                         stubTokenGetter
 		kms                             stubKeyGetter
 		ca                              stubCA
 		kubeClient                      stubKubeClient
+		evidence                        stubEvidenceRecorder
 		missingComponentsReferenceFile  bool
 		missingAdditionalPrincipalsFile bool
 		missingSSHHostKey               bool
+		missingPeerCertificate          bool
+		allowUnarchivedJoins            bool
+		wantNodeGroupSettings           *nodegroup.Settings
 		wantErr                         bool
 	}{
 		"worker node": {
@@ -200,6 +225,61 @@ func TestIssueJoinTicket(t *testing.T) {
 			kubeClient:                      stubKubeClient{getComponentsVal: clusterComponents, getK8sComponentsRefFromNodeVersionCRDVal: "k8s-components-ref"},
 			missingAdditionalPrincipalsFile: true,
 		},
+		"revoked disk": {
+			kubeadm: stubTokenGetter{token: testJoinToken},
+			kms: stubKeyGetter{dataKeys: map[string][]byte{
+				uuid:                                 testKey,
+				attestation.MeasurementSecretContext: measurementSecret,
+				constants.SSHCAKeySuffix:             testCaKey,
+			}},
+			ca: stubCA{cert: testCert, nodeName: "node"},
+			kubeClient: stubKubeClient{
+				getComponentsVal: clusterComponents, getK8sComponentsRefFromNodeVersionCRDVal: "k8s-components-ref",
+				denyList: denylist.DenyList{DiskUUIDs: []string{uuid}},
+			},
+			wantErr: true,
+		},
+		"revoked node": {
+			kubeadm: stubTokenGetter{token: testJoinToken},
+			kms: stubKeyGetter{dataKeys: map[string][]byte{
+				uuid:                                 testKey,
+				attestation.MeasurementSecretContext: measurementSecret,
+				constants.SSHCAKeySuffix:             testCaKey,
+			}},
+			ca: stubCA{cert: testCert, nodeName: "node"},
+			kubeClient: stubKubeClient{
+				getComponentsVal: clusterComponents, getK8sComponentsRefFromNodeVersionCRDVal: "k8s-components-ref",
+				denyList: denylist.DenyList{NodeNames: []string{"node"}},
+			},
+			wantErr: true,
+		},
+		"other nodes revoked": {
+			kubeadm: stubTokenGetter{token: testJoinToken},
+			kms: stubKeyGetter{dataKeys: map[string][]byte{
+				uuid:                                 testKey,
+				attestation.MeasurementSecretContext: measurementSecret,
+				constants.SSHCAKeySuffix:             testCaKey,
+			}},
+			ca: stubCA{cert: testCert, nodeName: "node"},
+			kubeClient: stubKubeClient{
+				getComponentsVal: clusterComponents, getK8sComponentsRefFromNodeVersionCRDVal: "k8s-components-ref",
+				denyList: denylist.DenyList{DiskUUIDs: []string{"other-uuid"}, NodeNames: []string{"other-node"}},
+			},
+		},
+		"deny-list unavailable": {
+			kubeadm: stubTokenGetter{token: testJoinToken},
+			kms: stubKeyGetter{dataKeys: map[string][]byte{
+				uuid:                                 testKey,
+				attestation.MeasurementSecretContext: measurementSecret,
+				constants.SSHCAKeySuffix:             testCaKey,
+			}},
+			ca: stubCA{cert: testCert, nodeName: "node"},
+			kubeClient: stubKubeClient{
+				getComponentsVal: clusterComponents, getK8sComponentsRefFromNodeVersionCRDVal: "k8s-components-ref",
+				denyListErr: someErr,
+			},
+			wantErr: true,
+		},
 		"Host pubkey is missing": {
 			kubeadm: stubTokenGetter{token: testJoinToken},
 			kms: stubKeyGetter{dataKeys: map[string][]byte{
@@ -212,6 +292,81 @@ func TestIssueJoinTicket(t *testing.T) {
 			missingSSHHostKey: true,
 			wantErr:           true,
 		},
+		"peer certificate is missing": {
+			kubeadm: stubTokenGetter{token: testJoinToken},
+			kms: stubKeyGetter{dataKeys: map[string][]byte{
+				uuid:                                 testKey,
+				attestation.MeasurementSecretContext: measurementSecret,
+				constants.SSHCAKeySuffix:             testCaKey,
+			}},
+			ca:                     stubCA{cert: testCert, nodeName: "node"},
+			kubeClient:             stubKubeClient{getComponentsVal: clusterComponents, getK8sComponentsRefFromNodeVersionCRDVal: "k8s-components-ref"},
+			missingPeerCertificate: true,
+			wantErr:                true,
+		},
+		"evidence is missing": {
+			kubeadm: stubTokenGetter{token: testJoinToken},
+			kms: stubKeyGetter{dataKeys: map[string][]byte{
+				uuid:                                 testKey,
+				attestation.MeasurementSecretContext: measurementSecret,
+				constants.SSHCAKeySuffix:             testCaKey,
+			}},
+			ca:         stubCA{cert: testCert, nodeName: "node"},
+			kubeClient: stubKubeClient{getComponentsVal: clusterComponents, getK8sComponentsRefFromNodeVersionCRDVal: "k8s-components-ref"},
+			evidence:   stubEvidenceRecorder{err: someErr},
+			wantErr:    true,
+		},
+		"node group settings": {
+			nodeGroupName: "gpu",
+			kubeadm:       stubTokenGetter{token: testJoinToken},
+			kms: stubKeyGetter{dataKeys: map[string][]byte{
+				uuid:                                 testKey,
+				attestation.MeasurementSecretContext: measurementSecret,
+				constants.SSHCAKeySuffix:             testCaKey,
+			}},
+			ca: stubCA{cert: testCert, nodeName: "node"},
+			kubeClient: stubKubeClient{
+				getComponentsVal: clusterComponents, getK8sComponentsRefFromNodeVersionCRDVal: "k8s-components-ref",
+				nodeGroupConfig: nodeGroups,
+			},
+			wantNodeGroupSettings: gpuSettings,
+		},
+		"node group settings unavailable": {
+			kubeadm: stubTokenGetter{token: testJoinToken},
+			kms: stubKeyGetter{dataKeys: map[string][]byte{
+				uuid:                                 testKey,
+				attestation.MeasurementSecretContext: measurementSecret,
+				constants.SSHCAKeySuffix:             testCaKey,
+			}},
+			ca: stubCA{cert: testCert, nodeName: "node"},
+			kubeClient: stubKubeClient{
+				getComponentsVal: clusterComponents, getK8sComponentsRefFromNodeVersionCRDVal: "k8s-components-ref",
+				nodeGroupConfigErr: someErr,
+			},
+			wantErr: true,
+		},
+		"Cannot archive evidence": {
+			kubeadm: stubTokenGetter{token: testJoinToken},
+			kms: stubKeyGetter{dataKeys: map[string][]byte{
+				uuid:                                 testKey,
+				attestation.MeasurementSecretContext: measurementSecret,
+				constants.SSHCAKeySuffix:             testCaKey,
+			}},
+			ca:         stubCA{cert: testCert, nodeName: "node"},
+			kubeClient: stubKubeClient{getComponentsVal: clusterComponents, appendEvidenceErr: someErr, getK8sComponentsRefFromNodeVersionCRDVal: "k8s-components-ref"},
+			wantErr:    true,
+		},
+		"Cannot archive evidence, unarchived joins allowed": {
+			kubeadm: stubTokenGetter{token: testJoinToken},
+			kms: stubKeyGetter{dataKeys: map[string][]byte{
+				uuid:                                 testKey,
+				attestation.MeasurementSecretContext: measurementSecret,
+				constants.SSHCAKeySuffix:             testCaKey,
+			}},
+			ca:                   stubCA{cert: testCert, nodeName: "node"},
+			kubeClient:           stubKubeClient{getComponentsVal: clusterComponents, appendEvidenceErr: someErr, getK8sComponentsRefFromNodeVersionCRDVal: "k8s-components-ref"},
+			allowUnarchivedJoins: true,
+		},
 	}
 
 	for name, tc := range testCases {
@@ -227,13 +382,22 @@ func TestIssueJoinTicket(t *testing.T) {
 			}
 
 			api := Server{
-				measurementSalt: salt,
-				ca:              tc.ca,
-				joinTokenGetter: tc.kubeadm,
-				dataKeyGetter:   tc.kms,
-				kubeClient:      &tc.kubeClient,
-				log:             logger.NewTest(t),
-				fileHandler:     fh,
+				measurementSalt:      salt,
+				ca:                   tc.ca,
+				joinTokenGetter:      tc.kubeadm,
+				dataKeyGetter:        tc.kms,
+				kubeClient:           &tc.kubeClient,
+				evidence:             tc.evidence,
+				allowUnarchivedJoins: tc.allowUnarchivedJoins,
+				log:                  logger.NewTest(t),
+				fileHandler:          fh,
+			}
+
+			ctx := t.Context()
+			if !tc.missingPeerCertificate {
+				ctx = peer.NewContext(ctx, &peer.Peer{Addr: &net.TCPAddr{}, AuthInfo: credentials.TLSInfo{
+					State: tls.ConnectionState{PeerCertificates: []*x509.Certificate{{}}},
+				}})
 			}
 
 			var keyToSend []byte
@@ -247,8 +411,9 @@ func TestIssueJoinTicket(t *testing.T) {
 				DiskUuid:       "uuid",
 				IsControlPlane: tc.isControlPlane,
 				HostPublicKey:  keyToSend,
+				NodeGroupName:  tc.nodeGroupName,
 			}
-			resp, err := api.IssueJoinTicket(t.Context(), req)
+			resp, err := api.IssueJoinTicket(ctx, req)
 			if tc.wantErr {
 				assert.Error(err)
 				return
@@ -265,6 +430,9 @@ func TestIssueJoinTicket(t *testing.T) {
 			assert.Equal(tc.kubeClient.getComponentsVal, resp.KubernetesComponents)
 			assert.Equal(tc.ca.nodeName, tc.kubeClient.joiningNodeName)
 			assert.Equal(tc.kubeClient.getK8sComponentsRefFromNodeVersionCRDVal, tc.kubeClient.componentsRef)
+			assert.Equal(uuid, tc.kubeClient.diskUUID)
+			assert.Equal(tc.ca.nodeName, tc.kubeClient.evidence.NodeName)
+			assert.Equal(tc.wantNodeGroupSettings, resp.NodeGroupSettings)
 
 			if tc.isControlPlane {
 				assert.Len(resp.ControlPlaneFiles, len(tc.kubeadm.files))
@@ -277,8 +445,9 @@ func TestIssueRejoinTicker(t *testing.T) {
 	uuid := "uuid"
 
 	testCases := map[string]struct {
-		keyGetter stubKeyGetter
-		wantErr   bool
+		keyGetter  stubKeyGetter
+		kubeClient stubKubeClient
+		wantErr    bool
 	}{
 		"success": {
 			keyGetter: stubKeyGetter{
@@ -288,6 +457,26 @@ func TestIssueRejoinTicker(t *testing.T) {
 				},
 			},
 		},
+		"revoked disk": {
+			keyGetter: stubKeyGetter{
+				dataKeys: map[string][]byte{
+					uuid:                                 {0x1, 0x2, 0x3},
+					attestation.MeasurementSecretContext: {0x4, 0x5, 0x6},
+				},
+			},
+			kubeClient: stubKubeClient{denyList: denylist.DenyList{DiskUUIDs: []string{uuid}}},
+			wantErr:    true,
+		},
+		"deny-list unavailable": {
+			keyGetter: stubKeyGetter{
+				dataKeys: map[string][]byte{
+					uuid:                                 {0x1, 0x2, 0x3},
+					attestation.MeasurementSecretContext: {0x4, 0x5, 0x6},
+				},
+			},
+			kubeClient: stubKubeClient{denyListErr: errors.New("error")},
+			wantErr:    true,
+		},
 		"failure": {
 			keyGetter: stubKeyGetter{
 				dataKeys:      make(map[string][]byte),
@@ -306,6 +495,7 @@ func TestIssueRejoinTicker(t *testing.T) {
 				ca:              stubCA{},
 				joinTokenGetter: stubTokenGetter{},
 				dataKeyGetter:   tc.keyGetter,
+				kubeClient:      &tc.kubeClient,
 				log:             logger.NewTest(t),
 				fileHandler:     file.NewHandler(afero.NewMemMapFs()),
 			}
@@ -375,6 +565,16 @@ type stubKubeClient struct {
 	addNodeToJoiningNodesErr error
 	joiningNodeName          string
 	componentsRef            string
+	diskUUID                 string
+
+	denyList    denylist.DenyList
+	denyListErr error
+
+	appendEvidenceErr error
+	evidence          evidence.Record
+
+	nodeGroupConfig    nodegroup.Config
+	nodeGroupConfigErr error
 }
 
 func (s *stubKubeClient) GetK8sComponentsRefFromNodeVersionCRD(_ context.Context, _ string) (string, error) {
@@ -385,12 +585,35 @@ func (s *stubKubeClient) GetComponents(_ context.Context, _ string) (components.
 	return s.getComponentsVal, s.getComponentsErr
 }
 
-func (s *stubKubeClient) AddNodeToJoiningNodes(_ context.Context, nodeName string, componentsRef string, _ bool) error {
+func (s *stubKubeClient) AddNodeToJoiningNodes(_ context.Context, nodeName, componentsRef, diskUUID string, _ bool) error {
 	s.joiningNodeName = nodeName
 	s.componentsRef = componentsRef
+	s.diskUUID = diskUUID
 	return s.addNodeToJoiningNodesErr
 }
 
+func (s *stubKubeClient) GetDenyList(context.Context) (denylist.DenyList, error) {
+	return s.denyList, s.denyListErr
+}
+
+func (s *stubKubeClient) AppendEvidence(_ context.Context, record evidence.Record) error {
+	s.evidence = record
+	return s.appendEvidenceErr
+}
+
+func (s *stubKubeClient) GetNodeGroupConfig(context.Context) (nodegroup.Config, error) {
+	return s.nodeGroupConfig, s.nodeGroupConfigErr
+}
+
+type stubEvidenceRecorder struct {
+	record evidence.Record
+	err    error
+}
+
+func (s stubEvidenceRecorder) PeerEvidence(*x509.Certificate) (evidence.Record, error) {
+	return s.record, s.err
+}
+
 const clusterConfig = `
 apiServer:
   certSANs:
@@ -8,21 +8,31 @@ package watcher
 
 import (
 	"context"
+	"crypto/sha256"
 	"crypto/x509"
 	"encoding/asn1"
+	"errors"
 	"fmt"
 	"log/slog"
 	"path/filepath"
 	"sync"
+	"time"
 
 	"github.com/edgelesssys/constellation/v2/internal/atls"
 	"github.com/edgelesssys/constellation/v2/internal/attestation/choose"
+	"github.com/edgelesssys/constellation/v2/internal/attestation/evidence"
 	"github.com/edgelesssys/constellation/v2/internal/attestation/variant"
 	"github.com/edgelesssys/constellation/v2/internal/config"
 	"github.com/edgelesssys/constellation/v2/internal/constants"
 	"github.com/edgelesssys/constellation/v2/internal/file"
 )
 
+// crlTimeout is the timeout for retrieving the AMD CRL from the certificate cache.
+const crlTimeout = 30 * time.Second
+
+// evidenceTTL is the time the evidence of a validated attestation statement is kept for retrieval by PeerEvidence.
+const evidenceTTL = 10 * time.Minute
+
 // Updatable implements an updatable atls.Validator.
 type Updatable struct {
 	log         *slog.Logger
@@ -30,16 +40,22 @@ type Updatable struct {
 	fileHandler file.Handler
 	variant     variant.Variant
 	cachedCerts cachedCerts
+	crls        crlCache
+	configHash  string
+	evidence    map[[sha256.Size]byte]evidence.Record
+	// crlNextUpdate is the next update of the cached CRL used by the validator, if any.
+	crlNextUpdate time.Time
 	atls.Validator
 }
 
 // NewValidator initializes a new updatable validator and performs an initial update (aka. initialization).
-func NewValidator(log *slog.Logger, variant variant.Variant, fileHandler file.Handler, cachedCerts cachedCerts) (*Updatable, error) {
+func NewValidator(log *slog.Logger, variant variant.Variant, fileHandler file.Handler, cachedCerts cachedCerts, crls crlCache) (*Updatable, error) {
 	u := &Updatable{
 		log:         log,
 		fileHandler: fileHandler,
 		variant:     variant,
 		cachedCerts: cachedCerts,
+		crls:        crls,
 	}
 	err := u.Update()
 
@@ -50,11 +66,63 @@ type cachedCerts interface {
 	SevSnpCerts() (ask *x509.Certificate, ark *x509.Certificate)
 }
 
+type crlCache interface {
+	CRL(ctx context.Context) (*x509.RevocationList, error)
+}
+
 // Validate calls the validators Validate method, and prevents any updates during the call.
+// The evidence of successfully validated attestation statements is kept for retrieval by PeerEvidence.
 func (u *Updatable) Validate(ctx context.Context, attDoc []byte, nonce []byte) ([]byte, error) {
 	u.mux.Lock()
 	defer u.mux.Unlock()
-	return u.Validator.Validate(ctx, attDoc, nonce)
+	if err := u.refreshOutdatedCRL(); err != nil {
+		return nil, err
+	}
+	userData, err := u.Validator.Validate(ctx, attDoc, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if u.evidence == nil {
+		u.evidence = make(map[[sha256.Size]byte]evidence.Record)
+	}
+	for digest, record := range u.evidence {
+		if now.Sub(record.Timestamp) > evidenceTTL {
+			delete(u.evidence, digest)
+		}
+	}
+	u.evidence[sha256.Sum256(attDoc)] = evidence.Record{
+		SchemaVersion:       evidence.SchemaVersion,
+		Variant:             u.variant.String(),
+		Timestamp:           now.UTC(),
+		Nonce:               nonce,
+		UserData:            userData,
+		AttestationDocument: attDoc,
+		ConfigHash:          u.configHash,
+	}
+	return userData, nil
+}
+
+// PeerEvidence returns the evidence of the attestation statement embedded in the given aTLS certificate of a peer.
+// The statement must have been validated by the validator before.
+// The evidence is removed from the validator, so it can only be retrieved once.
+func (u *Updatable) PeerEvidence(cert *x509.Certificate) (evidence.Record, error) {
+	u.mux.Lock()
+	defer u.mux.Unlock()
+	for _, ex := range cert.Extensions {
+		if !ex.Id.Equal(u.Validator.OID()) {
+			continue
+		}
+		digest := sha256.Sum256(ex.Value)
+		record, ok := u.evidence[digest]
+		if !ok {
+			return evidence.Record{}, errors.New("no evidence found for attestation statement of peer")
+		}
+		delete(u.evidence, digest)
+		return record, nil
+	}
+	return evidence.Record{}, errors.New("peer certificate does not contain an attestation statement")
 }
 
 // OID returns the validators Object Identifier.
@@ -68,7 +136,26 @@ func (u *Updatable) OID() asn1.ObjectIdentifier {
 func (u *Updatable) Update() error {
 	u.mux.Lock()
 	defer u.mux.Unlock()
+	return u.update()
+}
+
+// refreshOutdatedCRL updates the validator if the cached CRL it uses is due for its next update.
+// An outdated cached CRL doesn't list recent revocations, so attestation statements are rejected if the refresh fails.
+func (u *Updatable) refreshOutdatedCRL() error {
+	if u.crlNextUpdate.IsZero() || time.Now().Before(u.crlNextUpdate) {
+		return nil
+	}
+	u.log.Info("Cached CRL is outdated, updating validator")
+	if err := u.update(); err != nil {
+		return fmt.Errorf("updating validator with outdated CRL: %w", err)
+	}
+	if !u.crlNextUpdate.IsZero() && !time.Now().Before(u.crlNextUpdate) {
+		return fmt.Errorf("cached CRL expired at %s", u.crlNextUpdate)
+	}
+	return nil
+}
 
+func (u *Updatable) update() error {
 	u.log.Info("Updating expected measurements")
 
 	data, err := u.fileHandler.Read(filepath.Join(constants.ServiceBasePath, constants.AttestationConfigFilename))
@@ -81,6 +168,12 @@ func (u *Updatable) Update() error {
 	}
 	u.log.Debug(fmt.Sprintf("New expected measurements: %s", cfg.GetMeasurements().String()))
 
+	configHash, err := evidence.ConfigHash(cfg)
+	if err != nil {
+		return fmt.Errorf("hashing config: %w", err)
+	}
+
+	pinnedCRL := amdCRL(cfg)
 	cfgWithCerts, err := u.configWithCerts(cfg)
 	if err != nil {
 		return fmt.Errorf("adding cached certificates: %w", err)
@@ -91,6 +184,12 @@ func (u *Updatable) Update() error {
 		return fmt.Errorf("choosing validator: %w", err)
 	}
 	u.Validator = validator
+	u.configHash = configHash
+	// a CRL pinned in the config is used as is, only the cached CRL is refreshed.
+	u.crlNextUpdate = time.Time{}
+	if cachedCRL := amdCRL(cfgWithCerts); pinnedCRL.IsEmpty() && !cachedCRL.IsEmpty() {
+		u.crlNextUpdate = cachedCRL.NextUpdate
+	}
 
 	return nil
 }
@@ -104,6 +203,9 @@ func (u *Updatable) configWithCerts(cfg config.AttestationCfg) (config.Attestati
 			return nil, fmt.Errorf("getting cached ASK certificate: %w", err)
 		}
 		c.AMDSigningKey = config.Certificate(ask)
+		if c.CheckRevocations && c.AMDCRL.IsEmpty() {
+			c.AMDCRL = u.getCachedCRL()
+		}
 		return c, nil
 	case *config.AWSSEVSNP:
 		ask, err := u.getCachedAskCert()
@@ -111,12 +213,48 @@ func (u *Updatable) configWithCerts(cfg config.AttestationCfg) (config.Attestati
 			return nil, fmt.Errorf("getting cached ASK certificate: %w", err)
 		}
 		c.AMDSigningKey = config.Certificate(ask)
+		if c.CheckRevocations && c.AMDCRL.IsEmpty() {
+			c.AMDCRL = u.getCachedCRL()
+		}
+		return c, nil
+	case *config.QEMUSEVSNP:
+		// Bare-metal hosts may not provide the ASK, so fall back to the cached one if none is configured.
+		if c.AMDSigningKey.Equal(config.Certificate{}) {
+			ask, err := u.getCachedAskCert()
+			if err != nil {
+				return nil, fmt.Errorf("getting cached ASK certificate: %w", err)
+			}
+			c.AMDSigningKey = config.Certificate(ask)
+		}
+		if c.CheckRevocations && c.AMDCRL.IsEmpty() {
+			c.AMDCRL = u.getCachedCRL()
+		}
+		return c, nil
+	case *config.GCPSEVSNP:
+		if c.CheckRevocations && c.AMDCRL.IsEmpty() {
+			c.AMDCRL = u.getCachedCRL()
+		}
 		return c, nil
 	}
 
 	return cfg, nil
 }
 
+// amdCRL returns the AMD CRL set in the attestation config, if any.
+func amdCRL(cfg config.AttestationCfg) config.CRL {
+	switch c := cfg.(type) {
+	case *config.AzureSEVSNP:
+		return c.AMDCRL
+	case *config.AWSSEVSNP:
+		return c.AMDCRL
+	case *config.QEMUSEVSNP:
+		return c.AMDCRL
+	case *config.GCPSEVSNP:
+		return c.AMDCRL
+	}
+	return config.CRL{}
+}
+
 // getCachedAskCert returns the cached SEV-SNP ASK certificate.
 func (u *Updatable) getCachedAskCert() (x509.Certificate, error) {
 	if u.cachedCerts == nil {
@@ -128,3 +266,19 @@ func (u *Updatable) getCachedAskCert() (x509.Certificate, error) {
 	}
 	return *ask, nil
 }
+
+// getCachedCRL returns the AMD CRL from the certificate cache.
+// If the CRL can't be retrieved, an empty CRL is returned and the validator retrieves the CRL from the AMD KDS itself.
+func (u *Updatable) getCachedCRL() config.CRL {
+	if u.crls == nil {
+		return config.CRL{}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), crlTimeout)
+	defer cancel()
+	crl, err := u.crls.CRL(ctx)
+	if err != nil {
+		u.log.Warn(fmt.Sprintf("Failed to get cached CRL, falling back to retrieving it from AMD KDS: %v", err))
+		return config.CRL{}
+	}
+	return config.CRL(*crl)
+}
@@ -8,17 +8,24 @@ package watcher
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
 	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/asn1"
 	"encoding/json"
 	"io"
+	"math/big"
 	"net/http"
 	"net/http/httptest"
 	"path/filepath"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/edgelesssys/constellation/v2/internal/atls"
+	"github.com/edgelesssys/constellation/v2/internal/attestation/evidence"
 	"github.com/edgelesssys/constellation/v2/internal/attestation/measurements"
 	"github.com/edgelesssys/constellation/v2/internal/attestation/variant"
 	"github.com/edgelesssys/constellation/v2/internal/config"
@@ -91,6 +98,7 @@ func TestNewUpdateableValidator(t *testing.T) {
 				tc.variant,
 				handler,
 				tc.snpCerts,
+				nil,
 			)
 			if tc.wantErr {
 				assert.Error(err)
@@ -110,6 +118,104 @@ func (s *stubSnpCerts) SevSnpCerts() (ask *x509.Certificate, ark *x509.Certifica
 	return s.ask, s.ark
 }
 
+func TestConfigWithCerts(t *testing.T) {
+	crl := x509.RevocationList{Raw: []byte("cached CRL")}
+	pinnedCRL := config.CRL{Raw: []byte("pinned CRL")}
+
+	testCases := map[string]struct {
+		config     func() config.AttestationCfg
+		crls       *stubCRLCache
+		wantCRL    config.CRL
+		wantCalled bool
+	}{
+		"revocation checks disabled": {
+			config: func() config.AttestationCfg {
+				return config.DefaultForAzureSEVSNP()
+			},
+			crls: &stubCRLCache{crl: &crl},
+		},
+		"cached CRL": {
+			config: func() config.AttestationCfg {
+				cfg := config.DefaultForAzureSEVSNP()
+				cfg.CheckRevocations = true
+				return cfg
+			},
+			crls:       &stubCRLCache{crl: &crl},
+			wantCRL:    config.CRL(crl),
+			wantCalled: true,
+		},
+		"pinned CRL": {
+			config: func() config.AttestationCfg {
+				cfg := config.DefaultForGCPSEVSNP()
+				cfg.CheckRevocations = true
+				cfg.AMDCRL = pinnedCRL
+				return cfg
+			},
+			crls:    &stubCRLCache{crl: &crl},
+			wantCRL: pinnedCRL,
+		},
+		"cached CRL for QEMU SEV-SNP": {
+			config: func() config.AttestationCfg {
+				cfg := config.DefaultForQEMUSEVSNP()
+				cfg.CheckRevocations = true
+				return cfg
+			},
+			crls:       &stubCRLCache{crl: &crl},
+			wantCRL:    config.CRL(crl),
+			wantCalled: true,
+		},
+		"error getting cached CRL": {
+			config: func() config.AttestationCfg {
+				cfg := config.DefaultForAWSSEVSNP()
+				cfg.CheckRevocations = true
+				return cfg
+			},
+			crls:       &stubCRLCache{err: assert.AnError},
+			wantCalled: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			u := &Updatable{
+				log:         logger.NewTest(t),
+				cachedCerts: &stubSnpCerts{ask: &x509.Certificate{}, ark: &x509.Certificate{}},
+				crls:        tc.crls,
+			}
+
+			cfg, err := u.configWithCerts(tc.config())
+			require.NoError(err)
+			assert.Equal(tc.wantCalled, tc.crls.called)
+			switch c := cfg.(type) {
+			case *config.AzureSEVSNP:
+				assert.Equal(tc.wantCRL, c.AMDCRL)
+			case *config.AWSSEVSNP:
+				assert.Equal(tc.wantCRL, c.AMDCRL)
+			case *config.GCPSEVSNP:
+				assert.Equal(tc.wantCRL, c.AMDCRL)
+			case *config.QEMUSEVSNP:
+				assert.Equal(tc.wantCRL, c.AMDCRL)
+			default:
+				t.Fatalf("unexpected config type %T", cfg)
+			}
+		})
+	}
+}
+
+type stubCRLCache struct {
+	crl    *x509.RevocationList
+	err    error
+	called bool
+}
+
+func (s *stubCRLCache) CRL(context.Context) (*x509.RevocationList, error) {
+	s.called = true
+	return s.crl, s.err
+}
+
 func TestUpdate(t *testing.T) {
 	assert := assert.New(t)
 	require := require.New(t)
@@ -166,6 +272,165 @@ func TestUpdate(t *testing.T) {
 	assert.Error(err)
 }
 
+func TestRefreshOutdatedCRL(t *testing.T) {
+	testCases := map[string]struct {
+		pinnedCRL   config.CRL
+		refreshed   *x509.RevocationList
+		wantRefresh bool
+		wantErr     bool
+	}{
+		"cached CRL is refreshed": {
+			refreshed:   &x509.RevocationList{Raw: []byte("refreshed"), NextUpdate: time.Now().Add(time.Hour)},
+			wantRefresh: true,
+		},
+		"refreshed CRL is outdated": {
+			refreshed:   &x509.RevocationList{Raw: []byte("refreshed"), NextUpdate: time.Now().Add(-time.Hour)},
+			wantRefresh: true,
+			wantErr:     true,
+		},
+		"pinned CRL is not refreshed": {
+			pinnedCRL: newTestCRL(t, time.Now().Add(-2*time.Hour), time.Now().Add(-time.Hour)),
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			cfg := config.DefaultForAzureSEVSNP()
+			cfg.CheckRevocations = true
+			cfg.AMDCRL = tc.pinnedCRL
+			handler := file.NewHandler(afero.NewMemMapFs())
+			require.NoError(handler.WriteJSON(filepath.Join(constants.ServiceBasePath, constants.AttestationConfigFilename), cfg))
+			crls := &stubCRLCache{crl: &x509.RevocationList{Raw: []byte("cached"), NextUpdate: time.Now().Add(time.Hour)}}
+
+			u, err := NewValidator(logger.NewTest(t), variant.AzureSEVSNP{}, handler, &stubSnpCerts{ask: &x509.Certificate{}, ark: &x509.Certificate{}}, crls)
+			require.NoError(err)
+			require.NoError(u.refreshOutdatedCRL())
+			if !tc.pinnedCRL.IsEmpty() {
+				assert.Zero(u.crlNextUpdate)
+			} else {
+				// the cached CRL becomes outdated
+				u.crlNextUpdate = time.Now().Add(-time.Minute)
+			}
+			crls.called = false
+			crls.crl = tc.refreshed
+
+			err = u.refreshOutdatedCRL()
+			if tc.wantErr {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+			}
+			assert.Equal(tc.wantRefresh, crls.called)
+		})
+	}
+}
+
+func newTestCRL(t *testing.T, thisUpdate, nextUpdate time.Time) config.CRL {
+	t.Helper()
+	require := require.New(t)
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "ARK"},
+		NotBefore:             thisUpdate,
+		NotAfter:              nextUpdate,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}
+	raw, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(err)
+	ark, err := x509.ParseCertificate(raw)
+	require.NoError(err)
+	raw, err = x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:     big.NewInt(1),
+		ThisUpdate: thisUpdate,
+		NextUpdate: nextUpdate,
+	}, ark, key)
+	require.NoError(err)
+	crl, err := x509.ParseRevocationList(raw)
+	require.NoError(err)
+	return config.CRL(*crl)
+}
+
+func TestPeerEvidence(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	handler := file.NewHandler(afero.NewMemMapFs())
+	require.NoError(handler.WriteJSON(
+		filepath.Join(constants.ServiceBasePath, constants.AttestationConfigFilename),
+		&config.DummyCfg{Measurements: measurements.M{11: measurements.WithAllBytes(0x00, measurements.Enforce, measurements.PCRMeasurementLength)}},
+	))
+	validator := &Updatable{
+		log:         logger.NewTest(t),
+		variant:     variant.Dummy{},
+		fileHandler: handler,
+	}
+	require.NoError(validator.Update())
+
+	nonce := []byte("nonce")
+	userData := []byte("user data")
+	attDoc, err := json.Marshal(atls.FakeAttestationDoc{UserData: userData, Nonce: nonce})
+	require.NoError(err)
+	certWithDoc := func(doc []byte) *x509.Certificate {
+		return &x509.Certificate{Extensions: []pkix.Extension{{Id: variant.Dummy{}.OID(), Value: doc}}}
+	}
+
+	// a statement that wasn't validated has no evidence
+	_, err = validator.PeerEvidence(certWithDoc(attDoc))
+	assert.Error(err)
+
+	// a failed validation leaves no evidence
+	_, err = validator.Validate(t.Context(), attDoc, []byte("other nonce"))
+	require.Error(err)
+	_, err = validator.PeerEvidence(certWithDoc(attDoc))
+	assert.Error(err)
+
+	_, err = validator.Validate(t.Context(), attDoc, nonce)
+	require.NoError(err)
+
+	// a certificate without statement has no evidence
+	_, err = validator.PeerEvidence(&x509.Certificate{})
+	assert.Error(err)
+
+	record, err := validator.PeerEvidence(certWithDoc(attDoc))
+	require.NoError(err)
+	assert.Equal(evidence.SchemaVersion, record.SchemaVersion)
+	assert.Equal(variant.Dummy{}.String(), record.Variant)
+	assert.Equal(nonce, record.Nonce)
+	assert.Equal(userData, record.UserData)
+	assert.Equal(attDoc, record.AttestationDocument)
+	assert.Equal(validator.configHash, record.ConfigHash)
+	assert.NotEmpty(record.ConfigHash)
+	assert.NoError(record.Validate(t.Context(), atls.NewFakeValidator(variant.Dummy{})))
+
+	// evidence can only be retrieved once
+	_, err = validator.PeerEvidence(certWithDoc(attDoc))
+	assert.Error(err)
+
+	// expired evidence is removed
+	expiredDoc, err := json.Marshal(atls.FakeAttestationDoc{UserData: userData, Nonce: []byte("expired")})
+	require.NoError(err)
+	_, err = validator.Validate(t.Context(), expiredDoc, []byte("expired"))
+	require.NoError(err)
+	for digest, record := range validator.evidence {
+		record.Timestamp = record.Timestamp.Add(-2 * evidenceTTL)
+		validator.evidence[digest] = record
+	}
+	_, err = validator.Validate(t.Context(), attDoc, nonce)
+	require.NoError(err)
+	assert.Len(validator.evidence, 1)
+	_, err = validator.PeerEvidence(certWithDoc(expiredDoc))
+	assert.Error(err)
+	_, err = validator.PeerEvidence(certWithDoc(attDoc))
+	assert.NoError(err)
+}
+
 func TestOIDConcurrency(t *testing.T) {
 	assert := assert.New(t)
 	require := require.New(t)
@@ -8,6 +8,7 @@ package joinproto
 
 import (
 	context "context"
+	nodegroup "github.com/edgelesssys/constellation/v2/internal/nodegroup"
 	components "github.com/edgelesssys/constellation/v2/internal/versions/components"
 	grpc "google.golang.org/grpc"
 	codes "google.golang.org/grpc/codes"
@@ -33,6 +34,7 @@ type IssueJoinTicketRequest struct {
 	IsControlPlane            bool                   `protobuf:"varint,3,opt,name=is_control_plane,json=isControlPlane,proto3" json:"is_control_plane,omitempty"`
 	HostPublicKey             []byte                 `protobuf:"bytes,4,opt,name=host_public_key,json=hostPublicKey,proto3" json:"host_public_key,omitempty"`
 	HostCertificatePrincipals []string               `protobuf:"bytes,5,rep,name=host_certificate_principals,json=hostCertificatePrincipals,proto3" json:"host_certificate_principals,omitempty"`
+	NodeGroupName             string                 `protobuf:"bytes,6,opt,name=node_group_name,json=nodeGroupName,proto3" json:"node_group_name,omitempty"`
 	unknownFields             protoimpl.UnknownFields
 	sizeCache                 protoimpl.SizeCache
 }
@@ -102,6 +104,13 @@ func (x *IssueJoinTicketRequest) GetHostCertificatePrincipals() []string {
 	return nil
 }
 
+func (x *IssueJoinTicketRequest) GetNodeGroupName() string {
+	if x != nil {
+		return x.NodeGroupName
+	}
+	return ""
+}
+
 type IssueJoinTicketResponse struct {
 	state                    protoimpl.MessageState   `protogen:"open.v1"`
 	StateDiskKey             []byte                   `protobuf:"bytes,1,opt,name=state_disk_key,json=stateDiskKey,proto3" json:"state_disk_key,omitempty"`
@@ -116,6 +125,7 @@ type IssueJoinTicketResponse struct {
 	KubernetesComponents     []*components.Component  `protobuf:"bytes,10,rep,name=kubernetes_components,json=kubernetesComponents,proto3" json:"kubernetes_components,omitempty"`
 	AuthorizedCaPublicKey    []byte                   `protobuf:"bytes,11,opt,name=authorized_ca_public_key,json=authorizedCaPublicKey,proto3" json:"authorized_ca_public_key,omitempty"`
 	HostCertificate          []byte                   `protobuf:"bytes,12,opt,name=host_certificate,json=hostCertificate,proto3" json:"host_certificate,omitempty"`
+	NodeGroupSettings        *nodegroup.Settings      `protobuf:"bytes,13,opt,name=node_group_settings,json=nodeGroupSettings,proto3" json:"node_group_settings,omitempty"`
 	unknownFields            protoimpl.UnknownFields
 	sizeCache                protoimpl.SizeCache
 }
@@ -234,6 +244,13 @@ func (x *IssueJoinTicketResponse) GetHostCertificate() []byte {
 	return nil
 }
 
+func (x *IssueJoinTicketResponse) GetNodeGroupSettings() *nodegroup.Settings {
+	if x != nil {
+		return x.NodeGroupSettings
+	}
+	return nil
+}
+
 type ControlPlaneCertOrKey struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
@@ -386,13 +403,14 @@ var File_joinservice_joinproto_join_proto protoreflect.FileDescriptor
 
 const file_joinservice_joinproto_join_proto_rawDesc = "" +
 	"\n" +
-	" joinservice/joinproto/join.proto\x12\x04join\x1a-internal/versions/components/components.proto\"\xf8\x01\n" +
+	" joinservice/joinproto/join.proto\x12\x04join\x1a\"internal/nodegroup/nodegroup.proto\x1a-internal/versions/components/components.proto\"\xa0\x02\n" +
 	"\x16IssueJoinTicketRequest\x12\x1b\n" +
 	"\tdisk_uuid\x18\x01 \x01(\tR\bdiskUuid\x12/\n" +
 	"\x13certificate_request\x18\x02 \x01(\fR\x12certificateRequest\x12(\n" +
 	"\x10is_control_plane\x18\x03 \x01(\bR\x0eisControlPlane\x12&\n" +
 	"\x0fhost_public_key\x18\x04 \x01(\fR\rhostPublicKey\x12>\n" +
-	"\x1bhost_certificate_principals\x18\x05 \x03(\tR\x19hostCertificatePrincipals\"\xf2\x04\n" +
+	"\x1bhost_certificate_principals\x18\x05 \x03(\tR\x19hostCertificatePrincipals\x12&\n" +
+	"\x0fnode_group_name\x18\x06 \x01(\tR\rnodeGroupName\"\xb7\x05\n" +
 	"\x17IssueJoinTicketResponse\x12$\n" +
 	"\x0estate_disk_key\x18\x01 \x01(\fR\fstateDiskKey\x12)\n" +
 	"\x10measurement_salt\x18\x02 \x01(\fR\x0fmeasurementSalt\x12-\n" +
@@ -406,7 +424,8 @@ const file_joinservice_joinproto_join_proto_rawDesc = "" +
 	"\x15kubernetes_components\x18\n" +
 	" \x03(\v2\x15.components.ComponentR\x14kubernetesComponents\x127\n" +
 	"\x18authorized_ca_public_key\x18\v \x01(\fR\x15authorizedCaPublicKey\x12)\n" +
-	"\x10host_certificate\x18\f \x01(\fR\x0fhostCertificate\"C\n" +
+	"\x10host_certificate\x18\f \x01(\fR\x0fhostCertificate\x12C\n" +
+	"\x13node_group_settings\x18\r \x01(\v2\x13.nodegroup.SettingsR\x11nodeGroupSettings\"C\n" +
 	"\x19control_plane_cert_or_key\x12\x12\n" +
 	"\x04name\x18\x01 \x01(\tR\x04name\x12\x12\n" +
 	"\x04data\x18\x02 \x01(\fR\x04data\"7\n" +
@@ -439,19 +458,21 @@ var file_joinservice_joinproto_join_proto_goTypes = []any{
 	(*IssueRejoinTicketRequest)(nil),  // 3: join.IssueRejoinTicketRequest
 	(*IssueRejoinTicketResponse)(nil), // 4: join.IssueRejoinTicketResponse
 	(*components.Component)(nil),      // 5: components.Component
+	(*nodegroup.Settings)(nil),        // 6: nodegroup.Settings
 }
 var file_joinservice_joinproto_join_proto_depIdxs = []int32{
 	2, // 0: join.IssueJoinTicketResponse.control_plane_files:type_name -> join.control_plane_cert_or_key
 	5, // 1: join.IssueJoinTicketResponse.kubernetes_components:type_name -> components.Component
-	0, // 2: join.API.IssueJoinTicket:input_type -> join.IssueJoinTicketRequest
-	3, // 3: join.API.IssueRejoinTicket:input_type -> join.IssueRejoinTicketRequest
-	1, // 4: join.API.IssueJoinTicket:output_type -> join.IssueJoinTicketResponse
-	4, // 5: join.API.IssueRejoinTicket:output_type -> join.IssueRejoinTicketResponse
-	4, // [4:6] is the sub-list for method output_type
-	2, // [2:4] is the sub-list for method input_type
-	2, // [2:2] is the sub-list for extension type_name
-	2, // [2:2] is the sub-list for extension extendee
-	0, // [0:2] is the sub-list for field type_name
+	6, // 2: join.IssueJoinTicketResponse.node_group_settings:type_name -> nodegroup.Settings
+	0, // 3: join.API.IssueJoinTicket:input_type -> join.IssueJoinTicketRequest
+	3, // 4: join.API.IssueRejoinTicket:input_type -> join.IssueRejoinTicketRequest
+	1, // 5: join.API.IssueJoinTicket:output_type -> join.IssueJoinTicketResponse
+	4, // 6: join.API.IssueRejoinTicket:output_type -> join.IssueRejoinTicketResponse
+	5, // [5:7] is the sub-list for method output_type
+	3, // [3:5] is the sub-list for method input_type
+	3, // [3:3] is the sub-list for extension type_name
+	3, // [3:3] is the sub-list for extension extendee
+	0, // [0:3] is the sub-list for field type_name
 }
 
 func init() { file_joinservice_joinproto_join_proto_init() }
@@ -11,26 +11,50 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"io/fs"
 	"log/slog"
 	"os"
 	"path/filepath"
 	"strconv"
 	"time"
 
+	"github.com/edgelesssys/constellation/v2/internal/atls"
+	"github.com/edgelesssys/constellation/v2/internal/attestation/variant"
 	"github.com/edgelesssys/constellation/v2/internal/constants"
 	"github.com/edgelesssys/constellation/v2/internal/crypto"
 	"github.com/edgelesssys/constellation/v2/internal/file"
+	"github.com/edgelesssys/constellation/v2/internal/kms/kms"
 	"github.com/edgelesssys/constellation/v2/internal/kms/setup"
 	"github.com/edgelesssys/constellation/v2/internal/kms/uri"
 	"github.com/edgelesssys/constellation/v2/internal/logger"
+	"github.com/edgelesssys/constellation/v2/keyservice/internal/authz"
+	"github.com/edgelesssys/constellation/v2/keyservice/internal/rotation"
 	"github.com/edgelesssys/constellation/v2/keyservice/internal/server"
+	"github.com/edgelesssys/constellation/v2/upgrade-agent/upgradeproto"
 	"github.com/spf13/afero"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 )
 
 func main() {
 	port := flag.String("port", strconv.Itoa(constants.KeyServicePort), "Port gRPC server listens on")
 	masterSecretPath := flag.String("master-secret", filepath.Join(constants.ServiceBasePath, constants.ConstellationMasterSecretKey), "Path to the Constellation master secret")
 	saltPath := flag.String("salt", filepath.Join(constants.ServiceBasePath, constants.ConstellationSaltKey), "Path to the Constellation salt")
+	previousMasterSecretPath := flag.String("previous-master-secret", filepath.Join(constants.ServiceBasePath, constants.ConstellationPreviousMasterSecretKey), "Path to the Constellation master secret replaced by an ongoing key rotation")
+	previousSaltPath := flag.String("previous-salt", filepath.Join(constants.ServiceBasePath, constants.ConstellationPreviousSaltKey), "Path to the Constellation salt replaced by an ongoing key rotation")
+	measurementSecretPath := flag.String("measurement-secret", filepath.Join(constants.ServiceBasePath, constants.ConstellationMeasurementSecretKey), "Path to the pinned measurement secret. If the file does not exist, the measurement secret is derived from the master secret")
+	kmsURIPath := flag.String("kms-uri", filepath.Join(constants.ServiceBasePath, constants.ConstellationKMSURIKey), "Path to the URI of an external KMS. If the file does not exist, keys are derived from the master secret")
+	storageURIPath := flag.String("storage-uri", filepath.Join(constants.ServiceBasePath, constants.ConstellationStorageURIKey), "Path to the URI of the external KMS's storage backend")
+	authorizationMode := flag.String("authorization", string(authz.ModeEnforce), "Authorization mode for key derivations: \"enforce\" denies requests not allowed by a KeyAccessPolicy, \"audit\" only logs them")
+	atlsPort := flag.String("atls-port", "", "Port the gRPC server accepts aTLS connections of attested callers on. If empty, aTLS is disabled")
+	httpsPort := flag.String("https-port", "", "Port the workload key API is served over HTTPS on. If empty, the HTTPS API is disabled")
+	caCertPath := flag.String("kubernetes-ca-cert", constants.KubernetesCACertFilename, "Path to the certificate of the Kubernetes root CA, which signs the certificate of the HTTPS API")
+	caKeyPath := flag.String("kubernetes-ca-key", constants.KubernetesCAKeyFilename, "Path to the private key of the Kubernetes root CA, which signs the certificate of the HTTPS API")
+	attestationVariant := flag.String("attestation-variant", "", "Attestation variant used to validate aTLS callers")
+	rotateStateDiskKey := flag.Bool("rotate-state-disk-key", false, "Re-key the state disk of the node using the upgrade agent, then exit")
 	verbosity := flag.Int("v", 0, logger.CmdLineVerbosityDescription)
 
 	flag.Parse()
@@ -41,38 +65,173 @@ func main() {
 
 	// read master secret and salt
 	file := file.NewHandler(afero.NewOsFs())
-	masterKey, err := file.Read(*masterSecretPath)
+	masterSecret, err := readMasterSecret(file, *masterSecretPath, *saltPath)
 	if err != nil {
 		log.With(slog.Any("error", err)).Error("Failed to read master secret")
 		os.Exit(1)
 	}
-	if len(masterKey) < crypto.MasterSecretLengthMin {
-		log.With(slog.Any("error", errors.New("invalid key length"))).Error(fmt.Sprintf("Provided master secret is smaller than the required minimum of %d bytes", crypto.MasterSecretLengthMin))
-		os.Exit(1)
-	}
-	salt, err := file.Read(*saltPath)
-	if err != nil {
-		log.With(slog.Any("error", err)).Error("Failed to read salt")
-		os.Exit(1)
-	}
-	if len(salt) < crypto.RNGLengthDefault {
-		log.With(slog.Any("error", errors.New("invalid salt length"))).Error(fmt.Sprintf("Expected salt to be %d bytes, but got %d", crypto.RNGLengthDefault, len(salt)))
+	kmsURI, storageURI := masterSecret.EncodeToURI(), uri.NoStoreURI
+
+	// use an external KMS if one was configured on cluster creation
+	externalKMSURI, err := file.Read(*kmsURIPath)
+	switch {
+	case err == nil:
+		externalStorageURI, err := file.Read(*storageURIPath)
+		if err != nil {
+			log.With(slog.Any("error", err)).Error("Failed to read storage URI of external KMS")
+			os.Exit(1)
+		}
+		log.Info("Using external KMS")
+		kmsURI, storageURI = string(externalKMSURI), string(externalStorageURI)
+	case !errors.Is(err, fs.ErrNotExist):
+		log.With(slog.Any("error", err)).Error("Failed to read URI of external KMS")
 		os.Exit(1)
 	}
-	masterSecret := uri.MasterSecret{Key: masterKey, Salt: salt}
 
 	// set up Key Management Service
 	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Minute)
 	defer cancel()
-	conKMS, err := setup.KMS(ctx, uri.NoStoreURI, masterSecret.EncodeToURI())
+	conKMS, err := setup.KMS(ctx, storageURI, kmsURI)
 	if err != nil {
 		log.With(slog.Any("error", err)).Error("Failed to setup KMS")
 		os.Exit(1)
 	}
 	defer conKMS.Close()
 
-	if err := server.New(log.WithGroup("keyService"), conKMS).Run(*port); err != nil {
+	if *rotateStateDiskKey {
+		if err := rotate(ctx, log, file, conKMS, *previousMasterSecretPath, *previousSaltPath); err != nil {
+			log.With(slog.Any("error", err)).Error("Failed to rotate state disk key")
+			os.Exit(1)
+		}
+		return
+	}
+
+	measurementSecret, err := file.Read(*measurementSecretPath)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		log.With(slog.Any("error", err)).Error("Failed to read measurement secret")
+		os.Exit(1)
+	}
+
+	authorizer, err := newAuthorizer(log, *authorizationMode)
+	if err != nil {
+		log.With(slog.Any("error", err)).Error("Failed to set up authorization")
+		os.Exit(1)
+	}
+	keyServer := server.New(log.WithGroup("keyService"), conKMS, authorizer, measurementSecret)
+
+	if *atlsPort != "" {
+		attestVariant, err := variant.FromString(*attestationVariant)
+		if err != nil {
+			log.With(slog.Any("error", err)).Error("Failed to parse attestation variant")
+			os.Exit(1)
+		}
+		validator := authz.NewValidator(log.WithGroup("validator"), attestVariant, file)
+		go func() {
+			if err := keyServer.RunATLS(*atlsPort, []atls.Validator{validator}); err != nil {
+				log.With(slog.Any("error", err)).Error("Failed to run aTLS key-service server")
+				os.Exit(1)
+			}
+		}()
+	}
+
+	if *httpsPort != "" {
+		certIssuer, err := newServingCertIssuer(file, *caCertPath, *caKeyPath)
+		if err != nil {
+			log.With(slog.Any("error", err)).Error("Failed to set up serving certificate of workload key HTTPS server")
+			os.Exit(1)
+		}
+		go func() {
+			if err := keyServer.RunHTTPS(*httpsPort, certIssuer.GetCertificate); err != nil {
+				log.With(slog.Any("error", err)).Error("Failed to run workload key HTTPS server")
+				os.Exit(1)
+			}
+		}()
+	}
+
+	if err := keyServer.Run(*port); err != nil {
 		log.With(slog.Any("error", err)).Error("Failed to run key-service server")
 		os.Exit(1)
 	}
 }
+
+// newAuthorizer creates an authorizer that validates service account tokens
+// and loads KeyAccessPolicies using the in-cluster Kubernetes API.
+func newAuthorizer(log *slog.Logger, mode string) (*authz.Authorizer, error) {
+	authzMode, err := authz.ParseMode(mode)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := rest.InClusterConfig()
+	if err != nil {
+		return nil, fmt.Errorf("loading in-cluster config: %w", err)
+	}
+	client, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("creating Kubernetes client: %w", err)
+	}
+	dynamicClient, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("creating dynamic Kubernetes client: %w", err)
+	}
+	policies := authz.NewPolicyStore(dynamicClient.Resource(authz.KeyAccessPolicyResource))
+	return authz.New(authzMode, client.AuthenticationV1().TokenReviews(), policies, log.WithGroup("audit")), nil
+}
+
+// newServingCertIssuer creates an issuer of the serving certificate of the workload HTTPS API
+// for the DNS names of the KeyService's Kubernetes service.
+func newServingCertIssuer(file file.Handler, caCertPath, caKeyPath string) (*server.ServingCertIssuer, error) {
+	caCert, err := file.Read(caCertPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading Kubernetes CA certificate: %w", err)
+	}
+	caKey, err := file.Read(caKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading Kubernetes CA private key: %w", err)
+	}
+	service := "key-service." + constants.ConstellationNamespace
+	return server.NewServingCertIssuer(caCert, caKey, []string{service + ".svc", service, service + ".svc.cluster.local"})
+}
+
+// rotate re-keys the state disk of the node using the upgrade agent.
+func rotate(ctx context.Context, log *slog.Logger, file file.Handler, conKMS kms.CloudKMS, previousMasterSecretPath, previousSaltPath string) error {
+	// the previous master secret only exists if the master secret of the cluster KMS is being rotated
+	var previousKMS kms.CloudKMS
+	previousMasterSecret, err := readMasterSecret(file, previousMasterSecretPath, previousSaltPath)
+	switch {
+	case err == nil:
+		previousKMS, err = setup.KMS(ctx, uri.NoStoreURI, previousMasterSecret.EncodeToURI())
+		if err != nil {
+			return fmt.Errorf("setting up KMS for previous master secret: %w", err)
+		}
+		defer previousKMS.Close()
+	case !errors.Is(err, fs.ErrNotExist):
+		return fmt.Errorf("reading previous master secret: %w", err)
+	}
+
+	conn, err := grpc.NewClient("unix:"+constants.UpgradeAgentMountPath, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return fmt.Errorf("dialing upgrade agent: %w", err)
+	}
+	defer conn.Close()
+
+	return rotation.New(log.WithGroup("rotation"), upgradeproto.NewUpdateClient(conn), conKMS, previousKMS).Rotate(ctx)
+}
+
+// readMasterSecret reads a master secret and its salt from the given paths.
+func readMasterSecret(file file.Handler, masterSecretPath, saltPath string) (uri.MasterSecret, error) {
+	masterKey, err := file.Read(masterSecretPath)
+	if err != nil {
+		return uri.MasterSecret{}, err
+	}
+	if len(masterKey) < crypto.MasterSecretLengthMin {
+		return uri.MasterSecret{}, fmt.Errorf("master secret is smaller than the required minimum of %d bytes", crypto.MasterSecretLengthMin)
+	}
+	salt, err := file.Read(saltPath)
+	if err != nil {
+		return uri.MasterSecret{}, err
+	}
+	if len(salt) < crypto.RNGLengthDefault {
+		return uri.MasterSecret{}, fmt.Errorf("expected salt to be %d bytes, but got %d", crypto.RNGLengthDefault, len(salt))
+	}
+	return uri.MasterSecret{Key: masterKey, Salt: salt}, nil
+}
@@ -0,0 +1,280 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+/*
+Package authz authenticates callers of the key service and authorizes their key derivations.
+
+Callers authenticate either with a Kubernetes service account token, which is sent as bearer token in the gRPC metadata
+and validated with a TokenReview, or by attesting themselves over aTLS.
+KeyAccessPolicy resources map these identities to the key ID prefixes they may derive keys for.
+Every decision is written to the audit log.
+*/
+package authz
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/edgelesssys/constellation/v2/internal/constants"
+	"github.com/edgelesssys/constellation/v2/internal/crypto"
+	"github.com/edgelesssys/constellation/v2/internal/grpc/grpclog"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// tokenCacheTTL is the time a successful token review is cached.
+	tokenCacheTTL = time.Minute
+	// maxCachedTokens limits the number of cached token reviews.
+	maxCachedTokens = 1024
+	// serviceAccountUserPrefix is the prefix of the user names of service accounts.
+	serviceAccountUserPrefix = "system:serviceaccount:"
+)
+
+// Mode defines how authorization decisions are enforced.
+type Mode string
+
+const (
+	// ModeAudit logs all authorization decisions, but doesn't deny any requests.
+	ModeAudit Mode = "audit"
+	// ModeEnforce denies requests that aren't allowed by a KeyAccessPolicy.
+	ModeEnforce Mode = "enforce"
+)
+
+// ParseMode parses an authorization mode.
+func ParseMode(s string) (Mode, error) {
+	switch Mode(s) {
+	case ModeAudit, ModeEnforce:
+		return Mode(s), nil
+	default:
+		return "", fmt.Errorf("unknown authorization mode %q, expected %q or %q", s, ModeAudit, ModeEnforce)
+	}
+}
+
+// Identity is the authenticated identity of a caller.
+type Identity struct {
+	// Kind is the kind of the identity, e.g. ServiceAccount.
+	Kind string
+	// Namespace is the namespace of a service account.
+	Namespace string
+	// Name is the name of a service account.
+	Name string
+}
+
+// String returns a human readable representation of the identity.
+func (i Identity) String() string {
+	if i.Kind == SubjectKindServiceAccount {
+		return fmt.Sprintf("%s:%s/%s", i.Kind, i.Namespace, i.Name)
+	}
+	return i.Kind
+}
+
+// anonymous is the identity of callers that didn't authenticate.
+var anonymous = Identity{Kind: "Anonymous"}
+
+// Authorizer authenticates callers and authorizes their key derivations.
+type Authorizer struct {
+	mode     Mode
+	reviewer tokenReviewer
+	policies policyGetter
+	audit    *slog.Logger
+
+	mux    sync.Mutex
+	tokens map[[sha256.Size]byte]cachedIdentity
+	now    func() time.Time
+}
+
+// New creates a new Authorizer.
+// Decisions are logged to audit.
+func New(mode Mode, reviewer tokenReviewer, policies policyGetter, audit *slog.Logger) *Authorizer {
+	return &Authorizer{
+		mode:     mode,
+		reviewer: reviewer,
+		policies: policies,
+		audit:    audit,
+		tokens:   map[[sha256.Size]byte]cachedIdentity{},
+		now:      time.Now,
+	}
+}
+
+// Authorize checks if the caller of a gRPC request may derive the key with the given ID.
+// The returned error is a gRPC status error that can be returned to the caller.
+func (a *Authorizer) Authorize(ctx context.Context, keyID string) error {
+	log := a.audit.With(slog.String("keyID", keyID), slog.String("peerAddress", grpclog.PeerAddrFromContext(ctx)), slog.String("mode", string(a.mode)))
+
+	identity, err := a.authenticate(ctx)
+	if err != nil {
+		log.With(slog.Any("error", err)).Warn("Authentication failed")
+		if a.mode == ModeEnforce {
+			return status.Error(codes.Unauthenticated, "authentication failed")
+		}
+		identity = anonymous
+	}
+	log = log.With(slog.String("identity", identity.String()))
+
+	policy, err := a.allowedBy(ctx, identity, keyID)
+	if err != nil {
+		log.With(slog.Any("error", err)).Error("Loading key access policies failed")
+		if a.mode == ModeEnforce {
+			return status.Error(codes.Unavailable, "loading key access policies failed")
+		}
+		return nil
+	}
+	if policy == "" {
+		if a.mode == ModeEnforce {
+			log.Warn("Key derivation denied")
+			return status.Errorf(codes.PermissionDenied, "%s is not allowed to derive key %q", identity, keyID)
+		}
+		log.Warn("Key derivation would be denied")
+		return nil
+	}
+
+	log.With(slog.String("policy", policy)).Info("Key derivation allowed")
+	return nil
+}
+
+// AuthorizeWorkload authenticates the caller of a workload key derivation and returns the ID of the named key.
+// Workload keys are scoped to the namespace of the service account of the caller.
+// Any service account may derive the keys of its namespace, independent of KeyAccessPolicies and the authorization mode.
+// The returned error is a gRPC status error that can be returned to the caller.
+func (a *Authorizer) AuthorizeWorkload(ctx context.Context, name string) (string, error) {
+	log := a.audit.With(slog.String("name", name), slog.String("peerAddress", grpclog.PeerAddrFromContext(ctx)))
+
+	token, err := bearerToken(ctx)
+	if err == nil && token == "" {
+		err = errors.New("no service account token")
+	}
+	var identity Identity
+	if err == nil {
+		identity, err = a.reviewToken(ctx, token)
+	}
+	if err != nil {
+		log.With(slog.Any("error", err)).Warn("Workload authentication failed")
+		return "", status.Error(codes.Unauthenticated, "authentication with a service account token is required")
+	}
+
+	keyID := crypto.WorkloadKeyIDPrefix + identity.Namespace + "/" + name
+	log.With(slog.String("identity", identity.String()), slog.String("keyID", keyID)).Info("Workload key derivation allowed")
+	return keyID, nil
+}
+
+// allowedBy returns the name of a policy that allows the identity to derive the key with the given ID.
+// An empty name is returned if no policy allows the derivation.
+func (a *Authorizer) allowedBy(ctx context.Context, identity Identity, keyID string) (string, error) {
+	if identity == anonymous {
+		return "", nil
+	}
+	policies, err := a.policies.Policies(ctx)
+	if err != nil {
+		return "", err
+	}
+	for _, policy := range policies {
+		if policy.Allows(identity, keyID) {
+			return policy.Name, nil
+		}
+	}
+	return "", nil
+}
+
+// authenticate returns the identity of the caller of a gRPC request.
+// Service account tokens take precedence over aTLS, since they identify a specific workload.
+func (a *Authorizer) authenticate(ctx context.Context) (Identity, error) {
+	token, err := bearerToken(ctx)
+	if err != nil {
+		return Identity{}, err
+	}
+	if token != "" {
+		return a.reviewToken(ctx, token)
+	}
+
+	// Connections to the aTLS port require a client certificate with an attestation statement,
+	// which is verified during the handshake. A peer certificate therefore proves a successful attestation.
+	if p, ok := peer.FromContext(ctx); ok {
+		if tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo); ok && len(tlsInfo.State.PeerCertificates) > 0 {
+			return Identity{Kind: SubjectKindAttestedNode}, nil
+		}
+	}
+	return anonymous, nil
+}
+
+// reviewToken validates a service account token using a TokenReview.
+// Successful reviews are cached to reduce the load on the API server.
+func (a *Authorizer) reviewToken(ctx context.Context, token string) (Identity, error) {
+	hash := sha256.Sum256([]byte(token))
+
+	a.mux.Lock()
+	cached, ok := a.tokens[hash]
+	a.mux.Unlock()
+	if ok && a.now().Before(cached.expiry) {
+		return cached.identity, nil
+	}
+
+	review, err := a.reviewer.Create(ctx, &authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{
+			Token:     token,
+			Audiences: []string{constants.KeyServiceTokenAudience},
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return Identity{}, fmt.Errorf("reviewing token: %w", err)
+	}
+	if !review.Status.Authenticated {
+		return Identity{}, fmt.Errorf("token was rejected: %s", review.Status.Error)
+	}
+	namespace, name, ok := strings.Cut(strings.TrimPrefix(review.Status.User.Username, serviceAccountUserPrefix), ":")
+	if !strings.HasPrefix(review.Status.User.Username, serviceAccountUserPrefix) || !ok {
+		return Identity{}, fmt.Errorf("token belongs to %q, which is not a service account", review.Status.User.Username)
+	}
+	identity := Identity{Kind: SubjectKindServiceAccount, Namespace: namespace, Name: name}
+
+	a.mux.Lock()
+	defer a.mux.Unlock()
+	if len(a.tokens) >= maxCachedTokens {
+		clear(a.tokens)
+	}
+	a.tokens[hash] = cachedIdentity{identity: identity, expiry: a.now().Add(tokenCacheTTL)}
+	return identity, nil
+}
+
+// bearerToken returns the bearer token of a gRPC request, or an empty string if the request has none.
+func bearerToken(ctx context.Context) (string, error) {
+	values := metadata.ValueFromIncomingContext(ctx, "authorization")
+	if len(values) == 0 {
+		return "", nil
+	}
+	if len(values) > 1 {
+		return "", errors.New("multiple authorization headers")
+	}
+	token, ok := strings.CutPrefix(values[0], "Bearer ")
+	if !ok || token == "" {
+		return "", errors.New("authorization header is not a bearer token")
+	}
+	return token, nil
+}
+
+type cachedIdentity struct {
+	identity Identity
+	expiry   time.Time
+}
+
+type tokenReviewer interface {
+	Create(ctx context.Context, review *authenticationv1.TokenReview, opts metav1.CreateOptions) (*authenticationv1.TokenReview, error)
+}
+
+type policyGetter interface {
+	Policies(ctx context.Context) ([]KeyAccessPolicy, error)
+}
@@ -0,0 +1,322 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package authz
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/edgelesssys/constellation/v2/internal/constants"
+	"github.com/edgelesssys/constellation/v2/internal/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestMain(m *testing.M) {
+	goleak.VerifyTestMain(m)
+}
+
+func TestAuthorize(t *testing.T) {
+	policies := stubPolicyGetter{policies: []KeyAccessPolicy{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "s3proxy"},
+			Spec: KeyAccessPolicySpec{
+				Subjects:      []Subject{{Kind: SubjectKindServiceAccount, Namespace: "default", Name: "s3proxy"}},
+				KeyIDPrefixes: []string{"s3proxy/"},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "nodes"},
+			Spec: KeyAccessPolicySpec{
+				Subjects:      []Subject{{Kind: SubjectKindAttestedNode}},
+				KeyIDPrefixes: []string{"node/"},
+			},
+		},
+	}}
+	s3proxyReviewer := &stubTokenReviewer{username: "system:serviceaccount:default:s3proxy"}
+
+	testCases := map[string]struct {
+		mode     Mode
+		ctx      func(ctx context.Context) context.Context
+		reviewer *stubTokenReviewer
+		policies stubPolicyGetter
+		keyID    string
+		wantCode codes.Code
+	}{
+		"service account allowed": {
+			mode:     ModeEnforce,
+			ctx:      withToken("token"),
+			reviewer: s3proxyReviewer,
+			policies: policies,
+			keyID:    "s3proxy/bucket/0/",
+		},
+		"service account denied": {
+			mode:     ModeEnforce,
+			ctx:      withToken("token"),
+			reviewer: s3proxyReviewer,
+			policies: policies,
+			keyID:    "node/1",
+			wantCode: codes.PermissionDenied,
+		},
+		"other service account denied": {
+			mode:     ModeEnforce,
+			ctx:      withToken("token"),
+			reviewer: &stubTokenReviewer{username: "system:serviceaccount:other:s3proxy"},
+			policies: policies,
+			keyID:    "s3proxy/bucket/0/",
+			wantCode: codes.PermissionDenied,
+		},
+		"attested node allowed": {
+			mode:     ModeEnforce,
+			ctx:      withPeerCertificate,
+			reviewer: &stubTokenReviewer{},
+			policies: policies,
+			keyID:    "node/1",
+		},
+		"attested node denied": {
+			mode:     ModeEnforce,
+			ctx:      withPeerCertificate,
+			reviewer: &stubTokenReviewer{},
+			policies: policies,
+			keyID:    "s3proxy/bucket/0/",
+			wantCode: codes.PermissionDenied,
+		},
+		"anonymous denied": {
+			mode:     ModeEnforce,
+			ctx:      func(ctx context.Context) context.Context { return ctx },
+			reviewer: &stubTokenReviewer{},
+			policies: policies,
+			keyID:    "s3proxy/bucket/0/",
+			wantCode: codes.PermissionDenied,
+		},
+		"rejected token": {
+			mode:     ModeEnforce,
+			ctx:      withToken("token"),
+			reviewer: &stubTokenReviewer{rejected: true},
+			policies: policies,
+			keyID:    "s3proxy/bucket/0/",
+			wantCode: codes.Unauthenticated,
+		},
+		"token of a user": {
+			mode:     ModeEnforce,
+			ctx:      withToken("token"),
+			reviewer: &stubTokenReviewer{username: "admin"},
+			policies: policies,
+			keyID:    "s3proxy/bucket/0/",
+			wantCode: codes.Unauthenticated,
+		},
+		"token review fails": {
+			mode:     ModeEnforce,
+			ctx:      withToken("token"),
+			reviewer: &stubTokenReviewer{err: errors.New("failed")},
+			policies: policies,
+			keyID:    "s3proxy/bucket/0/",
+			wantCode: codes.Unauthenticated,
+		},
+		"invalid authorization header": {
+			mode: ModeEnforce,
+			ctx: func(ctx context.Context) context.Context {
+				return metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", "Basic abc"))
+			},
+			reviewer: s3proxyReviewer,
+			policies: policies,
+			keyID:    "s3proxy/bucket/0/",
+			wantCode: codes.Unauthenticated,
+		},
+		"loading policies fails": {
+			mode:     ModeEnforce,
+			ctx:      withToken("token"),
+			reviewer: s3proxyReviewer,
+			policies: stubPolicyGetter{err: errors.New("failed")},
+			keyID:    "s3proxy/bucket/0/",
+			wantCode: codes.Unavailable,
+		},
+		"audit mode allows denied requests": {
+			mode:     ModeAudit,
+			ctx:      withToken("token"),
+			reviewer: s3proxyReviewer,
+			policies: policies,
+			keyID:    "node/1",
+		},
+		"audit mode allows rejected tokens": {
+			mode:     ModeAudit,
+			ctx:      withToken("token"),
+			reviewer: &stubTokenReviewer{rejected: true},
+			policies: policies,
+			keyID:    "s3proxy/bucket/0/",
+		},
+		"audit mode allows requests if policies can't be loaded": {
+			mode:     ModeAudit,
+			ctx:      withToken("token"),
+			reviewer: s3proxyReviewer,
+			policies: stubPolicyGetter{err: errors.New("failed")},
+			keyID:    "s3proxy/bucket/0/",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			a := New(tc.mode, tc.reviewer, tc.policies, logger.NewTest(t))
+			err := a.Authorize(tc.ctx(t.Context()), tc.keyID)
+			assert.Equal(t, tc.wantCode, status.Code(err))
+		})
+	}
+}
+
+func TestAuthorizeWorkload(t *testing.T) {
+	testCases := map[string]struct {
+		mode      Mode
+		ctx       func(ctx context.Context) context.Context
+		reviewer  *stubTokenReviewer
+		wantKeyID string
+		wantCode  codes.Code
+	}{
+		"key is scoped to the namespace": {
+			mode:      ModeEnforce,
+			ctx:       withToken("token"),
+			reviewer:  &stubTokenReviewer{username: "system:serviceaccount:default:app"},
+			wantKeyID: "workload/default/db-password",
+		},
+		"policies don't apply": {
+			mode:      ModeAudit,
+			ctx:       withToken("token"),
+			reviewer:  &stubTokenReviewer{username: "system:serviceaccount:other:app"},
+			wantKeyID: "workload/other/db-password",
+		},
+		"no token": {
+			mode:     ModeAudit,
+			ctx:      func(ctx context.Context) context.Context { return ctx },
+			reviewer: &stubTokenReviewer{username: "system:serviceaccount:default:app"},
+			wantCode: codes.Unauthenticated,
+		},
+		"attested node": {
+			mode:     ModeEnforce,
+			ctx:      withPeerCertificate,
+			reviewer: &stubTokenReviewer{username: "system:serviceaccount:default:app"},
+			wantCode: codes.Unauthenticated,
+		},
+		"rejected token": {
+			mode:     ModeAudit,
+			ctx:      withToken("token"),
+			reviewer: &stubTokenReviewer{rejected: true},
+			wantCode: codes.Unauthenticated,
+		},
+		"token of a user": {
+			mode:     ModeEnforce,
+			ctx:      withToken("token"),
+			reviewer: &stubTokenReviewer{username: "admin"},
+			wantCode: codes.Unauthenticated,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			// Policies must not be consulted for workload keys.
+			a := New(tc.mode, tc.reviewer, stubPolicyGetter{err: errors.New("failed")}, logger.NewTest(t))
+			keyID, err := a.AuthorizeWorkload(tc.ctx(t.Context()), "db-password")
+			assert.Equal(t, tc.wantCode, status.Code(err))
+			assert.Equal(t, tc.wantKeyID, keyID)
+		})
+	}
+}
+
+func TestReviewTokenCache(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	reviewer := &stubTokenReviewer{username: "system:serviceaccount:default:s3proxy"}
+	a := New(ModeEnforce, reviewer, stubPolicyGetter{}, logger.NewTest(t))
+	now := time.Now()
+	a.now = func() time.Time { return now }
+
+	identity, err := a.reviewToken(t.Context(), "token")
+	require.NoError(err)
+	assert.Equal(Identity{Kind: SubjectKindServiceAccount, Namespace: "default", Name: "s3proxy"}, identity)
+	assert.Equal([]string{constants.KeyServiceTokenAudience}, reviewer.audiences)
+
+	// Cached reviews are reused.
+	_, err = a.reviewToken(t.Context(), "token")
+	require.NoError(err)
+	assert.Equal(1, reviewer.calls)
+
+	// Other tokens are reviewed.
+	_, err = a.reviewToken(t.Context(), "other")
+	require.NoError(err)
+	assert.Equal(2, reviewer.calls)
+
+	// Expired reviews are renewed.
+	now = now.Add(tokenCacheTTL)
+	_, err = a.reviewToken(t.Context(), "token")
+	require.NoError(err)
+	assert.Equal(3, reviewer.calls)
+}
+
+func TestParseMode(t *testing.T) {
+	mode, err := ParseMode("enforce")
+	assert.NoError(t, err)
+	assert.Equal(t, ModeEnforce, mode)
+	mode, err = ParseMode("audit")
+	assert.NoError(t, err)
+	assert.Equal(t, ModeAudit, mode)
+	_, err = ParseMode("disabled")
+	assert.Error(t, err)
+}
+
+func withToken(token string) func(ctx context.Context) context.Context {
+	return func(ctx context.Context) context.Context {
+		return metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", "Bearer "+token))
+	}
+}
+
+func withPeerCertificate(ctx context.Context) context.Context {
+	return peer.NewContext(ctx, &peer.Peer{Addr: &net.TCPAddr{}, AuthInfo: credentials.TLSInfo{
+		State: tls.ConnectionState{PeerCertificates: []*x509.Certificate{{}}},
+	}})
+}
+
+type stubTokenReviewer struct {
+	username  string
+	rejected  bool
+	err       error
+	calls     int
+	audiences []string
+}
+
+func (r *stubTokenReviewer) Create(_ context.Context, review *authenticationv1.TokenReview, _ metav1.CreateOptions) (*authenticationv1.TokenReview, error) {
+	r.calls++
+	r.audiences = review.Spec.Audiences
+	if r.err != nil {
+		return nil, r.err
+	}
+	review.Status = authenticationv1.TokenReviewStatus{
+		Authenticated: !r.rejected,
+		User:          authenticationv1.UserInfo{Username: r.username},
+	}
+	return review, nil
+}
+
+type stubPolicyGetter struct {
+	policies []KeyAccessPolicy
+	err      error
+}
+
+func (g stubPolicyGetter) Policies(context.Context) ([]KeyAccessPolicy, error) {
+	return g.policies, g.err
+}
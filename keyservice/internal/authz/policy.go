@@ -0,0 +1,143 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package authz
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	// SubjectKindServiceAccount is the subject kind of Kubernetes service accounts.
+	SubjectKindServiceAccount = "ServiceAccount"
+	// SubjectKindAttestedNode is the subject kind of callers that attested themselves over aTLS.
+	SubjectKindAttestedNode = "AttestedNode"
+
+	// policyRefreshInterval is the time after which policies are reloaded from the Kubernetes API.
+	policyRefreshInterval = 30 * time.Second
+)
+
+// KeyAccessPolicyResource is the resource of KeyAccessPolicy objects.
+var KeyAccessPolicyResource = schema.GroupVersionResource{
+	Group:    "keys.edgeless.systems",
+	Version:  "v1alpha1",
+	Resource: "keyaccesspolicies",
+}
+
+// KeyAccessPolicy allows subjects to derive keys with the given key ID prefixes.
+type KeyAccessPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec KeyAccessPolicySpec `json:"spec,omitempty"`
+}
+
+// KeyAccessPolicySpec defines the subjects and key ID prefixes of a KeyAccessPolicy.
+type KeyAccessPolicySpec struct {
+	// Subjects are the identities the policy applies to.
+	Subjects []Subject `json:"subjects"`
+	// KeyIDPrefixes are the prefixes of the key IDs the subjects may derive keys for.
+	// An empty prefix matches all key IDs.
+	KeyIDPrefixes []string `json:"keyIDPrefixes"`
+}
+
+// Subject is an identity a KeyAccessPolicy applies to.
+type Subject struct {
+	// Kind is either ServiceAccount or AttestedNode.
+	Kind string `json:"kind"`
+	// Namespace is the namespace of a service account.
+	Namespace string `json:"namespace,omitempty"`
+	// Name is the name of a service account.
+	Name string `json:"name,omitempty"`
+}
+
+// Allows reports whether the policy allows the identity to derive the key with the given ID.
+func (p KeyAccessPolicy) Allows(identity Identity, keyID string) bool {
+	if !p.appliesTo(identity) {
+		return false
+	}
+	for _, prefix := range p.Spec.KeyIDPrefixes {
+		if strings.HasPrefix(keyID, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p KeyAccessPolicy) appliesTo(identity Identity) bool {
+	for _, subject := range p.Spec.Subjects {
+		if subject.Kind != identity.Kind {
+			continue
+		}
+		if subject.Kind != SubjectKindServiceAccount ||
+			(subject.Namespace == identity.Namespace && subject.Name == identity.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// PolicyStore loads KeyAccessPolicies from the Kubernetes API and caches them.
+type PolicyStore struct {
+	client policyLister
+
+	mux      sync.Mutex
+	policies []KeyAccessPolicy
+	loaded   time.Time
+	now      func() time.Time
+}
+
+// NewPolicyStore creates a new PolicyStore.
+func NewPolicyStore(client policyLister) *PolicyStore {
+	return &PolicyStore{client: client, now: time.Now}
+}
+
+// Policies returns all KeyAccessPolicies of the cluster.
+// Policies are reloaded if they are older than the refresh interval.
+// If the KeyAccessPolicy resource doesn't exist, no policies are returned.
+func (s *PolicyStore) Policies(ctx context.Context) ([]KeyAccessPolicy, error) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	if !s.loaded.IsZero() && s.now().Sub(s.loaded) < policyRefreshInterval {
+		return s.policies, nil
+	}
+
+	list, err := s.client.List(ctx, metav1.ListOptions{})
+	if k8serrors.IsNotFound(err) {
+		list, err = &unstructured.UnstructuredList{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("listing key access policies: %w", err)
+	}
+
+	policies := make([]KeyAccessPolicy, 0, len(list.Items))
+	for _, item := range list.Items {
+		var policy KeyAccessPolicy
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(item.Object, &policy); err != nil {
+			return nil, fmt.Errorf("converting key access policy %q: %w", item.GetName(), err)
+		}
+		policies = append(policies, policy)
+	}
+
+	s.policies = policies
+	s.loaded = s.now()
+	return s.policies, nil
+}
+
+type policyLister interface {
+	List(ctx context.Context, opts metav1.ListOptions) (*unstructured.UnstructuredList, error)
+}
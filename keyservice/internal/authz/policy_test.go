@@ -0,0 +1,153 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package authz
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestPolicyAllows(t *testing.T) {
+	policy := KeyAccessPolicy{Spec: KeyAccessPolicySpec{
+		Subjects: []Subject{
+			{Kind: SubjectKindServiceAccount, Namespace: "default", Name: "s3proxy"},
+			{Kind: SubjectKindAttestedNode},
+		},
+		KeyIDPrefixes: []string{"s3proxy/", "shared/"},
+	}}
+	s3proxy := Identity{Kind: SubjectKindServiceAccount, Namespace: "default", Name: "s3proxy"}
+
+	testCases := map[string]struct {
+		policy   KeyAccessPolicy
+		identity Identity
+		keyID    string
+		want     bool
+	}{
+		"service account with matching prefix": {
+			policy:   policy,
+			identity: s3proxy,
+			keyID:    "s3proxy/bucket/0/",
+			want:     true,
+		},
+		"service account with second prefix": {
+			policy:   policy,
+			identity: s3proxy,
+			keyID:    "shared/key",
+			want:     true,
+		},
+		"service account without matching prefix": {
+			policy:   policy,
+			identity: s3proxy,
+			keyID:    "other/key",
+		},
+		"service account in other namespace": {
+			policy:   policy,
+			identity: Identity{Kind: SubjectKindServiceAccount, Namespace: "other", Name: "s3proxy"},
+			keyID:    "s3proxy/bucket/0/",
+		},
+		"attested node": {
+			policy:   policy,
+			identity: Identity{Kind: SubjectKindAttestedNode},
+			keyID:    "shared/key",
+			want:     true,
+		},
+		"empty prefix matches all keys": {
+			policy: KeyAccessPolicy{Spec: KeyAccessPolicySpec{
+				Subjects:      []Subject{{Kind: SubjectKindServiceAccount, Namespace: "default", Name: "s3proxy"}},
+				KeyIDPrefixes: []string{""},
+			}},
+			identity: s3proxy,
+			keyID:    "any",
+			want:     true,
+		},
+		"no prefixes": {
+			policy: KeyAccessPolicy{Spec: KeyAccessPolicySpec{
+				Subjects: []Subject{{Kind: SubjectKindServiceAccount, Namespace: "default", Name: "s3proxy"}},
+			}},
+			identity: s3proxy,
+			keyID:    "any",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.want, tc.policy.Allows(tc.identity, tc.keyID))
+		})
+	}
+}
+
+func TestPolicyStore(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	lister := &stubPolicyLister{items: []unstructured.Unstructured{{Object: map[string]any{
+		"apiVersion": "keys.edgeless.systems/v1alpha1",
+		"kind":       "KeyAccessPolicy",
+		"metadata":   map[string]any{"name": "s3proxy"},
+		"spec": map[string]any{
+			"subjects":      []any{map[string]any{"kind": "ServiceAccount", "namespace": "default", "name": "s3proxy"}},
+			"keyIDPrefixes": []any{"s3proxy/"},
+		},
+	}}}}
+	store := NewPolicyStore(lister)
+	now := time.Now()
+	store.now = func() time.Time { return now }
+
+	policies, err := store.Policies(t.Context())
+	require.NoError(err)
+	require.Len(policies, 1)
+	assert.Equal("s3proxy", policies[0].Name)
+	assert.Equal([]Subject{{Kind: SubjectKindServiceAccount, Namespace: "default", Name: "s3proxy"}}, policies[0].Spec.Subjects)
+	assert.Equal([]string{"s3proxy/"}, policies[0].Spec.KeyIDPrefixes)
+
+	// Policies are cached until the refresh interval passed.
+	lister.items = nil
+	policies, err = store.Policies(t.Context())
+	require.NoError(err)
+	assert.Len(policies, 1)
+	assert.Equal(1, lister.calls)
+
+	now = now.Add(policyRefreshInterval)
+	policies, err = store.Policies(t.Context())
+	require.NoError(err)
+	assert.Empty(policies)
+	assert.Equal(2, lister.calls)
+
+	// A missing CRD means there are no policies.
+	now = now.Add(policyRefreshInterval)
+	lister.err = k8serrors.NewNotFound(KeyAccessPolicyResource.GroupResource(), "")
+	policies, err = store.Policies(t.Context())
+	require.NoError(err)
+	assert.Empty(policies)
+
+	now = now.Add(policyRefreshInterval)
+	lister.err = errors.New("failed")
+	_, err = store.Policies(t.Context())
+	assert.Error(err)
+}
+
+type stubPolicyLister struct {
+	items []unstructured.Unstructured
+	err   error
+	calls int
+}
+
+func (l *stubPolicyLister) List(context.Context, metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+	l.calls++
+	if l.err != nil {
+		return nil, l.err
+	}
+	return &unstructured.UnstructuredList{Items: l.items}, nil
+}
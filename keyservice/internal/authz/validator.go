@@ -0,0 +1,56 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package authz
+
+import (
+	"context"
+	"encoding/asn1"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+
+	"github.com/edgelesssys/constellation/v2/internal/attestation/choose"
+	"github.com/edgelesssys/constellation/v2/internal/attestation/variant"
+	"github.com/edgelesssys/constellation/v2/internal/config"
+	"github.com/edgelesssys/constellation/v2/internal/constants"
+	"github.com/edgelesssys/constellation/v2/internal/file"
+)
+
+// Validator validates the attestation of aTLS callers against the attestation config of the cluster.
+// The config is read for every validation, so updated measurements apply without restarting the key service.
+type Validator struct {
+	log         *slog.Logger
+	variant     variant.Variant
+	fileHandler file.Handler
+}
+
+// NewValidator creates a new Validator for the given attestation variant.
+func NewValidator(log *slog.Logger, variant variant.Variant, fileHandler file.Handler) *Validator {
+	return &Validator{log: log, variant: variant, fileHandler: fileHandler}
+}
+
+// OID returns the OID of the attestation variant.
+func (v *Validator) OID() asn1.ObjectIdentifier {
+	return v.variant.OID()
+}
+
+// Validate validates an attestation document.
+func (v *Validator) Validate(ctx context.Context, attDoc []byte, nonce []byte) ([]byte, error) {
+	data, err := v.fileHandler.Read(filepath.Join(constants.ServiceBasePath, constants.AttestationConfigFilename))
+	if err != nil {
+		return nil, fmt.Errorf("reading attestation config: %w", err)
+	}
+	cfg, err := config.UnmarshalAttestationConfig(data, v.variant)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshaling attestation config: %w", err)
+	}
+	validator, err := choose.Validator(cfg, v.log)
+	if err != nil {
+		return nil, fmt.Errorf("choosing validator: %w", err)
+	}
+	return validator.Validate(ctx, attDoc, nonce)
+}
@@ -0,0 +1,97 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+/*
+Package rotation re-keys the state disk of a node after the key encryption key of the cluster was rotated.
+
+If the master secret of the cluster KMS was rotated, the state disk key derived from the previous master secret
+is replaced by the key derived from the new master secret using the upgrade agent of the node.
+If an external KMS is used, the state disk key stays the same, but its wrapped DEK is re-encrypted with the current KEK.
+*/
+package rotation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/edgelesssys/constellation/v2/internal/crypto"
+	"github.com/edgelesssys/constellation/v2/internal/kms/kms"
+	"github.com/edgelesssys/constellation/v2/upgrade-agent/upgradeproto"
+	"google.golang.org/grpc"
+)
+
+// Rotator re-keys the state disk of a node.
+type Rotator struct {
+	log         *slog.Logger
+	agent       upgradeAgent
+	kms         kms.CloudKMS
+	previousKMS kms.CloudKMS
+}
+
+// New creates a new Rotator.
+// previousKMS holds the KMS set up with the previous master secret.
+// It is nil if the KEK of an external KMS is rotated.
+func New(log *slog.Logger, agent upgradeAgent, kms, previousKMS kms.CloudKMS) *Rotator {
+	return &Rotator{
+		log:         log,
+		agent:       agent,
+		kms:         kms,
+		previousKMS: previousKMS,
+	}
+}
+
+// Rotate re-keys the state disk of the node.
+func (r *Rotator) Rotate(ctx context.Context) error {
+	resp, err := r.agent.GetStateDiskUUID(ctx, &upgradeproto.GetStateDiskUUIDRequest{})
+	if err != nil {
+		return fmt.Errorf("getting state disk UUID: %w", err)
+	}
+	if resp.DiskUuid == "" {
+		return errors.New("upgrade agent returned empty state disk UUID")
+	}
+	uuid := strings.ToLower(resp.DiskUuid)
+	keyID := crypto.DEKPrefix + uuid
+	log := r.log.With(slog.String("diskUUID", uuid))
+
+	if r.previousKMS == nil {
+		rewrapper, ok := r.kms.(kms.Rewrapper)
+		if !ok {
+			return errors.New("KMS does not support re-wrapping data encryption keys")
+		}
+		log.Info("Re-wrapping state disk key with current KEK")
+		if err := rewrapper.RewrapDEK(ctx, keyID); err != nil {
+			return fmt.Errorf("re-wrapping state disk key: %w", err)
+		}
+		return nil
+	}
+
+	currentPassphrase, err := r.previousKMS.GetDEK(ctx, keyID, crypto.StateDiskKeyLength)
+	if err != nil {
+		return fmt.Errorf("getting state disk key from previous master secret: %w", err)
+	}
+	newPassphrase, err := r.kms.GetDEK(ctx, keyID, crypto.StateDiskKeyLength)
+	if err != nil {
+		return fmt.Errorf("getting state disk key from current master secret: %w", err)
+	}
+
+	log.Info("Replacing state disk key")
+	if _, err := r.agent.RotateStateDiskKey(ctx, &upgradeproto.RotateStateDiskKeyRequest{
+		CurrentPassphrase: currentPassphrase,
+		NewPassphrase:     newPassphrase,
+	}); err != nil {
+		return fmt.Errorf("replacing state disk key: %w", err)
+	}
+	log.Info("State disk key replaced")
+	return nil
+}
+
+type upgradeAgent interface {
+	GetStateDiskUUID(ctx context.Context, in *upgradeproto.GetStateDiskUUIDRequest, opts ...grpc.CallOption) (*upgradeproto.GetStateDiskUUIDResponse, error)
+	RotateStateDiskKey(ctx context.Context, in *upgradeproto.RotateStateDiskKeyRequest, opts ...grpc.CallOption) (*upgradeproto.RotateStateDiskKeyResponse, error)
+}
@@ -0,0 +1,151 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package rotation
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/edgelesssys/constellation/v2/internal/kms/kms"
+	"github.com/edgelesssys/constellation/v2/internal/logger"
+	"github.com/edgelesssys/constellation/v2/upgrade-agent/upgradeproto"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/goleak"
+	"google.golang.org/grpc"
+)
+
+func TestMain(m *testing.M) {
+	goleak.VerifyTestMain(m)
+}
+
+func TestRotate(t *testing.T) {
+	someErr := errors.New("failed")
+
+	testCases := map[string]struct {
+		agent       *stubAgent
+		kms         kms.CloudKMS
+		previousKMS kms.CloudKMS
+		wantRewrap  bool
+		wantRotate  bool
+		wantErr     bool
+	}{
+		"master secret rotation": {
+			agent:       &stubAgent{uuid: "uuid"},
+			kms:         &stubKMS{dek: []byte("new")},
+			previousKMS: &stubKMS{dek: []byte("old")},
+			wantRotate:  true,
+		},
+		"external KMS rewrap": {
+			agent:      &stubAgent{uuid: "UUID"},
+			kms:        &stubRewrapKMS{},
+			wantRewrap: true,
+		},
+		"KMS without rewrap support": {
+			agent:   &stubAgent{uuid: "uuid"},
+			kms:     &stubKMS{},
+			wantErr: true,
+		},
+		"rewrap fails": {
+			agent:   &stubAgent{uuid: "uuid"},
+			kms:     &stubRewrapKMS{rewrapErr: someErr},
+			wantErr: true,
+		},
+		"getting UUID fails": {
+			agent:       &stubAgent{uuidErr: someErr},
+			kms:         &stubKMS{dek: []byte("new")},
+			previousKMS: &stubKMS{dek: []byte("old")},
+			wantErr:     true,
+		},
+		"empty UUID": {
+			agent:       &stubAgent{},
+			kms:         &stubKMS{dek: []byte("new")},
+			previousKMS: &stubKMS{dek: []byte("old")},
+			wantErr:     true,
+		},
+		"previous KMS fails": {
+			agent:       &stubAgent{uuid: "uuid"},
+			kms:         &stubKMS{dek: []byte("new")},
+			previousKMS: &stubKMS{getDEKErr: someErr},
+			wantErr:     true,
+		},
+		"current KMS fails": {
+			agent:       &stubAgent{uuid: "uuid"},
+			kms:         &stubKMS{getDEKErr: someErr},
+			previousKMS: &stubKMS{dek: []byte("old")},
+			wantErr:     true,
+		},
+		"agent fails to rotate": {
+			agent:       &stubAgent{uuid: "uuid", rotateErr: someErr},
+			kms:         &stubKMS{dek: []byte("new")},
+			previousKMS: &stubKMS{dek: []byte("old")},
+			wantRotate:  true,
+			wantErr:     true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			err := New(logger.NewTest(t), tc.agent, tc.kms, tc.previousKMS).Rotate(t.Context())
+			if tc.wantErr {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+			}
+
+			if tc.wantRotate {
+				assert.Equal([]byte("old"), tc.agent.rotateReq.CurrentPassphrase)
+				assert.Equal([]byte("new"), tc.agent.rotateReq.NewPassphrase)
+			} else {
+				assert.Nil(tc.agent.rotateReq)
+			}
+			if tc.wantRewrap {
+				assert.Equal("key-uuid", tc.kms.(*stubRewrapKMS).rewrappedID)
+			}
+		})
+	}
+}
+
+type stubAgent struct {
+	uuid      string
+	uuidErr   error
+	rotateErr error
+	rotateReq *upgradeproto.RotateStateDiskKeyRequest
+}
+
+func (a *stubAgent) GetStateDiskUUID(context.Context, *upgradeproto.GetStateDiskUUIDRequest, ...grpc.CallOption) (*upgradeproto.GetStateDiskUUIDResponse, error) {
+	return &upgradeproto.GetStateDiskUUIDResponse{DiskUuid: a.uuid}, a.uuidErr
+}
+
+func (a *stubAgent) RotateStateDiskKey(_ context.Context, req *upgradeproto.RotateStateDiskKeyRequest, _ ...grpc.CallOption) (*upgradeproto.RotateStateDiskKeyResponse, error) {
+	a.rotateReq = req
+	return &upgradeproto.RotateStateDiskKeyResponse{}, a.rotateErr
+}
+
+type stubKMS struct {
+	dek       []byte
+	getDEKErr error
+}
+
+func (k *stubKMS) GetDEK(context.Context, string, int) ([]byte, error) {
+	return k.dek, k.getDEKErr
+}
+
+func (k *stubKMS) Close() {}
+
+type stubRewrapKMS struct {
+	stubKMS
+	rewrappedID string
+	rewrapErr   error
+}
+
+func (k *stubRewrapKMS) RewrapDEK(_ context.Context, id string) error {
+	k.rewrappedID = id
+	return k.rewrapErr
+}
@@ -9,11 +9,18 @@ package server
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"log/slog"
 	"net"
+	"net/http"
+	"strings"
+	"time"
 
+	"github.com/edgelesssys/constellation/v2/internal/atls"
+	"github.com/edgelesssys/constellation/v2/internal/attestation"
 	"github.com/edgelesssys/constellation/v2/internal/crypto"
+	"github.com/edgelesssys/constellation/v2/internal/grpc/atlscredentials"
 	"github.com/edgelesssys/constellation/v2/internal/grpc/grpclog"
 	"github.com/edgelesssys/constellation/v2/internal/kms/kms"
 	"github.com/edgelesssys/constellation/v2/internal/logger"
@@ -24,24 +31,65 @@ import (
 )
 
 // Server implements an encryption key management server.
-// The server serves aTLS for cluster external requests
-// and plain gRPC for cluster internal requests.
+// The server serves plain gRPC for callers authenticating with service account tokens
+// and aTLS for callers that attest themselves.
+// Additionally, it serves the workload API, which derives keys scoped to the namespace of the caller, over gRPC and HTTPS.
 type Server struct {
-	log    *slog.Logger
-	conKMS kms.CloudKMS
+	log        *slog.Logger
+	conKMS     kms.CloudKMS
+	authorizer authorizer
+	workload   *workloadServer
+	// measurementSecret is served instead of a derived key if set.
+	// It is pinned when the master secret is rotated, so the cluster ID stays stable.
+	measurementSecret []byte
 	keyserviceproto.UnimplementedAPIServer
 }
 
 // New creates a new Server.
-func New(log *slog.Logger, conKMS kms.CloudKMS) *Server {
+func New(log *slog.Logger, conKMS kms.CloudKMS, authorizer authorizer, measurementSecret []byte) *Server {
 	return &Server{
-		log:    log,
-		conKMS: conKMS,
+		log:               log,
+		conKMS:            conKMS,
+		authorizer:        authorizer,
+		measurementSecret: measurementSecret,
+		workload: &workloadServer{
+			log:        log.WithGroup("workload"),
+			conKMS:     conKMS,
+			authorizer: authorizer,
+		},
 	}
 }
 
 // Run starts the gRPC server.
 func (s *Server) Run(port string) error {
+	return s.serve(port)
+}
+
+// RunATLS starts the gRPC server for callers that attest themselves using aTLS.
+// The server requires mutual aTLS, but doesn't attest itself.
+func (s *Server) RunATLS(port string, validators []atls.Validator) error {
+	return s.serve(port, grpc.Creds(atlscredentials.New(nil, validators)))
+}
+
+// RunHTTPS starts the HTTPS server of the workload API.
+// Callers send their service account token as bearer token, so the API is only served over TLS.
+func (s *Server) RunHTTPS(port string, getCertificate func(*tls.ClientHelloInfo) (*tls.Certificate, error)) error {
+	mux := http.NewServeMux()
+	mux.Handle("/v1/workload/keys", s.workload)
+	server := &http.Server{
+		Addr:    net.JoinHostPort("", port),
+		Handler: mux,
+		TLSConfig: &tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			GetCertificate: getCertificate,
+		},
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	s.log.Info(fmt.Sprintf("Starting Constellation workload key API on %s", server.Addr))
+	return server.ListenAndServeTLS("", "")
+}
+
+func (s *Server) serve(port string, opts ...grpc.ServerOption) error {
 	// set up listener
 	listener, err := net.Listen("tcp", net.JoinHostPort("", port))
 	if err != nil {
@@ -51,8 +99,9 @@ func (s *Server) Run(port string) error {
 	grpcLog := logger.GRPCLogger(s.log)
 	logger.ReplaceGRPCLogger(grpcLog)
 
-	server := grpc.NewServer(logger.GetServerUnaryInterceptor(grpcLog))
+	server := grpc.NewServer(append(opts, logger.GetServerUnaryInterceptor(grpcLog))...)
 	keyserviceproto.RegisterAPIServer(server, s)
+	keyserviceproto.RegisterWorkloadAPIServer(server, s.workload)
 
 	// start the server
 	s.log.Info(fmt.Sprintf("Starting Constellation key management service on %s", listener.Addr().String()))
@@ -75,6 +124,25 @@ func (s *Server) GetDataKey(ctx context.Context, in *keyserviceproto.GetDataKeyR
 		return nil, status.Error(codes.InvalidArgument, "no data key ID specified")
 	}
 
+	// Workload keys are scoped to the namespace of the caller and may only be derived through the workload API.
+	// This is independent of the authorization mode, since KeyAccessPolicies can't express namespace scoping.
+	if strings.HasPrefix(in.DataKeyId, crypto.WorkloadKeyIDPrefix) {
+		log.With(slog.String("keyID", in.DataKeyId)).Warn("Rejecting request for workload key")
+		return nil, status.Errorf(codes.PermissionDenied, "keys with prefix %q can only be derived through the workload API", crypto.WorkloadKeyIDPrefix)
+	}
+
+	if err := s.authorizer.Authorize(ctx, in.DataKeyId); err != nil {
+		return nil, err
+	}
+
+	if in.DataKeyId == attestation.MeasurementSecretContext && len(s.measurementSecret) > 0 {
+		if int(in.Length) != len(s.measurementSecret) {
+			log.Error("Requested length does not match pinned measurement secret")
+			return nil, status.Errorf(codes.InvalidArgument, "measurement secret has length %d", len(s.measurementSecret))
+		}
+		return &keyserviceproto.GetDataKeyResponse{DataKey: s.measurementSecret}, nil
+	}
+
 	key, err := s.conKMS.GetDEK(ctx, crypto.DEKPrefix+in.DataKeyId, int(in.Length))
 	if err != nil {
 		log.With(slog.Any("error", err)).Error("Failed to get data key")
@@ -82,3 +150,10 @@ func (s *Server) GetDataKey(ctx context.Context, in *keyserviceproto.GetDataKeyR
 	}
 	return &keyserviceproto.GetDataKeyResponse{DataKey: key}, nil
 }
+
+type authorizer interface {
+	// Authorize checks if the caller may derive the key with the given ID.
+	Authorize(ctx context.Context, keyID string) error
+	// AuthorizeWorkload authenticates the caller and returns the ID of the named key in its namespace.
+	AuthorizeWorkload(ctx context.Context, name string) (string, error)
+}
@@ -11,12 +11,15 @@ import (
 	"errors"
 	"testing"
 
+	"github.com/edgelesssys/constellation/v2/internal/attestation"
 	"github.com/edgelesssys/constellation/v2/internal/kms/kms"
 	"github.com/edgelesssys/constellation/v2/internal/logger"
 	"github.com/edgelesssys/constellation/v2/keyservice/keyserviceproto"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/goleak"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 func TestMain(m *testing.M) {
@@ -30,7 +33,7 @@ func TestGetDataKey(t *testing.T) {
 	log := logger.NewTest(t)
 
 	kms := &stubKMS{derivedKey: []byte{0x0, 0x1, 0x2, 0x3, 0x4, 0x5}}
-	api := New(log, kms)
+	api := New(log, kms, stubAuthorizer{}, nil)
 
 	res, err := api.GetDataKey(t.Context(), &keyserviceproto.GetDataKeyRequest{DataKeyId: "1", Length: 32})
 	require.NoError(err)
@@ -47,10 +50,71 @@ func TestGetDataKey(t *testing.T) {
 	assert.Nil(res)
 
 	// Test derive key error
-	api = New(log, &stubKMS{deriveKeyErr: errors.New("error")})
+	api = New(log, &stubKMS{deriveKeyErr: errors.New("error")}, stubAuthorizer{}, nil)
 	res, err = api.GetDataKey(t.Context(), &keyserviceproto.GetDataKeyRequest{DataKeyId: "1", Length: 32})
 	assert.Error(err)
 	assert.Nil(res)
+
+	// Test workload key, which is denied even if the caller is authorized
+	res, err = api.GetDataKey(t.Context(), &keyserviceproto.GetDataKeyRequest{DataKeyId: "workload/default/key", Length: 32})
+	assert.Equal(codes.PermissionDenied, status.Code(err))
+	assert.Nil(res)
+
+	// Test unauthorized caller
+	api = New(log, kms, stubAuthorizer{err: status.Error(codes.PermissionDenied, "denied")}, nil)
+	res, err = api.GetDataKey(t.Context(), &keyserviceproto.GetDataKeyRequest{DataKeyId: "1", Length: 32})
+	assert.Equal(codes.PermissionDenied, status.Code(err))
+	assert.Nil(res)
+}
+
+func TestGetDataKeyPinnedMeasurementSecret(t *testing.T) {
+	measurementSecret := []byte{0x7, 0x7, 0x7, 0x7}
+	testCases := map[string]struct {
+		pinned  []byte
+		id      string
+		length  uint32
+		want    []byte
+		wantErr bool
+	}{
+		"pinned measurement secret": {
+			pinned: measurementSecret,
+			id:     attestation.MeasurementSecretContext,
+			length: 4,
+			want:   measurementSecret,
+		},
+		"pinned measurement secret with wrong length": {
+			pinned:  measurementSecret,
+			id:      attestation.MeasurementSecretContext,
+			length:  32,
+			wantErr: true,
+		},
+		"other keys are derived": {
+			pinned: measurementSecret,
+			id:     "1",
+			length: 32,
+			want:   []byte{0x1},
+		},
+		"no pinned measurement secret": {
+			id:     attestation.MeasurementSecretContext,
+			length: 32,
+			want:   []byte{0x1},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			api := New(logger.NewTest(t), &stubKMS{derivedKey: []byte{0x1}}, stubAuthorizer{}, tc.pinned)
+			res, err := api.GetDataKey(t.Context(), &keyserviceproto.GetDataKeyRequest{DataKeyId: tc.id, Length: tc.length})
+			if tc.wantErr {
+				assert.Error(err)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(tc.want, res.DataKey)
+		})
+	}
 }
 
 type stubKMS struct {
@@ -71,3 +135,19 @@ func (c *stubKMS) GetDEK(_ context.Context, _ string, _ int) ([]byte, error) {
 	}
 	return c.derivedKey, nil
 }
+
+type stubAuthorizer struct {
+	err       error
+	namespace string
+}
+
+func (a stubAuthorizer) Authorize(context.Context, string) error {
+	return a.err
+}
+
+func (a stubAuthorizer) AuthorizeWorkload(_ context.Context, name string) (string, error) {
+	if a.err != nil {
+		return "", a.err
+	}
+	return "workload/" + a.namespace + "/" + name, nil
+}
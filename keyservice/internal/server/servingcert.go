@@ -0,0 +1,148 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package server
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	constellationcrypto "github.com/edgelesssys/constellation/v2/internal/crypto"
+)
+
+const (
+	// servingCertValidity is the validity of a serving certificate of the workload API.
+	servingCertValidity = 30 * 24 * time.Hour
+	// servingCertRenewBefore is the time before expiry at which a serving certificate is renewed.
+	servingCertRenewBefore = 7 * 24 * time.Hour
+)
+
+// ServingCertIssuer issues the TLS certificate of the workload HTTPS API.
+// The certificate is signed by the Kubernetes root CA, so workloads can verify it with the CA certificate
+// mounted into every pod at /var/run/secrets/kubernetes.io/serviceaccount/ca.crt.
+type ServingCertIssuer struct {
+	caCert   *x509.Certificate
+	caKey    crypto.Signer
+	dnsNames []string
+	now      func() time.Time
+
+	mux  sync.Mutex
+	cert *tls.Certificate
+}
+
+// NewServingCertIssuer creates a ServingCertIssuer for the given DNS names
+// from the PEM encoded certificate and private key of the Kubernetes root CA.
+func NewServingCertIssuer(caCertPEM, caKeyPEM []byte, dnsNames []string) (*ServingCertIssuer, error) {
+	if len(dnsNames) == 0 {
+		return nil, errors.New("no DNS names for the serving certificate")
+	}
+	caCert, err := constellationcrypto.PemToX509Cert(caCertPEM)
+	if err != nil {
+		return nil, fmt.Errorf("parsing CA certificate: %w", err)
+	}
+	caKey, err := parsePrivateKey(caKeyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("parsing CA private key: %w", err)
+	}
+	return &ServingCertIssuer{
+		caCert:   caCert,
+		caKey:    caKey,
+		dnsNames: dnsNames,
+		now:      time.Now,
+	}, nil
+}
+
+// GetCertificate returns the serving certificate and renews it shortly before it expires.
+// It implements the GetCertificate callback of tls.Config.
+func (i *ServingCertIssuer) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	i.mux.Lock()
+	defer i.mux.Unlock()
+
+	if i.cert != nil && i.now().Before(i.cert.Leaf.NotAfter.Add(-servingCertRenewBefore)) {
+		return i.cert, nil
+	}
+	cert, err := i.issue()
+	if err != nil {
+		return nil, err
+	}
+	i.cert = cert
+	return cert, nil
+}
+
+// issue creates a new key pair and signs its certificate with the Kubernetes root CA.
+func (i *ServingCertIssuer) issue() (*tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("generating serving key: %w", err)
+	}
+	serialNumber, err := constellationcrypto.GenerateCertificateSerialNumber()
+	if err != nil {
+		return nil, fmt.Errorf("generating serial number: %w", err)
+	}
+
+	now := i.now()
+	tmpl := &x509.Certificate{
+		SerialNumber:          serialNumber,
+		Subject:               pkix.Name{CommonName: i.dnsNames[0]},
+		DNSNames:              i.dnsNames,
+		NotBefore:             now.Add(-2 * time.Hour),
+		NotAfter:              now.Add(servingCertValidity),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+	}
+	certRaw, err := x509.CreateCertificate(rand.Reader, tmpl, i.caCert, &key.PublicKey, i.caKey)
+	if err != nil {
+		return nil, fmt.Errorf("creating serving certificate: %w", err)
+	}
+	leaf, err := x509.ParseCertificate(certRaw)
+	if err != nil {
+		return nil, fmt.Errorf("parsing serving certificate: %w", err)
+	}
+	return &tls.Certificate{
+		Certificate: [][]byte{certRaw},
+		PrivateKey:  key,
+		Leaf:        leaf,
+	}, nil
+}
+
+// parsePrivateKey parses a PEM encoded EC, RSA, or PKCS #8 private key.
+func parsePrivateKey(raw []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return nil, errors.New("no PEM data found")
+	}
+	var key any
+	var err error
+	switch block.Type {
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key %T", key)
+	}
+	return signer, nil
+}
@@ -0,0 +1,154 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewServingCertIssuer(t *testing.T) {
+	caCert, caKey := newTestCA(t)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	ecKeyRaw, err := x509.MarshalECPrivateKey(ecKey)
+	require.NoError(t, err)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	testCases := map[string]struct {
+		caCert   []byte
+		caKey    []byte
+		dnsNames []string
+		wantErr  bool
+	}{
+		"PKCS #8 key": {
+			caCert:   caCert,
+			caKey:    caKey,
+			dnsNames: []string{"key-service.kube-system.svc"},
+		},
+		"EC key": {
+			caCert:   caCert,
+			caKey:    pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: ecKeyRaw}),
+			dnsNames: []string{"key-service.kube-system.svc"},
+		},
+		"RSA key": {
+			caCert:   caCert,
+			caKey:    pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)}),
+			dnsNames: []string{"key-service.kube-system.svc"},
+		},
+		"unsupported key type": {
+			caCert:   caCert,
+			caKey:    pem.EncodeToMemory(&pem.Block{Type: "UNKNOWN", Bytes: ecKeyRaw}),
+			dnsNames: []string{"key-service.kube-system.svc"},
+			wantErr:  true,
+		},
+		"invalid key": {
+			caCert:   caCert,
+			caKey:    []byte("invalid"),
+			dnsNames: []string{"key-service.kube-system.svc"},
+			wantErr:  true,
+		},
+		"invalid certificate": {
+			caCert:   []byte("invalid"),
+			caKey:    caKey,
+			dnsNames: []string{"key-service.kube-system.svc"},
+			wantErr:  true,
+		},
+		"no DNS names": {
+			caCert:  caCert,
+			caKey:   caKey,
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewServingCertIssuer(tc.caCert, tc.caKey, tc.dnsNames)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestServingCertIssuerGetCertificate(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	caCertPEM, caKeyPEM := newTestCA(t)
+	issuer, err := NewServingCertIssuer(caCertPEM, caKeyPEM, []string{"key-service.kube-system.svc", "key-service.kube-system"})
+	require.NoError(err)
+	now := time.Now()
+	issuer.now = func() time.Time { return now }
+
+	cert, err := issuer.GetCertificate(nil)
+	require.NoError(err)
+	caCert, err := x509.ParseCertificate(pemBytes(t, caCertPEM))
+	require.NoError(err)
+	roots := x509.NewCertPool()
+	roots.AddCert(caCert)
+	for _, name := range []string{"key-service.kube-system.svc", "key-service.kube-system"} {
+		_, err = cert.Leaf.Verify(x509.VerifyOptions{DNSName: name, Roots: roots, CurrentTime: now})
+		assert.NoError(err)
+	}
+	_, err = cert.Leaf.Verify(x509.VerifyOptions{DNSName: "other.kube-system.svc", Roots: roots, CurrentTime: now})
+	assert.Error(err)
+
+	// the certificate is reused until it is close to expiring
+	now = now.Add(servingCertValidity - servingCertRenewBefore - time.Minute)
+	sameCert, err := issuer.GetCertificate(nil)
+	require.NoError(err)
+	assert.Same(cert, sameCert)
+
+	now = now.Add(2 * time.Minute)
+	renewedCert, err := issuer.GetCertificate(nil)
+	require.NoError(err)
+	assert.NotSame(cert, renewedCert)
+	assert.True(renewedCert.Leaf.NotAfter.After(cert.Leaf.NotAfter))
+}
+
+func newTestCA(t *testing.T) (certPEM, keyPEM []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "kubernetes"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(10 * 365 * 24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	certRaw, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyRaw, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certRaw}),
+		pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyRaw})
+}
+
+func pemBytes(t *testing.T, raw []byte) []byte {
+	t.Helper()
+	block, _ := pem.Decode(raw)
+	require.NotNil(t, block)
+	return block.Bytes
+}
@@ -0,0 +1,132 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"regexp"
+
+	"github.com/edgelesssys/constellation/v2/internal/crypto"
+	"github.com/edgelesssys/constellation/v2/internal/grpc/grpclog"
+	"github.com/edgelesssys/constellation/v2/keyservice/keyserviceproto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// maxWorkloadKeyLength is the maximum length of a key derived for a workload.
+const maxWorkloadKeyLength = 64
+
+// workloadKeyNamePattern restricts the names of workload keys.
+var workloadKeyNamePattern = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9._/-]{0,251}[a-zA-Z0-9])?$`)
+
+// workloadServer implements the workload-facing key derivation API.
+type workloadServer struct {
+	log        *slog.Logger
+	conKMS     deriver
+	authorizer authorizer
+	keyserviceproto.UnimplementedWorkloadAPIServer
+}
+
+// DeriveKey derives a key for the namespace of the caller.
+func (s *workloadServer) DeriveKey(ctx context.Context, in *keyserviceproto.DeriveKeyRequest) (*keyserviceproto.DeriveKeyResponse, error) {
+	log := s.log.With("peerAddress", grpclog.PeerAddrFromContext(ctx), "name", in.Name)
+
+	if in.Length == 0 || in.Length > maxWorkloadKeyLength {
+		log.Error("Requested key length is invalid")
+		return nil, status.Errorf(codes.InvalidArgument, "key length must be between 1 and %d bytes", maxWorkloadKeyLength)
+	}
+	if !workloadKeyNamePattern.MatchString(in.Name) {
+		log.Error("Requested key name is invalid")
+		return nil, status.Errorf(codes.InvalidArgument, "invalid key name %q", in.Name)
+	}
+
+	keyID, err := s.authorizer.AuthorizeWorkload(ctx, in.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := s.conKMS.GetDEK(ctx, crypto.DEKPrefix+keyID, int(in.Length))
+	if err != nil {
+		log.With(slog.Any("error", err)).Error("Failed to derive workload key")
+		return nil, status.Errorf(codes.Internal, "%v", err)
+	}
+	return &keyserviceproto.DeriveKeyResponse{Key: key}, nil
+}
+
+// ServeHTTP implements the workload API as JSON over HTTP for workloads that don't use gRPC.
+// Workloads authenticate with their service account token in the Authorization header.
+func (s *workloadServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req deriveKeyRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("decoding request: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	// Translate the HTTP request into the context of a gRPC request, so authentication and logging work the same for both.
+	ctx := metadata.NewIncomingContext(r.Context(), metadata.Pairs("authorization", r.Header.Get("Authorization")))
+	ctx = peer.NewContext(ctx, &peer.Peer{Addr: httpRemoteAddr(r.RemoteAddr)})
+
+	res, err := s.DeriveKey(ctx, &keyserviceproto.DeriveKeyRequest{Name: req.Name, Length: req.Length})
+	if err != nil {
+		http.Error(w, status.Convert(err).Message(), httpStatus(status.Code(err)))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(deriveKeyResponse{Key: res.Key}); err != nil {
+		s.log.With(slog.Any("error", err)).Error("Failed to write response")
+	}
+}
+
+// deriveKeyRequest is the body of a key derivation request over HTTP.
+type deriveKeyRequest struct {
+	Name   string `json:"name"`
+	Length uint32 `json:"length"`
+}
+
+// deriveKeyResponse is the body of a key derivation response over HTTP.
+// The key is encoded as base64.
+type deriveKeyResponse struct {
+	Key []byte `json:"key"`
+}
+
+// httpStatus maps the gRPC status codes returned by DeriveKey to HTTP status codes.
+func httpStatus(code codes.Code) int {
+	switch code {
+	case codes.InvalidArgument:
+		return http.StatusBadRequest
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// httpRemoteAddr is the address of an HTTP client.
+type httpRemoteAddr string
+
+func (a httpRemoteAddr) Network() string { return "tcp" }
+func (a httpRemoteAddr) String() string  { return string(a) }
+
+var _ net.Addr = httpRemoteAddr("")
+
+type deriver interface {
+	GetDEK(ctx context.Context, dekID string, dekSize int) ([]byte, error)
+}
@@ -0,0 +1,186 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/edgelesssys/constellation/v2/internal/logger"
+	"github.com/edgelesssys/constellation/v2/keyservice/keyserviceproto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestWorkloadDeriveKey(t *testing.T) {
+	testCases := map[string]struct {
+		kms        *recordingKMS
+		authorizer stubAuthorizer
+		req        *keyserviceproto.DeriveKeyRequest
+		wantKeyID  string
+		wantCode   codes.Code
+	}{
+		"success": {
+			kms:        &recordingKMS{key: []byte{0x1, 0x2}},
+			authorizer: stubAuthorizer{namespace: "default"},
+			req:        &keyserviceproto.DeriveKeyRequest{Name: "app/db-password", Length: 32},
+			wantKeyID:  "key-workload/default/app/db-password",
+		},
+		"zero length": {
+			kms:        &recordingKMS{},
+			authorizer: stubAuthorizer{namespace: "default"},
+			req:        &keyserviceproto.DeriveKeyRequest{Name: "key"},
+			wantCode:   codes.InvalidArgument,
+		},
+		"length too large": {
+			kms:        &recordingKMS{},
+			authorizer: stubAuthorizer{namespace: "default"},
+			req:        &keyserviceproto.DeriveKeyRequest{Name: "key", Length: maxWorkloadKeyLength + 1},
+			wantCode:   codes.InvalidArgument,
+		},
+		"empty name": {
+			kms:        &recordingKMS{},
+			authorizer: stubAuthorizer{namespace: "default"},
+			req:        &keyserviceproto.DeriveKeyRequest{Length: 32},
+			wantCode:   codes.InvalidArgument,
+		},
+		"name escaping the namespace": {
+			kms:        &recordingKMS{},
+			authorizer: stubAuthorizer{namespace: "default"},
+			req:        &keyserviceproto.DeriveKeyRequest{Name: "../other/key", Length: 32},
+			wantCode:   codes.InvalidArgument,
+		},
+		"unauthenticated": {
+			kms:        &recordingKMS{},
+			authorizer: stubAuthorizer{err: status.Error(codes.Unauthenticated, "no token")},
+			req:        &keyserviceproto.DeriveKeyRequest{Name: "key", Length: 32},
+			wantCode:   codes.Unauthenticated,
+		},
+		"derivation fails": {
+			kms:        &recordingKMS{err: errors.New("failed")},
+			authorizer: stubAuthorizer{namespace: "default"},
+			req:        &keyserviceproto.DeriveKeyRequest{Name: "key", Length: 32},
+			wantCode:   codes.Internal,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			s := &workloadServer{log: logger.NewTest(t), conKMS: tc.kms, authorizer: tc.authorizer}
+			res, err := s.DeriveKey(t.Context(), tc.req)
+			if tc.wantCode != codes.OK {
+				assert.Equal(tc.wantCode, status.Code(err))
+				assert.Empty(tc.kms.keyID)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(tc.kms.key, res.Key)
+			assert.Equal(tc.wantKeyID, tc.kms.keyID)
+			assert.Equal(int(tc.req.Length), tc.kms.length)
+		})
+	}
+}
+
+func TestWorkloadServeHTTP(t *testing.T) {
+	testCases := map[string]struct {
+		method     string
+		body       string
+		authorizer stubAuthorizer
+		wantStatus int
+	}{
+		"success": {
+			method:     http.MethodPost,
+			body:       `{"name": "key", "length": 32}`,
+			authorizer: stubAuthorizer{namespace: "default"},
+			wantStatus: http.StatusOK,
+		},
+		"wrong method": {
+			method:     http.MethodGet,
+			authorizer: stubAuthorizer{namespace: "default"},
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+		"invalid body": {
+			method:     http.MethodPost,
+			body:       `{"name":`,
+			authorizer: stubAuthorizer{namespace: "default"},
+			wantStatus: http.StatusBadRequest,
+		},
+		"invalid name": {
+			method:     http.MethodPost,
+			body:       `{"name": "/key", "length": 32}`,
+			authorizer: stubAuthorizer{namespace: "default"},
+			wantStatus: http.StatusBadRequest,
+		},
+		"unauthenticated": {
+			method:     http.MethodPost,
+			body:       `{"name": "key", "length": 32}`,
+			authorizer: stubAuthorizer{err: status.Error(codes.Unauthenticated, "no token")},
+			wantStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			kms := &recordingKMS{key: []byte{0x1, 0x2, 0x3}}
+			authorizer := &tokenRecordingAuthorizer{stubAuthorizer: tc.authorizer}
+			s := &workloadServer{log: logger.NewTest(t), conKMS: kms, authorizer: authorizer}
+
+			req := httptest.NewRequest(tc.method, "/v1/workload/keys", strings.NewReader(tc.body))
+			req.Header.Set("Authorization", "Bearer token")
+			rec := httptest.NewRecorder()
+			s.ServeHTTP(rec, req)
+
+			assert.Equal(tc.wantStatus, rec.Code)
+			if tc.wantStatus != http.StatusOK {
+				return
+			}
+			var res deriveKeyResponse
+			require.NoError(json.NewDecoder(rec.Body).Decode(&res))
+			assert.Equal(kms.key, res.Key)
+			assert.Equal([]string{"Bearer token"}, authorizer.authorization)
+		})
+	}
+}
+
+type recordingKMS struct {
+	key    []byte
+	err    error
+	keyID  string
+	length int
+}
+
+func (k *recordingKMS) GetDEK(_ context.Context, dekID string, dekSize int) ([]byte, error) {
+	if k.err != nil {
+		return nil, k.err
+	}
+	k.keyID = dekID
+	k.length = dekSize
+	return k.key, nil
+}
+
+type tokenRecordingAuthorizer struct {
+	stubAuthorizer
+	authorization []string
+}
+
+func (a *tokenRecordingAuthorizer) AuthorizeWorkload(ctx context.Context, name string) (string, error) {
+	a.authorization = metadata.ValueFromIncomingContext(ctx, "authorization")
+	return a.stubAuthorizer.AuthorizeWorkload(ctx, name)
+}
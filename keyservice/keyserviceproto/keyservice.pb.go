@@ -121,6 +121,102 @@ func (x *GetDataKeyResponse) GetDataKey() []byte {
 	return nil
 }
 
+type DeriveKeyRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Length        uint32                 `protobuf:"varint,2,opt,name=length,proto3" json:"length,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeriveKeyRequest) Reset() {
+	*x = DeriveKeyRequest{}
+	mi := &file_keyservice_keyserviceproto_keyservice_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeriveKeyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeriveKeyRequest) ProtoMessage() {}
+
+func (x *DeriveKeyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_keyservice_keyserviceproto_keyservice_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeriveKeyRequest.ProtoReflect.Descriptor instead.
+func (*DeriveKeyRequest) Descriptor() ([]byte, []int) {
+	return file_keyservice_keyserviceproto_keyservice_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *DeriveKeyRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *DeriveKeyRequest) GetLength() uint32 {
+	if x != nil {
+		return x.Length
+	}
+	return 0
+}
+
+type DeriveKeyResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           []byte                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeriveKeyResponse) Reset() {
+	*x = DeriveKeyResponse{}
+	mi := &file_keyservice_keyserviceproto_keyservice_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeriveKeyResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeriveKeyResponse) ProtoMessage() {}
+
+func (x *DeriveKeyResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_keyservice_keyserviceproto_keyservice_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeriveKeyResponse.ProtoReflect.Descriptor instead.
+func (*DeriveKeyResponse) Descriptor() ([]byte, []int) {
+	return file_keyservice_keyserviceproto_keyservice_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *DeriveKeyResponse) GetKey() []byte {
+	if x != nil {
+		return x.Key
+	}
+	return nil
+}
+
 var File_keyservice_keyserviceproto_keyservice_proto protoreflect.FileDescriptor
 
 const file_keyservice_keyserviceproto_keyservice_proto_rawDesc = "" +
@@ -130,10 +226,17 @@ const file_keyservice_keyserviceproto_keyservice_proto_rawDesc = "" +
 	"\vdata_key_id\x18\x01 \x01(\tR\tdataKeyId\x12\x16\n" +
 	"\x06length\x18\x02 \x01(\rR\x06length\"/\n" +
 	"\x12GetDataKeyResponse\x12\x19\n" +
-	"\bdata_key\x18\x01 \x01(\fR\adataKey2D\n" +
+	"\bdata_key\x18\x01 \x01(\fR\adataKey\">\n" +
+	"\x10DeriveKeyRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x16\n" +
+	"\x06length\x18\x02 \x01(\rR\x06length\"%\n" +
+	"\x11DeriveKeyResponse\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\fR\x03key2D\n" +
 	"\x03API\x12=\n" +
 	"\n" +
-	"GetDataKey\x12\x16.kms.GetDataKeyRequest\x1a\x17.kms.GetDataKeyResponseBDZBgithub.com/edgelesssys/constellation/v2/keyservice/keyserviceprotob\x06proto3"
+	"GetDataKey\x12\x16.kms.GetDataKeyRequest\x1a\x17.kms.GetDataKeyResponse2I\n" +
+	"\vWorkloadAPI\x12:\n" +
+	"\tDeriveKey\x12\x15.kms.DeriveKeyRequest\x1a\x16.kms.DeriveKeyResponseBDZBgithub.com/edgelesssys/constellation/v2/keyservice/keyserviceprotob\x06proto3"
 
 var (
 	file_keyservice_keyserviceproto_keyservice_proto_rawDescOnce sync.Once
@@ -147,16 +250,20 @@ func file_keyservice_keyserviceproto_keyservice_proto_rawDescGZIP() []byte {
 	return file_keyservice_keyserviceproto_keyservice_proto_rawDescData
 }
 
-var file_keyservice_keyserviceproto_keyservice_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_keyservice_keyserviceproto_keyservice_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
 var file_keyservice_keyserviceproto_keyservice_proto_goTypes = []any{
 	(*GetDataKeyRequest)(nil),  // 0: kms.GetDataKeyRequest
 	(*GetDataKeyResponse)(nil), // 1: kms.GetDataKeyResponse
+	(*DeriveKeyRequest)(nil),   // 2: kms.DeriveKeyRequest
+	(*DeriveKeyResponse)(nil),  // 3: kms.DeriveKeyResponse
 }
 var file_keyservice_keyserviceproto_keyservice_proto_depIdxs = []int32{
 	0, // 0: kms.API.GetDataKey:input_type -> kms.GetDataKeyRequest
-	1, // 1: kms.API.GetDataKey:output_type -> kms.GetDataKeyResponse
-	1, // [1:2] is the sub-list for method output_type
-	0, // [0:1] is the sub-list for method input_type
+	2, // 1: kms.WorkloadAPI.DeriveKey:input_type -> kms.DeriveKeyRequest
+	1, // 2: kms.API.GetDataKey:output_type -> kms.GetDataKeyResponse
+	3, // 3: kms.WorkloadAPI.DeriveKey:output_type -> kms.DeriveKeyResponse
+	2, // [2:4] is the sub-list for method output_type
+	0, // [0:2] is the sub-list for method input_type
 	0, // [0:0] is the sub-list for extension type_name
 	0, // [0:0] is the sub-list for extension extendee
 	0, // [0:0] is the sub-list for field type_name
@@ -173,9 +280,9 @@ func file_keyservice_keyserviceproto_keyservice_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_keyservice_keyserviceproto_keyservice_proto_rawDesc), len(file_keyservice_keyserviceproto_keyservice_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   2,
+			NumMessages:   4,
 			NumExtensions: 0,
-			NumServices:   1,
+			NumServices:   2,
 		},
 		GoTypes:           file_keyservice_keyserviceproto_keyservice_proto_goTypes,
 		DependencyIndexes: file_keyservice_keyserviceproto_keyservice_proto_depIdxs,
@@ -265,3 +372,75 @@ var _API_serviceDesc = grpc.ServiceDesc{
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "keyservice/keyserviceproto/keyservice.proto",
 }
+
+// WorkloadAPIClient is the client API for WorkloadAPI service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type WorkloadAPIClient interface {
+	DeriveKey(ctx context.Context, in *DeriveKeyRequest, opts ...grpc.CallOption) (*DeriveKeyResponse, error)
+}
+
+type workloadAPIClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewWorkloadAPIClient(cc grpc.ClientConnInterface) WorkloadAPIClient {
+	return &workloadAPIClient{cc}
+}
+
+func (c *workloadAPIClient) DeriveKey(ctx context.Context, in *DeriveKeyRequest, opts ...grpc.CallOption) (*DeriveKeyResponse, error) {
+	out := new(DeriveKeyResponse)
+	err := c.cc.Invoke(ctx, "/kms.WorkloadAPI/DeriveKey", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// WorkloadAPIServer is the server API for WorkloadAPI service.
+type WorkloadAPIServer interface {
+	DeriveKey(context.Context, *DeriveKeyRequest) (*DeriveKeyResponse, error)
+}
+
+// UnimplementedWorkloadAPIServer can be embedded to have forward compatible implementations.
+type UnimplementedWorkloadAPIServer struct {
+}
+
+func (*UnimplementedWorkloadAPIServer) DeriveKey(context.Context, *DeriveKeyRequest) (*DeriveKeyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeriveKey not implemented")
+}
+
+func RegisterWorkloadAPIServer(s *grpc.Server, srv WorkloadAPIServer) {
+	s.RegisterService(&_WorkloadAPI_serviceDesc, srv)
+}
+
+func _WorkloadAPI_DeriveKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeriveKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WorkloadAPIServer).DeriveKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/kms.WorkloadAPI/DeriveKey",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WorkloadAPIServer).DeriveKey(ctx, req.(*DeriveKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _WorkloadAPI_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "kms.WorkloadAPI",
+	HandlerType: (*WorkloadAPIServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "DeriveKey",
+			Handler:    _WorkloadAPI_DeriveKey_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "keyservice/keyserviceproto/keyservice.proto",
+}
@@ -0,0 +1,104 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+/*
+Package workloadkeys is a client for the workload key API of the Constellation KeyService.
+
+Workloads use it to derive stable keys that are bound to the cluster, without holding the cluster's master secret.
+Keys are scoped to the namespace of the workload: two workloads in the same namespace derive the same key for the same name,
+while workloads in other namespaces can't derive it.
+
+The client authenticates with a projected service account token for the audience "constellation-key-service":
+
+	volumes:
+	  - name: key-service-token
+	    projected:
+	      sources:
+	        - serviceAccountToken:
+	            audience: constellation-key-service
+	            expirationSeconds: 3600
+	            path: key-service-token
+
+The volume must be mounted at /var/run/secrets/tokens, or the path must be passed to New.
+*/
+package workloadkeys
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/edgelesssys/constellation/v2/internal/constants"
+	"github.com/edgelesssys/constellation/v2/keyservice/keyserviceproto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// DefaultEndpoint is the endpoint of the KeyService in a Constellation cluster.
+	DefaultEndpoint = "key-service.kube-system:9000"
+	// DefaultTokenPath is the default path of the projected service account token.
+	DefaultTokenPath = constants.KeyServiceTokenPath
+)
+
+// Client derives keys for a workload.
+type Client struct {
+	endpoint  string
+	tokenPath string
+	grpc      grpcClient
+}
+
+// New creates a new Client for the KeyService at endpoint,
+// which authenticates with the service account token at tokenPath.
+func New(endpoint, tokenPath string) *Client {
+	return &Client{
+		endpoint:  endpoint,
+		tokenPath: tokenPath,
+		grpc:      client{},
+	}
+}
+
+// DeriveKey derives the key with the given name and length in bytes.
+// The same name always results in the same key for workloads in the same namespace.
+func (c *Client) DeriveKey(ctx context.Context, name string, length int) ([]byte, error) {
+	if length <= 0 {
+		return nil, errors.New("key length must be positive")
+	}
+	token, err := os.ReadFile(c.tokenPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading service account token: %w", err)
+	}
+	ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+strings.TrimSpace(string(token)))
+
+	// the KeyService does not use TLS since traffic is only routed through the Constellation cluster
+	conn, err := grpc.NewClient(c.endpoint, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	res, err := c.grpc.DeriveKey(ctx, &keyserviceproto.DeriveKeyRequest{
+		Name:   name,
+		Length: uint32(length),
+	}, conn)
+	if err != nil {
+		return nil, fmt.Errorf("deriving key from Constellation KeyService: %w", err)
+	}
+	return res.Key, nil
+}
+
+type grpcClient interface {
+	DeriveKey(context.Context, *keyserviceproto.DeriveKeyRequest, *grpc.ClientConn) (*keyserviceproto.DeriveKeyResponse, error)
+}
+
+type client struct{}
+
+func (c client) DeriveKey(ctx context.Context, req *keyserviceproto.DeriveKeyRequest, conn *grpc.ClientConn) (*keyserviceproto.DeriveKeyResponse, error) {
+	return keyserviceproto.NewWorkloadAPIClient(conn).DeriveKey(ctx, req)
+}
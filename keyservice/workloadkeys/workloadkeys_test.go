@@ -0,0 +1,98 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package workloadkeys
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/edgelesssys/constellation/v2/keyservice/keyserviceproto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestMain(m *testing.M) {
+	goleak.VerifyTestMain(m)
+}
+
+func TestDeriveKey(t *testing.T) {
+	testCases := map[string]struct {
+		token   string
+		noToken bool
+		length  int
+		client  *stubGRPCClient
+		wantErr bool
+	}{
+		"success": {
+			token:  "token\n",
+			length: 32,
+			client: &stubGRPCClient{key: []byte{0x1, 0x2}},
+		},
+		"token missing": {
+			noToken: true,
+			length:  32,
+			client:  &stubGRPCClient{},
+			wantErr: true,
+		},
+		"invalid length": {
+			token:   "token",
+			client:  &stubGRPCClient{},
+			wantErr: true,
+		},
+		"derivation fails": {
+			token:   "token",
+			length:  32,
+			client:  &stubGRPCClient{err: errors.New("failed")},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			tokenPath := filepath.Join(t.TempDir(), "token")
+			if !tc.noToken {
+				require.NoError(os.WriteFile(tokenPath, []byte(tc.token), 0o600))
+			}
+			c := New("192.0.2.1:9000", tokenPath)
+			c.grpc = tc.client
+
+			key, err := c.DeriveKey(t.Context(), "db-password", tc.length)
+			if tc.wantErr {
+				assert.Error(err)
+				return
+			}
+			require.NoError(err)
+			assert.Equal(tc.client.key, key)
+			assert.Equal("db-password", tc.client.req.Name)
+			assert.EqualValues(tc.length, tc.client.req.Length)
+			assert.Equal([]string{"Bearer token"}, tc.client.authorization)
+		})
+	}
+}
+
+type stubGRPCClient struct {
+	key           []byte
+	err           error
+	req           *keyserviceproto.DeriveKeyRequest
+	authorization []string
+}
+
+func (c *stubGRPCClient) DeriveKey(ctx context.Context, req *keyserviceproto.DeriveKeyRequest, _ *grpc.ClientConn) (*keyserviceproto.DeriveKeyResponse, error) {
+	c.req = req
+	md, _ := metadata.FromOutgoingContext(ctx)
+	c.authorization = md.Get("authorization")
+	return &keyserviceproto.DeriveKeyResponse{Key: c.key}, c.err
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/edgelesssys/constellation/v2/internal/attestation/variant"
 	"github.com/edgelesssys/constellation/v2/internal/constants"
 	"github.com/edgelesssys/constellation/v2/internal/logger"
+	"github.com/edgelesssys/constellation/v2/measurement-reader/internal/snp"
 	"github.com/edgelesssys/constellation/v2/measurement-reader/internal/sorted"
 	"github.com/edgelesssys/constellation/v2/measurement-reader/internal/tdx"
 	"github.com/edgelesssys/constellation/v2/measurement-reader/internal/tpm"
@@ -30,7 +31,7 @@ func main() {
 
 	var m []sorted.Measurement
 	switch attestationVariant {
-	case variant.AWSNitroTPM{}, variant.AWSSEVSNP{}, variant.AzureSEVSNP{}, variant.AzureTrustedLaunch{}, variant.GCPSEVES{}, variant.GCPSEVSNP{}, variant.QEMUVTPM{}:
+	case variant.AWSNitroTPM{}, variant.AWSSEVSNP{}, variant.AzureSEVSNP{}, variant.AzureTrustedLaunch{}, variant.GCPSEVES{}, variant.GCPSEVSNP{}, variant.GCPTDX{}, variant.QEMUVTPM{}:
 		m, err = tpm.Measurements()
 		if err != nil {
 			log.With(slog.Any("error", err)).Error("Failed to read TPM measurements")
@@ -42,6 +43,12 @@ func main() {
 			log.With(slog.Any("error", err)).Error("Failed to read Intel TDX measurements")
 			os.Exit(1)
 		}
+	case variant.QEMUSEVSNP{}:
+		m, err = snp.Measurements()
+		if err != nil {
+			log.With(slog.Any("error", err)).Error("Failed to read AMD SEV-SNP measurements")
+			os.Exit(1)
+		}
 	default:
 		log.With(slog.String("attestationVariant", variantString)).Error("Unsupported attestation variant")
 		os.Exit(1)
@@ -50,6 +57,7 @@ func main() {
 	fmt.Println("Measurements:")
 	for _, measurement := range m {
 		// -7 should ensure consistent padding across all current prefixes: PCR[xx], MRTD, RTMR[x].
+		// The SEV-SNP MEASUREMENT prefix is longer, but it is the only measurement printed for SEV-SNP.
 		// If the prefix gets longer somewhen in the future, this might need adjustment for consistent padding.
 		fmt.Printf("\t%-7s : 0x%0X\n", measurement.Index, measurement.Value)
 	}
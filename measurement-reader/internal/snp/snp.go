@@ -0,0 +1,23 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+// Package snp reads measurements from an AMD SEV-SNP guest without a vTPM.
+package snp
+
+import (
+	"github.com/edgelesssys/constellation/v2/internal/attestation/qemu/snp"
+	"github.com/edgelesssys/constellation/v2/measurement-reader/internal/sorted"
+)
+
+// Measurements returns the SEV-SNP launch measurement.
+func Measurements() ([]sorted.Measurement, error) {
+	m, err := snp.GetSelectedMeasurements()
+	if err != nil {
+		return nil, err
+	}
+
+	return sorted.SortMeasurements(m, sorted.SNP), nil
+}
@@ -4,7 +4,7 @@ Copyright (c) Edgeless Systems GmbH
 SPDX-License-Identifier: BUSL-1.1
 */
 
-// Package sorted defines a type for print-friendly sorted measurements and allows sorting TPM and TDX measurements.
+// Package sorted defines a type for print-friendly sorted measurements and allows sorting TPM, TDX and SEV-SNP measurements.
 package sorted
 
 import (
@@ -27,11 +27,12 @@ type MeasurementType uint32
 const (
 	TPM MeasurementType = iota
 	TDX
+	SNP
 )
 
-// SortMeasurements returns the sorted measurements for either TPM or TDX measurements.
+// SortMeasurements returns the sorted measurements for either TPM, TDX or SEV-SNP measurements.
 func SortMeasurements(m measurements.M, measurementType MeasurementType) []Measurement {
-	if measurementType != TPM && measurementType != TDX {
+	if measurementType != TPM && measurementType != TDX && measurementType != SNP {
 		return nil
 	}
 
@@ -58,6 +59,9 @@ func SortMeasurements(m measurements.M, measurementType MeasurementType) []Measu
 			}
 			// RTMR 0 starts at idx 1, so we have to subtract by one here.
 			index = fmt.Sprintf("RTMR[%01d]", idx-1)
+		case SNP:
+			// SEV-SNP only has the launch measurement at idx 0.
+			index = "MEASUREMENT"
 		}
 
 		expected := m[idx].Expected
@@ -108,6 +108,18 @@ func TestSortMeasurements(t *testing.T) {
 				},
 			},
 		},
+		"SNP": {
+			measurementType: SNP,
+			input: measurements.M{
+				0: measurements.WithAllBytes(0x11, measurements.Enforce, measurements.SNPMeasurementLength),
+			},
+			want: []Measurement{
+				{
+					Index: "MEASUREMENT",
+					Value: bytes.Repeat([]byte{0x11}, 48),
+				},
+			},
+		},
 	}
 
 	for name, tc := range testCases {
@@ -0,0 +1,105 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// ConditionSnapshotFailed is used to signal that the last etcd snapshot failed.
+	ConditionSnapshotFailed = "SnapshotFailed"
+	// EtcdBackupStorageURIKey is the key of the storage URI in the destination secret of an EtcdBackup.
+	EtcdBackupStorageURIKey = "storageURI"
+)
+
+// EtcdBackupSpec defines the desired state of EtcdBackup.
+type EtcdBackupSpec struct {
+	// Interval is the time between two snapshots, e.g. "6h".
+	// Intervals shorter than one minute are raised to one minute.
+	Interval metav1.Duration `json:"interval"`
+	// Suspend stops taking new snapshots. Existing snapshots are kept.
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+	// Destination is the object storage snapshots are uploaded to.
+	Destination EtcdBackupDestination `json:"destination"`
+	// Retention limits the number of snapshots kept in the destination.
+	// +optional
+	Retention EtcdBackupRetention `json:"retention,omitempty"`
+}
+
+// EtcdBackupDestination defines where etcd snapshots are stored.
+type EtcdBackupDestination struct {
+	// SecretName is the name of a secret in the kube-system namespace.
+	// The secret holds the URI of the storage backend under the key "storageURI",
+	// e.g. "storage://aws?bucket=...&region=...&accessKeyID=...&accessKey=...".
+	// +kubebuilder:validation:MinLength=1
+	SecretName string `json:"secretName"`
+	// Prefix is prepended to the names of the snapshots, so that multiple clusters can share a bucket.
+	// +optional
+	Prefix string `json:"prefix,omitempty"`
+}
+
+// EtcdBackupRetention defines how many snapshots are kept.
+// The most recent snapshot is always kept.
+type EtcdBackupRetention struct {
+	// MaxCount is the maximum number of snapshots kept. Zero keeps all snapshots.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxCount int32 `json:"maxCount,omitempty"`
+	// MaxAge is the maximum age of snapshots kept. Zero keeps all snapshots.
+	// +optional
+	MaxAge metav1.Duration `json:"maxAge,omitempty"`
+}
+
+// EtcdBackupStatus defines the observed state of EtcdBackup.
+type EtcdBackupStatus struct {
+	// LastScheduleTime is the time the last snapshot was attempted.
+	// +optional
+	LastScheduleTime *metav1.Time `json:"lastScheduleTime,omitempty"`
+	// LastSuccessfulTime is the time the last snapshot was uploaded.
+	// +optional
+	LastSuccessfulTime *metav1.Time `json:"lastSuccessfulTime,omitempty"`
+	// LastSnapshot is the name of the last snapshot uploaded.
+	// +optional
+	LastSnapshot string `json:"lastSnapshot,omitempty"`
+	// Snapshots is the number of snapshots in the destination after retention was applied.
+	// +optional
+	Snapshots int32 `json:"snapshots,omitempty"`
+	// ConsecutiveFailures is the number of snapshots that failed since the last successful one.
+	// +optional
+	ConsecutiveFailures int32 `json:"consecutiveFailures,omitempty"`
+	// Conditions represent the latest available observations of an object's state.
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:scope=Cluster
+
+// EtcdBackup is the Schema for the etcdbackups API.
+type EtcdBackup struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   EtcdBackupSpec   `json:"spec,omitempty"`
+	Status EtcdBackupStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// EtcdBackupList contains a list of EtcdBackups.
+type EtcdBackupList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []EtcdBackup `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&EtcdBackup{}, &EtcdBackupList{})
+}
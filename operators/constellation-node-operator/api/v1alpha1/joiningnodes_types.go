@@ -16,6 +16,8 @@ type JoiningNodeSpec struct {
 	Name string `json:"name,omitempty"`
 	// ComponentsReference is the reference to the ConfigMap containing the components.
 	ComponentsReference string `json:"componentsreference,omitempty"`
+	// DiskUUID is the UUID of the state disk of the node.
+	DiskUUID string `json:"diskuuid,omitempty"`
 	// IsControlPlane is true if the node is a control plane node.
 	IsControlPlane bool `json:"iscontrolplane,omitempty"`
 	// Deadline is the time after which the joining node is considered to have failed.
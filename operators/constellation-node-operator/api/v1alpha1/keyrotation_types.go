@@ -0,0 +1,71 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// KeyRotationPhasePending is the phase of a key rotation that has not been started yet.
+	KeyRotationPhasePending KeyRotationPhase = "Pending"
+	// KeyRotationPhaseInProgress is the phase of a key rotation while state disks are being re-keyed.
+	KeyRotationPhaseInProgress KeyRotationPhase = "InProgress"
+	// KeyRotationPhaseCompleted is the phase of a key rotation after the state disks of all nodes were re-keyed.
+	KeyRotationPhaseCompleted KeyRotationPhase = "Completed"
+)
+
+// KeyRotationPhase is the phase of a key rotation.
+// +kubebuilder:validation:Enum=Pending;InProgress;Completed
+type KeyRotationPhase string
+
+// KeyRotationSpec defines the desired state of KeyRotation.
+type KeyRotationSpec struct {
+	// RotationID identifies the rotation of the master secret or KEK.
+	RotationID string `json:"rotationID,omitempty"`
+}
+
+// KeyRotationStatus defines the observed state of KeyRotation.
+type KeyRotationStatus struct {
+	// Phase is the phase of the key rotation.
+	Phase KeyRotationPhase `json:"phase,omitempty"`
+	// RotatedNodes are the names of the nodes whose state disk was re-keyed.
+	// +optional
+	RotatedNodes []string `json:"rotatedNodes,omitempty"`
+	// PendingNodes are the names of the nodes whose state disk is being re-keyed.
+	// +optional
+	PendingNodes []string `json:"pendingNodes,omitempty"`
+	// FailedAttempts is the number of failed attempts to re-key the state disk of a node, by node name.
+	// +optional
+	FailedAttempts map[string]int32 `json:"failedAttempts,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:scope=Cluster
+
+// KeyRotation is the Schema for the keyrotations API.
+type KeyRotation struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   KeyRotationSpec   `json:"spec,omitempty"`
+	Status KeyRotationStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// KeyRotationList contains a list of KeyRotations.
+type KeyRotationList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []KeyRotation `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&KeyRotation{}, &KeyRotationList{})
+}
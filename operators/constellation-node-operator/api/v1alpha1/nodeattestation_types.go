@@ -0,0 +1,94 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// NodeAttestationPhasePassing is the phase of a node whose last attestation succeeded.
+	NodeAttestationPhasePassing NodeAttestationPhase = "Passing"
+	// NodeAttestationPhaseFailing is the phase of a node whose last attestation failed.
+	NodeAttestationPhaseFailing NodeAttestationPhase = "Failing"
+	// NodeAttestationPhaseUnknown is the phase of a node whose attestation statement couldn't be retrieved.
+	NodeAttestationPhaseUnknown NodeAttestationPhase = "Unknown"
+)
+
+// NodeAttestationPhase is the phase of a node's attestation.
+// +kubebuilder:validation:Enum=Passing;Failing;Unknown
+type NodeAttestationPhase string
+
+// NodeAttestationSpec defines the desired state of NodeAttestation.
+type NodeAttestationSpec struct {
+	// NodeName is the name of the attested node.
+	NodeName string `json:"nodeName,omitempty"`
+}
+
+// NodeAttestationCheck is the verdict of a single check of a node's attestation.
+type NodeAttestationCheck struct {
+	// Name of the check.
+	Name string `json:"name"`
+	// Verdict of the check.
+	Verdict string `json:"verdict"`
+	// Detail explains the verdict.
+	// +optional
+	Detail string `json:"detail,omitempty"`
+}
+
+// NodeAttestationStatus defines the observed state of NodeAttestation.
+type NodeAttestationStatus struct {
+	// Phase is the phase of the node's attestation.
+	Phase NodeAttestationPhase `json:"phase,omitempty"`
+	// Verdict is the overall verdict of the last attestation.
+	// +optional
+	Verdict string `json:"verdict,omitempty"`
+	// Message explains the phase.
+	// +optional
+	Message string `json:"message,omitempty"`
+	// Checks are the verdicts of the checks of the last attestation.
+	// +optional
+	Checks []NodeAttestationCheck `json:"checks,omitempty"`
+	// LastAttestationTime is the time of the last attestation attempt.
+	// +optional
+	LastAttestationTime metav1.Time `json:"lastAttestationTime,omitempty"`
+	// LastPassingTime is the time of the last passing attestation.
+	// +optional
+	LastPassingTime metav1.Time `json:"lastPassingTime,omitempty"`
+	// ConsecutiveFailures is the number of attestations that didn't pass since the last passing one.
+	// +optional
+	ConsecutiveFailures int32 `json:"consecutiveFailures,omitempty"`
+	// Cordoned is true if the node was cordoned because its attestation failed.
+	// +optional
+	Cordoned bool `json:"cordoned,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:scope=Cluster
+
+// NodeAttestation is the Schema for the nodeattestations API.
+type NodeAttestation struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   NodeAttestationSpec   `json:"spec,omitempty"`
+	Status NodeAttestationStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// NodeAttestationList contains a list of NodeAttestations.
+type NodeAttestationList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []NodeAttestation `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&NodeAttestation{}, &NodeAttestationList{})
+}
@@ -21,6 +21,95 @@ type NodeVersionSpec struct {
 	KubernetesComponentsReference string `json:"kubernetesComponentsReference,omitempty"`
 	// KubernetesClusterVersion is the advertised Kubernetes version of the cluster.
 	KubernetesClusterVersion string `json:"kubernetesClusterVersion,omitempty"`
+	// Paused stops the operator from starting new node replacements.
+	// Node replacements that are already in progress are completed.
+	Paused bool `json:"paused,omitempty"`
+	// MaintenanceWindows are the time windows in which the operator may start new node replacements.
+	// If empty, node replacements may start at any time.
+	MaintenanceWindows []MaintenanceWindow `json:"maintenanceWindows,omitempty"`
+	// ControlPlaneRolloutStrategy limits concurrent replacements of control-plane nodes per scaling group.
+	ControlPlaneRolloutStrategy *RolloutStrategy `json:"controlPlaneRolloutStrategy,omitempty"`
+	// WorkerRolloutStrategy limits concurrent replacements of worker nodes per scaling group.
+	WorkerRolloutStrategy *RolloutStrategy `json:"workerRolloutStrategy,omitempty"`
+	// Canary configures staged rollouts of node image upgrades.
+	// If set, a new image is first rolled out to a subset of the nodes and only rolled out to all nodes once the health gates pass.
+	Canary *CanaryRollout `json:"canary,omitempty"`
+}
+
+// MaintenanceWindow is a recurring time window in which node replacements may start.
+type MaintenanceWindow struct {
+	// Days are the days of the week on which the maintenance window starts.
+	// If empty, the maintenance window starts every day.
+	Days []Weekday `json:"days,omitempty"`
+	// Start is the start time of the maintenance window in UTC, formatted as HH:MM.
+	// +kubebuilder:validation:Pattern=`^([01][0-9]|2[0-3]):[0-5][0-9]$`
+	Start string `json:"start"`
+	// Duration is the length of the maintenance window, e.g. "4h".
+	Duration metav1.Duration `json:"duration"`
+}
+
+// Weekday is a day of the week.
+// +kubebuilder:validation:Enum=Monday;Tuesday;Wednesday;Thursday;Friday;Saturday;Sunday
+type Weekday string
+
+// CanaryRollout configures a staged rollout of node image upgrades.
+type CanaryRollout struct {
+	// ScalingGroups are the names of the scaling groups that are upgraded in the canary stage.
+	// Control-plane scaling groups are always part of the canary stage, since they are upgraded before any worker.
+	// +optional
+	ScalingGroups []string `json:"scalingGroups,omitempty"`
+	// Percentage is the share of the cluster's nodes that are upgraded in the canary stage.
+	// It is only used if ScalingGroups is empty.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	Percentage int32 `json:"percentage,omitempty"`
+	// VerificationDuration is the time the health gates are checked after the canary stage before the rollout proceeds.
+	// +optional
+	VerificationDuration metav1.Duration `json:"verificationDuration,omitempty"`
+	// FailureThreshold is the number of consecutive failed health checks after which the rollout is halted and rolled back.
+	// Defaults to 3.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	FailureThreshold int32 `json:"failureThreshold,omitempty"`
+	// HealthGates are checked in addition to the readiness of the upgraded nodes.
+	// +optional
+	HealthGates CanaryHealthGates `json:"healthGates,omitempty"`
+}
+
+// CanaryHealthGates are the checks that must pass before a node image is rolled out to all nodes.
+type CanaryHealthGates struct {
+	// Attestation requires the upgraded nodes to pass the continuous attestation of the node attestation monitor.
+	// +optional
+	Attestation bool `json:"attestation,omitempty"`
+	// Webhook is a URL that the operator sends a POST request with the upgraded nodes to.
+	// The check passes if the webhook responds with a 2xx status code.
+	// +optional
+	Webhook string `json:"webhook,omitempty"`
+	// Prometheus is a Prometheus query that must report the cluster as healthy.
+	// +optional
+	Prometheus *PrometheusHealthGate `json:"prometheus,omitempty"`
+}
+
+// PrometheusHealthGate is a Prometheus query used as health gate.
+type PrometheusHealthGate struct {
+	// URL is the address of the Prometheus server, e.g. http://prometheus.monitoring:9090.
+	URL string `json:"url"`
+	// Query is an instant query in PromQL.
+	// The check passes if the query returns at least one sample and all samples are non-zero.
+	Query string `json:"query"`
+}
+
+// RolloutStrategy limits the number of nodes that are replaced concurrently.
+type RolloutStrategy struct {
+	// MaxSurge is the maximum number of replacement nodes that are created concurrently.
+	// If unset, the scaling group shares a cluster-wide limit of one replacement node with all other scaling groups without MaxSurge.
+	// +kubebuilder:validation:Minimum=1
+	MaxSurge *int32 `json:"maxSurge,omitempty"`
+	// MaxUnavailable is the maximum number of outdated nodes that are drained concurrently.
+	// If unset, only MaxSurge limits the number of drained nodes.
+	// +kubebuilder:validation:Minimum=1
+	MaxUnavailable *int32 `json:"maxUnavailable,omitempty"`
 }
 
 // NodeVersionStatus defines the observed state of NodeVersion.
@@ -49,6 +138,63 @@ type NodeVersionStatus struct {
 	Conditions []metav1.Condition `json:"conditions"`
 	// ActiveClusterVersionUpgrade indicates whether the cluster is currently upgrading.
 	ActiveClusterVersionUpgrade bool `json:"activeclusterversionupgrade"`
+	// RolledOutImageReference is the image that all nodes used after the last completed rollout.
+	// +optional
+	RolledOutImageReference string `json:"rolledOutImageReference,omitempty"`
+	// RolledOutImageVersion is the CSP independent version of RolledOutImageReference.
+	// +optional
+	RolledOutImageVersion string `json:"rolledOutImageVersion,omitempty"`
+	// Canary is the status of the staged rollout of the latest node image.
+	// +optional
+	Canary *CanaryStatus `json:"canary,omitempty"`
+}
+
+const (
+	// CanaryPhaseProgressing is the phase of a canary rollout while the canary nodes are replaced.
+	CanaryPhaseProgressing CanaryPhase = "Progressing"
+	// CanaryPhaseVerifying is the phase of a canary rollout while the health gates are checked.
+	CanaryPhaseVerifying CanaryPhase = "Verifying"
+	// CanaryPhaseSucceeded is the phase of a canary rollout whose health gates passed.
+	CanaryPhaseSucceeded CanaryPhase = "Succeeded"
+	// CanaryPhaseFailed is the phase of a canary rollout whose health gates failed.
+	CanaryPhaseFailed CanaryPhase = "Failed"
+)
+
+// CanaryPhase is the phase of a canary rollout.
+// +kubebuilder:validation:Enum=Progressing;Verifying;Succeeded;Failed
+type CanaryPhase string
+
+// CanaryStatus is the status of a canary rollout.
+type CanaryStatus struct {
+	// ImageReference is the image that is rolled out.
+	ImageReference string `json:"imageReference"`
+	// ImageVersion is the CSP independent version of the image that is rolled out.
+	// +optional
+	ImageVersion string `json:"imageVersion,omitempty"`
+	// PreviousImageReference is the image that is restored if the rollout fails.
+	// +optional
+	PreviousImageReference string `json:"previousImageReference,omitempty"`
+	// PreviousImageVersion is the CSP independent version of PreviousImageReference.
+	// +optional
+	PreviousImageVersion string `json:"previousImageVersion,omitempty"`
+	// Phase is the phase of the rollout.
+	Phase CanaryPhase `json:"phase"`
+	// VerificationStartTime is the time the health gates started being checked.
+	// +optional
+	VerificationStartTime *metav1.Time `json:"verificationStartTime,omitempty"`
+	// LastProbeTime is the time the health gates were last checked.
+	// +optional
+	LastProbeTime *metav1.Time `json:"lastProbeTime,omitempty"`
+	// ConsecutiveFailures is the number of health checks that failed since the last passing one.
+	// +optional
+	ConsecutiveFailures int32 `json:"consecutiveFailures,omitempty"`
+	// Message explains the phase.
+	// +optional
+	Message string `json:"message,omitempty"`
+	// ObservedGeneration is the generation of the NodeVersion when the rollout failed.
+	// Changing the NodeVersion afterwards restarts a failed rollout of the same image.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
 }
 
 //+kubebuilder:object:root=true
@@ -15,6 +15,8 @@ import (
 const (
 	// ConditionOutdated is used to signal outdated scaling groups.
 	ConditionOutdated = "Outdated"
+	// ConditionRolloutBlocked is used to signal that no new node replacements are started.
+	ConditionRolloutBlocked = "RolloutBlocked"
 
 	// UnknownRole is used to signal unknown scaling group roles.
 	UnknownRole NodeRole = ""
@@ -42,6 +44,9 @@ type ScalingGroupSpec struct {
 	Max int32 `json:"max,omitempty"`
 	// Role is the role of the nodes in the scaling group.
 	Role NodeRole `json:"role,omitempty"`
+	// RolloutStrategy limits concurrent node replacements in the scaling group.
+	// It takes precedence over the rollout strategy of the scaling group's role in the NodeVersion.
+	RolloutStrategy *RolloutStrategy `json:"rolloutStrategy,omitempty"`
 }
 
 // NodeRole is the role of a node.
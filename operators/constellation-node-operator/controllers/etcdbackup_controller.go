@@ -0,0 +1,229 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/edgelesssys/constellation/v2/internal/etcdbackup"
+	updatev1alpha1 "github.com/edgelesssys/constellation/v2/operators/constellation-node-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/utils/clock"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// etcdBackupSecretNamespace is the namespace of the destination secrets of EtcdBackups.
+	etcdBackupSecretNamespace = "kube-system"
+	// etcdBackupMinInterval is the shortest interval between two snapshots.
+	etcdBackupMinInterval = time.Minute
+	// etcdBackupRetryInterval is the time after which a failed snapshot is retried.
+	etcdBackupRetryInterval = 5 * time.Minute
+
+	conditionSnapshotSucceededReason = "SnapshotUploaded"
+	conditionSnapshotFailedReason    = "SnapshotFailed"
+)
+
+// EtcdBackupReconciler reconciles a EtcdBackup object.
+type EtcdBackupReconciler struct {
+	etcdSnapshotter
+	keyDeriver
+	newStorage func(ctx context.Context, storageURI string) (etcdbackup.Storage, error)
+	clock      clock.Clock
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+// NewEtcdBackupReconciler creates a new EtcdBackupReconciler.
+func NewEtcdBackupReconciler(snapshotter etcdSnapshotter, keyDeriver keyDeriver, client client.Client, scheme *runtime.Scheme) *EtcdBackupReconciler {
+	return &EtcdBackupReconciler{
+		etcdSnapshotter: snapshotter,
+		keyDeriver:      keyDeriver,
+		newStorage:      etcdbackup.NewStorage,
+		clock:           clock.RealClock{},
+		Client:          client,
+		Scheme:          scheme,
+	}
+}
+
+//+kubebuilder:rbac:groups=update.edgeless.systems,resources=etcdbackups,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=update.edgeless.systems,resources=etcdbackups/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=update.edgeless.systems,resources=etcdbackups/finalizers,verbs=update
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get
+
+// Reconcile takes an etcd snapshot whenever the interval of the EtcdBackup elapsed.
+// Snapshots are encrypted with a key derived by the key service and uploaded to the destination.
+// Afterwards, snapshots exceeding the retention limits are deleted.
+func (r *EtcdBackupReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logr := log.FromContext(ctx)
+	logr.Info("Reconciling EtcdBackup", "etcdBackup", req.NamespacedName)
+
+	var etcdBackup updatev1alpha1.EtcdBackup
+	if err := r.Get(ctx, req.NamespacedName, &etcdBackup); err != nil {
+		if !k8serrors.IsNotFound(err) {
+			logr.Error(err, "Unable to fetch EtcdBackup")
+		}
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if etcdBackup.Spec.Suspend {
+		logr.Info("EtcdBackup is suspended")
+		return ctrl.Result{}, nil
+	}
+
+	now := r.clock.Now()
+	if untilNextSnapshot := nextSnapshotTime(etcdBackup.Spec, etcdBackup.Status).Sub(now); untilNextSnapshot > 0 {
+		return ctrl.Result{RequeueAfter: untilNextSnapshot}, nil
+	}
+
+	status := *etcdBackup.Status.DeepCopy()
+	status.LastScheduleTime = &metav1.Time{Time: now}
+	condition := metav1.Condition{
+		Type:   updatev1alpha1.ConditionSnapshotFailed,
+		Status: metav1.ConditionFalse,
+		Reason: conditionSnapshotSucceededReason,
+	}
+	name, snapshots, err := r.backup(ctx, etcdBackup.Spec, now)
+	if err != nil {
+		logr.Error(err, "Taking etcd snapshot failed")
+		status.ConsecutiveFailures++
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = conditionSnapshotFailedReason
+		condition.Message = err.Error()
+	} else {
+		logr.Info("Uploaded etcd snapshot", "snapshot", name)
+		status.LastSuccessfulTime = &metav1.Time{Time: now}
+		status.LastSnapshot = name
+		status.Snapshots = int32(snapshots)
+		status.ConsecutiveFailures = 0
+		condition.Message = fmt.Sprintf("Uploaded snapshot %s", name)
+	}
+	meta.SetStatusCondition(&status.Conditions, condition)
+	if err := r.tryUpdateStatus(ctx, req.NamespacedName, status); err != nil {
+		logr.Error(err, "Unable to update EtcdBackup status")
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{RequeueAfter: nextSnapshotTime(etcdBackup.Spec, status).Sub(now)}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *EtcdBackupReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&updatev1alpha1.EtcdBackup{}).
+		Complete(r)
+}
+
+// backup takes an etcd snapshot, uploads it to the destination and applies the retention limits.
+// It returns the name of the new snapshot and the number of snapshots kept in the destination.
+// Failing to delete expired snapshots does not fail the backup, they are deleted by the next one.
+func (r *EtcdBackupReconciler) backup(ctx context.Context, spec updatev1alpha1.EtcdBackupSpec, now time.Time) (string, int, error) {
+	logr := log.FromContext(ctx)
+
+	storageURI, err := r.storageURI(ctx, spec.Destination.SecretName)
+	if err != nil {
+		return "", 0, err
+	}
+	store, err := r.newStorage(ctx, storageURI)
+	if err != nil {
+		return "", 0, fmt.Errorf("creating snapshot storage: %w", err)
+	}
+	key, err := r.DeriveKey(ctx, etcdbackup.KeyName, etcdbackup.KeyLength)
+	if err != nil {
+		return "", 0, fmt.Errorf("deriving snapshot encryption key: %w", err)
+	}
+	// The snapshot is streamed from etcd through the encryption to the destination,
+	// so the memory usage of the operator doesn't grow with the size of the etcd database.
+	snapshot, err := r.Snapshot(ctx)
+	if err != nil {
+		return "", 0, fmt.Errorf("taking etcd snapshot: %w", err)
+	}
+	defer snapshot.Close()
+	encrypted, err := etcdbackup.NewEncrypter(key, snapshot)
+	if err != nil {
+		return "", 0, fmt.Errorf("encrypting etcd snapshot: %w", err)
+	}
+	name := etcdbackup.SnapshotName(spec.Destination.Prefix, now)
+	if err := store.Put(ctx, name, encrypted); err != nil {
+		return "", 0, fmt.Errorf("uploading etcd snapshot: %w", err)
+	}
+
+	snapshots, err := etcdbackup.ListSnapshots(ctx, store, spec.Destination.Prefix)
+	if err != nil {
+		logr.Error(err, "Unable to list snapshots for retention")
+		return name, 0, nil
+	}
+	kept := len(snapshots)
+	for _, expired := range etcdbackup.ExpiredSnapshots(snapshots, now, int(spec.Retention.MaxCount), spec.Retention.MaxAge.Duration) {
+		if err := store.Delete(ctx, expired); err != nil {
+			logr.Error(err, "Unable to delete expired snapshot", "snapshot", expired)
+			continue
+		}
+		logr.Info("Deleted expired snapshot", "snapshot", expired)
+		kept--
+	}
+	return name, kept, nil
+}
+
+// storageURI returns the storage URI from the destination secret.
+func (r *EtcdBackupReconciler) storageURI(ctx context.Context, secretName string) (string, error) {
+	var secret corev1.Secret
+	if err := r.Get(ctx, types.NamespacedName{Namespace: etcdBackupSecretNamespace, Name: secretName}, &secret); err != nil {
+		return "", fmt.Errorf("getting destination secret: %w", err)
+	}
+	storageURI, ok := secret.Data[updatev1alpha1.EtcdBackupStorageURIKey]
+	if !ok || len(storageURI) == 0 {
+		return "", errors.New("destination secret has no storage URI")
+	}
+	return string(storageURI), nil
+}
+
+// tryUpdateStatus attempts to update the EtcdBackup status field in a retry loop.
+func (r *EtcdBackupReconciler) tryUpdateStatus(ctx context.Context, name types.NamespacedName, status updatev1alpha1.EtcdBackupStatus) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		var etcdBackup updatev1alpha1.EtcdBackup
+		if err := r.Get(ctx, name, &etcdBackup); err != nil {
+			return err
+		}
+		etcdBackup.Status = *status.DeepCopy()
+		return r.Status().Update(ctx, &etcdBackup)
+	})
+}
+
+// nextSnapshotTime returns the time the next snapshot is due.
+// Failed snapshots are retried before the interval elapses.
+func nextSnapshotTime(spec updatev1alpha1.EtcdBackupSpec, status updatev1alpha1.EtcdBackupStatus) time.Time {
+	if status.LastScheduleTime == nil {
+		return time.Time{}
+	}
+	interval := max(spec.Interval.Duration, etcdBackupMinInterval)
+	if status.ConsecutiveFailures > 0 {
+		interval = min(interval, etcdBackupRetryInterval)
+	}
+	return status.LastScheduleTime.Add(interval)
+}
+
+type etcdSnapshotter interface {
+	// Snapshot takes a snapshot of the etcd keyspace and returns a reader for it.
+	Snapshot(ctx context.Context) (io.ReadCloser, error)
+}
+
+type keyDeriver interface {
+	// DeriveKey derives a workload key from the key service.
+	DeriveKey(ctx context.Context, name string, length int) ([]byte, error)
+}
@@ -0,0 +1,394 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/edgelesssys/constellation/v2/internal/etcdbackup"
+	"github.com/edgelesssys/constellation/v2/internal/etcdbackup/storage/localfs"
+	updatev1alpha1 "github.com/edgelesssys/constellation/v2/operators/constellation-node-operator/api/v1alpha1"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	testclock "k8s.io/utils/clock/testing"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func TestEtcdBackupReconcile(t *testing.T) {
+	now := time.Date(2024, 3, 5, 12, 0, 0, 0, time.UTC)
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system", Name: "etcd-backup"},
+		Data:       map[string][]byte{updatev1alpha1.EtcdBackupStorageURIKey: []byte("storage://local?path=/backups")},
+	}
+	etcdBackup := func(mutate func(*updatev1alpha1.EtcdBackup)) *updatev1alpha1.EtcdBackup {
+		etcdBackup := &updatev1alpha1.EtcdBackup{
+			ObjectMeta: metav1.ObjectMeta{Name: "backup"},
+			Spec: updatev1alpha1.EtcdBackupSpec{
+				Interval:    metav1.Duration{Duration: time.Hour},
+				Destination: updatev1alpha1.EtcdBackupDestination{SecretName: "etcd-backup"},
+			},
+		}
+		if mutate != nil {
+			mutate(etcdBackup)
+		}
+		return etcdBackup
+	}
+
+	testCases := map[string]struct {
+		etcdBackup      *updatev1alpha1.EtcdBackup
+		snapshotErr     error
+		updateStatusErr error
+		wantSnapshot    bool
+		wantResult      ctrl.Result
+		wantErr         bool
+	}{
+		"first snapshot is taken immediately": {
+			etcdBackup:   etcdBackup(nil),
+			wantSnapshot: true,
+			wantResult:   ctrl.Result{RequeueAfter: time.Hour},
+		},
+		"snapshot is due": {
+			etcdBackup: etcdBackup(func(b *updatev1alpha1.EtcdBackup) {
+				b.Status.LastScheduleTime = &metav1.Time{Time: now.Add(-time.Hour)}
+			}),
+			wantSnapshot: true,
+			wantResult:   ctrl.Result{RequeueAfter: time.Hour},
+		},
+		"snapshot is not due": {
+			etcdBackup: etcdBackup(func(b *updatev1alpha1.EtcdBackup) {
+				b.Status.LastScheduleTime = &metav1.Time{Time: now.Add(-20 * time.Minute)}
+			}),
+			wantResult: ctrl.Result{RequeueAfter: 40 * time.Minute},
+		},
+		"failed snapshot is retried": {
+			etcdBackup: etcdBackup(func(b *updatev1alpha1.EtcdBackup) {
+				b.Status.LastScheduleTime = &metav1.Time{Time: now.Add(-etcdBackupRetryInterval)}
+				b.Status.ConsecutiveFailures = 1
+			}),
+			wantSnapshot: true,
+			wantResult:   ctrl.Result{RequeueAfter: time.Hour},
+		},
+		"suspended": {
+			etcdBackup: etcdBackup(func(b *updatev1alpha1.EtcdBackup) {
+				b.Spec.Suspend = true
+			}),
+		},
+		"failing snapshot is retried early": {
+			etcdBackup:   etcdBackup(nil),
+			snapshotErr:  errors.New("snapshot failed"),
+			wantSnapshot: true,
+			wantResult:   ctrl.Result{RequeueAfter: etcdBackupRetryInterval},
+		},
+		"updating status fails": {
+			etcdBackup:      etcdBackup(nil),
+			updateStatusErr: errors.New("update failed"),
+			wantSnapshot:    true,
+			wantErr:         true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			snapshotter := &stubEtcdSnapshotter{snapshot: []byte("snapshot"), snapshotErr: tc.snapshotErr}
+			store := &stubSnapshotStorage{Storage: localfs.New(afero.NewMemMapFs(), "/backups")}
+			reconciler := &EtcdBackupReconciler{
+				etcdSnapshotter: snapshotter,
+				keyDeriver:      &stubKeyDeriver{key: bytes.Repeat([]byte{0x1}, etcdbackup.KeyLength)},
+				newStorage: func(context.Context, string) (etcdbackup.Storage, error) {
+					return store, nil
+				},
+				clock: testclock.NewFakeClock(now),
+				Client: &stubReadWriterClient{
+					stubReaderClient: *newStubReaderClient(t, []runtime.Object{tc.etcdBackup, secret}, nil, nil),
+					stubWriterClient: stubWriterClient{statusWriter: stubStatusWriter{updateErr: tc.updateStatusErr}},
+				},
+			}
+
+			result, err := reconciler.Reconcile(t.Context(), ctrl.Request{NamespacedName: types.NamespacedName{Name: "backup"}})
+			assert.Equal(tc.wantSnapshot, snapshotter.called)
+			if tc.wantErr {
+				assert.Error(err)
+				return
+			}
+			require.NoError(err)
+			assert.Equal(tc.wantResult, result)
+		})
+	}
+}
+
+func TestEtcdBackup(t *testing.T) {
+	now := time.Date(2024, 3, 5, 12, 0, 0, 0, time.UTC)
+	key := bytes.Repeat([]byte{0x1}, etcdbackup.KeyLength)
+	secret := func(storageURI string) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system", Name: "etcd-backup"},
+			Data:       map[string][]byte{updatev1alpha1.EtcdBackupStorageURIKey: []byte(storageURI)},
+		}
+	}
+	spec := updatev1alpha1.EtcdBackupSpec{
+		Destination: updatev1alpha1.EtcdBackupDestination{SecretName: "etcd-backup", Prefix: "cluster"},
+		Retention:   updatev1alpha1.EtcdBackupRetention{MaxCount: 2},
+	}
+
+	testCases := map[string]struct {
+		secret          *corev1.Secret
+		existing        []string
+		newStorageErr   error
+		deriveKeyErr    error
+		snapshotErr     error
+		snapshotReadErr error
+		putErr          error
+		deleteErr       error
+		wantSnapshots   int
+		wantRemaining   []string
+		wantErr         bool
+	}{
+		"first snapshot": {
+			secret:        secret("storage://local?path=/backups"),
+			wantSnapshots: 1,
+			wantRemaining: []string{etcdbackup.SnapshotName("cluster", now)},
+		},
+		"retention is applied": {
+			secret: secret("storage://local?path=/backups"),
+			existing: []string{
+				etcdbackup.SnapshotName("cluster", now.Add(-2*time.Hour)),
+				etcdbackup.SnapshotName("cluster", now.Add(-time.Hour)),
+				etcdbackup.SnapshotName("other", now.Add(-2*time.Hour)),
+			},
+			wantSnapshots: 2,
+			wantRemaining: []string{
+				etcdbackup.SnapshotName("cluster", now.Add(-time.Hour)),
+				etcdbackup.SnapshotName("cluster", now),
+				etcdbackup.SnapshotName("other", now.Add(-2*time.Hour)),
+			},
+		},
+		"deleting expired snapshots fails": {
+			secret: secret("storage://local?path=/backups"),
+			existing: []string{
+				etcdbackup.SnapshotName("cluster", now.Add(-2*time.Hour)),
+				etcdbackup.SnapshotName("cluster", now.Add(-time.Hour)),
+			},
+			deleteErr:     errors.New("delete failed"),
+			wantSnapshots: 3,
+			wantRemaining: []string{
+				etcdbackup.SnapshotName("cluster", now.Add(-2*time.Hour)),
+				etcdbackup.SnapshotName("cluster", now.Add(-time.Hour)),
+				etcdbackup.SnapshotName("cluster", now),
+			},
+		},
+		"secret missing": {
+			wantErr: true,
+		},
+		"secret without storage URI": {
+			secret:  secret(""),
+			wantErr: true,
+		},
+		"creating storage fails": {
+			secret:        secret("storage://local?path=/backups"),
+			newStorageErr: errors.New("invalid storage"),
+			wantErr:       true,
+		},
+		"deriving key fails": {
+			secret:       secret("storage://local?path=/backups"),
+			deriveKeyErr: errors.New("key service unavailable"),
+			wantErr:      true,
+		},
+		"taking snapshot fails": {
+			secret:      secret("storage://local?path=/backups"),
+			snapshotErr: errors.New("snapshot failed"),
+			wantErr:     true,
+		},
+		"receiving snapshot fails": {
+			secret:          secret("storage://local?path=/backups"),
+			snapshotReadErr: errors.New("read failed"),
+			wantErr:         true,
+		},
+		"upload fails": {
+			secret:  secret("storage://local?path=/backups"),
+			putErr:  errors.New("upload failed"),
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			store := &stubSnapshotStorage{
+				Storage:   localfs.New(afero.NewMemMapFs(), "/backups"),
+				putErr:    tc.putErr,
+				deleteErr: tc.deleteErr,
+			}
+			for _, name := range tc.existing {
+				require.NoError(store.Storage.Put(t.Context(), name, strings.NewReader("")))
+			}
+			var objects []runtime.Object
+			if tc.secret != nil {
+				objects = append(objects, tc.secret)
+			}
+			snapshotter := &stubEtcdSnapshotter{snapshot: []byte("snapshot"), snapshotErr: tc.snapshotErr, snapshotReadErr: tc.snapshotReadErr}
+			var storageURI string
+			reconciler := &EtcdBackupReconciler{
+				etcdSnapshotter: snapshotter,
+				keyDeriver:      &stubKeyDeriver{key: key, deriveErr: tc.deriveKeyErr},
+				newStorage: func(_ context.Context, uri string) (etcdbackup.Storage, error) {
+					storageURI = uri
+					return store, tc.newStorageErr
+				},
+				Client: newStubReaderClient(t, objects, nil, nil),
+			}
+
+			name, snapshots, err := reconciler.backup(t.Context(), spec, now)
+			assert.Equal(snapshotter.opened, snapshotter.closed)
+			remaining, listErr := store.List(t.Context(), "")
+			require.NoError(listErr)
+			if tc.wantErr {
+				assert.Error(err)
+				assert.Empty(remaining)
+				return
+			}
+			require.NoError(err)
+			assert.Equal("storage://local?path=/backups", storageURI)
+			assert.Equal(etcdbackup.SnapshotName("cluster", now), name)
+			assert.Equal(tc.wantSnapshots, snapshots)
+			assert.Equal(tc.wantRemaining, remaining)
+
+			encrypted, err := store.Get(t.Context(), name)
+			require.NoError(err)
+			defer encrypted.Close()
+			decrypter, err := etcdbackup.NewDecrypter(key, encrypted)
+			require.NoError(err)
+			snapshot, err := io.ReadAll(decrypter)
+			require.NoError(err)
+			assert.Equal([]byte("snapshot"), snapshot)
+		})
+	}
+}
+
+func TestNextSnapshotTime(t *testing.T) {
+	last := time.Date(2024, 3, 5, 12, 0, 0, 0, time.UTC)
+
+	testCases := map[string]struct {
+		interval            time.Duration
+		lastScheduleTime    *metav1.Time
+		consecutiveFailures int32
+		want                time.Time
+	}{
+		"never scheduled": {
+			interval: time.Hour,
+		},
+		"interval": {
+			interval:         time.Hour,
+			lastScheduleTime: &metav1.Time{Time: last},
+			want:             last.Add(time.Hour),
+		},
+		"interval below minimum": {
+			interval:         time.Second,
+			lastScheduleTime: &metav1.Time{Time: last},
+			want:             last.Add(etcdBackupMinInterval),
+		},
+		"failed snapshot is retried": {
+			interval:            time.Hour,
+			lastScheduleTime:    &metav1.Time{Time: last},
+			consecutiveFailures: 2,
+			want:                last.Add(etcdBackupRetryInterval),
+		},
+		"retry does not exceed interval": {
+			interval:            2 * time.Minute,
+			lastScheduleTime:    &metav1.Time{Time: last},
+			consecutiveFailures: 1,
+			want:                last.Add(2 * time.Minute),
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			spec := updatev1alpha1.EtcdBackupSpec{Interval: metav1.Duration{Duration: tc.interval}}
+			status := updatev1alpha1.EtcdBackupStatus{LastScheduleTime: tc.lastScheduleTime, ConsecutiveFailures: tc.consecutiveFailures}
+			assert.Equal(tc.want, nextSnapshotTime(spec, status))
+		})
+	}
+}
+
+type stubEtcdSnapshotter struct {
+	snapshot        []byte
+	snapshotErr     error
+	snapshotReadErr error
+	called          bool
+	opened          bool
+	closed          bool
+}
+
+func (s *stubEtcdSnapshotter) Snapshot(_ context.Context) (io.ReadCloser, error) {
+	s.called = true
+	if s.snapshotErr != nil {
+		return nil, s.snapshotErr
+	}
+	s.opened = true
+	var reader io.Reader = bytes.NewReader(s.snapshot)
+	if s.snapshotReadErr != nil {
+		reader = io.MultiReader(reader, iotest.ErrReader(s.snapshotReadErr))
+	}
+	return &stubSnapshotReader{Reader: reader, closed: &s.closed}, nil
+}
+
+type stubSnapshotReader struct {
+	io.Reader
+	closed *bool
+}
+
+func (r *stubSnapshotReader) Close() error {
+	*r.closed = true
+	return nil
+}
+
+type stubKeyDeriver struct {
+	key       []byte
+	deriveErr error
+}
+
+func (s *stubKeyDeriver) DeriveKey(_ context.Context, _ string, _ int) ([]byte, error) {
+	return s.key, s.deriveErr
+}
+
+type stubSnapshotStorage struct {
+	etcdbackup.Storage
+	putErr    error
+	deleteErr error
+}
+
+func (s *stubSnapshotStorage) Put(ctx context.Context, name string, data io.Reader) error {
+	if s.putErr != nil {
+		return s.putErr
+	}
+	return s.Storage.Put(ctx, name, data)
+}
+
+func (s *stubSnapshotStorage) Delete(ctx context.Context, name string) error {
+	if s.deleteErr != nil {
+		return s.deleteErr
+	}
+	return s.Storage.Delete(ctx, name)
+}
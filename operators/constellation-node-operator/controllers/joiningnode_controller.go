@@ -74,6 +74,9 @@ func (r *JoiningNodesReconciler) Reconcile(ctx context.Context, req ctrl.Request
 			node.Annotations = map[string]string{}
 		}
 		node.Annotations[mainconstants.NodeKubernetesComponentsAnnotationKey] = joiningNode.Spec.ComponentsReference
+		if joiningNode.Spec.DiskUUID != "" {
+			node.Annotations[mainconstants.NodeDiskUUIDAnnotationKey] = joiningNode.Spec.DiskUUID
+		}
 		return r.Update(ctx, &node)
 	})
 	if err != nil {
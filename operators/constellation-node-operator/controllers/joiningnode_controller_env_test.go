@@ -34,6 +34,7 @@ var _ = Describe("JoiningNode controller", func() {
 		ComponentsReference1 = "test-ref-1"
 		ComponentsReference2 = "test-ref-2"
 		ComponentsReference3 = "test-ref-3"
+		diskUUID1            = "8a0d4d4e-8c3b-4b5a-9c1e-0d2f4b6a8c0e"
 
 		timeout  = time.Second * 20
 		duration = time.Second * 2
@@ -54,6 +55,7 @@ var _ = Describe("JoiningNode controller", func() {
 				Spec: updatev1alpha1.JoiningNodeSpec{
 					Name:                nodeName1,
 					ComponentsReference: ComponentsReference1,
+					DiskUUID:            diskUUID1,
 				},
 			}
 			Expect(k8sClient.Create(ctx, joiningNode)).Should(Succeed())
@@ -87,6 +89,7 @@ var _ = Describe("JoiningNode controller", func() {
 				_ = k8sClient.Get(ctx, types.NamespacedName{Name: nodeName1}, createdNode)
 				return createdNode.Annotations[mainconstants.NodeKubernetesComponentsAnnotationKey]
 			}, timeout, interval).Should(Equal(ComponentsReference1))
+			Expect(createdNode.Annotations[mainconstants.NodeDiskUUIDAnnotationKey]).Should(Equal(diskUUID1))
 
 			By("deleting the joining node resource")
 			Eventually(func() error {
@@ -0,0 +1,309 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	mainconstants "github.com/edgelesssys/constellation/v2/internal/constants"
+	updatev1alpha1 "github.com/edgelesssys/constellation/v2/operators/constellation-node-operator/api/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// keyRotationLabel is the label of key rotation jobs referencing the KeyRotation they belong to.
+	keyRotationLabel = "update.edgeless.systems/key-rotation"
+	// keyRotationNamespace is the namespace key rotation jobs are created in.
+	keyRotationNamespace = "kube-system"
+	// keyServiceDaemonSet is the name of the key service DaemonSet in the key rotation namespace.
+	keyServiceDaemonSet = "key-service"
+)
+
+// KeyRotationReconciler reconciles a KeyRotation object.
+type KeyRotationReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+// NewKeyRotationReconciler creates a new KeyRotationReconciler.
+func NewKeyRotationReconciler(client client.Client, scheme *runtime.Scheme) *KeyRotationReconciler {
+	return &KeyRotationReconciler{
+		Client: client,
+		Scheme: scheme,
+	}
+}
+
+//+kubebuilder:rbac:groups=update.edgeless.systems,resources=keyrotations,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=update.edgeless.systems,resources=keyrotations/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=update.edgeless.systems,resources=keyrotations/finalizers,verbs=update
+//+kubebuilder:rbac:groups="",resources=nodes,verbs=get;list;watch
+//+kubebuilder:rbac:groups=apps,resources=daemonsets,verbs=get;list;watch
+//+kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;delete
+
+// Reconcile re-keys the state disk of every node by running a key service job on the node.
+// Succeeded nodes are recorded in the status of the KeyRotation, so a partially rotated cluster resumes where it stopped.
+// Failed jobs are deleted and recreated on the next reconciliation.
+func (r *KeyRotationReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logr := log.FromContext(ctx)
+	logr.Info("Reconciling KeyRotation", "keyRotation", req.NamespacedName)
+
+	var keyRotation updatev1alpha1.KeyRotation
+	if err := r.Get(ctx, req.NamespacedName, &keyRotation); err != nil {
+		if !errors.IsNotFound(err) {
+			logr.Error(err, "Unable to fetch KeyRotation")
+		}
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if keyRotation.Status.Phase == updatev1alpha1.KeyRotationPhaseCompleted {
+		return ctrl.Result{}, nil
+	}
+
+	var nodeList corev1.NodeList
+	if err := r.List(ctx, &nodeList); err != nil {
+		logr.Error(err, "Unable to list nodes")
+		return ctrl.Result{}, err
+	}
+	var jobList batchv1.JobList
+	if err := r.List(ctx, &jobList, client.InNamespace(keyRotationNamespace), client.MatchingLabels{keyRotationLabel: keyRotation.Name}); err != nil {
+		logr.Error(err, "Unable to list key rotation jobs")
+		return ctrl.Result{}, err
+	}
+
+	status := *keyRotation.Status.DeepCopy()
+	groups := groupKeyRotationJobs(nodeList.Items, jobList.Items, status.RotatedNodes)
+
+	for _, job := range groups.succeeded {
+		logr.Info("Re-keyed state disk", "node", job.Spec.Template.Spec.NodeName)
+		status.RotatedNodes = append(status.RotatedNodes, job.Spec.Template.Spec.NodeName)
+	}
+	for _, job := range groups.failed {
+		nodeName := job.Spec.Template.Spec.NodeName
+		logr.Info("Re-keying state disk failed, retrying", "node", nodeName)
+		if status.FailedAttempts == nil {
+			status.FailedAttempts = map[string]int32{}
+		}
+		status.FailedAttempts[nodeName]++
+		groups.missing = append(groups.missing, nodeName)
+	}
+
+	var image string
+	if len(groups.missing) > 0 {
+		var err error
+		image, err = r.keyServiceImage(ctx)
+		if err != nil {
+			logr.Error(err, "Unable to get key service image")
+			return ctrl.Result{}, err
+		}
+	}
+	for _, nodeName := range groups.missing {
+		job := newKeyRotationJob(keyRotation.Name, nodeName, image)
+		if err := controllerutil.SetControllerReference(&keyRotation, job, r.Scheme); err != nil {
+			return ctrl.Result{}, err
+		}
+		if err := r.Create(ctx, job); err != nil {
+			logr.Error(err, "Unable to create key rotation job", "node", nodeName)
+			return ctrl.Result{}, err
+		}
+	}
+
+	status.PendingNodes = append(groups.running, groups.missing...)
+	slices.Sort(status.PendingNodes)
+	slices.Sort(status.RotatedNodes)
+	status.Phase = updatev1alpha1.KeyRotationPhaseInProgress
+	if len(status.PendingNodes) == 0 {
+		status.Phase = updatev1alpha1.KeyRotationPhaseCompleted
+	}
+	if err := r.tryUpdateStatus(ctx, req.NamespacedName, status); err != nil {
+		logr.Error(err, "Unable to update KeyRotation status")
+		return ctrl.Result{}, err
+	}
+
+	// jobs are only deleted once their result was persisted in the status
+	for _, job := range append(groups.succeeded, groups.failed...) {
+		if err := r.Delete(ctx, &job, client.PropagationPolicy(metav1.DeletePropagationBackground)); client.IgnoreNotFound(err) != nil {
+			logr.Error(err, "Unable to delete key rotation job", "job", job.Name)
+			return ctrl.Result{}, err
+		}
+	}
+
+	if status.Phase == updatev1alpha1.KeyRotationPhaseCompleted {
+		logr.Info("Key rotation completed", "keyRotation", req.Name)
+		return ctrl.Result{}, nil
+	}
+	return ctrl.Result{RequeueAfter: defaultCheckInterval}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *KeyRotationReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&updatev1alpha1.KeyRotation{}).
+		Owns(&batchv1.Job{}).
+		Watches(
+			client.Object(&corev1.Node{}),
+			handler.EnqueueRequestsFromMapFunc(r.findAllKeyRotations),
+		).
+		Complete(r)
+}
+
+// findAllKeyRotations requests a reconciliation of all KeyRotations, e.g. when nodes join or leave the cluster.
+func (r *KeyRotationReconciler) findAllKeyRotations(ctx context.Context, _ client.Object) []reconcile.Request {
+	var keyRotationList updatev1alpha1.KeyRotationList
+	if err := r.List(ctx, &keyRotationList); err != nil {
+		return []reconcile.Request{}
+	}
+	requests := make([]reconcile.Request, len(keyRotationList.Items))
+	for i, item := range keyRotationList.Items {
+		requests[i] = reconcile.Request{
+			NamespacedName: types.NamespacedName{Name: item.GetName()},
+		}
+	}
+	return requests
+}
+
+// keyServiceImage returns the image of the key service, which is used to run the key rotation jobs.
+func (r *KeyRotationReconciler) keyServiceImage(ctx context.Context) (string, error) {
+	var daemonSet appsv1.DaemonSet
+	if err := r.Get(ctx, types.NamespacedName{Namespace: keyRotationNamespace, Name: keyServiceDaemonSet}, &daemonSet); err != nil {
+		return "", err
+	}
+	if len(daemonSet.Spec.Template.Spec.Containers) == 0 {
+		return "", fmt.Errorf("daemonset %s has no containers", keyServiceDaemonSet)
+	}
+	return daemonSet.Spec.Template.Spec.Containers[0].Image, nil
+}
+
+// tryUpdateStatus attempts to update the KeyRotation status field in a retry loop.
+func (r *KeyRotationReconciler) tryUpdateStatus(ctx context.Context, name types.NamespacedName, status updatev1alpha1.KeyRotationStatus) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		var keyRotation updatev1alpha1.KeyRotation
+		if err := r.Get(ctx, name, &keyRotation); err != nil {
+			return err
+		}
+		keyRotation.Status = *status.DeepCopy()
+		return r.Status().Update(ctx, &keyRotation)
+	})
+}
+
+// keyRotationJobGroups groups the nodes of a key rotation by the state of their job.
+type keyRotationJobGroups struct {
+	// succeeded are jobs that re-keyed the state disk of their node.
+	succeeded []batchv1.Job
+	// failed are jobs that failed to re-key the state disk of their node.
+	failed []batchv1.Job
+	// running are the names of nodes with an active job.
+	running []string
+	// missing are the names of nodes that were not re-keyed yet and have no job.
+	missing []string
+}
+
+// groupKeyRotationJobs groups the nodes that were not rotated yet by the state of their key rotation job.
+// Jobs of nodes that left the cluster or were already rotated are ignored.
+func groupKeyRotationJobs(nodes []corev1.Node, jobs []batchv1.Job, rotatedNodes []string) keyRotationJobGroups {
+	jobsByNode := make(map[string]batchv1.Job, len(jobs))
+	for _, job := range jobs {
+		jobsByNode[job.Spec.Template.Spec.NodeName] = job
+	}
+
+	var groups keyRotationJobGroups
+	for _, node := range nodes {
+		if slices.Contains(rotatedNodes, node.Name) {
+			continue
+		}
+		job, ok := jobsByNode[node.Name]
+		switch {
+		case !ok:
+			groups.missing = append(groups.missing, node.Name)
+		case jobHasCondition(job, batchv1.JobComplete):
+			groups.succeeded = append(groups.succeeded, job)
+		case jobHasCondition(job, batchv1.JobFailed):
+			groups.failed = append(groups.failed, job)
+		default:
+			groups.running = append(groups.running, node.Name)
+		}
+	}
+	return groups
+}
+
+// jobHasCondition checks if the condition of the given type is true for the job.
+func jobHasCondition(job batchv1.Job, conditionType batchv1.JobConditionType) bool {
+	for _, condition := range job.Status.Conditions {
+		if condition.Type == conditionType && condition.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
+// newKeyRotationJob creates a job running the key service on the given node to re-key its state disk.
+func newKeyRotationJob(keyRotationName, nodeName, image string) *batchv1.Job {
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "key-rotation-",
+			Namespace:    keyRotationNamespace,
+			Labels:       map[string]string{keyRotationLabel: keyRotationName},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: ptr.To[int32](2),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{keyRotationLabel: keyRotationName},
+				},
+				Spec: corev1.PodSpec{
+					NodeName:          nodeName,
+					RestartPolicy:     corev1.RestartPolicyNever,
+					PriorityClassName: "system-node-critical",
+					SecurityContext:   &corev1.PodSecurityContext{RunAsUser: ptr.To[int64](0)},
+					Tolerations:       []corev1.Toleration{{Operator: corev1.TolerationOpExists}},
+					Containers: []corev1.Container{
+						{
+							Name:  "key-rotation",
+							Image: image,
+							Args:  []string{"--rotate-state-disk-key"},
+							VolumeMounts: []corev1.VolumeMount{
+								{Name: "config", MountPath: mainconstants.ServiceBasePath, ReadOnly: true},
+								{Name: "upgrade-agent-socket", MountPath: mainconstants.UpgradeAgentMountPath, ReadOnly: true},
+							},
+						},
+					},
+					Volumes: []corev1.Volume{
+						{
+							Name: "config",
+							VolumeSource: corev1.VolumeSource{
+								Secret: &corev1.SecretVolumeSource{SecretName: mainconstants.ConstellationMasterSecretStoreName},
+							},
+						},
+						{
+							Name: "upgrade-agent-socket",
+							VolumeSource: corev1.VolumeSource{
+								HostPath: &corev1.HostPathVolumeSource{
+									Path: mainconstants.UpgradeAgentSocketPath,
+									Type: ptr.To(corev1.HostPathSocket),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,96 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package controllers
+
+import (
+	"testing"
+
+	mainconstants "github.com/edgelesssys/constellation/v2/internal/constants"
+	"github.com/stretchr/testify/assert"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGroupKeyRotationJobs(t *testing.T) {
+	node := func(name string) corev1.Node {
+		return corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	}
+	job := func(nodeName string, conditions ...batchv1.JobConditionType) batchv1.Job {
+		job := batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{Name: "job-" + nodeName},
+			Spec: batchv1.JobSpec{
+				Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{NodeName: nodeName}},
+			},
+		}
+		for _, condition := range conditions {
+			job.Status.Conditions = append(job.Status.Conditions, batchv1.JobCondition{Type: condition, Status: corev1.ConditionTrue})
+		}
+		return job
+	}
+
+	testCases := map[string]struct {
+		nodes        []corev1.Node
+		jobs         []batchv1.Job
+		rotatedNodes []string
+		wantGroups   keyRotationJobGroups
+	}{
+		"no nodes": {},
+		"nodes without jobs": {
+			nodes:      []corev1.Node{node("a"), node("b")},
+			wantGroups: keyRotationJobGroups{missing: []string{"a", "b"}},
+		},
+		"rotated nodes are skipped": {
+			nodes:        []corev1.Node{node("a"), node("b")},
+			rotatedNodes: []string{"a"},
+			wantGroups:   keyRotationJobGroups{missing: []string{"b"}},
+		},
+		"jobs are grouped by state": {
+			nodes: []corev1.Node{node("a"), node("b"), node("c"), node("d")},
+			jobs: []batchv1.Job{
+				job("a", batchv1.JobComplete),
+				job("b", batchv1.JobFailed),
+				job("c"),
+			},
+			wantGroups: keyRotationJobGroups{
+				succeeded: []batchv1.Job{job("a", batchv1.JobComplete)},
+				failed:    []batchv1.Job{job("b", batchv1.JobFailed)},
+				running:   []string{"c"},
+				missing:   []string{"d"},
+			},
+		},
+		"jobs of removed nodes are ignored": {
+			nodes:      []corev1.Node{node("a")},
+			jobs:       []batchv1.Job{job("a"), job("gone", batchv1.JobFailed)},
+			wantGroups: keyRotationJobGroups{running: []string{"a"}},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			assert.Equal(tc.wantGroups, groupKeyRotationJobs(tc.nodes, tc.jobs, tc.rotatedNodes))
+		})
+	}
+}
+
+func TestNewKeyRotationJob(t *testing.T) {
+	assert := assert.New(t)
+
+	job := newKeyRotationJob("rotation", "node", "image")
+
+	assert.Equal(keyRotationNamespace, job.Namespace)
+	assert.Equal("rotation", job.Labels[keyRotationLabel])
+	podSpec := job.Spec.Template.Spec
+	assert.Equal("node", podSpec.NodeName)
+	assert.Equal(corev1.RestartPolicyNever, podSpec.RestartPolicy)
+	assert.Len(podSpec.Containers, 1)
+	assert.Equal("image", podSpec.Containers[0].Image)
+	assert.Equal([]string{"--rotate-state-disk-key"}, podSpec.Containers[0].Args)
+	assert.Equal(mainconstants.ConstellationMasterSecretStoreName, podSpec.Volumes[0].Secret.SecretName)
+	assert.Equal(mainconstants.UpgradeAgentSocketPath, podSpec.Volumes[1].HostPath.Path)
+}
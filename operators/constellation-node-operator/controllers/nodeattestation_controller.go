@@ -0,0 +1,449 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package controllers
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/edgelesssys/constellation/v2/internal/attestation/variant"
+	"github.com/edgelesssys/constellation/v2/internal/config"
+	mainconstants "github.com/edgelesssys/constellation/v2/internal/constants"
+	"github.com/edgelesssys/constellation/v2/internal/crypto"
+	"github.com/edgelesssys/constellation/v2/internal/verify"
+	updatev1alpha1 "github.com/edgelesssys/constellation/v2/operators/constellation-node-operator/api/v1alpha1"
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/utils/clock"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+const (
+	// attestationNodeCondition is the type of the node condition reflecting the node's attestation.
+	attestationNodeCondition corev1.NodeConditionType = "AttestationPassing"
+	// attestationCordonedAnnotation is set on nodes cordoned because their attestation failed.
+	// Only nodes with this annotation are uncordoned once their attestation passes again.
+	attestationCordonedAnnotation = "update.edgeless.systems/attestation-cordoned"
+	// verificationServiceLabel is the label selecting the pods of the verification service.
+	verificationServiceLabel = "k8s-app"
+	// verificationServiceName is the value of the verificationServiceLabel of the verification service pods.
+	verificationServiceName = "verification-service"
+)
+
+var (
+	nodeAttestationPassing = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "constellation_node_attestation_passing",
+		Help: "Whether the last attestation of the node passed (1) or not (0).",
+	}, []string{"node"})
+	nodeAttestationFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "constellation_node_attestation_failures_total",
+		Help: "Number of attestations of the node that didn't pass, by phase.",
+	}, []string{"node", "phase"})
+	nodeAttestationLastPassing = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "constellation_node_attestation_last_passing_timestamp_seconds",
+		Help: "Unix time of the last passing attestation of the node.",
+	}, []string{"node"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(nodeAttestationPassing, nodeAttestationFailures, nodeAttestationLastPassing)
+}
+
+// NodeAttestationReconciler periodically attests every node through its verification service.
+type NodeAttestationReconciler struct {
+	attester
+	client.Client
+	Scheme             *runtime.Scheme
+	attestationVariant variant.Variant
+	interval           time.Duration
+	cordonFailingNodes bool
+	clock              clock.Clock
+}
+
+// NewNodeAttestationReconciler creates a new NodeAttestationReconciler.
+func NewNodeAttestationReconciler(attester attester, attestationVariant variant.Variant, interval time.Duration,
+	cordonFailingNodes bool, client client.Client, scheme *runtime.Scheme,
+) *NodeAttestationReconciler {
+	return &NodeAttestationReconciler{
+		attester:           attester,
+		Client:             client,
+		Scheme:             scheme,
+		attestationVariant: attestationVariant,
+		interval:           interval,
+		cordonFailingNodes: cordonFailingNodes,
+		clock:              clock.RealClock{},
+	}
+}
+
+//+kubebuilder:rbac:groups=update.edgeless.systems,resources=nodeattestations,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=update.edgeless.systems,resources=nodeattestations/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=update.edgeless.systems,resources=nodeattestations/finalizers,verbs=update
+//+kubebuilder:rbac:groups="",resources=nodes,verbs=get;list;watch;patch
+//+kubebuilder:rbac:groups="",resources=nodes/status,verbs=get;patch
+//+kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch
+
+// Reconcile attests a node using the current join config and records the result
+// in the node's NodeAttestation, the node's conditions, and the operator's metrics.
+// If enabled, nodes whose attestation fails are cordoned.
+func (r *NodeAttestationReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logr := log.FromContext(ctx)
+
+	var node corev1.Node
+	if err := r.Get(ctx, req.NamespacedName, &node); err != nil {
+		if !k8serrors.IsNotFound(err) {
+			logr.Error(err, "Unable to fetch node")
+			return ctrl.Result{}, err
+		}
+		// the NodeAttestation is garbage collected together with its node
+		deleteNodeAttestationMetrics(req.Name)
+		return ctrl.Result{}, nil
+	}
+	if !node.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	attestationCfg, err := r.attestationConfig(ctx)
+	if err != nil {
+		logr.Error(err, "Unable to get attestation config")
+		return ctrl.Result{}, err
+	}
+
+	nodeAttestation, err := r.getOrCreateNodeAttestation(ctx, &node)
+	if err != nil {
+		logr.Error(err, "Unable to get NodeAttestation")
+		return ctrl.Result{}, err
+	}
+
+	var report verify.ClaimsReport
+	endpoint, attestErr := r.verificationServiceEndpoint(ctx, node.Name)
+	if attestErr == nil {
+		report, attestErr = r.Attest(ctx, endpoint, attestationCfg)
+	}
+	if attestErr != nil {
+		logr.Info("Unable to attest node", "node", node.Name, "error", attestErr)
+	}
+	status := newNodeAttestationStatus(nodeAttestation.Status, report, attestErr, r.clock.Now())
+	if status.Phase == updatev1alpha1.NodeAttestationPhaseFailing {
+		logr.Info("Node attestation failed", "node", node.Name, "message", status.Message)
+	}
+
+	if err := r.updateNodeCondition(ctx, &node, status); err != nil {
+		logr.Error(err, "Unable to update node condition")
+		return ctrl.Result{}, err
+	}
+	cordoned, err := r.updateCordon(ctx, &node, status.Phase)
+	if err != nil {
+		logr.Error(err, "Unable to cordon node")
+		return ctrl.Result{}, err
+	}
+	status.Cordoned = cordoned
+
+	if err := r.tryUpdateStatus(ctx, types.NamespacedName{Name: nodeAttestation.Name}, status); err != nil {
+		logr.Error(err, "Unable to update NodeAttestation status")
+		return ctrl.Result{}, err
+	}
+	recordNodeAttestationMetrics(node.Name, status)
+
+	return ctrl.Result{RequeueAfter: r.interval}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+// Nodes are only reconciled when they are created and then periodically, since node updates,
+// including the condition set by this controller, don't require a new attestation.
+func (r *NodeAttestationReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("nodeattestation").
+		For(&corev1.Node{}, builder.WithPredicates(predicate.Funcs{
+			UpdateFunc: func(event.UpdateEvent) bool { return false },
+		})).
+		Complete(r)
+}
+
+// attestationConfig returns the attestation config of the join config,
+// complemented with the certificates cached by the join service.
+func (r *NodeAttestationReconciler) attestationConfig(ctx context.Context) (config.AttestationCfg, error) {
+	var joinConfig corev1.ConfigMap
+	if err := r.Get(ctx, types.NamespacedName{Namespace: mainconstants.ConstellationNamespace, Name: mainconstants.JoinConfigMap}, &joinConfig); err != nil {
+		return nil, fmt.Errorf("getting join config: %w", err)
+	}
+	rawCfg, ok := joinConfig.Data[mainconstants.AttestationConfigFilename]
+	if !ok {
+		return nil, fmt.Errorf("join config has no %q key", mainconstants.AttestationConfigFilename)
+	}
+	attestationCfg, err := config.UnmarshalAttestationConfig([]byte(rawCfg), r.attestationVariant)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshaling attestation config: %w", err)
+	}
+
+	var certCache corev1.ConfigMap
+	err = r.Get(ctx, types.NamespacedName{Namespace: mainconstants.ConstellationNamespace, Name: mainconstants.SevSnpCertCacheConfigMapName}, &certCache)
+	if k8serrors.IsNotFound(err) {
+		return attestationCfg, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("getting certificate cache: %w", err)
+	}
+	return withCachedCerts(attestationCfg, certCache.Data)
+}
+
+// withCachedCerts sets the ASK and CRL of SEV-SNP attestation configs to the values cached by the join service,
+// if they aren't set in the config.
+func withCachedCerts(attestationCfg config.AttestationCfg, cache map[string]string) (config.AttestationCfg, error) {
+	var ask config.Certificate
+	if rawASK := cache[mainconstants.CertCacheAskKey]; rawASK != "" {
+		cert, err := crypto.PemToX509Cert([]byte(rawASK))
+		if err != nil {
+			return nil, fmt.Errorf("parsing cached ASK: %w", err)
+		}
+		ask = config.Certificate(*cert)
+	}
+	var crl config.CRL
+	if rawCRL := cache[mainconstants.CertCacheCRLKey]; rawCRL != "" {
+		block, _ := pem.Decode([]byte(rawCRL))
+		if block == nil {
+			return nil, errors.New("parsing cached CRL: no PEM block found")
+		}
+		parsed, err := x509.ParseRevocationList(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("parsing cached CRL: %w", err)
+		}
+		// an outdated cached CRL is ignored, so the validator fetches the current CRL itself.
+		if time.Now().Before(parsed.NextUpdate) {
+			crl = config.CRL(*parsed)
+		}
+	}
+
+	setCerts := func(signingKey *config.Certificate, amdCRL *config.CRL, checkRevocations bool) {
+		if signingKey != nil && signingKey.Equal(config.Certificate{}) {
+			*signingKey = ask
+		}
+		if checkRevocations && amdCRL.IsEmpty() {
+			*amdCRL = crl
+		}
+	}
+	switch c := attestationCfg.(type) {
+	case *config.AzureSEVSNP:
+		setCerts(&c.AMDSigningKey, &c.AMDCRL, c.CheckRevocations)
+	case *config.AWSSEVSNP:
+		setCerts(&c.AMDSigningKey, &c.AMDCRL, c.CheckRevocations)
+	case *config.QEMUSEVSNP:
+		setCerts(&c.AMDSigningKey, &c.AMDCRL, c.CheckRevocations)
+	case *config.GCPSEVSNP:
+		setCerts(nil, &c.AMDCRL, c.CheckRevocations)
+	}
+	return attestationCfg, nil
+}
+
+// verificationServiceEndpoint returns the gRPC endpoint of the verification service pod running on the given node.
+func (r *NodeAttestationReconciler) verificationServiceEndpoint(ctx context.Context, nodeName string) (string, error) {
+	var pods corev1.PodList
+	if err := r.List(ctx, &pods,
+		client.InNamespace(mainconstants.ConstellationNamespace),
+		client.MatchingLabels{verificationServiceLabel: verificationServiceName},
+	); err != nil {
+		return "", fmt.Errorf("listing verification service pods: %w", err)
+	}
+	for _, pod := range pods.Items {
+		if pod.Spec.NodeName == nodeName && pod.Status.Phase == corev1.PodRunning && pod.Status.PodIP != "" {
+			return fmt.Sprintf("%s:%d", pod.Status.PodIP, mainconstants.VerifyServicePortGRPC), nil
+		}
+	}
+	return "", fmt.Errorf("no running verification service on node %s", nodeName)
+}
+
+// getOrCreateNodeAttestation returns the NodeAttestation of the node.
+// A new NodeAttestation is owned by the node, so it's deleted together with the node.
+func (r *NodeAttestationReconciler) getOrCreateNodeAttestation(ctx context.Context, node *corev1.Node) (updatev1alpha1.NodeAttestation, error) {
+	var nodeAttestation updatev1alpha1.NodeAttestation
+	err := r.Get(ctx, types.NamespacedName{Name: node.Name}, &nodeAttestation)
+	if err == nil || !k8serrors.IsNotFound(err) {
+		return nodeAttestation, err
+	}
+
+	nodeAttestation = updatev1alpha1.NodeAttestation{
+		TypeMeta:   metav1.TypeMeta{APIVersion: updatev1alpha1.GroupVersion.String(), Kind: "NodeAttestation"},
+		ObjectMeta: metav1.ObjectMeta{Name: node.Name},
+		Spec:       updatev1alpha1.NodeAttestationSpec{NodeName: node.Name},
+	}
+	if err := controllerutil.SetOwnerReference(node, &nodeAttestation, r.Scheme); err != nil {
+		return updatev1alpha1.NodeAttestation{}, err
+	}
+	if err := r.Create(ctx, &nodeAttestation); err != nil {
+		return updatev1alpha1.NodeAttestation{}, err
+	}
+	return nodeAttestation, nil
+}
+
+// updateNodeCondition sets the attestation condition of the node.
+func (r *NodeAttestationReconciler) updateNodeCondition(ctx context.Context, node *corev1.Node, status updatev1alpha1.NodeAttestationStatus) error {
+	original := node.DeepCopy()
+	if !setAttestationCondition(node, status, r.clock.Now()) {
+		return nil
+	}
+	return r.Status().Patch(ctx, node, client.StrategicMergeFrom(original))
+}
+
+// updateCordon cordons nodes whose attestation failed, if enabled, and uncordons them once their attestation passes again.
+// It returns whether the node is cordoned because of its attestation.
+func (r *NodeAttestationReconciler) updateCordon(ctx context.Context, node *corev1.Node, phase updatev1alpha1.NodeAttestationPhase) (bool, error) {
+	_, cordoned := node.Annotations[attestationCordonedAnnotation]
+	original := node.DeepCopy()
+	switch {
+	case phase == updatev1alpha1.NodeAttestationPhaseFailing && !cordoned && !node.Spec.Unschedulable && r.cordonFailingNodes:
+		log.FromContext(ctx).Info("Cordoning node with failing attestation", "node", node.Name)
+		node.Spec.Unschedulable = true
+		if node.Annotations == nil {
+			node.Annotations = map[string]string{}
+		}
+		node.Annotations[attestationCordonedAnnotation] = "true"
+		cordoned = true
+	case phase == updatev1alpha1.NodeAttestationPhasePassing && cordoned:
+		log.FromContext(ctx).Info("Uncordoning node with passing attestation", "node", node.Name)
+		node.Spec.Unschedulable = false
+		delete(node.Annotations, attestationCordonedAnnotation)
+		cordoned = false
+	default:
+		return cordoned, nil
+	}
+	return cordoned, r.Patch(ctx, node, client.MergeFrom(original))
+}
+
+// tryUpdateStatus attempts to update the NodeAttestation status field in a retry loop.
+func (r *NodeAttestationReconciler) tryUpdateStatus(ctx context.Context, name types.NamespacedName, status updatev1alpha1.NodeAttestationStatus) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		var nodeAttestation updatev1alpha1.NodeAttestation
+		if err := r.Get(ctx, name, &nodeAttestation); err != nil {
+			return err
+		}
+		nodeAttestation.Status = *status.DeepCopy()
+		return r.Status().Update(ctx, &nodeAttestation)
+	})
+}
+
+// newNodeAttestationStatus returns the status of a NodeAttestation after an attestation with the given result.
+func newNodeAttestationStatus(previous updatev1alpha1.NodeAttestationStatus, report verify.ClaimsReport, attestErr error, now time.Time,
+) updatev1alpha1.NodeAttestationStatus {
+	status := updatev1alpha1.NodeAttestationStatus{
+		LastAttestationTime: metav1.NewTime(now),
+		LastPassingTime:     previous.LastPassingTime,
+		ConsecutiveFailures: previous.ConsecutiveFailures,
+		Cordoned:            previous.Cordoned,
+	}
+
+	if attestErr != nil {
+		status.Phase = updatev1alpha1.NodeAttestationPhaseUnknown
+		status.Message = attestErr.Error()
+		status.ConsecutiveFailures++
+		return status
+	}
+
+	status.Verdict = string(report.Verdict)
+	for _, check := range report.Checks {
+		status.Checks = append(status.Checks, updatev1alpha1.NodeAttestationCheck{
+			Name:    check.Name,
+			Verdict: string(check.Verdict),
+			Detail:  check.Detail,
+		})
+	}
+	switch report.Verdict {
+	case verify.VerdictPass, verify.VerdictWarn:
+		status.Phase = updatev1alpha1.NodeAttestationPhasePassing
+		status.Message = "Attestation passed"
+		status.LastPassingTime = metav1.NewTime(now)
+		status.ConsecutiveFailures = 0
+	default:
+		status.Phase = updatev1alpha1.NodeAttestationPhaseFailing
+		status.Message = "Attestation failed"
+		for _, check := range report.Checks {
+			if check.Verdict == verify.VerdictFail {
+				status.Message = fmt.Sprintf("Attestation failed: %s: %s", check.Name, check.Detail)
+				break
+			}
+		}
+		status.ConsecutiveFailures++
+	}
+	return status
+}
+
+// setAttestationCondition sets the attestation condition of the node according to the NodeAttestation status.
+// It returns true if the condition changed.
+func setAttestationCondition(node *corev1.Node, status updatev1alpha1.NodeAttestationStatus, now time.Time) bool {
+	condition := corev1.NodeCondition{
+		Type:              attestationNodeCondition,
+		Status:            corev1.ConditionUnknown,
+		Reason:            "AttestationUnknown",
+		Message:           status.Message,
+		LastHeartbeatTime: metav1.NewTime(now),
+	}
+	switch status.Phase {
+	case updatev1alpha1.NodeAttestationPhasePassing:
+		condition.Status = corev1.ConditionTrue
+		condition.Reason = "AttestationPassed"
+	case updatev1alpha1.NodeAttestationPhaseFailing:
+		condition.Status = corev1.ConditionFalse
+		condition.Reason = "AttestationFailed"
+	}
+
+	for i, existing := range node.Status.Conditions {
+		if existing.Type != attestationNodeCondition {
+			continue
+		}
+		if existing.Status == condition.Status && existing.Reason == condition.Reason && existing.Message == condition.Message {
+			return false
+		}
+		condition.LastTransitionTime = existing.LastTransitionTime
+		if existing.Status != condition.Status {
+			condition.LastTransitionTime = metav1.NewTime(now)
+		}
+		node.Status.Conditions[i] = condition
+		return true
+	}
+	condition.LastTransitionTime = metav1.NewTime(now)
+	node.Status.Conditions = append(node.Status.Conditions, condition)
+	return true
+}
+
+// recordNodeAttestationMetrics exports the NodeAttestation status of a node as metrics.
+func recordNodeAttestationMetrics(nodeName string, status updatev1alpha1.NodeAttestationStatus) {
+	passing := 0.0
+	if status.Phase == updatev1alpha1.NodeAttestationPhasePassing {
+		passing = 1
+	} else {
+		nodeAttestationFailures.WithLabelValues(nodeName, string(status.Phase)).Inc()
+	}
+	nodeAttestationPassing.WithLabelValues(nodeName).Set(passing)
+	if !status.LastPassingTime.IsZero() {
+		nodeAttestationLastPassing.WithLabelValues(nodeName).Set(float64(status.LastPassingTime.Unix()))
+	}
+}
+
+// deleteNodeAttestationMetrics removes the metrics of a node that left the cluster.
+func deleteNodeAttestationMetrics(nodeName string) {
+	nodeAttestationPassing.DeleteLabelValues(nodeName)
+	nodeAttestationLastPassing.DeleteLabelValues(nodeName)
+	nodeAttestationFailures.DeletePartialMatch(prometheus.Labels{"node": nodeName})
+}
+
+type attester interface {
+	Attest(ctx context.Context, endpoint string, attestationCfg config.AttestationCfg) (verify.ClaimsReport, error)
+}
@@ -0,0 +1,357 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package controllers
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/edgelesssys/constellation/v2/internal/config"
+	mainconstants "github.com/edgelesssys/constellation/v2/internal/constants"
+	"github.com/edgelesssys/constellation/v2/internal/verify"
+	updatev1alpha1 "github.com/edgelesssys/constellation/v2/operators/constellation-node-operator/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNewNodeAttestationStatus(t *testing.T) {
+	earlier := metav1.NewTime(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	now := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	previous := updatev1alpha1.NodeAttestationStatus{
+		Phase:               updatev1alpha1.NodeAttestationPhaseFailing,
+		LastPassingTime:     earlier,
+		ConsecutiveFailures: 2,
+	}
+
+	testCases := map[string]struct {
+		report     verify.ClaimsReport
+		attestErr  error
+		wantStatus updatev1alpha1.NodeAttestationStatus
+	}{
+		"passing": {
+			report: verify.ClaimsReport{
+				Verdict: verify.VerdictPass,
+				Checks:  []verify.CheckResult{{Name: "attestation", Verdict: verify.VerdictPass}},
+			},
+			wantStatus: updatev1alpha1.NodeAttestationStatus{
+				Phase:               updatev1alpha1.NodeAttestationPhasePassing,
+				Verdict:             "pass",
+				Message:             "Attestation passed",
+				Checks:              []updatev1alpha1.NodeAttestationCheck{{Name: "attestation", Verdict: "pass"}},
+				LastAttestationTime: metav1.NewTime(now),
+				LastPassingTime:     metav1.NewTime(now),
+			},
+		},
+		"warnings pass": {
+			report: verify.ClaimsReport{
+				Verdict: verify.VerdictWarn,
+				Checks:  []verify.CheckResult{{Name: "measurements", Verdict: verify.VerdictWarn, Detail: "warn only"}},
+			},
+			wantStatus: updatev1alpha1.NodeAttestationStatus{
+				Phase:               updatev1alpha1.NodeAttestationPhasePassing,
+				Verdict:             "warn",
+				Message:             "Attestation passed",
+				Checks:              []updatev1alpha1.NodeAttestationCheck{{Name: "measurements", Verdict: "warn", Detail: "warn only"}},
+				LastAttestationTime: metav1.NewTime(now),
+				LastPassingTime:     metav1.NewTime(now),
+			},
+		},
+		"failing": {
+			report: verify.ClaimsReport{
+				Verdict: verify.VerdictFail,
+				Checks: []verify.CheckResult{
+					{Name: "attestation", Verdict: verify.VerdictPass},
+					{Name: "policy", Verdict: verify.VerdictFail, Detail: "rule failed"},
+				},
+			},
+			wantStatus: updatev1alpha1.NodeAttestationStatus{
+				Phase:   updatev1alpha1.NodeAttestationPhaseFailing,
+				Verdict: "fail",
+				Message: "Attestation failed: policy: rule failed",
+				Checks: []updatev1alpha1.NodeAttestationCheck{
+					{Name: "attestation", Verdict: "pass"},
+					{Name: "policy", Verdict: "fail", Detail: "rule failed"},
+				},
+				LastAttestationTime: metav1.NewTime(now),
+				LastPassingTime:     earlier,
+				ConsecutiveFailures: 3,
+			},
+		},
+		"unreachable": {
+			attestErr: errors.New("connection refused"),
+			wantStatus: updatev1alpha1.NodeAttestationStatus{
+				Phase:               updatev1alpha1.NodeAttestationPhaseUnknown,
+				Message:             "connection refused",
+				LastAttestationTime: metav1.NewTime(now),
+				LastPassingTime:     earlier,
+				ConsecutiveFailures: 3,
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			status := newNodeAttestationStatus(previous, tc.report, tc.attestErr, now)
+			assert.Equal(t, tc.wantStatus, status)
+		})
+	}
+}
+
+func TestSetAttestationCondition(t *testing.T) {
+	earlier := metav1.NewTime(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	now := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	passing := updatev1alpha1.NodeAttestationStatus{Phase: updatev1alpha1.NodeAttestationPhasePassing, Message: "Attestation passed"}
+	failing := updatev1alpha1.NodeAttestationStatus{Phase: updatev1alpha1.NodeAttestationPhaseFailing, Message: "Attestation failed"}
+	passingCondition := corev1.NodeCondition{
+		Type:               attestationNodeCondition,
+		Status:             corev1.ConditionTrue,
+		Reason:             "AttestationPassed",
+		Message:            "Attestation passed",
+		LastHeartbeatTime:  earlier,
+		LastTransitionTime: earlier,
+	}
+
+	testCases := map[string]struct {
+		conditions     []corev1.NodeCondition
+		status         updatev1alpha1.NodeAttestationStatus
+		wantChanged    bool
+		wantCondition  corev1.ConditionStatus
+		wantTransition metav1.Time
+	}{
+		"condition is added": {
+			conditions:     []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}},
+			status:         passing,
+			wantChanged:    true,
+			wantCondition:  corev1.ConditionTrue,
+			wantTransition: metav1.NewTime(now),
+		},
+		"unchanged condition": {
+			conditions:     []corev1.NodeCondition{passingCondition},
+			status:         passing,
+			wantCondition:  corev1.ConditionTrue,
+			wantTransition: earlier,
+		},
+		"condition transitions": {
+			conditions:     []corev1.NodeCondition{passingCondition},
+			status:         failing,
+			wantChanged:    true,
+			wantCondition:  corev1.ConditionFalse,
+			wantTransition: metav1.NewTime(now),
+		},
+		"unknown phase": {
+			status:         updatev1alpha1.NodeAttestationStatus{Phase: updatev1alpha1.NodeAttestationPhaseUnknown},
+			wantChanged:    true,
+			wantCondition:  corev1.ConditionUnknown,
+			wantTransition: metav1.NewTime(now),
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			node := &corev1.Node{Status: corev1.NodeStatus{Conditions: tc.conditions}}
+			assert.Equal(tc.wantChanged, setAttestationCondition(node, tc.status, now))
+
+			var condition *corev1.NodeCondition
+			for i := range node.Status.Conditions {
+				if node.Status.Conditions[i].Type == attestationNodeCondition {
+					condition = &node.Status.Conditions[i]
+				}
+			}
+			require.NotNil(condition)
+			assert.Equal(tc.wantCondition, condition.Status)
+			assert.Equal(tc.wantTransition, condition.LastTransitionTime)
+		})
+	}
+}
+
+func TestUpdateCordon(t *testing.T) {
+	testCases := map[string]struct {
+		node               corev1.Node
+		phase              updatev1alpha1.NodeAttestationPhase
+		cordonFailingNodes bool
+		wantCordoned       bool
+		wantUnschedulable  bool
+	}{
+		"failing node is cordoned": {
+			phase:              updatev1alpha1.NodeAttestationPhaseFailing,
+			cordonFailingNodes: true,
+			wantCordoned:       true,
+			wantUnschedulable:  true,
+		},
+		"failing node is not cordoned if disabled": {
+			phase: updatev1alpha1.NodeAttestationPhaseFailing,
+		},
+		"unknown node is not cordoned": {
+			phase:              updatev1alpha1.NodeAttestationPhaseUnknown,
+			cordonFailingNodes: true,
+		},
+		"node cordoned by someone else is left alone": {
+			node:               corev1.Node{Spec: corev1.NodeSpec{Unschedulable: true}},
+			phase:              updatev1alpha1.NodeAttestationPhasePassing,
+			cordonFailingNodes: true,
+			wantUnschedulable:  true,
+		},
+		"passing node is uncordoned": {
+			node: corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{attestationCordonedAnnotation: "true"}},
+				Spec:       corev1.NodeSpec{Unschedulable: true},
+			},
+			phase:              updatev1alpha1.NodeAttestationPhasePassing,
+			cordonFailingNodes: true,
+		},
+		"still failing node stays cordoned": {
+			node: corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{attestationCordonedAnnotation: "true"}},
+				Spec:       corev1.NodeSpec{Unschedulable: true},
+			},
+			phase:              updatev1alpha1.NodeAttestationPhaseFailing,
+			cordonFailingNodes: true,
+			wantCordoned:       true,
+			wantUnschedulable:  true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			reconciler := NodeAttestationReconciler{
+				Client:             &stubReadWriterClient{},
+				cordonFailingNodes: tc.cordonFailingNodes,
+			}
+			node := tc.node.DeepCopy()
+			cordoned, err := reconciler.updateCordon(t.Context(), node, tc.phase)
+			require.NoError(err)
+			assert.Equal(tc.wantCordoned, cordoned)
+			assert.Equal(tc.wantUnschedulable, node.Spec.Unschedulable)
+			_, hasAnnotation := node.Annotations[attestationCordonedAnnotation]
+			assert.Equal(tc.wantCordoned, hasAnnotation)
+		})
+	}
+}
+
+func TestWithCachedCerts(t *testing.T) {
+	ask, crl := newTestCertAndCRL(t, time.Hour)
+	_, expiredCRL := newTestCertAndCRL(t, time.Nanosecond)
+	askPEM := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ask.Raw}))
+	crlPEM := string(pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: crl.Raw}))
+	expiredCRLPEM := string(pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: expiredCRL.Raw}))
+	otherKey := config.Certificate{Raw: []byte("configured ASK")}
+
+	testCases := map[string]struct {
+		cfg            config.AttestationCfg
+		cache          map[string]string
+		wantSigningKey config.Certificate
+		wantCRL        bool
+		wantErr        bool
+	}{
+		"empty cache": {
+			cfg: &config.AzureSEVSNP{},
+		},
+		"ASK is set": {
+			cfg:            &config.AzureSEVSNP{},
+			cache:          map[string]string{mainconstants.CertCacheAskKey: askPEM, mainconstants.CertCacheCRLKey: crlPEM},
+			wantSigningKey: config.Certificate(*ask),
+		},
+		"configured ASK is kept": {
+			cfg:            &config.AWSSEVSNP{AMDSigningKey: otherKey},
+			cache:          map[string]string{mainconstants.CertCacheAskKey: askPEM},
+			wantSigningKey: otherKey,
+		},
+		"CRL is set if revocations are checked": {
+			cfg:            &config.AzureSEVSNP{CheckRevocations: true},
+			cache:          map[string]string{mainconstants.CertCacheAskKey: askPEM, mainconstants.CertCacheCRLKey: crlPEM},
+			wantSigningKey: config.Certificate(*ask),
+			wantCRL:        true,
+		},
+		"expired CRL is not set": {
+			cfg:            &config.AzureSEVSNP{CheckRevocations: true},
+			cache:          map[string]string{mainconstants.CertCacheAskKey: askPEM, mainconstants.CertCacheCRLKey: expiredCRLPEM},
+			wantSigningKey: config.Certificate(*ask),
+		},
+		"invalid ASK": {
+			cfg:     &config.AzureSEVSNP{},
+			cache:   map[string]string{mainconstants.CertCacheAskKey: "invalid"},
+			wantErr: true,
+		},
+		"invalid CRL": {
+			cfg:     &config.AzureSEVSNP{},
+			cache:   map[string]string{mainconstants.CertCacheCRLKey: "invalid"},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			cfg, err := withCachedCerts(tc.cfg, tc.cache)
+			if tc.wantErr {
+				assert.Error(err)
+				return
+			}
+			require.NoError(err)
+
+			var signingKey config.Certificate
+			var amdCRL config.CRL
+			switch c := cfg.(type) {
+			case *config.AzureSEVSNP:
+				signingKey, amdCRL = c.AMDSigningKey, c.AMDCRL
+			case *config.AWSSEVSNP:
+				signingKey, amdCRL = c.AMDSigningKey, c.AMDCRL
+			}
+			assert.True(tc.wantSigningKey.Equal(signingKey))
+			assert.Equal(tc.wantCRL, !amdCRL.IsEmpty())
+		})
+	}
+}
+
+func newTestCertAndCRL(t *testing.T, crlValidity time.Duration) (*x509.Certificate, *x509.RevocationList) {
+	t.Helper()
+	require := require.New(t)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "ASK"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(err)
+	cert, err := x509.ParseCertificate(certDER)
+	require.NoError(err)
+
+	crlDER, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:     big.NewInt(1),
+		ThisUpdate: time.Now(),
+		NextUpdate: time.Now().Add(crlValidity),
+	}, cert, key)
+	require.NoError(err)
+	crl, err := x509.ParseRevocationList(crlDER)
+	require.NoError(err)
+	return cert, crl
+}
@@ -0,0 +1,389 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	updatev1alpha1 "github.com/edgelesssys/constellation/v2/operators/constellation-node-operator/api/v1alpha1"
+	"github.com/edgelesssys/constellation/v2/operators/constellation-node-operator/internal/healthcheck"
+	nodeutil "github.com/edgelesssys/constellation/v2/operators/constellation-node-operator/internal/node"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// canaryProbeInterval is the minimum time between two checks of the health gates of a canary rollout.
+	canaryProbeInterval = 30 * time.Second
+	// defaultCanaryFailureThreshold is the number of consecutive failed health checks after which a canary rollout fails.
+	defaultCanaryFailureThreshold = 3
+)
+
+// canaryHealth is the result of checking the health gates of a canary rollout.
+type canaryHealth int
+
+const (
+	// canaryHealthPending means that the result of a health gate is not known yet.
+	canaryHealthPending canaryHealth = iota
+	// canaryHealthPassed means that all health gates passed.
+	canaryHealthPassed
+	// canaryHealthFailed means that at least one health gate failed.
+	canaryHealthFailed
+)
+
+// canaryResult is the outcome of advancing a canary rollout in a Reconcile call.
+type canaryResult struct {
+	// status is the new canary status of the NodeVersion.
+	status *updatev1alpha1.CanaryStatus
+	// stage restricts the creation of new nodes. If nil, new nodes are not restricted.
+	stage *canaryStage
+	// requeueAfter is the time after which the health gates should be checked again.
+	requeueAfter time.Duration
+	// rolledBack is set if the image of the NodeVersion was rolled back.
+	rolledBack bool
+}
+
+// canaryStage restricts the creation of new nodes to the canary stage of a rollout.
+type canaryStage struct {
+	// scalingGroupIDs are the scaling groups in which new nodes may be created.
+	// If nil, new nodes may be created in any scaling group.
+	scalingGroupIDs map[string]struct{}
+	// remainingNodes is the number of new nodes that may still be created.
+	// A negative value means that the number of new nodes is not limited.
+	remainingNodes int
+}
+
+// haltedCanaryStage returns a stage that does not allow any new nodes.
+func haltedCanaryStage() *canaryStage {
+	return &canaryStage{remainingNodes: 0}
+}
+
+// allows checks if a new node may be created in the scaling group.
+func (s *canaryStage) allows(scalingGroupID string) bool {
+	if s == nil {
+		return true
+	}
+	if s.remainingNodes == 0 {
+		return false
+	}
+	if s.scalingGroupIDs == nil {
+		return true
+	}
+	_, ok := s.scalingGroupIDs[strings.ToLower(scalingGroupID)]
+	return ok
+}
+
+// consume uses up the stage for one new node.
+func (s *canaryStage) consume() {
+	if s == nil || s.remainingNodes < 0 {
+		return
+	}
+	s.remainingNodes--
+}
+
+// reconcileCanary advances the canary rollout of the NodeVersion.
+//
+// A canary rollout goes through the following phases:
+// Progressing: only the nodes of the canary stage are replaced.
+// Verifying: the health gates are checked until the verification duration has passed.
+// Succeeded: the image is rolled out to all nodes.
+// Failed: the image of the NodeVersion is rolled back to the previous image, or the rollout is halted if the previous image is unknown.
+func (r *NodeVersionReconciler) reconcileCanary(ctx context.Context, nodeVersion *updatev1alpha1.NodeVersion, groups nodeGroups,
+	pendingNodes []updatev1alpha1.PendingNode, scalingGroupByID map[string]updatev1alpha1.ScalingGroup, now time.Time,
+) (canaryResult, error) {
+	logr := log.FromContext(ctx)
+	canary := currentCanary(*nodeVersion, groups)
+	result := canaryResult{status: canary}
+	rollout := nodeVersion.Spec.Canary
+	if rollout == nil || canary == nil {
+		return result, nil
+	}
+
+	switch canary.Phase {
+	case updatev1alpha1.CanaryPhaseSucceeded:
+		return result, nil
+	case updatev1alpha1.CanaryPhaseFailed:
+		if !strings.EqualFold(canary.ImageReference, nodeVersion.Spec.ImageReference) {
+			// the image was rolled back and the previous image is restored on all nodes.
+			return result, nil
+		}
+		result.stage = haltedCanaryStage()
+		return result, nil
+	case updatev1alpha1.CanaryPhaseProgressing:
+		stage, complete := newCanaryStage(*rollout, groups, pendingNodes, scalingGroupByID)
+		if !complete {
+			result.stage = stage
+			return result, nil
+		}
+		logr.Info("Canary nodes replaced, verifying health gates", "image", canary.ImageReference)
+		startTime := metav1.NewTime(now)
+		canary.Phase = updatev1alpha1.CanaryPhaseVerifying
+		canary.VerificationStartTime = &startTime
+		canary.LastProbeTime = nil
+		canary.ConsecutiveFailures = 0
+		canary.Message = "Verifying health gates of canary nodes"
+	}
+
+	// no new nodes are created while the health gates are verified.
+	result.stage = haltedCanaryStage()
+	if canary.LastProbeTime != nil {
+		if sinceLastProbe := now.Sub(canary.LastProbeTime.Time); sinceLastProbe < canaryProbeInterval {
+			result.requeueAfter = canaryProbeInterval - sinceLastProbe
+			return result, nil
+		}
+	}
+
+	health, message := r.checkCanaryHealth(ctx, rollout.HealthGates, canary.ImageReference, canaryNodes(groups, canary.ImageReference))
+	probeTime := metav1.NewTime(now)
+	canary.LastProbeTime = &probeTime
+	canary.Message = message
+	result.requeueAfter = canaryProbeInterval
+	switch health {
+	case canaryHealthPassed:
+		canary.ConsecutiveFailures = 0
+		if canary.VerificationStartTime == nil || now.Sub(canary.VerificationStartTime.Time) >= rollout.VerificationDuration.Duration {
+			logr.Info("Canary health gates passed, rolling out image to all nodes", "image", canary.ImageReference)
+			canary.Phase = updatev1alpha1.CanaryPhaseSucceeded
+			canary.Message = "Health gates passed"
+			result.stage = nil
+			result.requeueAfter = 0
+		}
+		return result, nil
+	case canaryHealthFailed:
+		canary.ConsecutiveFailures++
+		logr.Info("Canary health gates failed", "image", canary.ImageReference, "reason", message, "consecutiveFailures", canary.ConsecutiveFailures)
+	}
+
+	failureThreshold := rollout.FailureThreshold
+	if failureThreshold < 1 {
+		failureThreshold = defaultCanaryFailureThreshold
+	}
+	if canary.ConsecutiveFailures < failureThreshold {
+		return result, nil
+	}
+
+	canary.Phase = updatev1alpha1.CanaryPhaseFailed
+	result.requeueAfter = 0
+	if canary.PreviousImageReference == "" || canary.PreviousImageVersion == "" {
+		logr.Info("Canary rollout failed and previous image is unknown, halting rollout", "image", canary.ImageReference)
+		canary.ObservedGeneration = nodeVersion.Generation
+		canary.Message = fmt.Sprintf("Health gates failed: %s. Rollout halted", message)
+		return result, nil
+	}
+	logr.Info("Canary rollout failed, rolling back image", "image", canary.ImageReference, "previousImage", canary.PreviousImageReference)
+	generation, err := r.rollbackImage(ctx, client.ObjectKeyFromObject(nodeVersion), canary)
+	if err != nil {
+		return canaryResult{}, fmt.Errorf("rolling back image: %w", err)
+	}
+	canary.ObservedGeneration = generation
+	canary.Message = fmt.Sprintf("Health gates failed: %s. Rolled back to %s", message, canary.PreviousImageReference)
+	result.rolledBack = true
+	return result, nil
+}
+
+// canaryActive checks if a canary rollout is replacing canary nodes or verifying their health.
+func canaryActive(canary *updatev1alpha1.CanaryStatus) bool {
+	return canary != nil && (canary.Phase == updatev1alpha1.CanaryPhaseProgressing || canary.Phase == updatev1alpha1.CanaryPhaseVerifying)
+}
+
+// currentCanary returns the canary status that applies to the NodeVersion.
+// A new canary rollout is started if outdated nodes need to be replaced by nodes using a new image.
+// A failed canary rollout is restarted if the NodeVersion was changed after the rollout failed.
+func currentCanary(nodeVersion updatev1alpha1.NodeVersion, groups nodeGroups) *updatev1alpha1.CanaryStatus {
+	canary := nodeVersion.Status.Canary.DeepCopy()
+	if nodeVersion.Spec.Canary == nil {
+		return canary
+	}
+	if canary != nil {
+		sameImage := strings.EqualFold(canary.ImageReference, nodeVersion.Spec.ImageReference)
+		if canary.Phase == updatev1alpha1.CanaryPhaseFailed {
+			rollingBack := strings.EqualFold(canary.PreviousImageReference, nodeVersion.Spec.ImageReference)
+			changedAfterFailure := nodeVersion.Generation > canary.ObservedGeneration
+			if rollingBack || (sameImage && !changedAfterFailure) {
+				return canary
+			}
+		} else if sameImage {
+			return canary
+		}
+	}
+	if !hasOutdatedImage(groups, nodeVersion.Spec.ImageReference) {
+		return canary
+	}
+
+	canary = &updatev1alpha1.CanaryStatus{
+		ImageReference: nodeVersion.Spec.ImageReference,
+		ImageVersion:   nodeVersion.Spec.ImageVersion,
+		Phase:          updatev1alpha1.CanaryPhaseProgressing,
+		Message:        "Replacing canary nodes",
+	}
+	if !strings.EqualFold(nodeVersion.Status.RolledOutImageReference, nodeVersion.Spec.ImageReference) {
+		canary.PreviousImageReference = nodeVersion.Status.RolledOutImageReference
+		canary.PreviousImageVersion = nodeVersion.Status.RolledOutImageVersion
+	}
+	return canary
+}
+
+// hasOutdatedImage checks if any node that is not yet replaced uses a different image.
+func hasOutdatedImage(groups nodeGroups, imageReference string) bool {
+	for _, node := range append(groups.Outdated, groups.Donors...) {
+		if !strings.EqualFold(node.Annotations[nodeImageAnnotation], imageReference) {
+			return true
+		}
+	}
+	return false
+}
+
+// newCanaryStage returns the stage that restricts the creation of new nodes to the canary nodes.
+// If all canary nodes are replaced, the stage is complete.
+func newCanaryStage(rollout updatev1alpha1.CanaryRollout, groups nodeGroups, pendingNodes []updatev1alpha1.PendingNode,
+	scalingGroupByID map[string]updatev1alpha1.ScalingGroup,
+) (*canaryStage, bool) {
+	var joiningNodes []updatev1alpha1.PendingNode
+	for _, pendingNode := range pendingNodes {
+		if pendingNode.Spec.Goal == updatev1alpha1.NodeGoalJoin {
+			joiningNodes = append(joiningNodes, pendingNode)
+		}
+	}
+
+	if len(rollout.ScalingGroups) == 0 && rollout.Percentage > 0 {
+		clusterNodes := len(groups.Outdated) + len(groups.Donors) + len(groups.UpToDate)
+		targetNodes := max((clusterNodes*int(rollout.Percentage)+99)/100, 1)
+		replacingNodes := len(groups.Heirs) + len(groups.Mint) + len(joiningNodes)
+		complete := replacingNodes == 0 && (len(groups.UpToDate) >= targetNodes || len(groups.Outdated)+len(groups.Donors) == 0)
+		return &canaryStage{remainingNodes: max(targetNodes-len(groups.UpToDate)-replacingNodes, 0)}, complete
+	}
+
+	scope := make(map[string]struct{})
+	for scalingGroupID, scalingGroup := range scalingGroupByID {
+		if scalingGroup.Spec.Role == updatev1alpha1.ControlPlaneRole {
+			scope[scalingGroupID] = struct{}{}
+			continue
+		}
+		for _, name := range rollout.ScalingGroups {
+			if strings.EqualFold(name, scalingGroup.Name) || strings.EqualFold(name, scalingGroup.Spec.NodeGroupName) ||
+				strings.EqualFold(name, scalingGroup.Spec.GroupID) {
+				scope[scalingGroupID] = struct{}{}
+				break
+			}
+		}
+	}
+	inScope := func(scalingGroupID string) bool {
+		_, ok := scope[strings.ToLower(scalingGroupID)]
+		return ok
+	}
+	complete := true
+	for _, node := range append(append(groups.Outdated, groups.Donors...), groups.Heirs...) {
+		if inScope(node.Annotations[scalingGroupAnnotation]) {
+			complete = false
+		}
+	}
+	for _, mintNode := range groups.Mint {
+		if inScope(mintNode.pendingNode.Spec.ScalingGroupID) {
+			complete = false
+		}
+	}
+	for _, joiningNode := range joiningNodes {
+		if inScope(joiningNode.Spec.ScalingGroupID) {
+			complete = false
+		}
+	}
+	return &canaryStage{scalingGroupIDs: scope, remainingNodes: -1}, complete
+}
+
+// canaryNodes returns the replaced nodes that use the image of the canary rollout.
+func canaryNodes(groups nodeGroups, imageReference string) []corev1.Node {
+	var nodes []corev1.Node
+	for _, node := range groups.UpToDate {
+		if strings.EqualFold(node.Annotations[nodeImageAnnotation], imageReference) {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
+
+// checkCanaryHealth checks the health gates for the canary nodes.
+// The readiness of the canary nodes is always checked.
+func (r *NodeVersionReconciler) checkCanaryHealth(ctx context.Context, gates updatev1alpha1.CanaryHealthGates, imageReference string, nodes []corev1.Node) (canaryHealth, string) {
+	if len(nodes) == 0 {
+		return canaryHealthPending, "Waiting for canary nodes"
+	}
+	nodeNames := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		if !nodeutil.Ready(&node) {
+			return canaryHealthFailed, fmt.Sprintf("node %s is not ready", node.Name)
+		}
+		nodeNames = append(nodeNames, node.Name)
+	}
+
+	if gates.Attestation {
+		for _, node := range nodes {
+			var nodeAttestation updatev1alpha1.NodeAttestation
+			if err := r.Get(ctx, types.NamespacedName{Name: node.Name}, &nodeAttestation); err != nil {
+				if client.IgnoreNotFound(err) != nil {
+					log.FromContext(ctx).Error(err, "Unable to get node attestation", "node", node.Name)
+				}
+				return canaryHealthPending, fmt.Sprintf("waiting for attestation of node %s", node.Name)
+			}
+			switch nodeAttestation.Status.Phase {
+			case updatev1alpha1.NodeAttestationPhasePassing:
+			case updatev1alpha1.NodeAttestationPhaseFailing:
+				return canaryHealthFailed, fmt.Sprintf("attestation of node %s failed", node.Name)
+			default:
+				return canaryHealthPending, fmt.Sprintf("waiting for attestation of node %s", node.Name)
+			}
+		}
+	}
+
+	if gates.Webhook != "" {
+		body := healthcheck.WebhookRequest{ImageReference: imageReference, Nodes: nodeNames}
+		if err := r.CheckWebhook(ctx, gates.Webhook, body); err != nil {
+			return canaryHealthFailed, fmt.Sprintf("webhook check failed: %s", err)
+		}
+	}
+
+	if gates.Prometheus != nil {
+		if err := r.CheckPrometheus(ctx, gates.Prometheus.URL, gates.Prometheus.Query); err != nil {
+			return canaryHealthFailed, fmt.Sprintf("prometheus check failed: %s", err)
+		}
+	}
+
+	return canaryHealthPassed, "Health gates passed"
+}
+
+// rollbackImage sets the image of the NodeVersion back to the previous image of the canary rollout in a retry loop.
+// The generation of the updated NodeVersion is returned.
+func (r *NodeVersionReconciler) rollbackImage(ctx context.Context, name types.NamespacedName, canary *updatev1alpha1.CanaryStatus) (int64, error) {
+	var generation int64
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		var nodeVersion updatev1alpha1.NodeVersion
+		if err := r.Get(ctx, name, &nodeVersion); err != nil {
+			return err
+		}
+		nodeVersion.Spec.ImageReference = canary.PreviousImageReference
+		nodeVersion.Spec.ImageVersion = canary.PreviousImageVersion
+		if err := r.Update(ctx, &nodeVersion); err != nil {
+			return err
+		}
+		generation = nodeVersion.Generation
+		return nil
+	})
+	return generation, err
+}
+
+type healthChecker interface {
+	// CheckWebhook sends the canary nodes to a webhook and returns an error if the webhook reports them as unhealthy.
+	CheckWebhook(ctx context.Context, webhookURL string, body healthcheck.WebhookRequest) error
+	// CheckPrometheus runs a Prometheus query and returns an error if it reports the cluster as unhealthy.
+	CheckPrometheus(ctx context.Context, prometheusURL, query string) error
+}
@@ -0,0 +1,556 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	updatev1alpha1 "github.com/edgelesssys/constellation/v2/operators/constellation-node-operator/api/v1alpha1"
+	"github.com/edgelesssys/constellation/v2/operators/constellation-node-operator/internal/healthcheck"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestCurrentCanary(t *testing.T) {
+	outdatedGroups := nodeGroups{Outdated: []corev1.Node{canaryTestNode("node", "worker-group", "image-1", true)}}
+
+	testCases := map[string]struct {
+		nodeVersion updatev1alpha1.NodeVersion
+		groups      nodeGroups
+		wantCanary  *updatev1alpha1.CanaryStatus
+	}{
+		"canary disabled": {
+			nodeVersion: canaryTestNodeVersion(nil, nil),
+			groups:      outdatedGroups,
+		},
+		"nodes up to date": {
+			nodeVersion: canaryTestNodeVersion(&updatev1alpha1.CanaryRollout{}, nil),
+		},
+		"new image starts canary": {
+			nodeVersion: canaryTestNodeVersion(&updatev1alpha1.CanaryRollout{}, nil),
+			groups:      outdatedGroups,
+			wantCanary: &updatev1alpha1.CanaryStatus{
+				ImageReference:         "image-2",
+				ImageVersion:           "v2",
+				PreviousImageReference: "image-1",
+				PreviousImageVersion:   "v1",
+				Phase:                  updatev1alpha1.CanaryPhaseProgressing,
+				Message:                "Replacing canary nodes",
+			},
+		},
+		"canary of same image is kept": {
+			nodeVersion: canaryTestNodeVersion(&updatev1alpha1.CanaryRollout{}, &updatev1alpha1.CanaryStatus{
+				ImageReference: "image-2",
+				Phase:          updatev1alpha1.CanaryPhaseVerifying,
+			}),
+			groups: outdatedGroups,
+			wantCanary: &updatev1alpha1.CanaryStatus{
+				ImageReference: "image-2",
+				Phase:          updatev1alpha1.CanaryPhaseVerifying,
+			},
+		},
+		"failed canary is kept while rolling back": {
+			nodeVersion: func() updatev1alpha1.NodeVersion {
+				nodeVersion := canaryTestNodeVersion(&updatev1alpha1.CanaryRollout{}, &updatev1alpha1.CanaryStatus{
+					ImageReference:         "image-2",
+					PreviousImageReference: "image-1",
+					Phase:                  updatev1alpha1.CanaryPhaseFailed,
+				})
+				nodeVersion.Spec.ImageReference = "image-1"
+				return nodeVersion
+			}(),
+			groups: nodeGroups{Outdated: []corev1.Node{canaryTestNode("node", "worker-group", "image-2", true)}},
+			wantCanary: &updatev1alpha1.CanaryStatus{
+				ImageReference:         "image-2",
+				PreviousImageReference: "image-1",
+				Phase:                  updatev1alpha1.CanaryPhaseFailed,
+			},
+		},
+		"failed canary is kept if unchanged": {
+			nodeVersion: canaryTestNodeVersion(&updatev1alpha1.CanaryRollout{}, &updatev1alpha1.CanaryStatus{
+				ImageReference:     "image-2",
+				Phase:              updatev1alpha1.CanaryPhaseFailed,
+				ObservedGeneration: 2,
+			}),
+			groups: outdatedGroups,
+			wantCanary: &updatev1alpha1.CanaryStatus{
+				ImageReference:     "image-2",
+				Phase:              updatev1alpha1.CanaryPhaseFailed,
+				ObservedGeneration: 2,
+			},
+		},
+		"failed canary is restarted if changed": {
+			nodeVersion: canaryTestNodeVersion(&updatev1alpha1.CanaryRollout{}, &updatev1alpha1.CanaryStatus{
+				ImageReference:     "image-2",
+				Phase:              updatev1alpha1.CanaryPhaseFailed,
+				ObservedGeneration: 1,
+			}),
+			groups: outdatedGroups,
+			wantCanary: &updatev1alpha1.CanaryStatus{
+				ImageReference:         "image-2",
+				ImageVersion:           "v2",
+				PreviousImageReference: "image-1",
+				PreviousImageVersion:   "v1",
+				Phase:                  updatev1alpha1.CanaryPhaseProgressing,
+				Message:                "Replacing canary nodes",
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.wantCanary, currentCanary(tc.nodeVersion, tc.groups))
+		})
+	}
+}
+
+func TestNewCanaryStage(t *testing.T) {
+	scalingGroupByID := map[string]updatev1alpha1.ScalingGroup{
+		"control-plane-group": {Spec: updatev1alpha1.ScalingGroupSpec{GroupID: "control-plane-group", Role: updatev1alpha1.ControlPlaneRole}},
+		"worker-group":        {Spec: updatev1alpha1.ScalingGroupSpec{GroupID: "worker-group", NodeGroupName: "canary", Role: updatev1alpha1.WorkerRole}},
+		"other-group":         {Spec: updatev1alpha1.ScalingGroupSpec{GroupID: "other-group", Role: updatev1alpha1.WorkerRole}},
+	}
+
+	testCases := map[string]struct {
+		rollout      updatev1alpha1.CanaryRollout
+		groups       nodeGroups
+		pendingNodes []updatev1alpha1.PendingNode
+		wantAllowed  []string
+		wantDenied   []string
+		wantComplete bool
+	}{
+		"scaling group outdated": {
+			rollout: updatev1alpha1.CanaryRollout{ScalingGroups: []string{"canary"}},
+			groups: nodeGroups{
+				Outdated: []corev1.Node{canaryTestNode("node", "worker-group", "image-1", true)},
+			},
+			wantAllowed: []string{"control-plane-group", "worker-group"},
+			wantDenied:  []string{"other-group"},
+		},
+		"scaling group joining": {
+			rollout: updatev1alpha1.CanaryRollout{ScalingGroups: []string{"canary"}},
+			pendingNodes: []updatev1alpha1.PendingNode{
+				{Spec: updatev1alpha1.PendingNodeSpec{ScalingGroupID: "worker-group", Goal: updatev1alpha1.NodeGoalJoin}},
+			},
+			wantAllowed: []string{"control-plane-group", "worker-group"},
+			wantDenied:  []string{"other-group"},
+		},
+		"scaling group replaced": {
+			rollout: updatev1alpha1.CanaryRollout{ScalingGroups: []string{"canary"}},
+			groups: nodeGroups{
+				Outdated: []corev1.Node{canaryTestNode("other-node", "other-group", "image-1", true)},
+				UpToDate: []corev1.Node{canaryTestNode("node", "worker-group", "image-2", true)},
+			},
+			wantAllowed:  []string{"control-plane-group", "worker-group"},
+			wantDenied:   []string{"other-group"},
+			wantComplete: true,
+		},
+		"no scaling groups includes control planes only": {
+			groups: nodeGroups{
+				Outdated: []corev1.Node{canaryTestNode("node", "worker-group", "image-1", true)},
+			},
+			wantAllowed:  []string{"control-plane-group"},
+			wantDenied:   []string{"worker-group", "other-group"},
+			wantComplete: true,
+		},
+		"percentage outdated": {
+			rollout: updatev1alpha1.CanaryRollout{Percentage: 50},
+			groups: nodeGroups{
+				Outdated: []corev1.Node{
+					canaryTestNode("node-1", "worker-group", "image-1", true),
+					canaryTestNode("node-2", "other-group", "image-1", true),
+					canaryTestNode("node-3", "other-group", "image-1", true),
+				},
+			},
+			wantAllowed: []string{"worker-group", "other-group"},
+		},
+		"percentage replacing": {
+			rollout: updatev1alpha1.CanaryRollout{Percentage: 25},
+			groups: nodeGroups{
+				Outdated: []corev1.Node{
+					canaryTestNode("node-2", "other-group", "image-1", true),
+					canaryTestNode("node-3", "other-group", "image-1", true),
+					canaryTestNode("node-4", "other-group", "image-1", true),
+				},
+				Donors: []corev1.Node{canaryTestNode("node-1", "worker-group", "image-1", true)},
+				Heirs:  []corev1.Node{canaryTestNode("node-5", "worker-group", "image-2", true)},
+			},
+			wantDenied: []string{"worker-group", "other-group"},
+		},
+		"percentage replaced": {
+			rollout: updatev1alpha1.CanaryRollout{Percentage: 25},
+			groups: nodeGroups{
+				Outdated: []corev1.Node{
+					canaryTestNode("node-2", "other-group", "image-1", true),
+					canaryTestNode("node-3", "other-group", "image-1", true),
+					canaryTestNode("node-4", "other-group", "image-1", true),
+				},
+				UpToDate: []corev1.Node{canaryTestNode("node-5", "worker-group", "image-2", true)},
+			},
+			wantDenied:   []string{"worker-group", "other-group"},
+			wantComplete: true,
+		},
+		"percentage rounds up": {
+			rollout: updatev1alpha1.CanaryRollout{Percentage: 50},
+			groups: nodeGroups{
+				Outdated: []corev1.Node{
+					canaryTestNode("node-2", "other-group", "image-1", true),
+					canaryTestNode("node-3", "other-group", "image-1", true),
+				},
+				UpToDate: []corev1.Node{canaryTestNode("node-4", "worker-group", "image-2", true)},
+			},
+			wantAllowed: []string{"worker-group", "other-group"},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			stage, complete := newCanaryStage(tc.rollout, tc.groups, tc.pendingNodes, scalingGroupByID)
+			assert.Equal(tc.wantComplete, complete)
+			for _, scalingGroupID := range tc.wantAllowed {
+				assert.True(stage.allows(scalingGroupID), scalingGroupID)
+			}
+			for _, scalingGroupID := range tc.wantDenied {
+				assert.False(stage.allows(scalingGroupID), scalingGroupID)
+			}
+		})
+	}
+}
+
+func TestCanaryStage(t *testing.T) {
+	assert := assert.New(t)
+
+	var unrestricted *canaryStage
+	assert.True(unrestricted.allows("scaling-group"))
+	unrestricted.consume()
+	assert.True(unrestricted.allows("scaling-group"))
+
+	assert.False(haltedCanaryStage().allows("scaling-group"))
+
+	limited := &canaryStage{remainingNodes: 1}
+	assert.True(limited.allows("scaling-group"))
+	limited.consume()
+	assert.False(limited.allows("scaling-group"))
+
+	scoped := &canaryStage{scalingGroupIDs: map[string]struct{}{"scaling-group": {}}, remainingNodes: -1}
+	assert.True(scoped.allows("Scaling-Group"))
+	scoped.consume()
+	assert.True(scoped.allows("scaling-group"))
+	assert.False(scoped.allows("other-group"))
+}
+
+func TestReconcileCanary(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	minuteAgo := metav1.NewTime(now.Add(-time.Minute))
+	secondsAgo := metav1.NewTime(now.Add(-10 * time.Second))
+	hourAgo := metav1.NewTime(now.Add(-time.Hour))
+	scalingGroupByID := map[string]updatev1alpha1.ScalingGroup{
+		"worker-group": {Spec: updatev1alpha1.ScalingGroupSpec{GroupID: "worker-group", NodeGroupName: "canary", Role: updatev1alpha1.WorkerRole}},
+		"other-group":  {Spec: updatev1alpha1.ScalingGroupSpec{GroupID: "other-group", Role: updatev1alpha1.WorkerRole}},
+	}
+	canaryReplaced := nodeGroups{
+		Outdated: []corev1.Node{canaryTestNode("other-node", "other-group", "image-1", true)},
+		UpToDate: []corev1.Node{canaryTestNode("node", "worker-group", "image-2", true)},
+	}
+	verifying := func(startTime, lastProbeTime *metav1.Time, consecutiveFailures int32) *updatev1alpha1.CanaryStatus {
+		return &updatev1alpha1.CanaryStatus{
+			ImageReference:         "image-2",
+			ImageVersion:           "v2",
+			PreviousImageReference: "image-1",
+			PreviousImageVersion:   "v1",
+			Phase:                  updatev1alpha1.CanaryPhaseVerifying,
+			VerificationStartTime:  startTime,
+			LastProbeTime:          lastProbeTime,
+			ConsecutiveFailures:    consecutiveFailures,
+		}
+	}
+
+	testCases := map[string]struct {
+		rollout          *updatev1alpha1.CanaryRollout
+		canary           *updatev1alpha1.CanaryStatus
+		groups           nodeGroups
+		objects          []runtime.Object
+		healthChecker    *stubHealthChecker
+		wantPhase        updatev1alpha1.CanaryPhase
+		wantFailures     int32
+		wantHalted       bool
+		wantUnrestricted bool
+		wantProbed       bool
+		wantRequeue      bool
+		wantRolledBack   bool
+	}{
+		"progressing": {
+			rollout:   &updatev1alpha1.CanaryRollout{ScalingGroups: []string{"canary"}},
+			groups:    nodeGroups{Outdated: []corev1.Node{canaryTestNode("node", "worker-group", "image-1", true)}},
+			wantPhase: updatev1alpha1.CanaryPhaseProgressing,
+		},
+		"canary nodes replaced": {
+			rollout: &updatev1alpha1.CanaryRollout{
+				ScalingGroups:        []string{"canary"},
+				VerificationDuration: metav1.Duration{Duration: time.Hour},
+			},
+			canary: &updatev1alpha1.CanaryStatus{
+				ImageReference: "image-2",
+				Phase:          updatev1alpha1.CanaryPhaseProgressing,
+			},
+			groups:        canaryReplaced,
+			healthChecker: &stubHealthChecker{},
+			wantPhase:     updatev1alpha1.CanaryPhaseVerifying,
+			wantHalted:    true,
+			wantProbed:    true,
+			wantRequeue:   true,
+		},
+		"verified": {
+			rollout: &updatev1alpha1.CanaryRollout{
+				ScalingGroups:        []string{"canary"},
+				VerificationDuration: metav1.Duration{Duration: 30 * time.Minute},
+			},
+			canary:           verifying(&hourAgo, &minuteAgo, 1),
+			groups:           canaryReplaced,
+			healthChecker:    &stubHealthChecker{},
+			wantPhase:        updatev1alpha1.CanaryPhaseSucceeded,
+			wantUnrestricted: true,
+			wantProbed:       true,
+		},
+		"probed recently": {
+			rollout: &updatev1alpha1.CanaryRollout{
+				ScalingGroups:        []string{"canary"},
+				VerificationDuration: metav1.Duration{Duration: 30 * time.Minute},
+			},
+			canary:        verifying(&hourAgo, &secondsAgo, 0),
+			groups:        canaryReplaced,
+			healthChecker: &stubHealthChecker{},
+			wantPhase:     updatev1alpha1.CanaryPhaseVerifying,
+			wantHalted:    true,
+			wantRequeue:   true,
+		},
+		"webhook fails": {
+			rollout: &updatev1alpha1.CanaryRollout{
+				ScalingGroups: []string{"canary"},
+				HealthGates:   updatev1alpha1.CanaryHealthGates{Webhook: "http://webhook"},
+			},
+			canary:        verifying(&hourAgo, &minuteAgo, 0),
+			groups:        canaryReplaced,
+			healthChecker: &stubHealthChecker{webhookErr: errors.New("unhealthy")},
+			wantPhase:     updatev1alpha1.CanaryPhaseVerifying,
+			wantFailures:  1,
+			wantHalted:    true,
+			wantProbed:    true,
+			wantRequeue:   true,
+		},
+		"prometheus fails": {
+			rollout: &updatev1alpha1.CanaryRollout{
+				ScalingGroups: []string{"canary"},
+				HealthGates:   updatev1alpha1.CanaryHealthGates{Prometheus: &updatev1alpha1.PrometheusHealthGate{URL: "http://prometheus", Query: "up"}},
+			},
+			canary:        verifying(&hourAgo, &minuteAgo, 0),
+			groups:        canaryReplaced,
+			healthChecker: &stubHealthChecker{prometheusErr: errors.New("unhealthy")},
+			wantPhase:     updatev1alpha1.CanaryPhaseVerifying,
+			wantFailures:  1,
+			wantHalted:    true,
+			wantProbed:    true,
+			wantRequeue:   true,
+		},
+		"canary node not ready": {
+			rollout: &updatev1alpha1.CanaryRollout{ScalingGroups: []string{"canary"}},
+			canary:  verifying(&hourAgo, &minuteAgo, 0),
+			groups: nodeGroups{
+				UpToDate: []corev1.Node{canaryTestNode("node", "worker-group", "image-2", false)},
+			},
+			healthChecker: &stubHealthChecker{},
+			wantPhase:     updatev1alpha1.CanaryPhaseVerifying,
+			wantFailures:  1,
+			wantHalted:    true,
+			wantProbed:    true,
+			wantRequeue:   true,
+		},
+		"attestation pending": {
+			rollout: &updatev1alpha1.CanaryRollout{
+				ScalingGroups: []string{"canary"},
+				HealthGates:   updatev1alpha1.CanaryHealthGates{Attestation: true},
+			},
+			canary:        verifying(&hourAgo, &minuteAgo, 1),
+			groups:        canaryReplaced,
+			healthChecker: &stubHealthChecker{},
+			wantPhase:     updatev1alpha1.CanaryPhaseVerifying,
+			wantFailures:  1,
+			wantHalted:    true,
+			wantProbed:    true,
+			wantRequeue:   true,
+		},
+		"attestation passing": {
+			rollout: &updatev1alpha1.CanaryRollout{
+				ScalingGroups: []string{"canary"},
+				HealthGates:   updatev1alpha1.CanaryHealthGates{Attestation: true},
+			},
+			canary: verifying(&hourAgo, &minuteAgo, 1),
+			groups: canaryReplaced,
+			objects: []runtime.Object{
+				&updatev1alpha1.NodeAttestation{
+					ObjectMeta: metav1.ObjectMeta{Name: "node"},
+					Status:     updatev1alpha1.NodeAttestationStatus{Phase: updatev1alpha1.NodeAttestationPhasePassing},
+				},
+			},
+			healthChecker:    &stubHealthChecker{},
+			wantPhase:        updatev1alpha1.CanaryPhaseSucceeded,
+			wantUnrestricted: true,
+			wantProbed:       true,
+		},
+		"attestation failing rolls back": {
+			rollout: &updatev1alpha1.CanaryRollout{
+				ScalingGroups:    []string{"canary"},
+				FailureThreshold: 2,
+				HealthGates:      updatev1alpha1.CanaryHealthGates{Attestation: true},
+			},
+			canary: verifying(&hourAgo, &minuteAgo, 1),
+			groups: canaryReplaced,
+			objects: []runtime.Object{
+				&updatev1alpha1.NodeAttestation{
+					ObjectMeta: metav1.ObjectMeta{Name: "node"},
+					Status:     updatev1alpha1.NodeAttestationStatus{Phase: updatev1alpha1.NodeAttestationPhaseFailing},
+				},
+			},
+			healthChecker:  &stubHealthChecker{},
+			wantPhase:      updatev1alpha1.CanaryPhaseFailed,
+			wantFailures:   2,
+			wantHalted:     true,
+			wantProbed:     true,
+			wantRolledBack: true,
+		},
+		"failure without previous image halts": {
+			rollout: &updatev1alpha1.CanaryRollout{
+				ScalingGroups: []string{"canary"},
+				HealthGates:   updatev1alpha1.CanaryHealthGates{Webhook: "http://webhook"},
+			},
+			canary: func() *updatev1alpha1.CanaryStatus {
+				canary := verifying(&hourAgo, &minuteAgo, 2)
+				canary.PreviousImageReference = ""
+				canary.PreviousImageVersion = ""
+				return canary
+			}(),
+			groups:        canaryReplaced,
+			healthChecker: &stubHealthChecker{webhookErr: errors.New("unhealthy")},
+			wantPhase:     updatev1alpha1.CanaryPhaseFailed,
+			wantFailures:  3,
+			wantHalted:    true,
+			wantProbed:    true,
+		},
+		"failed canary halts rollout": {
+			rollout: &updatev1alpha1.CanaryRollout{ScalingGroups: []string{"canary"}},
+			canary: &updatev1alpha1.CanaryStatus{
+				ImageReference:     "image-2",
+				Phase:              updatev1alpha1.CanaryPhaseFailed,
+				ObservedGeneration: 2,
+			},
+			groups:     canaryReplaced,
+			wantPhase:  updatev1alpha1.CanaryPhaseFailed,
+			wantHalted: true,
+		},
+		"succeeded canary": {
+			rollout: &updatev1alpha1.CanaryRollout{ScalingGroups: []string{"canary"}},
+			canary: &updatev1alpha1.CanaryStatus{
+				ImageReference: "image-2",
+				Phase:          updatev1alpha1.CanaryPhaseSucceeded,
+			},
+			groups:           canaryReplaced,
+			wantPhase:        updatev1alpha1.CanaryPhaseSucceeded,
+			wantUnrestricted: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			nodeVersion := canaryTestNodeVersion(tc.rollout, tc.canary)
+			reconciler := NodeVersionReconciler{
+				healthChecker: tc.healthChecker,
+				Client: &stubReadWriterClient{
+					stubReaderClient: *newStubReaderClient(t, append(tc.objects, &nodeVersion), nil, nil),
+				},
+				Scheme: getScheme(t),
+			}
+			result, err := reconciler.reconcileCanary(t.Context(), &nodeVersion, tc.groups, nil, scalingGroupByID, now)
+			require.NoError(err)
+			require.NotNil(result.status)
+			assert.Equal(tc.wantPhase, result.status.Phase)
+			assert.Equal(tc.wantFailures, result.status.ConsecutiveFailures)
+			assert.Equal(tc.wantRolledBack, result.rolledBack)
+			assert.Equal(tc.wantRequeue, result.requeueAfter > 0)
+			if tc.wantUnrestricted {
+				assert.Nil(result.stage)
+			}
+			if tc.wantHalted {
+				assert.False(result.stage.allows("worker-group"))
+				assert.False(result.stage.allows("other-group"))
+			}
+			assert.Equal(tc.wantProbed, result.status.LastProbeTime != nil && result.status.LastProbeTime.Time.Equal(now))
+		})
+	}
+}
+
+func canaryTestNodeVersion(rollout *updatev1alpha1.CanaryRollout, canary *updatev1alpha1.CanaryStatus) updatev1alpha1.NodeVersion {
+	return updatev1alpha1.NodeVersion{
+		ObjectMeta: metav1.ObjectMeta{Name: "nodeversion", Generation: 2},
+		Spec: updatev1alpha1.NodeVersionSpec{
+			ImageReference: "image-2",
+			ImageVersion:   "v2",
+			Canary:         rollout,
+		},
+		Status: updatev1alpha1.NodeVersionStatus{
+			RolledOutImageReference: "image-1",
+			RolledOutImageVersion:   "v1",
+			Canary:                  canary,
+		},
+	}
+}
+
+func canaryTestNode(name, scalingGroupID, image string, ready bool) corev1.Node {
+	readyStatus := corev1.ConditionFalse
+	if ready {
+		readyStatus = corev1.ConditionTrue
+	}
+	return corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+			Annotations: map[string]string{
+				scalingGroupAnnotation: scalingGroupID,
+				nodeImageAnnotation:    image,
+			},
+		},
+		Status: corev1.NodeStatus{
+			Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: readyStatus}},
+		},
+	}
+}
+
+type stubHealthChecker struct {
+	webhookErr      error
+	webhookCalls    int
+	prometheusErr   error
+	prometheusCalls int
+}
+
+func (c *stubHealthChecker) CheckWebhook(_ context.Context, _ string, _ healthcheck.WebhookRequest) error {
+	c.webhookCalls++
+	return c.webhookErr
+}
+
+func (c *stubHealthChecker) CheckPrometheus(_ context.Context, _, _ string) error {
+	c.prometheusCalls++
+	return c.prometheusErr
+}
@@ -10,11 +10,11 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
-	"reflect"
 	"strings"
 	"time"
 
 	mainconstants "github.com/edgelesssys/constellation/v2/internal/constants"
+	"github.com/edgelesssys/constellation/v2/internal/nodegroup"
 	"github.com/edgelesssys/constellation/v2/internal/versions/components"
 	nodeutil "github.com/edgelesssys/constellation/v2/operators/constellation-node-operator/internal/node"
 	"github.com/edgelesssys/constellation/v2/operators/constellation-node-operator/internal/patch"
@@ -32,6 +32,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 
 	nodemaintenancev1beta1 "github.com/edgelesssys/constellation/v2/3rdparty/node-maintenance-operator/api/v1beta1"
 	updatev1alpha1 "github.com/edgelesssys/constellation/v2/operators/constellation-node-operator/api/v1alpha1"
@@ -48,7 +49,7 @@ const (
 	heirAnnotation                       = "constellation.edgeless.systems/heir"
 	scalingGroupAnnotation               = "constellation.edgeless.systems/scaling-group-id"
 	nodeImageAnnotation                  = "constellation.edgeless.systems/node-image"
-	obsoleteAnnotation                   = "constellation.edgeless.systems/obsolete"
+	obsoleteAnnotation                   = mainconstants.NodeObsoleteAnnotationKey
 	conditionNodeVersionUpToDateReason   = "NodeVersionsUpToDate"
 	conditionNodeVersionUpToDateMessage  = "Node version of every node is up to date"
 	conditionNodeVersionOutOfDateReason  = "NodeVersionsOutOfDate"
@@ -61,17 +62,19 @@ type NodeVersionReconciler struct {
 	etcdRemover
 	clusterUpgrader
 	kubernetesServerVersionGetter
+	healthChecker
 	client.Client
 	Scheme *runtime.Scheme
 }
 
 // NewNodeVersionReconciler creates a new NodeVersionReconciler.
-func NewNodeVersionReconciler(nodeReplacer nodeReplacer, etcdRemover etcdRemover, clusterUpgrader clusterUpgrader, k8sVerGetter kubernetesServerVersionGetter, client client.Client, scheme *runtime.Scheme) *NodeVersionReconciler {
+func NewNodeVersionReconciler(nodeReplacer nodeReplacer, etcdRemover etcdRemover, clusterUpgrader clusterUpgrader, k8sVerGetter kubernetesServerVersionGetter, healthChecker healthChecker, client client.Client, scheme *runtime.Scheme) *NodeVersionReconciler {
 	return &NodeVersionReconciler{
 		nodeReplacer:                  nodeReplacer,
 		etcdRemover:                   etcdRemover,
 		clusterUpgrader:               clusterUpgrader,
 		kubernetesServerVersionGetter: k8sVerGetter,
+		healthChecker:                 healthChecker,
 		Client:                        client,
 		Scheme:                        scheme,
 	}
@@ -80,6 +83,7 @@ func NewNodeVersionReconciler(nodeReplacer nodeReplacer, etcdRemover etcdRemover
 //+kubebuilder:rbac:groups=update.edgeless.systems,resources=nodeversions,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=update.edgeless.systems,resources=nodeversions/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=update.edgeless.systems,resources=nodeversions/finalizers,verbs=update
+//+kubebuilder:rbac:groups=update.edgeless.systems,resources=nodeattestations,verbs=get;list;watch
 //+kubebuilder:rbac:groups=nodemaintenance.medik8s.io,resources=nodemaintenances,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups="",resources=nodes,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups="",resources=nodes/status,verbs=get
@@ -156,25 +160,45 @@ func (r *NodeVersionReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		"obsoleteNodes", len(groups.Obsolete),
 		"invalidNodes", len(invalidNodes))
 
-	// extraNodes are nodes that exist in the scaling group which cannot be used for regular workloads.
-	// consists of nodes that are
-	// - being created (joining)
-	// - being destroyed (leaving)
-	// - heirs to outdated nodes
-	extraNodes := len(groups.Heirs) + len(groups.AwaitingAnnotation) + len(pendingNodeList.Items)
 	// newNodesBudget is the maximum number of new nodes that can be created in this Reconcile call.
-	var newNodesBudget int
-	if extraNodes < nodeOverprovisionLimit {
-		newNodesBudget = nodeOverprovisionLimit - extraNodes
+	newNodesBudget := newSurgeBudget(desiredNodeVersion.Spec, scalingGroupByID, groups, pendingNodeList.Items)
+	// no new node replacements are started while paused or outside of maintenance windows.
+	rolloutBlockedCondition, untilNextWindow := rolloutCondition(desiredNodeVersion.Spec, time.Now())
+	if rolloutBlockedCondition.Status == metav1.ConditionTrue {
+		logr.Info("Node replacements are blocked", "reason", rolloutBlockedCondition.Reason)
+		newNodesBudget = &surgeBudget{}
 	}
-	logr.Info("Budget for new nodes", "newNodesBudget", newNodesBudget)
+	logr.Info("Budget for new nodes", "newNodesBudget", newNodesBudget.total())
 
-	status := nodeVersionStatus(r.Scheme, groups, pendingNodeList.Items, invalidNodes, newNodesBudget)
+	// a canary rollout restricts the creation of new nodes until its health gates pass.
+	canary, err := r.reconcileCanary(ctx, &desiredNodeVersion, groups, pendingNodeList.Items, scalingGroupByID, time.Now())
+	if err != nil {
+		logr.Error(err, "Reconciling canary rollout")
+		return ctrl.Result{}, err
+	}
+	requeueAfter := untilNextWindow
+	if canary.requeueAfter > 0 && (requeueAfter == 0 || canary.requeueAfter < requeueAfter) {
+		requeueAfter = canary.requeueAfter
+	}
+
+	allNodesUpToDate := len(groups.Outdated)+len(groups.Heirs)+len(groups.AwaitingAnnotation)+len(pendingNodeList.Items)+len(groups.Obsolete) == 0
+	status := nodeVersionStatus(r.Scheme, groups, pendingNodeList.Items, invalidNodes, newNodesBudget.total())
+	meta.SetStatusCondition(&status.Conditions, rolloutBlockedCondition)
+	status.Canary = canary.status
+	status.RolledOutImageReference = desiredNodeVersion.Status.RolledOutImageReference
+	status.RolledOutImageVersion = desiredNodeVersion.Status.RolledOutImageVersion
+	if allNodesUpToDate && !canaryActive(canary.status) {
+		status.RolledOutImageReference = desiredNodeVersion.Spec.ImageReference
+		status.RolledOutImageVersion = desiredNodeVersion.Spec.ImageVersion
+	}
 	if err := r.tryUpdateStatus(ctx, req.NamespacedName, status); err != nil {
 		logr.Error(err, "Updating status")
 	}
+	if canary.rolledBack {
+		// the desired image changed, start over with the rolled back NodeVersion.
+		return ctrl.Result{Requeue: true}, nil
+	}
 
-	allNodesUpToDate := len(groups.Outdated)+len(groups.Heirs)+len(groups.AwaitingAnnotation)+len(pendingNodeList.Items)+len(groups.Obsolete) == 0
 	if err := r.ensureAutoscaling(ctx, autoscalingEnabled, allNodesUpToDate); err != nil {
 		logr.Error(err, "Ensure autoscaling", "autoscalingEnabledIs", autoscalingEnabled, "autoscalingEnabledWant", allNodesUpToDate)
 		return ctrl.Result{}, err
@@ -182,11 +206,18 @@ func (r *NodeVersionReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 
 	if allNodesUpToDate {
 		logr.Info("All node versions up to date")
-		return ctrl.Result{}, nil
+		return requeueResult(false, requeueAfter), nil
 	}
 
 	// should requeue is set if a node is deleted
 	var shouldRequeue bool
+	// limit the number of outdated nodes that are drained concurrently
+	var nodeMaintenanceList nodemaintenancev1beta1.NodeMaintenanceList
+	if err := r.List(ctx, &nodeMaintenanceList); err != nil {
+		logr.Error(err, "Unable to list node maintenances")
+		return ctrl.Result{}, err
+	}
+	nodeDrainBudget := newDrainBudget(desiredNodeVersion.Spec, scalingGroupByID, groups.Donors, nodeMaintenanceList.Items)
 	// find pairs of mint nodes and outdated nodes in the same scaling group to become donor & heir
 	replacementPairs := r.pairDonorsAndHeirs(ctx, &desiredNodeVersion, groups.Outdated, groups.Mint)
 	// extend replacement pairs to include existing pairs of donors and heirs
@@ -194,7 +225,7 @@ func (r *NodeVersionReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	// replace donor nodes by heirs
 	for _, pair := range replacementPairs {
 		logr.Info("Replacing node", "donorNode", pair.donor.Name, "heirNode", pair.heir.Name)
-		done, err := r.replaceNode(ctx, &desiredNodeVersion, pair)
+		done, err := r.replaceNode(ctx, &desiredNodeVersion, pair, nodeDrainBudget)
 		if err != nil {
 			logr.Error(err, "Replacing node")
 			return ctrl.Result{}, err
@@ -208,18 +239,8 @@ func (r *NodeVersionReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		}
 	}
 
-	// only create new nodes if the autoscaler is disabled.
-	// otherwise, new nodes will also be created by the autoscaler
-	if autoscalingEnabled {
-		return ctrl.Result{Requeue: shouldRequeue}, nil
-	}
-
-	newNodeConfig := newNodeConfig{desiredNodeVersion, groups.Outdated, groups.Donors, pendingNodeList.Items, scalingGroupByID, newNodesBudget}
-	if err := r.createNewNodes(ctx, newNodeConfig); err != nil {
-		logr.Error(err, "Creating new nodes")
-		return ctrl.Result{Requeue: shouldRequeue}, nil
-	}
-	// cleanup obsolete nodes
+	// cleanup obsolete nodes, e.g. revoked ones.
+	// the autoscaler does not remove them, so this also happens while autoscaling is enabled.
 	for _, node := range groups.Obsolete {
 		done, err := r.deleteNode(ctx, &desiredNodeVersion, node)
 		if err != nil {
@@ -230,7 +251,27 @@ func (r *NodeVersionReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		}
 	}
 
-	return ctrl.Result{Requeue: shouldRequeue}, nil
+	// only create new nodes if the autoscaler is disabled.
+	// otherwise, new nodes will also be created by the autoscaler
+	if autoscalingEnabled {
+		return requeueResult(shouldRequeue, requeueAfter), nil
+	}
+
+	newNodeConfig := newNodeConfig{desiredNodeVersion, groups.Outdated, groups.Donors, pendingNodeList.Items, scalingGroupByID, newNodesBudget, canary.stage}
+	if err := r.createNewNodes(ctx, newNodeConfig); err != nil {
+		logr.Error(err, "Creating new nodes")
+		return requeueResult(shouldRequeue, requeueAfter), nil
+	}
+	return requeueResult(shouldRequeue, requeueAfter), nil
+}
+
+// requeueResult requeues immediately if requested, or otherwise after the given time,
+// e.g. when the next maintenance window opens.
+func requeueResult(shouldRequeue bool, requeueAfter time.Duration) ctrl.Result {
+	if shouldRequeue || requeueAfter == 0 {
+		return ctrl.Result{Requeue: shouldRequeue}
+	}
+	return ctrl.Result{RequeueAfter: requeueAfter}
 }
 
 // SetupWithManager sets up the controller with the Manager.
@@ -250,7 +291,7 @@ func (r *NodeVersionReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		Watches(
 			client.Object(&corev1.Node{}),
 			handler.EnqueueRequestsFromMapFunc(r.findAllNodeVersions),
-			builder.WithPredicates(nodeReadyPredicate()),
+			builder.WithPredicates(predicate.Or(nodeReadyPredicate(), nodeObsoletePredicate())),
 		).
 		Watches(
 			client.Object(&nodemaintenancev1beta1.NodeMaintenance{}),
@@ -515,21 +556,36 @@ func (r *NodeVersionReconciler) ensureAutoscaling(ctx context.Context, autoscali
 // replaceNode take a donor and a heir node and then replaces the donor node by the heir node.
 //
 // Replacing nodes involves the following steps:
-// Labels are copied from the donor node to the heir node.
+// Labels and taints are copied from the donor node to the heir node, unless they're managed by the node group config.
 // Readiness of the heir node is awaited.
-// Deletion of the donor node is scheduled.
-func (r *NodeVersionReconciler) replaceNode(ctx context.Context, controller metav1.Object, pair replacementPair) (bool, error) {
+// Deletion of the donor node is scheduled once the drain budget allows it.
+func (r *NodeVersionReconciler) replaceNode(ctx context.Context, controller metav1.Object, pair replacementPair, drainBudget drainBudget) (bool, error) {
 	logr := log.FromContext(ctx)
-	if !reflect.DeepEqual(nodeutil.FilterLabels(pair.donor.Labels), nodeutil.FilterLabels(pair.heir.Labels)) {
-		if err := r.copyNodeLabels(ctx, pair.donor.Name, pair.heir.Name); err != nil {
+	managed, err := r.nodeGroupManagedKeys(ctx)
+	if err != nil {
+		logr.Error(err, "Getting node group config")
+		return false, err
+	}
+	if labels := managed.copyableLabels(&pair.donor); !hasLabels(&pair.heir, labels) {
+		if err := r.copyNodeLabels(ctx, pair.donor.Name, pair.heir.Name, managed); err != nil {
 			logr.Error(err, "Copy node labels")
 			return false, err
 		}
 	}
+	if len(nodeutil.MissingTaints(&pair.heir, managed.copyableTaints(&pair.donor))) > 0 {
+		if err := r.copyNodeTaints(ctx, pair.donor.Name, pair.heir.Name, managed); err != nil {
+			logr.Error(err, "Copy node taints")
+			return false, err
+		}
+	}
 	heirReady := nodeutil.Ready(&pair.heir)
 	if !heirReady {
 		return false, nil
 	}
+	if !drainBudget.allowDrain(&pair.donor) {
+		logr.Info("Waiting for other outdated nodes to be drained", "donorNode", pair.donor.Name)
+		return false, nil
+	}
 	return r.deleteNode(ctx, controller, pair.donor)
 }
 
@@ -611,7 +667,7 @@ func (r *NodeVersionReconciler) deleteNode(ctx context.Context, controller metav
 // createNewNodes creates new nodes using up to date images as replacement for outdated nodes.
 func (r *NodeVersionReconciler) createNewNodes(ctx context.Context, config newNodeConfig) error {
 	logr := log.FromContext(ctx)
-	if config.newNodesBudget < 1 || len(config.outdatedNodes) == 0 {
+	if config.newNodesBudget.total() < 1 || len(config.outdatedNodes) == 0 {
 		return nil
 	}
 	// We need to look at both the outdated nodes *and* the nodes that have already
@@ -665,9 +721,18 @@ func (r *NodeVersionReconciler) createNewNodes(ctx context.Context, config newNo
 			logr.Info("There are still outdated control plane nodes which must be replaced first before this worker scaling group is upgraded", "scalingGroup", scalingGroupID)
 			continue
 		}
+		if !config.canaryStage.allows(scalingGroupID) {
+			logr.Info("Scaling group is not part of the current canary stage", "scalingGroup", scalingGroupID)
+			continue
+		}
 		for {
-			if config.newNodesBudget == 0 {
-				return nil
+			if !config.canaryStage.allows(scalingGroupID) {
+				logr.Info("No canary nodes left to create", "scalingGroup", scalingGroupID)
+				break
+			}
+			if config.newNodesBudget.remaining(scalingGroupID) < 1 {
+				logr.Info("No budget left for new nodes in scaling group", "scalingGroup", scalingGroupID)
+				break
 			}
 			if requiredNodesPerScalingGroup[scalingGroupID] == 0 {
 				break
@@ -696,7 +761,8 @@ func (r *NodeVersionReconciler) createNewNodes(ctx context.Context, config newNo
 			}
 			logr.Info("Created new node", "createdNode", nodeName, "scalingGroup", scalingGroupID, "requiredNodes", requiredNodesPerScalingGroup[scalingGroupID])
 			requiredNodesPerScalingGroup[scalingGroupID]--
-			config.newNodesBudget--
+			config.newNodesBudget.consume(scalingGroupID)
+			config.canaryStage.consume()
 		}
 	}
 	return nil
@@ -728,8 +794,89 @@ func (r *NodeVersionReconciler) patchUnsetNodeAnnotations(ctx context.Context, n
 	})
 }
 
+// managedKeys holds the keys of the labels and taints the node group config sets on nodes when they join.
+type managedKeys struct {
+	labels map[string]struct{}
+	taints map[string]struct{}
+}
+
+// nodeGroupManagedKeys returns the keys of the labels and taints managed by the node group config.
+// The settings of the config take precedence over the labels and taints of replaced nodes,
+// so that changes to the config are applied by replacing nodes.
+func (r *NodeVersionReconciler) nodeGroupManagedKeys(ctx context.Context) (managedKeys, error) {
+	managed := managedKeys{labels: map[string]struct{}{}, taints: map[string]struct{}{}}
+	var cm corev1.ConfigMap
+	err := r.Get(ctx, types.NamespacedName{Namespace: mainconstants.ConstellationNamespace, Name: mainconstants.NodeGroupConfigMap}, &cm)
+	if client.IgnoreNotFound(err) != nil {
+		return managedKeys{}, err
+	}
+	config, err := nodegroup.Unmarshal(cm.Data[mainconstants.NodeGroupConfigKey])
+	if err != nil {
+		return managedKeys{}, err
+	}
+	for _, settings := range config {
+		for key := range settings.GetLabels() {
+			managed.labels[key] = struct{}{}
+		}
+		for _, taint := range settings.GetTaints() {
+			managed.taints[taint.GetKey()] = struct{}{}
+		}
+	}
+	return managed, nil
+}
+
+// copyableLabels returns the labels of a node that are copied to its heir.
+func (m managedKeys) copyableLabels(node *corev1.Node) map[string]string {
+	labels := nodeutil.FilterLabels(node.Labels)
+	for key := range labels {
+		if _, ok := m.labels[key]; ok {
+			delete(labels, key)
+		}
+	}
+	return labels
+}
+
+// copyableTaints returns the taints of a node that are copied to its heir.
+func (m managedKeys) copyableTaints(node *corev1.Node) []corev1.Taint {
+	var taints []corev1.Taint
+	for _, taint := range nodeutil.FilterTaints(node.Spec.Taints) {
+		if _, ok := m.taints[taint.Key]; !ok {
+			taints = append(taints, taint)
+		}
+	}
+	return taints
+}
+
+// hasLabels checks if all given labels are set with the same value on a node.
+func hasLabels(node *corev1.Node, labels map[string]string) bool {
+	for key, val := range labels {
+		if existing, ok := node.Labels[key]; !ok || existing != val {
+			return false
+		}
+	}
+	return true
+}
+
 // copyNodeLabels attempts to copy all node labels (except for reserved labels) from one node to another in a retry loop.
-func (r *NodeVersionReconciler) copyNodeLabels(ctx context.Context, oldNodeName, newNodeName string) error {
+func (r *NodeVersionReconciler) copyNodeLabels(ctx context.Context, oldNodeName, newNodeName string, managed managedKeys) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		var oldNode corev1.Node
+		if err := r.Get(ctx, types.NamespacedName{Name: oldNodeName}, &oldNode); err != nil {
+			return err
+		}
+		var newNode corev1.Node
+		if err := r.Get(ctx, types.NamespacedName{Name: newNodeName}, &newNode); err != nil {
+			return err
+		}
+		patchedNode := newNode.DeepCopy()
+		patch := patch.SetLabels(&newNode, patchedNode, managed.copyableLabels(&oldNode))
+		return r.Client.Patch(ctx, patchedNode, patch)
+	})
+}
+
+// copyNodeTaints attempts to copy all node taints (except for reserved taints and taints managed by the node group config)
+// from one node to another in a retry loop.
+func (r *NodeVersionReconciler) copyNodeTaints(ctx context.Context, oldNodeName, newNodeName string, managed managedKeys) error {
 	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
 		var oldNode corev1.Node
 		if err := r.Get(ctx, types.NamespacedName{Name: oldNodeName}, &oldNode); err != nil {
@@ -740,7 +887,7 @@ func (r *NodeVersionReconciler) copyNodeLabels(ctx context.Context, oldNodeName,
 			return err
 		}
 		patchedNode := newNode.DeepCopy()
-		patch := patch.SetLabels(&newNode, patchedNode, nodeutil.FilterLabels(oldNode.GetLabels()))
+		patch := patch.SetTaints(&newNode, patchedNode, managed.copyableTaints(&oldNode))
 		return r.Client.Patch(ctx, patchedNode, patch)
 	})
 }
@@ -957,5 +1104,6 @@ type newNodeConfig struct {
 	donors             []corev1.Node
 	pendingNodes       []updatev1alpha1.PendingNode
 	scalingGroupByID   map[string]updatev1alpha1.ScalingGroup
-	newNodesBudget     int
+	newNodesBudget     *surgeBudget
+	canaryStage        *canaryStage
 }
@@ -15,11 +15,13 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/version"
 
 	mainconstants "github.com/edgelesssys/constellation/v2/internal/constants"
+	"github.com/edgelesssys/constellation/v2/internal/nodegroup"
 	updatev1alpha1 "github.com/edgelesssys/constellation/v2/operators/constellation-node-operator/api/v1alpha1"
 )
 
@@ -137,6 +139,83 @@ func TestAnnotateNodes(t *testing.T) {
 	}
 }
 
+func TestNodeGroupManagedKeys(t *testing.T) {
+	config := nodegroup.Config{
+		"gpu": {
+			Role:   "worker",
+			Labels: map[string]string{"gpu": "true"},
+			Taints: []*nodegroup.Taint{{Key: "gpu", Value: "true", Effect: string(corev1.TaintEffectNoSchedule)}},
+		},
+	}
+	rawConfig, err := config.Marshal()
+	require.NoError(t, err)
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: mainconstants.ConstellationNamespace, Name: mainconstants.NodeGroupConfigMap},
+		Data:       map[string]string{mainconstants.NodeGroupConfigKey: rawConfig},
+	}
+	donor := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: map[string]string{
+				"gpu":                    "false",
+				"custom":                 "value",
+				"kubernetes.io/hostname": "donor",
+			},
+		},
+		Spec: corev1.NodeSpec{
+			Taints: []corev1.Taint{
+				{Key: "gpu", Value: "false", Effect: corev1.TaintEffectNoSchedule},
+				{Key: "custom", Value: "value", Effect: corev1.TaintEffectNoExecute},
+				{Key: "node.kubernetes.io/unschedulable", Effect: corev1.TaintEffectNoSchedule},
+			},
+		},
+	}
+
+	testCases := map[string]struct {
+		objects    []runtime.Object
+		getErr     error
+		wantLabels map[string]string
+		wantTaints []corev1.Taint
+		wantErr    bool
+	}{
+		"managed labels and taints are not copied": {
+			objects:    []runtime.Object{configMap},
+			wantLabels: map[string]string{"custom": "value"},
+			wantTaints: []corev1.Taint{{Key: "custom", Value: "value", Effect: corev1.TaintEffectNoExecute}},
+		},
+		"no node group config": {
+			getErr:     k8serrors.NewNotFound(corev1.Resource("configmaps"), mainconstants.NodeGroupConfigMap),
+			wantLabels: map[string]string{"gpu": "false", "custom": "value"},
+			wantTaints: []corev1.Taint{
+				{Key: "gpu", Value: "false", Effect: corev1.TaintEffectNoSchedule},
+				{Key: "custom", Value: "value", Effect: corev1.TaintEffectNoExecute},
+			},
+		},
+		"error getting node group config": {
+			getErr:  errors.New("error"),
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			reconciler := NodeVersionReconciler{
+				Client: newStubReaderClient(t, tc.objects, tc.getErr, nil),
+			}
+			managed, err := reconciler.nodeGroupManagedKeys(t.Context())
+			if tc.wantErr {
+				assert.Error(err)
+				return
+			}
+			require.NoError(err)
+			assert.Equal(tc.wantLabels, managed.copyableLabels(donor))
+			assert.Equal(tc.wantTaints, managed.copyableTaints(donor))
+		})
+	}
+}
+
 func TestPairDonorsAndHeirs(t *testing.T) {
 	testCases := map[string]struct {
 		outdatedNode corev1.Node
@@ -334,6 +413,7 @@ func TestCreateNewNodes(t *testing.T) {
 		pendingNodes     []updatev1alpha1.PendingNode
 		scalingGroupByID map[string]updatev1alpha1.ScalingGroup
 		budget           int
+		canaryStage      *canaryStage
 		wantCreateCalls  []string
 	}{
 		"no outdated nodes": {
@@ -673,6 +753,87 @@ func TestCreateNewNodes(t *testing.T) {
 			},
 			budget: 1,
 		},
+		"canary stage limits new nodes": {
+			outdatedNodes: []corev1.Node{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "node-1",
+						Annotations: map[string]string{
+							scalingGroupAnnotation: "scaling-group",
+						},
+					},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "node-2",
+						Annotations: map[string]string{
+							scalingGroupAnnotation: "scaling-group",
+						},
+					},
+				},
+			},
+			scalingGroupByID: map[string]updatev1alpha1.ScalingGroup{
+				"scaling-group": {
+					Spec: updatev1alpha1.ScalingGroupSpec{
+						GroupID: "scaling-group",
+					},
+					Status: updatev1alpha1.ScalingGroupStatus{
+						ImageReference: "image",
+					},
+				},
+			},
+			budget:          2,
+			canaryStage:     &canaryStage{remainingNodes: 1},
+			wantCreateCalls: []string{"scaling-group"},
+		},
+		"scaling group outside of canary stage": {
+			outdatedNodes: []corev1.Node{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "node",
+						Annotations: map[string]string{
+							scalingGroupAnnotation: "scaling-group",
+						},
+					},
+				},
+			},
+			scalingGroupByID: map[string]updatev1alpha1.ScalingGroup{
+				"scaling-group": {
+					Spec: updatev1alpha1.ScalingGroupSpec{
+						GroupID: "scaling-group",
+					},
+					Status: updatev1alpha1.ScalingGroupStatus{
+						ImageReference: "image",
+					},
+				},
+			},
+			budget:      1,
+			canaryStage: &canaryStage{scalingGroupIDs: map[string]struct{}{"other-group": {}}, remainingNodes: -1},
+		},
+		"halted canary stage": {
+			outdatedNodes: []corev1.Node{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "node",
+						Annotations: map[string]string{
+							scalingGroupAnnotation: "scaling-group",
+						},
+					},
+				},
+			},
+			scalingGroupByID: map[string]updatev1alpha1.ScalingGroup{
+				"scaling-group": {
+					Spec: updatev1alpha1.ScalingGroupSpec{
+						GroupID: "scaling-group",
+					},
+					Status: updatev1alpha1.ScalingGroupStatus{
+						ImageReference: "image",
+					},
+				},
+			},
+			budget:      1,
+			canaryStage: haltedCanaryStage(),
+		},
 	}
 
 	for name, tc := range testCases {
@@ -692,7 +853,7 @@ func TestCreateNewNodes(t *testing.T) {
 				},
 				Scheme: getScheme(t),
 			}
-			newNodeConfig := newNodeConfig{desiredNodeImage, tc.outdatedNodes, tc.donors, tc.pendingNodes, tc.scalingGroupByID, tc.budget}
+			newNodeConfig := newNodeConfig{desiredNodeImage, tc.outdatedNodes, tc.donors, tc.pendingNodes, tc.scalingGroupByID, &surgeBudget{shared: tc.budget}, tc.canaryStage}
 			err := reconciler.createNewNodes(t.Context(), newNodeConfig)
 			require.NoError(err)
 			assert.Equal(tc.wantCreateCalls, reconciler.nodeReplacer.(*stubNodeReplacerWriter).createCalls)
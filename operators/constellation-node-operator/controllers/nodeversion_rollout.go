@@ -0,0 +1,263 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package controllers
+
+import (
+	"strings"
+	"time"
+
+	nodemaintenancev1beta1 "github.com/edgelesssys/constellation/v2/3rdparty/node-maintenance-operator/api/v1beta1"
+	updatev1alpha1 "github.com/edgelesssys/constellation/v2/operators/constellation-node-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	conditionRolloutPausedReason         = "Paused"
+	conditionRolloutPausedMessage        = "Node replacements are paused"
+	conditionRolloutOutsideWindowReason  = "OutsideMaintenanceWindow"
+	conditionRolloutOutsideWindowMessage = "Node replacements are blocked until the next maintenance window"
+	conditionRolloutAllowedReason        = "RolloutAllowed"
+	conditionRolloutAllowedMessage       = "Node replacements may be started"
+	// maintenanceWindowLookaround is the time span searched for maintenance window starts around a point in time.
+	maintenanceWindowLookaround = 8 * 24 * time.Hour
+)
+
+// rolloutCondition returns the RolloutBlocked condition of a NodeVersion at the given time.
+// If node replacements are blocked by the maintenance windows, the time until the next window opens is returned.
+func rolloutCondition(spec updatev1alpha1.NodeVersionSpec, now time.Time) (metav1.Condition, time.Duration) {
+	condition := metav1.Condition{
+		Type:    updatev1alpha1.ConditionRolloutBlocked,
+		Status:  metav1.ConditionFalse,
+		Reason:  conditionRolloutAllowedReason,
+		Message: conditionRolloutAllowedMessage,
+	}
+	if spec.Paused {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = conditionRolloutPausedReason
+		condition.Message = conditionRolloutPausedMessage
+		return condition, 0
+	}
+	if len(spec.MaintenanceWindows) == 0 {
+		return condition, 0
+	}
+	open, untilNextWindow := inMaintenanceWindow(spec.MaintenanceWindows, now)
+	if !open {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = conditionRolloutOutsideWindowReason
+		condition.Message = conditionRolloutOutsideWindowMessage
+	}
+	return condition, untilNextWindow
+}
+
+// inMaintenanceWindow checks if the given time is inside any of the maintenance windows.
+// If it is not, the time until the next maintenance window opens is returned.
+// Windows with an invalid start time never open.
+func inMaintenanceWindow(windows []updatev1alpha1.MaintenanceWindow, now time.Time) (bool, time.Duration) {
+	now = now.UTC()
+	var untilNextWindow time.Duration
+	for _, window := range windows {
+		startOfDay, err := time.Parse("15:04", window.Start)
+		if err != nil {
+			continue
+		}
+		midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+		for day := -maintenanceWindowLookaround; day <= maintenanceWindowLookaround; day += 24 * time.Hour {
+			start := midnight.Add(day).Add(time.Duration(startOfDay.Hour())*time.Hour + time.Duration(startOfDay.Minute())*time.Minute)
+			if !startsOnDay(window.Days, start.Weekday()) {
+				continue
+			}
+			if !now.Before(start) && now.Before(start.Add(window.Duration.Duration)) {
+				return true, 0
+			}
+			if start.After(now) && (untilNextWindow == 0 || start.Sub(now) < untilNextWindow) {
+				untilNextWindow = start.Sub(now)
+			}
+		}
+	}
+	return false, untilNextWindow
+}
+
+// startsOnDay checks if a maintenance window starting on the given days starts on the weekday.
+func startsOnDay(days []updatev1alpha1.Weekday, weekday time.Weekday) bool {
+	if len(days) == 0 {
+		return true
+	}
+	for _, day := range days {
+		if strings.EqualFold(string(day), weekday.String()) {
+			return true
+		}
+	}
+	return false
+}
+
+// rolloutStrategy returns the rollout strategy that applies to a scaling group.
+// The strategy of the scaling group takes precedence over the strategy of its role.
+func rolloutStrategy(spec updatev1alpha1.NodeVersionSpec, scalingGroup updatev1alpha1.ScalingGroup) updatev1alpha1.RolloutStrategy {
+	var strategy updatev1alpha1.RolloutStrategy
+	switch scalingGroup.Spec.Role {
+	case updatev1alpha1.ControlPlaneRole:
+		if spec.ControlPlaneRolloutStrategy != nil {
+			strategy = *spec.ControlPlaneRolloutStrategy
+		}
+	case updatev1alpha1.WorkerRole:
+		if spec.WorkerRolloutStrategy != nil {
+			strategy = *spec.WorkerRolloutStrategy
+		}
+	}
+	if override := scalingGroup.Spec.RolloutStrategy; override != nil {
+		if override.MaxSurge != nil {
+			strategy.MaxSurge = override.MaxSurge
+		}
+		if override.MaxUnavailable != nil {
+			strategy.MaxUnavailable = override.MaxUnavailable
+		}
+	}
+	return strategy
+}
+
+// surgeBudget is the number of new nodes that can be created as replacements for outdated nodes.
+// Scaling groups with a MaxSurge have their own budget.
+// All other scaling groups share a budget limited by nodeOverprovisionLimit.
+type surgeBudget struct {
+	shared   int
+	perGroup map[string]int
+}
+
+// newSurgeBudget calculates the budget for new nodes given the current extra nodes of the cluster.
+func newSurgeBudget(spec updatev1alpha1.NodeVersionSpec, scalingGroupByID map[string]updatev1alpha1.ScalingGroup,
+	groups nodeGroups, pendingNodes []updatev1alpha1.PendingNode,
+) *surgeBudget {
+	maxSurge := make(map[string]int)
+	for scalingGroupID, scalingGroup := range scalingGroupByID {
+		if strategy := rolloutStrategy(spec, scalingGroup); strategy.MaxSurge != nil {
+			maxSurge[scalingGroupID] = int(*strategy.MaxSurge)
+		}
+	}
+
+	// extraNodes are nodes that exist in the scaling group which cannot be used for regular workloads.
+	// consists of nodes that are
+	// - being created (joining)
+	// - being destroyed (leaving)
+	// - heirs to outdated nodes
+	// Nodes awaiting annotation are not yet assigned to a scaling group and count towards the shared budget.
+	sharedExtraNodes := len(groups.AwaitingAnnotation)
+	extraNodesPerGroup := make(map[string]int)
+	countExtraNode := func(scalingGroupID string) {
+		scalingGroupID = strings.ToLower(scalingGroupID)
+		if _, ok := maxSurge[scalingGroupID]; ok {
+			extraNodesPerGroup[scalingGroupID]++
+		} else {
+			sharedExtraNodes++
+		}
+	}
+	for _, heir := range groups.Heirs {
+		countExtraNode(heir.Annotations[scalingGroupAnnotation])
+	}
+	for _, pendingNode := range pendingNodes {
+		countExtraNode(pendingNode.Spec.ScalingGroupID)
+	}
+
+	budget := &surgeBudget{perGroup: make(map[string]int, len(maxSurge))}
+	if sharedExtraNodes < nodeOverprovisionLimit {
+		budget.shared = nodeOverprovisionLimit - sharedExtraNodes
+	}
+	for scalingGroupID, limit := range maxSurge {
+		budget.perGroup[scalingGroupID] = max(limit-extraNodesPerGroup[scalingGroupID], 0)
+	}
+	return budget
+}
+
+// remaining returns the number of new nodes that can still be created in the scaling group.
+func (b *surgeBudget) remaining(scalingGroupID string) int {
+	if budget, ok := b.perGroup[strings.ToLower(scalingGroupID)]; ok {
+		return budget
+	}
+	return b.shared
+}
+
+// consume uses up the budget for one new node in the scaling group.
+func (b *surgeBudget) consume(scalingGroupID string) {
+	if _, ok := b.perGroup[strings.ToLower(scalingGroupID)]; ok {
+		b.perGroup[strings.ToLower(scalingGroupID)]--
+		return
+	}
+	b.shared--
+}
+
+// total returns the number of new nodes that can be created in all scaling groups.
+func (b *surgeBudget) total() int {
+	total := b.shared
+	for _, budget := range b.perGroup {
+		total += budget
+	}
+	return total
+}
+
+// drainBudget limits the number of outdated nodes that are drained concurrently per scaling group.
+type drainBudget struct {
+	perGroup map[string]int
+	// draining holds the names of nodes with a NodeMaintenance, which are cordoned and drained.
+	draining map[string]struct{}
+}
+
+// newDrainBudget calculates the number of outdated nodes that can still be drained per scaling group.
+// Scaling groups without a MaxUnavailable are not limited.
+// Donors that are cordoned or have a NodeMaintenance are being drained and use up the budget.
+func newDrainBudget(spec updatev1alpha1.NodeVersionSpec, scalingGroupByID map[string]updatev1alpha1.ScalingGroup,
+	donors []corev1.Node, nodeMaintenances []nodemaintenancev1beta1.NodeMaintenance,
+) drainBudget {
+	budget := drainBudget{
+		perGroup: make(map[string]int),
+		draining: make(map[string]struct{}, len(nodeMaintenances)),
+	}
+	for scalingGroupID, scalingGroup := range scalingGroupByID {
+		if strategy := rolloutStrategy(spec, scalingGroup); strategy.MaxUnavailable != nil {
+			budget.perGroup[scalingGroupID] = int(*strategy.MaxUnavailable)
+		}
+	}
+	for _, nodeMaintenance := range nodeMaintenances {
+		budget.draining[nodeMaintenance.Spec.NodeName] = struct{}{}
+	}
+	for _, donor := range donors {
+		if !budget.isDraining(&donor) {
+			continue
+		}
+		scalingGroupID := strings.ToLower(donor.Annotations[scalingGroupAnnotation])
+		if _, ok := budget.perGroup[scalingGroupID]; ok {
+			budget.perGroup[scalingGroupID]--
+		}
+	}
+	return budget
+}
+
+// allowDrain checks if the donor of a replacement pair may be drained and uses up the budget if so.
+// Donors that are already being drained are always allowed to continue.
+func (b drainBudget) allowDrain(donor *corev1.Node) bool {
+	if b.isDraining(donor) {
+		return true
+	}
+	scalingGroupID := strings.ToLower(donor.Annotations[scalingGroupAnnotation])
+	budget, ok := b.perGroup[scalingGroupID]
+	if !ok {
+		return true
+	}
+	if budget <= 0 {
+		return false
+	}
+	b.perGroup[scalingGroupID]--
+	return true
+}
+
+// isDraining checks if a node is cordoned or has a NodeMaintenance.
+func (b drainBudget) isDraining(node *corev1.Node) bool {
+	if node.Spec.Unschedulable {
+		return true
+	}
+	_, ok := b.draining[node.Name]
+	return ok
+}
@@ -0,0 +1,324 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	nodemaintenancev1beta1 "github.com/edgelesssys/constellation/v2/3rdparty/node-maintenance-operator/api/v1beta1"
+	updatev1alpha1 "github.com/edgelesssys/constellation/v2/operators/constellation-node-operator/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRolloutCondition(t *testing.T) {
+	// Wednesday
+	now := time.Date(2024, time.January, 3, 12, 0, 0, 0, time.UTC)
+
+	testCases := map[string]struct {
+		spec                updatev1alpha1.NodeVersionSpec
+		wantStatus          metav1.ConditionStatus
+		wantReason          string
+		wantUntilNextWindow time.Duration
+	}{
+		"no restrictions": {
+			wantStatus: metav1.ConditionFalse,
+			wantReason: conditionRolloutAllowedReason,
+		},
+		"paused": {
+			spec: updatev1alpha1.NodeVersionSpec{
+				Paused: true,
+				MaintenanceWindows: []updatev1alpha1.MaintenanceWindow{
+					{Start: "11:00", Duration: metav1.Duration{Duration: 2 * time.Hour}},
+				},
+			},
+			wantStatus: metav1.ConditionTrue,
+			wantReason: conditionRolloutPausedReason,
+		},
+		"inside maintenance window": {
+			spec: updatev1alpha1.NodeVersionSpec{
+				MaintenanceWindows: []updatev1alpha1.MaintenanceWindow{
+					{Start: "11:00", Duration: metav1.Duration{Duration: 2 * time.Hour}},
+				},
+			},
+			wantStatus: metav1.ConditionFalse,
+			wantReason: conditionRolloutAllowedReason,
+		},
+		"outside maintenance window": {
+			spec: updatev1alpha1.NodeVersionSpec{
+				MaintenanceWindows: []updatev1alpha1.MaintenanceWindow{
+					{Start: "22:00", Duration: metav1.Duration{Duration: 4 * time.Hour}},
+				},
+			},
+			wantStatus:          metav1.ConditionTrue,
+			wantReason:          conditionRolloutOutsideWindowReason,
+			wantUntilNextWindow: 10 * time.Hour,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			condition, untilNextWindow := rolloutCondition(tc.spec, now)
+			assert.Equal(updatev1alpha1.ConditionRolloutBlocked, condition.Type)
+			assert.Equal(tc.wantStatus, condition.Status)
+			assert.Equal(tc.wantReason, condition.Reason)
+			assert.Equal(tc.wantUntilNextWindow, untilNextWindow)
+		})
+	}
+}
+
+func TestInMaintenanceWindow(t *testing.T) {
+	// Wednesday
+	now := time.Date(2024, time.January, 3, 12, 0, 0, 0, time.UTC)
+
+	testCases := map[string]struct {
+		windows             []updatev1alpha1.MaintenanceWindow
+		wantOpen            bool
+		wantUntilNextWindow time.Duration
+	}{
+		"daily window is open": {
+			windows: []updatev1alpha1.MaintenanceWindow{
+				{Start: "12:00", Duration: metav1.Duration{Duration: time.Hour}},
+			},
+			wantOpen: true,
+		},
+		"daily window has ended": {
+			windows: []updatev1alpha1.MaintenanceWindow{
+				{Start: "10:00", Duration: metav1.Duration{Duration: 2 * time.Hour}},
+			},
+			wantUntilNextWindow: 22 * time.Hour,
+		},
+		"window spanning midnight is open": {
+			windows: []updatev1alpha1.MaintenanceWindow{
+				{Days: []updatev1alpha1.Weekday{"Tuesday"}, Start: "23:00", Duration: metav1.Duration{Duration: 14 * time.Hour}},
+			},
+			wantOpen: true,
+		},
+		"window on other day": {
+			windows: []updatev1alpha1.MaintenanceWindow{
+				{Days: []updatev1alpha1.Weekday{"Saturday", "Sunday"}, Start: "02:00", Duration: metav1.Duration{Duration: 4 * time.Hour}},
+			},
+			wantUntilNextWindow: 2*24*time.Hour + 14*time.Hour,
+		},
+		"window on same weekday next week": {
+			windows: []updatev1alpha1.MaintenanceWindow{
+				{Days: []updatev1alpha1.Weekday{"Wednesday"}, Start: "08:00", Duration: metav1.Duration{Duration: time.Hour}},
+			},
+			wantUntilNextWindow: 7*24*time.Hour - 4*time.Hour,
+		},
+		"earliest of multiple windows": {
+			windows: []updatev1alpha1.MaintenanceWindow{
+				{Start: "20:00", Duration: metav1.Duration{Duration: time.Hour}},
+				{Start: "14:30", Duration: metav1.Duration{Duration: time.Hour}},
+			},
+			wantUntilNextWindow: 2*time.Hour + 30*time.Minute,
+		},
+		"invalid window never opens": {
+			windows: []updatev1alpha1.MaintenanceWindow{
+				{Start: "noon", Duration: metav1.Duration{Duration: time.Hour}},
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			open, untilNextWindow := inMaintenanceWindow(tc.windows, now)
+			assert.Equal(tc.wantOpen, open)
+			assert.Equal(tc.wantUntilNextWindow, untilNextWindow)
+		})
+	}
+}
+
+func TestRolloutStrategy(t *testing.T) {
+	one, two, three := int32(1), int32(2), int32(3)
+	spec := updatev1alpha1.NodeVersionSpec{
+		ControlPlaneRolloutStrategy: &updatev1alpha1.RolloutStrategy{MaxSurge: &one},
+		WorkerRolloutStrategy:       &updatev1alpha1.RolloutStrategy{MaxSurge: &two, MaxUnavailable: &one},
+	}
+
+	testCases := map[string]struct {
+		scalingGroup updatev1alpha1.ScalingGroup
+		wantStrategy updatev1alpha1.RolloutStrategy
+	}{
+		"control-plane role": {
+			scalingGroup: updatev1alpha1.ScalingGroup{Spec: updatev1alpha1.ScalingGroupSpec{Role: updatev1alpha1.ControlPlaneRole}},
+			wantStrategy: updatev1alpha1.RolloutStrategy{MaxSurge: &one},
+		},
+		"worker role": {
+			scalingGroup: updatev1alpha1.ScalingGroup{Spec: updatev1alpha1.ScalingGroupSpec{Role: updatev1alpha1.WorkerRole}},
+			wantStrategy: updatev1alpha1.RolloutStrategy{MaxSurge: &two, MaxUnavailable: &one},
+		},
+		"scaling group overrides role": {
+			scalingGroup: updatev1alpha1.ScalingGroup{Spec: updatev1alpha1.ScalingGroupSpec{
+				Role:            updatev1alpha1.WorkerRole,
+				RolloutStrategy: &updatev1alpha1.RolloutStrategy{MaxSurge: &three},
+			}},
+			wantStrategy: updatev1alpha1.RolloutStrategy{MaxSurge: &three, MaxUnavailable: &one},
+		},
+		"unknown role": {
+			scalingGroup: updatev1alpha1.ScalingGroup{},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			assert.Equal(tc.wantStrategy, rolloutStrategy(spec, tc.scalingGroup))
+		})
+	}
+}
+
+func TestSurgeBudget(t *testing.T) {
+	two := int32(2)
+	scalingGroupByID := map[string]updatev1alpha1.ScalingGroup{
+		"control-plane-group": {
+			Spec: updatev1alpha1.ScalingGroupSpec{GroupID: "control-plane-group", Role: updatev1alpha1.ControlPlaneRole},
+		},
+		"worker-group": {
+			Spec: updatev1alpha1.ScalingGroupSpec{GroupID: "worker-group", Role: updatev1alpha1.WorkerRole},
+		},
+	}
+	spec := updatev1alpha1.NodeVersionSpec{
+		WorkerRolloutStrategy: &updatev1alpha1.RolloutStrategy{MaxSurge: &two},
+	}
+
+	testCases := map[string]struct {
+		groups           nodeGroups
+		pendingNodes     []updatev1alpha1.PendingNode
+		wantControlPlane int
+		wantWorker       int
+		wantTotal        int
+	}{
+		"no extra nodes": {
+			wantControlPlane: 1,
+			wantWorker:       2,
+			wantTotal:        3,
+		},
+		"heir in worker group": {
+			groups: nodeGroups{Heirs: []corev1.Node{
+				{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{scalingGroupAnnotation: "Worker-Group"}}},
+			}},
+			wantControlPlane: 1,
+			wantWorker:       1,
+			wantTotal:        2,
+		},
+		"pending node in control-plane group": {
+			pendingNodes: []updatev1alpha1.PendingNode{
+				{Spec: updatev1alpha1.PendingNodeSpec{ScalingGroupID: "control-plane-group"}},
+			},
+			wantWorker: 2,
+			wantTotal:  2,
+		},
+		"node awaiting annotation uses shared budget": {
+			groups:     nodeGroups{AwaitingAnnotation: []corev1.Node{{}}},
+			wantWorker: 2,
+			wantTotal:  2,
+		},
+		"more extra nodes than max surge": {
+			pendingNodes: []updatev1alpha1.PendingNode{
+				{Spec: updatev1alpha1.PendingNodeSpec{ScalingGroupID: "worker-group"}},
+				{Spec: updatev1alpha1.PendingNodeSpec{ScalingGroupID: "worker-group"}},
+				{Spec: updatev1alpha1.PendingNodeSpec{ScalingGroupID: "worker-group"}},
+			},
+			wantControlPlane: 1,
+			wantTotal:        1,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			budget := newSurgeBudget(spec, scalingGroupByID, tc.groups, tc.pendingNodes)
+			assert.Equal(tc.wantControlPlane, budget.remaining("control-plane-group"))
+			assert.Equal(tc.wantWorker, budget.remaining("worker-group"))
+			assert.Equal(tc.wantTotal, budget.total())
+
+			if tc.wantWorker > 0 {
+				budget.consume("worker-group")
+				assert.Equal(tc.wantWorker-1, budget.remaining("worker-group"))
+				assert.Equal(tc.wantControlPlane, budget.remaining("control-plane-group"))
+			}
+		})
+	}
+}
+
+func TestDrainBudget(t *testing.T) {
+	one := int32(1)
+	scalingGroupByID := map[string]updatev1alpha1.ScalingGroup{
+		"limited-group": {
+			Spec: updatev1alpha1.ScalingGroupSpec{
+				GroupID:         "limited-group",
+				Role:            updatev1alpha1.WorkerRole,
+				RolloutStrategy: &updatev1alpha1.RolloutStrategy{MaxUnavailable: &one},
+			},
+		},
+		"unlimited-group": {
+			Spec: updatev1alpha1.ScalingGroupSpec{GroupID: "unlimited-group", Role: updatev1alpha1.WorkerRole},
+		},
+	}
+	donor := func(scalingGroupID string, cordoned bool) corev1.Node {
+		return corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{scalingGroupAnnotation: scalingGroupID}},
+			Spec:       corev1.NodeSpec{Unschedulable: cordoned},
+		}
+	}
+	namedDonor := func(name, scalingGroupID string) corev1.Node {
+		node := donor(scalingGroupID, false)
+		node.Name = name
+		return node
+	}
+
+	testCases := map[string]struct {
+		donors           []corev1.Node
+		nodeMaintenances []nodemaintenancev1beta1.NodeMaintenance
+		drain            []corev1.Node
+		wantAllows       []bool
+	}{
+		"limited group drains one node at a time": {
+			drain:      []corev1.Node{donor("limited-group", false), donor("limited-group", false)},
+			wantAllows: []bool{true, false},
+		},
+		"cordoned donor blocks other donors": {
+			donors:     []corev1.Node{donor("limited-group", true)},
+			drain:      []corev1.Node{donor("limited-group", false), donor("limited-group", true)},
+			wantAllows: []bool{false, true},
+		},
+		"donor with node maintenance blocks other donors": {
+			donors: []corev1.Node{namedDonor("draining", "limited-group")},
+			nodeMaintenances: []nodemaintenancev1beta1.NodeMaintenance{
+				{Spec: nodemaintenancev1beta1.NodeMaintenanceSpec{NodeName: "draining"}},
+			},
+			drain:      []corev1.Node{namedDonor("other", "limited-group"), namedDonor("draining", "limited-group")},
+			wantAllows: []bool{false, true},
+		},
+		"unlimited group": {
+			donors:     []corev1.Node{donor("unlimited-group", true)},
+			drain:      []corev1.Node{donor("unlimited-group", false), donor("unlimited-group", false)},
+			wantAllows: []bool{true, true},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			budget := newDrainBudget(updatev1alpha1.NodeVersionSpec{}, scalingGroupByID, tc.donors, tc.nodeMaintenances)
+			var allows []bool
+			for _, node := range tc.drain {
+				allows = append(allows, budget.allowDrain(&node))
+			}
+			assert.Equal(tc.wantAllows, allows)
+		})
+	}
+}
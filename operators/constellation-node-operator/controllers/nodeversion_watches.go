@@ -74,6 +74,23 @@ func nodeReadyPredicate() predicate.Predicate {
 	}
 }
 
+// nodeObsoletePredicate checks if a node was marked as obsolete, e.g. because it was revoked.
+func nodeObsoletePredicate() predicate.Predicate {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldNode, ok := e.ObjectOld.(*corev1.Node)
+			if !ok {
+				return false
+			}
+			newNode, ok := e.ObjectNew.(*corev1.Node)
+			if !ok {
+				return false
+			}
+			return oldNode.Annotations[obsoleteAnnotation] != "true" && newNode.Annotations[obsoleteAnnotation] == "true"
+		},
+	}
+}
+
 // nodeMaintenanceSucceededPredicate checks if a node maintenance resource switched its status to "maintenance succeeded".
 func nodeMaintenanceSucceededPredicate() predicate.Predicate {
 	return predicate.Funcs{
@@ -179,6 +179,63 @@ func TestNodeReadyPredicate(t *testing.T) {
 	}
 }
 
+func TestNodeObsoletePredicate(t *testing.T) {
+	testCases := map[string]struct {
+		event          event.UpdateEvent
+		wantProcessing bool
+	}{
+		"old object is not a node": {
+			event: event.UpdateEvent{
+				ObjectNew: &corev1.Node{},
+			},
+		},
+		"new object is not a node": {
+			event: event.UpdateEvent{
+				ObjectOld: &corev1.Node{},
+			},
+		},
+		"annotations are unchanged": {
+			event: event.UpdateEvent{
+				ObjectOld: &corev1.Node{},
+				ObjectNew: &corev1.Node{},
+			},
+		},
+		"node was marked as obsolete": {
+			event: event.UpdateEvent{
+				ObjectOld: &corev1.Node{},
+				ObjectNew: &corev1.Node{
+					ObjectMeta: metav1.ObjectMeta{
+						Annotations: map[string]string{obsoleteAnnotation: "true"},
+					},
+				},
+			},
+			wantProcessing: true,
+		},
+		"node was already obsolete": {
+			event: event.UpdateEvent{
+				ObjectOld: &corev1.Node{
+					ObjectMeta: metav1.ObjectMeta{
+						Annotations: map[string]string{obsoleteAnnotation: "true"},
+					},
+				},
+				ObjectNew: &corev1.Node{
+					ObjectMeta: metav1.ObjectMeta{
+						Annotations: map[string]string{obsoleteAnnotation: "true"},
+					},
+				},
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			predicate := nodeObsoletePredicate()
+			assert.Equal(tc.wantProcessing, predicate.Update(tc.event))
+		})
+	}
+}
+
 func TestNodeMaintenanceSucceededPredicate(t *testing.T) {
 	testCases := map[string]struct {
 		event          event.UpdateEvent
@@ -0,0 +1,81 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+// Package attestation attests nodes through the verification service running on each node.
+package attestation
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/edgelesssys/constellation/v2/internal/attestation"
+	"github.com/edgelesssys/constellation/v2/internal/attestation/choose"
+	"github.com/edgelesssys/constellation/v2/internal/config"
+	mainconstants "github.com/edgelesssys/constellation/v2/internal/constants"
+	"github.com/edgelesssys/constellation/v2/internal/crypto"
+	"github.com/edgelesssys/constellation/v2/internal/verify"
+	"github.com/edgelesssys/constellation/v2/verify/verifyproto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// Client attests nodes by requesting an attestation statement from their verification service.
+type Client struct {
+	dialOpts []grpc.DialOption
+	log      attestation.Logger
+}
+
+// NewClient creates a new attestation client.
+func NewClient(log attestation.Logger) *Client {
+	return &Client{
+		dialOpts: []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())},
+		log:      log,
+	}
+}
+
+// Attest requests an attestation statement from the verification service at endpoint and validates it
+// using the given attestation config.
+// An error is only returned if the attestation statement couldn't be retrieved.
+// Failed validations are reported in the verdicts of the returned claims report.
+func (c *Client) Attest(ctx context.Context, endpoint string, attestationCfg config.AttestationCfg) (verify.ClaimsReport, error) {
+	validator, err := choose.Validator(attestationCfg, c.log)
+	if err != nil {
+		return verify.ClaimsReport{}, fmt.Errorf("creating validator: %w", err)
+	}
+	nonce, err := crypto.GenerateRandomBytes(32)
+	if err != nil {
+		return verify.ClaimsReport{}, fmt.Errorf("generating nonce: %w", err)
+	}
+
+	conn, err := grpc.NewClient(endpoint, c.dialOpts...)
+	if err != nil {
+		return verify.ClaimsReport{}, fmt.Errorf("dialing verification service: %w", err)
+	}
+	defer conn.Close()
+	resp, err := verifyproto.NewAPIClient(conn).GetAttestation(ctx, &verifyproto.GetAttestationRequest{Nonce: nonce})
+	if err != nil {
+		return verify.ClaimsReport{}, fmt.Errorf("getting attestation: %w", err)
+	}
+
+	signedData, validationErr := validator.Validate(ctx, resp.Attestation, nonce)
+	if validationErr == nil && !bytes.Equal(signedData, []byte(mainconstants.ConstellationVerifyServiceUserData)) {
+		validationErr = errors.New("signed data in attestation does not match expected user data")
+	}
+
+	now := time.Now()
+	report, err := verify.NewClaimsReport(attestationCfg, resp.Attestation, validationErr, now)
+	if err != nil {
+		// A node returning an attestation statement that can't be parsed is failing, not unreachable.
+		if validationErr == nil {
+			validationErr = err
+		}
+		return verify.NewFailedClaimsReport(attestationCfg, validationErr, now), nil
+	}
+	return report, nil
+}
@@ -0,0 +1,115 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package attestation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/edgelesssys/constellation/v2/internal/atls"
+	"github.com/edgelesssys/constellation/v2/internal/attestation"
+	"github.com/edgelesssys/constellation/v2/internal/config"
+	mainconstants "github.com/edgelesssys/constellation/v2/internal/constants"
+	"github.com/edgelesssys/constellation/v2/internal/grpc/testdialer"
+	"github.com/edgelesssys/constellation/v2/internal/verify"
+	"github.com/edgelesssys/constellation/v2/verify/verifyproto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func TestAttest(t *testing.T) {
+	testCases := map[string]struct {
+		server      *stubVerifyServer
+		wantVerdict verify.Verdict
+		wantErr     bool
+	}{
+		"passing": {
+			server:      &stubVerifyServer{userData: []byte(mainconstants.ConstellationVerifyServiceUserData)},
+			wantVerdict: verify.VerdictPass,
+		},
+		"wrong user data": {
+			server:      &stubVerifyServer{userData: []byte("other")},
+			wantVerdict: verify.VerdictFail,
+		},
+		"wrong nonce": {
+			server:      &stubVerifyServer{userData: []byte(mainconstants.ConstellationVerifyServiceUserData), nonce: []byte("nonce")},
+			wantVerdict: verify.VerdictFail,
+		},
+		"unparsable attestation": {
+			server:      &stubVerifyServer{rawAttestation: []byte("invalid")},
+			wantVerdict: verify.VerdictFail,
+		},
+		"server error": {
+			server:  &stubVerifyServer{err: errors.New("failed")},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			netDialer := testdialer.NewBufconnDialer()
+			endpoint := "192.0.2.1:9090"
+			server := grpc.NewServer()
+			verifyproto.RegisterAPIServer(server, tc.server)
+			go server.Serve(netDialer.GetListener(endpoint))
+			t.Cleanup(server.Stop)
+
+			client := &Client{
+				dialOpts: []grpc.DialOption{
+					grpc.WithTransportCredentials(insecure.NewCredentials()),
+					grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+						return netDialer.DialContext(ctx, "tcp", addr)
+					}),
+				},
+				log: attestation.NOPLogger{},
+			}
+
+			report, err := client.Attest(t.Context(), endpoint, &config.DummyCfg{})
+			if tc.wantErr {
+				assert.Error(err)
+				return
+			}
+			require.NoError(err)
+			assert.Equal(tc.wantVerdict, report.Verdict)
+			assert.Equal(verify.ClaimsSchemaVersion, report.SchemaVersion)
+		})
+	}
+}
+
+type stubVerifyServer struct {
+	userData       []byte
+	nonce          []byte
+	rawAttestation []byte
+	err            error
+	verifyproto.UnimplementedAPIServer
+}
+
+func (s *stubVerifyServer) GetAttestation(_ context.Context, req *verifyproto.GetAttestationRequest) (*verifyproto.GetAttestationResponse, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	if s.rawAttestation != nil {
+		return &verifyproto.GetAttestationResponse{Attestation: s.rawAttestation}, nil
+	}
+	nonce := req.Nonce
+	if s.nonce != nil {
+		nonce = s.nonce
+	}
+	attDoc, err := json.Marshal(atls.FakeAttestationDoc{UserData: s.userData, Nonce: nonce})
+	if err != nil {
+		return nil, err
+	}
+	return &verifyproto.GetAttestationResponse{Attestation: attDoc}, nil
+}
@@ -0,0 +1,36 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package client
+
+import (
+	"context"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/blockstorage/v3/volumes"
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servergroups"
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
+	"github.com/gophercloud/gophercloud/v2/openstack/networking/v2/ports"
+)
+
+type computeAPI interface {
+	ListServers(ctx context.Context, opts servers.ListOpts) ([]servers.Server, error)
+	GetServer(ctx context.Context, id string) (*servers.Server, error)
+	CreateServer(ctx context.Context, opts servers.CreateOpts, hintOpts servers.SchedulerHintOpts) (*servers.Server, error)
+	DeleteServer(ctx context.Context, id string) error
+	UpdateServerMetadata(ctx context.Context, id string, metadata map[string]string) error
+	ListServerGroups(ctx context.Context) ([]servergroups.ServerGroup, error)
+}
+
+type networkAPI interface {
+	ListPorts(ctx context.Context, opts ports.ListOpts) ([]ports.Port, error)
+	CreatePort(ctx context.Context, opts ports.CreateOpts) (*ports.Port, error)
+	DeletePort(ctx context.Context, id string) error
+	ReplaceAllTags(ctx context.Context, portID string, tags []string) error
+}
+
+type volumeAPI interface {
+	GetVolume(ctx context.Context, id string) (*volumes.Volume, error)
+}
@@ -0,0 +1,15 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package client
+
+import "github.com/edgelesssys/constellation/v2/operators/constellation-node-operator/internal/constants"
+
+// AutoscalingCloudProvider returns the cloud-provider name as used by k8s cluster-autoscaler.
+// The cluster-autoscaler has no provider for plain OpenStack servers, so the placeholder for unsupported CSPs is returned.
+func (c *Client) AutoscalingCloudProvider() string {
+	return constants.PlaceholderImageName
+}
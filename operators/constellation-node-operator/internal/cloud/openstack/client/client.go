@@ -0,0 +1,155 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/edgelesssys/constellation/v2/internal/cloud/openstack"
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
+	"github.com/gophercloud/utils/v2/openstack/clientconfig"
+)
+
+const (
+	// microversion is the compute API microversion used by the client.
+	// Server tags require at least 2.26.
+	microversion = "2.42"
+
+	providerIDPrefix = "openstack://"
+	uidTagFormat     = "constellation-uid-%s"
+
+	roleMetadataKey      = "constellation-role"
+	nodeGroupMetadataKey = "constellation-node-group"
+	// imageMetadataKey is the server metadata key storing the image of the scaling group a server belongs to.
+	imageMetadataKey = "constellation-image"
+)
+
+// memberNameRegexp matches the names of scaling group members.
+// Servers created by Terraform are named "<scaling group ID>-<index>", servers created by the operator
+// "<scaling group ID>-op-<index>", so that they never collide with a server Terraform creates later.
+var memberNameRegexp = regexp.MustCompile(`^(.+?)-(op-)?([0-9]+)$`)
+
+// Client is a client for OpenStack clouds.
+// OpenStack has no native scaling groups. A scaling group is the set of servers created by one
+// Terraform instance group, sharing the name prefix "<base name>-<role>-<group uid>".
+type Client struct {
+	compute computeAPI
+	network networkAPI
+	volume  volumeAPI
+	// userData is the user data passed to new servers.
+	userData []byte
+}
+
+// New creates a client with initialized clients.
+// The credentials are read from the user data of the instance the operator is running on.
+func New(ctx context.Context) (*Client, error) {
+	clientOpts, err := openstack.NewClientOpts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting OpenStack credentials: %w", err)
+	}
+	userData, err := openstack.UserData(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting user data: %w", err)
+	}
+
+	computeServiceClient, err := clientconfig.NewServiceClient(ctx, "compute", clientOpts)
+	if err != nil {
+		return nil, fmt.Errorf("creating compute client: %w", err)
+	}
+	computeServiceClient.Microversion = microversion
+	networkServiceClient, err := clientconfig.NewServiceClient(ctx, "network", clientOpts)
+	if err != nil {
+		return nil, fmt.Errorf("creating network client: %w", err)
+	}
+	volumeServiceClient, err := clientconfig.NewServiceClient(ctx, "volume", clientOpts)
+	if err != nil {
+		return nil, fmt.Errorf("creating volume client: %w", err)
+	}
+
+	return &Client{
+		compute:  &computeClient{client: computeServiceClient},
+		network:  &networkClient{client: networkServiceClient},
+		volume:   &volumeClient{client: volumeServiceClient},
+		userData: userData,
+	}, nil
+}
+
+// listMembers returns the servers of a scaling group.
+// Servers created by Terraform come first, followed by the servers created by the operator, each sorted by their index.
+func (c *Client) listMembers(ctx context.Context, scalingGroupID string) ([]member, error) {
+	// the name filter of the compute API is a regular expression
+	list, err := c.compute.ListServers(ctx, servers.ListOpts{
+		Name: "^" + regexp.QuoteMeta(scalingGroupID) + "-(op-)?[0-9]+$",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing servers of scaling group %q: %w", scalingGroupID, err)
+	}
+	var members []member
+	for _, server := range list {
+		groupID, index, operatorNode, ok := splitServerName(server.Name)
+		if !ok || groupID != scalingGroupID {
+			continue
+		}
+		members = append(members, member{server: server, index: index, operatorNode: operatorNode})
+	}
+	slices.SortFunc(members, func(a, b member) int {
+		if a.operatorNode != b.operatorNode {
+			if a.operatorNode {
+				return 1
+			}
+			return -1
+		}
+		return a.index - b.index
+	})
+	return members, nil
+}
+
+// member is a server belonging to a scaling group.
+type member struct {
+	server servers.Server
+	index  int
+	// operatorNode is true if the server was created by the operator instead of Terraform.
+	operatorNode bool
+}
+
+func getServerIDFromProviderID(providerID string) (string, error) {
+	// openstack:///<server id> or openstack://<region>/<server id>
+	rest, ok := strings.CutPrefix(providerID, providerIDPrefix)
+	if !ok {
+		return "", fmt.Errorf("invalid providerID: %s", providerID)
+	}
+	parts := strings.Split(rest, "/")
+	if len(parts) != 2 || parts[1] == "" {
+		return "", fmt.Errorf("invalid providerID: %s", providerID)
+	}
+	return parts[1], nil
+}
+
+// splitServerName splits the name of a scaling group member into the scaling group ID and the member's index.
+// operatorNode reports whether the name is one given to servers created by the operator.
+func splitServerName(name string) (scalingGroupID string, index int, operatorNode, ok bool) {
+	matches := memberNameRegexp.FindStringSubmatch(name)
+	if matches == nil {
+		return "", 0, false, false
+	}
+	index, err := strconv.Atoi(matches[3])
+	if err != nil {
+		return "", 0, false, false
+	}
+	return matches[1], index, matches[2] != "", true
+}
+
+func isNotFoundError(err error) bool {
+	return gophercloud.ResponseCodeIs(err, http.StatusNotFound)
+}
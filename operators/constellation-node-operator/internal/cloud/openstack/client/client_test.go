@@ -0,0 +1,292 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package client
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack/blockstorage/v3/volumes"
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servergroups"
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
+	"github.com/gophercloud/gophercloud/v2/openstack/networking/v2/ports"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetServerIDFromProviderID(t *testing.T) {
+	testCases := map[string]struct {
+		providerID   string
+		wantServerID string
+		wantErr      bool
+	}{
+		"without region": {
+			providerID:   "openstack:///0b2a8b0a-4f8e-4b3a-9c6d-1f2e3d4c5b6a",
+			wantServerID: "0b2a8b0a-4f8e-4b3a-9c6d-1f2e3d4c5b6a",
+		},
+		"with region": {
+			providerID:   "openstack://RegionOne/0b2a8b0a-4f8e-4b3a-9c6d-1f2e3d4c5b6a",
+			wantServerID: "0b2a8b0a-4f8e-4b3a-9c6d-1f2e3d4c5b6a",
+		},
+		"wrong provider": {
+			providerID: "aws:///us-east-2a/i-06888991e7138ed4e",
+			wantErr:    true,
+		},
+		"missing server ID": {
+			providerID: "openstack:///",
+			wantErr:    true,
+		},
+		"too many parts": {
+			providerID: "openstack:///a/b",
+			wantErr:    true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			serverID, err := getServerIDFromProviderID(tc.providerID)
+			if tc.wantErr {
+				assert.Error(err)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(tc.wantServerID, serverID)
+		})
+	}
+}
+
+func TestSplitServerName(t *testing.T) {
+	testCases := map[string]struct {
+		name               string
+		wantScalingGroupID string
+		wantIndex          int
+		wantOperatorNode   bool
+		wantOK             bool
+	}{
+		"control-plane": {
+			name:               "constell-control-plane-1a2b3c4d-0",
+			wantScalingGroupID: "constell-control-plane-1a2b3c4d",
+			wantIndex:          0,
+			wantOK:             true,
+		},
+		"worker with large index": {
+			name:               "constell-worker-1a2b3c4d-12",
+			wantScalingGroupID: "constell-worker-1a2b3c4d",
+			wantIndex:          12,
+			wantOK:             true,
+		},
+		"created by operator": {
+			name:               "constell-worker-1a2b3c4d-op-3",
+			wantScalingGroupID: "constell-worker-1a2b3c4d",
+			wantIndex:          3,
+			wantOperatorNode:   true,
+			wantOK:             true,
+		},
+		"numeric group uid": {
+			name:               "constell-worker-12345678-1",
+			wantScalingGroupID: "constell-worker-12345678",
+			wantIndex:          1,
+			wantOK:             true,
+		},
+		"no index": {
+			name: "constell-worker-1a2b3c4d",
+		},
+		"operator prefix without index": {
+			name: "constell-worker-1a2b3c4d-op-",
+		},
+		"no prefix": {
+			name: "-1",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			scalingGroupID, index, operatorNode, ok := splitServerName(tc.name)
+			assert.Equal(tc.wantOK, ok)
+			assert.Equal(tc.wantScalingGroupID, scalingGroupID)
+			assert.Equal(tc.wantIndex, index)
+			assert.Equal(tc.wantOperatorNode, operatorNode)
+		})
+	}
+}
+
+var errNotFound = gophercloud.ErrUnexpectedResponseCode{Actual: http.StatusNotFound}
+
+type stubCompute struct {
+	servers      []servers.Server
+	serverGroups []servergroups.ServerGroup
+	listErr      error
+	getErr       error
+	createErr    error
+	deleteErr    error
+	updateErr    error
+	groupsErr    error
+
+	listOpts       servers.ListOpts
+	createOpts     servers.CreateOpts
+	hintOpts       servers.SchedulerHintOpts
+	deletedServers []string
+	updatedServers map[string]map[string]string
+}
+
+func (s *stubCompute) ListServers(_ context.Context, opts servers.ListOpts) ([]servers.Server, error) {
+	s.listOpts = opts
+	return s.servers, s.listErr
+}
+
+func (s *stubCompute) GetServer(_ context.Context, id string) (*servers.Server, error) {
+	if s.getErr != nil {
+		return nil, s.getErr
+	}
+	for _, server := range s.servers {
+		if server.ID == id {
+			return &server, nil
+		}
+	}
+	return nil, errNotFound
+}
+
+func (s *stubCompute) CreateServer(_ context.Context, opts servers.CreateOpts, hintOpts servers.SchedulerHintOpts) (*servers.Server, error) {
+	s.createOpts = opts
+	s.hintOpts = hintOpts
+	if s.createErr != nil {
+		return nil, s.createErr
+	}
+	return &servers.Server{ID: "new-server-id", Name: opts.Name}, nil
+}
+
+func (s *stubCompute) DeleteServer(_ context.Context, id string) error {
+	if s.deleteErr != nil {
+		return s.deleteErr
+	}
+	s.deletedServers = append(s.deletedServers, id)
+	return nil
+}
+
+func (s *stubCompute) UpdateServerMetadata(_ context.Context, id string, metadata map[string]string) error {
+	if s.updateErr != nil {
+		return s.updateErr
+	}
+	if s.updatedServers == nil {
+		s.updatedServers = map[string]map[string]string{}
+	}
+	s.updatedServers[id] = metadata
+	return nil
+}
+
+func (s *stubCompute) ListServerGroups(_ context.Context) ([]servergroups.ServerGroup, error) {
+	return s.serverGroups, s.groupsErr
+}
+
+type stubNetwork struct {
+	ports     []ports.Port
+	listErr   error
+	createErr error
+	deleteErr error
+	tagErr    error
+
+	createOpts   ports.CreateOpts
+	deletedPorts []string
+	portTags     map[string][]string
+}
+
+func (s *stubNetwork) ListPorts(_ context.Context, opts ports.ListOpts) ([]ports.Port, error) {
+	if s.listErr != nil {
+		return nil, s.listErr
+	}
+	var result []ports.Port
+	for _, port := range s.ports {
+		if port.DeviceID == opts.DeviceID {
+			result = append(result, port)
+		}
+	}
+	return result, nil
+}
+
+func (s *stubNetwork) CreatePort(_ context.Context, opts ports.CreateOpts) (*ports.Port, error) {
+	s.createOpts = opts
+	if s.createErr != nil {
+		return nil, s.createErr
+	}
+	return &ports.Port{ID: "new-port-id", NetworkID: opts.NetworkID}, nil
+}
+
+func (s *stubNetwork) DeletePort(_ context.Context, id string) error {
+	if s.deleteErr != nil {
+		return s.deleteErr
+	}
+	s.deletedPorts = append(s.deletedPorts, id)
+	return nil
+}
+
+func (s *stubNetwork) ReplaceAllTags(_ context.Context, portID string, tags []string) error {
+	if s.tagErr != nil {
+		return s.tagErr
+	}
+	if s.portTags == nil {
+		s.portTags = map[string][]string{}
+	}
+	s.portTags[portID] = tags
+	return nil
+}
+
+type stubVolume struct {
+	volumes map[string]volumes.Volume
+	getErr  error
+}
+
+func (s *stubVolume) GetVolume(_ context.Context, id string) (*volumes.Volume, error) {
+	if s.getErr != nil {
+		return nil, s.getErr
+	}
+	volume, ok := s.volumes[id]
+	if !ok {
+		return nil, errNotFound
+	}
+	return &volume, nil
+}
+
+// testServer returns a server created by Terraform, booting from a volume.
+func testServer(id, name string) servers.Server {
+	return servers.Server{
+		ID:               id,
+		Name:             name,
+		Status:           "ACTIVE",
+		Flavor:           map[string]any{"id": "flavor-id"},
+		AvailabilityZone: "az-1",
+		Tags:             &[]string{"constellation-uid-uid", "constellation-role-worker", "constellation-node-group-worker_default"},
+		Metadata: map[string]string{
+			"constellation-role":       "worker",
+			"constellation-node-group": "worker_default",
+			"constellation-uid":        "uid",
+		},
+		AttachedVolumes: []servers.AttachedVolume{{ID: id + "-boot"}, {ID: id + "-state"}},
+	}
+}
+
+// testVolumes returns the volumes of servers created by testServer.
+func testVolumes(serverIDs ...string) *stubVolume {
+	stub := &stubVolume{volumes: map[string]volumes.Volume{}}
+	for _, id := range serverIDs {
+		stub.volumes[id+"-boot"] = volumes.Volume{
+			ID:                  id + "-boot",
+			Size:                5,
+			Bootable:            "true",
+			VolumeImageMetadata: map[string]string{"image_id": "image-id"},
+		}
+		stub.volumes[id+"-state"] = volumes.Volume{
+			ID:         id + "-state",
+			Size:       30,
+			Bootable:   "false",
+			VolumeType: "ssd",
+		}
+	}
+	return stub
+}
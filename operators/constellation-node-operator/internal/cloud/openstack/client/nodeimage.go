@@ -0,0 +1,252 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/edgelesssys/constellation/v2/internal/cloud/openstack"
+	"github.com/gophercloud/gophercloud/v2/openstack/blockstorage/v3/volumes"
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
+	"github.com/gophercloud/gophercloud/v2/openstack/networking/v2/ports"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// GetNodeImage returns the image name of the node.
+func (c *Client) GetNodeImage(ctx context.Context, providerID string) (string, error) {
+	serverID, err := getServerIDFromProviderID(providerID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get server ID from providerID: %w", err)
+	}
+	server, err := c.compute.GetServer(ctx, serverID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get server %q: %w", serverID, err)
+	}
+	return c.serverImage(ctx, server)
+}
+
+// GetScalingGroupID returns the scaling group ID of the node.
+func (c *Client) GetScalingGroupID(ctx context.Context, providerID string) (string, error) {
+	serverID, err := getServerIDFromProviderID(providerID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get server ID from providerID: %w", err)
+	}
+	server, err := c.compute.GetServer(ctx, serverID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get server %q: %w", serverID, err)
+	}
+	scalingGroupID, _, _, ok := splitServerName(server.Name)
+	if !ok {
+		return "", fmt.Errorf("server %q is not part of a scaling group", server.Name)
+	}
+	return scalingGroupID, nil
+}
+
+// CreateNode creates a node in the specified scaling group.
+// The new server is a copy of the first server of the group, booting from the image of the group.
+// Terraform does not know about the new server and its port. They are tagged with [openstack.OperatorNodeTag]
+// and named outside of the names Terraform uses, so the CLI can find and delete them when terminating the cluster.
+func (c *Client) CreateNode(ctx context.Context, scalingGroupID string) (nodeName, providerID string, err error) {
+	members, err := c.listMembers(ctx, scalingGroupID)
+	if err != nil {
+		return "", "", err
+	}
+	if len(members) == 0 {
+		return "", "", fmt.Errorf("scaling group %q has no servers to use as template", scalingGroupID)
+	}
+	template := members[0].server
+	name := fmt.Sprintf("%s-op-%d", scalingGroupID, nextOperatorIndex(members))
+
+	image, err := c.scalingGroupImage(ctx, members)
+	if err != nil {
+		return "", "", err
+	}
+	flavorID, ok := template.Flavor["id"].(string)
+	if !ok || flavorID == "" {
+		return "", "", fmt.Errorf("template server %q has no flavor ID", template.Name)
+	}
+	bootVolume, stateVolume, err := c.serverVolumes(ctx, &template)
+	if err != nil {
+		return "", "", err
+	}
+	templatePorts, err := c.network.ListPorts(ctx, ports.ListOpts{DeviceID: template.ID})
+	if err != nil {
+		return "", "", fmt.Errorf("listing ports of template server %q: %w", template.Name, err)
+	}
+	if len(templatePorts) == 0 {
+		return "", "", fmt.Errorf("template server %q has no network ports", template.Name)
+	}
+	hintOpts := c.schedulerHints(ctx, template.ID)
+
+	// Like the instance groups created by Terraform, every server has its own port in the cluster subnet.
+	var fixedIPs []ports.IP
+	for _, ip := range templatePorts[0].FixedIPs {
+		fixedIPs = append(fixedIPs, ports.IP{SubnetID: ip.SubnetID})
+	}
+	var tags []string
+	if template.Tags != nil {
+		tags = slices.Clone(*template.Tags)
+	}
+	if !slices.Contains(tags, openstack.OperatorNodeTag) {
+		tags = append(tags, openstack.OperatorNodeTag)
+	}
+
+	port, err := c.network.CreatePort(ctx, ports.CreateOpts{
+		Name:           name,
+		NetworkID:      templatePorts[0].NetworkID,
+		AdminStateUp:   toPtr(true),
+		FixedIPs:       fixedIPs,
+		SecurityGroups: &templatePorts[0].SecurityGroups,
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("creating port for server %q: %w", name, err)
+	}
+	defer func() {
+		if err != nil {
+			err = errors.Join(err, c.network.DeletePort(ctx, port.ID))
+		}
+	}()
+	if err := c.network.ReplaceAllTags(ctx, port.ID, tags); err != nil {
+		return "", "", fmt.Errorf("tagging port of server %q: %w", name, err)
+	}
+
+	metadata := maps.Clone(template.Metadata)
+	if metadata == nil {
+		metadata = map[string]string{}
+	}
+	metadata[imageMetadataKey] = image
+
+	server, err := c.compute.CreateServer(ctx, servers.CreateOpts{
+		Name:             name,
+		FlavorRef:        flavorID,
+		Networks:         []servers.Network{{Port: port.ID}},
+		Metadata:         metadata,
+		Tags:             tags,
+		UserData:         c.userData,
+		AvailabilityZone: template.AvailabilityZone,
+		BlockDevice: []servers.BlockDevice{
+			{
+				SourceType:          servers.SourceImage,
+				DestinationType:     servers.DestinationVolume,
+				UUID:                image,
+				VolumeSize:          bootVolume.Size,
+				BootIndex:           0,
+				DeleteOnTermination: true,
+			},
+			{
+				SourceType:          servers.SourceBlank,
+				DestinationType:     servers.DestinationVolume,
+				VolumeSize:          stateVolume.Size,
+				VolumeType:          stateVolume.VolumeType,
+				BootIndex:           1,
+				DeleteOnTermination: true,
+			},
+		},
+	}, hintOpts)
+	if err != nil {
+		return "", "", fmt.Errorf("creating server %q: %w", name, err)
+	}
+	return name, providerIDPrefix + "/" + server.ID, nil
+}
+
+// DeleteNode deletes a node from the specified scaling group.
+func (c *Client) DeleteNode(ctx context.Context, providerID string) error {
+	serverID, err := getServerIDFromProviderID(providerID)
+	if err != nil {
+		return fmt.Errorf("failed to get server ID from providerID: %w", err)
+	}
+	serverPorts, err := c.network.ListPorts(ctx, ports.ListOpts{DeviceID: serverID})
+	if err != nil {
+		return fmt.Errorf("listing ports of server %q: %w", serverID, err)
+	}
+	if err := c.compute.DeleteServer(ctx, serverID); err != nil && !isNotFoundError(err) {
+		return fmt.Errorf("failed to delete server %q: %w", serverID, err)
+	}
+	// ports created before the server are not deleted together with it
+	for _, port := range serverPorts {
+		if err := c.network.DeletePort(ctx, port.ID); err != nil && !isNotFoundError(err) {
+			return fmt.Errorf("failed to delete port %q: %w", port.ID, err)
+		}
+	}
+	return nil
+}
+
+// nextOperatorIndex returns the index of the next server created by the operator.
+func nextOperatorIndex(members []member) int {
+	next := 0
+	for _, m := range members {
+		if m.operatorNode && m.index >= next {
+			next = m.index + 1
+		}
+	}
+	return next
+}
+
+// serverImage returns the ID of the image the server booted from.
+func (c *Client) serverImage(ctx context.Context, server *servers.Server) (string, error) {
+	if imageID, ok := server.Image["id"].(string); ok && imageID != "" {
+		return imageID, nil
+	}
+	// servers booting from a volume have no image set
+	bootVolume, _, err := c.serverVolumes(ctx, server)
+	if err != nil {
+		return "", err
+	}
+	imageID := bootVolume.VolumeImageMetadata["image_id"]
+	if imageID == "" {
+		return "", fmt.Errorf("boot volume of server %q has no image", server.Name)
+	}
+	return imageID, nil
+}
+
+// serverVolumes returns the boot volume and the state disk volume of a server.
+func (c *Client) serverVolumes(ctx context.Context, server *servers.Server) (bootVolume, stateVolume *volumes.Volume, err error) {
+	for _, attached := range server.AttachedVolumes {
+		volume, err := c.volume.GetVolume(ctx, attached.ID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("getting volume %q of server %q: %w", attached.ID, server.Name, err)
+		}
+		if volume.Bootable == "true" && bootVolume == nil {
+			bootVolume = volume
+		} else if stateVolume == nil {
+			stateVolume = volume
+		}
+	}
+	if bootVolume == nil {
+		return nil, nil, fmt.Errorf("server %q has no boot volume", server.Name)
+	}
+	if stateVolume == nil {
+		return nil, nil, fmt.Errorf("server %q has no state volume", server.Name)
+	}
+	return bootVolume, stateVolume, nil
+}
+
+// schedulerHints returns the scheduler hints to place a new server in the same server group as the template server.
+// Server groups are optional, so errors listing them are only logged.
+func (c *Client) schedulerHints(ctx context.Context, templateID string) servers.SchedulerHintOpts {
+	groups, err := c.compute.ListServerGroups(ctx)
+	if err != nil {
+		log.FromContext(ctx).Info("Unable to list server groups, creating server without server group", "error", err)
+		return servers.SchedulerHintOpts{}
+	}
+	for _, group := range groups {
+		for _, member := range group.Members {
+			if member == templateID {
+				return servers.SchedulerHintOpts{Group: group.ID}
+			}
+		}
+	}
+	return servers.SchedulerHintOpts{}
+}
+
+func toPtr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,397 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package client
+
+import (
+	"testing"
+
+	"github.com/edgelesssys/constellation/v2/internal/cloud/openstack"
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servergroups"
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
+	"github.com/gophercloud/gophercloud/v2/openstack/networking/v2/ports"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetNodeImage(t *testing.T) {
+	testCases := map[string]struct {
+		providerID string
+		compute    *stubCompute
+		volume     *stubVolume
+		wantImage  string
+		wantErr    bool
+	}{
+		"image of boot volume": {
+			providerID: "openstack:///server-0",
+			compute:    &stubCompute{servers: []servers.Server{testServer("server-0", "constell-worker-1a2b3c4d-0")}},
+			volume:     testVolumes("server-0"),
+			wantImage:  "image-id",
+		},
+		"image of server booting from image": {
+			providerID: "openstack:///server-0",
+			compute: &stubCompute{servers: []servers.Server{{
+				ID:    "server-0",
+				Image: map[string]any{"id": "other-image-id"},
+			}}},
+			volume:    &stubVolume{},
+			wantImage: "other-image-id",
+		},
+		"boot volume without image": {
+			providerID: "openstack:///server-0",
+			compute:    &stubCompute{servers: []servers.Server{testServer("server-0", "constell-worker-1a2b3c4d-0")}},
+			volume: func() *stubVolume {
+				stub := testVolumes("server-0")
+				boot := stub.volumes["server-0-boot"]
+				boot.VolumeImageMetadata = nil
+				stub.volumes["server-0-boot"] = boot
+				return stub
+			}(),
+			wantErr: true,
+		},
+		"server not found": {
+			providerID: "openstack:///server-0",
+			compute:    &stubCompute{},
+			volume:     &stubVolume{},
+			wantErr:    true,
+		},
+		"invalid provider ID": {
+			providerID: "server-0",
+			compute:    &stubCompute{},
+			volume:     &stubVolume{},
+			wantErr:    true,
+		},
+		"getting volume fails": {
+			providerID: "openstack:///server-0",
+			compute:    &stubCompute{servers: []servers.Server{testServer("server-0", "constell-worker-1a2b3c4d-0")}},
+			volume:     &stubVolume{getErr: assert.AnError},
+			wantErr:    true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			client := &Client{compute: tc.compute, volume: tc.volume}
+
+			image, err := client.GetNodeImage(t.Context(), tc.providerID)
+			if tc.wantErr {
+				assert.Error(err)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(tc.wantImage, image)
+		})
+	}
+}
+
+func TestGetScalingGroupID(t *testing.T) {
+	testCases := map[string]struct {
+		providerID string
+		compute    *stubCompute
+		wantID     string
+		wantErr    bool
+	}{
+		"getting scaling group ID works": {
+			providerID: "openstack:///server-0",
+			compute:    &stubCompute{servers: []servers.Server{testServer("server-0", "constell-worker-1a2b3c4d-3")}},
+			wantID:     "constell-worker-1a2b3c4d",
+		},
+		"server not part of a scaling group": {
+			providerID: "openstack:///server-0",
+			compute:    &stubCompute{servers: []servers.Server{testServer("server-0", "bastion")}},
+			wantErr:    true,
+		},
+		"getting server fails": {
+			providerID: "openstack:///server-0",
+			compute:    &stubCompute{getErr: assert.AnError},
+			wantErr:    true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			client := &Client{compute: tc.compute}
+
+			id, err := client.GetScalingGroupID(t.Context(), tc.providerID)
+			if tc.wantErr {
+				assert.Error(err)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(tc.wantID, id)
+		})
+	}
+}
+
+func TestCreateNode(t *testing.T) {
+	templatePort := ports.Port{
+		ID:             "port-0",
+		DeviceID:       "server-0",
+		NetworkID:      "network-id",
+		FixedIPs:       []ports.IP{{SubnetID: "subnet-id", IPAddress: "192.168.178.10"}},
+		SecurityGroups: []string{"security-group-id"},
+	}
+	withImageMetadata := testServer("server-0", "constell-worker-1a2b3c4d-0")
+	withImageMetadata.Metadata["constellation-image"] = "new-image-id"
+
+	testCases := map[string]struct {
+		compute        *stubCompute
+		network        *stubNetwork
+		volume         *stubVolume
+		wantName       string
+		wantImage      string
+		wantGroupHint  string
+		wantPortDelete bool
+		wantErr        bool
+	}{
+		"creating node works": {
+			compute: &stubCompute{servers: []servers.Server{
+				testServer("server-2", "constell-worker-1a2b3c4d-2"),
+				testServer("server-0", "constell-worker-1a2b3c4d-0"),
+				testServer("other", "constell-control-plane-5e6f7a8b-7"),
+			}},
+			network:   &stubNetwork{ports: []ports.Port{templatePort}},
+			volume:    testVolumes("server-0"),
+			wantName:  "constell-worker-1a2b3c4d-op-0",
+			wantImage: "image-id",
+		},
+		"index continues after operator nodes": {
+			compute: &stubCompute{servers: []servers.Server{
+				testServer("server-3", "constell-worker-1a2b3c4d-op-4"),
+				testServer("server-0", "constell-worker-1a2b3c4d-0"),
+				testServer("server-1", "constell-worker-1a2b3c4d-op-1"),
+			}},
+			network:   &stubNetwork{ports: []ports.Port{templatePort}},
+			volume:    testVolumes("server-0"),
+			wantName:  "constell-worker-1a2b3c4d-op-5",
+			wantImage: "image-id",
+		},
+		"image from metadata": {
+			compute:   &stubCompute{servers: []servers.Server{withImageMetadata}},
+			network:   &stubNetwork{ports: []ports.Port{templatePort}},
+			volume:    testVolumes("server-0"),
+			wantName:  "constell-worker-1a2b3c4d-op-0",
+			wantImage: "new-image-id",
+		},
+		"server group of template is used": {
+			compute: &stubCompute{
+				servers: []servers.Server{testServer("server-0", "constell-worker-1a2b3c4d-0")},
+				serverGroups: []servergroups.ServerGroup{
+					{ID: "other-group", Members: []string{"other"}},
+					{ID: "group-id", Members: []string{"server-1", "server-0"}},
+				},
+			},
+			network:       &stubNetwork{ports: []ports.Port{templatePort}},
+			volume:        testVolumes("server-0"),
+			wantName:      "constell-worker-1a2b3c4d-op-0",
+			wantImage:     "image-id",
+			wantGroupHint: "group-id",
+		},
+		"server groups not available": {
+			compute: &stubCompute{
+				servers:   []servers.Server{testServer("server-0", "constell-worker-1a2b3c4d-0")},
+				groupsErr: assert.AnError,
+			},
+			network:   &stubNetwork{ports: []ports.Port{templatePort}},
+			volume:    testVolumes("server-0"),
+			wantName:  "constell-worker-1a2b3c4d-op-0",
+			wantImage: "image-id",
+		},
+		"empty scaling group": {
+			compute: &stubCompute{},
+			network: &stubNetwork{},
+			volume:  &stubVolume{},
+			wantErr: true,
+		},
+		"listing servers fails": {
+			compute: &stubCompute{listErr: assert.AnError},
+			network: &stubNetwork{},
+			volume:  &stubVolume{},
+			wantErr: true,
+		},
+		"template without ports": {
+			compute: &stubCompute{servers: []servers.Server{testServer("server-0", "constell-worker-1a2b3c4d-0")}},
+			network: &stubNetwork{},
+			volume:  testVolumes("server-0"),
+			wantErr: true,
+		},
+		"template without state volume": {
+			compute: &stubCompute{servers: []servers.Server{testServer("server-0", "constell-worker-1a2b3c4d-0")}},
+			network: &stubNetwork{ports: []ports.Port{templatePort}},
+			volume: func() *stubVolume {
+				stub := testVolumes("server-0")
+				delete(stub.volumes, "server-0-state")
+				return stub
+			}(),
+			wantErr: true,
+		},
+		"creating port fails": {
+			compute: &stubCompute{servers: []servers.Server{testServer("server-0", "constell-worker-1a2b3c4d-0")}},
+			network: &stubNetwork{ports: []ports.Port{templatePort}, createErr: assert.AnError},
+			volume:  testVolumes("server-0"),
+			wantErr: true,
+		},
+		"tagging port fails": {
+			compute:        &stubCompute{servers: []servers.Server{testServer("server-0", "constell-worker-1a2b3c4d-0")}},
+			network:        &stubNetwork{ports: []ports.Port{templatePort}, tagErr: assert.AnError},
+			volume:         testVolumes("server-0"),
+			wantPortDelete: true,
+			wantErr:        true,
+		},
+		"creating server fails": {
+			compute: &stubCompute{
+				servers:   []servers.Server{testServer("server-0", "constell-worker-1a2b3c4d-0")},
+				createErr: assert.AnError,
+			},
+			network:        &stubNetwork{ports: []ports.Port{templatePort}},
+			volume:         testVolumes("server-0"),
+			wantPortDelete: true,
+			wantErr:        true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+			client := &Client{compute: tc.compute, network: tc.network, volume: tc.volume, userData: []byte("user-data")}
+
+			nodeName, providerID, err := client.CreateNode(t.Context(), "constell-worker-1a2b3c4d")
+			if tc.wantPortDelete {
+				assert.Equal([]string{"new-port-id"}, tc.network.deletedPorts)
+			}
+			if tc.wantErr {
+				assert.Error(err)
+				return
+			}
+			require.NoError(err)
+			assert.Equal(tc.wantName, nodeName)
+			assert.Equal("openstack:///new-server-id", providerID)
+			assert.Empty(tc.network.deletedPorts)
+
+			// new port mirrors the template's port
+			assert.Equal(tc.wantName, tc.network.createOpts.Name)
+			assert.Equal("network-id", tc.network.createOpts.NetworkID)
+			assert.Equal([]ports.IP{{SubnetID: "subnet-id"}}, tc.network.createOpts.FixedIPs)
+			assert.Equal(&[]string{"security-group-id"}, tc.network.createOpts.SecurityGroups)
+
+			// new server mirrors the template server
+			opts := tc.compute.createOpts
+			assert.Equal(tc.wantName, opts.Name)
+			assert.Equal("flavor-id", opts.FlavorRef)
+			assert.Equal([]servers.Network{{Port: "new-port-id"}}, opts.Networks)
+			assert.Equal("az-1", opts.AvailabilityZone)
+			assert.Equal([]byte("user-data"), opts.UserData)
+			wantTags := append(*testServer("", "").Tags, openstack.OperatorNodeTag)
+			assert.Equal(wantTags, opts.Tags)
+			assert.Equal(wantTags, tc.network.portTags["new-port-id"])
+			assert.Equal("worker_default", opts.Metadata["constellation-node-group"])
+			assert.Equal(tc.wantImage, opts.Metadata["constellation-image"])
+			assert.Equal([]servers.BlockDevice{
+				{
+					SourceType:          servers.SourceImage,
+					DestinationType:     servers.DestinationVolume,
+					UUID:                tc.wantImage,
+					VolumeSize:          5,
+					BootIndex:           0,
+					DeleteOnTermination: true,
+				},
+				{
+					SourceType:          servers.SourceBlank,
+					DestinationType:     servers.DestinationVolume,
+					VolumeSize:          30,
+					VolumeType:          "ssd",
+					BootIndex:           1,
+					DeleteOnTermination: true,
+				},
+			}, opts.BlockDevice)
+			assert.Equal(tc.wantGroupHint, tc.compute.hintOpts.Group)
+		})
+	}
+}
+
+func TestDeleteNode(t *testing.T) {
+	serverPort := ports.Port{ID: "port-0", DeviceID: "server-0"}
+
+	testCases := map[string]struct {
+		providerID      string
+		compute         *stubCompute
+		network         *stubNetwork
+		wantDeleted     bool
+		wantPortDeleted bool
+		wantErr         bool
+	}{
+		"deleting node works": {
+			providerID:      "openstack:///server-0",
+			compute:         &stubCompute{},
+			network:         &stubNetwork{ports: []ports.Port{serverPort, {ID: "port-1", DeviceID: "server-1"}}},
+			wantDeleted:     true,
+			wantPortDeleted: true,
+		},
+		"server already deleted": {
+			providerID: "openstack:///server-0",
+			compute:    &stubCompute{deleteErr: errNotFound},
+			network:    &stubNetwork{},
+		},
+		"port already deleted": {
+			providerID:  "openstack:///server-0",
+			compute:     &stubCompute{},
+			network:     &stubNetwork{ports: []ports.Port{serverPort}, deleteErr: errNotFound},
+			wantDeleted: true,
+		},
+		"invalid provider ID": {
+			providerID: "aws:///us-east-2a/i-06888991e7138ed4e",
+			compute:    &stubCompute{},
+			network:    &stubNetwork{},
+			wantErr:    true,
+		},
+		"listing ports fails": {
+			providerID: "openstack:///server-0",
+			compute:    &stubCompute{},
+			network:    &stubNetwork{listErr: assert.AnError},
+			wantErr:    true,
+		},
+		"deleting server fails": {
+			providerID: "openstack:///server-0",
+			compute:    &stubCompute{deleteErr: assert.AnError},
+			network:    &stubNetwork{ports: []ports.Port{serverPort}},
+			wantErr:    true,
+		},
+		"deleting port fails": {
+			providerID:  "openstack:///server-0",
+			compute:     &stubCompute{},
+			network:     &stubNetwork{ports: []ports.Port{serverPort}, deleteErr: assert.AnError},
+			wantDeleted: true,
+			wantErr:     true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			client := &Client{compute: tc.compute, network: tc.network}
+
+			err := client.DeleteNode(t.Context(), tc.providerID)
+			if tc.wantDeleted {
+				assert.Equal([]string{"server-0"}, tc.compute.deletedServers)
+			} else {
+				assert.Empty(tc.compute.deletedServers)
+			}
+			if tc.wantErr {
+				assert.Error(err)
+				return
+			}
+			assert.NoError(err)
+			if tc.wantPortDeleted {
+				assert.Equal([]string{"port-0"}, tc.network.deletedPorts)
+			} else {
+				assert.Empty(tc.network.deletedPorts)
+			}
+		})
+	}
+}
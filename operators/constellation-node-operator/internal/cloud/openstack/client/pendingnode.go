@@ -0,0 +1,54 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package client
+
+import (
+	"context"
+	"fmt"
+
+	updatev1alpha1 "github.com/edgelesssys/constellation/v2/operators/constellation-node-operator/api/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// GetNodeState returns the state of the node.
+func (c *Client) GetNodeState(ctx context.Context, providerID string) (updatev1alpha1.CSPNodeState, error) {
+	logr := log.FromContext(ctx)
+	logr.Info("GetNodeState", "providerID", providerID)
+	serverID, err := getServerIDFromProviderID(providerID)
+	if err != nil {
+		return updatev1alpha1.NodeStateUnknown, fmt.Errorf("failed to get server ID from providerID: %w", err)
+	}
+	server, err := c.compute.GetServer(ctx, serverID)
+	if isNotFoundError(err) {
+		return updatev1alpha1.NodeStateTerminated, nil
+	}
+	if err != nil {
+		return updatev1alpha1.NodeStateUnknown, err
+	}
+	if server.TaskState == "deleting" {
+		return updatev1alpha1.NodeStateTerminating, nil
+	}
+
+	// Translate OpenStack server status to node state.
+	// https://docs.openstack.org/api-guide/compute/server_concepts.html
+	switch server.Status {
+	case "ACTIVE":
+		return updatev1alpha1.NodeStateReady, nil
+	case "BUILD", "REBUILD":
+		return updatev1alpha1.NodeStateCreating, nil
+	case "SHUTOFF", "STOPPED", "PAUSED", "SUSPENDED", "SHELVED", "SHELVED_OFFLOADED":
+		return updatev1alpha1.NodeStateStopped, nil
+	case "DELETED", "SOFT_DELETED":
+		return updatev1alpha1.NodeStateTerminated, nil
+	case "ERROR":
+		return updatev1alpha1.NodeStateFailed, nil
+	case "REBOOT", "HARD_REBOOT", "MIGRATING", "RESIZE", "VERIFY_RESIZE", "REVERT_RESIZE", "PASSWORD", "RESCUE", "UNKNOWN":
+		return updatev1alpha1.NodeStateUnknown, nil
+	default:
+		return updatev1alpha1.NodeStateUnknown, fmt.Errorf("unknown server status %q", server.Status)
+	}
+}
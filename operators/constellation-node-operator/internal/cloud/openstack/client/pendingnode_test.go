@@ -0,0 +1,87 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package client
+
+import (
+	"testing"
+
+	updatev1alpha1 "github.com/edgelesssys/constellation/v2/operators/constellation-node-operator/api/v1alpha1"
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetNodeState(t *testing.T) {
+	testCases := map[string]struct {
+		status    string
+		taskState string
+		getErr    error
+		wantState updatev1alpha1.CSPNodeState
+		wantErr   bool
+	}{
+		"active server is ready": {
+			status:    "ACTIVE",
+			wantState: updatev1alpha1.NodeStateReady,
+		},
+		"server in build is creating": {
+			status:    "BUILD",
+			wantState: updatev1alpha1.NodeStateCreating,
+		},
+		"shut off server is stopped": {
+			status:    "SHUTOFF",
+			wantState: updatev1alpha1.NodeStateStopped,
+		},
+		"deleted server is terminated": {
+			status:    "DELETED",
+			wantState: updatev1alpha1.NodeStateTerminated,
+		},
+		"server being deleted is terminating": {
+			status:    "ACTIVE",
+			taskState: "deleting",
+			wantState: updatev1alpha1.NodeStateTerminating,
+		},
+		"server in error state failed": {
+			status:    "ERROR",
+			wantState: updatev1alpha1.NodeStateFailed,
+		},
+		"rebooting server": {
+			status:    "REBOOT",
+			wantState: updatev1alpha1.NodeStateUnknown,
+		},
+		"server not found is terminated": {
+			getErr:    errNotFound,
+			wantState: updatev1alpha1.NodeStateTerminated,
+		},
+		"unknown status": {
+			status:    "invalid",
+			wantState: updatev1alpha1.NodeStateUnknown,
+			wantErr:   true,
+		},
+		"getting server fails": {
+			getErr:    assert.AnError,
+			wantState: updatev1alpha1.NodeStateUnknown,
+			wantErr:   true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			client := &Client{compute: &stubCompute{
+				servers: []servers.Server{{ID: "server-0", Status: tc.status, TaskState: tc.taskState}},
+				getErr:  tc.getErr,
+			}}
+
+			state, err := client.GetNodeState(t.Context(), "openstack:///server-0")
+			assert.Equal(tc.wantState, state)
+			if tc.wantErr {
+				assert.Error(err)
+				return
+			}
+			assert.NoError(err)
+		})
+	}
+}
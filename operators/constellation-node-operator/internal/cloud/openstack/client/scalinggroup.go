@@ -0,0 +1,127 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/edgelesssys/constellation/v2/internal/constants"
+	updatev1alpha1 "github.com/edgelesssys/constellation/v2/operators/constellation-node-operator/api/v1alpha1"
+	cspapi "github.com/edgelesssys/constellation/v2/operators/constellation-node-operator/internal/cloud/api"
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
+)
+
+// GetScalingGroupImage returns the image ID of the scaling group.
+func (c *Client) GetScalingGroupImage(ctx context.Context, scalingGroupID string) (string, error) {
+	members, err := c.listMembers(ctx, scalingGroupID)
+	if err != nil {
+		return "", err
+	}
+	if len(members) == 0 {
+		return "", fmt.Errorf("scaling group %q has no servers", scalingGroupID)
+	}
+	return c.scalingGroupImage(ctx, members)
+}
+
+// SetScalingGroupImage sets the image ID of the scaling group.
+// The image is stored in the metadata of all servers of the group and used for newly created servers.
+func (c *Client) SetScalingGroupImage(ctx context.Context, scalingGroupID, imageURI string) error {
+	members, err := c.listMembers(ctx, scalingGroupID)
+	if err != nil {
+		return err
+	}
+	if len(members) == 0 {
+		return fmt.Errorf("scaling group %q has no servers", scalingGroupID)
+	}
+	for _, member := range members {
+		if err := c.compute.UpdateServerMetadata(ctx, member.server.ID, map[string]string{imageMetadataKey: imageURI}); err != nil {
+			return fmt.Errorf("setting image of server %q: %w", member.server.Name, err)
+		}
+	}
+	return nil
+}
+
+// GetScalingGroupName retrieves the name of a scaling group.
+// This keeps the casing of the original name, but Kubernetes requires the name to be lowercase,
+// so use strings.ToLower() on the result if using the name in a Kubernetes context.
+func (c *Client) GetScalingGroupName(scalingGroupID string) (string, error) {
+	return strings.ToLower(scalingGroupID), nil
+}
+
+// GetAutoscalingGroupName retrieves the name of a scaling group as needed by the cluster-autoscaler.
+func (c *Client) GetAutoscalingGroupName(scalingGroupID string) (string, error) {
+	return scalingGroupID, nil
+}
+
+// ListScalingGroups retrieves a list of scaling groups for the cluster.
+func (c *Client) ListScalingGroups(ctx context.Context, uid string) ([]cspapi.ScalingGroup, error) {
+	list, err := c.compute.ListServers(ctx, servers.ListOpts{Tags: fmt.Sprintf(uidTagFormat, uid)})
+	if err != nil {
+		return nil, fmt.Errorf("listing servers: %w", err)
+	}
+
+	groups := map[string]servers.Server{}
+	for _, server := range list {
+		scalingGroupID, _, _, ok := splitServerName(server.Name)
+		if !ok {
+			continue
+		}
+		if _, ok := groups[scalingGroupID]; !ok {
+			groups[scalingGroupID] = server
+		}
+	}
+
+	results := []cspapi.ScalingGroup{}
+	for scalingGroupID, server := range groups {
+		role := updatev1alpha1.NodeRoleFromString(server.Metadata[roleMetadataKey])
+		nodeGroupName := server.Metadata[nodeGroupMetadataKey]
+		if nodeGroupName == "" {
+			switch role {
+			case updatev1alpha1.ControlPlaneRole:
+				nodeGroupName = constants.ControlPlaneDefault
+			case updatev1alpha1.WorkerRole:
+				nodeGroupName = constants.WorkerDefault
+			}
+		}
+
+		name, err := c.GetScalingGroupName(scalingGroupID)
+		if err != nil {
+			return nil, fmt.Errorf("getting scaling group name: %w", err)
+		}
+		nodeGroupName, err = c.GetScalingGroupName(nodeGroupName)
+		if err != nil {
+			return nil, fmt.Errorf("getting node group name: %w", err)
+		}
+		autoscalerGroupName, err := c.GetAutoscalingGroupName(scalingGroupID)
+		if err != nil {
+			return nil, fmt.Errorf("getting autoscaler group name: %w", err)
+		}
+		results = append(results, cspapi.ScalingGroup{
+			Name:                 name,
+			NodeGroupName:        nodeGroupName,
+			GroupID:              scalingGroupID,
+			AutoscalingGroupName: autoscalerGroupName,
+			Role:                 role,
+		})
+	}
+	slices.SortFunc(results, func(a, b cspapi.ScalingGroup) int {
+		return strings.Compare(a.GroupID, b.GroupID)
+	})
+	return results, nil
+}
+
+// scalingGroupImage returns the image of a scaling group.
+// Servers created by Terraform have no image metadata, so the boot image of the first server is used as fallback.
+func (c *Client) scalingGroupImage(ctx context.Context, members []member) (string, error) {
+	if image := members[0].server.Metadata[imageMetadataKey]; image != "" {
+		return image, nil
+	}
+	return c.serverImage(ctx, &members[0].server)
+}
@@ -0,0 +1,187 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package client
+
+import (
+	"testing"
+
+	"github.com/edgelesssys/constellation/v2/internal/constants"
+	updatev1alpha1 "github.com/edgelesssys/constellation/v2/operators/constellation-node-operator/api/v1alpha1"
+	cspapi "github.com/edgelesssys/constellation/v2/operators/constellation-node-operator/internal/cloud/api"
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetScalingGroupImage(t *testing.T) {
+	withImageMetadata := testServer("server-0", "constell-worker-1a2b3c4d-0")
+	withImageMetadata.Metadata["constellation-image"] = "new-image-id"
+
+	testCases := map[string]struct {
+		compute   *stubCompute
+		volume    *stubVolume
+		wantImage string
+		wantErr   bool
+	}{
+		"image from metadata": {
+			compute:   &stubCompute{servers: []servers.Server{withImageMetadata}},
+			volume:    testVolumes("server-0"),
+			wantImage: "new-image-id",
+		},
+		"image of boot volume": {
+			compute:   &stubCompute{servers: []servers.Server{testServer("server-0", "constell-worker-1a2b3c4d-0")}},
+			volume:    testVolumes("server-0"),
+			wantImage: "image-id",
+		},
+		"empty scaling group": {
+			compute: &stubCompute{servers: []servers.Server{testServer("server-0", "constell-worker-5e6f7a8b-0")}},
+			volume:  testVolumes("server-0"),
+			wantErr: true,
+		},
+		"listing servers fails": {
+			compute: &stubCompute{listErr: assert.AnError},
+			volume:  &stubVolume{},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			client := &Client{compute: tc.compute, volume: tc.volume}
+
+			image, err := client.GetScalingGroupImage(t.Context(), "constell-worker-1a2b3c4d")
+			if tc.wantErr {
+				assert.Error(err)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(tc.wantImage, image)
+			assert.Equal(`^constell-worker-1a2b3c4d-(op-)?[0-9]+$`, tc.compute.listOpts.Name)
+		})
+	}
+}
+
+func TestSetScalingGroupImage(t *testing.T) {
+	testCases := map[string]struct {
+		compute     *stubCompute
+		wantUpdated map[string]map[string]string
+		wantErr     bool
+	}{
+		"setting image works": {
+			compute: &stubCompute{servers: []servers.Server{
+				testServer("server-0", "constell-worker-1a2b3c4d-0"),
+				testServer("server-1", "constell-worker-1a2b3c4d-1"),
+				testServer("other", "constell-worker-5e6f7a8b-0"),
+			}},
+			wantUpdated: map[string]map[string]string{
+				"server-0": {"constellation-image": "new-image-id"},
+				"server-1": {"constellation-image": "new-image-id"},
+			},
+		},
+		"empty scaling group": {
+			compute: &stubCompute{},
+			wantErr: true,
+		},
+		"updating metadata fails": {
+			compute: &stubCompute{
+				servers:   []servers.Server{testServer("server-0", "constell-worker-1a2b3c4d-0")},
+				updateErr: assert.AnError,
+			},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			client := &Client{compute: tc.compute}
+
+			err := client.SetScalingGroupImage(t.Context(), "constell-worker-1a2b3c4d", "new-image-id")
+			if tc.wantErr {
+				assert.Error(err)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(tc.wantUpdated, tc.compute.updatedServers)
+		})
+	}
+}
+
+func TestListScalingGroups(t *testing.T) {
+	controlPlane := testServer("server-0", "constell-control-plane-1a2b3c4d-0")
+	controlPlane.Metadata["constellation-role"] = "control-plane"
+	controlPlane.Metadata["constellation-node-group"] = "control_plane_default"
+	legacyWorker := testServer("server-1", "constell-worker-5e6f7a8b-0")
+	delete(legacyWorker.Metadata, "constellation-node-group")
+
+	testCases := map[string]struct {
+		compute    *stubCompute
+		wantGroups []cspapi.ScalingGroup
+		wantErr    bool
+	}{
+		"listing scaling groups works": {
+			compute: &stubCompute{servers: []servers.Server{
+				testServer("server-3", "constell-worker-5e6f7a8b-1"),
+				legacyWorker,
+				controlPlane,
+				testServer("bastion", "bastion"),
+			}},
+			wantGroups: []cspapi.ScalingGroup{
+				{
+					Name:                 "constell-control-plane-1a2b3c4d",
+					NodeGroupName:        constants.ControlPlaneDefault,
+					GroupID:              "constell-control-plane-1a2b3c4d",
+					AutoscalingGroupName: "constell-control-plane-1a2b3c4d",
+					Role:                 updatev1alpha1.ControlPlaneRole,
+				},
+				{
+					Name:                 "constell-worker-5e6f7a8b",
+					NodeGroupName:        constants.WorkerDefault,
+					GroupID:              "constell-worker-5e6f7a8b",
+					AutoscalingGroupName: "constell-worker-5e6f7a8b",
+					Role:                 updatev1alpha1.WorkerRole,
+				},
+			},
+		},
+		"legacy server without node group": {
+			compute: &stubCompute{servers: []servers.Server{legacyWorker}},
+			wantGroups: []cspapi.ScalingGroup{
+				{
+					Name:                 "constell-worker-5e6f7a8b",
+					NodeGroupName:        constants.WorkerDefault,
+					GroupID:              "constell-worker-5e6f7a8b",
+					AutoscalingGroupName: "constell-worker-5e6f7a8b",
+					Role:                 updatev1alpha1.WorkerRole,
+				},
+			},
+		},
+		"no servers": {
+			compute:    &stubCompute{},
+			wantGroups: []cspapi.ScalingGroup{},
+		},
+		"listing servers fails": {
+			compute: &stubCompute{listErr: assert.AnError},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			client := &Client{compute: tc.compute}
+
+			groups, err := client.ListScalingGroups(t.Context(), "uid")
+			if tc.wantErr {
+				assert.Error(err)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(tc.wantGroups, groups)
+			assert.Equal("constellation-uid-uid", tc.compute.listOpts.Tags)
+		})
+	}
+}
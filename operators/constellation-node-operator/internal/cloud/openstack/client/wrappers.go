@@ -0,0 +1,88 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package client
+
+import (
+	"context"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack/blockstorage/v3/volumes"
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servergroups"
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
+	"github.com/gophercloud/gophercloud/v2/openstack/networking/v2/extensions/attributestags"
+	"github.com/gophercloud/gophercloud/v2/openstack/networking/v2/ports"
+)
+
+type computeClient struct {
+	client *gophercloud.ServiceClient
+}
+
+func (c *computeClient) ListServers(ctx context.Context, opts servers.ListOpts) ([]servers.Server, error) {
+	pages, err := servers.List(c.client, opts).AllPages(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return servers.ExtractServers(pages)
+}
+
+func (c *computeClient) GetServer(ctx context.Context, id string) (*servers.Server, error) {
+	return servers.Get(ctx, c.client, id).Extract()
+}
+
+func (c *computeClient) CreateServer(ctx context.Context, opts servers.CreateOpts, hintOpts servers.SchedulerHintOpts) (*servers.Server, error) {
+	return servers.Create(ctx, c.client, opts, hintOpts).Extract()
+}
+
+func (c *computeClient) DeleteServer(ctx context.Context, id string) error {
+	return servers.Delete(ctx, c.client, id).ExtractErr()
+}
+
+func (c *computeClient) UpdateServerMetadata(ctx context.Context, id string, metadata map[string]string) error {
+	_, err := servers.UpdateMetadata(ctx, c.client, id, servers.MetadataOpts(metadata)).Extract()
+	return err
+}
+
+func (c *computeClient) ListServerGroups(ctx context.Context) ([]servergroups.ServerGroup, error) {
+	pages, err := servergroups.List(c.client, servergroups.ListOpts{}).AllPages(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return servergroups.ExtractServerGroups(pages)
+}
+
+type networkClient struct {
+	client *gophercloud.ServiceClient
+}
+
+func (c *networkClient) ListPorts(ctx context.Context, opts ports.ListOpts) ([]ports.Port, error) {
+	pages, err := ports.List(c.client, opts).AllPages(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return ports.ExtractPorts(pages)
+}
+
+func (c *networkClient) CreatePort(ctx context.Context, opts ports.CreateOpts) (*ports.Port, error) {
+	return ports.Create(ctx, c.client, opts).Extract()
+}
+
+func (c *networkClient) DeletePort(ctx context.Context, id string) error {
+	return ports.Delete(ctx, c.client, id).ExtractErr()
+}
+
+func (c *networkClient) ReplaceAllTags(ctx context.Context, portID string, tags []string) error {
+	_, err := attributestags.ReplaceAll(ctx, c.client, "ports", portID, attributestags.ReplaceAllOpts{Tags: tags}).Extract()
+	return err
+}
+
+type volumeClient struct {
+	client *gophercloud.ServiceClient
+}
+
+func (c *volumeClient) GetVolume(ctx context.Context, id string) (*volumes.Volume, error) {
+	return volumes.Get(ctx, c.client, id).Extract()
+}
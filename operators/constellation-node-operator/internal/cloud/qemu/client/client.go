@@ -0,0 +1,91 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/edgelesssys/constellation/v2/internal/cloud/qemu"
+)
+
+// Client is a client for QEMU clusters.
+// Instances are managed through the QEMU metadata API, which creates and deletes libvirt domains on the host.
+type Client struct {
+	httpClient httpClient
+	endpoint   string
+}
+
+// New creates a client for the QEMU metadata API.
+func New() *Client {
+	return &Client{
+		httpClient: &http.Client{},
+		endpoint:   "http://" + qemu.MetadataEndpoint,
+	}
+}
+
+// getInstance returns the instance with the given provider ID.
+func (c *Client) getInstance(ctx context.Context, providerID string) (qemu.Instance, error) {
+	hostname, err := qemu.HostnameFromProviderID(providerID)
+	if err != nil {
+		return qemu.Instance{}, err
+	}
+	var instance qemu.Instance
+	if err := c.do(ctx, http.MethodGet, "/instances/"+url.PathEscape(hostname), nil, &instance); err != nil {
+		return qemu.Instance{}, fmt.Errorf("getting instance %q: %w", hostname, err)
+	}
+	return instance, nil
+}
+
+// do sends a request to the QEMU metadata API and decodes the response into out, if out is not nil.
+// If the API does not know the requested resource, qemu.ErrInstanceNotFound is returned.
+func (c *Client) do(ctx context.Context, method, path string, in, out any) error {
+	var reqBody io.Reader = http.NoBody
+	if in != nil {
+		raw, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("marshaling request: %w", err)
+		}
+		reqBody = bytes.NewReader(raw)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.endpoint+path, reqBody)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("querying the QEMU metadata API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return qemu.ErrInstanceNotFound
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("QEMU metadata API returned status %d: %s", resp.StatusCode, bytes.TrimSpace(msg))
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+	return nil
+}
+
+type httpClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
@@ -0,0 +1,156 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package client
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/edgelesssys/constellation/v2/internal/cloud/qemu"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDo(t *testing.T) {
+	testCases := map[string]struct {
+		status   int
+		respBody string
+		in       any
+		decode   bool
+		wantBody string
+		wantOut  map[string]string
+		wantErr  error
+		wantFail bool
+	}{
+		"success": {
+			status:   http.StatusOK,
+			respBody: `{"foo":"bar"}`,
+			in:       map[string]string{"image": "/image"},
+			decode:   true,
+			wantBody: `{"image":"/image"}`,
+			wantOut:  map[string]string{"foo": "bar"},
+		},
+		"no content": {
+			status: http.StatusNoContent,
+		},
+		"not found": {
+			status:  http.StatusNotFound,
+			wantErr: qemu.ErrInstanceNotFound,
+		},
+		"server error": {
+			status:   http.StatusInternalServerError,
+			respBody: "failed",
+			wantFail: true,
+		},
+		"invalid response": {
+			status:   http.StatusOK,
+			respBody: "not json",
+			decode:   true,
+			wantFail: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			var gotBody string
+			client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				gotBody = string(body)
+				w.WriteHeader(tc.status)
+				_, _ = w.Write([]byte(tc.respBody))
+			})
+
+			var out map[string]string
+			var outPtr any
+			if tc.decode {
+				outPtr = &out
+			}
+			err := client.do(t.Context(), http.MethodPost, "/test", tc.in, outPtr)
+			if tc.wantErr != nil {
+				assert.ErrorIs(err, tc.wantErr)
+				return
+			}
+			if tc.wantFail {
+				assert.Error(err)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(tc.wantBody, gotBody)
+			assert.Equal(tc.wantOut, out)
+		})
+	}
+}
+
+// newTestClient returns a client for a QEMU metadata API served by the given handler.
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return &Client{
+		httpClient: server.Client(),
+		endpoint:   server.URL,
+	}
+}
+
+// stubAPI is a minimal in-memory QEMU metadata API.
+type stubAPI struct {
+	groups    []qemu.ScalingGroup
+	instances map[string]qemu.Instance
+	created   qemu.Instance
+	status    int
+
+	requests []string
+	setImage qemu.SetImageRequest
+}
+
+func (s *stubAPI) handler(t *testing.T) http.HandlerFunc {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /scalinggroups", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(t, w, s.groups)
+	})
+	mux.HandleFunc("PUT /scalinggroups/{id}/image", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&s.setImage))
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("POST /scalinggroups/{id}/instances", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(t, w, s.created)
+	})
+	mux.HandleFunc("GET /instances/{name}", func(w http.ResponseWriter, r *http.Request) {
+		instance, ok := s.instances[r.PathValue("name")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		writeJSON(t, w, instance)
+	})
+	mux.HandleFunc("DELETE /instances/{name}", func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := s.instances[r.PathValue("name")]; !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.requests = append(s.requests, r.Method+" "+r.URL.Path)
+		if s.status != 0 {
+			http.Error(w, "error", s.status)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}
+}
+
+func writeJSON(t *testing.T, w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	require.NoError(t, json.NewEncoder(w).Encode(v))
+}
@@ -0,0 +1,57 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/edgelesssys/constellation/v2/internal/cloud/qemu"
+)
+
+// GetNodeImage returns the image name of the node.
+func (c *Client) GetNodeImage(ctx context.Context, providerID string) (string, error) {
+	instance, err := c.getInstance(ctx, providerID)
+	if err != nil {
+		return "", err
+	}
+	return instance.Image, nil
+}
+
+// GetScalingGroupID returns the scaling group ID of the node.
+func (c *Client) GetScalingGroupID(ctx context.Context, providerID string) (string, error) {
+	instance, err := c.getInstance(ctx, providerID)
+	if err != nil {
+		return "", err
+	}
+	return instance.ScalingGroupID, nil
+}
+
+// CreateNode creates a node in the specified scaling group.
+func (c *Client) CreateNode(ctx context.Context, scalingGroupID string) (nodeName, providerID string, err error) {
+	var instance qemu.Instance
+	if err := c.do(ctx, http.MethodPost, "/scalinggroups/"+url.PathEscape(scalingGroupID)+"/instances", nil, &instance); err != nil {
+		return "", "", fmt.Errorf("creating instance in scaling group %q: %w", scalingGroupID, err)
+	}
+	return instance.Name, instance.ProviderID, nil
+}
+
+// DeleteNode deletes a node from the specified scaling group.
+func (c *Client) DeleteNode(ctx context.Context, providerID string) error {
+	hostname, err := qemu.HostnameFromProviderID(providerID)
+	if err != nil {
+		return err
+	}
+	err = c.do(ctx, http.MethodDelete, "/instances/"+url.PathEscape(hostname), nil, nil)
+	if err != nil && !errors.Is(err, qemu.ErrInstanceNotFound) {
+		return fmt.Errorf("deleting instance %q: %w", hostname, err)
+	}
+	return nil
+}
@@ -0,0 +1,183 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package client
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/edgelesssys/constellation/v2/internal/cloud/qemu"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetNodeImage(t *testing.T) {
+	testCases := map[string]struct {
+		providerID string
+		api        *stubAPI
+		wantImage  string
+		wantErr    bool
+	}{
+		"getting node image works": {
+			providerID: "qemu:///hostname/worker-0",
+			api: &stubAPI{instances: map[string]qemu.Instance{
+				"worker-0": {Name: "worker-0", Image: "/var/lib/libvirt/images/image"},
+			}},
+			wantImage: "/var/lib/libvirt/images/image",
+		},
+		"invalid provider ID": {
+			providerID: "aws:///us-east-2a/i-06888991e7138ed4e",
+			api:        &stubAPI{},
+			wantErr:    true,
+		},
+		"instance not found": {
+			providerID: "qemu:///hostname/worker-0",
+			api:        &stubAPI{},
+			wantErr:    true,
+		},
+		"api error": {
+			providerID: "qemu:///hostname/worker-0",
+			api:        &stubAPI{status: http.StatusInternalServerError},
+			wantErr:    true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			client := newTestClient(t, tc.api.handler(t))
+
+			image, err := client.GetNodeImage(t.Context(), tc.providerID)
+			if tc.wantErr {
+				assert.Error(err)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(tc.wantImage, image)
+		})
+	}
+}
+
+func TestGetScalingGroupID(t *testing.T) {
+	testCases := map[string]struct {
+		providerID string
+		api        *stubAPI
+		wantID     string
+		wantErr    bool
+	}{
+		"getting scaling group ID works": {
+			providerID: "qemu:///hostname/control-plane-1",
+			api: &stubAPI{instances: map[string]qemu.Instance{
+				"control-plane-1": {Name: "control-plane-1", ScalingGroupID: "constell-control-plane-1a2b3c4d"},
+			}},
+			wantID: "constell-control-plane-1a2b3c4d",
+		},
+		"instance not found": {
+			providerID: "qemu:///hostname/control-plane-1",
+			api:        &stubAPI{},
+			wantErr:    true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			client := newTestClient(t, tc.api.handler(t))
+
+			id, err := client.GetScalingGroupID(t.Context(), tc.providerID)
+			if tc.wantErr {
+				assert.Error(err)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(tc.wantID, id)
+		})
+	}
+}
+
+func TestCreateNode(t *testing.T) {
+	testCases := map[string]struct {
+		api            *stubAPI
+		wantNodeName   string
+		wantProviderID string
+		wantErr        bool
+	}{
+		"creating node works": {
+			api: &stubAPI{created: qemu.Instance{
+				Name:           "worker-2",
+				ProviderID:     "qemu:///hostname/worker-2",
+				ScalingGroupID: "constell-worker-1a2b3c4d",
+			}},
+			wantNodeName:   "worker-2",
+			wantProviderID: "qemu:///hostname/worker-2",
+		},
+		"api error": {
+			api:     &stubAPI{status: http.StatusInternalServerError},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			client := newTestClient(t, tc.api.handler(t))
+
+			nodeName, providerID, err := client.CreateNode(t.Context(), "constell-worker-1a2b3c4d")
+			if tc.wantErr {
+				assert.Error(err)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(tc.wantNodeName, nodeName)
+			assert.Equal(tc.wantProviderID, providerID)
+			assert.Equal([]string{"POST /scalinggroups/constell-worker-1a2b3c4d/instances"}, tc.api.requests)
+		})
+	}
+}
+
+func TestDeleteNode(t *testing.T) {
+	testCases := map[string]struct {
+		providerID string
+		api        *stubAPI
+		wantErr    bool
+	}{
+		"deleting node works": {
+			providerID: "qemu:///hostname/worker-0",
+			api: &stubAPI{instances: map[string]qemu.Instance{
+				"worker-0": {Name: "worker-0"},
+			}},
+		},
+		"node already deleted": {
+			providerID: "qemu:///hostname/worker-0",
+			api:        &stubAPI{},
+		},
+		"invalid provider ID": {
+			providerID: "qemu:///worker-0",
+			api:        &stubAPI{},
+			wantErr:    true,
+		},
+		"api error": {
+			providerID: "qemu:///hostname/worker-0",
+			api:        &stubAPI{status: http.StatusInternalServerError},
+			wantErr:    true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			client := newTestClient(t, tc.api.handler(t))
+
+			err := client.DeleteNode(t.Context(), tc.providerID)
+			if tc.wantErr {
+				assert.Error(err)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal([]string{"DELETE /instances/worker-0"}, tc.api.requests)
+		})
+	}
+}
@@ -0,0 +1,43 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/edgelesssys/constellation/v2/internal/cloud/qemu"
+	updatev1alpha1 "github.com/edgelesssys/constellation/v2/operators/constellation-node-operator/api/v1alpha1"
+)
+
+// GetNodeState returns the state of the node.
+func (c *Client) GetNodeState(ctx context.Context, providerID string) (updatev1alpha1.CSPNodeState, error) {
+	instance, err := c.getInstance(ctx, providerID)
+	if errors.Is(err, qemu.ErrInstanceNotFound) {
+		return updatev1alpha1.NodeStateTerminated, nil
+	}
+	if err != nil {
+		return updatev1alpha1.NodeStateUnknown, err
+	}
+
+	// Translate libvirt domain state to node state.
+	switch instance.State {
+	case qemu.InstanceStateRunning:
+		return updatev1alpha1.NodeStateReady, nil
+	case qemu.InstanceStateStopped:
+		return updatev1alpha1.NodeStateStopped, nil
+	case qemu.InstanceStateShuttingDown:
+		return updatev1alpha1.NodeStateTerminating, nil
+	case qemu.InstanceStateCrashed:
+		return updatev1alpha1.NodeStateFailed, nil
+	case qemu.InstanceStateUnknown:
+		return updatev1alpha1.NodeStateUnknown, nil
+	default:
+		return updatev1alpha1.NodeStateUnknown, fmt.Errorf("unknown instance state %q", instance.State)
+	}
+}
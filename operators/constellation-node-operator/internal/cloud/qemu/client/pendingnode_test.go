@@ -0,0 +1,81 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package client
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/edgelesssys/constellation/v2/internal/cloud/qemu"
+	updatev1alpha1 "github.com/edgelesssys/constellation/v2/operators/constellation-node-operator/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetNodeState(t *testing.T) {
+	testCases := map[string]struct {
+		state     qemu.InstanceState
+		notFound  bool
+		status    int
+		wantState updatev1alpha1.CSPNodeState
+		wantErr   bool
+	}{
+		"running node is ready": {
+			state:     qemu.InstanceStateRunning,
+			wantState: updatev1alpha1.NodeStateReady,
+		},
+		"stopped node": {
+			state:     qemu.InstanceStateStopped,
+			wantState: updatev1alpha1.NodeStateStopped,
+		},
+		"node shutting down is terminating": {
+			state:     qemu.InstanceStateShuttingDown,
+			wantState: updatev1alpha1.NodeStateTerminating,
+		},
+		"crashed node failed": {
+			state:     qemu.InstanceStateCrashed,
+			wantState: updatev1alpha1.NodeStateFailed,
+		},
+		"unknown state": {
+			state:     qemu.InstanceStateUnknown,
+			wantState: updatev1alpha1.NodeStateUnknown,
+		},
+		"deleted node is terminated": {
+			notFound:  true,
+			wantState: updatev1alpha1.NodeStateTerminated,
+		},
+		"invalid state": {
+			state:     "invalid",
+			wantState: updatev1alpha1.NodeStateUnknown,
+			wantErr:   true,
+		},
+		"api error": {
+			status:    http.StatusInternalServerError,
+			wantState: updatev1alpha1.NodeStateUnknown,
+			wantErr:   true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			api := &stubAPI{status: tc.status, instances: map[string]qemu.Instance{}}
+			if !tc.notFound {
+				api.instances["worker-0"] = qemu.Instance{Name: "worker-0", State: tc.state}
+			}
+			client := newTestClient(t, api.handler(t))
+
+			state, err := client.GetNodeState(t.Context(), "qemu:///hostname/worker-0")
+			assert.Equal(tc.wantState, state)
+			if tc.wantErr {
+				assert.Error(err)
+				return
+			}
+			assert.NoError(err)
+		})
+	}
+}
@@ -0,0 +1,103 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/edgelesssys/constellation/v2/internal/cloud/qemu"
+	"github.com/edgelesssys/constellation/v2/internal/constants"
+	updatev1alpha1 "github.com/edgelesssys/constellation/v2/operators/constellation-node-operator/api/v1alpha1"
+	cspapi "github.com/edgelesssys/constellation/v2/operators/constellation-node-operator/internal/cloud/api"
+)
+
+// GetScalingGroupImage returns the image of the scaling group.
+// On QEMU, images are paths of libvirt volumes on the host.
+func (c *Client) GetScalingGroupImage(ctx context.Context, scalingGroupID string) (string, error) {
+	groups, err := c.listScalingGroups(ctx)
+	if err != nil {
+		return "", err
+	}
+	for _, group := range groups {
+		if group.ID == scalingGroupID {
+			return group.Image, nil
+		}
+	}
+	return "", fmt.Errorf("scaling group %q: %w", scalingGroupID, qemu.ErrInstanceNotFound)
+}
+
+// SetScalingGroupImage sets the image of the scaling group.
+// The image must be the path of a libvirt volume in the storage pool of the cluster.
+func (c *Client) SetScalingGroupImage(ctx context.Context, scalingGroupID, imageURI string) error {
+	req := qemu.SetImageRequest{Image: imageURI}
+	if err := c.do(ctx, http.MethodPut, "/scalinggroups/"+url.PathEscape(scalingGroupID)+"/image", req, nil); err != nil {
+		return fmt.Errorf("setting image of scaling group %q: %w", scalingGroupID, err)
+	}
+	return nil
+}
+
+// GetScalingGroupName retrieves the name of a scaling group.
+func (c *Client) GetScalingGroupName(scalingGroupID string) (string, error) {
+	return strings.ToLower(scalingGroupID), nil
+}
+
+// GetAutoscalingGroupName retrieves the name of a scaling group as needed by the cluster-autoscaler.
+func (c *Client) GetAutoscalingGroupName(scalingGroupID string) (string, error) {
+	return scalingGroupID, nil
+}
+
+// ListScalingGroups retrieves a list of scaling groups for the cluster.
+// The QEMU metadata API only serves a single cluster, so the uid is not used to filter the groups.
+func (c *Client) ListScalingGroups(ctx context.Context, _ string) ([]cspapi.ScalingGroup, error) {
+	groups, err := c.listScalingGroups(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []cspapi.ScalingGroup{}
+	for _, group := range groups {
+		role := updatev1alpha1.NodeRoleFromString(group.Role.String())
+		var nodeGroupName string
+		switch role {
+		case updatev1alpha1.ControlPlaneRole:
+			nodeGroupName = constants.ControlPlaneDefault
+		case updatev1alpha1.WorkerRole:
+			nodeGroupName = constants.WorkerDefault
+		default:
+			continue
+		}
+
+		name, err := c.GetScalingGroupName(group.ID)
+		if err != nil {
+			return nil, fmt.Errorf("getting scaling group name: %w", err)
+		}
+		autoscalerGroupName, err := c.GetAutoscalingGroupName(group.ID)
+		if err != nil {
+			return nil, fmt.Errorf("getting autoscaler group name: %w", err)
+		}
+		results = append(results, cspapi.ScalingGroup{
+			Name:                 name,
+			NodeGroupName:        nodeGroupName,
+			GroupID:              group.ID,
+			AutoscalingGroupName: autoscalerGroupName,
+			Role:                 role,
+		})
+	}
+	return results, nil
+}
+
+func (c *Client) listScalingGroups(ctx context.Context) ([]qemu.ScalingGroup, error) {
+	var groups []qemu.ScalingGroup
+	if err := c.do(ctx, http.MethodGet, "/scalinggroups", nil, &groups); err != nil {
+		return nil, fmt.Errorf("listing scaling groups: %w", err)
+	}
+	return groups, nil
+}
@@ -0,0 +1,146 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package client
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/edgelesssys/constellation/v2/internal/cloud/qemu"
+	"github.com/edgelesssys/constellation/v2/internal/constants"
+	"github.com/edgelesssys/constellation/v2/internal/role"
+	updatev1alpha1 "github.com/edgelesssys/constellation/v2/operators/constellation-node-operator/api/v1alpha1"
+	cspapi "github.com/edgelesssys/constellation/v2/operators/constellation-node-operator/internal/cloud/api"
+	"github.com/stretchr/testify/assert"
+)
+
+var testScalingGroups = []qemu.ScalingGroup{
+	{ID: "constell-control-plane-1a2b3c4d", Role: role.ControlPlane, Image: "/var/lib/libvirt/images/image-1"},
+	{ID: "constell-worker-5e6f7a8b", Role: role.Worker, Image: "/var/lib/libvirt/images/image-2"},
+}
+
+func TestGetScalingGroupImage(t *testing.T) {
+	testCases := map[string]struct {
+		scalingGroupID string
+		api            *stubAPI
+		wantImage      string
+		wantErr        bool
+	}{
+		"getting image works": {
+			scalingGroupID: "constell-worker-5e6f7a8b",
+			api:            &stubAPI{groups: testScalingGroups},
+			wantImage:      "/var/lib/libvirt/images/image-2",
+		},
+		"unknown scaling group": {
+			scalingGroupID: "constell-worker-00000000",
+			api:            &stubAPI{groups: testScalingGroups},
+			wantErr:        true,
+		},
+		"api error": {
+			scalingGroupID: "constell-worker-5e6f7a8b",
+			api:            &stubAPI{status: http.StatusInternalServerError},
+			wantErr:        true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			client := newTestClient(t, tc.api.handler(t))
+
+			image, err := client.GetScalingGroupImage(t.Context(), tc.scalingGroupID)
+			if tc.wantErr {
+				assert.Error(err)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(tc.wantImage, image)
+		})
+	}
+}
+
+func TestSetScalingGroupImage(t *testing.T) {
+	testCases := map[string]struct {
+		api     *stubAPI
+		wantErr bool
+	}{
+		"setting image works": {
+			api: &stubAPI{},
+		},
+		"api error": {
+			api:     &stubAPI{status: http.StatusInternalServerError},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			client := newTestClient(t, tc.api.handler(t))
+
+			err := client.SetScalingGroupImage(t.Context(), "constell-worker-5e6f7a8b", "/var/lib/libvirt/images/image-3")
+			if tc.wantErr {
+				assert.Error(err)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal([]string{"PUT /scalinggroups/constell-worker-5e6f7a8b/image"}, tc.api.requests)
+			assert.Equal("/var/lib/libvirt/images/image-3", tc.api.setImage.Image)
+		})
+	}
+}
+
+func TestListScalingGroups(t *testing.T) {
+	testCases := map[string]struct {
+		api        *stubAPI
+		wantGroups []cspapi.ScalingGroup
+		wantErr    bool
+	}{
+		"listing scaling groups works": {
+			api: &stubAPI{groups: testScalingGroups},
+			wantGroups: []cspapi.ScalingGroup{
+				{
+					Name:                 "constell-control-plane-1a2b3c4d",
+					NodeGroupName:        constants.ControlPlaneDefault,
+					GroupID:              "constell-control-plane-1a2b3c4d",
+					AutoscalingGroupName: "constell-control-plane-1a2b3c4d",
+					Role:                 updatev1alpha1.ControlPlaneRole,
+				},
+				{
+					Name:                 "constell-worker-5e6f7a8b",
+					NodeGroupName:        constants.WorkerDefault,
+					GroupID:              "constell-worker-5e6f7a8b",
+					AutoscalingGroupName: "constell-worker-5e6f7a8b",
+					Role:                 updatev1alpha1.WorkerRole,
+				},
+			},
+		},
+		"groups with unknown role are skipped": {
+			api:        &stubAPI{groups: []qemu.ScalingGroup{{ID: "constell-unknown-1a2b3c4d", Role: role.Unknown}}},
+			wantGroups: []cspapi.ScalingGroup{},
+		},
+		"api error": {
+			api:     &stubAPI{status: http.StatusInternalServerError},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			client := newTestClient(t, tc.api.handler(t))
+
+			groups, err := client.ListScalingGroups(t.Context(), "uid")
+			if tc.wantErr {
+				assert.Error(err)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(tc.wantGroups, groups)
+		})
+	}
+}
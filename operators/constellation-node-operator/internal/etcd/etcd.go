@@ -10,6 +10,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"net"
 	"net/url"
 
@@ -34,7 +35,7 @@ const (
 
 var errMemberNotFound = errors.New("member not found")
 
-// Client is an etcd client that can be used to remove a member from an etcd cluster.
+// Client is an etcd client that can be used to remove a member from an etcd cluster and to take snapshots.
 type Client struct {
 	etcdClient etcdClient
 }
@@ -88,6 +89,17 @@ func (c *Client) RemoveEtcdMemberFromCluster(ctx context.Context, vpcIP string)
 	return err
 }
 
+// Snapshot takes a snapshot of the etcd keyspace and returns a reader for the snapshot database.
+// The snapshot is streamed from etcd while it is read, so it is never held in memory as a whole.
+// The caller must close the reader.
+func (c *Client) Snapshot(ctx context.Context) (io.ReadCloser, error) {
+	reader, err := c.etcdClient.Snapshot(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("requesting snapshot: %w", err)
+	}
+	return reader, nil
+}
+
 // getMemberID returns the member ID of the member with the given vpcIP.
 func (c *Client) getMemberID(ctx context.Context, vpcIP string) (uint64, error) {
 	listResponse, err := c.etcdClient.MemberList(ctx)
@@ -129,6 +141,7 @@ func getInitialEndpoints(k8sClient client.Client) ([]string, error) {
 type etcdClient interface {
 	MemberList(ctx context.Context, opts ...clientv3.OpOption) (*clientv3.MemberListResponse, error)
 	MemberRemove(ctx context.Context, memberID uint64) (*clientv3.MemberRemoveResponse, error)
+	Snapshot(ctx context.Context) (io.ReadCloser, error)
 	Sync(ctx context.Context) error
 	Close() error
 }
@@ -7,9 +7,12 @@ SPDX-License-Identifier: BUSL-1.1
 package etcd
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"io"
 	"testing"
+	"testing/iotest"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -64,6 +67,50 @@ func TestRemoveEtcdMemberFromCluster(t *testing.T) {
 	}
 }
 
+func TestSnapshot(t *testing.T) {
+	testCases := map[string]struct {
+		snapshotErr error
+		readErr     error
+		wantErr     bool
+	}{
+		"taking snapshot works": {},
+		"requesting snapshot fails": {
+			snapshotErr: errors.New("snapshot failed"),
+			wantErr:     true,
+		},
+		"receiving snapshot fails": {
+			readErr: errors.New("read failed"),
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			client := Client{etcdClient: &stubEtcdClient{
+				snapshot:        []byte("snapshot"),
+				snapshotErr:     tc.snapshotErr,
+				snapshotReadErr: tc.readErr,
+			}}
+			reader, err := client.Snapshot(t.Context())
+			if err == nil {
+				defer reader.Close()
+				var snapshot []byte
+				snapshot, err = io.ReadAll(reader)
+				if err == nil {
+					assert.Equal([]byte("snapshot"), snapshot)
+				}
+			}
+			if tc.wantErr {
+				assert.Error(err)
+				return
+			}
+			assert.NoError(err)
+		})
+	}
+}
+
 func TestGetMemberID(t *testing.T) {
 	testCases := map[string]struct {
 		members       []*pb.Member
@@ -183,6 +230,10 @@ type stubEtcdClient struct {
 	removeErr error
 	syncErr   error
 	closeErr  error
+
+	snapshot        []byte
+	snapshotErr     error
+	snapshotReadErr error
 }
 
 func (c *stubEtcdClient) MemberList(_ context.Context, _ ...clientv3.OpOption) (*clientv3.MemberListResponse, error) {
@@ -197,6 +248,16 @@ func (c *stubEtcdClient) MemberRemove(_ context.Context, _ uint64) (*clientv3.Me
 	}, c.removeErr
 }
 
+func (c *stubEtcdClient) Snapshot(_ context.Context) (io.ReadCloser, error) {
+	if c.snapshotErr != nil {
+		return nil, c.snapshotErr
+	}
+	if c.snapshotReadErr != nil {
+		return io.NopCloser(iotest.ErrReader(c.snapshotReadErr)), nil
+	}
+	return io.NopCloser(bytes.NewReader(c.snapshot)), nil
+}
+
 func (c *stubEtcdClient) Sync(_ context.Context) error {
 	return c.syncErr
 }
@@ -0,0 +1,155 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+// Package healthcheck checks user-supplied health gates of node image rollouts.
+package healthcheck
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// requestTimeout is the time limit for a single health check request.
+const requestTimeout = 10 * time.Second
+
+// Client checks health gates using HTTP requests.
+type Client struct {
+	httpClient *http.Client
+}
+
+// NewClient creates a new health check client.
+func NewClient() *Client {
+	return &Client{httpClient: &http.Client{Timeout: requestTimeout}}
+}
+
+// WebhookRequest is the body sent to a health check webhook.
+type WebhookRequest struct {
+	// ImageReference is the image that is rolled out.
+	ImageReference string `json:"imageReference"`
+	// Nodes are the names of the nodes that use the image.
+	Nodes []string `json:"nodes"`
+}
+
+// CheckWebhook sends the upgraded nodes to the webhook.
+// The check passes if the webhook responds with a 2xx status code.
+func (c *Client) CheckWebhook(ctx context.Context, webhookURL string, body WebhookRequest) error {
+	rawBody, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("marshaling webhook request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookURL, bytes.NewReader(rawBody))
+	if err != nil {
+		return fmt.Errorf("creating webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("sending webhook request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded with status %s", resp.Status)
+	}
+	return nil
+}
+
+// CheckPrometheus runs an instant query against the Prometheus HTTP API.
+// The check passes if the query returns at least one sample and all samples are non-zero.
+func (c *Client) CheckPrometheus(ctx context.Context, prometheusURL, query string) error {
+	queryURL, err := url.JoinPath(prometheusURL, "/api/v1/query")
+	if err != nil {
+		return fmt.Errorf("building query URL: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, queryURL+"?"+url.Values{"query": {query}}.Encode(), http.NoBody)
+	if err != nil {
+		return fmt.Errorf("creating query request: %w", err)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("sending query request: %w", err)
+	}
+	defer resp.Body.Close()
+	rawBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("reading query response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("prometheus responded with status %s", resp.Status)
+	}
+	return evaluateQueryResponse(rawBody)
+}
+
+// queryResponse is the response of the Prometheus instant query API.
+type queryResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error"`
+	Data   struct {
+		ResultType string          `json:"resultType"`
+		Result     json.RawMessage `json:"result"`
+	} `json:"data"`
+}
+
+// sample is a single sample of an instant vector.
+type sample struct {
+	Value [2]any `json:"value"`
+}
+
+// evaluateQueryResponse checks that a query response contains at least one sample and no zero values.
+func evaluateQueryResponse(rawResponse []byte) error {
+	var resp queryResponse
+	if err := json.Unmarshal(rawResponse, &resp); err != nil {
+		return fmt.Errorf("unmarshaling query response: %w", err)
+	}
+	if resp.Status != "success" {
+		return fmt.Errorf("query failed: %s", resp.Error)
+	}
+
+	var values []any
+	switch resp.Data.ResultType {
+	case "vector":
+		var samples []sample
+		if err := json.Unmarshal(resp.Data.Result, &samples); err != nil {
+			return fmt.Errorf("unmarshaling vector: %w", err)
+		}
+		for _, s := range samples {
+			values = append(values, s.Value[1])
+		}
+	case "scalar":
+		var scalar [2]any
+		if err := json.Unmarshal(resp.Data.Result, &scalar); err != nil {
+			return fmt.Errorf("unmarshaling scalar: %w", err)
+		}
+		values = append(values, scalar[1])
+	default:
+		return fmt.Errorf("unsupported result type %q", resp.Data.ResultType)
+	}
+
+	if len(values) == 0 {
+		return errors.New("query returned no samples")
+	}
+	for _, value := range values {
+		rawValue, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("invalid sample value %v", value)
+		}
+		parsed, err := strconv.ParseFloat(rawValue, 64)
+		if err != nil {
+			return fmt.Errorf("parsing sample value: %w", err)
+		}
+		if parsed == 0 {
+			return errors.New("query returned a zero sample")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package healthcheck
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckWebhook(t *testing.T) {
+	testCases := map[string]struct {
+		statusCode int
+		wantErr    bool
+	}{
+		"ok": {
+			statusCode: http.StatusOK,
+		},
+		"no content": {
+			statusCode: http.StatusNoContent,
+		},
+		"server error": {
+			statusCode: http.StatusInternalServerError,
+			wantErr:    true,
+		},
+		"not modified": {
+			statusCode: http.StatusNotModified,
+			wantErr:    true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			var gotRequest WebhookRequest
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(http.MethodPost, r.Method)
+				assert.NoError(json.NewDecoder(r.Body).Decode(&gotRequest))
+				w.WriteHeader(tc.statusCode)
+			}))
+			defer server.Close()
+
+			body := WebhookRequest{ImageReference: "image-2", Nodes: []string{"node-1"}}
+			err := NewClient().CheckWebhook(context.Background(), server.URL, body)
+			if tc.wantErr {
+				assert.Error(err)
+				return
+			}
+			require.NoError(err)
+			assert.Equal(body, gotRequest)
+		})
+	}
+}
+
+func TestCheckPrometheus(t *testing.T) {
+	testCases := map[string]struct {
+		statusCode int
+		response   string
+		wantErr    bool
+	}{
+		"non-zero vector": {
+			statusCode: http.StatusOK,
+			response:   `{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1700000000,"1"]},{"metric":{},"value":[1700000000,"0.5"]}]}}`,
+		},
+		"non-zero scalar": {
+			statusCode: http.StatusOK,
+			response:   `{"status":"success","data":{"resultType":"scalar","result":[1700000000,"1"]}}`,
+		},
+		"zero sample": {
+			statusCode: http.StatusOK,
+			response:   `{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1700000000,"1"]},{"metric":{},"value":[1700000000,"0"]}]}}`,
+			wantErr:    true,
+		},
+		"empty vector": {
+			statusCode: http.StatusOK,
+			response:   `{"status":"success","data":{"resultType":"vector","result":[]}}`,
+			wantErr:    true,
+		},
+		"unsupported result type": {
+			statusCode: http.StatusOK,
+			response:   `{"status":"success","data":{"resultType":"matrix","result":[]}}`,
+			wantErr:    true,
+		},
+		"query error": {
+			statusCode: http.StatusBadRequest,
+			response:   `{"status":"error","error":"parse error"}`,
+			wantErr:    true,
+		},
+		"invalid response": {
+			statusCode: http.StatusOK,
+			response:   `invalid`,
+			wantErr:    true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal("/api/v1/query", r.URL.Path)
+				assert.Equal("up", r.URL.Query().Get("query"))
+				w.WriteHeader(tc.statusCode)
+				_, _ = w.Write([]byte(tc.response))
+			}))
+			defer server.Close()
+
+			err := NewClient().CheckPrometheus(context.Background(), server.URL, "up")
+			if tc.wantErr {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+			}
+		})
+	}
+}
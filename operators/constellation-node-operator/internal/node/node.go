@@ -71,3 +71,36 @@ func FilterLabels(labels map[string]string) map[string]string {
 	}
 	return result
 }
+
+// FilterTaints removes reserved node taints from a list of taints.
+// reference: https://kubernetes.io/docs/reference/labels-annotations-taints/ .
+func FilterTaints(taints []corev1.Taint) []corev1.Taint {
+	var result []corev1.Taint
+	for _, taint := range taints {
+		if reservedHostRegex.MatchString(taint.Key) {
+			continue
+		}
+		result = append(result, taint)
+	}
+	return result
+}
+
+// MissingTaints returns the taints that are not set with the same value on a node.
+func MissingTaints(node *corev1.Node, taints []corev1.Taint) []corev1.Taint {
+	var missing []corev1.Taint
+	for _, taint := range taints {
+		if !taintExists(node.Spec.Taints, taint) {
+			missing = append(missing, taint)
+		}
+	}
+	return missing
+}
+
+func taintExists(taints []corev1.Taint, taint corev1.Taint) bool {
+	for _, existing := range taints {
+		if existing.MatchTaint(&taint) && existing.Value == taint.Value {
+			return true
+		}
+	}
+	return false
+}
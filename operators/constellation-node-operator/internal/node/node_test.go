@@ -212,6 +212,58 @@ func TestFilterLabels(t *testing.T) {
 	assert.Equal(wantFiltered, FilterLabels(labels))
 }
 
+func TestFilterTaints(t *testing.T) {
+	taints := []corev1.Taint{
+		{Key: "dedicated", Value: "gpu", Effect: corev1.TaintEffectNoSchedule},
+		{Key: "node-role.kubernetes.io/control-plane", Effect: corev1.TaintEffectNoSchedule},
+		{Key: "node.kubernetes.io/unschedulable", Effect: corev1.TaintEffectNoSchedule},
+		{Key: "node.cloudprovider.kubernetes.io/uninitialized", Value: "true", Effect: corev1.TaintEffectNoSchedule},
+	}
+	wantFiltered := []corev1.Taint{
+		{Key: "dedicated", Value: "gpu", Effect: corev1.TaintEffectNoSchedule},
+	}
+	assert := assert.New(t)
+	assert.Equal(wantFiltered, FilterTaints(taints))
+}
+
+func TestMissingTaints(t *testing.T) {
+	dedicated := corev1.Taint{Key: "dedicated", Value: "gpu", Effect: corev1.TaintEffectNoSchedule}
+
+	testCases := map[string]struct {
+		nodeTaints  []corev1.Taint
+		taints      []corev1.Taint
+		wantMissing []corev1.Taint
+	}{
+		"no taints": {},
+		"taint is missing": {
+			taints:      []corev1.Taint{dedicated},
+			wantMissing: []corev1.Taint{dedicated},
+		},
+		"taint exists": {
+			nodeTaints: []corev1.Taint{dedicated},
+			taints:     []corev1.Taint{dedicated},
+		},
+		"taint with different value": {
+			nodeTaints:  []corev1.Taint{{Key: "dedicated", Value: "cpu", Effect: corev1.TaintEffectNoSchedule}},
+			taints:      []corev1.Taint{dedicated},
+			wantMissing: []corev1.Taint{dedicated},
+		},
+		"taint with different effect": {
+			nodeTaints:  []corev1.Taint{{Key: "dedicated", Value: "gpu", Effect: corev1.TaintEffectNoExecute}},
+			taints:      []corev1.Taint{dedicated},
+			wantMissing: []corev1.Taint{dedicated},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			node := &corev1.Node{Spec: corev1.NodeSpec{Taints: tc.nodeTaints}}
+			assert.Equal(tc.wantMissing, MissingTaints(node, tc.taints))
+		})
+	}
+}
+
 var pendingNodes = []updatev1alpha1.PendingNode{
 	{
 		Spec: updatev1alpha1.PendingNodeSpec{
@@ -0,0 +1,31 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package patch
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// SetTaints creates a patch for a node by merging taints with existing taints.
+// Existing taints with the same key and effect are replaced.
+func SetTaints(original, patched *corev1.Node, taints []corev1.Taint) client.Patch {
+	for _, taint := range taints {
+		replaced := false
+		for i := range patched.Spec.Taints {
+			if patched.Spec.Taints[i].MatchTaint(&taint) {
+				patched.Spec.Taints[i] = taint
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			patched.Spec.Taints = append(patched.Spec.Taints, taint)
+		}
+	}
+	return client.MergeFromWithOptions(original, client.MergeFromWithOptimisticLock{})
+}
@@ -0,0 +1,61 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package patch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestSetTaints(t *testing.T) {
+	dedicated := corev1.Taint{Key: "dedicated", Value: "gpu", Effect: corev1.TaintEffectNoSchedule}
+
+	testCases := map[string]struct {
+		oldTaints []corev1.Taint
+		newTaints []corev1.Taint
+		wantPatch []byte
+	}{
+		"empty patch only contains resource version": {
+			wantPatch: []byte(`{"metadata":{"resourceVersion":"0"}}`),
+		},
+		"patch on node without existing taints": {
+			newTaints: []corev1.Taint{dedicated},
+			wantPatch: []byte(`{"metadata":{"resourceVersion":"0"},"spec":{"taints":[{"effect":"NoSchedule","key":"dedicated","value":"gpu"}]}}`),
+		},
+		"patch on node with same existing taints": {
+			oldTaints: []corev1.Taint{dedicated},
+			newTaints: []corev1.Taint{dedicated},
+			wantPatch: []byte(`{"metadata":{"resourceVersion":"0"}}`),
+		},
+		"patch on node with same key and effect but different value": {
+			oldTaints: []corev1.Taint{{Key: "dedicated", Value: "cpu", Effect: corev1.TaintEffectNoSchedule}},
+			newTaints: []corev1.Taint{dedicated},
+			wantPatch: []byte(`{"metadata":{"resourceVersion":"0"},"spec":{"taints":[{"effect":"NoSchedule","key":"dedicated","value":"gpu"}]}}`),
+		},
+		"patch on node with other existing taints": {
+			oldTaints: []corev1.Taint{{Key: "other", Effect: corev1.TaintEffectNoExecute}},
+			newTaints: []corev1.Taint{dedicated},
+			wantPatch: []byte(`{"metadata":{"resourceVersion":"0"},"spec":{"taints":[{"effect":"NoExecute","key":"other"},{"effect":"NoSchedule","key":"dedicated","value":"gpu"}]}}`),
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			original := labelsTestObject.DeepCopy()
+			original.Spec.Taints = tc.oldTaints
+			patched := original.DeepCopy()
+			patch := SetTaints(original, patched, tc.newTaints)
+			data, err := patch.Data(patched)
+			assert.NoError(err)
+			assert.Equal(tc.wantPatch, data)
+		})
+	}
+}
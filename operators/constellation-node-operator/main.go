@@ -9,8 +9,10 @@ package main
 import (
 	"context"
 	"flag"
+	"log/slog"
 	"os"
 	"strings"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
@@ -26,17 +28,24 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 
+	"github.com/edgelesssys/constellation/v2/operators/constellation-node-operator/internal/attestation"
 	cspapi "github.com/edgelesssys/constellation/v2/operators/constellation-node-operator/internal/cloud/api"
 	awsclient "github.com/edgelesssys/constellation/v2/operators/constellation-node-operator/internal/cloud/aws/client"
 	azureclient "github.com/edgelesssys/constellation/v2/operators/constellation-node-operator/internal/cloud/azure/client"
 	cloudfake "github.com/edgelesssys/constellation/v2/operators/constellation-node-operator/internal/cloud/fake/client"
 	gcpclient "github.com/edgelesssys/constellation/v2/operators/constellation-node-operator/internal/cloud/gcp/client"
+	openstackclient "github.com/edgelesssys/constellation/v2/operators/constellation-node-operator/internal/cloud/openstack/client"
+	qemuclient "github.com/edgelesssys/constellation/v2/operators/constellation-node-operator/internal/cloud/qemu/client"
 	"github.com/edgelesssys/constellation/v2/operators/constellation-node-operator/internal/deploy"
 	"github.com/edgelesssys/constellation/v2/operators/constellation-node-operator/internal/executor"
+	"github.com/edgelesssys/constellation/v2/operators/constellation-node-operator/internal/healthcheck"
 	"github.com/edgelesssys/constellation/v2/operators/constellation-node-operator/internal/upgrade"
 	"github.com/edgelesssys/constellation/v2/operators/constellation-node-operator/sgreconciler"
 
 	nodemaintenancev1beta1 "github.com/edgelesssys/constellation/v2/3rdparty/node-maintenance-operator/api/v1beta1"
+	"github.com/edgelesssys/constellation/v2/internal/attestation/variant"
+	"github.com/edgelesssys/constellation/v2/internal/logger"
+	"github.com/edgelesssys/constellation/v2/keyservice/workloadkeys"
 	updatev1alpha1 "github.com/edgelesssys/constellation/v2/operators/constellation-node-operator/api/v1alpha1"
 	"github.com/edgelesssys/constellation/v2/operators/constellation-node-operator/controllers"
 	"github.com/edgelesssys/constellation/v2/operators/constellation-node-operator/internal/etcd"
@@ -69,12 +78,19 @@ func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
 	var probeAddr string
+	var attestationVariant string
+	var attestationInterval time.Duration
+	var cordonFailingNodes bool
 	flag.StringVar(&cloudConfigPath, "cloud-config", "", "Path to provider specific cloud config. Optional.")
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.StringVar(&attestationVariant, "attestation-variant", "",
+		"Attestation variant of the cluster's nodes. Nodes are only attested periodically if this is set.")
+	flag.DurationVar(&attestationInterval, "attestation-interval", 10*time.Minute, "The interval in which every node is attested.")
+	flag.BoolVar(&cordonFailingNodes, "cordon-failing-nodes", false, "Cordon nodes whose attestation fails.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -112,6 +128,14 @@ func main() {
 			setupLog.Error(clientErr, "unable to create AWS client")
 			os.Exit(1)
 		}
+	case "openstack", "stackit":
+		cspClient, clientErr = openstackclient.New(context.Background())
+		if clientErr != nil {
+			setupLog.Error(clientErr, "unable to create OpenStack client")
+			os.Exit(1)
+		}
+	case "qemu":
+		cspClient = qemuclient.New()
 	default:
 		setupLog.Info("CSP does not support upgrades", "csp", csp)
 		cspClient = &cloudfake.Client{}
@@ -167,9 +191,9 @@ func main() {
 		os.Exit(1)
 	}
 	// Create Controllers
-	if csp == "azure" || csp == "gcp" || csp == "aws" {
+	if csp == "azure" || csp == "gcp" || csp == "aws" || csp == "openstack" || csp == "stackit" || csp == "qemu" {
 		if err = controllers.NewNodeVersionReconciler(
-			cspClient, etcdClient, upgrade.NewClient(), discoveryClient, mgr.GetClient(), mgr.GetScheme(),
+			cspClient, etcdClient, upgrade.NewClient(), discoveryClient, healthcheck.NewClient(), mgr.GetClient(), mgr.GetScheme(),
 		).SetupWithManager(mgr); err != nil {
 			setupLog.Error(err, "Unable to create controller", "controller", "NodeVersion")
 			os.Exit(1)
@@ -203,6 +227,40 @@ func main() {
 		os.Exit(1)
 	}
 
+	if err = controllers.NewKeyRotationReconciler(
+		mgr.GetClient(),
+		mgr.GetScheme(),
+	).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "Unable to create controller", "controller", "KeyRotation")
+		os.Exit(1)
+	}
+
+	if err = controllers.NewEtcdBackupReconciler(
+		etcdClient,
+		workloadkeys.New(workloadkeys.DefaultEndpoint, workloadkeys.DefaultTokenPath),
+		mgr.GetClient(),
+		mgr.GetScheme(),
+	).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "Unable to create controller", "controller", "EtcdBackup")
+		os.Exit(1)
+	}
+
+	if attestationVariant != "" {
+		attVariant, err := variant.FromString(attestationVariant)
+		if err != nil {
+			setupLog.Error(err, "Unable to parse attestation variant")
+			os.Exit(1)
+		}
+		if err = controllers.NewNodeAttestationReconciler(
+			attestation.NewClient(logger.NewJSONLogger(slog.LevelInfo).WithGroup("attestation")),
+			attVariant, attestationInterval, cordonFailingNodes,
+			mgr.GetClient(), mgr.GetScheme(),
+		).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "Unable to create controller", "controller", "NodeAttestation")
+			os.Exit(1)
+		}
+	}
+
 	//+kubebuilder:scaffold:builder
 
 	if err = sgreconciler.NewNodeJoinWatcher(
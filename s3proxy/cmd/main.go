@@ -16,16 +16,25 @@ import (
 	"log/slog"
 	"net"
 	"net/http"
+	"os"
+	"strings"
 
 	"github.com/edgelesssys/constellation/v2/internal/logger"
+	"github.com/edgelesssys/constellation/v2/s3proxy/internal/keyring"
+	"github.com/edgelesssys/constellation/v2/s3proxy/internal/kms"
 	"github.com/edgelesssys/constellation/v2/s3proxy/internal/router"
 )
 
 const (
 	// defaultPort is the default port to listen on.
 	defaultPort = 4433
+	// defaultAdminPort is the default port the admin API listens on.
+	defaultAdminPort = 4434
 	// defaultIP is the default IP to listen on.
 	defaultIP = "0.0.0.0"
+	// defaultAdminIP is the default IP the admin API listens on.
+	// The admin API isn't authenticated, so it's only reachable from within the Pod by default, e.g., through kubectl port-forward.
+	defaultAdminIP = "127.0.0.1"
 	// defaultRegion is the default AWS region to use.
 	defaultRegion = "eu-west-1"
 	// defaultCertLocation is the default location of the TLS certificate.
@@ -42,8 +51,15 @@ func main() {
 
 	logger := logger.NewJSONLogger(logger.VerbosityFromInt(flags.logLevel))
 
-	if flags.forwardMultipartReqs {
-		logger.Warn("configured to forward multipart uploads, this may leak data to AWS")
+	if flags.allowLegacyKEK {
+		logger.Warn("objects without a key ID are decrypted with the all-zero legacy KEK: rewrite them with CopyObject and disable allow-legacy-kek")
+	}
+	if flags.adminIP != defaultAdminIP {
+		logger.With(slog.String("ip", flags.adminIP)).Warn("the unauthenticated admin API listens on a non-loopback address: restrict access with a NetworkPolicy")
+	}
+
+	if flags.allowMultipart {
+		logger.Warn("the allow-multipart flag is deprecated and has no effect: multipart uploads are always encrypted")
 	}
 
 	if err := runServer(flags, logger); err != nil {
@@ -54,10 +70,26 @@ func main() {
 func runServer(flags cmdFlags, log *slog.Logger) error {
 	log.With(slog.String("ip", flags.ip), slog.Int("port", defaultPort), slog.String("region", flags.region)).Info("listening")
 
-	router, err := router.New(flags.region, flags.kmsEndpoint, flags.forwardMultipartReqs, log)
+	store, err := keyring.NewConfigMapStore(flags.namespace, flags.keyringConfigMap)
+	if err != nil {
+		return fmt.Errorf("creating keyring store: %w", err)
+	}
+	keys, err := keyring.New(kms.New(log, flags.kmsEndpoint), store, flags.kekPrefixes, flags.allowLegacyKEK, log)
 	if err != nil {
-		return fmt.Errorf("creating router: %w", err)
+		return fmt.Errorf("creating keyring: %w", err)
+	}
+	router := router.New(flags.region, keys, log)
+
+	// The admin API is served without TLS and authentication and must only be reachable by cluster administrators.
+	adminServer := http.Server{
+		Addr:    fmt.Sprintf("%s:%d", flags.adminIP, flags.adminPort),
+		Handler: keyring.NewAdminHandler(keys, log),
 	}
+	adminErr := make(chan error, 1)
+	go func() {
+		log.With(slog.String("ip", flags.adminIP), slog.Int("port", flags.adminPort)).Info("admin API listening")
+		adminErr <- adminServer.ListenAndServe()
+	}()
 
 	server := http.Server{
 		Addr:    fmt.Sprintf("%s:%d", flags.ip, defaultPort),
@@ -80,11 +112,24 @@ func runServer(flags cmdFlags, log *slog.Logger) error {
 		}
 
 		// TLSConfig is populated, so we can safely pass empty strings to ListenAndServeTLS.
-		return server.ListenAndServeTLS("", "")
+		return serve(func() error { return server.ListenAndServeTLS("", "") }, adminErr)
 	}
 
 	log.Warn("TLS is disabled")
-	return server.ListenAndServe()
+	return serve(server.ListenAndServe, adminErr)
+}
+
+// serve runs the S3 API server and returns once the S3 API server or the admin API server stopped.
+func serve(listenAndServe func() error, adminErr <-chan error) error {
+	serverErr := make(chan error, 1)
+	go func() { serverErr <- listenAndServe() }()
+
+	select {
+	case err := <-serverErr:
+		return err
+	case err := <-adminErr:
+		return fmt.Errorf("serving admin API: %w", err)
+	}
 }
 
 func parseFlags() (cmdFlags, error) {
@@ -93,8 +138,14 @@ func parseFlags() (cmdFlags, error) {
 	region := flag.String("region", defaultRegion, "AWS region in which target bucket is located")
 	certLocation := flag.String("cert", defaultCertLocation, "location of TLS certificate")
 	kmsEndpoint := flag.String("kms", "key-service.kube-system:9000", "endpoint of the KMS service to get key encryption keys from")
-	forwardMultipartReqs := flag.Bool("allow-multipart", false, "forward multipart requests to the target bucket; beware: this may store unencrypted data on AWS. See the documentation for more information")
+	// Deprecated: multipart uploads are always encrypted. The flag is kept so existing deployments keep working.
+	allowMultipart := flag.Bool("allow-multipart", false, "deprecated: has no effect, multipart uploads are always encrypted")
 	level := flag.Int("level", defaultLogLevel, "log level")
+	adminIP := flag.String("admin-ip", defaultAdminIP, "ip the unauthenticated admin API listens on")
+	adminPort := flag.Int("admin-port", defaultAdminPort, "port the admin API listens on")
+	allowLegacyKEK := flag.Bool("allow-legacy-kek", false, "decrypt objects without a key ID, written by previous versions of s3proxy, with the all-zero legacy KEK")
+	kekPrefixes := flag.String("kek-prefixes", "", "comma-separated list of <bucket>/<prefix> that use their own KEK, separate from the KEK of the bucket")
+	keyringConfigMap := flag.String("keyring-configmap", "s3proxy-keyring", "name of the ConfigMap that stores the key generations of buckets and prefixes")
 
 	flag.Parse()
 
@@ -102,24 +153,49 @@ func parseFlags() (cmdFlags, error) {
 	if netIP == nil {
 		return cmdFlags{}, fmt.Errorf("not a valid IPv4 address: %s", *ip)
 	}
+	netAdminIP := net.ParseIP(*adminIP)
+	if netAdminIP == nil {
+		return cmdFlags{}, fmt.Errorf("not a valid IPv4 address: %s", *adminIP)
+	}
+
+	namespace := os.Getenv("POD_NAMESPACE")
+	if namespace == "" {
+		return cmdFlags{}, fmt.Errorf("environment variable POD_NAMESPACE must be set")
+	}
+	var prefixes []string
+	if *kekPrefixes != "" {
+		prefixes = strings.Split(*kekPrefixes, ",")
+	}
 
 	return cmdFlags{
-		noTLS:                *noTLS,
-		ip:                   netIP.String(),
-		region:               *region,
-		certLocation:         *certLocation,
-		kmsEndpoint:          *kmsEndpoint,
-		forwardMultipartReqs: *forwardMultipartReqs,
-		logLevel:             *level,
+		noTLS:            *noTLS,
+		ip:               netIP.String(),
+		region:           *region,
+		certLocation:     *certLocation,
+		kmsEndpoint:      *kmsEndpoint,
+		allowMultipart:   *allowMultipart,
+		logLevel:         *level,
+		adminIP:          netAdminIP.String(),
+		adminPort:        *adminPort,
+		allowLegacyKEK:   *allowLegacyKEK,
+		kekPrefixes:      prefixes,
+		keyringConfigMap: *keyringConfigMap,
+		namespace:        namespace,
 	}, nil
 }
 
 type cmdFlags struct {
-	noTLS                bool
-	ip                   string
-	region               string
-	certLocation         string
-	kmsEndpoint          string
-	forwardMultipartReqs bool
-	logLevel             int
+	noTLS            bool
+	ip               string
+	region           string
+	certLocation     string
+	kmsEndpoint      string
+	allowMultipart   bool
+	logLevel         int
+	adminIP          string
+	adminPort        int
+	allowLegacyKEK   bool
+	kekPrefixes      []string
+	keyringConfigMap string
+	namespace        string
 }
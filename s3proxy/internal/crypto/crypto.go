@@ -7,6 +7,10 @@ SPDX-License-Identifier: BUSL-1.1
 /*
 Package crypto provides encryption and decryption functions for the s3proxy.
 It uses AES-256-GCM to encrypt and decrypt data.
+
+Objects are encrypted as a stream of authenticated segments, see EncryptStream.
+Encrypt and Decrypt implement the legacy format, which seals an object as a single AES-GCM-SIV ciphertext.
+They are kept to read objects written by previous versions of s3proxy.
 */
 package crypto
 
@@ -18,6 +22,10 @@ import (
 	"github.com/tink-crypto/tink-go/v2/subtle/random"
 )
 
+// legacyOverhead is the size difference between a ciphertext created by Encrypt and its plaintext:
+// a 12 byte nonce is prepended and a 16 byte tag is appended.
+const legacyOverhead = 12 + 16
+
 // Encrypt generates a random key to encrypt a plaintext using AES-256-GCM.
 // The generated key is encrypted using the supplied key encryption key (KEK).
 // The ciphertext and encrypted data encryption key (DEK) are returned.
@@ -71,3 +79,34 @@ func Decrypt(ciphertext, encryptedDEK []byte, kek [32]byte) ([]byte, error) {
 
 	return plaintext, nil
 }
+
+// LegacyPlaintextSize returns the size of the plaintext of a ciphertext created by Encrypt.
+func LegacyPlaintextSize(ciphertextSize int64) (int64, error) {
+	if ciphertextSize < legacyOverhead {
+		return 0, fmt.Errorf("ciphertext size %d is smaller than the encryption overhead", ciphertextSize)
+	}
+	return ciphertextSize - legacyOverhead, nil
+}
+
+// RewrapDEK decrypts an encrypted DEK with kek and encrypts it again with newKEK.
+// This allows to move an object to another KEK without re-encrypting its data.
+func RewrapDEK(encryptedDEK []byte, kek, newKEK [32]byte) ([]byte, error) {
+	keywrapper, err := kwpsubtle.NewKWP(kek[:])
+	if err != nil {
+		return nil, fmt.Errorf("getting kwp: %w", err)
+	}
+	dek, err := keywrapper.Unwrap(encryptedDEK)
+	if err != nil {
+		return nil, fmt.Errorf("unwrapping dek: %w", err)
+	}
+
+	newKeywrapper, err := kwpsubtle.NewKWP(newKEK[:])
+	if err != nil {
+		return nil, fmt.Errorf("getting kwp: %w", err)
+	}
+	newEncryptedDEK, err := newKeywrapper.Wrap(dek)
+	if err != nil {
+		return nil, fmt.Errorf("wrapping dek: %w", err)
+	}
+	return newEncryptedDEK, nil
+}
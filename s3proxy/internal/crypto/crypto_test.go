@@ -46,3 +46,38 @@ func TestEncryptDecrypt(t *testing.T) {
 		})
 	}
 }
+
+func TestLegacyPlaintextSize(t *testing.T) {
+	for _, size := range []int{0, 1, 12, 1000} {
+		ciphertext, _, err := Encrypt(make([]byte, size), [32]byte{})
+		require.NoError(t, err)
+
+		plaintextSize, err := LegacyPlaintextSize(int64(len(ciphertext)))
+		require.NoError(t, err)
+		assert.Equal(t, int64(size), plaintextSize)
+	}
+
+	_, err := LegacyPlaintextSize(27)
+	assert.Error(t, err)
+}
+
+func TestRewrapDEK(t *testing.T) {
+	kek := [32]byte{1}
+	newKEK := [32]byte{2}
+	plaintext := []byte("hello, world")
+
+	ciphertext, encryptedDEK, err := Encrypt(plaintext, kek)
+	require.NoError(t, err)
+
+	rewrapped, err := RewrapDEK(encryptedDEK, kek, newKEK)
+	require.NoError(t, err)
+
+	decrypted, err := Decrypt(ciphertext, rewrapped, newKEK)
+	require.NoError(t, err)
+	assert.Equal(t, plaintext, decrypted)
+
+	_, err = Decrypt(ciphertext, rewrapped, kek)
+	assert.Error(t, err)
+	_, err = RewrapDEK(encryptedDEK, newKEK, kek)
+	assert.Error(t, err)
+}
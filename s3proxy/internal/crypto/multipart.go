@@ -0,0 +1,282 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package crypto
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/edgelesssys/constellation/v2/internal/crypto/stream"
+	"github.com/tink-crypto/tink-go/v2/subtle/random"
+)
+
+/*
+Multipart uploads are encrypted with a DEK that is generated once per upload.
+Parts are uploaded independently, possibly in parallel and more than once, so every part is encrypted
+as its own stream: a part consists of a random nonce prefix followed by the segments of the part.
+The part number is authenticated as additional data of every segment.
+
+The completed object is the concatenation of its parts. Since S3 doesn't allow to attach data to an object
+once it is completed, the part layout is stored in a separate manifest that is sealed with the DEK.
+The manifest protects against reordering, dropping, or truncating parts.
+*/
+
+const (
+	// multipartVersion is the version of the multipart format.
+	multipartVersion = 2
+	// uploadIDSize is the size of the random upload ID stored in the header.
+	uploadIDSize = 16
+	// multipartHeaderSize is the size of the header of a multipart object: version || upload ID.
+	multipartHeaderSize = 1 + uploadIDSize
+	// PartPrefixSize is the size of the nonce prefix at the start of every part.
+	PartPrefixSize = noncePrefixSize
+	// manifestPartSize is the size of a part entry in the manifest: part number || plaintext size.
+	manifestPartSize = 4 + 8
+)
+
+// NewMultipartUpload generates a random key to encrypt the parts of a multipart upload.
+// The generated key is encrypted using the supplied key encryption key (KEK).
+// The returned header and encrypted data encryption key (DEK) have to be stored alongside the object.
+func NewMultipartUpload(kek [32]byte) (header, encryptedDEK []byte, err error) {
+	_, encryptedDEK, err = generateGCM(kek)
+	if err != nil {
+		return nil, nil, err
+	}
+	header = append([]byte{multipartVersion}, random.GetRandomBytes(uploadIDSize)...)
+	return header, encryptedDEK, nil
+}
+
+// EncryptPart returns a reader that yields the ciphertext of a part of a multipart upload.
+func EncryptPart(plaintext io.Reader, header, encryptedDEK []byte, kek [32]byte, partNumber int32) (io.Reader, error) {
+	if err := validateMultipartHeader(header); err != nil {
+		return nil, err
+	}
+	aead, err := unwrapGCM(encryptedDEK, kek)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := random.GetRandomBytes(PartPrefixSize)
+	return io.MultiReader(bytes.NewReader(prefix), stream.NewEncryptingReader(plaintext, aead, prefix, partAAD(header, partNumber))), nil
+}
+
+// PartCiphertextSize returns the size of the ciphertext of a part with the given plaintext size.
+func PartCiphertextSize(plaintextSize int64) int64 {
+	return PartPrefixSize + CiphertextSize(plaintextSize)
+}
+
+// PartPlaintextSize returns the size of the plaintext of a part with the given ciphertext size.
+func PartPlaintextSize(ciphertextSize int64) (int64, error) {
+	return PlaintextSize(ciphertextSize - PartPrefixSize)
+}
+
+// Manifest describes the parts of a completed multipart object.
+type Manifest struct {
+	Parts []ManifestPart
+}
+
+// ManifestPart is a part of a completed multipart object.
+type ManifestPart struct {
+	// Number is the part number used during upload.
+	Number int32
+	// Size is the plaintext size of the part.
+	Size int64
+}
+
+// Size returns the plaintext size of the object.
+func (m Manifest) Size() int64 {
+	var size int64
+	for _, part := range m.Parts {
+		size += part.Size
+	}
+	return size
+}
+
+// CiphertextSize returns the ciphertext size of the object.
+func (m Manifest) CiphertextSize() int64 {
+	var size int64
+	for _, part := range m.Parts {
+		size += PartCiphertextSize(part.Size)
+	}
+	return size
+}
+
+// CiphertextRange returns the ciphertext byte range [ctStart, ctEnd] holding all segments
+// that cover the plaintext bytes [start, end] of the object.
+// Decrypting the first part additionally requires its nonce prefix, which is located at prefixOffset.
+// If the range doesn't start with the prefix, i.e. prefixOffset != ctStart, the prefix has to be fetched separately.
+func (m Manifest) CiphertextRange(start, end int64) (ctStart, ctEnd, prefixOffset int64) {
+	var offset, ctOffset int64
+	ctStart, ctEnd, prefixOffset = -1, -1, -1
+	for _, part := range m.Parts {
+		partEnd := offset + part.Size - 1
+		if ctStart < 0 && start <= partEnd {
+			prefixOffset = ctOffset
+			ctStart = ctOffset
+			if segmentStart, _ := CiphertextRange(start-offset, start-offset, part.Size); segmentStart > 0 {
+				ctStart = ctOffset + PartPrefixSize + segmentStart
+			}
+		}
+		if end <= partEnd {
+			_, segmentEnd := CiphertextRange(max(start-offset, 0), end-offset, part.Size)
+			ctEnd = ctOffset + PartPrefixSize + segmentEnd
+			break
+		}
+		offset += part.Size
+		ctOffset += PartCiphertextSize(part.Size)
+	}
+	return ctStart, ctEnd, prefixOffset
+}
+
+// DecryptParts returns a reader that yields the plaintext bytes [start, end] of a multipart object.
+// ciphertext has to hold the ciphertext bytes returned by m.CiphertextRange for the same range.
+// If the nonce prefix of the first part isn't part of that range, it has to be passed as prefix.
+func DecryptParts(ciphertext io.Reader, prefix, header, encryptedDEK []byte, kek [32]byte, m Manifest, start, end int64) (io.Reader, error) {
+	if err := validateMultipartHeader(header); err != nil {
+		return nil, err
+	}
+	if start < 0 || start > end || end >= m.Size() {
+		return nil, fmt.Errorf("invalid range %d-%d for object of size %d", start, end, m.Size())
+	}
+	aead, err := unwrapGCM(encryptedDEK, kek)
+	if err != nil {
+		return nil, err
+	}
+
+	var readers []io.Reader
+	var offset int64
+	for _, part := range m.Parts {
+		partStart, partEnd := offset, offset+part.Size-1
+		offset += part.Size
+		if partEnd < start || partStart > end {
+			continue
+		}
+
+		readers = append(readers, &partReader{
+			src:        ciphertext,
+			aead:       aead,
+			prefix:     prefix,
+			aad:        partAAD(header, part.Number),
+			start:      max(start, partStart) - partStart,
+			end:        min(end, partEnd) - partStart,
+			size:       part.Size,
+			readPrefix: len(readers) > 0 || prefix == nil,
+		})
+	}
+	return io.MultiReader(readers...), nil
+}
+
+// SealManifest encrypts and authenticates the manifest of a multipart object.
+func SealManifest(m Manifest, header, encryptedDEK []byte, kek [32]byte) ([]byte, error) {
+	if err := validateMultipartHeader(header); err != nil {
+		return nil, err
+	}
+	if len(m.Parts) == 0 {
+		return nil, errors.New("manifest has no parts")
+	}
+	aead, err := unwrapGCM(encryptedDEK, kek)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext := binary.BigEndian.AppendUint32(nil, uint32(len(m.Parts)))
+	for _, part := range m.Parts {
+		plaintext = binary.BigEndian.AppendUint32(plaintext, uint32(part.Number))
+		plaintext = binary.BigEndian.AppendUint64(plaintext, uint64(part.Size))
+	}
+
+	// Part numbers start at 1, so the manifest never shares additional data with a part.
+	prefix := random.GetRandomBytes(PartPrefixSize)
+	return aead.Seal(prefix, stream.SegmentNonce(prefix, 0, true), plaintext, partAAD(header, 0)), nil
+}
+
+// OpenManifest decrypts and verifies the manifest of a multipart object.
+func OpenManifest(sealed, header, encryptedDEK []byte, kek [32]byte) (Manifest, error) {
+	if err := validateMultipartHeader(header); err != nil {
+		return Manifest{}, err
+	}
+	if len(sealed) < PartPrefixSize {
+		return Manifest{}, errors.New("manifest too short")
+	}
+	aead, err := unwrapGCM(encryptedDEK, kek)
+	if err != nil {
+		return Manifest{}, err
+	}
+
+	prefix := sealed[:PartPrefixSize]
+	plaintext, err := aead.Open(nil, stream.SegmentNonce(prefix, 0, true), sealed[PartPrefixSize:], partAAD(header, 0))
+	if err != nil {
+		return Manifest{}, fmt.Errorf("decrypting manifest: %w", err)
+	}
+
+	if len(plaintext) < 4 {
+		return Manifest{}, errors.New("invalid manifest")
+	}
+	count := binary.BigEndian.Uint32(plaintext)
+	plaintext = plaintext[4:]
+	if count == 0 || uint64(len(plaintext)) != uint64(count)*manifestPartSize {
+		return Manifest{}, errors.New("invalid manifest")
+	}
+	var m Manifest
+	for i := range count {
+		entry := plaintext[i*manifestPartSize:]
+		m.Parts = append(m.Parts, ManifestPart{
+			Number: int32(binary.BigEndian.Uint32(entry)),
+			Size:   int64(binary.BigEndian.Uint64(entry[4:])),
+		})
+	}
+	return m, nil
+}
+
+// partReader decrypts the plaintext bytes [start, end] of a single part.
+type partReader struct {
+	src        io.Reader
+	aead       cipher.AEAD
+	prefix     []byte
+	aad        []byte
+	start, end int64
+	size       int64
+	readPrefix bool
+	plaintext  io.Reader
+}
+
+// Read implements io.Reader.
+func (r *partReader) Read(p []byte) (int, error) {
+	if r.plaintext == nil {
+		// The nonce prefix can only be read once the previous part was consumed.
+		if r.readPrefix {
+			r.prefix = make([]byte, PartPrefixSize)
+			if _, err := io.ReadFull(r.src, r.prefix); err != nil {
+				if errors.Is(err, io.EOF) {
+					err = io.ErrUnexpectedEOF
+				}
+				return 0, fmt.Errorf("reading part prefix: %w", err)
+			}
+		}
+		r.plaintext = stream.NewRangeReader(r.src, r.aead, r.prefix, r.aad, r.start, r.end, r.size)
+	}
+	return r.plaintext.Read(p)
+}
+
+// partAAD returns the additional data of the segments of a part: header || part number.
+func partAAD(header []byte, partNumber int32) []byte {
+	return binary.BigEndian.AppendUint32(bytes.Clone(header), uint32(partNumber))
+}
+
+func validateMultipartHeader(header []byte) error {
+	if len(header) != multipartHeaderSize {
+		return fmt.Errorf("invalid header size %d", len(header))
+	}
+	if header[0] != multipartVersion {
+		return fmt.Errorf("unsupported multipart version %d", header[0])
+	}
+	return nil
+}
@@ -0,0 +1,192 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package crypto
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMultipart(t *testing.T) {
+	kek := [32]byte{}
+	_, err := rand.Read(kek[:])
+	require.NoError(t, err)
+	header, encryptedDEK, err := NewMultipartUpload(kek)
+	require.NoError(t, err)
+
+	// Part numbers may have gaps and part sizes are not aligned to segments.
+	manifest := Manifest{Parts: []ManifestPart{
+		{Number: 1, Size: 2*SegmentSize + 10},
+		{Number: 3, Size: SegmentSize},
+		{Number: 4, Size: 100},
+	}}
+	parts := map[int32][]byte{}
+	for _, part := range manifest.Parts {
+		plaintext := make([]byte, part.Size)
+		_, err := rand.Read(plaintext)
+		require.NoError(t, err)
+		parts[part.Number] = plaintext
+	}
+
+	var plaintext, ciphertext []byte
+	for i := len(manifest.Parts) - 1; i >= 0; i-- {
+		part := manifest.Parts[i]
+		encrypter, err := EncryptPart(bytes.NewReader(parts[part.Number]), header, encryptedDEK, kek, part.Number)
+		require.NoError(t, err)
+		partCiphertext, err := io.ReadAll(encrypter)
+		require.NoError(t, err)
+		require.Len(t, partCiphertext, int(PartCiphertextSize(part.Size)))
+		partSize, err := PartPlaintextSize(int64(len(partCiphertext)))
+		require.NoError(t, err)
+		require.Equal(t, part.Size, partSize)
+
+		plaintext = append(parts[part.Number], plaintext...)
+		ciphertext = append(partCiphertext, ciphertext...)
+	}
+	require.Equal(t, manifest.Size(), int64(len(plaintext)))
+	require.Equal(t, manifest.CiphertextSize(), int64(len(ciphertext)))
+
+	sealed, err := SealManifest(manifest, header, encryptedDEK, kek)
+	require.NoError(t, err)
+	opened, err := OpenManifest(sealed, header, encryptedDEK, kek)
+	require.NoError(t, err)
+	assert.Equal(t, manifest, opened)
+
+	size := manifest.Size()
+	testCases := map[string]struct {
+		start, end int64
+	}{
+		"whole object":            {start: 0, end: size - 1},
+		"first byte":              {start: 0, end: 0},
+		"last byte":               {start: size - 1, end: size - 1},
+		"within first segment":    {start: 10, end: 20},
+		"within later segment":    {start: SegmentSize + 10, end: SegmentSize + 20},
+		"across parts":            {start: 2*SegmentSize + 5, end: 2*SegmentSize + 15},
+		"from segment to end":     {start: SegmentSize + 1, end: size - 1},
+		"whole second part":       {start: 2*SegmentSize + 10, end: 3*SegmentSize + 9},
+		"second part to last one": {start: 3 * SegmentSize, end: size - 50},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			ctStart, ctEnd, prefixOffset := opened.CiphertextRange(tc.start, tc.end)
+			var prefix []byte
+			if prefixOffset != ctStart {
+				prefix = ciphertext[prefixOffset : prefixOffset+PartPrefixSize]
+			}
+			decrypter, err := DecryptParts(bytes.NewReader(ciphertext[ctStart:ctEnd+1]), prefix, header, encryptedDEK, kek, opened, tc.start, tc.end)
+			require.NoError(err)
+			decrypted, err := io.ReadAll(decrypter)
+			require.NoError(err)
+			assert.Equal(plaintext[tc.start:tc.end+1], decrypted)
+		})
+	}
+
+	t.Run("swapped parts", func(t *testing.T) {
+		swapped := Manifest{Parts: []ManifestPart{{Number: 1, Size: 10}, {Number: 2, Size: 10}}}
+		var swappedCiphertext []byte
+		for _, number := range []int32{2, 1} {
+			encrypter, err := EncryptPart(bytes.NewReader(make([]byte, 10)), header, encryptedDEK, kek, number)
+			require.NoError(t, err)
+			partCiphertext, err := io.ReadAll(encrypter)
+			require.NoError(t, err)
+			swappedCiphertext = append(swappedCiphertext, partCiphertext...)
+		}
+
+		decrypter, err := DecryptParts(bytes.NewReader(swappedCiphertext), nil, header, encryptedDEK, kek, swapped, 0, swapped.Size()-1)
+		require.NoError(t, err)
+		_, err = io.ReadAll(decrypter)
+		assert.Error(t, err)
+	})
+
+	t.Run("truncated part", func(t *testing.T) {
+		decrypter, err := DecryptParts(bytes.NewReader(ciphertext[:len(ciphertext)-1]), nil, header, encryptedDEK, kek, manifest, 0, size-1)
+		require.NoError(t, err)
+		_, err = io.ReadAll(decrypter)
+		assert.Error(t, err)
+	})
+}
+
+func TestOpenManifest(t *testing.T) {
+	kek := [32]byte{}
+	_, err := rand.Read(kek[:])
+	require.NoError(t, err)
+	header, encryptedDEK, err := NewMultipartUpload(kek)
+	require.NoError(t, err)
+	otherHeader, _, err := NewMultipartUpload(kek)
+	require.NoError(t, err)
+
+	manifest := Manifest{Parts: []ManifestPart{{Number: 1, Size: 5}}}
+	sealed, err := SealManifest(manifest, header, encryptedDEK, kek)
+	require.NoError(t, err)
+
+	testCases := map[string]struct {
+		sealed  []byte
+		header  []byte
+		kek     [32]byte
+		wantErr bool
+	}{
+		"valid": {
+			sealed: sealed,
+			header: header,
+			kek:    kek,
+		},
+		"modified manifest": {
+			sealed: func() []byte {
+				s := bytes.Clone(sealed)
+				s[len(s)-1] ^= 1
+				return s
+			}(),
+			header:  header,
+			kek:     kek,
+			wantErr: true,
+		},
+		"manifest of other upload": {
+			sealed:  sealed,
+			header:  otherHeader,
+			kek:     kek,
+			wantErr: true,
+		},
+		"too short": {
+			sealed:  sealed[:3],
+			header:  header,
+			kek:     kek,
+			wantErr: true,
+		},
+		"wrong kek": {
+			sealed:  sealed,
+			header:  header,
+			wantErr: true,
+		},
+		"single stream header": {
+			sealed:  sealed,
+			header:  header[:headerSize],
+			kek:     kek,
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			opened, err := OpenManifest(tc.sealed, tc.header, encryptedDEK, tc.kek)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, manifest, opened)
+		})
+	}
+}
@@ -0,0 +1,141 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"fmt"
+	"io"
+
+	"github.com/edgelesssys/constellation/v2/internal/crypto/stream"
+	kwpsubtle "github.com/tink-crypto/tink-go/v2/kwp/subtle"
+	"github.com/tink-crypto/tink-go/v2/subtle/random"
+)
+
+/*
+Objects are encrypted in segments using the STREAM construction implemented by the stream package.
+Every object has its own random DEK and nonce prefix.
+
+Every object has a header that holds the format version and the nonce prefix.
+The header is not part of the ciphertext, but is authenticated as additional data of every segment.
+*/
+
+const (
+	// SegmentSize is the size of a plaintext segment.
+	SegmentSize = stream.SegmentSize
+	// streamVersion is the version of the stream format.
+	streamVersion = 1
+	// noncePrefixSize is the size of the random nonce prefix stored in the header.
+	noncePrefixSize = stream.NoncePrefixSize
+	// headerSize is the size of the object header: version || nonce prefix.
+	headerSize = 1 + noncePrefixSize
+)
+
+// EncryptStream generates a random key to encrypt a plaintext stream in segments using AES-256-GCM.
+// The generated key is encrypted using the supplied key encryption key (KEK).
+// The returned reader yields the ciphertext. The object header and encrypted data encryption key (DEK)
+// have to be stored alongside the ciphertext.
+func EncryptStream(plaintext io.Reader, kek [32]byte) (ciphertext io.Reader, header, encryptedDEK []byte, err error) {
+	aead, encryptedDEK, err := generateGCM(kek)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	header = append([]byte{streamVersion}, random.GetRandomBytes(noncePrefixSize)...)
+
+	return stream.NewEncryptingReader(plaintext, aead, header[1:], header), header, encryptedDEK, nil
+}
+
+// DecryptStream returns a reader that yields the plaintext bytes [start, end] of an object with the given plaintext size.
+// ciphertext has to hold the ciphertext bytes returned by CiphertextRange for the same arguments.
+// Segments are only released after they were authenticated.
+func DecryptStream(ciphertext io.Reader, header, encryptedDEK []byte, kek [32]byte, start, end, plaintextSize int64) (io.Reader, error) {
+	if err := validateHeader(header); err != nil {
+		return nil, err
+	}
+	if start < 0 || start > end+1 || end >= plaintextSize {
+		return nil, fmt.Errorf("invalid range %d-%d for object of size %d", start, end, plaintextSize)
+	}
+
+	aead, err := unwrapGCM(encryptedDEK, kek)
+	if err != nil {
+		return nil, err
+	}
+
+	return stream.NewRangeReader(ciphertext, aead, header[1:], header, start, end, plaintextSize), nil
+}
+
+// CiphertextSize returns the size of the ciphertext of a plaintext with the given size.
+func CiphertextSize(plaintextSize int64) int64 {
+	return stream.CiphertextSize(plaintextSize)
+}
+
+// PlaintextSize returns the size of the plaintext of a ciphertext with the given size.
+func PlaintextSize(ciphertextSize int64) (int64, error) {
+	return stream.PlaintextSize(ciphertextSize)
+}
+
+// CiphertextRange returns the ciphertext byte range [ctStart, ctEnd] holding all segments
+// that cover the plaintext bytes [start, end] of an object with the given plaintext size.
+func CiphertextRange(start, end, plaintextSize int64) (ctStart, ctEnd int64) {
+	return stream.CiphertextRange(start, end, plaintextSize)
+}
+
+func validateHeader(header []byte) error {
+	if len(header) != headerSize {
+		return fmt.Errorf("invalid header size %d", len(header))
+	}
+	if header[0] != streamVersion {
+		return fmt.Errorf("unsupported stream version %d", header[0])
+	}
+	return nil
+}
+
+// generateGCM generates a random DEK and returns an AES-GCM instance using it, and the DEK encrypted with the KEK.
+func generateGCM(kek [32]byte) (cipher.AEAD, []byte, error) {
+	dek := random.GetRandomBytes(32)
+	aead, err := newGCM(dek)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	keywrapper, err := kwpsubtle.NewKWP(kek[:])
+	if err != nil {
+		return nil, nil, fmt.Errorf("getting kwp: %w", err)
+	}
+	encryptedDEK, err := keywrapper.Wrap(dek)
+	if err != nil {
+		return nil, nil, fmt.Errorf("wrapping dek: %w", err)
+	}
+	return aead, encryptedDEK, nil
+}
+
+// unwrapGCM unwraps an encrypted DEK and returns an AES-GCM instance using it.
+func unwrapGCM(encryptedDEK []byte, kek [32]byte) (cipher.AEAD, error) {
+	keywrapper, err := kwpsubtle.NewKWP(kek[:])
+	if err != nil {
+		return nil, fmt.Errorf("getting kwp: %w", err)
+	}
+	dek, err := keywrapper.Unwrap(encryptedDEK)
+	if err != nil {
+		return nil, fmt.Errorf("unwrapping dek: %w", err)
+	}
+	return newGCM(dek)
+}
+
+func newGCM(dek []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(dek)
+	if err != nil {
+		return nil, fmt.Errorf("creating aes cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("getting aesgcm: %w", err)
+	}
+	return aead, nil
+}
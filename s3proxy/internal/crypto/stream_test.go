@@ -0,0 +1,176 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package crypto
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"io"
+	"testing"
+	"testing/iotest"
+
+	"github.com/edgelesssys/constellation/v2/internal/crypto/stream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptDecryptStream(t *testing.T) {
+	sizes := map[string]int{
+		"empty":                 0,
+		"single byte":           1,
+		"less than one segment": SegmentSize - 1,
+		"one segment":           SegmentSize,
+		"one segment and a bit": SegmentSize + 1,
+		"multiple segments":     3*SegmentSize + 1234,
+	}
+
+	for name, size := range sizes {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			kek := [32]byte{}
+			_, err := rand.Read(kek[:])
+			require.NoError(err)
+			plaintext := make([]byte, size)
+			_, err = rand.Read(plaintext)
+			require.NoError(err)
+
+			// OneByteReader makes sure segments are assembled from short reads.
+			encrypter, header, encryptedDEK, err := EncryptStream(iotest.OneByteReader(bytes.NewReader(plaintext)), kek)
+			require.NoError(err)
+			ciphertext, err := io.ReadAll(encrypter)
+			require.NoError(err)
+			assert.Len(ciphertext, int(CiphertextSize(int64(size))))
+
+			plaintextSize, err := PlaintextSize(int64(len(ciphertext)))
+			require.NoError(err)
+			assert.Equal(int64(size), plaintextSize)
+
+			decrypter, err := DecryptStream(bytes.NewReader(ciphertext), header, encryptedDEK, kek, 0, plaintextSize-1, plaintextSize)
+			require.NoError(err)
+			decrypted, err := io.ReadAll(decrypter)
+			require.NoError(err)
+			assert.Equal(plaintext, decrypted)
+		})
+	}
+}
+
+func TestDecryptStreamRange(t *testing.T) {
+	const size = 3*SegmentSize + 100
+
+	testCases := map[string]struct {
+		start, end int64
+	}{
+		"first byte":           {start: 0, end: 0},
+		"last byte":            {start: size - 1, end: size - 1},
+		"within segment":       {start: 10, end: 20},
+		"segment boundary":     {start: SegmentSize - 1, end: SegmentSize},
+		"full second segment":  {start: SegmentSize, end: 2*SegmentSize - 1},
+		"spanning segments":    {start: 100, end: 2*SegmentSize + 5},
+		"final segment only":   {start: 3 * SegmentSize, end: size - 1},
+		"whole object":         {start: 0, end: size - 1},
+		"start of last two":    {start: 2*SegmentSize + 1, end: size - 1},
+		"first segment, full":  {start: 0, end: SegmentSize - 1},
+		"middle to final byte": {start: SegmentSize + 7, end: size - 1},
+	}
+
+	kek := [32]byte{}
+	_, err := rand.Read(kek[:])
+	require.NoError(t, err)
+	plaintext := make([]byte, size)
+	_, err = rand.Read(plaintext)
+	require.NoError(t, err)
+	encrypter, header, encryptedDEK, err := EncryptStream(bytes.NewReader(plaintext), kek)
+	require.NoError(t, err)
+	ciphertext, err := io.ReadAll(encrypter)
+	require.NoError(t, err)
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			ctStart, ctEnd := CiphertextRange(tc.start, tc.end, size)
+			decrypter, err := DecryptStream(bytes.NewReader(ciphertext[ctStart:ctEnd+1]), header, encryptedDEK, kek, tc.start, tc.end, size)
+			require.NoError(err)
+			decrypted, err := io.ReadAll(decrypter)
+			require.NoError(err)
+			assert.Equal(plaintext[tc.start:tc.end+1], decrypted)
+		})
+	}
+}
+
+func TestDecryptStreamTampering(t *testing.T) {
+	kek := [32]byte{}
+	_, err := rand.Read(kek[:])
+	require.NoError(t, err)
+	plaintext := make([]byte, 2*SegmentSize+10)
+	encrypter, header, encryptedDEK, err := EncryptStream(bytes.NewReader(plaintext), kek)
+	require.NoError(t, err)
+	ciphertext, err := io.ReadAll(encrypter)
+	require.NoError(t, err)
+
+	testCases := map[string]struct {
+		ciphertext    []byte
+		header        []byte
+		plaintextSize int64
+	}{
+		"modified segment": {
+			ciphertext: func() []byte {
+				c := bytes.Clone(ciphertext)
+				c[stream.CiphertextSegmentSize+1] ^= 1
+				return c
+			}(),
+			header:        header,
+			plaintextSize: int64(len(plaintext)),
+		},
+		"modified header": {
+			ciphertext:    ciphertext,
+			header:        append([]byte{streamVersion}, bytes.Repeat([]byte{0}, noncePrefixSize)...),
+			plaintextSize: int64(len(plaintext)),
+		},
+		"truncated to full segments": {
+			ciphertext:    ciphertext[:2*stream.CiphertextSegmentSize],
+			header:        header,
+			plaintextSize: 2 * SegmentSize,
+		},
+		"swapped segments": {
+			ciphertext: func() []byte {
+				c := bytes.Clone(ciphertext)
+				copy(c, ciphertext[stream.CiphertextSegmentSize:2*stream.CiphertextSegmentSize])
+				copy(c[stream.CiphertextSegmentSize:], ciphertext[:stream.CiphertextSegmentSize])
+				return c
+			}(),
+			header:        header,
+			plaintextSize: int64(len(plaintext)),
+		},
+		"short read": {
+			ciphertext:    ciphertext[:len(ciphertext)-1],
+			header:        header,
+			plaintextSize: int64(len(plaintext)),
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			decrypter, err := DecryptStream(bytes.NewReader(tc.ciphertext), tc.header, encryptedDEK, kek, 0, tc.plaintextSize-1, tc.plaintextSize)
+			require.NoError(t, err)
+			_, err = io.ReadAll(decrypter)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestEncryptStreamReadError(t *testing.T) {
+	someErr := errors.New("failed")
+	encrypter, _, _, err := EncryptStream(io.MultiReader(bytes.NewReader(make([]byte, SegmentSize+1)), iotest.ErrReader(someErr)), [32]byte{})
+	require.NoError(t, err)
+	_, err = io.ReadAll(encrypter)
+	assert.ErrorIs(t, err, someErr)
+}
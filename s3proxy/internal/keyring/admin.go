@@ -0,0 +1,42 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package keyring
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+)
+
+// NewAdminHandler returns the handler of the admin API.
+// The admin API isn't authenticated and must only be reachable by cluster administrators, as it allows to revoke the access of s3proxy to data.
+//
+//	POST /shred?bucket=<bucket>[&prefix=<prefix>]
+func NewAdminHandler(k *Keyring, log *slog.Logger) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /shred", func(w http.ResponseWriter, r *http.Request) {
+		bucket := r.URL.Query().Get("bucket")
+		prefix := r.URL.Query().Get("prefix")
+		log := log.With(slog.String("bucket", bucket), slog.String("prefix", prefix))
+		if bucket == "" {
+			http.Error(w, "missing bucket parameter", http.StatusBadRequest)
+			return
+		}
+
+		if err := k.Shred(r.Context(), bucket, prefix); err != nil {
+			log.With(slog.Any("error", err)).Error("Shredding key")
+			if errors.Is(err, ErrUnknownPrefix) {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	return mux
+}
@@ -0,0 +1,111 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package keyring
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/retry"
+)
+
+// ConfigMapStore stores key generations in a ConfigMap.
+// Every bucket is stored as a separate key of the ConfigMap, holding a JSON encoded map of prefixes to generations.
+type ConfigMapStore struct {
+	client    kubernetes.Interface
+	namespace string
+	name      string
+}
+
+// NewConfigMapStore creates a new ConfigMapStore using the in-cluster configuration.
+func NewConfigMapStore(namespace, name string) (*ConfigMapStore, error) {
+	config, err := rest.InClusterConfig()
+	if err != nil {
+		return nil, fmt.Errorf("creating in-cluster config: %w", err)
+	}
+	client, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("creating clientset: %w", err)
+	}
+	return &ConfigMapStore{client: client, namespace: namespace, name: name}, nil
+}
+
+// Generations returns the key generations of the bucket.
+// Buckets and prefixes that were never shredded use generation 0.
+func (s *ConfigMapStore) Generations(ctx context.Context, bucket string) (map[string]uint64, error) {
+	cm, err := s.client.CoreV1().ConfigMaps(s.namespace).Get(ctx, s.name, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		return map[string]uint64{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting ConfigMap %s/%s: %w", s.namespace, s.name, err)
+	}
+	return decodeGenerations(cm.Data[bucket])
+}
+
+// Increment increments the key generations of the given prefixes of the bucket.
+func (s *ConfigMapStore) Increment(ctx context.Context, bucket string, prefixes []string) (map[string]uint64, error) {
+	var generations map[string]uint64
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cm, err := s.client.CoreV1().ConfigMaps(s.namespace).Get(ctx, s.name, metav1.GetOptions{})
+		notFound := k8serrors.IsNotFound(err)
+		if err != nil && !notFound {
+			return fmt.Errorf("getting ConfigMap %s/%s: %w", s.namespace, s.name, err)
+		}
+		if notFound {
+			cm = &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: s.name, Namespace: s.namespace}}
+		}
+		if cm.Data == nil {
+			cm.Data = map[string]string{}
+		}
+
+		generations, err = decodeGenerations(cm.Data[bucket])
+		if err != nil {
+			return err
+		}
+		for _, prefix := range prefixes {
+			generations[prefix]++
+		}
+		encoded, err := json.Marshal(generations)
+		if err != nil {
+			return fmt.Errorf("encoding key generations: %w", err)
+		}
+		cm.Data[bucket] = string(encoded)
+
+		if notFound {
+			_, err = s.client.CoreV1().ConfigMaps(s.namespace).Create(ctx, cm, metav1.CreateOptions{})
+			if k8serrors.IsAlreadyExists(err) {
+				// Another replica created the ConfigMap concurrently. Retry with the existing one.
+				return k8serrors.NewConflict(corev1.Resource("configmaps"), s.name, err)
+			}
+		} else {
+			_, err = s.client.CoreV1().ConfigMaps(s.namespace).Update(ctx, cm, metav1.UpdateOptions{})
+		}
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("updating ConfigMap %s/%s: %w", s.namespace, s.name, err)
+	}
+	return generations, nil
+}
+
+func decodeGenerations(raw string) (map[string]uint64, error) {
+	generations := map[string]uint64{}
+	if raw == "" {
+		return generations, nil
+	}
+	if err := json.Unmarshal([]byte(raw), &generations); err != nil {
+		return nil, fmt.Errorf("decoding key generations: %w", err)
+	}
+	return generations, nil
+}
@@ -0,0 +1,294 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+/*
+Package keyring manages the key encryption keys (KEKs) of s3proxy.
+
+Every bucket, and optionally every configured prefix within a bucket, is encrypted with its own KEK.
+KEKs are derived by Constellation's keyservice from a data key ID of the form
+
+	s3proxy/<bucket>/<generation>/<prefix>
+
+The data key ID is stored in the metadata of every object, so the KEK of an object can be derived again on retrieval.
+Shredding a bucket or prefix increments its generation. Objects that were encrypted with a KEK of a previous generation
+can't be decrypted by s3proxy anymore.
+Shredding revokes the access of s3proxy to a KEK, it doesn't erase the KEK: the keyservice can still derive KEKs of previous generations.
+The generations are stored in a ConfigMap inside the cluster, since the storage provider isn't trusted.
+
+Objects written by previous versions of s3proxy don't store a data key ID and are encrypted with an all-zero KEK.
+They can only be decrypted if the legacy KEK is explicitly allowed.
+*/
+package keyring
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// keyIDPrefix is the prefix of all data key IDs used by s3proxy.
+	keyIDPrefix = "s3proxy"
+	// kekSizeBytes is the size of a KEK. Use a 32*8 = 256 bit key for AES-256.
+	kekSizeBytes = 32
+	// refreshInterval is the interval after which cached generations are fetched again.
+	// Shredding a bucket or prefix takes effect on other s3proxy replicas after at most this interval.
+	refreshInterval = 30 * time.Second
+)
+
+var (
+	// ErrShredded is returned if an object was encrypted with a KEK that was shredded.
+	ErrShredded = errors.New("the key of the object was shredded")
+	// ErrLegacyKEK is returned if an object without a data key ID is decrypted, but the legacy all-zero KEK isn't allowed.
+	ErrLegacyKEK = errors.New("the object has no key ID and the legacy KEK isn't allowed")
+	// ErrUnknownPrefix is returned if a prefix that wasn't configured is shredded.
+	ErrUnknownPrefix = errors.New("prefix isn't configured to use its own key")
+)
+
+// Keyring derives and caches the KEKs of buckets and prefixes.
+type Keyring struct {
+	kms      dataKeyGetter
+	store    generationStore
+	prefixes map[string][]string
+	// allowLegacyKEK allows decrypting objects without a data key ID with the all-zero KEK.
+	allowLegacyKEK bool
+	log            *slog.Logger
+	now            func() time.Time
+
+	mu          sync.Mutex
+	keks        map[string][32]byte
+	generations map[string]cachedGenerations
+}
+
+type cachedGenerations struct {
+	generations map[string]uint64
+	fetched     time.Time
+}
+
+// New creates a new Keyring.
+// prefixes are given as "<bucket>/<prefix>" and use their own KEK, separate from the KEK of the bucket.
+// If allowLegacyKEK is set, objects without a data key ID are decrypted with the all-zero KEK of previous versions of s3proxy.
+func New(kms dataKeyGetter, store generationStore, prefixes []string, allowLegacyKEK bool, log *slog.Logger) (*Keyring, error) {
+	bucketPrefixes := map[string][]string{}
+	for _, p := range prefixes {
+		bucket, prefix, ok := strings.Cut(p, "/")
+		if !ok || bucket == "" || prefix == "" {
+			return nil, fmt.Errorf("invalid prefix %q, expected <bucket>/<prefix>", p)
+		}
+		bucketPrefixes[bucket] = append(bucketPrefixes[bucket], prefix)
+	}
+	for _, p := range bucketPrefixes {
+		// The longest matching prefix determines the key of an object.
+		sort.Slice(p, func(i, j int) bool { return len(p[i]) > len(p[j]) })
+	}
+
+	return &Keyring{
+		kms:            kms,
+		store:          store,
+		prefixes:       bucketPrefixes,
+		allowLegacyKEK: allowLegacyKEK,
+		log:            log,
+		now:            time.Now,
+		keks:           map[string][32]byte{},
+		generations:    map[string]cachedGenerations{},
+	}, nil
+}
+
+// CurrentKey returns the data key ID and KEK to encrypt a new object with.
+func (k *Keyring) CurrentKey(ctx context.Context, bucket, objectKey string) (keyID string, kek [32]byte, err error) {
+	prefix := k.prefixOf(bucket, objectKey)
+	generations, err := k.getGenerations(ctx, bucket, false)
+	if err != nil {
+		return "", [32]byte{}, err
+	}
+
+	keyID = formatKeyID(bucket, prefix, generations[prefix])
+	kek, err = k.getKEK(ctx, keyID)
+	if err != nil {
+		return "", [32]byte{}, err
+	}
+	return keyID, kek, nil
+}
+
+// Key returns the KEK for the data key ID stored with an object.
+// Objects without a data key ID were written by previous versions of s3proxy, which didn't pass
+// the KEK fetched from the keyservice to request handlers. These objects are encrypted with an all-zero KEK,
+// which is only returned if the legacy KEK is allowed. Otherwise, ErrLegacyKEK is returned.
+func (k *Keyring) Key(ctx context.Context, bucket, keyID string) ([32]byte, error) {
+	if keyID == "" {
+		if !k.allowLegacyKEK {
+			return [32]byte{}, ErrLegacyKEK
+		}
+		return [32]byte{}, nil
+	}
+
+	keyBucket, prefix, generation, err := parseKeyID(keyID)
+	if err != nil {
+		return [32]byte{}, err
+	}
+	if keyBucket != bucket {
+		return [32]byte{}, fmt.Errorf("key %q belongs to bucket %q", keyID, keyBucket)
+	}
+
+	generations, err := k.getGenerations(ctx, bucket, false)
+	if err != nil {
+		return [32]byte{}, err
+	}
+	if generation > generations[prefix] {
+		// The key may have been rotated by another replica since the generations were cached.
+		generations, err = k.getGenerations(ctx, bucket, true)
+		if err != nil {
+			return [32]byte{}, err
+		}
+	}
+	switch {
+	case generation < generations[prefix]:
+		return [32]byte{}, ErrShredded
+	case generation > generations[prefix]:
+		return [32]byte{}, fmt.Errorf("key %q has unknown generation %d", keyID, generation)
+	}
+
+	return k.getKEK(ctx, keyID)
+}
+
+// Shred rotates the data key ID of a bucket or prefix.
+// Objects encrypted with the previous key can't be decrypted anymore.
+// Shredding a bucket, i.e. passing an empty prefix, also shreds all prefixes of the bucket.
+func (k *Keyring) Shred(ctx context.Context, bucket, prefix string) error {
+	prefixes := []string{prefix}
+	if prefix == "" {
+		prefixes = append(prefixes, k.prefixes[bucket]...)
+	} else if !k.isPrefix(bucket, prefix) {
+		return ErrUnknownPrefix
+	}
+
+	generations, err := k.store.Increment(ctx, bucket, prefixes)
+	if err != nil {
+		return fmt.Errorf("incrementing key generation: %w", err)
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.generations[bucket] = cachedGenerations{generations: generations, fetched: k.now()}
+	for keyID := range k.keks {
+		keyBucket, keyPrefix, generation, err := parseKeyID(keyID)
+		if err == nil && keyBucket == bucket && generation < generations[keyPrefix] {
+			delete(k.keks, keyID)
+		}
+	}
+	k.log.With(slog.String("bucket", bucket), slog.String("prefix", prefix)).Info("Shredded key")
+	return nil
+}
+
+// prefixOf returns the configured prefix that determines the key of an object, or "" if the bucket key is used.
+func (k *Keyring) prefixOf(bucket, objectKey string) string {
+	for _, prefix := range k.prefixes[bucket] {
+		if strings.HasPrefix(objectKey, prefix) {
+			return prefix
+		}
+	}
+	return ""
+}
+
+func (k *Keyring) isPrefix(bucket, prefix string) bool {
+	for _, p := range k.prefixes[bucket] {
+		if p == prefix {
+			return true
+		}
+	}
+	return false
+}
+
+// getGenerations returns the key generations of a bucket, fetching them from the store if the cache is outdated.
+func (k *Keyring) getGenerations(ctx context.Context, bucket string, forceRefresh bool) (map[string]uint64, error) {
+	k.mu.Lock()
+	cached, ok := k.generations[bucket]
+	k.mu.Unlock()
+	if ok && !forceRefresh && k.now().Sub(cached.fetched) < refreshInterval {
+		return cached.generations, nil
+	}
+
+	generations, err := k.store.Generations(ctx, bucket)
+	if err != nil {
+		return nil, fmt.Errorf("getting key generations: %w", err)
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.generations[bucket] = cachedGenerations{generations: generations, fetched: k.now()}
+	return generations, nil
+}
+
+// getKEK returns the KEK for a data key ID, fetching it from the keyservice if it isn't cached.
+func (k *Keyring) getKEK(ctx context.Context, keyID string) ([32]byte, error) {
+	k.mu.Lock()
+	kek, ok := k.keks[keyID]
+	k.mu.Unlock()
+	if ok {
+		return kek, nil
+	}
+
+	key, err := k.kms.GetDataKey(ctx, keyID, kekSizeBytes)
+	if err != nil {
+		return [32]byte{}, fmt.Errorf("getting KEK: %w", err)
+	}
+	kek, err = byteSliceToByteArray(key)
+	if err != nil {
+		return [32]byte{}, fmt.Errorf("converting KEK to byte array: %w", err)
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.keks[keyID] = kek
+	return kek, nil
+}
+
+// formatKeyID returns the data key ID of a bucket or prefix.
+// The prefix is the last element, since it may contain slashes.
+func formatKeyID(bucket, prefix string, generation uint64) string {
+	return fmt.Sprintf("%s/%s/%d/%s", keyIDPrefix, bucket, generation, prefix)
+}
+
+// parseKeyID parses a data key ID created by formatKeyID.
+func parseKeyID(keyID string) (bucket, prefix string, generation uint64, err error) {
+	parts := strings.SplitN(keyID, "/", 4)
+	if len(parts) != 4 || parts[0] != keyIDPrefix || parts[1] == "" {
+		return "", "", 0, fmt.Errorf("invalid key ID %q", keyID)
+	}
+	generation, err = strconv.ParseUint(parts[2], 10, 64)
+	if err != nil {
+		return "", "", 0, fmt.Errorf("invalid generation in key ID %q: %w", keyID, err)
+	}
+	return parts[1], parts[3], generation, nil
+}
+
+// byteSliceToByteArray casts a byte slice to a byte array of length 32.
+// It does a length check to prevent the cast from panic'ing.
+func byteSliceToByteArray(input []byte) ([32]byte, error) {
+	if len(input) != 32 {
+		return [32]byte{}, fmt.Errorf("input length mismatch, got: %d", len(input))
+	}
+
+	return ([32]byte)(input), nil
+}
+
+type dataKeyGetter interface {
+	GetDataKey(ctx context.Context, keyID string, length int) ([]byte, error)
+}
+
+type generationStore interface {
+	// Generations returns the key generations of the bucket, indexed by prefix.
+	// The bucket itself uses the empty prefix.
+	Generations(ctx context.Context, bucket string) (map[string]uint64, error)
+	// Increment increments the key generations of the given prefixes and returns the new generations of the bucket.
+	Increment(ctx context.Context, bucket string, prefixes []string) (map[string]uint64, error)
+}
@@ -0,0 +1,382 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package keyring
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestMain(m *testing.M) {
+	goleak.VerifyTestMain(m)
+}
+
+func TestKeyring(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx := context.Background()
+
+	kms := &stubKMS{}
+	keyring := newTestKeyring(t, kms, newTestStore(), []string{"bucket/logs/", "bucket/logs/audit/"})
+
+	bucketID, bucketKEK, err := keyring.CurrentKey(ctx, "bucket", "data/object")
+	require.NoError(err)
+	assert.Equal("s3proxy/bucket/0/", bucketID)
+	logsID, logsKEK, err := keyring.CurrentKey(ctx, "bucket", "logs/object")
+	require.NoError(err)
+	assert.Equal("s3proxy/bucket/0/logs/", logsID)
+	auditID, _, err := keyring.CurrentKey(ctx, "bucket", "logs/audit/object")
+	require.NoError(err)
+	assert.Equal("s3proxy/bucket/0/logs/audit/", auditID)
+	otherID, otherKEK, err := keyring.CurrentKey(ctx, "other", "logs/object")
+	require.NoError(err)
+	assert.Equal("s3proxy/other/0/", otherID)
+
+	assert.NotEqual(bucketKEK, logsKEK)
+	assert.NotEqual(bucketKEK, otherKEK)
+
+	kek, err := keyring.Key(ctx, "bucket", logsID)
+	require.NoError(err)
+	assert.Equal(logsKEK, kek)
+	_, err = keyring.Key(ctx, "bucket", "")
+	assert.ErrorIs(err, ErrLegacyKEK)
+	_, err = keyring.Key(ctx, "other", logsID)
+	assert.Error(err)
+
+	// KEKs are cached.
+	assert.Equal(4, kms.calls)
+
+	require.NoError(keyring.Shred(ctx, "bucket", "logs/"))
+	_, err = keyring.Key(ctx, "bucket", logsID)
+	assert.ErrorIs(err, ErrShredded)
+	newLogsID, newLogsKEK, err := keyring.CurrentKey(ctx, "bucket", "logs/object")
+	require.NoError(err)
+	assert.Equal("s3proxy/bucket/1/logs/", newLogsID)
+	assert.NotEqual(logsKEK, newLogsKEK)
+	_, err = keyring.Key(ctx, "bucket", bucketID)
+	assert.NoError(err)
+	_, err = keyring.Key(ctx, "bucket", auditID)
+	assert.NoError(err)
+
+	// Shredding a bucket shreds all of its prefixes.
+	require.NoError(keyring.Shred(ctx, "bucket", ""))
+	for _, keyID := range []string{bucketID, newLogsID, auditID} {
+		_, err = keyring.Key(ctx, "bucket", keyID)
+		assert.ErrorIs(err, ErrShredded)
+	}
+	_, err = keyring.Key(ctx, "other", otherID)
+	assert.NoError(err)
+
+	assert.ErrorIs(keyring.Shred(ctx, "bucket", "unknown/"), ErrUnknownPrefix)
+}
+
+func TestKeyringLegacyKEK(t *testing.T) {
+	testCases := map[string]struct {
+		allowLegacyKEK bool
+		wantErr        bool
+	}{
+		"legacy KEK allowed": {allowLegacyKEK: true},
+		"legacy KEK not allowed": {
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			kms := &stubKMS{}
+			keyring, err := New(kms, newTestStore(), nil, tc.allowLegacyKEK, slog.New(slog.NewTextHandler(io.Discard, nil)))
+			require.NoError(t, err)
+
+			kek, err := keyring.Key(context.Background(), "bucket", "")
+			if tc.wantErr {
+				assert.ErrorIs(err, ErrLegacyKEK)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal([32]byte{}, kek)
+			assert.Zero(kms.calls)
+		})
+	}
+}
+
+func TestKeyringReplicas(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx := context.Background()
+
+	store := newTestStore()
+	now := time.Now()
+	first := newTestKeyring(t, &stubKMS{}, store, nil)
+	second := newTestKeyring(t, &stubKMS{}, store, nil)
+	second.now = func() time.Time { return now }
+
+	oldID, _, err := second.CurrentKey(ctx, "bucket", "object")
+	require.NoError(err)
+
+	require.NoError(first.Shred(ctx, "bucket", ""))
+	newID, _, err := first.CurrentKey(ctx, "bucket", "object")
+	require.NoError(err)
+
+	// Keys of newer generations are accepted immediately.
+	_, err = second.Key(ctx, "bucket", newID)
+	assert.NoError(err)
+
+	// Shredded keys are rejected once the cache was refreshed.
+	second.now = func() time.Time { return now.Add(refreshInterval) }
+	_, err = second.Key(ctx, "bucket", oldID)
+	assert.ErrorIs(err, ErrShredded)
+}
+
+func TestKeyringErrors(t *testing.T) {
+	ctx := context.Background()
+
+	testCases := map[string]struct {
+		kms      *stubKMS
+		prefixes []string
+		keyID    string
+		wantErr  bool
+	}{
+		"valid": {
+			kms:   &stubKMS{},
+			keyID: "s3proxy/bucket/0/",
+		},
+		"kms error": {
+			kms:     &stubKMS{err: errors.New("failed")},
+			keyID:   "s3proxy/bucket/0/",
+			wantErr: true,
+		},
+		"invalid key length": {
+			kms:     &stubKMS{length: 16},
+			keyID:   "s3proxy/bucket/0/",
+			wantErr: true,
+		},
+		"unknown generation": {
+			kms:     &stubKMS{},
+			keyID:   "s3proxy/bucket/1/",
+			wantErr: true,
+		},
+		"invalid generation": {
+			kms:     &stubKMS{},
+			keyID:   "s3proxy/bucket/one/",
+			wantErr: true,
+		},
+		"foreign key ID": {
+			kms:     &stubKMS{},
+			keyID:   "s3proxy-kek",
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			keyring := newTestKeyring(t, tc.kms, newTestStore(), tc.prefixes)
+			_, err := keyring.Key(ctx, "bucket", tc.keyID)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	testCases := map[string]struct {
+		prefixes []string
+		wantErr  bool
+	}{
+		"no prefixes":    {},
+		"valid prefixes": {prefixes: []string{"bucket/a/", "bucket/b", "other/c/d"}},
+		"missing prefix": {prefixes: []string{"bucket/"}, wantErr: true},
+		"missing bucket": {prefixes: []string{"/prefix"}, wantErr: true},
+		"bucket only":    {prefixes: []string{"bucket"}, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_, err := New(&stubKMS{}, newTestStore(), tc.prefixes, false, slog.New(slog.NewTextHandler(io.Discard, nil)))
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestConfigMapStore(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx := context.Background()
+
+	store := newTestStore()
+	generations, err := store.Generations(ctx, "bucket")
+	require.NoError(err)
+	assert.Empty(generations)
+
+	generations, err = store.Increment(ctx, "bucket", []string{"", "logs/"})
+	require.NoError(err)
+	assert.Equal(map[string]uint64{"": 1, "logs/": 1}, generations)
+	generations, err = store.Increment(ctx, "bucket", []string{"logs/"})
+	require.NoError(err)
+	assert.Equal(map[string]uint64{"": 1, "logs/": 2}, generations)
+	_, err = store.Increment(ctx, "other", []string{""})
+	require.NoError(err)
+
+	generations, err = store.Generations(ctx, "bucket")
+	require.NoError(err)
+	assert.Equal(map[string]uint64{"": 1, "logs/": 2}, generations)
+
+	cm, err := store.client.CoreV1().ConfigMaps("s3proxy").Get(ctx, "s3proxy-keyring", metav1.GetOptions{})
+	require.NoError(err)
+	assert.Equal(`{"":1}`, cm.Data["other"])
+}
+
+func TestConfigMapStoreExisting(t *testing.T) {
+	store := &ConfigMapStore{
+		client: fake.NewClientset(&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "s3proxy-keyring", Namespace: "s3proxy"},
+			Data:       map[string]string{"bucket": "invalid"},
+		}),
+		namespace: "s3proxy",
+		name:      "s3proxy-keyring",
+	}
+	_, err := store.Generations(context.Background(), "bucket")
+	assert.Error(t, err)
+	_, err = store.Increment(context.Background(), "bucket", []string{""})
+	assert.Error(t, err)
+}
+
+func TestAdminHandler(t *testing.T) {
+	testCases := map[string]struct {
+		method     string
+		target     string
+		wantStatus int
+	}{
+		"shred bucket": {
+			method:     http.MethodPost,
+			target:     "/shred?bucket=bucket",
+			wantStatus: http.StatusNoContent,
+		},
+		"shred prefix": {
+			method:     http.MethodPost,
+			target:     "/shred?bucket=bucket&prefix=logs/",
+			wantStatus: http.StatusNoContent,
+		},
+		"unknown prefix": {
+			method:     http.MethodPost,
+			target:     "/shred?bucket=bucket&prefix=other/",
+			wantStatus: http.StatusBadRequest,
+		},
+		"missing bucket": {
+			method:     http.MethodPost,
+			target:     "/shred",
+			wantStatus: http.StatusBadRequest,
+		},
+		"wrong method": {
+			method:     http.MethodGet,
+			target:     "/shred?bucket=bucket",
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			keyring := newTestKeyring(t, &stubKMS{}, newTestStore(), []string{"bucket/logs/"})
+			handler := NewAdminHandler(keyring, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+			resp := httptest.NewRecorder()
+			handler.ServeHTTP(resp, httptest.NewRequest(tc.method, tc.target, nil))
+			assert.Equal(t, tc.wantStatus, resp.Code)
+		})
+	}
+}
+
+func TestByteSliceToByteArray(t *testing.T) {
+	tests := map[string]struct {
+		input   []byte
+		output  [32]byte
+		wantErr bool
+	}{
+		"empty input": {
+			input:  []byte{},
+			output: [32]byte{},
+		},
+		"successful input": {
+			input:  []byte("AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"),
+			output: [32]byte{0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41},
+		},
+		"input too short": {
+			input:   []byte("AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"),
+			output:  [32]byte{0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41},
+			wantErr: true,
+		},
+		"input too long": {
+			input:   []byte("AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"),
+			output:  [32]byte{0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			result, err := byteSliceToByteArray(tc.input)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.Equal(t, tc.output, result)
+		})
+	}
+}
+
+func newTestKeyring(t *testing.T, kms dataKeyGetter, store generationStore, prefixes []string) *Keyring {
+	t.Helper()
+	keyring, err := New(kms, store, prefixes, false, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	return keyring
+}
+
+func newTestStore() *ConfigMapStore {
+	return &ConfigMapStore{client: fake.NewClientset(), namespace: "s3proxy", name: "s3proxy-keyring"}
+}
+
+// stubKMS derives keys by hashing the key ID.
+type stubKMS struct {
+	length int
+	err    error
+	calls  int
+}
+
+func (s *stubKMS) GetDataKey(_ context.Context, keyID string, length int) ([]byte, error) {
+	s.calls++
+	if s.err != nil {
+		return nil, s.err
+	}
+	key := sha256.Sum256([]byte(keyID))
+	if s.length != 0 {
+		length = s.length
+	}
+	return key[:length], nil
+}
@@ -9,6 +9,7 @@ package kms
 import (
 	"context"
 	"errors"
+	"io/fs"
 	"testing"
 
 	"github.com/edgelesssys/constellation/v2/internal/logger"
@@ -16,15 +17,19 @@ import (
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/goleak"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/test/bufconn"
 )
 
 type stubClient struct {
 	getDataKeyErr error
 	dataKey       []byte
+	authorization []string
 }
 
-func (c *stubClient) GetDataKey(context.Context, *keyserviceproto.GetDataKeyRequest, *grpc.ClientConn) (*keyserviceproto.GetDataKeyResponse, error) {
+func (c *stubClient) GetDataKey(ctx context.Context, _ *keyserviceproto.GetDataKeyRequest, _ *grpc.ClientConn) (*keyserviceproto.GetDataKeyResponse, error) {
+	md, _ := metadata.FromOutgoingContext(ctx)
+	c.authorization = md.Get("authorization")
 	return &keyserviceproto.GetDataKeyResponse{DataKey: c.dataKey}, c.getDataKeyErr
 }
 
@@ -34,15 +39,30 @@ func TestMain(m *testing.M) {
 
 func TestGetDataKey(t *testing.T) {
 	testCases := map[string]struct {
-		client  *stubClient
-		wantErr bool
+		client            *stubClient
+		token             []byte
+		readTokenErr      error
+		wantAuthorization []string
+		wantErr           bool
 	}{
 		"GetDataKey success": {
-			client: &stubClient{dataKey: []byte{0x1, 0x2, 0x3}},
+			client:       &stubClient{dataKey: []byte{0x1, 0x2, 0x3}},
+			readTokenErr: fs.ErrNotExist,
+		},
+		"GetDataKey with token": {
+			client:            &stubClient{dataKey: []byte{0x1, 0x2, 0x3}},
+			token:             []byte("token\n"),
+			wantAuthorization: []string{"Bearer token"},
 		},
 		"GetDataKey error": {
-			client:  &stubClient{getDataKeyErr: errors.New("error")},
-			wantErr: true,
+			client:       &stubClient{getDataKeyErr: errors.New("error")},
+			readTokenErr: fs.ErrNotExist,
+			wantErr:      true,
+		},
+		"reading token fails": {
+			client:       &stubClient{dataKey: []byte{0x1, 0x2, 0x3}},
+			readTokenErr: errors.New("error"),
+			wantErr:      true,
 		},
 	}
 
@@ -59,6 +79,7 @@ func TestGetDataKey(t *testing.T) {
 			)
 
 			client.grpc = tc.client
+			client.readToken = func() ([]byte, error) { return tc.token, tc.readTokenErr }
 
 			res, err := client.GetDataKey(t.Context(), "disk-uuid", 32)
 			if tc.wantErr {
@@ -66,6 +87,7 @@ func TestGetDataKey(t *testing.T) {
 			} else {
 				assert.NoError(err)
 				assert.Equal(tc.client.dataKey, res)
+				assert.Equal(tc.wantAuthorization, tc.client.authorization)
 			}
 		})
 	}
@@ -0,0 +1,301 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package router
+
+import (
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/edgelesssys/constellation/v2/s3proxy/internal/crypto"
+)
+
+// copyObject bundles data to implement CopyObject.
+type copyObject struct {
+	client                         s3Client
+	keys                           keyProvider
+	key                            string
+	bucket                         string
+	copySource                     string
+	copySourceIfMatch              string
+	copySourceIfNoneMatch          string
+	copySourceIfModifiedSince      string
+	copySourceIfUnmodifiedSince    string
+	metadataDirective              string
+	metadata                       map[string]string
+	contentType                    string
+	tags                           string
+	taggingDirective               string
+	storageClass                   string
+	sseCustomerAlgorithm           string
+	sseCustomerKey                 string
+	sseCustomerKeyMD5              string
+	copySourceSSECustomerAlgorithm string
+	copySourceSSECustomerKey       string
+	copySourceSSECustomerKeyMD5    string
+	log                            *slog.Logger
+}
+
+// copy is a http.HandlerFunc that implements CopyObject.
+// Encrypted objects are copied by S3, after their DEK was re-wrapped with the KEK of the destination.
+// Unencrypted objects are encrypted while they are copied through s3proxy.
+func (c copyObject) copy(w http.ResponseWriter, r *http.Request) {
+	c.log.With(slog.String("key", c.key), slog.String("host", c.bucket), slog.String("source", c.copySource)).Debug("copyObject")
+
+	srcBucket, srcKey, srcVersionID, err := parseCopySource(c.copySource)
+	if err != nil {
+		c.log.With(slog.Any("error", err)).Debug("CopyObject parsing copy source")
+		http.Error(w, fmt.Sprintf("InvalidArgument: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+	if strings.HasPrefix(srcKey, stateKeyPrefix) {
+		http.Error(w, reservedPrefixError, http.StatusForbidden)
+		return
+	}
+	if c.metadataDirective != "" && c.metadataDirective != "COPY" && c.metadataDirective != "REPLACE" {
+		http.Error(w, fmt.Sprintf("InvalidArgument: unknown metadata directive %q", c.metadataDirective), http.StatusBadRequest)
+		return
+	}
+	// S3 rejects copying an object onto itself without any change.
+	// Since s3proxy always replaces the metadata, this has to be checked here.
+	if srcBucket == c.bucket && srcKey == c.key && srcVersionID == "" && c.metadataDirective != "REPLACE" && c.storageClass == "" {
+		http.Error(w, "InvalidRequest: this copy request is illegal because it is trying to copy an object to itself without changing the object's metadata or storage class", http.StatusBadRequest)
+		return
+	}
+
+	head, err := c.client.HeadObject(r.Context(), srcBucket, srcKey, srcVersionID, c.copySourceSSECustomerAlgorithm, c.copySourceSSECustomerKey, c.copySourceSSECustomerKeyMD5)
+	if err != nil {
+		c.log.With(slog.Any("error", err)).Error("CopyObject sending head request to S3")
+		writeS3Error(w, err)
+		return
+	}
+	if status := evaluateConditions(c.copySourceIfMatch, c.copySourceIfNoneMatch, c.copySourceIfModifiedSince, c.copySourceIfUnmodifiedSince, aws.ToString(head.ETag), aws.ToTime(head.LastModified)); status != 0 {
+		// S3 responds with 412 for all failed conditions of the copy source.
+		http.Error(w, "PreconditionFailed: at least one of the pre-conditions you specified did not hold", http.StatusPreconditionFailed)
+		return
+	}
+
+	metadata := userMetadata(head.Metadata)
+	contentType := aws.ToString(head.ContentType)
+	if c.metadataDirective == "REPLACE" {
+		metadata = map[string]string{}
+		maps.Copy(metadata, c.metadata)
+		contentType = c.contentType
+	}
+	// Pin the version returned by the head request, so the copied object matches the evaluated conditions.
+	if head.VersionId != nil {
+		srcVersionID = *head.VersionId
+	}
+
+	if encryptionOf(head.Metadata) == encryptionNone {
+		c.encryptCopy(w, r, srcBucket, srcKey, srcVersionID, head, metadata, contentType)
+		return
+	}
+	c.rewrapCopy(w, r, srcBucket, srcKey, srcVersionID, head, metadata, contentType)
+}
+
+// rewrapCopy copies an encrypted object within S3.
+// Only the DEK of the object is re-wrapped with the KEK of the destination, the ciphertext stays the same.
+func (c copyObject) rewrapCopy(w http.ResponseWriter, r *http.Request, srcBucket, srcKey, srcVersionID string, head *s3.HeadObjectOutput, metadata map[string]string, contentType string) {
+	kek, err := c.keys.Key(r.Context(), srcBucket, head.Metadata[keyIDTag])
+	if err != nil {
+		c.log.With(slog.Any("error", err)).Error("CopyObject getting KEK of source")
+		writeKeyError(w, err)
+		return
+	}
+	keyID, newKEK, err := c.keys.CurrentKey(r.Context(), c.bucket, c.key)
+	if err != nil {
+		c.log.With(slog.Any("error", err)).Error("CopyObject getting KEK")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	encryptedDEK, err := hex.DecodeString(head.Metadata[dekTag])
+	if err != nil {
+		c.log.With(slog.Any("error", err)).Error("CopyObject decoding DEK")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	newEncryptedDEK, err := crypto.RewrapDEK(encryptedDEK, kek, newKEK)
+	if err != nil {
+		c.log.With(slog.Any("error", err)).Error("CopyObject re-wrapping DEK")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	metadata[dekTag] = hex.EncodeToString(newEncryptedDEK)
+	metadata[keyIDTag] = keyID
+	for _, tag := range []string{streamTag, multipartTag} {
+		if value, ok := head.Metadata[tag]; ok {
+			metadata[tag] = value
+		}
+	}
+
+	// The manifest of an object uploaded in parts is stored next to the object.
+	// It is sealed with the DEK, so it can be copied as is.
+	var manifest string
+	if _, ok := head.Metadata[multipartTag]; ok {
+		header, _, err := parseMetadata(head.Metadata, multipartTag)
+		if err != nil {
+			c.log.With(slog.Any("error", err)).Error("CopyObject parsing metadata")
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		manifest = manifestKey(c.key, header)
+		if srcManifest := manifestKey(srcKey, header); srcBucket != c.bucket || srcManifest != manifest {
+			if _, err := c.client.CopyObject(r.Context(), c.bucket, manifest, formatCopySource(srcBucket, srcManifest, ""), "", "", "", "application/octet-stream", "", "", "", "", "", "", "", map[string]string{}); err != nil {
+				c.log.With(slog.Any("error", err)).Error("CopyObject copying manifest")
+				writeS3Error(w, err)
+				return
+			}
+		}
+	}
+
+	output, err := c.client.CopyObject(r.Context(), c.bucket, c.key, formatCopySource(srcBucket, srcKey, srcVersionID), aws.ToString(head.ETag), c.tags, c.taggingDirective, contentType, c.storageClass, c.sseCustomerAlgorithm, c.sseCustomerKey, c.sseCustomerKeyMD5, c.copySourceSSECustomerAlgorithm, c.copySourceSSECustomerKey, c.copySourceSSECustomerKeyMD5, metadata)
+	if err != nil {
+		c.log.With(slog.Any("error", err)).Error("CopyObject sending request to S3")
+		writeS3Error(w, err)
+		return
+	}
+	c.pruneManifests(r, output.VersionId, manifest)
+
+	if output.VersionId != nil {
+		w.Header().Set("x-amz-version-id", *output.VersionId)
+	}
+	if output.CopySourceVersionId != nil {
+		w.Header().Set("x-amz-copy-source-version-id", *output.CopySourceVersionId)
+	}
+	if output.ServerSideEncryption != "" {
+		w.Header().Set("x-amz-server-side-encryption", string(output.ServerSideEncryption))
+	}
+	result := copyObjectResult{}
+	if output.CopyObjectResult != nil {
+		result.ETag = aws.ToString(output.CopyObjectResult.ETag)
+		result.LastModified = formatXMLTime(aws.ToTime(output.CopyObjectResult.LastModified))
+	}
+	writeXML(w, result, c.log)
+}
+
+// encryptCopy copies an unencrypted object by streaming it through s3proxy, encrypting it on the way.
+// Tags of the source are only copied by S3 for server-side copies, so they are lost unless replaced by the request.
+func (c copyObject) encryptCopy(w http.ResponseWriter, r *http.Request, srcBucket, srcKey, srcVersionID string, head *s3.HeadObjectOutput, metadata map[string]string, contentType string) {
+	keyID, kek, err := c.keys.CurrentKey(r.Context(), c.bucket, c.key)
+	if err != nil {
+		c.log.With(slog.Any("error", err)).Error("CopyObject getting KEK")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	source, err := c.client.GetObject(r.Context(), srcBucket, srcKey, srcVersionID, "", aws.ToString(head.ETag), c.copySourceSSECustomerAlgorithm, c.copySourceSSECustomerKey, c.copySourceSSECustomerKeyMD5)
+	if err != nil {
+		c.log.With(slog.Any("error", err)).Error("CopyObject getting source")
+		writeS3Error(w, err)
+		return
+	}
+	defer source.Body.Close()
+
+	ciphertext, header, encryptedDEK, err := crypto.EncryptStream(source.Body, kek)
+	if err != nil {
+		c.log.With(slog.Any("error", err)).Error("CopyObject encrypting source")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	metadata[dekTag] = hex.EncodeToString(encryptedDEK)
+	metadata[streamTag] = hex.EncodeToString(header)
+	metadata[keyIDTag] = keyID
+
+	tags := ""
+	if c.taggingDirective == "REPLACE" {
+		tags = c.tags
+	}
+	output, err := c.client.PutObject(r.Context(), c.bucket, c.key, tags, contentType, "", "", c.sseCustomerAlgorithm, c.sseCustomerKey, c.sseCustomerKeyMD5, time.Time{}, metadata, ciphertext, crypto.CiphertextSize(aws.ToInt64(source.ContentLength)))
+	if err != nil {
+		c.log.With(slog.Any("error", err)).Error("CopyObject sending request to S3")
+		writeS3Error(w, err)
+		return
+	}
+	c.pruneManifests(r, output.VersionId, "")
+
+	if output.VersionId != nil {
+		w.Header().Set("x-amz-version-id", *output.VersionId)
+	}
+	if source.VersionId != nil {
+		w.Header().Set("x-amz-copy-source-version-id", *source.VersionId)
+	}
+	if output.ServerSideEncryption != "" {
+		w.Header().Set("x-amz-server-side-encryption", string(output.ServerSideEncryption))
+	}
+	writeXML(w, copyObjectResult{
+		ETag:         aws.ToString(output.ETag),
+		LastModified: formatXMLTime(time.Now()),
+	}, c.log)
+}
+
+// pruneManifests deletes the manifests of the object replaced by a copy, except for the manifest of the copy.
+// In versioned buckets, S3 keeps the replaced object as a previous version, so its manifest is kept as well.
+func (c copyObject) pruneManifests(r *http.Request, versionID *string, keep string) {
+	if versionID != nil {
+		return
+	}
+	if err := pruneManifests(r.Context(), c.client, c.bucket, c.key, keep); err != nil {
+		c.log.With(slog.Any("error", err)).Error("CopyObject deleting manifests of replaced object")
+	}
+}
+
+// parseCopySource parses the value of the x-amz-copy-source header: [/]<bucket>/<key>[?versionId=<versionID>].
+// The key is URL encoded.
+func parseCopySource(raw string) (bucket, key, versionID string, err error) {
+	path, query, _ := strings.Cut(strings.TrimPrefix(raw, "/"), "?")
+	if query != "" {
+		values, err := url.ParseQuery(query)
+		if err != nil {
+			return "", "", "", fmt.Errorf("parsing copy source query: %w", err)
+		}
+		versionID = values.Get("versionId")
+	}
+	path, err = url.PathUnescape(path)
+	if err != nil {
+		return "", "", "", fmt.Errorf("decoding copy source: %w", err)
+	}
+	bucket, key, ok := strings.Cut(path, "/")
+	if !ok || bucket == "" || key == "" {
+		return "", "", "", fmt.Errorf("copy source %q must be of the form <bucket>/<key>", raw)
+	}
+	return bucket, key, versionID, nil
+}
+
+// formatCopySource formats a copy source as expected by S3.
+func formatCopySource(bucket, key, versionID string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	source := bucket + "/" + strings.Join(segments, "/")
+	if versionID != "" {
+		source += "?versionId=" + url.QueryEscape(versionID)
+	}
+	return source
+}
+
+// formatXMLTime formats a timestamp as used in the XML responses of S3.
+func formatXMLTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05.000Z")
+}
+
+// copyObjectResult is the response body of a CopyObject request.
+type copyObjectResult struct {
+	XMLName      xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ CopyObjectResult"`
+	ETag         string   `xml:"ETag"`
+	LastModified string   `xml:"LastModified"`
+}
@@ -0,0 +1,289 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package router
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/xml"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/edgelesssys/constellation/v2/s3proxy/internal/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCopyObject(t *testing.T) {
+	oldKEK := [32]byte{1}
+	newKEK := [32]byte{2}
+	plaintext := randomBytes(t, 2*crypto.SegmentSize+100)
+	legacyData, legacyDEK, err := crypto.Encrypt(plaintext, oldKEK)
+	require.NoError(t, err)
+
+	testCases := map[string]struct {
+		setup func(t *testing.T, client *stubBucket)
+	}{
+		"stream": {
+			setup: func(t *testing.T, client *stubBucket) {
+				body, err := newDigestReader(bytes.NewReader(plaintext), "", "")
+				require.NoError(t, err)
+				obj := newTestObject(client, oldKEK)
+				obj.key = "source"
+				obj.body = body
+				obj.contentLength = int64(len(plaintext))
+				obj.metadata = map[string]string{"color": "blue"}
+				resp := httptest.NewRecorder()
+				obj.put(resp, httptest.NewRequest(http.MethodPut, "/bucket/source", nil))
+				require.Equal(t, http.StatusOK, resp.Code)
+			},
+		},
+		"multipart": {
+			setup: func(t *testing.T, client *stubBucket) {
+				uploadID := createTestUpload(t, client, oldKEK)
+				upload := client.metadata[uploadID]
+				upload["color"] = "blue"
+				etags := map[int32]string{}
+				for i, part := range [][]byte{plaintext[:crypto.SegmentSize], plaintext[crypto.SegmentSize:]} {
+					resp := uploadTestPart(t, client, oldKEK, uploadID, int32(i+1), part)
+					require.Equal(t, http.StatusOK, resp.Code)
+					etags[int32(i+1)] = resp.Header().Get("ETag")
+				}
+				resp := completeTestUpload(t, client, oldKEK, uploadID, []int32{1, 2}, etags)
+				require.Equal(t, http.StatusOK, resp.Code)
+				moveTestObject(t, client, "key", "source")
+			},
+		},
+		"legacy": {
+			setup: func(t *testing.T, client *stubBucket) {
+				client.objects["source"] = &stubS3Client{
+					data:     legacyData,
+					etag:     "etag",
+					metadata: map[string]string{dekTag: hex.EncodeToString(legacyDEK), keyIDTag: "s3proxy/bucket/0/", "color": "blue"},
+				}
+			},
+		},
+		"unencrypted": {
+			setup: func(t *testing.T, client *stubBucket) {
+				client.objects["source"] = &stubS3Client{data: plaintext, etag: "etag", metadata: map[string]string{"color": "blue"}}
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			client := newStubBucket()
+			tc.setup(t, client)
+
+			c := newTestCopy(client, "bucket/source")
+			c.keys = testKeys(oldKEK, newKEK, "s3proxy/bucket/1/")
+			resp := httptest.NewRecorder()
+			c.copy(resp, httptest.NewRequest(http.MethodPut, "/bucket/key", nil))
+			require.Equal(http.StatusOK, resp.Code, resp.Body.String())
+			var result copyObjectResult
+			require.NoError(xml.Unmarshal(resp.Body.Bytes(), &result))
+			assert.NotEmpty(result.ETag)
+
+			copied := client.objects["key"]
+			assert.Equal("s3proxy/bucket/1/", copied.metadata[keyIDTag])
+			assert.Equal("blue", copied.metadata["color"])
+			assert.NotContains(string(copied.data), string(plaintext))
+
+			// The copy can only be decrypted with the KEK of the destination.
+			obj := newTestObject(client, newKEK)
+			resp = httptest.NewRecorder()
+			obj.get(resp, httptest.NewRequest(http.MethodGet, "/bucket/key", nil))
+			require.Equal(http.StatusOK, resp.Code)
+			assert.Equal(plaintext, resp.Body.Bytes())
+
+			// The copy doesn't depend on the source or its manifest.
+			d := deleteObject{client: client, key: "source", bucket: "bucket", log: obj.log}
+			resp = httptest.NewRecorder()
+			d.delete(resp, httptest.NewRequest(http.MethodDelete, "/bucket/source", nil))
+			require.Equal(http.StatusNoContent, resp.Code)
+			resp = httptest.NewRecorder()
+			obj.get(resp, httptest.NewRequest(http.MethodGet, "/bucket/key", nil))
+			require.Equal(http.StatusOK, resp.Code)
+			assert.Equal(plaintext, resp.Body.Bytes())
+		})
+	}
+}
+
+func TestCopyObjectReplaceMetadata(t *testing.T) {
+	client := newStubBucket()
+	client.objects["source"] = &stubS3Client{data: []byte("hello"), etag: "etag", metadata: map[string]string{"color": "blue"}}
+
+	c := newTestCopy(client, "/bucket/source")
+	c.metadataDirective = "REPLACE"
+	c.metadata = map[string]string{"shape": "round"}
+	c.contentType = "text/plain"
+	resp := httptest.NewRecorder()
+	c.copy(resp, httptest.NewRequest(http.MethodPut, "/bucket/key", nil))
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+
+	copied := client.objects["key"]
+	assert.Equal(t, "round", copied.metadata["shape"])
+	assert.NotContains(t, copied.metadata, "color")
+	assert.Equal(t, "text/plain", copied.contentType)
+}
+
+func TestCopyObjectErrors(t *testing.T) {
+	testCases := map[string]struct {
+		copySource        string
+		metadataDirective string
+		ifMatch           string
+		wantStatus        int
+	}{
+		"invalid copy source": {
+			copySource: "bucket",
+			wantStatus: http.StatusBadRequest,
+		},
+		"unknown metadata directive": {
+			copySource:        "bucket/source",
+			metadataDirective: "MERGE",
+			wantStatus:        http.StatusBadRequest,
+		},
+		"copy onto itself": {
+			copySource: "bucket/key",
+			wantStatus: http.StatusBadRequest,
+		},
+		"reserved source": {
+			copySource: "bucket/" + stateKeyPrefix + "manifests/x",
+			wantStatus: http.StatusForbidden,
+		},
+		"source not found": {
+			copySource: "bucket/missing",
+			wantStatus: http.StatusNotFound,
+		},
+		"precondition failed": {
+			copySource: "bucket/source",
+			ifMatch:    `"other"`,
+			wantStatus: http.StatusPreconditionFailed,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			client := newStubBucket()
+			client.objects["key"] = &stubS3Client{data: []byte("hello"), etag: "etag", metadata: map[string]string{}}
+			client.objects["source"] = &stubS3Client{data: []byte("hello"), etag: "etag", metadata: map[string]string{}}
+
+			c := newTestCopy(client, tc.copySource)
+			c.metadataDirective = tc.metadataDirective
+			c.copySourceIfMatch = tc.ifMatch
+			resp := httptest.NewRecorder()
+			c.copy(resp, httptest.NewRequest(http.MethodPut, "/bucket/key", nil))
+			assert.Equal(t, tc.wantStatus, resp.Code)
+			assert.Equal(t, []byte("hello"), client.objects["key"].data)
+		})
+	}
+}
+
+func TestParseCopySource(t *testing.T) {
+	testCases := map[string]struct {
+		raw           string
+		wantBucket    string
+		wantKey       string
+		wantVersionID string
+		wantErr       bool
+	}{
+		"simple": {
+			raw:        "bucket/key",
+			wantBucket: "bucket",
+			wantKey:    "key",
+		},
+		"leading slash": {
+			raw:        "/bucket/dir/key",
+			wantBucket: "bucket",
+			wantKey:    "dir/key",
+		},
+		"encoded key": {
+			raw:        "bucket/dir/a%20b%2Bc%3F",
+			wantBucket: "bucket",
+			wantKey:    "dir/a b+c?",
+		},
+		"version": {
+			raw:           "bucket/key?versionId=abc%2B",
+			wantBucket:    "bucket",
+			wantKey:       "key",
+			wantVersionID: "abc+",
+		},
+		"missing key": {
+			raw:     "bucket/",
+			wantErr: true,
+		},
+		"invalid encoding": {
+			raw:     "bucket/%zz",
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			bucket, key, versionID, err := parseCopySource(tc.raw)
+			if tc.wantErr {
+				assert.Error(err)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(tc.wantBucket, bucket)
+			assert.Equal(tc.wantKey, key)
+			assert.Equal(tc.wantVersionID, versionID)
+
+			// formatCopySource is the inverse of parseCopySource.
+			bucket, key, versionID, err = parseCopySource(formatCopySource(bucket, key, versionID))
+			assert.NoError(err)
+			assert.Equal(tc.wantBucket, bucket)
+			assert.Equal(tc.wantKey, key)
+			assert.Equal(tc.wantVersionID, versionID)
+		})
+	}
+}
+
+func newTestCopy(client s3Client, copySource string) copyObject {
+	return copyObject{
+		client:     client,
+		keys:       stubKeys{},
+		bucket:     "bucket",
+		key:        "key",
+		copySource: copySource,
+		metadata:   map[string]string{},
+		log:        slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+}
+
+// testKeys returns a keyProvider that encrypts new objects with newKEK under the given key ID.
+// All other key IDs use oldKEK.
+func testKeys(oldKEK, newKEK [32]byte, keyID string) keyProvider {
+	return mappedKeys{current: keyID, keks: map[string][32]byte{keyID: newKEK}, fallback: oldKEK}
+}
+
+type mappedKeys struct {
+	current  string
+	keks     map[string][32]byte
+	fallback [32]byte
+}
+
+func (m mappedKeys) CurrentKey(context.Context, string, string) (string, [32]byte, error) {
+	return m.current, m.keks[m.current], nil
+}
+
+func (m mappedKeys) Key(_ context.Context, _, keyID string) ([32]byte, error) {
+	if kek, ok := m.keks[keyID]; ok {
+		return kek, nil
+	}
+	return m.fallback, nil
+}
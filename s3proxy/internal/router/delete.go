@@ -0,0 +1,246 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package router
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+const (
+	// maxDeleteBodySize limits the size of DeleteObjects request bodies.
+	// A request listing the maximum of 1000 keys with 1024 bytes each is well below 2 MiB.
+	maxDeleteBodySize = 2 << 20
+	// maxDeleteObjects is the maximum number of objects S3 accepts in a single DeleteObjects request.
+	maxDeleteObjects = 1000
+)
+
+// deleteObject bundles data to implement DeleteObject.
+// The manifest of a deleted object uploaded in parts is deleted as well,
+// unless S3 keeps the object as a previous version.
+type deleteObject struct {
+	client                    s3Client
+	key                       string
+	bucket                    string
+	versionID                 string
+	bypassGovernanceRetention bool
+	log                       *slog.Logger
+}
+
+// delete is a http.HandlerFunc that implements DeleteObject.
+func (d deleteObject) delete(w http.ResponseWriter, r *http.Request) {
+	d.log.With(slog.String("key", d.key), slog.String("host", d.bucket), slog.String("versionId", d.versionID)).Debug("deleteObject")
+
+	manifest := versionManifest(r.Context(), d.client, d.bucket, d.key, d.versionID, d.log)
+
+	output, err := d.client.DeleteObject(r.Context(), d.bucket, d.key, d.versionID, d.bypassGovernanceRetention)
+	if err != nil {
+		d.log.With(slog.Any("error", err)).Error("DeleteObject sending request to S3")
+		writeS3Error(w, err)
+		return
+	}
+	if err := deleteManifests(r.Context(), d.client, d.bucket, d.key, d.versionID, manifest, aws.ToBool(output.DeleteMarker)); err != nil {
+		// The object was deleted, so only log the error.
+		d.log.With(slog.Any("error", err)).Error("DeleteObject deleting manifests")
+	}
+
+	if aws.ToBool(output.DeleteMarker) {
+		w.Header().Set("x-amz-delete-marker", "true")
+	}
+	if output.VersionId != nil {
+		w.Header().Set("x-amz-version-id", *output.VersionId)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// deleteObjects bundles data to implement DeleteObjects.
+type deleteObjects struct {
+	client                    s3Client
+	bucket                    string
+	body                      io.Reader
+	bypassGovernanceRetention bool
+	log                       *slog.Logger
+}
+
+// delete is a http.HandlerFunc that implements DeleteObjects.
+// Objects under the reserved prefix of s3proxy can't be deleted.
+func (d deleteObjects) delete(w http.ResponseWriter, r *http.Request) {
+	d.log.With(slog.String("host", d.bucket)).Debug("deleteObjects")
+
+	var request deleteRequest
+	if err := xml.NewDecoder(d.body).Decode(&request); err != nil {
+		d.log.With(slog.Any("error", err)).Debug("DeleteObjects parsing request")
+		http.Error(w, fmt.Sprintf("MalformedXML: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+	if len(request.Objects) == 0 || len(request.Objects) > maxDeleteObjects {
+		http.Error(w, fmt.Sprintf("MalformedXML: the request must contain between 1 and %d objects", maxDeleteObjects), http.StatusBadRequest)
+		return
+	}
+	identifiers := make([]types.ObjectIdentifier, len(request.Objects))
+	for i, object := range request.Objects {
+		if strings.HasPrefix(object.Key, stateKeyPrefix) {
+			http.Error(w, reservedPrefixError, http.StatusForbidden)
+			return
+		}
+		identifiers[i] = types.ObjectIdentifier{Key: aws.String(object.Key)}
+		if object.VersionID != "" {
+			identifiers[i].VersionId = aws.String(object.VersionID)
+		}
+	}
+
+	manifests := d.versionManifests(r.Context(), request.Objects)
+
+	output, err := d.client.DeleteObjects(r.Context(), d.bucket, identifiers, d.bypassGovernanceRetention)
+	if err != nil {
+		d.log.With(slog.Any("error", err)).Error("DeleteObjects sending request to S3")
+		writeS3Error(w, err)
+		return
+	}
+
+	var result deleteResult
+	for _, deleted := range output.Deleted {
+		key, versionID := aws.ToString(deleted.Key), aws.ToString(deleted.VersionId)
+		if err := deleteManifests(r.Context(), d.client, d.bucket, key, versionID, manifests[deleteRequestObject{Key: key, VersionID: versionID}], aws.ToBool(deleted.DeleteMarker)); err != nil {
+			// The object was deleted, so only log the error.
+			d.log.With(slog.String("key", key), slog.Any("error", err)).Error("DeleteObjects deleting manifests")
+		}
+		if request.Quiet {
+			continue
+		}
+		result.Deleted = append(result.Deleted, deletedEntry{
+			Key:                   key,
+			VersionID:             versionID,
+			DeleteMarker:          aws.ToBool(deleted.DeleteMarker),
+			DeleteMarkerVersionID: aws.ToString(deleted.DeleteMarkerVersionId),
+		})
+	}
+	for _, deleteErr := range output.Errors {
+		result.Errors = append(result.Errors, deleteError{
+			Key:       aws.ToString(deleteErr.Key),
+			VersionID: aws.ToString(deleteErr.VersionId),
+			Code:      aws.ToString(deleteErr.Code),
+			Message:   aws.ToString(deleteErr.Message),
+		})
+	}
+	writeXML(w, result, d.log)
+}
+
+// versionManifests returns the manifests of the object versions that are deleted explicitly.
+func (d deleteObjects) versionManifests(ctx context.Context, objects []deleteRequestObject) map[deleteRequestObject]string {
+	manifests := make(map[deleteRequestObject]string)
+	var mux sync.Mutex
+	sem := make(chan struct{}, maxConcurrentHeads)
+	var wg sync.WaitGroup
+	for _, object := range objects {
+		if object.VersionID == "" {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			if manifest := versionManifest(ctx, d.client, d.bucket, object.Key, object.VersionID, d.log); manifest != "" {
+				mux.Lock()
+				manifests[object] = manifest
+				mux.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return manifests
+}
+
+// versionManifest returns the key of the manifest of the given version of an object.
+// An empty key is returned if no version is given, or if the version wasn't uploaded in parts.
+func versionManifest(ctx context.Context, client s3Client, bucket, key, versionID string, log *slog.Logger) string {
+	if versionID == "" {
+		return ""
+	}
+	head, err := client.HeadObject(ctx, bucket, key, versionID, "", "", "")
+	if err != nil {
+		// S3 decides whether the version can be deleted. Delete markers and objects encrypted with a customer key can't be inspected.
+		log.With(slog.String("key", key), slog.String("versionId", versionID), slog.Any("error", err)).Debug("Sending head request to S3 before deleting object")
+		return ""
+	}
+	if encryptionOf(head.Metadata) != encryptionMultipart {
+		return ""
+	}
+	header, _, err := parseMetadata(head.Metadata, multipartTag)
+	if err != nil {
+		return ""
+	}
+	return manifestKey(key, header)
+}
+
+// deleteManifests deletes the manifests that are no longer needed after an object was deleted.
+// If a version was deleted explicitly, only the manifest of that version is deleted.
+// If S3 created a delete marker instead of deleting the object, previous versions and their manifests are kept.
+// Otherwise, the bucket is unversioned and all manifests of the object are deleted.
+func deleteManifests(ctx context.Context, client s3Client, bucket, key, versionID, manifest string, deleteMarker bool) error {
+	switch {
+	case versionID != "":
+		if manifest == "" {
+			return nil
+		}
+		if _, err := client.DeleteObject(ctx, bucket, manifest, "", false); err != nil {
+			return fmt.Errorf("deleting manifest: %w", err)
+		}
+		return nil
+	case deleteMarker:
+		return nil
+	default:
+		return pruneManifests(ctx, client, bucket, key, "")
+	}
+}
+
+// deleteRequest is the body of a DeleteObjects request.
+type deleteRequest struct {
+	XMLName xml.Name              `xml:"Delete"`
+	Objects []deleteRequestObject `xml:"Object"`
+	Quiet   bool                  `xml:"Quiet"`
+}
+
+// deleteRequestObject is an object listed in a DeleteObjects request.
+type deleteRequestObject struct {
+	Key       string `xml:"Key"`
+	VersionID string `xml:"VersionId"`
+}
+
+// deleteResult is the response to a DeleteObjects request.
+type deleteResult struct {
+	XMLName xml.Name       `xml:"http://s3.amazonaws.com/doc/2006-03-01/ DeleteResult"`
+	Deleted []deletedEntry `xml:"Deleted"`
+	Errors  []deleteError  `xml:"Error"`
+}
+
+// deletedEntry is an object that was deleted by a DeleteObjects request.
+type deletedEntry struct {
+	Key                   string `xml:"Key"`
+	VersionID             string `xml:"VersionId,omitempty"`
+	DeleteMarker          bool   `xml:"DeleteMarker,omitempty"`
+	DeleteMarkerVersionID string `xml:"DeleteMarkerVersionId,omitempty"`
+}
+
+// deleteError is an object that couldn't be deleted by a DeleteObjects request.
+type deleteError struct {
+	Key       string `xml:"Key"`
+	VersionID string `xml:"VersionId,omitempty"`
+	Code      string `xml:"Code"`
+	Message   string `xml:"Message"`
+}
@@ -0,0 +1,207 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package router
+
+import (
+	"encoding/xml"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeleteObject(t *testing.T) {
+	kek := [32]byte{1, 2, 3}
+
+	testCases := map[string]struct {
+		versioned        bool
+		versionID        string
+		wantManifest     bool
+		wantDeleteMarker bool
+	}{
+		"unversioned bucket": {},
+		"versioned bucket keeps manifest of previous version": {
+			versioned:        true,
+			wantManifest:     true,
+			wantDeleteMarker: true,
+		},
+		"delete version": {
+			versioned: true,
+			versionID: "version-1",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			client := newStubBucket()
+			client.versioned = tc.versioned
+			manifest := uploadTestObject(t, client, kek)
+
+			d := deleteObject{
+				client:    client,
+				key:       "key",
+				bucket:    "bucket",
+				versionID: tc.versionID,
+				log:       slog.New(slog.NewTextHandler(io.Discard, nil)),
+			}
+			resp := httptest.NewRecorder()
+			d.delete(resp, httptest.NewRequest(http.MethodDelete, "/bucket/key", nil))
+			require.Equal(http.StatusNoContent, resp.Code, resp.Body.String())
+
+			assert.NotContains(client.objects, "key")
+			_, ok := client.objects[manifest]
+			assert.Equal(tc.wantManifest, ok)
+			if tc.wantDeleteMarker {
+				assert.Equal("true", resp.Header().Get("x-amz-delete-marker"))
+			}
+		})
+	}
+}
+
+func TestDeleteObjects(t *testing.T) {
+	kek := [32]byte{1, 2, 3}
+
+	testCases := map[string]struct {
+		body         string
+		wantCode     int
+		wantDeleted  []string
+		wantErrors   []string
+		wantRemoved  bool
+		wantManifest bool
+	}{
+		"delete objects": {
+			body:        "<Delete><Object><Key>key</Key></Object><Object><Key>plain</Key></Object><Object><Key>missing</Key></Object></Delete>",
+			wantCode:    http.StatusOK,
+			wantDeleted: []string{"key", "plain"},
+			wantErrors:  []string{"missing"},
+			wantRemoved: true,
+		},
+		"quiet": {
+			body:        "<Delete><Quiet>true</Quiet><Object><Key>key</Key></Object><Object><Key>plain</Key></Object></Delete>",
+			wantCode:    http.StatusOK,
+			wantRemoved: true,
+		},
+		"reserved prefix": {
+			body:         "<Delete><Object><Key>plain</Key></Object><Object><Key>" + stateKeyPrefix + "manifests/x</Key></Object></Delete>",
+			wantCode:     http.StatusForbidden,
+			wantManifest: true,
+		},
+		"no objects": {
+			body:         "<Delete></Delete>",
+			wantCode:     http.StatusBadRequest,
+			wantManifest: true,
+		},
+		"malformed body": {
+			body:         "<Delete>",
+			wantCode:     http.StatusBadRequest,
+			wantManifest: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			client := newStubBucket()
+			manifest := uploadTestObject(t, client, kek)
+			client.objects["plain"] = &stubS3Client{data: []byte("hello"), etag: "etag", metadata: map[string]string{}}
+
+			d := deleteObjects{
+				client: client,
+				bucket: "bucket",
+				body:   strings.NewReader(tc.body),
+				log:    slog.New(slog.NewTextHandler(io.Discard, nil)),
+			}
+			resp := httptest.NewRecorder()
+			d.delete(resp, httptest.NewRequest(http.MethodPost, "/bucket?delete", nil))
+			require.Equal(tc.wantCode, resp.Code, resp.Body.String())
+
+			_, ok := client.objects[manifest]
+			assert.Equal(tc.wantManifest, ok)
+			_, ok = client.objects["plain"]
+			assert.Equal(!tc.wantRemoved, ok)
+			if tc.wantCode != http.StatusOK {
+				return
+			}
+
+			var result deleteResult
+			require.NoError(xml.Unmarshal(resp.Body.Bytes(), &result))
+			var deleted, failed []string
+			for _, entry := range result.Deleted {
+				deleted = append(deleted, entry.Key)
+			}
+			for _, entry := range result.Errors {
+				failed = append(failed, entry.Key)
+			}
+			assert.ElementsMatch(tc.wantDeleted, deleted)
+			assert.ElementsMatch(tc.wantErrors, failed)
+		})
+	}
+}
+
+func TestServeRejectsReservedPrefix(t *testing.T) {
+	testCases := map[string]struct {
+		method string
+		host   string
+		path   string
+	}{
+		"get": {
+			method: http.MethodGet,
+			host:   "s3.eu-west-1.amazonaws.com",
+			path:   "/bucket/" + stateKeyPrefix + "manifests/x",
+		},
+		"put": {
+			method: http.MethodPut,
+			host:   "s3.eu-west-1.amazonaws.com",
+			path:   "/bucket/" + stateKeyPrefix + "manifests/x",
+		},
+		"delete": {
+			method: http.MethodDelete,
+			host:   "s3.eu-west-1.amazonaws.com",
+			path:   "/bucket/" + stateKeyPrefix + "manifests/x",
+		},
+		"bucket in host": {
+			method: http.MethodGet,
+			host:   "bucket.s3.eu-west-1.amazonaws.com",
+			path:   "/" + stateKeyPrefix + "manifests/x",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			r := New("eu-west-1", stubKeys{}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+			req := httptest.NewRequest(tc.method, tc.path, nil)
+			req.Host = tc.host
+			resp := httptest.NewRecorder()
+			r.Serve(resp, req)
+			assert.Equal(t, http.StatusForbidden, resp.Code)
+		})
+	}
+}
+
+// uploadTestObject uploads an object in parts under "key" and returns the key of its manifest.
+func uploadTestObject(t *testing.T, client *stubBucket, kek [32]byte) string {
+	t.Helper()
+	uploadID := createTestUpload(t, client, kek)
+	resp := uploadTestPart(t, client, kek, uploadID, 1, randomBytes(t, 1000))
+	require.Equal(t, http.StatusOK, resp.Code)
+	resp = completeTestUpload(t, client, kek, uploadID, []int32{1}, map[int32]string{1: resp.Header().Get("ETag")})
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	manifest := manifestKey("key", decodeHeader(t, client.objects["key"].metadata[multipartTag]))
+	require.Contains(t, client.objects, manifest)
+	return manifest
+}
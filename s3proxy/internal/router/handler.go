@@ -7,28 +7,26 @@ SPDX-License-Identifier: BUSL-1.1
 package router
 
 import (
-	"encoding/xml"
 	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/edgelesssys/constellation/v2/s3proxy/internal/s3"
 )
 
-func handleGetObject(client *s3.Client, key string, bucket string, log *slog.Logger) http.HandlerFunc {
+func handleGetObject(client *s3.Client, keys keyProvider, key string, bucket string, log *slog.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
 		log.With(slog.String("path", req.URL.Path), slog.String("method", req.Method), slog.String("host", req.Host)).Debug("intercepting")
-		if req.Header.Get("Range") != "" {
-			log.Error("GetObject Range header unsupported")
-			http.Error(w, "s3proxy currently does not support Range headers", http.StatusNotImplemented)
-			return
-		}
 
 		obj := object{
 			client:               client,
+			keys:                 keys,
 			key:                  key,
 			bucket:               bucket,
+			byteRange:            req.Header.Get("Range"),
 			query:                req.URL.Query(),
 			sseCustomerAlgorithm: req.Header.Get("x-amz-server-side-encryption-customer-algorithm"),
 			sseCustomerKey:       req.Header.Get("x-amz-server-side-encryption-customer-key"),
@@ -39,37 +37,33 @@ func handleGetObject(client *s3.Client, key string, bucket string, log *slog.Log
 	}
 }
 
-func handlePutObject(client *s3.Client, key string, bucket string, log *slog.Logger) http.HandlerFunc {
+func handleHeadObject(client *s3.Client, keys keyProvider, key string, bucket string, log *slog.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
-		log.With(slog.String("path", req.URL.Path), slog.String("method", req.Method), slog.String("host", req.Host)).Debug("intercepting")
-		body, err := io.ReadAll(req.Body)
-		if err != nil {
-			log.With(slog.Any("error", err)).Error("PutObject")
-			http.Error(w, fmt.Sprintf("reading body: %s", err.Error()), http.StatusInternalServerError)
-			return
+		log.With(slog.String("path", req.URL.Path), slog.String("method", req.Method), slog.String("host", req.Host)).Debug("intercepting HeadObject")
+
+		obj := object{
+			client:               client,
+			keys:                 keys,
+			key:                  key,
+			bucket:               bucket,
+			query:                req.URL.Query(),
+			sseCustomerAlgorithm: req.Header.Get("x-amz-server-side-encryption-customer-algorithm"),
+			sseCustomerKey:       req.Header.Get("x-amz-server-side-encryption-customer-key"),
+			sseCustomerKeyMD5:    req.Header.Get("x-amz-server-side-encryption-customer-key-MD5"),
+			log:                  log,
 		}
+		allowMethod(obj.head, "HEAD")(w, req)
+	}
+}
 
-		clientDigest := req.Header.Get("x-amz-content-sha256")
-		serverDigest := sha256sum(body)
-
-		// There may be a client that wants to test that incorrect content digests result in API errors.
-		// For encrypting the body we have to recalculate the content digest.
-		// If the client intentionally sends a mismatching content digest, we would take the client request, rewrap it,
-		// calculate the correct digest for the new body and NOT get an error.
-		// Thus we have to check incoming requets for matching content digests.
-		// UNSIGNED-PAYLOAD can be used to disabled payload signing. In that case we don't check the content digest.
-		if clientDigest != "" && clientDigest != "UNSIGNED-PAYLOAD" && clientDigest != serverDigest {
-			log.Debug("PutObject", "error", "x-amz-content-sha256 mismatch")
-			// The S3 API responds with an XML formatted error message.
-			mismatchErr := NewContentSHA256MismatchError(clientDigest, serverDigest)
-			marshalled, err := xml.Marshal(mismatchErr)
-			if err != nil {
-				log.With(slog.Any("error", err)).Error("PutObject")
-				http.Error(w, fmt.Sprintf("marshalling error: %s", err.Error()), http.StatusInternalServerError)
-				return
-			}
-
-			http.Error(w, string(marshalled), http.StatusBadRequest)
+func handlePutObject(client *s3.Client, keys keyProvider, key string, bucket string, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		log.With(slog.String("path", req.URL.Path), slog.String("method", req.Method), slog.String("host", req.Host)).Debug("intercepting")
+		// The ciphertext size is derived from the plaintext size, so the body size has to be known upfront.
+		// S3 itself rejects PutObject requests without a Content-Length.
+		if req.ContentLength < 0 {
+			log.Error("PutObject missing Content-Length")
+			http.Error(w, "MissingContentLength: you must provide the Content-Length HTTP header", http.StatusLengthRequired)
 			return
 		}
 
@@ -83,7 +77,7 @@ func handlePutObject(client *s3.Client, key string, bucket string, log *slog.Log
 			return
 		}
 
-		err = validateContentMD5(req.Header.Get("content-md5"), body)
+		body, err := newDigestReader(req.Body, req.Header.Get("x-amz-content-sha256"), req.Header.Get("content-md5"))
 		if err != nil {
 			log.With(slog.Any("error", err)).Error("validating content md5")
 			http.Error(w, fmt.Sprintf("validating content md5: %s", err.Error()), http.StatusBadRequest)
@@ -92,9 +86,11 @@ func handlePutObject(client *s3.Client, key string, bucket string, log *slog.Log
 
 		obj := object{
 			client:                    client,
+			keys:                      keys,
 			key:                       key,
 			bucket:                    bucket,
-			data:                      body,
+			body:                      body,
+			contentLength:             req.ContentLength,
 			query:                     req.URL.Query(),
 			tags:                      req.Header.Get("x-amz-tagging"),
 			contentType:               req.Header.Get("Content-Type"),
@@ -112,6 +108,67 @@ func handlePutObject(client *s3.Client, key string, bucket string, log *slog.Log
 	}
 }
 
+func handleCopyObject(client *s3.Client, keys keyProvider, key string, bucket string, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		log.With(slog.String("path", req.URL.Path), slog.String("method", req.Method), slog.String("host", req.Host)).Debug("intercepting CopyObject")
+
+		c := copyObject{
+			client:                         client,
+			keys:                           keys,
+			key:                            key,
+			bucket:                         bucket,
+			copySource:                     req.Header.Get("x-amz-copy-source"),
+			copySourceIfMatch:              req.Header.Get("x-amz-copy-source-if-match"),
+			copySourceIfNoneMatch:          req.Header.Get("x-amz-copy-source-if-none-match"),
+			copySourceIfModifiedSince:      req.Header.Get("x-amz-copy-source-if-modified-since"),
+			copySourceIfUnmodifiedSince:    req.Header.Get("x-amz-copy-source-if-unmodified-since"),
+			metadataDirective:              req.Header.Get("x-amz-metadata-directive"),
+			metadata:                       getMetadataHeaders(req.Header),
+			contentType:                    req.Header.Get("Content-Type"),
+			tags:                           req.Header.Get("x-amz-tagging"),
+			taggingDirective:               req.Header.Get("x-amz-tagging-directive"),
+			storageClass:                   req.Header.Get("x-amz-storage-class"),
+			sseCustomerAlgorithm:           req.Header.Get("x-amz-server-side-encryption-customer-algorithm"),
+			sseCustomerKey:                 req.Header.Get("x-amz-server-side-encryption-customer-key"),
+			sseCustomerKeyMD5:              req.Header.Get("x-amz-server-side-encryption-customer-key-MD5"),
+			copySourceSSECustomerAlgorithm: req.Header.Get("x-amz-copy-source-server-side-encryption-customer-algorithm"),
+			copySourceSSECustomerKey:       req.Header.Get("x-amz-copy-source-server-side-encryption-customer-key"),
+			copySourceSSECustomerKeyMD5:    req.Header.Get("x-amz-copy-source-server-side-encryption-customer-key-MD5"),
+			log:                            log,
+		}
+		put(c.copy)(w, req)
+	}
+}
+
+func handleListObjectsV2(client *s3.Client, keys keyProvider, bucket string, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		log.With(slog.String("path", req.URL.Path), slog.String("method", req.Method), slog.String("host", req.Host)).Debug("intercepting ListObjectsV2")
+
+		query := req.URL.Query()
+		maxKeys, err := parseMaxKeys(query.Get("max-keys"))
+		if err != nil {
+			log.With(slog.Any("error", err)).Debug("ListObjectsV2 parsing max-keys")
+			http.Error(w, fmt.Sprintf("InvalidArgument: %s", err.Error()), http.StatusBadRequest)
+			return
+		}
+
+		l := listObjects{
+			client:            client,
+			keys:              keys,
+			bucket:            bucket,
+			prefix:            query.Get("prefix"),
+			delimiter:         query.Get("delimiter"),
+			continuationToken: query.Get("continuation-token"),
+			startAfter:        query.Get("start-after"),
+			maxKeys:           maxKeys,
+			fetchOwner:        query.Get("fetch-owner") == "true",
+			encodingType:      query.Get("encoding-type"),
+			log:               log,
+		}
+		get(l.list)(w, req)
+	}
+}
+
 func handleForwards(log *slog.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
 		log.With(slog.String("path", req.URL.Path), slog.String("method", req.Method), slog.String("host", req.Host)).Debug("forwarding")
@@ -149,42 +206,155 @@ func handleForwards(log *slog.Logger) http.HandlerFunc {
 	}
 }
 
-// handleCreateMultipartUpload logs the request and blocks with an error message.
-func handleCreateMultipartUpload(log *slog.Logger) http.HandlerFunc {
+func handleCreateMultipartUpload(client *s3.Client, keys keyProvider, key string, bucket string, log *slog.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
 		log.With(slog.String("path", req.URL.Path), slog.String("method", req.Method), slog.String("host", req.Host)).Debug("intercepting CreateMultipartUpload")
 
-		log.Error("Blocking CreateMultipartUpload request")
-		http.Error(w, "s3proxy is configured to block CreateMultipartUpload requests", http.StatusNotImplemented)
+		raw := req.Header.Get("x-amz-object-lock-retain-until-date")
+		retentionTime, err := parseRetentionTime(raw)
+		if err != nil {
+			log.With(slog.String("data", raw), slog.Any("error", err)).Error("parsing lock retention time")
+			http.Error(w, fmt.Sprintf("parsing x-amz-object-lock-retain-until-date: %s", err.Error()), http.StatusInternalServerError)
+			return
+		}
+
+		upload := multipartUpload{
+			client:                    client,
+			keys:                      keys,
+			key:                       key,
+			bucket:                    bucket,
+			tags:                      req.Header.Get("x-amz-tagging"),
+			contentType:               req.Header.Get("Content-Type"),
+			metadata:                  getMetadataHeaders(req.Header),
+			objectLockLegalHoldStatus: req.Header.Get("x-amz-object-lock-legal-hold"),
+			objectLockMode:            req.Header.Get("x-amz-object-lock-mode"),
+			objectLockRetainUntilDate: retentionTime,
+			sseCustomerAlgorithm:      req.Header.Get("x-amz-server-side-encryption-customer-algorithm"),
+			sseCustomerKey:            req.Header.Get("x-amz-server-side-encryption-customer-key"),
+			sseCustomerKeyMD5:         req.Header.Get("x-amz-server-side-encryption-customer-key-MD5"),
+			log:                       log,
+		}
+		upload.create(w, req)
 	}
 }
 
-// handleUploadPart logs the request and blocks with an error message.
-func handleUploadPart(log *slog.Logger) http.HandlerFunc {
+func handleUploadPart(client *s3.Client, keys keyProvider, key string, bucket string, log *slog.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
 		log.With(slog.String("path", req.URL.Path), slog.String("method", req.Method), slog.String("host", req.Host)).Debug("intercepting UploadPart")
+		// Same as for PutObject, the ciphertext size is derived from the plaintext size.
+		if req.ContentLength < 0 {
+			log.Error("UploadPart missing Content-Length")
+			http.Error(w, "MissingContentLength: you must provide the Content-Length HTTP header", http.StatusLengthRequired)
+			return
+		}
+
+		query := req.URL.Query()
+		partNumber, err := strconv.ParseInt(query.Get("partNumber"), 10, 32)
+		if err != nil || partNumber < 1 || partNumber > 10000 {
+			log.With(slog.String("partNumber", query.Get("partNumber"))).Debug("UploadPart invalid part number")
+			http.Error(w, "InvalidArgument: part number must be an integer between 1 and 10000, inclusive", http.StatusBadRequest)
+			return
+		}
 
-		log.Error("Blocking UploadPart request")
-		http.Error(w, "s3proxy is configured to block UploadPart requests", http.StatusNotImplemented)
+		body, err := newDigestReader(req.Body, req.Header.Get("x-amz-content-sha256"), req.Header.Get("content-md5"))
+		if err != nil {
+			log.With(slog.Any("error", err)).Error("validating content md5")
+			http.Error(w, fmt.Sprintf("validating content md5: %s", err.Error()), http.StatusBadRequest)
+			return
+		}
+
+		upload := multipartUpload{
+			client:               client,
+			keys:                 keys,
+			key:                  key,
+			bucket:               bucket,
+			uploadID:             query.Get("uploadId"),
+			partNumber:           int32(partNumber),
+			body:                 body,
+			contentLength:        req.ContentLength,
+			sseCustomerAlgorithm: req.Header.Get("x-amz-server-side-encryption-customer-algorithm"),
+			sseCustomerKey:       req.Header.Get("x-amz-server-side-encryption-customer-key"),
+			sseCustomerKeyMD5:    req.Header.Get("x-amz-server-side-encryption-customer-key-MD5"),
+			log:                  log,
+		}
+		put(upload.uploadPart)(w, req)
 	}
 }
 
-// handleCompleteMultipartUpload logs the request and blocks with an error message.
-func handleCompleteMultipartUpload(log *slog.Logger) http.HandlerFunc {
+func handleCompleteMultipartUpload(client *s3.Client, keys keyProvider, key string, bucket string, log *slog.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
 		log.With(slog.String("path", req.URL.Path), slog.String("method", req.Method), slog.String("host", req.Host)).Debug("intercepting CompleteMultipartUpload")
 
-		log.Error("Blocking CompleteMultipartUpload request")
-		http.Error(w, "s3proxy is configured to block CompleteMultipartUpload requests", http.StatusNotImplemented)
+		upload := multipartUpload{
+			client:               client,
+			keys:                 keys,
+			key:                  key,
+			bucket:               bucket,
+			uploadID:             req.URL.Query().Get("uploadId"),
+			completeBody:         http.MaxBytesReader(w, req.Body, maxCompleteBodySize),
+			sseCustomerAlgorithm: req.Header.Get("x-amz-server-side-encryption-customer-algorithm"),
+			sseCustomerKey:       req.Header.Get("x-amz-server-side-encryption-customer-key"),
+			sseCustomerKeyMD5:    req.Header.Get("x-amz-server-side-encryption-customer-key-MD5"),
+			log:                  log,
+		}
+		upload.complete(w, req)
 	}
 }
 
-// handleAbortMultipartUpload logs the request and blocks with an error message.
-func handleAbortMultipartUpload(log *slog.Logger) http.HandlerFunc {
+func handleAbortMultipartUpload(client *s3.Client, keys keyProvider, key string, bucket string, log *slog.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
 		log.With(slog.String("path", req.URL.Path), slog.String("method", req.Method), slog.String("host", req.Host)).Debug("intercepting AbortMultipartUpload")
 
-		log.Error("Blocking AbortMultipartUpload request")
-		http.Error(w, "s3proxy is configured to block AbortMultipartUpload requests", http.StatusNotImplemented)
+		upload := multipartUpload{
+			client:   client,
+			keys:     keys,
+			key:      key,
+			bucket:   bucket,
+			uploadID: req.URL.Query().Get("uploadId"),
+			log:      log,
+		}
+		upload.abort(w, req)
+	}
+}
+
+func handleDeleteObject(client *s3.Client, key string, bucket string, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		log.With(slog.String("path", req.URL.Path), slog.String("method", req.Method), slog.String("host", req.Host)).Debug("intercepting DeleteObject")
+
+		d := deleteObject{
+			client:                    client,
+			key:                       key,
+			bucket:                    bucket,
+			versionID:                 req.URL.Query().Get("versionId"),
+			bypassGovernanceRetention: strings.EqualFold(req.Header.Get("x-amz-bypass-governance-retention"), "true"),
+			log:                       log,
+		}
+		allowMethod(d.delete, "DELETE")(w, req)
+	}
+}
+
+func handleDeleteObjects(client *s3.Client, bucket string, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		log.With(slog.String("path", req.URL.Path), slog.String("method", req.Method), slog.String("host", req.Host)).Debug("intercepting DeleteObjects")
+
+		d := deleteObjects{
+			client:                    client,
+			bucket:                    bucket,
+			body:                      http.MaxBytesReader(w, req.Body, maxDeleteBodySize),
+			bypassGovernanceRetention: strings.EqualFold(req.Header.Get("x-amz-bypass-governance-retention"), "true"),
+			log:                       log,
+		}
+		allowMethod(d.delete, "POST")(w, req)
+	}
+}
+
+// handleUploadPartCopy logs the request and blocks with an error message.
+// Copied parts would be stored without being encrypted for the target upload.
+func handleUploadPartCopy(log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		log.With(slog.String("path", req.URL.Path), slog.String("method", req.Method), slog.String("host", req.Host)).Debug("intercepting UploadPartCopy")
+
+		log.Error("Blocking UploadPartCopy request")
+		http.Error(w, "s3proxy does not support UploadPartCopy requests", http.StatusNotImplemented)
 	}
 }
@@ -0,0 +1,188 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package router
+
+import (
+	"encoding/xml"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// maxConcurrentHeads limits the number of HeadObject requests sent in parallel to determine the sizes of listed objects.
+const maxConcurrentHeads = 16
+
+// listObjects bundles data to implement ListObjectsV2.
+type listObjects struct {
+	client            s3Client
+	keys              keyProvider
+	bucket            string
+	prefix            string
+	delimiter         string
+	continuationToken string
+	startAfter        string
+	maxKeys           int32
+	fetchOwner        bool
+	encodingType      string
+	log               *slog.Logger
+}
+
+// list is a http.HandlerFunc that implements ListObjectsV2.
+// S3 lists the sizes of the ciphertexts. s3proxy replaces them with the sizes of the plaintexts, which are derived from the metadata of every object.
+// Objects under the reserved prefix of s3proxy are hidden.
+func (l listObjects) list(w http.ResponseWriter, r *http.Request) {
+	l.log.With(slog.String("host", l.bucket), slog.String("prefix", l.prefix)).Debug("listObjectsV2")
+
+	if l.encodingType != "" && l.encodingType != "url" {
+		http.Error(w, fmt.Sprintf("InvalidArgument: invalid encoding type %q", l.encodingType), http.StatusBadRequest)
+		return
+	}
+
+	output, err := l.client.ListObjectsV2(r.Context(), l.bucket, l.prefix, l.delimiter, l.continuationToken, l.startAfter, l.maxKeys, l.fetchOwner)
+	if err != nil {
+		l.log.With(slog.Any("error", err)).Error("ListObjectsV2 sending request to S3")
+		writeS3Error(w, err)
+		return
+	}
+
+	var contents []types.Object
+	for _, object := range output.Contents {
+		if !strings.HasPrefix(aws.ToString(object.Key), stateKeyPrefix) {
+			contents = append(contents, object)
+		}
+	}
+	sizes := l.plaintextSizes(r, contents)
+
+	result := listBucketResult{
+		Name:                  l.bucket,
+		Prefix:                l.encode(l.prefix),
+		Delimiter:             l.encode(l.delimiter),
+		MaxKeys:               aws.ToInt32(output.MaxKeys),
+		IsTruncated:           aws.ToBool(output.IsTruncated),
+		ContinuationToken:     l.continuationToken,
+		NextContinuationToken: aws.ToString(output.NextContinuationToken),
+		StartAfter:            l.encode(l.startAfter),
+		EncodingType:          l.encodingType,
+	}
+	for i, object := range contents {
+		entry := listEntry{
+			Key:          l.encode(aws.ToString(object.Key)),
+			LastModified: formatXMLTime(aws.ToTime(object.LastModified)),
+			ETag:         aws.ToString(object.ETag),
+			Size:         sizes[i],
+			StorageClass: string(object.StorageClass),
+		}
+		if object.Owner != nil {
+			entry.Owner = &listOwner{ID: aws.ToString(object.Owner.ID), DisplayName: aws.ToString(object.Owner.DisplayName)}
+		}
+		result.Contents = append(result.Contents, entry)
+	}
+	for _, prefix := range output.CommonPrefixes {
+		if aws.ToString(prefix.Prefix) == stateKeyPrefix {
+			continue
+		}
+		result.CommonPrefixes = append(result.CommonPrefixes, listPrefix{Prefix: l.encode(aws.ToString(prefix.Prefix))})
+	}
+	result.KeyCount = int32(len(result.Contents) + len(result.CommonPrefixes))
+
+	writeXML(w, result, l.log)
+}
+
+// plaintextSizes returns the plaintext sizes of the listed objects.
+// If the size of an object can't be determined, e.g. because it is encrypted with a customer key or its KEK was shredded,
+// the size listed by S3 is used.
+func (l listObjects) plaintextSizes(r *http.Request, objects []types.Object) []int64 {
+	sizes := make([]int64, len(objects))
+	sem := make(chan struct{}, maxConcurrentHeads)
+	var wg sync.WaitGroup
+	for i, object := range objects {
+		sizes[i] = aws.ToInt64(object.Size)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			log := l.log.With(slog.String("key", aws.ToString(object.Key)))
+			head, err := l.client.HeadObject(r.Context(), l.bucket, aws.ToString(object.Key), "", "", "", "")
+			if err != nil {
+				log.With(slog.Any("error", err)).Warn("ListObjectsV2 sending head request to S3")
+				return
+			}
+			size, err := plaintextSize(r.Context(), l.client, l.keys, l.bucket, aws.ToString(object.Key), head.Metadata, aws.ToInt64(head.ContentLength))
+			if err != nil {
+				log.With(slog.Any("error", err)).Warn("ListObjectsV2 getting plaintext size")
+				return
+			}
+			sizes[i] = size
+		}()
+	}
+	wg.Wait()
+	return sizes
+}
+
+// encode encodes a value of the response if the client requested URL encoding.
+func (l listObjects) encode(s string) string {
+	if l.encodingType != "url" {
+		return s
+	}
+	return url.QueryEscape(s)
+}
+
+// parseMaxKeys parses the max-keys parameter of a ListObjectsV2 request.
+func parseMaxKeys(raw string) (int32, error) {
+	if raw == "" {
+		return 0, nil
+	}
+	maxKeys, err := strconv.ParseInt(raw, 10, 32)
+	if err != nil || maxKeys < 0 {
+		return 0, fmt.Errorf("invalid max-keys %q", raw)
+	}
+	return int32(maxKeys), nil
+}
+
+// listBucketResult is the response body of a ListObjectsV2 request.
+type listBucketResult struct {
+	XMLName               xml.Name     `xml:"http://s3.amazonaws.com/doc/2006-03-01/ ListBucketResult"`
+	Name                  string       `xml:"Name"`
+	Prefix                string       `xml:"Prefix"`
+	Delimiter             string       `xml:"Delimiter,omitempty"`
+	MaxKeys               int32        `xml:"MaxKeys"`
+	KeyCount              int32        `xml:"KeyCount"`
+	IsTruncated           bool         `xml:"IsTruncated"`
+	ContinuationToken     string       `xml:"ContinuationToken,omitempty"`
+	NextContinuationToken string       `xml:"NextContinuationToken,omitempty"`
+	StartAfter            string       `xml:"StartAfter,omitempty"`
+	EncodingType          string       `xml:"EncodingType,omitempty"`
+	Contents              []listEntry  `xml:"Contents"`
+	CommonPrefixes        []listPrefix `xml:"CommonPrefixes"`
+}
+
+type listEntry struct {
+	Key          string     `xml:"Key"`
+	LastModified string     `xml:"LastModified"`
+	ETag         string     `xml:"ETag"`
+	Size         int64      `xml:"Size"`
+	StorageClass string     `xml:"StorageClass,omitempty"`
+	Owner        *listOwner `xml:"Owner,omitempty"`
+}
+
+type listOwner struct {
+	ID          string `xml:"ID"`
+	DisplayName string `xml:"DisplayName,omitempty"`
+}
+
+type listPrefix struct {
+	Prefix string `xml:"Prefix"`
+}
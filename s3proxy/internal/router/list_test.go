@@ -0,0 +1,121 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package router
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/edgelesssys/constellation/v2/s3proxy/internal/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListObjectsV2(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	kek := [32]byte{1, 2, 3}
+	client := newStubBucket()
+
+	// An object encrypted in segments.
+	streamPlaintext := randomBytes(t, crypto.SegmentSize+100)
+	body, err := newDigestReader(bytes.NewReader(streamPlaintext), "", "")
+	require.NoError(err)
+	obj := newTestObject(client, kek)
+	obj.key = "a/stream"
+	obj.body = body
+	obj.contentLength = int64(len(streamPlaintext))
+	obj.metadata = map[string]string{}
+	resp := httptest.NewRecorder()
+	obj.put(resp, httptest.NewRequest(http.MethodPut, "/bucket/a/stream", nil))
+	require.Equal(http.StatusOK, resp.Code)
+
+	// An object uploaded in parts, which also stores a manifest under the reserved prefix.
+	uploadID := createTestUpload(t, client, kek)
+	resp = uploadTestPart(t, client, kek, uploadID, 1, randomBytes(t, 1000))
+	require.Equal(http.StatusOK, resp.Code)
+	resp = completeTestUpload(t, client, kek, uploadID, []int32{1}, map[int32]string{1: resp.Header().Get("ETag")})
+	require.Equal(http.StatusOK, resp.Code)
+	moveTestObject(t, client, "key", "a/multipart")
+
+	// An unencrypted object.
+	client.objects["a/plain text"] = &stubS3Client{data: []byte("hello"), etag: "etag", metadata: map[string]string{}}
+
+	l := listObjects{
+		client: client,
+		keys:   stubKeys{kek: kek},
+		bucket: "bucket",
+		log:    slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	resp = httptest.NewRecorder()
+	l.list(resp, httptest.NewRequest(http.MethodGet, "/bucket?list-type=2", nil))
+	require.Equal(http.StatusOK, resp.Code, resp.Body.String())
+
+	var result listBucketResult
+	require.NoError(xml.Unmarshal(resp.Body.Bytes(), &result))
+	assert.Equal("bucket", result.Name)
+	assert.EqualValues(3, result.KeyCount)
+	sizes := map[string]int64{}
+	for _, entry := range result.Contents {
+		sizes[entry.Key] = entry.Size
+	}
+	assert.Equal(map[string]int64{
+		"a/multipart":  1000,
+		"a/plain text": 5,
+		"a/stream":     int64(len(streamPlaintext)),
+	}, sizes)
+
+	// Keys are encoded if requested by the client.
+	l.prefix = "a/p"
+	l.encodingType = "url"
+	resp = httptest.NewRecorder()
+	l.list(resp, httptest.NewRequest(http.MethodGet, "/bucket?list-type=2&prefix=a%2Fp&encoding-type=url", nil))
+	require.Equal(http.StatusOK, resp.Code, resp.Body.String())
+	result = listBucketResult{}
+	require.NoError(xml.Unmarshal(resp.Body.Bytes(), &result))
+	require.Len(result.Contents, 1)
+	assert.Equal("a%2Fplain+text", result.Contents[0].Key)
+	assert.Equal("a%2Fp", result.Prefix)
+	assert.Equal("url", result.EncodingType)
+
+	l.encodingType = "base64"
+	resp = httptest.NewRecorder()
+	l.list(resp, httptest.NewRequest(http.MethodGet, "/bucket?list-type=2&encoding-type=base64", nil))
+	assert.Equal(http.StatusBadRequest, resp.Code)
+}
+
+func TestParseMaxKeys(t *testing.T) {
+	testCases := map[string]struct {
+		raw     string
+		want    int32
+		wantErr bool
+	}{
+		"empty":    {raw: "", want: 0},
+		"valid":    {raw: "100", want: 100},
+		"negative": {raw: "-1", wantErr: true},
+		"invalid":  {raw: "abc", wantErr: true},
+		"overflow": {raw: "4294967296", wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			maxKeys, err := parseMaxKeys(tc.raw)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, maxKeys)
+		})
+	}
+}
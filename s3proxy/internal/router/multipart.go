@@ -0,0 +1,545 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package router
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/edgelesssys/constellation/v2/s3proxy/internal/crypto"
+)
+
+const (
+	// stateKeyPrefix is the key prefix under which s3proxy stores its own objects in a bucket.
+	stateKeyPrefix = ".constellation-s3proxy/"
+	// maxCompleteBodySize limits the size of CompleteMultipartUpload request bodies.
+	// A request listing the maximum of 10000 parts is well below 2 MiB.
+	maxCompleteBodySize = 2 << 20
+	// maxManifestSize limits the size of manifests read from S3.
+	maxManifestSize = 1 << 20
+)
+
+// multipartUpload bundles data to implement http.Handler methods for multipart uploads.
+// The DEK of an upload is generated on CreateMultipartUpload and stored in the bucket,
+// since UploadPart requests only reference the upload by its ID.
+type multipartUpload struct {
+	keys                      keyProvider
+	client                    s3Client
+	key                       string
+	bucket                    string
+	uploadID                  string
+	partNumber                int32
+	body                      *digestReader
+	contentLength             int64
+	completeBody              io.Reader
+	tags                      string
+	contentType               string
+	metadata                  map[string]string
+	objectLockLegalHoldStatus string
+	objectLockMode            string
+	objectLockRetainUntilDate time.Time
+	sseCustomerAlgorithm      string
+	sseCustomerKey            string
+	sseCustomerKeyMD5         string
+	log                       *slog.Logger
+}
+
+// uploadState is the state of a multipart upload that is stored in the bucket until the upload is completed or aborted.
+// The DEK is encrypted with the KEK, same as in the metadata of the object.
+type uploadState struct {
+	Header       []byte `json:"header"`
+	EncryptedDEK []byte `json:"encryptedDEK"`
+	KeyID        string `json:"keyID"`
+}
+
+// create is a http.HandlerFunc that implements CreateMultipartUpload.
+func (u multipartUpload) create(w http.ResponseWriter, r *http.Request) {
+	u.log.With(slog.String("key", u.key), slog.String("host", u.bucket)).Debug("createMultipartUpload")
+
+	keyID, kek, err := u.keys.CurrentKey(r.Context(), u.bucket, u.key)
+	if err != nil {
+		u.log.With(slog.Any("error", err)).Error("CreateMultipartUpload getting KEK")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	header, encryptedDEK, err := crypto.NewMultipartUpload(kek)
+	if err != nil {
+		u.log.With(slog.Any("error", err)).Error("CreateMultipartUpload")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	u.metadata[dekTag] = hex.EncodeToString(encryptedDEK)
+	u.metadata[multipartTag] = hex.EncodeToString(header)
+	u.metadata[keyIDTag] = keyID
+
+	output, err := u.client.CreateMultipartUpload(r.Context(), u.bucket, u.key, u.tags, u.contentType, u.objectLockLegalHoldStatus, u.objectLockMode, u.sseCustomerAlgorithm, u.sseCustomerKey, u.sseCustomerKeyMD5, u.objectLockRetainUntilDate, u.metadata)
+	if err != nil {
+		u.log.With(slog.Any("error", err)).Error("CreateMultipartUpload sending request to S3")
+		writeS3Error(w, err)
+		return
+	}
+	uploadID := aws.ToString(output.UploadId)
+
+	state, err := json.Marshal(uploadState{Header: header, EncryptedDEK: encryptedDEK, KeyID: keyID})
+	if err != nil {
+		u.log.With(slog.Any("error", err)).Error("CreateMultipartUpload marshalling upload state")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if _, err := u.client.PutObject(r.Context(), u.bucket, uploadStateKey(uploadID), "", "application/json", "", "", "", "", "", time.Time{}, map[string]string{}, bytes.NewReader(state), int64(len(state))); err != nil {
+		u.log.With(slog.Any("error", err)).Error("CreateMultipartUpload storing upload state")
+		// Without its state, the upload can't be used. Clean up to not leave an incomplete upload behind.
+		if _, abortErr := u.client.AbortMultipartUpload(context.WithoutCancel(r.Context()), u.bucket, u.key, uploadID); abortErr != nil {
+			u.log.With(slog.Any("error", abortErr)).Error("CreateMultipartUpload aborting upload")
+		}
+		writeS3Error(w, err)
+		return
+	}
+
+	if output.AbortDate != nil {
+		w.Header().Set("x-amz-abort-date", output.AbortDate.Format(http.TimeFormat))
+	}
+	if output.AbortRuleId != nil {
+		w.Header().Set("x-amz-abort-rule-id", *output.AbortRuleId)
+	}
+	if output.SSECustomerAlgorithm != nil {
+		w.Header().Set("x-amz-server-side-encryption-customer-algorithm", *output.SSECustomerAlgorithm)
+	}
+	if output.SSECustomerKeyMD5 != nil {
+		w.Header().Set("x-amz-server-side-encryption-customer-key-MD5", *output.SSECustomerKeyMD5)
+	}
+
+	writeXML(w, initiateMultipartUploadResult{
+		Bucket:   u.bucket,
+		Key:      u.key,
+		UploadID: uploadID,
+	}, u.log)
+}
+
+// uploadPart is a http.HandlerFunc that implements UploadPart.
+func (u multipartUpload) uploadPart(w http.ResponseWriter, r *http.Request) {
+	u.log.With(slog.String("key", u.key), slog.String("host", u.bucket), slog.Int("partNumber", int(u.partNumber))).Debug("uploadPart")
+
+	state, err := u.getState(r.Context())
+	if err != nil {
+		u.log.With(slog.Any("error", err)).Error("UploadPart reading upload state")
+		writeS3Error(w, err)
+		return
+	}
+
+	kek, err := u.keys.Key(r.Context(), u.bucket, state.KeyID)
+	if err != nil {
+		u.log.With(slog.Any("error", err)).Error("UploadPart getting KEK")
+		writeKeyError(w, err)
+		return
+	}
+
+	ciphertext, err := crypto.EncryptPart(u.body, state.Header, state.EncryptedDEK, kek, u.partNumber)
+	if err != nil {
+		u.log.With(slog.Any("error", err)).Error("UploadPart")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	output, err := u.client.UploadPart(r.Context(), u.bucket, u.key, u.uploadID, u.partNumber, u.sseCustomerAlgorithm, u.sseCustomerKey, u.sseCustomerKeyMD5, ciphertext, crypto.PartCiphertextSize(u.contentLength))
+	if err != nil {
+		if u.body.err != nil {
+			u.log.With(slog.Any("error", u.body.err)).Debug("UploadPart validating body")
+			writeDigestError(w, u.body.err, u.log)
+			return
+		}
+
+		u.log.With(slog.Any("error", err)).Error("UploadPart sending request to S3")
+		writeS3Error(w, err)
+		return
+	}
+
+	if output.ETag != nil {
+		w.Header().Set("ETag", strings.Trim(*output.ETag, "\""))
+	}
+	if output.SSECustomerAlgorithm != nil {
+		w.Header().Set("x-amz-server-side-encryption-customer-algorithm", *output.SSECustomerAlgorithm)
+	}
+	if output.SSECustomerKeyMD5 != nil {
+		w.Header().Set("x-amz-server-side-encryption-customer-key-MD5", *output.SSECustomerKeyMD5)
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// complete is a http.HandlerFunc that implements CompleteMultipartUpload.
+// It stores the manifest of the object before completing the upload.
+func (u multipartUpload) complete(w http.ResponseWriter, r *http.Request) {
+	u.log.With(slog.String("key", u.key), slog.String("host", u.bucket)).Debug("completeMultipartUpload")
+
+	var request completeMultipartUpload
+	if err := xml.NewDecoder(u.completeBody).Decode(&request); err != nil {
+		u.log.With(slog.Any("error", err)).Debug("CompleteMultipartUpload parsing request")
+		http.Error(w, fmt.Sprintf("MalformedXML: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+	if len(request.Parts) == 0 {
+		http.Error(w, "MalformedXML: the request must contain at least one part", http.StatusBadRequest)
+		return
+	}
+
+	state, err := u.getState(r.Context())
+	if err != nil {
+		u.log.With(slog.Any("error", err)).Error("CompleteMultipartUpload reading upload state")
+		writeS3Error(w, err)
+		return
+	}
+
+	uploadedParts, err := u.client.ListParts(r.Context(), u.bucket, u.key, u.uploadID, u.sseCustomerAlgorithm, u.sseCustomerKey, u.sseCustomerKeyMD5)
+	if err != nil {
+		u.log.With(slog.Any("error", err)).Error("CompleteMultipartUpload listing parts")
+		writeS3Error(w, err)
+		return
+	}
+	manifest, completedParts, err := buildManifest(request, uploadedParts)
+	if err != nil {
+		u.log.With(slog.Any("error", err)).Debug("CompleteMultipartUpload matching parts")
+		http.Error(w, fmt.Sprintf("InvalidPart: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	kek, err := u.keys.Key(r.Context(), u.bucket, state.KeyID)
+	if err != nil {
+		u.log.With(slog.Any("error", err)).Error("CompleteMultipartUpload getting KEK")
+		writeKeyError(w, err)
+		return
+	}
+	sealed, err := crypto.SealManifest(manifest, state.Header, state.EncryptedDEK, kek)
+	if err != nil {
+		u.log.With(slog.Any("error", err)).Error("CompleteMultipartUpload sealing manifest")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if _, err := u.client.PutObject(r.Context(), u.bucket, manifestKey(u.key, state.Header), "", "application/octet-stream", "", "", "", "", "", time.Time{}, map[string]string{}, bytes.NewReader(sealed), int64(len(sealed))); err != nil {
+		u.log.With(slog.Any("error", err)).Error("CompleteMultipartUpload storing manifest")
+		writeS3Error(w, err)
+		return
+	}
+
+	output, err := u.client.CompleteMultipartUpload(r.Context(), u.bucket, u.key, u.uploadID, completedParts, u.sseCustomerAlgorithm, u.sseCustomerKey, u.sseCustomerKeyMD5)
+	if err != nil {
+		u.log.With(slog.Any("error", err)).Error("CompleteMultipartUpload sending request to S3")
+		writeS3Error(w, err)
+		return
+	}
+
+	if _, err := u.client.DeleteObject(r.Context(), u.bucket, uploadStateKey(u.uploadID), "", false); err != nil {
+		// The upload was completed, so only log the error.
+		u.log.With(slog.Any("error", err)).Error("CompleteMultipartUpload deleting upload state")
+	}
+	if output.VersionId == nil {
+		// The object replaced a previous object with the same key, whose manifest is no longer needed.
+		if err := pruneManifests(r.Context(), u.client, u.bucket, u.key, manifestKey(u.key, state.Header)); err != nil {
+			u.log.With(slog.Any("error", err)).Error("CompleteMultipartUpload deleting manifests of replaced object")
+		}
+	}
+
+	if output.VersionId != nil {
+		w.Header().Set("x-amz-version-id", *output.VersionId)
+	}
+	if output.Expiration != nil {
+		w.Header().Set("x-amz-expiration", *output.Expiration)
+	}
+	if output.ServerSideEncryption != "" {
+		w.Header().Set("x-amz-server-side-encryption", string(output.ServerSideEncryption))
+	}
+
+	writeXML(w, completeMultipartUploadResult{
+		Location: aws.ToString(output.Location),
+		Bucket:   u.bucket,
+		Key:      u.key,
+		ETag:     aws.ToString(output.ETag),
+	}, u.log)
+}
+
+// abort is a http.HandlerFunc that implements AbortMultipartUpload.
+func (u multipartUpload) abort(w http.ResponseWriter, r *http.Request) {
+	u.log.With(slog.String("key", u.key), slog.String("host", u.bucket)).Debug("abortMultipartUpload")
+
+	if _, err := u.client.AbortMultipartUpload(r.Context(), u.bucket, u.key, u.uploadID); err != nil {
+		u.log.With(slog.Any("error", err)).Error("AbortMultipartUpload sending request to S3")
+		writeS3Error(w, err)
+		return
+	}
+
+	if _, err := u.client.DeleteObject(r.Context(), u.bucket, uploadStateKey(u.uploadID), "", false); err != nil {
+		// The upload was aborted, so only log the error.
+		u.log.With(slog.Any("error", err)).Error("AbortMultipartUpload deleting upload state")
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getState reads the state of the multipart upload from the bucket.
+func (u multipartUpload) getState(ctx context.Context) (uploadState, error) {
+	output, err := u.client.GetObject(ctx, u.bucket, uploadStateKey(u.uploadID), "", "", "", "", "", "")
+	if err != nil {
+		return uploadState{}, fmt.Errorf("getting upload state: %w", err)
+	}
+	defer output.Body.Close()
+
+	var state uploadState
+	if err := json.NewDecoder(io.LimitReader(output.Body, maxManifestSize)).Decode(&state); err != nil {
+		return uploadState{}, fmt.Errorf("decoding upload state: %w", err)
+	}
+	return state, nil
+}
+
+// buildManifest matches the parts of a CompleteMultipartUpload request with the uploaded parts.
+// It returns the manifest of the object and the parts to send to S3.
+func buildManifest(request completeMultipartUpload, uploadedParts []types.Part) (crypto.Manifest, []types.CompletedPart, error) {
+	uploaded := make(map[int32]types.Part, len(uploadedParts))
+	for _, part := range uploadedParts {
+		uploaded[aws.ToInt32(part.PartNumber)] = part
+	}
+
+	var manifest crypto.Manifest
+	var completedParts []types.CompletedPart
+	for i, requested := range request.Parts {
+		if i > 0 && requested.PartNumber <= request.Parts[i-1].PartNumber {
+			return crypto.Manifest{}, nil, fmt.Errorf("parts must be listed in ascending order, part %d follows part %d", requested.PartNumber, request.Parts[i-1].PartNumber)
+		}
+		part, ok := uploaded[requested.PartNumber]
+		if !ok || strings.Trim(aws.ToString(part.ETag), "\"") != strings.Trim(requested.ETag, "\"") {
+			return crypto.Manifest{}, nil, fmt.Errorf("part %d not found or ETag mismatch", requested.PartNumber)
+		}
+		size, err := crypto.PartPlaintextSize(aws.ToInt64(part.Size))
+		if err != nil {
+			return crypto.Manifest{}, nil, fmt.Errorf("part %d: %w", requested.PartNumber, err)
+		}
+
+		manifest.Parts = append(manifest.Parts, crypto.ManifestPart{Number: requested.PartNumber, Size: size})
+		completedParts = append(completedParts, types.CompletedPart{
+			PartNumber:    part.PartNumber,
+			ETag:          part.ETag,
+			ChecksumCRC32: part.ChecksumCRC32,
+		})
+	}
+	return manifest, completedParts, nil
+}
+
+// getManifest reads and verifies the manifest of a multipart object.
+func getManifest(ctx context.Context, client s3Client, bucket, key string, metadata map[string]string, kek [32]byte) (crypto.Manifest, []byte, []byte, error) {
+	header, encryptedDEK, err := parseMetadata(metadata, multipartTag)
+	if err != nil {
+		return crypto.Manifest{}, nil, nil, err
+	}
+
+	output, err := client.GetObject(ctx, bucket, manifestKey(key, header), "", "", "", "", "", "")
+	if err != nil {
+		return crypto.Manifest{}, nil, nil, fmt.Errorf("getting manifest: %w", err)
+	}
+	defer output.Body.Close()
+	sealed, err := io.ReadAll(io.LimitReader(output.Body, maxManifestSize))
+	if err != nil {
+		return crypto.Manifest{}, nil, nil, fmt.Errorf("reading manifest: %w", err)
+	}
+
+	manifest, err := crypto.OpenManifest(sealed, header, encryptedDEK, kek)
+	if err != nil {
+		return crypto.Manifest{}, nil, nil, err
+	}
+	return manifest, header, encryptedDEK, nil
+}
+
+// decryptMultipart returns the plaintext of a multipart object and its size.
+func (o object) decryptMultipart(ctx context.Context, output *s3.GetObjectOutput, kek [32]byte) (io.Reader, int64, error) {
+	manifest, header, encryptedDEK, err := getManifest(ctx, o.client, o.bucket, o.key, output.Metadata, kek)
+	if err != nil {
+		return nil, 0, err
+	}
+	if aws.ToInt64(output.ContentLength) != manifest.CiphertextSize() {
+		return nil, 0, fmt.Errorf("object size %d doesn't match manifest size %d", aws.ToInt64(output.ContentLength), manifest.CiphertextSize())
+	}
+
+	size := manifest.Size()
+	if size == 0 {
+		return bytes.NewReader(nil), 0, nil
+	}
+	plaintext, err := crypto.DecryptParts(output.Body, nil, header, encryptedDEK, kek, manifest, 0, size-1)
+	if err != nil {
+		return nil, 0, err
+	}
+	return plaintext, size, nil
+}
+
+// getMultipartRange serves a byte range of a multipart object.
+// Only the segments covering the range are fetched and decrypted.
+func (o object) getMultipartRange(w http.ResponseWriter, r *http.Request, versionID string, head *s3.HeadObjectOutput, kek [32]byte) {
+	manifest, header, encryptedDEK, err := getManifest(r.Context(), o.client, o.bucket, o.key, head.Metadata, kek)
+	if err != nil {
+		o.log.With(slog.Any("error", err)).Error("GetObject reading manifest")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if aws.ToInt64(head.ContentLength) != manifest.CiphertextSize() {
+		err := fmt.Errorf("object size %d doesn't match manifest size %d", aws.ToInt64(head.ContentLength), manifest.CiphertextSize())
+		o.log.With(slog.Any("error", err)).Error("GetObject reading manifest")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	size := manifest.Size()
+	start, end, status, ok := o.resolveRange(w, size)
+	if !ok {
+		return
+	}
+	if size == 0 {
+		writeRangeHeaders(w, 0, -1, 0, status)
+		return
+	}
+
+	// Pin the version returned by the head request, so the fetched segments belong to the same object.
+	if head.VersionId != nil {
+		versionID = *head.VersionId
+	}
+	etag := aws.ToString(head.ETag)
+	ctStart, ctEnd, prefixOffset := manifest.CiphertextRange(start, end)
+
+	var prefix []byte
+	if prefixOffset != ctStart {
+		prefixOutput, err := o.client.GetObject(r.Context(), o.bucket, o.key, versionID, fmt.Sprintf("bytes=%d-%d", prefixOffset, prefixOffset+crypto.PartPrefixSize-1), etag, o.sseCustomerAlgorithm, o.sseCustomerKey, o.sseCustomerKeyMD5)
+		if err != nil {
+			o.log.With(slog.Any("error", err)).Error("GetObject sending request to S3")
+			writeS3Error(w, err)
+			return
+		}
+		prefix = make([]byte, crypto.PartPrefixSize)
+		_, err = io.ReadFull(prefixOutput.Body, prefix)
+		prefixOutput.Body.Close()
+		if err != nil {
+			o.log.With(slog.Any("error", err)).Error("GetObject reading part prefix")
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	output, err := o.client.GetObject(r.Context(), o.bucket, o.key, versionID, fmt.Sprintf("bytes=%d-%d", ctStart, ctEnd), etag, o.sseCustomerAlgorithm, o.sseCustomerKey, o.sseCustomerKeyMD5)
+	if err != nil {
+		o.log.With(slog.Any("error", err)).Error("GetObject sending request to S3")
+		writeS3Error(w, err)
+		return
+	}
+	defer output.Body.Close()
+
+	plaintext, err := crypto.DecryptParts(output.Body, prefix, header, encryptedDEK, kek, manifest, start, end)
+	if err != nil {
+		o.log.With(slog.Any("error", err)).Error("GetObject decrypting response")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	setGetObjectHeaders(w, output)
+	writeRangeHeaders(w, start, end, size, status)
+	if _, err := io.Copy(w, plaintext); err != nil {
+		o.log.With(slog.Any("error", err)).Error("GetObject sending response")
+	}
+}
+
+// uploadStateKey returns the key of the object holding the state of a multipart upload.
+func uploadStateKey(uploadID string) string {
+	return stateKeyPrefix + "uploads/" + uploadID
+}
+
+// manifestKey returns the key of the object holding the manifest of a multipart object.
+// Manifests are stored per object key, so copies of an object within a bucket don't share a manifest.
+func manifestKey(key string, header []byte) string {
+	return manifestPrefix(key) + hex.EncodeToString(header)
+}
+
+// manifestPrefix returns the key prefix of the manifests of all versions of an object.
+func manifestPrefix(key string) string {
+	digest := sha256.Sum256([]byte(key))
+	return stateKeyPrefix + "manifests/" + hex.EncodeToString(digest[:]) + "/"
+}
+
+// pruneManifests deletes the manifests of an object except for the manifest with the key keep, which may be empty.
+// It must only be called if previous versions of the object aren't kept by S3, i.e., the bucket is unversioned.
+func pruneManifests(ctx context.Context, client s3Client, bucket, key, keep string) error {
+	var continuationToken string
+	for {
+		output, err := client.ListObjectsV2(ctx, bucket, manifestPrefix(key), "", continuationToken, "", 1000, false)
+		if err != nil {
+			return fmt.Errorf("listing manifests: %w", err)
+		}
+		for _, object := range output.Contents {
+			if aws.ToString(object.Key) == keep {
+				continue
+			}
+			if _, err := client.DeleteObject(ctx, bucket, aws.ToString(object.Key), "", false); err != nil {
+				return fmt.Errorf("deleting manifest: %w", err)
+			}
+		}
+		if !aws.ToBool(output.IsTruncated) {
+			return nil
+		}
+		continuationToken = aws.ToString(output.NextContinuationToken)
+	}
+}
+
+// writeXML writes an XML response with status 200.
+func writeXML(w http.ResponseWriter, v any, log *slog.Logger) {
+	body, err := xml.Marshal(v)
+	if err != nil {
+		log.With(slog.Any("error", err)).Error("marshalling response")
+		http.Error(w, fmt.Sprintf("marshalling response: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+	body = append([]byte(xml.Header), body...)
+
+	w.Header().Set("Content-Type", "application/xml")
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(body); err != nil {
+		log.With(slog.Any("error", err)).Error("sending response")
+	}
+}
+
+// completeMultipartUpload is the body of a CompleteMultipartUpload request.
+type completeMultipartUpload struct {
+	XMLName xml.Name `xml:"CompleteMultipartUpload"`
+	Parts   []struct {
+		PartNumber int32  `xml:"PartNumber"`
+		ETag       string `xml:"ETag"`
+	} `xml:"Part"`
+}
+
+// initiateMultipartUploadResult is the response to a CreateMultipartUpload request.
+type initiateMultipartUploadResult struct {
+	XMLName  xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ InitiateMultipartUploadResult"`
+	Bucket   string   `xml:"Bucket"`
+	Key      string   `xml:"Key"`
+	UploadID string   `xml:"UploadId"`
+}
+
+// completeMultipartUploadResult is the response to a CompleteMultipartUpload request.
+type completeMultipartUploadResult struct {
+	XMLName  xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ CompleteMultipartUploadResult"`
+	Location string   `xml:"Location"`
+	Bucket   string   `xml:"Bucket"`
+	Key      string   `xml:"Key"`
+	ETag     string   `xml:"ETag"`
+}
@@ -0,0 +1,449 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package router
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/edgelesssys/constellation/v2/s3proxy/internal/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMultipartUpload(t *testing.T) {
+	client := newStubBucket()
+	kek := [32]byte{1, 2, 3}
+
+	uploadID := createTestUpload(t, client, kek)
+	assert.Contains(t, client.objects, uploadStateKey(uploadID))
+
+	// Parts are uploaded out of order and part 2 is uploaded twice.
+	parts := map[int32][]byte{
+		1: randomBytes(t, 2*crypto.SegmentSize+10),
+		2: randomBytes(t, crypto.SegmentSize),
+		4: randomBytes(t, 100),
+	}
+	etags := map[int32]string{}
+	for _, number := range []int32{4, 2, 1, 2} {
+		resp := uploadTestPart(t, client, kek, uploadID, number, parts[number])
+		require.Equal(t, http.StatusOK, resp.Code)
+		etags[number] = resp.Header().Get("ETag")
+		assert.NotContains(t, string(client.uploads[uploadID][number]), string(parts[number]))
+	}
+
+	resp := completeTestUpload(t, client, kek, uploadID, []int32{1, 2, 4}, etags)
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+	var result completeMultipartUploadResult
+	require.NoError(t, xml.Unmarshal(resp.Body.Bytes(), &result))
+	assert.Equal(t, "key", result.Key)
+	assert.NotContains(t, client.objects, uploadStateKey(uploadID))
+	assert.Contains(t, client.objects["key"].metadata, multipartTag)
+	assert.Equal(t, "s3proxy/bucket/0/", client.objects["key"].metadata[keyIDTag])
+
+	plaintext := append(append(bytes.Clone(parts[1]), parts[2]...), parts[4]...)
+	size := int64(len(plaintext))
+
+	t.Run("get", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		newTestObject(client, kek).get(resp, httptest.NewRequest(http.MethodGet, "/bucket/key", nil))
+		require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+		assert.Equal(t, plaintext, resp.Body.Bytes())
+		assert.Equal(t, strconv.FormatInt(size, 10), resp.Header().Get("Content-Length"))
+	})
+
+	ranges := map[string]struct {
+		start, end int64
+	}{
+		"within first part":    {start: 10, end: 20},
+		"within later segment": {start: crypto.SegmentSize + 1, end: crypto.SegmentSize + 2},
+		"across parts":         {start: 2*crypto.SegmentSize + 5, end: 2*crypto.SegmentSize + 15},
+		"last part":            {start: size - 100, end: size - 1},
+	}
+	for name, tc := range ranges {
+		t.Run(name, func(t *testing.T) {
+			obj := newTestObject(client, kek)
+			obj.byteRange = fmt.Sprintf("bytes=%d-%d", tc.start, tc.end)
+			resp := httptest.NewRecorder()
+			obj.get(resp, httptest.NewRequest(http.MethodGet, "/bucket/key", nil))
+			require.Equal(t, http.StatusPartialContent, resp.Code, resp.Body.String())
+			assert.Equal(t, plaintext[tc.start:tc.end+1], resp.Body.Bytes())
+			assert.Equal(t, fmt.Sprintf("bytes %d-%d/%d", tc.start, tc.end, size), resp.Header().Get("Content-Range"))
+		})
+	}
+
+	t.Run("modified manifest", func(t *testing.T) {
+		manifestKey := manifestKey("key", decodeHeader(t, client.objects["key"].metadata[multipartTag]))
+		manifest := client.objects[manifestKey]
+		original := manifest.data
+		manifest.data = bytes.Clone(original)
+		manifest.data[len(manifest.data)-1] ^= 1
+		defer func() { manifest.data = original }()
+
+		resp := httptest.NewRecorder()
+		newTestObject(client, kek).get(resp, httptest.NewRequest(http.MethodGet, "/bucket/key", nil))
+		assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	})
+}
+
+func TestCompleteMultipartUploadInvalidParts(t *testing.T) {
+	kek := [32]byte{1, 2, 3}
+
+	testCases := map[string]struct {
+		numbers    []int32
+		etags      map[int32]string
+		wantStatus int
+	}{
+		"unknown part": {
+			numbers:    []int32{1, 2},
+			wantStatus: http.StatusBadRequest,
+		},
+		"wrong etag": {
+			numbers:    []int32{1},
+			etags:      map[int32]string{1: "wrong"},
+			wantStatus: http.StatusBadRequest,
+		},
+		"descending order": {
+			numbers:    []int32{3, 1},
+			wantStatus: http.StatusBadRequest,
+		},
+		"no parts": {
+			numbers:    []int32{},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			client := newStubBucket()
+			uploadID := createTestUpload(t, client, kek)
+			etags := map[int32]string{}
+			for _, number := range []int32{1, 3} {
+				resp := uploadTestPart(t, client, kek, uploadID, number, []byte("part"))
+				require.Equal(t, http.StatusOK, resp.Code)
+				etags[number] = resp.Header().Get("ETag")
+			}
+			for number, etag := range tc.etags {
+				etags[number] = etag
+			}
+
+			resp := completeTestUpload(t, client, kek, uploadID, tc.numbers, etags)
+			assert.Equal(t, tc.wantStatus, resp.Code)
+			assert.NotContains(t, client.objects, "key")
+		})
+	}
+}
+
+func TestUploadPartDigestMismatch(t *testing.T) {
+	client := newStubBucket()
+	kek := [32]byte{1, 2, 3}
+	uploadID := createTestUpload(t, client, kek)
+
+	// digest of "hello, world"
+	body, err := newDigestReader(strings.NewReader("hello, world!"), "09ca7e4eaa6e8ae9c7d261167129184883644d07dfba7cbfbc4c8a2e08360d5b", "")
+	require.NoError(t, err)
+	upload := newTestUpload(client, kek, uploadID)
+	upload.partNumber = 1
+	upload.body = body
+	upload.contentLength = int64(len("hello, world!"))
+
+	resp := httptest.NewRecorder()
+	upload.uploadPart(resp, httptest.NewRequest(http.MethodPut, "/bucket/key", nil))
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+	assert.Contains(t, resp.Body.String(), "XAmzContentSHA256Mismatch")
+	assert.Empty(t, client.uploads[uploadID])
+}
+
+func TestAbortMultipartUpload(t *testing.T) {
+	client := newStubBucket()
+	kek := [32]byte{1, 2, 3}
+	uploadID := createTestUpload(t, client, kek)
+
+	resp := httptest.NewRecorder()
+	newTestUpload(client, kek, uploadID).abort(resp, httptest.NewRequest(http.MethodDelete, "/bucket/key", nil))
+	assert.Equal(t, http.StatusNoContent, resp.Code)
+	assert.NotContains(t, client.uploads, uploadID)
+	assert.NotContains(t, client.objects, uploadStateKey(uploadID))
+}
+
+func createTestUpload(t *testing.T, client *stubBucket, kek [32]byte) string {
+	t.Helper()
+	upload := newTestUpload(client, kek, "")
+	upload.metadata = map[string]string{}
+	resp := httptest.NewRecorder()
+	upload.create(resp, httptest.NewRequest(http.MethodPost, "/bucket/key?uploads", nil))
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+
+	var result initiateMultipartUploadResult
+	require.NoError(t, xml.Unmarshal(resp.Body.Bytes(), &result))
+	return result.UploadID
+}
+
+func uploadTestPart(t *testing.T, client *stubBucket, kek [32]byte, uploadID string, number int32, data []byte) *httptest.ResponseRecorder {
+	t.Helper()
+	body, err := newDigestReader(bytes.NewReader(data), "", "")
+	require.NoError(t, err)
+	upload := newTestUpload(client, kek, uploadID)
+	upload.partNumber = number
+	upload.body = body
+	upload.contentLength = int64(len(data))
+	resp := httptest.NewRecorder()
+	upload.uploadPart(resp, httptest.NewRequest(http.MethodPut, "/bucket/key", nil))
+	return resp
+}
+
+func completeTestUpload(t *testing.T, client *stubBucket, kek [32]byte, uploadID string, numbers []int32, etags map[int32]string) *httptest.ResponseRecorder {
+	t.Helper()
+	var body strings.Builder
+	body.WriteString("<CompleteMultipartUpload>")
+	for _, number := range numbers {
+		fmt.Fprintf(&body, "<Part><PartNumber>%d</PartNumber><ETag>%q</ETag></Part>", number, etags[number])
+	}
+	body.WriteString("</CompleteMultipartUpload>")
+
+	upload := newTestUpload(client, kek, uploadID)
+	upload.completeBody = strings.NewReader(body.String())
+	resp := httptest.NewRecorder()
+	upload.complete(resp, httptest.NewRequest(http.MethodPost, "/bucket/key", nil))
+	return resp
+}
+
+func newTestUpload(client s3Client, kek [32]byte, uploadID string) multipartUpload {
+	return multipartUpload{
+		client:   client,
+		keys:     stubKeys{kek: kek},
+		bucket:   "bucket",
+		key:      "key",
+		uploadID: uploadID,
+		log:      slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+}
+
+func randomBytes(t *testing.T, n int) []byte {
+	t.Helper()
+	b := make([]byte, n)
+	_, err := rand.Read(b)
+	require.NoError(t, err)
+	return b
+}
+
+// moveTestObject renames an object uploaded in parts together with its manifest.
+func moveTestObject(t *testing.T, client *stubBucket, from, to string) {
+	t.Helper()
+	obj := client.objects[from]
+	header := decodeHeader(t, obj.metadata[multipartTag])
+	client.objects[to] = obj
+	client.objects[manifestKey(to, header)] = client.objects[manifestKey(from, header)]
+	delete(client.objects, from)
+	delete(client.objects, manifestKey(from, header))
+}
+
+func decodeHeader(t *testing.T, s string) []byte {
+	t.Helper()
+	header, _, err := parseMetadata(map[string]string{multipartTag: s}, multipartTag)
+	require.NoError(t, err)
+	return header
+}
+
+// stubBucket stores objects and multipart uploads of a single bucket in memory.
+// If versioned is set, requests report version IDs and deletions create delete markers,
+// but only the latest version of an object is stored.
+type stubBucket struct {
+	objects   map[string]*stubS3Client
+	uploads   map[string]map[int32][]byte
+	metadata  map[string]map[string]string
+	nextID    int
+	versioned bool
+}
+
+func newStubBucket() *stubBucket {
+	return &stubBucket{
+		objects:  map[string]*stubS3Client{},
+		uploads:  map[string]map[int32][]byte{},
+		metadata: map[string]map[string]string{},
+	}
+}
+
+func (b *stubBucket) GetObject(ctx context.Context, bucket, key, versionID, byteRange, ifMatch, sseCustomerAlgorithm, sseCustomerKey, sseCustomerKeyMD5 string) (*s3.GetObjectOutput, error) {
+	obj, ok := b.objects[key]
+	if !ok {
+		return nil, fmt.Errorf("https response error StatusCode: %d", http.StatusNotFound)
+	}
+	return obj.GetObject(ctx, bucket, key, versionID, byteRange, ifMatch, sseCustomerAlgorithm, sseCustomerKey, sseCustomerKeyMD5)
+}
+
+func (b *stubBucket) HeadObject(ctx context.Context, bucket, key, versionID, sseCustomerAlgorithm, sseCustomerKey, sseCustomerKeyMD5 string) (*s3.HeadObjectOutput, error) {
+	obj, ok := b.objects[key]
+	if !ok {
+		return nil, fmt.Errorf("https response error StatusCode: %d", http.StatusNotFound)
+	}
+	return obj.HeadObject(ctx, bucket, key, versionID, sseCustomerAlgorithm, sseCustomerKey, sseCustomerKeyMD5)
+}
+
+func (b *stubBucket) PutObject(ctx context.Context, bucket, key, tags, contentType, objectLockLegalHoldStatus, objectLockMode, sseCustomerAlgorithm, sseCustomerKey, sseCustomerKeyMD5 string, objectLockRetainUntilDate time.Time, metadata map[string]string, body io.Reader, contentLength int64) (*s3.PutObjectOutput, error) {
+	obj := &stubS3Client{}
+	output, err := obj.PutObject(ctx, bucket, key, tags, contentType, objectLockLegalHoldStatus, objectLockMode, sseCustomerAlgorithm, sseCustomerKey, sseCustomerKeyMD5, objectLockRetainUntilDate, metadata, body, contentLength)
+	if err != nil {
+		return nil, err
+	}
+	b.objects[key] = obj
+	output.VersionId = b.versionID()
+	return output, nil
+}
+
+func (b *stubBucket) DeleteObject(_ context.Context, _, key, versionID string, _ bool) (*s3.DeleteObjectOutput, error) {
+	delete(b.objects, key)
+	if b.versioned && versionID == "" {
+		return &s3.DeleteObjectOutput{DeleteMarker: aws.Bool(true), VersionId: b.versionID()}, nil
+	}
+	if versionID != "" {
+		return &s3.DeleteObjectOutput{VersionId: &versionID}, nil
+	}
+	return &s3.DeleteObjectOutput{}, nil
+}
+
+func (b *stubBucket) DeleteObjects(ctx context.Context, bucket string, objects []types.ObjectIdentifier, bypassGovernanceRetention bool) (*s3.DeleteObjectsOutput, error) {
+	output := &s3.DeleteObjectsOutput{}
+	for _, object := range objects {
+		if _, ok := b.objects[aws.ToString(object.Key)]; !ok {
+			output.Errors = append(output.Errors, types.Error{Key: object.Key, VersionId: object.VersionId, Code: aws.String("NoSuchKey"), Message: aws.String("not found")})
+			continue
+		}
+		deleted, err := b.DeleteObject(ctx, bucket, aws.ToString(object.Key), aws.ToString(object.VersionId), bypassGovernanceRetention)
+		if err != nil {
+			return nil, err
+		}
+		entry := types.DeletedObject{Key: object.Key, VersionId: object.VersionId, DeleteMarker: deleted.DeleteMarker}
+		if aws.ToBool(deleted.DeleteMarker) {
+			entry.DeleteMarkerVersionId = deleted.VersionId
+		}
+		output.Deleted = append(output.Deleted, entry)
+	}
+	return output, nil
+}
+
+// versionID returns a new version ID if the bucket is versioned.
+func (b *stubBucket) versionID() *string {
+	if !b.versioned {
+		return nil
+	}
+	b.nextID++
+	return aws.String(fmt.Sprintf("version-%d", b.nextID))
+}
+
+func (b *stubBucket) CreateMultipartUpload(_ context.Context, _, _, _, _, _, _, _, _, _ string, _ time.Time, metadata map[string]string) (*s3.CreateMultipartUploadOutput, error) {
+	b.nextID++
+	uploadID := fmt.Sprintf("upload-%d", b.nextID)
+	b.uploads[uploadID] = map[int32][]byte{}
+	b.metadata[uploadID] = metadata
+	return &s3.CreateMultipartUploadOutput{UploadId: &uploadID}, nil
+}
+
+func (b *stubBucket) UploadPart(_ context.Context, _, _, uploadID string, partNumber int32, _, _, _ string, body io.Reader, contentLength int64) (*s3.UploadPartOutput, error) {
+	parts, ok := b.uploads[uploadID]
+	if !ok {
+		return nil, fmt.Errorf("https response error StatusCode: %d", http.StatusNotFound)
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) != contentLength {
+		return nil, fmt.Errorf("content length mismatch: %d != %d", len(data), contentLength)
+	}
+	parts[partNumber] = data
+	etag := fmt.Sprintf("%q", fmt.Sprintf("etag-%x", data[:crypto.PartPrefixSize]))
+	return &s3.UploadPartOutput{ETag: &etag}, nil
+}
+
+func (b *stubBucket) ListParts(_ context.Context, _, _, uploadID, _, _, _ string) ([]types.Part, error) {
+	parts, ok := b.uploads[uploadID]
+	if !ok {
+		return nil, fmt.Errorf("https response error StatusCode: %d", http.StatusNotFound)
+	}
+	var listed []types.Part
+	for number, data := range parts {
+		etag := fmt.Sprintf("%q", fmt.Sprintf("etag-%x", data[:crypto.PartPrefixSize]))
+		listed = append(listed, types.Part{PartNumber: aws.Int32(number), ETag: &etag, Size: aws.Int64(int64(len(data)))})
+	}
+	sort.Slice(listed, func(i, j int) bool { return *listed[i].PartNumber < *listed[j].PartNumber })
+	return listed, nil
+}
+
+func (b *stubBucket) CompleteMultipartUpload(_ context.Context, _, key, uploadID string, completed []types.CompletedPart, _, _, _ string) (*s3.CompleteMultipartUploadOutput, error) {
+	parts, ok := b.uploads[uploadID]
+	if !ok {
+		return nil, fmt.Errorf("https response error StatusCode: %d", http.StatusNotFound)
+	}
+	var data []byte
+	for _, part := range completed {
+		data = append(data, parts[*part.PartNumber]...)
+	}
+	b.objects[key] = &stubS3Client{data: data, metadata: b.metadata[uploadID], etag: "etag"}
+	delete(b.uploads, uploadID)
+	etag := "\"etag-2\""
+	return &s3.CompleteMultipartUploadOutput{ETag: &etag, VersionId: b.versionID()}, nil
+}
+
+func (b *stubBucket) AbortMultipartUpload(_ context.Context, _, _, uploadID string) (*s3.AbortMultipartUploadOutput, error) {
+	delete(b.uploads, uploadID)
+	return &s3.AbortMultipartUploadOutput{}, nil
+}
+
+func (b *stubBucket) CopyObject(_ context.Context, _, key, copySource, copySourceIfMatch, _, _, contentType, _, _, _, _, _, _, _ string, metadata map[string]string) (*s3.CopyObjectOutput, error) {
+	_, srcKey, _, err := parseCopySource(copySource)
+	if err != nil {
+		return nil, err
+	}
+	src, ok := b.objects[srcKey]
+	if !ok {
+		return nil, fmt.Errorf("https response error StatusCode: %d", http.StatusNotFound)
+	}
+	if copySourceIfMatch != "" && copySourceIfMatch != src.etag {
+		return nil, fmt.Errorf("https response error StatusCode: %d", http.StatusPreconditionFailed)
+	}
+	b.objects[key] = &stubS3Client{data: src.data, metadata: metadata, contentType: contentType, etag: "etag-copy"}
+	etag := "\"etag-copy\""
+	return &s3.CopyObjectOutput{CopyObjectResult: &types.CopyObjectResult{ETag: &etag, LastModified: aws.Time(time.Now())}, VersionId: b.versionID()}, nil
+}
+
+func (b *stubBucket) ListObjectsV2(_ context.Context, _, prefix, _, _, _ string, _ int32, _ bool) (*s3.ListObjectsV2Output, error) {
+	var keys []string
+	for key := range b.objects {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	output := &s3.ListObjectsV2Output{MaxKeys: aws.Int32(1000), IsTruncated: aws.Bool(false)}
+	for _, key := range keys {
+		obj := b.objects[key]
+		output.Contents = append(output.Contents, types.Object{
+			Key:          aws.String(key),
+			ETag:         aws.String(fmt.Sprintf("%q", obj.etag)),
+			Size:         aws.Int64(int64(len(obj.data))),
+			LastModified: aws.Time(time.Unix(0, 0)),
+		})
+	}
+	return output, nil
+}
@@ -7,8 +7,11 @@ SPDX-License-Identifier: BUSL-1.1
 package router
 
 import (
+	"bytes"
 	"context"
 	"encoding/hex"
+	"errors"
+	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
@@ -18,23 +21,74 @@ import (
 	"strings"
 	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 	"github.com/edgelesssys/constellation/v2/s3proxy/internal/crypto"
+	"github.com/edgelesssys/constellation/v2/s3proxy/internal/keyring"
 )
 
 const (
 	// dekTag is the name of the header that holds the encrypted data encryption key for the attached object. Presence of the key implies the object needs to be decrypted.
 	// Use lowercase only, as AWS automatically lowercases all metadata keys.
 	dekTag = "constellation-dek"
+	// streamTag is the name of the header that holds the stream header of the attached object. Presence of the key implies the object is encrypted in segments.
+	// Objects that only carry a dekTag were encrypted as a single ciphertext by previous versions of s3proxy.
+	streamTag = "constellation-stream"
+	// multipartTag is the name of the header that holds the multipart header of the attached object. Presence of the key implies the object was uploaded in encrypted parts.
+	// The layout of the parts is stored in a separate manifest object.
+	multipartTag = "constellation-multipart"
+	// keyIDTag is the name of the header that holds the ID of the KEK the DEK is encrypted with.
+	// Objects without a key ID were written by previous versions of s3proxy.
+	keyIDTag = "constellation-key-id"
 )
 
+// encryption describes how an object is encrypted.
+type encryption int
+
+const (
+	encryptionNone encryption = iota
+	encryptionLegacy
+	encryptionStream
+	encryptionMultipart
+)
+
+// encryptionOf returns the encryption of an object based on its metadata.
+func encryptionOf(metadata map[string]string) encryption {
+	if _, ok := metadata[dekTag]; !ok {
+		return encryptionNone
+	}
+	if _, ok := metadata[multipartTag]; ok {
+		return encryptionMultipart
+	}
+	if _, ok := metadata[streamTag]; !ok {
+		return encryptionLegacy
+	}
+	return encryptionStream
+}
+
+// parseMetadata decodes the header stored under headerTag and the encrypted DEK of an object encrypted in segments.
+func parseMetadata(metadata map[string]string, headerTag string) (header, encryptedDEK []byte, err error) {
+	header, err = hex.DecodeString(metadata[headerTag])
+	if err != nil {
+		return nil, nil, fmt.Errorf("decoding %s header: %w", headerTag, err)
+	}
+	encryptedDEK, err = hex.DecodeString(metadata[dekTag])
+	if err != nil {
+		return nil, nil, fmt.Errorf("decoding DEK: %w", err)
+	}
+	return header, encryptedDEK, nil
+}
+
 // object bundles data to implement http.Handler methods that use data from incoming requests.
 type object struct {
-	kek                       [32]byte
+	keys                      keyProvider
 	client                    s3Client
 	key                       string
 	bucket                    string
-	data                      []byte
+	body                      *digestReader
+	contentLength             int64
+	byteRange                 string
 	query                     url.Values
 	tags                      string
 	contentType               string
@@ -57,7 +111,12 @@ func (o object) get(w http.ResponseWriter, r *http.Request) {
 		versionID = []string{""}
 	}
 
-	output, err := o.client.GetObject(r.Context(), o.bucket, o.key, versionID[0], o.sseCustomerAlgorithm, o.sseCustomerKey, o.sseCustomerKeyMD5)
+	if o.byteRange != "" {
+		o.getRange(w, r, versionID[0])
+		return
+	}
+
+	output, err := o.client.GetObject(r.Context(), o.bucket, o.key, versionID[0], "", "", o.sseCustomerAlgorithm, o.sseCustomerKey, o.sseCustomerKeyMD5)
 	if err != nil {
 		// log with Info as it might be expected behavior (e.g. object not found).
 		o.log.With(slog.Any("error", err)).Error("GetObject sending request to S3")
@@ -72,81 +131,326 @@ func (o object) get(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	defer output.Body.Close()
 
-	if output.ETag != nil {
-		w.Header().Set("ETag", strings.Trim(*output.ETag, "\""))
+	kek, err := o.kekOf(r.Context(), output.Metadata)
+	if err != nil {
+		o.log.With(slog.Any("error", err)).Error("GetObject getting KEK")
+		writeKeyError(w, err)
+		return
 	}
-	if output.Expiration != nil {
-		w.Header().Set("x-amz-expiration", *output.Expiration)
+
+	setGetObjectHeaders(w, output)
+
+	var plaintext io.Reader
+	var plaintextSize int64
+	switch encryption := encryptionOf(output.Metadata); encryption {
+	case encryptionStream:
+		header, encryptedDEK, err := parseMetadata(output.Metadata, streamTag)
+		if err != nil {
+			o.log.With(slog.Any("error", err)).Error("GetObject parsing metadata")
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		plaintextSize, err = crypto.PlaintextSize(aws.ToInt64(output.ContentLength))
+		if err != nil {
+			o.log.With(slog.Any("error", err)).Error("GetObject parsing object size")
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		plaintext, err = crypto.DecryptStream(output.Body, header, encryptedDEK, kek, 0, plaintextSize-1, plaintextSize)
+		if err != nil {
+			o.log.With(slog.Any("error", err)).Error("GetObject decrypting response")
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	case encryptionMultipart:
+		plaintext, plaintextSize, err = o.decryptMultipart(r.Context(), output, kek)
+		if err != nil {
+			o.log.With(slog.Any("error", err)).Error("GetObject decrypting response")
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	case encryptionLegacy:
+		body, err := decryptLegacy(output, kek)
+		if err != nil {
+			o.log.With(slog.Any("error", err)).Error("GetObject decrypting response")
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		plaintext, plaintextSize = bytes.NewReader(body), int64(len(body))
+	default:
+		plaintext, plaintextSize = output.Body, aws.ToInt64(output.ContentLength)
 	}
-	if output.ChecksumCRC32 != nil {
-		w.Header().Set("x-amz-checksum-crc32", *output.ChecksumCRC32)
+
+	w.Header().Set("Accept-Ranges", "bytes")
+	w.Header().Set("Content-Length", strconv.FormatInt(plaintextSize, 10))
+	w.WriteHeader(http.StatusOK)
+	// Segments are authenticated before they are released, so a modified object
+	// results in a truncated response. Clients detect this through the Content-Length.
+	if _, err := io.Copy(w, plaintext); err != nil {
+		o.log.With(slog.Any("error", err)).Error("GetObject sending response")
 	}
-	if output.ChecksumCRC32C != nil {
-		w.Header().Set("x-amz-checksum-crc32c", *output.ChecksumCRC32C)
+}
+
+// getRange serves the byte range requested by the client.
+// For objects encrypted in segments, only the segments covering the range are fetched and decrypted.
+func (o object) getRange(w http.ResponseWriter, r *http.Request, versionID string) {
+	head, err := o.client.HeadObject(r.Context(), o.bucket, o.key, versionID, o.sseCustomerAlgorithm, o.sseCustomerKey, o.sseCustomerKeyMD5)
+	if err != nil {
+		o.log.With(slog.Any("error", err)).Error("GetObject sending head request to S3")
+
+		code := parseErrorCode(err)
+		if code != 0 {
+			http.Error(w, err.Error(), code)
+			return
+		}
+
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
 	}
-	if output.ChecksumSHA1 != nil {
-		w.Header().Set("x-amz-checksum-sha1", *output.ChecksumSHA1)
+
+	encryption := encryptionOf(head.Metadata)
+	if encryption == encryptionNone {
+		// Unencrypted objects are served by S3 directly.
+		o.getPassthroughRange(w, r, versionID)
+		return
 	}
-	if output.ChecksumSHA256 != nil {
-		w.Header().Set("x-amz-checksum-sha256", *output.ChecksumSHA256)
+
+	kek, err := o.kekOf(r.Context(), head.Metadata)
+	if err != nil {
+		o.log.With(slog.Any("error", err)).Error("GetObject getting KEK")
+		writeKeyError(w, err)
+		return
 	}
-	if output.SSECustomerAlgorithm != nil {
-		w.Header().Set("x-amz-server-side-encryption-customer-algorithm", *output.SSECustomerAlgorithm)
+	if encryption == encryptionLegacy {
+		o.getLegacyRange(w, r, versionID, kek)
+		return
 	}
-	if output.SSECustomerKeyMD5 != nil {
-		w.Header().Set("x-amz-server-side-encryption-customer-key-MD5", *output.SSECustomerKeyMD5)
+	if encryption == encryptionMultipart {
+		o.getMultipartRange(w, r, versionID, head, kek)
+		return
 	}
-	if output.SSEKMSKeyId != nil {
-		w.Header().Set("x-amz-server-side-encryption-aws-kms-key-id", *output.SSEKMSKeyId)
+
+	header, encryptedDEK, err := parseMetadata(head.Metadata, streamTag)
+	if err != nil {
+		o.log.With(slog.Any("error", err)).Error("GetObject parsing metadata")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
 	}
-	if output.ServerSideEncryption != "" {
-		w.Header().Set("x-amz-server-side-encryption-context", string(output.ServerSideEncryption))
+	plaintextSize, err := crypto.PlaintextSize(aws.ToInt64(head.ContentLength))
+	if err != nil {
+		o.log.With(slog.Any("error", err)).Error("GetObject parsing object size")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	start, end, status, ok := o.resolveRange(w, plaintextSize)
+	if !ok {
+		return
 	}
 
-	body, err := io.ReadAll(output.Body)
+	// Pin the version returned by the head request, so the fetched segments belong to the same object.
+	if head.VersionId != nil {
+		versionID = *head.VersionId
+	}
+	ctStart, ctEnd := crypto.CiphertextRange(start, end, plaintextSize)
+	output, err := o.client.GetObject(r.Context(), o.bucket, o.key, versionID, fmt.Sprintf("bytes=%d-%d", ctStart, ctEnd), aws.ToString(head.ETag), o.sseCustomerAlgorithm, o.sseCustomerKey, o.sseCustomerKeyMD5)
 	if err != nil {
-		o.log.With(slog.Any("error", err)).Error("GetObject reading S3 response")
+		o.log.With(slog.Any("error", err)).Error("GetObject sending request to S3")
+
+		code := parseErrorCode(err)
+		if code != 0 {
+			http.Error(w, err.Error(), code)
+			return
+		}
+
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	defer output.Body.Close()
 
-	plaintext := body
-	rawEncryptedDEK, ok := output.Metadata[dekTag]
-	if ok {
-		encryptedDEK, err := hex.DecodeString(rawEncryptedDEK)
-		if err != nil {
-			o.log.Error("GetObject decoding DEK", "error", err)
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+	plaintext, err := crypto.DecryptStream(output.Body, header, encryptedDEK, kek, start, end, plaintextSize)
+	if err != nil {
+		o.log.With(slog.Any("error", err)).Error("GetObject decrypting response")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	setGetObjectHeaders(w, output)
+	writeRangeHeaders(w, start, end, plaintextSize, status)
+	if _, err := io.Copy(w, plaintext); err != nil {
+		o.log.With(slog.Any("error", err)).Error("GetObject sending response")
+	}
+}
+
+// getLegacyRange serves a byte range of an object that was encrypted as a single ciphertext.
+// The whole object has to be fetched and decrypted.
+func (o object) getLegacyRange(w http.ResponseWriter, r *http.Request, versionID string, kek [32]byte) {
+	output, err := o.client.GetObject(r.Context(), o.bucket, o.key, versionID, "", "", o.sseCustomerAlgorithm, o.sseCustomerKey, o.sseCustomerKeyMD5)
+	if err != nil {
+		o.log.With(slog.Any("error", err)).Error("GetObject sending request to S3")
+
+		code := parseErrorCode(err)
+		if code != 0 {
+			http.Error(w, err.Error(), code)
 			return
 		}
 
-		plaintext, err = crypto.Decrypt(body, encryptedDEK, o.kek)
-		if err != nil {
-			o.log.With(slog.Any("error", err)).Error("GetObject decrypting response")
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer output.Body.Close()
+
+	plaintext, err := decryptLegacy(output, kek)
+	if err != nil {
+		o.log.With(slog.Any("error", err)).Error("GetObject decrypting response")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	plaintextSize := int64(len(plaintext))
+	start, end, status, ok := o.resolveRange(w, plaintextSize)
+	if !ok {
+		return
+	}
+
+	setGetObjectHeaders(w, output)
+	writeRangeHeaders(w, start, end, plaintextSize, status)
+	if _, err := w.Write(plaintext[start : end+1]); err != nil {
+		o.log.With(slog.Any("error", err)).Error("GetObject sending response")
+	}
+}
+
+// getPassthroughRange forwards a range request for an unencrypted object to S3.
+func (o object) getPassthroughRange(w http.ResponseWriter, r *http.Request, versionID string) {
+	output, err := o.client.GetObject(r.Context(), o.bucket, o.key, versionID, o.byteRange, "", o.sseCustomerAlgorithm, o.sseCustomerKey, o.sseCustomerKeyMD5)
+	if err != nil {
+		o.log.With(slog.Any("error", err)).Error("GetObject sending request to S3")
+
+		code := parseErrorCode(err)
+		if code != 0 {
+			http.Error(w, err.Error(), code)
 			return
 		}
+
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
 	}
+	defer output.Body.Close()
 
-	w.WriteHeader(http.StatusOK)
-	if _, err := w.Write(plaintext); err != nil {
+	setGetObjectHeaders(w, output)
+	w.Header().Set("Accept-Ranges", "bytes")
+	w.Header().Set("Content-Length", strconv.FormatInt(aws.ToInt64(output.ContentLength), 10))
+	status := http.StatusOK
+	if output.ContentRange != nil {
+		w.Header().Set("Content-Range", *output.ContentRange)
+		status = http.StatusPartialContent
+	}
+	w.WriteHeader(status)
+	if _, err := io.Copy(w, output.Body); err != nil {
 		o.log.With(slog.Any("error", err)).Error("GetObject sending response")
 	}
 }
 
+// decryptLegacy reads and decrypts an object that was encrypted as a single ciphertext.
+func decryptLegacy(output *s3.GetObjectOutput, kek [32]byte) ([]byte, error) {
+	body, err := io.ReadAll(output.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading S3 response: %w", err)
+	}
+	encryptedDEK, err := hex.DecodeString(output.Metadata[dekTag])
+	if err != nil {
+		return nil, fmt.Errorf("decoding DEK: %w", err)
+	}
+	return crypto.Decrypt(body, encryptedDEK, kek)
+}
+
+// head is a http.HandlerFunc that implements the HEAD method for objects.
+// The reported Content-Length is the size of the plaintext.
+func (o object) head(w http.ResponseWriter, r *http.Request) {
+	o.log.With(slog.String("key", o.key), slog.String("host", o.bucket)).Debug("headObject")
+
+	output, err := o.client.HeadObject(r.Context(), o.bucket, o.key, o.query.Get("versionId"), o.sseCustomerAlgorithm, o.sseCustomerKey, o.sseCustomerKeyMD5)
+	if err != nil {
+		o.log.With(slog.Any("error", err)).Error("HeadObject sending request to S3")
+		writeS3Error(w, err)
+		return
+	}
+
+	// Conditional requests can't be forwarded to S3, since we send our own request.
+	if status := evaluateConditions(r.Header.Get("If-Match"), r.Header.Get("If-None-Match"), r.Header.Get("If-Modified-Since"), r.Header.Get("If-Unmodified-Since"), aws.ToString(output.ETag), aws.ToTime(output.LastModified)); status != 0 {
+		w.WriteHeader(status)
+		return
+	}
+
+	size, err := plaintextSize(r.Context(), o.client, o.keys, o.bucket, o.key, output.Metadata, aws.ToInt64(output.ContentLength))
+	if err != nil {
+		o.log.With(slog.Any("error", err)).Error("HeadObject getting plaintext size")
+		writeKeyError(w, err)
+		return
+	}
+
+	setHeadObjectHeaders(w, output)
+	w.Header().Set("Accept-Ranges", "bytes")
+	w.Header().Set("Content-Length", strconv.FormatInt(size, 10))
+	w.WriteHeader(http.StatusOK)
+}
+
+// plaintextSize returns the size of the plaintext of an object with the given metadata and (ciphertext) size.
+// The KEK of the object is only needed for objects uploaded in parts, whose size is stored in the manifest.
+func plaintextSize(ctx context.Context, client s3Client, keys keyProvider, bucket, key string, metadata map[string]string, size int64) (int64, error) {
+	switch encryptionOf(metadata) {
+	case encryptionStream:
+		return crypto.PlaintextSize(size)
+	case encryptionLegacy:
+		return crypto.LegacyPlaintextSize(size)
+	case encryptionMultipart:
+		kek, err := keys.Key(ctx, bucket, metadata[keyIDTag])
+		if err != nil {
+			return 0, err
+		}
+		manifest, _, _, err := getManifest(ctx, client, bucket, key, metadata, kek)
+		if err != nil {
+			return 0, err
+		}
+		if size != manifest.CiphertextSize() {
+			return 0, fmt.Errorf("object size %d doesn't match manifest size %d", size, manifest.CiphertextSize())
+		}
+		return manifest.Size(), nil
+	default:
+		return size, nil
+	}
+}
+
 // put is a http.HandlerFunc that implements the PUT method for objects.
 func (o object) put(w http.ResponseWriter, r *http.Request) {
-	ciphertext, encryptedDEK, err := crypto.Encrypt(o.data, o.kek)
+	keyID, kek, err := o.keys.CurrentKey(r.Context(), o.bucket, o.key)
+	if err != nil {
+		o.log.With(slog.Any("error", err)).Error("PutObject getting KEK")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	ciphertext, header, encryptedDEK, err := crypto.EncryptStream(o.body, kek)
 	if err != nil {
 		o.log.With(slog.Any("error", err)).Error("PutObject")
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 	o.metadata[dekTag] = hex.EncodeToString(encryptedDEK)
+	o.metadata[streamTag] = hex.EncodeToString(header)
+	o.metadata[keyIDTag] = keyID
 
-	output, err := o.client.PutObject(r.Context(), o.bucket, o.key, o.tags, o.contentType, o.objectLockLegalHoldStatus, o.objectLockMode, o.sseCustomerAlgorithm, o.sseCustomerKey, o.sseCustomerKeyMD5, o.objectLockRetainUntilDate, o.metadata, ciphertext)
+	output, err := o.client.PutObject(r.Context(), o.bucket, o.key, o.tags, o.contentType, o.objectLockLegalHoldStatus, o.objectLockMode, o.sseCustomerAlgorithm, o.sseCustomerKey, o.sseCustomerKeyMD5, o.objectLockRetainUntilDate, o.metadata, ciphertext, crypto.CiphertextSize(o.contentLength))
 	if err != nil {
+		// The body is verified while it is streamed to S3.
+		// A digest mismatch aborts the upload, so the object is never stored.
+		if o.body.err != nil {
+			o.log.With(slog.Any("error", o.body.err)).Debug("PutObject validating body")
+			writeDigestError(w, o.body.err, o.log)
+			return
+		}
+
 		o.log.With(slog.Any("error", err)).Error("PutObject sending request to S3")
 
 		// We want to forward error codes from the s3 API to clients whenever possible.
@@ -160,6 +464,13 @@ func (o object) put(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if output.VersionId == nil {
+		// The object replaced a previous object with the same key, whose manifest is no longer needed.
+		if err := pruneManifests(r.Context(), o.client, o.bucket, o.key, ""); err != nil {
+			o.log.With(slog.Any("error", err)).Error("PutObject deleting manifests of replaced object")
+		}
+	}
+
 	w.Header().Set("x-amz-server-side-encryption", string(output.ServerSideEncryption))
 
 	if output.VersionId != nil {
@@ -202,6 +513,194 @@ func (o object) put(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// kekOf returns the KEK the DEK of an object is encrypted with.
+func (o object) kekOf(ctx context.Context, metadata map[string]string) ([32]byte, error) {
+	return o.keys.Key(ctx, o.bucket, metadata[keyIDTag])
+}
+
+// writeKeyError writes the response for a failed KEK lookup.
+func writeKeyError(w http.ResponseWriter, err error) {
+	if errors.Is(err, keyring.ErrShredded) || errors.Is(err, keyring.ErrLegacyKEK) {
+		http.Error(w, fmt.Sprintf("AccessDenied: %s", err.Error()), http.StatusForbidden)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
+
+// resolveRange resolves the Range header sent by the client for an object of the given size.
+// If the range can't be satisfied, an error is written to w and false is returned.
+// Invalid and multiple ranges are ignored as done by S3, in which case the whole object is served with status 200.
+func (o object) resolveRange(w http.ResponseWriter, size int64) (start, end int64, status int, ok bool) {
+	start, end, err := parseRange(o.byteRange, size)
+	if errors.Is(err, errUnsatisfiableRange) {
+		o.log.With(slog.String("range", o.byteRange)).Debug("GetObject range not satisfiable")
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", size))
+		http.Error(w, err.Error(), http.StatusRequestedRangeNotSatisfiable)
+		return 0, 0, 0, false
+	}
+	if err != nil {
+		o.log.With(slog.String("range", o.byteRange), slog.Any("error", err)).Debug("GetObject ignoring range")
+		return 0, size - 1, http.StatusOK, true
+	}
+	return start, end, http.StatusPartialContent, true
+}
+
+// writeRangeHeaders writes the headers and status of a response serving the bytes [start, end] of an object.
+func writeRangeHeaders(w http.ResponseWriter, start, end, size int64, status int) {
+	w.Header().Set("Accept-Ranges", "bytes")
+	w.Header().Set("Content-Length", strconv.FormatInt(end-start+1, 10))
+	if status == http.StatusPartialContent {
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, size))
+	}
+	w.WriteHeader(status)
+}
+
+// setGetObjectHeaders forwards the response headers of a GetObject request to the client.
+func setGetObjectHeaders(w http.ResponseWriter, output *s3.GetObjectOutput) {
+	if output.ETag != nil {
+		w.Header().Set("ETag", strings.Trim(*output.ETag, "\""))
+	}
+	if output.ContentType != nil {
+		w.Header().Set("Content-Type", *output.ContentType)
+	}
+	if output.LastModified != nil {
+		w.Header().Set("Last-Modified", output.LastModified.UTC().Format(http.TimeFormat))
+	}
+	if output.VersionId != nil {
+		w.Header().Set("x-amz-version-id", *output.VersionId)
+	}
+	setUserMetadataHeaders(w, output.Metadata)
+	if output.Expiration != nil {
+		w.Header().Set("x-amz-expiration", *output.Expiration)
+	}
+	if output.ChecksumCRC32 != nil {
+		w.Header().Set("x-amz-checksum-crc32", *output.ChecksumCRC32)
+	}
+	if output.ChecksumCRC32C != nil {
+		w.Header().Set("x-amz-checksum-crc32c", *output.ChecksumCRC32C)
+	}
+	if output.ChecksumSHA1 != nil {
+		w.Header().Set("x-amz-checksum-sha1", *output.ChecksumSHA1)
+	}
+	if output.ChecksumSHA256 != nil {
+		w.Header().Set("x-amz-checksum-sha256", *output.ChecksumSHA256)
+	}
+	if output.SSECustomerAlgorithm != nil {
+		w.Header().Set("x-amz-server-side-encryption-customer-algorithm", *output.SSECustomerAlgorithm)
+	}
+	if output.SSECustomerKeyMD5 != nil {
+		w.Header().Set("x-amz-server-side-encryption-customer-key-MD5", *output.SSECustomerKeyMD5)
+	}
+	if output.SSEKMSKeyId != nil {
+		w.Header().Set("x-amz-server-side-encryption-aws-kms-key-id", *output.SSEKMSKeyId)
+	}
+	if output.ServerSideEncryption != "" {
+		w.Header().Set("x-amz-server-side-encryption-context", string(output.ServerSideEncryption))
+	}
+}
+
+// setHeadObjectHeaders forwards the response headers of a HeadObject request to the client.
+func setHeadObjectHeaders(w http.ResponseWriter, output *s3.HeadObjectOutput) {
+	if output.ETag != nil {
+		w.Header().Set("ETag", strings.Trim(*output.ETag, "\""))
+	}
+	if output.ContentType != nil {
+		w.Header().Set("Content-Type", *output.ContentType)
+	}
+	if output.LastModified != nil {
+		w.Header().Set("Last-Modified", output.LastModified.UTC().Format(http.TimeFormat))
+	}
+	if output.VersionId != nil {
+		w.Header().Set("x-amz-version-id", *output.VersionId)
+	}
+	if output.Expiration != nil {
+		w.Header().Set("x-amz-expiration", *output.Expiration)
+	}
+	if output.StorageClass != "" {
+		w.Header().Set("x-amz-storage-class", string(output.StorageClass))
+	}
+	if output.SSECustomerAlgorithm != nil {
+		w.Header().Set("x-amz-server-side-encryption-customer-algorithm", *output.SSECustomerAlgorithm)
+	}
+	if output.SSECustomerKeyMD5 != nil {
+		w.Header().Set("x-amz-server-side-encryption-customer-key-MD5", *output.SSECustomerKeyMD5)
+	}
+	if output.SSEKMSKeyId != nil {
+		w.Header().Set("x-amz-server-side-encryption-aws-kms-key-id", *output.SSEKMSKeyId)
+	}
+	if output.ServerSideEncryption != "" {
+		w.Header().Set("x-amz-server-side-encryption", string(output.ServerSideEncryption))
+	}
+	setUserMetadataHeaders(w, output.Metadata)
+}
+
+// setUserMetadataHeaders forwards the user-defined metadata of an object to the client.
+// The metadata used by s3proxy itself is not exposed.
+func setUserMetadataHeaders(w http.ResponseWriter, metadata map[string]string) {
+	for key, value := range userMetadata(metadata) {
+		w.Header().Set("x-amz-meta-"+key, value)
+	}
+}
+
+// userMetadata returns the user-defined metadata of an object, without the metadata used by s3proxy itself.
+func userMetadata(metadata map[string]string) map[string]string {
+	result := map[string]string{}
+	for key, value := range metadata {
+		switch key {
+		case dekTag, streamTag, multipartTag, keyIDTag:
+			continue
+		}
+		result[key] = value
+	}
+	return result
+}
+
+// evaluateConditions evaluates the conditional headers of a request against an object, as done by S3.
+// It returns 0 if the request should be served, or the status code to respond with otherwise.
+// If-Match takes precedence over If-Unmodified-Since, and If-None-Match over If-Modified-Since.
+func evaluateConditions(ifMatch, ifNoneMatch, ifModifiedSince, ifUnmodifiedSince, etag string, lastModified time.Time) int {
+	if ifMatch != "" {
+		if !etagMatches(ifMatch, etag) {
+			return http.StatusPreconditionFailed
+		}
+	} else if since, err := http.ParseTime(ifUnmodifiedSince); err == nil && lastModified.After(since) {
+		return http.StatusPreconditionFailed
+	}
+
+	if ifNoneMatch != "" {
+		if etagMatches(ifNoneMatch, etag) {
+			return http.StatusNotModified
+		}
+	} else if since, err := http.ParseTime(ifModifiedSince); err == nil && !lastModified.After(since) {
+		return http.StatusNotModified
+	}
+	return 0
+}
+
+// etagMatches reports whether etag is contained in a comma separated list of ETags, or the list is "*".
+func etagMatches(list, etag string) bool {
+	etag = strings.Trim(etag, "\"")
+	for _, candidate := range strings.Split(list, ",") {
+		candidate = strings.Trim(strings.TrimPrefix(strings.TrimSpace(candidate), "W/"), "\"")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// writeS3Error writes the response for a failed request to the S3 API.
+// We want to forward error codes from the s3 API to clients as much as possible.
+func writeS3Error(w http.ResponseWriter, err error) {
+	code := parseErrorCode(err)
+	if code != 0 {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
+
 func parseErrorCode(err error) int {
 	regex := regexp.MustCompile(`https response error StatusCode: (\d+)`)
 	matches := regex.FindStringSubmatch(err.Error())
@@ -213,7 +712,25 @@ func parseErrorCode(err error) int {
 	return 0
 }
 
+// keyProvider provides the KEKs of buckets and prefixes.
+type keyProvider interface {
+	// CurrentKey returns the ID of the KEK and the KEK to encrypt a new object with.
+	CurrentKey(ctx context.Context, bucket, objectKey string) (keyID string, kek [32]byte, err error)
+	// Key returns the KEK with the given ID.
+	Key(ctx context.Context, bucket, keyID string) ([32]byte, error)
+}
+
 type s3Client interface {
-	GetObject(ctx context.Context, bucket, key, versionID, sseCustomerAlgorithm, sseCustomerKey, sseCustomerKeyMD5 string) (*s3.GetObjectOutput, error)
-	PutObject(ctx context.Context, bucket, key, tags, contentType, objectLockLegalHoldStatus, objectLockMode, sseCustomerAlgorithm, sseCustomerKey, sseCustomerKeyMD5 string, objectLockRetainUntilDate time.Time, metadata map[string]string, body []byte) (*s3.PutObjectOutput, error)
+	GetObject(ctx context.Context, bucket, key, versionID, byteRange, ifMatch, sseCustomerAlgorithm, sseCustomerKey, sseCustomerKeyMD5 string) (*s3.GetObjectOutput, error)
+	HeadObject(ctx context.Context, bucket, key, versionID, sseCustomerAlgorithm, sseCustomerKey, sseCustomerKeyMD5 string) (*s3.HeadObjectOutput, error)
+	PutObject(ctx context.Context, bucket, key, tags, contentType, objectLockLegalHoldStatus, objectLockMode, sseCustomerAlgorithm, sseCustomerKey, sseCustomerKeyMD5 string, objectLockRetainUntilDate time.Time, metadata map[string]string, body io.Reader, contentLength int64) (*s3.PutObjectOutput, error)
+	DeleteObject(ctx context.Context, bucket, key, versionID string, bypassGovernanceRetention bool) (*s3.DeleteObjectOutput, error)
+	DeleteObjects(ctx context.Context, bucket string, objects []types.ObjectIdentifier, bypassGovernanceRetention bool) (*s3.DeleteObjectsOutput, error)
+	CreateMultipartUpload(ctx context.Context, bucket, key, tags, contentType, objectLockLegalHoldStatus, objectLockMode, sseCustomerAlgorithm, sseCustomerKey, sseCustomerKeyMD5 string, objectLockRetainUntilDate time.Time, metadata map[string]string) (*s3.CreateMultipartUploadOutput, error)
+	UploadPart(ctx context.Context, bucket, key, uploadID string, partNumber int32, sseCustomerAlgorithm, sseCustomerKey, sseCustomerKeyMD5 string, body io.Reader, contentLength int64) (*s3.UploadPartOutput, error)
+	ListParts(ctx context.Context, bucket, key, uploadID, sseCustomerAlgorithm, sseCustomerKey, sseCustomerKeyMD5 string) ([]types.Part, error)
+	CompleteMultipartUpload(ctx context.Context, bucket, key, uploadID string, parts []types.CompletedPart, sseCustomerAlgorithm, sseCustomerKey, sseCustomerKeyMD5 string) (*s3.CompleteMultipartUploadOutput, error)
+	AbortMultipartUpload(ctx context.Context, bucket, key, uploadID string) (*s3.AbortMultipartUploadOutput, error)
+	CopyObject(ctx context.Context, bucket, key, copySource, copySourceIfMatch, tags, taggingDirective, contentType, storageClass, sseCustomerAlgorithm, sseCustomerKey, sseCustomerKeyMD5, copySourceSSECustomerAlgorithm, copySourceSSECustomerKey, copySourceSSECustomerKeyMD5 string, metadata map[string]string) (*s3.CopyObjectOutput, error)
+	ListObjectsV2(ctx context.Context, bucket, prefix, delimiter, continuationToken, startAfter string, maxKeys int32, fetchOwner bool) (*s3.ListObjectsV2Output, error)
 }
@@ -0,0 +1,511 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package router
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/edgelesssys/constellation/v2/s3proxy/internal/crypto"
+	"github.com/edgelesssys/constellation/v2/s3proxy/internal/keyring"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestObjectPutGet(t *testing.T) {
+	sizes := map[string]int{
+		"empty":             0,
+		"small":             100,
+		"multiple segments": 2*crypto.SegmentSize + 100,
+	}
+
+	for name, size := range sizes {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			client := &stubS3Client{}
+			kek := [32]byte{1, 2, 3}
+			plaintext := make([]byte, size)
+			_, err := rand.Read(plaintext)
+			require.NoError(err)
+
+			body, err := newDigestReader(bytes.NewReader(plaintext), "", "")
+			require.NoError(err)
+			obj := newTestObject(client, kek)
+			obj.body = body
+			obj.contentLength = int64(size)
+			obj.metadata = map[string]string{}
+			resp := httptest.NewRecorder()
+			obj.put(resp, httptest.NewRequest(http.MethodPut, "/bucket/key", nil))
+			require.Equal(http.StatusOK, resp.Code)
+
+			assert.Contains(client.metadata, dekTag)
+			assert.Contains(client.metadata, streamTag)
+			assert.Equal("s3proxy/bucket/0/", client.metadata[keyIDTag])
+			assert.Len(client.data, int(crypto.CiphertextSize(int64(size))))
+			if size > 0 {
+				assert.NotContains(string(client.data), string(plaintext))
+			}
+
+			resp = httptest.NewRecorder()
+			newTestObject(client, kek).get(resp, httptest.NewRequest(http.MethodGet, "/bucket/key", nil))
+			require.Equal(http.StatusOK, resp.Code)
+			assert.Equal(string(plaintext), resp.Body.String())
+			assert.Equal(strconv.Itoa(size), resp.Header().Get("Content-Length"))
+		})
+	}
+}
+
+func TestObjectPutDeletesManifests(t *testing.T) {
+	testCases := map[string]struct {
+		versioned    bool
+		wantManifest bool
+	}{
+		"unversioned bucket": {},
+		"versioned bucket": {
+			versioned:    true,
+			wantManifest: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			require := require.New(t)
+			kek := [32]byte{1, 2, 3}
+
+			client := newStubBucket()
+			client.versioned = tc.versioned
+			manifest := uploadTestObject(t, client, kek)
+
+			// Overwrite the object uploaded in parts with a single request.
+			plaintext := []byte("hello")
+			body, err := newDigestReader(bytes.NewReader(plaintext), "", "")
+			require.NoError(err)
+			obj := newTestObject(client, kek)
+			obj.body = body
+			obj.contentLength = int64(len(plaintext))
+			obj.metadata = map[string]string{}
+			resp := httptest.NewRecorder()
+			obj.put(resp, httptest.NewRequest(http.MethodPut, "/bucket/key", nil))
+			require.Equal(http.StatusOK, resp.Code)
+
+			_, ok := client.objects[manifest]
+			assert.Equal(t, tc.wantManifest, ok)
+		})
+	}
+}
+
+func TestObjectPutDigestMismatch(t *testing.T) {
+	client := &stubS3Client{}
+	// digest of "hello, world"
+	body, err := newDigestReader(strings.NewReader("hello, world!"), "09ca7e4eaa6e8ae9c7d261167129184883644d07dfba7cbfbc4c8a2e08360d5b", "")
+	require.NoError(t, err)
+
+	obj := newTestObject(client, [32]byte{})
+	obj.body = body
+	obj.contentLength = int64(len("hello, world!"))
+	obj.metadata = map[string]string{}
+
+	resp := httptest.NewRecorder()
+	obj.put(resp, httptest.NewRequest(http.MethodPut, "/bucket/key", nil))
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+	assert.Contains(t, resp.Body.String(), "XAmzContentSHA256Mismatch")
+	assert.Nil(t, client.data)
+}
+
+func TestObjectGetRange(t *testing.T) {
+	kek := [32]byte{1, 2, 3}
+	plaintext := make([]byte, 3*crypto.SegmentSize+100)
+	_, err := rand.Read(plaintext)
+	require.NoError(t, err)
+
+	ciphertext, header, encryptedDEK, err := crypto.EncryptStream(bytes.NewReader(plaintext), kek)
+	require.NoError(t, err)
+	streamData, err := io.ReadAll(ciphertext)
+	require.NoError(t, err)
+	legacyData, legacyDEK, err := crypto.Encrypt(plaintext, kek)
+	require.NoError(t, err)
+
+	objects := map[string]*stubS3Client{
+		"stream": {
+			data: streamData,
+			etag: "etag",
+			metadata: map[string]string{
+				dekTag:    hex.EncodeToString(encryptedDEK),
+				streamTag: hex.EncodeToString(header),
+			},
+		},
+		"legacy": {
+			data:     legacyData,
+			metadata: map[string]string{dekTag: hex.EncodeToString(legacyDEK)},
+		},
+		"unencrypted": {
+			data:     plaintext,
+			metadata: map[string]string{},
+		},
+	}
+	size := int64(len(plaintext))
+
+	testCases := map[string]struct {
+		byteRange        string
+		wantStatus       int
+		wantStart        int64
+		wantEnd          int64
+		wantContentRange string
+	}{
+		"within one segment": {
+			byteRange:        "bytes=10-20",
+			wantStatus:       http.StatusPartialContent,
+			wantStart:        10,
+			wantEnd:          20,
+			wantContentRange: fmt.Sprintf("bytes 10-20/%d", size),
+		},
+		"spanning segments": {
+			byteRange:        fmt.Sprintf("bytes=%d-%d", crypto.SegmentSize-5, 2*crypto.SegmentSize+5),
+			wantStatus:       http.StatusPartialContent,
+			wantStart:        crypto.SegmentSize - 5,
+			wantEnd:          2*crypto.SegmentSize + 5,
+			wantContentRange: fmt.Sprintf("bytes %d-%d/%d", crypto.SegmentSize-5, 2*crypto.SegmentSize+5, size),
+		},
+		"suffix": {
+			byteRange:        "bytes=-50",
+			wantStatus:       http.StatusPartialContent,
+			wantStart:        size - 50,
+			wantEnd:          size - 1,
+			wantContentRange: fmt.Sprintf("bytes %d-%d/%d", size-50, size-1, size),
+		},
+		"open end": {
+			byteRange:        fmt.Sprintf("bytes=%d-", 3*crypto.SegmentSize),
+			wantStatus:       http.StatusPartialContent,
+			wantStart:        3 * crypto.SegmentSize,
+			wantEnd:          size - 1,
+			wantContentRange: fmt.Sprintf("bytes %d-%d/%d", 3*crypto.SegmentSize, size-1, size),
+		},
+		"unsatisfiable": {
+			byteRange:  fmt.Sprintf("bytes=%d-", size),
+			wantStatus: http.StatusRequestedRangeNotSatisfiable,
+		},
+	}
+
+	for objName, client := range objects {
+		for name, tc := range testCases {
+			t.Run(objName+" "+name, func(t *testing.T) {
+				assert := assert.New(t)
+
+				obj := newTestObject(client, kek)
+				obj.byteRange = tc.byteRange
+				resp := httptest.NewRecorder()
+				obj.get(resp, httptest.NewRequest(http.MethodGet, "/bucket/key", nil))
+
+				assert.Equal(tc.wantStatus, resp.Code)
+				if tc.wantStatus != http.StatusPartialContent {
+					return
+				}
+				assert.Equal(plaintext[tc.wantStart:tc.wantEnd+1], resp.Body.Bytes())
+				assert.Equal(tc.wantContentRange, resp.Header().Get("Content-Range"))
+			})
+		}
+	}
+
+	t.Run("stream fetches covering segments only", func(t *testing.T) {
+		client := objects["stream"]
+		obj := newTestObject(client, kek)
+		obj.byteRange = fmt.Sprintf("bytes=%d-%d", crypto.SegmentSize+1, crypto.SegmentSize+2)
+		obj.get(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/bucket/key", nil))
+
+		ctStart, ctEnd := crypto.CiphertextRange(crypto.SegmentSize+1, crypto.SegmentSize+2, size)
+		assert.Equal(t, fmt.Sprintf("bytes=%d-%d", ctStart, ctEnd), client.lastRange)
+		assert.Equal(t, client.etag, client.lastIfMatch)
+	})
+}
+
+func TestObjectGetLegacy(t *testing.T) {
+	kek := [32]byte{1, 2, 3}
+	plaintext := []byte("hello, world")
+	ciphertext, encryptedDEK, err := crypto.Encrypt(plaintext, kek)
+	require.NoError(t, err)
+
+	client := &stubS3Client{
+		data:     ciphertext,
+		metadata: map[string]string{dekTag: hex.EncodeToString(encryptedDEK)},
+	}
+	resp := httptest.NewRecorder()
+	newTestObject(client, kek).get(resp, httptest.NewRequest(http.MethodGet, "/bucket/key", nil))
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, plaintext, resp.Body.Bytes())
+}
+
+func TestObjectGetShredded(t *testing.T) {
+	client := &stubS3Client{}
+	body, err := newDigestReader(strings.NewReader("hello, world"), "", "")
+	require.NoError(t, err)
+	obj := newTestObject(client, [32]byte{1, 2, 3})
+	obj.body = body
+	obj.contentLength = int64(len("hello, world"))
+	obj.metadata = map[string]string{}
+	resp := httptest.NewRecorder()
+	obj.put(resp, httptest.NewRequest(http.MethodPut, "/bucket/key", nil))
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	for name, byteRange := range map[string]string{"whole object": "", "range": "bytes=0-4"} {
+		t.Run(name, func(t *testing.T) {
+			obj := newTestObject(client, [32]byte{1, 2, 3})
+			obj.keys = stubKeys{err: keyring.ErrShredded}
+			obj.byteRange = byteRange
+			resp := httptest.NewRecorder()
+			obj.get(resp, httptest.NewRequest(http.MethodGet, "/bucket/key", nil))
+			assert.Equal(t, http.StatusForbidden, resp.Code)
+			assert.NotContains(t, resp.Body.String(), "hello")
+		})
+	}
+}
+
+func TestObjectHead(t *testing.T) {
+	kek := [32]byte{1, 2, 3}
+	plaintext := []byte("hello, world")
+	legacyData, legacyDEK, err := crypto.Encrypt(plaintext, kek)
+	require.NoError(t, err)
+
+	streamClient := &stubS3Client{}
+	body, err := newDigestReader(bytes.NewReader(plaintext), "", "")
+	require.NoError(t, err)
+	obj := newTestObject(streamClient, kek)
+	obj.body = body
+	obj.contentLength = int64(len(plaintext))
+	obj.metadata = map[string]string{"color": "blue"}
+	obj.contentType = "text/plain"
+	resp := httptest.NewRecorder()
+	obj.put(resp, httptest.NewRequest(http.MethodPut, "/bucket/key", nil))
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	testCases := map[string]struct {
+		client     *stubS3Client
+		header     http.Header
+		wantStatus int
+	}{
+		"stream": {
+			client:     streamClient,
+			wantStatus: http.StatusOK,
+		},
+		"legacy": {
+			client: &stubS3Client{
+				data:     legacyData,
+				metadata: map[string]string{dekTag: hex.EncodeToString(legacyDEK), "color": "blue"},
+			},
+			wantStatus: http.StatusOK,
+		},
+		"unencrypted": {
+			client:     &stubS3Client{data: plaintext, metadata: map[string]string{"color": "blue"}},
+			wantStatus: http.StatusOK,
+		},
+		"if-none-match": {
+			client:     streamClient,
+			header:     http.Header{"If-None-Match": []string{`"etag"`}},
+			wantStatus: http.StatusNotModified,
+		},
+		"if-match": {
+			client:     streamClient,
+			header:     http.Header{"If-Match": []string{`"other"`}},
+			wantStatus: http.StatusPreconditionFailed,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			req := httptest.NewRequest(http.MethodHead, "/bucket/key", nil)
+			for key, values := range tc.header {
+				req.Header[key] = values
+			}
+			resp := httptest.NewRecorder()
+			newTestObject(tc.client, kek).head(resp, req)
+
+			assert.Equal(tc.wantStatus, resp.Code)
+			if tc.wantStatus != http.StatusOK {
+				return
+			}
+			assert.Equal(strconv.Itoa(len(plaintext)), resp.Header().Get("Content-Length"))
+			assert.Equal("blue", resp.Header().Get("x-amz-meta-color"))
+			for _, tag := range []string{dekTag, streamTag, keyIDTag} {
+				assert.Empty(resp.Header().Get("x-amz-meta-" + tag))
+			}
+		})
+	}
+}
+
+func TestEvaluateConditions(t *testing.T) {
+	lastModified := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	before := lastModified.Add(-time.Hour).Format(http.TimeFormat)
+	after := lastModified.Add(time.Hour).Format(http.TimeFormat)
+
+	testCases := map[string]struct {
+		ifMatch, ifNoneMatch, ifModifiedSince, ifUnmodifiedSince string
+		want                                                     int
+	}{
+		"no conditions":                          {},
+		"if-match":                               {ifMatch: `"etag"`},
+		"if-match list":                          {ifMatch: `"other", "etag"`},
+		"if-match wildcard":                      {ifMatch: "*"},
+		"if-match mismatch":                      {ifMatch: `"other"`, want: http.StatusPreconditionFailed},
+		"if-none-match":                          {ifNoneMatch: `"etag"`, want: http.StatusNotModified},
+		"if-none-match mismatch":                 {ifNoneMatch: `"other"`},
+		"if-modified-since":                      {ifModifiedSince: before},
+		"if-modified-since not modified":         {ifModifiedSince: after, want: http.StatusNotModified},
+		"if-unmodified-since":                    {ifUnmodifiedSince: after},
+		"if-unmodified-since modified":           {ifUnmodifiedSince: before, want: http.StatusPreconditionFailed},
+		"if-match overrides if-unmodified-since": {ifMatch: `"etag"`, ifUnmodifiedSince: before},
+		"if-none-match overrides if-modified":    {ifNoneMatch: `"other"`, ifModifiedSince: after},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.want, evaluateConditions(tc.ifMatch, tc.ifNoneMatch, tc.ifModifiedSince, tc.ifUnmodifiedSince, `"etag"`, lastModified))
+		})
+	}
+}
+
+func newTestObject(client s3Client, kek [32]byte) object {
+	return object{
+		client: client,
+		keys:   stubKeys{kek: kek},
+		bucket: "bucket",
+		key:    "key",
+		query:  url.Values{},
+		log:    slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+}
+
+// stubKeys uses the same KEK for all objects.
+type stubKeys struct {
+	kek [32]byte
+	err error
+}
+
+func (s stubKeys) CurrentKey(context.Context, string, string) (string, [32]byte, error) {
+	return "s3proxy/bucket/0/", s.kek, s.err
+}
+
+func (s stubKeys) Key(context.Context, string, string) ([32]byte, error) {
+	return s.kek, s.err
+}
+
+// stubS3Client stores a single object in memory.
+type stubS3Client struct {
+	data        []byte
+	metadata    map[string]string
+	contentType string
+	etag        string
+	lastRange   string
+	lastIfMatch string
+}
+
+func (s *stubS3Client) GetObject(_ context.Context, _, _, _, byteRange, ifMatch, _, _, _ string) (*s3.GetObjectOutput, error) {
+	s.lastRange, s.lastIfMatch = byteRange, ifMatch
+	if ifMatch != "" && ifMatch != s.etag {
+		return nil, fmt.Errorf("https response error StatusCode: %d", http.StatusPreconditionFailed)
+	}
+
+	data := s.data
+	output := &s3.GetObjectOutput{Metadata: s.metadata, ETag: &s.etag}
+	if byteRange != "" {
+		var start, end int64
+		if _, err := fmt.Sscanf(byteRange, "bytes=%d-%d", &start, &end); err != nil {
+			// open ranges are only used for unencrypted objects in tests.
+			if _, err := fmt.Sscanf(byteRange, "bytes=%d-", &start); err == nil {
+				end = int64(len(s.data)) - 1
+			} else if _, err := fmt.Sscanf(byteRange, "bytes=-%d", &start); err == nil {
+				start, end = int64(len(s.data))-start, int64(len(s.data))-1
+			} else {
+				return nil, err
+			}
+		}
+		if start >= int64(len(s.data)) {
+			return nil, fmt.Errorf("https response error StatusCode: %d", http.StatusRequestedRangeNotSatisfiable)
+		}
+		end = min(end, int64(len(s.data))-1)
+		data = s.data[start : end+1]
+		contentRange := fmt.Sprintf("bytes %d-%d/%d", start, end, len(s.data))
+		output.ContentRange = &contentRange
+	}
+	contentLength := int64(len(data))
+	output.ContentLength = &contentLength
+	output.Body = io.NopCloser(bytes.NewReader(data))
+	return output, nil
+}
+
+func (s *stubS3Client) HeadObject(_ context.Context, _, _, _, _, _, _ string) (*s3.HeadObjectOutput, error) {
+	contentLength := int64(len(s.data))
+	return &s3.HeadObjectOutput{Metadata: s.metadata, ETag: &s.etag, ContentType: &s.contentType, ContentLength: &contentLength, LastModified: aws.Time(time.Unix(0, 0))}, nil
+}
+
+func (s *stubS3Client) PutObject(_ context.Context, _, _, _, contentType, _, _, _, _, _ string, _ time.Time, metadata map[string]string, body io.Reader, contentLength int64) (*s3.PutObjectOutput, error) {
+	// Like an HTTP transport, fail the request if the body can't be read completely.
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) != contentLength {
+		return nil, fmt.Errorf("content length mismatch: %d != %d", len(data), contentLength)
+	}
+	s.data, s.metadata, s.contentType, s.etag = data, metadata, contentType, "etag"
+	return &s3.PutObjectOutput{ETag: aws.String(`"etag"`)}, nil
+}
+
+func (s *stubS3Client) DeleteObject(context.Context, string, string, string, bool) (*s3.DeleteObjectOutput, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (s *stubS3Client) DeleteObjects(context.Context, string, []types.ObjectIdentifier, bool) (*s3.DeleteObjectsOutput, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (s *stubS3Client) CreateMultipartUpload(context.Context, string, string, string, string, string, string, string, string, string, time.Time, map[string]string) (*s3.CreateMultipartUploadOutput, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (s *stubS3Client) UploadPart(context.Context, string, string, string, int32, string, string, string, io.Reader, int64) (*s3.UploadPartOutput, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (s *stubS3Client) ListParts(context.Context, string, string, string, string, string, string) ([]types.Part, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (s *stubS3Client) CompleteMultipartUpload(context.Context, string, string, string, []types.CompletedPart, string, string, string) (*s3.CompleteMultipartUploadOutput, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (s *stubS3Client) AbortMultipartUpload(context.Context, string, string, string) (*s3.AbortMultipartUploadOutput, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (s *stubS3Client) CopyObject(context.Context, string, string, string, string, string, string, string, string, string, string, string, string, string, string, map[string]string) (*s3.CopyObjectOutput, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (s *stubS3Client) ListObjectsV2(context.Context, string, string, string, string, string, int32, bool) (*s3.ListObjectsV2Output, error) {
+	// A single object has no manifests to list.
+	return &s3.ListObjectsV2Output{IsTruncated: aws.Bool(false)}, nil
+}
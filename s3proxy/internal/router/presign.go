@@ -0,0 +1,170 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package router
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+const (
+	// presignAlgorithm is the only signing algorithm supported for presigned requests.
+	presignAlgorithm = "AWS4-HMAC-SHA256"
+	// maxPresignExpiry is the maximum validity of a presigned request accepted by S3.
+	maxPresignExpiry = 7 * 24 * time.Hour
+	// maxClockSkew is the tolerated difference between the signing time of a presigned request and the time of the proxy.
+	maxClockSkew = 15 * time.Minute
+)
+
+// errPresignExpired is returned by verifyPresigned if a presigned request is expired or not yet valid.
+var errPresignExpired = errors.New("request has expired")
+
+// isPresigned reports whether a request carries a signature version 4 query string, i.e. was created from a presigned URL.
+func isPresigned(query url.Values) bool {
+	_, signature := query["X-Amz-Signature"]
+	return signature
+}
+
+// verifyPresigned verifies the signature version 4 query string of a presigned request.
+// Since s3proxy sends its own requests to S3, the signature is verified with the credentials of s3proxy.
+// Presigned URLs therefore have to be created with the same credentials that are configured for s3proxy.
+func verifyPresigned(req *http.Request, creds aws.Credentials, now time.Time) error {
+	query := req.URL.Query()
+	if algorithm := query.Get("X-Amz-Algorithm"); algorithm != presignAlgorithm {
+		return fmt.Errorf("unsupported signing algorithm %q", algorithm)
+	}
+
+	accessKeyID, scope, ok := strings.Cut(query.Get("X-Amz-Credential"), "/")
+	if !ok {
+		return fmt.Errorf("invalid credential %q", query.Get("X-Amz-Credential"))
+	}
+	if accessKeyID != creds.AccessKeyID {
+		return fmt.Errorf("request was signed with unknown access key %q", accessKeyID)
+	}
+	if token, ok := query["X-Amz-Security-Token"]; ok && creds.SessionToken != "" && token[0] != creds.SessionToken {
+		return errors.New("request was signed with an unknown session token")
+	}
+
+	amzDate := query.Get("X-Amz-Date")
+	signingTime, err := time.Parse("20060102T150405Z", amzDate)
+	if err != nil {
+		return fmt.Errorf("invalid X-Amz-Date %q: %w", amzDate, err)
+	}
+	scopeParts := strings.Split(scope, "/")
+	if len(scopeParts) != 4 || scopeParts[0] != amzDate[:8] || scopeParts[2] != "s3" || scopeParts[3] != "aws4_request" {
+		return fmt.Errorf("invalid credential scope %q", scope)
+	}
+	expires, err := strconv.ParseInt(query.Get("X-Amz-Expires"), 10, 64)
+	if err != nil || expires < 1 || time.Duration(expires)*time.Second > maxPresignExpiry {
+		return fmt.Errorf("invalid X-Amz-Expires %q", query.Get("X-Amz-Expires"))
+	}
+	if now.Before(signingTime.Add(-maxClockSkew)) || now.After(signingTime.Add(time.Duration(expires)*time.Second)) {
+		return errPresignExpired
+	}
+
+	signedHeaders := strings.Split(query.Get("X-Amz-SignedHeaders"), ";")
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		uriEncode(req.URL.Path, false),
+		canonicalQuery(query),
+		canonicalHeaders(req, signedHeaders),
+		strings.Join(signedHeaders, ";"),
+		payloadHash(query),
+	}, "\n")
+	canonicalRequestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{presignAlgorithm, amzDate, scope, hex.EncodeToString(canonicalRequestHash[:])}, "\n")
+
+	key := []byte("AWS4" + creds.SecretAccessKey)
+	for _, part := range scopeParts {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	if !hmac.Equal([]byte(signature), []byte(query.Get("X-Amz-Signature"))) {
+		return errors.New("the request signature does not match")
+	}
+	return nil
+}
+
+// canonicalQuery returns the sorted and encoded query of a presigned request, without its signature.
+func canonicalQuery(query url.Values) string {
+	var params []string
+	for key, values := range query {
+		if key == "X-Amz-Signature" {
+			continue
+		}
+		for _, value := range values {
+			params = append(params, uriEncode(key, true)+"="+uriEncode(value, true))
+		}
+	}
+	sort.Strings(params)
+	return strings.Join(params, "&")
+}
+
+// canonicalHeaders returns the canonical form of the signed headers of a request, each followed by a newline.
+func canonicalHeaders(req *http.Request, signedHeaders []string) string {
+	var b strings.Builder
+	for _, name := range signedHeaders {
+		rawValues := req.Header.Values(name)
+		if name == "host" {
+			rawValues = []string{req.Host}
+		}
+		values := make([]string, len(rawValues))
+		for i, value := range rawValues {
+			values[i] = strings.Join(strings.Fields(value), " ")
+		}
+		b.WriteString(name + ":" + strings.Join(values, ",") + "\n")
+	}
+	return b.String()
+}
+
+// payloadHash returns the payload hash of a presigned request.
+// Presigned requests usually don't sign their payload.
+func payloadHash(query url.Values) string {
+	if hash := query.Get("X-Amz-Content-Sha256"); hash != "" {
+		return hash
+	}
+	return "UNSIGNED-PAYLOAD"
+}
+
+// uriEncode encodes a string as defined for signature version 4: all bytes except unreserved characters are percent-encoded.
+// Slashes are only encoded if encodeSlash is set, which is the case for query parameters.
+func uriEncode(s string, encodeSlash bool) string {
+	const hexDigits = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9', c == '-', c == '.', c == '_', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			b.WriteByte('%')
+			b.WriteByte(hexDigits[c>>4])
+			b.WriteByte(hexDigits[c&0x0f])
+		}
+	}
+	return b.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,162 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package router
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyPresigned(t *testing.T) {
+	creds := aws.Credentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "secret"}
+	otherCreds := aws.Credentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "other"}
+
+	testCases := map[string]struct {
+		presign func(ctx context.Context, client *s3.PresignClient) (*v4.PresignedHTTPRequest, error)
+		creds   aws.Credentials
+		modify  func(req *http.Request)
+		now     time.Time
+		wantErr bool
+	}{
+		"GetObject": {
+			presign: presignGet("dir/key"),
+			creds:   creds,
+		},
+		"GetObject with special characters": {
+			presign: presignGet("dir/a b+c!*'(),=&$@;:?.txt"),
+			creds:   creds,
+		},
+		"GetObject with version": {
+			presign: func(ctx context.Context, client *s3.PresignClient) (*v4.PresignedHTTPRequest, error) {
+				return client.PresignGetObject(ctx, &s3.GetObjectInput{Bucket: aws.String("bucket"), Key: aws.String("key"), VersionId: aws.String("v1+/=")})
+			},
+			creds: creds,
+		},
+		"PutObject": {
+			presign: func(ctx context.Context, client *s3.PresignClient) (*v4.PresignedHTTPRequest, error) {
+				return client.PresignPutObject(ctx, &s3.PutObjectInput{Bucket: aws.String("bucket"), Key: aws.String("key"), ContentType: aws.String("text/plain")})
+			},
+			creds: creds,
+		},
+		"HeadObject": {
+			presign: func(ctx context.Context, client *s3.PresignClient) (*v4.PresignedHTTPRequest, error) {
+				return client.PresignHeadObject(ctx, &s3.HeadObjectInput{Bucket: aws.String("bucket"), Key: aws.String("key")})
+			},
+			creds: creds,
+		},
+		"wrong secret": {
+			presign: presignGet("key"),
+			creds:   otherCreds,
+			wantErr: true,
+		},
+		"unknown access key": {
+			presign: presignGet("key"),
+			creds:   aws.Credentials{AccessKeyID: "other", SecretAccessKey: "secret"},
+			wantErr: true,
+		},
+		"modified path": {
+			presign: presignGet("key"),
+			creds:   creds,
+			modify:  func(req *http.Request) { req.URL.Path = "/bucket/other" },
+			wantErr: true,
+		},
+		"modified method": {
+			presign: presignGet("key"),
+			creds:   creds,
+			modify:  func(req *http.Request) { req.Method = http.MethodPut },
+			wantErr: true,
+		},
+		"modified host": {
+			presign: presignGet("key"),
+			creds:   creds,
+			modify:  func(req *http.Request) { req.Host = "other.example" },
+			wantErr: true,
+		},
+		"modified query": {
+			presign: presignGet("key"),
+			creds:   creds,
+			modify: func(req *http.Request) {
+				query := req.URL.Query()
+				query.Set("versionId", "v2")
+				req.URL.RawQuery = query.Encode()
+			},
+			wantErr: true,
+		},
+		"expired": {
+			presign: presignGet("key"),
+			creds:   creds,
+			now:     time.Now().Add(2 * time.Hour),
+			wantErr: true,
+		},
+		"not yet valid": {
+			presign: presignGet("key"),
+			creds:   creds,
+			now:     time.Now().Add(-time.Hour),
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			client := s3.NewPresignClient(s3.New(s3.Options{
+				Region:       "eu-west-1",
+				BaseEndpoint: aws.String("http://s3proxy.example"),
+				UsePathStyle: true,
+				Credentials: aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+					return creds, nil
+				}),
+			}), s3.WithPresignExpires(time.Hour))
+			presigned, err := tc.presign(t.Context(), client)
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(presigned.Method, presigned.URL, nil)
+			for key, values := range presigned.SignedHeader {
+				if key == "Host" {
+					continue
+				}
+				req.Header[key] = values
+			}
+			require.True(t, isPresigned(req.URL.Query()))
+			if tc.modify != nil {
+				tc.modify(req)
+			}
+
+			now := tc.now
+			if now.IsZero() {
+				now = time.Now()
+			}
+			err = verifyPresigned(req, tc.creds, now)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestIsPresigned(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/bucket/key?versionId=1", nil)
+	assert.False(t, isPresigned(req.URL.Query()))
+	req = httptest.NewRequest(http.MethodGet, "/bucket/key?X-Amz-Signature=abc", nil)
+	assert.True(t, isPresigned(req.URL.Query()))
+}
+
+func presignGet(key string) func(ctx context.Context, client *s3.PresignClient) (*v4.PresignedHTTPRequest, error) {
+	return func(ctx context.Context, client *s3.PresignClient) (*v4.PresignedHTTPRequest, error) {
+		return client.PresignGetObject(ctx, &s3.GetObjectInput{Bucket: aws.String("bucket"), Key: aws.String(key)})
+	}
+}
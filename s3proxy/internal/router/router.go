@@ -15,126 +15,206 @@ If the router intercepts a PutObject request it will encrypt the body before for
 The stored object will have a tag that holds an encrypted data encryption key (DEK).
 That DEK is used to encrypt the object's body.
 The DEK is generated randomly for each PutObject request.
-The DEK is encrypted with a key encryption key (KEK) fetched from Constellation's keyservice.
+The DEK is encrypted with a key encryption key (KEK) of the object's bucket or prefix, which is derived by Constellation's keyservice.
+The ID of the KEK is stored in another tag of the object.
+
+Bodies are encrypted in segments and streamed to and from the S3 API, so objects are never held in memory as a whole.
+GetObject requests with a Range header only fetch and decrypt the segments covering the requested range.
+
+CopyObject requests for encrypted objects re-wrap the DEK with the KEK of the destination and let S3 copy the ciphertext.
+HeadObject and ListObjectsV2 responses are rewritten to report the sizes of the plaintexts.
+Presigned requests that are intercepted are verified with the credentials of s3proxy, since s3proxy sends its own requests to S3.
+
+Multipart uploads are encrypted with a DEK per upload, which is stored in the bucket under the reserved ".constellation-s3proxy/" prefix
+until the upload is completed or aborted. On completion, a sealed manifest of the parts is stored under the same prefix.
+The manifest is deleted when its object is deleted or overwritten, unless the bucket is versioned and S3 keeps the object as a previous version.
+Clients can't access objects under the reserved prefix through s3proxy.
 */
 package router
 
 import (
 	"bytes"
-	"context"
 	"crypto/md5"
 	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/xml"
+	"errors"
 	"fmt"
+	"hash"
+	"io"
 	"log/slog"
 	"net/http"
 	"net/url"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
-	"github.com/edgelesssys/constellation/v2/s3proxy/internal/kms"
 	"github.com/edgelesssys/constellation/v2/s3proxy/internal/s3"
 )
 
-const (
-	// Use a 32*8 = 256 bit key for AES-256.
-	kekSizeBytes = 32
-	kekID        = "s3proxy-kek"
-)
+// reservedPrefixError is the error message for requests to objects under the reserved prefix of s3proxy.
+const reservedPrefixError = "AccessDenied: objects under the reserved prefix " + stateKeyPrefix + " are managed by s3proxy"
 
 var (
 	keyPattern          = regexp.MustCompile("/(.+)")
 	bucketAndKeyPattern = regexp.MustCompile("/([^/?]+)/(.+)")
+	bucketPattern       = regexp.MustCompile("^/([^/?]+)/?$")
 )
 
 // Router implements the interception logic for the s3proxy.
 type Router struct {
 	region string
-	kek    [32]byte
-	// forwardMultipartReqs controls whether we forward the following requests: CreateMultipartUpload, UploadPart, CompleteMultipartUpload, AbortMultipartUpload.
-	// s3proxy does not implement those yet.
-	// Setting forwardMultipartReqs to true will forward those requests to the S3 API, otherwise we block them (secure defaults).
-	forwardMultipartReqs bool
-	log                  *slog.Logger
+	keys   keyProvider
+	log    *slog.Logger
 }
 
 // New creates a new Router.
-func New(region, endpoint string, forwardMultipartReqs bool, log *slog.Logger) (Router, error) {
-	kms := kms.New(log, endpoint)
-
-	// Get the key encryption key that encrypts all DEKs.
-	kek, err := kms.GetDataKey(context.Background(), kekID, kekSizeBytes)
-	if err != nil {
-		return Router{}, fmt.Errorf("getting KEK: %w", err)
-	}
-
-	kekArray, err := byteSliceToByteArray(kek)
-	if err != nil {
-		return Router{}, fmt.Errorf("converting KEK to byte array: %w", err)
-	}
-
-	return Router{region: region, kek: kekArray, forwardMultipartReqs: forwardMultipartReqs, log: log}, nil
+func New(region string, keys keyProvider, log *slog.Logger) Router {
+	return Router{region: region, keys: keys, log: log}
 }
 
 // Serve implements the routing logic for the s3 proxy.
-// It intercepts GetObject and PutObject requests, encrypting/decrypting their bodies if necessary.
+// It intercepts GetObject, PutObject, CopyObject and multipart upload requests, encrypting/decrypting their bodies if necessary.
+// HeadObject and ListObjectsV2 requests are intercepted to report the sizes of the plaintexts.
+// DeleteObject and DeleteObjects requests are intercepted to delete the manifests of multipart objects.
+// Requests for objects under the reserved prefix of s3proxy are rejected.
 // All other requests are forwarded to the S3 API.
 // Ideally we could separate routing logic, request handling and s3 interactions.
 // Currently routing logic and request handling are integrated.
 func (r Router) Serve(w http.ResponseWriter, req *http.Request) {
-	client, err := s3.NewClient(r.region)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
 	var key string
 	var bucket string
 	var matchingPath bool
+	var matchingBucketPath bool
 	if containsBucket(req.Host) {
 		// BUCKET.s3.REGION.amazonaws.com
 		parts := strings.Split(req.Host, ".")
 		bucket = parts[0]
 
 		matchingPath = match(req.URL.Path, keyPattern, &key)
-
+		matchingBucketPath = req.URL.Path == "/" || req.URL.Path == ""
 	} else {
 		matchingPath = match(req.URL.Path, bucketAndKeyPattern, &bucket, &key)
+		if !matchingPath {
+			matchingBucketPath = match(req.URL.Path, bucketPattern, &bucket)
+		}
+	}
+
+	// Objects under the reserved prefix hold the state of s3proxy, e.g., the manifests of multipart objects.
+	// Clients must neither read nor modify them.
+	if matchingPath && strings.HasPrefix(key, stateKeyPrefix) {
+		r.log.With(slog.String("key", key), slog.String("method", req.Method)).Debug("Rejecting request for reserved prefix")
+		http.Error(w, reservedPrefixError, http.StatusForbidden)
+		return
+	}
+
+	client, err := s3.NewClient(r.region)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
 	}
 
 	var h http.Handler
+	intercept := true
 
 	switch {
 	// intercept GetObject.
 	case matchingPath && req.Method == "GET" && !isUnwantedGetEndpoint(req.URL.Query()):
-		h = handleGetObject(client, key, bucket, r.log)
+		h = handleGetObject(client, r.keys, key, bucket, r.log)
+	// intercept HeadObject.
+	case matchingPath && req.Method == "HEAD" && !isUnwantedHeadEndpoint(req.URL.Query()):
+		h = handleHeadObject(client, r.keys, key, bucket, r.log)
 	// intercept PutObject.
 	case matchingPath && req.Method == "PUT" && !isUnwantedPutEndpoint(req.Header, req.URL.Query()):
-		h = handlePutObject(client, key, bucket, r.log)
-	case !r.forwardMultipartReqs && matchingPath && isUploadPart(req.Method, req.URL.Query()):
-		h = handleUploadPart(r.log)
-	case !r.forwardMultipartReqs && matchingPath && isCreateMultipartUpload(req.Method, req.URL.Query()):
-		h = handleCreateMultipartUpload(r.log)
-	case !r.forwardMultipartReqs && matchingPath && isCompleteMultipartUpload(req.Method, req.URL.Query()):
-		h = handleCompleteMultipartUpload(r.log)
-	case !r.forwardMultipartReqs && matchingPath && isAbortMultipartUpload(req.Method, req.URL.Query()):
-		h = handleAbortMultipartUpload(r.log)
+		h = handlePutObject(client, r.keys, key, bucket, r.log)
+	case matchingPath && isCopyObject(req.Method, req.Header, req.URL.Query()):
+		h = handleCopyObject(client, r.keys, key, bucket, r.log)
+	case matchingBucketPath && isListObjectsV2(req.Method, req.URL.Query()):
+		h = handleListObjectsV2(client, r.keys, bucket, r.log)
+	case matchingPath && isUploadPart(req.Method, req.URL.Query()) && req.Header.Get("x-amz-copy-source") != "":
+		h = handleUploadPartCopy(r.log)
+	case matchingPath && isUploadPart(req.Method, req.URL.Query()):
+		h = handleUploadPart(client, r.keys, key, bucket, r.log)
+	case matchingPath && isCreateMultipartUpload(req.Method, req.URL.Query()):
+		h = handleCreateMultipartUpload(client, r.keys, key, bucket, r.log)
+	case matchingPath && isCompleteMultipartUpload(req.Method, req.URL.Query()):
+		h = handleCompleteMultipartUpload(client, r.keys, key, bucket, r.log)
+	case matchingPath && isAbortMultipartUpload(req.Method, req.URL.Query()):
+		h = handleAbortMultipartUpload(client, r.keys, key, bucket, r.log)
+	case matchingPath && isDeleteObject(req.Method, req.URL.Query()):
+		h = handleDeleteObject(client, key, bucket, r.log)
+	case matchingBucketPath && isDeleteObjects(req.Method, req.URL.Query()):
+		h = handleDeleteObjects(client, bucket, r.log)
 	// Forward all other requests.
 	default:
 		h = handleForwards(r.log)
+		intercept = false
+	}
+
+	// Forwarded presigned requests are verified by S3.
+	// Intercepted requests are sent to S3 with the credentials of s3proxy, so their signature has to be verified here.
+	if intercept && isPresigned(req.URL.Query()) {
+		if err := verifyPresignedWith(req, client); err != nil {
+			r.log.With(slog.Any("error", err)).Debug("Rejecting presigned request")
+			http.Error(w, fmt.Sprintf("AccessDenied: %s", err.Error()), http.StatusForbidden)
+			return
+		}
 	}
 
 	h.ServeHTTP(w, req)
 }
 
+// verifyPresignedWith verifies the signature of a presigned request with the credentials of the given client.
+func verifyPresignedWith(req *http.Request, client *s3.Client) error {
+	creds, err := client.Credentials(req.Context())
+	if err != nil {
+		return fmt.Errorf("retrieving credentials: %w", err)
+	}
+	return verifyPresigned(req, creds, time.Now())
+}
+
+func isCopyObject(method string, header http.Header, query url.Values) bool {
+	_, partNumber := query["partNumber"]
+	_, uploadID := query["uploadId"]
+
+	return method == "PUT" && header.Get("x-amz-copy-source") != "" && !partNumber && !uploadID
+}
+
+func isListObjectsV2(method string, query url.Values) bool {
+	return method == "GET" && query.Get("list-type") == "2"
+}
+
+// isUnwantedHeadEndpoint returns true if the request is a HeadObject request for a single part of an object.
+// The sizes of parts are not tracked for encrypted objects, so these requests are forwarded.
+func isUnwantedHeadEndpoint(query url.Values) bool {
+	_, partNumber := query["partNumber"]
+
+	return partNumber
+}
+
 func isAbortMultipartUpload(method string, query url.Values) bool {
 	_, uploadID := query["uploadId"]
 
 	return method == "DELETE" && uploadID
 }
 
+// isDeleteObject returns true if the request is a DeleteObject request.
+// Requests for subresources, like DeleteObjectTagging, are forwarded.
+func isDeleteObject(method string, query url.Values) bool {
+	_, uploadID := query["uploadId"]
+	_, tagging := query["tagging"]
+
+	return method == "DELETE" && !uploadID && !tagging
+}
+
+func isDeleteObjects(method string, query url.Values) bool {
+	_, del := query["delete"]
+
+	return method == "POST" && del
+}
+
 func isCompleteMultipartUpload(method string, query url.Values) bool {
 	_, multipart := query["uploadId"]
 
@@ -174,16 +254,6 @@ func NewContentSHA256MismatchError(clientComputedContentSHA256, s3ComputedConten
 	}
 }
 
-// byteSliceToByteArray casts a byte slice to a byte array of length 32.
-// It does a length check to prevent the cast from panic'ing.
-func byteSliceToByteArray(input []byte) ([32]byte, error) {
-	if len(input) != 32 {
-		return [32]byte{}, fmt.Errorf("input length mismatch, got: %d", len(input))
-	}
-
-	return ([32]byte)(input), nil
-}
-
 // containsBucket is a helper to recognizes cases where the bucket name is sent as part of the host.
 // In other cases the bucket name is sent as part of the path.
 func containsBucket(host string) bool {
@@ -226,11 +296,6 @@ func isUnwantedPutEndpoint(header http.Header, query url.Values) bool {
 	return partNumber || uploadID || tagging || legalHold || objectLock || retention || publicAccessBlock || acl
 }
 
-func sha256sum(data []byte) string {
-	digest := sha256.Sum256(data)
-	return fmt.Sprintf("%x", digest)
-}
-
 // getMetadataHeaders parses user-defined metadata headers from a
 // http.Header object. Users can define custom headers by taking
 // HEADERNAME and prefixing it with "x-amz-meta-".
@@ -271,30 +336,165 @@ func repackage(r *http.Request) http.Request {
 	return *req
 }
 
-// validateContentMD5 checks if the content-md5 header matches the body.
-func validateContentMD5(contentMD5 string, body []byte) error {
-	if contentMD5 == "" {
-		return nil
-	}
+// digestReader wraps the body of a PutObject request and validates the digests sent by the client.
+// Since the body is streamed, the digests can only be validated once the body was read completely.
+// In case of a mismatch, the final read returns an error instead of io.EOF, which aborts the upload.
+type digestReader struct {
+	r            io.Reader
+	sha256       hash.Hash
+	md5          hash.Hash
+	clientSHA256 string
+	clientMD5    []byte
+	err          error
+}
 
-	expected, err := base64.StdEncoding.DecodeString(contentMD5)
-	if err != nil {
-		return fmt.Errorf("decoding base64: %w", err)
+// newDigestReader creates a new digestReader.
+// contentSHA256 and contentMD5 are the values of the x-amz-content-sha256 and content-md5 headers, which may be empty.
+func newDigestReader(body io.Reader, contentSHA256, contentMD5 string) (*digestReader, error) {
+	var clientMD5 []byte
+	if contentMD5 != "" {
+		var err error
+		clientMD5, err = base64.StdEncoding.DecodeString(contentMD5)
+		if err != nil {
+			return nil, fmt.Errorf("decoding base64: %w", err)
+		}
+		if len(clientMD5) != 16 {
+			return nil, fmt.Errorf("content-md5 must be 16 bytes long, got %d bytes", len(clientMD5))
+		}
 	}
 
-	if len(expected) != 16 {
-		return fmt.Errorf("content-md5 must be 16 bytes long, got %d bytes", len(expected))
+	// There may be a client that wants to test that incorrect content digests result in API errors.
+	// For encrypting the body we have to recalculate the content digest.
+	// If the client intentionally sends a mismatching content digest, we would take the client request, rewrap it,
+	// calculate the correct digest for the new body and NOT get an error.
+	// Thus we have to check incoming requets for matching content digests.
+	// UNSIGNED-PAYLOAD can be used to disabled payload signing. In that case we don't check the content digest.
+	if contentSHA256 == "UNSIGNED-PAYLOAD" {
+		contentSHA256 = ""
 	}
 
-	actual := md5.Sum(body)
+	return &digestReader{
+		r:            body,
+		sha256:       sha256.New(),
+		md5:          md5.New(),
+		clientSHA256: contentSHA256,
+		clientMD5:    clientMD5,
+	}, nil
+}
 
-	if !bytes.Equal(actual[:], expected) {
-		return fmt.Errorf("content-md5 mismatch, header is %x, body is %x", expected, actual)
+// Read implements io.Reader.
+func (d *digestReader) Read(p []byte) (int, error) {
+	if d.err != nil {
+		return 0, d.err
 	}
 
+	n, err := d.r.Read(p)
+	d.sha256.Write(p[:n])
+	d.md5.Write(p[:n])
+	if errors.Is(err, io.EOF) {
+		if verifyErr := d.verify(); verifyErr != nil {
+			d.err = verifyErr
+			return n, verifyErr
+		}
+	}
+	return n, err
+}
+
+func (d *digestReader) verify() error {
+	serverSHA256 := hex.EncodeToString(d.sha256.Sum(nil))
+	if d.clientSHA256 != "" && d.clientSHA256 != serverSHA256 {
+		return &contentSHA256MismatchError{client: d.clientSHA256, server: serverSHA256}
+	}
+	serverMD5 := d.md5.Sum(nil)
+	if d.clientMD5 != nil && !bytes.Equal(d.clientMD5, serverMD5) {
+		return &contentMD5MismatchError{client: d.clientMD5, server: serverMD5}
+	}
 	return nil
 }
 
+type contentSHA256MismatchError struct {
+	client string
+	server string
+}
+
+func (e *contentSHA256MismatchError) Error() string {
+	return fmt.Sprintf("x-amz-content-sha256 mismatch, header is %s, body is %s", e.client, e.server)
+}
+
+type contentMD5MismatchError struct {
+	client []byte
+	server []byte
+}
+
+func (e *contentMD5MismatchError) Error() string {
+	return fmt.Sprintf("content-md5 mismatch, header is %x, body is %x", e.client, e.server)
+}
+
+// writeDigestError writes the response for a failed digest validation.
+func writeDigestError(w http.ResponseWriter, err error, log *slog.Logger) {
+	var mismatchErr *contentSHA256MismatchError
+	if !errors.As(err, &mismatchErr) {
+		http.Error(w, fmt.Sprintf("validating content md5: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	// The S3 API responds with an XML formatted error message.
+	marshalled, err := xml.Marshal(NewContentSHA256MismatchError(mismatchErr.client, mismatchErr.server))
+	if err != nil {
+		log.With(slog.Any("error", err)).Error("PutObject")
+		http.Error(w, fmt.Sprintf("marshalling error: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+	http.Error(w, string(marshalled), http.StatusBadRequest)
+}
+
+// errUnsatisfiableRange is returned by parseRange if the range doesn't overlap the object.
+var errUnsatisfiableRange = errors.New("the requested range is not satisfiable")
+
+// parseRange parses a Range header with a single byte range as used by GetObject.
+// It returns the first and last byte of the range, limited to an object of the given size.
+func parseRange(header string, size int64) (start, end int64, err error) {
+	spec, ok := strings.CutPrefix(header, "bytes=")
+	if !ok {
+		return 0, 0, fmt.Errorf("unsupported range unit in %q", header)
+	}
+	if strings.Contains(spec, ",") {
+		return 0, 0, fmt.Errorf("multiple ranges are not supported: %q", header)
+	}
+	first, last, ok := strings.Cut(strings.TrimSpace(spec), "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid range %q", header)
+	}
+
+	// Suffix range: the last N bytes of the object.
+	if first == "" {
+		n, err := strconv.ParseInt(last, 10, 64)
+		if err != nil || n < 0 {
+			return 0, 0, fmt.Errorf("invalid range %q", header)
+		}
+		if n == 0 || size == 0 {
+			return 0, 0, errUnsatisfiableRange
+		}
+		return max(size-n, 0), size - 1, nil
+	}
+
+	start, err = strconv.ParseInt(first, 10, 64)
+	if err != nil || start < 0 {
+		return 0, 0, fmt.Errorf("invalid range %q", header)
+	}
+	end = size - 1
+	if last != "" {
+		end, err = strconv.ParseInt(last, 10, 64)
+		if err != nil || end < start {
+			return 0, 0, fmt.Errorf("invalid range %q", header)
+		}
+	}
+	if start >= size {
+		return 0, 0, errUnsatisfiableRange
+	}
+	return start, min(end, size-1), nil
+}
+
 // match reports whether path matches pattern, and if it matches,
 // assigns any capture groups to the *string or *int vars.
 func match(path string, pattern *regexp.Regexp, vars ...*string) bool {
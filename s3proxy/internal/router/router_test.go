@@ -6,82 +6,132 @@ SPDX-License-Identifier: BUSL-1.1
 package router
 
 import (
+	"bytes"
+	"io"
 	"testing"
+	"testing/iotest"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
-func TestValidateContentMD5(t *testing.T) {
+func TestDigestReader(t *testing.T) {
 	tests := map[string]struct {
+		contentSHA256  string
 		contentMD5     string
 		body           []byte
+		wantCreateErr  bool
+		wantSHA256Err  bool
+		wantMD5Err     bool
 		expectedErrMsg string
 	}{
-		"empty content-md5": {
-			contentMD5: "",
-			body:       []byte("hello, world"),
+		"no digests": {
+			body: []byte("hello, world"),
 		},
-		// https://datatracker.ietf.org/doc/html/rfc1864#section-2
 		"valid content-md5": {
-			contentMD5: "Q2hlY2sgSW50ZWdyaXR5IQ==",
-			body:       []byte("Check Integrity!"),
+			contentMD5: "5NfxtO0uQtFYmPSyewGdpA==",
+			body:       []byte("hello, world"),
 		},
 		"invalid content-md5": {
-			contentMD5:     "invalid base64",
-			body:           []byte("hello, world"),
-			expectedErrMsg: "decoding base64",
+			contentMD5:    "invalid base64",
+			body:          []byte("hello, world"),
+			wantCreateErr: true,
+		},
+		"content-md5 too short": {
+			contentMD5:    "Q2hlY2s=",
+			body:          []byte("hello, world"),
+			wantCreateErr: true,
+		},
+		"mismatching content-md5": {
+			contentMD5: "5NfxtO0uQtFYmPSyewGdpA==",
+			body:       []byte("hello, world!"),
+			wantMD5Err: true,
+		},
+		"valid content-sha256": {
+			contentSHA256: "09ca7e4eaa6e8ae9c7d261167129184883644d07dfba7cbfbc4c8a2e08360d5b",
+			body:          []byte("hello, world"),
+		},
+		"mismatching content-sha256": {
+			contentSHA256: "09ca7e4eaa6e8ae9c7d261167129184883644d07dfba7cbfbc4c8a2e08360d5b",
+			body:          []byte("hello, world!"),
+			wantSHA256Err: true,
+		},
+		"unsigned payload": {
+			contentSHA256: "UNSIGNED-PAYLOAD",
+			body:          []byte("hello, world"),
 		},
 	}
 
-	// Iterate over the test cases
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
-			// Call the validateContentMD5 function
-			err := validateContentMD5(tc.contentMD5, tc.body)
+			assert := assert.New(t)
+			require := require.New(t)
 
-			// Check the result against the expected value
-			if tc.expectedErrMsg != "" {
-				assert.ErrorContains(t, err, tc.expectedErrMsg)
+			reader, err := newDigestReader(iotest.HalfReader(bytes.NewReader(tc.body)), tc.contentSHA256, tc.contentMD5)
+			if tc.wantCreateErr {
+				assert.Error(err)
+				return
 			}
+			require.NoError(err)
+
+			body, err := io.ReadAll(reader)
+			var sha256Err *contentSHA256MismatchError
+			var md5Err *contentMD5MismatchError
+			switch {
+			case tc.wantSHA256Err:
+				assert.ErrorAs(err, &sha256Err)
+			case tc.wantMD5Err:
+				assert.ErrorAs(err, &md5Err)
+			default:
+				require.NoError(err)
+				assert.Equal(tc.body, body)
+			}
+			assert.Equal(err, reader.err)
 		})
 	}
 }
 
-func TestByteSliceToByteArray(t *testing.T) {
+func TestParseRange(t *testing.T) {
 	tests := map[string]struct {
-		input   []byte
-		output  [32]byte
-		wantErr bool
+		header          string
+		size            int64
+		wantStart       int64
+		wantEnd         int64
+		wantErr         bool
+		wantUnsatisfied bool
 	}{
-		"empty input": {
-			input:  []byte{},
-			output: [32]byte{},
-		},
-		"successful input": {
-			input:  []byte("AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"),
-			output: [32]byte{0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41},
-		},
-		"input too short": {
-			input:   []byte("AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"),
-			output:  [32]byte{0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41},
-			wantErr: true,
-		},
-		"input too long": {
-			input:   []byte("AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"),
-			output:  [32]byte{0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41, 0x41},
-			wantErr: true,
-		},
+		"closed range":            {header: "bytes=10-20", size: 100, wantStart: 10, wantEnd: 20},
+		"open range":              {header: "bytes=10-", size: 100, wantStart: 10, wantEnd: 99},
+		"suffix range":            {header: "bytes=-10", size: 100, wantStart: 90, wantEnd: 99},
+		"suffix larger than size": {header: "bytes=-200", size: 100, wantStart: 0, wantEnd: 99},
+		"end beyond size":         {header: "bytes=90-200", size: 100, wantStart: 90, wantEnd: 99},
+		"single byte":             {header: "bytes=0-0", size: 1, wantStart: 0, wantEnd: 0},
+		"start beyond size":       {header: "bytes=100-", size: 100, wantUnsatisfied: true},
+		"empty suffix":            {header: "bytes=-0", size: 100, wantUnsatisfied: true},
+		"empty object":            {header: "bytes=0-", size: 0, wantUnsatisfied: true},
+		"end before start":        {header: "bytes=20-10", size: 100, wantErr: true},
+		"multiple ranges":         {header: "bytes=0-1,5-6", size: 100, wantErr: true},
+		"unknown unit":            {header: "items=0-1", size: 100, wantErr: true},
+		"not a number":            {header: "bytes=a-b", size: 100, wantErr: true},
+		"missing dash":            {header: "bytes=10", size: 100, wantErr: true},
 	}
 
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
-			result, err := byteSliceToByteArray(tc.input)
-			if tc.wantErr {
-				assert.Error(t, err)
-				return
-			}
+			assert := assert.New(t)
 
-			assert.Equal(t, tc.output, result)
+			start, end, err := parseRange(tc.header, tc.size)
+			switch {
+			case tc.wantUnsatisfied:
+				assert.ErrorIs(err, errUnsatisfiableRange)
+			case tc.wantErr:
+				assert.Error(err)
+				assert.NotErrorIs(err, errUnsatisfiableRange)
+			default:
+				assert.NoError(err)
+				assert.Equal(tc.wantStart, start)
+				assert.Equal(tc.wantEnd, end)
+			}
 		})
 	}
 }
@@ -11,13 +11,13 @@ It only exists to enable stubbing of the AWS S3 client in tests.
 package s3
 
 import (
-	"bytes"
 	"context"
-	"crypto/md5"
-	"encoding/base64"
+	"errors"
 	"fmt"
+	"io"
 	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/aws/aws-sdk-go-v2/service/s3/types"
@@ -48,7 +48,9 @@ func NewClient(region string) (*Client, error) {
 
 // GetObject returns the object with the given key from the given bucket.
 // If a versionID is given, the specific version of the object is returned.
-func (c Client) GetObject(ctx context.Context, bucket, key, versionID, sseCustomerAlgorithm, sseCustomerKey, sseCustomerKeyMD5 string) (*s3.GetObjectOutput, error) {
+// If a byteRange is given, only the given range of the object is returned.
+// If ifMatch is given, the object is only returned if its ETag matches.
+func (c Client) GetObject(ctx context.Context, bucket, key, versionID, byteRange, ifMatch, sseCustomerAlgorithm, sseCustomerKey, sseCustomerKeyMD5 string) (*s3.GetObjectOutput, error) {
 	getObjectInput := &s3.GetObjectInput{
 		Bucket: &bucket,
 		Key:    &key,
@@ -56,6 +58,12 @@ func (c Client) GetObject(ctx context.Context, bucket, key, versionID, sseCustom
 	if versionID != "" {
 		getObjectInput.VersionId = &versionID
 	}
+	if byteRange != "" {
+		getObjectInput.Range = &byteRange
+	}
+	if ifMatch != "" {
+		getObjectInput.IfMatch = &ifMatch
+	}
 	if sseCustomerAlgorithm != "" {
 		getObjectInput.SSECustomerAlgorithm = &sseCustomerAlgorithm
 	}
@@ -69,9 +77,33 @@ func (c Client) GetObject(ctx context.Context, bucket, key, versionID, sseCustom
 	return c.s3client.GetObject(ctx, getObjectInput)
 }
 
+// HeadObject returns the metadata of the object with the given key from the given bucket.
+// If a versionID is given, the metadata of the specific version of the object is returned.
+func (c Client) HeadObject(ctx context.Context, bucket, key, versionID, sseCustomerAlgorithm, sseCustomerKey, sseCustomerKeyMD5 string) (*s3.HeadObjectOutput, error) {
+	headObjectInput := &s3.HeadObjectInput{
+		Bucket: &bucket,
+		Key:    &key,
+	}
+	if versionID != "" {
+		headObjectInput.VersionId = &versionID
+	}
+	if sseCustomerAlgorithm != "" {
+		headObjectInput.SSECustomerAlgorithm = &sseCustomerAlgorithm
+	}
+	if sseCustomerKey != "" {
+		headObjectInput.SSECustomerKey = &sseCustomerKey
+	}
+	if sseCustomerKeyMD5 != "" {
+		headObjectInput.SSECustomerKeyMD5 = &sseCustomerKeyMD5
+	}
+
+	return c.s3client.HeadObject(ctx, headObjectInput)
+}
+
 // PutObject creates a new object in the given bucket with the given key and body.
+// The body is streamed to S3 and has to yield exactly contentLength bytes.
 // Various optional parameters can be set.
-func (c Client) PutObject(ctx context.Context, bucket, key, tags, contentType, objectLockLegalHoldStatus, objectLockMode, sseCustomerAlgorithm, sseCustomerKey, sseCustomerKeyMD5 string, objectLockRetainUntilDate time.Time, metadata map[string]string, body []byte) (*s3.PutObjectOutput, error) {
+func (c Client) PutObject(ctx context.Context, bucket, key, tags, contentType, objectLockLegalHoldStatus, objectLockMode, sseCustomerAlgorithm, sseCustomerKey, sseCustomerKeyMD5 string, objectLockRetainUntilDate time.Time, metadata map[string]string, body io.Reader, contentLength int64) (*s3.PutObjectOutput, error) {
 	// The AWS Go SDK has two versions. V1 does not set the Content-Type header.
 	// V2 always sets the Content-Type header. We use V2.
 	// The s3 API sets an object's content-type to binary/octet-stream if
@@ -83,16 +115,16 @@ func (c Client) PutObject(ctx context.Context, bucket, key, tags, contentType, o
 		contentType = "binary/octet-stream"
 	}
 
-	contentMD5 := md5.Sum(body)
-	encodedContentMD5 := base64.StdEncoding.EncodeToString(contentMD5[:])
-
+	// The body is not seekable, so its checksum can't be computed upfront.
+	// Instead, the SDK sends a CRC32 checksum as trailer of the request.
 	putObjectInput := &s3.PutObjectInput{
 		Bucket:                    &bucket,
 		Key:                       &key,
-		Body:                      bytes.NewReader(body),
+		Body:                      body,
+		ContentLength:             &contentLength,
+		ChecksumAlgorithm:         types.ChecksumAlgorithmCrc32,
 		Tagging:                   &tags,
 		Metadata:                  metadata,
-		ContentMD5:                &encodedContentMD5,
 		ContentType:               &contentType,
 		ObjectLockLegalHoldStatus: types.ObjectLockLegalHoldStatus(objectLockLegalHoldStatus),
 	}
@@ -114,3 +146,237 @@ func (c Client) PutObject(ctx context.Context, bucket, key, tags, contentType, o
 
 	return c.s3client.PutObject(ctx, putObjectInput)
 }
+
+// CreateMultipartUpload starts a multipart upload for the given key in the given bucket.
+// Various optional parameters can be set.
+func (c Client) CreateMultipartUpload(ctx context.Context, bucket, key, tags, contentType, objectLockLegalHoldStatus, objectLockMode, sseCustomerAlgorithm, sseCustomerKey, sseCustomerKeyMD5 string, objectLockRetainUntilDate time.Time, metadata map[string]string) (*s3.CreateMultipartUploadOutput, error) {
+	if contentType == "" {
+		contentType = "binary/octet-stream"
+	}
+
+	// Parts are uploaded with a CRC32 checksum, see UploadPart.
+	createInput := &s3.CreateMultipartUploadInput{
+		Bucket:                    &bucket,
+		Key:                       &key,
+		Tagging:                   &tags,
+		Metadata:                  metadata,
+		ContentType:               &contentType,
+		ChecksumAlgorithm:         types.ChecksumAlgorithmCrc32,
+		ObjectLockLegalHoldStatus: types.ObjectLockLegalHoldStatus(objectLockLegalHoldStatus),
+	}
+	if sseCustomerAlgorithm != "" {
+		createInput.SSECustomerAlgorithm = &sseCustomerAlgorithm
+	}
+	if sseCustomerKey != "" {
+		createInput.SSECustomerKey = &sseCustomerKey
+	}
+	if sseCustomerKeyMD5 != "" {
+		createInput.SSECustomerKeyMD5 = &sseCustomerKeyMD5
+	}
+
+	// It is not allowed to only set one of these two properties.
+	if objectLockMode != "" && !objectLockRetainUntilDate.IsZero() {
+		createInput.ObjectLockMode = types.ObjectLockMode(objectLockMode)
+		createInput.ObjectLockRetainUntilDate = &objectLockRetainUntilDate
+	}
+
+	return c.s3client.CreateMultipartUpload(ctx, createInput)
+}
+
+// UploadPart uploads a part of the multipart upload with the given ID.
+// The body is streamed to S3 and has to yield exactly contentLength bytes.
+func (c Client) UploadPart(ctx context.Context, bucket, key, uploadID string, partNumber int32, sseCustomerAlgorithm, sseCustomerKey, sseCustomerKeyMD5 string, body io.Reader, contentLength int64) (*s3.UploadPartOutput, error) {
+	// The body is not seekable, so its checksum can't be computed upfront.
+	// Instead, the SDK sends a CRC32 checksum as trailer of the request.
+	uploadPartInput := &s3.UploadPartInput{
+		Bucket:            &bucket,
+		Key:               &key,
+		UploadId:          &uploadID,
+		PartNumber:        &partNumber,
+		Body:              body,
+		ContentLength:     &contentLength,
+		ChecksumAlgorithm: types.ChecksumAlgorithmCrc32,
+	}
+	if sseCustomerAlgorithm != "" {
+		uploadPartInput.SSECustomerAlgorithm = &sseCustomerAlgorithm
+	}
+	if sseCustomerKey != "" {
+		uploadPartInput.SSECustomerKey = &sseCustomerKey
+	}
+	if sseCustomerKeyMD5 != "" {
+		uploadPartInput.SSECustomerKeyMD5 = &sseCustomerKeyMD5
+	}
+
+	return c.s3client.UploadPart(ctx, uploadPartInput)
+}
+
+// ListParts returns all parts that were uploaded for the multipart upload with the given ID.
+func (c Client) ListParts(ctx context.Context, bucket, key, uploadID, sseCustomerAlgorithm, sseCustomerKey, sseCustomerKeyMD5 string) ([]types.Part, error) {
+	listPartsInput := &s3.ListPartsInput{
+		Bucket:   &bucket,
+		Key:      &key,
+		UploadId: &uploadID,
+	}
+	if sseCustomerAlgorithm != "" {
+		listPartsInput.SSECustomerAlgorithm = &sseCustomerAlgorithm
+	}
+	if sseCustomerKey != "" {
+		listPartsInput.SSECustomerKey = &sseCustomerKey
+	}
+	if sseCustomerKeyMD5 != "" {
+		listPartsInput.SSECustomerKeyMD5 = &sseCustomerKeyMD5
+	}
+
+	var parts []types.Part
+	paginator := s3.NewListPartsPaginator(c.s3client, listPartsInput)
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, output.Parts...)
+	}
+	return parts, nil
+}
+
+// CompleteMultipartUpload completes the multipart upload with the given ID by assembling the given parts.
+func (c Client) CompleteMultipartUpload(ctx context.Context, bucket, key, uploadID string, parts []types.CompletedPart, sseCustomerAlgorithm, sseCustomerKey, sseCustomerKeyMD5 string) (*s3.CompleteMultipartUploadOutput, error) {
+	completeInput := &s3.CompleteMultipartUploadInput{
+		Bucket:          &bucket,
+		Key:             &key,
+		UploadId:        &uploadID,
+		MultipartUpload: &types.CompletedMultipartUpload{Parts: parts},
+	}
+	if sseCustomerAlgorithm != "" {
+		completeInput.SSECustomerAlgorithm = &sseCustomerAlgorithm
+	}
+	if sseCustomerKey != "" {
+		completeInput.SSECustomerKey = &sseCustomerKey
+	}
+	if sseCustomerKeyMD5 != "" {
+		completeInput.SSECustomerKeyMD5 = &sseCustomerKeyMD5
+	}
+
+	return c.s3client.CompleteMultipartUpload(ctx, completeInput)
+}
+
+// AbortMultipartUpload aborts the multipart upload with the given ID.
+func (c Client) AbortMultipartUpload(ctx context.Context, bucket, key, uploadID string) (*s3.AbortMultipartUploadOutput, error) {
+	return c.s3client.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{
+		Bucket:   &bucket,
+		Key:      &key,
+		UploadId: &uploadID,
+	})
+}
+
+// DeleteObject deletes the object with the given key from the given bucket.
+// If versionID is given, only the given version of the object is deleted.
+func (c Client) DeleteObject(ctx context.Context, bucket, key, versionID string, bypassGovernanceRetention bool) (*s3.DeleteObjectOutput, error) {
+	deleteObjectInput := &s3.DeleteObjectInput{
+		Bucket: &bucket,
+		Key:    &key,
+	}
+	if versionID != "" {
+		deleteObjectInput.VersionId = &versionID
+	}
+	if bypassGovernanceRetention {
+		deleteObjectInput.BypassGovernanceRetention = &bypassGovernanceRetention
+	}
+	return c.s3client.DeleteObject(ctx, deleteObjectInput)
+}
+
+// DeleteObjects deletes the given objects from the given bucket.
+// The result of every object is reported, errors of single objects don't fail the request.
+func (c Client) DeleteObjects(ctx context.Context, bucket string, objects []types.ObjectIdentifier, bypassGovernanceRetention bool) (*s3.DeleteObjectsOutput, error) {
+	deleteObjectsInput := &s3.DeleteObjectsInput{
+		Bucket: &bucket,
+		Delete: &types.Delete{
+			Objects: objects,
+			Quiet:   aws.Bool(false),
+		},
+	}
+	if bypassGovernanceRetention {
+		deleteObjectsInput.BypassGovernanceRetention = &bypassGovernanceRetention
+	}
+	return c.s3client.DeleteObjects(ctx, deleteObjectsInput)
+}
+
+// CopyObject copies the object copySource, given as "<bucket>/<key>[?versionId=<versionID>]", to the given key in the given bucket.
+// The metadata of the object is replaced with the given metadata.
+// If copySourceIfMatch is given, the object is only copied if the ETag of the source matches.
+// Tags are copied from the source, unless taggingDirective is "REPLACE".
+func (c Client) CopyObject(ctx context.Context, bucket, key, copySource, copySourceIfMatch, tags, taggingDirective, contentType, storageClass, sseCustomerAlgorithm, sseCustomerKey, sseCustomerKeyMD5, copySourceSSECustomerAlgorithm, copySourceSSECustomerKey, copySourceSSECustomerKeyMD5 string, metadata map[string]string) (*s3.CopyObjectOutput, error) {
+	copyObjectInput := &s3.CopyObjectInput{
+		Bucket:            &bucket,
+		Key:               &key,
+		CopySource:        &copySource,
+		Metadata:          metadata,
+		MetadataDirective: types.MetadataDirectiveReplace,
+		TaggingDirective:  types.TaggingDirective(taggingDirective),
+		StorageClass:      types.StorageClass(storageClass),
+	}
+	if copySourceIfMatch != "" {
+		copyObjectInput.CopySourceIfMatch = &copySourceIfMatch
+	}
+	if tags != "" {
+		copyObjectInput.Tagging = &tags
+	}
+	if contentType != "" {
+		copyObjectInput.ContentType = &contentType
+	}
+	if sseCustomerAlgorithm != "" {
+		copyObjectInput.SSECustomerAlgorithm = &sseCustomerAlgorithm
+	}
+	if sseCustomerKey != "" {
+		copyObjectInput.SSECustomerKey = &sseCustomerKey
+	}
+	if sseCustomerKeyMD5 != "" {
+		copyObjectInput.SSECustomerKeyMD5 = &sseCustomerKeyMD5
+	}
+	if copySourceSSECustomerAlgorithm != "" {
+		copyObjectInput.CopySourceSSECustomerAlgorithm = &copySourceSSECustomerAlgorithm
+	}
+	if copySourceSSECustomerKey != "" {
+		copyObjectInput.CopySourceSSECustomerKey = &copySourceSSECustomerKey
+	}
+	if copySourceSSECustomerKeyMD5 != "" {
+		copyObjectInput.CopySourceSSECustomerKeyMD5 = &copySourceSSECustomerKeyMD5
+	}
+
+	return c.s3client.CopyObject(ctx, copyObjectInput)
+}
+
+// ListObjectsV2 returns a page of the objects in the given bucket.
+// A maxKeys of 0 uses the default page size of S3.
+func (c Client) ListObjectsV2(ctx context.Context, bucket, prefix, delimiter, continuationToken, startAfter string, maxKeys int32, fetchOwner bool) (*s3.ListObjectsV2Output, error) {
+	listInput := &s3.ListObjectsV2Input{
+		Bucket:     &bucket,
+		FetchOwner: &fetchOwner,
+	}
+	if prefix != "" {
+		listInput.Prefix = &prefix
+	}
+	if delimiter != "" {
+		listInput.Delimiter = &delimiter
+	}
+	if continuationToken != "" {
+		listInput.ContinuationToken = &continuationToken
+	}
+	if startAfter != "" {
+		listInput.StartAfter = &startAfter
+	}
+	if maxKeys != 0 {
+		listInput.MaxKeys = &maxKeys
+	}
+
+	return c.s3client.ListObjectsV2(ctx, listInput)
+}
+
+// Credentials returns the AWS credentials the client uses to sign requests.
+func (c Client) Credentials(ctx context.Context) (aws.Credentials, error) {
+	provider := c.s3client.Options().Credentials
+	if provider == nil {
+		return aws.Credentials{}, errors.New("no credentials configured")
+	}
+	return provider.Retrieve(ctx)
+}
@@ -176,7 +176,8 @@ func (d *AttestationDataSource) Read(ctx context.Context, req datasource.ReadReq
 	if attestationVariant.Equal(variant.AWSSEVSNP{}) ||
 		attestationVariant.Equal(variant.AzureSEVSNP{}) ||
 		attestationVariant.Equal(variant.AzureTDX{}) ||
-		attestationVariant.Equal(variant.GCPSEVSNP{}) {
+		attestationVariant.Equal(variant.GCPSEVSNP{}) ||
+		attestationVariant.Equal(variant.GCPTDX{}) {
 		latestVersions, err = d.fetcher.FetchLatestVersion(ctx, attestationVariant)
 		if err != nil {
 			resp.Diagnostics.AddError("Fetching SNP Version numbers", err.Error())
@@ -87,7 +87,7 @@ func convertFromTfAttestationCfg(tfAttestation attestationAttribute, attestation
 			MicrocodeVersion:  newVersion(tfAttestation.MicrocodeVersion),
 			AMDRootKey:        rootKey,
 		}
-	case variant.AzureTDX{}:
+	case variant.AzureTDX{}, variant.GCPTDX{}:
 		var rootKey config.Certificate
 		if err := json.Unmarshal([]byte(tfAttestation.TDX.IntelRootKey), &rootKey); err != nil {
 			return nil, fmt.Errorf("unmarshalling root key: %w", err)
@@ -109,6 +109,19 @@ func convertFromTfAttestationCfg(tfAttestation attestationAttribute, attestation
 			return nil, fmt.Errorf("decoding xfam: %w", err)
 		}
 
+		if attestationVariant.Equal(variant.GCPTDX{}) {
+			attestationConfig = &config.GCPTDX{
+				Measurements: c11nMeasurements,
+				QESVN:        newVersion(tfAttestation.TDX.QESVN),
+				PCESVN:       newVersion(tfAttestation.TDX.PCESVN),
+				TEETCBSVN:    newVersion(encoding.HexBytes(teeTCBSVN)),
+				QEVendorID:   newVersion(encoding.HexBytes(qeVendorID)),
+				MRSeam:       mrSeam,
+				XFAM:         newVersion(encoding.HexBytes(xfam)),
+				IntelRootKey: rootKey,
+			}
+			break
+		}
 		attestationConfig = &config.AzureTDX{
 			Measurements: c11nMeasurements,
 			QESVN:        newVersion(tfAttestation.TDX.QESVN),
@@ -131,6 +144,20 @@ func convertFromTfAttestationCfg(tfAttestation attestationAttribute, attestation
 		attestationConfig = &config.QEMUVTPM{
 			Measurements: c11nMeasurements,
 		}
+	case variant.QEMUSEVSNP{}:
+		var rootKey config.Certificate
+		if err := json.Unmarshal([]byte(tfAttestation.AMDRootKey), &rootKey); err != nil {
+			return nil, fmt.Errorf("unmarshalling root key: %w", err)
+		}
+
+		attestationConfig = &config.QEMUSEVSNP{
+			Measurements:      c11nMeasurements,
+			BootloaderVersion: tfAttestation.BootloaderVersion,
+			TEEVersion:        tfAttestation.TEEVersion,
+			SNPVersion:        tfAttestation.SNPVersion,
+			MicrocodeVersion:  tfAttestation.MicrocodeVersion,
+			AMDRootKey:        rootKey,
+		}
 	default:
 		return nil, fmt.Errorf("unknown attestation variant: %s", attestationVariant)
 	}
@@ -184,7 +211,8 @@ func convertToTfAttestation(attVar variant.Variant, latestVersions attestationco
 		}
 		tfAttestation.AzureSNPFirmwareSignerConfig = tfFirmwareCfg
 
-	case variant.AzureTDX{}:
+	case variant.AzureTDX{}, variant.GCPTDX{}:
+		// Azure and GCP TDX use the same Intel root key
 		certStr, err := certAsString(config.DefaultForAzureTDX().IntelRootKey)
 		if err != nil {
 			return tfAttestation, err
@@ -196,6 +224,15 @@ func convertToTfAttestation(attVar variant.Variant, latestVersions attestationco
 		tfAttestation.TDX.QEVendorID = hex.EncodeToString(latestVersions.QEVendorID[:])
 		tfAttestation.TDX.XFAM = hex.EncodeToString(latestVersions.XFAM[:])
 
+	case variant.QEMUSEVSNP{}:
+		// Bare-metal hosts are not covered by the attestation config API,
+		// so the TCB versions default to 0 and must be set by the user.
+		certStr, err := certAsString(config.DefaultForQEMUSEVSNP().AMDRootKey)
+		if err != nil {
+			return tfAttestation, err
+		}
+		tfAttestation.AMDRootKey = certStr
+
 	case variant.GCPSEVES{}, variant.QEMUVTPM{}:
 		// no additional fields
 	default:
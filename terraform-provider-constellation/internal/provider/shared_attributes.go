@@ -33,11 +33,13 @@ func newAttestationVariantAttributeSchema(t attributeType) schema.Attribute {
 			"  * `azure-tdx`\n" +
 			"  * `gcp-sev-snp`\n" +
 			"  * `gcp-sev-es`\n" +
-			"  * `qemu-vtpm`\n",
+			"  * `gcp-tdx`\n" +
+			"  * `qemu-vtpm`\n" +
+			"  * `qemu-sev-snp`\n",
 		Required: isInput,
 		Computed: !isInput,
 		Validators: []validator.String{
-			stringvalidator.OneOf("aws-sev-snp", "aws-nitro-tpm", "azure-sev-snp", "azure-tdx", "gcp-sev-es", "gcp-sev-snp", "qemu-vtpm"),
+			stringvalidator.OneOf("aws-sev-snp", "aws-nitro-tpm", "azure-sev-snp", "azure-tdx", "gcp-sev-es", "gcp-sev-snp", "gcp-tdx", "qemu-vtpm", "qemu-sev-snp"),
 		},
 	}
 }
@@ -33,7 +33,7 @@ resource "google_compute_instance_template" "template" {
 
   confidential_instance_config {
     enable_confidential_compute = true
-    confidential_instance_type  = var.cc_technology == "SEV_SNP" ? "SEV_SNP" : null
+    confidential_instance_type  = var.cc_technology == "SEV" ? null : var.cc_technology
   }
 
   # If SEV-SNP is used, we have to explicitly select a Milan processor, as per
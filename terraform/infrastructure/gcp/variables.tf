@@ -63,10 +63,10 @@ variable "zone" {
 
 variable "cc_technology" {
   type        = string
-  description = "The confidential computing technology to use for the nodes. One of `SEV`, `SEV_SNP`."
+  description = "The confidential computing technology to use for the nodes. One of `SEV`, `SEV_SNP`, `TDX`."
   validation {
-    condition     = contains(["SEV", "SEV_SNP"], var.cc_technology)
-    error_message = "The confidential computing technology has to be 'SEV' or 'SEV_SNP'."
+    condition     = contains(["SEV", "SEV_SNP", "TDX"], var.cc_technology)
+    error_message = "The confidential computing technology has to be 'SEV', 'SEV_SNP' or 'TDX'."
   }
 }
 
@@ -72,6 +72,7 @@ resource "openstack_compute_instance_v2" "instance_group_member" {
   }
   metadata = {
     constellation-role             = var.role
+    constellation-node-group       = var.node_group_name
     constellation-uid              = var.uid
     constellation-init-secret-hash = var.init_secret_hash
   }
@@ -14,6 +14,7 @@ import (
 	"github.com/edgelesssys/constellation/v2/internal/constants"
 	"github.com/edgelesssys/constellation/v2/internal/file"
 	"github.com/edgelesssys/constellation/v2/internal/logger"
+	"github.com/edgelesssys/constellation/v2/upgrade-agent/internal/diskencryption"
 	"github.com/edgelesssys/constellation/v2/upgrade-agent/internal/server"
 	"github.com/spf13/afero"
 )
@@ -29,7 +30,7 @@ func main() {
 	logger.ReplaceGRPCLogger(logger.GRPCLogger(log))
 
 	handler := file.NewHandler(afero.NewOsFs())
-	server, err := server.New(log, handler)
+	server, err := server.New(log, handler, diskencryption.New())
 	if err != nil {
 		log.With(slog.Any("error", err)).Error("Failed to create update server")
 		os.Exit(1)
@@ -0,0 +1,71 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+// Package diskencryption handles re-keying of a node's state disk.
+package diskencryption
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/edgelesssys/constellation/v2/internal/cryptsetup"
+)
+
+const (
+	stateMapperDevice = "state"
+	keyslot           = 0
+)
+
+// DiskEncryption manages the encrypted state mapper device.
+type DiskEncryption struct {
+	device cryptdevice
+}
+
+// New creates a new DiskEncryption.
+func New() *DiskEncryption {
+	return &DiskEncryption{
+		device: cryptsetup.New(),
+	}
+}
+
+// UUID returns the UUID of the state disk.
+func (c *DiskEncryption) UUID() (string, error) {
+	free, err := c.device.InitByName(stateMapperDevice)
+	if err != nil {
+		return "", fmt.Errorf("initializing state disk: %w", err)
+	}
+	defer free()
+
+	return c.device.GetUUID()
+}
+
+// RotatePassphrase replaces the passphrase of the state disk.
+// If the state disk is already encrypted with newPassphrase, no error is returned,
+// so an interrupted rotation can safely be repeated.
+func (c *DiskEncryption) RotatePassphrase(currentPassphrase, newPassphrase string) error {
+	free, err := c.device.InitByName(stateMapperDevice)
+	if err != nil {
+		return fmt.Errorf("initializing state disk: %w", err)
+	}
+	defer free()
+
+	changeErr := c.device.KeyslotChangeByPassphrase(keyslot, keyslot, currentPassphrase, newPassphrase)
+	if changeErr == nil {
+		return nil
+	}
+
+	// Replacing the new passphrase with itself only succeeds if the disk was already re-keyed.
+	if err := c.device.KeyslotChangeByPassphrase(keyslot, keyslot, newPassphrase, newPassphrase); err != nil {
+		return fmt.Errorf("changing state disk passphrase: %w", errors.Join(changeErr, err))
+	}
+	return nil
+}
+
+type cryptdevice interface {
+	InitByName(name string) (func(), error)
+	GetUUID() (string, error)
+	KeyslotChangeByPassphrase(currentKeyslot int, newKeyslot int, currentPassphrase string, newPassphrase string) error
+}
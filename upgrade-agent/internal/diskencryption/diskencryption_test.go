@@ -0,0 +1,96 @@
+/*
+Copyright (c) Edgeless Systems GmbH
+
+SPDX-License-Identifier: BUSL-1.1
+*/
+
+package diskencryption
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/goleak"
+)
+
+func TestMain(m *testing.M) {
+	goleak.VerifyTestMain(m, goleak.IgnoreAnyFunction("github.com/bazelbuild/rules_go/go/tools/bzltestutil.RegisterTimeoutHandler.func1"))
+}
+
+func TestRotatePassphrase(t *testing.T) {
+	testCases := map[string]struct {
+		device     *stubCryptdevice
+		wantErr    bool
+		wantPhrase string
+	}{
+		"rotate passphrase": {
+			device:     &stubCryptdevice{passphrase: "current"},
+			wantPhrase: "new",
+		},
+		"already rotated": {
+			device:     &stubCryptdevice{passphrase: "new"},
+			wantPhrase: "new",
+		},
+		"unknown passphrase": {
+			device:     &stubCryptdevice{passphrase: "other"},
+			wantErr:    true,
+			wantPhrase: "other",
+		},
+		"init fails": {
+			device:  &stubCryptdevice{initErr: errors.New("init error")},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			crypt := &DiskEncryption{device: tc.device}
+
+			err := crypt.RotatePassphrase("current", "new")
+			if tc.wantErr {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+			}
+			assert.Equal(tc.wantPhrase, tc.device.passphrase)
+		})
+	}
+}
+
+func TestUUID(t *testing.T) {
+	assert := assert.New(t)
+
+	crypt := &DiskEncryption{device: &stubCryptdevice{uuid: "uuid"}}
+	uuid, err := crypt.UUID()
+	assert.NoError(err)
+	assert.Equal("uuid", uuid)
+
+	crypt = &DiskEncryption{device: &stubCryptdevice{initErr: errors.New("init error")}}
+	_, err = crypt.UUID()
+	assert.Error(err)
+}
+
+type stubCryptdevice struct {
+	initErr    error
+	uuid       string
+	passphrase string
+}
+
+func (s *stubCryptdevice) InitByName(_ string) (func(), error) {
+	return func() {}, s.initErr
+}
+
+func (s *stubCryptdevice) GetUUID() (string, error) {
+	return s.uuid, nil
+}
+
+func (s *stubCryptdevice) KeyslotChangeByPassphrase(_, _ int, currentPassphrase, newPassphrase string) error {
+	if currentPassphrase != s.passphrase {
+		return errors.New("no key available with this passphrase")
+	}
+	s.passphrase = newPassphrase
+	return nil
+}
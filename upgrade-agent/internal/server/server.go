@@ -8,7 +8,8 @@ SPDX-License-Identifier: BUSL-1.1
 Package server implements the gRPC server for the upgrade agent.
 
 The server is responsible for using kubeadm to upgrade the Kubernetes
-release of a Constellation node.
+release of a Constellation node, and for re-keying the node's state disk
+during a key rotation.
 */
 package server
 
@@ -39,18 +40,20 @@ var errInvalidKubernetesVersion = errors.New("invalid kubernetes version")
 // Server is the upgrade-agent server.
 type Server struct {
 	file       file.Handler
+	stateDisk  stateDisk
 	grpcServer serveStopper
 	log        *slog.Logger
 	upgradeproto.UnimplementedUpdateServer
 }
 
 // New creates a new upgrade-agent server.
-func New(log *slog.Logger, fileHandler file.Handler) (*Server, error) {
+func New(log *slog.Logger, fileHandler file.Handler, stateDisk stateDisk) (*Server, error) {
 	log = log.WithGroup("upgradeServer")
 
 	server := &Server{
-		log:  log,
-		file: fileHandler,
+		log:       log,
+		file:      fileHandler,
+		stateDisk: stateDisk,
 	}
 
 	grpcServer := grpc.NewServer(
@@ -131,6 +134,29 @@ func (s *Server) ExecuteUpdate(ctx context.Context, updateRequest *upgradeproto.
 	return &upgradeproto.ExecuteUpdateResponse{}, nil
 }
 
+// GetStateDiskUUID returns the UUID of the node's state disk.
+func (s *Server) GetStateDiskUUID(_ context.Context, _ *upgradeproto.GetStateDiskUUIDRequest) (*upgradeproto.GetStateDiskUUIDResponse, error) {
+	uuid, err := s.stateDisk.UUID()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "unable to get state disk UUID: %s", err)
+	}
+	return &upgradeproto.GetStateDiskUUIDResponse{DiskUuid: uuid}, nil
+}
+
+// RotateStateDiskKey replaces the passphrase of the node's state disk.
+func (s *Server) RotateStateDiskKey(_ context.Context, req *upgradeproto.RotateStateDiskKeyRequest) (*upgradeproto.RotateStateDiskKeyResponse, error) {
+	if len(req.CurrentPassphrase) == 0 || len(req.NewPassphrase) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "passphrases must not be empty")
+	}
+
+	s.log.Info("Rotating state disk key")
+	if err := s.stateDisk.RotatePassphrase(string(req.CurrentPassphrase), string(req.NewPassphrase)); err != nil {
+		return nil, status.Errorf(codes.Internal, "unable to rotate state disk key: %s", err)
+	}
+	s.log.Info("Rotated state disk key")
+	return &upgradeproto.RotateStateDiskKeyResponse{}, nil
+}
+
 // prepareUpdate downloads & installs the specified kubeadm version and verifies the desired Kubernetes version.
 func prepareUpdate(ctx context.Context, installer osInstaller, updateRequest *upgradeproto.ExecuteUpdateRequest) error {
 	// verify Kubernetes version
@@ -160,6 +186,13 @@ type osInstaller interface {
 	Install(ctx context.Context, kubernetesComponent *components.Component) error
 }
 
+type stateDisk interface {
+	// UUID returns the UUID of the state disk.
+	UUID() (string, error)
+	// RotatePassphrase replaces the passphrase of the state disk.
+	RotatePassphrase(currentPassphrase, newPassphrase string) error
+}
+
 type serveStopper interface {
 	// Serve starts the server.
 	Serve(lis net.Listener) error
@@ -8,9 +8,11 @@ package server
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"testing"
 
+	"github.com/edgelesssys/constellation/v2/internal/logger"
 	"github.com/edgelesssys/constellation/v2/internal/versions/components"
 	"github.com/edgelesssys/constellation/v2/upgrade-agent/upgradeproto"
 	"github.com/stretchr/testify/assert"
@@ -136,6 +138,82 @@ func TestPrepareUpdate(t *testing.T) {
 	}
 }
 
+func TestRotateStateDiskKey(t *testing.T) {
+	testCases := map[string]struct {
+		stateDisk *stubStateDisk
+		req       *upgradeproto.RotateStateDiskKeyRequest
+		wantErr   bool
+	}{
+		"success": {
+			stateDisk: &stubStateDisk{},
+			req:       &upgradeproto.RotateStateDiskKeyRequest{CurrentPassphrase: []byte("current"), NewPassphrase: []byte("new")},
+		},
+		"empty current passphrase": {
+			stateDisk: &stubStateDisk{},
+			req:       &upgradeproto.RotateStateDiskKeyRequest{NewPassphrase: []byte("new")},
+			wantErr:   true,
+		},
+		"empty new passphrase": {
+			stateDisk: &stubStateDisk{},
+			req:       &upgradeproto.RotateStateDiskKeyRequest{CurrentPassphrase: []byte("current")},
+			wantErr:   true,
+		},
+		"rotation fails": {
+			stateDisk: &stubStateDisk{rotateErr: errors.New("failed")},
+			req:       &upgradeproto.RotateStateDiskKeyRequest{CurrentPassphrase: []byte("current"), NewPassphrase: []byte("new")},
+			wantErr:   true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			server := &Server{log: logger.NewTest(t), stateDisk: tc.stateDisk}
+
+			_, err := server.RotateStateDiskKey(t.Context(), tc.req)
+			if tc.wantErr {
+				assert.Error(err)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal("current", tc.stateDisk.currentPassphrase)
+			assert.Equal("new", tc.stateDisk.newPassphrase)
+		})
+	}
+}
+
+func TestGetStateDiskUUID(t *testing.T) {
+	assert := assert.New(t)
+
+	server := &Server{stateDisk: &stubStateDisk{uuid: "uuid"}}
+	resp, err := server.GetStateDiskUUID(t.Context(), &upgradeproto.GetStateDiskUUIDRequest{})
+	assert.NoError(err)
+	assert.Equal("uuid", resp.DiskUuid)
+
+	server = &Server{stateDisk: &stubStateDisk{uuidErr: errors.New("failed")}}
+	_, err = server.GetStateDiskUUID(t.Context(), &upgradeproto.GetStateDiskUUIDRequest{})
+	assert.Error(err)
+}
+
+type stubStateDisk struct {
+	uuid              string
+	uuidErr           error
+	rotateErr         error
+	currentPassphrase string
+	newPassphrase     string
+}
+
+func (s *stubStateDisk) UUID() (string, error) {
+	return s.uuid, s.uuidErr
+}
+
+func (s *stubStateDisk) RotatePassphrase(currentPassphrase, newPassphrase string) error {
+	s.currentPassphrase = currentPassphrase
+	s.newPassphrase = newPassphrase
+	return s.rotateErr
+}
+
 type stubOsInstaller struct {
 	InstallErr error
 }
@@ -114,6 +114,174 @@ func (*ExecuteUpdateResponse) Descriptor() ([]byte, []int) {
 	return file_upgrade_agent_upgradeproto_upgrade_proto_rawDescGZIP(), []int{1}
 }
 
+type GetStateDiskUUIDRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetStateDiskUUIDRequest) Reset() {
+	*x = GetStateDiskUUIDRequest{}
+	mi := &file_upgrade_agent_upgradeproto_upgrade_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetStateDiskUUIDRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStateDiskUUIDRequest) ProtoMessage() {}
+
+func (x *GetStateDiskUUIDRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_upgrade_agent_upgradeproto_upgrade_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStateDiskUUIDRequest.ProtoReflect.Descriptor instead.
+func (*GetStateDiskUUIDRequest) Descriptor() ([]byte, []int) {
+	return file_upgrade_agent_upgradeproto_upgrade_proto_rawDescGZIP(), []int{2}
+}
+
+type GetStateDiskUUIDResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DiskUuid      string                 `protobuf:"bytes,1,opt,name=disk_uuid,json=diskUuid,proto3" json:"disk_uuid,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetStateDiskUUIDResponse) Reset() {
+	*x = GetStateDiskUUIDResponse{}
+	mi := &file_upgrade_agent_upgradeproto_upgrade_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetStateDiskUUIDResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStateDiskUUIDResponse) ProtoMessage() {}
+
+func (x *GetStateDiskUUIDResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_upgrade_agent_upgradeproto_upgrade_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStateDiskUUIDResponse.ProtoReflect.Descriptor instead.
+func (*GetStateDiskUUIDResponse) Descriptor() ([]byte, []int) {
+	return file_upgrade_agent_upgradeproto_upgrade_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetStateDiskUUIDResponse) GetDiskUuid() string {
+	if x != nil {
+		return x.DiskUuid
+	}
+	return ""
+}
+
+type RotateStateDiskKeyRequest struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	CurrentPassphrase []byte                 `protobuf:"bytes,1,opt,name=current_passphrase,json=currentPassphrase,proto3" json:"current_passphrase,omitempty"`
+	NewPassphrase     []byte                 `protobuf:"bytes,2,opt,name=new_passphrase,json=newPassphrase,proto3" json:"new_passphrase,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *RotateStateDiskKeyRequest) Reset() {
+	*x = RotateStateDiskKeyRequest{}
+	mi := &file_upgrade_agent_upgradeproto_upgrade_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RotateStateDiskKeyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RotateStateDiskKeyRequest) ProtoMessage() {}
+
+func (x *RotateStateDiskKeyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_upgrade_agent_upgradeproto_upgrade_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RotateStateDiskKeyRequest.ProtoReflect.Descriptor instead.
+func (*RotateStateDiskKeyRequest) Descriptor() ([]byte, []int) {
+	return file_upgrade_agent_upgradeproto_upgrade_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *RotateStateDiskKeyRequest) GetCurrentPassphrase() []byte {
+	if x != nil {
+		return x.CurrentPassphrase
+	}
+	return nil
+}
+
+func (x *RotateStateDiskKeyRequest) GetNewPassphrase() []byte {
+	if x != nil {
+		return x.NewPassphrase
+	}
+	return nil
+}
+
+type RotateStateDiskKeyResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RotateStateDiskKeyResponse) Reset() {
+	*x = RotateStateDiskKeyResponse{}
+	mi := &file_upgrade_agent_upgradeproto_upgrade_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RotateStateDiskKeyResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RotateStateDiskKeyResponse) ProtoMessage() {}
+
+func (x *RotateStateDiskKeyResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_upgrade_agent_upgradeproto_upgrade_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RotateStateDiskKeyResponse.ProtoReflect.Descriptor instead.
+func (*RotateStateDiskKeyResponse) Descriptor() ([]byte, []int) {
+	return file_upgrade_agent_upgradeproto_upgrade_proto_rawDescGZIP(), []int{5}
+}
+
 var File_upgrade_agent_upgradeproto_upgrade_proto protoreflect.FileDescriptor
 
 const file_upgrade_agent_upgradeproto_upgrade_proto_rawDesc = "" +
@@ -122,9 +290,18 @@ const file_upgrade_agent_upgradeproto_upgrade_proto_rawDesc = "" +
 	"\x14ExecuteUpdateRequest\x12:\n" +
 	"\x19wanted_kubernetes_version\x18\x03 \x01(\tR\x17wantedKubernetesVersion\x12J\n" +
 	"\x15kubernetes_components\x18\x04 \x03(\v2\x15.components.ComponentR\x14kubernetesComponentsJ\x04\b\x01\x10\x02J\x04\b\x02\x10\x03R\vkubeadm_urlR\fkubeadm_hash\"\x17\n" +
-	"\x15ExecuteUpdateResponse2X\n" +
+	"\x15ExecuteUpdateResponse\"\x19\n" +
+	"\x17GetStateDiskUUIDRequest\"7\n" +
+	"\x18GetStateDiskUUIDResponse\x12\x1b\n" +
+	"\tdisk_uuid\x18\x01 \x01(\tR\bdiskUuid\"q\n" +
+	"\x19RotateStateDiskKeyRequest\x12-\n" +
+	"\x12current_passphrase\x18\x01 \x01(\fR\x11currentPassphrase\x12%\n" +
+	"\x0enew_passphrase\x18\x02 \x01(\fR\rnewPassphrase\"\x1c\n" +
+	"\x1aRotateStateDiskKeyResponse2\x90\x02\n" +
 	"\x06Update\x12N\n" +
-	"\rExecuteUpdate\x12\x1d.upgrade.ExecuteUpdateRequest\x1a\x1e.upgrade.ExecuteUpdateResponseBDZBgithub.com/edgelesssys/constellation/v2/upgrade-agent/upgradeprotob\x06proto3"
+	"\rExecuteUpdate\x12\x1d.upgrade.ExecuteUpdateRequest\x1a\x1e.upgrade.ExecuteUpdateResponse\x12W\n" +
+	"\x10GetStateDiskUUID\x12 .upgrade.GetStateDiskUUIDRequest\x1a!.upgrade.GetStateDiskUUIDResponse\x12]\n" +
+	"\x12RotateStateDiskKey\x12\".upgrade.RotateStateDiskKeyRequest\x1a#.upgrade.RotateStateDiskKeyResponseBDZBgithub.com/edgelesssys/constellation/v2/upgrade-agent/upgradeprotob\x06proto3"
 
 var (
 	file_upgrade_agent_upgradeproto_upgrade_proto_rawDescOnce sync.Once
@@ -138,18 +315,26 @@ func file_upgrade_agent_upgradeproto_upgrade_proto_rawDescGZIP() []byte {
 	return file_upgrade_agent_upgradeproto_upgrade_proto_rawDescData
 }
 
-var file_upgrade_agent_upgradeproto_upgrade_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_upgrade_agent_upgradeproto_upgrade_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
 var file_upgrade_agent_upgradeproto_upgrade_proto_goTypes = []any{
-	(*ExecuteUpdateRequest)(nil),  // 0: upgrade.ExecuteUpdateRequest
-	(*ExecuteUpdateResponse)(nil), // 1: upgrade.ExecuteUpdateResponse
-	(*components.Component)(nil),  // 2: components.Component
+	(*ExecuteUpdateRequest)(nil),       // 0: upgrade.ExecuteUpdateRequest
+	(*ExecuteUpdateResponse)(nil),      // 1: upgrade.ExecuteUpdateResponse
+	(*GetStateDiskUUIDRequest)(nil),    // 2: upgrade.GetStateDiskUUIDRequest
+	(*GetStateDiskUUIDResponse)(nil),   // 3: upgrade.GetStateDiskUUIDResponse
+	(*RotateStateDiskKeyRequest)(nil),  // 4: upgrade.RotateStateDiskKeyRequest
+	(*RotateStateDiskKeyResponse)(nil), // 5: upgrade.RotateStateDiskKeyResponse
+	(*components.Component)(nil),       // 6: components.Component
 }
 var file_upgrade_agent_upgradeproto_upgrade_proto_depIdxs = []int32{
-	2, // 0: upgrade.ExecuteUpdateRequest.kubernetes_components:type_name -> components.Component
+	6, // 0: upgrade.ExecuteUpdateRequest.kubernetes_components:type_name -> components.Component
 	0, // 1: upgrade.Update.ExecuteUpdate:input_type -> upgrade.ExecuteUpdateRequest
-	1, // 2: upgrade.Update.ExecuteUpdate:output_type -> upgrade.ExecuteUpdateResponse
-	2, // [2:3] is the sub-list for method output_type
-	1, // [1:2] is the sub-list for method input_type
+	2, // 2: upgrade.Update.GetStateDiskUUID:input_type -> upgrade.GetStateDiskUUIDRequest
+	4, // 3: upgrade.Update.RotateStateDiskKey:input_type -> upgrade.RotateStateDiskKeyRequest
+	1, // 4: upgrade.Update.ExecuteUpdate:output_type -> upgrade.ExecuteUpdateResponse
+	3, // 5: upgrade.Update.GetStateDiskUUID:output_type -> upgrade.GetStateDiskUUIDResponse
+	5, // 6: upgrade.Update.RotateStateDiskKey:output_type -> upgrade.RotateStateDiskKeyResponse
+	4, // [4:7] is the sub-list for method output_type
+	1, // [1:4] is the sub-list for method input_type
 	1, // [1:1] is the sub-list for extension type_name
 	1, // [1:1] is the sub-list for extension extendee
 	0, // [0:1] is the sub-list for field type_name
@@ -166,7 +351,7 @@ func file_upgrade_agent_upgradeproto_upgrade_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_upgrade_agent_upgradeproto_upgrade_proto_rawDesc), len(file_upgrade_agent_upgradeproto_upgrade_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   2,
+			NumMessages:   6,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -192,6 +377,8 @@ const _ = grpc.SupportPackageIsVersion6
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
 type UpdateClient interface {
 	ExecuteUpdate(ctx context.Context, in *ExecuteUpdateRequest, opts ...grpc.CallOption) (*ExecuteUpdateResponse, error)
+	GetStateDiskUUID(ctx context.Context, in *GetStateDiskUUIDRequest, opts ...grpc.CallOption) (*GetStateDiskUUIDResponse, error)
+	RotateStateDiskKey(ctx context.Context, in *RotateStateDiskKeyRequest, opts ...grpc.CallOption) (*RotateStateDiskKeyResponse, error)
 }
 
 type updateClient struct {
@@ -211,9 +398,29 @@ func (c *updateClient) ExecuteUpdate(ctx context.Context, in *ExecuteUpdateReque
 	return out, nil
 }
 
+func (c *updateClient) GetStateDiskUUID(ctx context.Context, in *GetStateDiskUUIDRequest, opts ...grpc.CallOption) (*GetStateDiskUUIDResponse, error) {
+	out := new(GetStateDiskUUIDResponse)
+	err := c.cc.Invoke(ctx, "/upgrade.Update/GetStateDiskUUID", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *updateClient) RotateStateDiskKey(ctx context.Context, in *RotateStateDiskKeyRequest, opts ...grpc.CallOption) (*RotateStateDiskKeyResponse, error) {
+	out := new(RotateStateDiskKeyResponse)
+	err := c.cc.Invoke(ctx, "/upgrade.Update/RotateStateDiskKey", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // UpdateServer is the server API for Update service.
 type UpdateServer interface {
 	ExecuteUpdate(context.Context, *ExecuteUpdateRequest) (*ExecuteUpdateResponse, error)
+	GetStateDiskUUID(context.Context, *GetStateDiskUUIDRequest) (*GetStateDiskUUIDResponse, error)
+	RotateStateDiskKey(context.Context, *RotateStateDiskKeyRequest) (*RotateStateDiskKeyResponse, error)
 }
 
 // UnimplementedUpdateServer can be embedded to have forward compatible implementations.
@@ -223,6 +430,12 @@ type UnimplementedUpdateServer struct {
 func (*UnimplementedUpdateServer) ExecuteUpdate(context.Context, *ExecuteUpdateRequest) (*ExecuteUpdateResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ExecuteUpdate not implemented")
 }
+func (*UnimplementedUpdateServer) GetStateDiskUUID(context.Context, *GetStateDiskUUIDRequest) (*GetStateDiskUUIDResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetStateDiskUUID not implemented")
+}
+func (*UnimplementedUpdateServer) RotateStateDiskKey(context.Context, *RotateStateDiskKeyRequest) (*RotateStateDiskKeyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RotateStateDiskKey not implemented")
+}
 
 func RegisterUpdateServer(s *grpc.Server, srv UpdateServer) {
 	s.RegisterService(&_Update_serviceDesc, srv)
@@ -246,6 +459,42 @@ func _Update_ExecuteUpdate_Handler(srv interface{}, ctx context.Context, dec fun
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Update_GetStateDiskUUID_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetStateDiskUUIDRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UpdateServer).GetStateDiskUUID(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/upgrade.Update/GetStateDiskUUID",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UpdateServer).GetStateDiskUUID(ctx, req.(*GetStateDiskUUIDRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Update_RotateStateDiskKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RotateStateDiskKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UpdateServer).RotateStateDiskKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/upgrade.Update/RotateStateDiskKey",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UpdateServer).RotateStateDiskKey(ctx, req.(*RotateStateDiskKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _Update_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "upgrade.Update",
 	HandlerType: (*UpdateServer)(nil),
@@ -254,6 +503,14 @@ var _Update_serviceDesc = grpc.ServiceDesc{
 			MethodName: "ExecuteUpdate",
 			Handler:    _Update_ExecuteUpdate_Handler,
 		},
+		{
+			MethodName: "GetStateDiskUUID",
+			Handler:    _Update_GetStateDiskUUID_Handler,
+		},
+		{
+			MethodName: "RotateStateDiskKey",
+			Handler:    _Update_RotateStateDiskKey_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "upgrade-agent/upgradeproto/upgrade.proto",